@@ -12,6 +12,7 @@ import (
 	"google.golang.org/grpc/balancer"
 	_ "google.golang.org/grpc/xds"
 
+	"github.com/authzed/spicedb/internal/dispatch/discovery"
 	log "github.com/authzed/spicedb/internal/logging"
 	consistentbalancer "github.com/authzed/spicedb/pkg/balancer"
 	"github.com/authzed/spicedb/pkg/cmd"
@@ -33,6 +34,9 @@ func main() {
 	// Enable Kubernetes gRPC resolver
 	kuberesolver.RegisterInCluster()
 
+	// Enable Kubernetes EndpointSlice gRPC resolver
+	discovery.RegisterInCluster()
+
 	// Enable consistent hashring gRPC load balancer
 	balancer.Register(consistentbalancer.NewConsistentHashringBuilder(
 		xxhash.Sum64,
@@ -63,6 +67,14 @@ func main() {
 	cmd.RegisterHeadFlags(headCmd)
 	rootCmd.AddCommand(headCmd)
 
+	// Add datastore commands
+	datastoreCmd := cmd.NewDatastoreCommand()
+	rootCmd.AddCommand(datastoreCmd)
+
+	copyCmd := cmd.NewDatastoreCopyCommand(rootCmd.Use)
+	cmd.RegisterDatastoreCopyFlags(copyCmd)
+	datastoreCmd.AddCommand(copyCmd)
+
 	// Add server commands
 	var serverConfig cmdutil.Config
 	serveCmd := cmd.NewServeCommand(rootCmd.Use, &serverConfig)
@@ -73,6 +85,11 @@ func main() {
 	cmd.RegisterDevtoolsFlags(devtoolsCmd)
 	rootCmd.AddCommand(devtoolsCmd)
 
+	var doctorConfig cmdutil.Config
+	doctorCmd := cmd.NewDoctorCommand(rootCmd.Use, &doctorConfig)
+	cmd.RegisterDoctorFlags(doctorCmd, &doctorConfig)
+	rootCmd.AddCommand(doctorCmd)
+
 	var testServerConfig testserver.Config
 	testingCmd := cmd.NewTestingCommand(rootCmd.Use, &testServerConfig)
 	cmd.RegisterTestingFlags(testingCmd, &testServerConfig)
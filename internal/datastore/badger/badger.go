@@ -0,0 +1,400 @@
+package badger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	badgerdb "github.com/dgraph-io/badger/v3"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/common/revisions"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/internal/datastore/proxy"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+const (
+	Engine = "badger"
+
+	// initialTransaction is the transaction which created the datastore, at which it is empty.
+	initialTransaction = uint64(1)
+
+	errUnableToInstantiate = "unable to instantiate datastore: %w"
+)
+
+var tracer = otel.Tracer("spicedb/internal/datastore/badger")
+
+func init() {
+	datastore.Engines = append(datastore.Engines, Engine)
+}
+
+// NewBadgerDatastore creates a new datastore.Datastore stored by an embedded Badger database in
+// the directory of the path, which is created if it does not exist. Supports customization via
+// the various options available in this package.
+//
+// The datastore needs no migrations: its keys are initialized as it is first opened. The
+// directory is locked while the datastore is open, so that it is served by a single process.
+func NewBadgerDatastore(path string, options ...Option) (datastore.Datastore, error) {
+	ds, err := newBadgerDatastore(path, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return proxy.NewSeparatingContextDatastoreProxy(ds), nil
+}
+
+func newBadgerDatastore(path string, options ...Option) (*Datastore, error) {
+	config, err := generateConfig(options)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+	if path == "" {
+		return nil, fmt.Errorf(errUnableToInstantiate, errors.New("the path of the database directory is required"))
+	}
+
+	if config.enablePrometheusStats {
+		if err := common.RegisterGCMetrics(); err != nil {
+			return nil, fmt.Errorf(errUnableToInstantiate, err)
+		}
+	}
+
+	// Every version of a key is kept until garbage collection moves the discard timestamp past
+	// it, after which compactions drop the versions which are no longer visible.
+	db, err := badgerdb.OpenManaged(badgerdb.DefaultOptions(path).
+		WithLogger(badgerLogger{}).
+		WithSyncWrites(config.syncWrites).
+		WithNumVersionsToKeep(math.MaxInt32))
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+
+	gcCtx, cancelGc := context.WithCancel(context.Background())
+
+	maxRevisionStaleness := time.Duration(float64(config.revisionQuantization.Nanoseconds())*
+		config.maxRevisionStalenessPercent) * time.Nanosecond
+
+	store := &Datastore{
+		db:                     db,
+		revisionQuantization:   config.revisionQuantization,
+		gcWindow:               config.gcWindow,
+		gcInterval:             config.gcInterval,
+		gcTimeout:              config.gcMaxOperationTime,
+		gcCtx:                  gcCtx,
+		cancelGc:               cancelGc,
+		watchBufferLength:      config.watchBufferLength,
+		valueLogGCDiscardRatio: config.valueLogGCDiscardRatio,
+		retryPolicy: common.RetryPolicy{
+			MaxRetries:     config.maxRetries,
+			InitialBackoff: config.retryInitialBackoff,
+			MaxBackoff:     config.retryMaxBackoff,
+		},
+		CachedOptimizedRevisions: revisions.NewCachedOptimizedRevisions(
+			maxRevisionStaleness,
+		),
+	}
+
+	store.SetOptimizedRevisionFunc(store.optimizedRevisionFunc)
+
+	if err := store.initialize(); err != nil {
+		cancelGc()
+		_ = db.Close()
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+
+	// Start a goroutine for garbage collection.
+	if store.gcInterval > 0*time.Minute && config.gcEnabled {
+		store.gcGroup, store.gcCtx = errgroup.WithContext(store.gcCtx)
+		store.gcGroup.Go(func() error {
+			return common.StartGarbageCollector(
+				store.gcCtx,
+				store,
+				store.gcInterval,
+				store.gcWindow,
+				store.gcTimeout,
+			)
+		})
+	} else {
+		log.Warn().Msg("datastore garbage collection disabled")
+	}
+
+	return store, nil
+}
+
+// transactionRecord is the record of a committed transaction.
+type transactionRecord struct {
+	// Timestamp is the time at which the transaction committed in nanoseconds, which never
+	// decreases from one transaction to the next.
+	Timestamp int64             `json:"t"`
+	Metadata  map[string]string `json:"md,omitempty"`
+}
+
+// initialize reads the latest transaction of the database, or writes the unique ID and the
+// first transaction of the datastore if the database is new.
+func (bds *Datastore) initialize() error {
+	txn := bds.db.NewTransactionAt(math.MaxUint64, true)
+	defer txn.Discard()
+
+	item, err := txn.Get([]byte(headKey))
+	switch {
+	case errors.Is(err, badgerdb.ErrKeyNotFound):
+		committedAt := bds.nextCommitTime()
+		if err := txn.Set([]byte(uniqueIDKey), []byte(uuid.NewString())); err != nil {
+			return err
+		}
+		if err := writeTransaction(txn, initialTransaction, transactionRecord{Timestamp: committedAt}); err != nil {
+			return err
+		}
+		if err := txn.CommitAt(initialTransaction, nil); err != nil {
+			return err
+		}
+		bds.head.Store(initialTransaction)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	encoded, err := item.ValueCopy(nil)
+	if err != nil {
+		return err
+	}
+	head, err := strconv.ParseUint(string(encoded), 10, 64)
+	if err != nil {
+		return fmt.Errorf("unable to read the latest transaction: %w", err)
+	}
+
+	record, err := readTransaction(txn, head)
+	if err != nil {
+		return err
+	}
+	bds.head.Store(head)
+	bds.lastCommit = record.Timestamp
+	return nil
+}
+
+// Datastore is a Badger-based implementation of the datastore.Datastore interface
+type Datastore struct {
+	db *badgerdb.DB
+
+	// head is the latest committed transaction, which is the commit timestamp of its versions.
+	head atomic.Uint64
+
+	// commitMu serializes the commits of transactions, which are assigned consecutive IDs, and
+	// guards the time of the latest commit and the transaction at or below which versions may
+	// be discarded.
+	commitMu   sync.Mutex
+	lastCommit int64
+	discardTxn uint64
+
+	revisionQuantization   time.Duration
+	gcWindow               time.Duration
+	gcInterval             time.Duration
+	gcTimeout              time.Duration
+	watchBufferLength      uint16
+	valueLogGCDiscardRatio float64
+	retryPolicy            common.RetryPolicy
+
+	gcGroup  *errgroup.Group
+	gcCtx    context.Context
+	cancelGc context.CancelFunc
+
+	*revisions.CachedOptimizedRevisions
+	revision.DecimalDecoder
+}
+
+// SnapshotReader ignores consistency hints, as all reads are served by the same database.
+func (bds *Datastore) SnapshotReader(revisionRaw datastore.Revision, _ ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return &badgerReader{
+		db:  bds.db,
+		txn: transactionFromRevision(revisionRaw.(revision.Decimal)),
+	}
+}
+
+// ReadWriteTx starts a read/write transaction, whose writes are applied if no error is returned
+// and discarded if an error is returned.
+//
+// Transactions are optimistic: each reads and writes a Badger transaction at the latest
+// transaction as it began, which commits at the next transaction ID. Every transaction writes
+// the head, so a transaction conflicts with any transaction which committed after it began, and
+// is retried.
+func (bds *Datastore) ReadWriteTx(
+	ctx context.Context,
+	fn datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
+) (datastore.Revision, error) {
+	config := options.NewRWTOptionsWithOptions(opts...)
+
+	var newTxnID uint64
+	_, err := common.RetryTx(ctx, bds.retryPolicy, isErrorRetryable, func(ctx context.Context) error {
+		readTxnID := bds.head.Load()
+		txn := bds.db.NewTransactionAt(readTxnID, true)
+		defer txn.Discard()
+
+		// Reading the head adds it to the reads checked for conflicts as the transaction commits.
+		if _, err := txn.Get([]byte(headKey)); err != nil {
+			return err
+		}
+
+		rwt := newReadWriteTXN(bds.db, txn, config.Metadata)
+		if err := fn(rwt); err != nil {
+			return err
+		}
+
+		var err error
+		newTxnID, err = bds.commit(ctx, rwt, readTxnID)
+		return err
+	})
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+
+	return revisionFromTransaction(newTxnID), nil
+}
+
+// commit commits the transaction as the transaction after the head.
+func (bds *Datastore) commit(ctx context.Context, rwt *badgerReadWriteTXN, readTxnID uint64) (uint64, error) {
+	bds.commitMu.Lock()
+	defer bds.commitMu.Unlock()
+
+	// Badger forgets the transactions committed at or below the discard timestamp, so that a
+	// transaction which began before it cannot be checked for conflicts.
+	if readTxnID < bds.discardTxn {
+		return 0, badgerdb.ErrConflict
+	}
+
+	txnID := bds.head.Load() + 1
+	if err := rwt.commit(ctx, txnID, bds.nextCommitTime()); err != nil {
+		return 0, err
+	}
+
+	bds.head.Store(txnID)
+	return txnID, nil
+}
+
+// nextCommitTime returns the time at which a transaction commits, which is the current time
+// unless the clock has moved back since the previous commit. Must be called under commitMu.
+func (bds *Datastore) nextCommitTime() int64 {
+	now := time.Now().UnixNano()
+	if now <= bds.lastCommit {
+		now = bds.lastCommit + 1
+	}
+	bds.lastCommit = now
+	return now
+}
+
+func isErrorRetryable(err error) bool {
+	return errors.Is(err, badgerdb.ErrConflict)
+}
+
+// BulkLoad writes all relationships from the source in a single transaction, whose writes must
+// fit within the largest transaction Badger accepts, of about a tenth of its memtable size.
+func (bds *Datastore) BulkLoad(
+	ctx context.Context,
+	source datastore.BulkWriteRelationshipSource,
+) (datastore.Revision, error) {
+	return bds.ReadWriteTx(ctx, common.SingleAttemptTxFunc(func(rwt datastore.ReadWriteTransaction) error {
+		_, err := common.BulkLoadInBatches(ctx, source, common.DefaultBulkLoadBatchSize, rwt.WriteRelationships)
+		return err
+	}))
+}
+
+// Close stops garbage collection and closes the database, which compacts its memtables to disk.
+func (bds *Datastore) Close() error {
+	bds.cancelGc()
+	if bds.gcGroup != nil {
+		if err := bds.gcGroup.Wait(); err != nil {
+			log.Error().Err(err).Msg("error waiting for garbage collector to shutdown")
+		}
+	}
+	return bds.db.Close()
+}
+
+// IsReady returns whether the datastore is ready to accept data, which is as long as the
+// database is open.
+func (bds *Datastore) IsReady(_ context.Context) (bool, error) {
+	return !bds.db.IsClosed(), nil
+}
+
+func (bds *Datastore) Features(_ context.Context) (*datastore.Features, error) {
+	return &datastore.Features{
+		Watch:                  datastore.Feature{Enabled: true},
+		WatchCheckpoints:       datastore.Feature{Enabled: true},
+		Caveats:                datastore.Feature{Enabled: true},
+		BulkLoad:               datastore.Feature{Enabled: true},
+		ReverseQuerySort:       datastore.Feature{Enabled: true},
+		RelationshipExpiration: datastore.Feature{Enabled: true},
+	}, nil
+}
+
+// writeTransaction writes the record of a transaction and makes it the head.
+func writeTransaction(txn *badgerdb.Txn, txnID uint64, record transactionRecord) error {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := txn.Set(transactionKey(txnID), encoded); err != nil {
+		return err
+	}
+	return txn.Set([]byte(headKey), []byte(strconv.FormatUint(txnID, 10)))
+}
+
+// readTransaction reads the record of a transaction, which must not have been garbage
+// collected.
+func readTransaction(txn *badgerdb.Txn, txnID uint64) (transactionRecord, error) {
+	var record transactionRecord
+	item, err := txn.Get(transactionKey(txnID))
+	if err != nil {
+		return record, fmt.Errorf("unable to read transaction %d: %w", txnID, err)
+	}
+	err = item.Value(func(encoded []byte) error {
+		return json.Unmarshal(encoded, &record)
+	})
+	return record, err
+}
+
+func revisionFromTransaction(txID uint64) revision.Decimal {
+	return revision.NewFromDecimal(decimal.NewFromBigInt(new(big.Int).SetUint64(txID), 0))
+}
+
+func transactionFromRevision(revision revision.Decimal) uint64 {
+	return uint64(revision.IntPart())
+}
+
+// badgerLogger logs the messages of Badger through the logger of the process, lowering its
+// informational messages about compactions and value log files to debug messages.
+type badgerLogger struct{}
+
+func (badgerLogger) Errorf(format string, args ...any) {
+	log.Error().Msg(badgerMessage(format, args))
+}
+
+func (badgerLogger) Warningf(format string, args ...any) {
+	log.Warn().Msg(badgerMessage(format, args))
+}
+
+func (badgerLogger) Infof(format string, args ...any) {
+	log.Debug().Msg(badgerMessage(format, args))
+}
+
+func (badgerLogger) Debugf(format string, args ...any) {
+	log.Trace().Msg(badgerMessage(format, args))
+}
+
+// badgerMessage formats a message of Badger, whose messages end with a newline.
+func badgerMessage(format string, args []any) string {
+	return "badger: " + strings.TrimSpace(fmt.Sprintf(format, args...))
+}
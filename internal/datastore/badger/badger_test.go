@@ -0,0 +1,132 @@
+//go:build ci
+// +build ci
+
+package badger
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	badgerdb "github.com/dgraph-io/badger/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	"github.com/authzed/spicedb/pkg/datastore/test"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+type datastoreTester struct {
+	t *testing.T
+}
+
+func (dst *datastoreTester) createDatastore(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+	ds, err := NewBadgerDatastore(filepath.Join(dst.t.TempDir(), "spicedb"),
+		RevisionQuantization(revisionQuantization),
+		GCWindow(gcWindow),
+		GCInterval(0*time.Second),
+		WatchBufferLength(watchBufferLength),
+	)
+	require.NoError(dst.t, err)
+	dst.t.Cleanup(func() {
+		require.NoError(dst.t, ds.Close())
+	})
+	return ds, nil
+}
+
+func TestBadgerDatastore(t *testing.T) {
+	dst := datastoreTester{t: t}
+	test.All(t, test.DatastoreTesterFunc(dst.createDatastore))
+}
+
+func TestBadgerReopen(t *testing.T) {
+	req := require.New(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "spicedb")
+
+	ds, err := NewBadgerDatastore(path, GCInterval(0*time.Second))
+	req.NoError(err)
+
+	tpl := tuple.MustParse("document:doc#viewer@user:tom")
+	written, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl)
+	req.NoError(err)
+
+	stats, err := ds.Statistics(ctx)
+	req.NoError(err)
+	req.NoError(ds.Close())
+
+	// The transactions, their revisions and the unique ID are read back from disk.
+	reopened, err := NewBadgerDatastore(path, GCInterval(0*time.Second))
+	req.NoError(err)
+	defer func() {
+		req.NoError(reopened.Close())
+	}()
+
+	head, err := reopened.HeadRevision(ctx)
+	req.NoError(err)
+	req.True(head.Equal(written))
+	req.NoError(reopened.CheckRevision(ctx, written))
+
+	reopenedStats, err := reopened.Statistics(ctx)
+	req.NoError(err)
+	req.Equal(stats.UniqueID, reopenedStats.UniqueID)
+
+	iter, err := reopened.SnapshotReader(head).QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType: "document",
+	})
+	req.NoError(err)
+	defer iter.Close()
+	req.Equal(tuple.String(tpl), tuple.String(iter.Next()))
+	req.Nil(iter.Next())
+
+	next, err := common.WriteTuples(ctx, reopened, core.RelationTupleUpdate_DELETE, tpl)
+	req.NoError(err)
+	req.True(next.GreaterThan(written))
+}
+
+func TestBadgerGarbageCollectionDeletesRecords(t *testing.T) {
+	req := require.New(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "spicedb")
+	ds, err := newBadgerDatastore(path, GCWindow(time.Second), GCInterval(0*time.Second), RevisionQuantization(0))
+	req.NoError(err)
+	defer func() {
+		req.NoError(ds.Close())
+	}()
+
+	tpl := tuple.MustParse("document:doc#viewer@user:tom")
+	created, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl)
+	req.NoError(err)
+	deleted, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, tpl)
+	req.NoError(err)
+
+	time.Sleep(time.Second + 100*time.Millisecond)
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH, tuple.MustParse("document:other#viewer@user:tom"))
+	req.NoError(err)
+
+	collected, err := ds.CollectGarbage(ctx)
+	req.NoError(err)
+	req.Equal(int64(1), collected.Relationships)
+	req.Positive(collected.Transactions)
+	req.ErrorAs(ds.CheckRevision(ctx, created), &datastore.ErrInvalidRevision{})
+
+	// The markers of the replaced versions and the changes of the collected transactions are
+	// gone; Badger drops the versions themselves as it compacts below the discard timestamp.
+	txn := ds.db.NewTransactionAt(ds.head.Load(), false)
+	defer txn.Discard()
+
+	watermark := transactionFromRevision(deleted.(revision.Decimal))
+	for prefix, oldest := range map[string]uint64{deletedPrefix: watermark + 1, changePrefix: watermark} {
+		_, err := scan(txn, []byte(prefix), nil, false, func(item *badgerdb.Item) (bool, error) {
+			txnID, err := transactionOfKey(prefix, item.Key())
+			req.NoError(err)
+			req.GreaterOrEqual(txnID, oldest, string(item.Key()))
+			return true, nil
+		})
+		req.NoError(err)
+	}
+}
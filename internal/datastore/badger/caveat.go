@@ -0,0 +1,118 @@
+package badger
+
+import (
+	"context"
+	"fmt"
+
+	badgerdb "github.com/dgraph-io/badger/v3"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	errReadCaveat    = "unable to read caveat: %w"
+	errListCaveats   = "unable to list caveats: %w"
+	errWriteCaveats  = "unable to write caveats: %w"
+	errDeleteCaveats = "unable to delete caveats: %w"
+)
+
+func (br *badgerReader) ReadCaveatByName(_ context.Context, name string) (*core.CaveatDefinition, datastore.Revision, error) {
+	var definition []byte
+	var version uint64
+	var found bool
+	err := br.view(func(txn *badgerdb.Txn) (err error) {
+		definition, version, found, err = readDefinition(txn, caveatKey(name))
+		return err
+	})
+	if err != nil {
+		return nil, datastore.NoRevision, fmt.Errorf(errReadCaveat, err)
+	}
+	if !found {
+		return nil, datastore.NoRevision, datastore.NewCaveatNameNotFoundErr(name)
+	}
+
+	def := &core.CaveatDefinition{}
+	if err := def.UnmarshalVT(definition); err != nil {
+		return nil, datastore.NoRevision, fmt.Errorf(errReadCaveat, err)
+	}
+	return def, revisionFromTransaction(version), nil
+}
+
+func (br *badgerReader) ListCaveats(_ context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	var definitions [][]byte
+	err := br.view(func(txn *badgerdb.Txn) (err error) {
+		definitions, err = listDefinitions(txn, caveatPrefix, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errListCaveats, err)
+	}
+
+	caveats := make([]*core.CaveatDefinition, 0, len(definitions))
+	for _, definition := range definitions {
+		def := &core.CaveatDefinition{}
+		if err := def.UnmarshalVT(definition); err != nil {
+			return nil, fmt.Errorf(errListCaveats, err)
+		}
+		caveats = append(caveats, def)
+	}
+	return caveats, nil
+}
+
+func (br *badgerReader) LookupCaveats(_ context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	caveats := make([]*core.CaveatDefinition, 0, len(caveatNames))
+	err := br.view(func(txn *badgerdb.Txn) error {
+		for _, name := range caveatNames {
+			definition, _, found, err := readDefinition(txn, caveatKey(name))
+			if err != nil {
+				return err
+			}
+			if !found {
+				continue
+			}
+
+			def := &core.CaveatDefinition{}
+			if err := def.UnmarshalVT(definition); err != nil {
+				return err
+			}
+			caveats = append(caveats, def)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errListCaveats, err)
+	}
+	return caveats, nil
+}
+
+func (rwt *badgerReadWriteTXN) WriteCaveats(_ context.Context, caveats []*core.CaveatDefinition) error {
+	names := make(map[string]struct{}, len(caveats))
+	for _, caveat := range caveats {
+		if _, ok := names[caveat.Name]; ok {
+			return fmt.Errorf(errWriteCaveats, fmt.Errorf("duplicate caveats in input: %s", caveat.Name))
+		}
+		names[caveat.Name] = struct{}{}
+	}
+
+	for _, caveat := range caveats {
+		serialized, err := caveat.MarshalVT()
+		if err != nil {
+			return fmt.Errorf(errWriteCaveats, err)
+		}
+		if err := rwt.badgerTxn.Set(caveatKey(caveat.Name), serialized); err != nil {
+			return fmt.Errorf(errWriteCaveats, err)
+		}
+	}
+	return nil
+}
+
+func (rwt *badgerReadWriteTXN) DeleteCaveats(_ context.Context, names []string) error {
+	for _, name := range names {
+		if err := rwt.badgerTxn.Delete(caveatKey(name)); err != nil {
+			return fmt.Errorf(errDeleteCaveats, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package badger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	badgerdb "github.com/dgraph-io/badger/v3"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+var (
+	_ common.GarbageCollector               = (*Datastore)(nil)
+	_ datastore.GarbageCollectableDatastore = (*Datastore)(nil)
+)
+
+// Now returns the time of the process, which also timestamps the transactions it commits.
+func (bds *Datastore) Now(_ context.Context) (time.Time, error) {
+	return time.Now().UTC(), nil
+}
+
+func (bds *Datastore) TxIDBefore(_ context.Context, before time.Time) (datastore.Revision, error) {
+	txn := bds.db.NewTransactionAt(bds.head.Load(), false)
+	defer txn.Discard()
+
+	// Find the highest transaction ID before the GC window, reading the transactions which have
+	// not been collected in order.
+	var found uint64
+	_, err := scan(txn, []byte(transactionPrefix), nil, true, func(item *badgerdb.Item) (bool, error) {
+		var record transactionRecord
+		if err := item.Value(func(encoded []byte) error {
+			return json.Unmarshal(encoded, &record)
+		}); err != nil {
+			return false, err
+		}
+		if record.Timestamp >= before.UnixNano() {
+			return false, nil
+		}
+
+		txnID, err := transactionOfKey(transactionPrefix, item.Key())
+		if err != nil {
+			return false, err
+		}
+		found = txnID
+		return true, nil
+	})
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+
+	if found == 0 {
+		log.Debug().Time("before", before).Msg("no stale transactions found in the datastore")
+		return datastore.NoRevision, nil
+	}
+	return revisionFromTransaction(found), nil
+}
+
+func (bds *Datastore) CountTransactionsBeforeTx(_ context.Context, txID datastore.Revision) (int64, error) {
+	tx := transactionFromRevision(txID.(revision.Decimal))
+
+	txn := bds.db.NewTransactionAt(bds.head.Load(), false)
+	defer txn.Discard()
+
+	var count int64
+	_, err := scan(txn, []byte(transactionPrefix), nil, false, func(item *badgerdb.Item) (bool, error) {
+		txnID, err := transactionOfKey(transactionPrefix, item.Key())
+		if err != nil || txnID >= tx {
+			return false, err
+		}
+		count++
+		return true, nil
+	})
+	return count, err
+}
+
+// CollectGarbage runs garbage collection immediately, regardless of whether the background
+// garbage collection is enabled.
+func (bds *Datastore) CollectGarbage(ctx context.Context) (datastore.GarbageCollected, error) {
+	return common.RunGarbageCollection(ctx, bds, bds.gcWindow, bds.gcTimeout)
+}
+
+// DeleteBeforeTx deletes the relationships which have expired, and moves the discard timestamp
+// of Badger to the transaction, so that its compactions drop the versions replaced or deleted
+// at or before it. The relationships collected are counted from the records of the versions
+// replaced or deleted, as compactions run in the background.
+func (bds *Datastore) DeleteBeforeTx(
+	ctx context.Context,
+	txID datastore.Revision,
+) (removed common.DeletionCounts, err error) {
+	removed, err = bds.discardBefore(transactionFromRevision(txID.(revision.Decimal)))
+	if err != nil {
+		return
+	}
+
+	// The value log is rewritten without blocking commits.
+	err = bds.collectValueLog(ctx)
+	return
+}
+
+// discardBefore deletes the relationships which have expired and the records before the
+// transaction, and moves the discard timestamp to the transaction.
+func (bds *Datastore) discardBefore(tx uint64) (removed common.DeletionCounts, err error) {
+	bds.commitMu.Lock()
+	defer bds.commitMu.Unlock()
+
+	removed.Relationships, err = bds.deleteExpiredRelationships()
+	if err != nil {
+		return
+	}
+
+	dropped, err := bds.deleteRecordsBefore(tx)
+	if err != nil {
+		return
+	}
+	removed.Relationships += dropped.Relationships
+	removed.Transactions = dropped.Transactions
+
+	bds.db.SetDiscardTs(tx)
+	bds.discardTxn = tx
+	return
+}
+
+// deleteExpiredRelationships deletes the living relationships which have expired by a new
+// transaction, which records no changes, so that the snapshots of the earlier transactions are
+// left intact. Must be called under commitMu.
+func (bds *Datastore) deleteExpiredRelationships() (int64, error) {
+	head := bds.head.Load()
+	txn := bds.db.NewTransactionAt(head, true)
+	defer txn.Discard()
+
+	now := time.Now()
+	var keys [][]byte
+	_, err := scan(txn, []byte(expirationPrefix), nil, false, func(item *badgerdb.Item) (bool, error) {
+		expiration, err := transactionOfKey(expirationPrefix, item.Key())
+		if err != nil || int64(expiration) > now.UnixNano() {
+			return false, err
+		}
+		keys = append(keys, item.KeyCopy(nil)[len(expirationPrefix)+21:])
+		return true, nil
+	})
+	if err != nil || len(keys) == 0 {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, key := range keys {
+		existing, err := readRelationship(txn, key)
+		if err != nil {
+			return 0, err
+		}
+		if existing == nil || !expired(existing, now) {
+			continue
+		}
+
+		if err := deleteRelationship(txn, key, existing); err != nil {
+			return 0, err
+		}
+		deleted++
+	}
+	if deleted == 0 {
+		return 0, nil
+	}
+
+	txnID := head + 1
+	if err := writeTransaction(txn, txnID, transactionRecord{Timestamp: bds.nextCommitTime()}); err != nil {
+		return 0, err
+	}
+	if err := txn.CommitAt(txnID, nil); err != nil {
+		return 0, err
+	}
+	bds.head.Store(txnID)
+	return deleted, nil
+}
+
+// deleteRecordsBefore deletes the records of the versions replaced or deleted at or before the
+// transaction, and the records and changes of the transactions before it, which can no longer
+// be read or watched. The records are deleted at the head, as no snapshot within the window
+// reads them. Must be called under commitMu.
+func (bds *Datastore) deleteRecordsBefore(tx uint64) (removed common.DeletionCounts, err error) {
+	head := bds.head.Load()
+	txn := bds.db.NewTransactionAt(head, false)
+	defer txn.Discard()
+
+	batch := bds.db.NewWriteBatchAt(head)
+
+	deleteBefore := func(prefix string, limit uint64, count *int64) error {
+		_, err := scan(txn, []byte(prefix), nil, false, func(item *badgerdb.Item) (bool, error) {
+			txnID, err := transactionOfKey(prefix, item.Key())
+			if err != nil || txnID >= limit {
+				return false, err
+			}
+			if count != nil {
+				*count++
+			}
+			return true, batch.Delete(item.KeyCopy(nil))
+		})
+		return err
+	}
+
+	// We don't delete the transaction itself to ensure there is always at least
+	// one transaction present.
+	if err = deleteBefore(deletedPrefix, tx+1, &removed.Relationships); err == nil {
+		if err = deleteBefore(changePrefix, tx, nil); err == nil {
+			err = deleteBefore(transactionPrefix, tx, &removed.Transactions)
+		}
+	}
+	if err != nil {
+		batch.Cancel()
+		return
+	}
+
+	err = batch.Flush()
+	return
+}
+
+// collectValueLog rewrites the value log files of which enough is garbage, until none is left
+// or the context is canceled.
+func (bds *Datastore) collectValueLog(ctx context.Context) error {
+	for ctx.Err() == nil {
+		err := bds.db.RunValueLogGC(bds.valueLogGCDiscardRatio)
+		switch {
+		case errors.Is(err, badgerdb.ErrNoRewrite), errors.Is(err, badgerdb.ErrRejected):
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+	return nil
+}
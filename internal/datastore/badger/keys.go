@@ -0,0 +1,120 @@
+package badger
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// The keys of the datastore begin with a prefix naming the kind of record they hold. Each record
+// is written at the commit timestamp of the transaction which wrote it, so that Badger keeps a
+// version of every key per transaction, and a snapshot read at a transaction reads the versions
+// as of that transaction.
+const (
+	// relationshipPrefix keys the relationships by resource and then by subject, and
+	// subjectPrefix indexes them by subject and then by resource.
+	relationshipPrefix = "r/"
+	subjectPrefix      = "s/"
+
+	// expirationPrefix indexes the relationships which expire by their expiration.
+	expirationPrefix = "e/"
+
+	// deletedPrefix records the relationships replaced or deleted by each transaction, whose
+	// prior versions garbage collection hands over to compaction.
+	deletedPrefix = "d/"
+
+	// changePrefix keys the changes of the relationships by transaction and sequence number,
+	// and transactionPrefix keys the commit time and metadata of the transactions.
+	changePrefix      = "w/"
+	transactionPrefix = "x/"
+
+	namespacePrefix = "n/"
+	caveatPrefix    = "c/"
+
+	headKey     = "m/head"
+	uniqueIDKey = "m/unique_id"
+
+	// separator separates the parts of the keys of relationships. Names and object IDs cannot
+	// contain it, so that the keys sort in the order of their parts.
+	separator = "\x00"
+)
+
+// relationshipKey is the key of a relationship, which identifies it across its versions.
+func relationshipKey(tpl *core.RelationTuple) []byte {
+	return []byte(relationshipPrefix + strings.Join([]string{
+		tpl.ResourceAndRelation.Namespace,
+		tpl.ResourceAndRelation.ObjectId,
+		tpl.ResourceAndRelation.Relation,
+		tpl.Subject.Namespace,
+		tpl.Subject.ObjectId,
+		tpl.Subject.Relation,
+	}, separator))
+}
+
+// subjectKey is the key of the entry of a relationship in the subject index, which sorts the
+// relationships by subject and then by resource.
+func subjectKey(tpl *core.RelationTuple) []byte {
+	return []byte(subjectPrefix + strings.Join([]string{
+		tpl.Subject.Namespace,
+		tpl.Subject.ObjectId,
+		tpl.Subject.Relation,
+		tpl.ResourceAndRelation.Namespace,
+		tpl.ResourceAndRelation.ObjectId,
+		tpl.ResourceAndRelation.Relation,
+	}, separator))
+}
+
+// relationshipKeyOfSubjectKey returns the key of the relationship of an entry of the subject
+// index.
+func relationshipKeyOfSubjectKey(key []byte) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(string(key), subjectPrefix), separator)
+	if len(parts) != 6 {
+		return nil, fmt.Errorf("malformed subject key %q", key)
+	}
+	return []byte(relationshipPrefix + strings.Join(append(parts[3:], parts[:3]...), separator)), nil
+}
+
+// keyPrefix returns the prefix of the keys whose leading parts are the parts.
+func keyPrefix(prefix string, parts ...string) []byte {
+	return []byte(prefix + strings.Join(parts, separator) + separator)
+}
+
+// expirationKey is the key of the entry of a relationship in the expiration index, which
+// sorts the relationships by their expiration in nanoseconds.
+func expirationKey(expiration int64, tpl *core.RelationTuple) []byte {
+	return append([]byte(fmt.Sprintf("%s%020d/", expirationPrefix, expiration)), relationshipKey(tpl)...)
+}
+
+// deletedKey is the key recording that a transaction replaced or deleted a relationship.
+func deletedKey(txn uint64, tpl *core.RelationTuple) []byte {
+	return append(transactionScopedKey(deletedPrefix, txn), relationshipKey(tpl)...)
+}
+
+// changeKey is the key of a change of a relationship made by a transaction.
+func changeKey(txn uint64, seq int) []byte {
+	return []byte(fmt.Sprintf("%s%020d/%010d", changePrefix, txn, seq))
+}
+
+func transactionKey(txn uint64) []byte {
+	return []byte(fmt.Sprintf("%s%020d", transactionPrefix, txn))
+}
+
+// transactionScopedKey returns the prefix of the keys of a transaction within a prefix.
+func transactionScopedKey(prefix string, txn uint64) []byte {
+	return []byte(fmt.Sprintf("%s%020d/", prefix, txn))
+}
+
+// transactionOfKey returns the transaction or expiration encoded after the prefix of a key.
+func transactionOfKey(prefix string, key []byte) (uint64, error) {
+	encoded := bytes.TrimPrefix(key, []byte(prefix))
+	if len(encoded) < 20 {
+		return 0, fmt.Errorf("malformed key %q", key)
+	}
+	return strconv.ParseUint(string(encoded[:20]), 10, 64)
+}
+
+func namespaceKey(name string) []byte { return []byte(namespacePrefix + name) }
+func caveatKey(name string) []byte    { return []byte(caveatPrefix + name) }
@@ -0,0 +1,215 @@
+package badger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+)
+
+const (
+	errQuantizationTooLarge = "revision quantization interval (%s) must be less than GC window (%s)"
+
+	defaultGarbageCollectionWindow           = 24 * time.Hour
+	defaultGarbageCollectionInterval         = time.Minute * 3
+	defaultGarbageCollectionMaxOperationTime = time.Minute
+	defaultWatchBufferLength                 = 128
+	defaultQuantization                      = 5 * time.Second
+	defaultMaxRevisionStalenessPercent       = 0.1
+	defaultEnablePrometheusStats             = false
+	defaultMaxRetries                        = 8
+	defaultGCEnabled                         = true
+	defaultSyncWrites                        = true
+	defaultValueLogGCDiscardRatio            = 0.5
+)
+
+type badgerOptions struct {
+	revisionQuantization        time.Duration
+	gcWindow                    time.Duration
+	gcInterval                  time.Duration
+	gcMaxOperationTime          time.Duration
+	maxRevisionStalenessPercent float64
+	watchBufferLength           uint16
+	enablePrometheusStats       bool
+	maxRetries                  uint8
+	retryInitialBackoff         time.Duration
+	retryMaxBackoff             time.Duration
+	gcEnabled                   bool
+	syncWrites                  bool
+	valueLogGCDiscardRatio      float64
+}
+
+// Option provides the facility to configure how the Badger datastore stores
+// its data.
+type Option func(*badgerOptions)
+
+func generateConfig(options []Option) (badgerOptions, error) {
+	computed := badgerOptions{
+		gcWindow:                    defaultGarbageCollectionWindow,
+		gcInterval:                  defaultGarbageCollectionInterval,
+		gcMaxOperationTime:          defaultGarbageCollectionMaxOperationTime,
+		watchBufferLength:           defaultWatchBufferLength,
+		revisionQuantization:        defaultQuantization,
+		maxRevisionStalenessPercent: defaultMaxRevisionStalenessPercent,
+		enablePrometheusStats:       defaultEnablePrometheusStats,
+		maxRetries:                  defaultMaxRetries,
+		retryInitialBackoff:         common.DefaultRetryInitialBackoff,
+		retryMaxBackoff:             common.DefaultRetryMaxBackoff,
+		gcEnabled:                   defaultGCEnabled,
+		syncWrites:                  defaultSyncWrites,
+		valueLogGCDiscardRatio:      defaultValueLogGCDiscardRatio,
+	}
+
+	for _, option := range options {
+		option(&computed)
+	}
+
+	// Run any checks on the config that need to be done
+	if computed.revisionQuantization >= computed.gcWindow {
+		return computed, fmt.Errorf(
+			errQuantizationTooLarge,
+			computed.revisionQuantization,
+			computed.gcWindow,
+		)
+	}
+
+	if computed.valueLogGCDiscardRatio <= 0 || computed.valueLogGCDiscardRatio >= 1 {
+		return computed, fmt.Errorf(
+			"value log GC discard ratio (%v) must be between 0 and 1",
+			computed.valueLogGCDiscardRatio,
+		)
+	}
+
+	return computed, nil
+}
+
+// WatchBufferLength is the number of entries that can be stored in the watch
+// buffer while awaiting read by the client.
+//
+// This value defaults to 128.
+func WatchBufferLength(watchBufferLength uint16) Option {
+	return func(bo *badgerOptions) {
+		bo.watchBufferLength = watchBufferLength
+	}
+}
+
+// RevisionQuantization is the time bucket size to which advertised
+// revisions will be rounded.
+//
+// This value defaults to 5 seconds.
+func RevisionQuantization(quantization time.Duration) Option {
+	return func(bo *badgerOptions) {
+		bo.revisionQuantization = quantization
+	}
+}
+
+// MaxRevisionStalenessPercent is the amount of time, expressed as a percentage of
+// the revision quantization window, that a previously computed rounded revision
+// can still be advertised after the next rounded revision would otherwise be ready.
+//
+// This value defaults to 0.1 (10%).
+func MaxRevisionStalenessPercent(stalenessPercent float64) Option {
+	return func(bo *badgerOptions) {
+		bo.maxRevisionStalenessPercent = stalenessPercent
+	}
+}
+
+// GCWindow is the maximum age of a passed revision that will be considered
+// valid.
+//
+// This value defaults to 24 hours.
+func GCWindow(window time.Duration) Option {
+	return func(bo *badgerOptions) {
+		bo.gcWindow = window
+	}
+}
+
+// GCInterval is the interval at which garbage collection will occur.
+//
+// This value defaults to 3 minutes.
+func GCInterval(interval time.Duration) Option {
+	return func(bo *badgerOptions) {
+		bo.gcInterval = interval
+	}
+}
+
+// GCMaxOperationTime is the maximum operation time of a garbage collection
+// pass before it times out.
+//
+// This value defaults to 1 minute.
+func GCMaxOperationTime(time time.Duration) Option {
+	return func(bo *badgerOptions) {
+		bo.gcMaxOperationTime = time
+	}
+}
+
+// GCEnabled indicates whether garbage collection is enabled.
+//
+// GC is enabled by default.
+func GCEnabled(isGCEnabled bool) Option {
+	return func(bo *badgerOptions) {
+		bo.gcEnabled = isGCEnabled
+	}
+}
+
+// MaxRetries is the maximum number of times a transaction which conflicted
+// with another transaction will be client-side retried.
+//
+// Default: 8
+func MaxRetries(maxRetries uint8) Option {
+	return func(bo *badgerOptions) {
+		bo.maxRetries = maxRetries
+	}
+}
+
+// RetryInitialBackoff is the wait before the first client-side retry of a
+// transaction, which doubles with each further retry up to RetryMaxBackoff.
+// Zero retries immediately.
+//
+// Default: 10ms
+func RetryInitialBackoff(backoff time.Duration) Option {
+	return func(bo *badgerOptions) {
+		bo.retryInitialBackoff = backoff
+	}
+}
+
+// RetryMaxBackoff is the longest wait between client-side retries of a
+// transaction.
+//
+// Default: 1s
+func RetryMaxBackoff(backoff time.Duration) Option {
+	return func(bo *badgerOptions) {
+		bo.retryMaxBackoff = backoff
+	}
+}
+
+// WithEnablePrometheusStats marks whether Prometheus metrics of garbage
+// collection are enabled.
+//
+// Prometheus metrics are disabled by default.
+func WithEnablePrometheusStats(enablePrometheusStats bool) Option {
+	return func(bo *badgerOptions) {
+		bo.enablePrometheusStats = enablePrometheusStats
+	}
+}
+
+// SyncWrites marks whether each transaction is synced to disk before it
+// commits. Without it, a crash of the host may lose the transactions
+// committed shortly before it, though never part of a transaction.
+//
+// Writes are synced by default.
+func SyncWrites(syncWrites bool) Option {
+	return func(bo *badgerOptions) {
+		bo.syncWrites = syncWrites
+	}
+}
+
+// ValueLogGCDiscardRatio is the fraction of a value log file which must be
+// garbage for garbage collection to rewrite the file.
+//
+// This value defaults to 0.5.
+func ValueLogGCDiscardRatio(ratio float64) Option {
+	return func(bo *badgerOptions) {
+		bo.valueLogGCDiscardRatio = ratio
+	}
+}
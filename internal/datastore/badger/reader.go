@@ -0,0 +1,526 @@
+package badger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	badgerdb "github.com/dgraph-io/badger/v3"
+	"github.com/jzelinskie/stringz"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	errUnableToReadConfig         = "unable to read namespace config: %w"
+	errUnableToListNamespaces     = "unable to list namespaces: %w"
+	errUnableToQueryTuples        = "unable to query tuples: %w"
+	errUnableToCountRelationships = "unable to count relationships: %w"
+	errUnableToListResourceTypes  = "unable to list resource types: %w"
+)
+
+// badgerReader reads the versions of the keys at a transaction. Snapshot readers read each
+// query through a new Badger transaction at the transaction of their revision, and the reader of
+// a read/write transaction through its Badger transaction, which sees its own writes.
+type badgerReader struct {
+	db        *badgerdb.DB
+	txn       uint64
+	badgerTxn *badgerdb.Txn
+}
+
+// view calls fn with the Badger transaction through which the reader reads.
+func (br *badgerReader) view(fn func(txn *badgerdb.Txn) error) error {
+	if br.badgerTxn != nil {
+		return fn(br.badgerTxn)
+	}
+
+	txn := br.db.NewTransactionAt(br.txn, false)
+	defer txn.Discard()
+	return fn(txn)
+}
+
+// readTime returns the time at which the transaction of the reader committed, at which
+// expiration is evaluated. Read/write transactions read at the current time, as they have not
+// committed yet.
+func (br *badgerReader) readTime(txn *badgerdb.Txn) (time.Time, error) {
+	if br.badgerTxn != nil {
+		return time.Now(), nil
+	}
+
+	record, err := readTransaction(txn, br.txn)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, record.Timestamp), nil
+}
+
+func (br *badgerReader) QueryRelationships(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	_, span := tracer.Start(ctx, "QueryRelationships")
+	defer span.End()
+
+	queryOpts := options.NewQueryOptionsWithOptions(opts...)
+
+	var matched []*core.RelationTuple
+	err = br.view(func(txn *badgerdb.Txn) error {
+		return br.scanRelationships(txn, filter, func(tpl *core.RelationTuple) bool {
+			if matchesFilter(tpl, filter, queryOpts.Usersets) {
+				matched = append(matched, tpl)
+			}
+			return !reachedLimit(matched, queryOpts.Limit)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToQueryTuples, err)
+	}
+	return datastore.NewSliceRelationshipIterator(matched), nil
+}
+
+func (br *badgerReader) ReverseQueryRelationships(
+	ctx context.Context,
+	subjectsFilter datastore.SubjectsFilter,
+	opts ...options.ReverseQueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	_, span := tracer.Start(ctx, "ReverseQueryRelationships")
+	defer span.End()
+
+	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
+
+	filter := datastore.RelationshipsFilter{OptionalSubjectsFilter: &subjectsFilter}
+	if queryOpts.ResRelation != nil {
+		filter.ResourceType = queryOpts.ResRelation.Namespace
+		filter.OptionalResourceRelation = queryOpts.ResRelation.Relation
+	}
+
+	// The subject index is sorted by subject, so that a sorted query resumes by seeking past
+	// the entry of the cursor.
+	var start []byte
+	if queryOpts.ReverseSort == options.BySubject && queryOpts.ReverseAfter != nil {
+		start = append(subjectKey(queryOpts.ReverseAfter), separator...)
+	}
+
+	var matched []*core.RelationTuple
+	err = br.view(func(txn *badgerdb.Txn) error {
+		readTime, err := br.readTime(txn)
+		if err != nil {
+			return err
+		}
+
+		for _, prefix := range subjectPrefixes(subjectsFilter) {
+			more, err := scanSubjectIndex(txn, prefix, start, readTime, func(tpl *core.RelationTuple) bool {
+				if matchesFilter(tpl, filter, nil) {
+					matched = append(matched, tpl)
+				}
+				return !reachedLimit(matched, queryOpts.ReverseLimit)
+			})
+			if err != nil || !more {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToQueryTuples, err)
+	}
+	return datastore.NewSliceRelationshipIterator(matched), nil
+}
+
+func (br *badgerReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	_, span := tracer.Start(ctx, "CountRelationships")
+	defer span.End()
+
+	var count uint64
+	err := br.view(func(txn *badgerdb.Txn) error {
+		return br.scanRelationships(txn, filter, func(tpl *core.RelationTuple) bool {
+			if matchesFilter(tpl, filter, nil) {
+				count++
+			}
+			return true
+		})
+	})
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+	return count, nil
+}
+
+// ListResourceTypes seeks past the relationships of each resource type as soon as one of them
+// is found to be visible.
+func (br *badgerReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	_, span := tracer.Start(ctx, "ListResourceTypes")
+	defer span.End()
+
+	var resourceTypes []string
+	err := br.view(func(txn *badgerdb.Txn) error {
+		readTime, err := br.readTime(txn)
+		if err != nil {
+			return err
+		}
+
+		opts := badgerdb.DefaultIteratorOptions
+		opts.Prefix = []byte(relationshipPrefix)
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(opts.Prefix); it.Valid(); {
+			tpl, err := decodeRelationship(it.Item())
+			if err != nil {
+				return err
+			}
+			if expired(tpl, readTime) {
+				it.Next()
+				continue
+			}
+
+			resourceType := tpl.ResourceAndRelation.Namespace
+			resourceTypes = append(resourceTypes, resourceType)
+
+			next := keyPrefix(relationshipPrefix, resourceType)
+			next[len(next)-1]++
+			it.Seek(next)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+	return resourceTypes, nil
+}
+
+// scanRelationships calls fn with the relationships visible to the reader which may match the
+// filter, read through the most selective prefix of the relationships or the subject index,
+// until fn returns false.
+func (br *badgerReader) scanRelationships(txn *badgerdb.Txn, filter datastore.RelationshipsFilter, fn func(tpl *core.RelationTuple) bool) error {
+	readTime, err := br.readTime(txn)
+	if err != nil {
+		return err
+	}
+
+	if filter.ResourceType == "" && filter.OptionalSubjectsFilter != nil {
+		for _, prefix := range subjectPrefixes(*filter.OptionalSubjectsFilter) {
+			more, err := scanSubjectIndex(txn, prefix, nil, readTime, fn)
+			if err != nil || !more {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var prefixes [][]byte
+	switch {
+	case filter.ResourceType != "" && len(filter.OptionalResourceIds) > 0:
+		for _, resourceID := range sortedIDs(filter.OptionalResourceIds) {
+			if filter.OptionalResourceRelation != "" {
+				prefixes = append(prefixes, keyPrefix(relationshipPrefix, filter.ResourceType, resourceID, filter.OptionalResourceRelation))
+			} else {
+				prefixes = append(prefixes, keyPrefix(relationshipPrefix, filter.ResourceType, resourceID))
+			}
+		}
+	case filter.ResourceType != "":
+		prefixes = [][]byte{keyPrefix(relationshipPrefix, filter.ResourceType)}
+	default:
+		prefixes = [][]byte{[]byte(relationshipPrefix)}
+	}
+
+	for _, prefix := range prefixes {
+		more, err := scan(txn, prefix, nil, true, func(item *badgerdb.Item) (bool, error) {
+			tpl, err := decodeRelationship(item)
+			if err != nil {
+				return false, err
+			}
+			if expired(tpl, readTime) {
+				return true, nil
+			}
+			return fn(tpl), nil
+		})
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+// subjectPrefixes returns the prefixes of the subject index of the subjects of the filter, in
+// order.
+func subjectPrefixes(filter datastore.SubjectsFilter) [][]byte {
+	if len(filter.OptionalSubjectIds) == 0 {
+		return [][]byte{keyPrefix(subjectPrefix, filter.SubjectType)}
+	}
+
+	prefixes := make([][]byte, 0, len(filter.OptionalSubjectIds))
+	for _, subjectID := range sortedIDs(filter.OptionalSubjectIds) {
+		prefixes = append(prefixes, keyPrefix(subjectPrefix, filter.SubjectType, subjectID))
+	}
+	return prefixes
+}
+
+// scanSubjectIndex calls fn with the visible relationships of the entries of the subject index
+// with the prefix, from the start key if it follows the prefix, until fn returns false. It
+// returns whether the entries were all read.
+func scanSubjectIndex(txn *badgerdb.Txn, prefix, start []byte, readTime time.Time, fn func(tpl *core.RelationTuple) bool) (bool, error) {
+	return scan(txn, prefix, start, false, func(item *badgerdb.Item) (bool, error) {
+		key, err := relationshipKeyOfSubjectKey(item.Key())
+		if err != nil {
+			return false, err
+		}
+
+		tpl, err := readRelationship(txn, key)
+		if err != nil {
+			return false, err
+		}
+		if tpl == nil || expired(tpl, readTime) {
+			return true, nil
+		}
+		return fn(tpl), nil
+	})
+}
+
+// scan calls fn with the items of the keys with the prefix, from the start key if it follows
+// the prefix, until fn returns false. It returns whether the items were all read.
+func scan(txn *badgerdb.Txn, prefix, start []byte, prefetch bool, fn func(item *badgerdb.Item) (bool, error)) (bool, error) {
+	opts := badgerdb.DefaultIteratorOptions
+	opts.Prefix = prefix
+	opts.PrefetchValues = prefetch
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	if bytes.Compare(start, prefix) < 0 {
+		start = prefix
+	}
+	for it.Seek(start); it.Valid(); it.Next() {
+		more, err := fn(it.Item())
+		if err != nil || !more {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// readRelationship returns the relationship of the key, or nil if it does not exist.
+func readRelationship(txn *badgerdb.Txn, key []byte) (*core.RelationTuple, error) {
+	item, err := txn.Get(key)
+	switch {
+	case errors.Is(err, badgerdb.ErrKeyNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	return decodeRelationship(item)
+}
+
+func decodeRelationship(item *badgerdb.Item) (*core.RelationTuple, error) {
+	tpl := &core.RelationTuple{}
+	if err := item.Value(tpl.UnmarshalVT); err != nil {
+		return nil, fmt.Errorf("unable to decode relationship: %w", err)
+	}
+	withCaveatContext(tpl)
+	return tpl, nil
+}
+
+// withCaveatContext sets the context of the caveat of a decoded relationship to an empty struct
+// if it was written without one, as the other datastores read it.
+func withCaveatContext(tpl *core.RelationTuple) {
+	if tpl.Caveat != nil && tpl.Caveat.Context == nil {
+		tpl.Caveat.Context = &structpb.Struct{}
+	}
+}
+
+// expired returns whether the relationship has expired at the time.
+func expired(tpl *core.RelationTuple, at time.Time) bool {
+	return tpl.OptionalExpirationTime != nil && !tpl.OptionalExpirationTime.AsTime().After(at)
+}
+
+func reachedLimit(relationships []*core.RelationTuple, limit *uint64) bool {
+	return limit != nil && uint64(len(relationships)) >= *limit
+}
+
+// sortedIDs returns the distinct IDs in order, so that their prefixes are read in order.
+func sortedIDs(ids []string) []string {
+	sorted := stringz.Dedup(ids)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// readDefinition returns the version of the definition of the key and the transaction which
+// wrote it, or false if it did not exist.
+func readDefinition(txn *badgerdb.Txn, key []byte) ([]byte, uint64, bool, error) {
+	item, err := txn.Get(key)
+	switch {
+	case errors.Is(err, badgerdb.ErrKeyNotFound):
+		return nil, 0, false, nil
+	case err != nil:
+		return nil, 0, false, err
+	}
+
+	definition, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return definition, item.Version(), true, nil
+}
+
+// listDefinitions returns the definitions with the prefix, in order of their names, and paged
+// by the options.
+func listDefinitions(txn *badgerdb.Txn, prefix string, opts ...options.ListOptionsOption) ([][]byte, error) {
+	listOpts := options.NewListOptionsWithOptions(opts...)
+
+	var start []byte
+	if listOpts.ListAfter != "" {
+		start = []byte(prefix + listOpts.ListAfter + separator)
+	}
+
+	var definitions [][]byte
+	_, err := scan(txn, []byte(prefix), start, true, func(item *badgerdb.Item) (bool, error) {
+		if listOpts.ListLimit != nil && uint64(len(definitions)) >= *listOpts.ListLimit {
+			return false, nil
+		}
+
+		definition, err := item.ValueCopy(nil)
+		if err != nil {
+			return false, err
+		}
+		definitions = append(definitions, definition)
+		return true, nil
+	})
+	return definitions, err
+}
+
+func (br *badgerReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
+	_, span := tracer.Start(ctx, "ReadNamespace")
+	defer span.End()
+
+	var definition []byte
+	var version uint64
+	var found bool
+	err := br.view(func(txn *badgerdb.Txn) (err error) {
+		definition, version, found, err = readDefinition(txn, namespaceKey(nsName))
+		return err
+	})
+	if err != nil {
+		return nil, datastore.NoRevision, fmt.Errorf(errUnableToReadConfig, err)
+	}
+	if !found {
+		return nil, datastore.NoRevision, datastore.NewNamespaceNotFoundErr(nsName)
+	}
+
+	loaded := &core.NamespaceDefinition{}
+	if err := loaded.UnmarshalVT(definition); err != nil {
+		return nil, datastore.NoRevision, fmt.Errorf(errUnableToReadConfig, err)
+	}
+	return loaded, revisionFromTransaction(version), nil
+}
+
+func (br *badgerReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	_, span := tracer.Start(ctx, "ListNamespaces")
+	defer span.End()
+
+	var definitions [][]byte
+	err := br.view(func(txn *badgerdb.Txn) (err error) {
+		definitions, err = listDefinitions(txn, namespacePrefix, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListNamespaces, err)
+	}
+
+	nsDefs := make([]*core.NamespaceDefinition, 0, len(definitions))
+	for _, definition := range definitions {
+		loaded := &core.NamespaceDefinition{}
+		if err := loaded.UnmarshalVT(definition); err != nil {
+			return nil, fmt.Errorf(errUnableToListNamespaces, err)
+		}
+		nsDefs = append(nsDefs, loaded)
+	}
+	return nsDefs, nil
+}
+
+func (br *badgerReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
+	_, span := tracer.Start(ctx, "LookupNamespaces")
+	defer span.End()
+
+	nsDefs := make([]*core.NamespaceDefinition, 0, len(nsNames))
+	err := br.view(func(txn *badgerdb.Txn) error {
+		for _, nsName := range nsNames {
+			definition, _, found, err := readDefinition(txn, namespaceKey(nsName))
+			if err != nil {
+				return err
+			}
+			if !found {
+				continue
+			}
+
+			loaded := &core.NamespaceDefinition{}
+			if err := loaded.UnmarshalVT(definition); err != nil {
+				return err
+			}
+			nsDefs = append(nsDefs, loaded)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadConfig, err)
+	}
+	return nsDefs, nil
+}
+
+// matchesFilter returns whether the relationship matches every part of the filter, and is one
+// of the usersets, if any.
+func matchesFilter(tpl *core.RelationTuple, filter datastore.RelationshipsFilter, usersets []*core.ObjectAndRelation) bool {
+	switch {
+	case filter.ResourceType != "" && filter.ResourceType != tpl.ResourceAndRelation.Namespace:
+		return false
+	case len(filter.OptionalResourceIds) > 0 && !stringz.SliceContains(filter.OptionalResourceIds, tpl.ResourceAndRelation.ObjectId):
+		return false
+	case filter.OptionalResourceRelation != "" && filter.OptionalResourceRelation != tpl.ResourceAndRelation.Relation:
+		return false
+	case filter.OptionalCaveatName != "" && (tpl.Caveat == nil || tpl.Caveat.CaveatName != filter.OptionalCaveatName):
+		return false
+	case filter.OptionalSubjectsFilter != nil && !matchesSubjectsFilter(tpl, *filter.OptionalSubjectsFilter):
+		return false
+	}
+
+	for key, value := range filter.OptionalMetadata {
+		found, ok := tpl.OptionalMetadata[key]
+		if !ok || (value != "" && value != found) {
+			return false
+		}
+	}
+
+	if len(usersets) == 0 {
+		return true
+	}
+	for _, userset := range usersets {
+		if userset.Namespace == tpl.Subject.Namespace &&
+			userset.ObjectId == tpl.Subject.ObjectId &&
+			userset.Relation == tpl.Subject.Relation {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesSubjectsFilter(tpl *core.RelationTuple, filter datastore.SubjectsFilter) bool {
+	if filter.SubjectType != tpl.Subject.Namespace {
+		return false
+	}
+	if len(filter.OptionalSubjectIds) > 0 && !stringz.SliceContains(filter.OptionalSubjectIds, tpl.Subject.ObjectId) {
+		return false
+	}
+	if filter.RelationFilter.IsEmpty() {
+		return true
+	}
+	return (filter.RelationFilter.IncludeEllipsisRelation && tpl.Subject.Relation == datastore.Ellipsis) ||
+		(filter.RelationFilter.NonEllipsisRelation != "" && tpl.Subject.Relation == filter.RelationFilter.NonEllipsisRelation)
+}
+
+var _ datastore.Reader = &badgerReader{}
@@ -0,0 +1,236 @@
+package badger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	badgerdb "github.com/dgraph-io/badger/v3"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+const (
+	errUnableToWriteRelationships  = "unable to write relationships: %w"
+	errUnableToDeleteRelationships = "unable to delete relationships: %w"
+	errUnableToWriteConfig         = "unable to write namespace config: %w"
+	errUnableToDeleteConfig        = "unable to delete namespace config: %w"
+	errUnableToCommit              = "unable to commit transaction: %w"
+)
+
+// badgerReadWriteTXN writes through a Badger transaction, whose reads see its own writes. The
+// relationships it writes are tracked, so that its changes and the versions it replaces are
+// recorded as it commits.
+type badgerReadWriteTXN struct {
+	*badgerReader
+
+	metadata map[string]string
+
+	// The keys of the relationships written by the transaction in the order they were first
+	// written, the versions which existed before the transaction wrote them, and the versions
+	// it wrote, which are nil if it deleted them.
+	relationshipOrder []string
+	previous          map[string]*core.RelationTuple
+	written           map[string]*core.RelationTuple
+}
+
+func newReadWriteTXN(db *badgerdb.DB, txn *badgerdb.Txn, metadata map[string]string) *badgerReadWriteTXN {
+	return &badgerReadWriteTXN{
+		badgerReader: &badgerReader{db: db, txn: txn.ReadTs(), badgerTxn: txn},
+		metadata:     metadata,
+		previous:     make(map[string]*core.RelationTuple),
+		written:      make(map[string]*core.RelationTuple),
+	}
+}
+
+// WriteRelationships writes the mutations through the transaction. A relationship which has
+// expired does not prevent its creation.
+func (rwt *badgerReadWriteTXN) WriteRelationships(_ context.Context, mutations []*core.RelationTupleUpdate) error {
+	now := time.Now()
+	for _, mut := range mutations {
+		key := relationshipKey(mut.Tuple)
+		existing, err := readRelationship(rwt.badgerTxn, key)
+		if err != nil {
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+
+		if mut.Operation == core.RelationTupleUpdate_CREATE && existing != nil && !expired(existing, now) {
+			return common.NewCreateRelationshipExistsError(mut.Tuple)
+		}
+
+		if _, ok := rwt.written[string(key)]; !ok {
+			rwt.relationshipOrder = append(rwt.relationshipOrder, string(key))
+			rwt.previous[string(key)] = existing
+		}
+
+		if existing != nil {
+			if err := deleteRelationship(rwt.badgerTxn, key, existing); err != nil {
+				return fmt.Errorf(errUnableToWriteRelationships, err)
+			}
+		}
+
+		if mut.Operation == core.RelationTupleUpdate_DELETE {
+			rwt.written[string(key)] = nil
+			continue
+		}
+
+		if err := rwt.setRelationship(key, mut.Tuple); err != nil {
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+		rwt.written[string(key)] = mut.Tuple
+	}
+	return nil
+}
+
+// setRelationship writes the relationship of the key and its index entries.
+func (rwt *badgerReadWriteTXN) setRelationship(key []byte, tpl *core.RelationTuple) error {
+	encoded, err := tpl.MarshalVT()
+	if err != nil {
+		return err
+	}
+	if err := rwt.badgerTxn.Set(key, encoded); err != nil {
+		return err
+	}
+	if err := rwt.badgerTxn.Set(subjectKey(tpl), nil); err != nil {
+		return err
+	}
+	if tpl.OptionalExpirationTime != nil {
+		return rwt.badgerTxn.Set(expirationKey(tpl.OptionalExpirationTime.AsTime().UnixNano(), tpl), nil)
+	}
+	return nil
+}
+
+// deleteRelationship deletes the relationship of the key and its index entries.
+func deleteRelationship(txn *badgerdb.Txn, key []byte, existing *core.RelationTuple) error {
+	if err := txn.Delete(key); err != nil {
+		return err
+	}
+	if err := txn.Delete(subjectKey(existing)); err != nil {
+		return err
+	}
+	if existing.OptionalExpirationTime != nil {
+		return txn.Delete(expirationKey(existing.OptionalExpirationTime.AsTime().UnixNano(), existing))
+	}
+	return nil
+}
+
+// DeleteRelationships deletes the living relationships matching the filter, including those
+// written earlier in the transaction.
+func (rwt *badgerReadWriteTXN) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
+	dsFilter := datastore.RelationshipsFilterFromPublicFilter(filter)
+
+	// The relationships are read before any is deleted, as the iterators of a Badger transaction
+	// do not see the writes made while they are open.
+	var mutations []*core.RelationTupleUpdate
+	err := rwt.scanRelationships(rwt.badgerTxn, dsFilter, func(tpl *core.RelationTuple) bool {
+		if matchesFilter(tpl, dsFilter, nil) {
+			mutations = append(mutations, tuple.Delete(tpl))
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf(errUnableToDeleteRelationships, err)
+	}
+
+	if err := rwt.WriteRelationships(ctx, mutations); err != nil {
+		return fmt.Errorf(errUnableToDeleteRelationships, err)
+	}
+	return nil
+}
+
+func (rwt *badgerReadWriteTXN) WriteNamespaces(_ context.Context, newNamespaces ...*core.NamespaceDefinition) error {
+	for _, newNamespace := range newNamespaces {
+		serialized, err := newNamespace.MarshalVT()
+		if err != nil {
+			return fmt.Errorf(errUnableToWriteConfig, err)
+		}
+		if err := rwt.badgerTxn.Set(namespaceKey(newNamespace.Name), serialized); err != nil {
+			return fmt.Errorf(errUnableToWriteConfig, err)
+		}
+	}
+	return nil
+}
+
+func (rwt *badgerReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+	// Check that each namespace exists before deleting any of them.
+	for _, nsName := range nsNames {
+		_, _, err := rwt.ReadNamespace(ctx, nsName)
+		switch {
+		case errors.As(err, &datastore.ErrNamespaceNotFound{}):
+			return err
+		case err == nil:
+			break
+		default:
+			return fmt.Errorf(errUnableToDeleteConfig, err)
+		}
+	}
+
+	for _, nsName := range nsNames {
+		if err := rwt.badgerTxn.Delete(namespaceKey(nsName)); err != nil {
+			return fmt.Errorf(errUnableToDeleteConfig, err)
+		}
+
+		if err := rwt.DeleteRelationships(ctx, &v1.RelationshipFilter{ResourceType: nsName}); err != nil {
+			return fmt.Errorf(errUnableToDeleteConfig, err)
+		}
+	}
+	return nil
+}
+
+// commit writes the changes of the transaction, the records of the versions it replaced and
+// the record of the transaction, and commits the Badger transaction at the transaction ID.
+func (rwt *badgerReadWriteTXN) commit(ctx context.Context, txnID uint64, committedAt int64) error {
+	_, span := tracer.Start(ctx, "commit")
+	defer span.End()
+
+	now := time.Unix(0, committedAt)
+	seq := 0
+	for _, key := range rwt.relationshipOrder {
+		previous, final := rwt.previous[key], rwt.written[key]
+
+		var change *core.RelationTupleUpdate
+		switch {
+		case final != nil:
+			change = tuple.Touch(final)
+		case previous != nil && !expired(previous, now):
+			change = tuple.Delete(previous)
+		}
+
+		if change != nil {
+			encoded, err := change.MarshalVT()
+			if err != nil {
+				return fmt.Errorf(errUnableToCommit, err)
+			}
+			if err := rwt.badgerTxn.Set(changeKey(txnID, seq), encoded); err != nil {
+				return fmt.Errorf(errUnableToCommit, err)
+			}
+			seq++
+		}
+
+		if previous != nil {
+			if err := rwt.badgerTxn.Set(deletedKey(txnID, previous), nil); err != nil {
+				return fmt.Errorf(errUnableToCommit, err)
+			}
+		}
+	}
+
+	record := transactionRecord{Timestamp: committedAt, Metadata: rwt.metadata}
+	if err := writeTransaction(rwt.badgerTxn, txnID, record); err != nil {
+		return fmt.Errorf(errUnableToCommit, err)
+	}
+
+	if err := rwt.badgerTxn.CommitAt(txnID, nil); err != nil {
+		if errors.Is(err, badgerdb.ErrConflict) {
+			return err
+		}
+		return fmt.Errorf(errUnableToCommit, err)
+	}
+	return nil
+}
+
+var _ datastore.ReadWriteTransaction = &badgerReadWriteTXN{}
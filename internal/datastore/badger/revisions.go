@@ -0,0 +1,120 @@
+package badger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	badgerdb "github.com/dgraph-io/badger/v3"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+const (
+	errRevision      = "unable to find revision: %w"
+	errCheckRevision = "unable to check revision: %w"
+)
+
+func (bds *Datastore) optimizedRevisionFunc(_ context.Context) (datastore.Revision, time.Duration, error) {
+	quantization := bds.revisionQuantization.Nanoseconds()
+	if quantization < 1 {
+		quantization = 1
+	}
+
+	now := time.Now().UnixNano()
+	periodStart := now - now%quantization
+	validFor := time.Duration(quantization - now%quantization)
+
+	// Picks the first transaction at or after the start of the current quantization period, or
+	// the latest transaction if there are none.
+	txn, err := bds.firstTransactionSince(periodStart)
+	if err != nil {
+		return revision.NoRevision, 0, fmt.Errorf(errRevision, err)
+	}
+	return revisionFromTransaction(txn), validFor, nil
+}
+
+func (bds *Datastore) HeadRevision(ctx context.Context) (datastore.Revision, error) {
+	_, span := tracer.Start(ctx, "HeadRevision")
+	defer span.End()
+
+	return revisionFromTransaction(bds.head.Load()), nil
+}
+
+func (bds *Datastore) CheckRevision(ctx context.Context, revisionRaw datastore.Revision) error {
+	if revisionRaw == datastore.NoRevision {
+		return datastore.NewInvalidRevisionErr(revisionRaw, datastore.CouldNotDetermineRevision)
+	}
+
+	rev := revisionRaw.(revision.Decimal)
+	revisionTx := transactionFromRevision(rev)
+
+	_, span := tracer.Start(ctx, "checkValidTransaction")
+	defer span.End()
+
+	// The latest transaction is always fresh enough, even if it is older than the garbage
+	// collection window.
+	head := bds.head.Load()
+	switch {
+	case revisionTx > head:
+		return datastore.NewInvalidRevisionErr(rev, datastore.CouldNotDetermineRevision)
+	case revisionTx == head:
+		return nil
+	}
+
+	// As the commit times of the transactions never decrease, a transaction before the head is
+	// within the window if it committed within the window.
+	txn := bds.db.NewTransactionAt(head, false)
+	defer txn.Discard()
+
+	record, err := readTransaction(txn, revisionTx)
+	switch {
+	case errors.Is(err, badgerdb.ErrKeyNotFound):
+		return datastore.NewInvalidRevisionErr(rev, datastore.RevisionStale)
+	case err != nil:
+		return fmt.Errorf(errCheckRevision, err)
+	}
+
+	if record.Timestamp < time.Now().Add(-bds.gcWindow).UnixNano() {
+		return datastore.NewInvalidRevisionErr(rev, datastore.RevisionStale)
+	}
+	return nil
+}
+
+// firstTransactionSince returns the first transaction which committed at or after the time in
+// nanoseconds, or the head if there is none. The transactions are read from the head back, as
+// the commit times of the transactions never decrease.
+func (bds *Datastore) firstTransactionSince(nanos int64) (uint64, error) {
+	head := bds.head.Load()
+	txn := bds.db.NewTransactionAt(head, false)
+	defer txn.Discard()
+
+	opts := badgerdb.DefaultIteratorOptions
+	opts.Prefix = []byte(transactionPrefix)
+	opts.Reverse = true
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	first := head
+	for it.Seek(transactionKey(head)); it.Valid(); it.Next() {
+		var record transactionRecord
+		if err := it.Item().Value(func(encoded []byte) error {
+			return json.Unmarshal(encoded, &record)
+		}); err != nil {
+			return 0, err
+		}
+		if record.Timestamp < nanos {
+			break
+		}
+
+		txnID, err := transactionOfKey(transactionPrefix, it.Item().Key())
+		if err != nil {
+			return 0, err
+		}
+		first = txnID
+	}
+	return first, nil
+}
@@ -0,0 +1,60 @@
+package badger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	badgerdb "github.com/dgraph-io/badger/v3"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+// Statistics counts the living relationships from their keys at the head, which includes any
+// relationships which have expired but not yet been garbage collected.
+func (bds *Datastore) Statistics(ctx context.Context) (datastore.Stats, error) {
+	head := bds.head.Load()
+	txn := bds.db.NewTransactionAt(head, false)
+	defer txn.Discard()
+
+	uniqueID, _, found, err := readDefinition(txn, []byte(uniqueIDKey))
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to query unique ID: %w", err)
+	}
+	if !found {
+		return datastore.Stats{}, fmt.Errorf("unable to query unique ID: no unique ID found")
+	}
+
+	var count uint64
+	relationshipEstimates := make(map[string]uint64)
+	resources := make(map[string]struct{})
+	subjects := make(map[string]struct{})
+	_, err = scan(txn, []byte(relationshipPrefix), nil, false, func(item *badgerdb.Item) (bool, error) {
+		parts := strings.Split(strings.TrimPrefix(string(item.Key()), relationshipPrefix), separator)
+		if len(parts) != 6 {
+			return true, nil
+		}
+
+		relationshipEstimates[parts[0]]++
+		resources[parts[0]+":"+parts[1]] = struct{}{}
+		subjects[parts[3]+":"+parts[4]] = struct{}{}
+		count++
+		return true, nil
+	})
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to count relationships: %w", err)
+	}
+
+	nsDefs, err := bds.SnapshotReader(revisionFromTransaction(head)).ListNamespaces(ctx)
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to load namespaces: %w", err)
+	}
+
+	return datastore.Stats{
+		UniqueID:                   string(uniqueID),
+		ObjectTypeStatistics:       datastore.ComputeObjectTypeStatsWithEstimates(nsDefs, relationshipEstimates),
+		EstimatedRelationshipCount: count,
+		EstimatedDistinctResources: uint64(len(resources)),
+		EstimatedDistinctSubjects:  uint64(len(subjects)),
+	}, nil
+}
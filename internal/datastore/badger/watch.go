@@ -0,0 +1,153 @@
+package badger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	badgerdb "github.com/dgraph-io/badger/v3"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	watchSleep = 100 * time.Millisecond
+)
+
+// Watch notifies the caller about all changes to tuples.
+//
+// All events following afterRevision will be sent to the caller. Changes are read from the
+// change records written by each transaction as it commits.
+func (bds *Datastore) Watch(ctx context.Context, afterRevisionRaw datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
+	afterRevision := afterRevisionRaw.(revision.Decimal)
+
+	updates := make(chan *datastore.RevisionChanges, bds.watchBufferLength)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(updates)
+		defer close(errs)
+
+		currentTxn := transactionFromRevision(afterRevision)
+
+		lastSent := time.Now()
+
+		for {
+			var stagedUpdates []*datastore.RevisionChanges
+			var err error
+			stagedUpdates, currentTxn, err = bds.loadChanges(ctx, currentTxn)
+			if err != nil {
+				if errors.Is(ctx.Err(), context.Canceled) {
+					errs <- datastore.NewWatchCanceledErr()
+				} else {
+					errs <- err
+				}
+				return
+			}
+
+			// Write the staged updates to the channel
+			for _, changeToWrite := range stagedUpdates {
+				select {
+				case updates <- changeToWrite:
+				default:
+					errs <- datastore.NewWatchDisconnectedErr()
+					return
+				}
+				lastSent = time.Now()
+			}
+
+			// If there were no changes, send a checkpoint if none has been sent within the
+			// interval, and sleep a bit
+			if len(stagedUpdates) == 0 {
+				if time.Since(lastSent) >= common.WatchCheckpointInterval {
+					select {
+					case updates <- &datastore.RevisionChanges{
+						Revision:     revisionFromTransaction(currentTxn),
+						IsCheckpoint: true,
+					}:
+					default:
+						errs <- datastore.NewWatchDisconnectedErr()
+						return
+					}
+					lastSent = time.Now()
+				}
+
+				sleep := time.NewTimer(watchSleep)
+
+				select {
+				case <-sleep.C:
+					break
+				case <-ctx.Done():
+					errs <- datastore.NewWatchCanceledErr()
+					return
+				}
+			}
+		}
+	}()
+
+	return updates, errs
+}
+
+func (bds *Datastore) loadChanges(
+	ctx context.Context,
+	afterRevision uint64,
+) (changes []*datastore.RevisionChanges, newRevision uint64, err error) {
+	newRevision = bds.head.Load()
+	if newRevision <= afterRevision {
+		newRevision = afterRevision
+		return
+	}
+
+	// The changes are read at the head, which every change of the transactions up to the head
+	// is visible at.
+	txn := bds.db.NewTransactionAt(newRevision, false)
+	defer txn.Discard()
+
+	stagedChanges := common.NewChanges()
+	_, err = scan(txn, []byte(transactionPrefix), transactionKey(afterRevision+1), true, func(item *badgerdb.Item) (bool, error) {
+		txnID, err := transactionOfKey(transactionPrefix, item.Key())
+		if err != nil {
+			return false, err
+		}
+
+		var record transactionRecord
+		if err := item.Value(func(encoded []byte) error {
+			return json.Unmarshal(encoded, &record)
+		}); err != nil {
+			return false, err
+		}
+		if len(record.Metadata) > 0 {
+			stagedChanges.SetRevisionMetadata(revisionFromTransaction(txnID), record.Metadata)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return
+	}
+
+	_, err = scan(txn, []byte(changePrefix), transactionScopedKey(changePrefix, afterRevision+1), true, func(item *badgerdb.Item) (bool, error) {
+		txnID, err := transactionOfKey(changePrefix, item.Key())
+		if err != nil {
+			return false, err
+		}
+
+		change := &core.RelationTupleUpdate{}
+		if err := item.Value(change.UnmarshalVT); err != nil {
+			return false, err
+		}
+		withCaveatContext(change.Tuple)
+		stagedChanges.AddChange(ctx, revisionFromTransaction(txnID), change.Tuple, change.Operation)
+		return true, nil
+	})
+	if err != nil {
+		return
+	}
+
+	changes = stagedChanges.AsRevisionChanges(bds)
+
+	return
+}
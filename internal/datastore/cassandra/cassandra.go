@@ -0,0 +1,254 @@
+package cassandra
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/authzed/spicedb/internal/datastore/cassandra/cql"
+	"github.com/authzed/spicedb/internal/datastore/cassandra/migrations"
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/common/revisions"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/internal/datastore/proxy"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+const (
+	Engine = "cassandra"
+
+	tableNamespace             = "namespace_config"
+	tableCaveat                = "caveat"
+	tableRelationship          = "relationship"
+	tableRelationshipBySubject = "relationship_by_subject"
+	tableRelationshipLiving    = "relationship_living"
+	tableChangelog             = "changelog"
+	tableMetadata              = "metadata"
+
+	// The operations recorded in the changelog.
+	changelogTouched = 1
+	changelogDeleted = 2
+
+	// liveRevision reads the latest version of every row, which is read by read/write
+	// transactions.
+	liveRevision = int64(math.MaxInt64)
+
+	errUnableToInstantiate = "unable to instantiate datastore: %w"
+)
+
+var tracer = otel.Tracer("spicedb/internal/datastore/cassandra")
+
+func init() {
+	datastore.Engines = append(datastore.Engines, Engine)
+}
+
+// NewCassandraDatastore creates a new datastore.Datastore backed by the keyspace of the
+// connection URI on a Cassandra or ScyllaDB cluster, which must already have been migrated.
+// Supports customization via the various options available in this package.
+func NewCassandraDatastore(uri string, options ...Option) (datastore.Datastore, error) {
+	ds, err := newCassandraDatastore(uri, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return proxy.NewSeparatingContextDatastoreProxy(ds), nil
+}
+
+func newCassandraDatastore(uri string, options ...Option) (*Datastore, error) {
+	config, err := generateConfig(options)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+
+	sessionConfig, err := cql.ParseURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+
+	session, err := cql.NewSession(sessionConfig)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+
+	if config.enablePrometheusStats {
+		if err := common.RegisterGCMetrics(); err != nil {
+			return nil, fmt.Errorf(errUnableToInstantiate, err)
+		}
+	}
+
+	gcCtx, cancelGc := context.WithCancel(context.Background())
+
+	maxRevisionStaleness := time.Duration(float64(config.revisionQuantization.Nanoseconds())*
+		config.maxRevisionStalenessPercent) * time.Nanosecond
+
+	store := &Datastore{
+		session:           session,
+		driver:            migrations.NewCassandraDriverFromSession(session),
+		gcWindow:          config.gcWindow,
+		gcInterval:        config.gcInterval,
+		gcTimeout:         config.gcMaxOperationTime,
+		gcCtx:             gcCtx,
+		cancelGc:          cancelGc,
+		watchBufferLength: config.watchBufferLength,
+		watchLag:          config.watchLag,
+		writeConcurrency:  config.writeConcurrency,
+		retryPolicy: common.RetryPolicy{
+			MaxRetries:     config.maxRetries,
+			InitialBackoff: config.retryInitialBackoff,
+			MaxBackoff:     config.retryMaxBackoff,
+		},
+		RemoteClockRevisions: revisions.NewRemoteClockRevisions(
+			config.gcWindow,
+			maxRevisionStaleness,
+			0,
+			config.revisionQuantization,
+		),
+	}
+
+	store.SetNowFunc(store.headRevisionInternal)
+
+	// Start a goroutine for garbage collection.
+	if store.gcInterval > 0*time.Minute && config.gcEnabled {
+		store.gcGroup, store.gcCtx = errgroup.WithContext(store.gcCtx)
+		store.gcGroup.Go(func() error {
+			return common.StartGarbageCollector(
+				store.gcCtx,
+				store,
+				store.gcInterval,
+				store.gcWindow,
+				store.gcTimeout,
+			)
+		})
+	} else {
+		log.Warn().Msg("datastore garbage collection disabled")
+	}
+
+	return store, nil
+}
+
+// Datastore is a Cassandra-based implementation of the datastore.Datastore interface
+type Datastore struct {
+	session *cql.Session
+	driver  *migrations.CassandraDriver
+
+	gcWindow          time.Duration
+	gcInterval        time.Duration
+	gcTimeout         time.Duration
+	watchBufferLength uint16
+	watchLag          time.Duration
+	writeConcurrency  int
+	retryPolicy       common.RetryPolicy
+
+	// lastRevisionMicros is the revision most recently assigned to a transaction by this
+	// process, in microseconds.
+	revisionLock       sync.Mutex
+	lastRevisionMicros int64
+
+	gcGroup  *errgroup.Group
+	gcCtx    context.Context
+	cancelGc context.CancelFunc
+
+	*revisions.RemoteClockRevisions
+	revision.DecimalDecoder
+}
+
+// SnapshotReader ignores consistency hints, as every read is made at the consistency level of
+// the connection URI.
+func (cds *Datastore) SnapshotReader(revisionRaw datastore.Revision, _ ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return &cassandraReader{
+		session:  cds.session,
+		revision: revisionRaw.(revision.Decimal).IntPart(),
+	}
+}
+
+// ReadWriteTx starts a read/write transaction, whose writes are applied if no error is returned
+// and discarded if an error is returned.
+//
+// Cassandra has no multi-row transactions, so the writes of the transaction are buffered and
+// applied as it commits: CREATE operations first insert the living row of their relationship
+// with a lightweight transaction, which fails if the relationship exists, and the versions of
+// the relationships and definitions are then written by logged batches, which are eventually
+// applied in full once any of their statements is. The reads of the transaction do not see its
+// own buffered writes.
+func (cds *Datastore) ReadWriteTx(
+	ctx context.Context,
+	fn datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
+) (datastore.Revision, error) {
+	config := options.NewRWTOptionsWithOptions(opts...)
+
+	var newRevision int64
+	_, err := common.RetryTx(ctx, cds.retryPolicy, cql.IsRetryable, func(ctx context.Context) error {
+		rwt := newReadWriteTXN(cds, config.Metadata)
+		if err := fn(rwt); err != nil {
+			return err
+		}
+
+		// The revision is assigned as the transaction commits, so that the changes of the
+		// transaction are written soon after it, within the lag of watches.
+		newRevision = cds.nextRevision()
+		return rwt.commit(ctx, newRevision)
+	})
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+
+	return revisionFromNanos(newRevision), nil
+}
+
+// BulkLoad writes all relationships from the source in a single transaction, whose batches of
+// common.DefaultBulkLoadBatchSize relationships are buffered before it commits.
+func (cds *Datastore) BulkLoad(
+	ctx context.Context,
+	source datastore.BulkWriteRelationshipSource,
+) (datastore.Revision, error) {
+	return cds.ReadWriteTx(ctx, common.SingleAttemptTxFunc(func(rwt datastore.ReadWriteTransaction) error {
+		_, err := common.BulkLoadInBatches(ctx, source, common.DefaultBulkLoadBatchSize, rwt.WriteRelationships)
+		return err
+	}))
+}
+
+// Close closes the data store.
+func (cds *Datastore) Close() error {
+	cds.cancelGc()
+	if cds.gcGroup != nil {
+		if err := cds.gcGroup.Wait(); err != nil {
+			log.Error().Err(err).Msg("error waiting for garbage collector to shutdown")
+		}
+	}
+	return cds.session.Close()
+}
+
+// IsReady returns whether the datastore is ready to accept data, which is once the keyspace has
+// been migrated to the head migration.
+func (cds *Datastore) IsReady(ctx context.Context) (bool, error) {
+	headMigration, err := migrations.Manager.HeadRevision()
+	if err != nil {
+		return false, fmt.Errorf("invalid head migration found for cassandra: %w", err)
+	}
+
+	version, err := cds.driver.Version(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return version == headMigration, nil
+}
+
+func (cds *Datastore) Features(ctx context.Context) (*datastore.Features, error) {
+	return &datastore.Features{
+		Watch:                  datastore.Feature{Enabled: true},
+		WatchCheckpoints:       datastore.Feature{Enabled: true},
+		Caveats:                datastore.Feature{Enabled: true},
+		BulkLoad:               datastore.Feature{Enabled: true},
+		ReverseQuerySort:       datastore.Feature{Enabled: true},
+		RelationshipExpiration: datastore.Feature{Enabled: true},
+	}, nil
+}
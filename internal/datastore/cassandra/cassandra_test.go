@@ -0,0 +1,38 @@
+//go:build ci && docker
+// +build ci,docker
+
+package cassandra
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	testdatastore "github.com/authzed/spicedb/internal/testserver/datastore"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/test"
+)
+
+func TestCassandraDatastore(t *testing.T) {
+	b := testdatastore.RunCassandraForTesting(t, "")
+	test.All(t, test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+		// The watch lag must be shorter than the GC window, which some tests shorten.
+		watchLag := defaultWatchLag
+		if watchLag >= gcWindow {
+			watchLag = gcWindow / 2
+		}
+
+		ds := b.NewDatastore(t, func(engine, uri string) datastore.Datastore {
+			ds, err := NewCassandraDatastore(uri,
+				RevisionQuantization(revisionQuantization),
+				GCWindow(gcWindow),
+				WatchBufferLength(watchBufferLength),
+				WatchLag(watchLag),
+			)
+			require.NoError(t, err)
+			return ds
+		})
+		return ds, nil
+	}))
+}
@@ -0,0 +1,101 @@
+package cassandra
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	errReadCaveat   = "unable to read caveat: %w"
+	errListCaveats  = "unable to list caveats: %w"
+	errWriteCaveats = "unable to write caveats: %w"
+
+	queryCaveatAtRevision = "SELECT name, revision, deleted, definition FROM " + tableCaveat + " WHERE name = ? AND revision <= ? LIMIT 1"
+	queryCaveats          = "SELECT name, revision, deleted, definition FROM " + tableCaveat
+)
+
+func (cr *cassandraReader) ReadCaveatByName(ctx context.Context, name string) (*core.CaveatDefinition, datastore.Revision, error) {
+	version, found, err := cr.readDefinition(ctx, queryCaveatAtRevision, name)
+	if err != nil {
+		return nil, datastore.NoRevision, fmt.Errorf(errReadCaveat, err)
+	}
+	if !found {
+		return nil, datastore.NoRevision, datastore.NewCaveatNameNotFoundErr(name)
+	}
+
+	def := &core.CaveatDefinition{}
+	if err := def.UnmarshalVT(version.serialized); err != nil {
+		return nil, datastore.NoRevision, fmt.Errorf(errReadCaveat, err)
+	}
+	return def, revisionFromNanos(version.revision), nil
+}
+
+func (cr *cassandraReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	versions, err := cr.listDefinitions(ctx, queryCaveats, opts...)
+	if err != nil {
+		return nil, fmt.Errorf(errListCaveats, err)
+	}
+
+	caveats := make([]*core.CaveatDefinition, 0, len(versions))
+	for _, version := range versions {
+		def := &core.CaveatDefinition{}
+		if err := def.UnmarshalVT(version.serialized); err != nil {
+			return nil, fmt.Errorf(errListCaveats, err)
+		}
+		caveats = append(caveats, def)
+	}
+	return caveats, nil
+}
+
+func (cr *cassandraReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	caveats := make([]*core.CaveatDefinition, 0, len(caveatNames))
+	for _, name := range caveatNames {
+		version, found, err := cr.readDefinition(ctx, queryCaveatAtRevision, name)
+		if err != nil {
+			return nil, fmt.Errorf(errListCaveats, err)
+		}
+		if !found {
+			continue
+		}
+
+		def := &core.CaveatDefinition{}
+		if err := def.UnmarshalVT(version.serialized); err != nil {
+			return nil, fmt.Errorf(errListCaveats, err)
+		}
+		caveats = append(caveats, def)
+	}
+	return caveats, nil
+}
+
+func (rwt *cassandraReadWriteTXN) WriteCaveats(_ context.Context, caveats []*core.CaveatDefinition) error {
+	names := make(map[string]struct{}, len(caveats))
+	for _, caveat := range caveats {
+		if _, ok := names[caveat.Name]; ok {
+			return fmt.Errorf(errWriteCaveats, fmt.Errorf("duplicate caveats in input: %s", caveat.Name))
+		}
+		names[caveat.Name] = struct{}{}
+	}
+
+	for _, caveat := range caveats {
+		rwt.bufferCaveat(caveat.Name, caveat)
+	}
+	return nil
+}
+
+func (rwt *cassandraReadWriteTXN) DeleteCaveats(_ context.Context, names []string) error {
+	for _, name := range names {
+		rwt.bufferCaveat(name, nil)
+	}
+	return nil
+}
+
+func (rwt *cassandraReadWriteTXN) bufferCaveat(name string, caveat *core.CaveatDefinition) {
+	if _, ok := rwt.caveats[name]; !ok {
+		rwt.caveatOrder = append(rwt.caveatOrder, name)
+	}
+	rwt.caveats[name] = caveat
+}
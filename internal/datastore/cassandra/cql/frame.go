@@ -0,0 +1,433 @@
+package cql
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The native protocol of Cassandra and ScyllaDB, version 4:
+// https://github.com/apache/cassandra/blob/trunk/doc/native_protocol_v4.spec
+const (
+	protocolVersion  = 0x04
+	responseVersion  = 0x84
+	frameHeaderSize  = 9
+	maxFrameBodySize = 256 << 20
+	frameFlagWarning = 0x08
+
+	opError         = 0x00
+	opStartup       = 0x01
+	opReady         = 0x02
+	opAuthenticate  = 0x03
+	opQuery         = 0x07
+	opResult        = 0x08
+	opBatch         = 0x0d
+	opAuthChallenge = 0x0e
+	opAuthResponse  = 0x0f
+	opAuthSuccess   = 0x10
+
+	resultVoid         = 0x0001
+	resultRows         = 0x0002
+	resultSetKeyspace  = 0x0003
+	resultPrepared     = 0x0004
+	resultSchemaChange = 0x0005
+
+	queryFlagValues            = 0x01
+	queryFlagPageSize          = 0x04
+	queryFlagPagingState       = 0x08
+	queryFlagSerialConsistency = 0x10
+
+	rowsFlagGlobalTablesSpec = 0x0001
+	rowsFlagHasMorePages     = 0x0002
+	rowsFlagNoMetadata       = 0x0004
+
+	batchLogged = 0x00
+	batchQuery  = 0x00
+
+	typeCustom    = 0x0000
+	typeASCII     = 0x0001
+	typeBigint    = 0x0002
+	typeBlob      = 0x0003
+	typeBoolean   = 0x0004
+	typeCounter   = 0x0005
+	typeInt       = 0x0009
+	typeTimestamp = 0x000b
+	typeUUID      = 0x000c
+	typeVarchar   = 0x000d
+	typeTimeUUID  = 0x000f
+	typeList      = 0x0020
+	typeMap       = 0x0021
+	typeSet       = 0x0022
+	typeUDT       = 0x0030
+	typeTuple     = 0x0031
+)
+
+// Consistency is the consistency level at which a statement is executed.
+type Consistency uint16
+
+const (
+	Any         Consistency = 0x0000
+	One         Consistency = 0x0001
+	Two         Consistency = 0x0002
+	Three       Consistency = 0x0003
+	Quorum      Consistency = 0x0004
+	All         Consistency = 0x0005
+	LocalQuorum Consistency = 0x0006
+	EachQuorum  Consistency = 0x0007
+	Serial      Consistency = 0x0008
+	LocalSerial Consistency = 0x0009
+	LocalOne    Consistency = 0x000a
+)
+
+var consistencyNames = map[string]Consistency{
+	"ANY":          Any,
+	"ONE":          One,
+	"TWO":          Two,
+	"THREE":        Three,
+	"QUORUM":       Quorum,
+	"ALL":          All,
+	"LOCAL_QUORUM": LocalQuorum,
+	"EACH_QUORUM":  EachQuorum,
+	"SERIAL":       Serial,
+	"LOCAL_SERIAL": LocalSerial,
+	"LOCAL_ONE":    LocalOne,
+}
+
+// ParseConsistency returns the consistency level of the name, such as "LOCAL_QUORUM".
+func ParseConsistency(name string) (Consistency, error) {
+	consistency, ok := consistencyNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown consistency level %q", name)
+	}
+	return consistency, nil
+}
+
+// The codes of the errors returned by the server which can succeed if retried.
+const (
+	errServer          = 0x0000
+	errUnavailable     = 0x1000
+	errOverloaded      = 0x1001
+	errIsBootstrapping = 0x1002
+	errWriteTimeout    = 0x1100
+	errReadTimeout     = 0x1200
+)
+
+// Error is an error returned by the server.
+type Error struct {
+	Code    int32
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("cql error 0x%04x: %s", e.Code, e.Message)
+}
+
+// IsRetryable returns true if the error is one of the transient errors of a coordinator or its
+// replicas, after which the statement can be retried.
+func IsRetryable(err error) bool {
+	var cqlErr *Error
+	if !errors.As(err, &cqlErr) {
+		return false
+	}
+
+	switch cqlErr.Code {
+	case errUnavailable, errOverloaded, errIsBootstrapping, errWriteTimeout, errReadTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+var errTruncated = errors.New("truncated cql frame")
+
+// frameBuilder appends the notations of the protocol to the body of a frame.
+type frameBuilder []byte
+
+func (b frameBuilder) byte(v byte) frameBuilder {
+	return append(b, v)
+}
+
+func (b frameBuilder) short(v uint16) frameBuilder {
+	return binary.BigEndian.AppendUint16(b, v)
+}
+
+func (b frameBuilder) int(v int32) frameBuilder {
+	return binary.BigEndian.AppendUint32(b, uint32(v))
+}
+
+func (b frameBuilder) string(v string) frameBuilder {
+	return append(b.short(uint16(len(v))), v...)
+}
+
+func (b frameBuilder) longString(v string) frameBuilder {
+	return append(b.int(int32(len(v))), v...)
+}
+
+func (b frameBuilder) bytes(v []byte) frameBuilder {
+	if v == nil {
+		return b.int(-1)
+	}
+	return append(b.int(int32(len(v))), v...)
+}
+
+func (b frameBuilder) stringMap(m map[string]string) frameBuilder {
+	b = b.short(uint16(len(m)))
+	for key, value := range m {
+		b = b.string(key).string(value)
+	}
+	return b
+}
+
+// values appends the values bound to the markers of a statement.
+func (b frameBuilder) values(values []any) (frameBuilder, error) {
+	b = b.short(uint16(len(values)))
+	for i, value := range values {
+		encoded, err := encodeValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode value %d: %w", i, err)
+		}
+		b = b.bytes(encoded)
+	}
+	return b, nil
+}
+
+// encodeValue returns the serialized form of a Go value, or nil for a null.
+func encodeValue(value any) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(v), nil
+	case []byte:
+		if v == nil {
+			return nil, nil
+		}
+		return v, nil
+	case bool:
+		if v {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case int32:
+		return binary.BigEndian.AppendUint32(nil, uint32(v)), nil
+	case int64:
+		return binary.BigEndian.AppendUint64(nil, uint64(v)), nil
+	case time.Time:
+		return binary.BigEndian.AppendUint64(nil, uint64(v.UnixMilli())), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+// frameReader reads the notations of the protocol from the body of a frame.
+type frameReader struct {
+	data []byte
+	err  error
+}
+
+func (r *frameReader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data) {
+		r.err = errTruncated
+		return nil
+	}
+	taken := r.data[:n]
+	r.data = r.data[n:]
+	return taken
+}
+
+func (r *frameReader) short() uint16 {
+	data := r.take(2)
+	if data == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(data)
+}
+
+func (r *frameReader) int() int32 {
+	data := r.take(4)
+	if data == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(data))
+}
+
+func (r *frameReader) string() string {
+	return string(r.take(int(r.short())))
+}
+
+func (r *frameReader) bytes() []byte {
+	length := r.int()
+	if length < 0 {
+		return nil
+	}
+	return r.take(int(length))
+}
+
+// columnType is the type of a column of a result, with the types of its elements or fields for
+// collections, tuples and user-defined types.
+type columnType struct {
+	id       uint16
+	elements []columnType
+}
+
+func (r *frameReader) columnType() columnType {
+	t := columnType{id: r.short()}
+	switch t.id {
+	case typeCustom:
+		r.string()
+	case typeList, typeSet:
+		t.elements = []columnType{r.columnType()}
+	case typeMap:
+		t.elements = []columnType{r.columnType(), r.columnType()}
+	case typeUDT:
+		r.string()
+		r.string()
+		count := r.short()
+		for i := uint16(0); i < count && r.err == nil; i++ {
+			r.string()
+			t.elements = append(t.elements, r.columnType())
+		}
+	case typeTuple:
+		count := r.short()
+		for i := uint16(0); i < count && r.err == nil; i++ {
+			t.elements = append(t.elements, r.columnType())
+		}
+	}
+	return t
+}
+
+// decodeValue returns the Go value of a serialized value of the type. Values of the types which
+// are never read by the datastore are returned as their serialized bytes.
+func decodeValue(t columnType, data []byte) (any, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	switch t.id {
+	case typeASCII, typeVarchar:
+		return string(data), nil
+	case typeBlob:
+		return data, nil
+	case typeBoolean:
+		if len(data) != 1 {
+			return nil, errTruncated
+		}
+		return data[0] != 0, nil
+	case typeInt:
+		if len(data) != 4 {
+			return nil, errTruncated
+		}
+		return int32(binary.BigEndian.Uint32(data)), nil
+	case typeBigint, typeCounter:
+		if len(data) != 8 {
+			return nil, errTruncated
+		}
+		return int64(binary.BigEndian.Uint64(data)), nil
+	case typeTimestamp:
+		if len(data) != 8 {
+			return nil, errTruncated
+		}
+		return time.UnixMilli(int64(binary.BigEndian.Uint64(data))).UTC(), nil
+	case typeUUID, typeTimeUUID:
+		id, err := uuid.FromBytes(data)
+		if err != nil {
+			return nil, err
+		}
+		return id.String(), nil
+	default:
+		return data, nil
+	}
+}
+
+// Rows are the rows returned by a query, with the values of each column as Go values: text as
+// string, bigint as int64, int as int32, blob as []byte, boolean as bool, timestamp as
+// time.Time, UUIDs as string, and null as nil.
+type Rows struct {
+	Columns []string
+	Values  [][]any
+}
+
+// Column returns the index of the column with the name, or -1 if there is none.
+func (r *Rows) Column(name string) int {
+	for i, column := range r.Columns {
+		if column == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// decodeRows decodes the body of a rows result following its kind, and returns the paging state
+// from which the next page is read, if there are more pages.
+func decodeRows(r *frameReader) (*Rows, []byte, error) {
+	flags := r.int()
+	columnCount := int(r.int())
+
+	var pagingState []byte
+	if flags&rowsFlagHasMorePages != 0 {
+		pagingState = r.bytes()
+	}
+	if flags&rowsFlagNoMetadata != 0 {
+		return nil, nil, errors.New("rows result without metadata")
+	}
+	if flags&rowsFlagGlobalTablesSpec != 0 {
+		r.string()
+		r.string()
+	}
+
+	rows := &Rows{Columns: make([]string, 0, columnCount)}
+	types := make([]columnType, 0, columnCount)
+	for i := 0; i < columnCount && r.err == nil; i++ {
+		if flags&rowsFlagGlobalTablesSpec == 0 {
+			r.string()
+			r.string()
+		}
+		rows.Columns = append(rows.Columns, r.string())
+		types = append(types, r.columnType())
+	}
+
+	rowCount := int(r.int())
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	if rowCount < 0 || rowCount > math.MaxInt32/4 {
+		return nil, nil, errTruncated
+	}
+
+	rows.Values = make([][]any, 0, rowCount)
+	for i := 0; i < rowCount; i++ {
+		row := make([]any, columnCount)
+		for j := range row {
+			data := r.bytes()
+			if r.err != nil {
+				return nil, nil, r.err
+			}
+
+			value, err := decodeValue(types[j], data)
+			if err != nil {
+				return nil, nil, fmt.Errorf("unable to decode column %s: %w", rows.Columns[j], err)
+			}
+			row[j] = value
+		}
+		rows.Values = append(rows.Values, row)
+	}
+
+	return rows, pagingState, nil
+}
+
+// decodeError returns the error of the body of an ERROR frame.
+func decodeError(body []byte) error {
+	r := &frameReader{data: body}
+	err := &Error{Code: r.int(), Message: r.string()}
+	if r.err != nil {
+		return r.err
+	}
+	return err
+}
@@ -0,0 +1,148 @@
+package cassandra
+
+import (
+	"context"
+	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/cassandra/cql"
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+const (
+	queryAllRelationships = "SELECT " + relationshipColumns + " FROM " + tableRelationship
+
+	deleteRelationshipVersion          = "DELETE FROM " + tableRelationship + " WHERE " + keyCondition + " AND revision = ?"
+	deleteRelationshipBySubjectVersion = "DELETE FROM " + tableRelationshipBySubject + " WHERE " + keyCondition + " AND revision = ?"
+	deleteExpiredLiving                = "DELETE FROM " + tableRelationshipLiving + " WHERE " + keyCondition + " IF revision = ?"
+)
+
+var (
+	_ common.GarbageCollector               = (*Datastore)(nil)
+	_ datastore.GarbageCollectableDatastore = (*Datastore)(nil)
+)
+
+// Now returns the time of the process, as the revisions are assigned from its clock.
+func (cds *Datastore) Now(_ context.Context) (time.Time, error) {
+	return time.Now().UTC(), nil
+}
+
+// TxIDBefore returns the revision at the time, as revisions are timestamps.
+func (cds *Datastore) TxIDBefore(_ context.Context, before time.Time) (datastore.Revision, error) {
+	return revisionFromNanos(before.UnixNano()), nil
+}
+
+// CountTransactionsBeforeTx returns zero, as no transactions are recorded other than by the
+// changelog, which expires by its time to live.
+func (cds *Datastore) CountTransactionsBeforeTx(_ context.Context, _ datastore.Revision) (int64, error) {
+	return 0, nil
+}
+
+// CollectGarbage runs garbage collection immediately, regardless of whether the background
+// garbage collection is enabled.
+func (cds *Datastore) CollectGarbage(ctx context.Context) (datastore.GarbageCollected, error) {
+	return common.RunGarbageCollection(ctx, cds, cds.gcWindow, cds.gcTimeout)
+}
+
+// DeleteBeforeTx deletes the versions of relationships which can no longer be read at any
+// revision after txID: those replaced by a later version at or before txID, and the latest
+// version at or before txID if it is a deletion or has expired. The changelog is not deleted,
+// as it expires by its time to live, and the prior versions of definitions are retained.
+//
+// Every version of the forward table of relationships is scanned, and each deleted version
+// leaves a tombstone until the table is compacted.
+func (cds *Datastore) DeleteBeforeTx(ctx context.Context, txID datastore.Revision) (removed common.DeletionCounts, err error) {
+	ctx, span := tracer.Start(ctx, "DeleteBeforeTx")
+	defer span.End()
+
+	collector := &garbageCollector{
+		session: cds.session,
+		cutoff:  txID.(revision.Decimal).IntPart(),
+		now:     time.Now(),
+	}
+	if err = cds.session.Iterate(ctx, queryAllRelationships, nil, func(rows *cql.Rows) error {
+		return collector.collect(ctx, rows)
+	}); err != nil {
+		return
+	}
+
+	err = collector.flush(ctx)
+	removed.Relationships = collector.removed
+	return
+}
+
+// garbageCollector deletes the collectable versions of the rows of the forward table of
+// relationships, which are read with the versions of each relationship in descending order
+// of their revisions.
+type garbageCollector struct {
+	session *cql.Session
+	cutoff  int64
+	now     time.Time
+
+	// The relationship whose versions are being read, whether its latest version has been
+	// read, and whether a version at or before the cutoff has been read.
+	current    relationshipKey
+	seenLatest bool
+	seenCutoff bool
+
+	pending []cql.Statement
+	removed int64
+}
+
+func (gc *garbageCollector) collect(ctx context.Context, rows *cql.Rows) error {
+	for _, row := range rows.Values {
+		version, err := relationshipVersionFrom(row)
+		if err != nil {
+			return err
+		}
+
+		key := keyOf(version.tuple)
+		isLatest := !gc.seenLatest || key != gc.current
+		if isLatest {
+			gc.current = key
+			gc.seenLatest = true
+			gc.seenCutoff = false
+		}
+		if version.revision > gc.cutoff {
+			continue
+		}
+
+		// The versions before the first at or before the cutoff are never read, and the first
+		// is only read if it has neither been deleted nor expired.
+		collectable := gc.seenCutoff || version.deleted || version.expired(gc.now)
+		gc.seenCutoff = true
+		if !collectable {
+			continue
+		}
+
+		values := append(keyValues(version.tuple), version.revision)
+		gc.pending = append(gc.pending,
+			cql.Statement{Query: deleteRelationshipVersion, Values: values},
+			cql.Statement{Query: deleteRelationshipBySubjectVersion, Values: values},
+		)
+		gc.removed++
+
+		// The living row of an expired relationship is deleted unless it has been recreated.
+		if isLatest && !version.deleted {
+			if _, _, err := gc.session.ExecCAS(ctx, deleteExpiredLiving, values...); err != nil {
+				return err
+			}
+		}
+
+		if len(gc.pending) >= 4*relationshipsPerBatch {
+			if err := gc.flush(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (gc *garbageCollector) flush(ctx context.Context) error {
+	if err := gc.session.Batch(ctx, gc.pending); err != nil {
+		return err
+	}
+	gc.pending = gc.pending[:0]
+	return nil
+}
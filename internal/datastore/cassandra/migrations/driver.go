@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/authzed/spicedb/internal/datastore/cassandra/cql"
+	"github.com/authzed/spicedb/pkg/migrate"
+)
+
+const (
+	errUnableToInstantiate = "unable to instantiate CassandraDriver: %w"
+
+	queryMigrationTableExists = "SELECT table_name FROM system_schema.tables WHERE keyspace_name = ? AND table_name = ?"
+	queryVersion              = "SELECT version_num FROM " + tableMigrationVersion + " WHERE id = 0"
+	updateVersion             = "UPDATE " + tableMigrationVersion + " SET version_num = ? WHERE id = 0 IF version_num = ?"
+)
+
+// CassandraDriver is an implementation of migrate.Driver for Cassandra and ScyllaDB
+type CassandraDriver struct {
+	session *cql.Session
+}
+
+// NewCassandraDriver creates a new migration driver for the keyspace of the connection URI. The
+// keyspace must already exist, as its replication is chosen by the operator of the cluster.
+func NewCassandraDriver(uri string) (*CassandraDriver, error) {
+	config, err := cql.ParseURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+
+	session, err := cql.NewSession(config)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+	return NewCassandraDriverFromSession(session), nil
+}
+
+// NewCassandraDriverFromSession creates a new migration driver with a session specified upfront.
+func NewCassandraDriverFromSession(session *cql.Session) *CassandraDriver {
+	return &CassandraDriver{session}
+}
+
+// Version returns the version of the schema to which the connected keyspace
+// has been migrated.
+func (driver *CassandraDriver) Version(ctx context.Context) (string, error) {
+	tables, err := driver.session.Query(ctx, queryMigrationTableExists, driver.session.Keyspace(), tableMigrationVersion)
+	if err != nil {
+		return "", fmt.Errorf("unable to load driver migration revision: %w", err)
+	}
+	if len(tables.Values) == 0 {
+		return "", nil
+	}
+
+	rows, err := driver.session.Query(ctx, queryVersion)
+	if err != nil {
+		return "", fmt.Errorf("unable to load driver migration revision: %w", err)
+	}
+	if len(rows.Values) == 0 {
+		return "", nil
+	}
+
+	loaded, _ := rows.Values[0][0].(string)
+	return loaded, nil
+}
+
+// Conn returns the underlying session of the driver.
+func (driver *CassandraDriver) Conn() *cql.Session {
+	return driver.session
+}
+
+// RunTx runs the function with the session of the driver, as Cassandra has no transactions.
+func (driver *CassandraDriver) RunTx(ctx context.Context, f migrate.TxMigrationFunc[*cql.Session]) error {
+	return f(ctx, driver.session)
+}
+
+// WriteVersion replaces the version of the keyspace schema, if it is still the replaced version.
+func (driver *CassandraDriver) WriteVersion(ctx context.Context, session *cql.Session, version, replaced string) error {
+	applied, _, err := session.ExecCAS(ctx, updateVersion, version, replaced)
+	if err != nil {
+		return fmt.Errorf("unable to update version row: %w", err)
+	}
+	if !applied {
+		return fmt.Errorf("version row is no longer %q", replaced)
+	}
+	return nil
+}
+
+func (driver *CassandraDriver) Close(_ context.Context) error {
+	return driver.session.Close()
+}
+
+var _ migrate.Driver[*cql.Session, *cql.Session] = &CassandraDriver{}
@@ -0,0 +1,15 @@
+package migrations
+
+import (
+	"github.com/authzed/spicedb/internal/datastore/cassandra/cql"
+	"github.com/authzed/spicedb/pkg/migrate"
+)
+
+const tableMigrationVersion = "migration_version"
+
+// Cassandra has no transactions, so every migration is non-atomic, and the version is replaced
+// by a lightweight transaction once it has run.
+var noTxMigration migrate.TxMigrationFunc[*cql.Session]
+
+// Manager is the singleton migration manager instance for Cassandra
+var Manager = migrate.NewManager[*CassandraDriver, *cql.Session, *cql.Session]()
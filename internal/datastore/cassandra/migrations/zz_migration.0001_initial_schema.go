@@ -0,0 +1,141 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/authzed/spicedb/internal/datastore/cassandra/cql"
+)
+
+// Revisions are stored as bigint nanoseconds since the Unix epoch. Every version of a definition
+// or relationship is a row clustered by its revision, newest first, so that the version of a
+// revision is the first row at or before it.
+const createNamespaceConfig = `CREATE TABLE IF NOT EXISTS namespace_config (
+	namespace text,
+	revision bigint,
+	deleted boolean,
+	serialized_config blob,
+	PRIMARY KEY ((namespace), revision)
+) WITH CLUSTERING ORDER BY (revision DESC)`
+
+const createCaveat = `CREATE TABLE IF NOT EXISTS caveat (
+	name text,
+	revision bigint,
+	deleted boolean,
+	definition blob,
+	PRIMARY KEY ((name), revision)
+) WITH CLUSTERING ORDER BY (revision DESC)`
+
+// The relationships are denormalized into a forward table partitioned by resource and a reverse
+// table partitioned by subject, each holding every version of a relationship.
+const createRelationship = `CREATE TABLE IF NOT EXISTS relationship (
+	namespace text,
+	object_id text,
+	relation text,
+	subject_namespace text,
+	subject_object_id text,
+	subject_relation text,
+	revision bigint,
+	deleted boolean,
+	caveat_name text,
+	caveat_context blob,
+	expiration bigint,
+	metadata blob,
+	PRIMARY KEY ((namespace, object_id), relation, subject_namespace, subject_object_id, subject_relation, revision)
+) WITH CLUSTERING ORDER BY (relation ASC, subject_namespace ASC, subject_object_id ASC, subject_relation ASC, revision DESC)`
+
+const createRelationshipBySubject = `CREATE TABLE IF NOT EXISTS relationship_by_subject (
+	subject_namespace text,
+	subject_object_id text,
+	subject_relation text,
+	namespace text,
+	relation text,
+	object_id text,
+	revision bigint,
+	deleted boolean,
+	caveat_name text,
+	caveat_context blob,
+	expiration bigint,
+	metadata blob,
+	PRIMARY KEY ((subject_namespace, subject_object_id), subject_relation, namespace, relation, object_id, revision)
+) WITH CLUSTERING ORDER BY (subject_relation ASC, namespace ASC, relation ASC, object_id ASC, revision DESC)`
+
+// The living relationships hold a single row per relationship, which is the target of the
+// lightweight transactions of CREATE operations.
+const createRelationshipLiving = `CREATE TABLE IF NOT EXISTS relationship_living (
+	namespace text,
+	object_id text,
+	relation text,
+	subject_namespace text,
+	subject_object_id text,
+	subject_relation text,
+	revision bigint,
+	expiration bigint,
+	PRIMARY KEY ((namespace, object_id), relation, subject_namespace, subject_object_id, subject_relation)
+)`
+
+// The changelog is partitioned into buckets of time, so that a watch reads a single partition at
+// a time.
+const createChangelog = `CREATE TABLE IF NOT EXISTS changelog (
+	bucket bigint,
+	revision bigint,
+	seq int,
+	operation int,
+	namespace text,
+	object_id text,
+	relation text,
+	subject_namespace text,
+	subject_object_id text,
+	subject_relation text,
+	caveat_name text,
+	caveat_context blob,
+	expiration bigint,
+	metadata blob,
+	transaction_metadata blob,
+	PRIMARY KEY ((bucket), revision, seq)
+) WITH CLUSTERING ORDER BY (revision ASC, seq ASC)`
+
+const createMetadata = `CREATE TABLE IF NOT EXISTS metadata (
+	id int PRIMARY KEY,
+	unique_id text
+)`
+
+const insertUniqueID = `INSERT INTO metadata (id, unique_id) VALUES (0, ?) IF NOT EXISTS`
+
+const createMigrationVersion = `CREATE TABLE IF NOT EXISTS migration_version (
+	id int PRIMARY KEY,
+	version_num text
+)`
+
+const insertEmptyVersion = `INSERT INTO migration_version (id, version_num) VALUES (0, '') IF NOT EXISTS`
+
+func init() {
+	if err := Manager.Register("initial", "", func(ctx context.Context, session *cql.Session) error {
+		// Schema changes are not transactional, so each statement can be run again after a
+		// migration fails part way.
+		statements := []string{
+			createNamespaceConfig,
+			createCaveat,
+			createRelationship,
+			createRelationshipBySubject,
+			createRelationshipLiving,
+			createChangelog,
+			createMetadata,
+			createMigrationVersion,
+		}
+		for _, stmt := range statements {
+			if err := session.Exec(ctx, stmt); err != nil {
+				return err
+			}
+		}
+
+		if _, _, err := session.ExecCAS(ctx, insertUniqueID, uuid.NewString()); err != nil {
+			return err
+		}
+		_, _, err := session.ExecCAS(ctx, insertEmptyVersion)
+		return err
+	}, noTxMigration); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
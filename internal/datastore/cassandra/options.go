@@ -0,0 +1,220 @@
+package cassandra
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+)
+
+const (
+	errQuantizationTooLarge = "revision quantization interval (%s) must be less than GC window (%s)"
+	errWatchLagTooLarge     = "watch lag (%s) must be less than GC window (%s)"
+
+	defaultGarbageCollectionWindow           = 24 * time.Hour
+	defaultGarbageCollectionInterval         = time.Minute * 3
+	defaultGarbageCollectionMaxOperationTime = time.Minute
+	defaultWatchBufferLength                 = 128
+	defaultWatchLag                          = time.Second
+	defaultWriteConcurrency                  = 16
+	defaultQuantization                      = 5 * time.Second
+	defaultMaxRevisionStalenessPercent       = 0.1
+	defaultEnablePrometheusStats             = false
+	defaultMaxRetries                        = 8
+	defaultGCEnabled                         = true
+)
+
+type cassandraOptions struct {
+	revisionQuantization        time.Duration
+	gcWindow                    time.Duration
+	gcInterval                  time.Duration
+	gcMaxOperationTime          time.Duration
+	maxRevisionStalenessPercent float64
+	watchBufferLength           uint16
+	watchLag                    time.Duration
+	writeConcurrency            int
+	enablePrometheusStats       bool
+	maxRetries                  uint8
+	retryInitialBackoff         time.Duration
+	retryMaxBackoff             time.Duration
+	gcEnabled                   bool
+}
+
+// Option provides the facility to configure how the Cassandra datastore accesses
+// its keyspace.
+type Option func(*cassandraOptions)
+
+func generateConfig(options []Option) (cassandraOptions, error) {
+	computed := cassandraOptions{
+		gcWindow:                    defaultGarbageCollectionWindow,
+		gcInterval:                  defaultGarbageCollectionInterval,
+		gcMaxOperationTime:          defaultGarbageCollectionMaxOperationTime,
+		watchBufferLength:           defaultWatchBufferLength,
+		watchLag:                    defaultWatchLag,
+		writeConcurrency:            defaultWriteConcurrency,
+		revisionQuantization:        defaultQuantization,
+		maxRevisionStalenessPercent: defaultMaxRevisionStalenessPercent,
+		enablePrometheusStats:       defaultEnablePrometheusStats,
+		maxRetries:                  defaultMaxRetries,
+		retryInitialBackoff:         common.DefaultRetryInitialBackoff,
+		retryMaxBackoff:             common.DefaultRetryMaxBackoff,
+		gcEnabled:                   defaultGCEnabled,
+	}
+
+	for _, option := range options {
+		option(&computed)
+	}
+
+	// Run any checks on the config that need to be done
+	if computed.revisionQuantization >= computed.gcWindow {
+		return computed, fmt.Errorf(
+			errQuantizationTooLarge,
+			computed.revisionQuantization,
+			computed.gcWindow,
+		)
+	}
+
+	if computed.watchLag >= computed.gcWindow {
+		return computed, fmt.Errorf(errWatchLagTooLarge, computed.watchLag, computed.gcWindow)
+	}
+
+	if computed.writeConcurrency < 1 {
+		computed.writeConcurrency = 1
+	}
+
+	return computed, nil
+}
+
+// WatchBufferLength is the number of entries that can be stored in the watch
+// buffer while awaiting read by the client.
+//
+// This value defaults to 128.
+func WatchBufferLength(watchBufferLength uint16) Option {
+	return func(co *cassandraOptions) {
+		co.watchBufferLength = watchBufferLength
+	}
+}
+
+// WatchLag is how far behind the current time watches read the changelog.
+// The revision of a transaction is assigned as it commits, before its changes
+// are written, so a watch only sends the changes of revisions older than the
+// lag, by which time they are expected to have been written. It must exceed
+// the time taken to commit a transaction, including the clock skew between
+// the SpiceDB instances.
+//
+// This value defaults to 1 second.
+func WatchLag(lag time.Duration) Option {
+	return func(co *cassandraOptions) {
+		co.watchLag = lag
+	}
+}
+
+// WriteConcurrency is the number of lightweight transactions run concurrently
+// as a transaction which creates relationships commits.
+//
+// This value defaults to 16.
+func WriteConcurrency(concurrency int) Option {
+	return func(co *cassandraOptions) {
+		co.writeConcurrency = concurrency
+	}
+}
+
+// RevisionQuantization is the time bucket size to which advertised
+// revisions will be rounded.
+//
+// This value defaults to 5 seconds.
+func RevisionQuantization(quantization time.Duration) Option {
+	return func(co *cassandraOptions) {
+		co.revisionQuantization = quantization
+	}
+}
+
+// MaxRevisionStalenessPercent is the amount of time, expressed as a percentage of
+// the revision quantization window, that a previously computed rounded revision
+// can still be advertised after the next rounded revision would otherwise be ready.
+//
+// This value defaults to 0.1 (10%).
+func MaxRevisionStalenessPercent(stalenessPercent float64) Option {
+	return func(co *cassandraOptions) {
+		co.maxRevisionStalenessPercent = stalenessPercent
+	}
+}
+
+// GCWindow is the maximum age of a passed revision that will be considered
+// valid. The changelog is written with it as its time to live.
+//
+// This value defaults to 24 hours.
+func GCWindow(window time.Duration) Option {
+	return func(co *cassandraOptions) {
+		co.gcWindow = window
+	}
+}
+
+// GCInterval is the interval at which garbage collection will occur.
+//
+// This value defaults to 3 minutes.
+func GCInterval(interval time.Duration) Option {
+	return func(co *cassandraOptions) {
+		co.gcInterval = interval
+	}
+}
+
+// GCMaxOperationTime is the maximum operation time of a garbage collection
+// pass before it times out.
+//
+// This value defaults to 1 minute.
+func GCMaxOperationTime(time time.Duration) Option {
+	return func(co *cassandraOptions) {
+		co.gcMaxOperationTime = time
+	}
+}
+
+// GCEnabled indicates whether garbage collection is enabled.
+//
+// GC is enabled by default.
+func GCEnabled(isGCEnabled bool) Option {
+	return func(co *cassandraOptions) {
+		co.gcEnabled = isGCEnabled
+	}
+}
+
+// MaxRetries is the maximum number of times a transaction which failed with
+// a timeout or an unavailable replica will be client-side retried.
+//
+// Default: 8
+func MaxRetries(maxRetries uint8) Option {
+	return func(co *cassandraOptions) {
+		co.maxRetries = maxRetries
+	}
+}
+
+// RetryInitialBackoff is the wait before the first client-side retry of a
+// transaction, which doubles with each further retry up to RetryMaxBackoff.
+// Zero retries immediately.
+//
+// Default: 10ms
+func RetryInitialBackoff(backoff time.Duration) Option {
+	return func(co *cassandraOptions) {
+		co.retryInitialBackoff = backoff
+	}
+}
+
+// RetryMaxBackoff is the longest wait between client-side retries of a
+// transaction.
+//
+// Default: 1s
+func RetryMaxBackoff(backoff time.Duration) Option {
+	return func(co *cassandraOptions) {
+		co.retryMaxBackoff = backoff
+	}
+}
+
+// WithEnablePrometheusStats marks whether Prometheus metrics of garbage
+// collection are enabled.
+//
+// Prometheus metrics are disabled by default.
+func WithEnablePrometheusStats(enablePrometheusStats bool) Option {
+	return func(co *cassandraOptions) {
+		co.enablePrometheusStats = enablePrometheusStats
+	}
+}
@@ -0,0 +1,344 @@
+package cassandra
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/authzed/spicedb/internal/datastore/cassandra/cql"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	errUnableToReadConfig         = "unable to read namespace config: %w"
+	errUnableToListNamespaces     = "unable to list namespaces: %w"
+	errUnableToQueryTuples        = "unable to query tuples: %w"
+	errUnableToCountRelationships = "unable to count relationships: %w"
+	errUnableToListResourceTypes  = "unable to list resource types: %w"
+
+	queryRelationshipsByResource     = "SELECT " + relationshipColumns + " FROM " + tableRelationship + " WHERE namespace = ? AND object_id = ?"
+	queryRelationshipsByResourceType = "SELECT " + relationshipColumns + " FROM " + tableRelationship + " WHERE namespace = ?"
+	queryRelationships               = "SELECT " + relationshipColumns + " FROM " + tableRelationship
+	queryRelationshipsBySubject      = "SELECT " + relationshipColumns + " FROM " + tableRelationshipBySubject + " WHERE subject_namespace = ? AND subject_object_id = ?"
+	queryRelationshipsBySubjectType  = "SELECT " + relationshipColumns + " FROM " + tableRelationshipBySubject + " WHERE subject_namespace = ?"
+	andRelation                      = " AND relation = ?"
+	allowFiltering                   = " ALLOW FILTERING"
+	queryNamespaceAtRevision         = "SELECT namespace, revision, deleted, serialized_config FROM " + tableNamespace + " WHERE namespace = ? AND revision <= ? LIMIT 1"
+	queryNamespaces                  = "SELECT namespace, revision, deleted, serialized_config FROM " + tableNamespace
+)
+
+// cassandraReader reads the versions of rows at or before its revision. Reads filter the versions
+// of relationships and definitions in the process, as Cassandra can only restrict the clustering
+// columns of a query by a prefix.
+type cassandraReader struct {
+	session  *cql.Session
+	revision int64
+}
+
+func (cr *cassandraReader) QueryRelationships(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	ctx, span := tracer.Start(ctx, "QueryRelationships")
+	defer span.End()
+
+	queryOpts := options.NewQueryOptionsWithOptions(opts...)
+
+	relationships, err := cr.loadRelationships(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToQueryTuples, err)
+	}
+
+	matched := relationships[:0]
+	for _, tpl := range relationships {
+		if matchesFilter(tpl, filter, queryOpts.Usersets) {
+			matched = append(matched, tpl)
+		}
+	}
+	return datastore.NewSliceRelationshipIterator(limited(matched, queryOpts.Limit)), nil
+}
+
+func (cr *cassandraReader) ReverseQueryRelationships(
+	ctx context.Context,
+	subjectsFilter datastore.SubjectsFilter,
+	opts ...options.ReverseQueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	ctx, span := tracer.Start(ctx, "ReverseQueryRelationships")
+	defer span.End()
+
+	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
+
+	filter := datastore.RelationshipsFilter{OptionalSubjectsFilter: &subjectsFilter}
+	if queryOpts.ResRelation != nil {
+		filter.ResourceType = queryOpts.ResRelation.Namespace
+		filter.OptionalResourceRelation = queryOpts.ResRelation.Relation
+	}
+
+	relationships, err := cr.loadRelationshipsBySubject(ctx, subjectsFilter)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToQueryTuples, err)
+	}
+
+	matched := relationships[:0]
+	for _, tpl := range relationships {
+		if matchesFilter(tpl, filter, nil) {
+			matched = append(matched, tpl)
+		}
+	}
+
+	// The partitions of the reverse table are read in the order of their tokens, so the
+	// relationships are sorted once they have all been read.
+	if queryOpts.ReverseSort == options.BySubject {
+		sortBySubject(matched)
+		if queryOpts.ReverseAfter != nil {
+			matched = afterCursor(matched, queryOpts.ReverseAfter)
+		}
+	}
+
+	return datastore.NewSliceRelationshipIterator(limited(matched, queryOpts.ReverseLimit)), nil
+}
+
+func (cr *cassandraReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	ctx, span := tracer.Start(ctx, "CountRelationships")
+	defer span.End()
+
+	relationships, err := cr.loadRelationships(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+
+	var count uint64
+	for _, tpl := range relationships {
+		if matchesFilter(tpl, filter, nil) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (cr *cassandraReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "ListResourceTypes")
+	defer span.End()
+
+	relationships, err := cr.loadRelationships(ctx, datastore.RelationshipsFilter{})
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+
+	seen := map[string]struct{}{}
+	var resourceTypes []string
+	for _, tpl := range relationships {
+		if _, ok := seen[tpl.ResourceAndRelation.Namespace]; ok {
+			continue
+		}
+		seen[tpl.ResourceAndRelation.Namespace] = struct{}{}
+		resourceTypes = append(resourceTypes, tpl.ResourceAndRelation.Namespace)
+	}
+	sort.Strings(resourceTypes)
+	return resourceTypes, nil
+}
+
+// loadRelationships reads the relationships which may match the filter, from the partitions of
+// its resources or subjects if it has IDs, or else by scanning the relationships of the resource
+// type.
+func (cr *cassandraReader) loadRelationships(ctx context.Context, filter datastore.RelationshipsFilter) ([]*core.RelationTuple, error) {
+	var statements []cql.Statement
+	switch {
+	case len(filter.OptionalResourceIds) > 0:
+		for _, resourceID := range filter.OptionalResourceIds {
+			statement := cql.Statement{Query: queryRelationshipsByResource, Values: []any{filter.ResourceType, resourceID}}
+			if filter.OptionalResourceRelation != "" {
+				statement.Query += andRelation
+				statement.Values = append(statement.Values, filter.OptionalResourceRelation)
+			}
+			statements = append(statements, statement)
+		}
+
+	case filter.OptionalSubjectsFilter != nil && len(filter.OptionalSubjectsFilter.OptionalSubjectIds) > 0:
+		return cr.loadRelationshipsBySubject(ctx, *filter.OptionalSubjectsFilter)
+
+	case filter.ResourceType != "":
+		statement := cql.Statement{Query: queryRelationshipsByResourceType, Values: []any{filter.ResourceType}}
+		if filter.OptionalResourceRelation != "" {
+			statement.Query += andRelation
+			statement.Values = append(statement.Values, filter.OptionalResourceRelation)
+		}
+		statement.Query += allowFiltering
+		statements = append(statements, statement)
+
+	default:
+		statements = append(statements, cql.Statement{Query: queryRelationships})
+	}
+
+	return cr.relationshipsOf(ctx, statements)
+}
+
+// loadRelationshipsBySubject reads the relationships which may match the filter from the reverse
+// table.
+func (cr *cassandraReader) loadRelationshipsBySubject(ctx context.Context, filter datastore.SubjectsFilter) ([]*core.RelationTuple, error) {
+	var statements []cql.Statement
+	if len(filter.OptionalSubjectIds) > 0 {
+		for _, subjectID := range filter.OptionalSubjectIds {
+			statements = append(statements, cql.Statement{
+				Query:  queryRelationshipsBySubject,
+				Values: []any{filter.SubjectType, subjectID},
+			})
+		}
+	} else {
+		statements = append(statements, cql.Statement{
+			Query:  queryRelationshipsBySubjectType + allowFiltering,
+			Values: []any{filter.SubjectType},
+		})
+	}
+
+	return cr.relationshipsOf(ctx, statements)
+}
+
+func (cr *cassandraReader) relationshipsOf(ctx context.Context, statements []cql.Statement) ([]*core.RelationTuple, error) {
+	pages := make([]*cql.Rows, 0, len(statements))
+	for _, statement := range statements {
+		rows, err := cr.session.Query(ctx, statement.Query, statement.Values...)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, rows)
+	}
+	return relationshipsAt(pages, cr.revision)
+}
+
+// definitionVersion is a row of the namespace or caveat table, which is the version of a
+// definition written at a revision.
+type definitionVersion struct {
+	name       string
+	revision   int64
+	deleted    bool
+	serialized []byte
+}
+
+func definitionVersionFrom(row []any) definitionVersion {
+	serialized, _ := row[3].([]byte)
+	return definitionVersion{
+		name:       stringValue(row[0]),
+		revision:   int64Value(row[1]),
+		deleted:    boolValue(row[2]),
+		serialized: serialized,
+	}
+}
+
+// readDefinition returns the version of a definition at the revision, or false if it did not
+// exist at the revision.
+func (cr *cassandraReader) readDefinition(ctx context.Context, query, name string) (definitionVersion, bool, error) {
+	rows, err := cr.session.Query(ctx, query, name, cr.revision)
+	if err != nil {
+		return definitionVersion{}, false, err
+	}
+	if len(rows.Values) == 0 {
+		return definitionVersion{}, false, nil
+	}
+
+	version := definitionVersionFrom(rows.Values[0])
+	return version, !version.deleted, nil
+}
+
+// listDefinitions returns the versions of the definitions which existed at the revision, in
+// order of their names, and paged by the options.
+func (cr *cassandraReader) listDefinitions(ctx context.Context, query string, opts ...options.ListOptionsOption) ([]definitionVersion, error) {
+	rows, err := cr.session.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]definitionVersion)
+	for _, row := range rows.Values {
+		version := definitionVersionFrom(row)
+		if version.revision > cr.revision {
+			continue
+		}
+		if found, ok := latest[version.name]; !ok || version.revision > found.revision {
+			latest[version.name] = version
+		}
+	}
+
+	listOpts := options.NewListOptionsWithOptions(opts...)
+	versions := make([]definitionVersion, 0, len(latest))
+	for name, version := range latest {
+		if !version.deleted && name > listOpts.ListAfter {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].name < versions[j].name
+	})
+
+	if listOpts.ListLimit != nil && uint64(len(versions)) > *listOpts.ListLimit {
+		versions = versions[:*listOpts.ListLimit]
+	}
+	return versions, nil
+}
+
+func (cr *cassandraReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
+	ctx, span := tracer.Start(ctx, "ReadNamespace")
+	defer span.End()
+
+	version, found, err := cr.readDefinition(ctx, queryNamespaceAtRevision, nsName)
+	if err != nil {
+		return nil, datastore.NoRevision, fmt.Errorf(errUnableToReadConfig, err)
+	}
+	if !found {
+		return nil, datastore.NoRevision, datastore.NewNamespaceNotFoundErr(nsName)
+	}
+
+	loaded := &core.NamespaceDefinition{}
+	if err := loaded.UnmarshalVT(version.serialized); err != nil {
+		return nil, datastore.NoRevision, fmt.Errorf(errUnableToReadConfig, err)
+	}
+	return loaded, revisionFromNanos(version.revision), nil
+}
+
+func (cr *cassandraReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	ctx, span := tracer.Start(ctx, "ListNamespaces")
+	defer span.End()
+
+	versions, err := cr.listDefinitions(ctx, queryNamespaces, opts...)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListNamespaces, err)
+	}
+
+	nsDefs := make([]*core.NamespaceDefinition, 0, len(versions))
+	for _, version := range versions {
+		loaded := &core.NamespaceDefinition{}
+		if err := loaded.UnmarshalVT(version.serialized); err != nil {
+			return nil, fmt.Errorf(errUnableToListNamespaces, err)
+		}
+		nsDefs = append(nsDefs, loaded)
+	}
+	return nsDefs, nil
+}
+
+func (cr *cassandraReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
+	ctx, span := tracer.Start(ctx, "LookupNamespaces")
+	defer span.End()
+
+	nsDefs := make([]*core.NamespaceDefinition, 0, len(nsNames))
+	for _, nsName := range nsNames {
+		version, found, err := cr.readDefinition(ctx, queryNamespaceAtRevision, nsName)
+		if err != nil {
+			return nil, fmt.Errorf(errUnableToReadConfig, err)
+		}
+		if !found {
+			continue
+		}
+
+		loaded := &core.NamespaceDefinition{}
+		if err := loaded.UnmarshalVT(version.serialized); err != nil {
+			return nil, fmt.Errorf(errUnableToReadConfig, err)
+		}
+		nsDefs = append(nsDefs, loaded)
+	}
+	return nsDefs, nil
+}
+
+var _ datastore.Reader = &cassandraReader{}
@@ -0,0 +1,434 @@
+package cassandra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/authzed/spicedb/internal/datastore/cassandra/cql"
+	"github.com/authzed/spicedb/internal/datastore/common"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+const (
+	errUnableToWriteRelationships  = "unable to write relationships: %w"
+	errUnableToDeleteRelationships = "unable to delete relationships: %w"
+	errUnableToWriteConfig         = "unable to write namespace config: %w"
+	errUnableToDeleteConfig        = "unable to delete namespace config: %w"
+	errUnableToCommit              = "unable to commit transaction: %w"
+
+	// relationshipsPerBatch is the number of relationship updates written by each logged batch,
+	// each of which is four statements, so that batches stay below the size at which Cassandra
+	// rejects them.
+	relationshipsPerBatch = 16
+
+	// rollbackTimeout bounds the lightweight transactions which undo the relationships created
+	// by a transaction which failed, which are run even if its context has ended.
+	rollbackTimeout = 10 * time.Second
+
+	// maxTTLSeconds is the longest time to live accepted by Cassandra.
+	maxTTLSeconds = 630720000
+
+	keyColumns   = "namespace, object_id, relation, subject_namespace, subject_object_id, subject_relation"
+	keyCondition = "namespace = ? AND object_id = ? AND relation = ? AND subject_namespace = ? AND subject_object_id = ? AND subject_relation = ?"
+
+	insertRelationship          = "INSERT INTO " + tableRelationship + " (" + relationshipColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) USING TIMESTAMP ?"
+	insertRelationshipBySubject = "INSERT INTO " + tableRelationshipBySubject + " (" + relationshipColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) USING TIMESTAMP ?"
+	insertLiving                = "INSERT INTO " + tableRelationshipLiving + " (" + keyColumns + ", revision, expiration) VALUES (?, ?, ?, ?, ?, ?, ?, ?) USING TIMESTAMP ?"
+	deleteLiving                = "DELETE FROM " + tableRelationshipLiving + " USING TIMESTAMP ? WHERE " + keyCondition
+	createLiving                = "INSERT INTO " + tableRelationshipLiving + " (" + keyColumns + ", revision, expiration) VALUES (?, ?, ?, ?, ?, ?, ?, ?) IF NOT EXISTS"
+	replaceExpiredLiving        = "UPDATE " + tableRelationshipLiving + " SET revision = ?, expiration = ? WHERE " + keyCondition + " IF revision = ?"
+	rollbackLiving              = "DELETE FROM " + tableRelationshipLiving + " WHERE " + keyCondition + " IF revision = ?"
+	insertChange                = "INSERT INTO " + tableChangelog + " (bucket, revision, seq, operation, " + keyColumns +
+		", caveat_name, caveat_context, expiration, metadata, transaction_metadata) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ? AND TIMESTAMP ?"
+	insertNamespace = "INSERT INTO " + tableNamespace + " (namespace, revision, deleted, serialized_config) VALUES (?, ?, ?, ?) USING TIMESTAMP ?"
+	insertCaveat    = "INSERT INTO " + tableCaveat + " (name, revision, deleted, definition) VALUES (?, ?, ?, ?) USING TIMESTAMP ?"
+)
+
+// cassandraReadWriteTXN buffers the writes of a transaction, which are applied as it commits. The
+// last write of a relationship or definition within the transaction replaces any earlier write.
+type cassandraReadWriteTXN struct {
+	*cassandraReader
+
+	ds       *Datastore
+	metadata map[string]string
+
+	relationshipOrder []relationshipKey
+	relationships     map[relationshipKey]*core.RelationTupleUpdate
+
+	// The definitions written by the transaction, which are nil if they are deleted.
+	namespaceOrder []string
+	namespaces     map[string]*core.NamespaceDefinition
+	caveatOrder    []string
+	caveats        map[string]*core.CaveatDefinition
+}
+
+func newReadWriteTXN(ds *Datastore, metadata map[string]string) *cassandraReadWriteTXN {
+	return &cassandraReadWriteTXN{
+		cassandraReader: &cassandraReader{session: ds.session, revision: liveRevision},
+		ds:              ds,
+		metadata:        metadata,
+		relationships:   make(map[relationshipKey]*core.RelationTupleUpdate),
+		namespaces:      make(map[string]*core.NamespaceDefinition),
+		caveats:         make(map[string]*core.CaveatDefinition),
+	}
+}
+
+func (rwt *cassandraReadWriteTXN) bufferRelationship(update *core.RelationTupleUpdate) {
+	key := keyOf(update.Tuple)
+	if _, ok := rwt.relationships[key]; !ok {
+		rwt.relationshipOrder = append(rwt.relationshipOrder, key)
+	}
+	rwt.relationships[key] = update
+}
+
+// WriteRelationships buffers the mutations, which are applied as the transaction commits.
+func (rwt *cassandraReadWriteTXN) WriteRelationships(_ context.Context, mutations []*core.RelationTupleUpdate) error {
+	for _, mut := range mutations {
+		rwt.bufferRelationship(mut)
+	}
+	return nil
+}
+
+// DeleteRelationships buffers the deletion of the relationships matching the filter, including
+// those written earlier in the transaction.
+func (rwt *cassandraReadWriteTXN) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
+	dsFilter := datastore.RelationshipsFilterFromPublicFilter(filter)
+
+	existing, err := rwt.loadRelationships(ctx, dsFilter)
+	if err != nil {
+		return fmt.Errorf(errUnableToDeleteRelationships, err)
+	}
+	for _, tpl := range existing {
+		if matchesFilter(tpl, dsFilter, nil) {
+			rwt.bufferRelationship(tuple.Delete(tpl))
+		}
+	}
+
+	for _, key := range rwt.relationshipOrder {
+		update := rwt.relationships[key]
+		if update.Operation != core.RelationTupleUpdate_DELETE && matchesFilter(update.Tuple, dsFilter, nil) {
+			rwt.relationships[key] = tuple.Delete(update.Tuple)
+		}
+	}
+	return nil
+}
+
+func (rwt *cassandraReadWriteTXN) WriteNamespaces(_ context.Context, newNamespaces ...*core.NamespaceDefinition) error {
+	for _, newNamespace := range newNamespaces {
+		if _, ok := rwt.namespaces[newNamespace.Name]; !ok {
+			rwt.namespaceOrder = append(rwt.namespaceOrder, newNamespace.Name)
+		}
+		rwt.namespaces[newNamespace.Name] = newNamespace
+	}
+	return nil
+}
+
+func (rwt *cassandraReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+	// Check that each namespace exists before deleting any of them.
+	for _, nsName := range nsNames {
+		_, _, err := rwt.ReadNamespace(ctx, nsName)
+		switch {
+		case errors.As(err, &datastore.ErrNamespaceNotFound{}):
+			return err
+		case err == nil:
+			break
+		default:
+			return fmt.Errorf(errUnableToDeleteConfig, err)
+		}
+	}
+
+	for _, nsName := range nsNames {
+		if _, ok := rwt.namespaces[nsName]; !ok {
+			rwt.namespaceOrder = append(rwt.namespaceOrder, nsName)
+		}
+		rwt.namespaces[nsName] = nil
+
+		if err := rwt.DeleteRelationships(ctx, &v1.RelationshipFilter{ResourceType: nsName}); err != nil {
+			return fmt.Errorf(errUnableToDeleteConfig, err)
+		}
+	}
+	return nil
+}
+
+// commit applies the buffered writes at the revision. The living rows of created relationships
+// are inserted first, and are deleted again if the transaction then fails to commit.
+func (rwt *cassandraReadWriteTXN) commit(ctx context.Context, revision int64) error {
+	ctx, span := tracer.Start(ctx, "commit")
+	defer span.End()
+
+	created, err := rwt.createLiving(ctx, revision)
+	if err == nil {
+		err = rwt.writeBatches(ctx, revision)
+	}
+	if err != nil {
+		rwt.rollbackLiving(created, revision)
+		return err
+	}
+	return nil
+}
+
+// createLiving inserts the living row of each created relationship with a lightweight
+// transaction, which fails if the relationship exists and has not expired. It returns the
+// relationships whose living rows were inserted, even if it fails.
+func (rwt *cassandraReadWriteTXN) createLiving(ctx context.Context, revision int64) ([]*core.RelationTuple, error) {
+	var lock sync.Mutex
+	var created []*core.RelationTuple
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(rwt.ds.writeConcurrency)
+	for _, key := range rwt.relationshipOrder {
+		update := rwt.relationships[key]
+		if update.Operation != core.RelationTupleUpdate_CREATE {
+			continue
+		}
+
+		g.Go(func() error {
+			applied, err := rwt.createLivingRow(ctx, update.Tuple, revision)
+			if err != nil {
+				return fmt.Errorf(errUnableToWriteRelationships, err)
+			}
+			if !applied {
+				return common.NewCreateRelationshipExistsError(update.Tuple)
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+			created = append(created, update.Tuple)
+			return nil
+		})
+	}
+
+	err := g.Wait()
+	return created, err
+}
+
+func (rwt *cassandraReadWriteTXN) createLivingRow(ctx context.Context, tpl *core.RelationTuple, revision int64) (bool, error) {
+	key := keyValues(tpl)
+	applied, existing, err := rwt.session.ExecCAS(ctx, createLiving, append(key, revision, expirationOf(tpl))...)
+	if err != nil || applied {
+		return applied, err
+	}
+
+	// A relationship which expired by the revision no longer exists, so its living row is
+	// replaced, unless another transaction replaces it first.
+	expiration := int64Value(existing.Values[0][existing.Column("expiration")])
+	if expiration == 0 || expiration > revision {
+		return false, nil
+	}
+
+	previous := existing.Values[0][existing.Column("revision")]
+	applied, _, err = rwt.session.ExecCAS(ctx, replaceExpiredLiving, append(append([]any{revision, expirationOf(tpl)}, key...), previous)...)
+	return applied, err
+}
+
+// rollbackLiving deletes the living rows which were inserted by a transaction which failed to
+// commit, unless they have since been replaced.
+func (rwt *cassandraReadWriteTXN) rollbackLiving(created []*core.RelationTuple, revision int64) {
+	if len(created) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+
+	for _, tpl := range created {
+		if _, _, err := rwt.session.ExecCAS(ctx, rollbackLiving, append(keyValues(tpl), revision)...); err != nil {
+			log.Ctx(ctx).Warn().Err(err).Str("relationship", tuple.String(tpl)).
+				Msg("failed to roll back the creation of a relationship")
+		}
+	}
+}
+
+// writeBatches writes the versions of the definitions and relationships of the transaction, and
+// the changes of its relationships, in logged batches. The statements of each relationship are
+// written by the same batch, so that its tables are consistent once the batch is applied.
+func (rwt *cassandraReadWriteTXN) writeBatches(ctx context.Context, revision int64) error {
+	timestamp := writeTimestamp(revision)
+
+	var definitions []cql.Statement
+	for _, nsName := range rwt.namespaceOrder {
+		var serialized []byte
+		nsDef := rwt.namespaces[nsName]
+		if nsDef != nil {
+			var err error
+			if serialized, err = nsDef.MarshalVT(); err != nil {
+				return fmt.Errorf(errUnableToWriteConfig, err)
+			}
+		}
+		definitions = append(definitions, cql.Statement{
+			Query:  insertNamespace,
+			Values: []any{nsName, revision, nsDef == nil, serialized, timestamp},
+		})
+	}
+	for _, name := range rwt.caveatOrder {
+		var serialized []byte
+		caveat := rwt.caveats[name]
+		if caveat != nil {
+			var err error
+			if serialized, err = caveat.MarshalVT(); err != nil {
+				return fmt.Errorf(errWriteCaveats, err)
+			}
+		}
+		definitions = append(definitions, cql.Statement{
+			Query:  insertCaveat,
+			Values: []any{name, revision, caveat == nil, serialized, timestamp},
+		})
+	}
+	if err := rwt.session.Batch(ctx, definitions); err != nil {
+		return fmt.Errorf(errUnableToCommit, err)
+	}
+
+	ttl := int32(rwt.ds.gcWindow.Seconds())
+	if ttl < 1 || ttl > maxTTLSeconds {
+		ttl = maxTTLSeconds
+	}
+
+	transactionMetadata, err := marshalBlob(rwt.metadata)
+	if err != nil {
+		return fmt.Errorf(errUnableToCommit, err)
+	}
+
+	batch := make([]cql.Statement, 0, 4*relationshipsPerBatch)
+	for seq, key := range rwt.relationshipOrder {
+		statements, err := relationshipStatements(rwt.relationships[key], revision, timestamp)
+		if err != nil {
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+
+		change, err := changeStatement(rwt.relationships[key], revision, int32(seq), timestamp, ttl)
+		if err != nil {
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+
+		// The metadata of the transaction is stored with its first change.
+		if seq == 0 {
+			change.Values[14] = transactionMetadata
+		}
+
+		batch = append(append(batch, statements...), change)
+		if len(batch) >= 4*relationshipsPerBatch {
+			if err := rwt.session.Batch(ctx, batch); err != nil {
+				return fmt.Errorf(errUnableToCommit, err)
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if err := rwt.session.Batch(ctx, batch); err != nil {
+		return fmt.Errorf(errUnableToCommit, err)
+	}
+	return nil
+}
+
+// relationshipStatements returns the statements writing the version of a relationship to the
+// forward and reverse tables, and updating its living row. The living rows of created
+// relationships are written before the batches, by lightweight transactions.
+func relationshipStatements(update *core.RelationTupleUpdate, revision, timestamp int64) ([]cql.Statement, error) {
+	tpl := update.Tuple
+	deleted := update.Operation == core.RelationTupleUpdate_DELETE
+
+	var caveatName string
+	var caveatContext, metadata []byte
+	var expiration any
+	if !deleted {
+		var err error
+		if tpl.Caveat != nil {
+			caveatName = tpl.Caveat.CaveatName
+			if caveatContext, err = marshalBlob(tpl.Caveat.Context.AsMap()); err != nil {
+				return nil, err
+			}
+		}
+		if metadata, err = marshalBlob(tpl.OptionalMetadata); err != nil {
+			return nil, err
+		}
+		expiration = expirationOf(tpl)
+	}
+
+	rel := tpl.ResourceAndRelation
+	subject := tpl.Subject
+	statements := []cql.Statement{
+		{Query: insertRelationship, Values: []any{
+			rel.Namespace, rel.ObjectId, rel.Relation, subject.Namespace, subject.ObjectId, subject.Relation,
+			revision, deleted, caveatName, caveatContext, expiration, metadata, timestamp,
+		}},
+		{Query: insertRelationshipBySubject, Values: []any{
+			rel.Namespace, rel.ObjectId, rel.Relation, subject.Namespace, subject.ObjectId, subject.Relation,
+			revision, deleted, caveatName, caveatContext, expiration, metadata, timestamp,
+		}},
+	}
+
+	switch update.Operation {
+	case core.RelationTupleUpdate_TOUCH:
+		statements = append(statements, cql.Statement{
+			Query:  insertLiving,
+			Values: append(keyValues(tpl), revision, expiration, timestamp),
+		})
+	case core.RelationTupleUpdate_DELETE:
+		statements = append(statements, cql.Statement{
+			Query:  deleteLiving,
+			Values: append([]any{timestamp}, keyValues(tpl)...),
+		})
+	}
+	return statements, nil
+}
+
+// changeStatement returns the statement writing the change of a relationship to the changelog,
+// which expires once its revision is outside of the garbage collection window.
+func changeStatement(update *core.RelationTupleUpdate, revision int64, seq int32, timestamp int64, ttl int32) (cql.Statement, error) {
+	tpl := update.Tuple
+
+	operation := int32(changelogTouched)
+	if update.Operation == core.RelationTupleUpdate_DELETE {
+		operation = changelogDeleted
+	}
+
+	var caveatName string
+	var caveatContext []byte
+	if tpl.Caveat != nil {
+		caveatName = tpl.Caveat.CaveatName
+		var err error
+		if caveatContext, err = marshalBlob(tpl.Caveat.Context.AsMap()); err != nil {
+			return cql.Statement{}, err
+		}
+	}
+
+	metadata, err := marshalBlob(tpl.OptionalMetadata)
+	if err != nil {
+		return cql.Statement{}, err
+	}
+
+	values := append([]any{changelogBucket(revision), revision, seq, operation}, keyValues(tpl)...)
+	values = append(values, caveatName, caveatContext, expirationOf(tpl), metadata, nil, ttl, timestamp)
+	return cql.Statement{Query: insertChange, Values: values}, nil
+}
+
+func keyValues(tpl *core.RelationTuple) []any {
+	return []any{
+		tpl.ResourceAndRelation.Namespace,
+		tpl.ResourceAndRelation.ObjectId,
+		tpl.ResourceAndRelation.Relation,
+		tpl.Subject.Namespace,
+		tpl.Subject.ObjectId,
+		tpl.Subject.Relation,
+	}
+}
+
+// expirationOf returns the expiration of the relationship in nanoseconds, or null if it never
+// expires.
+func expirationOf(tpl *core.RelationTuple) any {
+	if tpl.OptionalExpirationTime == nil {
+		return nil
+	}
+	return tpl.OptionalExpirationTime.AsTime().UnixNano()
+}
+
+var _ datastore.ReadWriteTransaction = &cassandraReadWriteTXN{}
@@ -0,0 +1,269 @@
+package cassandra
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jzelinskie/stringz"
+
+	"github.com/authzed/spicedb/internal/datastore/cassandra/cql"
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// relationshipColumns are the columns selected from both the forward and the reverse tables of
+// relationships, in the order decoded by relationshipVersionFrom.
+const relationshipColumns = "namespace, object_id, relation, subject_namespace, subject_object_id, subject_relation, " +
+	"revision, deleted, caveat_name, caveat_context, expiration, metadata"
+
+// relationshipKey identifies a relationship across its versions.
+type relationshipKey struct {
+	namespace        string
+	objectID         string
+	relation         string
+	subjectNamespace string
+	subjectObjectID  string
+	subjectRelation  string
+}
+
+func keyOf(tpl *core.RelationTuple) relationshipKey {
+	return relationshipKey{
+		namespace:        tpl.ResourceAndRelation.Namespace,
+		objectID:         tpl.ResourceAndRelation.ObjectId,
+		relation:         tpl.ResourceAndRelation.Relation,
+		subjectNamespace: tpl.Subject.Namespace,
+		subjectObjectID:  tpl.Subject.ObjectId,
+		subjectRelation:  tpl.Subject.Relation,
+	}
+}
+
+// relationshipVersion is a row of the forward or reverse table of relationships, which is the
+// version of a relationship written at a revision.
+type relationshipVersion struct {
+	tuple    *core.RelationTuple
+	revision int64
+	deleted  bool
+
+	// expiration is the expiration of the relationship in nanoseconds, or zero if it never
+	// expires.
+	expiration int64
+}
+
+func (rv relationshipVersion) expired(now time.Time) bool {
+	return rv.expiration != 0 && rv.expiration <= now.UnixNano()
+}
+
+func relationshipVersionFrom(row []any) (relationshipVersion, error) {
+	version := relationshipVersion{
+		tuple: &core.RelationTuple{
+			ResourceAndRelation: &core.ObjectAndRelation{
+				Namespace: stringValue(row[0]),
+				ObjectId:  stringValue(row[1]),
+				Relation:  stringValue(row[2]),
+			},
+			Subject: &core.ObjectAndRelation{
+				Namespace: stringValue(row[3]),
+				ObjectId:  stringValue(row[4]),
+				Relation:  stringValue(row[5]),
+			},
+		},
+		revision:   int64Value(row[6]),
+		deleted:    boolValue(row[7]),
+		expiration: int64Value(row[10]),
+	}
+
+	var caveatContext map[string]any
+	if err := unmarshalBlob(row[9], &caveatContext); err != nil {
+		return relationshipVersion{}, fmt.Errorf("unable to decode caveat context: %w", err)
+	}
+
+	var err error
+	version.tuple.Caveat, err = common.ContextualizedCaveatFrom(stringValue(row[8]), caveatContext)
+	if err != nil {
+		return relationshipVersion{}, err
+	}
+
+	if version.expiration != 0 {
+		version.tuple.OptionalExpirationTime = common.ExpirationFrom(sql.NullTime{
+			Time:  time.Unix(0, version.expiration).UTC(),
+			Valid: true,
+		})
+	}
+
+	if err := unmarshalBlob(row[11], &version.tuple.OptionalMetadata); err != nil {
+		return relationshipVersion{}, fmt.Errorf("unable to decode metadata: %w", err)
+	}
+	return version, nil
+}
+
+// relationshipsAt returns the relationships of the rows which exist at the revision: those whose
+// latest version at or before the revision was not a deletion, and had not expired at the revision. The
+// relationships are returned in the order in which they were first found.
+func relationshipsAt(pages []*cql.Rows, revision int64) ([]*core.RelationTuple, error) {
+	var order []relationshipKey
+	latest := make(map[relationshipKey]relationshipVersion)
+	for _, page := range pages {
+		for _, row := range page.Values {
+			version, err := relationshipVersionFrom(row)
+			if err != nil {
+				return nil, err
+			}
+			if version.revision > revision {
+				continue
+			}
+
+			key := keyOf(version.tuple)
+			found, ok := latest[key]
+			if !ok {
+				order = append(order, key)
+			}
+			if !ok || version.revision > found.revision {
+				latest[key] = version
+			}
+		}
+	}
+
+	readTime := time.Unix(0, revision)
+	relationships := make([]*core.RelationTuple, 0, len(order))
+	for _, key := range order {
+		version := latest[key]
+		if version.deleted || version.expired(readTime) {
+			continue
+		}
+		relationships = append(relationships, version.tuple)
+	}
+	return relationships, nil
+}
+
+// matchesFilter returns whether the relationship matches every part of the filter, and is one
+// of the usersets, if any.
+func matchesFilter(tpl *core.RelationTuple, filter datastore.RelationshipsFilter, usersets []*core.ObjectAndRelation) bool {
+	switch {
+	case filter.ResourceType != "" && filter.ResourceType != tpl.ResourceAndRelation.Namespace:
+		return false
+	case len(filter.OptionalResourceIds) > 0 && !stringz.SliceContains(filter.OptionalResourceIds, tpl.ResourceAndRelation.ObjectId):
+		return false
+	case filter.OptionalResourceRelation != "" && filter.OptionalResourceRelation != tpl.ResourceAndRelation.Relation:
+		return false
+	case filter.OptionalCaveatName != "" && (tpl.Caveat == nil || tpl.Caveat.CaveatName != filter.OptionalCaveatName):
+		return false
+	case filter.OptionalSubjectsFilter != nil && !matchesSubjectsFilter(tpl, *filter.OptionalSubjectsFilter):
+		return false
+	}
+
+	for key, value := range filter.OptionalMetadata {
+		found, ok := tpl.OptionalMetadata[key]
+		if !ok || (value != "" && value != found) {
+			return false
+		}
+	}
+
+	if len(usersets) == 0 {
+		return true
+	}
+	for _, userset := range usersets {
+		if userset.Namespace == tpl.Subject.Namespace &&
+			userset.ObjectId == tpl.Subject.ObjectId &&
+			userset.Relation == tpl.Subject.Relation {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesSubjectsFilter(tpl *core.RelationTuple, filter datastore.SubjectsFilter) bool {
+	if filter.SubjectType != tpl.Subject.Namespace {
+		return false
+	}
+	if len(filter.OptionalSubjectIds) > 0 && !stringz.SliceContains(filter.OptionalSubjectIds, tpl.Subject.ObjectId) {
+		return false
+	}
+	if filter.RelationFilter.IsEmpty() {
+		return true
+	}
+	return (filter.RelationFilter.IncludeEllipsisRelation && tpl.Subject.Relation == datastore.Ellipsis) ||
+		(filter.RelationFilter.NonEllipsisRelation != "" && tpl.Subject.Relation == filter.RelationFilter.NonEllipsisRelation)
+}
+
+// sortBySubject sorts the relationships by subject and then by resource, comparing the bytes of
+// each field as Cassandra compares text.
+func sortBySubject(relationships []*core.RelationTuple) {
+	sort.Slice(relationships, func(i, j int) bool {
+		return compareBySubject(relationships[i], relationships[j]) < 0
+	})
+}
+
+func compareBySubject(a, b *core.RelationTuple) int {
+	for _, pair := range [][2]string{
+		{a.Subject.Namespace, b.Subject.Namespace},
+		{a.Subject.ObjectId, b.Subject.ObjectId},
+		{a.Subject.Relation, b.Subject.Relation},
+		{a.ResourceAndRelation.Namespace, b.ResourceAndRelation.Namespace},
+		{a.ResourceAndRelation.ObjectId, b.ResourceAndRelation.ObjectId},
+		{a.ResourceAndRelation.Relation, b.ResourceAndRelation.Relation},
+	} {
+		switch {
+		case pair[0] < pair[1]:
+			return -1
+		case pair[0] > pair[1]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// afterCursor returns the sorted relationships which follow the cursor.
+func afterCursor(sorted []*core.RelationTuple, cursor options.Cursor) []*core.RelationTuple {
+	index := sort.Search(len(sorted), func(i int) bool {
+		return compareBySubject(sorted[i], cursor) > 0
+	})
+	return sorted[index:]
+}
+
+func limited(relationships []*core.RelationTuple, limit *uint64) []*core.RelationTuple {
+	if limit != nil && uint64(len(relationships)) > *limit {
+		return relationships[:*limit]
+	}
+	return relationships
+}
+
+// marshalBlob encodes a caveat context or metadata as JSON, storing empty values as null.
+func marshalBlob[T any](value map[string]T) ([]byte, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+func unmarshalBlob[T any](value any, target *map[string]T) error {
+	encoded, _ := value.([]byte)
+	if len(encoded) == 0 {
+		return nil
+	}
+	return json.Unmarshal(encoded, target)
+}
+
+func stringValue(value any) string {
+	s, _ := value.(string)
+	return s
+}
+
+func int64Value(value any) int64 {
+	i, _ := value.(int64)
+	return i
+}
+
+func boolValue(value any) bool {
+	b, _ := value.(bool)
+	return b
+}
+
+func int32Value(value any) int32 {
+	i, _ := value.(int32)
+	return i
+}
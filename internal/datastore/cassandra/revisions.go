@@ -0,0 +1,56 @@
+package cassandra
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+// Revisions are the commit timestamps of transactions in nanoseconds, taken from the clock of the
+// SpiceDB instance which commits them, as Cassandra orders the writes to a cell by their
+// timestamps. Each revision is a whole number of microseconds, which is the precision of the
+// timestamps of writes, and the revisions assigned by an instance always increase.
+
+// headRevisionInternal returns the current time, or the latest revision assigned by this
+// instance if it is later.
+func (cds *Datastore) headRevisionInternal(_ context.Context) (revision.Decimal, error) {
+	nowMicros := time.Now().UnixMicro()
+
+	cds.revisionLock.Lock()
+	defer cds.revisionLock.Unlock()
+	if cds.lastRevisionMicros > nowMicros {
+		nowMicros = cds.lastRevisionMicros
+	}
+	return revisionFromNanos(nowMicros * 1000), nil
+}
+
+func (cds *Datastore) HeadRevision(ctx context.Context) (datastore.Revision, error) {
+	return cds.headRevisionInternal(ctx)
+}
+
+// nextRevision assigns the revision of a transaction, which is the current time unless a
+// transaction has already been assigned it.
+func (cds *Datastore) nextRevision() int64 {
+	nowMicros := time.Now().UnixMicro()
+
+	cds.revisionLock.Lock()
+	defer cds.revisionLock.Unlock()
+	if nowMicros <= cds.lastRevisionMicros {
+		nowMicros = cds.lastRevisionMicros + 1
+	}
+	cds.lastRevisionMicros = nowMicros
+	return nowMicros * 1000
+}
+
+func revisionFromNanos(nanos int64) revision.Decimal {
+	return revision.NewFromDecimal(decimal.NewFromInt(nanos))
+}
+
+// writeTimestamp is the timestamp in microseconds of the writes of the revision.
+func writeTimestamp(revisionNanos int64) int64 {
+	return revisionNanos / 1000
+}
@@ -0,0 +1,78 @@
+package cassandra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/cassandra/cql"
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+const (
+	queryUniqueID = "SELECT unique_id FROM " + tableMetadata + " WHERE id = 0"
+	queryLiving   = "SELECT namespace, object_id, subject_namespace, subject_object_id, expiration FROM " + tableRelationshipLiving
+
+	// statsScanLimit is the number of living relationships counted by Statistics, beyond which
+	// the counts are not exact.
+	statsScanLimit = 1_000_000
+)
+
+var errStatsScanLimit = errors.New("statistics scan limit reached")
+
+// Statistics counts the living relationships, up to statsScanLimit of them. The estimates kept
+// by Cassandra in system.size_estimates only cover flushed partitions, so they are not used.
+func (cds *Datastore) Statistics(ctx context.Context) (datastore.Stats, error) {
+	rows, err := cds.session.Query(ctx, queryUniqueID)
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to query unique ID: %w", err)
+	}
+	if len(rows.Values) == 0 {
+		return datastore.Stats{}, fmt.Errorf("unable to query unique ID: no unique ID found")
+	}
+	uniqueID := stringValue(rows.Values[0][0])
+
+	type object struct{ namespace, objectID string }
+
+	var count uint64
+	relationshipEstimates := make(map[string]uint64)
+	resources := make(map[object]struct{})
+	subjects := make(map[object]struct{})
+	now := time.Now().UnixNano()
+
+	err = cds.session.Iterate(ctx, queryLiving, nil, func(rows *cql.Rows) error {
+		for _, row := range rows.Values {
+			if expiration := int64Value(row[4]); expiration != 0 && expiration <= now {
+				continue
+			}
+
+			namespace := stringValue(row[0])
+			relationshipEstimates[namespace]++
+			resources[object{namespace, stringValue(row[1])}] = struct{}{}
+			subjects[object{stringValue(row[2]), stringValue(row[3])}] = struct{}{}
+
+			count++
+			if count >= statsScanLimit {
+				return errStatsScanLimit
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStatsScanLimit) {
+		return datastore.Stats{}, fmt.Errorf("unable to count relationships: %w", err)
+	}
+
+	nsDefs, err := cds.SnapshotReader(revisionFromNanos(liveRevision)).ListNamespaces(ctx)
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to load namespaces: %w", err)
+	}
+
+	return datastore.Stats{
+		UniqueID:                   uniqueID,
+		ObjectTypeStatistics:       datastore.ComputeObjectTypeStatsWithEstimates(nsDefs, relationshipEstimates),
+		EstimatedRelationshipCount: count,
+		EstimatedDistinctResources: uint64(len(resources)),
+		EstimatedDistinctSubjects:  uint64(len(subjects)),
+	}, nil
+}
@@ -0,0 +1,171 @@
+package cassandra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	watchSleep = 100 * time.Millisecond
+
+	// changelogBucketSize is the span of revisions in nanoseconds whose changes are written to
+	// each partition of the changelog.
+	changelogBucketSize = int64(time.Minute)
+
+	queryChangelog = "SELECT revision, operation, " + keyColumns + ", caveat_name, caveat_context, expiration, metadata, transaction_metadata FROM " +
+		tableChangelog + " WHERE bucket = ? AND revision > ? AND revision <= ?"
+)
+
+func changelogBucket(revision int64) int64 {
+	return revision / changelogBucketSize
+}
+
+// Watch notifies the caller about all changes to tuples.
+//
+// All events following afterRevision will be sent to the caller. Changes are read from the
+// changelog table, which is written by the same batches as the relationships. As the revision
+// of a transaction is assigned before its batches are written, only the changes of revisions
+// older than the watch lag are read.
+func (cds *Datastore) Watch(ctx context.Context, afterRevisionRaw datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
+	afterRevision := afterRevisionRaw.(revision.Decimal)
+
+	updates := make(chan *datastore.RevisionChanges, cds.watchBufferLength)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(updates)
+		defer close(errs)
+
+		currentRevision := afterRevision.IntPart()
+
+		lastSent := time.Now()
+
+		for {
+			var stagedUpdates []*datastore.RevisionChanges
+			var err error
+			stagedUpdates, currentRevision, err = cds.loadChanges(ctx, currentRevision)
+			if err != nil {
+				if errors.Is(ctx.Err(), context.Canceled) {
+					errs <- datastore.NewWatchCanceledErr()
+				} else {
+					errs <- err
+				}
+				return
+			}
+
+			// Write the staged updates to the channel
+			for _, changeToWrite := range stagedUpdates {
+				select {
+				case updates <- changeToWrite:
+				default:
+					errs <- datastore.NewWatchDisconnectedErr()
+					return
+				}
+				lastSent = time.Now()
+			}
+
+			// If there were no changes, send a checkpoint if none has been sent within the
+			// interval, and sleep a bit
+			if len(stagedUpdates) == 0 {
+				if time.Since(lastSent) >= common.WatchCheckpointInterval {
+					select {
+					case updates <- &datastore.RevisionChanges{
+						Revision:     revisionFromNanos(currentRevision),
+						IsCheckpoint: true,
+					}:
+					default:
+						errs <- datastore.NewWatchDisconnectedErr()
+						return
+					}
+					lastSent = time.Now()
+				}
+
+				sleep := time.NewTimer(watchSleep)
+
+				select {
+				case <-sleep.C:
+					break
+				case <-ctx.Done():
+					errs <- datastore.NewWatchCanceledErr()
+					return
+				}
+			}
+		}
+	}()
+
+	return updates, errs
+}
+
+// loadChanges reads the changes of the revisions after afterRevision and up to the current time
+// less the watch lag, returning the latest revision read.
+func (cds *Datastore) loadChanges(
+	ctx context.Context,
+	afterRevision int64,
+) (changes []*datastore.RevisionChanges, newRevision int64, err error) {
+	now := time.Now()
+	newRevision = now.Add(-cds.watchLag).UnixNano()
+	if newRevision <= afterRevision {
+		return nil, afterRevision, nil
+	}
+
+	// The changelog has expired before the garbage collection window, so its earlier buckets
+	// need not be read.
+	fromRevision := afterRevision
+	if expired := now.Add(-cds.gcWindow).UnixNano(); fromRevision < expired {
+		fromRevision = expired
+	}
+
+	stagedChanges := common.NewChanges()
+	for bucket := changelogBucket(fromRevision); bucket <= changelogBucket(newRevision); bucket++ {
+		rows, err := cds.session.Query(ctx, queryChangelog, bucket, afterRevision, newRevision)
+		if err != nil {
+			return nil, afterRevision, fmt.Errorf("unable to load changes: %w", err)
+		}
+
+		for _, row := range rows.Values {
+			if err := addChange(ctx, stagedChanges, row); err != nil {
+				return nil, afterRevision, fmt.Errorf("unable to load changes: %w", err)
+			}
+		}
+	}
+
+	return stagedChanges.AsRevisionChanges(cds), newRevision, nil
+}
+
+// addChange adds a row of the changelog, in the order of queryChangelog, to the staged changes.
+func addChange(ctx context.Context, stagedChanges common.Changes, row []any) error {
+	changeRevision := revisionFromNanos(int64Value(row[0]))
+
+	// The change is decoded as a relationship version, whose columns differ only in the
+	// operation preceding the key.
+	version, err := relationshipVersionFrom([]any{
+		row[2], row[3], row[4], row[5], row[6], row[7],
+		row[0], false, row[8], row[9], row[10], row[11],
+	})
+	if err != nil {
+		return err
+	}
+
+	switch int32Value(row[1]) {
+	case changelogTouched:
+		stagedChanges.AddChange(ctx, changeRevision, version.tuple, core.RelationTupleUpdate_TOUCH)
+	case changelogDeleted:
+		stagedChanges.AddChange(ctx, changeRevision, version.tuple, core.RelationTupleUpdate_DELETE)
+	}
+
+	var transactionMetadata map[string]string
+	if err := unmarshalBlob(row[12], &transactionMetadata); err != nil {
+		return fmt.Errorf("unable to decode transaction metadata: %w", err)
+	}
+	if len(transactionMetadata) > 0 {
+		stagedChanges.SetRevisionMetadata(changeRevision, transactionMetadata)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package common
+
+import (
+	"context"
+	"errors"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// DefaultBulkLoadBatchSize is the default number of relationships written together by datastores
+// which bulk load relationships in batches.
+const DefaultBulkLoadBatchSize = 1000
+
+// ErrBulkLoadRetried is returned when a datastore attempts to retry a transaction performing a
+// bulk load, which is not possible as the relationship source cannot be replayed.
+var ErrBulkLoadRetried = errors.New("bulk load transaction could not be committed and cannot be retried")
+
+// SingleAttemptTxFunc wraps a transaction function which consumes a bulk load source, returning
+// ErrBulkLoadRetried if the datastore invokes it more than once.
+func SingleAttemptTxFunc(fn datastore.TxUserFunc) datastore.TxUserFunc {
+	attempted := false
+	return func(rwt datastore.ReadWriteTransaction) error {
+		if attempted {
+			return ErrBulkLoadRetried
+		}
+		attempted = true
+		return fn(rwt)
+	}
+}
+
+// BulkLoadInBatches reads all relationships from the source, invoking writeBatch with each batch
+// of at most batchSize relationships as CREATE operations. Returns the number of relationships
+// written.
+func BulkLoadInBatches(
+	ctx context.Context,
+	source datastore.BulkWriteRelationshipSource,
+	batchSize int,
+	writeBatch func(ctx context.Context, batch []*core.RelationTupleUpdate) error,
+) (uint64, error) {
+	var written uint64
+	batch := make([]*core.RelationTupleUpdate, 0, batchSize)
+	for {
+		tpl, err := source.Next(ctx)
+		if err != nil {
+			return written, err
+		}
+
+		if tpl != nil {
+			batch = append(batch, &core.RelationTupleUpdate{
+				Operation: core.RelationTupleUpdate_CREATE,
+				Tuple:     tpl,
+			})
+		}
+
+		if len(batch) == batchSize || (tpl == nil && len(batch) > 0) {
+			if err := writeBatch(ctx, batch); err != nil {
+				return written, err
+			}
+
+			written += uint64(len(batch))
+			batch = make([]*core.RelationTupleUpdate, 0, batchSize)
+		}
+
+		if tpl == nil {
+			return written, nil
+		}
+	}
+}
+
+// SliceBulkLoadSource returns a bulk load source producing the given relationships.
+func SliceBulkLoadSource(relationships []*core.RelationTuple) datastore.BulkWriteRelationshipSource {
+	return &sliceSource{relationships: relationships}
+}
+
+type sliceSource struct {
+	relationships []*core.RelationTuple
+	index         int
+}
+
+func (s *sliceSource) Next(_ context.Context) (*core.RelationTuple, error) {
+	if s.index >= len(s.relationships) {
+		return nil, nil
+	}
+
+	tpl := s.relationships[s.index]
+	s.index++
+	return tpl, nil
+}
@@ -0,0 +1,68 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestBulkLoadInBatches(t *testing.T) {
+	testCases := []struct {
+		numTuples          int
+		batchSize          int
+		expectedBatchSizes []int
+	}{
+		{0, 3, nil},
+		{1, 3, []int{1}},
+		{3, 3, []int{3}},
+		{7, 3, []int{3, 3, 1}},
+		{6, 3, []int{3, 3}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%d/%d", tc.numTuples, tc.batchSize), func(t *testing.T) {
+			require := require.New(t)
+
+			tuples := make([]*core.RelationTuple, 0, tc.numTuples)
+			for i := 0; i < tc.numTuples; i++ {
+				tuples = append(tuples, tuple.MustParse(fmt.Sprintf("docs:%d#reader@user:1", i)))
+			}
+
+			var batchSizes []int
+			var loaded []*core.RelationTuple
+			written, err := BulkLoadInBatches(context.Background(), SliceBulkLoadSource(tuples), tc.batchSize, func(ctx context.Context, batch []*core.RelationTupleUpdate) error {
+				batchSizes = append(batchSizes, len(batch))
+				for _, update := range batch {
+					require.Equal(core.RelationTupleUpdate_CREATE, update.Operation)
+					loaded = append(loaded, update.Tuple)
+				}
+				return nil
+			})
+			require.NoError(err)
+			require.Equal(uint64(tc.numTuples), written)
+			require.Equal(tc.expectedBatchSizes, batchSizes)
+			require.Equal(len(tuples), len(loaded))
+		})
+	}
+}
+
+func TestSingleAttemptTxFunc(t *testing.T) {
+	require := require.New(t)
+
+	calls := 0
+	fn := SingleAttemptTxFunc(func(rwt datastore.ReadWriteTransaction) error {
+		calls++
+		return nil
+	})
+
+	require.NoError(fn(nil))
+	require.ErrorIs(fn(nil), ErrBulkLoadRetried)
+	require.Equal(1, calls)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"time"
 
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -11,6 +12,10 @@ import (
 	"github.com/authzed/spicedb/pkg/tuple"
 )
 
+// WatchCheckpointInterval is the interval at which datastores send a checkpoint from Watch while
+// no changes are occurring.
+const WatchCheckpointInterval = 1 * time.Second
+
 type revisionKey string
 
 func keyFromRevision(rev datastore.Revision) revisionKey {
@@ -32,6 +37,7 @@ type Changes map[revisionKey]*changeRecord
 type changeRecord struct {
 	tupleTouches map[string]*core.RelationTuple
 	tupleDeletes map[string]*core.RelationTuple
+	metadata     map[string]string
 }
 
 // NewChanges creates a new Changes object for change tracking and de-duplication.
@@ -46,16 +52,7 @@ func (ch Changes) AddChange(
 	tpl *core.RelationTuple,
 	op core.RelationTupleUpdate_Operation,
 ) {
-	rk := keyFromRevision(rev)
-	revisionChanges, ok := ch[rk]
-	if !ok {
-		revisionChanges = &changeRecord{
-			tupleTouches: make(map[string]*core.RelationTuple),
-			tupleDeletes: make(map[string]*core.RelationTuple),
-		}
-		ch[rk] = revisionChanges
-	}
-
+	revisionChanges := ch.recordForRevision(rev)
 	tplKey := tuple.String(tpl)
 
 	switch op {
@@ -75,8 +72,27 @@ func (ch Changes) AddChange(
 	}
 }
 
+// SetRevisionMetadata sets the caller-supplied metadata stored with the transaction at the
+// specified revision.
+func (ch Changes) SetRevisionMetadata(rev datastore.Revision, metadata map[string]string) {
+	ch.recordForRevision(rev).metadata = metadata
+}
+
+func (ch Changes) recordForRevision(rev datastore.Revision) *changeRecord {
+	rk := keyFromRevision(rev)
+	revisionChanges, ok := ch[rk]
+	if !ok {
+		revisionChanges = &changeRecord{
+			tupleTouches: make(map[string]*core.RelationTuple),
+			tupleDeletes: make(map[string]*core.RelationTuple),
+		}
+		ch[rk] = revisionChanges
+	}
+	return revisionChanges
+}
+
 // AsRevisionChanges returns the list of changes processed so far as a datastore watch
-// compatible, ordered, changelist.
+// compatible, ordered, changelist. Revisions for which only metadata was recorded are omitted.
 func (ch Changes) AsRevisionChanges(ds revisionDecoder) (changes []*datastore.RevisionChanges) {
 	type keyAndRevision struct {
 		key revisionKey
@@ -84,7 +100,11 @@ func (ch Changes) AsRevisionChanges(ds revisionDecoder) (changes []*datastore.Re
 	}
 
 	revisionsWithChanges := make([]keyAndRevision, 0, len(ch))
-	for rk := range ch {
+	for rk, record := range ch {
+		if len(record.tupleTouches) == 0 && len(record.tupleDeletes) == 0 {
+			continue
+		}
+
 		kar := keyAndRevision{rk, mustRevisionFromKey(rk, ds)}
 		revisionsWithChanges = append(revisionsWithChanges, kar)
 	}
@@ -93,11 +113,12 @@ func (ch Changes) AsRevisionChanges(ds revisionDecoder) (changes []*datastore.Re
 	})
 
 	for _, kar := range revisionsWithChanges {
+		revisionChangeRecord := ch[kar.key]
 		revisionChange := &datastore.RevisionChanges{
 			Revision: kar.rev,
+			Metadata: revisionChangeRecord.metadata,
 		}
 
-		revisionChangeRecord := ch[kar.key]
 		for _, tpl := range revisionChangeRecord.tupleTouches {
 			revisionChange.Changes = append(revisionChange.Changes, &core.RelationTupleUpdate{
 				Operation: core.RelationTupleUpdate_TOUCH,
@@ -194,6 +194,24 @@ func TestChanges(t *testing.T) {
 	}
 }
 
+func TestChangesMetadata(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	ch := NewChanges()
+	ch.AddChange(ctx, rev1, tuple.MustParse(tuple1), core.RelationTupleUpdate_TOUCH)
+	ch.SetRevisionMetadata(rev1, map[string]string{"actor": "tom"})
+	ch.SetRevisionMetadata(rev2, map[string]string{"actor": "fred"})
+
+	// Revisions with metadata but no relationship changes are omitted.
+	require.Equal(
+		canonicalize([]*datastore.RevisionChanges{
+			{Revision: rev1, Changes: []*core.RelationTupleUpdate{touch(tuple1)}, Metadata: map[string]string{"actor": "tom"}},
+		}),
+		canonicalize(ch.AsRevisionChanges(revision.DecimalDecoder{})),
+	)
+}
+
 func TestCanonicalize(t *testing.T) {
 	testCases := []struct {
 		name            string
@@ -315,6 +333,7 @@ func canonicalize(in []*datastore.RevisionChanges) []*datastore.RevisionChanges
 		out = append(out, &datastore.RevisionChanges{
 			Revision: rev.Revision,
 			Changes:  outChanges,
+			Metadata: rev.Metadata,
 		})
 	}
 
@@ -2,6 +2,8 @@ package common
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -34,14 +36,33 @@ var (
 		Help:      "The number of stale transactions deleted by the datastore garbage collection.",
 	})
 
-	gcNamespacesCounter = prometheus.NewCounter(prometheus.CounterOpts{
+	gcBacklogGauge = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: "spicedb",
 		Subsystem: "datastore",
-		Name:      "gc_namespaces_total",
-		Help:      "The number of stale namespaces deleted by the datastore garbage collection.",
+		Name:      "gc_backlog_transactions",
+		Help:      "The number of stale transactions which remained to be deleted after the last datastore garbage collection.",
 	})
+
+	gcRunsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "spicedb",
+		Subsystem: "datastore",
+		Name:      "gc_runs_total",
+		Help:      "The number of datastore garbage collection runs, by what triggered them.",
+	}, []string{"trigger"})
+)
+
+const (
+	triggerInterval = "interval"
+	triggerManual   = "manual"
 )
 
+// errNotReady is returned when garbage collection is attempted before the datastore is ready.
+var errNotReady = errors.New("datastore wasn't ready when attempting garbage collection")
+
+// collectLock ensures that only one garbage collection runs at a time, so that a run triggered
+// on demand does not race with the background worker.
+var collectLock sync.Mutex
+
 // RegisterGCMetrics registers garbage collection metrics to the default
 // registry.
 func RegisterGCMetrics() error {
@@ -49,7 +70,8 @@ func RegisterGCMetrics() error {
 		gcDurationHistogram,
 		gcRelationshipsCounter,
 		gcTransactionsCounter,
-		gcNamespacesCounter,
+		gcBacklogGauge,
+		gcRunsCounter,
 	} {
 		if err := prometheus.Register(metric); err != nil {
 			return err
@@ -65,6 +87,7 @@ type GarbageCollector interface {
 	IsReady(context.Context) (bool, error)
 	Now(context.Context) (time.Time, error)
 	TxIDBefore(context.Context, time.Time) (datastore.Revision, error)
+	CountTransactionsBeforeTx(ctx context.Context, txID datastore.Revision) (int64, error)
 	DeleteBeforeTx(ctx context.Context, txID datastore.Revision) (DeletionCounts, error)
 }
 
@@ -73,14 +96,12 @@ type GarbageCollector interface {
 type DeletionCounts struct {
 	Relationships int64
 	Transactions  int64
-	Namespaces    int64
 }
 
 func (g DeletionCounts) MarshalZerologObject(e *zerolog.Event) {
 	e.
 		Int64("relationships", g.Relationships).
-		Int64("transactions", g.Transactions).
-		Int64("namespaces", g.Namespaces)
+		Int64("transactions", g.Transactions)
 }
 
 // StartGarbageCollector loops forever until the context is canceled and
@@ -98,8 +119,10 @@ func StartGarbageCollector(ctx context.Context, gc GarbageCollector, interval, w
 			return ctx.Err()
 
 		case <-time.After(interval):
-			err := collect(gc, window, timeout)
-			if err != nil {
+			_, err := collect(context.Background(), gc, window, timeout, triggerInterval)
+			if errors.Is(err, errNotReady) {
+				log.Ctx(ctx).Warn().Msg(err.Error())
+			} else if err != nil {
 				log.Ctx(ctx).Warn().Err(err).
 					Msg("error attempting to perform garbage collection")
 			}
@@ -107,52 +130,83 @@ func StartGarbageCollector(ctx context.Context, gc GarbageCollector, interval, w
 	}
 }
 
-func collect(gc GarbageCollector, window, timeout time.Duration) error {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+// RunGarbageCollection performs garbage collection immediately, waiting for any collection
+// already in progress to finish first.
+func RunGarbageCollection(ctx context.Context, gc GarbageCollector, window, timeout time.Duration) (datastore.GarbageCollected, error) {
+	return collect(ctx, gc, window, timeout, triggerManual)
+}
+
+func collect(ctx context.Context, gc GarbageCollector, window, timeout time.Duration, trigger string) (datastore.GarbageCollected, error) {
+	collectLock.Lock()
+	defer collectLock.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	gcRunsCounter.WithLabelValues(trigger).Inc()
+
 	// Before attempting anything, check if the datastore is ready.
 	ready, err := gc.IsReady(ctx)
 	if err != nil {
-		return err
+		return datastore.GarbageCollected{}, err
 	}
 	if !ready {
-		log.Ctx(ctx).Warn().
-			Msg("datastore wasn't ready when attempting garbage collection")
-		return nil
+		return datastore.GarbageCollected{}, errNotReady
 	}
 
 	var (
 		startTime = time.Now()
 		collected DeletionCounts
 		watermark datastore.Revision
+		stale     int64
 	)
 
 	defer func() {
 		collectionDuration := time.Since(startTime)
 		gcDurationHistogram.Observe(collectionDuration.Seconds())
 
+		backlog := remainingBacklog(stale, collected)
+
 		log.Ctx(ctx).Debug().
 			Stringer("highestTxID", watermark).
 			Dur("duration", collectionDuration).
+			Str("trigger", trigger).
 			Interface("collected", collected).
+			Int64("backlog", backlog).
 			Msg("datastore garbage collection completed")
 
 		gcRelationshipsCounter.Add(float64(collected.Relationships))
 		gcTransactionsCounter.Add(float64(collected.Transactions))
-		gcNamespacesCounter.Add(float64(collected.Namespaces))
+		gcBacklogGauge.Set(float64(backlog))
 	}()
 
 	now, err := gc.Now(ctx)
 	if err != nil {
-		return err
+		return datastore.GarbageCollected{}, err
 	}
 
 	watermark, err = gc.TxIDBefore(ctx, now.Add(-1*window))
 	if err != nil {
-		return err
+		return datastore.GarbageCollected{}, err
+	}
+
+	stale, err = gc.CountTransactionsBeforeTx(ctx, watermark)
+	if err != nil {
+		return datastore.GarbageCollected{}, err
 	}
 
 	collected, err = gc.DeleteBeforeTx(ctx, watermark)
-	return err
+	return datastore.GarbageCollected{
+		Relationships: collected.Relationships,
+		Transactions:  collected.Transactions,
+		Backlog:       remainingBacklog(stale, collected),
+	}, err
+}
+
+// remainingBacklog returns the number of the stale transactions which were not deleted.
+func remainingBacklog(stale int64, collected DeletionCounts) int64 {
+	if collected.Transactions >= stale {
+		return 0
+	}
+	return stale - collected.Transactions
 }
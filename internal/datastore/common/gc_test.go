@@ -0,0 +1,74 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+// fakeGC is a GarbageCollector whose transactions are numbered one per second.
+type fakeGC struct {
+	sync.Mutex
+
+	ready     bool
+	now       time.Time
+	stale     int64
+	deleteErr error
+	deleted   []datastore.Revision
+}
+
+func (gc *fakeGC) IsReady(_ context.Context) (bool, error) { return gc.ready, nil }
+
+func (gc *fakeGC) Now(_ context.Context) (time.Time, error) { return gc.now, nil }
+
+func (gc *fakeGC) TxIDBefore(_ context.Context, before time.Time) (datastore.Revision, error) {
+	return revisionFromTransactionID(uint64(before.Unix())), nil
+}
+
+func (gc *fakeGC) CountTransactionsBeforeTx(_ context.Context, _ datastore.Revision) (int64, error) {
+	return gc.stale, nil
+}
+
+func (gc *fakeGC) DeleteBeforeTx(_ context.Context, txID datastore.Revision) (DeletionCounts, error) {
+	gc.Lock()
+	defer gc.Unlock()
+
+	gc.deleted = append(gc.deleted, txID)
+	if gc.deleteErr != nil {
+		return DeletionCounts{Relationships: 3, Transactions: 2}, gc.deleteErr
+	}
+	return DeletionCounts{Relationships: 7, Transactions: gc.stale}, nil
+}
+
+func TestRunGarbageCollection(t *testing.T) {
+	require := require.New(t)
+
+	gc := &fakeGC{ready: true, now: time.Unix(1000, 0), stale: 5}
+	collected, err := RunGarbageCollection(context.Background(), gc, 100*time.Second, time.Minute)
+	require.NoError(err)
+	require.Equal(datastore.GarbageCollected{Relationships: 7, Transactions: 5}, collected)
+	require.Equal([]datastore.Revision{revisionFromTransactionID(900)}, gc.deleted)
+}
+
+func TestRunGarbageCollectionIncomplete(t *testing.T) {
+	require := require.New(t)
+
+	deleteErr := errors.New("context deadline exceeded")
+	gc := &fakeGC{ready: true, now: time.Unix(1000, 0), stale: 5, deleteErr: deleteErr}
+	collected, err := RunGarbageCollection(context.Background(), gc, 100*time.Second, time.Minute)
+	require.ErrorIs(err, deleteErr)
+	require.Equal(datastore.GarbageCollected{Relationships: 3, Transactions: 2, Backlog: 3}, collected)
+}
+
+func TestRunGarbageCollectionNotReady(t *testing.T) {
+	gc := &fakeGC{now: time.Unix(1000, 0)}
+	_, err := RunGarbageCollection(context.Background(), gc, 100*time.Second, time.Minute)
+	require.ErrorIs(t, err, errNotReady)
+	require.Empty(t, gc.deleted)
+}
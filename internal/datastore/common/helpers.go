@@ -2,9 +2,12 @@ package common
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"time"
 
 	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -69,3 +72,22 @@ func ContextualizedCaveatFrom(name string, context map[string]any) (*core.Contex
 	}
 	return caveat, nil
 }
+
+// ExpirationTimeOf returns the expiration time of the tuple for storage in a nullable column, or
+// nil if the tuple does not expire.
+func ExpirationTimeOf(tpl *core.RelationTuple) *time.Time {
+	if tpl.OptionalExpirationTime == nil {
+		return nil
+	}
+
+	expiration := tpl.OptionalExpirationTime.AsTime()
+	return &expiration
+}
+
+// ExpirationFrom converts an expiration time loaded from a nullable column into its protobuf form.
+func ExpirationFrom(expiration sql.NullTime) *timestamppb.Timestamp {
+	if !expiration.Valid {
+		return nil
+	}
+	return timestamppb.New(expiration.Time)
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"context"
+	"time"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+const (
+	// DefaultRetryInitialBackoff is the default wait before the first retry of a transaction.
+	DefaultRetryInitialBackoff = 10 * time.Millisecond
+
+	// DefaultRetryMaxBackoff is the default longest wait between retries of a transaction.
+	DefaultRetryMaxBackoff = time.Second
+
+	// retryJitterFactor is the fraction by which each backoff is randomly adjusted, so that
+	// transactions which conflicted with each other do not retry in lockstep.
+	retryJitterFactor = 0.5
+)
+
+// RetryPolicy is the retry budget of transactions which fail with a retryable error, such as a
+// serialization failure or deadlock.
+type RetryPolicy struct {
+	// MaxRetries is the number of times a transaction is retried after its first attempt.
+	MaxRetries uint8
+
+	// InitialBackoff is the wait before the first retry, which is doubled before each further
+	// retry up to MaxBackoff. No wait is made if it is zero.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the longest wait between retries.
+	MaxBackoff time.Duration
+}
+
+// backoff returns the jittered wait before the given retry, numbered from zero.
+func (p RetryPolicy) backoff(retry uint8) time.Duration {
+	if p.InitialBackoff <= 0 {
+		return 0
+	}
+
+	backoff := p.InitialBackoff
+	for i := uint8(0); i < retry && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return WithJitter(retryJitterFactor, backoff)
+}
+
+// RetryTx runs the transaction function, retrying it with backoff for as long as it fails with an
+// error for which retryable returns true and the policy allows. It returns the number of retries
+// made, and a datastore.ErrMaxRetriesExceeded wrapping the last error if none succeeded.
+func RetryTx(ctx context.Context, policy RetryPolicy, retryable func(error) bool, fn func(context.Context) error) (uint8, error) {
+	var retries uint8
+	for {
+		err := fn(ctx)
+		if err == nil || !retryable(err) {
+			return retries, err
+		}
+
+		if retries >= policy.MaxRetries {
+			return retries, datastore.NewMaxRetriesExceededErr(int(retries)+1, err)
+		}
+
+		backoff := policy.backoff(retries)
+		log.Ctx(ctx).Debug().Err(err).Uint8("retry", retries+1).Dur("backoff", backoff).Msg("retrying transaction")
+
+		if backoff > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return retries, err
+			}
+		}
+		retries++
+	}
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+var (
+	errRetryable = errors.New("retryable")
+	errFatal     = errors.New("fatal")
+)
+
+func isRetryable(err error) bool {
+	return errors.Is(err, errRetryable)
+}
+
+// failing returns a transaction function which fails with the errors in order, and then succeeds.
+func failing(errs ...error) (func(context.Context) error, *int) {
+	attempts := 0
+	return func(context.Context) error {
+		attempts++
+		if attempts <= len(errs) {
+			return errs[attempts-1]
+		}
+		return nil
+	}, &attempts
+}
+
+func TestRetryTx(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+
+	fn, attempts := failing(errRetryable, errRetryable)
+	retries, err := RetryTx(context.Background(), policy, isRetryable, fn)
+	require.NoError(t, err)
+	require.Equal(t, uint8(2), retries)
+	require.Equal(t, 3, *attempts)
+
+	fn, attempts = failing(errRetryable, errFatal)
+	_, err = RetryTx(context.Background(), policy, isRetryable, fn)
+	require.ErrorIs(t, err, errFatal)
+	require.Equal(t, 2, *attempts)
+
+	fn, attempts = failing(errRetryable, errRetryable, errRetryable, errRetryable, errRetryable)
+	retries, err = RetryTx(context.Background(), policy, isRetryable, fn)
+	require.ErrorIs(t, err, errRetryable)
+	require.Equal(t, uint8(3), retries)
+	require.Equal(t, 4, *attempts)
+
+	var exceeded datastore.ErrMaxRetriesExceeded
+	require.ErrorAs(t, err, &exceeded)
+	require.Equal(t, 4, exceeded.Attempts())
+}
+
+func TestRetryTxCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fn, attempts := failing(errRetryable, errRetryable)
+	_, err := RetryTx(ctx, RetryPolicy{MaxRetries: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour}, isRetryable, fn)
+	require.ErrorIs(t, err, errRetryable)
+	require.Equal(t, 1, *attempts)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	for retry, expected := range []time.Duration{10, 20, 40, 50, 50} {
+		backoff := policy.backoff(uint8(retry))
+		require.GreaterOrEqual(t, backoff, expected*time.Millisecond/2)
+		require.LessOrEqual(t, backoff, expected*time.Millisecond*3/2)
+	}
+
+	require.Zero(t, RetryPolicy{}.backoff(3))
+}
@@ -21,6 +21,7 @@ type RemoteClockRevisions struct {
 
 	gcWindowNanos          int64
 	nowFunc                RemoteNowFunction
+	optimizedNowFunc       RemoteNowFunction
 	followerReadDelayNanos int64
 	quantizationNanos      int64
 }
@@ -44,7 +45,12 @@ func NewRemoteClockRevisions(gcWindow, maxRevisionStaleness, followerReadDelay,
 }
 
 func (rcr *RemoteClockRevisions) optimizedRevisionFunc(ctx context.Context) (datastore.Revision, time.Duration, error) {
-	nowHLC, err := rcr.nowFunc(ctx)
+	nowFunc := rcr.nowFunc
+	if rcr.optimizedNowFunc != nil {
+		nowFunc = rcr.optimizedNowFunc
+	}
+
+	nowHLC, err := nowFunc(ctx)
 	if err != nil {
 		return revision.NoRevision, 0, err
 	}
@@ -62,11 +68,27 @@ func (rcr *RemoteClockRevisions) optimizedRevisionFunc(ctx context.Context) (dat
 	return revision.NewFromDecimal(decimal.NewFromInt(quantized)), time.Duration(validForNanos) * time.Nanosecond, nil
 }
 
+// NextOptimizedRevision returns the optimized revision which will be selected once the current
+// quantization window rolls over, which is the start of the next window.
+func (rcr *RemoteClockRevisions) NextOptimizedRevision(ctx context.Context) (datastore.Revision, error) {
+	current, _, err := rcr.optimizedRevisionFunc(ctx)
+	if err != nil {
+		return revision.NoRevision, err
+	}
+	return revision.NewFromDecimal(current.(revision.Decimal).Add(decimal.NewFromInt(rcr.quantizationNanos))), nil
+}
+
 // SetNowFunc sets the function used to determine the head revision
 func (rcr *RemoteClockRevisions) SetNowFunc(nowFunc RemoteNowFunction) {
 	rcr.nowFunc = nowFunc
 }
 
+// SetOptimizedNowFunc sets the function used to determine the revision from which optimized
+// revisions are computed, in place of the head revision.
+func (rcr *RemoteClockRevisions) SetOptimizedNowFunc(nowFunc RemoteNowFunction) {
+	rcr.optimizedNowFunc = nowFunc
+}
+
 func (rcr *RemoteClockRevisions) CheckRevision(ctx context.Context, dsRevision datastore.Revision) error {
 	if dsRevision == datastore.NoRevision {
 		return datastore.NewInvalidRevisionErr(dsRevision, datastore.CouldNotDetermineRevision)
@@ -106,6 +106,47 @@ func TestRemoteClockOptimizedRevisions(t *testing.T) {
 	}
 }
 
+func TestRemoteClockOptimizedNowFunc(t *testing.T) {
+	require := require.New(t)
+
+	rcr := NewRemoteClockRevisions(1*time.Hour, 0, 0, 5*time.Second)
+	rcr.SetNowFunc(func(ctx context.Context) (revision.Decimal, error) {
+		return revision.NewFromDecimal(decimal.NewFromInt(1238 * 1_000_000_000)), nil
+	})
+	rcr.SetOptimizedNowFunc(func(ctx context.Context) (revision.Decimal, error) {
+		return revision.NewFromDecimal(decimal.NewFromInt(1233 * 1_000_000_000)), nil
+	})
+
+	optimized, err := rcr.OptimizedRevision(context.Background())
+	require.NoError(err)
+	require.True(revision.NewFromDecimal(decimal.NewFromInt(1230 * 1_000_000_000)).Equal(optimized))
+
+	// Revisions are still checked against the head revision.
+	require.NoError(rcr.CheckRevision(context.Background(), revision.NewFromDecimal(decimal.NewFromInt(1236*1_000_000_000))))
+}
+
+func TestRemoteClockNextOptimizedRevision(t *testing.T) {
+	require := require.New(t)
+
+	rcr := NewRemoteClockRevisions(1*time.Hour, 0, 5*time.Second, 5*time.Second)
+	remoteClock := clock.NewMock()
+	rcr.clockFn = remoteClock
+	rcr.SetNowFunc(func(ctx context.Context) (revision.Decimal, error) {
+		return revision.NewFromDecimal(decimal.NewFromInt(remoteClock.Now().UnixNano())), nil
+	})
+
+	remoteClock.Set(time.Unix(1234, 0))
+	next, err := rcr.NextOptimizedRevision(context.Background())
+	require.NoError(err)
+	require.True(revision.NewFromDecimal(decimal.NewFromInt(1230*1_000_000_000)).Equal(next), "unexpected next revision %s", next)
+
+	// The next revision is the one selected once the window rolls over.
+	remoteClock.Set(time.Unix(1235, 0))
+	optimized, err := rcr.OptimizedRevision(context.Background())
+	require.NoError(err)
+	require.True(next.Equal(optimized))
+}
+
 func TestRemoteClockCheckRevisions(t *testing.T) {
 	testCases := []struct {
 		name                string
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"regexp"
 	"runtime"
+	"sort"
 
 	sq "github.com/Masterminds/squirrel"
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
@@ -44,8 +46,15 @@ var (
 	// ID.
 	SubObjectIDKey = attribute.Key("authzed.com/spicedb/sql/subObjectId")
 
+	// MetadataKeyKey is a tracing attribute representing a relationship metadata key.
+	MetadataKeyKey = attribute.Key("authzed.com/spicedb/sql/metadataKey")
+
 	limitKey = attribute.Key("authzed.com/spicedb/sql/limit")
 
+	// metadataKeyRegex matches the keys allowed in relationship metadata, as validated on
+	// RelationTuple.
+	metadataKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,63}$`)
+
 	tracer = otel.Tracer("spicedb/internal/datastore/common")
 )
 
@@ -59,6 +68,26 @@ type SchemaInformation struct {
 	ColUsersetObjectID  string
 	ColUsersetRelation  string
 	ColCaveatName       string
+
+	// ColExpiration is the optional column holding the expiration time of a relationship. If
+	// set along with ExpirationCutoff, expired relationships are filtered out of queries.
+	ColExpiration string
+
+	// ExpirationCutoff is the SQL expression of the time at which relationships are read, against
+	// which ColExpiration is compared. Readers of a revision set it to the time of the revision,
+	// rather than the current time, so that every read of a revision finds the same relationships.
+	ExpirationCutoff sq.Sqlizer
+
+	// MetadataValue returns the SQL expression selecting the value for a key from the metadata of
+	// a relationship, along with its arguments. It is required to filter on metadata.
+	MetadataValue func(key string) (string, []any)
+}
+
+// WithExpirationCutoff returns the schema information with the relationships which expired at or
+// before the cutoff filtered out of queries.
+func (si SchemaInformation) WithExpirationCutoff(cutoff sq.Sqlizer) SchemaInformation {
+	si.ExpirationCutoff = cutoff
+	return si
 }
 
 // SchemaQueryFilterer wraps a SchemaInformation and SelectBuilder to give an opinionated
@@ -71,6 +100,13 @@ type SchemaQueryFilterer struct {
 
 // NewSchemaQueryFilterer creates a new SchemaQueryFilterer object.
 func NewSchemaQueryFilterer(schema SchemaInformation, initialQuery sq.SelectBuilder) SchemaQueryFilterer {
+	if schema.ColExpiration != "" && schema.ExpirationCutoff != nil {
+		initialQuery = initialQuery.Where(sq.Or{
+			sq.Eq{schema.ColExpiration: nil},
+			sq.Expr(schema.ColExpiration+" > ?", schema.ExpirationCutoff),
+		})
+	}
+
 	return SchemaQueryFilterer{
 		schema:       schema,
 		queryBuilder: initialQuery,
@@ -152,6 +188,38 @@ func (sqf SchemaQueryFilterer) FilterWithRelationshipsFilter(filter datastore.Re
 		sqf = sqf.FilterWithCaveatName(filter.OptionalCaveatName)
 	}
 
+	if len(filter.OptionalMetadata) > 0 {
+		sqf = sqf.FilterWithMetadata(filter.OptionalMetadata)
+	}
+
+	return sqf
+}
+
+// FilterWithMetadata returns a new SchemaQueryFilterer that is limited to relationships with
+// metadata containing every key in the filter and, for non-empty values, the matching value.
+func (sqf SchemaQueryFilterer) FilterWithMetadata(metadata map[string]string) SchemaQueryFilterer {
+	if sqf.schema.MetadataValue == nil {
+		panic("datastore does not support filtering on metadata")
+	}
+
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		if !metadataKeyRegex.MatchString(key) {
+			panic(fmt.Sprintf("got invalid metadata key %q", key))
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		expr, args := sqf.schema.MetadataValue(key)
+		if value := metadata[key]; value != "" {
+			sqf.queryBuilder = sqf.queryBuilder.Where(expr+" = ?", append(args, value)...)
+		} else {
+			sqf.queryBuilder = sqf.queryBuilder.Where(expr+" IS NOT NULL", args...)
+		}
+		sqf.tracerAttributes = append(sqf.tracerAttributes, MetadataKeyKey.String(key))
+	}
 	return sqf
 }
 
@@ -244,6 +312,53 @@ func (sqf SchemaQueryFilterer) FilterWithCaveatName(caveatName string) SchemaQue
 	return sqf
 }
 
+// SortBySubject returns a new SchemaQueryFilterer whose results are ordered by subject and then
+// by resource, as described by options.BySubject.
+func (sqf SchemaQueryFilterer) SortBySubject() SchemaQueryFilterer {
+	sqf.queryBuilder = sqf.queryBuilder.OrderBy(sqf.subjectSortColumns()...)
+	return sqf
+}
+
+// FilterAfterSubject returns a new SchemaQueryFilterer that is limited to relationships which
+// come after the cursor when ordered by SortBySubject.
+func (sqf SchemaQueryFilterer) FilterAfterSubject(cursor options.Cursor) SchemaQueryFilterer {
+	columns := sqf.subjectSortColumns()
+	values := []string{
+		cursor.Subject.Namespace,
+		cursor.Subject.ObjectId,
+		cursor.Subject.Relation,
+		cursor.ResourceAndRelation.Namespace,
+		cursor.ResourceAndRelation.ObjectId,
+		cursor.ResourceAndRelation.Relation,
+	}
+
+	// The comparison is expanded rather than written as a row comparison, which is not
+	// supported by every datastore.
+	orClause := sq.Or{}
+	for index := range columns {
+		andClause := sq.And{}
+		for prefix := 0; prefix < index; prefix++ {
+			andClause = append(andClause, sq.Eq{columns[prefix]: values[prefix]})
+		}
+		andClause = append(andClause, sq.Gt{columns[index]: values[index]})
+		orClause = append(orClause, andClause)
+	}
+
+	sqf.queryBuilder = sqf.queryBuilder.Where(orClause)
+	return sqf
+}
+
+func (sqf SchemaQueryFilterer) subjectSortColumns() []string {
+	return []string{
+		sqf.schema.ColUsersetNamespace,
+		sqf.schema.ColUsersetObjectID,
+		sqf.schema.ColUsersetRelation,
+		sqf.schema.ColNamespace,
+		sqf.schema.ColObjectID,
+		sqf.schema.ColRelation,
+	}
+}
+
 // FilterToUsersets returns a new SchemaQueryFilterer that is limited to resources with subjects
 // in the specified list of usersets. Nil or empty usersets parameter does not affect the underlying
 // query.
@@ -273,6 +388,11 @@ func (sqf SchemaQueryFilterer) limit(limit uint64) SchemaQueryFilterer {
 	return sqf
 }
 
+// ToSQL renders the filtered query into SQL and its arguments.
+func (sqf SchemaQueryFilterer) ToSQL() (string, []any, error) {
+	return sqf.queryBuilder.ToSql()
+}
+
 // TupleQuerySplitter is a tuple query runner shared by SQL implementations of the datastore.
 type TupleQuerySplitter struct {
 	Executor         ExecuteQueryFunc
@@ -335,3 +455,18 @@ type ExecuteQueryFunc func(ctx context.Context, sql string, args []any) ([]*core
 // TxCleanupFunc is a function that should be executed when the caller of
 // TransactionFactory is done with the transaction.
 type TxCleanupFunc func(context.Context)
+
+// ListDefinitionsPage orders a query of namespace or caveat definitions by the name column, and
+// restricts it to the page of definitions selected by the list options.
+func ListDefinitionsPage(query sq.SelectBuilder, nameColumn string, opts ...options.ListOptionsOption) sq.SelectBuilder {
+	listOpts := options.NewListOptionsWithOptions(opts...)
+
+	query = query.OrderBy(nameColumn)
+	if listOpts.ListAfter != "" {
+		query = query.Where(sq.Gt{nameColumn: listOpts.ListAfter})
+	}
+	if listOpts.ListLimit != nil {
+		query = query.Limit(*listOpts.ListLimit)
+	}
+	return query
+}
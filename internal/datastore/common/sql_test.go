@@ -287,6 +287,35 @@ func TestSchemaQueryFilterer(t *testing.T) {
 			"SELECT * WHERE ns = ? AND relation = ? AND object_id IN (?, ?) AND subject_ns = ? AND subject_object_id IN (?, ?) AND (subject_relation = ? OR subject_relation = ?)",
 			[]any{"someresourcetype", "somerelation", "someid", "anotherid", "somesubjectype", "somesubjectid", "anothersubjectid", "...", "somesubrel"},
 		},
+		{
+			"metadata filter",
+			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
+				return filterer.FilterWithMetadata(map[string]string{
+					"source": "",
+					"ticket": "ABC-123",
+				})
+			},
+			"SELECT * WHERE metadata->>? IS NOT NULL AND metadata->>? = ?",
+			[]any{"source", "ticket", "ABC-123"},
+		},
+		{
+			"sorted by subject after cursor",
+			func(filterer SchemaQueryFilterer) SchemaQueryFilterer {
+				return filterer.FilterToResourceType("sometype").
+					SortBySubject().
+					FilterAfterSubject(tuple.MustParse("document:doc#viewer@user:tom"))
+			},
+			"SELECT * WHERE ns = ? AND ((subject_ns > ?) OR (subject_ns = ? AND subject_object_id > ?) OR (subject_ns = ? AND subject_object_id = ? AND subject_relation > ?) OR (subject_ns = ? AND subject_object_id = ? AND subject_relation = ? AND ns > ?) OR (subject_ns = ? AND subject_object_id = ? AND subject_relation = ? AND ns = ? AND object_id > ?) OR (subject_ns = ? AND subject_object_id = ? AND subject_relation = ? AND ns = ? AND object_id = ? AND relation > ?)) ORDER BY subject_ns, subject_object_id, subject_relation, ns, object_id, relation",
+			[]any{
+				"sometype",
+				"user",
+				"user", "tom",
+				"user", "tom", "...",
+				"user", "tom", "...", "document",
+				"user", "tom", "...", "document", "doc",
+				"user", "tom", "...", "document", "doc", "viewer",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -300,6 +329,9 @@ func TestSchemaQueryFilterer(t *testing.T) {
 				ColUsersetNamespace: "subject_ns",
 				ColUsersetObjectID:  "subject_object_id",
 				ColUsersetRelation:  "subject_relation",
+				MetadataValue: func(key string) (string, []any) {
+					return "metadata->>?", []any{key}
+				},
 			}, base)
 
 			sql, args, err := test.run(filterer).queryBuilder.ToSql()
@@ -309,3 +341,22 @@ func TestSchemaQueryFilterer(t *testing.T) {
 		})
 	}
 }
+
+func TestSchemaQueryFiltererExpirationCutoff(t *testing.T) {
+	schema := SchemaInformation{
+		TableTuple:    "tuple",
+		ColNamespace:  "ns",
+		ColExpiration: "expiration",
+	}
+
+	sql, args, err := NewSchemaQueryFilterer(schema, sq.Select("*")).FilterToResourceType("sometype").queryBuilder.ToSql()
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * WHERE ns = ?", sql, "expiration is not filtered without a cutoff")
+	require.Equal(t, []any{"sometype"}, args)
+
+	cutoff := sq.Expr("(SELECT timestamp FROM transactions WHERE id = ?)", 42)
+	sql, args, err = NewSchemaQueryFilterer(schema.WithExpirationCutoff(cutoff), sq.Select("*")).FilterToResourceType("sometype").queryBuilder.ToSql()
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * WHERE (expiration IS NULL OR expiration > (SELECT timestamp FROM transactions WHERE id = ?)) AND ns = ?", sql)
+	require.Equal(t, []any{42, "sometype"}, args)
+}
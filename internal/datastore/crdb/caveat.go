@@ -9,6 +9,8 @@ import (
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v4"
 
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
@@ -22,7 +24,7 @@ var (
 	)
 	writeCaveat  = psql.Insert(tableCaveat).Columns(colCaveatName, colCaveatDefinition).Suffix(upsertCaveatSuffix)
 	readCaveat   = psql.Select(colCaveatDefinition, colTimestamp).From(tableCaveat)
-	listCaveat   = psql.Select(colCaveatName, colCaveatDefinition).From(tableCaveat).OrderBy(colCaveatName)
+	listCaveat   = psql.Select(colCaveatName, colCaveatDefinition).From(tableCaveat)
 	deleteCaveat = psql.Delete(tableCaveat)
 )
 
@@ -62,13 +64,20 @@ func (cr *crdbReader) ReadCaveatByName(ctx context.Context, name string) (*core.
 	return loaded, revisionFromTimestamp(timestamp), nil
 }
 
-func (cr *crdbReader) ListCaveats(ctx context.Context, caveatNames ...string) ([]*core.CaveatDefinition, error) {
-	caveatsWithNames := listCaveat
-	if len(caveatNames) > 0 {
-		caveatsWithNames = caveatsWithNames.Where(sq.Eq{colCaveatName: caveatNames})
+func (cr *crdbReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	return cr.loadCaveats(ctx, common.ListDefinitionsPage(listCaveat, colCaveatName, opts...))
+}
+
+func (cr *crdbReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	if len(caveatNames) == 0 {
+		return nil, nil
 	}
 
-	sql, args, err := caveatsWithNames.ToSql()
+	return cr.loadCaveats(ctx, listCaveat.Where(sq.Eq{colCaveatName: caveatNames}))
+}
+
+func (cr *crdbReader) loadCaveats(ctx context.Context, query sq.SelectBuilder) ([]*core.CaveatDefinition, error) {
+	sql, args, err := query.ToSql()
 	if err != nil {
 		return nil, fmt.Errorf(errListCaveats, err)
 	}
@@ -19,6 +19,7 @@ import (
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/datastore/common/revisions"
 	"github.com/authzed/spicedb/internal/datastore/crdb/migrations"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
 	"github.com/authzed/spicedb/internal/datastore/proxy"
 	log "github.com/authzed/spicedb/internal/logging"
@@ -43,6 +44,7 @@ const (
 	tableNamespace    = "namespace_config"
 	tableTuple        = "relation_tuple"
 	tableTransactions = "transactions"
+	tableTxMetadata   = "transaction_metadata"
 	tableCaveat       = "caveat"
 
 	colNamespace         = "namespace"
@@ -58,14 +60,24 @@ const (
 	colCaveatDefinition  = "definition"
 	colCaveatContextName = "caveat_name"
 	colCaveatContext     = "caveat_context"
+	colExpiration        = "expiration"
+	colMetadata          = "metadata"
+	colRegion            = "crdb_region"
 
 	errUnableToInstantiate = "unable to instantiate datastore: %w"
 	errRevision            = "unable to find revision: %w"
 
 	querySelectNow          = "SELECT cluster_logical_timestamp()"
+	querySelectFollowerRead = "SELECT follower_read_timestamp()"
 	queryShowZoneConfig     = "SHOW ZONE CONFIGURATION FOR RANGE default;"
+	queryShowRegions        = "SELECT region FROM [SHOW REGIONS FROM DATABASE]"
 	querySetTransactionTime = "SET TRANSACTION AS OF SYSTEM TIME %s"
 
+	// followerReadTimestamp allows a read to be served by the nearest replica, at the cost of
+	// reading data up to followerReadStaleness old.
+	followerReadTimestamp = "follower_read_timestamp()"
+	followerReadStaleness = 4800 * time.Millisecond
+
 	livingTupleConstraint = "pk_relation_tuple"
 )
 
@@ -113,6 +125,12 @@ func newCRDBDatastore(url string, options ...Option) (datastore.Datastore, error
 		)
 	}
 
+	if len(config.namespaceRegions) > 0 {
+		if err := checkNamespaceRegions(pool, config.namespaceRegions); err != nil {
+			return nil, fmt.Errorf(errUnableToInstantiate, err)
+		}
+	}
+
 	var keyer overlapKeyer
 	switch config.overlapStrategy {
 	case overlapStrategyStatic:
@@ -145,13 +163,22 @@ func newCRDBDatastore(url string, options ...Option) (datastore.Datastore, error
 		url,
 		pool,
 		config.watchBufferLength,
+		config.watchResolvedInterval,
 		keyer,
 		config.splitAtUsersetCount,
-		executeWithMaxRetries(config.maxRetries),
+		executeWithRetryPolicy(common.RetryPolicy{
+			MaxRetries:     config.maxRetries,
+			InitialBackoff: config.retryInitialBackoff,
+			MaxBackoff:     config.retryMaxBackoff,
+		}),
 		config.disableStats,
+		config.namespaceRegions,
 	}
 
 	ds.RemoteClockRevisions.SetNowFunc(ds.headRevisionInternal)
+	if config.followerReads {
+		ds.RemoteClockRevisions.SetOptimizedNowFunc(ds.followerReadRevision)
+	}
 
 	return ds, nil
 }
@@ -194,16 +221,20 @@ type crdbDatastore struct {
 	*revisions.RemoteClockRevisions
 	revision.DecimalDecoder
 
-	dburl             string
-	pool              *pgxpool.Pool
-	watchBufferLength uint16
-	writeOverlapKeyer overlapKeyer
-	usersetBatchSize  uint16
-	execute           executeTxRetryFunc
-	disableStats      bool
+	dburl                 string
+	pool                  *pgxpool.Pool
+	watchBufferLength     uint16
+	watchResolvedInterval time.Duration
+	writeOverlapKeyer     overlapKeyer
+	usersetBatchSize      uint16
+	execute               executeTxRetryFunc
+	disableStats          bool
+	namespaceRegions      map[string]string
 }
 
-func (cds *crdbDatastore) SnapshotReader(rev datastore.Revision) datastore.Reader {
+func (cds *crdbDatastore) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	txTime := transactionTime(rev, options.NewSnapshotReaderOptionsWithOptions(opts...))
+
 	createTxFunc := func(ctx context.Context) (pgx.Tx, common.TxCleanupFunc, error) {
 		tx, err := cds.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
 		if err != nil {
@@ -216,7 +247,7 @@ func (cds *crdbDatastore) SnapshotReader(rev datastore.Revision) datastore.Reade
 			}
 		}
 
-		setTxTime := fmt.Sprintf(querySetTransactionTime, rev)
+		setTxTime := fmt.Sprintf(querySetTransactionTime, txTime)
 		if _, err := tx.Exec(ctx, setTxTime); err != nil {
 			if err := tx.Rollback(ctx); err != nil {
 				log.Warn().Err(err).Msg(
@@ -234,7 +265,35 @@ func (cds *crdbDatastore) SnapshotReader(rev datastore.Revision) datastore.Reade
 		UsersetBatchSize: cds.usersetBatchSize,
 	}
 
-	return &crdbReader{createTxFunc, querySplitter, noOverlapKeyer, nil, cds.execute}
+	return &crdbReader{
+		createTxFunc,
+		querySplitter,
+		noOverlapKeyer,
+		nil,
+		cds.execute,
+		schema.WithExpirationCutoff(expirationCutoff(rev)),
+	}
+}
+
+// transactionTime returns the AS OF SYSTEM TIME expression for a snapshot read at the revision.
+// Bounded staleness reads which tolerate the follower read lag are served by the nearest replica.
+// All other reads are made at exactly the revision, which CockroachDB serves from a follower
+// anyway once the revision is older than the closed timestamp.
+func transactionTime(rev datastore.Revision, opts *options.SnapshotReaderOptions) string {
+	if opts.Consistency == options.BoundedStaleness && opts.MaxStaleness >= followerReadStaleness {
+		return followerReadTimestamp
+	}
+	return rev.String()
+}
+
+// expirationCutoff returns the time of the revision, by which relationships which have expired
+// are filtered out of reads of it, whichever time they are served at.
+func expirationCutoff(rev datastore.Revision) sq.Sqlizer {
+	decimalRev, ok := rev.(revision.Decimal)
+	if !ok {
+		return sq.Expr("now()")
+	}
+	return sq.Expr("?", time.Unix(0, decimalRev.IntPart()).UTC())
 }
 
 func noCleanup(context.Context) {}
@@ -242,7 +301,10 @@ func noCleanup(context.Context) {}
 func (cds *crdbDatastore) ReadWriteTx(
 	ctx context.Context,
 	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
+	config := options.NewRWTOptionsWithOptions(opts...)
+
 	var commitTimestamp revision.Decimal
 	if err := cds.execute(ctx, func(ctx context.Context) error {
 		return cds.pool.BeginTxFunc(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
@@ -262,15 +324,24 @@ func (cds *crdbDatastore) ReadWriteTx(
 					cds.writeOverlapKeyer,
 					make(keySet),
 					executeOnce,
+					// The transaction reads and writes at now(), its own timestamp.
+					schema.WithExpirationCutoff(sq.Expr("now()")),
 				},
 				tx,
 				0,
+				cds.namespaceRegions,
 			}
 
 			if err := f(rwt); err != nil {
 				return err
 			}
 
+			if len(config.Metadata) > 0 {
+				if _, err := tx.Exec(ctx, queryWriteTxMetadata, config.Metadata); err != nil {
+					return fmt.Errorf("error writing transaction metadata: %w", err)
+				}
+			}
+
 			// Touching the transaction key happens last so that the "write intent" for
 			// the transaction as a whole lands in a range for the affected tuples.
 			for k := range rwt.overlapKeySet {
@@ -303,6 +374,18 @@ func (cds *crdbDatastore) ReadWriteTx(
 	return commitTimestamp, nil
 }
 
+// BulkLoad writes all relationships from the source in a single transaction, using multi-row
+// inserts of common.DefaultBulkLoadBatchSize relationships each.
+func (cds *crdbDatastore) BulkLoad(
+	ctx context.Context,
+	source datastore.BulkWriteRelationshipSource,
+) (datastore.Revision, error) {
+	return cds.ReadWriteTx(ctx, common.SingleAttemptTxFunc(func(rwt datastore.ReadWriteTransaction) error {
+		_, err := common.BulkLoadInBatches(ctx, source, common.DefaultBulkLoadBatchSize, rwt.WriteRelationships)
+		return err
+	}))
+}
+
 func (cds *crdbDatastore) IsReady(ctx context.Context) (bool, error) {
 	headMigration, err := migrations.CRDBMigrations.HeadRevision()
 	if err != nil {
@@ -349,8 +432,27 @@ func (cds *crdbDatastore) headRevisionInternal(ctx context.Context) (revision.De
 	return hlcNow, err
 }
 
+// followerReadRevision returns the revision of the follower read timestamp of the cluster, which
+// is the most recent time at which reads can be served by the nearest replica.
+func (cds *crdbDatastore) followerReadRevision(ctx context.Context) (revision.Decimal, error) {
+	ctx, span := tracer.Start(ctx, "followerReadRevision")
+	defer span.End()
+
+	var followerRead time.Time
+	if err := cds.pool.QueryRow(ctx, querySelectFollowerRead).Scan(&followerRead); err != nil {
+		return revision.NoRevision, fmt.Errorf(errRevision, err)
+	}
+
+	return revision.NewFromDecimal(decimal.NewFromInt(followerRead.UnixNano())), nil
+}
+
 func (cds *crdbDatastore) Features(ctx context.Context) (*datastore.Features, error) {
-	var features datastore.Features
+	features := datastore.Features{
+		Caveats:                datastore.Feature{Enabled: true},
+		BulkLoad:               datastore.Feature{Enabled: true},
+		ReverseQuerySort:       datastore.Feature{Enabled: true},
+		RelationshipExpiration: datastore.Feature{Enabled: true},
+	}
 
 	head, err := cds.HeadRevision(ctx)
 	if err != nil {
@@ -362,7 +464,7 @@ func (cds *crdbDatastore) Features(ctx context.Context) (*datastore.Features, er
 	streamCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	time.AfterFunc(1*time.Second, cancel)
-	_, err = cds.pool.Exec(streamCtx, fmt.Sprintf(queryChangefeed, tableTuple, head))
+	_, err = cds.pool.Exec(streamCtx, fmt.Sprintf(queryChangefeed, changefeedTables, head, cds.watchResolvedInterval))
 	if err != nil && errors.Is(err, context.Canceled) {
 		features.Watch.Enabled = true
 		features.Watch.Reason = ""
@@ -372,6 +474,9 @@ func (cds *crdbDatastore) Features(ctx context.Context) (*datastore.Features, er
 	}
 	<-streamCtx.Done()
 
+	// Checkpoints are sent from the resolved timestamps of the changefeed.
+	features.WatchCheckpoints = features.Watch
+
 	return &features, nil
 }
 
@@ -408,6 +513,35 @@ func readClusterTTLNanos(conn *pgxpool.Pool) (int64, error) {
 	return gcSeconds * 1_000_000_000, nil
 }
 
+// checkNamespaceRegions ensures that every region to which a namespace is mapped is a region of
+// the database, so that relationships can be homed in it.
+func checkNamespaceRegions(conn *pgxpool.Pool, namespaceRegions map[string]string) error {
+	rows, err := conn.Query(context.Background(), queryShowRegions)
+	if err != nil {
+		return fmt.Errorf("unable to read database regions: %w", err)
+	}
+	defer rows.Close()
+
+	regions := make(map[string]struct{})
+	for rows.Next() {
+		var region string
+		if err := rows.Scan(&region); err != nil {
+			return fmt.Errorf("unable to read database regions: %w", err)
+		}
+		regions[region] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("unable to read database regions: %w", err)
+	}
+
+	for namespace, region := range namespaceRegions {
+		if _, ok := regions[region]; !ok {
+			return fmt.Errorf("namespace %s is mapped to region %s, which is not a region of the database", namespace, region)
+		}
+	}
+	return nil
+}
+
 func revisionFromTimestamp(t time.Time) revision.Decimal {
 	return revision.NewFromDecimal(decimal.NewFromInt(t.UnixNano()))
 }
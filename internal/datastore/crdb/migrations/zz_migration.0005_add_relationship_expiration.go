@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const addRelationshipExpiration = `ALTER TABLE relation_tuple
+	ADD COLUMN expiration TIMESTAMPTZ;`
+
+func init() {
+	err := CRDBMigrations.Register("add-relationship-expiration", "add-caveats", addRelationshipExpirationFunc, noAtomicMigration)
+	if err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
+
+func addRelationshipExpirationFunc(ctx context.Context, conn *pgx.Conn) error {
+	_, err := conn.Exec(ctx, addRelationshipExpiration)
+	return err
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const addRelationshipMetadata = `ALTER TABLE relation_tuple
+	ADD COLUMN metadata JSONB;`
+
+func init() {
+	err := CRDBMigrations.Register("add-relationship-metadata", "add-relationship-expiration", addRelationshipMetadataFunc, noAtomicMigration)
+	if err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
+
+func addRelationshipMetadataFunc(ctx context.Context, conn *pgx.Conn) error {
+	_, err := conn.Exec(ctx, addRelationshipMetadata)
+	return err
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const createTransactionMetadata = `CREATE TABLE transaction_metadata (
+    key UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    metadata JSONB NOT NULL
+);`
+
+func init() {
+	if err := CRDBMigrations.Register("add-transaction-metadata", "add-relationship-metadata", noNonAtomicMigration, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, createTransactionMetadata)
+		return err
+	}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
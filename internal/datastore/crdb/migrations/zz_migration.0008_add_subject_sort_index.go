@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const createSubjectSortIndex = `CREATE INDEX ix_relation_tuple_by_subject_and_resource
+	ON relation_tuple (userset_namespace, userset_object_id, userset_relation, namespace, object_id, relation)`
+
+func init() {
+	if err := CRDBMigrations.Register("add-subject-sort-index", "add-transaction-metadata", noNonAtomicMigration, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, createSubjectSortIndex)
+		return err
+	}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
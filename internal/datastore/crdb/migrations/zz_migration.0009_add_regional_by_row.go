@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	queryCountRegions = `SELECT count(*) FROM [SHOW REGIONS FROM DATABASE]`
+
+	// setRelationTupleRegionalByRow adds the hidden crdb_region column, in which the region of
+	// each relationship is written, and partitions the table and its indexes by it.
+	setRelationTupleRegionalByRow = `ALTER TABLE relation_tuple SET LOCALITY REGIONAL BY ROW`
+)
+
+func init() {
+	err := CRDBMigrations.Register("add-regional-by-row", "add-subject-sort-index", addRegionalByRowFunc, noAtomicMigration)
+	if err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
+
+// addRegionalByRowFunc homes each relationship in a region of a multi-region database. A database
+// without regions is left unchanged.
+func addRegionalByRowFunc(ctx context.Context, conn *pgx.Conn) error {
+	var regions int
+	if err := conn.QueryRow(ctx, queryCountRegions).Scan(&regions); err != nil {
+		return fmt.Errorf("unable to read database regions: %w", err)
+	}
+	if regions == 0 {
+		return nil
+	}
+
+	_, err := conn.Exec(ctx, setRelationTupleRegionalByRow)
+	return err
+}
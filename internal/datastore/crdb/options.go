@@ -3,6 +3,8 @@ package crdb
 import (
 	"fmt"
 	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
 )
 
 type crdbOptions struct {
@@ -13,21 +15,27 @@ type crdbOptions struct {
 	maxOpenConns            *int
 
 	watchBufferLength           uint16
+	watchResolvedInterval       time.Duration
 	revisionQuantization        time.Duration
 	followerReadDelay           time.Duration
+	followerReads               bool
 	maxRevisionStalenessPercent float64
 	gcWindow                    time.Duration
 	maxRetries                  uint8
+	retryInitialBackoff         time.Duration
+	retryMaxBackoff             time.Duration
 	splitAtUsersetCount         uint16
 	overlapStrategy             string
 	overlapKey                  string
 	disableStats                bool
+	namespaceRegions            map[string]string
 
 	enablePrometheusStats bool
 }
 
 const (
 	errQuantizationTooLarge = "revision quantization (%s) must be less than GC window (%s)"
+	errResolvedInterval     = "watch resolved interval (%s) must be greater than zero"
 
 	overlapStrategyPrefix   = "prefix"
 	overlapStrategyStatic   = "static"
@@ -54,11 +62,14 @@ func generateConfig(options []Option) (crdbOptions, error) {
 	computed := crdbOptions{
 		gcWindow:                    24 * time.Hour,
 		watchBufferLength:           defaultWatchBufferLength,
+		watchResolvedInterval:       common.WatchCheckpointInterval,
 		revisionQuantization:        defaultRevisionQuantization,
 		followerReadDelay:           defaultFollowerReadDelay,
 		maxRevisionStalenessPercent: defaultMaxRevisionStalenessPercent,
 		splitAtUsersetCount:         defaultSplitSize,
 		maxRetries:                  defaultMaxRetries,
+		retryInitialBackoff:         common.DefaultRetryInitialBackoff,
+		retryMaxBackoff:             common.DefaultRetryMaxBackoff,
 		overlapKey:                  defaultOverlapKey,
 		overlapStrategy:             defaultOverlapStrategy,
 		disableStats:                false,
@@ -78,6 +89,10 @@ func generateConfig(options []Option) (crdbOptions, error) {
 		)
 	}
 
+	if computed.watchResolvedInterval <= 0 {
+		return computed, fmt.Errorf(errResolvedInterval, computed.watchResolvedInterval)
+	}
+
 	return computed, nil
 }
 
@@ -159,6 +174,18 @@ func WatchBufferLength(watchBufferLength uint16) Option {
 	}
 }
 
+// WatchResolvedInterval is the interval at which the changefeeds of watches
+// emit resolved timestamps. Changes are sent once a resolved timestamp has
+// passed them, so a shorter interval lowers the latency of watches at the cost
+// of more frequent checkpoints.
+//
+// This value defaults to 1 second.
+func WatchResolvedInterval(interval time.Duration) Option {
+	return func(po *crdbOptions) {
+		po.watchResolvedInterval = interval
+	}
+}
+
 // RevisionQuantization is the time bucket size to which advertised revisions
 // will be rounded.
 //
@@ -178,6 +205,18 @@ func FollowerReadDelay(delay time.Duration) Option {
 	}
 }
 
+// FollowerReads computes the revisions of reads which minimize latency from
+// the follower read timestamp of the cluster, rather than from the current
+// time, so that they are served by the nearest replica. The follower read
+// delay is applied on top of the follower read timestamp.
+//
+// This value defaults to false.
+func FollowerReads(enabled bool) Option {
+	return func(po *crdbOptions) {
+		po.followerReads = enabled
+	}
+}
+
 // MaxRevisionStalenessPercent is the amount of time, expressed as a percentage of
 // the revision quantization window, that a previously computed rounded revision
 // can still be advertised after the next rounded revision would otherwise be ready.
@@ -208,6 +247,25 @@ func MaxRetries(maxRetries uint8) Option {
 	}
 }
 
+// RetryInitialBackoff is the wait before the first client-side retry of a
+// transaction, which doubles with each further retry up to RetryMaxBackoff.
+// Zero retries immediately.
+// Default: 10ms
+func RetryInitialBackoff(backoff time.Duration) Option {
+	return func(po *crdbOptions) {
+		po.retryInitialBackoff = backoff
+	}
+}
+
+// RetryMaxBackoff is the longest wait between client-side retries of a
+// transaction.
+// Default: 1s
+func RetryMaxBackoff(backoff time.Duration) Option {
+	return func(po *crdbOptions) {
+		po.retryMaxBackoff = backoff
+	}
+}
+
 // OverlapStrategy is the strategy used to generate overlap keys on write.
 // Default: 'static'
 func OverlapStrategy(strategy string) Option {
@@ -224,6 +282,17 @@ func OverlapKey(key string) Option {
 	}
 }
 
+// NamespaceRegions maps the resource types of relationships to the regions of the database in
+// which they are homed, once the relationship table is REGIONAL BY ROW. The relationships of
+// other resource types are homed in the region of the gateway node which writes them.
+//
+// This value defaults to no mapping, which leaves the placement of relationships to the database.
+func NamespaceRegions(regions map[string]string) Option {
+	return func(po *crdbOptions) {
+		po.namespaceRegions = regions
+	}
+}
+
 // DisableStats disables recording counts to the stats table
 func DisableStats(disable bool) Option {
 	return func(po *crdbOptions) {
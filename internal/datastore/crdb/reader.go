@@ -17,8 +17,10 @@ import (
 )
 
 const (
-	errUnableToReadConfig     = "unable to read namespace config: %w"
-	errUnableToListNamespaces = "unable to list namespaces: %w"
+	errUnableToReadConfig         = "unable to read namespace config: %w"
+	errUnableToListNamespaces     = "unable to list namespaces: %w"
+	errUnableToCountRelationships = "unable to count relationships: %w"
+	errUnableToListResourceTypes  = "unable to list resource types: %w"
 )
 
 var (
@@ -33,8 +35,14 @@ var (
 		colUsersetRelation,
 		colCaveatContextName,
 		colCaveatContext,
+		colExpiration,
+		colMetadata,
 	).From(tableTuple)
 
+	countTuples = psql.Select("COUNT(*)").From(tableTuple)
+
+	listResourceTypes = psql.Select(colNamespace).Distinct().From(tableTuple).OrderBy(colNamespace)
+
 	schema = common.SchemaInformation{
 		ColNamespace:        colNamespace,
 		ColObjectID:         colObjectID,
@@ -43,15 +51,23 @@ var (
 		ColUsersetObjectID:  colUsersetObjectID,
 		ColUsersetRelation:  colUsersetRelation,
 		ColCaveatName:       colCaveatContextName,
+		ColExpiration:       colExpiration,
+		MetadataValue:       metadataValue,
 	}
 )
 
+// metadataValue selects the value for a key from the JSONB metadata column.
+func metadataValue(key string) (string, []any) {
+	return colMetadata + "->>(?::text)", []any{key}
+}
+
 type crdbReader struct {
 	txSource      pgxcommon.TxFactory
 	querySplitter common.TupleQuerySplitter
 	keyer         overlapKeyer
 	overlapKeySet keySet
 	execute       executeTxRetryFunc
+	schema        common.SchemaInformation
 }
 
 func (cr *crdbReader) ReadNamespace(
@@ -85,7 +101,7 @@ func (cr *crdbReader) ReadNamespace(
 	return config, revisionFromTimestamp(timestamp), nil
 }
 
-func (cr *crdbReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+func (cr *crdbReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
 	var nsDefs []*core.NamespaceDefinition
 	if err := cr.execute(ctx, func(ctx context.Context) error {
 		tx, txCleanup, err := cr.txSource(ctx)
@@ -94,7 +110,7 @@ func (cr *crdbReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefi
 		}
 		defer txCleanup(ctx)
 
-		nsDefs, err = loadAllNamespaces(ctx, tx)
+		nsDefs, err = loadAllNamespaces(ctx, tx, opts...)
 		if err != nil {
 			return err
 		}
@@ -144,7 +160,7 @@ func (cr *crdbReader) QueryRelationships(
 	filter datastore.RelationshipsFilter,
 	opts ...options.QueryOptionsOption,
 ) (iter datastore.RelationshipIterator, err error) {
-	qBuilder := common.NewSchemaQueryFilterer(schema, queryTuples).FilterWithRelationshipsFilter(filter)
+	qBuilder := common.NewSchemaQueryFilterer(cr.schema, queryTuples).FilterWithRelationshipsFilter(filter)
 
 	if err := cr.execute(ctx, func(ctx context.Context) error {
 		iter, err = cr.querySplitter.SplitAndExecuteQuery(ctx, qBuilder, opts...)
@@ -161,7 +177,7 @@ func (cr *crdbReader) ReverseQueryRelationships(
 	subjectsFilter datastore.SubjectsFilter,
 	opts ...options.ReverseQueryOptionsOption,
 ) (iter datastore.RelationshipIterator, err error) {
-	qBuilder := common.NewSchemaQueryFilterer(schema, queryTuples).
+	qBuilder := common.NewSchemaQueryFilterer(cr.schema, queryTuples).
 		FilterWithSubjectsFilter(subjectsFilter)
 
 	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
@@ -172,6 +188,14 @@ func (cr *crdbReader) ReverseQueryRelationships(
 			FilterToRelation(queryOpts.ResRelation.Relation)
 	}
 
+	if queryOpts.ReverseSort == options.BySubject {
+		qBuilder = qBuilder.SortBySubject()
+	}
+
+	if queryOpts.ReverseAfter != nil {
+		qBuilder = qBuilder.FilterAfterSubject(queryOpts.ReverseAfter)
+	}
+
 	err = cr.execute(ctx, func(ctx context.Context) error {
 		iter, err = cr.querySplitter.SplitAndExecuteQuery(
 			ctx,
@@ -184,6 +208,67 @@ func (cr *crdbReader) ReverseQueryRelationships(
 	return
 }
 
+func (cr *crdbReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	sql, args, err := common.NewSchemaQueryFilterer(cr.schema, countTuples).
+		FilterWithRelationshipsFilter(filter).
+		ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+
+	var count int64
+	if err := cr.execute(ctx, func(ctx context.Context) error {
+		tx, txCleanup, err := cr.txSource(ctx)
+		if err != nil {
+			return err
+		}
+		defer txCleanup(ctx)
+
+		return tx.QueryRow(ctx, sql, args...).Scan(&count)
+	}); err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+
+	return uint64(count), nil
+}
+
+func (cr *crdbReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	sql, args, err := common.NewSchemaQueryFilterer(cr.schema, listResourceTypes).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+
+	var resourceTypes []string
+	if err := cr.execute(ctx, func(ctx context.Context) error {
+		resourceTypes = nil
+
+		tx, txCleanup, err := cr.txSource(ctx)
+		if err != nil {
+			return err
+		}
+		defer txCleanup(ctx)
+
+		rows, err := tx.Query(ctx, sql, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var resourceType string
+			if err := rows.Scan(&resourceType); err != nil {
+				return err
+			}
+			resourceTypes = append(resourceTypes, resourceType)
+		}
+		return rows.Err()
+	}); err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+
+	return resourceTypes, nil
+}
+
 func loadNamespace(ctx context.Context, tx pgx.Tx, nsName string) (*core.NamespaceDefinition, time.Time, error) {
 	query := queryReadNamespace.Where(sq.Eq{colNamespace: nsName})
 
@@ -251,8 +336,8 @@ func lookupNamespaces(ctx context.Context, tx pgx.Tx, nsNames []string) ([]*core
 	return nsDefs, nil
 }
 
-func loadAllNamespaces(ctx context.Context, tx pgx.Tx) ([]*core.NamespaceDefinition, error) {
-	query := queryReadNamespace
+func loadAllNamespaces(ctx context.Context, tx pgx.Tx, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	query := common.ListDefinitionsPage(queryReadNamespace, colNamespace, opts...)
 
 	sql, args, err := query.ToSql()
 	if err != nil {
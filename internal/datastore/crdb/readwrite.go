@@ -11,6 +11,7 @@ import (
 	"github.com/jzelinskie/stringz"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/authzed/spicedb/internal/datastore/common"
 	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -41,13 +42,14 @@ var (
 
 type crdbReadWriteTXN struct {
 	*crdbReader
-	tx             pgx.Tx
-	relCountChange int64
+	tx               pgx.Tx
+	relCountChange   int64
+	namespaceRegions map[string]string
 }
 
 var (
 	upsertTupleSuffix = fmt.Sprintf(
-		"ON CONFLICT (%s,%s,%s,%s,%s,%s) DO UPDATE SET %s = now(), %s = excluded.%s, %s = excluded.%s",
+		"ON CONFLICT (%s,%s,%s,%s,%s,%s) DO UPDATE SET %s = now(), %s = excluded.%s, %s = excluded.%s, %s = excluded.%s, %s = excluded.%s",
 		colNamespace,
 		colObjectID,
 		colRelation,
@@ -59,6 +61,10 @@ var (
 		colCaveatContextName,
 		colCaveatContext,
 		colCaveatContext,
+		colExpiration,
+		colExpiration,
+		colMetadata,
+		colMetadata,
 	)
 
 	queryWriteTuple = psql.Insert(tableTuple).Columns(
@@ -70,12 +76,31 @@ var (
 		colUsersetRelation,
 		colCaveatContextName,
 		colCaveatContext,
+		colExpiration,
+		colMetadata,
 	)
 
 	queryTouchTuple = queryWriteTuple.Suffix(upsertTupleSuffix)
 
+	// The regional queries also write the region in which each relationship is homed, which
+	// moves a relationship touched after its namespace is mapped to another region.
+	queryWriteRegionalTuple = queryWriteTuple.Columns(colRegion)
+	queryTouchRegionalTuple = queryWriteRegionalTuple.Suffix(
+		upsertTupleSuffix + fmt.Sprintf(", %s = excluded.%s", colRegion, colRegion),
+	)
+
+	// defaultRegion homes a relationship in the region of the gateway node, as is the default
+	// of a REGIONAL BY ROW table.
+	defaultRegion = sq.Expr("DEFAULT")
+
 	queryDeleteTuples = psql.Delete(tableTuple)
 
+	queryWriteTxMetadata = fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES ($1)",
+		tableTxMetadata,
+		colMetadata,
+	)
+
 	queryTouchTransaction = fmt.Sprintf(
 		"INSERT INTO %s (%s) VALUES ($1::text) ON CONFLICT (%s) DO UPDATE SET %s = now()",
 		tableTransactions,
@@ -92,6 +117,13 @@ func (rwt *crdbReadWriteTXN) WriteRelationships(ctx context.Context, mutations [
 	bulkTouch := queryTouchTuple
 	var bulkTouchCount int64
 
+	if len(rwt.namespaceRegions) > 0 {
+		bulkWrite = queryWriteRegionalTuple
+		bulkTouch = queryTouchRegionalTuple
+	}
+
+	expiredCreates := sq.Or{}
+
 	// Process the actual updates
 	for _, mutation := range mutations {
 		rel := mutation.Tuple
@@ -109,30 +141,13 @@ func (rwt *crdbReadWriteTXN) WriteRelationships(ctx context.Context, mutations [
 		switch mutation.Operation {
 		case core.RelationTupleUpdate_TOUCH:
 			rwt.relCountChange++
-			bulkTouch = bulkTouch.Values(
-				rel.ResourceAndRelation.Namespace,
-				rel.ResourceAndRelation.ObjectId,
-				rel.ResourceAndRelation.Relation,
-				rel.Subject.Namespace,
-				rel.Subject.ObjectId,
-				rel.Subject.Relation,
-				caveatName,
-				caveatContext,
-			)
+			bulkTouch = bulkTouch.Values(rwt.tupleValues(rel, caveatName, caveatContext)...)
 			bulkTouchCount++
 		case core.RelationTupleUpdate_CREATE:
 			rwt.relCountChange++
-			bulkWrite = bulkWrite.Values(
-				rel.ResourceAndRelation.Namespace,
-				rel.ResourceAndRelation.ObjectId,
-				rel.ResourceAndRelation.Relation,
-				rel.Subject.Namespace,
-				rel.Subject.ObjectId,
-				rel.Subject.Relation,
-				caveatName,
-				caveatContext,
-			)
+			bulkWrite = bulkWrite.Values(rwt.tupleValues(rel, caveatName, caveatContext)...)
 			bulkWriteCount++
+			expiredCreates = append(expiredCreates, exactRelationshipClause(rel))
 		case core.RelationTupleUpdate_DELETE:
 			rwt.relCountChange--
 			sql, args, err := queryDeleteTuples.Where(exactRelationshipClause(rel)).ToSql()
@@ -149,6 +164,24 @@ func (rwt *crdbReadWriteTXN) WriteRelationships(ctx context.Context, mutations [
 		}
 	}
 
+	// Expired relationships no longer exist, so they are removed before a CREATE of the same
+	// relationship.
+	if len(expiredCreates) > 0 {
+		sql, args, err := queryDeleteTuples.Where(sq.And{
+			expiredCreates,
+			sq.Expr(colExpiration + " <= now()"),
+		}).ToSql()
+		if err != nil {
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+
+		modified, err := rwt.tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+		rwt.relCountChange -= modified.RowsAffected()
+	}
+
 	bulkUpdateQueries := make([]sq.InsertBuilder, 0, 2)
 	if bulkWriteCount > 0 {
 		bulkUpdateQueries = append(bulkUpdateQueries, bulkWrite)
@@ -177,6 +210,31 @@ func (rwt *crdbReadWriteTXN) WriteRelationships(ctx context.Context, mutations [
 	return nil
 }
 
+// tupleValues returns the values of the columns written for the relationship, which include its
+// region when namespaces are mapped to regions.
+func (rwt *crdbReadWriteTXN) tupleValues(rel *core.RelationTuple, caveatName string, caveatContext map[string]any) []any {
+	values := []any{
+		rel.ResourceAndRelation.Namespace,
+		rel.ResourceAndRelation.ObjectId,
+		rel.ResourceAndRelation.Relation,
+		rel.Subject.Namespace,
+		rel.Subject.ObjectId,
+		rel.Subject.Relation,
+		caveatName,
+		caveatContext,
+		common.ExpirationTimeOf(rel),
+		rel.OptionalMetadata,
+	}
+	if len(rwt.namespaceRegions) == 0 {
+		return values
+	}
+
+	if region, ok := rwt.namespaceRegions[rel.ResourceAndRelation.Namespace]; ok {
+		return append(values, region)
+	}
+	return append(values, defaultRegion)
+}
+
 func exactRelationshipClause(r *core.RelationTuple) sq.Eq {
 	return sq.Eq{
 		colNamespace:        r.ResourceAndRelation.Namespace,
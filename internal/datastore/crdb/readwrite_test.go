@@ -0,0 +1,34 @@
+package crdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestRegionalTupleWrites(t *testing.T) {
+	rel := tuple.MustParse("tenant_a/document:firstdoc#viewer@tenant_a/user:tom")
+	other := tuple.MustParse("tenant_b/document:firstdoc#viewer@tenant_b/user:tom")
+
+	unmapped := &crdbReadWriteTXN{}
+	require.Len(t, unmapped.tupleValues(rel, "", nil), 10)
+
+	mapped := &crdbReadWriteTXN{namespaceRegions: map[string]string{"tenant_a/document": "us-east1"}}
+	values := mapped.tupleValues(rel, "", nil)
+	require.Len(t, values, 11)
+	require.Equal(t, "us-east1", values[10])
+	require.Equal(t, defaultRegion, mapped.tupleValues(other, "", nil)[10])
+
+	sql, args, err := queryTouchRegionalTuple.Values(values...).ToSql()
+	require.NoError(t, err)
+	require.Contains(t, sql, "crdb_region) VALUES")
+	require.Contains(t, sql, "crdb_region = excluded.crdb_region")
+	require.Len(t, args, 11)
+
+	sql, args, err = queryWriteRegionalTuple.Values(mapped.tupleValues(other, "", nil)...).ToSql()
+	require.NoError(t, err)
+	require.Contains(t, sql, ",DEFAULT)")
+	require.Len(t, args, 10)
+}
@@ -0,0 +1,43 @@
+package crdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+func TestTransactionTime(t *testing.T) {
+	rev := revision.NewFromDecimal(decimal.RequireFromString("1234567890.0000000001"))
+
+	cases := []struct {
+		name     string
+		opts     []options.SnapshotReaderOptionsOption
+		expected string
+	}{
+		{"default", nil, rev.String()},
+		{"exact", []options.SnapshotReaderOptionsOption{options.WithConsistency(options.ExactSnapshot)}, rev.String()},
+		{"minimize latency", []options.SnapshotReaderOptionsOption{options.WithConsistency(options.MinimizeLatency)}, rev.String()},
+		{
+			"bounded staleness below follower lag",
+			[]options.SnapshotReaderOptionsOption{options.WithConsistency(options.BoundedStaleness), options.WithMaxStaleness(time.Second)},
+			rev.String(),
+		},
+		{
+			"bounded staleness above follower lag",
+			[]options.SnapshotReaderOptionsOption{options.WithConsistency(options.BoundedStaleness), options.WithMaxStaleness(10 * time.Second)},
+			followerReadTimestamp,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, transactionTime(rev, options.NewSnapshotReaderOptionsWithOptions(tc.opts...)))
+		})
+	}
+}
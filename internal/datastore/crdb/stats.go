@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"strings"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/shopspring/decimal"
@@ -26,6 +27,12 @@ var (
 	queryReadUniqueID         = psql.Select(colUniqueID).From(tableMetadata)
 	queryRelationshipEstimate = fmt.Sprintf("SELECT COALESCE(SUM(%s), 0) FROM %s", colCount, tableCounters)
 
+	// The table statistics collected by CockroachDB include the distinct counts of each index
+	// prefix, from which the number of distinct resources and subjects are estimated.
+	queryTableStatistics = fmt.Sprintf("SELECT column_names, distinct_count FROM [SHOW STATISTICS FOR TABLE %s] ORDER BY created DESC", tableTuple)
+	resourceColumns      = strings.Join([]string{colNamespace, colObjectID}, ",")
+	subjectColumns       = strings.Join([]string{colUsersetObjectID, colUsersetNamespace}, ",")
+
 	upsertCounterQuery = psql.Insert(tableCounters).Columns(
 		colID,
 		colCount,
@@ -41,6 +48,7 @@ func (cds *crdbDatastore) Statistics(ctx context.Context) (datastore.Stats, erro
 	var uniqueID string
 	var nsDefs []*corev1.NamespaceDefinition
 	var relCount uint64
+	var distinctResources, distinctSubjects uint64
 	if err := cds.pool.BeginTxFunc(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
 		if err := tx.QueryRow(ctx, sql, args...).Scan(&uniqueID); err != nil {
 			return fmt.Errorf("unable to query unique ID: %w", err)
@@ -55,6 +63,11 @@ func (cds *crdbDatastore) Statistics(ctx context.Context) (datastore.Stats, erro
 			return fmt.Errorf("unable to read namespaces: %w", err)
 		}
 
+		distinctResources, distinctSubjects, err = readDistinctEstimates(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("unable to read table statistics: %w", err)
+		}
+
 		return nil
 	}); err != nil {
 		return datastore.Stats{}, err
@@ -63,10 +76,45 @@ func (cds *crdbDatastore) Statistics(ctx context.Context) (datastore.Stats, erro
 	return datastore.Stats{
 		UniqueID:                   uniqueID,
 		EstimatedRelationshipCount: relCount,
+		EstimatedDistinctResources: distinctResources,
+		EstimatedDistinctSubjects:  distinctSubjects,
 		ObjectTypeStatistics:       datastore.ComputeObjectTypeStats(nsDefs),
 	}, nil
 }
 
+// readDistinctEstimates reads the most recent distinct counts for the resource and subject
+// columns of the relationship table.
+func readDistinctEstimates(ctx context.Context, tx pgx.Tx) (resources uint64, subjects uint64, err error) {
+	rows, err := tx.Query(ctx, queryTableStatistics)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	seen := make(map[string]struct{})
+	for rows.Next() {
+		var columnNames []string
+		var distinctCount uint64
+		if err := rows.Scan(&columnNames, &distinctCount); err != nil {
+			return 0, 0, err
+		}
+
+		columns := strings.Join(columnNames, ",")
+		if _, ok := seen[columns]; ok {
+			continue
+		}
+		seen[columns] = struct{}{}
+
+		switch columns {
+		case resourceColumns:
+			resources = distinctCount
+		case subjectColumns:
+			subjects = distinctCount
+		}
+	}
+	return resources, subjects, rows.Err()
+}
+
 func updateCounter(ctx context.Context, tx pgx.Tx, change int64) (revision.Decimal, error) {
 	counterID := make([]byte, 2)
 	_, err := rand.Read(counterID)
@@ -3,13 +3,13 @@ package crdb
 import (
 	"context"
 	"errors"
-	"fmt"
 	"strings"
 
-	log "github.com/authzed/spicedb/internal/logging"
-
 	"github.com/jackc/pgconn"
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	log "github.com/authzed/spicedb/internal/logging"
 )
 
 const (
@@ -23,8 +23,6 @@ const (
 	crdbUnknownSQLState = "XXUUU"
 	// Error message encountered when crdb nodes have large clock skew
 	crdbClockSkewMessage = "cannot specify timestamp in the future"
-
-	errReachedMaxRetries = "maximum retries reached (%d/%d): %w"
 )
 
 var resetHistogram = prometheus.NewHistogram(prometheus.HistogramOpts{
@@ -41,36 +39,24 @@ type innerFunc func(ctx context.Context) error
 
 type executeTxRetryFunc func(context.Context, innerFunc) error
 
-func executeWithMaxRetries(max uint8) executeTxRetryFunc {
+func executeWithRetryPolicy(policy common.RetryPolicy) executeTxRetryFunc {
 	return func(ctx context.Context, fn innerFunc) (err error) {
-		return executeWithResets(ctx, fn, max)
+		return executeWithResets(ctx, fn, policy)
 	}
 }
 
 func executeOnce(ctx context.Context, fn innerFunc) (err error) {
-	return executeWithResets(ctx, fn, 0)
+	return executeWithResets(ctx, fn, common.RetryPolicy{})
 }
 
-// executeWithResets executes transactionFn and resets the tx when ambiguous crdb errors are encountered.
-func executeWithResets(ctx context.Context, fn innerFunc, maxRetries uint8) (err error) {
-	var retries uint8
-	defer func() {
-		resetHistogram.Observe(float64(retries))
-	}()
-
-	for retries = 0; retries <= maxRetries; retries++ {
-		err = fn(ctx)
-		if resettable(ctx, err) {
-			log.Ctx(ctx).Warn().Err(err).Msg("retrying resetteable database error")
-			continue
-		}
-
-		// This can be nil or an un-resettable error.
-		return err
-	}
-
-	// The last error was resettable but we're out of retries
-	return fmt.Errorf(errReachedMaxRetries, retries, maxRetries+1, err)
+// executeWithResets executes transactionFn and resets the tx when ambiguous crdb errors are encountered,
+// backing off between resets as configured by the policy.
+func executeWithResets(ctx context.Context, fn innerFunc, policy common.RetryPolicy) error {
+	retries, err := common.RetryTx(ctx, policy, func(err error) bool {
+		return resettable(ctx, err)
+	}, fn)
+	resetHistogram.Observe(float64(retries))
+	return err
 }
 
 func resettable(ctx context.Context, err error) bool {
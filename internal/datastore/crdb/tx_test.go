@@ -12,6 +12,7 @@ import (
 	"github.com/jackc/pgconn"
 	"github.com/stretchr/testify/require"
 
+	"github.com/authzed/spicedb/internal/datastore/common"
 	testdatastore "github.com/authzed/spicedb/internal/testserver/datastore"
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/datastore/revision"
@@ -36,7 +37,7 @@ func executeWithErrors(errors *[]error, maxRetries uint8) executeTxRetryFunc {
 			return fn(ctx)
 		}
 
-		return executeWithResets(ctx, wrappedFn, maxRetries)
+		return executeWithResets(ctx, wrappedFn, common.RetryPolicy{MaxRetries: maxRetries})
 	}
 }
 
@@ -139,7 +140,7 @@ func TestTxReset(t *testing.T) {
 				return rwt.WriteNamespaces(ctx, testUserNS)
 			})
 			if tt.expectError {
-				require.Error(err)
+				require.ErrorAs(err, &datastore.ErrMaxRetriesExceeded{})
 				require.Equal(datastore.NoRevision, rev)
 			} else {
 				require.NoError(err)
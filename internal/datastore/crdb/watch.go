@@ -6,6 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
 
@@ -13,14 +16,23 @@ import (
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
 
-const queryChangefeed = "EXPERIMENTAL CHANGEFEED FOR %s WITH updated, cursor = '%s', resolved = '1s';"
+// queryChangefeed requests resolved timestamps at the resolved interval of the datastore. Changes
+// are only emitted once a resolved timestamp has passed their revision, and resolved timestamps
+// with no changes to emit are sent as checkpoints.
+const queryChangefeed = "EXPERIMENTAL CHANGEFEED FOR %s WITH updated, cursor = '%s', resolved = '%s';"
+
+// changefeedTables are the tables watched for changes: relationships, and the metadata written
+// by transactions which were supplied with it.
+var changefeedTables = tableTuple + ", " + tableTxMetadata
 
 type changeDetails struct {
 	Resolved string
 	Updated  string
 	After    *struct {
-		CaveatContext map[string]any `json:"caveat_context"`
-		CaveatName    string         `json:"caveat_name"`
+		CaveatContext map[string]any    `json:"caveat_context"`
+		CaveatName    string            `json:"caveat_name"`
+		Expiration    *time.Time        `json:"expiration"`
+		Metadata      map[string]string `json:"metadata"`
 	}
 }
 
@@ -39,7 +51,7 @@ func (cds *crdbDatastore) Watch(ctx context.Context, afterRevision datastore.Rev
 		return updates, errs
 	}
 
-	interpolated := fmt.Sprintf(queryChangefeed, tableTuple, afterRevision)
+	interpolated := fmt.Sprintf(queryChangefeed, changefeedTables, afterRevision, cds.watchResolvedInterval)
 
 	go func() {
 		defer close(updates)
@@ -62,11 +74,11 @@ func (cds *crdbDatastore) Watch(ctx context.Context, afterRevision datastore.Rev
 		defer func() { go changes.Close() }()
 
 		for changes.Next() {
-			var unused interface{}
+			var tableName string
 			var changeJSON []byte
 			var primaryKeyValuesJSON []byte
 
-			if err := changes.Scan(&unused, &primaryKeyValuesJSON, &changeJSON); err != nil {
+			if err := changes.Scan(&tableName, &primaryKeyValuesJSON, &changeJSON); err != nil {
 				if errors.Is(ctx.Err(), context.Canceled) {
 					errs <- datastore.NewWatchCanceledErr()
 				} else {
@@ -90,14 +102,29 @@ func (cds *crdbDatastore) Watch(ctx context.Context, afterRevision datastore.Rev
 				}
 
 				var toEmit []*datastore.RevisionChanges
+				pendingAtResolved := false
 				for ts, values := range pendingChanges {
 					if resolved.GreaterThan(values.Revision) {
 						delete(pendingChanges, ts)
 
-						toEmit = append(toEmit, values)
+						// Transactions which only wrote metadata have no changes to emit.
+						if len(values.Changes) > 0 {
+							toEmit = append(toEmit, values)
+						}
+					} else if resolved.Equal(values.Revision) {
+						pendingAtResolved = true
 					}
 				}
 
+				// If there are no changes to emit, the resolved timestamp is sent as a
+				// checkpoint, unless changes at that timestamp are still pending.
+				if len(toEmit) == 0 && !pendingAtResolved {
+					toEmit = append(toEmit, &datastore.RevisionChanges{
+						Revision:     resolved,
+						IsCheckpoint: true,
+					})
+				}
+
 				sort.Slice(toEmit, func(i, j int) bool {
 					return toEmit[i].Revision.LessThan(toEmit[j].Revision)
 				})
@@ -114,18 +141,33 @@ func (cds *crdbDatastore) Watch(ctx context.Context, afterRevision datastore.Rev
 				continue
 			}
 
-			var pkValues [6]string
-			if err := json.Unmarshal(primaryKeyValuesJSON, &pkValues); err != nil {
-				errs <- err
-				return
-			}
-
 			revision, err := cds.RevisionFromString(details.Updated)
 			if err != nil {
 				errs <- fmt.Errorf("malformed update timestamp: %w", err)
 				return
 			}
 
+			pending, ok := pendingChanges[details.Updated]
+			if !ok {
+				pending = &datastore.RevisionChanges{
+					Revision: revision,
+				}
+				pendingChanges[details.Updated] = pending
+			}
+
+			if tableName == tableTxMetadata {
+				if details.After != nil {
+					pending.Metadata = details.After.Metadata
+				}
+				continue
+			}
+
+			var pkValues [6]string
+			if err := json.Unmarshal(primaryKeyValuesJSON, &pkValues); err != nil {
+				errs <- err
+				return
+			}
+
 			var caveatName string
 			var caveatContext map[string]any
 			if details.After != nil && details.After.CaveatName != "" {
@@ -138,6 +180,15 @@ func (cds *crdbDatastore) Watch(ctx context.Context, afterRevision datastore.Rev
 				return
 			}
 
+			var expiration *timestamppb.Timestamp
+			var metadata map[string]string
+			if details.After != nil {
+				if details.After.Expiration != nil {
+					expiration = timestamppb.New(*details.After.Expiration)
+				}
+				metadata = details.After.Metadata
+			}
+
 			oneChange := &core.RelationTupleUpdate{
 				Tuple: &core.RelationTuple{
 					ResourceAndRelation: &core.ObjectAndRelation{
@@ -150,7 +201,9 @@ func (cds *crdbDatastore) Watch(ctx context.Context, afterRevision datastore.Rev
 						ObjectId:  pkValues[4],
 						Relation:  pkValues[5],
 					},
-					Caveat: ctxCaveat,
+					Caveat:                 ctxCaveat,
+					OptionalExpirationTime: expiration,
+					OptionalMetadata:       metadata,
 				},
 			}
 
@@ -160,13 +213,6 @@ func (cds *crdbDatastore) Watch(ctx context.Context, afterRevision datastore.Rev
 				oneChange.Operation = core.RelationTupleUpdate_TOUCH
 			}
 
-			pending, ok := pendingChanges[details.Updated]
-			if !ok {
-				pending = &datastore.RevisionChanges{
-					Revision: revision,
-				}
-				pendingChanges[details.Updated] = pending
-			}
 			pending.Changes = append(pending.Changes, oneChange)
 		}
 		if changes.Err() != nil {
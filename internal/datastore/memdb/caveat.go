@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/go-memdb"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/util"
@@ -14,6 +15,7 @@ import (
 const tableCaveats = "caveats"
 
 type caveat struct {
+	tenant     string
 	name       string
 	definition []byte
 	revision   datastore.Revision
@@ -37,7 +39,7 @@ func (r *memdbReader) ReadCaveatByName(_ context.Context, name string) (*core.Ca
 }
 
 func (r *memdbReader) readCaveatByName(tx *memdb.Txn, name string) (*caveat, datastore.Revision, error) {
-	found, err := tx.First(tableCaveats, indexID, name)
+	found, err := tx.First(tableCaveats, indexID, r.tenant, name)
 	if err != nil {
 		return nil, datastore.NoRevision, err
 	}
@@ -60,7 +62,22 @@ func (r *memdbReader) readUnwrappedCaveatByName(tx *memdb.Txn, name string) (*co
 	return unwrapped, rev, nil
 }
 
-func (r *memdbReader) ListCaveats(_ context.Context, caveatNames ...string) ([]*core.CaveatDefinition, error) {
+func (r *memdbReader) ListCaveats(_ context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	listOpts := options.NewListOptionsWithOptions(opts...)
+	return r.readCaveats(func(name string) bool { return name > listOpts.ListAfter }, listOpts.ListLimit)
+}
+
+func (r *memdbReader) LookupCaveats(_ context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	if len(caveatNames) == 0 {
+		return nil, nil
+	}
+
+	setOfCaveats := util.NewSet(caveatNames...)
+	return r.readCaveats(setOfCaveats.Has, nil)
+}
+
+// readCaveats reads the caveats whose names match, in order of their names, up to the limit.
+func (r *memdbReader) readCaveats(matches func(name string) bool, limit *uint64) ([]*core.CaveatDefinition, error) {
 	r.lockOrPanic()
 	defer r.Unlock()
 
@@ -70,17 +87,19 @@ func (r *memdbReader) ListCaveats(_ context.Context, caveatNames ...string) ([]*
 	}
 
 	var caveats []*core.CaveatDefinition
-	it, err := tx.LowerBound(tableCaveats, indexID)
+	it, err := tx.Get(tableCaveats, indexID+"_prefix", r.tenant)
 	if err != nil {
 		return nil, err
 	}
 
-	setOfCaveats := util.NewSet(caveatNames...)
 	for foundRaw := it.Next(); foundRaw != nil; foundRaw = it.Next() {
 		rawCaveat := foundRaw.(*caveat)
-		if !setOfCaveats.IsEmpty() && !setOfCaveats.Has(rawCaveat.name) {
+		if !matches(rawCaveat.name) {
 			continue
 		}
+		if limit != nil && uint64(len(caveats)) >= *limit {
+			break
+		}
 		definition, err := rawCaveat.Unwrap()
 		if err != nil {
 			return nil, err
@@ -112,6 +131,7 @@ func (rwt *memdbReadWriteTx) writeCaveat(tx *memdb.Txn, caveats []*core.CaveatDe
 			return err
 		}
 		c := caveat{
+			tenant:     rwt.tenant,
 			name:       coreCaveat.Name,
 			definition: marshalled,
 			revision:   rwt.newRevision,
@@ -119,6 +139,9 @@ func (rwt *memdbReadWriteTx) writeCaveat(tx *memdb.Txn, caveats []*core.CaveatDe
 		if err := tx.Insert(tableCaveats, &c); err != nil {
 			return err
 		}
+		if err := recordHistory(tx, rwt.tenant, historyKindCaveat, coreCaveat.Name, rwt.newRevision, marshalled); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -131,7 +154,10 @@ func (rwt *memdbReadWriteTx) DeleteCaveats(ctx context.Context, names []string)
 		return err
 	}
 	for _, name := range names {
-		if err := tx.Delete(tableCaveats, caveat{name: name}); err != nil {
+		if err := tx.Delete(tableCaveats, caveat{tenant: rwt.tenant, name: name}); err != nil {
+			return err
+		}
+		if err := recordHistory(tx, rwt.tenant, historyKindCaveat, name, rwt.newRevision, nil); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,116 @@
+package memdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+var _ datastore.SchemaHistoryDatastore = (*memdbDatastore)(nil)
+
+const (
+	tableSchemaHistory = "schemaHistory"
+
+	historyKindNamespace = "namespace"
+	historyKindCaveat    = "caveat"
+)
+
+// definitionVersion is a version of a namespace or caveat definition, or its deletion.
+type definitionVersion struct {
+	tenant        string
+	kind          string
+	name          string
+	revisionNanos int64
+	revision      datastore.Revision
+	definition    []byte
+	deleted       bool
+}
+
+// recordHistory records a version of a tenant's definition written, or deleted if definition
+// is nil, at the revision.
+func recordHistory(tx *memdb.Txn, tenant, kind, name string, rev datastore.Revision, definition []byte) error {
+	return tx.Insert(tableSchemaHistory, &definitionVersion{
+		tenant:        tenant,
+		kind:          kind,
+		name:          name,
+		revisionNanos: rev.(revision.Decimal).IntPart(),
+		revision:      rev,
+		definition:    definition,
+		deleted:       definition == nil,
+	})
+}
+
+// NamespaceHistory returns every version of the namespace definition which has been written.
+func (mdb *memdbDatastore) NamespaceHistory(ctx context.Context, nsName string) ([]datastore.DefinitionVersion[*core.NamespaceDefinition], error) {
+	return readHistory(mdb, datastore.DefaultTenant, historyKindNamespace, nsName, func() *core.NamespaceDefinition {
+		return &core.NamespaceDefinition{}
+	})
+}
+
+// CaveatHistory returns every version of the caveat definition which has been written.
+func (mdb *memdbDatastore) CaveatHistory(ctx context.Context, name string) ([]datastore.DefinitionVersion[*core.CaveatDefinition], error) {
+	return readHistory(mdb, datastore.DefaultTenant, historyKindCaveat, name, func() *core.CaveatDefinition {
+		return &core.CaveatDefinition{}
+	})
+}
+
+func readHistory[T interface{ UnmarshalVT([]byte) error }](
+	mdb *memdbDatastore,
+	tenant, kind, name string,
+	newDefinition func() T,
+) ([]datastore.DefinitionVersion[T], error) {
+	mdb.RLock()
+	defer mdb.RUnlock()
+
+	if mdb.db == nil {
+		return nil, fmt.Errorf("datastore is closed")
+	}
+
+	txn := mdb.db.Txn(false)
+	defer txn.Abort()
+
+	it, err := txn.Get(tableSchemaHistory, indexID+"_prefix", tenant, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []*definitionVersion
+	for foundRaw := it.Next(); foundRaw != nil; foundRaw = it.Next() {
+		if version := foundRaw.(*definitionVersion); version.name == name {
+			found = append(found, version)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].revisionNanos < found[j].revisionNanos
+	})
+
+	var versions []datastore.DefinitionVersion[T]
+	for _, version := range found {
+		if version.deleted {
+			if len(versions) > 0 {
+				versions[len(versions)-1].Deleted = true
+			}
+			continue
+		}
+
+		definition := newDefinition()
+		if err := definition.UnmarshalVT(version.definition); err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, datastore.DefinitionVersion[T]{
+			Definition: definition,
+			Revision:   version.revision,
+			WrittenAt:  time.Unix(0, version.revisionNanos).UTC(),
+		})
+	}
+
+	return versions, nil
+}
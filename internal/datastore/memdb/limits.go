@@ -0,0 +1,95 @@
+package memdb
+
+import (
+	"github.com/hashicorp/go-memdb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	relationshipsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "spicedb",
+		Subsystem: "datastore",
+		Name:      "memdb_relationships",
+		Help:      "The number of relationships stored by the in-memory datastores.",
+	})
+
+	revisionsGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "spicedb",
+		Subsystem: "datastore",
+		Name:      "memdb_revisions",
+		Help:      "The number of revisions retained by the in-memory datastores.",
+	})
+
+	evictedRevisionsCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "spicedb",
+		Subsystem: "datastore",
+		Name:      "memdb_evicted_revisions_total",
+		Help:      "The number of revisions evicted by the in-memory datastores to stay within their maximum.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(relationshipsGauge, revisionsGauge, evictedRevisionsCounter)
+}
+
+// revisionsToEvict returns the number of the oldest revisions which are evicted as a revision is
+// added, so that no more than the maximum are retained. It must be called with the lock held.
+func (mdb *memdbDatastore) revisionsToEvict() int {
+	if mdb.options.maxRevisions <= 0 {
+		return 0
+	}
+
+	evict := len(mdb.revisions) + 1 - mdb.options.maxRevisions
+	if evict < 0 {
+		return 0
+	}
+	return evict
+}
+
+// addRevision retains the snapshot of a new revision, and evicts the oldest revisions beyond the
+// maximum. It must be called with the lock held.
+func (mdb *memdbDatastore) addRevision(snap snapshot) {
+	evict := mdb.revisionsToEvict()
+	mdb.revisions = append(mdb.revisions, snap)
+	revisionsGauge.Inc()
+	if evict == 0 {
+		return
+	}
+
+	mdb.evictedRevision = mdb.revisions[evict-1].revision
+
+	// The evicted snapshots are cleared so that they can be collected before the slice is
+	// reallocated.
+	for i := range mdb.revisions[:evict] {
+		mdb.revisions[i] = snapshot{}
+	}
+	mdb.revisions = mdb.revisions[evict:]
+
+	revisionsGauge.Sub(float64(evict))
+	evictedRevisionsCounter.Add(float64(evict))
+}
+
+// pruneChangelog deletes the changes of the revisions up to and including the given revision,
+// which have been or are being evicted.
+func pruneChangelog(tx *memdb.Txn, upTo decimal.Decimal) error {
+	it, err := tx.Get(tableChangelog, indexRevision)
+	if err != nil {
+		return err
+	}
+
+	var pruned []interface{}
+	for changeRaw := it.Next(); changeRaw != nil; changeRaw = it.Next() {
+		if changeRaw.(*changelog).revisionNanos > upTo.IntPart() {
+			break
+		}
+		pruned = append(pruned, changeRaw)
+	}
+
+	for _, change := range pruned {
+		if err := tx.Delete(tableChangelog, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
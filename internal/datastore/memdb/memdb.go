@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/go-memdb"
 	"github.com/shopspring/decimal"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/datastore/revision"
 	corev1 "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -36,7 +37,13 @@ func NewMemdbDatastore(
 	watchBufferLength uint16,
 	revisionQuantization,
 	gcWindow time.Duration,
+	opts ...Option,
 ) (datastore.Datastore, error) {
+	var config memdbOptions
+	for _, opt := range opts {
+		opt(&config)
+	}
+
 	if revisionQuantization > gcWindow {
 		return nil, errors.New("gc window must be larger than quantization interval")
 	}
@@ -50,6 +57,15 @@ func NewMemdbDatastore(
 		return nil, err
 	}
 
+	var relationshipCount uint64
+	restored := false
+	if config.snapshotFile != "" {
+		relationshipCount, restored, err = restoreSnapshot(db, config.snapshotFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if watchBufferLength == 0 {
 		watchBufferLength = defaultWatchBufferLength
 	}
@@ -58,11 +74,15 @@ func NewMemdbDatastore(
 
 	negativeGCWindow := decimal.NewFromInt(gcWindow.Nanoseconds()).Mul(decimal.NewFromInt(-1))
 
-	return &memdbDatastore{
+	// A restored datastore starts at the current time, as its data has not changed since it was
+	// saved, so that its head revision is never older than the GC window.
+	initialRevision := revisionFromTimestamp(time.Now().UTC()).Decimal
+
+	mdb := &memdbDatastore{
 		db: db,
 		revisions: []snapshot{
 			{
-				revision: revisionFromTimestamp(time.Now().UTC()).Decimal,
+				revision: initialRevision,
 				db:       db,
 			},
 		},
@@ -71,7 +91,22 @@ func NewMemdbDatastore(
 		quantizationPeriod: decimal.NewFromInt(revisionQuantization.Nanoseconds()),
 		watchBufferLength:  watchBufferLength,
 		uniqueID:           uniqueID,
-	}, nil
+		options:            config,
+		relationshipCount:  relationshipCount,
+	}
+	revisionsGauge.Inc()
+	relationshipsGauge.Add(float64(relationshipCount))
+	if restored {
+		mdb.snapshotRevision = initialRevision
+	}
+
+	if config.snapshotFile != "" && config.snapshotInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		mdb.stopSnapshots = cancel
+		go mdb.runSnapshots(ctx)
+	}
+
+	return mdb, nil
 }
 
 type memdbDatastore struct {
@@ -86,6 +121,18 @@ type memdbDatastore struct {
 	quantizationPeriod decimal.Decimal
 	watchBufferLength  uint16
 	uniqueID           string
+
+	options          memdbOptions
+	snapshotLock     sync.Mutex
+	snapshotRevision decimal.Decimal
+	stopSnapshots    context.CancelFunc
+
+	// relationshipCount is the number of relationships stored across all tenants.
+	relationshipCount uint64
+
+	// evictedRevision is the newest revision which has been evicted, at or before which
+	// revisions can no longer be read or watched from.
+	evictedRevision decimal.Decimal
 }
 
 type snapshot struct {
@@ -93,18 +140,23 @@ type snapshot struct {
 	db       *memdb.MemDB
 }
 
-func (mdb *memdbDatastore) SnapshotReader(revisionRaw datastore.Revision) datastore.Reader {
+// SnapshotReader ignores consistency hints, as all reads are served from local memory.
+func (mdb *memdbDatastore) SnapshotReader(revisionRaw datastore.Revision, _ ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return mdb.snapshotReader(datastore.DefaultTenant, revisionRaw)
+}
+
+func (mdb *memdbDatastore) snapshotReader(tenant string, revisionRaw datastore.Revision) datastore.Reader {
 	dr := revisionRaw.(revision.Decimal)
 
 	mdb.RLock()
 	defer mdb.RUnlock()
 
 	if len(mdb.revisions) == 0 {
-		return &memdbReader{nil, nil, fmt.Errorf("memdb datastore is not ready")}
+		return &memdbReader{nil, nil, fmt.Errorf("memdb datastore is not ready"), tenant, time.Time{}}
 	}
 
 	if err := mdb.checkRevisionLocal(dr); err != nil {
-		return &memdbReader{nil, nil, err}
+		return &memdbReader{nil, nil, err, tenant, time.Time{}}
 	}
 
 	revIndex := sort.Search(len(mdb.revisions), func(i int) bool {
@@ -118,7 +170,7 @@ func (mdb *memdbDatastore) SnapshotReader(revisionRaw datastore.Revision) datast
 
 	rev := mdb.revisions[revIndex]
 	if rev.db == nil {
-		return &memdbReader{nil, nil, fmt.Errorf("memdb datastore is already closed")}
+		return &memdbReader{nil, nil, fmt.Errorf("memdb datastore is already closed"), tenant, time.Time{}}
 	}
 
 	roTxn := rev.db.Txn(false)
@@ -126,13 +178,25 @@ func (mdb *memdbDatastore) SnapshotReader(revisionRaw datastore.Revision) datast
 		return roTxn, nil
 	}
 
-	return &memdbReader{noopTryLocker{}, txSrc, nil}
+	return &memdbReader{noopTryLocker{}, txSrc, nil, tenant, timeFromRevision(dr)}
 }
 
 func (mdb *memdbDatastore) ReadWriteTx(
 	ctx context.Context,
 	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
+) (datastore.Revision, error) {
+	return mdb.readWriteTx(ctx, datastore.DefaultTenant, f, opts...)
+}
+
+func (mdb *memdbDatastore) readWriteTx(
+	ctx context.Context,
+	tenant string,
+	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
+	config := options.NewRWTOptionsWithOptions(opts...)
+
 	for i := 0; i < numRetries; i++ {
 		var tx *memdb.Txn
 		createTxOnce := sync.Once{}
@@ -161,7 +225,7 @@ func (mdb *memdbDatastore) ReadWriteTx(
 		}
 
 		newRevision := mdb.newRevisionID()
-		rwt := &memdbReadWriteTx{memdbReader{&sync.Mutex{}, txSrc, nil}, newRevision}
+		rwt := &memdbReadWriteTx{memdbReader{&sync.Mutex{}, txSrc, nil, tenant, timeFromRevision(newRevision)}, newRevision}
 		if err := f(rwt); err != nil {
 			mdb.Lock()
 			if tx != nil {
@@ -191,10 +255,15 @@ func (mdb *memdbDatastore) ReadWriteTx(
 		newChanges := datastore.RevisionChanges{
 			Revision: newRevision,
 			Changes:  nil,
+			Metadata: copyMetadata(config.Metadata),
 		}
 		if tx != nil {
+			var relationshipDelta int64
 			for _, change := range tx.Changes() {
 				if change.Table == tableRelationship {
+					if change.After != nil && change.Before == nil {
+						relationshipDelta++
+					}
 					if change.After != nil {
 						rt, err := change.After.(*relationship).RelationTuple()
 						if err != nil {
@@ -206,6 +275,7 @@ func (mdb *memdbDatastore) ReadWriteTx(
 						})
 					}
 					if change.After == nil && change.Before != nil {
+						relationshipDelta--
 						rt, err := change.Before.(*relationship).RelationTuple()
 						if err != nil {
 							return datastore.NoRevision, err
@@ -218,7 +288,21 @@ func (mdb *memdbDatastore) ReadWriteTx(
 				}
 			}
 
+			if maxRelationships := mdb.options.maxRelationships; maxRelationships > 0 && relationshipDelta > 0 &&
+				mdb.relationshipCount+uint64(relationshipDelta) > maxRelationships {
+				tx.Abort()
+				mdb.activeWriteTxn = nil
+				return datastore.NoRevision, datastore.NewRelationshipLimitExceededErr(maxRelationships)
+			}
+
+			if evict := mdb.revisionsToEvict(); evict > 0 {
+				if err := pruneChangelog(tx, mdb.revisions[evict-1].revision); err != nil {
+					return datastore.NoRevision, fmt.Errorf("error pruning changelog: %w", err)
+				}
+			}
+
 			change := &changelog{
+				tenant:        tenant,
 				revisionNanos: newRevision.IntPart(),
 				changes:       newChanges,
 			}
@@ -227,6 +311,9 @@ func (mdb *memdbDatastore) ReadWriteTx(
 			}
 
 			tx.Commit()
+
+			mdb.relationshipCount = uint64(int64(mdb.relationshipCount) + relationshipDelta)
+			relationshipsGauge.Add(float64(relationshipDelta))
 		}
 		mdb.activeWriteTxn = nil
 
@@ -236,13 +323,48 @@ func (mdb *memdbDatastore) ReadWriteTx(
 		}
 
 		snap := mdb.db.Snapshot()
-		mdb.revisions = append(mdb.revisions, snapshot{newRevision.Decimal, snap})
+		mdb.addRevision(snapshot{newRevision.Decimal, snap})
 		return newRevision, nil
 	}
 
 	return datastore.NoRevision, errors.New("serialization max retries exceeded")
 }
 
+// BulkLoad reads the full source into memory before writing it in a single transaction, as the
+// data would be held in memory regardless and this allows for the transaction to be retried.
+func (mdb *memdbDatastore) BulkLoad(
+	ctx context.Context,
+	source datastore.BulkWriteRelationshipSource,
+) (datastore.Revision, error) {
+	return mdb.bulkLoad(ctx, datastore.DefaultTenant, source)
+}
+
+func (mdb *memdbDatastore) bulkLoad(
+	ctx context.Context,
+	tenant string,
+	source datastore.BulkWriteRelationshipSource,
+) (datastore.Revision, error) {
+	var mutations []*corev1.RelationTupleUpdate
+	for {
+		tpl, err := source.Next(ctx)
+		if err != nil {
+			return datastore.NoRevision, err
+		}
+		if tpl == nil {
+			break
+		}
+
+		mutations = append(mutations, &corev1.RelationTupleUpdate{
+			Operation: corev1.RelationTupleUpdate_CREATE,
+			Tuple:     tpl,
+		})
+	}
+
+	return mdb.readWriteTx(ctx, tenant, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, mutations)
+	})
+}
+
 func (mdb *memdbDatastore) IsReady(ctx context.Context) (bool, error) {
 	mdb.RLock()
 	defer mdb.RUnlock()
@@ -251,13 +373,34 @@ func (mdb *memdbDatastore) IsReady(ctx context.Context) (bool, error) {
 }
 
 func (mdb *memdbDatastore) Features(ctx context.Context) (*datastore.Features, error) {
-	return &datastore.Features{Watch: datastore.Feature{Enabled: true}}, nil
+	return &datastore.Features{
+		Watch:                  datastore.Feature{Enabled: true},
+		WatchCheckpoints:       datastore.Feature{Enabled: true},
+		Caveats:                datastore.Feature{Enabled: true},
+		BulkLoad:               datastore.Feature{Enabled: true},
+		ReverseQuerySort:       datastore.Feature{Enabled: true},
+		RelationshipExpiration: datastore.Feature{Enabled: true},
+	}, nil
 }
 
 func (mdb *memdbDatastore) Close() error {
+	if mdb.stopSnapshots != nil {
+		mdb.stopSnapshots()
+	}
+	if mdb.options.snapshotFile != "" {
+		if err := mdb.saveSnapshot(); err != nil {
+			return err
+		}
+	}
+
 	mdb.Lock()
 	defer mdb.Unlock()
 
+	if mdb.db != nil {
+		revisionsGauge.Sub(float64(len(mdb.revisions)))
+		relationshipsGauge.Sub(float64(mdb.relationshipCount))
+	}
+
 	// TODO Make this nil once we have removed all access to closed datastores
 	if db := mdb.db; db != nil {
 		mdb.revisions = []snapshot{
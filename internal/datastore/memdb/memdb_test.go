@@ -4,17 +4,27 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
 	test "github.com/authzed/spicedb/pkg/datastore/test"
 	ns "github.com/authzed/spicedb/pkg/namespace"
 	corev1 "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/testutil"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 type memDBTest struct{}
@@ -27,6 +37,30 @@ func TestMemdbDatastore(t *testing.T) {
 	test.All(t, memDBTest{})
 }
 
+func TestStatisticsEstimates(t *testing.T) {
+	require := require.New(t)
+
+	rawDS, err := NewMemdbDatastore(0, 0, DisableGC)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+
+	stats, err := ds.Statistics(context.Background())
+	require.NoError(err)
+	require.Equal(uint64(len(testfixtures.StandardTuples)), stats.EstimatedRelationshipCount)
+	require.Greater(stats.EstimatedDistinctResources, uint64(0))
+	require.Greater(stats.EstimatedDistinctSubjects, uint64(0))
+
+	expectedCounts := make(map[string]uint64)
+	for _, tpl := range testfixtures.StandardTuples {
+		expectedCounts[strings.Split(tpl, ":")[0]]++
+	}
+
+	for _, objTypeStats := range stats.ObjectTypeStatistics {
+		require.Equal(expectedCounts[objTypeStats.Name], objTypeStats.EstimatedRelationshipCount, objTypeStats.Name)
+	}
+}
+
 func TestConcurrentWritePanic(t *testing.T) {
 	require := require.New(t)
 
@@ -77,3 +111,161 @@ func TestConcurrentWritePanic(t *testing.T) {
 	}, 1*time.Second, 10*time.Millisecond)
 	require.ErrorIs(err, recoverErr)
 }
+
+func TestSnapshotRestore(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	rawDS, err := NewMemdbDatastore(0, 0, DisableGC, SnapshotFile(path))
+	require.NoError(err)
+	ds, _ := testfixtures.StandardDatastoreWithCaveatedData(rawDS, require)
+
+	expiring := tuple.MustParse("document:expiring#viewer@user:tom")
+	expiring.OptionalExpirationTime = timestamppb.New(time.Now().Add(time.Hour).Truncate(time.Second))
+	expiring.OptionalMetadata = map[string]string{"source": "import"}
+	_, err = common.WriteTuples(ctx, ds, corev1.RelationTupleUpdate_CREATE, expiring)
+	require.NoError(err)
+
+	_, err = rawDS.(datastore.TenantPartitionedDatastore).ForTenant("tenant").ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(ctx, ns.Namespace("user"))
+	})
+	require.NoError(err)
+
+	before := readSnapshotContents(t, rawDS)
+	require.NoError(rawDS.Close())
+
+	restored, err := NewMemdbDatastore(0, 0, DisableGC, SnapshotFile(path))
+	require.NoError(err)
+	defer restored.Close()
+
+	after := readSnapshotContents(t, restored)
+	byNamespaceName := func(first, second *corev1.NamespaceDefinition) int {
+		return strings.Compare(first.Name, second.Name)
+	}
+	testutil.RequireProtoSlicesEqual(t, before.namespaces, after.namespaces, byNamespaceName, "namespaces")
+	testutil.RequireProtoSlicesEqual(t, before.caveats, after.caveats, func(first, second *corev1.CaveatDefinition) int {
+		return strings.Compare(first.Name, second.Name)
+	}, "caveats")
+	testutil.RequireProtoSlicesEqual(t, before.relationships, after.relationships, func(first, second *corev1.RelationTuple) int {
+		return strings.Compare(tuple.String(first), tuple.String(second))
+	}, "relationships")
+	testutil.RequireProtoSlicesEqual(t, before.tenantNamespaces, after.tenantNamespaces, byNamespaceName, "tenant namespaces")
+	require.Len(after.tenantNamespaces, 1)
+
+	history, err := restored.(datastore.SchemaHistoryDatastore).NamespaceHistory(ctx, "document")
+	require.NoError(err)
+	require.Len(history, 1)
+}
+
+type snapshotContents struct {
+	namespaces       []*corev1.NamespaceDefinition
+	caveats          []*corev1.CaveatDefinition
+	relationships    []*corev1.RelationTuple
+	tenantNamespaces []*corev1.NamespaceDefinition
+}
+
+func readSnapshotContents(t *testing.T, ds datastore.Datastore) snapshotContents {
+	ctx := context.Background()
+	rev, err := ds.HeadRevision(ctx)
+	require.NoError(t, err)
+	reader := ds.SnapshotReader(rev)
+
+	var contents snapshotContents
+	contents.namespaces, err = reader.ListNamespaces(ctx)
+	require.NoError(t, err)
+	contents.caveats, err = reader.ListCaveats(ctx)
+	require.NoError(t, err)
+
+	for _, def := range contents.namespaces {
+		iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: def.Name})
+		require.NoError(t, err)
+		for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+			contents.relationships = append(contents.relationships, tpl)
+		}
+		require.NoError(t, iter.Err())
+		iter.Close()
+	}
+
+	contents.tenantNamespaces, err = ds.(datastore.TenantPartitionedDatastore).ForTenant("tenant").SnapshotReader(rev).ListNamespaces(ctx)
+	require.NoError(t, err)
+
+	return contents
+}
+
+func TestRelationshipLimit(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawDS, err := NewMemdbDatastore(0, 0, DisableGC, MaxRelationships(2))
+	require.NoError(err)
+	ds, _ := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+
+	_, err = common.WriteTuples(ctx, ds, corev1.RelationTupleUpdate_CREATE,
+		tuple.MustParse("document:first#viewer@user:tom"),
+		tuple.MustParse("document:second#viewer@user:tom"),
+	)
+	require.NoError(err)
+
+	// Touching a stored relationship does not store another.
+	_, err = common.WriteTuples(ctx, ds, corev1.RelationTupleUpdate_TOUCH, tuple.MustParse("document:first#viewer@user:tom"))
+	require.NoError(err)
+
+	_, err = common.WriteTuples(ctx, ds, corev1.RelationTupleUpdate_CREATE, tuple.MustParse("document:third#viewer@user:tom"))
+	var limitErr datastore.ErrRelationshipLimitExceeded
+	require.ErrorAs(err, &limitErr)
+	require.Equal(uint64(2), limitErr.MaxRelationships())
+
+	// The datastore accepts writes once relationships are deleted.
+	_, err = common.WriteTuples(ctx, ds, corev1.RelationTupleUpdate_DELETE, tuple.MustParse("document:first#viewer@user:tom"))
+	require.NoError(err)
+	_, err = common.WriteTuples(ctx, ds, corev1.RelationTupleUpdate_CREATE, tuple.MustParse("document:third#viewer@user:tom"))
+	require.NoError(err)
+}
+
+func TestRevisionEviction(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawDS, err := NewMemdbDatastore(0, 0, DisableGC, MaxRevisions(3))
+	require.NoError(err)
+	ds, _ := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+
+	var revisions []datastore.Revision
+	for i := 0; i < 4; i++ {
+		rev, err := common.WriteTuples(ctx, ds, corev1.RelationTupleUpdate_CREATE, tuple.MustParse(fmt.Sprintf("document:doc%d#viewer@user:tom", i)))
+		require.NoError(err)
+		revisions = append(revisions, rev)
+	}
+
+	require.Len(rawDS.(*memdbDatastore).revisions, 3)
+
+	var invalidErr datastore.ErrInvalidRevision
+	require.ErrorAs(ds.CheckRevision(ctx, revisions[0]), &invalidErr)
+	require.Equal(datastore.RevisionStale, invalidErr.Reason())
+	require.NoError(ds.CheckRevision(ctx, revisions[1]))
+
+	_, err = ds.SnapshotReader(revisions[0]).ListNamespaces(ctx)
+	require.ErrorAs(err, &invalidErr)
+
+	optimized, err := ds.OptimizedRevision(ctx)
+	require.NoError(err)
+	require.NoError(ds.CheckRevision(ctx, optimized))
+
+	// The changes of the evicted revisions are pruned, so watches cannot start before the newest
+	// of them.
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	beforeEvicted := revision.NewFromDecimal(revisions[0].(revision.Decimal).Sub(decimal.NewFromInt(1)))
+	_, errs := ds.Watch(watchCtx, beforeEvicted)
+	require.ErrorAs(<-errs, &invalidErr)
+
+	changes, errs := ds.Watch(watchCtx, revisions[0])
+	select {
+	case change := <-changes:
+		require.True(revisions[1].Equal(change.Revision))
+	case err := <-errs:
+		require.NoError(err)
+	}
+}
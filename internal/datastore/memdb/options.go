@@ -0,0 +1,55 @@
+package memdb
+
+import "time"
+
+type memdbOptions struct {
+	snapshotFile     string
+	snapshotInterval time.Duration
+	maxRelationships uint64
+	maxRevisions     int
+}
+
+// Option configures the memdb datastore.
+type Option func(*memdbOptions)
+
+// SnapshotFile is the path of the file to which the datastore is saved, and from which it is
+// restored when it is created. The datastore is saved as it is closed, and at the snapshot
+// interval if one is set.
+//
+// The datastore is not saved by default.
+func SnapshotFile(path string) Option {
+	return func(mo *memdbOptions) {
+		mo.snapshotFile = path
+	}
+}
+
+// SnapshotInterval is the interval at which the datastore is saved to its snapshot file if it
+// has changed, so that fewer changes are lost if the process does not close it.
+//
+// This value defaults to 0, which saves the datastore only as it is closed.
+func SnapshotInterval(interval time.Duration) Option {
+	return func(mo *memdbOptions) {
+		mo.snapshotInterval = interval
+	}
+}
+
+// MaxRelationships is the maximum number of relationships stored across all tenants. A
+// transaction which would store more fails with an ErrRelationshipLimitExceeded.
+//
+// This value defaults to 0, which does not limit the number of relationships.
+func MaxRelationships(max uint64) Option {
+	return func(mo *memdbOptions) {
+		mo.maxRelationships = max
+	}
+}
+
+// MaxRevisions is the maximum number of revisions retained. As each transaction adds a revision,
+// the oldest revisions beyond the maximum are evicted along with their changes, and can no longer
+// be read or watched from.
+//
+// This value defaults to 0, which retains every revision.
+func MaxRevisions(max int) Option {
+	return func(mo *memdbOptions) {
+		mo.maxRevisions = max
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"time"
 
 	"github.com/hashicorp/go-memdb"
 	"github.com/jzelinskie/stringz"
@@ -19,6 +20,10 @@ type memdbReader struct {
 	TryLocker
 	txSource txFactory
 	initErr  error
+	tenant   string
+
+	// readTime is the time of the revision being read, at which expiration is evaluated.
+	readTime time.Time
 }
 
 // QueryRelationships reads relationships starting from the resource side.
@@ -41,17 +46,19 @@ func (r *memdbReader) QueryRelationships(
 
 	queryOpts := options.NewQueryOptionsWithOptions(opts...)
 
-	bestIterator, err := iteratorForFilter(tx, filter)
+	bestIterator, err := iteratorForFilter(tx, r.tenant, filter)
 	if err != nil {
 		return nil, err
 	}
 
 	matchingRelationshipsFilterFunc := filterFuncForFilters(
+		r.readTime,
 		filter.ResourceType,
 		filter.OptionalResourceIds,
 		filter.OptionalResourceRelation,
 		filter.OptionalSubjectsFilter,
 		filter.OptionalCaveatName,
+		filter.OptionalMetadata,
 		queryOpts.Usersets,
 	)
 	filteredIterator := memdb.NewFilterIterator(bestIterator, matchingRelationshipsFilterFunc)
@@ -90,11 +97,22 @@ func (r *memdbReader) ReverseQueryRelationships(
 
 	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
 
-	iterator, err := tx.Get(
-		tableRelationship,
-		indexSubjectNamespace,
-		subjectsFilter.SubjectType,
-	)
+	// Relationships are always returned ordered by subject and then by resource, so the results
+	// are sorted whether or not options.BySubject was requested.
+	lowerBound := []any{r.tenant, subjectsFilter.SubjectType, "", "", "", "", ""}
+	if after := queryOpts.ReverseAfter; after != nil {
+		lowerBound = []any{
+			r.tenant,
+			after.Subject.Namespace,
+			after.Subject.ObjectId,
+			after.Subject.Relation,
+			after.ResourceAndRelation.Namespace,
+			after.ResourceAndRelation.ObjectId,
+			after.ResourceAndRelation.Relation,
+		}
+	}
+
+	iterator, err := tx.LowerBound(tableRelationship, indexSubjectAndResource, lowerBound...)
 	if err != nil {
 		return nil, err
 	}
@@ -106,14 +124,21 @@ func (r *memdbReader) ReverseQueryRelationships(
 	}
 
 	matchingRelationshipsFilterFunc := filterFuncForFilters(
+		r.readTime,
 		filterObjectType,
 		nil,
 		filterRelation,
 		&subjectsFilter,
 		"",
 		nil,
+		nil,
 	)
-	filteredIterator := memdb.NewFilterIterator(iterator, matchingRelationshipsFilterFunc)
+	filteredIterator := memdb.NewFilterIterator(&subjectTypeIterator{
+		it:          iterator,
+		tenant:      r.tenant,
+		subjectType: subjectsFilter.SubjectType,
+		after:       queryOpts.ReverseAfter,
+	}, matchingRelationshipsFilterFunc)
 
 	iter := &memdbTupleIterator{
 		it:    filteredIterator,
@@ -129,6 +154,78 @@ func (r *memdbReader) ReverseQueryRelationships(
 	return iter, nil
 }
 
+// CountRelationships counts the relationships matching the filter.
+func (r *memdbReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	if r.initErr != nil {
+		return 0, r.initErr
+	}
+
+	r.lockOrPanic()
+	defer r.Unlock()
+
+	tx, err := r.txSource()
+	if err != nil {
+		return 0, err
+	}
+
+	bestIterator, err := iteratorForFilter(tx, r.tenant, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	matchingRelationshipsFilterFunc := filterFuncForFilters(
+		r.readTime,
+		filter.ResourceType,
+		filter.OptionalResourceIds,
+		filter.OptionalResourceRelation,
+		filter.OptionalSubjectsFilter,
+		filter.OptionalCaveatName,
+		filter.OptionalMetadata,
+		nil,
+	)
+	filteredIterator := memdb.NewFilterIterator(bestIterator, matchingRelationshipsFilterFunc)
+
+	var count uint64
+	for foundRaw := filteredIterator.Next(); foundRaw != nil; foundRaw = filteredIterator.Next() {
+		count++
+	}
+
+	return count, nil
+}
+
+// ListResourceTypes lists the distinct resource types of the relationships stored.
+func (r *memdbReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	if r.initErr != nil {
+		return nil, r.initErr
+	}
+
+	r.lockOrPanic()
+	defer r.Unlock()
+
+	tx, err := r.txSource()
+	if err != nil {
+		return nil, err
+	}
+
+	// The namespace index is ordered by the resource type of the relationships of each tenant.
+	it, err := tx.Get(tableRelationship, indexNamespace+"_prefix", r.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list resource types: %w", err)
+	}
+
+	filteredIterator := memdb.NewFilterIterator(it, filterFuncForFilters(r.readTime, "", nil, "", nil, "", nil, nil))
+
+	var resourceTypes []string
+	for foundRaw := filteredIterator.Next(); foundRaw != nil; foundRaw = filteredIterator.Next() {
+		resourceType := foundRaw.(*relationship).namespace
+		if len(resourceTypes) == 0 || resourceTypes[len(resourceTypes)-1] != resourceType {
+			resourceTypes = append(resourceTypes, resourceType)
+		}
+	}
+
+	return resourceTypes, nil
+}
+
 // ReadNamespace reads a namespace definition and version and returns it, and the revision at
 // which it was created or last written, if found.
 func (r *memdbReader) ReadNamespace(ctx context.Context, nsName string) (ns *core.NamespaceDefinition, lastWritten datastore.Revision, err error) {
@@ -144,7 +241,7 @@ func (r *memdbReader) ReadNamespace(ctx context.Context, nsName string) (ns *cor
 		return nil, datastore.NoRevision, err
 	}
 
-	foundRaw, err := tx.First(tableNamespace, indexID, nsName)
+	foundRaw, err := tx.First(tableNamespace, indexID, r.tenant, nsName)
 	if err != nil {
 		return nil, datastore.NoRevision, err
 	}
@@ -163,8 +260,8 @@ func (r *memdbReader) ReadNamespace(ctx context.Context, nsName string) (ns *cor
 	return loaded, found.updated, nil
 }
 
-// ListNamespaces lists all namespaces defined.
-func (r *memdbReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+// ListNamespaces lists the namespaces defined, in order of their names.
+func (r *memdbReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
 	if r.initErr != nil {
 		return nil, r.initErr
 	}
@@ -179,13 +276,20 @@ func (r *memdbReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefi
 
 	var nsDefs []*core.NamespaceDefinition
 
-	it, err := tx.LowerBound(tableNamespace, indexID)
+	it, err := tx.Get(tableNamespace, indexID+"_prefix", r.tenant)
 	if err != nil {
 		return nil, err
 	}
 
+	listOpts := options.NewListOptionsWithOptions(opts...)
 	for foundRaw := it.Next(); foundRaw != nil; foundRaw = it.Next() {
 		found := foundRaw.(*namespace)
+		if found.name <= listOpts.ListAfter {
+			continue
+		}
+		if listOpts.ListLimit != nil && uint64(len(nsDefs)) >= *listOpts.ListLimit {
+			break
+		}
 
 		loaded := &core.NamespaceDefinition{}
 		if err := loaded.UnmarshalVT(found.configBytes); err != nil {
@@ -215,7 +319,7 @@ func (r *memdbReader) LookupNamespaces(ctx context.Context, nsNames []string) ([
 		return nil, err
 	}
 
-	it, err := tx.LowerBound(tableNamespace, indexID)
+	it, err := tx.Get(tableNamespace, indexID+"_prefix", r.tenant)
 	if err != nil {
 		return nil, err
 	}
@@ -249,9 +353,9 @@ func (r *memdbReader) lockOrPanic() {
 	}
 }
 
-func iteratorForFilter(txn *memdb.Txn, filter datastore.RelationshipsFilter) (memdb.ResultIterator, error) {
+func iteratorForFilter(txn *memdb.Txn, tenant string, filter datastore.RelationshipsFilter) (memdb.ResultIterator, error) {
 	index := indexNamespace
-	args := []any{filter.ResourceType}
+	args := []any{tenant, filter.ResourceType}
 	if filter.OptionalResourceRelation != "" {
 		args = append(args, filter.OptionalResourceRelation)
 		index = indexNamespaceAndRelation
@@ -266,17 +370,21 @@ func iteratorForFilter(txn *memdb.Txn, filter datastore.RelationshipsFilter) (me
 }
 
 func filterFuncForFilters(
+	readTime time.Time,
 	optionalResourceType string,
 	optionalResourceIds []string,
 	optionalRelation string,
 	optionalSubjectsFilter *datastore.SubjectsFilter,
 	optionalCaveatFilter string,
+	optionalMetadataFilter map[string]string,
 	usersets []*core.ObjectAndRelation,
 ) memdb.FilterFunc {
 	return func(tupleRaw interface{}) bool {
 		tuple := tupleRaw.(*relationship)
 
 		switch {
+		case tuple.expired(readTime):
+			return true
 		case optionalResourceType != "" && optionalResourceType != tuple.namespace:
 			return true
 		case len(optionalResourceIds) > 0 && !stringz.SliceContains(optionalResourceIds, tuple.resourceID):
@@ -287,6 +395,13 @@ func filterFuncForFilters(
 			return true
 		}
 
+		for key, value := range optionalMetadataFilter {
+			found, ok := tuple.metadata[key]
+			if !ok || (value != "" && value != found) {
+				return true
+			}
+		}
+
 		if optionalSubjectsFilter != nil {
 			relations := make([]string, 0, 2)
 			if optionalSubjectsFilter.RelationFilter.IncludeEllipsisRelation {
@@ -324,6 +439,36 @@ func filterFuncForFilters(
 	}
 }
 
+// subjectTypeIterator iterates over the subject index from a lower bound, skipping the
+// relationship at the cursor, if any, and stopping at the end of the subject type in the tenant.
+type subjectTypeIterator struct {
+	it          memdb.ResultIterator
+	tenant      string
+	subjectType string
+	after       options.Cursor
+}
+
+func (sti *subjectTypeIterator) WatchCh() <-chan struct{} {
+	return sti.it.WatchCh()
+}
+
+func (sti *subjectTypeIterator) Next() interface{} {
+	for foundRaw := sti.it.Next(); foundRaw != nil; foundRaw = sti.it.Next() {
+		found := foundRaw.(*relationship)
+		if found.tenant != sti.tenant || found.subjectNamespace != sti.subjectType {
+			return nil
+		}
+
+		if sti.after != nil && found.isCursor(sti.after) {
+			continue
+		}
+
+		return found
+	}
+
+	return nil
+}
+
 type memdbTupleIterator struct {
 	closed bool
 	it     memdb.ResultIterator
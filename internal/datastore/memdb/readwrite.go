@@ -37,6 +37,7 @@ func (rwt *memdbReadWriteTx) write(tx *memdb.Txn, mutations ...*core.RelationTup
 	// Apply the mutations
 	for _, mutation := range mutations {
 		rel := &relationship{
+			rwt.tenant,
 			mutation.Tuple.ResourceAndRelation.Namespace,
 			mutation.Tuple.ResourceAndRelation.ObjectId,
 			mutation.Tuple.ResourceAndRelation.Relation,
@@ -44,11 +45,14 @@ func (rwt *memdbReadWriteTx) write(tx *memdb.Txn, mutations ...*core.RelationTup
 			mutation.Tuple.Subject.ObjectId,
 			mutation.Tuple.Subject.Relation,
 			rwt.toCaveatReference(mutation),
+			common.ExpirationTimeOf(mutation.Tuple),
+			copyMetadata(mutation.Tuple.OptionalMetadata),
 		}
 
 		found, err := tx.First(
 			tableRelationship,
 			indexID,
+			rel.tenant,
 			rel.namespace,
 			rel.resourceID,
 			rel.relation,
@@ -67,7 +71,8 @@ func (rwt *memdbReadWriteTx) write(tx *memdb.Txn, mutations ...*core.RelationTup
 
 		switch mutation.Operation {
 		case core.RelationTupleUpdate_CREATE:
-			if existing != nil {
+			// Expired relationships are treated as if they no longer exist.
+			if existing != nil && !existing.expired(rwt.readTime) {
 				rt, err := existing.RelationTuple()
 				if err != nil {
 					return err
@@ -119,7 +124,7 @@ func (rwt *memdbReadWriteTx) DeleteRelationships(ctx context.Context, filter *v1
 // caller must already hold the concurrent access lock
 func (rwt *memdbReadWriteTx) deleteWithLock(tx *memdb.Txn, filter *v1.RelationshipFilter) error {
 	// Create an iterator to find the relevant tuples
-	bestIter, err := iteratorForFilter(tx, datastore.RelationshipsFilterFromPublicFilter(filter))
+	bestIter, err := iteratorForFilter(tx, rwt.tenant, datastore.RelationshipsFilterFromPublicFilter(filter))
 	if err != nil {
 		return err
 	}
@@ -153,12 +158,16 @@ func (rwt *memdbReadWriteTx) WriteNamespaces(ctx context.Context, newConfigs ...
 			return err
 		}
 
-		newConfigEntry := &namespace{newConfig.Name, serialized, rwt.newRevision}
+		newConfigEntry := &namespace{rwt.tenant, newConfig.Name, serialized, rwt.newRevision}
 
 		err = tx.Insert(tableNamespace, newConfigEntry)
 		if err != nil {
 			return err
 		}
+
+		if err := recordHistory(tx, rwt.tenant, historyKindNamespace, newConfig.Name, rwt.newRevision, serialized); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -174,7 +183,7 @@ func (rwt *memdbReadWriteTx) DeleteNamespaces(ctx context.Context, nsNames ...st
 	}
 
 	for _, nsName := range nsNames {
-		foundRaw, err := tx.First(tableNamespace, indexID, nsName)
+		foundRaw, err := tx.First(tableNamespace, indexID, rwt.tenant, nsName)
 		if err != nil {
 			return err
 		}
@@ -187,6 +196,10 @@ func (rwt *memdbReadWriteTx) DeleteNamespaces(ctx context.Context, nsNames ...st
 			return err
 		}
 
+		if err := recordHistory(tx, rwt.tenant, historyKindNamespace, nsName, rwt.newRevision, nil); err != nil {
+			return err
+		}
+
 		// Delete the relationships from the namespace
 		if err := rwt.deleteWithLock(tx, &v1.RelationshipFilter{
 			ResourceType: nsName,
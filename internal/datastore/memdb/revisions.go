@@ -14,6 +14,10 @@ func revisionFromTimestamp(t time.Time) revision.Decimal {
 	return revision.NewFromDecimal(decimal.NewFromInt(t.UnixNano()))
 }
 
+func timeFromRevision(rev revision.Decimal) time.Time {
+	return time.Unix(0, rev.IntPart())
+}
+
 func (mdb *memdbDatastore) newRevisionID() revision.Decimal {
 	mdb.Lock()
 	defer mdb.Unlock()
@@ -55,7 +59,23 @@ func (mdb *memdbDatastore) headRevision(ctx context.Context) (decimal.Decimal, e
 
 func (mdb *memdbDatastore) OptimizedRevision(ctx context.Context) (datastore.Revision, error) {
 	now := revisionFromTimestamp(time.Now().UTC())
-	return revision.NewFromDecimal(now.Sub(now.Mod(mdb.quantizationPeriod))), nil
+	optimized := now.Sub(now.Mod(mdb.quantizationPeriod))
+
+	// A quantized revision which has been evicted is replaced with the oldest revision retained.
+	mdb.RLock()
+	defer mdb.RUnlock()
+	if len(mdb.revisions) > 0 && !mdb.evictedRevision.IsZero() && optimized.LessThanOrEqual(mdb.evictedRevision) {
+		optimized = mdb.revisions[0].revision
+	}
+
+	return revision.NewFromDecimal(optimized), nil
+}
+
+// NextOptimizedRevision returns the optimized revision which will be selected once the current
+// quantization window rolls over, which is the start of the next window.
+func (mdb *memdbDatastore) NextOptimizedRevision(_ context.Context) (datastore.Revision, error) {
+	now := revisionFromTimestamp(time.Now().UTC())
+	return revision.NewFromDecimal(now.Sub(now.Mod(mdb.quantizationPeriod)).Add(mdb.quantizationPeriod)), nil
 }
 
 func (mdb *memdbDatastore) CheckRevision(ctx context.Context, revisionRaw datastore.Revision) error {
@@ -63,6 +83,9 @@ func (mdb *memdbDatastore) CheckRevision(ctx context.Context, revisionRaw datast
 	if !ok {
 		return datastore.NewInvalidRevisionErr(revisionRaw, datastore.CouldNotDetermineRevision)
 	}
+
+	mdb.RLock()
+	defer mdb.RUnlock()
 	return mdb.checkRevisionLocal(dr)
 }
 
@@ -78,5 +101,9 @@ func (mdb *memdbDatastore) checkRevisionLocal(revisionRaw revision.Decimal) erro
 		return datastore.NewInvalidRevisionErr(revisionRaw, datastore.RevisionStale)
 	}
 
+	if !mdb.evictedRevision.IsZero() && revisionRaw.Decimal.LessThanOrEqual(mdb.evictedRevision) {
+		return datastore.NewInvalidRevisionErr(revisionRaw, datastore.RevisionStale)
+	}
+
 	return nil
 }
@@ -2,13 +2,17 @@ package memdb
 
 import (
 	"fmt"
+	"reflect"
+	"time"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/hashicorp/go-memdb"
 	"github.com/jzelinskie/stringz"
 	"github.com/rs/zerolog"
 	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
@@ -22,13 +26,14 @@ const (
 	indexNamespaceAndResourceID = "namespaceAndResourceID"
 	indexNamespaceAndRelation   = "namespaceAndRelation"
 	indexNamespaceAndSubjectID  = "namespaceAndSubjectID"
-	indexSubjectNamespace       = "subjectNamespace"
+	indexSubjectAndResource     = "subjectAndResource"
 
 	tableChangelog = "changelog"
 	indexRevision  = "id"
 )
 
 type namespace struct {
+	tenant      string
 	name        string
 	configBytes []byte
 	updated     datastore.Revision
@@ -39,6 +44,7 @@ func (ns namespace) MarshalZerologObject(e *zerolog.Event) {
 }
 
 type relationship struct {
+	tenant           string
 	namespace        string
 	resourceID       string
 	relation         string
@@ -46,6 +52,21 @@ type relationship struct {
 	subjectObjectID  string
 	subjectRelation  string
 	caveat           *contextualizedCaveat
+	expiration       *time.Time
+	metadata         map[string]string
+}
+
+func (r relationship) expired(now time.Time) bool {
+	return r.expiration != nil && !r.expiration.After(now)
+}
+
+func (r relationship) isCursor(cursor options.Cursor) bool {
+	return r.namespace == cursor.ResourceAndRelation.Namespace &&
+		r.resourceID == cursor.ResourceAndRelation.ObjectId &&
+		r.relation == cursor.ResourceAndRelation.Relation &&
+		r.subjectNamespace == cursor.Subject.Namespace &&
+		r.subjectObjectID == cursor.Subject.ObjectId &&
+		r.subjectRelation == cursor.Subject.Relation
 }
 
 type contextualizedCaveat struct {
@@ -111,6 +132,12 @@ func (r relationship) RelationTuple() (*core.RelationTuple, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	var expiration *timestamppb.Timestamp
+	if r.expiration != nil {
+		expiration = timestamppb.New(*r.expiration)
+	}
+
 	return &core.RelationTuple{
 		ResourceAndRelation: &core.ObjectAndRelation{
 			Namespace: r.namespace,
@@ -122,24 +149,63 @@ func (r relationship) RelationTuple() (*core.RelationTuple, error) {
 			ObjectId:  r.subjectObjectID,
 			Relation:  r.subjectRelation,
 		},
-		Caveat: cr,
+		Caveat:                 cr,
+		OptionalExpirationTime: expiration,
+		OptionalMetadata:       copyMetadata(r.metadata),
 	}, nil
 }
 
+// copyMetadata copies relationship metadata, so that stored relationships are not modified by
+// changes to the maps of written or read tuples.
+func copyMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	copied := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		copied[key] = value
+	}
+	return copied
+}
+
 type changelog struct {
+	tenant        string
 	revisionNanos int64
 	changes       datastore.RevisionChanges
 }
 
+// tenantFieldIndex indexes the tenant of a row. Unlike memdb.StringFieldIndex, it indexes the
+// empty DefaultTenant, and prefix scans on it match only the exact tenant.
+type tenantFieldIndex struct {
+	memdb.StringFieldIndex
+}
+
+func (tfi *tenantFieldIndex) FromObject(obj interface{}) (bool, []byte, error) {
+	tenant := reflect.Indirect(reflect.ValueOf(obj)).FieldByName(tfi.Field).String()
+	return true, []byte(tenant + "\x00"), nil
+}
+
+func (tfi *tenantFieldIndex) PrefixFromArgs(args ...interface{}) ([]byte, error) {
+	return tfi.FromArgs(args...)
+}
+
+var tenantIndex = &tenantFieldIndex{memdb.StringFieldIndex{Field: "tenant"}}
+
 var schema = &memdb.DBSchema{
 	Tables: map[string]*memdb.TableSchema{
 		tableNamespace: {
 			Name: tableNamespace,
 			Indexes: map[string]*memdb.IndexSchema{
 				indexID: {
-					Name:    indexID,
-					Unique:  true,
-					Indexer: &memdb.StringFieldIndex{Field: "name"},
+					Name:   indexID,
+					Unique: true,
+					Indexer: &memdb.CompoundIndex{
+						Indexes: []memdb.Indexer{
+							tenantIndex,
+							&memdb.StringFieldIndex{Field: "name"},
+						},
+					},
 				},
 			},
 		},
@@ -161,6 +227,7 @@ var schema = &memdb.DBSchema{
 					Unique: true,
 					Indexer: &memdb.CompoundIndex{
 						Indexes: []memdb.Indexer{
+							tenantIndex,
 							&memdb.StringFieldIndex{Field: "namespace"},
 							&memdb.StringFieldIndex{Field: "resourceID"},
 							&memdb.StringFieldIndex{Field: "relation"},
@@ -171,15 +238,21 @@ var schema = &memdb.DBSchema{
 					},
 				},
 				indexNamespace: {
-					Name:    indexNamespace,
-					Unique:  false,
-					Indexer: &memdb.StringFieldIndex{Field: "namespace"},
+					Name:   indexNamespace,
+					Unique: false,
+					Indexer: &memdb.CompoundIndex{
+						Indexes: []memdb.Indexer{
+							tenantIndex,
+							&memdb.StringFieldIndex{Field: "namespace"},
+						},
+					},
 				},
 				indexNamespaceAndResourceID: {
 					Name:   indexNamespaceAndResourceID,
 					Unique: false,
 					Indexer: &memdb.CompoundIndex{
 						Indexes: []memdb.Indexer{
+							tenantIndex,
 							&memdb.StringFieldIndex{Field: "namespace"},
 							&memdb.StringFieldIndex{Field: "resourceID"},
 						},
@@ -190,6 +263,7 @@ var schema = &memdb.DBSchema{
 					Unique: false,
 					Indexer: &memdb.CompoundIndex{
 						Indexes: []memdb.Indexer{
+							tenantIndex,
 							&memdb.StringFieldIndex{Field: "namespace"},
 							&memdb.StringFieldIndex{Field: "relation"},
 						},
@@ -200,16 +274,44 @@ var schema = &memdb.DBSchema{
 					Unique: false,
 					Indexer: &memdb.CompoundIndex{
 						Indexes: []memdb.Indexer{
+							tenantIndex,
 							&memdb.StringFieldIndex{Field: "namespace"},
 							&memdb.StringFieldIndex{Field: "subjectNamespace"},
 							&memdb.StringFieldIndex{Field: "subjectObjectID"},
 						},
 					},
 				},
-				indexSubjectNamespace: {
-					Name:    indexSubjectNamespace,
-					Unique:  false,
-					Indexer: &memdb.StringFieldIndex{Field: "subjectNamespace"},
+				indexSubjectAndResource: {
+					Name:   indexSubjectAndResource,
+					Unique: true,
+					Indexer: &memdb.CompoundIndex{
+						Indexes: []memdb.Indexer{
+							tenantIndex,
+							&memdb.StringFieldIndex{Field: "subjectNamespace"},
+							&memdb.StringFieldIndex{Field: "subjectObjectID"},
+							&memdb.StringFieldIndex{Field: "subjectRelation"},
+							&memdb.StringFieldIndex{Field: "namespace"},
+							&memdb.StringFieldIndex{Field: "resourceID"},
+							&memdb.StringFieldIndex{Field: "relation"},
+						},
+					},
+				},
+			},
+		},
+		tableSchemaHistory: {
+			Name: tableSchemaHistory,
+			Indexes: map[string]*memdb.IndexSchema{
+				indexID: {
+					Name:   indexID,
+					Unique: true,
+					Indexer: &memdb.CompoundIndex{
+						Indexes: []memdb.Indexer{
+							tenantIndex,
+							&memdb.StringFieldIndex{Field: "kind"},
+							&memdb.StringFieldIndex{Field: "name"},
+							&memdb.IntFieldIndex{Field: "revisionNanos"},
+						},
+					},
 				},
 			},
 		},
@@ -217,9 +319,14 @@ var schema = &memdb.DBSchema{
 			Name: tableCaveats,
 			Indexes: map[string]*memdb.IndexSchema{
 				indexID: {
-					Name:    indexID,
-					Unique:  true,
-					Indexer: &memdb.StringFieldIndex{Field: "name"},
+					Name:   indexID,
+					Unique: true,
+					Indexer: &memdb.CompoundIndex{
+						Indexes: []memdb.Indexer{
+							tenantIndex,
+							&memdb.StringFieldIndex{Field: "name"},
+						},
+					},
 				},
 			},
 		},
@@ -0,0 +1,310 @@
+package memdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
+	"github.com/shopspring/decimal"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+// snapshotFormatVersion is the version of the format of snapshot files, which is checked as they
+// are restored.
+const snapshotFormatVersion = 1
+
+// snapshotData is the content of a snapshot file: every row of the datastore, but for its
+// changelog. Revisions are stored as nanosecond timestamps.
+type snapshotData struct {
+	Version       int                     `json:"version"`
+	Namespaces    []snapshotNamespace     `json:"namespaces"`
+	Caveats       []snapshotCaveat        `json:"caveats"`
+	Relationships []snapshotRelationship  `json:"relationships"`
+	History       []snapshotHistoryRecord `json:"history"`
+}
+
+type snapshotNamespace struct {
+	Tenant   string `json:"tenant,omitempty"`
+	Name     string `json:"name"`
+	Config   []byte `json:"config"`
+	Revision int64  `json:"revision"`
+}
+
+type snapshotCaveat struct {
+	Tenant     string `json:"tenant,omitempty"`
+	Name       string `json:"name"`
+	Definition []byte `json:"definition"`
+	Revision   int64  `json:"revision"`
+}
+
+type snapshotRelationship struct {
+	Tenant           string            `json:"tenant,omitempty"`
+	Namespace        string            `json:"namespace"`
+	ResourceID       string            `json:"resource_id"`
+	Relation         string            `json:"relation"`
+	SubjectNamespace string            `json:"subject_namespace"`
+	SubjectObjectID  string            `json:"subject_object_id"`
+	SubjectRelation  string            `json:"subject_relation"`
+	CaveatName       string            `json:"caveat_name,omitempty"`
+	CaveatContext    map[string]any    `json:"caveat_context,omitempty"`
+	Expiration       *time.Time        `json:"expiration,omitempty"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+}
+
+type snapshotHistoryRecord struct {
+	Tenant     string `json:"tenant,omitempty"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Revision   int64  `json:"revision"`
+	Definition []byte `json:"definition,omitempty"`
+	Deleted    bool   `json:"deleted,omitempty"`
+}
+
+func revisionNanos(rev datastore.Revision) int64 {
+	return rev.(revision.Decimal).IntPart()
+}
+
+func revisionFromNanos(nanos int64) revision.Decimal {
+	return revision.NewFromDecimal(decimal.NewFromInt(nanos))
+}
+
+// restoreSnapshot inserts the rows of the snapshot file into the database, and returns the number
+// of relationships restored. It returns false if there is no snapshot file.
+func restoreSnapshot(db *memdb.MemDB, path string) (uint64, bool, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to read memdb snapshot: %w", err)
+	}
+
+	var data snapshotData
+	if err := json.Unmarshal(contents, &data); err != nil {
+		return 0, false, fmt.Errorf("unable to decode memdb snapshot: %w", err)
+	}
+	if data.Version != snapshotFormatVersion {
+		return 0, false, fmt.Errorf("unsupported memdb snapshot version %d", data.Version)
+	}
+
+	tx := db.Txn(true)
+	defer tx.Abort()
+
+	for _, ns := range data.Namespaces {
+		if err := tx.Insert(tableNamespace, &namespace{
+			tenant:      ns.Tenant,
+			name:        ns.Name,
+			configBytes: ns.Config,
+			updated:     revisionFromNanos(ns.Revision),
+		}); err != nil {
+			return 0, false, err
+		}
+	}
+
+	for _, c := range data.Caveats {
+		if err := tx.Insert(tableCaveats, &caveat{
+			tenant:     c.Tenant,
+			name:       c.Name,
+			definition: c.Definition,
+			revision:   revisionFromNanos(c.Revision),
+		}); err != nil {
+			return 0, false, err
+		}
+	}
+
+	for _, rel := range data.Relationships {
+		var cr *contextualizedCaveat
+		if rel.CaveatName != "" {
+			cr = &contextualizedCaveat{caveatName: rel.CaveatName, context: rel.CaveatContext}
+		}
+		if err := tx.Insert(tableRelationship, &relationship{
+			tenant:           rel.Tenant,
+			namespace:        rel.Namespace,
+			resourceID:       rel.ResourceID,
+			relation:         rel.Relation,
+			subjectNamespace: rel.SubjectNamespace,
+			subjectObjectID:  rel.SubjectObjectID,
+			subjectRelation:  rel.SubjectRelation,
+			caveat:           cr,
+			expiration:       rel.Expiration,
+			metadata:         rel.Metadata,
+		}); err != nil {
+			return 0, false, err
+		}
+	}
+
+	for _, version := range data.History {
+		if err := tx.Insert(tableSchemaHistory, &definitionVersion{
+			tenant:        version.Tenant,
+			kind:          version.Kind,
+			name:          version.Name,
+			revisionNanos: version.Revision,
+			revision:      revisionFromNanos(version.Revision),
+			definition:    version.Definition,
+			deleted:       version.Deleted,
+		}); err != nil {
+			return 0, false, err
+		}
+	}
+
+	tx.Commit()
+	return uint64(len(data.Relationships)), true, nil
+}
+
+// saveSnapshot writes the rows of the database at its head revision to the snapshot file, unless
+// they have already been written. The file is replaced atomically, so that a failed save leaves
+// the previous snapshot in place.
+func (mdb *memdbDatastore) saveSnapshot() error {
+	mdb.snapshotLock.Lock()
+	defer mdb.snapshotLock.Unlock()
+
+	mdb.RLock()
+	if mdb.db == nil {
+		mdb.RUnlock()
+		return nil
+	}
+	head := mdb.revisions[len(mdb.revisions)-1].revision
+	tx := mdb.db.Txn(false)
+	mdb.RUnlock()
+
+	if head.Equal(mdb.snapshotRevision) {
+		return nil
+	}
+
+	data, err := snapshotOf(tx)
+	if err != nil {
+		return fmt.Errorf("unable to snapshot memdb: %w", err)
+	}
+
+	contents, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("unable to encode memdb snapshot: %w", err)
+	}
+
+	if err := writeFileAtomically(mdb.options.snapshotFile, contents); err != nil {
+		return fmt.Errorf("unable to write memdb snapshot: %w", err)
+	}
+
+	mdb.snapshotRevision = head
+	return nil
+}
+
+func snapshotOf(tx *memdb.Txn) (*snapshotData, error) {
+	data := &snapshotData{Version: snapshotFormatVersion}
+
+	it, err := tx.Get(tableNamespace, indexID)
+	if err != nil {
+		return nil, err
+	}
+	for raw := it.Next(); raw != nil; raw = it.Next() {
+		ns := raw.(*namespace)
+		data.Namespaces = append(data.Namespaces, snapshotNamespace{
+			Tenant:   ns.tenant,
+			Name:     ns.name,
+			Config:   ns.configBytes,
+			Revision: revisionNanos(ns.updated),
+		})
+	}
+
+	it, err = tx.Get(tableCaveats, indexID)
+	if err != nil {
+		return nil, err
+	}
+	for raw := it.Next(); raw != nil; raw = it.Next() {
+		c := raw.(*caveat)
+		data.Caveats = append(data.Caveats, snapshotCaveat{
+			Tenant:     c.tenant,
+			Name:       c.name,
+			Definition: c.definition,
+			Revision:   revisionNanos(c.revision),
+		})
+	}
+
+	it, err = tx.Get(tableRelationship, indexID)
+	if err != nil {
+		return nil, err
+	}
+	for raw := it.Next(); raw != nil; raw = it.Next() {
+		rel := raw.(*relationship)
+		record := snapshotRelationship{
+			Tenant:           rel.tenant,
+			Namespace:        rel.namespace,
+			ResourceID:       rel.resourceID,
+			Relation:         rel.relation,
+			SubjectNamespace: rel.subjectNamespace,
+			SubjectObjectID:  rel.subjectObjectID,
+			SubjectRelation:  rel.subjectRelation,
+			Expiration:       rel.expiration,
+			Metadata:         rel.metadata,
+		}
+		if rel.caveat != nil {
+			record.CaveatName = rel.caveat.caveatName
+			record.CaveatContext = rel.caveat.context
+		}
+		data.Relationships = append(data.Relationships, record)
+	}
+
+	it, err = tx.Get(tableSchemaHistory, indexID)
+	if err != nil {
+		return nil, err
+	}
+	for raw := it.Next(); raw != nil; raw = it.Next() {
+		version := raw.(*definitionVersion)
+		data.History = append(data.History, snapshotHistoryRecord{
+			Tenant:     version.tenant,
+			Kind:       version.kind,
+			Name:       version.name,
+			Revision:   version.revisionNanos,
+			Definition: version.definition,
+			Deleted:    version.deleted,
+		})
+	}
+
+	return data, nil
+}
+
+func writeFileAtomically(path string, contents []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(contents); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// runSnapshots saves the datastore at the snapshot interval until the context is canceled.
+func (mdb *memdbDatastore) runSnapshots(ctx context.Context) {
+	ticker := time.NewTicker(mdb.options.snapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := mdb.saveSnapshot(); err != nil {
+				log.Warn().Err(err).Str("path", mdb.options.snapshotFile).Msg("failed to save memdb snapshot")
+			}
+		}
+	}
+}
@@ -5,47 +5,71 @@ import (
 	"fmt"
 
 	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/util"
 )
 
 func (mdb *memdbDatastore) Statistics(ctx context.Context) (datastore.Stats, error) {
+	return mdb.statistics(ctx, datastore.DefaultTenant)
+}
+
+func (mdb *memdbDatastore) statistics(ctx context.Context, tenant string) (datastore.Stats, error) {
 	head, err := mdb.HeadRevision(ctx)
 	if err != nil {
 		return datastore.Stats{}, fmt.Errorf("unable to compute head revision: %w", err)
 	}
 
-	count, err := mdb.countRelationships(ctx)
+	counts, err := mdb.countRelationships(ctx, tenant)
 	if err != nil {
 		return datastore.Stats{}, fmt.Errorf("unable to count relationships: %w", err)
 	}
 
-	objTypes, err := mdb.SnapshotReader(head).ListNamespaces(ctx)
+	objTypes, err := mdb.snapshotReader(tenant, head).ListNamespaces(ctx)
 	if err != nil {
 		return datastore.Stats{}, fmt.Errorf("unable to list object types: %w", err)
 	}
 
 	return datastore.Stats{
 		UniqueID:                   mdb.uniqueID,
-		EstimatedRelationshipCount: count,
-		ObjectTypeStatistics:       datastore.ComputeObjectTypeStats(objTypes),
+		EstimatedRelationshipCount: counts.relationships,
+		EstimatedDistinctResources: uint64(counts.resources.Len()),
+		EstimatedDistinctSubjects:  uint64(counts.subjects.Len()),
+		ObjectTypeStatistics:       datastore.ComputeObjectTypeStatsWithEstimates(objTypes, counts.byNamespace),
 	}, nil
 }
 
-func (mdb *memdbDatastore) countRelationships(ctx context.Context) (uint64, error) {
+// relationshipCounts are the exact counts of the stored relationships.
+type relationshipCounts struct {
+	relationships uint64
+	byNamespace   map[string]uint64
+	resources     *util.Set[string]
+	subjects      *util.Set[string]
+}
+
+func (mdb *memdbDatastore) countRelationships(ctx context.Context, tenant string) (relationshipCounts, error) {
 	mdb.RLock()
 	defer mdb.RUnlock()
 
 	txn := mdb.db.Txn(false)
 	defer txn.Abort()
 
-	it, err := txn.LowerBound(tableRelationship, indexID)
+	counts := relationshipCounts{
+		byNamespace: make(map[string]uint64),
+		resources:   util.NewSet[string](),
+		subjects:    util.NewSet[string](),
+	}
+
+	it, err := txn.Get(tableRelationship, indexID+"_prefix", tenant)
 	if err != nil {
-		return 0, err
+		return counts, err
 	}
 
-	var count uint64
 	for row := it.Next(); row != nil; row = it.Next() {
-		count++
+		rel := row.(*relationship)
+		counts.relationships++
+		counts.byNamespace[rel.namespace]++
+		counts.resources.Add(rel.namespace + ":" + rel.resourceID)
+		counts.subjects.Add(rel.subjectNamespace + ":" + rel.subjectObjectID)
 	}
 
-	return count, nil
+	return counts, nil
 }
@@ -0,0 +1,73 @@
+package memdb
+
+import (
+	"context"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+var _ datastore.TenantPartitionedDatastore = (*memdbDatastore)(nil)
+
+// ForTenant returns a datastore for the data of the tenant, which shares the memdb database
+// and its revisions with this datastore.
+func (mdb *memdbDatastore) ForTenant(tenantID string) datastore.Datastore {
+	return &memdbTenantDatastore{mdb, tenantID}
+}
+
+// memdbTenantDatastore reads and writes the data of a single tenant. Methods which do not
+// depend on the tenant are served by the shared datastore.
+type memdbTenantDatastore struct {
+	*memdbDatastore
+	tenant string
+}
+
+func (td *memdbTenantDatastore) SnapshotReader(revisionRaw datastore.Revision, _ ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return td.snapshotReader(td.tenant, revisionRaw)
+}
+
+func (td *memdbTenantDatastore) ReadWriteTx(
+	ctx context.Context,
+	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
+) (datastore.Revision, error) {
+	return td.readWriteTx(ctx, td.tenant, f, opts...)
+}
+
+func (td *memdbTenantDatastore) BulkLoad(
+	ctx context.Context,
+	source datastore.BulkWriteRelationshipSource,
+) (datastore.Revision, error) {
+	return td.bulkLoad(ctx, td.tenant, source)
+}
+
+func (td *memdbTenantDatastore) Watch(ctx context.Context, afterRevision datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
+	return td.watch(ctx, td.tenant, afterRevision)
+}
+
+func (td *memdbTenantDatastore) Statistics(ctx context.Context) (datastore.Stats, error) {
+	return td.statistics(ctx, td.tenant)
+}
+
+func (td *memdbTenantDatastore) NamespaceHistory(ctx context.Context, nsName string) ([]datastore.DefinitionVersion[*core.NamespaceDefinition], error) {
+	return readHistory(td.memdbDatastore, td.tenant, historyKindNamespace, nsName, func() *core.NamespaceDefinition {
+		return &core.NamespaceDefinition{}
+	})
+}
+
+func (td *memdbTenantDatastore) CaveatHistory(ctx context.Context, name string) ([]datastore.DefinitionVersion[*core.CaveatDefinition], error) {
+	return readHistory(td.memdbDatastore, td.tenant, historyKindCaveat, name, func() *core.CaveatDefinition {
+		return &core.CaveatDefinition{}
+	})
+}
+
+// ForTenant returns a datastore for another tenant of the shared datastore.
+func (td *memdbTenantDatastore) ForTenant(tenantID string) datastore.Datastore {
+	return td.memdbDatastore.ForTenant(tenantID)
+}
+
+// Close does nothing, as the memdb database is owned by the shared datastore.
+func (td *memdbTenantDatastore) Close() error {
+	return nil
+}
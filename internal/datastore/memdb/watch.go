@@ -6,7 +6,9 @@ import (
 	"fmt"
 
 	"github.com/hashicorp/go-memdb"
+	"github.com/shopspring/decimal"
 
+	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/datastore/revision"
 )
@@ -14,6 +16,10 @@ import (
 const errWatchError = "watch error: %w"
 
 func (mdb *memdbDatastore) Watch(ctx context.Context, afterRevision datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
+	return mdb.watch(ctx, datastore.DefaultTenant, afterRevision)
+}
+
+func (mdb *memdbDatastore) watch(ctx context.Context, tenant string, afterRevision datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
 	ar := afterRevision.(revision.Decimal)
 
 	updates := make(chan *datastore.RevisionChanges, mdb.watchBufferLength)
@@ -29,7 +35,7 @@ func (mdb *memdbDatastore) Watch(ctx context.Context, afterRevision datastore.Re
 			var stagedUpdates []*datastore.RevisionChanges
 			var watchChan <-chan struct{}
 			var err error
-			stagedUpdates, currentTxn, watchChan, err = mdb.loadChanges(ctx, currentTxn)
+			stagedUpdates, currentTxn, watchChan, err = mdb.loadChanges(ctx, tenant, currentTxn)
 			if err != nil {
 				errs <- err
 				return
@@ -45,11 +51,25 @@ func (mdb *memdbDatastore) Watch(ctx context.Context, afterRevision datastore.Re
 				}
 			}
 
-			// Wait for new changes
+			// Wait for new changes, sending a checkpoint if none occur within the interval
 			ws := memdb.NewWatchSet()
 			ws.Add(watchChan)
 
-			err = ws.WatchCtx(ctx)
+			waitCtx, cancelWait := context.WithTimeout(ctx, common.WatchCheckpointInterval)
+			err = ws.WatchCtx(waitCtx)
+			cancelWait()
+			if err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+				select {
+				case updates <- &datastore.RevisionChanges{
+					Revision:     revision.NewFromDecimal(decimal.NewFromInt(currentTxn)),
+					IsCheckpoint: true,
+				}:
+				default:
+					errs <- datastore.NewWatchDisconnectedErr()
+					return
+				}
+				continue
+			}
 			if err != nil {
 				switch {
 				case errors.Is(err, context.Canceled):
@@ -65,10 +85,16 @@ func (mdb *memdbDatastore) Watch(ctx context.Context, afterRevision datastore.Re
 	return updates, errs
 }
 
-func (mdb *memdbDatastore) loadChanges(ctx context.Context, currentTxn int64) ([]*datastore.RevisionChanges, int64, <-chan struct{}, error) {
+func (mdb *memdbDatastore) loadChanges(ctx context.Context, tenant string, currentTxn int64) ([]*datastore.RevisionChanges, int64, <-chan struct{}, error) {
 	mdb.RLock()
 	defer mdb.RUnlock()
 
+	// The changes of evicted revisions have been pruned, so a watch which has fallen behind the
+	// evicted revisions cannot continue.
+	if !mdb.evictedRevision.IsZero() && mdb.evictedRevision.IntPart() > currentTxn {
+		return nil, 0, nil, datastore.NewInvalidRevisionErr(revision.NewFromDecimal(decimal.NewFromInt(currentTxn)), datastore.RevisionStale)
+	}
+
 	loadNewTxn := mdb.db.Txn(false)
 	defer loadNewTxn.Abort()
 
@@ -81,8 +107,11 @@ func (mdb *memdbDatastore) loadChanges(ctx context.Context, currentTxn int64) ([
 	lastRevision := currentTxn
 	for changeRaw := it.Next(); changeRaw != nil; changeRaw = it.Next() {
 		change := changeRaw.(*changelog)
-		changes = append(changes, &change.changes)
 		lastRevision = change.revisionNanos
+		if change.tenant != tenant {
+			continue
+		}
+		changes = append(changes, &change.changes)
 	}
 
 	watchChan, _, err := loadNewTxn.LastWatch(tableChangelog, indexRevision)
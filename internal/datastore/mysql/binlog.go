@@ -0,0 +1,858 @@
+package mysql
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // required by the authentication protocol of MySQL
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// The subset of the client/server protocol of MySQL used by a replication connection.
+// See https://dev.mysql.com/doc/dev/mysql-server/latest/PAGE_PROTOCOL.html
+const (
+	clientLongPassword             = 0x00000001
+	clientProtocol41               = 0x00000200
+	clientSSL                      = 0x00000800
+	clientTransactions             = 0x00002000
+	clientSecureConnection         = 0x00008000
+	clientPluginAuth               = 0x00080000
+	clientPluginAuthLenencData     = 0x00200000
+	clientRequiredCapabilities     = clientProtocol41 | clientSecureConnection | clientPluginAuth
+	clientDefaultCapabilities      = clientLongPassword | clientTransactions | clientRequiredCapabilities
+	clientCharsetUTF8              = 33
+	protocolVersion                = 10
+	maxProtocolPacketSize          = 1<<24 - 1
+	comQuery                       = 0x03
+	comBinlogDump                  = 0x12
+	comRegisterSlave               = 0x15
+	packetOK                       = 0x00
+	packetAuthMoreData             = 0x01
+	packetEOF                      = 0xfe
+	packetErr                      = 0xff
+	authNativePassword             = "mysql_native_password"
+	authCachingSHA2Password        = "caching_sha2_password"
+	authCachingSHA2FastAuthSuccess = 0x03
+	authCachingSHA2FullAuth        = 0x04
+	authCachingSHA2PublicKey       = 0x02
+)
+
+// The events of the binary log decoded by a watch.
+// See https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_replication_binlog_event.html
+const (
+	binlogEventHeaderSize        = 19
+	binlogChecksumSize           = 4
+	binlogFormatDescriptionEvent = 15
+	binlogXIDEvent               = 16
+	binlogTableMapEvent          = 19
+	binlogWriteRowsEventV1       = 23
+	binlogWriteRowsEventV2       = 30
+)
+
+// The types of the columns of a table map event, which determine how their values are encoded
+// in a rows event.
+const (
+	mysqlTypeDecimal    = 0
+	mysqlTypeTiny       = 1
+	mysqlTypeShort      = 2
+	mysqlTypeLong       = 3
+	mysqlTypeFloat      = 4
+	mysqlTypeDouble     = 5
+	mysqlTypeNull       = 6
+	mysqlTypeTimestamp  = 7
+	mysqlTypeLongLong   = 8
+	mysqlTypeInt24      = 9
+	mysqlTypeDate       = 10
+	mysqlTypeTime       = 11
+	mysqlTypeDateTime   = 12
+	mysqlTypeYear       = 13
+	mysqlTypeNewDate    = 14
+	mysqlTypeVarchar    = 15
+	mysqlTypeBit        = 16
+	mysqlTypeTimestamp2 = 17
+	mysqlTypeDateTime2  = 18
+	mysqlTypeTime2      = 19
+	mysqlTypeJSON       = 245
+	mysqlTypeNewDecimal = 246
+	mysqlTypeEnum       = 247
+	mysqlTypeSet        = 248
+	mysqlTypeTinyBlob   = 249
+	mysqlTypeMediumBlob = 250
+	mysqlTypeLongBlob   = 251
+	mysqlTypeBlob       = 252
+	mysqlTypeVarString  = 253
+	mysqlTypeString     = 254
+	mysqlTypeGeometry   = 255
+)
+
+var errBinlogTruncated = errors.New("truncated binary log packet")
+
+// binlogConn is a replication connection, which streams the events of the binary log of the
+// server as a replica would.
+type binlogConn struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	sequence byte
+
+	// checksum is whether each event ends with a CRC32 checksum, which is stripped.
+	checksum bool
+}
+
+// dialBinlog opens a connection to the server of the DSN config and authenticates with its
+// credentials, using TLS where the config requires it. The deadline of the context applies to
+// the connection until it is cleared.
+func dialBinlog(ctx context.Context, config *mysql.Config) (*binlogConn, error) {
+	tlsConfig, err := binlogTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, config.Net, config.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &binlogConn{conn: conn, reader: bufio.NewReader(conn)}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			c.close()
+			return nil, err
+		}
+	}
+
+	if err := c.handshake(config, tlsConfig); err != nil {
+		c.close()
+		return nil, fmt.Errorf("unable to authenticate replication connection: %w", err)
+	}
+	return c, nil
+}
+
+// binlogTLSConfig returns the TLS config of the named TLS configs of the DSN. Configs registered
+// with the driver cannot be read, so they are not supported.
+func binlogTLSConfig(config *mysql.Config) (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(config.Addr)
+	if err != nil {
+		host = config.Addr
+	}
+
+	switch config.TLSConfig {
+	case "", "false":
+		return nil, nil
+	case "true":
+		return &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}, nil
+	case "skip-verify", "preferred":
+		return &tls.Config{InsecureSkipVerify: true}, nil //nolint:gosec // requested by the DSN
+	default:
+		return nil, fmt.Errorf("custom TLS config %q is not supported by replication connections", config.TLSConfig)
+	}
+}
+
+func (c *binlogConn) clearDeadline() error {
+	return c.conn.SetDeadline(time.Time{})
+}
+
+func (c *binlogConn) close() {
+	_ = c.conn.Close()
+}
+
+// handshake reads the greeting of the server and authenticates the connection.
+// See https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_connection_phase.html
+func (c *binlogConn) handshake(config *mysql.Config, tlsConfig *tls.Config) error {
+	data, err := c.readPacket()
+	if err != nil {
+		return err
+	}
+	if data[0] == packetErr {
+		return decodeErrPacket(data)
+	}
+
+	r := binlogReader{data: data}
+	if version := r.uint8(); version != protocolVersion {
+		return fmt.Errorf("unsupported protocol version %d", version)
+	}
+	r.cstring() // server version
+	r.skip(4)   // connection id
+	scramble := append([]byte{}, r.bytes(8)...)
+	r.skip(1) // filler
+	capabilities := uint32(r.uint16())
+	r.skip(3) // character set, status flags
+	capabilities |= uint32(r.uint16()) << 16
+	scrambleLength := int(r.uint8())
+	r.skip(10) // reserved
+	if scrambleLength > 8 {
+		scramble = append(scramble, r.bytes(scrambleLength-8)...)
+	}
+	plugin := r.cstring()
+	if r.err != nil {
+		return r.err
+	}
+	if capabilities&clientRequiredCapabilities != clientRequiredCapabilities {
+		return fmt.Errorf("server does not support protocol 4.1 authentication")
+	}
+	scramble = trimNUL(scramble)
+
+	clientCapabilities := clientDefaultCapabilities | capabilities&clientPluginAuthLenencData
+	if tlsConfig != nil {
+		switch {
+		case capabilities&clientSSL != 0:
+			clientCapabilities |= clientSSL
+			if err := c.writePacket(handshakeResponsePrefix(clientCapabilities)); err != nil {
+				return err
+			}
+			tlsConn := tls.Client(c.conn, tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return err
+			}
+			c.conn = tlsConn
+			c.reader = bufio.NewReader(tlsConn)
+		case config.TLSConfig == "preferred":
+			tlsConfig = nil
+		default:
+			return fmt.Errorf("server does not support TLS")
+		}
+	}
+
+	authResponse, err := scramblePassword(plugin, scramble, config.Passwd)
+	if err != nil {
+		return err
+	}
+
+	response := handshakeResponsePrefix(clientCapabilities)
+	response = append(append(response, config.User...), 0)
+	if clientCapabilities&clientPluginAuthLenencData != 0 {
+		response = appendLenencInt(response, uint64(len(authResponse)))
+	} else {
+		response = append(response, byte(len(authResponse)))
+	}
+	response = append(append(append(response, authResponse...), plugin...), 0)
+	if err := c.writePacket(response); err != nil {
+		return err
+	}
+
+	for {
+		data, err := c.readPacket()
+		if err != nil {
+			return err
+		}
+
+		switch data[0] {
+		case packetOK:
+			return nil
+
+		case packetErr:
+			return decodeErrPacket(data)
+
+		case packetEOF:
+			// The server switches the connection to the authentication method of the user.
+			r := binlogReader{data: data[1:]}
+			plugin = r.cstring()
+			scramble = trimNUL(r.rest())
+			if r.err != nil {
+				return r.err
+			}
+			authResponse, err := scramblePassword(plugin, scramble, config.Passwd)
+			if err != nil {
+				return err
+			}
+			if err := c.writePacket(authResponse); err != nil {
+				return err
+			}
+
+		case packetAuthMoreData:
+			if plugin != authCachingSHA2Password || len(data) < 2 {
+				return fmt.Errorf("unexpected authentication packet for %s", plugin)
+			}
+
+			switch data[1] {
+			case authCachingSHA2FastAuthSuccess:
+				// The password was found in the cache of the server, which follows with OK.
+
+			case authCachingSHA2FullAuth:
+				if tlsConfig != nil {
+					if err := c.writePacket(append([]byte(config.Passwd), 0)); err != nil {
+						return err
+					}
+					continue
+				}
+
+				if err := c.writePacket([]byte{authCachingSHA2PublicKey}); err != nil {
+					return err
+				}
+				keyData, err := c.readPacket()
+				if err != nil {
+					return err
+				}
+				if keyData[0] != packetAuthMoreData {
+					return fmt.Errorf("unable to retrieve the public key of the server")
+				}
+				encrypted, err := encryptPassword(keyData[1:], scramble, config.Passwd)
+				if err != nil {
+					return err
+				}
+				if err := c.writePacket(encrypted); err != nil {
+					return err
+				}
+
+			default:
+				return fmt.Errorf("unexpected %s authentication status %d", plugin, data[1])
+			}
+
+		default:
+			return fmt.Errorf("unexpected authentication packet %#x", data[0])
+		}
+	}
+}
+
+// handshakeResponsePrefix returns the fields which start both the TLS request and the
+// handshake response of the client.
+func handshakeResponsePrefix(capabilities uint32) []byte {
+	prefix := binary.LittleEndian.AppendUint32(nil, capabilities)
+	prefix = binary.LittleEndian.AppendUint32(prefix, maxProtocolPacketSize)
+	prefix = append(prefix, clientCharsetUTF8)
+	return append(prefix, make([]byte, 23)...)
+}
+
+// scramblePassword returns the response of the authentication method to the scramble sent by
+// the server.
+func scramblePassword(plugin string, scramble []byte, password string) ([]byte, error) {
+	if password == "" {
+		return nil, nil
+	}
+
+	switch plugin {
+	case authNativePassword:
+		// SHA1(password) XOR SHA1(scramble + SHA1(SHA1(password)))
+		stage1 := sha1.Sum([]byte(password)) //nolint:gosec
+		stage2 := sha1.Sum(stage1[:])        //nolint:gosec
+		hash := sha1.New()                   //nolint:gosec
+		hash.Write(scramble)
+		hash.Write(stage2[:])
+		return xorBytes(stage1[:], hash.Sum(nil)), nil
+
+	case authCachingSHA2Password:
+		// SHA256(password) XOR SHA256(SHA256(SHA256(password)) + scramble)
+		stage1 := sha256.Sum256([]byte(password))
+		stage2 := sha256.Sum256(stage1[:])
+		hash := sha256.New()
+		hash.Write(stage2[:])
+		hash.Write(scramble)
+		return xorBytes(stage1[:], hash.Sum(nil)), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported authentication method %s", plugin)
+	}
+}
+
+// encryptPassword encrypts the password, XORed with the scramble, with the PEM encoded RSA public
+// key of the server, for full caching_sha2_password authentication without TLS.
+func encryptPassword(keyPEM []byte, scramble []byte, password string) ([]byte, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("unable to decode the public key of the server")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the public key of the server: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("the public key of the server is not an RSA key")
+	}
+
+	plain := append([]byte(password), 0)
+	for i := range plain {
+		plain[i] ^= scramble[i%len(scramble)]
+	}
+	return rsa.EncryptOAEP(sha1.New(), rand.Reader, rsaKey, plain, nil) //nolint:gosec
+}
+
+func xorBytes(a, b []byte) []byte {
+	for i := range a {
+		a[i] ^= b[i]
+	}
+	return a
+}
+
+func trimNUL(b []byte) []byte {
+	if len(b) > 0 && b[len(b)-1] == 0 {
+		return b[:len(b)-1]
+	}
+	return b
+}
+
+// exec runs a statement which returns no rows.
+func (c *binlogConn) exec(query string) error {
+	return c.command(append([]byte{comQuery}, query...))
+}
+
+// register registers the connection as a replica with the server ID, which must be unique among
+// the replicas of the server.
+func (c *binlogConn) register(serverID uint32) error {
+	command := binary.LittleEndian.AppendUint32([]byte{comRegisterSlave}, serverID)
+	command = append(command, 0, 0, 0) // hostname, user and password
+	command = binary.LittleEndian.AppendUint16(command, 0)
+	command = binary.LittleEndian.AppendUint32(command, 0) // replication rank
+	command = binary.LittleEndian.AppendUint32(command, 0) // source ID
+	return c.command(command)
+}
+
+// command sends a command, to which the server responds with OK.
+func (c *binlogConn) command(command []byte) error {
+	c.sequence = 0
+	if err := c.writePacket(command); err != nil {
+		return err
+	}
+
+	data, err := c.readPacket()
+	if err != nil {
+		return err
+	}
+	switch data[0] {
+	case packetOK:
+		return nil
+	case packetErr:
+		return decodeErrPacket(data)
+	default:
+		return fmt.Errorf("unexpected response packet %#x", data[0])
+	}
+}
+
+// dump starts streaming the binary log from the position, after which the connection only
+// receives events.
+func (c *binlogConn) dump(serverID uint32, file string, position uint32) error {
+	command := binary.LittleEndian.AppendUint32([]byte{comBinlogDump}, position)
+	command = binary.LittleEndian.AppendUint16(command, 0) // flags
+	command = binary.LittleEndian.AppendUint32(command, serverID)
+	command = append(command, file...)
+
+	c.sequence = 0
+	return c.writePacket(command)
+}
+
+// readEvent reads the next event of the binary log, which must be received before the timeout.
+func (c *binlogConn) readEvent(timeout time.Duration) ([]byte, error) {
+	if err := c.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	data, err := c.readPacket()
+	if err != nil {
+		return nil, err
+	}
+
+	switch data[0] {
+	case packetOK:
+		event := data[1:]
+		if len(event) < binlogEventHeaderSize {
+			return nil, errBinlogTruncated
+		}
+		if c.checksum && len(event) >= binlogEventHeaderSize+binlogChecksumSize {
+			event = event[:len(event)-binlogChecksumSize]
+		}
+		return event, nil
+	case packetErr:
+		return nil, decodeErrPacket(data)
+	case packetEOF:
+		return nil, fmt.Errorf("binary log stream ended")
+	default:
+		return nil, fmt.Errorf("unexpected replication packet %#x", data[0])
+	}
+}
+
+// readPacket reads a packet, joining the packets into which a payload larger than the maximum
+// packet size is split.
+func (c *binlogConn) readPacket() ([]byte, error) {
+	var payload []byte
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+			return nil, err
+		}
+		length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+		c.sequence = header[3] + 1
+
+		start := len(payload)
+		payload = append(payload, make([]byte, length)...)
+		if _, err := io.ReadFull(c.reader, payload[start:]); err != nil {
+			return nil, err
+		}
+
+		if length < maxProtocolPacketSize {
+			if len(payload) == 0 {
+				return nil, errBinlogTruncated
+			}
+			return payload, nil
+		}
+	}
+}
+
+// writePacket writes the payload, split into packets of at most the maximum packet size.
+func (c *binlogConn) writePacket(payload []byte) error {
+	for {
+		length := len(payload)
+		if length > maxProtocolPacketSize {
+			length = maxProtocolPacketSize
+		}
+
+		packet := []byte{byte(length), byte(length >> 8), byte(length >> 16), c.sequence}
+		packet = append(packet, payload[:length]...)
+		if _, err := c.conn.Write(packet); err != nil {
+			return err
+		}
+		c.sequence++
+
+		payload = payload[length:]
+		if length < maxProtocolPacketSize {
+			return nil
+		}
+	}
+}
+
+// decodeErrPacket returns the error of an ERR packet, as the driver would.
+func decodeErrPacket(data []byte) error {
+	r := binlogReader{data: data[1:]}
+	number := r.uint16()
+	message := r.rest()
+	if len(message) > 0 && message[0] == '#' && len(message) >= 6 {
+		message = message[6:] // SQL state
+	}
+	if r.err != nil {
+		return r.err
+	}
+	return &mysql.MySQLError{Number: number, Message: string(message)}
+}
+
+// binlogDecoder decodes the events of the binary log which are relevant to a watch.
+type binlogDecoder struct {
+	// postHeaderLengths are the lengths of the post-header of each event type, indexed by the
+	// type minus one, as described by the format description event.
+	postHeaderLengths []byte
+
+	tables map[uint64]binlogTable
+}
+
+// binlogTable is a table described by a table map event.
+type binlogTable struct {
+	schema      string
+	name        string
+	columnTypes []byte
+	columnMeta  []uint16
+}
+
+// binlogEvent is a decoded event of the binary log.
+type binlogEvent struct {
+	kind byte
+
+	// table is the table of a table map or rows event.
+	table binlogTable
+
+	// rows are the rows written by a write rows event, with the raw value of each column, which
+	// is nil for a NULL value or a column which is not included.
+	rows [][][]byte
+}
+
+func newBinlogDecoder() binlogDecoder {
+	return binlogDecoder{tables: make(map[uint64]binlogTable)}
+}
+
+func (d *binlogDecoder) decode(data []byte) (binlogEvent, error) {
+	event := binlogEvent{kind: data[4]}
+	r := binlogReader{data: data[binlogEventHeaderSize:]}
+
+	switch event.kind {
+	case binlogFormatDescriptionEvent:
+		r.skip(2 + 50 + 4) // binary log version, server version, creation time
+		if headerLength := r.uint8(); headerLength != binlogEventHeaderSize && r.err == nil {
+			return event, fmt.Errorf("unsupported binary log event header length %d", headerLength)
+		}
+		d.postHeaderLengths = append([]byte{}, r.rest()...)
+
+	case binlogTableMapEvent:
+		tableID := r.tableID(d.postHeaderLength(event.kind, 8))
+		r.skip(2) // flags
+		event.table.schema = string(r.bytes(int(r.uint8())))
+		r.skip(1)
+		event.table.name = string(r.bytes(int(r.uint8())))
+		r.skip(1)
+		event.table.columnTypes = append([]byte{}, r.bytes(int(r.lenencInt()))...)
+		meta := binlogReader{data: r.bytes(int(r.lenencInt()))}
+		event.table.columnMeta = make([]uint16, len(event.table.columnTypes))
+		for i, columnType := range event.table.columnTypes {
+			event.table.columnMeta[i] = meta.columnMeta(columnType)
+		}
+		if r.err != nil || meta.err != nil {
+			return event, errBinlogTruncated
+		}
+		d.tables[tableID] = event.table
+
+	case binlogWriteRowsEventV1, binlogWriteRowsEventV2:
+		tableID := r.tableID(d.postHeaderLength(event.kind, 10))
+		r.skip(2) // flags
+		if event.kind == binlogWriteRowsEventV2 {
+			r.skip(int(r.uint16()) - 2) // extra data, which includes its length
+		}
+		if r.err != nil {
+			return event, r.err
+		}
+
+		table, ok := d.tables[tableID]
+		if !ok {
+			return event, fmt.Errorf("rows event of unknown table %d", tableID)
+		}
+		event.table = table
+
+		columnCount := int(r.lenencInt())
+		if columnCount != len(table.columnTypes) {
+			return event, fmt.Errorf("rows event of %d columns for table %s.%s of %d columns", columnCount, table.schema, table.name, len(table.columnTypes))
+		}
+		present := r.bytes((columnCount + 7) / 8)
+		presentCount := 0
+		for i := 0; i < columnCount; i++ {
+			if bitSet(present, i) {
+				presentCount++
+			}
+		}
+
+		for r.err == nil && r.pos < len(r.data) {
+			nulls := r.bytes((presentCount + 7) / 8)
+			row := make([][]byte, columnCount)
+			index := 0
+			for i := 0; i < columnCount; i++ {
+				if !bitSet(present, i) {
+					continue
+				}
+				if !bitSet(nulls, index) {
+					size, err := valueSize(table.columnTypes[i], table.columnMeta[i], r.data[r.pos:])
+					if err != nil {
+						return event, fmt.Errorf("column %d of table %s.%s: %w", i, table.schema, table.name, err)
+					}
+					row[i] = r.bytes(size)
+				}
+				index++
+			}
+			event.rows = append(event.rows, row)
+		}
+		if r.err != nil {
+			return event, r.err
+		}
+	}
+
+	return event, nil
+}
+
+// postHeaderLength returns the post-header length of the event type, or the length of the
+// current binary log version before the format has been described.
+func (d *binlogDecoder) postHeaderLength(kind byte, current int) int {
+	if int(kind) > len(d.postHeaderLengths) {
+		return current
+	}
+	return int(d.postHeaderLengths[kind-1])
+}
+
+func bitSet(bitmap []byte, i int) bool {
+	return bitmap[i/8]&(1<<(i%8)) != 0
+}
+
+// decimalDigitBytes is the size of the leftover digits of a decimal value, beyond those packed
+// into four bytes for each nine digits.
+var decimalDigitBytes = [10]int{0, 1, 1, 2, 2, 3, 3, 4, 4, 4}
+
+// valueSize returns the size of the value of a column of the type and metadata, which starts the
+// data.
+func valueSize(columnType byte, meta uint16, data []byte) (int, error) {
+	lengthPrefixed := func(prefixSize int) (int, error) {
+		if len(data) < prefixSize {
+			return 0, errBinlogTruncated
+		}
+		length := 0
+		for i := 0; i < prefixSize; i++ {
+			length |= int(data[i]) << (8 * i)
+		}
+		return prefixSize + length, nil
+	}
+
+	switch columnType {
+	case mysqlTypeNull:
+		return 0, nil
+	case mysqlTypeTiny, mysqlTypeYear:
+		return 1, nil
+	case mysqlTypeShort:
+		return 2, nil
+	case mysqlTypeInt24, mysqlTypeDate, mysqlTypeNewDate, mysqlTypeTime:
+		return 3, nil
+	case mysqlTypeLong, mysqlTypeFloat, mysqlTypeTimestamp:
+		return 4, nil
+	case mysqlTypeLongLong, mysqlTypeDouble, mysqlTypeDateTime:
+		return 8, nil
+	case mysqlTypeTimestamp2:
+		return 4 + (int(meta)+1)/2, nil
+	case mysqlTypeDateTime2:
+		return 5 + (int(meta)+1)/2, nil
+	case mysqlTypeTime2:
+		return 3 + (int(meta)+1)/2, nil
+	case mysqlTypeVarchar, mysqlTypeVarString:
+		if meta < 256 {
+			return lengthPrefixed(1)
+		}
+		return lengthPrefixed(2)
+	case mysqlTypeBlob, mysqlTypeTinyBlob, mysqlTypeMediumBlob, mysqlTypeLongBlob, mysqlTypeJSON, mysqlTypeGeometry:
+		return lengthPrefixed(int(meta))
+	case mysqlTypeNewDecimal:
+		precision, scale := int(meta>>8), int(meta&0xff)
+		integral := precision - scale
+		return integral/9*4 + decimalDigitBytes[integral%9] + scale/9*4 + decimalDigitBytes[scale%9], nil
+	case mysqlTypeBit:
+		bits, bytes := int(meta&0xff), int(meta>>8)
+		if bits > 0 {
+			bytes++
+		}
+		return bytes, nil
+	case mysqlTypeString, mysqlTypeEnum, mysqlTypeSet:
+		// The real type of the column is stored in its metadata, along with its length, the upper
+		// bits of which are stored in the unused bits of the type.
+		realType, length := byte(meta>>8), int(meta&0xff)
+		if realType&0x30 != 0x30 {
+			length |= int((realType&0x30)^0x30) << 4
+			realType |= 0x30
+		}
+		switch realType {
+		case mysqlTypeEnum, mysqlTypeSet:
+			return length, nil
+		case mysqlTypeString:
+			if length > 255 {
+				return lengthPrefixed(2)
+			}
+			return lengthPrefixed(1)
+		}
+		return 0, fmt.Errorf("unsupported string column type %d", realType)
+	default:
+		return 0, fmt.Errorf("unsupported column type %d", columnType)
+	}
+}
+
+// binlogReader reads the little-endian fields of a packet, recording an error if the packet is
+// shorter than the fields read.
+type binlogReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *binlogReader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || r.pos+n > len(r.data) {
+		r.err = errBinlogTruncated
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *binlogReader) skip(n int) {
+	r.bytes(n)
+}
+
+func (r *binlogReader) rest() []byte {
+	return r.bytes(len(r.data) - r.pos)
+}
+
+func (r *binlogReader) uint8() uint8 {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *binlogReader) uint16() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *binlogReader) uintN(n int) uint64 {
+	var value uint64
+	for i, b := range r.bytes(n) {
+		value |= uint64(b) << (8 * i)
+	}
+	return value
+}
+
+func (r *binlogReader) cstring() string {
+	if r.err != nil {
+		return ""
+	}
+	for i := r.pos; i < len(r.data); i++ {
+		if r.data[i] == 0 {
+			s := string(r.data[r.pos:i])
+			r.pos = i + 1
+			return s
+		}
+	}
+	r.err = errBinlogTruncated
+	return ""
+}
+
+// lenencInt reads a length-encoded integer.
+func (r *binlogReader) lenencInt() uint64 {
+	switch first := r.uint8(); first {
+	case 0xfc:
+		return r.uintN(2)
+	case 0xfd:
+		return r.uintN(3)
+	case 0xfe:
+		return r.uintN(8)
+	default:
+		return uint64(first)
+	}
+}
+
+// tableID reads the ID of a table, which is 4 bytes long in the oldest binary log versions.
+func (r *binlogReader) tableID(postHeaderLength int) uint64 {
+	if postHeaderLength == 6 {
+		return r.uintN(4)
+	}
+	return r.uintN(6)
+}
+
+// columnMeta reads the metadata of a column of the type from the metadata of a table map event.
+func (r *binlogReader) columnMeta(columnType byte) uint16 {
+	switch columnType {
+	case mysqlTypeFloat, mysqlTypeDouble, mysqlTypeBlob, mysqlTypeTinyBlob, mysqlTypeMediumBlob,
+		mysqlTypeLongBlob, mysqlTypeGeometry, mysqlTypeJSON, mysqlTypeTimestamp2, mysqlTypeDateTime2, mysqlTypeTime2:
+		return uint16(r.uint8())
+	case mysqlTypeVarchar, mysqlTypeVarString, mysqlTypeBit:
+		return r.uint16()
+	case mysqlTypeNewDecimal, mysqlTypeString, mysqlTypeEnum, mysqlTypeSet:
+		high := r.uint8()
+		return uint16(high)<<8 | uint16(r.uint8())
+	default:
+		return 0
+	}
+}
+
+func appendLenencInt(b []byte, value uint64) []byte {
+	switch {
+	case value < 0xfb:
+		return append(b, byte(value))
+	case value <= 0xffff:
+		return binary.LittleEndian.AppendUint16(append(b, 0xfc), uint16(value))
+	case value <= 0xffffff:
+		return append(b, 0xfd, byte(value), byte(value>>8), byte(value>>16))
+	default:
+		return binary.LittleEndian.AppendUint64(append(b, 0xfe), value)
+	}
+}
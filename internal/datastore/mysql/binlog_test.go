@@ -0,0 +1,236 @@
+package mysql
+
+import (
+	"bufio"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+type binlogBuilder []byte
+
+func (b binlogBuilder) byte(v ...byte) binlogBuilder {
+	return append(b, v...)
+}
+
+func (b binlogBuilder) uint16(v uint16) binlogBuilder {
+	return binary.LittleEndian.AppendUint16(b, v)
+}
+
+func (b binlogBuilder) uint48(v uint64) binlogBuilder {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24), byte(v>>32), byte(v>>40))
+}
+
+func (b binlogBuilder) uint64(v uint64) binlogBuilder {
+	return binary.LittleEndian.AppendUint64(b, v)
+}
+
+func (b binlogBuilder) name(v string) binlogBuilder {
+	return append(append(b.byte(byte(len(v))), v...), 0)
+}
+
+func binlogEventData(kind byte, body binlogBuilder) []byte {
+	header := make([]byte, binlogEventHeaderSize)
+	header[4] = kind
+	return append(header, body...)
+}
+
+// transactionTableMap describes the transactions table, of an ID, a timestamp, metadata and a
+// tenant.
+func transactionTableMap(tableID uint64) []byte {
+	return binlogEventData(binlogTableMapEvent, binlogBuilder{}.uint48(tableID).uint16(1).
+		name("spicedb").name("relation_tuple_transaction").
+		byte(4, mysqlTypeLongLong, mysqlTypeDateTime2, mysqlTypeJSON, mysqlTypeVarchar).
+		byte(4, 6, 4).uint16(256).
+		byte(0x0c))
+}
+
+func TestBinlogDecode(t *testing.T) {
+	require := require.New(t)
+	decoder := newBinlogDecoder()
+
+	event, err := decoder.decode(transactionTableMap(42))
+	require.NoError(err)
+	require.Equal(byte(binlogTableMapEvent), event.kind)
+	require.Equal("spicedb", event.table.schema)
+	require.Equal("relation_tuple_transaction", event.table.name)
+	require.Equal([]uint16{0, 6, 4, 256}, event.table.columnMeta)
+
+	row := func(id uint64, metadata string, tenant string) binlogBuilder {
+		b := binlogBuilder{}
+		if metadata == "" {
+			b = b.byte(0x04)
+		} else {
+			b = b.byte(0x00)
+		}
+		b = b.uint64(id).byte(0x99, 0xb0, 0x5c, 0x45, 0x2a, 0x01, 0x02, 0x03)
+		if metadata != "" {
+			b = binary.LittleEndian.AppendUint32(b, uint32(len(metadata)))
+			b = append(b, metadata...)
+		}
+		return append(b.uint16(uint16(len(tenant))), tenant...)
+	}
+
+	rows := binlogBuilder{}.uint48(42).uint16(0).uint16(2).byte(4, 0x0f)
+	rows = append(rows, row(7, "", "")...)
+	rows = append(rows, row(8, "\x00\x00\x00", "tenant")...)
+	event, err = decoder.decode(binlogEventData(binlogWriteRowsEventV2, rows))
+	require.NoError(err)
+	require.Equal(byte(binlogWriteRowsEventV2), event.kind)
+	require.Len(event.rows, 2)
+	require.Nil(event.rows[0][2])
+	require.Equal([]byte("tenant"), event.rows[1][3][2:])
+
+	for i, expected := range []uint64{7, 8} {
+		txnID, err := rowTransactionID(event.table, event.rows[i])
+		require.NoError(err)
+		require.Equal(expected, txnID)
+	}
+
+	_, err = decoder.decode(binlogEventData(binlogWriteRowsEventV2, binlogBuilder{}.uint48(43).uint16(0).uint16(2).byte(0)))
+	require.ErrorContains(err, "unknown table 43")
+
+	_, err = decoder.decode(binlogEventData(binlogWriteRowsEventV2, rows[:len(rows)-3]))
+	require.ErrorIs(err, errBinlogTruncated)
+
+	event, err = decoder.decode(binlogEventData(binlogXIDEvent, binlogBuilder{}.uint64(1)))
+	require.NoError(err)
+	require.Equal(byte(binlogXIDEvent), event.kind)
+}
+
+func TestValueSize(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		columnType byte
+		meta       uint16
+		data       []byte
+		size       int
+	}{
+		{"bigint", mysqlTypeLongLong, 0, nil, 8},
+		{"datetime(6)", mysqlTypeDateTime2, 6, nil, 8},
+		{"timestamp(3)", mysqlTypeTimestamp2, 3, nil, 6},
+		{"short varchar", mysqlTypeVarchar, 255, []byte{3}, 4},
+		{"long varchar", mysqlTypeVarchar, 1024, []byte{0x01, 0x01}, 259},
+		{"json", mysqlTypeJSON, 4, []byte{2, 0, 0, 0}, 6},
+		{"decimal(10,2)", mysqlTypeNewDecimal, 10<<8 | 2, nil, 5},
+		{"bit(10)", mysqlTypeBit, 1<<8 | 2, nil, 2},
+		{"char(10)", mysqlTypeString, mysqlTypeString<<8 | 40, []byte{5}, 6},
+		{"enum", mysqlTypeString, mysqlTypeEnum<<8 | 1, nil, 1},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			size, err := valueSize(tc.columnType, tc.meta, tc.data)
+			require.NoError(t, err)
+			require.Equal(t, tc.size, size)
+		})
+	}
+
+	_, err := valueSize(mysqlTypeDecimal, 0, nil)
+	require.Error(t, err)
+}
+
+func TestScramblePassword(t *testing.T) {
+	scramble := []byte("01234567890123456789")
+
+	// The server verifies the response against the double hash of the password it stores.
+	response, err := scramblePassword(authNativePassword, scramble, "secret")
+	require.NoError(t, err)
+	stage1 := sha1.Sum([]byte("secret")) //nolint:gosec
+	stored := sha1.Sum(stage1[:])        //nolint:gosec
+	hash := sha1.New()                   //nolint:gosec
+	hash.Write(scramble)
+	hash.Write(stored[:])
+	recovered := sha1.Sum(xorBytes(response, hash.Sum(nil))) //nolint:gosec
+	require.Equal(t, stored, recovered)
+
+	response, err = scramblePassword(authCachingSHA2Password, scramble, "secret")
+	require.NoError(t, err)
+	sha2Stage1 := sha256.Sum256([]byte("secret"))
+	sha2Stored := sha256.Sum256(sha2Stage1[:])
+	sha2Hash := sha256.New()
+	sha2Hash.Write(sha2Stored[:])
+	sha2Hash.Write(scramble)
+	require.Equal(t, sha2Stored, sha256.Sum256(xorBytes(response, sha2Hash.Sum(nil))))
+
+	response, err = scramblePassword(authNativePassword, scramble, "")
+	require.NoError(t, err)
+	require.Empty(t, response)
+
+	_, err = scramblePassword("sha256_password", scramble, "secret")
+	require.Error(t, err)
+}
+
+func TestDecodeErrPacket(t *testing.T) {
+	err := decodeErrPacket(append(binlogBuilder{}.byte(packetErr).uint16(1227).byte('#'), "42000Access denied"...))
+	require.EqualError(t, err, "Error 1227: Access denied")
+}
+
+// fakeServer exchanges packets with a binlogConn over a pipe.
+type fakeServer struct {
+	t    *testing.T
+	conn *binlogConn
+}
+
+func (s fakeServer) expect(prefix ...byte) []byte {
+	data, err := s.conn.readPacket()
+	require.NoError(s.t, err)
+	require.Equal(s.t, string(prefix), string(data[:len(prefix)]))
+	return data
+}
+
+func (s fakeServer) send(payload []byte) {
+	require.NoError(s.t, s.conn.writePacket(payload))
+}
+
+func TestBinlogConn(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	server := fakeServer{t, &binlogConn{conn: serverConn, reader: bufio.NewReader(serverConn)}}
+	scramble := []byte("abcdefghijklmnopqrst")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer serverConn.Close()
+
+		greeting := binlogBuilder{}.byte(protocolVersion).byte([]byte("8.0.36\x00")...).byte(1, 0, 0, 0).
+			byte(scramble[:8]...).byte(0).uint16(0xffff).byte(clientCharsetUTF8).uint16(0).uint16(0x00ff).
+			byte(21).byte(make([]byte, 10)...).byte(scramble[8:]...).byte(0).
+			byte([]byte(authNativePassword)...).byte(0)
+		server.send(greeting)
+
+		response := server.expect()
+		expected, err := scramblePassword(authNativePassword, scramble, "secret")
+		require.NoError(t, err)
+		require.Contains(t, string(response), "spicedb\x00\x14"+string(expected)+authNativePassword)
+		server.send([]byte{packetOK, 0, 0})
+
+		server.conn.sequence = 1
+		server.expect(comQuery)
+		server.send([]byte{packetOK, 0, 0})
+
+		server.conn.sequence = 1
+		server.expect(binlogBuilder{}.byte(comBinlogDump).byte(4, 0, 0, 0, 0, 0, 7, 0, 0, 0x80)...)
+		server.send(append(append([]byte{packetOK}, binlogEventData(binlogXIDEvent, binlogBuilder{}.uint64(3))...), 1, 2, 3, 4))
+		server.send(binlogBuilder{}.byte(packetErr).uint16(1236).byte([]byte("#HY000binary log purged")...))
+	}()
+
+	conn := &binlogConn{conn: clientConn, reader: bufio.NewReader(clientConn), checksum: true}
+	require.NoError(t, conn.handshake(&mysql.Config{User: "spicedb", Passwd: "secret"}, nil))
+	require.NoError(t, conn.exec("SET @master_heartbeat_period = 1"))
+	require.NoError(t, conn.dump(0x80000007, "binlog.000001", 4))
+
+	event, err := conn.readEvent(time.Second)
+	require.NoError(t, err)
+	require.Equal(t, binlogEventData(binlogXIDEvent, binlogBuilder{}.uint64(3)), event)
+
+	_, err = conn.readEvent(time.Second)
+	require.EqualError(t, err, "Error 1236: binary log purged")
+	<-done
+}
@@ -0,0 +1,336 @@
+package mysql
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	"github.com/authzed/spicedb/pkg/util"
+)
+
+const (
+	querySelectBinlogSettings = "SELECT @@global.log_bin, @@global.binlog_format, @@global.binlog_checksum"
+
+	// binlogHeartbeatPeriod is the interval at which the server sends a heartbeat event while
+	// there are no events to stream, at which a watch checks whether a checkpoint is due.
+	binlogHeartbeatPeriod = time.Second
+
+	// binlogReceiveTimeout is the longest a watch waits for an event, after which the
+	// replication connection is considered broken.
+	binlogReceiveTimeout = 5 * binlogHeartbeatPeriod
+
+	binlogConnectTimeout = 10 * time.Second
+
+	// binlogCommitPollInterval is the interval at which a watch checks whether a transaction
+	// streamed from the binary log has become visible. The binary log is streamed before the
+	// transactions it contains have been committed by the storage engine.
+	binlogCommitPollInterval = 5 * time.Millisecond
+)
+
+// binlogStream is a replication connection streaming the binary log from the position at which
+// it was opened.
+type binlogStream struct {
+	conn    *binlogConn
+	decoder binlogDecoder
+
+	// schema is the database of the datastore, whose transactions table is watched.
+	schema string
+}
+
+// openBinlogStream opens a replication connection with the credentials of the datastore, and
+// starts streaming the binary log from its current position. It fails if the binary log does not
+// log rows, or if the user of the datastore is not granted REPLICATION CLIENT and REPLICATION
+// SLAVE.
+func (mds *Datastore) openBinlogStream(ctx context.Context) (*binlogStream, error) {
+	config, err := mysql.ParseDSN(mds.url)
+	if err != nil {
+		return nil, err
+	}
+
+	var logBin bool
+	var format, checksum string
+	if err := mds.db.QueryRowContext(ctx, querySelectBinlogSettings).Scan(&logBin, &format, &checksum); err != nil {
+		return nil, fmt.Errorf("unable to read binary log settings: %w", err)
+	}
+	if !logBin {
+		return nil, fmt.Errorf("binary logging is disabled")
+	}
+	if format != "ROW" {
+		return nil, fmt.Errorf("binary log format is %s, rather than ROW", format)
+	}
+
+	file, position, err := mds.binlogPosition(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	serverID, err := replicaServerID()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, binlogConnectTimeout)
+	defer cancel()
+
+	conn, err := dialBinlog(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// A replica declares that it can verify checksums by requesting those of the server, and is
+	// otherwise refused the stream of a server which writes them. The variables were renamed in
+	// MySQL 8.0.26, so both names are set.
+	statements := []string{
+		"SET @master_binlog_checksum = @@global.binlog_checksum, @source_binlog_checksum = @@global.binlog_checksum",
+		fmt.Sprintf("SET @master_heartbeat_period = %d, @source_heartbeat_period = %[1]d", binlogHeartbeatPeriod.Nanoseconds()),
+	}
+	for _, statement := range statements {
+		if err := conn.exec(statement); err != nil {
+			conn.close()
+			return nil, fmt.Errorf("unable to configure replication connection: %w", err)
+		}
+	}
+	conn.checksum = checksum != "NONE"
+
+	if err := conn.register(serverID); err != nil {
+		conn.close()
+		return nil, fmt.Errorf("unable to register replication connection: %w", err)
+	}
+	if err := conn.dump(serverID, file, position); err != nil {
+		conn.close()
+		return nil, fmt.Errorf("unable to stream binary log: %w", err)
+	}
+	if err := conn.clearDeadline(); err != nil {
+		conn.close()
+		return nil, err
+	}
+
+	return &binlogStream{conn: conn, decoder: newBinlogDecoder(), schema: config.DBName}, nil
+}
+
+// binlogPosition returns the current file and position of the binary log.
+func (mds *Datastore) binlogPosition(ctx context.Context) (string, uint32, error) {
+	rows, err := mds.db.QueryContext(ctx, "SHOW BINARY LOG STATUS")
+	if err != nil {
+		// The statement was named SHOW MASTER STATUS before MySQL 8.2.
+		rows, err = mds.db.QueryContext(ctx, "SHOW MASTER STATUS")
+		if err != nil {
+			return "", 0, fmt.Errorf("unable to read binary log position: %w", err)
+		}
+	}
+	defer common.LogOnError(ctx, rows.Close)
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", 0, err
+	}
+	if len(columns) < 2 {
+		return "", 0, fmt.Errorf("unexpected binary log status of %d columns", len(columns))
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", 0, err
+		}
+		return "", 0, fmt.Errorf("binary log position is not visible to the datastore user")
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return "", 0, err
+	}
+
+	position, err := strconv.ParseUint(string(values[1]), 10, 32)
+	if err != nil {
+		return "", 0, fmt.Errorf("unable to parse binary log position: %w", err)
+	}
+	return string(values[0]), uint32(position), nil
+}
+
+// replicaServerID returns a random server ID for a replication connection, in the upper half of
+// the range so that it is unlikely to collide with the IDs of the replicas of the server.
+func replicaServerID() (uint32, error) {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b[:]) | 1<<31, nil
+}
+
+func (s *binlogStream) receive() (binlogEvent, error) {
+	data, err := s.conn.readEvent(binlogReceiveTimeout)
+	if err != nil {
+		return binlogEvent{}, err
+	}
+	return s.decoder.decode(data)
+}
+
+func (s *binlogStream) close() {
+	s.conn.close()
+}
+
+// watchBinlog sends the changes of the transactions committed after the transaction, in the
+// order in which they are committed, as they are streamed from the binary log.
+//
+// The transactions committed before the stream started are first loaded from the tables. As
+// transaction IDs are allocated before their transactions commit, a transaction can be streamed
+// after transactions with greater IDs have been loaded, so only the transactions which were
+// loaded are skipped as they are streamed.
+func (mds *Datastore) watchBinlog(
+	ctx context.Context,
+	stream *binlogStream,
+	currentTxn uint64,
+	updates chan<- *datastore.RevisionChanges,
+	errs chan<- error,
+) {
+	sendError := func(err error) {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			errs <- datastore.NewWatchCanceledErr()
+		} else {
+			errs <- err
+		}
+	}
+
+	lastSent := time.Now()
+	send := func(changes *datastore.RevisionChanges) bool {
+		select {
+		case updates <- changes:
+			lastSent = time.Now()
+			return true
+		default:
+			errs <- datastore.NewWatchDisconnectedErr()
+			return false
+		}
+	}
+
+	loaded, currentTxn, err := mds.loadChanges(ctx, currentTxn)
+	if err != nil {
+		sendError(err)
+		return
+	}
+
+	loadedTxns := util.NewSet[uint64]()
+	for _, changes := range loaded {
+		loadedTxns.Add(transactionFromRevision(changes.Revision.(revision.Decimal)))
+		if !send(changes) {
+			return
+		}
+	}
+	loadedUpTo := currentTxn
+
+	// The IDs of the transactions inserted by the transaction being streamed, which are sent
+	// as it commits.
+	var committing []uint64
+	for {
+		if ctx.Err() != nil {
+			sendError(ctx.Err())
+			return
+		}
+
+		event, err := stream.receive()
+		if err != nil {
+			sendError(fmt.Errorf("unable to stream binary log: %w", err))
+			return
+		}
+
+		switch event.kind {
+		case binlogWriteRowsEventV1, binlogWriteRowsEventV2:
+			if event.table.schema != stream.schema || event.table.name != mds.driver.RelationTupleTransaction() {
+				continue
+			}
+			for _, row := range event.rows {
+				txnID, err := rowTransactionID(event.table, row)
+				if err != nil {
+					sendError(err)
+					return
+				}
+				committing = append(committing, txnID)
+			}
+
+		case binlogXIDEvent:
+			for _, txnID := range committing {
+				if txnID <= loadedUpTo && loadedTxns.Has(txnID) {
+					continue
+				}
+
+				changes, err := mds.loadCommittedChanges(ctx, txnID)
+				if err != nil {
+					sendError(err)
+					return
+				}
+				for _, change := range changes {
+					if !send(change) {
+						return
+					}
+				}
+				if txnID > currentTxn {
+					currentTxn = txnID
+				}
+			}
+			committing = nil
+		}
+
+		// Send a checkpoint if no changes have been sent within the interval
+		if time.Since(lastSent) >= common.WatchCheckpointInterval {
+			if !send(&datastore.RevisionChanges{
+				Revision:     revisionFromTransaction(currentTxn),
+				IsCheckpoint: true,
+			}) {
+				return
+			}
+		}
+	}
+}
+
+// rowTransactionID returns the ID of a row written to the transactions table.
+func rowTransactionID(table binlogTable, row [][]byte) (uint64, error) {
+	if len(row) == 0 || table.columnTypes[0] != mysqlTypeLongLong || len(row[0]) != 8 {
+		return 0, fmt.Errorf("transaction row without an ID in table %s", table.name)
+	}
+	return binary.LittleEndian.Uint64(row[0]), nil
+}
+
+// loadCommittedChanges loads the changes of a transaction streamed from the binary log, once it
+// has been committed.
+func (mds *Datastore) loadCommittedChanges(ctx context.Context, txnID uint64) ([]*datastore.RevisionChanges, error) {
+	query, args, err := sb.Select("COUNT(*)").
+		From(mds.driver.RelationTupleTransaction()).
+		Where(sq.Eq{colID: txnID}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var count int
+		if err := mds.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+			return nil, fmt.Errorf("unable to load streamed transaction: %w", err)
+		}
+		if count > 0 {
+			break
+		}
+
+		select {
+		case <-time.After(binlogCommitPollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	return mds.loadChangesBetween(ctx, txnID-1, txnID)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/datastore/revision"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -52,14 +53,20 @@ func (mr *mysqlReader) ReadCaveatByName(ctx context.Context, name string) (*core
 	return &def, revision.NewFromDecimal(rev), nil
 }
 
-func (mr *mysqlReader) ListCaveats(ctx context.Context, caveatNames ...string) ([]*core.CaveatDefinition, error) {
-	caveatsWithNames := mr.ListCaveatsQuery
-	if len(caveatNames) > 0 {
-		caveatsWithNames = caveatsWithNames.Where(sq.Eq{colName: caveatNames})
+func (mr *mysqlReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	return mr.loadCaveats(ctx, common.ListDefinitionsPage(mr.filterer(mr.ListCaveatsQuery), colName, opts...))
+}
+
+func (mr *mysqlReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	if len(caveatNames) == 0 {
+		return nil, nil
 	}
 
-	filteredListCaveat := mr.filterer(caveatsWithNames)
-	listSQL, listArgs, err := filteredListCaveat.ToSql()
+	return mr.loadCaveats(ctx, mr.filterer(mr.ListCaveatsQuery).Where(sq.Eq{colName: caveatNames}))
+}
+
+func (mr *mysqlReader) loadCaveats(ctx context.Context, query sq.SelectBuilder) ([]*core.CaveatDefinition, error) {
+	listSQL, listArgs, err := query.ToSql()
 	if err != nil {
 		return nil, err
 	}
@@ -110,7 +117,7 @@ func (rwt *mysqlReadWriteTXN) WriteCaveats(ctx context.Context, caveats []*core.
 			return fmt.Errorf("unable to write caveat: %w", err)
 		}
 
-		writeQuery = writeQuery.Values(newCaveat.Name, serialized, rwt.newTxnID)
+		writeQuery = writeQuery.Values(rwt.tenant, newCaveat.Name, serialized, rwt.newTxnID)
 		caveatNamesToWrite = append(caveatNamesToWrite, newCaveat.Name)
 	}
 
@@ -139,7 +146,7 @@ func (rwt *mysqlReadWriteTXN) DeleteCaveats(ctx context.Context, names []string)
 func (rwt *mysqlReadWriteTXN) deleteCaveatsFromNames(ctx context.Context, names []string) error {
 	delSQL, delArgs, err := rwt.DeleteCaveatQuery.
 		Set(colDeletedTxn, rwt.newTxnID).
-		Where(sq.Eq{colName: names}).
+		Where(sq.Eq{colTenant: rwt.tenant, colName: names}).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf(errDeleteCaveat, err)
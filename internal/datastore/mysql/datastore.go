@@ -3,9 +3,12 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -21,6 +24,7 @@ import (
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/datastore/common/revisions"
 	"github.com/authzed/spicedb/internal/datastore/mysql/migrations"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/internal/datastore/proxy"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -46,6 +50,10 @@ const (
 	colCaveatDefinition = "definition"
 	colCaveatName       = "caveat_name"
 	colCaveatContext    = "caveat_context"
+	colExpiration       = "expiration"
+	colMetadata         = "metadata"
+	colCreatedAt        = "created_at"
+	colTenant           = "tenant_id"
 
 	errUnableToInstantiate = "unable to instantiate datastore: %w"
 	liveDeletedTxnID       = uint64(math.MaxInt64)
@@ -53,6 +61,8 @@ const (
 	noLastInsertID         = 0
 	seedingTimeout         = 10 * time.Second
 
+	querySelectVersion = "SELECT VERSION()"
+
 	// https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html#error_er_lock_wait_timeout
 	errMysqlLockWaitTimeout = 1205
 
@@ -111,11 +121,35 @@ func newMySQLDatastore(uri string, options ...Option) (*Datastore, error) {
 		return nil, fmt.Errorf("NewMySQLDatastore: failed to create connector: %w", err)
 	}
 
+	sessionVariables := make(map[string]string)
 	if config.lockWaitTimeoutSeconds != nil {
 		log.Info().Uint8("timeout", *config.lockWaitTimeoutSeconds).Msg("overriding innodb_lock_wait_timeout")
-		connector, err = addSessionVariables(connector, map[string]string{
-			"innodb_lock_wait_timeout": fmt.Sprintf("%d", *config.lockWaitTimeoutSeconds),
-		})
+		sessionVariables["innodb_lock_wait_timeout"] = fmt.Sprintf("%d", *config.lockWaitTimeoutSeconds)
+	}
+
+	// Transactions are started with the options of the driver by default, which sets the
+	// isolation level before each transaction. Older versions of vtgate support neither this nor
+	// READ ONLY transactions, so on Vitess the isolation level is set for the session instead.
+	readTxOptions := &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}
+	readWriteTxOptions := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	if config.vitessCompatibility {
+		log.Info().Msg("enabling vitess compatibility")
+		sessionVariables["transaction_isolation"] = "'SERIALIZABLE'"
+		readTxOptions = nil
+		readWriteTxOptions = nil
+	}
+
+	// TiDB rejects the SERIALIZABLE isolation level, and replays the statements of optimistic
+	// transactions which fail to commit unless disabled, which would not recheck what they read.
+	if config.tidbCompatibility {
+		log.Info().Msg("enabling tidb compatibility")
+		sessionVariables["tidb_disable_txn_auto_retry"] = "ON"
+		readTxOptions = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+		readWriteTxOptions = &sql.TxOptions{Isolation: sql.LevelRepeatableRead}
+	}
+
+	if len(sessionVariables) > 0 {
+		connector, err = addSessionVariables(connector, sessionVariables)
 		if err != nil {
 			return nil, fmt.Errorf("NewMySQLDatastore: failed to add session variables to connector: %w", err)
 		}
@@ -201,9 +235,17 @@ func newMySQLDatastore(uri string, options ...Option) (*Datastore, error) {
 		createTxn:              createTxn,
 		createBaseTxn:          createBaseTxn,
 		QueryBuilder:           queryBuilder,
-		readTxOptions:          &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true},
-		maxRetries:             config.maxRetries,
-		analyzeBeforeStats:     config.analyzeBeforeStats,
+		readTxOptions:          readTxOptions,
+		readWriteTxOptions:     readWriteTxOptions,
+		vitessCompatibility:    config.vitessCompatibility,
+		tidbCompatibility:      config.tidbCompatibility,
+		watchMode:              watchModes[config.watchMode],
+		retryPolicy: common.RetryPolicy{
+			MaxRetries:     config.maxRetries,
+			InitialBackoff: config.retryInitialBackoff,
+			MaxBackoff:     config.retryMaxBackoff,
+		},
+		analyzeBeforeStats: config.analyzeBeforeStats,
 		CachedOptimizedRevisions: revisions.NewCachedOptimizedRevisions(
 			maxRevisionStaleness,
 		),
@@ -218,6 +260,13 @@ func newMySQLDatastore(uri string, options ...Option) (*Datastore, error) {
 		return nil, err
 	}
 
+	if !config.vitessCompatibility {
+		store.reverseQueryCTE, err = store.supportsCTEs(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Start a goroutine for garbage collection.
 	if store.gcInterval > 0*time.Minute && config.gcEnabled {
 		store.gcGroup, store.gcCtx = errgroup.WithContext(store.gcCtx)
@@ -237,8 +286,9 @@ func newMySQLDatastore(uri string, options ...Option) (*Datastore, error) {
 	return store, nil
 }
 
+// SnapshotReader ignores consistency hints, as all reads are served by the primary.
 // TODO (@vroldanbet) dupe from postgres datastore - need to refactor
-func (mds *Datastore) SnapshotReader(revisionRaw datastore.Revision) datastore.Reader {
+func (mds *Datastore) SnapshotReader(revisionRaw datastore.Revision, _ ...options.SnapshotReaderOptionsOption) datastore.Reader {
 	rev := revisionRaw.(revision.Decimal)
 
 	createTxFunc := func(ctx context.Context) (*sql.Tx, txCleanupFunc, error) {
@@ -259,7 +309,9 @@ func (mds *Datastore) SnapshotReader(revisionRaw datastore.Revision) datastore.R
 		mds.QueryBuilder,
 		createTxFunc,
 		querySplitter,
-		buildLivingObjectFilterForRevision(rev),
+		filterToTenant(mds.tenant, buildLivingObjectFilterForRevision(rev)),
+		mds.reverseQueryCTE,
+		schema.WithExpirationCutoff(mds.transactionTimestamp(transactionFromRevision(rev))),
 	}
 }
 
@@ -271,12 +323,15 @@ func noCleanup() error { return nil }
 func (mds *Datastore) ReadWriteTx(
 	ctx context.Context,
 	fn datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
-	var err error
-	for i := uint8(0); i <= mds.maxRetries; i++ {
-		var newTxnID uint64
-		if err = migrations.BeginTxFunc(ctx, mds.db, &sql.TxOptions{Isolation: sql.LevelSerializable}, func(tx *sql.Tx) error {
-			newTxnID, err = mds.createNewTransaction(ctx, tx)
+	config := options.NewRWTOptionsWithOptions(opts...)
+
+	var newTxnID uint64
+	_, err := common.RetryTx(ctx, mds.retryPolicy, isErrorRetryable, func(ctx context.Context) error {
+		return migrations.BeginTxFunc(ctx, mds.db, mds.readWriteTxOptions, func(tx *sql.Tx) error {
+			var err error
+			newTxnID, err = mds.createNewTransaction(ctx, tx, config.Metadata)
 			if err != nil {
 				return fmt.Errorf("unable to create new txn ID: %w", err)
 			}
@@ -295,10 +350,13 @@ func (mds *Datastore) ReadWriteTx(
 					mds.QueryBuilder,
 					longLivedTx,
 					querySplitter,
-					currentlyLivingObjects,
+					filterToTenant(mds.tenant, currentlyLivingObjects),
+					mds.reverseQueryCTE,
+					schema.WithExpirationCutoff(mds.transactionTimestamp(newTxnID)),
 				},
 				tx,
 				newTxnID,
+				mds.tenant,
 			}
 
 			if err := fn(rwt); err != nil {
@@ -306,27 +364,46 @@ func (mds *Datastore) ReadWriteTx(
 			}
 
 			return nil
-		}); err != nil {
-			if isErrorRetryable(err) {
-				continue
-			}
+		})
+	})
+	if err != nil {
+		return datastore.NoRevision, err
+	}
 
-			return datastore.NoRevision, err
-		}
+	return revisionFromTransaction(newTxnID), nil
+}
 
-		return revisionFromTransaction(newTxnID), nil
-	}
-	return datastore.NoRevision, fmt.Errorf("max retries exceeded: %w", err)
+// BulkLoad writes all relationships from the source in a single transaction, using multi-row
+// inserts of common.DefaultBulkLoadBatchSize relationships each.
+func (mds *Datastore) BulkLoad(
+	ctx context.Context,
+	source datastore.BulkWriteRelationshipSource,
+) (datastore.Revision, error) {
+	return mds.ReadWriteTx(ctx, common.SingleAttemptTxFunc(func(rwt datastore.ReadWriteTransaction) error {
+		_, err := common.BulkLoadInBatches(ctx, source, common.DefaultBulkLoadBatchSize, rwt.WriteRelationships)
+		return err
+	}))
 }
 
 func isErrorRetryable(err error) bool {
+	// A connection which was found to be broken before the transaction was sent is safe to retry
+	// on another connection.
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+
 	var mysqlerr *mysql.MySQLError
 	if !errors.As(err, &mysqlerr) {
 		log.Debug().Err(err).Msg("couldn't determine a sqlstate error code")
 		return false
 	}
 
-	return mysqlerr.Number == errMysqlDeadlock || mysqlerr.Number == errMysqlLockWaitTimeout
+	switch mysqlerr.Number {
+	case errMysqlDeadlock, errMysqlLockWaitTimeout, errTiDBWriteConflict, errTiDBTxnRetryable, errTiDBInfoSchemaChanged:
+		return true
+	default:
+		return false
+	}
 }
 
 type querier interface {
@@ -369,6 +446,8 @@ func newMySQLExecutor(tx querier) common.ExecuteQueryFunc {
 
 			var caveatName string
 			var caveatContext caveatContextWrapper
+			var expiration sql.NullTime
+			var metadata metadataWrapper
 			err := rows.Scan(
 				&nextTuple.ResourceAndRelation.Namespace,
 				&nextTuple.ResourceAndRelation.ObjectId,
@@ -378,6 +457,8 @@ func newMySQLExecutor(tx querier) common.ExecuteQueryFunc {
 				&nextTuple.Subject.Relation,
 				&caveatName,
 				&caveatContext,
+				&expiration,
+				&metadata,
 			)
 			if err != nil {
 				return nil, fmt.Errorf(errUnableToQueryTuples, err)
@@ -387,6 +468,8 @@ func newMySQLExecutor(tx querier) common.ExecuteQueryFunc {
 			if err != nil {
 				return nil, fmt.Errorf(errUnableToQueryTuples, err)
 			}
+			nextTuple.OptionalExpirationTime = common.ExpirationFrom(expiration)
+			nextTuple.OptionalMetadata = metadata
 
 			tuples = append(tuples, nextTuple)
 		}
@@ -403,16 +486,25 @@ type Datastore struct {
 	db                 *sql.DB
 	driver             *migrations.MySQLDriver
 	readTxOptions      *sql.TxOptions
+	readWriteTxOptions *sql.TxOptions
 	url                string
 	analyzeBeforeStats bool
 
+	vitessCompatibility bool
+	tidbCompatibility   bool
+	watchMode           watchMode
+
+	// reverseQueryCTE is whether reverse queries are made with common table expressions, which
+	// are supported from MySQL 8 and by TiDB, but not by every version of Vitess.
+	reverseQueryCTE bool
+
 	revisionQuantization time.Duration
 	gcWindow             time.Duration
 	gcInterval           time.Duration
 	gcTimeout            time.Duration
 	watchBufferLength    uint16
 	usersetBatchSize     uint16
-	maxRetries           uint8
+	retryPolicy          common.RetryPolicy
 
 	optimizedRevisionQuery string
 	validTransactionQuery  string
@@ -424,6 +516,10 @@ type Datastore struct {
 	createTxn     string
 	createBaseTxn string
 
+	// tenant is the tenant whose data is read and written, which is the default tenant unless
+	// the datastore was returned by ForTenant.
+	tenant string
+
 	*QueryBuilder
 	*revisions.CachedOptimizedRevisions
 	revision.DecimalDecoder
@@ -479,7 +575,14 @@ func (mds *Datastore) IsReady(ctx context.Context) (bool, error) {
 }
 
 func (mds *Datastore) Features(ctx context.Context) (*datastore.Features, error) {
-	return &datastore.Features{Watch: datastore.Feature{Enabled: true}}, nil
+	return &datastore.Features{
+		Watch:                  datastore.Feature{Enabled: true},
+		WatchCheckpoints:       datastore.Feature{Enabled: true},
+		Caveats:                datastore.Feature{Enabled: true},
+		BulkLoad:               datastore.Feature{Enabled: true},
+		ReverseQuerySort:       datastore.Feature{Enabled: true},
+		RelationshipExpiration: datastore.Feature{Enabled: true},
+	}, nil
 }
 
 // isSeeded determines if the backing database has been seeded
@@ -569,6 +672,27 @@ func (mds *Datastore) seedDatabase(ctx context.Context) error {
 	return nil
 }
 
+// supportsCTEs returns whether the server supports common table expressions, which MySQL does from
+// version 8. TiDB reports the version of MySQL with which it is compatible, which is 5.7, but
+// supports them as well.
+func (mds *Datastore) supportsCTEs(ctx context.Context) (bool, error) {
+	if mds.tidbCompatibility {
+		return true, nil
+	}
+
+	var version string
+	if err := mds.db.QueryRowContext(ctx, querySelectVersion).Scan(&version); err != nil {
+		return false, fmt.Errorf("NewMySQLDatastore: unable to read server version: %w", err)
+	}
+
+	major, _, _ := strings.Cut(version, ".")
+	majorVersion, err := strconv.Atoi(major)
+	if err != nil {
+		return false, fmt.Errorf("NewMySQLDatastore: unexpected server version %q", version)
+	}
+	return majorVersion >= 8, nil
+}
+
 // TODO (@vroldanbet) dupe from postgres datastore - need to refactor
 func buildLivingObjectFilterForRevision(revision revision.Decimal) queryFilterer {
 	return func(original sq.SelectBuilder) sq.SelectBuilder {
@@ -580,7 +704,19 @@ func buildLivingObjectFilterForRevision(revision revision.Decimal) queryFilterer
 	}
 }
 
+// transactionTimestamp selects the time at which the transaction was written.
+func (mds *Datastore) transactionTimestamp(txID uint64) sq.Sqlizer {
+	return sq.Expr("(SELECT "+colTimestamp+" FROM "+mds.driver.RelationTupleTransaction()+" WHERE "+colID+" = ?)", txID)
+}
+
 // TODO (@vroldanbet) dupe from postgres datastore - need to refactor
 func currentlyLivingObjects(original sq.SelectBuilder) sq.SelectBuilder {
 	return original.Where(sq.Eq{colDeletedTxn: liveDeletedTxnID})
 }
+
+// filterToTenant restricts the rows selected by the filterer to those of the tenant.
+func filterToTenant(tenant string, filterer queryFilterer) queryFilterer {
+	return func(original sq.SelectBuilder) sq.SelectBuilder {
+		return filterer(original).Where(sq.Eq{colTenant: tenant})
+	}
+}
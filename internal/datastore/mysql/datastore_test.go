@@ -107,6 +107,86 @@ func TestMySQLDatastoreWithTablePrefix(t *testing.T) {
 	test.All(t, test.DatastoreTesterFunc(dst.createDatastore))
 }
 
+func TestMySQLDatastoreWithBinlogWatch(t *testing.T) {
+	b := testdatastore.RunMySQLForTesting(t, "")
+	tester := test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+		ds := b.NewDatastore(t, func(engine, uri string) datastore.Datastore {
+			ds, err := newMySQLDatastore(uri,
+				RevisionQuantization(revisionQuantization),
+				GCWindow(gcWindow),
+				GCInterval(0*time.Second),
+				WatchBufferLength(watchBufferLength),
+				OverrideLockWaitTimeout(1),
+				WatchMode("binlog"),
+			)
+			require.NoError(t, err)
+			return ds
+		})
+		return ds, nil
+	})
+
+	t.Run("TestWatch", func(t *testing.T) { test.WatchTest(t, tester) })
+	t.Run("TestWatchCancel", func(t *testing.T) { test.WatchCancelTest(t, tester) })
+	t.Run("TestWatchWithMetadata", func(t *testing.T) { test.WatchWithMetadataTest(t, tester) })
+	t.Run("TestWatchCheckpoint", func(t *testing.T) { test.WatchCheckpointTest(t, tester) })
+	t.Run("TestCaveatedRelationshipWatch", func(t *testing.T) { test.CaveatedRelationshipWatchTest(t, tester) })
+}
+
+func TestMySQLDatastoreOnVitess(t *testing.T) {
+	b := testdatastore.RunVitessForTesting(t, "")
+	test.All(t, test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+		ds := b.NewDatastore(t, func(uri, tablePrefix string) datastore.Datastore {
+			ds, err := newMySQLDatastore(uri,
+				RevisionQuantization(revisionQuantization),
+				GCWindow(gcWindow),
+				GCInterval(0*time.Second),
+				WatchBufferLength(watchBufferLength),
+				TablePrefix(tablePrefix),
+				OverrideLockWaitTimeout(1),
+				VitessCompatibility(true),
+			)
+			require.NoError(t, err)
+			return ds
+		})
+		return ds, nil
+	}))
+
+	t.Run("GarbageCollection", func(t *testing.T) {
+		ds := b.NewDatastore(t, func(uri, tablePrefix string) datastore.Datastore {
+			ds, err := newMySQLDatastore(uri, append(defaultOptions, TablePrefix(tablePrefix), VitessCompatibility(true))...)
+			require.NoError(t, err)
+			return ds
+		})
+		defer failOnError(t, ds.Close)
+
+		GarbageCollectionTest(t, ds)
+	})
+}
+
+func TestMySQLDatastoreOnTiDB(t *testing.T) {
+	b := testdatastore.RunTiDBForTesting(t, "")
+	test.All(t, test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+		ds := b.NewDatastore(t, func(engine, uri string) datastore.Datastore {
+			ds, err := newMySQLDatastore(uri,
+				RevisionQuantization(revisionQuantization),
+				GCWindow(gcWindow),
+				GCInterval(0*time.Second),
+				WatchBufferLength(watchBufferLength),
+				OverrideLockWaitTimeout(1),
+				TiDBCompatibility(true),
+			)
+			require.NoError(t, err)
+			return ds
+		})
+		return ds, nil
+	}))
+
+	tidbOptions := append(append([]Option{}, defaultOptions...), TiDBCompatibility(true))
+	t.Run("DatabaseSeeding", createDatastoreTest(b, DatabaseSeedingTest, TiDBCompatibility(true)))
+	t.Run("GarbageCollection", createDatastoreTest(b, GarbageCollectionTest, tidbOptions...))
+	t.Run("TransactionTimestamps", createDatastoreTest(b, TransactionTimestampsTest, tidbOptions...))
+}
+
 func DatabaseSeedingTest(t *testing.T, ds datastore.Datastore) {
 	req := require.New(t)
 
@@ -170,7 +250,6 @@ func GarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	removed, err := mds.DeleteBeforeTx(ctx, writtenAt)
 	req.NoError(err)
 	req.Zero(removed.Relationships)
-	req.Zero(removed.Namespaces)
 
 	// Replace the namespace with a new one.
 	writtenAt, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
@@ -191,7 +270,6 @@ func GarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	req.NoError(err)
 	req.Zero(removed.Relationships)
 	req.Equal(int64(1), removed.Transactions)
-	req.Equal(int64(2), removed.Namespaces)
 
 	// Write a relationship.
 
@@ -204,14 +282,12 @@ func GarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	req.NoError(err)
 	req.Zero(removed.Relationships)
 	req.Equal(int64(1), removed.Transactions)
-	req.Zero(removed.Namespaces)
 
 	// Run GC again and ensure there are no changes.
 	removed, err = mds.DeleteBeforeTx(ctx, relWrittenAt)
 	req.NoError(err)
 	req.Zero(removed.Relationships)
 	req.Zero(removed.Transactions)
-	req.Zero(removed.Namespaces)
 
 	// Ensure the relationship is still present.
 	tRequire := testfixtures.TupleChecker{Require: req, DS: ds}
@@ -226,14 +302,12 @@ func GarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	req.NoError(err)
 	req.Equal(int64(1), removed.Relationships)
 	req.Equal(int64(1), removed.Transactions)
-	req.Zero(removed.Namespaces)
 
 	// Run GC again and ensure there are no changes.
 	removed, err = mds.DeleteBeforeTx(ctx, relOverwrittenAt)
 	req.NoError(err)
 	req.Zero(removed.Relationships)
 	req.Zero(removed.Transactions)
-	req.Zero(removed.Namespaces)
 
 	// Ensure the relationship is still present.
 	tRequire.TupleExists(ctx, tpl, relOverwrittenAt)
@@ -250,14 +324,12 @@ func GarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	req.NoError(err)
 	req.Equal(int64(1), removed.Relationships)
 	req.Equal(int64(1), removed.Transactions)
-	req.Zero(removed.Namespaces)
 
 	// Run GC again and ensure there are no changes.
 	removed, err = mds.DeleteBeforeTx(ctx, relDeletedAt)
 	req.NoError(err)
 	req.Zero(removed.Relationships)
 	req.Zero(removed.Transactions)
-	req.Zero(removed.Namespaces)
 
 	// Write the relationship a few times.
 	_, err = common.WriteTuples(ctx, ds, corev1.RelationTupleUpdate_TOUCH, tpl)
@@ -275,7 +347,6 @@ func GarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	req.NoError(err)
 	req.Equal(int64(2), removed.Relationships)
 	req.Equal(int64(3), removed.Transactions)
-	req.Zero(removed.Namespaces)
 
 	// Ensure the relationship is still present.
 	tRequire.TupleExists(ctx, tpl, relLastWriteAt)
@@ -324,7 +395,6 @@ func GarbageCollectionByTimeTest(t *testing.T, ds datastore.Datastore) {
 	req.NoError(err)
 	req.Zero(removed.Relationships)
 	req.NotZero(removed.Transactions)
-	req.Zero(removed.Namespaces)
 
 	// Ensure the relationship is still present.
 	tRequire := testfixtures.TupleChecker{Require: req, DS: ds}
@@ -348,7 +418,6 @@ func GarbageCollectionByTimeTest(t *testing.T, ds datastore.Datastore) {
 	req.NoError(err)
 	req.Equal(int64(1), removed.Relationships)
 	req.Equal(int64(1), removed.Transactions)
-	req.Zero(removed.Namespaces)
 
 	// Ensure the relationship is still not present.
 	tRequire.NoTupleExists(ctx, tpl, relDeletedAt)
@@ -406,7 +475,6 @@ func ChunkedGarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	req.NoError(err)
 	req.Zero(removed.Relationships)
 	req.NotZero(removed.Transactions)
-	req.Zero(removed.Namespaces)
 
 	// Sleep to ensure the relationships will GC.
 	time.Sleep(1 * time.Millisecond)
@@ -434,7 +502,6 @@ func ChunkedGarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	req.NoError(err)
 	req.Equal(int64(chunkRelationshipCount), removed.Relationships)
 	req.Equal(int64(1), removed.Transactions)
-	req.Zero(removed.Namespaces)
 }
 
 func QuantizedRevisionTest(t *testing.T, b testdatastore.RunningEngineForTest) {
@@ -556,7 +623,7 @@ func TransactionTimestampsTest(t *testing.T, ds datastore.Datastore) {
 	// Transaction timestamp should not be stored in system time zone
 	tx, err := db.BeginTx(ctx, nil)
 	req.NoError(err)
-	txID, err := ds.(*Datastore).createNewTransaction(ctx, tx)
+	txID, err := ds.(*Datastore).createNewTransaction(ctx, tx, nil)
 	req.NoError(err)
 	err = tx.Commit()
 	req.NoError(err)
@@ -14,7 +14,10 @@ import (
 	"github.com/authzed/spicedb/pkg/datastore/revision"
 )
 
-var _ common.GarbageCollector = (*Datastore)(nil)
+var (
+	_ common.GarbageCollector               = (*Datastore)(nil)
+	_ datastore.GarbageCollectableDatastore = (*Datastore)(nil)
+)
 
 // TODO (@vroldanbet) dupe from postgres datastore - need to refactor
 func (mds *Datastore) Now(ctx context.Context) (time.Time, error) {
@@ -57,14 +60,36 @@ func (mds *Datastore) TxIDBefore(ctx context.Context, before time.Time) (datasto
 	return revision.NewFromDecimal(decimal.NewFromInt(value.Int64)), nil
 }
 
+// TODO (@vroldanbet) dupe from postgres datastore - need to refactor
+func (mds *Datastore) CountTransactionsBeforeTx(ctx context.Context, txID datastore.Revision) (int64, error) {
+	query, args, err := sb.Select("COUNT(*)").From(mds.driver.RelationTupleTransaction()).Where(sq.Lt{colID: txID}).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = mds.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count, err
+}
+
+// CollectGarbage runs garbage collection immediately, regardless of whether the background
+// garbage collection is enabled.
+func (mds *Datastore) CollectGarbage(ctx context.Context) (datastore.GarbageCollected, error) {
+	return common.RunGarbageCollection(ctx, mds, mds.gcWindow, mds.gcTimeout)
+}
+
 // TODO (@vroldanbet) dupe from postgres datastore - need to refactor
 // - implementation misses metrics
 func (mds *Datastore) DeleteBeforeTx(
 	ctx context.Context,
 	txID datastore.Revision,
 ) (removed common.DeletionCounts, err error) {
-	// Delete any relationship rows with deleted_transaction <= the transaction ID.
-	removed.Relationships, err = mds.batchDelete(ctx, mds.driver.RelationTuple(), sq.LtOrEq{colDeletedTxn: txID})
+	// Delete any relationship rows with deleted_transaction <= the transaction ID, or which have
+	// expired.
+	removed.Relationships, err = mds.batchDelete(ctx, mds.driver.RelationTuple(), sq.Or{
+		sq.LtOrEq{colDeletedTxn: txID},
+		sq.Expr(colExpiration + " < UTC_TIMESTAMP(6)"),
+	})
 	if err != nil {
 		return
 	}
@@ -74,12 +99,9 @@ func (mds *Datastore) DeleteBeforeTx(
 	// We don't delete the transaction itself to ensure there is always at least
 	// one transaction present.
 	removed.Transactions, err = mds.batchDelete(ctx, mds.driver.RelationTupleTransaction(), sq.Lt{colID: txID})
-	if err != nil {
-		return
-	}
 
-	// Delete any namespace rows with deleted_transaction <= the transaction ID.
-	removed.Namespaces, err = mds.batchDelete(ctx, mds.driver.Namespace(), sq.LtOrEq{colDeletedTxn: txID})
+	// Namespace and caveat definitions are not deleted, as their prior versions are retained
+	// for the schema history.
 	return
 }
 
@@ -87,6 +109,10 @@ func (mds *Datastore) DeleteBeforeTx(
 // - query was reworked to make it compatible with Vitess
 // - API differences with PSQL driver
 func (mds *Datastore) batchDelete(ctx context.Context, tableName string, filter sqlFilter) (int64, error) {
+	if mds.vitessCompatibility {
+		return mds.batchDeleteByID(ctx, tableName, filter)
+	}
+
 	query, args, err := sb.Delete(tableName).Where(filter).Limit(batchDeleteSize).ToSql()
 	if err != nil {
 		return -1, err
@@ -111,3 +137,64 @@ func (mds *Datastore) batchDelete(ctx context.Context, tableName string, filter
 
 	return deletedCount, nil
 }
+
+// batchDeleteByID deletes the rows matching the filter in batches selected by their primary key,
+// as vtgate does not support DELETE ... LIMIT on sharded keyspaces. The filter is applied to the
+// delete as well, as auto-incremented IDs are only unique within a shard unless the table is
+// backed by a Vitess sequence.
+func (mds *Datastore) batchDeleteByID(ctx context.Context, tableName string, filter sqlFilter) (int64, error) {
+	selectQuery, selectArgs, err := sb.Select(colID).From(tableName).Where(filter).Limit(batchDeleteSize).ToSql()
+	if err != nil {
+		return -1, err
+	}
+
+	var deletedCount int64
+	for {
+		ids, err := mds.loadIDs(ctx, selectQuery, selectArgs)
+		if err != nil {
+			return deletedCount, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		query, args, err := sb.Delete(tableName).Where(sq.And{sq.Eq{colID: ids}, filter}).ToSql()
+		if err != nil {
+			return deletedCount, err
+		}
+
+		cr, err := mds.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return deletedCount, err
+		}
+
+		rowsDeleted, err := cr.RowsAffected()
+		if err != nil {
+			return deletedCount, err
+		}
+		deletedCount += rowsDeleted
+		if len(ids) < batchDeleteSize {
+			break
+		}
+	}
+
+	return deletedCount, nil
+}
+
+func (mds *Datastore) loadIDs(ctx context.Context, query string, args []any) ([]uint64, error) {
+	rows, err := mds.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer common.LogOnError(ctx, rows.Close)
+
+	var ids []uint64
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
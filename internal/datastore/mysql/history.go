@@ -0,0 +1,95 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/shopspring/decimal"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+var _ datastore.SchemaHistoryDatastore = (*Datastore)(nil)
+
+const errUnableToReadHistory = "unable to read schema history: %w"
+
+// NamespaceHistory returns every version of the namespace definition which has been written.
+func (mds *Datastore) NamespaceHistory(ctx context.Context, nsName string) ([]datastore.DefinitionVersion[*core.NamespaceDefinition], error) {
+	return readHistory(ctx, mds, mds.ReadNamespaceHistoryQuery.Where(sq.Eq{colTenant: mds.tenant, colNamespace: nsName}), func() *core.NamespaceDefinition {
+		return &core.NamespaceDefinition{}
+	})
+}
+
+// CaveatHistory returns every version of the caveat definition which has been written.
+func (mds *Datastore) CaveatHistory(ctx context.Context, name string) ([]datastore.DefinitionVersion[*core.CaveatDefinition], error) {
+	return readHistory(ctx, mds, mds.ReadCaveatHistoryQuery.Where(sq.Eq{colTenant: mds.tenant, colName: name}), func() *core.CaveatDefinition {
+		return &core.CaveatDefinition{}
+	})
+}
+
+// TODO (@vroldanbet) dupe from postgres datastore - need to refactor
+func readHistory[T interface{ UnmarshalVT([]byte) error }](
+	ctx context.Context,
+	mds *Datastore,
+	historyQuery sq.SelectBuilder,
+	newDefinition func() T,
+) ([]datastore.DefinitionVersion[T], error) {
+	query, args, err := historyQuery.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadHistory, err)
+	}
+
+	rows, err := mds.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadHistory, err)
+	}
+	defer common.LogOnError(ctx, rows.Close)
+
+	var versions []datastore.DefinitionVersion[T]
+	var lastDeletedTxn uint64
+	for rows.Next() {
+		var serialized []byte
+		var createdTxn decimal.Decimal
+		var deletedTxn uint64
+		var createdAt sql.NullTime
+		if err := rows.Scan(&serialized, &createdTxn, &deletedTxn, &createdAt); err != nil {
+			return nil, fmt.Errorf(errUnableToReadHistory, err)
+		}
+
+		definition := newDefinition()
+		if err := definition.UnmarshalVT(serialized); err != nil {
+			return nil, fmt.Errorf(errUnableToReadHistory, err)
+		}
+
+		version := datastore.DefinitionVersion[T]{
+			Definition: definition,
+			Revision:   revision.NewFromDecimal(createdTxn),
+		}
+		if createdAt.Valid {
+			version.WrittenAt = createdAt.Time.UTC()
+		}
+
+		// A version which was replaced is deleted in the same transaction which wrote its
+		// replacement.
+		if len(versions) > 0 && lastDeletedTxn != uint64(createdTxn.IntPart()) {
+			versions[len(versions)-1].Deleted = true
+		}
+
+		versions = append(versions, version)
+		lastDeletedTxn = deletedTxn
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf(errUnableToReadHistory, rows.Err())
+	}
+
+	if len(versions) > 0 && lastDeletedTxn != liveDeletedTxnID {
+		versions[len(versions)-1].Deleted = true
+	}
+
+	return versions, nil
+}
@@ -29,6 +29,8 @@ var sb = sq.StatementBuilder.PlaceholderFormat(sq.Question)
 type MySQLDriver struct {
 	db *sql.DB
 	*tables
+
+	txOptions *sql.TxOptions
 }
 
 // NewMySQLDriverFromDSN creates a new migration driver with a connection pool to the database DSN specified.
@@ -52,9 +54,33 @@ func NewMySQLDriverFromDSN(url string, tablePrefix string) (*MySQLDriver, error)
 	return NewMySQLDriverFromDB(db, tablePrefix), nil
 }
 
+// NewVitessDriverFromDSN creates a new migration driver for a Vitess keyspace reached through
+// vtgate at the DSN specified. Migrations run in transactions started without setting an
+// isolation level, which older versions of vtgate do not support.
+func NewVitessDriverFromDSN(url string, tablePrefix string) (*MySQLDriver, error) {
+	driver, err := NewMySQLDriverFromDSN(url, tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+	driver.txOptions = nil
+	return driver, nil
+}
+
+// NewTiDBDriverFromDSN creates a new migration driver for a TiDB database at the DSN specified.
+// Migrations run in transactions at the REPEATABLE READ isolation level, as TiDB rejects
+// SERIALIZABLE.
+func NewTiDBDriverFromDSN(url string, tablePrefix string) (*MySQLDriver, error) {
+	driver, err := NewMySQLDriverFromDSN(url, tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+	driver.txOptions = &sql.TxOptions{Isolation: sql.LevelRepeatableRead}
+	return driver, nil
+}
+
 // NewMySQLDriverFromDB creates a new migration driver with a connection pool specified upfront.
 func NewMySQLDriverFromDB(db *sql.DB, tablePrefix string) *MySQLDriver {
-	return &MySQLDriver{db, newTables(tablePrefix)}
+	return &MySQLDriver{db, newTables(tablePrefix), &sql.TxOptions{Isolation: sql.LevelSerializable}}
 }
 
 // revisionToColumnName generates the column name that will denote a given migration revision
@@ -110,7 +136,7 @@ func (driver *MySQLDriver) RunTx(ctx context.Context, f migrate.TxMigrationFunc[
 	return BeginTxFunc(
 		ctx,
 		driver.db,
-		&sql.TxOptions{Isolation: sql.LevelSerializable},
+		driver.txOptions,
 		func(tx *sql.Tx) error {
 			return f(ctx, TxWrapper{tx, driver.tables})
 		},
@@ -0,0 +1,18 @@
+package migrations
+
+import "fmt"
+
+func addExpirationToRelationTuplesTable(t *tables) string {
+	return fmt.Sprintf(`ALTER TABLE %s
+			ADD COLUMN expiration DATETIME(6);`,
+		t.RelationTuple(),
+	)
+}
+
+func init() {
+	mustRegisterMigration("add_relationship_expiration", "add_caveat", noNonatomicMigration,
+		newStatementBatch(
+			addExpirationToRelationTuplesTable,
+		).execute,
+	)
+}
@@ -0,0 +1,18 @@
+package migrations
+
+import "fmt"
+
+func addMetadataToRelationTuplesTable(t *tables) string {
+	return fmt.Sprintf(`ALTER TABLE %s
+			ADD COLUMN metadata JSON;`,
+		t.RelationTuple(),
+	)
+}
+
+func init() {
+	mustRegisterMigration("add_relationship_metadata", "add_relationship_expiration", noNonatomicMigration,
+		newStatementBatch(
+			addMetadataToRelationTuplesTable,
+		).execute,
+	)
+}
@@ -0,0 +1,18 @@
+package migrations
+
+import "fmt"
+
+func addMetadataToRelationTupleTransactionTable(t *tables) string {
+	return fmt.Sprintf(`ALTER TABLE %s
+			ADD COLUMN metadata JSON;`,
+		t.RelationTupleTransaction(),
+	)
+}
+
+func init() {
+	mustRegisterMigration("add_transaction_metadata", "add_relationship_metadata", noNonatomicMigration,
+		newStatementBatch(
+			addMetadataToRelationTupleTransactionTable,
+		).execute,
+	)
+}
@@ -0,0 +1,18 @@
+package migrations
+
+import "fmt"
+
+func addSubjectSortIndexToRelationTupleTable(t *tables) string {
+	return fmt.Sprintf(`ALTER TABLE %s
+			ADD INDEX ix_relation_tuple_by_subject_and_resource (userset_namespace, userset_object_id, userset_relation, namespace, object_id, relation);`,
+		t.RelationTuple(),
+	)
+}
+
+func init() {
+	mustRegisterMigration("add_subject_sort_index", "add_transaction_metadata", noNonatomicMigration,
+		newStatementBatch(
+			addSubjectSortIndexToRelationTupleTable,
+		).execute,
+	)
+}
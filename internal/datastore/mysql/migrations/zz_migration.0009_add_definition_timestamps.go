@@ -0,0 +1,64 @@
+package migrations
+
+import "fmt"
+
+func addCreatedAtToNamespaceTable(t *tables) string {
+	return fmt.Sprintf(`ALTER TABLE %s
+			ADD COLUMN created_at DATETIME(6) NULL;`,
+		t.Namespace(),
+	)
+}
+
+func addCreatedAtToCaveatTable(t *tables) string {
+	return fmt.Sprintf(`ALTER TABLE %s
+			ADD COLUMN created_at DATETIME(6) NULL;`,
+		t.Caveat(),
+	)
+}
+
+// Definitions written before this migration take the timestamp of their transaction, if it has
+// not yet been garbage collected.
+func backfillNamespaceCreatedAt(t *tables) string {
+	return fmt.Sprintf(`UPDATE %s ns
+			JOIN %s tx ON tx.id = ns.created_transaction
+			SET ns.created_at = tx.timestamp;`,
+		t.Namespace(),
+		t.RelationTupleTransaction(),
+	)
+}
+
+func backfillCaveatCreatedAt(t *tables) string {
+	return fmt.Sprintf(`UPDATE %s cv
+			JOIN %s tx ON tx.id = cv.created_transaction
+			SET cv.created_at = tx.timestamp;`,
+		t.Caveat(),
+		t.RelationTupleTransaction(),
+	)
+}
+
+func addCreatedAtDefaultToNamespaceTable(t *tables) string {
+	return fmt.Sprintf(`ALTER TABLE %s
+			MODIFY COLUMN created_at DATETIME(6) NULL DEFAULT NOW(6);`,
+		t.Namespace(),
+	)
+}
+
+func addCreatedAtDefaultToCaveatTable(t *tables) string {
+	return fmt.Sprintf(`ALTER TABLE %s
+			MODIFY COLUMN created_at DATETIME(6) NULL DEFAULT NOW(6);`,
+		t.Caveat(),
+	)
+}
+
+func init() {
+	mustRegisterMigration("add_definition_timestamps", "add_subject_sort_index", noNonatomicMigration,
+		newStatementBatch(
+			addCreatedAtToNamespaceTable,
+			addCreatedAtToCaveatTable,
+			backfillNamespaceCreatedAt,
+			backfillCaveatCreatedAt,
+			addCreatedAtDefaultToNamespaceTable,
+			addCreatedAtDefaultToCaveatTable,
+		).execute,
+	)
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import "fmt"
+
+// The tenant is limited to 64 characters so that the unique index of living relationships, and
+// the primary key of caveats, stay within the 3KB key size limit of InnoDB. Existing rows belong to
+// the default tenant, which is the empty string.
+func addTenantToTransactionTable(t *tables) string {
+	return fmt.Sprintf(`ALTER TABLE %s
+			ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT '';`,
+		t.RelationTupleTransaction(),
+	)
+}
+
+func addTenantToNamespaceTable(t *tables) string {
+	return fmt.Sprintf(`ALTER TABLE %s
+			ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT '',
+			DROP INDEX uq_namespace_living,
+			ADD CONSTRAINT uq_namespace_living_tenant UNIQUE (tenant_id, namespace, deleted_transaction);`,
+		t.Namespace(),
+	)
+}
+
+func addTenantToCaveatTable(t *tables) string {
+	return fmt.Sprintf(`ALTER TABLE %s
+			ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT '',
+			DROP PRIMARY KEY,
+			ADD CONSTRAINT pk_caveat PRIMARY KEY (tenant_id, name, deleted_transaction);`,
+		t.Caveat(),
+	)
+}
+
+func addTenantToRelationTupleTable(t *tables) string {
+	return fmt.Sprintf(`ALTER TABLE %s
+			ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT '',
+			DROP INDEX uq_relation_tuple_living,
+			ADD CONSTRAINT uq_relation_tuple_living_tenant UNIQUE (tenant_id, namespace, object_id, relation, userset_namespace, userset_object_id, userset_relation, deleted_transaction);`,
+		t.RelationTuple(),
+	)
+}
+
+func init() {
+	mustRegisterMigration("add_tenants", "add_definition_timestamps", noNonatomicMigration,
+		newStatementBatch(
+			addTenantToTransactionTable,
+			addTenantToNamespaceTable,
+			addTenantToCaveatTable,
+			addTenantToRelationTupleTable,
+		).execute,
+	)
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import "fmt"
+
+// The reverse query index covers the filters of reverse queries, which select relationships by
+// tenant and subject, and the revision and expiration of each relationship, so that the IDs of
+// the matching relationships can be read without reading the table. It stays within the 3KB key
+// size limit of InnoDB.
+func addReverseQueryIndexToRelationTupleTable(t *tables) string {
+	return fmt.Sprintf(`ALTER TABLE %s
+			ADD INDEX ix_relation_tuple_reverse (tenant_id, userset_namespace, userset_object_id, userset_relation, namespace, object_id, relation, created_transaction, deleted_transaction, expiration);`,
+		t.RelationTuple(),
+	)
+}
+
+func init() {
+	mustRegisterMigration("add_reverse_query_index", "add_tenants", noNonatomicMigration,
+		newStatementBatch(
+			addReverseQueryIndexToRelationTupleTable,
+		).execute,
+	)
+}
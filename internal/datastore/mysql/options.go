@@ -3,6 +3,8 @@ package mysql
 import (
 	"fmt"
 	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
 )
 
 const (
@@ -23,6 +25,19 @@ const (
 	defaultGCEnabled                         = true
 )
 
+type watchMode uint8
+
+const (
+	watchPolling watchMode = iota
+	watchBinlog
+)
+
+var watchModes = map[string]watchMode{
+	"":        watchPolling,
+	"polling": watchPolling,
+	"binlog":  watchBinlog,
+}
+
 type mysqlOptions struct {
 	revisionQuantization        time.Duration
 	gcWindow                    time.Duration
@@ -38,8 +53,13 @@ type mysqlOptions struct {
 	splitAtUsersetCount         uint16
 	analyzeBeforeStats          bool
 	maxRetries                  uint8
+	retryInitialBackoff         time.Duration
+	retryMaxBackoff             time.Duration
 	lockWaitTimeoutSeconds      *uint8
 	gcEnabled                   bool
+	vitessCompatibility         bool
+	tidbCompatibility           bool
+	watchMode                   string
 }
 
 // Option provides the facility to configure how clients within the
@@ -60,6 +80,8 @@ func generateConfig(options []Option) (mysqlOptions, error) {
 		maxRevisionStalenessPercent: defaultMaxRevisionStalenessPercent,
 		enablePrometheusStats:       defaultEnablePrometheusStats,
 		maxRetries:                  defaultMaxRetries,
+		retryInitialBackoff:         common.DefaultRetryInitialBackoff,
+		retryMaxBackoff:             common.DefaultRetryMaxBackoff,
 		gcEnabled:                   defaultGCEnabled,
 	}
 
@@ -76,6 +98,20 @@ func generateConfig(options []Option) (mysqlOptions, error) {
 		)
 	}
 
+	mode, ok := watchModes[computed.watchMode]
+	if !ok {
+		return computed, fmt.Errorf("unknown watch mode: %s", computed.watchMode)
+	}
+	if mode == watchBinlog && computed.vitessCompatibility {
+		return computed, fmt.Errorf("watch mode binlog cannot be used with vitess compatibility")
+	}
+	if mode == watchBinlog && computed.tidbCompatibility {
+		return computed, fmt.Errorf("watch mode binlog cannot be used with tidb compatibility")
+	}
+	if computed.vitessCompatibility && computed.tidbCompatibility {
+		return computed, fmt.Errorf("vitess and tidb compatibility cannot be used together")
+	}
+
 	return computed, nil
 }
 
@@ -139,6 +175,27 @@ func MaxRetries(maxRetries uint8) Option {
 	}
 }
 
+// RetryInitialBackoff is the wait before the first client-side retry of a
+// transaction, which doubles with each further retry up to RetryMaxBackoff.
+// Zero retries immediately.
+//
+// Default: 10ms
+func RetryInitialBackoff(backoff time.Duration) Option {
+	return func(mo *mysqlOptions) {
+		mo.retryInitialBackoff = backoff
+	}
+}
+
+// RetryMaxBackoff is the longest wait between client-side retries of a
+// transaction.
+//
+// Default: 1s
+func RetryMaxBackoff(backoff time.Duration) Option {
+	return func(mo *mysqlOptions) {
+		mo.retryMaxBackoff = backoff
+	}
+}
+
 // TablePrefix allows defining a MySQL table name prefix.
 //
 // No prefix is set by default
@@ -222,6 +279,59 @@ func OverrideLockWaitTimeout(seconds uint8) Option {
 	}
 }
 
+// VitessCompatibility adapts the datastore to a Vitess keyspace, reached
+// through vtgate:
+//
+//   - The SERIALIZABLE isolation level is set once for each connection, rather
+//     than before each transaction, and transactions are not started READ ONLY,
+//     as neither is supported by older versions of vtgate.
+//   - Garbage collection deletes batches of rows by primary key, as vtgate
+//     rejects DELETE ... LIMIT across shards.
+//
+// Vitess compatibility is disabled by default.
+func VitessCompatibility(enabled bool) Option {
+	return func(mo *mysqlOptions) {
+		mo.vitessCompatibility = enabled
+	}
+}
+
+// TiDBCompatibility adapts the datastore to TiDB:
+//
+//   - Transactions run at the REPEATABLE READ isolation level, which is the
+//     snapshot isolation of TiDB, as TiDB rejects SERIALIZABLE.
+//   - Transaction IDs are the start timestamps allocated by the timestamp
+//     oracle of the cluster, rather than the auto-increment IDs which each
+//     TiDB server allocates from its own range. Commit timestamps cannot be
+//     used, as those of async commit transactions are only decided as they
+//     commit.
+//   - Transactions which fail on a write conflict, as optimistic transactions
+//     do as they commit, are retried by the datastore, rather than by TiDB
+//     replaying their statements.
+//   - Watches do not pass the start timestamp of the oldest transaction
+//     running in the database, as it may yet commit.
+//
+// TiDB compatibility is disabled by default.
+func TiDBCompatibility(enabled bool) Option {
+	return func(mo *mysqlOptions) {
+		mo.tidbCompatibility = enabled
+	}
+}
+
+// WatchMode is how watches find the transactions committed after their revision:
+//   - "polling" queries the transactions table for new transactions at an
+//     interval
+//   - "binlog" streams the binary log of the server as a replica would, which
+//     requires binlog_format=ROW and a user granted REPLICATION CLIENT and
+//     REPLICATION SLAVE; a watch which cannot stream the binary log falls back
+//     to polling
+//
+// Watches poll by default.
+func WatchMode(mode string) Option {
+	return func(mo *mysqlOptions) {
+		mo.watchMode = mode
+	}
+}
+
 // GCEnabled indicates whether garbage collection is enabled.
 //
 // GC is enabled by default.
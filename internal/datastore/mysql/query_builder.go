@@ -9,26 +9,33 @@ import (
 // QueryBuilder captures all parameterizable queries used
 // by the MySQL datastore implementation
 type QueryBuilder struct {
-	GetLastRevision  sq.SelectBuilder
-	GetRevisionRange sq.SelectBuilder
+	GetLastRevision          sq.SelectBuilder
+	GetRevisionRange         sq.SelectBuilder
+	CreateTxnWithMetadata    sq.InsertBuilder
+	CreateTxnWithID          sq.InsertBuilder
+	QueryTransactionMetadata sq.SelectBuilder
 
 	WriteNamespaceQuery        sq.InsertBuilder
 	ReadNamespaceQuery         sq.SelectBuilder
+	ReadNamespaceHistoryQuery  sq.SelectBuilder
 	DeleteNamespaceQuery       sq.UpdateBuilder
 	DeleteNamespaceTuplesQuery sq.UpdateBuilder
 
-	QueryTupleIdsQuery    sq.SelectBuilder
-	QueryTuplesQuery      sq.SelectBuilder
-	DeleteTupleQuery      sq.UpdateBuilder
-	QueryTupleExistsQuery sq.SelectBuilder
-	WriteTupleQuery       sq.InsertBuilder
-	QueryChangedQuery     sq.SelectBuilder
-	CountTupleQuery       sq.SelectBuilder
+	QueryTupleIdsQuery      sq.SelectBuilder
+	QueryTuplesQuery        sq.SelectBuilder
+	ReverseQueryTuplesQuery sq.SelectBuilder
+	DeleteTupleQuery        sq.UpdateBuilder
+	QueryTupleExistsQuery   sq.SelectBuilder
+	WriteTupleQuery         sq.InsertBuilder
+	QueryChangedQuery       sq.SelectBuilder
+	CountTupleQuery         sq.SelectBuilder
+	ListResourceTypesQuery  sq.SelectBuilder
 
-	WriteCaveatQuery  sq.InsertBuilder
-	ReadCaveatQuery   sq.SelectBuilder
-	ListCaveatsQuery  sq.SelectBuilder
-	DeleteCaveatQuery sq.UpdateBuilder
+	WriteCaveatQuery       sq.InsertBuilder
+	ReadCaveatQuery        sq.SelectBuilder
+	ReadCaveatHistoryQuery sq.SelectBuilder
+	ListCaveatsQuery       sq.SelectBuilder
+	DeleteCaveatQuery      sq.UpdateBuilder
 }
 
 // NewQueryBuilder returns a new QueryBuilder instance. The migration
@@ -39,24 +46,31 @@ func NewQueryBuilder(driver *migrations.MySQLDriver) *QueryBuilder {
 	// transaction builders
 	builder.GetLastRevision = getLastRevision(driver.RelationTupleTransaction())
 	builder.GetRevisionRange = getRevisionRange(driver.RelationTupleTransaction())
+	builder.CreateTxnWithMetadata = createTxnWithMetadata(driver.RelationTupleTransaction())
+	builder.CreateTxnWithID = createTxnWithID(driver.RelationTupleTransaction())
+	builder.QueryTransactionMetadata = queryTransactionMetadata(driver.RelationTupleTransaction())
 
 	// namespace builders
 	builder.WriteNamespaceQuery = writeNamespace(driver.Namespace())
 	builder.ReadNamespaceQuery = readNamespace(driver.Namespace())
+	builder.ReadNamespaceHistoryQuery = readNamespaceHistory(driver.Namespace())
 	builder.DeleteNamespaceQuery = deleteNamespace(driver.Namespace())
 
 	// tuple builders
 	builder.QueryTupleIdsQuery = queryTupleIds(driver.RelationTuple())
 	builder.DeleteNamespaceTuplesQuery = deleteNamespaceTuples(driver.RelationTuple())
 	builder.QueryTuplesQuery = queryTuples(driver.RelationTuple())
+	builder.ReverseQueryTuplesQuery = reverseQueryTuples(driver.RelationTuple())
 	builder.DeleteTupleQuery = deleteTuple(driver.RelationTuple())
 	builder.QueryTupleExistsQuery = queryTupleExists(driver.RelationTuple())
 	builder.WriteTupleQuery = writeTuple(driver.RelationTuple())
 	builder.QueryChangedQuery = queryChanged(driver.RelationTuple())
 	builder.CountTupleQuery = countTuples(driver.RelationTuple())
+	builder.ListResourceTypesQuery = listResourceTypes(driver.RelationTuple())
 
 	// caveat builders
 	builder.ReadCaveatQuery = readCaveat(driver.Caveat())
+	builder.ReadCaveatHistoryQuery = readCaveatHistory(driver.Caveat())
 	builder.ListCaveatsQuery = listCaveats(driver.Caveat())
 	builder.WriteCaveatQuery = writeCaveat(driver.Caveat())
 	builder.DeleteCaveatQuery = deleteCaveat(driver.Caveat())
@@ -65,7 +79,7 @@ func NewQueryBuilder(driver *migrations.MySQLDriver) *QueryBuilder {
 }
 
 func listCaveats(tableCaveat string) sq.SelectBuilder {
-	return sb.Select(colCaveatDefinition).From(tableCaveat).OrderBy(colName)
+	return sb.Select(colCaveatDefinition).From(tableCaveat)
 }
 
 func deleteCaveat(tableCaveat string) sq.UpdateBuilder {
@@ -74,6 +88,7 @@ func deleteCaveat(tableCaveat string) sq.UpdateBuilder {
 
 func writeCaveat(tableCaveat string) sq.InsertBuilder {
 	return sb.Insert(tableCaveat).Columns(
+		colTenant,
 		colName,
 		colCaveatDefinition,
 		colCreatedTxn,
@@ -84,6 +99,10 @@ func readCaveat(tableCaveat string) sq.SelectBuilder {
 	return sb.Select(colCaveatDefinition, colCreatedTxn).From(tableCaveat)
 }
 
+func readCaveatHistory(tableCaveat string) sq.SelectBuilder {
+	return sb.Select(colCaveatDefinition, colCreatedTxn, colDeletedTxn, colCreatedAt).From(tableCaveat).OrderBy(colCreatedTxn)
+}
+
 func getLastRevision(tableTransaction string) sq.SelectBuilder {
 	return sb.Select("MAX(id)").From(tableTransaction).Limit(1)
 }
@@ -92,8 +111,21 @@ func getRevisionRange(tableTransaction string) sq.SelectBuilder {
 	return sb.Select("MIN(id)", "MAX(id)").From(tableTransaction)
 }
 
+func createTxnWithMetadata(tableTransaction string) sq.InsertBuilder {
+	return sb.Insert(tableTransaction).Columns(colTenant, colMetadata)
+}
+
+func createTxnWithID(tableTransaction string) sq.InsertBuilder {
+	return sb.Insert(tableTransaction).Columns(colID, colTenant, colMetadata)
+}
+
+func queryTransactionMetadata(tableTransaction string) sq.SelectBuilder {
+	return sb.Select(colID, colMetadata).From(tableTransaction).Where(sq.NotEq{colMetadata: nil})
+}
+
 func writeNamespace(tableNamespace string) sq.InsertBuilder {
 	return sb.Insert(tableNamespace).Columns(
+		colTenant,
 		colNamespace,
 		colConfig,
 		colCreatedTxn,
@@ -104,6 +136,10 @@ func readNamespace(tableNamespace string) sq.SelectBuilder {
 	return sb.Select(colConfig, colCreatedTxn).From(tableNamespace)
 }
 
+func readNamespaceHistory(tableNamespace string) sq.SelectBuilder {
+	return sb.Select(colConfig, colCreatedTxn, colDeletedTxn, colCreatedAt).From(tableNamespace).OrderBy(colCreatedTxn)
+}
+
 func deleteNamespace(tableNamespace string) sq.UpdateBuilder {
 	return sb.Update(tableNamespace).Where(sq.Eq{colDeletedTxn: liveDeletedTxnID})
 }
@@ -128,15 +164,27 @@ func queryTuples(tableTuple string) sq.SelectBuilder {
 		colUsersetRelation,
 		colCaveatName,
 		colCaveatContext,
+		colExpiration,
+		colMetadata,
 	).From(tableTuple)
 }
 
+// reverseQueryTuples selects the relationships whose IDs are selected by the matchingTuples common
+// table expression, which reads only the reverse query index.
+func reverseQueryTuples(tableTuple string) sq.SelectBuilder {
+	return queryTuples(tableTuple).Join(matchingTuples + " USING (" + colID + ")")
+}
+
 func countTuples(tableTuple string) sq.SelectBuilder {
 	return sb.Select(
 		"count(*)",
 	).From(tableTuple)
 }
 
+func listResourceTypes(tableTuple string) sq.SelectBuilder {
+	return sb.Select(colNamespace).Distinct().From(tableTuple).OrderBy(colNamespace)
+}
+
 func deleteTuple(tableTuple string) sq.UpdateBuilder {
 	return sb.Update(tableTuple).Where(sq.Eq{colDeletedTxn: liveDeletedTxnID})
 }
@@ -147,6 +195,7 @@ func queryTupleExists(tableTuple string) sq.SelectBuilder {
 
 func writeTuple(tableTuple string) sq.InsertBuilder {
 	return sb.Insert(tableTuple).Columns(
+		colTenant,
 		colNamespace,
 		colObjectID,
 		colRelation,
@@ -155,6 +204,8 @@ func writeTuple(tableTuple string) sq.InsertBuilder {
 		colUsersetRelation,
 		colCaveatName,
 		colCaveatContext,
+		colExpiration,
+		colMetadata,
 		colCreatedTxn,
 	)
 }
@@ -169,6 +220,8 @@ func queryChanged(tableTuple string) sq.SelectBuilder {
 		colUsersetRelation,
 		colCaveatName,
 		colCaveatContext,
+		colExpiration,
+		colMetadata,
 		colCreatedTxn,
 		colDeletedTxn,
 	).From(tableTuple)
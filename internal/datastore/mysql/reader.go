@@ -23,17 +23,25 @@ type txFactory func(context.Context) (*sql.Tx, txCleanupFunc, error)
 type mysqlReader struct {
 	*QueryBuilder
 
-	txSource      txFactory
-	querySplitter common.TupleQuerySplitter
-	filterer      queryFilterer
+	txSource        txFactory
+	querySplitter   common.TupleQuerySplitter
+	filterer        queryFilterer
+	reverseQueryCTE bool
+	schema          common.SchemaInformation
 }
 
 type queryFilterer func(original sq.SelectBuilder) sq.SelectBuilder
 
 const (
-	errUnableToReadConfig     = "unable to read namespace config: %w"
-	errUnableToListNamespaces = "unable to list namespaces: %w"
-	errUnableToQueryTuples    = "unable to query tuples: %w"
+	errUnableToReadConfig         = "unable to read namespace config: %w"
+	errUnableToListNamespaces     = "unable to list namespaces: %w"
+	errUnableToQueryTuples        = "unable to query tuples: %w"
+	errUnableToCountRelationships = "unable to count relationships: %w"
+	errUnableToListResourceTypes  = "unable to list resource types: %w"
+
+	// matchingTuples is the common table expression of the IDs of the relationships matched by a
+	// reverse query.
+	matchingTuples = "matching_tuples"
 )
 
 // TODO (@vroldanbet) dupe from postgres datastore - need to refactor
@@ -45,6 +53,24 @@ var schema = common.SchemaInformation{
 	ColUsersetObjectID:  colUsersetObjectID,
 	ColUsersetRelation:  colUsersetRelation,
 	ColCaveatName:       colCaveatName,
+	ColExpiration:       colExpiration,
+	MetadataValue:       metadataValue,
+}
+
+// subjectSortColumns are the columns by which relationships are ordered by subject.
+var subjectSortColumns = []string{
+	colUsersetNamespace,
+	colUsersetObjectID,
+	colUsersetRelation,
+	colNamespace,
+	colObjectID,
+	colRelation,
+}
+
+// metadataValue selects the value for a key from the JSON metadata column. Metadata keys are
+// restricted to characters which do not need escaping in a JSON path.
+func metadataValue(key string) (string, []any) {
+	return "JSON_UNQUOTE(JSON_EXTRACT(" + colMetadata + ", ?))", []any{fmt.Sprintf(`$."%s"`, key)}
 }
 
 func (mr *mysqlReader) QueryRelationships(
@@ -53,7 +79,7 @@ func (mr *mysqlReader) QueryRelationships(
 	opts ...options.QueryOptionsOption,
 ) (iter datastore.RelationshipIterator, err error) {
 	// TODO (@vroldanbet) dupe from postgres datastore - need to refactor
-	qBuilder := common.NewSchemaQueryFilterer(schema, mr.filterer(mr.QueryTuplesQuery)).FilterWithRelationshipsFilter(filter)
+	qBuilder := common.NewSchemaQueryFilterer(mr.schema, mr.filterer(mr.QueryTuplesQuery)).FilterWithRelationshipsFilter(filter)
 	return mr.querySplitter.SplitAndExecuteQuery(ctx, qBuilder, opts...)
 }
 
@@ -62,25 +88,115 @@ func (mr *mysqlReader) ReverseQueryRelationships(
 	subjectsFilter datastore.SubjectsFilter,
 	opts ...options.ReverseQueryOptionsOption,
 ) (iter datastore.RelationshipIterator, err error) {
+	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
+
+	// With common table expressions, the relationships are matched by reading only the IDs from
+	// the covering reverse query index, and just those matched are then read from the table.
+	baseQuery := mr.QueryTuplesQuery
+	querySplitter := mr.querySplitter
+	if mr.reverseQueryCTE {
+		baseQuery = mr.QueryTupleIdsQuery
+		querySplitter.Executor = mr.reverseQueryExecutor(queryOpts.ReverseSort == options.BySubject)
+	}
+
 	// TODO (@vroldanbet) dupe from postgres datastore - need to refactor
-	qBuilder := common.NewSchemaQueryFilterer(schema, mr.filterer(mr.QueryTuplesQuery)).
+	qBuilder := common.NewSchemaQueryFilterer(mr.schema, mr.filterer(baseQuery)).
 		FilterWithSubjectsFilter(subjectsFilter)
 
-	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
-
 	if queryOpts.ResRelation != nil {
 		qBuilder = qBuilder.
 			FilterToResourceType(queryOpts.ResRelation.Namespace).
 			FilterToRelation(queryOpts.ResRelation.Relation)
 	}
 
-	return mr.querySplitter.SplitAndExecuteQuery(
+	if queryOpts.ReverseSort == options.BySubject {
+		qBuilder = qBuilder.SortBySubject()
+	}
+
+	if queryOpts.ReverseAfter != nil {
+		qBuilder = qBuilder.FilterAfterSubject(queryOpts.ReverseAfter)
+	}
+
+	return querySplitter.SplitAndExecuteQuery(
 		ctx,
 		qBuilder,
 		options.WithLimit(queryOpts.ReverseLimit),
 	)
 }
 
+// reverseQueryExecutor executes a query of the IDs of matching relationships as the common table
+// expression of a query of the relationships with those IDs, which are ordered by subject if the
+// IDs are.
+func (mr *mysqlReader) reverseQueryExecutor(sortBySubject bool) common.ExecuteQueryFunc {
+	return func(ctx context.Context, idsQuery string, args []any) ([]*core.RelationTuple, error) {
+		query := mr.ReverseQueryTuplesQuery.Prefix("WITH "+matchingTuples+" AS ("+idsQuery+")", args...)
+		if sortBySubject {
+			query = query.OrderBy(subjectSortColumns...)
+		}
+
+		sql, args, err := query.ToSql()
+		if err != nil {
+			return nil, fmt.Errorf(errUnableToQueryTuples, err)
+		}
+		return mr.querySplitter.Executor(ctx, sql, args)
+	}
+}
+
+func (mr *mysqlReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	query, args, err := common.NewSchemaQueryFilterer(mr.schema, mr.filterer(mr.CountTupleQuery)).
+		FilterWithRelationshipsFilter(filter).
+		ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+
+	tx, txCleanup, err := mr.txSource(ctx)
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+	defer common.LogOnError(ctx, txCleanup)
+
+	var count uint64
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+
+	return count, nil
+}
+
+func (mr *mysqlReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	query, args, err := common.NewSchemaQueryFilterer(mr.schema, mr.filterer(mr.ListResourceTypesQuery)).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+
+	tx, txCleanup, err := mr.txSource(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+	defer common.LogOnError(ctx, txCleanup)
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+	defer common.LogOnError(ctx, rows.Close)
+
+	var resourceTypes []string
+	for rows.Next() {
+		var resourceType string
+		if err := rows.Scan(&resourceType); err != nil {
+			return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+		}
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, rows.Err())
+	}
+
+	return resourceTypes, nil
+}
+
 func (mr *mysqlReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
 	// TODO (@vroldanbet) dupe from postgres datastore - need to refactor
 	tx, txCleanup, err := mr.txSource(ctx)
@@ -128,7 +244,7 @@ func loadNamespace(ctx context.Context, namespace string, tx *sql.Tx, baseQuery
 	return loaded, revision.NewFromDecimal(version), nil
 }
 
-func (mr *mysqlReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+func (mr *mysqlReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
 	// TODO (@vroldanbet) dupe from postgres datastore - need to refactor
 	tx, txCleanup, err := mr.txSource(ctx)
 	if err != nil {
@@ -136,7 +252,7 @@ func (mr *mysqlReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDef
 	}
 	defer common.LogOnError(ctx, txCleanup)
 
-	query := mr.filterer(mr.ReadNamespaceQuery)
+	query := common.ListDefinitionsPage(mr.filterer(mr.ReadNamespaceQuery), colNamespace, opts...)
 
 	nsDefs, err := loadAllNamespaces(ctx, tx, query)
 	if err != nil {
@@ -0,0 +1,57 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/mysql/migrations"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+func TestReverseQueryCTE(t *testing.T) {
+	for _, reverseQueryCTE := range []bool{false, true} {
+		reverseQueryCTE := reverseQueryCTE
+		t.Run("", func(t *testing.T) {
+			var executed []string
+			var executedArgs [][]any
+			reader := &mysqlReader{
+				QueryBuilder: NewQueryBuilder(migrations.NewMySQLDriverFromDB(nil, "")),
+				querySplitter: common.TupleQuerySplitter{
+					Executor: func(ctx context.Context, sql string, args []any) ([]*core.RelationTuple, error) {
+						executed = append(executed, sql)
+						executedArgs = append(executedArgs, args)
+						return nil, nil
+					},
+					UsersetBatchSize: 100,
+				},
+				filterer:        filterToTenant("tenant", currentlyLivingObjects),
+				reverseQueryCTE: reverseQueryCTE,
+				schema:          schema,
+			}
+
+			limit := uint64(10)
+			_, err := reader.ReverseQueryRelationships(context.Background(), datastore.SubjectsFilter{
+				SubjectType:        "user",
+				OptionalSubjectIds: []string{"tom"},
+			}, options.WithReverseSort(options.BySubject), options.WithReverseLimit(&limit))
+			require.NoError(t, err)
+			require.Len(t, executed, 1)
+
+			if !reverseQueryCTE {
+				require.NotContains(t, executed[0], "WITH")
+				return
+			}
+
+			require.Regexp(t, `^WITH matching_tuples AS \(SELECT id FROM relation_tuple WHERE .* ORDER BY .* LIMIT 10\) `+
+				`SELECT namespace, .* FROM relation_tuple JOIN matching_tuples USING \(id\) `+
+				`ORDER BY userset_namespace, userset_object_id, userset_relation, namespace, object_id, relation$`, executed[0])
+			require.Contains(t, executedArgs[0], "tenant")
+			require.Contains(t, executedArgs[0], "tom")
+		})
+	}
+}
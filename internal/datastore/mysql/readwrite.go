@@ -28,13 +28,14 @@ const (
 	errUnableToDeleteConfig        = "unable to delete namespace config: %w"
 )
 
-var duplicateEntryRegx = regexp.MustCompile(`^Duplicate entry '(.+)' for key 'uq_relation_tuple_living'$`)
+var duplicateEntryRegx = regexp.MustCompile(`^Duplicate entry '(.+)' for key 'uq_relation_tuple_living_tenant'$`)
 
 type mysqlReadWriteTXN struct {
 	*mysqlReader
 
 	tx       *sql.Tx
 	newTxnID uint64
+	tenant   string
 }
 
 // caveatContextWrapper is used to marshall maps into MySQLs JSON data type
@@ -52,6 +53,30 @@ func (cc *caveatContextWrapper) Value() (driver.Value, error) {
 	return json.Marshal(&cc)
 }
 
+// metadataWrapper is used to marshall relationship metadata into MySQLs JSON data type, storing
+// empty metadata as NULL
+type metadataWrapper map[string]string
+
+func (mw *metadataWrapper) Scan(val any) error {
+	if val == nil {
+		*mw = nil
+		return nil
+	}
+
+	v, ok := val.([]byte)
+	if !ok {
+		return fmt.Errorf("unsupported type: %T", val)
+	}
+	return json.Unmarshal(v, mw)
+}
+
+func (mw metadataWrapper) Value() (driver.Value, error) {
+	if len(mw) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(map[string]string(mw))
+}
+
 // WriteRelationships takes a list of existing relationships that must exist, and a list of
 // tuple mutations and applies it to the datastore for the specified namespace.
 func (rwt *mysqlReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
@@ -74,6 +99,15 @@ func (rwt *mysqlReadWriteTXN) WriteRelationships(ctx context.Context, mutations
 			clauses = append(clauses, exactRelationshipClause(tpl))
 		}
 
+		// Expired relationships no longer exist, so they are removed before a CREATE of the same
+		// relationship.
+		if mut.Operation == core.RelationTupleUpdate_CREATE {
+			clauses = append(clauses, sq.And{
+				exactRelationshipClause(tpl),
+				sq.Expr(colExpiration + " <= UTC_TIMESTAMP(6)"),
+			})
+		}
+
 		var caveatName string
 		var caveatContext caveatContextWrapper
 		if tpl.Caveat != nil {
@@ -82,6 +116,7 @@ func (rwt *mysqlReadWriteTXN) WriteRelationships(ctx context.Context, mutations
 		}
 		if mut.Operation == core.RelationTupleUpdate_TOUCH || mut.Operation == core.RelationTupleUpdate_CREATE {
 			bulkWrite = bulkWrite.Values(
+				rwt.tenant,
 				tpl.ResourceAndRelation.Namespace,
 				tpl.ResourceAndRelation.ObjectId,
 				tpl.ResourceAndRelation.Relation,
@@ -90,6 +125,8 @@ func (rwt *mysqlReadWriteTXN) WriteRelationships(ctx context.Context, mutations
 				tpl.Subject.Relation,
 				caveatName,
 				&caveatContext,
+				common.ExpirationTimeOf(tpl),
+				metadataWrapper(tpl.OptionalMetadata),
 				rwt.newTxnID,
 			)
 			bulkWriteHasValues = true
@@ -97,7 +134,7 @@ func (rwt *mysqlReadWriteTXN) WriteRelationships(ctx context.Context, mutations
 	}
 
 	if len(clauses) > 0 {
-		query, args, err := selectForUpdateQuery.Where(clauses).ToSql()
+		query, args, err := selectForUpdateQuery.Where(sq.Eq{colTenant: rwt.tenant}).Where(clauses).ToSql()
 		if err != nil {
 			return fmt.Errorf(errUnableToWriteRelationships, err)
 		}
@@ -145,7 +182,7 @@ func (rwt *mysqlReadWriteTXN) WriteRelationships(ctx context.Context, mutations
 
 		_, err = rwt.tx.ExecContext(ctx, query, args...)
 		if err != nil {
-			if cerr := convertToWriteConstraintError(err); cerr != nil {
+			if cerr := convertToWriteConstraintError(rwt.tenant, err); cerr != nil {
 				return cerr
 			}
 
@@ -159,7 +196,7 @@ func (rwt *mysqlReadWriteTXN) WriteRelationships(ctx context.Context, mutations
 func (rwt *mysqlReadWriteTXN) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
 	// TODO (@vroldanbet) dupe from postgres datastore - need to refactor
 	// Add clauses for the ResourceFilter
-	query := rwt.DeleteTupleQuery.Where(sq.Eq{colNamespace: filter.ResourceType})
+	query := rwt.DeleteTupleQuery.Where(sq.Eq{colTenant: rwt.tenant, colNamespace: filter.ResourceType})
 	if filter.OptionalResourceId != "" {
 		query = query.Where(sq.Eq{colObjectID: filter.OptionalResourceId})
 	}
@@ -205,12 +242,12 @@ func (rwt *mysqlReadWriteTXN) WriteNamespaces(ctx context.Context, newNamespaces
 		}
 
 		deletedNamespaceClause = append(deletedNamespaceClause, sq.Eq{colNamespace: newNamespace.Name})
-		writeQuery = writeQuery.Values(newNamespace.Name, serialized, rwt.newTxnID)
+		writeQuery = writeQuery.Values(rwt.tenant, newNamespace.Name, serialized, rwt.newTxnID)
 	}
 
 	delSQL, delArgs, err := rwt.DeleteNamespaceQuery.
 		Set(colDeletedTxn, rwt.newTxnID).
-		Where(sq.And{sq.Eq{colDeletedTxn: liveDeletedTxnID}, deletedNamespaceClause}).
+		Where(sq.And{sq.Eq{colDeletedTxn: liveDeletedTxnID, colTenant: rwt.tenant}, deletedNamespaceClause}).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf(errUnableToWriteConfig, err)
@@ -243,7 +280,7 @@ func (rwt *mysqlReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...s
 	tplClauses := make([]sq.Sqlizer, 0, len(nsNames))
 	for _, nsName := range nsNames {
 		// TODO(jzelinskie): check these in one query
-		baseQuery := rwt.ReadNamespaceQuery.Where(sq.Eq{colDeletedTxn: liveDeletedTxnID})
+		baseQuery := rwt.filterer(rwt.ReadNamespaceQuery)
 		_, createdAt, err := loadNamespace(ctx, nsName, rwt.tx, baseQuery)
 		switch {
 		case errors.As(err, &datastore.ErrNamespaceNotFound{}):
@@ -261,6 +298,7 @@ func (rwt *mysqlReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...s
 
 	delSQL, delArgs, err := rwt.DeleteNamespaceQuery.
 		Set(colDeletedTxn, rwt.newTxnID).
+		Where(sq.Eq{colTenant: rwt.tenant}).
 		Where(sq.Or(nsClauses)).
 		ToSql()
 	if err != nil {
@@ -274,6 +312,7 @@ func (rwt *mysqlReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...s
 
 	deleteTupleSQL, deleteTupleArgs, err := rwt.DeleteNamespaceTuplesQuery.
 		Set(colDeletedTxn, rwt.newTxnID).
+		Where(sq.Eq{colTenant: rwt.tenant}).
 		Where(sq.Or(tplClauses)).
 		ToSql()
 	if err != nil {
@@ -288,12 +327,13 @@ func (rwt *mysqlReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...s
 	return nil
 }
 
-func convertToWriteConstraintError(err error) error {
+func convertToWriteConstraintError(tenant string, err error) error {
 	var mysqlErr *mysql.MySQLError
 	if errors.As(err, &mysqlErr) && mysqlErr.Number == errMysqlDuplicateEntry {
 		found := duplicateEntryRegx.FindStringSubmatch(mysqlErr.Message)
 		if found != nil {
-			parts := strings.Split(found[1], "-")
+			// The duplicate entry starts with the tenant of the relationship.
+			parts := strings.Split(strings.TrimPrefix(found[1], tenant+"-"), "-")
 			if len(parts) == 7 {
 				return common.NewCreateRelationshipExistsError(&core.RelationTuple{
 					ResourceAndRelation: &core.ObjectAndRelation{
@@ -152,16 +152,24 @@ func (mds *Datastore) checkValidTransaction(ctx context.Context, revisionTx uint
 	return freshEnough.Bool, unknown.Bool, nil
 }
 
-func (mds *Datastore) createNewTransaction(ctx context.Context, tx *sql.Tx) (newTxnID uint64, err error) {
+func (mds *Datastore) createNewTransaction(ctx context.Context, tx *sql.Tx, metadata map[string]string) (newTxnID uint64, err error) {
 	ctx, span := tracer.Start(ctx, "createNewTransaction")
 	defer span.End()
 
+	if mds.tidbCompatibility {
+		return mds.createTiDBTransaction(ctx, tx, metadata)
+	}
+
 	createQuery := mds.createTxn
+	var args []any
+	if len(metadata) > 0 || mds.tenant != datastore.DefaultTenant {
+		createQuery, args, err = mds.CreateTxnWithMetadata.Values(mds.tenant, metadataWrapper(metadata)).ToSql()
+	}
 	if err != nil {
 		return 0, fmt.Errorf("createNewTransaction: %w", err)
 	}
 
-	result, err := tx.ExecContext(ctx, createQuery)
+	result, err := tx.ExecContext(ctx, createQuery, args...)
 	if err != nil {
 		return 0, fmt.Errorf("createNewTransaction: %w", err)
 	}
@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
@@ -15,6 +16,19 @@ const (
 	informationSchemaTableRowsColumn = "table_rows"
 	informationSchemaTablesTable     = "INFORMATION_SCHEMA.TABLES"
 	informationSchemaTableNameColumn = "table_name"
+	informationSchemaDataLength      = "data_length"
+	informationSchemaIndexLength     = "index_length"
+
+	informationSchemaStatisticsTable   = "INFORMATION_SCHEMA.STATISTICS"
+	informationSchemaIndexNameColumn   = "index_name"
+	informationSchemaCardinalityColumn = "cardinality"
+	informationSchemaSeqInIndexColumn  = "seq_in_index"
+
+	// The cardinality of the (tenant_id, namespace, object_id) prefix of the living index and of the
+	// (userset_object_id, userset_namespace) prefix of the subject index estimate the number of
+	// distinct resources and subjects.
+	indexLivingRelationships    = "uq_relation_tuple_living_tenant"
+	indexRelationshipsBySubject = "ix_relation_tuple_by_subject"
 
 	analyzeTableQuery = "ANALYZE TABLE %s"
 
@@ -36,15 +50,20 @@ func (mds *Datastore) Statistics(ctx context.Context) (datastore.Stats, error) {
 	}
 
 	query, args, err := sb.
-		Select(informationSchemaTableRowsColumn).
+		Select(informationSchemaTableRowsColumn, informationSchemaDataLength+" + "+informationSchemaIndexLength).
 		From(informationSchemaTablesTable).
 		Where(squirrel.Eq{informationSchemaTableNameColumn: mds.driver.RelationTuple()}).
 		ToSql()
 	if err != nil {
 		return datastore.Stats{}, err
 	}
-	var count uint64
-	err = mds.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	var count, storageBytes uint64
+	err = mds.db.QueryRowContext(ctx, query, args...).Scan(&count, &storageBytes)
+	if err != nil {
+		return datastore.Stats{}, err
+	}
+
+	distinctResources, distinctSubjects, err := mds.getDistinctEstimates(ctx)
 	if err != nil {
 		return datastore.Stats{}, err
 	}
@@ -52,7 +71,7 @@ func (mds *Datastore) Statistics(ctx context.Context) (datastore.Stats, error) {
 	if count == 0 {
 		// If we get a count of zero, its possible the information schema table has not yet
 		// been updated, so we use a slower count(*) call.
-		query, args, err := mds.QueryBuilder.CountTupleQuery.ToSql()
+		query, args, err := mds.QueryBuilder.CountTupleQuery.Where(squirrel.Eq{colTenant: mds.tenant}).ToSql()
 		if err != nil {
 			return datastore.Stats{}, err
 		}
@@ -62,7 +81,8 @@ func (mds *Datastore) Statistics(ctx context.Context) (datastore.Stats, error) {
 		}
 	}
 
-	nsQuery := mds.ReadNamespaceQuery.Where(squirrel.Eq{colDeletedTxn: liveDeletedTxnID})
+	// The estimates above are of the whole table, while the object types are those of the tenant.
+	nsQuery := filterToTenant(mds.tenant, currentlyLivingObjects)(mds.ReadNamespaceQuery)
 
 	tx, err := mds.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -79,9 +99,52 @@ func (mds *Datastore) Statistics(ctx context.Context) (datastore.Stats, error) {
 		UniqueID:                   uniqueID,
 		ObjectTypeStatistics:       datastore.ComputeObjectTypeStats(nsDefs),
 		EstimatedRelationshipCount: count,
+		EstimatedDistinctResources: distinctResources,
+		EstimatedDistinctSubjects:  distinctSubjects,
+		EstimatedStorageBytes:      storageBytes,
 	}, nil
 }
 
+func (mds *Datastore) getDistinctEstimates(ctx context.Context) (resources uint64, subjects uint64, err error) {
+	query, args, err := sb.
+		Select(informationSchemaIndexNameColumn, informationSchemaCardinalityColumn).
+		From(informationSchemaStatisticsTable).
+		Where(squirrel.Eq{informationSchemaTableNameColumn: mds.driver.RelationTuple()}).
+		Where(squirrel.Or{
+			squirrel.Eq{informationSchemaIndexNameColumn: indexLivingRelationships, informationSchemaSeqInIndexColumn: 3},
+			squirrel.Eq{informationSchemaIndexNameColumn: indexRelationshipsBySubject, informationSchemaSeqInIndexColumn: 2},
+		}).
+		ToSql()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rows, err := mds.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("unable to query index statistics: %w", err)
+	}
+	defer common.LogOnError(ctx, rows.Close)
+
+	for rows.Next() {
+		var indexName string
+		var cardinality sql.NullInt64
+		if err := rows.Scan(&indexName, &cardinality); err != nil {
+			return 0, 0, fmt.Errorf("unable to decode index statistics: %w", err)
+		}
+		if !cardinality.Valid || cardinality.Int64 < 0 {
+			continue
+		}
+
+		switch indexName {
+		case indexLivingRelationships:
+			resources = uint64(cardinality.Int64)
+		case indexRelationshipsBySubject:
+			subjects = uint64(cardinality.Int64)
+		}
+	}
+	return resources, subjects, rows.Err()
+}
+
 func (mds *Datastore) getUniqueID(ctx context.Context) (string, error) {
 	sql, args, err := sb.Select(metadataUniqueIDColumn).From(mds.driver.Metadata()).ToSql()
 	if err != nil {
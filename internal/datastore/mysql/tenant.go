@@ -0,0 +1,26 @@
+package mysql
+
+import (
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+var _ datastore.TenantPartitionedDatastore = (*Datastore)(nil)
+
+// ForTenant returns a datastore for the data of the tenant, which shares the connection pool and
+// garbage collection of this datastore. Tenant IDs are limited to 64 characters by the schema.
+func (mds *Datastore) ForTenant(tenantID string) datastore.Datastore {
+	forTenant := *mds
+	forTenant.tenant = tenantID
+	return &mysqlTenantDatastore{&forTenant}
+}
+
+// mysqlTenantDatastore is a datastore for the data of a single tenant.
+type mysqlTenantDatastore struct {
+	*Datastore
+}
+
+// Close does nothing, as the connection pool is owned by the datastore from which the tenant's
+// datastore was created.
+func (td *mysqlTenantDatastore) Close() error {
+	return nil
+}
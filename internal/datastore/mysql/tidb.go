@@ -0,0 +1,55 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+const (
+	// https://docs.pingcap.com/tidb/stable/error-codes
+	errTiDBTxnRetryable      = 8022
+	errTiDBInfoSchemaChanged = 8028
+	errTiDBWriteConflict     = 9007
+
+	queryTiDBStartTimestamp = "SELECT @@tidb_current_ts"
+
+	// queryTiDBOldestTransaction returns the start timestamp of the oldest transaction running in
+	// the database of the session, on any TiDB server of the cluster. The transactions of other
+	// users are only visible to users granted PROCESS.
+	queryTiDBOldestTransaction = "SELECT MIN(ID) FROM INFORMATION_SCHEMA.CLUSTER_TIDB_TRX WHERE DB = DATABASE()"
+)
+
+// createTiDBTransaction inserts the row of a transaction whose ID is its start timestamp, which is
+// ordered across every TiDB server of the cluster.
+func (mds *Datastore) createTiDBTransaction(ctx context.Context, tx *sql.Tx, metadata map[string]string) (uint64, error) {
+	var startTimestamp uint64
+	if err := tx.QueryRowContext(ctx, queryTiDBStartTimestamp).Scan(&startTimestamp); err != nil {
+		return 0, fmt.Errorf("createNewTransaction: unable to read start timestamp: %w", err)
+	}
+
+	query, args, err := mds.CreateTxnWithID.Values(startTimestamp, mds.tenant, metadataWrapper(metadata)).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("createNewTransaction: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return 0, fmt.Errorf("createNewTransaction: %w", err)
+	}
+	return startTimestamp, nil
+}
+
+// tidbCommittedRevision returns the latest transaction, up to the given transaction, before which
+// no transaction is still running. A running transaction can commit after transactions which
+// started later, and so with greater IDs, have committed.
+func (mds *Datastore) tidbCommittedRevision(ctx context.Context, latest uint64) (uint64, error) {
+	var oldest *uint64
+	if err := mds.db.QueryRowContext(ctx, queryTiDBOldestTransaction).Scan(&oldest); err != nil {
+		return 0, fmt.Errorf(errRevision, err)
+	}
+
+	if oldest != nil && *oldest <= latest {
+		return *oldest - 1, nil
+	}
+	return latest, nil
+}
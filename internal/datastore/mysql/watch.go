@@ -2,10 +2,12 @@ package mysql
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"time"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
+	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/datastore/revision"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -34,45 +36,83 @@ func (mds *Datastore) Watch(ctx context.Context, afterRevisionRaw datastore.Revi
 
 		currentTxn := transactionFromRevision(afterRevision)
 
-		for {
-			var stagedUpdates []*datastore.RevisionChanges
-			var err error
-			stagedUpdates, currentTxn, err = mds.loadChanges(ctx, currentTxn)
-			if err != nil {
-				if errors.Is(ctx.Err(), context.Canceled) {
-					errs <- datastore.NewWatchCanceledErr()
-				} else {
-					errs <- err
-				}
+		if mds.watchMode == watchBinlog {
+			stream, err := mds.openBinlogStream(ctx)
+			if err == nil {
+				defer stream.close()
+				mds.watchBinlog(ctx, stream, currentTxn, updates, errs)
+				return
+			}
+			log.Ctx(ctx).Warn().Err(err).Msg("unable to stream the binary log, falling back to polling for the watch")
+		}
+
+		mds.watchPolling(ctx, currentTxn, updates, errs)
+	}()
+
+	return updates, errs
+}
+
+// watchPolling sends the changes of the transactions committed after the transaction, which
+// are loaded by polling the transactions table.
+func (mds *Datastore) watchPolling(
+	ctx context.Context,
+	currentTxn uint64,
+	updates chan<- *datastore.RevisionChanges,
+	errs chan<- error,
+) {
+	lastSent := time.Now()
+
+	for {
+		var stagedUpdates []*datastore.RevisionChanges
+		var err error
+		stagedUpdates, currentTxn, err = mds.loadChanges(ctx, currentTxn)
+		if err != nil {
+			if errors.Is(ctx.Err(), context.Canceled) {
+				errs <- datastore.NewWatchCanceledErr()
+			} else {
+				errs <- err
+			}
+			return
+		}
+
+		// Write the staged updates to the channel
+		for _, changeToWrite := range stagedUpdates {
+			select {
+			case updates <- changeToWrite:
+			default:
+				errs <- datastore.NewWatchDisconnectedErr()
 				return
 			}
+			lastSent = time.Now()
+		}
 
-			// Write the staged updates to the channel
-			for _, changeToWrite := range stagedUpdates {
+		// If there were no changes, send a checkpoint if none has been sent within the
+		// interval, and sleep a bit
+		if len(stagedUpdates) == 0 {
+			if time.Since(lastSent) >= common.WatchCheckpointInterval {
 				select {
-				case updates <- changeToWrite:
+				case updates <- &datastore.RevisionChanges{
+					Revision:     revisionFromTransaction(currentTxn),
+					IsCheckpoint: true,
+				}:
 				default:
 					errs <- datastore.NewWatchDisconnectedErr()
 					return
 				}
+				lastSent = time.Now()
 			}
 
-			// If there were no changes, sleep a bit
-			if len(stagedUpdates) == 0 {
-				sleep := time.NewTimer(watchSleep)
+			sleep := time.NewTimer(watchSleep)
 
-				select {
-				case <-sleep.C:
-					break
-				case <-ctx.Done():
-					errs <- datastore.NewWatchCanceledErr()
-					return
-				}
+			select {
+			case <-sleep.C:
+				break
+			case <-ctx.Done():
+				errs <- datastore.NewWatchCanceledErr()
+				return
 			}
 		}
-	}()
-
-	return updates, errs
+	}
 }
 
 // TODO (@vroldanbet) dupe from postgres datastore - need to refactor
@@ -85,11 +125,32 @@ func (mds *Datastore) loadChanges(
 		return
 	}
 
+	if mds.tidbCompatibility {
+		newRevision, err = mds.tidbCommittedRevision(ctx, newRevision)
+		if err != nil {
+			return
+		}
+		if newRevision < afterRevision {
+			newRevision = afterRevision
+		}
+	}
+
 	if newRevision == afterRevision {
 		return
 	}
 
-	sql, args, err := mds.QueryChangedQuery.Where(sq.Or{
+	changes, err = mds.loadChangesBetween(ctx, afterRevision, newRevision)
+	return
+}
+
+// loadChangesBetween loads the changes of the transactions after afterRevision, up to and
+// including newRevision.
+func (mds *Datastore) loadChangesBetween(
+	ctx context.Context,
+	afterRevision uint64,
+	newRevision uint64,
+) (changes []*datastore.RevisionChanges, err error) {
+	query, args, err := mds.QueryChangedQuery.Where(sq.Or{
 		sq.And{
 			sq.Gt{colCreatedTxn: afterRevision},
 			sq.LtOrEq{colCreatedTxn: newRevision},
@@ -98,12 +159,12 @@ func (mds *Datastore) loadChanges(
 			sq.Gt{colDeletedTxn: afterRevision},
 			sq.LtOrEq{colDeletedTxn: newRevision},
 		},
-	}).ToSql()
+	}).Where(sq.Eq{colTenant: mds.tenant}).ToSql()
 	if err != nil {
 		return
 	}
 
-	rows, err := mds.db.QueryContext(ctx, sql, args...)
+	rows, err := mds.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			err = datastore.NewWatchCanceledErr()
@@ -124,6 +185,8 @@ func (mds *Datastore) loadChanges(
 		var deletedTxn uint64
 		var caveatName string
 		var caveatContext caveatContextWrapper
+		var expiration sql.NullTime
+		var metadata metadataWrapper
 		err = rows.Scan(
 			&nextTuple.ResourceAndRelation.Namespace,
 			&nextTuple.ResourceAndRelation.ObjectId,
@@ -133,6 +196,8 @@ func (mds *Datastore) loadChanges(
 			&nextTuple.Subject.Relation,
 			&caveatName,
 			&caveatContext,
+			&expiration,
+			&metadata,
 			&createdTxn,
 			&deletedTxn,
 		)
@@ -143,6 +208,8 @@ func (mds *Datastore) loadChanges(
 		if err != nil {
 			return
 		}
+		nextTuple.OptionalExpirationTime = common.ExpirationFrom(expiration)
+		nextTuple.OptionalMetadata = metadata
 
 		if createdTxn > afterRevision && createdTxn <= newRevision {
 			stagedChanges.AddChange(ctx, revisionFromTransaction(createdTxn), nextTuple, core.RelationTupleUpdate_TOUCH)
@@ -156,7 +223,48 @@ func (mds *Datastore) loadChanges(
 		return
 	}
 
+	if err = mds.loadTransactionMetadata(ctx, afterRevision, newRevision, stagedChanges); err != nil {
+		return
+	}
+
 	changes = stagedChanges.AsRevisionChanges(mds)
 
 	return
 }
+
+// loadTransactionMetadata adds the metadata stored with the transactions in the revision range
+// to the staged changes.
+func (mds *Datastore) loadTransactionMetadata(
+	ctx context.Context,
+	afterRevision uint64,
+	newRevision uint64,
+	stagedChanges common.Changes,
+) error {
+	query, args, err := mds.QueryTransactionMetadata.Where(sq.And{
+		sq.Gt{colID: afterRevision},
+		sq.LtOrEq{colID: newRevision},
+		sq.Eq{colTenant: mds.tenant},
+	}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := mds.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			err = datastore.NewWatchCanceledErr()
+		}
+		return err
+	}
+	defer common.LogOnError(ctx, rows.Close)
+
+	for rows.Next() {
+		var txnID uint64
+		var metadata metadataWrapper
+		if err := rows.Scan(&txnID, &metadata); err != nil {
+			return err
+		}
+		stagedChanges.SetRevisionMetadata(revisionFromTransaction(txnID), metadata)
+	}
+	return rows.Err()
+}
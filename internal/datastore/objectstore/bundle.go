@@ -0,0 +1,140 @@
+package objectstore
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"google.golang.org/api/option"
+	storage "google.golang.org/api/storage/v1"
+)
+
+// openBundle opens the snapshot bundle at the URI, which is one of
+//
+//	s3://bucket/key?region=us-east-1&endpoint=http://localhost:9000
+//	gs://bucket/object?endpoint=http://localhost:4443/storage/v1/
+//	file:///path/to/bundle
+//
+// A bundle whose name ends in .gz is decompressed as it is read.
+func openBundle(ctx context.Context, uri string) (io.ReadCloser, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle URI: %w", err)
+	}
+
+	var body io.ReadCloser
+	switch parsed.Scheme {
+	case "s3":
+		body, err = openS3Object(ctx, parsed)
+	case "gs":
+		body, err = openGCSObject(ctx, parsed)
+	case "file":
+		body, err = os.Open(parsed.Path)
+	default:
+		return nil, fmt.Errorf("unsupported bundle URI scheme %q", parsed.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(parsed.Path, ".gz") {
+		return body, nil
+	}
+
+	decompressed, err := gzip.NewReader(body)
+	if err != nil {
+		_ = body.Close()
+		return nil, fmt.Errorf("unable to decompress bundle: %w", err)
+	}
+	return &gzipBody{Reader: decompressed, body: body}, nil
+}
+
+// objectOf returns the bucket and object named by the URI.
+func objectOf(parsed *url.URL) (string, string, error) {
+	object := strings.TrimPrefix(parsed.Path, "/")
+	if parsed.Host == "" || object == "" {
+		return "", "", fmt.Errorf("bundle URI must name a bucket and an object")
+	}
+	return parsed.Host, object, nil
+}
+
+// openS3Object opens an object of S3, or of an S3-compatible store at the endpoint parameter,
+// with the credentials of the environment.
+func openS3Object(ctx context.Context, parsed *url.URL) (io.ReadCloser, error) {
+	bucket, key, err := objectOf(parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	config := aws.Config{}
+	if region := parsed.Query().Get("region"); region != "" {
+		config.Region = aws.String(region)
+	}
+	if endpoint := parsed.Query().Get("endpoint"); endpoint != "" {
+		config.Endpoint = aws.String(endpoint)
+		config.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create AWS session: %w", err)
+	}
+
+	output, err := s3.New(sess).GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read s3://%s/%s: %w", bucket, key, err)
+	}
+	return output.Body, nil
+}
+
+// openGCSObject opens an object of Google Cloud Storage, with the application default
+// credentials, or of the storage emulator at the endpoint parameter.
+func openGCSObject(ctx context.Context, parsed *url.URL) (io.ReadCloser, error) {
+	bucket, object, err := objectOf(parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []option.ClientOption{option.WithScopes(storage.DevstorageReadOnlyScope)}
+	if endpoint := parsed.Query().Get("endpoint"); endpoint != "" {
+		opts = append(opts, option.WithEndpoint(endpoint), option.WithoutAuthentication())
+	}
+
+	service, err := storage.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create storage client: %w", err)
+	}
+
+	response, err := service.Objects.Get(bucket, object).Context(ctx).Download()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read gs://%s/%s: %w", bucket, object, err)
+	}
+	return response.Body, nil
+}
+
+// gzipBody closes both the decompressor and the body it reads.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (gb *gzipBody) Close() error {
+	if err := gb.Reader.Close(); err != nil {
+		_ = gb.body.Close()
+		return err
+	}
+	return gb.body.Close()
+}
@@ -0,0 +1,158 @@
+// Package objectstore implements a read-only datastore which serves a snapshot bundle, written by
+// the snapshot package, from an object store at a fixed revision.
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/snapshot"
+)
+
+const (
+	Engine = "objectstore"
+
+	errUnableToInstantiate = "unable to instantiate datastore: %w"
+
+	defaultLoadTimeout = 30 * time.Minute
+
+	// neverStale is the GC window of the datastore holding the bundle, whose single revision
+	// must remain readable for as long as the bundle is served.
+	neverStale = time.Duration(math.MaxInt64)
+
+	fixedRevisionReason = "the objectstore datastore serves a snapshot bundle at a fixed revision"
+)
+
+func init() {
+	datastore.Engines = append(datastore.Engines, Engine)
+}
+
+type objectStoreOptions struct {
+	loadTimeout time.Duration
+}
+
+// Option provides the facility to configure how the bundle is loaded.
+type Option func(*objectStoreOptions)
+
+// LoadTimeout is the longest the bundle may take to download and load.
+//
+// This value defaults to 30 minutes.
+func LoadTimeout(timeout time.Duration) Option {
+	return func(oo *objectStoreOptions) {
+		oo.loadTimeout = timeout
+	}
+}
+
+// NewObjectStoreDatastore creates a new read-only datastore.Datastore serving the snapshot bundle
+// at the URI, which is downloaded and loaded into memory before it returns. The bundle is served
+// at the revision at which it was loaded, which is the only revision of the datastore.
+func NewObjectStoreDatastore(uri string, opts ...Option) (datastore.Datastore, error) {
+	config := objectStoreOptions{loadTimeout: defaultLoadTimeout}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.loadTimeout)
+	defer cancel()
+
+	bundle, err := openBundle(ctx, uri)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+	defer bundle.Close()
+
+	delegate, err := memdb.NewMemdbDatastore(0, 0, neverStale)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+
+	revision, summary, err := snapshot.Import(ctx, delegate, bundle)
+	if err != nil {
+		_ = delegate.Close()
+		return nil, fmt.Errorf(errUnableToInstantiate, fmt.Errorf("unable to load bundle: %w", err))
+	}
+
+	log.Info().
+		Str("uri", uri).
+		Uint64("caveats", summary.Caveats).
+		Uint64("namespaces", summary.Namespaces).
+		Uint64("relationships", summary.Relationships).
+		Stringer("revision", revision).
+		Msg("loaded snapshot bundle")
+
+	return &Datastore{Datastore: delegate, revision: revision}, nil
+}
+
+// Datastore serves the data of a snapshot bundle, which is held by an in-memory datastore, at the
+// revision at which it was loaded.
+type Datastore struct {
+	datastore.Datastore
+
+	revision datastore.Revision
+}
+
+func (ods *Datastore) Unwrap() datastore.Datastore { return ods.Datastore }
+
+// SnapshotReader reads the bundle at its revision, whichever revision is requested.
+func (ods *Datastore) SnapshotReader(_ datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return ods.Datastore.SnapshotReader(ods.revision, opts...)
+}
+
+func (ods *Datastore) ReadWriteTx(context.Context, datastore.TxUserFunc, ...options.RWTOptionsOption) (datastore.Revision, error) {
+	return datastore.NoRevision, datastore.NewReadonlyErr()
+}
+
+func (ods *Datastore) BulkLoad(context.Context, datastore.BulkWriteRelationshipSource) (datastore.Revision, error) {
+	return datastore.NoRevision, datastore.NewReadonlyErr()
+}
+
+func (ods *Datastore) OptimizedRevision(_ context.Context) (datastore.Revision, error) {
+	return ods.revision, nil
+}
+
+func (ods *Datastore) HeadRevision(_ context.Context) (datastore.Revision, error) {
+	return ods.revision, nil
+}
+
+// CheckRevision accepts only the revision of the bundle. Earlier revisions are reported as stale,
+// so that requests for them are served at the revision of the bundle instead.
+func (ods *Datastore) CheckRevision(_ context.Context, revision datastore.Revision) error {
+	switch {
+	case revision == datastore.NoRevision:
+		return datastore.NewInvalidRevisionErr(revision, datastore.CouldNotDetermineRevision)
+	case revision.Equal(ods.revision):
+		return nil
+	case revision.LessThan(ods.revision):
+		return datastore.NewInvalidRevisionErr(revision, datastore.RevisionStale)
+	default:
+		return datastore.NewInvalidRevisionErr(revision, datastore.CouldNotDetermineRevision)
+	}
+}
+
+func (ods *Datastore) Watch(_ context.Context, _ datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
+	updates := make(chan *datastore.RevisionChanges)
+	errs := make(chan error, 1)
+	errs <- datastore.NewWatchDisabledErr(fixedRevisionReason)
+	return updates, errs
+}
+
+func (ods *Datastore) IsReady(_ context.Context) (bool, error) {
+	return true, nil
+}
+
+func (ods *Datastore) Features(_ context.Context) (*datastore.Features, error) {
+	return &datastore.Features{
+		Watch:                  datastore.Feature{Enabled: false, Reason: fixedRevisionReason},
+		WatchCheckpoints:       datastore.Feature{Enabled: false, Reason: fixedRevisionReason},
+		Caveats:                datastore.Feature{Enabled: true},
+		BulkLoad:               datastore.Feature{Enabled: false, Reason: fixedRevisionReason},
+		ReverseQuerySort:       datastore.Feature{Enabled: true},
+		RelationshipExpiration: datastore.Feature{Enabled: true},
+	}, nil
+}
@@ -0,0 +1,127 @@
+package objectstore
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/snapshot"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// writeBundle exports the standard data to a bundle at the path, compressing it if its name ends
+// in .gz.
+func writeBundle(t *testing.T, path string) {
+	ctx := context.Background()
+
+	rawSource, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+	source, revision := testfixtures.StandardDatastoreWithCaveatedData(rawSource, require.New(t))
+
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var w io.Writer = file
+	if filepath.Ext(path) == ".gz" {
+		compressed := gzip.NewWriter(file)
+		defer func() { require.NoError(t, compressed.Close()) }()
+		w = compressed
+	}
+
+	_, err = snapshot.Export(ctx, source, revision, w)
+	require.NoError(t, err)
+}
+
+func TestObjectStoreDatastore(t *testing.T) {
+	for _, name := range []string{"bundle", "bundle.gz"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			require := require.New(t)
+			ctx := context.Background()
+
+			path := filepath.Join(t.TempDir(), name)
+			writeBundle(t, path)
+
+			ds, err := NewObjectStoreDatastore("file://" + path)
+			require.NoError(err)
+			defer ds.Close()
+
+			revision, err := ds.HeadRevision(ctx)
+			require.NoError(err)
+			optimized, err := ds.OptimizedRevision(ctx)
+			require.NoError(err)
+			require.True(revision.Equal(optimized))
+
+			// Every revision reads the bundle.
+			for _, readRevision := range []datastore.Revision{revision, datastore.NoRevision} {
+				iter, err := ds.SnapshotReader(readRevision).QueryRelationships(ctx, datastore.RelationshipsFilter{
+					ResourceType: testfixtures.DocumentNS.Name,
+				})
+				require.NoError(err)
+
+				found := 0
+				for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+					require.Equal(testfixtures.DocumentNS.Name, tpl.ResourceAndRelation.Namespace, tuple.String(tpl))
+					found++
+				}
+				require.NoError(iter.Err())
+				iter.Close()
+				require.NotZero(found)
+			}
+
+			caveat, _, err := ds.SnapshotReader(revision).ReadCaveatByName(ctx, "test")
+			require.NoError(err)
+			require.Equal("test", caveat.Name)
+
+			require.NoError(ds.CheckRevision(ctx, revision))
+			require.ErrorAs(ds.CheckRevision(ctx, datastore.NoRevision), &datastore.ErrInvalidRevision{})
+
+			_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+				return nil
+			})
+			require.ErrorAs(err, &datastore.ErrReadOnly{})
+
+			_, errs := ds.Watch(ctx, revision)
+			require.ErrorAs(<-errs, &datastore.ErrWatchDisabled{})
+
+			features, err := ds.Features(ctx)
+			require.NoError(err)
+			require.False(features.Watch.Enabled)
+			require.False(features.BulkLoad.Enabled)
+			require.True(features.Caveats.Enabled)
+		})
+	}
+}
+
+func TestObjectStoreDatastoreErrors(t *testing.T) {
+	truncated := filepath.Join(t.TempDir(), "truncated")
+	require.NoError(t, os.WriteFile(truncated, []byte{0x01}, 0o600))
+
+	testCases := []struct {
+		name          string
+		uri           string
+		expectedError string
+	}{
+		{"unsupported scheme", "ftp://bucket/bundle", "unsupported bundle URI scheme"},
+		{"missing object", "s3://bucket", "must name a bucket and an object"},
+		{"missing file", "file://" + filepath.Join(t.TempDir(), "missing"), "no such file"},
+		{"truncated bundle", "file://" + truncated, "unable to load bundle"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewObjectStoreDatastore(tc.uri)
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
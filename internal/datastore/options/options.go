@@ -1,10 +1,12 @@
 package options
 
 import (
+	"time"
+
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
 
-//go:generate go run github.com/ecordell/optgen -output zz_generated.query_options.go . QueryOptions ReverseQueryOptions
+//go:generate go run github.com/ecordell/optgen -output zz_generated.query_options.go . QueryOptions ReverseQueryOptions SnapshotReaderOptions RWTOptions ListOptions
 
 // QueryOptions are the options that can affect the results of a normal forward query.
 type QueryOptions struct {
@@ -16,8 +18,82 @@ type QueryOptions struct {
 type ReverseQueryOptions struct {
 	ReverseLimit *uint64
 	ResRelation  *ResourceRelation
+
+	// ReverseSort is the order in which the relationships are returned.
+	ReverseSort SortOrder
+
+	// ReverseAfter resumes a sorted query after the relationship at the cursor. It requires
+	// ReverseSort to be set.
+	ReverseAfter Cursor
+}
+
+// SnapshotReaderOptions are the options that can affect how a snapshot reader reads data.
+type SnapshotReaderOptions struct {
+	Consistency  ReadConsistency
+	MaxStaleness time.Duration
+}
+
+// RWTOptions are the options that can affect a read-write transaction.
+type RWTOptions struct {
+	// Metadata is caller-supplied metadata, such as the actor or reason for the change, which is
+	// stored with the transaction and returned with its changes from Watch.
+	Metadata map[string]string
+}
+
+// ListOptions are the options that can affect the results of listing the namespace or caveat
+// definitions. Definitions are always listed in order of their names.
+type ListOptions struct {
+	// ListLimit is the maximum number of definitions to return.
+	ListLimit *uint64
+
+	// ListAfter resumes the listing after the definition with this name, which is the name of
+	// the last definition returned by a previous listing.
+	ListAfter string
+}
+
+// IsPaged returns true if the listing is limited or resumes from a previous listing.
+func (lo *ListOptions) IsPaged() bool {
+	return lo.ListLimit != nil || lo.ListAfter != ""
 }
 
+// ReadConsistency is a hint to the datastore about the consistency required by the reads made
+// through a snapshot reader. Datastores which cannot make use of a hint read at exactly the
+// requested revision.
+type ReadConsistency int
+
+const (
+	// ExactSnapshot reads the data exactly as of the requested revision. This is the default.
+	ExactSnapshot ReadConsistency = iota
+
+	// MinimizeLatency allows the datastore to read the data as of any point at or after the
+	// requested revision, so that the read can be served by the closest available replica.
+	MinimizeLatency
+
+	// BoundedStaleness allows the datastore to read data which is at most MaxStaleness old,
+	// even if that is before the requested revision, so that the read can be served by a
+	// follower or stale replica.
+	BoundedStaleness
+)
+
+// SortOrder is the order in which the relationships of a query are returned.
+type SortOrder int
+
+const (
+	// Unsorted returns the relationships in whichever order is cheapest for the datastore.
+	// This is the default.
+	Unsorted SortOrder = iota
+
+	// BySubject returns the relationships ordered by subject type, subject ID and subject
+	// relation, and then by resource type, resource ID and resource relation. Datastores
+	// compare each of these using the collation of the underlying storage.
+	BySubject
+)
+
+// Cursor marks a position in a sorted query, from which the query can be resumed. A cursor is
+// made by converting the last relationship returned by a query, and should otherwise be treated
+// as opaque.
+type Cursor *core.RelationTuple
+
 // ResourceRelation combines a resource object type and relation.
 type ResourceRelation struct {
 	Namespace string
@@ -1,7 +1,10 @@
 // Code generated by github.com/ecordell/optgen. DO NOT EDIT.
 package options
 
-import v1 "github.com/authzed/spicedb/pkg/proto/core/v1"
+import (
+	v1 "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"time"
+)
 
 type QueryOptionsOption func(q *QueryOptions)
 
@@ -67,6 +70,8 @@ func (r *ReverseQueryOptions) ToOption() ReverseQueryOptionsOption {
 	return func(to *ReverseQueryOptions) {
 		to.ReverseLimit = r.ReverseLimit
 		to.ResRelation = r.ResRelation
+		to.ReverseSort = r.ReverseSort
+		to.ReverseAfter = r.ReverseAfter
 	}
 }
 
@@ -91,3 +96,142 @@ func WithResRelation(resRelation *ResourceRelation) ReverseQueryOptionsOption {
 		r.ResRelation = resRelation
 	}
 }
+
+// WithReverseSort returns an option that can set ReverseSort on a ReverseQueryOptions
+func WithReverseSort(reverseSort SortOrder) ReverseQueryOptionsOption {
+	return func(r *ReverseQueryOptions) {
+		r.ReverseSort = reverseSort
+	}
+}
+
+// WithReverseAfter returns an option that can set ReverseAfter on a ReverseQueryOptions
+func WithReverseAfter(reverseAfter Cursor) ReverseQueryOptionsOption {
+	return func(r *ReverseQueryOptions) {
+		r.ReverseAfter = reverseAfter
+	}
+}
+
+type SnapshotReaderOptionsOption func(s *SnapshotReaderOptions)
+
+// NewSnapshotReaderOptionsWithOptions creates a new SnapshotReaderOptions with the passed in options set
+func NewSnapshotReaderOptionsWithOptions(opts ...SnapshotReaderOptionsOption) *SnapshotReaderOptions {
+	s := &SnapshotReaderOptions{}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// ToOption returns a new SnapshotReaderOptionsOption that sets the values from the passed in SnapshotReaderOptions
+func (s *SnapshotReaderOptions) ToOption() SnapshotReaderOptionsOption {
+	return func(to *SnapshotReaderOptions) {
+		to.Consistency = s.Consistency
+		to.MaxStaleness = s.MaxStaleness
+	}
+}
+
+// SnapshotReaderOptionsWithOptions configures an existing SnapshotReaderOptions with the passed in options set
+func SnapshotReaderOptionsWithOptions(s *SnapshotReaderOptions, opts ...SnapshotReaderOptionsOption) *SnapshotReaderOptions {
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// WithConsistency returns an option that can set Consistency on a SnapshotReaderOptions
+func WithConsistency(consistency ReadConsistency) SnapshotReaderOptionsOption {
+	return func(s *SnapshotReaderOptions) {
+		s.Consistency = consistency
+	}
+}
+
+// WithMaxStaleness returns an option that can set MaxStaleness on a SnapshotReaderOptions
+func WithMaxStaleness(maxStaleness time.Duration) SnapshotReaderOptionsOption {
+	return func(s *SnapshotReaderOptions) {
+		s.MaxStaleness = maxStaleness
+	}
+}
+
+type RWTOptionsOption func(r *RWTOptions)
+
+// NewRWTOptionsWithOptions creates a new RWTOptions with the passed in options set
+func NewRWTOptionsWithOptions(opts ...RWTOptionsOption) *RWTOptions {
+	r := &RWTOptions{}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// ToOption returns a new RWTOptionsOption that sets the values from the passed in RWTOptions
+func (r *RWTOptions) ToOption() RWTOptionsOption {
+	return func(to *RWTOptions) {
+		to.Metadata = r.Metadata
+	}
+}
+
+// RWTOptionsWithOptions configures an existing RWTOptions with the passed in options set
+func RWTOptionsWithOptions(r *RWTOptions, opts ...RWTOptionsOption) *RWTOptions {
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// WithMetadata returns an option that can append Metadatas to RWTOptions.Metadata
+func WithMetadata(key string, value string) RWTOptionsOption {
+	return func(r *RWTOptions) {
+		if r.Metadata == nil {
+			r.Metadata = make(map[string]string)
+		}
+		r.Metadata[key] = value
+	}
+}
+
+// SetMetadata returns an option that can set Metadata on a RWTOptions
+func SetMetadata(metadata map[string]string) RWTOptionsOption {
+	return func(r *RWTOptions) {
+		r.Metadata = metadata
+	}
+}
+
+type ListOptionsOption func(l *ListOptions)
+
+// NewListOptionsWithOptions creates a new ListOptions with the passed in options set
+func NewListOptionsWithOptions(opts ...ListOptionsOption) *ListOptions {
+	l := &ListOptions{}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// ToOption returns a new ListOptionsOption that sets the values from the passed in ListOptions
+func (l *ListOptions) ToOption() ListOptionsOption {
+	return func(to *ListOptions) {
+		to.ListLimit = l.ListLimit
+		to.ListAfter = l.ListAfter
+	}
+}
+
+// ListOptionsWithOptions configures an existing ListOptions with the passed in options set
+func ListOptionsWithOptions(l *ListOptions, opts ...ListOptionsOption) *ListOptions {
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+// WithListLimit returns an option that can set ListLimit on a ListOptions
+func WithListLimit(listLimit *uint64) ListOptionsOption {
+	return func(l *ListOptions) {
+		l.ListLimit = listLimit
+	}
+}
+
+// WithListAfter returns an option that can set ListAfter on a ListOptions
+func WithListAfter(listAfter string) ListOptionsOption {
+	return func(l *ListOptions) {
+		l.ListAfter = listAfter
+	}
+}
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
+	"github.com/authzed/spicedb/internal/datastore/postgres/migrations"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	// tableStaging is the temporary table into which bulk loads are copied. It has neither the
+	// indices nor the constraints of the relationships table, so that the copy is not slowed by
+	// maintaining them row by row, and is dropped as the transaction commits.
+	tableStaging = "relation_tuple_staging"
+
+	createStagingTable = `CREATE TEMPORARY TABLE %[1]s (LIKE %[2]s INCLUDING DEFAULTS) ON COMMIT DROP;`
+
+	// moveStagedRelationships moves the staged relationships into the relationships table in a
+	// single statement, sorted by the primary key so that its index is built in order.
+	//
+	//   $1 the transaction ID
+	moveStagedRelationships = `INSERT INTO %[1]s (%[3]s, %[5]s) SELECT %[3]s, $1 FROM %[2]s ORDER BY %[4]s;`
+)
+
+var (
+	createStagingTableQuery = fmt.Sprintf(createStagingTable, tableStaging, tableTuple)
+
+	moveStagedRelationshipsQuery = fmt.Sprintf(
+		moveStagedRelationships,
+		tableTuple,
+		tableStaging,
+		strings.Join(copyCols, ", "),
+		strings.Join([]string{colNamespace, colObjectID, colRelation, colUsersetNamespace, colUsersetObjectID, colUsersetRelation}, ", "),
+		colCreatedXid,
+	)
+)
+
+var copyCols = []string{
+	colNamespace,
+	colObjectID,
+	colRelation,
+	colUsersetNamespace,
+	colUsersetObjectID,
+	colUsersetRelation,
+	colCaveatContextName,
+	colCaveatContext,
+	colExpiration,
+	colMetadata,
+	colTenant,
+}
+
+// BulkLoad writes all relationships from the source in a single transaction. The relationships
+// are streamed with the COPY protocol into a temporary staging table, and then moved into the
+// relationships table by a single statement, in which the created_xid of each row is set to the
+// ID of the transaction and duplicates fail the unique constraint.
+func (pgd *pgDatastore) BulkLoad(
+	ctx context.Context,
+	source datastore.BulkWriteRelationshipSource,
+) (datastore.Revision, error) {
+	var newXID, newXmin xid8
+	err := pgd.writePool.BeginTxFunc(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(tx pgx.Tx) error {
+		if err := pgd.writeTimeouts.setLocal(ctx, tx); err != nil {
+			return err
+		}
+
+		var err error
+		newXID, newXmin, err = createNewTransaction(ctx, tx, pgd.tenant, nil)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, createStagingTableQuery); err != nil {
+			return fmt.Errorf("unable to create staging table: %w", err)
+		}
+
+		_, err = tx.CopyFrom(ctx, pgx.Identifier{tableStaging}, copyCols, &copySource{ctx: ctx, source: source, tenant: pgd.tenant})
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, moveStagedRelationshipsQuery, newXID); err != nil {
+			return err
+		}
+
+		if pgd.partitioning.Strategy == migrations.CreatedXIDRangePartitioning {
+			return checkLivingRelationshipsUnique(ctx, tx, pgd.tenant, newXID)
+		}
+		return nil
+	})
+	if err != nil {
+		// If a unique constraint violation is returned, then its likely that the cause
+		// was an existing relationship.
+		if cerr := pgxcommon.ConvertToWriteConstraintError(livingTupleConstraint, err); cerr != nil {
+			return datastore.NoRevision, cerr
+		}
+
+		return datastore.NoRevision, fmt.Errorf(errUnableToWriteRelationships, err)
+	}
+
+	return postgresRevision{newXID, newXmin}, nil
+}
+
+// copySource adapts a BulkWriteRelationshipSource to the pgx.CopyFromSource interface.
+type copySource struct {
+	ctx    context.Context
+	source datastore.BulkWriteRelationshipSource
+	tenant string
+
+	current *core.RelationTuple
+	err     error
+}
+
+func (cs *copySource) Next() bool {
+	cs.current, cs.err = cs.source.Next(cs.ctx)
+	return cs.current != nil
+}
+
+func (cs *copySource) Values() ([]any, error) {
+	var caveatName string
+	var caveatContext map[string]any
+	if cs.current.Caveat != nil {
+		caveatName = cs.current.Caveat.CaveatName
+		caveatContext = cs.current.Caveat.Context.AsMap()
+	}
+
+	return []any{
+		cs.current.ResourceAndRelation.Namespace,
+		cs.current.ResourceAndRelation.ObjectId,
+		cs.current.ResourceAndRelation.Relation,
+		cs.current.Subject.Namespace,
+		cs.current.Subject.ObjectId,
+		cs.current.Subject.Relation,
+		caveatName,
+		caveatContext,
+		common.ExpirationTimeOf(cs.current),
+		cs.current.OptionalMetadata,
+		cs.tenant,
+	}, nil
+}
+
+func (cs *copySource) Err() error {
+	return cs.err
+}
+
+var _ pgx.CopyFromSource = (*copySource)(nil)
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 
@@ -13,8 +15,8 @@ import (
 )
 
 var (
-	writeCaveat  = psql.Insert(tableCaveat).Columns(colCaveatName, colCaveatDefinition)
-	listCaveat   = psql.Select(colCaveatDefinition).From(tableCaveat).OrderBy(colCaveatName)
+	writeCaveat  = psql.Insert(tableCaveat).Columns(colCaveatName, colCaveatDefinition, colTenant)
+	listCaveat   = psql.Select(colCaveatDefinition).From(tableCaveat)
 	readCaveat   = psql.Select(colCaveatDefinition, colCreatedXid).From(tableCaveat)
 	deleteCaveat = psql.Update(tableCaveat).Where(sq.Eq{colDeletedXid: liveDeletedTxnID})
 )
@@ -58,14 +60,20 @@ func (r *pgReader) ReadCaveatByName(ctx context.Context, name string) (*core.Cav
 	return &def, rev, nil
 }
 
-func (r *pgReader) ListCaveats(ctx context.Context, caveatNames ...string) ([]*core.CaveatDefinition, error) {
-	caveatsWithNames := listCaveat
-	if len(caveatNames) > 0 {
-		caveatsWithNames = caveatsWithNames.Where(sq.Eq{colCaveatName: caveatNames})
+func (r *pgReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	return r.loadCaveats(ctx, common.ListDefinitionsPage(r.filterer(listCaveat), colCaveatName, opts...))
+}
+
+func (r *pgReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	if len(caveatNames) == 0 {
+		return nil, nil
 	}
 
-	filteredListCaveat := r.filterer(caveatsWithNames)
-	sql, args, err := filteredListCaveat.ToSql()
+	return r.loadCaveats(ctx, r.filterer(listCaveat).Where(sq.Eq{colCaveatName: caveatNames}))
+}
+
+func (r *pgReader) loadCaveats(ctx context.Context, query sq.SelectBuilder) ([]*core.CaveatDefinition, error) {
+	sql, args, err := query.ToSql()
 	if err != nil {
 		return nil, fmt.Errorf(errListCaveats, err)
 	}
@@ -114,7 +122,7 @@ func (rwt *pgReadWriteTXN) WriteCaveats(ctx context.Context, caveats []*core.Cav
 		if err != nil {
 			return fmt.Errorf(errWriteCaveats, err)
 		}
-		valuesToWrite := []any{caveat.Name, definitionBytes}
+		valuesToWrite := []any{caveat.Name, definitionBytes, rwt.tenant}
 		write = write.Values(valuesToWrite...)
 		writtenCaveatNames = append(writtenCaveatNames, caveat.Name)
 	}
@@ -144,7 +152,7 @@ func (rwt *pgReadWriteTXN) DeleteCaveats(ctx context.Context, names []string) er
 func (rwt *pgReadWriteTXN) deleteCaveatsFromNames(ctx context.Context, names []string) error {
 	sql, args, err := deleteCaveat.
 		Set(colDeletedXid, rwt.newXID).
-		Where(sq.Eq{colCaveatName: names}).
+		Where(sq.Eq{colTenant: rwt.tenant, colCaveatName: names}).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf(errDeleteCaveats, err)
@@ -0,0 +1,195 @@
+package common
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/rds/rdsutils"
+	"github.com/jackc/pgconn"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// AuthMethodPassword authenticates with the password of the connection string, if any.
+	AuthMethodPassword = "password"
+
+	// AuthMethodAWSIAM authenticates with an AWS RDS IAM authentication token, signed with the
+	// default credentials of the AWS SDK.
+	AuthMethodAWSIAM = "aws-iam"
+
+	// AuthMethodGCPIAM authenticates with an OAuth2 access token of the application default
+	// credentials of Google Cloud, as required for Cloud SQL IAM database authentication.
+	AuthMethodGCPIAM = "gcp-iam"
+
+	gcpSQLLoginScope = "https://www.googleapis.com/auth/sqlservice.login"
+)
+
+// AuthMethods are the supported values of Credentials.AuthMethod.
+var AuthMethods = map[string]struct{}{
+	"":                 {},
+	AuthMethodPassword: {},
+	AuthMethodAWSIAM:   {},
+	AuthMethodGCPIAM:   {},
+}
+
+// Credentials are the means by which connections authenticate to the database, beyond those of
+// the connection string.
+type Credentials struct {
+	// AuthMethod is the method by which the password of each connection is found.
+	AuthMethod string
+
+	// AWSRegion is the region of the RDS instance signed into AWS IAM authentication tokens,
+	// defaulting to that of the AWS SDK configuration.
+	AWSRegion string
+
+	// ClientCertPath and ClientKeyPath are the PEM encoded certificate and key presented to the
+	// server for mutual TLS.
+	ClientCertPath string
+	ClientKeyPath  string
+
+	// RootCertPath is the PEM encoded certificate authority which must have signed the certificate
+	// of the server.
+	RootCertPath string
+}
+
+// PasswordFunc sets the password of the config of a connection about to be opened. Tokens with a
+// limited lifetime are refreshed as needed, so it must be called before each connection.
+type PasswordFunc func(ctx context.Context, config *pgconn.Config) error
+
+// NewPasswordFunc returns the function which sets the password of each new connection.
+func (c Credentials) NewPasswordFunc() (PasswordFunc, error) {
+	switch c.AuthMethod {
+	case "", AuthMethodPassword:
+		return keepPassword, nil
+	case AuthMethodAWSIAM:
+		return c.awsIAMPassword()
+	case AuthMethodGCPIAM:
+		return gcpIAMPassword()
+	default:
+		return nil, fmt.Errorf("unknown authentication method %q", c.AuthMethod)
+	}
+}
+
+// ConfigureTLS sets the TLS certificates of the credentials on the connection config and each of
+// its fallbacks.
+func (c Credentials) ConfigureTLS(config *pgconn.Config) error {
+	if c.ClientCertPath == "" && c.ClientKeyPath == "" && c.RootCertPath == "" {
+		return nil
+	}
+	if (c.ClientCertPath == "") != (c.ClientKeyPath == "") {
+		return errors.New("a client certificate and key must be configured together")
+	}
+
+	var certificates []tls.Certificate
+	if c.ClientCertPath != "" {
+		certificate, err := tls.LoadX509KeyPair(c.ClientCertPath, c.ClientKeyPath)
+		if err != nil {
+			return fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		certificates = append(certificates, certificate)
+	}
+
+	var rootCAs *x509.CertPool
+	if c.RootCertPath != "" {
+		pem, err := os.ReadFile(c.RootCertPath)
+		if err != nil {
+			return fmt.Errorf("unable to read root certificate: %w", err)
+		}
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in root certificate %s", c.RootCertPath)
+		}
+	}
+
+	configured := false
+	configure := func(tlsConfig *tls.Config) {
+		if tlsConfig == nil {
+			return
+		}
+		if certificates != nil {
+			tlsConfig.Certificates = certificates
+		}
+		if rootCAs != nil {
+			tlsConfig.RootCAs = rootCAs
+		}
+		configured = true
+	}
+
+	configure(config.TLSConfig)
+	for _, fallback := range config.Fallbacks {
+		configure(fallback.TLSConfig)
+	}
+	if !configured {
+		return errors.New("TLS certificates cannot be used with sslmode=disable")
+	}
+	return nil
+}
+
+func keepPassword(context.Context, *pgconn.Config) error {
+	return nil
+}
+
+// awsIAMPassword signs an authentication token for the host, port and user of each connection.
+// Tokens are signed locally and are valid for 15 minutes, so a new one is signed for every
+// connection.
+func (c Credentials) awsIAMPassword() (PasswordFunc, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load AWS configuration: %w", err)
+	}
+
+	region := c.AWSRegion
+	if region == "" && sess.Config.Region != nil {
+		region = *sess.Config.Region
+	}
+	if region == "" {
+		return nil, errors.New("an AWS region must be configured for AWS IAM authentication")
+	}
+
+	return awsIAMPasswordFunc(region, sess.Config.Credentials), nil
+}
+
+func awsIAMPasswordFunc(region string, creds *credentials.Credentials) PasswordFunc {
+	return func(_ context.Context, config *pgconn.Config) error {
+		endpoint := net.JoinHostPort(config.Host, strconv.Itoa(int(config.Port)))
+		token, err := rdsutils.BuildAuthToken(endpoint, region, config.User, creds)
+		if err != nil {
+			return fmt.Errorf("unable to build AWS IAM authentication token: %w", err)
+		}
+		config.Password = token
+		return nil
+	}
+}
+
+// gcpIAMPassword uses an access token of the application default credentials, which is reused
+// until shortly before it expires.
+func gcpIAMPassword() (PasswordFunc, error) {
+	// The token source refreshes tokens with the context it is created with, so it must outlive
+	// the datastore.
+	tokens, err := google.DefaultTokenSource(context.Background(), gcpSQLLoginScope)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load Google Cloud credentials: %w", err)
+	}
+
+	return tokenSourcePasswordFunc(tokens), nil
+}
+
+func tokenSourcePasswordFunc(tokens oauth2.TokenSource) PasswordFunc {
+	return func(_ context.Context, config *pgconn.Config) error {
+		token, err := tokens.Token()
+		if err != nil {
+			return fmt.Errorf("unable to fetch Google Cloud access token: %w", err)
+		}
+		config.Password = token.AccessToken
+		return nil
+	}
+}
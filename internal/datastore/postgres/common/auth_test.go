@@ -0,0 +1,46 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestAWSIAMPassword(t *testing.T) {
+	config, err := pgconn.ParseConfig("postgres://spicedb@example.us-east-1.rds.amazonaws.com:5432/spicedb")
+	require.NoError(t, err)
+
+	password := awsIAMPasswordFunc("us-east-1", credentials.NewStaticCredentials("AKIDEXAMPLE", "secret", ""))
+	require.NoError(t, password(context.Background(), config))
+	require.True(t, strings.HasPrefix(config.Password, "example.us-east-1.rds.amazonaws.com:5432?Action=connect&DBUser=spicedb"), config.Password)
+	require.Contains(t, config.Password, "X-Amz-Signature=")
+}
+
+func TestGCPIAMPassword(t *testing.T) {
+	config, err := pgconn.ParseConfig("postgres://spicedb@localhost:5432/spicedb")
+	require.NoError(t, err)
+
+	password := tokenSourcePasswordFunc(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}))
+	require.NoError(t, password(context.Background(), config))
+	require.Equal(t, "token", config.Password)
+}
+
+func TestConfigureTLSErrors(t *testing.T) {
+	config, err := pgconn.ParseConfig("postgres://localhost/spicedb?sslmode=disable")
+	require.NoError(t, err)
+	require.NoError(t, Credentials{}.ConfigureTLS(config))
+
+	err = Credentials{ClientCertPath: "client.crt"}.ConfigureTLS(config)
+	require.ErrorContains(t, err, "certificate and key")
+
+	err = Credentials{RootCertPath: "missing.crt"}.ConfigureTLS(config)
+	require.ErrorContains(t, err, "unable to read root certificate")
+
+	_, err = Credentials{AuthMethod: "kerberos"}.NewPasswordFunc()
+	require.Error(t, err)
+}
@@ -15,7 +15,9 @@ const (
 	pgUniqueConstraintViolation = "23505"
 )
 
-var createConflictDetailsRegex = regexp.MustCompile(`^Key (.+)=\(([^,]+),([^,]+),([^,]+),([^,]+),([^,]+),([^,]+),([^,]+)\) already exists`)
+// createConflictDetailsRegex matches the details of a conflict on the living tuple constraint,
+// skipping the leading tenant of the relationship if the constraint is scoped to tenants.
+var createConflictDetailsRegex = regexp.MustCompile(`^Key (.+)=\((?:[^,]*,)?([^,]+),([^,]+),([^,]+),([^,]+),([^,]+),([^,]+),([^,]+)\) already exists`)
 
 // ConvertToWriteConstraintError converts the given Postgres error into a CreateRelationshipExistsError
 // if applicable. If not applicable, returns nils.
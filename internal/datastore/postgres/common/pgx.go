@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/logging"
@@ -53,6 +54,7 @@ func queryTuples(ctx context.Context, sqlStatement string, args []any, span trac
 		}
 		var caveatName sql.NullString
 		var caveatCtx map[string]any
+		var expiration sql.NullTime
 		err := rows.Scan(
 			&nextTuple.ResourceAndRelation.Namespace,
 			&nextTuple.ResourceAndRelation.ObjectId,
@@ -62,6 +64,8 @@ func queryTuples(ctx context.Context, sqlStatement string, args []any, span trac
 			&nextTuple.Subject.Relation,
 			&caveatName,
 			&caveatCtx,
+			&expiration,
+			&nextTuple.OptionalMetadata,
 		)
 		if err != nil {
 			return nil, fmt.Errorf(errUnableToQueryTuples, err)
@@ -71,6 +75,7 @@ func queryTuples(ctx context.Context, sqlStatement string, args []any, span trac
 		if err != nil {
 			return nil, fmt.Errorf("unable to fetch caveat context: %w", err)
 		}
+		nextTuple.OptionalExpirationTime = common.ExpirationFrom(expiration)
 		tuples = append(tuples, nextTuple)
 	}
 	if err := rows.Err(); err != nil {
@@ -104,6 +109,30 @@ func ConfigurePGXLogger(connConfig *pgx.ConnConfig) {
 	connConfig.Logger = levelMappingFn(l)
 }
 
+// SetLocalTimeouts sets the statement and lock timeouts of the transaction with SET LOCAL, so
+// that they are reset as it ends. The timeouts of the session are kept for either timeout which is
+// zero.
+func SetLocalTimeouts(ctx context.Context, tx pgx.Tx, statementTimeout, lockTimeout time.Duration) error {
+	for setting, timeout := range map[string]time.Duration{
+		"statement_timeout": statementTimeout,
+		"lock_timeout":      lockTimeout,
+	} {
+		if timeout <= 0 {
+			continue
+		}
+
+		// A timeout of zero milliseconds would disable the timeout instead.
+		millis := timeout.Milliseconds()
+		if millis < 1 {
+			millis = 1
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL %s = %d", setting, millis)); err != nil {
+			return fmt.Errorf("unable to set %s: %w", setting, err)
+		}
+	}
+	return nil
+}
+
 // TxFactory returns a transaction, cleanup function, and any errors that may have
 // occurred when building the transaction.
 type TxFactory func(context.Context) (pgx.Tx, common.TxCleanupFunc, error)
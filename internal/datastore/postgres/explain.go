@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
+	log "github.com/authzed/spicedb/internal/logging"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	explainQuery        = "EXPLAIN %s"
+	explainAnalyzeQuery = "EXPLAIN (ANALYZE, BUFFERS) %s"
+
+	// explainTimeout bounds the time spent explaining a slow query, which is not bound by the
+	// deadline of the request that made it.
+	explainTimeout = 30 * time.Second
+)
+
+var slowQueriesCounter = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "spicedb",
+	Subsystem: "datastore",
+	Name:      "postgres_slow_queries_total",
+	Help:      "number of relationship queries which exceeded the slow query threshold.",
+})
+
+func init() {
+	prometheus.MustRegister(slowQueriesCounter)
+}
+
+// explainSlowQueries wraps the executor of relationship queries, logging the plan of each query
+// which takes at least the slow query threshold. The plan is explained in a new transaction from
+// the same source, which reads the same snapshot as the slow query.
+func (pgd *pgDatastore) explainSlowQueries(txSource pgxcommon.TxFactory, executor common.ExecuteQueryFunc) common.ExecuteQueryFunc {
+	if pgd.slowQueryThreshold <= 0 {
+		return executor
+	}
+
+	return func(ctx context.Context, sql string, args []any) ([]*core.RelationTuple, error) {
+		start := time.Now()
+		tuples, err := executor(ctx, sql, args)
+		duration := time.Since(start)
+		if err != nil || duration < pgd.slowQueryThreshold {
+			return tuples, err
+		}
+
+		slowQueriesCounter.Inc()
+
+		plan, explainErr := pgd.explain(ctx, txSource, sql, args)
+		if explainErr != nil {
+			log.Ctx(ctx).Warn().Err(explainErr).Str("sql", sql).Dur("duration", duration).Msg("slow datastore query could not be explained")
+			return tuples, err
+		}
+
+		trace.SpanFromContext(ctx).AddEvent("Slow query explained", trace.WithAttributes(
+			attribute.String("sql", sql),
+			attribute.Int64("durationMs", duration.Milliseconds()),
+			attribute.String("plan", plan),
+		))
+		log.Ctx(ctx).Warn().
+			Str("sql", sql).
+			Dur("duration", duration).
+			Int("tupleCount", len(tuples)).
+			Str("plan", plan).
+			Msg("slow datastore query")
+
+		return tuples, err
+	}
+}
+
+// explain returns the plan of the query, re-running it with EXPLAIN (ANALYZE, BUFFERS) if
+// configured.
+func (pgd *pgDatastore) explain(ctx context.Context, txSource pgxcommon.TxFactory, sql string, args []any) (string, error) {
+	explainCtx, cancel := context.WithTimeout(trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx)), explainTimeout)
+	defer cancel()
+
+	tx, txCleanup, err := txSource(explainCtx)
+	if err != nil {
+		return "", err
+	}
+	defer txCleanup(explainCtx)
+
+	query := explainQuery
+	if pgd.slowQueryExplainAnalyze {
+		query = explainAnalyzeQuery
+	}
+
+	rows, err := tx.Query(explainCtx, fmt.Sprintf(query, sql), args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		plan = append(plan, line)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	return strings.Join(plan, "\n"), nil
+}
@@ -8,6 +8,8 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
 	log "github.com/authzed/spicedb/internal/logging"
@@ -15,7 +17,8 @@ import (
 )
 
 var (
-	_ common.GarbageCollector = (*pgDatastore)(nil)
+	_ common.GarbageCollector               = (*pgDatastore)(nil)
+	_ datastore.GarbageCollectableDatastore = (*pgDatastore)(nil)
 
 	relationTuplePKCols = []string{
 		colNamespace,
@@ -28,11 +31,38 @@ var (
 		colDeletedXid,
 	}
 
-	namespacePKCols = []string{colNamespace, colCreatedXid, colDeletedXid}
-
 	transactionPKCols = []string{colXID}
+
+	gcBatchDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "spicedb",
+		Subsystem: "datastore",
+		Name:      "postgres_gc_batch_duration_seconds",
+		Help:      "The duration of each batch of deletes of the postgres garbage collection, by table.",
+		Buckets:   []float64{0.001, 0.01, 0.05, 0.1, 0.5, 1, 5, 10},
+	}, []string{"table"})
+
+	gcBacklogRowsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "spicedb",
+		Subsystem: "datastore",
+		Name:      "postgres_gc_backlog_rows",
+		Help:      "The number of stale rows remaining to be deleted by the postgres garbage collection, by table, counted up to 100000.",
+	}, []string{"table"})
+
+	gcLastSuccessGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "spicedb",
+		Subsystem: "datastore",
+		Name:      "postgres_gc_last_success_timestamp_seconds",
+		Help:      "The time at which the last postgres garbage collection pass completed successfully.",
+	})
 )
 
+// gcBacklogCountLimit is the most stale rows of a table counted for the backlog metric.
+const gcBacklogCountLimit = 100000
+
+func init() {
+	prometheus.MustRegister(gcBatchDurationHistogram, gcBacklogRowsGauge, gcLastSuccessGauge)
+}
+
 func (pgd *pgDatastore) Now(ctx context.Context) (time.Time, error) {
 	// Retrieve the `now` time from the database.
 	nowSQL, nowArgs, err := getNow.ToSql()
@@ -41,7 +71,7 @@ func (pgd *pgDatastore) Now(ctx context.Context) (time.Time, error) {
 	}
 
 	var now time.Time
-	err = pgd.dbpool.QueryRow(ctx, nowSQL, nowArgs...).Scan(&now)
+	err = pgd.writePool.QueryRow(ctx, nowSQL, nowArgs...).Scan(&now)
 	if err != nil {
 		return time.Time{}, err
 	}
@@ -59,7 +89,7 @@ func (pgd *pgDatastore) TxIDBefore(ctx context.Context, before time.Time) (datas
 	}
 
 	var value, xmin xid8
-	err = pgd.dbpool.QueryRow(ctx, sql, args...).Scan(&value, &xmin)
+	err = pgd.writePool.QueryRow(ctx, sql, args...).Scan(&value, &xmin)
 	if err != nil {
 		return datastore.NoRevision, err
 	}
@@ -72,6 +102,25 @@ func (pgd *pgDatastore) TxIDBefore(ctx context.Context, before time.Time) (datas
 	return postgresRevision{value, xmin}, nil
 }
 
+func (pgd *pgDatastore) CountTransactionsBeforeTx(ctx context.Context, txID datastore.Revision) (int64, error) {
+	revision := txID.(postgresRevision)
+
+	sql, args, err := psql.Select("COUNT(*)").From(tableTransaction).Where(sq.Lt{colXID: revision.tx}).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = pgd.writePool.QueryRow(ctx, sql, args...).Scan(&count)
+	return count, err
+}
+
+// CollectGarbage runs garbage collection immediately, regardless of whether the background
+// garbage collection is enabled.
+func (pgd *pgDatastore) CollectGarbage(ctx context.Context) (datastore.GarbageCollected, error) {
+	return common.RunGarbageCollection(ctx, pgd, pgd.gcWindow, pgd.gcTimeout)
+}
+
 func (pgd *pgDatastore) DeleteBeforeTx(ctx context.Context, txID datastore.Revision) (removed common.DeletionCounts, err error) {
 	revision := txID.(postgresRevision)
 
@@ -80,12 +129,21 @@ func (pgd *pgDatastore) DeleteBeforeTx(ctx context.Context, txID datastore.Revis
 		minTxAlive = revision.xmin
 	}
 
-	// Delete any relationship rows that were already dead when this transaction started
+	// Partitions for the upcoming transactions are created as part of each pass.
+	if err = pgd.ensurePartitions(ctx); err != nil {
+		return
+	}
+
+	// Delete any relationship rows that were already dead when this transaction started, or
+	// which have expired
 	removed.Relationships, err = pgd.batchDelete(
 		ctx,
 		tableTuple,
 		relationTuplePKCols,
-		sq.Lt{colDeletedXid: minTxAlive},
+		sq.Or{
+			sq.Lt{colDeletedXid: minTxAlive},
+			sq.Expr(colExpiration + " < NOW()"),
+		},
 	)
 	if err != nil {
 		return
@@ -105,27 +163,21 @@ func (pgd *pgDatastore) DeleteBeforeTx(ctx context.Context, txID datastore.Revis
 		return
 	}
 
-	// Delete any namespace rows with deleted_transaction <= the transaction ID.
-	removed.Namespaces, err = pgd.batchDelete(
-		ctx,
-		tableNamespace,
-		namespacePKCols,
-		sq.Lt{colDeletedXid: minTxAlive},
-	)
-	if err != nil {
-		return
-	}
-
+	// Namespace and caveat definitions are not deleted, as their prior versions are retained
+	// for the schema history.
+	gcLastSuccessGauge.SetToCurrentTime()
 	return
 }
 
+// batchDelete deletes the rows matching the filter in batches of at most the garbage collection
+// batch size, each in its own transaction, waiting for the batch delay after each full batch.
 func (pgd *pgDatastore) batchDelete(
 	ctx context.Context,
 	tableName string,
 	pkCols []string,
 	filter sqlFilter,
 ) (int64, error) {
-	sql, args, err := psql.Select(pkCols...).From(tableName).Where(filter).Limit(batchDeleteSize).ToSql()
+	sql, args, err := psql.Select(pkCols...).From(tableName).Where(filter).Limit(pgd.gcBatchSize).ToSql()
 	if err != nil {
 		return -1, err
 	}
@@ -137,19 +189,75 @@ func (pgd *pgDatastore) batchDelete(
 		  WHERE (%[3]s) IN (SELECT %[3]s FROM rows);
 	`, sql, tableName, pkColsExpression)
 
+	backlog, err := pgd.countBacklog(ctx, tableName, filter)
+	if err != nil {
+		return -1, err
+	}
+	backlogGauge := gcBacklogRowsGauge.WithLabelValues(tableName)
+	backlogGauge.Set(float64(backlog))
+
 	var deletedCount int64
 	for {
-		cr, err := pgd.dbpool.Exec(ctx, query, args...)
-		if err != nil {
+		var rowsDeleted int64
+		start := time.Now()
+		if err := pgd.writePool.BeginFunc(ctx, func(tx pgx.Tx) error {
+			if err := pgd.gcTimeouts.setLocal(ctx, tx); err != nil {
+				return err
+			}
+
+			cr, err := tx.Exec(ctx, query, args...)
+			rowsDeleted = cr.RowsAffected()
+			return err
+		}); err != nil {
 			return deletedCount, err
 		}
+		gcBatchDurationHistogram.WithLabelValues(tableName).Observe(time.Since(start).Seconds())
 
-		rowsDeleted := cr.RowsAffected()
 		deletedCount += rowsDeleted
-		if rowsDeleted < batchDeleteSize {
+		if uint64(rowsDeleted) < pgd.gcBatchSize {
+			backlogGauge.Set(0)
 			break
 		}
+
+		// The backlog is only counted up to a limit, so once the counted rows have been deleted
+		// the gauge keeps its last value until a short batch shows that none remain.
+		backlog -= rowsDeleted
+		if backlog > 0 {
+			backlogGauge.Set(float64(backlog))
+		}
+
+		if pgd.gcBatchDelay > 0 {
+			delay := time.NewTimer(pgd.gcBatchDelay)
+			select {
+			case <-delay.C:
+			case <-ctx.Done():
+				delay.Stop()
+				return deletedCount, ctx.Err()
+			}
+		}
 	}
 
 	return deletedCount, nil
 }
+
+// countBacklog counts the rows matching the filter, up to the backlog count limit, so that
+// counting a large backlog does not itself scan the whole table.
+func (pgd *pgDatastore) countBacklog(ctx context.Context, tableName string, filter sqlFilter) (int64, error) {
+	sql, args, err := psql.Select("1").From(tableName).Where(filter).Limit(gcBacklogCountLimit).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var backlog int64
+	err = pgd.writePool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if err := pgd.gcTimeouts.setLocal(ctx, tx); err != nil {
+			return err
+		}
+		return tx.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM (%s) AS backlog", sql), args...).Scan(&backlog)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to count garbage collection backlog: %w", err)
+	}
+
+	return backlog, nil
+}
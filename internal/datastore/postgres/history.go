@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/authzed/spicedb/internal/namespace"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+var (
+	_ datastore.SchemaHistoryDatastore        = (*pgDatastore)(nil)
+	_ datastore.NamespaceDiffHistoryDatastore = (*pgDatastore)(nil)
+)
+
+const (
+	tableNamespaceDiff = "namespace_config_diff"
+	colDeltas          = "deltas"
+)
+
+var (
+	readNamespaceHistory = psql.Select(colConfig, colCreatedXid, colDeletedXid, colCreatedAt).
+				From(tableNamespace).
+				OrderBy(colCreatedXid)
+
+	readCaveatHistory = psql.Select(colCaveatDefinition, colCreatedXid, colDeletedXid, colCreatedAt).
+				From(tableCaveat).
+				OrderBy(colCreatedXid)
+
+	readNamespaceDiffHistory = psql.Select(colDeltas, colCreatedXid, colCreatedAt).
+					From(tableNamespaceDiff).
+					OrderBy(colCreatedXid)
+
+	writeNamespaceDiff = psql.Insert(tableNamespaceDiff).Columns(colNamespace, colTenant, colCreatedXid, colDeltas)
+)
+
+const errUnableToReadHistory = "unable to read schema history: %w"
+
+// storedNamespaceDelta is a namespace delta as it is stored in the deltas column, which can be
+// queried as JSON.
+type storedNamespaceDelta struct {
+	Type         string          `json:"type"`
+	RelationName string          `json:"relation,omitempty"`
+	AllowedType  json.RawMessage `json:"allowed_type,omitempty"`
+}
+
+// NamespaceHistory returns every version of the namespace definition which has been written.
+func (pgd *pgDatastore) NamespaceHistory(ctx context.Context, nsName string) ([]datastore.DefinitionVersion[*core.NamespaceDefinition], error) {
+	return readHistory(ctx, pgd, readNamespaceHistory.Where(sq.Eq{colTenant: pgd.tenant, colNamespace: nsName}), func() *core.NamespaceDefinition {
+		return &core.NamespaceDefinition{}
+	})
+}
+
+// CaveatHistory returns every version of the caveat definition which has been written.
+func (pgd *pgDatastore) CaveatHistory(ctx context.Context, name string) ([]datastore.DefinitionVersion[*core.CaveatDefinition], error) {
+	return readHistory(ctx, pgd, readCaveatHistory.Where(sq.Eq{colTenant: pgd.tenant, colCaveatName: name}), func() *core.CaveatDefinition {
+		return &core.CaveatDefinition{}
+	})
+}
+
+func readHistory[T interface{ UnmarshalVT([]byte) error }](
+	ctx context.Context,
+	pgd *pgDatastore,
+	query sq.SelectBuilder,
+	newDefinition func() T,
+) ([]datastore.DefinitionVersion[T], error) {
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadHistory, err)
+	}
+
+	rows, err := pgd.readPool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadHistory, err)
+	}
+	defer rows.Close()
+
+	var versions []datastore.DefinitionVersion[T]
+	var lastDeletedXid xid8
+	for rows.Next() {
+		var serialized []byte
+		var createdXid, deletedXid xid8
+		var createdAt *time.Time
+		if err := rows.Scan(&serialized, &createdXid, &deletedXid, &createdAt); err != nil {
+			return nil, fmt.Errorf(errUnableToReadHistory, err)
+		}
+
+		definition := newDefinition()
+		if err := definition.UnmarshalVT(serialized); err != nil {
+			return nil, fmt.Errorf(errUnableToReadHistory, err)
+		}
+
+		version := datastore.DefinitionVersion[T]{
+			Definition: definition,
+			Revision:   postgresRevision{createdXid, noXmin},
+		}
+		if createdAt != nil {
+			version.WrittenAt = createdAt.UTC()
+		}
+
+		// A version which was replaced is deleted in the same transaction which wrote its
+		// replacement.
+		if len(versions) > 0 && lastDeletedXid.Uint != createdXid.Uint {
+			versions[len(versions)-1].Deleted = true
+		}
+
+		versions = append(versions, version)
+		lastDeletedXid = deletedXid
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf(errUnableToReadHistory, rows.Err())
+	}
+
+	if len(versions) > 0 && lastDeletedXid.Uint != liveDeletedTxnID {
+		versions[len(versions)-1].Deleted = true
+	}
+
+	return versions, nil
+}
+
+// NamespaceDiffHistory returns the changes made by each replacement of the namespace definition.
+func (pgd *pgDatastore) NamespaceDiffHistory(ctx context.Context, nsName string) ([]datastore.NamespaceDiff, error) {
+	sql, args, err := readNamespaceDiffHistory.Where(sq.Eq{colTenant: pgd.tenant, colNamespace: nsName}).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadHistory, err)
+	}
+
+	rows, err := pgd.readPool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToReadHistory, err)
+	}
+	defer rows.Close()
+
+	var diffs []datastore.NamespaceDiff
+	for rows.Next() {
+		var stored []storedNamespaceDelta
+		var createdXid xid8
+		var createdAt time.Time
+		if err := rows.Scan(&stored, &createdXid, &createdAt); err != nil {
+			return nil, fmt.Errorf(errUnableToReadHistory, err)
+		}
+
+		diff := datastore.NamespaceDiff{
+			Revision:  postgresRevision{createdXid, noXmin},
+			WrittenAt: createdAt.UTC(),
+			Deltas:    make([]datastore.NamespaceDelta, 0, len(stored)),
+		}
+		for _, delta := range stored {
+			decoded := datastore.NamespaceDelta{Type: delta.Type, RelationName: delta.RelationName}
+			if len(delta.AllowedType) > 0 {
+				decoded.AllowedType = &core.AllowedRelation{}
+				if err := protojson.Unmarshal(delta.AllowedType, decoded.AllowedType); err != nil {
+					return nil, fmt.Errorf(errUnableToReadHistory, err)
+				}
+			}
+			diff.Deltas = append(diff.Deltas, decoded)
+		}
+		diffs = append(diffs, diff)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf(errUnableToReadHistory, rows.Err())
+	}
+
+	return diffs, nil
+}
+
+// writeNamespaceDiffs records the changes made by each of the namespace definitions which
+// replaces a living definition. A replacement which makes no changes is not recorded.
+func (rwt *pgReadWriteTXN) writeNamespaceDiffs(ctx context.Context, newConfigs []*core.NamespaceDefinition) error {
+	names := make([]string, 0, len(newConfigs))
+	for _, newNamespace := range newConfigs {
+		names = append(names, newNamespace.Name)
+	}
+
+	existing, err := loadAllNamespaces(ctx, rwt.tx, func(original sq.SelectBuilder) sq.SelectBuilder {
+		return filterToTenant(rwt.tenant, currentlyLivingObjects)(original).Where(sq.Eq{colNamespace: names})
+	})
+	if err != nil {
+		return err
+	}
+
+	existingByName := make(map[string]*core.NamespaceDefinition, len(existing))
+	for _, loaded := range existing {
+		existingByName[loaded.nsDef.Name] = loaded.nsDef
+	}
+
+	writeQuery := writeNamespaceDiff
+	hasDiffs := false
+	for _, newNamespace := range newConfigs {
+		existingNamespace, ok := existingByName[newNamespace.Name]
+		if !ok {
+			continue
+		}
+
+		diff, err := namespace.DiffNamespaces(existingNamespace, newNamespace)
+		if err != nil {
+			return err
+		}
+		if len(diff.Deltas()) == 0 {
+			continue
+		}
+
+		stored := make([]storedNamespaceDelta, 0, len(diff.Deltas()))
+		for _, delta := range diff.Deltas() {
+			storedDelta := storedNamespaceDelta{Type: string(delta.Type), RelationName: delta.RelationName}
+			if delta.AllowedType != nil {
+				storedDelta.AllowedType, err = protojson.Marshal(delta.AllowedType)
+				if err != nil {
+					return err
+				}
+			}
+			stored = append(stored, storedDelta)
+		}
+
+		writeQuery = writeQuery.Values(newNamespace.Name, rwt.tenant, rwt.newXID, stored)
+		hasDiffs = true
+	}
+
+	if !hasDiffs {
+		return nil
+	}
+
+	sql, args, err := writeQuery.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = rwt.tx.Exec(ctx, sql, args...)
+	return err
+}
@@ -0,0 +1,533 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgtype"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/secrets"
+	"github.com/authzed/spicedb/pkg/util"
+)
+
+const (
+	// watchPublication is the publication of the relationship and transaction tables, created by
+	// the add-watch-publication migration.
+	watchPublication = "spicedb_watch"
+
+	// replicationReceiveTimeout is the longest a logical replication watch waits for a message
+	// before checking whether a checkpoint or status update is due.
+	replicationReceiveTimeout = time.Second
+
+	// replicationStatusInterval is the interval at which a logical replication watch reports
+	// the position up to which it has consumed the stream, which allows the server to recycle
+	// the WAL before it.
+	replicationStatusInterval = 10 * time.Second
+
+	replicationXLogData       = 'w'
+	replicationKeepalive      = 'k'
+	replicationStatusUpdate   = 'r'
+	replicationXLogDataHeader = 24
+)
+
+// committedRevisionsQuery loads the transactions committed after the given one. Unlike
+// newRevisionsQuery, it includes the transactions which committed while earlier transactions
+// are still running, as a logical replication watch streams transactions in commit order.
+var committedRevisionsQuery = fmt.Sprintf(`
+	SELECT %[1]s, %[3]s, %[4]s from %[2]s
+	WHERE pg_xact_commit_timestamp(%[1]s::xid) > (
+		SELECT pg_xact_commit_timestamp(%[1]s::xid) FROM relation_tuple_transaction where %[1]s = $1
+	)
+	ORDER BY pg_xact_commit_timestamp(%[1]s::xid);
+`, colXID, tableTransaction, colMetadata, colTenant)
+
+// postgresEpoch is the epoch of the timestamps of the replication protocol.
+var postgresEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// watchLogicalReplication streams the changes committed after the revision from a temporary
+// logical replication slot, decoding them with the pgoutput plugin.
+//
+// The transactions committed before the slot was created are not streamed, so they are first
+// loaded from the tables. A transaction which is both loaded and streamed is only sent once.
+func (pgd *pgDatastore) watchLogicalReplication(
+	ctx context.Context,
+	afterRevision postgresRevision,
+	updates chan<- *datastore.RevisionChanges,
+	errs chan<- error,
+) {
+	sendError := func(err error) {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			errs <- datastore.NewWatchCanceledErr()
+		} else {
+			errs <- err
+		}
+	}
+
+	stream, err := pgd.createReplicationStream(ctx)
+	if err != nil {
+		sendError(err)
+		return
+	}
+	defer stream.close()
+
+	currentTxn := afterRevision.tx
+	lastSent := time.Now()
+	send := func(changes *datastore.RevisionChanges) bool {
+		select {
+		case updates <- changes:
+			lastSent = time.Now()
+			return true
+		default:
+			errs <- datastore.NewWatchDisconnectedErr()
+			return false
+		}
+	}
+
+	loaded, err := pgd.getCommittedRevisions(ctx, currentTxn)
+	if err != nil {
+		sendError(err)
+		return
+	}
+
+	// Transactions which have already been loaded advance the watch only once, so that it never
+	// moves back to an earlier revision.
+	loadedXIDs := util.NewSet[uint64]()
+	for _, txn := range loaded {
+		loadedXIDs.Add(txn.xid.Uint)
+		currentTxn = txn.xid
+		if txn.tenant != pgd.tenant {
+			continue
+		}
+
+		changes, err := pgd.loadChanges(ctx, txn.xid, txn.metadata)
+		if err != nil {
+			sendError(err)
+			return
+		}
+		if !send(changes) {
+			return
+		}
+	}
+
+	if err := stream.start(ctx); err != nil {
+		sendError(err)
+		return
+	}
+
+	var txn *streamedTransaction
+	for {
+		data, err := stream.receive(ctx)
+		if err != nil {
+			sendError(err)
+			return
+		}
+
+		if data == nil {
+			// Send a checkpoint if no changes have been sent within the interval
+			if time.Since(lastSent) >= common.WatchCheckpointInterval {
+				if !send(&datastore.RevisionChanges{
+					Revision:     postgresRevision{currentTxn, noXmin},
+					IsCheckpoint: true,
+				}) {
+					return
+				}
+			}
+			continue
+		}
+
+		msg, err := stream.decoder.decode(data)
+		if err != nil {
+			sendError(fmt.Errorf("unable to decode replication message: %w", err))
+			return
+		}
+
+		switch msg.kind {
+		case pgoutputBegin:
+			txn = &streamedTransaction{}
+
+		case pgoutputInsert, pgoutputUpdate:
+			if txn == nil {
+				continue
+			}
+			if err := pgd.addStreamedRow(ctx, txn, msg); err != nil {
+				sendError(err)
+				return
+			}
+
+		case pgoutputCommit:
+			if txn != nil && txn.xid.Status == pgtype.Present && !loadedXIDs.Has(txn.xid.Uint) {
+				if txn.tenant == pgd.tenant {
+					tracked := common.NewChanges()
+					for _, change := range txn.changes {
+						tracked.AddChange(ctx, postgresRevision{txn.xid, noXmin}, change.relationship, change.operation)
+					}
+					if !send(pgd.revisionChanges(tracked, txn.xid, txn.metadata)) {
+						return
+					}
+				}
+				currentTxn = txn.xid
+			}
+
+			txn = nil
+			stream.consumedLSN = msg.lsn
+		}
+	}
+}
+
+// streamedTransaction is a transaction being decoded from a replication stream.
+type streamedTransaction struct {
+	xid      xid8
+	metadata map[string]string
+	tenant   string
+	changes  []streamedChange
+}
+
+type streamedChange struct {
+	relationship *core.RelationTuple
+	operation    core.RelationTupleUpdate_Operation
+}
+
+// addStreamedRow adds a row inserted or updated by the transaction. Only new transaction rows,
+// and relationship rows which are created or deleted, are written by SpiceDB transactions.
+func (pgd *pgDatastore) addStreamedRow(ctx context.Context, txn *streamedTransaction, msg pgoutputMessage) error {
+	switch {
+	case msg.table == tableTransaction && msg.kind == pgoutputInsert:
+		xid, err := rowXID(msg.row, colXID)
+		if err != nil {
+			return err
+		}
+		txn.xid = xid
+		txn.tenant = rowString(msg.row, colTenant)
+		if metadata := msg.row.values[colMetadata]; metadata != nil {
+			if err := json.Unmarshal([]byte(*metadata), &txn.metadata); err != nil {
+				return fmt.Errorf("unable to decode transaction metadata: %w", err)
+			}
+		}
+
+	case msg.table == tableTuple:
+		if rowString(msg.row, colTenant) != pgd.tenant {
+			return nil
+		}
+
+		relationship, deletedXID, err := pgd.rowRelationship(ctx, msg.row)
+		if err != nil {
+			return err
+		}
+
+		operation := core.RelationTupleUpdate_TOUCH
+		if deletedXID.Uint != liveDeletedTxnID {
+			operation = core.RelationTupleUpdate_DELETE
+		}
+		txn.changes = append(txn.changes, streamedChange{relationship, operation})
+	}
+
+	return nil
+}
+
+// rowRelationship decodes the relationship of a row of the relationships table, along with the
+// transaction which deleted it. The values which were not streamed, as they were TOASTed and
+// unchanged, are loaded from the table.
+func (pgd *pgDatastore) rowRelationship(ctx context.Context, row pgoutputRow) (*core.RelationTuple, xid8, error) {
+	createdXID, err := rowXID(row, colCreatedXid)
+	if err != nil {
+		return nil, xid8{}, err
+	}
+	deletedXID, err := rowXID(row, colDeletedXid)
+	if err != nil {
+		return nil, xid8{}, err
+	}
+
+	relationship := &core.RelationTuple{
+		ResourceAndRelation: &core.ObjectAndRelation{
+			Namespace: rowString(row, colNamespace),
+			ObjectId:  rowString(row, colObjectID),
+			Relation:  rowString(row, colRelation),
+		},
+		Subject: &core.ObjectAndRelation{
+			Namespace: rowString(row, colUsersetNamespace),
+			ObjectId:  rowString(row, colUsersetObjectID),
+			Relation:  rowString(row, colUsersetRelation),
+		},
+	}
+
+	if row.unchanged {
+		query, args, err := queryChanged.Where(sq.Eq{
+			colNamespace:        relationship.ResourceAndRelation.Namespace,
+			colObjectID:         relationship.ResourceAndRelation.ObjectId,
+			colRelation:         relationship.ResourceAndRelation.Relation,
+			colUsersetNamespace: relationship.Subject.Namespace,
+			colUsersetObjectID:  relationship.Subject.ObjectId,
+			colUsersetRelation:  relationship.Subject.Relation,
+			colCreatedXid:       createdXID,
+			colDeletedXid:       deletedXID,
+			colTenant:           pgd.tenant,
+		}).ToSql()
+		if err != nil {
+			return nil, xid8{}, fmt.Errorf("unable to prepare changes SQL: %w", err)
+		}
+
+		relationship, _, _, err = scanChangedTuple(pgd.readPool.QueryRow(ctx, query, args...))
+		return relationship, deletedXID, err
+	}
+
+	if caveatName := rowString(row, colCaveatContextName); caveatName != "" {
+		var caveatContext map[string]any
+		if value := row.values[colCaveatContext]; value != nil {
+			if err := json.Unmarshal([]byte(*value), &caveatContext); err != nil {
+				return nil, xid8{}, fmt.Errorf("unable to decode caveat context: %w", err)
+			}
+		}
+		contextStruct, err := structpb.NewStruct(caveatContext)
+		if err != nil {
+			return nil, xid8{}, fmt.Errorf("failed to read caveat context from update: %w", err)
+		}
+		relationship.Caveat = &core.ContextualizedCaveat{
+			CaveatName: caveatName,
+			Context:    contextStruct,
+		}
+	}
+
+	if value := row.values[colExpiration]; value != nil {
+		var expiration pgtype.Timestamptz
+		if err := expiration.DecodeText(nil, []byte(*value)); err != nil {
+			return nil, xid8{}, fmt.Errorf("unable to decode relationship expiration: %w", err)
+		}
+		relationship.OptionalExpirationTime = common.ExpirationFrom(sql.NullTime{Time: expiration.Time, Valid: true})
+	}
+
+	if value := row.values[colMetadata]; value != nil {
+		if err := json.Unmarshal([]byte(*value), &relationship.OptionalMetadata); err != nil {
+			return nil, xid8{}, fmt.Errorf("unable to decode relationship metadata: %w", err)
+		}
+	}
+
+	return relationship, deletedXID, nil
+}
+
+func rowString(row pgoutputRow, column string) string {
+	if value := row.values[column]; value != nil {
+		return *value
+	}
+	return ""
+}
+
+func rowXID(row pgoutputRow, column string) (xid8, error) {
+	var xid xid8
+	value := row.values[column]
+	if value == nil {
+		return xid, fmt.Errorf("missing %s in replicated row", column)
+	}
+	if err := xid.DecodeText(nil, []byte(*value)); err != nil {
+		return xid, fmt.Errorf("unable to decode %s: %w", column, err)
+	}
+	return xid, nil
+}
+
+func (pgd *pgDatastore) getCommittedRevisions(ctx context.Context, afterTX xid8) ([]transactionInfo, error) {
+	rows, err := pgd.readPool.Query(ctx, committedRevisionsQuery, afterTX)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load new revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var txns []transactionInfo
+	for rows.Next() {
+		var nextTxn transactionInfo
+		if err := rows.Scan(&nextTxn.xid, &nextTxn.metadata, &nextTxn.tenant); err != nil {
+			return nil, fmt.Errorf("unable to decode new revision: %w", err)
+		}
+		txns = append(txns, nextTxn)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to load new revisions: %w", err)
+	}
+
+	return txns, nil
+}
+
+// replicationStream is a logical replication connection streaming the publication of the watch
+// from a temporary slot, which is dropped as the connection closes.
+type replicationStream struct {
+	conn    *pgconn.PgConn
+	slot    string
+	decoder pgoutputDecoder
+
+	// consumedLSN is the position up to which the stream has been consumed, which is reported
+	// to the server by each status update.
+	consumedLSN uint64
+	lastStatus  time.Time
+}
+
+func (pgd *pgDatastore) createReplicationStream(ctx context.Context) (*replicationStream, error) {
+	config := pgd.writePool.Config().ConnConfig.Copy()
+	config.RuntimeParams["replication"] = "database"
+	if err := pgd.password(ctx, &config.Config); err != nil {
+		return nil, err
+	}
+
+	conn, err := pgconn.ConnectConfig(ctx, &config.Config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open replication connection: %w", err)
+	}
+
+	suffix, err := secrets.TokenHex(8)
+	if err != nil {
+		conn.Close(ctx)
+		return nil, err
+	}
+	stream := &replicationStream{conn: conn, slot: "spicedb_watch_" + suffix}
+
+	results, err := conn.Exec(ctx, fmt.Sprintf("CREATE_REPLICATION_SLOT %s TEMPORARY LOGICAL pgoutput NOEXPORT_SNAPSHOT", stream.slot)).ReadAll()
+	if err != nil {
+		conn.Close(ctx)
+		return nil, fmt.Errorf("unable to create replication slot: %w", err)
+	}
+	if len(results) != 1 || len(results[0].Rows) != 1 || len(results[0].Rows[0]) < 2 {
+		conn.Close(ctx)
+		return nil, fmt.Errorf("unexpected result creating replication slot")
+	}
+
+	stream.consumedLSN, err = parseLSN(string(results[0].Rows[0][1]))
+	if err != nil {
+		conn.Close(ctx)
+		return nil, err
+	}
+
+	return stream, nil
+}
+
+// start starts streaming from the point at which the slot was created.
+func (s *replicationStream) start(ctx context.Context) error {
+	query := fmt.Sprintf(
+		"START_REPLICATION SLOT %s LOGICAL %s (proto_version '1', publication_names '%s')",
+		s.slot,
+		formatLSN(s.consumedLSN),
+		watchPublication,
+	)
+	if err := s.conn.SendBytes(ctx, (&pgproto3.Query{String: query}).Encode(nil)); err != nil {
+		return fmt.Errorf("unable to start replication: %w", err)
+	}
+
+	for {
+		msg, err := s.conn.ReceiveMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to start replication: %w", err)
+		}
+
+		switch msg := msg.(type) {
+		case *pgproto3.CopyBothResponse:
+			s.lastStatus = time.Now()
+			return nil
+		case *pgproto3.ErrorResponse:
+			return fmt.Errorf("unable to start replication: %w", pgconn.ErrorResponseToPgError(msg))
+		}
+	}
+}
+
+// receive returns the next pgoutput message of the stream, or nil if none was received within
+// the receive timeout.
+func (s *replicationStream) receive(ctx context.Context) ([]byte, error) {
+	for {
+		if time.Since(s.lastStatus) >= replicationStatusInterval {
+			if err := s.sendStatus(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		receiveCtx, cancel := context.WithTimeout(ctx, replicationReceiveTimeout)
+		msg, err := s.conn.ReceiveMessage(receiveCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && pgconn.Timeout(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unable to receive replication message: %w", err)
+		}
+
+		switch msg := msg.(type) {
+		case *pgproto3.CopyData:
+			if len(msg.Data) == 0 {
+				continue
+			}
+
+			switch msg.Data[0] {
+			case replicationXLogData:
+				if len(msg.Data) < 1+replicationXLogDataHeader {
+					return nil, errPgoutputTruncated
+				}
+				return msg.Data[1+replicationXLogDataHeader:], nil
+
+			case replicationKeepalive:
+				// The final byte of a keepalive is set if the server requests a status update.
+				if msg.Data[len(msg.Data)-1] == 1 {
+					if err := s.sendStatus(ctx); err != nil {
+						return nil, err
+					}
+				}
+			}
+
+		case *pgproto3.ErrorResponse:
+			return nil, fmt.Errorf("replication failed: %w", pgconn.ErrorResponseToPgError(msg))
+		}
+	}
+}
+
+// sendStatus reports the position up to which the stream has been consumed.
+func (s *replicationStream) sendStatus(ctx context.Context) error {
+	status := make([]byte, 34)
+	status[0] = replicationStatusUpdate
+	binary.BigEndian.PutUint64(status[1:], s.consumedLSN)  // written
+	binary.BigEndian.PutUint64(status[9:], s.consumedLSN)  // flushed
+	binary.BigEndian.PutUint64(status[17:], s.consumedLSN) // applied
+	binary.BigEndian.PutUint64(status[25:], uint64(time.Since(postgresEpoch).Microseconds()))
+
+	if err := s.conn.SendBytes(ctx, (&pgproto3.CopyData{Data: status}).Encode(nil)); err != nil {
+		return fmt.Errorf("unable to send replication status: %w", err)
+	}
+	s.lastStatus = time.Now()
+	return nil
+}
+
+func (s *replicationStream) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.conn.Close(ctx); err != nil {
+		log.Warn().Err(err).Str("slot", s.slot).Msg("unable to close replication connection")
+	}
+}
+
+// parseLSN parses a log sequence number of the form `XXX/XXX`.
+func parseLSN(lsn string) (uint64, error) {
+	high, low, ok := strings.Cut(lsn, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid log sequence number %s", lsn)
+	}
+	highBits, err := strconv.ParseUint(high, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid log sequence number %s: %w", lsn, err)
+	}
+	lowBits, err := strconv.ParseUint(low, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid log sequence number %s: %w", lsn, err)
+	}
+	return highBits<<32 | lowBits, nil
+}
+
+func formatLSN(lsn uint64) string {
+	return fmt.Sprintf("%X/%X", lsn>>32, uint32(lsn))
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+
+	log "github.com/authzed/spicedb/internal/logging"
+)
+
+// queryOldestTransactionAge returns the age in seconds of the oldest transaction which has not
+// yet been garbage collected, or zero if there are none.
+const queryOldestTransactionAge = `SELECT COALESCE(
+	EXTRACT(EPOCH FROM (now() AT TIME ZONE 'UTC') - MIN(timestamp)), 0
+)::float8 FROM relation_tuple_transaction;`
+
+// transactionAgeQueryTimeout bounds the query made as the metrics are collected.
+const transactionAgeQueryTimeout = 5 * time.Second
+
+// transactionAgeCollector reports the age of the oldest transaction row, which grows beyond the
+// garbage collection window as the revision history accumulates. It is queried as the metrics
+// are collected, so that it keeps growing when garbage collection stops.
+type transactionAgeCollector struct {
+	pool *pgxpool.Pool
+	desc *prometheus.Desc
+}
+
+func newTransactionAgeCollector(pool *pgxpool.Pool) *transactionAgeCollector {
+	return &transactionAgeCollector{
+		pool: pool,
+		desc: prometheus.NewDesc(
+			"spicedb_datastore_postgres_oldest_transaction_age_seconds",
+			"The age of the oldest transaction which has not been garbage collected.",
+			nil,
+			nil,
+		),
+	}
+}
+
+func (c *transactionAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *transactionAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), transactionAgeQueryTimeout)
+	defer cancel()
+
+	var age float64
+	if err := c.pool.QueryRow(ctx, queryOldestTransactionAge).Scan(&age); err != nil {
+		log.Warn().Err(err).Msg("unable to collect the age of the oldest transaction")
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, age)
+}
@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+)
+
+// DistributionColumn is the column by which the relation_tuple table is distributed across the
+// workers of a Citus cluster.
+type DistributionColumn string
+
+const (
+	// NoDistribution leaves the relation_tuple table as a local table.
+	NoDistribution DistributionColumn = ""
+
+	// NamespaceDistribution distributes relationships by their resource type. Queries for the
+	// relationships of a resource type are routed to a single worker, but all relationships of
+	// a resource type are stored on the same worker.
+	NamespaceDistribution DistributionColumn = "namespace"
+
+	// ObjectIDDistribution distributes relationships by their resource ID. Queries for the
+	// relationships of a resource are routed to a single worker, while queries for a whole
+	// resource type are run on every worker.
+	ObjectIDDistribution DistributionColumn = "object_id"
+)
+
+// distributionColumns are the columns by which the relation_tuple table can be distributed, by
+// their name on the command line.
+var distributionColumns = map[string]DistributionColumn{
+	"":          NoDistribution,
+	"namespace": NamespaceDistribution,
+	"object-id": ObjectIDDistribution,
+}
+
+// ParseDistribution parses the distribution column of the relation_tuple table, which is one of
+// `namespace` or `object-id`. The empty string is no distribution.
+func ParseDistribution(spec string) (DistributionColumn, error) {
+	column, ok := distributionColumns[spec]
+	if !ok {
+		return NoDistribution, fmt.Errorf("unknown relationship distribution column `%s`: must be one of namespace or object-id", spec)
+	}
+	return column, nil
+}
+
+// ReadDistribution returns the distribution column of the relation_tuple table recorded by the
+// distribution migration.
+func ReadDistribution(ctx context.Context, db querier) (DistributionColumn, error) {
+	var column string
+	if err := db.QueryRow(ctx, selectDistribution).Scan(&column); err != nil {
+		return NoDistribution, fmt.Errorf("unable to read relationship distribution: %w", err)
+	}
+
+	return DistributionColumn(column), nil
+}
@@ -4,15 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgconn"
+	"github.com/jackc/pgconn/stmtcache"
 	"github.com/jackc/pgx/v4"
 	"github.com/lib/pq"
 
+	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
 	"github.com/authzed/spicedb/pkg/migrate"
 )
 
-const postgresMissingTableErrorCode = "42P01"
+const (
+	postgresMissingTableErrorCode = "42P01"
+
+	transactionPoolingStatementCacheCapacity = 512
+)
 
 // AlembicPostgresDriver implements a schema migration facility for use in
 // SpiceDB's Postgres datastore.
@@ -20,21 +27,81 @@ const postgresMissingTableErrorCode = "42P01"
 // It is compatible with the popular Python library, Alembic
 type AlembicPostgresDriver struct {
 	db *pgx.Conn
+
+	statementTimeout time.Duration
+	lockTimeout      time.Duration
+
+	credentials pgxcommon.Credentials
+}
+
+// DriverOption configures an AlembicPostgresDriver and its connection.
+type DriverOption func(*AlembicPostgresDriver, *pgx.ConnConfig)
+
+// WithTransactionPooling configures the driver to run behind a pooler in
+// transaction pooling mode, such as PgBouncer, which may run each transaction on
+// a different server session. Statements are executed unnamed rather than
+// prepared on the session, and migrations hold no state across transactions.
+func WithTransactionPooling() DriverOption {
+	return func(_ *AlembicPostgresDriver, config *pgx.ConnConfig) {
+		config.BuildStatementCache = func(conn *pgconn.PgConn) stmtcache.Cache {
+			return stmtcache.New(conn, stmtcache.ModeDescribe, transactionPoolingStatementCacheCapacity)
+		}
+	}
+}
+
+// WithTimeouts sets the statement_timeout and lock_timeout of each migration
+// transaction, keeping those of the session for either timeout which is zero.
+// Non-atomic migrations, which run outside of a transaction, keep the timeouts
+// of the session.
+func WithTimeouts(statementTimeout, lockTimeout time.Duration) DriverOption {
+	return func(apd *AlembicPostgresDriver, _ *pgx.ConnConfig) {
+		apd.statementTimeout = statementTimeout
+		apd.lockTimeout = lockTimeout
+	}
+}
+
+// WithCredentials authenticates the connection of the driver with the TLS
+// certificates and authentication method of the credentials.
+func WithCredentials(credentials pgxcommon.Credentials) DriverOption {
+	return func(apd *AlembicPostgresDriver, _ *pgx.ConnConfig) {
+		apd.credentials = credentials
+	}
 }
 
 // NewAlembicPostgresDriver creates a new driver with active connections to the database specified.
-func NewAlembicPostgresDriver(url string) (*AlembicPostgresDriver, error) {
+func NewAlembicPostgresDriver(url string, opts ...DriverOption) (*AlembicPostgresDriver, error) {
 	connectStr, err := pq.ParseURL(url)
 	if err != nil {
 		return nil, err
 	}
 
-	db, err := pgx.Connect(context.Background(), connectStr)
+	connConfig, err := pgx.ParseConfig(connectStr)
 	if err != nil {
 		return nil, err
 	}
 
-	return &AlembicPostgresDriver{db}, nil
+	apd := &AlembicPostgresDriver{}
+	for _, opt := range opts {
+		opt(apd, connConfig)
+	}
+
+	if err := apd.credentials.ConfigureTLS(&connConfig.Config); err != nil {
+		return nil, err
+	}
+	password, err := apd.credentials.NewPasswordFunc()
+	if err != nil {
+		return nil, err
+	}
+	if err := password(context.Background(), &connConfig.Config); err != nil {
+		return nil, err
+	}
+
+	apd.db, err = pgx.ConnectConfig(context.Background(), connConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return apd, nil
 }
 
 // Conn returns the underlying pgx.Conn instance for this driver
@@ -44,6 +111,9 @@ func (apd *AlembicPostgresDriver) Conn() *pgx.Conn {
 
 func (apd *AlembicPostgresDriver) RunTx(ctx context.Context, f migrate.TxMigrationFunc[pgx.Tx]) error {
 	return apd.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if err := pgxcommon.SetLocalTimeouts(ctx, tx, apd.statementTimeout, apd.lockTimeout); err != nil {
+			return err
+		}
 		return f(ctx, tx)
 	})
 }
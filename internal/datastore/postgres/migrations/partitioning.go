@@ -0,0 +1,115 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// PartitionStrategy is a strategy for the declarative partitioning of the relation_tuple table.
+type PartitionStrategy string
+
+const (
+	// NoPartitioning leaves the relation_tuple table unpartitioned.
+	NoPartitioning PartitionStrategy = ""
+
+	// NamespaceHashPartitioning partitions relationships by a hash of their resource type into a
+	// fixed number of partitions. Queries for the relationships of a resource type read a single
+	// partition.
+	NamespaceHashPartitioning PartitionStrategy = "namespace-hash"
+
+	// CreatedXIDRangePartitioning partitions relationships by ranges of the transaction which
+	// created them. Snapshot reads skip the partitions created after their revision, and older
+	// partitions are only vacuumed as their relationships are garbage collected.
+	//
+	// As a unique constraint must include the partitioning column, the uniqueness of living
+	// relationships is checked by the datastore as they are written rather than by a constraint.
+	CreatedXIDRangePartitioning PartitionStrategy = "created-xid-range"
+)
+
+// RangePartitionsAhead is the number of range partitions kept ahead of the current transaction,
+// so that new relationships need never be written to the default partition.
+const RangePartitionsAhead = 2
+
+// Partitioning is the partitioning of the relation_tuple table.
+type Partitioning struct {
+	Strategy PartitionStrategy
+
+	// Size is the number of partitions of namespace hash partitioning, or the number of
+	// transactions covered by each partition of created transaction range partitioning.
+	Size uint64
+}
+
+// ParsePartitioning parses a partitioning of the form `<strategy>:<size>`, such as
+// `namespace-hash:16`. The empty string is no partitioning.
+func ParsePartitioning(spec string) (Partitioning, error) {
+	if spec == "" {
+		return Partitioning{}, nil
+	}
+
+	strategy, sizeStr, ok := strings.Cut(spec, ":")
+	if !ok {
+		return Partitioning{}, fmt.Errorf("invalid relationship partitioning `%s`: expected <strategy>:<size>", spec)
+	}
+
+	size, err := strconv.ParseUint(sizeStr, 10, 64)
+	if err != nil || size == 0 {
+		return Partitioning{}, fmt.Errorf("invalid relationship partitioning size `%s`: must be a positive integer", sizeStr)
+	}
+
+	switch PartitionStrategy(strategy) {
+	case NamespaceHashPartitioning, CreatedXIDRangePartitioning:
+		return Partitioning{PartitionStrategy(strategy), size}, nil
+	default:
+		return Partitioning{}, fmt.Errorf("unknown relationship partitioning strategy `%s`: must be one of %s or %s", strategy, NamespaceHashPartitioning, CreatedXIDRangePartitioning)
+	}
+}
+
+func (p Partitioning) String() string {
+	if p.Strategy == NoPartitioning {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", p.Strategy, p.Size)
+}
+
+// ReadPartitioning returns the partitioning of the relation_tuple table recorded by the
+// partitioning migration.
+func ReadPartitioning(ctx context.Context, db querier) (Partitioning, error) {
+	var strategy string
+	var size uint64
+	if err := db.QueryRow(ctx, selectPartitioning).Scan(&strategy, &size); err != nil {
+		return Partitioning{}, fmt.Errorf("unable to read relationship partitioning: %w", err)
+	}
+
+	return Partitioning{PartitionStrategy(strategy), size}, nil
+}
+
+// EnsureRangePartitions creates any missing created transaction range partitions from the one
+// containing the first transaction through RangePartitionsAhead partitions beyond the one
+// containing the current transaction.
+func EnsureRangePartitions(ctx context.Context, db execer, size, firstXID, currentXID uint64) error {
+	for index := firstXID / size; index <= currentXID/size+RangePartitionsAhead; index++ {
+		stmt := fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS relation_tuple_%d PARTITION OF relation_tuple FOR VALUES FROM ('%d') TO ('%d');`,
+			index,
+			index*size,
+			(index+1)*size,
+		)
+		if _, err := db.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("unable to create relationship partition %d: %w", index, err)
+		}
+	}
+	return nil
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
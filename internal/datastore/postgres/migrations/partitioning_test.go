@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePartitioning(t *testing.T) {
+	for spec, expected := range map[string]Partitioning{
+		"":                      {},
+		"namespace-hash:16":     {NamespaceHashPartitioning, 16},
+		"created-xid-range:100": {CreatedXIDRangePartitioning, 100},
+	} {
+		spec, expected := spec, expected
+		t.Run(spec, func(t *testing.T) {
+			parsed, err := ParsePartitioning(spec)
+			require.NoError(t, err)
+			require.Equal(t, expected, parsed)
+			require.Equal(t, spec, parsed.String())
+		})
+	}
+
+	for _, spec := range []string{
+		"namespace-hash",
+		"namespace-hash:0",
+		"namespace-hash:many",
+		"object-hash:16",
+	} {
+		_, err := ParsePartitioning(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func TestParseDistribution(t *testing.T) {
+	for spec, expected := range map[string]DistributionColumn{
+		"":          NoDistribution,
+		"namespace": NamespaceDistribution,
+		"object-id": ObjectIDDistribution,
+	} {
+		parsed, err := ParseDistribution(spec)
+		require.NoError(t, err, spec)
+		require.Equal(t, expected, parsed, spec)
+	}
+
+	for _, spec := range []string{"object_id", "tenant", "namespace:16"} {
+		_, err := ParseDistribution(spec)
+		require.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const addExpirationColumn = `ALTER TABLE relation_tuple
+	ADD COLUMN expiration TIMESTAMPTZ;`
+
+func init() {
+	if err := DatabaseMigrations.Register("add-relationship-expiration", "drop-bigserial-ids",
+		noNonatomicMigration,
+		func(ctx context.Context, tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, addExpirationColumn)
+			return err
+		}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const addMetadataColumn = `ALTER TABLE relation_tuple
+	ADD COLUMN metadata JSONB;`
+
+func init() {
+	if err := DatabaseMigrations.Register("add-relationship-metadata", "add-relationship-expiration",
+		noNonatomicMigration,
+		func(ctx context.Context, tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, addMetadataColumn)
+			return err
+		}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
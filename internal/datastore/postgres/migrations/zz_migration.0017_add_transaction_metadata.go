@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const addTransactionMetadataColumn = `ALTER TABLE relation_tuple_transaction
+	ADD COLUMN metadata JSONB;`
+
+func init() {
+	if err := DatabaseMigrations.Register("add-transaction-metadata", "add-relationship-metadata",
+		noNonatomicMigration,
+		func(ctx context.Context, tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, addTransactionMetadataColumn)
+			return err
+		}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const createSubjectSortIndex = `CREATE INDEX CONCURRENTLY IF NOT EXISTS ix_relation_tuple_by_subject_and_resource
+	ON relation_tuple (userset_namespace, userset_object_id, userset_relation, namespace, object_id, relation)`
+
+func init() {
+	if err := DatabaseMigrations.Register("add-subject-sort-index", "add-transaction-metadata",
+		func(ctx context.Context, conn *pgx.Conn) error {
+			// CREATE INDEX CONCURRENTLY cannot run inside a transaction block (SQLSTATE 25001)
+			_, err := conn.Exec(ctx, createSubjectSortIndex)
+			return err
+		}, noTxMigration,
+	); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+var addDefinitionTimestamps = []string{
+	`ALTER TABLE namespace_config
+		ADD COLUMN created_at TIMESTAMP WITHOUT TIME ZONE;`,
+	`ALTER TABLE namespace_config
+		ALTER COLUMN created_at SET DEFAULT (now() AT TIME ZONE 'UTC');`,
+	`UPDATE namespace_config SET created_at = relation_tuple_transaction.timestamp
+		FROM relation_tuple_transaction
+		WHERE relation_tuple_transaction.xid = namespace_config.created_xid;`,
+	`ALTER TABLE caveat
+		ADD COLUMN created_at TIMESTAMP WITHOUT TIME ZONE;`,
+	`ALTER TABLE caveat
+		ALTER COLUMN created_at SET DEFAULT (now() AT TIME ZONE 'UTC');`,
+	`UPDATE caveat SET created_at = relation_tuple_transaction.timestamp
+		FROM relation_tuple_transaction
+		WHERE relation_tuple_transaction.xid = caveat.created_xid;`,
+}
+
+func init() {
+	if err := DatabaseMigrations.Register("add-definition-timestamps", "add-subject-sort-index",
+		noNonatomicMigration,
+		func(ctx context.Context, tx pgx.Tx) error {
+			// Definitions written before this migration take the timestamp of their transaction,
+			// if it has not yet been garbage collected.
+			for _, stmt := range addDefinitionTimestamps {
+				if _, err := tx.Exec(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Existing rows belong to the default tenant, which is the empty string.
+var addTenantColumns = []string{
+	`ALTER TABLE relation_tuple_transaction
+		ADD COLUMN tenant_id VARCHAR NOT NULL DEFAULT '';`,
+	`ALTER TABLE namespace_config
+		ADD COLUMN tenant_id VARCHAR NOT NULL DEFAULT '';`,
+	`ALTER TABLE caveat
+		ADD COLUMN tenant_id VARCHAR NOT NULL DEFAULT '';`,
+	`ALTER TABLE relation_tuple
+		ADD COLUMN tenant_id VARCHAR NOT NULL DEFAULT '';`,
+}
+
+func init() {
+	if err := DatabaseMigrations.Register("add-tenant-columns", "add-definition-timestamps",
+		noNonatomicMigration,
+		func(ctx context.Context, tx pgx.Tx) error {
+			for _, stmt := range addTenantColumns {
+				if _, err := tx.Exec(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
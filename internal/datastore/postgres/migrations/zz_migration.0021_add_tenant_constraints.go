@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// The uniqueness of living rows is scoped to their tenant, so that tenants may define the same
+// namespaces, caveats and relationships.
+var addTenantIndices = []string{
+	`CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS ix_namespace_config_living_tenant
+		ON namespace_config (tenant_id, namespace, deleted_xid);`,
+	`CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS ix_caveat_living_tenant
+		ON caveat (tenant_id, name, deleted_xid);`,
+	`CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS ix_relation_tuple_living_tenant
+		ON relation_tuple (tenant_id, namespace, object_id, relation, userset_namespace,
+						   userset_object_id, userset_relation, deleted_xid);`,
+}
+
+var addTenantConstraints = []string{
+	`ALTER TABLE namespace_config
+		DROP CONSTRAINT uq_namespace_living_xid,
+		ADD CONSTRAINT uq_namespace_living_tenant_xid UNIQUE USING INDEX ix_namespace_config_living_tenant;`,
+	`ALTER TABLE caveat
+		DROP CONSTRAINT pk_caveat_v2,
+		ADD CONSTRAINT pk_caveat_v3 PRIMARY KEY USING INDEX ix_caveat_living_tenant;`,
+	`ALTER TABLE relation_tuple
+		DROP CONSTRAINT uq_relation_tuple_living_xid,
+		ADD CONSTRAINT uq_relation_tuple_living_tenant_xid UNIQUE USING INDEX ix_relation_tuple_living_tenant;`,
+}
+
+func init() {
+	if err := DatabaseMigrations.Register("add-tenant-constraints", "add-tenant-columns",
+		func(ctx context.Context, conn *pgx.Conn) error {
+			// CREATE INDEX CONCURRENTLY cannot run inside a transaction block (SQLSTATE 25001)
+			for _, stmt := range addTenantIndices {
+				if _, err := conn.Exec(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(ctx context.Context, tx pgx.Tx) error {
+			for _, stmt := range addTenantConstraints {
+				if _, err := tx.Exec(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// Each new transaction notifies the listeners of the transaction channel when it commits, so
+// that watches need not poll for new revisions.
+var addTransactionNotifyTrigger = []string{
+	`CREATE OR REPLACE FUNCTION notify_relation_tuple_transaction() RETURNS TRIGGER AS $$
+	BEGIN
+		PERFORM pg_notify('spicedb_transaction', '');
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;`,
+	`CREATE TRIGGER relation_tuple_transaction_notify
+		AFTER INSERT ON relation_tuple_transaction
+		FOR EACH ROW EXECUTE FUNCTION notify_relation_tuple_transaction();`,
+}
+
+func init() {
+	if err := DatabaseMigrations.Register("add-transaction-notify-trigger", "add-tenant-constraints",
+		noNonatomicMigration,
+		func(ctx context.Context, tx pgx.Tx) error {
+			for _, stmt := range addTransactionNotifyTrigger {
+				if _, err := tx.Exec(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
@@ -0,0 +1,145 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/migrate"
+)
+
+const (
+	createPartitioningTable = `CREATE TABLE relation_tuple_partitioning (
+		strategy VARCHAR NOT NULL,
+		size BIGINT NOT NULL
+	);`
+
+	insertPartitioning = `INSERT INTO relation_tuple_partitioning (strategy, size) VALUES ($1, $2);`
+
+	selectPartitioning = `SELECT strategy, size FROM relation_tuple_partitioning;`
+
+	// The partitioned table is created alongside the existing table, as it cannot be altered into
+	// a partitioned table in place.
+	renameUnpartitionedTable = `ALTER TABLE relation_tuple RENAME TO relation_tuple_unpartitioned;`
+
+	createPartitionedTable = `CREATE TABLE relation_tuple
+		(LIKE relation_tuple_unpartitioned INCLUDING DEFAULTS INCLUDING STORAGE)
+		PARTITION BY %s;`
+
+	createHashPartition = `CREATE TABLE relation_tuple_%[1]d PARTITION OF relation_tuple
+		FOR VALUES WITH (MODULUS %[2]d, REMAINDER %[1]d);`
+
+	createDefaultPartition = `CREATE TABLE relation_tuple_default PARTITION OF relation_tuple DEFAULT;`
+
+	selectFirstCreatedXID = `SELECT COALESCE(
+		(SELECT created_xid::text::bigint FROM relation_tuple_unpartitioned ORDER BY created_xid LIMIT 1),
+		pg_current_xact_id()::text::bigint
+	), pg_current_xact_id()::text::bigint;`
+
+	copyUnpartitionedRows = `INSERT INTO relation_tuple SELECT * FROM relation_tuple_unpartitioned;`
+
+	dropUnpartitionedTable = `DROP TABLE relation_tuple_unpartitioned;`
+)
+
+// The constraints and indices of the table are recreated under their existing names once the
+// rows have been copied.
+var addPartitionedIndices = []string{
+	`ALTER TABLE relation_tuple
+		ADD CONSTRAINT pk_relation_tuple PRIMARY KEY (namespace, object_id, relation, userset_namespace,
+			userset_object_id, userset_relation, created_xid, deleted_xid);`,
+	`CREATE INDEX ix_relation_tuple_by_subject
+		ON relation_tuple (userset_object_id, userset_namespace, userset_relation, namespace, relation);`,
+	`CREATE INDEX ix_relation_tuple_by_subject_relation
+		ON relation_tuple (userset_namespace, userset_relation, namespace, relation);`,
+	`CREATE INDEX ix_relation_tuple_by_subject_and_resource
+		ON relation_tuple (userset_namespace, userset_object_id, userset_relation, namespace, object_id, relation);`,
+}
+
+var addHashPartitionedIndices = []string{
+	`ALTER TABLE relation_tuple
+		ADD CONSTRAINT uq_relation_tuple_living_tenant_xid UNIQUE (tenant_id, namespace, object_id, relation,
+			userset_namespace, userset_object_id, userset_relation, deleted_xid);`,
+}
+
+// A unique constraint must include the created_xid when the table is partitioned by it, so the
+// living relationships are only indexed.
+var addRangePartitionedIndices = []string{
+	`CREATE INDEX ix_relation_tuple_living_tenant
+		ON relation_tuple (tenant_id, namespace, object_id, relation, userset_namespace,
+			userset_object_id, userset_relation, deleted_xid);`,
+	`CREATE INDEX ix_relation_tuple_by_created_xid ON relation_tuple (created_xid);`,
+}
+
+func partitionRelationTuple(ctx context.Context, tx pgx.Tx, partitioning Partitioning) error {
+	if _, err := tx.Exec(ctx, renameUnpartitionedTable); err != nil {
+		return err
+	}
+
+	var indices []string
+	switch partitioning.Strategy {
+	case NamespaceHashPartitioning:
+		if _, err := tx.Exec(ctx, fmt.Sprintf(createPartitionedTable, "HASH (namespace)")); err != nil {
+			return err
+		}
+		for remainder := uint64(0); remainder < partitioning.Size; remainder++ {
+			if _, err := tx.Exec(ctx, fmt.Sprintf(createHashPartition, remainder, partitioning.Size)); err != nil {
+				return err
+			}
+		}
+		indices = append(addPartitionedIndices, addHashPartitionedIndices...)
+
+	case CreatedXIDRangePartitioning:
+		if _, err := tx.Exec(ctx, fmt.Sprintf(createPartitionedTable, "RANGE (created_xid)")); err != nil {
+			return err
+		}
+
+		var firstXID, currentXID uint64
+		if err := tx.QueryRow(ctx, selectFirstCreatedXID).Scan(&firstXID, &currentXID); err != nil {
+			return err
+		}
+		if err := EnsureRangePartitions(ctx, tx, partitioning.Size, firstXID, currentXID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, createDefaultPartition); err != nil {
+			return err
+		}
+		indices = append(addPartitionedIndices, addRangePartitionedIndices...)
+	}
+
+	for _, stmt := range append([]string{copyUnpartitionedRows, dropUnpartitionedTable}, indices...) {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	if err := DatabaseMigrations.Register("add-relationship-partitioning", "add-transaction-notify-trigger",
+		noNonatomicMigration,
+		func(ctx context.Context, tx pgx.Tx) error {
+			spec, _ := ctx.Value(migrate.RelationshipPartitioning).(string)
+			partitioning, err := ParsePartitioning(spec)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.Exec(ctx, createPartitioningTable); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(ctx, insertPartitioning, string(partitioning.Strategy), partitioning.Size); err != nil {
+				return err
+			}
+
+			if partitioning.Strategy == NoPartitioning {
+				return nil
+			}
+
+			log.Ctx(ctx).Info().Stringer("partitioning", partitioning).Msg("partitioning relationships table")
+			return partitionRelationTuple(ctx, tx, partitioning)
+		}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
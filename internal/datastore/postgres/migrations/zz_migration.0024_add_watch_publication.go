@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// The publication streams the new transactions and relationship changes to watches which decode
+// them from a logical replication slot. Deletes are not published, as only garbage collection
+// deletes relationships, once they are no longer visible to any watch.
+const addWatchPublication = `CREATE PUBLICATION spicedb_watch
+	FOR TABLE relation_tuple, relation_tuple_transaction
+	WITH (publish = 'insert, update', publish_via_partition_root = true);`
+
+func init() {
+	if err := DatabaseMigrations.Register("add-watch-publication", "add-relationship-partitioning",
+		noNonatomicMigration,
+		func(ctx context.Context, tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, addWatchPublication)
+			return err
+		}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
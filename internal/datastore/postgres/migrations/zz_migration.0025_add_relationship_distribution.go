@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/migrate"
+)
+
+const (
+	createDistributionTable = `CREATE TABLE relation_tuple_distribution (
+		distribution_column VARCHAR NOT NULL
+	);`
+
+	insertDistribution = `INSERT INTO relation_tuple_distribution (distribution_column) VALUES ($1);`
+
+	selectDistribution = `SELECT distribution_column FROM relation_tuple_distribution;`
+
+	selectCitusInstalled = `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'citus');`
+
+	// Changes to the shards of a distributed table are not decoded from the WAL of the
+	// coordinator, so the relationships table cannot be published to logical replication watches.
+	dropTupleFromPublication = `ALTER PUBLICATION spicedb_watch DROP TABLE relation_tuple;`
+
+	// The transactions, namespaces and caveats remain local tables on the coordinator, where the
+	// transaction IDs recorded with each relationship are assigned.
+	createDistributedTable = `SELECT create_distributed_table('relation_tuple', '%s');`
+)
+
+func init() {
+	if err := DatabaseMigrations.Register("add-relationship-distribution", "add-watch-publication",
+		noNonatomicMigration,
+		func(ctx context.Context, tx pgx.Tx) error {
+			spec, _ := ctx.Value(migrate.RelationshipDistribution).(string)
+			column, err := ParseDistribution(spec)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.Exec(ctx, createDistributionTable); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(ctx, insertDistribution, string(column)); err != nil {
+				return err
+			}
+
+			if column == NoDistribution {
+				return nil
+			}
+
+			var citusInstalled bool
+			if err := tx.QueryRow(ctx, selectCitusInstalled).Scan(&citusInstalled); err != nil {
+				return err
+			}
+			if !citusInstalled {
+				return fmt.Errorf("relationship distribution requires the citus extension to be created in the database")
+			}
+
+			log.Ctx(ctx).Info().Str("column", string(column)).Msg("distributing relationships table")
+			for _, stmt := range []string{dropTupleFromPublication, fmt.Sprintf(createDistributedTable, column)} {
+				if _, err := tx.Exec(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
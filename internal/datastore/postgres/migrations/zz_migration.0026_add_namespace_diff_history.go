@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// The diffs are retained with the namespace definitions, rather than being garbage collected
+// with their transactions.
+const createNamespaceDiffTable = `CREATE TABLE namespace_config_diff (
+	namespace VARCHAR NOT NULL,
+	tenant_id VARCHAR NOT NULL DEFAULT '',
+	created_xid xid8 NOT NULL,
+	deltas JSONB NOT NULL,
+	created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT (now() AT TIME ZONE 'UTC'),
+	CONSTRAINT pk_namespace_config_diff PRIMARY KEY (tenant_id, namespace, created_xid)
+);`
+
+func init() {
+	if err := DatabaseMigrations.Register("add-namespace-diff-history", "add-relationship-distribution",
+		noNonatomicMigration,
+		func(ctx context.Context, tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, createNamespaceDiffTable)
+			return err
+		}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+
+	log "github.com/authzed/spicedb/internal/logging"
+)
+
+const (
+	selectIsYugabyte = `SELECT version() LIKE '%-YB-%';`
+
+	// YugabyteDB shards a table by the hash of the first column of its primary key unless told
+	// otherwise, which would store all relationships of a resource type in the same tablet.
+	// Relationships are instead sharded by the hash of their resource, and ordered within it.
+	dropTuplePrimaryKey = `ALTER TABLE relation_tuple DROP CONSTRAINT pk_relation_tuple;`
+
+	addHashShardedTuplePrimaryKey = `ALTER TABLE relation_tuple
+		ADD CONSTRAINT pk_relation_tuple PRIMARY KEY ((namespace, object_id) HASH, relation ASC,
+			userset_namespace ASC, userset_object_id ASC, userset_relation ASC, created_xid ASC, deleted_xid ASC);`
+)
+
+func init() {
+	if err := DatabaseMigrations.Register("add-relationship-hash-sharding", "add-namespace-diff-history",
+		noNonatomicMigration,
+		func(ctx context.Context, tx pgx.Tx) error {
+			var isYugabyte bool
+			if err := tx.QueryRow(ctx, selectIsYugabyte).Scan(&isYugabyte); err != nil {
+				return err
+			}
+			if !isYugabyte {
+				return nil
+			}
+
+			log.Ctx(ctx).Info().Msg("sharding relationships table by the hash of their resource")
+			for _, stmt := range []string{dropTuplePrimaryKey, addHashShardedTuplePrimaryKey} {
+				if _, err := tx.Exec(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
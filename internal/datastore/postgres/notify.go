@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
+	log "github.com/authzed/spicedb/internal/logging"
+)
+
+const (
+	// transactionChannel is the channel notified by the trigger on the transaction table whenever
+	// a transaction commits.
+	transactionChannel = "spicedb_transaction"
+
+	// notifiedWatchPoll is the interval at which watches poll for new revisions while transaction
+	// notifications are being received. Polling is still required, as a transaction only becomes
+	// visible to a watch once every transaction which started before it has completed, which need
+	// not trigger a notification.
+	notifiedWatchPoll = time.Second
+
+	// listenReconnectDelay is the time waited before reconnecting a lost listener connection.
+	listenReconnectDelay = time.Second
+)
+
+// transactionNotifier listens for the notifications sent as transactions commit, and wakes the
+// watches of the datastore on each of them.
+type transactionNotifier struct {
+	config   *pgx.ConnConfig
+	password pgxcommon.PasswordFunc
+
+	sync.Mutex
+	notified  chan struct{}
+	listening bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newTransactionNotifier(config *pgx.ConnConfig, password pgxcommon.PasswordFunc) *transactionNotifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &transactionNotifier{
+		config:   config,
+		password: password,
+		notified: make(chan struct{}),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	go n.listen(ctx)
+
+	return n
+}
+
+// wait returns a channel which is closed on the next transaction notification, along with the
+// interval at which the caller should poll regardless. The interval is shortened to watchSleep
+// while the listener is disconnected, and a nil notifier is never notified.
+func (n *transactionNotifier) wait() (<-chan struct{}, time.Duration) {
+	if n == nil {
+		return nil, watchSleep
+	}
+
+	n.Lock()
+	defer n.Unlock()
+
+	if !n.listening {
+		return n.notified, watchSleep
+	}
+	return n.notified, notifiedWatchPoll
+}
+
+func (n *transactionNotifier) notify(listening bool) {
+	n.Lock()
+	defer n.Unlock()
+
+	close(n.notified)
+	n.notified = make(chan struct{})
+	n.listening = listening
+}
+
+// listen receives notifications over a dedicated connection until the notifier is closed,
+// reconnecting whenever the connection is lost.
+func (n *transactionNotifier) listen(ctx context.Context) {
+	defer close(n.done)
+
+	for {
+		err := n.receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Waiting watches poll again at the shorter interval until the listener reconnects.
+		n.notify(false)
+		log.Warn().Err(err).Msg("lost connection listening for postgres transactions, falling back to polling for watch")
+
+		select {
+		case <-time.After(listenReconnectDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (n *transactionNotifier) receive(ctx context.Context) error {
+	config := n.config.Copy()
+	if err := n.password(ctx, &config.Config); err != nil {
+		return err
+	}
+
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+transactionChannel); err != nil {
+		return err
+	}
+
+	// Transactions committed before the listener connected are picked up by the next poll.
+	n.notify(true)
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		n.notify(true)
+	}
+}
+
+func (n *transactionNotifier) Close() {
+	n.cancel()
+	<-n.done
+}
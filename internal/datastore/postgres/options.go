@@ -3,14 +3,14 @@ package postgres
 import (
 	"fmt"
 	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
 )
 
 type postgresOptions struct {
-	connMaxIdleTime             *time.Duration
-	connMaxLifetime             *time.Duration
+	readPoolOpts, writePoolOpts poolOptions
 	healthCheckPeriod           *time.Duration
-	maxOpenConns                *int
-	minOpenConns                *int
 	maxRevisionStalenessPercent float64
 
 	watchBufferLength    uint16
@@ -18,8 +18,12 @@ type postgresOptions struct {
 	gcWindow             time.Duration
 	gcInterval           time.Duration
 	gcMaxOperationTime   time.Duration
+	gcBatchSize          uint64
+	gcBatchDelay         time.Duration
 	splitAtUsersetCount  uint16
 	maxRetries           uint8
+	retryInitialBackoff  time.Duration
+	retryMaxBackoff      time.Duration
 
 	enablePrometheusStats   bool
 	analyzeBeforeStatistics bool
@@ -27,9 +31,42 @@ type postgresOptions struct {
 
 	migrationPhase string
 
+	readReplicaURLs []string
+
+	statementCacheMode     string
+	statementCacheCapacity *int
+
+	slowQueryThreshold      time.Duration
+	slowQueryExplainAnalyze bool
+
+	transactionPooling bool
+	yugabyte           bool
+
+	watchMode string
+
+	credentials pgxcommon.Credentials
+
+	readTimeouts, writeTimeouts, gcTimeouts operationTimeouts
+
 	logger *tracingLogger
 }
 
+// poolOptions configures one of the connection pools of the datastore.
+type poolOptions struct {
+	connMaxIdleTime    *time.Duration
+	connMaxLifetime    *time.Duration
+	maxOpenConns       *int
+	minOpenConns       *int
+	targetSessionAttrs string
+}
+
+// operationTimeouts are the statement and lock timeouts set on each transaction of a class of
+// operations. A zero timeout keeps that of the session.
+type operationTimeouts struct {
+	statementTimeout time.Duration
+	lockTimeout      time.Duration
+}
+
 type migrationPhase uint8
 
 const (
@@ -44,6 +81,37 @@ var migrationPhases = map[string]migrationPhase{
 	"":                    complete,
 }
 
+type statementCacheMode uint8
+
+const (
+	statementCacheDefault statementCacheMode = iota
+	statementCachePrepare
+	statementCacheDescribe
+	statementCacheDisabled
+	statementCacheSimpleProtocol
+)
+
+var statementCacheModes = map[string]statementCacheMode{
+	"":                statementCacheDefault,
+	"prepare":         statementCachePrepare,
+	"describe":        statementCacheDescribe,
+	"disabled":        statementCacheDisabled,
+	"simple-protocol": statementCacheSimpleProtocol,
+}
+
+type watchMode uint8
+
+const (
+	watchPolling watchMode = iota
+	watchLogicalReplication
+)
+
+var watchModes = map[string]watchMode{
+	"":                    watchPolling,
+	"polling":             watchPolling,
+	"logical-replication": watchLogicalReplication,
+}
+
 const (
 	errQuantizationTooLarge = "revision quantization interval (%s) must be less than GC window (%s)"
 
@@ -51,12 +119,14 @@ const (
 	defaultGarbageCollectionWindow           = 24 * time.Hour
 	defaultGarbageCollectionInterval         = time.Minute * 3
 	defaultGarbageCollectionMaxOperationTime = time.Minute
+	defaultGarbageCollectionBatchSize        = 1000
 	defaultUsersetBatchSize                  = 1024
 	defaultQuantization                      = 5 * time.Second
 	defaultMaxRevisionStalenessPercent       = 0.1
 	defaultEnablePrometheusStats             = false
 	defaultMaxRetries                        = 10
 	defaultGCEnabled                         = true
+	defaultStatementCacheCapacity            = 512
 )
 
 // Option provides the facility to configure how clients within the
@@ -68,12 +138,15 @@ func generateConfig(options []Option) (postgresOptions, error) {
 		gcWindow:                    defaultGarbageCollectionWindow,
 		gcInterval:                  defaultGarbageCollectionInterval,
 		gcMaxOperationTime:          defaultGarbageCollectionMaxOperationTime,
+		gcBatchSize:                 defaultGarbageCollectionBatchSize,
 		watchBufferLength:           defaultWatchBufferLength,
 		splitAtUsersetCount:         defaultUsersetBatchSize,
 		revisionQuantization:        defaultQuantization,
 		maxRevisionStalenessPercent: defaultMaxRevisionStalenessPercent,
 		enablePrometheusStats:       defaultEnablePrometheusStats,
 		maxRetries:                  defaultMaxRetries,
+		retryInitialBackoff:         common.DefaultRetryInitialBackoff,
+		retryMaxBackoff:             common.DefaultRetryMaxBackoff,
 		gcEnabled:                   defaultGCEnabled,
 	}
 
@@ -90,10 +163,48 @@ func generateConfig(options []Option) (postgresOptions, error) {
 		)
 	}
 
+	if computed.gcBatchSize == 0 {
+		return computed, fmt.Errorf("garbage collection batch size must be positive")
+	}
+
+	for _, poolOpts := range []poolOptions{computed.readPoolOpts, computed.writePoolOpts} {
+		if _, ok := targetSessionAttrsValidators[poolOpts.targetSessionAttrs]; !ok {
+			return computed, fmt.Errorf("unknown target session attributes: %s", poolOpts.targetSessionAttrs)
+		}
+	}
+
 	if _, ok := migrationPhases[computed.migrationPhase]; !ok {
 		return computed, fmt.Errorf("unknown migration phase: %s", computed.migrationPhase)
 	}
 
+	if _, ok := statementCacheModes[computed.statementCacheMode]; !ok {
+		return computed, fmt.Errorf("unknown statement cache mode: %s", computed.statementCacheMode)
+	}
+
+	if computed.transactionPooling {
+		switch statementCacheModes[computed.statementCacheMode] {
+		case statementCacheDefault:
+			computed.statementCacheMode = "describe"
+		case statementCachePrepare:
+			return computed, fmt.Errorf("statement cache mode prepare cannot be used with transaction pooling")
+		}
+	}
+
+	mode, ok := watchModes[computed.watchMode]
+	if !ok {
+		return computed, fmt.Errorf("unknown watch mode: %s", computed.watchMode)
+	}
+	if mode == watchLogicalReplication && computed.transactionPooling {
+		return computed, fmt.Errorf("watch mode logical-replication cannot be used with transaction pooling")
+	}
+	if mode == watchLogicalReplication && computed.yugabyte {
+		return computed, fmt.Errorf("watch mode logical-replication cannot be used with yugabytedb")
+	}
+
+	if _, ok := pgxcommon.AuthMethods[computed.credentials.AuthMethod]; !ok {
+		return computed, fmt.Errorf("unknown authentication method: %s", computed.credentials.AuthMethod)
+	}
+
 	return computed, nil
 }
 
@@ -107,51 +218,55 @@ func SplitAtUsersetCount(splitAtUsersetCount uint16) Option {
 	}
 }
 
-// ConnMaxIdleTime is the duration after which an idle connection will be
+// ConnMaxIdleTime is the duration after which an idle connection of either pool will be
 // automatically closed by the health check.
 //
 // This value defaults to having no maximum.
 func ConnMaxIdleTime(idle time.Duration) Option {
 	return func(po *postgresOptions) {
-		po.connMaxIdleTime = &idle
+		po.readPoolOpts.connMaxIdleTime = &idle
+		po.writePoolOpts.connMaxIdleTime = &idle
 	}
 }
 
-// ConnMaxLifetime is the duration since creation after which a connection will
-// be automatically closed.
+// ConnMaxLifetime is the duration since creation after which a connection of
+// either pool will be automatically closed.
 //
 // This value defaults to having no maximum.
 func ConnMaxLifetime(lifetime time.Duration) Option {
 	return func(po *postgresOptions) {
-		po.connMaxLifetime = &lifetime
+		po.readPoolOpts.connMaxLifetime = &lifetime
+		po.writePoolOpts.connMaxLifetime = &lifetime
 	}
 }
 
 // HealthCheckPeriod is the interval by which idle Postgres client connections
-// are health checked in order to keep them alive in a connection pool.
+// are health checked in order to keep them alive in the connection pools.
 func HealthCheckPeriod(period time.Duration) Option {
 	return func(po *postgresOptions) {
 		po.healthCheckPeriod = &period
 	}
 }
 
-// MaxOpenConns is the maximum size of the connection pool.
+// MaxOpenConns is the maximum size of each of the connection pools.
 //
 // This value defaults to having no maximum.
 func MaxOpenConns(conns int) Option {
 	return func(po *postgresOptions) {
-		po.maxOpenConns = &conns
+		po.readPoolOpts.maxOpenConns = &conns
+		po.writePoolOpts.maxOpenConns = &conns
 	}
 }
 
-// MinOpenConns is the minimum size of the connection pool.
+// MinOpenConns is the minimum size of each of the connection pools.
 // The health check will increase the number of connections to this amount if
 // it had dropped below.
 //
 // This value defaults to zero.
 func MinOpenConns(conns int) Option {
 	return func(po *postgresOptions) {
-		po.minOpenConns = &conns
+		po.readPoolOpts.minOpenConns = &conns
+		po.writePoolOpts.minOpenConns = &conns
 	}
 }
 
@@ -224,6 +339,25 @@ func MaxRetries(maxRetries uint8) Option {
 	}
 }
 
+// RetryInitialBackoff is the wait before the first client-side retry of a
+// transaction, which doubles with each further retry up to RetryMaxBackoff.
+// Zero retries immediately.
+// Default: 10ms
+func RetryInitialBackoff(backoff time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.retryInitialBackoff = backoff
+	}
+}
+
+// RetryMaxBackoff is the longest wait between client-side retries of a
+// transaction.
+// Default: 1s
+func RetryMaxBackoff(backoff time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.retryMaxBackoff = backoff
+	}
+}
+
 // WithEnablePrometheusStats marks whether Prometheus metrics provided by the Postgres
 // clients being used by the datastore are enabled.
 //
@@ -244,6 +378,29 @@ func EnableTracing() Option {
 	}
 }
 
+// GCBatchSize is the maximum number of rows deleted by each garbage collection
+// transaction. Smaller batches hold their locks for less time, and leave less
+// to be vacuumed at once.
+//
+// This value defaults to 1000.
+func GCBatchSize(size uint64) Option {
+	return func(po *postgresOptions) {
+		po.gcBatchSize = size
+	}
+}
+
+// GCBatchDelay is the time that garbage collection waits after each full batch
+// of deletes, which limits the rate at which it writes. A garbage collection
+// pass stops at its maximum operation time, and the remaining rows are deleted
+// by the next pass.
+//
+// This value defaults to not waiting between batches.
+func GCBatchDelay(delay time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.gcBatchDelay = delay
+	}
+}
+
 // GCEnabled indicates whether garbage collection is enabled.
 //
 // GC is enabled by default.
@@ -273,3 +430,293 @@ func MigrationPhase(phase string) Option {
 		po.migrationPhase = phase
 	}
 }
+
+// ReadReplicaURLs is the connection URLs of streaming replicas of the primary database, to which
+// snapshot reads are routed in turn. A read falls back to the primary database whenever the
+// replica has not yet replayed the transaction of the requested revision.
+//
+// Reads are made against the primary database by default.
+func ReadReplicaURLs(urls ...string) Option {
+	return func(po *postgresOptions) {
+		po.readReplicaURLs = urls
+	}
+}
+
+// StatementCacheMode is how queries are prepared on each connection:
+//
+//   - `prepare` prepares each query as a named statement which is reused by
+//     later executions of the query on the connection
+//   - `describe` caches only the description of each query, executing it as an
+//     unnamed statement, which is safe behind poolers in transaction mode
+//   - `disabled` describes each query every time it is executed
+//   - `simple-protocol` sends queries with their arguments interpolated over the
+//     simple protocol, for poolers which do not support the extended protocol
+//
+// This value defaults to the `statement_cache_mode` of the connection string,
+// which is `prepare` when unset.
+func StatementCacheMode(mode string) Option {
+	return func(po *postgresOptions) {
+		po.statementCacheMode = mode
+	}
+}
+
+// StatementCacheCapacity is the number of queries whose statements are cached
+// by each connection in the `prepare` and `describe` statement cache modes.
+//
+// This value defaults to the `statement_cache_capacity` of the connection
+// string, which is 512 when unset.
+func StatementCacheCapacity(capacity int) Option {
+	return func(po *postgresOptions) {
+		po.statementCacheCapacity = &capacity
+	}
+}
+
+// SlowQueryThreshold is the duration after which a relationship query is
+// logged as slow, along with its plan.
+//
+// Slow queries are not logged by default.
+func SlowQueryThreshold(threshold time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.slowQueryThreshold = threshold
+	}
+}
+
+// SlowQueryExplainAnalyze re-runs each slow query with EXPLAIN (ANALYZE,
+// BUFFERS), so that its logged plan includes the actual row counts, timings and
+// buffer usage rather than the estimates of the planner.
+//
+// Slow queries are only explained without being re-run by default.
+func SlowQueryExplainAnalyze(explainAnalyze bool) Option {
+	return func(po *postgresOptions) {
+		po.slowQueryExplainAnalyze = explainAnalyze
+	}
+}
+
+// TransactionPooling configures the driver to run behind a pooler in
+// transaction pooling mode, such as PgBouncer, which may run each transaction on
+// a different server session. No state is held on a session across
+// transactions: statements are executed unnamed rather than prepared, unless
+// the `simple-protocol` statement cache mode is configured, and watches poll for
+// new transactions rather than listening for their notifications.
+//
+// Transaction pooling is disabled by default.
+func TransactionPooling(enabled bool) Option {
+	return func(po *postgresOptions) {
+		po.transactionPooling = enabled
+	}
+}
+
+// YugabyteDB adapts the driver to the YSQL API of a YugabyteDB cluster:
+//
+//   - Transactions which fail on a conflict between distributed transactions,
+//     which some versions of YugabyteDB raise as internal errors rather than as
+//     serialization failures, are retried.
+//   - Watches poll for new transactions rather than listening for their
+//     notifications, which are not delivered by YugabyteDB.
+//
+// The relationships table is sharded by the hash of their resource by the
+// migrations of a YugabyteDB database, regardless of this option.
+//
+// YugabyteDB compatibility is disabled by default.
+func YugabyteDB(enabled bool) Option {
+	return func(po *postgresOptions) {
+		po.yugabyte = enabled
+	}
+}
+
+// WatchMode is how watches find the transactions committed after their revision:
+//   - "polling" queries the transactions table for new transactions, as each
+//     transaction is committed or at an interval
+//   - "logical-replication" streams the changes of each transaction from a
+//     temporary logical replication slot, which requires wal_level=logical and
+//     a connection which can replicate, and cannot be used with transaction
+//     pooling
+//
+// Watches poll by default.
+func WatchMode(mode string) Option {
+	return func(po *postgresOptions) {
+		po.watchMode = mode
+	}
+}
+
+// AuthMethod is how the password of each new connection is found:
+//   - "password" uses the password of the connection string, if any
+//   - "aws-iam" signs an RDS IAM authentication token with the default
+//     credentials of the AWS SDK
+//   - "gcp-iam" uses an access token of the application default credentials
+//     of Google Cloud, for Cloud SQL IAM database authentication
+//
+// Tokens are refreshed as new connections are opened, so connections should
+// not outlive the expiry of their token where the server requires that.
+// Defaults to "password".
+func AuthMethod(method string) Option {
+	return func(po *postgresOptions) {
+		po.credentials.AuthMethod = method
+	}
+}
+
+// AWSRegion is the region of the RDS instance signed into its IAM
+// authentication tokens.
+//
+// This value defaults to the region of the AWS SDK configuration.
+func AWSRegion(region string) Option {
+	return func(po *postgresOptions) {
+		po.credentials.AWSRegion = region
+	}
+}
+
+// ClientCertificate is the path of the PEM encoded certificate and key
+// presented by each connection for mutual TLS authentication, which requires
+// that the connection string enables TLS.
+func ClientCertificate(certPath, keyPath string) Option {
+	return func(po *postgresOptions) {
+		po.credentials.ClientCertPath = certPath
+		po.credentials.ClientKeyPath = keyPath
+	}
+}
+
+// RootCertificate is the path of the PEM encoded certificate authority which
+// must have signed the certificate of the server, when the sslmode of the
+// connection string verifies it.
+func RootCertificate(path string) Option {
+	return func(po *postgresOptions) {
+		po.credentials.RootCertPath = path
+	}
+}
+
+// ReadStatementTimeout is the statement_timeout set on each snapshot read
+// transaction.
+//
+// This value defaults to the statement_timeout of the session.
+func ReadStatementTimeout(timeout time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.readTimeouts.statementTimeout = timeout
+	}
+}
+
+// ReadLockTimeout is the lock_timeout set on each snapshot read transaction.
+//
+// This value defaults to the lock_timeout of the session.
+func ReadLockTimeout(timeout time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.readTimeouts.lockTimeout = timeout
+	}
+}
+
+// WriteStatementTimeout is the statement_timeout set on each read-write
+// transaction.
+//
+// This value defaults to the statement_timeout of the session.
+func WriteStatementTimeout(timeout time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.writeTimeouts.statementTimeout = timeout
+	}
+}
+
+// WriteLockTimeout is the lock_timeout set on each read-write transaction.
+//
+// This value defaults to the lock_timeout of the session.
+func WriteLockTimeout(timeout time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.writeTimeouts.lockTimeout = timeout
+	}
+}
+
+// GCStatementTimeout is the statement_timeout set on each garbage collection
+// transaction.
+//
+// This value defaults to the statement_timeout of the session.
+func GCStatementTimeout(timeout time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.gcTimeouts.statementTimeout = timeout
+	}
+}
+
+// GCLockTimeout is the lock_timeout set on each garbage collection transaction.
+//
+// This value defaults to the lock_timeout of the session.
+func GCLockTimeout(timeout time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.gcTimeouts.lockTimeout = timeout
+	}
+}
+
+// ReadConnMaxIdleTime overrides ConnMaxIdleTime for the read pool, which
+// serves snapshot reads, revisions and watches.
+func ReadConnMaxIdleTime(idle time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.readPoolOpts.connMaxIdleTime = &idle
+	}
+}
+
+// ReadConnMaxLifetime overrides ConnMaxLifetime for the read pool.
+func ReadConnMaxLifetime(lifetime time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.readPoolOpts.connMaxLifetime = &lifetime
+	}
+}
+
+// ReadMaxOpenConns overrides MaxOpenConns for the read pool.
+func ReadMaxOpenConns(conns int) Option {
+	return func(po *postgresOptions) {
+		po.readPoolOpts.maxOpenConns = &conns
+	}
+}
+
+// ReadMinOpenConns overrides MinOpenConns for the read pool.
+func ReadMinOpenConns(conns int) Option {
+	return func(po *postgresOptions) {
+		po.readPoolOpts.minOpenConns = &conns
+	}
+}
+
+// ReadTargetSessionAttrs is the libpq target_session_attrs, such as
+// `prefer-standby`, which the connections of the read pool must satisfy when
+// the connection string lists multiple hosts.
+//
+// This value defaults to that of the connection string.
+func ReadTargetSessionAttrs(attrs string) Option {
+	return func(po *postgresOptions) {
+		po.readPoolOpts.targetSessionAttrs = attrs
+	}
+}
+
+// WriteConnMaxIdleTime overrides ConnMaxIdleTime for the write pool, which
+// serves read-write transactions and garbage collection.
+func WriteConnMaxIdleTime(idle time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.writePoolOpts.connMaxIdleTime = &idle
+	}
+}
+
+// WriteConnMaxLifetime overrides ConnMaxLifetime for the write pool.
+func WriteConnMaxLifetime(lifetime time.Duration) Option {
+	return func(po *postgresOptions) {
+		po.writePoolOpts.connMaxLifetime = &lifetime
+	}
+}
+
+// WriteMaxOpenConns overrides MaxOpenConns for the write pool.
+func WriteMaxOpenConns(conns int) Option {
+	return func(po *postgresOptions) {
+		po.writePoolOpts.maxOpenConns = &conns
+	}
+}
+
+// WriteMinOpenConns overrides MinOpenConns for the write pool.
+func WriteMinOpenConns(conns int) Option {
+	return func(po *postgresOptions) {
+		po.writePoolOpts.minOpenConns = &conns
+	}
+}
+
+// WriteTargetSessionAttrs is the libpq target_session_attrs, such as
+// `read-write`, which the connections of the write pool must satisfy when the
+// connection string lists multiple hosts.
+//
+// This value defaults to that of the connection string.
+func WriteTargetSessionAttrs(attrs string) Option {
+	return func(po *postgresOptions) {
+		po.writePoolOpts.targetSessionAttrs = attrs
+	}
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/jackc/pgconn/stmtcache"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureStatementCache(t *testing.T) {
+	testCases := []struct {
+		connString           string
+		options              []Option
+		expectedCacheMode    int
+		expectedCapacity     int
+		expectedSimpleProto  bool
+		expectedCacheEnabled bool
+	}{
+		{"postgres://localhost", nil, stmtcache.ModePrepare, 512, false, true},
+		{"postgres://localhost?statement_cache_mode=describe", nil, stmtcache.ModeDescribe, 512, false, true},
+		{"postgres://localhost?statement_cache_mode=describe", []Option{StatementCacheCapacity(16)}, stmtcache.ModeDescribe, 16, false, true},
+		{"postgres://localhost", []Option{StatementCacheMode("describe")}, stmtcache.ModeDescribe, 512, false, true},
+		{"postgres://localhost?statement_cache_mode=describe", []Option{StatementCacheMode("prepare"), StatementCacheCapacity(32)}, stmtcache.ModePrepare, 32, false, true},
+		{"postgres://localhost", []Option{StatementCacheMode("disabled")}, 0, 0, false, false},
+		{"postgres://localhost", []Option{StatementCacheMode("simple-protocol")}, 0, 0, true, false},
+		{"postgres://localhost", []Option{TransactionPooling(true)}, stmtcache.ModeDescribe, 512, false, true},
+		{"postgres://localhost", []Option{TransactionPooling(true), StatementCacheMode("simple-protocol")}, 0, 0, true, false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.connString, func(t *testing.T) {
+			config, err := generateConfig(tc.options)
+			require.NoError(t, err)
+
+			connConfig, err := pgx.ParseConfig(tc.connString)
+			require.NoError(t, err)
+
+			configureStatementCache(config, connConfig)
+			require.Equal(t, tc.expectedSimpleProto, connConfig.PreferSimpleProtocol)
+			if !tc.expectedCacheEnabled {
+				require.Nil(t, connConfig.BuildStatementCache)
+				return
+			}
+
+			cache := connConfig.BuildStatementCache(nil)
+			require.Equal(t, tc.expectedCacheMode, cache.Mode())
+			require.Equal(t, tc.expectedCapacity, cache.Cap())
+		})
+	}
+}
+
+func TestUnknownStatementCacheMode(t *testing.T) {
+	_, err := generateConfig([]Option{StatementCacheMode("unprepared")})
+	require.Error(t, err)
+}
+
+func TestTransactionPoolingPreparedStatements(t *testing.T) {
+	_, err := generateConfig([]Option{TransactionPooling(true), StatementCacheMode("prepare")})
+	require.Error(t, err)
+}
+
+func TestYugabyteLogicalReplication(t *testing.T) {
+	_, err := generateConfig([]Option{YugabyteDB(true), WatchMode("logical-replication")})
+	require.Error(t, err)
+
+	_, err = generateConfig([]Option{YugabyteDB(true)})
+	require.NoError(t, err)
+}
+
+func TestUnknownAuthMethod(t *testing.T) {
+	_, err := generateConfig([]Option{AuthMethod("kerberos")})
+	require.Error(t, err)
+
+	_, err = generateConfig([]Option{AuthMethod("aws-iam")})
+	require.NoError(t, err)
+}
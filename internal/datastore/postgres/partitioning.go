@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/postgres/migrations"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	pgMissingTable = "42P01"
+
+	// queryNextXID returns the ID which will be assigned to the next transaction, without
+	// assigning one.
+	queryNextXID = `SELECT pg_snapshot_xmax(pg_current_snapshot())::text::bigint;`
+
+	// queryDuplicateLivingRelationship returns a relationship written by the transaction of which
+	// there is more than one living row, if any.
+	//
+	//   $1 the tenant of the transaction
+	//   $2 the transaction ID
+	queryDuplicateLivingRelationship = `
+	SELECT %[1]s FROM %[2]s
+	WHERE %[3]s = $1 AND %[4]s = '%[5]d' AND (%[1]s) IN (
+		SELECT %[1]s FROM %[2]s WHERE %[3]s = $1 AND %[6]s = $2
+	)
+	GROUP BY %[1]s
+	HAVING COUNT(*) > 1
+	LIMIT 1;`
+)
+
+var duplicateLivingRelationshipQuery = fmt.Sprintf(
+	queryDuplicateLivingRelationship,
+	colNamespace+", "+colObjectID+", "+colRelation+", "+colUsersetNamespace+", "+colUsersetObjectID+", "+colUsersetRelation,
+	tableTuple,
+	colTenant,
+	colDeletedXid,
+	liveDeletedTxnID,
+	colCreatedXid,
+)
+
+// readPartitioning returns the partitioning of the relationships table, which is unpartitioned if
+// the database has not yet been migrated to record it.
+func readPartitioning(ctx context.Context, dbpool *pgxpool.Pool) (migrations.Partitioning, error) {
+	partitioning, err := migrations.ReadPartitioning(ctx, dbpool)
+	var pgerr *pgconn.PgError
+	if errors.As(err, &pgerr) && pgerr.Code == pgMissingTable {
+		return migrations.Partitioning{}, nil
+	}
+	return partitioning, err
+}
+
+// readDistribution returns the distribution column of the relationships table, which is
+// undistributed if the database has not yet been migrated to record it.
+func readDistribution(ctx context.Context, dbpool *pgxpool.Pool) (migrations.DistributionColumn, error) {
+	distribution, err := migrations.ReadDistribution(ctx, dbpool)
+	var pgerr *pgconn.PgError
+	if errors.As(err, &pgerr) && pgerr.Code == pgMissingTable {
+		return migrations.NoDistribution, nil
+	}
+	return distribution, err
+}
+
+// ensurePartitions creates the range partitions for the upcoming transactions, if the
+// relationships table is partitioned by range.
+func (pgd *pgDatastore) ensurePartitions(ctx context.Context) error {
+	if pgd.partitioning.Strategy != migrations.CreatedXIDRangePartitioning {
+		return nil
+	}
+
+	// Creating a partition locks the whole relationships table, so the lock timeout of garbage
+	// collection bounds how long it can block relationship reads and writes.
+	return pgd.writePool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if err := pgd.gcTimeouts.setLocal(ctx, tx); err != nil {
+			return err
+		}
+
+		var nextXID uint64
+		if err := tx.QueryRow(ctx, queryNextXID).Scan(&nextXID); err != nil {
+			return fmt.Errorf("unable to load next transaction ID: %w", err)
+		}
+
+		return migrations.EnsureRangePartitions(ctx, tx, pgd.partitioning.Size, nextXID, nextXID)
+	})
+}
+
+// checkLivingRelationshipsUnique fails with a relationship exists error if the transaction
+// created a relationship which was already living. It stands in for the unique constraint on
+// living relationships, which cannot be created on a table partitioned by the created
+// transaction.
+func checkLivingRelationshipsUnique(ctx context.Context, tx pgx.Tx, tenant string, xid xid8) error {
+	duplicate := &core.RelationTuple{
+		ResourceAndRelation: &core.ObjectAndRelation{},
+		Subject:             &core.ObjectAndRelation{},
+	}
+
+	err := tx.QueryRow(ctx, duplicateLivingRelationshipQuery, tenant, xid).Scan(
+		&duplicate.ResourceAndRelation.Namespace,
+		&duplicate.ResourceAndRelation.ObjectId,
+		&duplicate.ResourceAndRelation.Relation,
+		&duplicate.Subject.Namespace,
+		&duplicate.Subject.ObjectId,
+		&duplicate.Subject.Relation,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to check for duplicate relationships: %w", err)
+	}
+
+	return common.NewCreateRelationshipExistsError(duplicate)
+}
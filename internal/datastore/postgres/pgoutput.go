@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// The pgoutput messages decoded for watches, as documented in
+// https://www.postgresql.org/docs/current/protocol-logicalrep-message-formats.html
+const (
+	pgoutputBegin    = 'B'
+	pgoutputCommit   = 'C'
+	pgoutputRelation = 'R'
+	pgoutputInsert   = 'I'
+	pgoutputUpdate   = 'U'
+
+	pgoutputNewTuple     = 'N'
+	pgoutputKeyTuple     = 'K'
+	pgoutputOldTuple     = 'O'
+	pgoutputNullValue    = 'n'
+	pgoutputToastedValue = 'u'
+	pgoutputTextValue    = 't'
+	pgoutputBinaryValue  = 'b'
+)
+
+var errPgoutputTruncated = errors.New("truncated pgoutput message")
+
+// pgoutputRow is a row of a published table, by column name. A null value is nil.
+type pgoutputRow struct {
+	values map[string]*string
+
+	// unchanged is set if any value is an unchanged TOASTed value of an updated row, which is
+	// not sent and is therefore missing from the values.
+	unchanged bool
+}
+
+// pgoutputMessage is a decoded pgoutput message. Only the fields of the kind of message are set.
+type pgoutputMessage struct {
+	kind byte
+
+	// lsn is the end of a committed transaction.
+	lsn uint64
+
+	// table and row are the table and new row of an insert or update.
+	table string
+	row   pgoutputRow
+}
+
+type pgoutputRelationInfo struct {
+	table   string
+	columns []string
+}
+
+// pgoutputDecoder decodes the messages of the pgoutput plugin, tracking the relations which they
+// reference.
+type pgoutputDecoder struct {
+	relations map[uint32]pgoutputRelationInfo
+}
+
+func (d *pgoutputDecoder) decode(data []byte) (pgoutputMessage, error) {
+	r := &pgoutputReader{buf: data}
+	msg := pgoutputMessage{kind: r.byte()}
+
+	switch msg.kind {
+	case pgoutputCommit:
+		r.byte()   // flags
+		r.uint64() // commit LSN
+		msg.lsn = r.uint64()
+
+	case pgoutputRelation:
+		id := r.uint32()
+		r.cstring() // schema
+		info := pgoutputRelationInfo{table: r.cstring()}
+		r.byte() // replica identity
+		columns := int(r.uint16())
+		for i := 0; i < columns && r.err == nil; i++ {
+			r.byte() // flags
+			info.columns = append(info.columns, r.cstring())
+			r.uint32() // type
+			r.uint32() // type modifier
+		}
+		if d.relations == nil {
+			d.relations = make(map[uint32]pgoutputRelationInfo)
+		}
+		d.relations[id] = info
+
+	case pgoutputInsert, pgoutputUpdate:
+		info, ok := d.relations[r.uint32()]
+		if !ok && r.err == nil {
+			return msg, fmt.Errorf("pgoutput %c message for unknown relation", msg.kind)
+		}
+		msg.table = info.table
+
+		kind := r.byte()
+		if kind == pgoutputKeyTuple || kind == pgoutputOldTuple {
+			r.tuple(info.columns)
+			kind = r.byte()
+		}
+		if kind != pgoutputNewTuple && r.err == nil {
+			return msg, fmt.Errorf("unexpected pgoutput tuple kind %c", kind)
+		}
+		msg.row = r.tuple(info.columns)
+	}
+
+	return msg, r.err
+}
+
+// pgoutputReader reads the big-endian fields of a message, recording the first error so that it
+// can be checked once the message has been read.
+type pgoutputReader struct {
+	buf []byte
+	err error
+}
+
+func (r *pgoutputReader) next(n int) []byte {
+	if r.err != nil || len(r.buf) < n {
+		if r.err == nil {
+			r.err = errPgoutputTruncated
+		}
+
+		// Fixed size fields still read as zero, while values are read as empty.
+		if n > 8 {
+			return nil
+		}
+		return make([]byte, n)
+	}
+	next := r.buf[:n]
+	r.buf = r.buf[n:]
+	return next
+}
+
+func (r *pgoutputReader) byte() byte {
+	return r.next(1)[0]
+}
+
+func (r *pgoutputReader) uint16() uint16 {
+	return binary.BigEndian.Uint16(r.next(2))
+}
+
+func (r *pgoutputReader) uint32() uint32 {
+	return binary.BigEndian.Uint32(r.next(4))
+}
+
+func (r *pgoutputReader) uint64() uint64 {
+	return binary.BigEndian.Uint64(r.next(8))
+}
+
+func (r *pgoutputReader) cstring() string {
+	for i, b := range r.buf {
+		if b == 0 {
+			s := string(r.buf[:i])
+			r.buf = r.buf[i+1:]
+			return s
+		}
+	}
+	if r.err == nil {
+		r.err = errPgoutputTruncated
+	}
+	return ""
+}
+
+func (r *pgoutputReader) tuple(columns []string) pgoutputRow {
+	row := pgoutputRow{values: make(map[string]*string, len(columns))}
+	count := int(r.uint16())
+	for i := 0; i < count && r.err == nil; i++ {
+		var column string
+		if i < len(columns) {
+			column = columns[i]
+		}
+
+		switch kind := r.byte(); kind {
+		case pgoutputNullValue:
+			row.values[column] = nil
+		case pgoutputToastedValue:
+			row.unchanged = true
+		case pgoutputTextValue, pgoutputBinaryValue:
+			value := string(r.next(int(r.uint32())))
+			row.values[column] = &value
+		default:
+			if r.err == nil {
+				r.err = fmt.Errorf("unexpected pgoutput value kind %c", kind)
+			}
+		}
+	}
+	return row
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type pgoutputBuilder []byte
+
+func (b pgoutputBuilder) byte(v byte) pgoutputBuilder {
+	return append(b, v)
+}
+
+func (b pgoutputBuilder) uint16(v uint16) pgoutputBuilder {
+	return binary.BigEndian.AppendUint16(b, v)
+}
+
+func (b pgoutputBuilder) uint32(v uint32) pgoutputBuilder {
+	return binary.BigEndian.AppendUint32(b, v)
+}
+
+func (b pgoutputBuilder) uint64(v uint64) pgoutputBuilder {
+	return binary.BigEndian.AppendUint64(b, v)
+}
+
+func (b pgoutputBuilder) cstring(v string) pgoutputBuilder {
+	return append(append(b, v...), 0)
+}
+
+func (b pgoutputBuilder) text(v string) pgoutputBuilder {
+	return append(b.byte(pgoutputTextValue).uint32(uint32(len(v))), v...)
+}
+
+func relationMessage(id uint32, table string, columns ...string) []byte {
+	b := pgoutputBuilder{}.byte(pgoutputRelation).uint32(id).cstring("public").cstring(table).byte('d').uint16(uint16(len(columns)))
+	for _, column := range columns {
+		b = b.byte(0).cstring(column).uint32(25).uint32(0xffffffff)
+	}
+	return b
+}
+
+func TestPgoutputDecode(t *testing.T) {
+	require := require.New(t)
+	decoder := pgoutputDecoder{}
+
+	msg, err := decoder.decode(relationMessage(16384, "relation_tuple", "namespace", "metadata", "deleted_xid"))
+	require.NoError(err)
+	require.Equal(byte(pgoutputRelation), msg.kind)
+
+	msg, err = decoder.decode(pgoutputBuilder{}.byte(pgoutputBegin).uint64(1).uint64(2).uint32(3))
+	require.NoError(err)
+	require.Equal(byte(pgoutputBegin), msg.kind)
+
+	insert := pgoutputBuilder{}.byte(pgoutputInsert).uint32(16384).byte(pgoutputNewTuple).uint16(3).
+		text("document").byte(pgoutputNullValue).text("9223372036854775807")
+	msg, err = decoder.decode(insert)
+	require.NoError(err)
+	require.Equal(byte(pgoutputInsert), msg.kind)
+	require.Equal("relation_tuple", msg.table)
+	require.False(msg.row.unchanged)
+	require.Equal("document", rowString(msg.row, "namespace"))
+	require.Contains(msg.row.values, "metadata")
+	require.Nil(msg.row.values["metadata"])
+
+	xid, err := rowXID(msg.row, "deleted_xid")
+	require.NoError(err)
+	require.Equal(uint64(liveDeletedTxnID), xid.Uint)
+
+	update := pgoutputBuilder{}.byte(pgoutputUpdate).uint32(16384).
+		byte(pgoutputKeyTuple).uint16(1).text("document").
+		byte(pgoutputNewTuple).uint16(3).text("document").byte(pgoutputToastedValue).text("42")
+	msg, err = decoder.decode(update)
+	require.NoError(err)
+	require.Equal(byte(pgoutputUpdate), msg.kind)
+	require.True(msg.row.unchanged)
+	require.NotContains(msg.row.values, "metadata")
+
+	xid, err = rowXID(msg.row, "deleted_xid")
+	require.NoError(err)
+	require.Equal(uint64(42), xid.Uint)
+
+	msg, err = decoder.decode(pgoutputBuilder{}.byte(pgoutputCommit).byte(0).uint64(100).uint64(200).uint64(0))
+	require.NoError(err)
+	require.Equal(byte(pgoutputCommit), msg.kind)
+	require.Equal(uint64(200), msg.lsn)
+}
+
+func TestPgoutputDecodeErrors(t *testing.T) {
+	decoder := pgoutputDecoder{}
+
+	_, err := decoder.decode(pgoutputBuilder{}.byte(pgoutputInsert).uint32(1).byte(pgoutputNewTuple).uint16(0))
+	require.ErrorContains(t, err, "unknown relation")
+
+	_, err = decoder.decode(relationMessage(1, "relation_tuple", "namespace"))
+	require.NoError(t, err)
+
+	_, err = decoder.decode(pgoutputBuilder{}.byte(pgoutputInsert).uint32(1).byte(pgoutputNewTuple).uint16(1).byte(pgoutputTextValue).uint32(10))
+	require.ErrorIs(t, err, errPgoutputTruncated)
+
+	_, err = decoder.decode(pgoutputBuilder{}.byte(pgoutputCommit).byte(0).uint64(1))
+	require.ErrorIs(t, err, errPgoutputTruncated)
+}
+
+func TestParseLSN(t *testing.T) {
+	lsn, err := parseLSN("16/B374D848")
+	require.NoError(t, err)
+	require.Equal(t, uint64(0x16B374D848), lsn)
+	require.Equal(t, "16/B374D848", formatLSN(lsn))
+
+	_, err = parseLSN("B374D848")
+	require.Error(t, err)
+}
@@ -5,11 +5,13 @@ import (
 	dbsql "database/sql"
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/IBM/pgxpoolprometheus"
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jackc/pgconn"
+	"github.com/jackc/pgconn/stmtcache"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/jackc/pgx/v4/stdlib"
@@ -20,6 +22,7 @@ import (
 
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/datastore/common/revisions"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
 	"github.com/authzed/spicedb/internal/datastore/postgres/migrations"
 	"github.com/authzed/spicedb/internal/datastore/proxy"
@@ -54,6 +57,10 @@ const (
 	colCaveatDefinition  = "definition"
 	colCaveatContextName = "caveat_name"
 	colCaveatContext     = "caveat_context"
+	colExpiration        = "expiration"
+	colMetadata          = "metadata"
+	colCreatedAt         = "created_at"
+	colTenant            = "tenant_id"
 
 	errUnableToInstantiate = "unable to instantiate datastore: %w"
 
@@ -70,12 +77,12 @@ const (
 
 	tracingDriverName = "postgres-tracing"
 
-	batchDeleteSize = 1000
-
 	pgSerializationFailure      = "40001"
+	pgDeadlockDetected          = "40P01"
 	pgUniqueConstraintViolation = "23505"
+	pgInternalError             = "XX000"
 
-	livingTupleConstraint = "uq_relation_tuple_living_xid"
+	livingTupleConstraint = "uq_relation_tuple_living_tenant_xid"
 )
 
 func init() {
@@ -92,8 +99,18 @@ var (
 			Limit(1)
 
 	createTxn = fmt.Sprintf(
-		"INSERT INTO %s DEFAULT VALUES RETURNING %s, pg_snapshot_xmin(%s)",
+		"INSERT INTO %s (%s) VALUES ($1) RETURNING %s, pg_snapshot_xmin(%s)",
+		tableTransaction,
+		colTenant,
+		colXID,
+		colSnapshot,
+	)
+
+	createTxnWithMetadata = fmt.Sprintf(
+		"INSERT INTO %s (%s, %s) VALUES ($1, $2) RETURNING %s, pg_snapshot_xmin(%s)",
 		tableTransaction,
+		colTenant,
+		colMetadata,
 		colXID,
 		colSnapshot,
 	)
@@ -138,38 +155,76 @@ func newPostgresDatastore(
 			Msg("postgres configured to use intermediate migration phase")
 	}
 
-	// config must be initialized by ParseConfig
-	pgxConfig, err := pgxpool.ParseConfig(url)
+	initializationContext, cancelInit := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelInit()
+
+	password, err := config.credentials.NewPasswordFunc()
 	if err != nil {
 		return nil, fmt.Errorf(errUnableToInstantiate, err)
 	}
 
-	configurePool(config, pgxConfig)
-
-	initializationContext, cancelInit := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancelInit()
+	// Snapshot reads are made from a separate pool from read-write transactions, so that neither
+	// can starve the other of connections.
+	readPool, err := connectPool(initializationContext, url, config, config.readPoolOpts, password)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
 
-	dbpool, err := pgxpool.ConnectConfig(initializationContext, pgxConfig)
+	writePool, err := connectPool(initializationContext, url, config, config.writePoolOpts, password)
 	if err != nil {
 		return nil, fmt.Errorf(errUnableToInstantiate, err)
 	}
 
 	// Verify that the server supports commit timestamps
 	var trackTSOn string
-	if err := dbpool.
+	if err := readPool.
 		QueryRow(initializationContext, "SHOW track_commit_timestamp;").
 		Scan(&trackTSOn); err != nil {
 		return nil, fmt.Errorf(errUnableToInstantiate, err)
 	}
 
+	partitioning, err := readPartitioning(initializationContext, writePool)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+
+	distribution, err := readDistribution(initializationContext, writePool)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+	if distribution != migrations.NoDistribution && watchModes[config.watchMode] == watchLogicalReplication {
+		return nil, fmt.Errorf(errUnableToInstantiate, fmt.Errorf("watch mode logical-replication cannot be used with a distributed relationships table"))
+	}
+
+	replicas, err := connectReplicas(initializationContext, config, password)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+
 	watchEnabled := trackTSOn == "on"
 	if !watchEnabled {
 		log.Warn().Msg("watch API disabled, postgres must be run with track_commit_timestamp=on")
 	}
 
+	watchMode := watchModes[config.watchMode]
+	if watchEnabled && watchMode == watchLogicalReplication {
+		var walLevel string
+		if err := writePool.QueryRow(initializationContext, "SHOW wal_level;").Scan(&walLevel); err != nil {
+			return nil, fmt.Errorf(errUnableToInstantiate, err)
+		}
+		if walLevel != "logical" {
+			return nil, fmt.Errorf(errUnableToInstantiate, fmt.Errorf("watch mode logical-replication requires wal_level=logical, found %s", walLevel))
+		}
+	}
+
 	if config.enablePrometheusStats {
-		collector := pgxpoolprometheus.NewCollector(dbpool, map[string]string{"db_name": "spicedb"})
-		if err := prometheus.Register(collector); err != nil {
+		for usage, pool := range map[string]*pgxpool.Pool{"read": readPool, "write": writePool} {
+			collector := pgxpoolprometheus.NewCollector(pool, map[string]string{"db_name": "spicedb", "pool_usage": usage})
+			if err := prometheus.Register(collector); err != nil {
+				return nil, fmt.Errorf(errUnableToInstantiate, err)
+			}
+		}
+		if err := prometheus.Register(newTransactionAgeCollector(readPool)); err != nil {
 			return nil, fmt.Errorf(errUnableToInstantiate, err)
 		}
 		if err := common.RegisterGCMetrics(); err != nil {
@@ -177,6 +232,14 @@ func newPostgresDatastore(
 		}
 	}
 
+	// Watches are woken as each transaction commits, rather than only polling for new revisions.
+	// A pooler in transaction pooling mode does not deliver notifications, as LISTEN is bound to
+	// a session, and nor does YugabyteDB.
+	var notifier *transactionNotifier
+	if watchEnabled && !config.transactionPooling && !config.yugabyte && watchMode == watchPolling {
+		notifier = newTransactionNotifier(writePool.Config().ConnConfig.Copy(), password)
+	}
+
 	gcCtx, cancelGc := context.WithCancel(context.Background())
 
 	quantizationPeriodNanos := config.revisionQuantization.Nanoseconds()
@@ -208,24 +271,53 @@ func newPostgresDatastore(
 			maxRevisionStaleness,
 		),
 		dburl:                   url,
-		dbpool:                  dbpool,
+		credentials:             config.credentials,
+		password:                password,
+		readPool:                readPool,
+		writePool:               writePool,
+		replicas:                replicas,
+		replicaCounter:          &atomic.Uint32{},
 		watchBufferLength:       config.watchBufferLength,
 		optimizedRevisionQuery:  revisionQuery,
 		validTransactionQuery:   validTransactionQuery,
 		gcWindow:                config.gcWindow,
 		gcInterval:              config.gcInterval,
 		gcTimeout:               config.gcMaxOperationTime,
+		gcBatchSize:             config.gcBatchSize,
+		gcBatchDelay:            config.gcBatchDelay,
 		analyzeBeforeStatistics: config.analyzeBeforeStatistics,
 		usersetBatchSize:        config.splitAtUsersetCount,
 		watchEnabled:            watchEnabled,
 		gcCtx:                   gcCtx,
 		cancelGc:                cancelGc,
+		notifier:                notifier,
+		partitioning:            partitioning,
 		readTxOptions:           pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly},
-		maxRetries:              config.maxRetries,
+		retryPolicy: common.RetryPolicy{
+			MaxRetries:     config.maxRetries,
+			InitialBackoff: config.retryInitialBackoff,
+			MaxBackoff:     config.retryMaxBackoff,
+		},
+		slowQueryThreshold:      config.slowQueryThreshold,
+		slowQueryExplainAnalyze: config.slowQueryExplainAnalyze,
+		transactionPooling:      config.transactionPooling,
+		yugabyte:                config.yugabyte,
+		watchMode:               watchMode,
+		readTimeouts:            config.readTimeouts,
+		writeTimeouts:           config.writeTimeouts,
+		gcTimeouts:              config.gcTimeouts,
 	}
 
 	datastore.SetOptimizedRevisionFunc(datastore.optimizedRevisionFunc)
 
+	// Range partitions are otherwise only created by garbage collection, which may not run until
+	// after the first writes.
+	if config.gcEnabled {
+		if err := datastore.ensurePartitions(initializationContext); err != nil {
+			return nil, fmt.Errorf(errUnableToInstantiate, err)
+		}
+	}
+
 	// Start a goroutine for garbage collection.
 	if datastore.gcInterval > 0*time.Minute && config.gcEnabled {
 		datastore.gcGroup, datastore.gcCtx = errgroup.WithContext(datastore.gcCtx)
@@ -245,92 +337,190 @@ func newPostgresDatastore(
 	return datastore, nil
 }
 
-func configurePool(config postgresOptions, pgxConfig *pgxpool.Config) {
-	if config.maxOpenConns != nil {
-		pgxConfig.MaxConns = int32(*config.maxOpenConns)
+// targetSessionAttrsValidators are the validators of the libpq target_session_attrs which can be
+// configured for a connection pool. The empty string keeps the validator of the connection string.
+var targetSessionAttrsValidators = map[string]pgconn.ValidateConnectFunc{
+	"":               nil,
+	"any":            nil,
+	"read-write":     pgconn.ValidateConnectTargetSessionAttrsReadWrite,
+	"read-only":      pgconn.ValidateConnectTargetSessionAttrsReadOnly,
+	"primary":        pgconn.ValidateConnectTargetSessionAttrsPrimary,
+	"standby":        pgconn.ValidateConnectTargetSessionAttrsStandby,
+	"prefer-standby": pgconn.ValidateConnectTargetSessionAttrsPreferStandby,
+}
+
+func connectPool(ctx context.Context, url string, config postgresOptions, poolOpts poolOptions, password pgxcommon.PasswordFunc) (*pgxpool.Pool, error) {
+	// config must be initialized by ParseConfig
+	pgxConfig, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := configurePool(config, poolOpts, pgxConfig, password); err != nil {
+		return nil, err
+	}
+
+	return pgxpool.ConnectConfig(ctx, pgxConfig)
+}
+
+func configurePool(config postgresOptions, poolOpts poolOptions, pgxConfig *pgxpool.Config, password pgxcommon.PasswordFunc) error {
+	if err := config.credentials.ConfigureTLS(&pgxConfig.ConnConfig.Config); err != nil {
+		return err
+	}
+
+	// The password is found as each connection is opened, so that tokens are refreshed before
+	// they expire.
+	pgxConfig.BeforeConnect = func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+		return password(ctx, &connConfig.Config)
+	}
+
+	if poolOpts.maxOpenConns != nil {
+		pgxConfig.MaxConns = int32(*poolOpts.maxOpenConns)
 	}
-	if config.minOpenConns != nil {
-		pgxConfig.MinConns = int32(*config.minOpenConns)
+	if poolOpts.minOpenConns != nil {
+		pgxConfig.MinConns = int32(*poolOpts.minOpenConns)
 	}
-	if config.connMaxIdleTime != nil {
-		pgxConfig.MaxConnIdleTime = *config.connMaxIdleTime
+	if poolOpts.connMaxIdleTime != nil {
+		pgxConfig.MaxConnIdleTime = *poolOpts.connMaxIdleTime
 	}
-	if config.connMaxLifetime != nil {
-		pgxConfig.MaxConnLifetime = *config.connMaxLifetime
+	if poolOpts.connMaxLifetime != nil {
+		pgxConfig.MaxConnLifetime = *poolOpts.connMaxLifetime
 	}
 	if config.healthCheckPeriod != nil {
 		pgxConfig.HealthCheckPeriod = *config.healthCheckPeriod
 	}
+	if poolOpts.targetSessionAttrs != "" {
+		pgxConfig.ConnConfig.ValidateConnect = targetSessionAttrsValidators[poolOpts.targetSessionAttrs]
+	}
+
+	configureStatementCache(config, pgxConfig.ConnConfig)
 
 	pgxcommon.ConfigurePGXLogger(pgxConfig.ConnConfig)
+	return nil
+}
+
+func configureStatementCache(config postgresOptions, connConfig *pgx.ConnConfig) {
+	capacity := defaultStatementCacheCapacity
+	if config.statementCacheCapacity != nil {
+		capacity = *config.statementCacheCapacity
+	}
+
+	switch statementCacheModes[config.statementCacheMode] {
+	case statementCacheDefault:
+		if config.statementCacheCapacity != nil && connConfig.BuildStatementCache != nil {
+			// The mode of the connection string is kept, which can only be learned from a cache.
+			mode := connConfig.BuildStatementCache(nil).Mode()
+			connConfig.BuildStatementCache = buildStatementCache(mode, capacity)
+		}
+	case statementCachePrepare:
+		connConfig.BuildStatementCache = buildStatementCache(stmtcache.ModePrepare, capacity)
+	case statementCacheDescribe:
+		connConfig.BuildStatementCache = buildStatementCache(stmtcache.ModeDescribe, capacity)
+	case statementCacheDisabled:
+		connConfig.BuildStatementCache = nil
+	case statementCacheSimpleProtocol:
+		connConfig.BuildStatementCache = nil
+		connConfig.PreferSimpleProtocol = true
+	}
+}
+
+func buildStatementCache(mode int, capacity int) pgx.BuildStatementCacheFunc {
+	return func(conn *pgconn.PgConn) stmtcache.Cache {
+		return stmtcache.New(conn, mode, capacity)
+	}
 }
 
 type pgDatastore struct {
 	*revisions.CachedOptimizedRevisions
 
 	dburl                   string
-	dbpool                  *pgxpool.Pool
+	credentials             pgxcommon.Credentials
+	password                pgxcommon.PasswordFunc
+	readPool                *pgxpool.Pool
+	writePool               *pgxpool.Pool
+	replicas                []*readReplica
+	replicaCounter          *atomic.Uint32
 	watchBufferLength       uint16
 	optimizedRevisionQuery  string
 	validTransactionQuery   string
 	gcWindow                time.Duration
 	gcInterval              time.Duration
 	gcTimeout               time.Duration
+	gcBatchSize             uint64
+	gcBatchDelay            time.Duration
 	usersetBatchSize        uint16
 	analyzeBeforeStatistics bool
 	readTxOptions           pgx.TxOptions
-	maxRetries              uint8
+	retryPolicy             common.RetryPolicy
 	watchEnabled            bool
+	tenant                  string
 
 	gcGroup  *errgroup.Group
 	gcCtx    context.Context
 	cancelGc context.CancelFunc
-}
 
-func (pgd *pgDatastore) SnapshotReader(revRaw datastore.Revision) datastore.Reader {
-	rev := revRaw.(postgresRevision)
+	notifier     *transactionNotifier
+	partitioning migrations.Partitioning
 
-	createTxFunc := func(ctx context.Context) (pgx.Tx, common.TxCleanupFunc, error) {
-		tx, err := pgd.dbpool.BeginTx(ctx, pgd.readTxOptions)
-		if err != nil {
-			return nil, nil, err
-		}
+	slowQueryThreshold      time.Duration
+	slowQueryExplainAnalyze bool
+	transactionPooling      bool
+	yugabyte                bool
+	watchMode               watchMode
 
-		cleanup := func(ctx context.Context) {
-			if err := tx.Rollback(ctx); err != nil {
-				log.Ctx(ctx).Err(err).Msg("error running transaction cleanup function")
-			}
-		}
+	readTimeouts, writeTimeouts, gcTimeouts operationTimeouts
+}
 
-		return tx, cleanup, nil
-	}
+// SnapshotReader ignores consistency hints, as a read replica is only used once it has replayed
+// the requested revision, and Postgres has no way to trade consistency for latency on the primary.
+func (pgd *pgDatastore) SnapshotReader(revRaw datastore.Revision, _ ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	rev := revRaw.(postgresRevision)
+
+	createTxFunc := pgd.snapshotTxSource(rev)
 
 	querySplitter := common.TupleQuerySplitter{
-		Executor:         pgxcommon.NewPGXExecutor(createTxFunc),
+		Executor:         pgd.explainSlowQueries(createTxFunc, pgxcommon.NewPGXExecutor(createTxFunc)),
 		UsersetBatchSize: pgd.usersetBatchSize,
 	}
 
 	return &pgReader{
 		createTxFunc,
 		querySplitter,
-		buildLivingObjectFilterForRevision(rev),
+		filterToTenant(pgd.tenant, buildLivingObjectFilterForRevision(rev)),
+		schema.WithExpirationCutoff(revisionTimestamp(rev)),
 	}
 }
 
 func noCleanup(context.Context) {}
 
+// setLocal sets the timeouts on the transaction, until it ends.
+func (t operationTimeouts) setLocal(ctx context.Context, tx pgx.Tx) error {
+	return pgxcommon.SetLocalTimeouts(ctx, tx, t.statementTimeout, t.lockTimeout)
+}
+
 // ReadWriteTx tarts a read/write transaction, which will be committed if no error is
 // returned and rolled back if an error is returned.
 func (pgd *pgDatastore) ReadWriteTx(
 	ctx context.Context,
 	fn datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
-	var err error
-	for i := uint8(0); i <= pgd.maxRetries; i++ {
-		var newXID, newXmin xid8
-		err = pgd.dbpool.BeginTxFunc(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(tx pgx.Tx) error {
+	config := options.NewRWTOptionsWithOptions(opts...)
+
+	isRetryable := errorRetryable
+	if pgd.yugabyte {
+		isRetryable = yugabyteErrorRetryable
+	}
+
+	var newXID, newXmin xid8
+	_, err := common.RetryTx(ctx, pgd.retryPolicy, isRetryable, func(ctx context.Context) error {
+		return pgd.writePool.BeginTxFunc(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(tx pgx.Tx) error {
+			if err := pgd.writeTimeouts.setLocal(ctx, tx); err != nil {
+				return err
+			}
+
 			var err error
-			newXID, newXmin, err = createNewTransaction(ctx, tx)
+			newXID, newXmin, err = createNewTransaction(ctx, tx, pgd.tenant, config.Metadata)
 			if err != nil {
 				return err
 			}
@@ -340,7 +530,7 @@ func (pgd *pgDatastore) ReadWriteTx(
 			}
 
 			querySplitter := common.TupleQuerySplitter{
-				Executor:         pgxcommon.NewPGXExecutor(longLivedTx),
+				Executor:         pgd.explainSlowQueries(longLivedTx, pgxcommon.NewPGXExecutor(longLivedTx)),
 				UsersetBatchSize: pgd.usersetBatchSize,
 			}
 
@@ -348,24 +538,30 @@ func (pgd *pgDatastore) ReadWriteTx(
 				&pgReader{
 					longLivedTx,
 					querySplitter,
-					currentlyLivingObjects,
+					filterToTenant(pgd.tenant, currentlyLivingObjects),
+					// The transaction of the revision being written is timestamped with NOW().
+					schema.WithExpirationCutoff(sq.Expr("NOW()")),
 				},
 				tx,
 				newXID,
+				pgd.tenant,
 			}
 
-			return fn(rwt)
-		})
-		if err != nil {
-			if errorRetryable(err) {
-				continue
+			if err := fn(rwt); err != nil {
+				return err
 			}
-			return datastore.NoRevision, err
-		}
 
-		return postgresRevision{newXID, newXmin}, nil
+			if pgd.partitioning.Strategy == migrations.CreatedXIDRangePartitioning {
+				return checkLivingRelationshipsUnique(ctx, tx, pgd.tenant, newXID)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return datastore.NoRevision, err
 	}
-	return datastore.NoRevision, fmt.Errorf("max retries exceeded: %w", err)
+
+	return postgresRevision{newXID, newXmin}, nil
 }
 
 func (pgd *pgDatastore) Close() error {
@@ -376,11 +572,25 @@ func (pgd *pgDatastore) Close() error {
 		log.Warn().Err(err).Msg("completed shutdown of postgres datastore")
 	}
 
-	pgd.dbpool.Close()
+	if pgd.notifier != nil {
+		pgd.notifier.Close()
+	}
+
+	pgd.readPool.Close()
+	pgd.writePool.Close()
+	for _, replica := range pgd.replicas {
+		replica.dbpool.Close()
+	}
 	return nil
 }
 
 func errorRetryable(err error) bool {
+	// Errors raised before the transaction could have been sent, such as those of a connection
+	// which was closed while idle in the pool, are safe to retry.
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+
 	var pgerr *pgconn.PgError
 	if !errors.As(err, &pgerr) {
 		log.Debug().Err(err).Msg("couldn't determine a sqlstate error code")
@@ -390,7 +600,12 @@ func errorRetryable(err error) bool {
 	// We need to check unique constraint here because some versions of postgres have an error where
 	// unique constraint violations are raised instead of serialization errors.
 	// (e.g. https://www.postgresql.org/message-id/flat/CAGPCyEZG76zjv7S31v_xPeLNRuzj-m%3DY2GOY7PEzu7vhB%3DyQog%40mail.gmail.com)
-	return pgerr.SQLState() == pgSerializationFailure || pgerr.SQLState() == pgUniqueConstraintViolation
+	switch pgerr.SQLState() {
+	case pgSerializationFailure, pgDeadlockDetected, pgUniqueConstraintViolation:
+		return true
+	default:
+		return false
+	}
 }
 
 func (pgd *pgDatastore) IsReady(ctx context.Context) (bool, error) {
@@ -399,7 +614,12 @@ func (pgd *pgDatastore) IsReady(ctx context.Context) (bool, error) {
 		return false, fmt.Errorf("invalid head migration found for postgres: %w", err)
 	}
 
-	currentRevision, err := migrations.NewAlembicPostgresDriver(pgd.dburl)
+	driverOpts := []migrations.DriverOption{migrations.WithCredentials(pgd.credentials)}
+	if pgd.transactionPooling {
+		driverOpts = append(driverOpts, migrations.WithTransactionPooling())
+	}
+
+	currentRevision, err := migrations.NewAlembicPostgresDriver(pgd.dburl, driverOpts...)
 	if err != nil {
 		return false, err
 	}
@@ -414,7 +634,19 @@ func (pgd *pgDatastore) IsReady(ctx context.Context) (bool, error) {
 }
 
 func (pgd *pgDatastore) Features(ctx context.Context) (*datastore.Features, error) {
-	return &datastore.Features{Watch: datastore.Feature{Enabled: pgd.watchEnabled}}, nil
+	watch := datastore.Feature{Enabled: true}
+	if !pgd.watchEnabled {
+		watch = datastore.Feature{Enabled: false, Reason: watchDisabledReason}
+	}
+
+	return &datastore.Features{
+		Watch:                  watch,
+		WatchCheckpoints:       watch,
+		Caveats:                datastore.Feature{Enabled: true},
+		BulkLoad:               datastore.Feature{Enabled: true},
+		ReverseQuerySort:       datastore.Feature{Enabled: true},
+		RelationshipExpiration: datastore.Feature{Enabled: true},
+	}, nil
 }
 
 func buildLivingObjectFilterForRevision(revision postgresRevision) queryFilterer {
@@ -445,13 +677,35 @@ func buildLivingObjectFilterForRevision(revision postgresRevision) queryFilterer
 		sq.Expr(colDeletedXid+" <> "+sq.Placeholders(1), revision.tx),
 	}
 
+	// Every relationship visible at the revision was created at or before its transaction. The
+	// bound is implied by the snapshot check, but unlike it allows partitions to be pruned.
+	createdAtOrBefore := sq.LtOrEq{colCreatedXid: revision.tx}
+
 	return func(original sq.SelectBuilder) sq.SelectBuilder {
-		return original.Where(alreadyAlive).Where(notYetDead)
+		return original.Where(createdAtOrBefore).Where(alreadyAlive).Where(notYetDead)
 	}
 }
 
+// revisionTimestamp selects the time at which the transaction of the revision was written.
+func revisionTimestamp(revision postgresRevision) sq.Sqlizer {
+	return sq.Expr(fmt.Sprintf(
+		"(SELECT %s AT TIME ZONE 'utc' FROM %s WHERE %s = %s)",
+		colTimestamp,
+		tableTransaction,
+		colXID,
+		sq.Placeholders(1),
+	), revision.tx)
+}
+
 func currentlyLivingObjects(original sq.SelectBuilder) sq.SelectBuilder {
 	return original.Where(sq.Eq{colDeletedXid: liveDeletedTxnID})
 }
 
+// filterToTenant restricts the rows selected by the filterer to those of the tenant.
+func filterToTenant(tenant string, filterer queryFilterer) queryFilterer {
+	return func(original sq.SelectBuilder) sq.SelectBuilder {
+		return filterer(original).Where(sq.Eq{colTenant: tenant})
+	}
+}
+
 var _ datastore.Datastore = &pgDatastore{}
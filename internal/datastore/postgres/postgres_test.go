@@ -12,10 +12,13 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/postgres/migrations"
 	"github.com/authzed/spicedb/internal/testfixtures"
 	testdatastore "github.com/authzed/spicedb/internal/testserver/datastore"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -23,6 +26,8 @@ import (
 	"github.com/authzed/spicedb/pkg/migrate"
 	"github.com/authzed/spicedb/pkg/namespace"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/schemadsl/input"
 	"github.com/authzed/spicedb/pkg/tuple"
 )
 
@@ -31,7 +36,7 @@ func TestPostgresDatastore(t *testing.T) {
 		targetMigration string
 		migrationPhase  string
 	}{
-		{"drop-bigserial-ids", ""},
+		{"add-relationship-hash-sharding", ""},
 	} {
 		config := config
 		t.Run(fmt.Sprintf("%s-%s", config.targetMigration, config.migrationPhase), func(t *testing.T) {
@@ -73,6 +78,29 @@ func TestPostgresDatastore(t *testing.T) {
 				}))
 			})
 
+			t.Run("WithLogicalReplicationWatch", func(t *testing.T) {
+				tester := test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+					ds := b.NewDatastore(t, func(engine, uri string) datastore.Datastore {
+						ds, err := newPostgresDatastore(uri,
+							RevisionQuantization(revisionQuantization),
+							GCWindow(gcWindow),
+							WatchBufferLength(watchBufferLength),
+							WatchMode("logical-replication"),
+							MigrationPhase(config.migrationPhase),
+						)
+						require.NoError(t, err)
+						return ds
+					})
+					return ds, nil
+				})
+
+				t.Run("TestWatch", func(t *testing.T) { test.WatchTest(t, tester) })
+				t.Run("TestWatchCancel", func(t *testing.T) { test.WatchCancelTest(t, tester) })
+				t.Run("TestWatchWithMetadata", func(t *testing.T) { test.WatchWithMetadataTest(t, tester) })
+				t.Run("TestWatchCheckpoint", func(t *testing.T) { test.WatchCheckpointTest(t, tester) })
+				t.Run("TestCaveatedRelationshipWatch", func(t *testing.T) { test.CaveatedRelationshipWatchTest(t, tester) })
+			})
+
 			t.Run("GarbageCollection", createDatastoreTest(
 				b,
 				GarbageCollectionTest,
@@ -117,6 +145,67 @@ func TestPostgresDatastore(t *testing.T) {
 				WatchNotEnabledTest(t, b)
 			})
 
+			t.Run("TransactionNotifications", createDatastoreTest(
+				b,
+				TransactionNotificationTest,
+				RevisionQuantization(0),
+				GCWindow(1*time.Millisecond),
+				WatchBufferLength(1),
+				MigrationPhase(config.migrationPhase),
+			))
+
+			t.Run("ReadReplicas", func(t *testing.T) {
+				ReadReplicaTest(t, b)
+			})
+
+			t.Run("OperationTimeouts", createDatastoreTest(
+				b,
+				OperationTimeoutsTest,
+				RevisionQuantization(0),
+				GCWindow(1*time.Millisecond),
+				MigrationPhase(config.migrationPhase),
+				ReadStatementTimeout(5*time.Second),
+				WriteLockTimeout(500*time.Millisecond),
+				GCStatementTimeout(time.Minute),
+			))
+
+			t.Run("BatchedGarbageCollection", createDatastoreTest(
+				b,
+				ChunkedGarbageCollectionTest,
+				RevisionQuantization(0),
+				GCWindow(1*time.Millisecond),
+				WatchBufferLength(1),
+				MigrationPhase(config.migrationPhase),
+				GCBatchSize(128),
+				GCBatchDelay(time.Millisecond),
+			))
+
+			t.Run("SlowQueryExplain", createDatastoreTest(
+				b,
+				SlowQueryExplainTest,
+				RevisionQuantization(0),
+				GCWindow(1*time.Millisecond),
+				MigrationPhase(config.migrationPhase),
+				SlowQueryThreshold(time.Nanosecond),
+				SlowQueryExplainAnalyze(true),
+			))
+
+			t.Run("AtomicSchemaWrite", createDatastoreTest(
+				b,
+				AtomicSchemaWriteTest,
+				RevisionQuantization(0),
+				GCWindow(1*time.Millisecond),
+				MigrationPhase(config.migrationPhase),
+			))
+
+			t.Run("NamespaceDiffHistory", createDatastoreTest(
+				b,
+				NamespaceDiffHistoryTest,
+				RevisionQuantization(0),
+				GCWindow(1*time.Millisecond),
+				MigrationPhase(config.migrationPhase),
+			))
+
 			if config.migrationPhase == "" {
 				t.Run("RevisionInversion", createDatastoreTest(
 					b,
@@ -131,6 +220,73 @@ func TestPostgresDatastore(t *testing.T) {
 	}
 }
 
+func TestPartitionedPostgresDatastore(t *testing.T) {
+	// The range partitions are small enough that the tests write across several of them, and
+	// into the default partition.
+	for _, partitioning := range []string{"namespace-hash:4", "created-xid-range:50"} {
+		partitioning := partitioning
+		t.Run(partitioning, func(t *testing.T) {
+			t.Parallel()
+			b := testdatastore.RunPostgresForTesting(t, "", migrate.Head)
+
+			test.All(t, test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+				uri := b.NewDatabase(t)
+
+				migrationDriver, err := migrations.NewAlembicPostgresDriver(uri)
+				require.NoError(t, err)
+				ctx := context.WithValue(context.Background(), migrate.BackfillBatchSize, uint64(1000))
+				ctx = context.WithValue(ctx, migrate.RelationshipPartitioning, partitioning)
+				require.NoError(t, migrations.DatabaseMigrations.Run(ctx, migrationDriver, migrate.Head, migrate.LiveRun))
+
+				ds, err := newPostgresDatastore(uri,
+					RevisionQuantization(revisionQuantization),
+					GCWindow(gcWindow),
+					WatchBufferLength(watchBufferLength),
+					DebugAnalyzeBeforeStatistics(),
+				)
+				require.NoError(t, err)
+
+				expected, err := migrations.ParsePartitioning(partitioning)
+				require.NoError(t, err)
+				require.Equal(t, expected, ds.(*pgDatastore).partitioning)
+				return ds, nil
+			}))
+		})
+	}
+}
+
+func TestTransactionPoolingPostgresDatastore(t *testing.T) {
+	b := testdatastore.RunPostgresForTesting(t, "", migrate.Head)
+
+	test.All(t, test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+		uri := b.NewDatabase(t)
+
+		migrationDriver, err := migrations.NewAlembicPostgresDriver(uri, migrations.WithTransactionPooling())
+		require.NoError(t, err)
+		defer migrationDriver.Close(context.Background())
+
+		ctx := context.WithValue(context.Background(), migrate.BackfillBatchSize, uint64(1000))
+		require.NoError(t, migrations.DatabaseMigrations.Run(ctx, migrationDriver, migrate.Head, migrate.LiveRun))
+
+		// A pooler may run the next transaction on another session, which would lack any
+		// statements prepared by the migrations.
+		var prepared int
+		require.NoError(t, migrationDriver.Conn().QueryRow(ctx, "SELECT COUNT(*) FROM pg_prepared_statements").Scan(&prepared))
+		require.Zero(t, prepared)
+
+		ds, err := newPostgresDatastore(uri,
+			RevisionQuantization(revisionQuantization),
+			GCWindow(gcWindow),
+			WatchBufferLength(watchBufferLength),
+			DebugAnalyzeBeforeStatistics(),
+			TransactionPooling(true),
+		)
+		require.NoError(t, err)
+		require.Nil(t, ds.(*pgDatastore).notifier)
+		return ds, nil
+	}))
+}
+
 type datastoreTestFunc func(t *testing.T, ds datastore.Datastore)
 
 func createDatastoreTest(b testdatastore.RunningEngineForTest, tf datastoreTestFunc, options ...Option) func(*testing.T) {
@@ -170,7 +326,6 @@ func GarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	removed, err := pds.DeleteBeforeTx(ctx, firstWrite)
 	require.NoError(err)
 	require.Zero(removed.Relationships)
-	require.Zero(removed.Namespaces)
 
 	// Replace the namespace with a new one.
 	updateTwoNamespaces, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
@@ -191,7 +346,6 @@ func GarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	require.NoError(err)
 	require.Zero(removed.Relationships)
 	require.Equal(int64(1), removed.Transactions) // firstWrite
-	require.Zero(removed.Namespaces)
 
 	// Write a relationship.
 	tpl := tuple.Parse("resource:someresource#reader@user:someuser#...")
@@ -204,14 +358,12 @@ func GarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	require.NoError(err)
 	require.Zero(removed.Relationships)
 	require.Equal(int64(1), removed.Transactions) // updateTwoNamespaces
-	require.Equal(int64(2), removed.Namespaces)   // resource, user
 
 	// Run GC again and ensure there are no changes.
 	removed, err = pds.DeleteBeforeTx(ctx, wroteOneRelationship)
 	require.NoError(err)
 	require.Zero(removed.Relationships)
 	require.Zero(removed.Transactions)
-	require.Zero(removed.Namespaces)
 
 	// Ensure the relationship is still present.
 	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
@@ -226,14 +378,12 @@ func GarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	require.NoError(err)
 	require.Zero(removed.Relationships)
 	require.Equal(int64(1), removed.Transactions) // wroteOneRelationship
-	require.Zero(removed.Namespaces)
 
 	// Run GC again and ensure there are no changes.
 	removed, err = pds.DeleteBeforeTx(ctx, relOverwrittenAt)
 	require.NoError(err)
 	require.Zero(removed.Relationships)
 	require.Zero(removed.Transactions)
-	require.Zero(removed.Namespaces)
 
 	// Ensure the relationship is still present.
 	tRequire.TupleExists(ctx, tpl, relOverwrittenAt)
@@ -250,14 +400,12 @@ func GarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	require.NoError(err)
 	require.Equal(int64(1), removed.Relationships)
 	require.Equal(int64(1), removed.Transactions) // relOverwrittenAt
-	require.Zero(removed.Namespaces)
 
 	// Run GC again and ensure there are no changes.
 	removed, err = pds.DeleteBeforeTx(ctx, relDeletedAt)
 	require.NoError(err)
 	require.Zero(removed.Relationships)
 	require.Zero(removed.Transactions)
-	require.Zero(removed.Namespaces)
 
 	// Write a the relationship a few times.
 	var relLastWriteAt datastore.Revision
@@ -273,7 +421,6 @@ func GarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	require.NoError(err)
 	require.Equal(int64(2), removed.Relationships) // delete, old1
 	require.Equal(int64(3), removed.Transactions)  // removed, write1, write2
-	require.Zero(removed.Namespaces)
 
 	// Ensure the relationship is still present.
 	tRequire.TupleExists(ctx, tpl, relLastWriteAt)
@@ -289,7 +436,6 @@ func GarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	require.NoError(err)
 	require.Equal(int64(1), removed.Relationships) // old2
 	require.Equal(int64(1), removed.Transactions)  // write3
-	require.Zero(removed.Namespaces)
 }
 
 func TransactionTimestampsTest(t *testing.T, ds datastore.Datastore) {
@@ -302,7 +448,7 @@ func TransactionTimestampsTest(t *testing.T, ds datastore.Datastore) {
 
 	// Setting db default time zone to before UTC
 	pgd := ds.(*pgDatastore)
-	_, err = pgd.dbpool.Exec(ctx, "SET TIME ZONE 'America/New_York';")
+	_, err = pgd.writePool.Exec(ctx, "SET TIME ZONE 'America/New_York';")
 	require.NoError(err)
 
 	// Get timestamp in UTC as reference
@@ -310,10 +456,10 @@ func TransactionTimestampsTest(t *testing.T, ds datastore.Datastore) {
 	require.NoError(err)
 
 	// Transaction timestamp should not be stored in system time zone
-	tx, err := pgd.dbpool.Begin(ctx)
+	tx, err := pgd.writePool.Begin(ctx)
 	require.NoError(err)
 
-	txXID, _, err := createNewTransaction(ctx, tx)
+	txXID, _, err := createNewTransaction(ctx, tx, datastore.DefaultTenant, nil)
 	require.NoError(err)
 
 	err = tx.Commit(ctx)
@@ -322,7 +468,7 @@ func TransactionTimestampsTest(t *testing.T, ds datastore.Datastore) {
 	var ts time.Time
 	sql, args, err := psql.Select("timestamp").From(tableTransaction).Where(sq.Eq{"xid": txXID}).ToSql()
 	require.NoError(err)
-	err = pgd.dbpool.QueryRow(ctx, sql, args...).Scan(&ts)
+	err = pgd.writePool.QueryRow(ctx, sql, args...).Scan(&ts)
 	require.NoError(err)
 
 	// Transaction timestamp will be before the reference time if it was stored
@@ -368,7 +514,6 @@ func GarbageCollectionByTimeTest(t *testing.T, ds datastore.Datastore) {
 	require.NoError(err)
 	require.Zero(removed.Relationships)
 	require.True(removed.Transactions > 0)
-	require.Zero(removed.Namespaces)
 
 	// Ensure the relationship is still present.
 	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
@@ -398,7 +543,6 @@ func GarbageCollectionByTimeTest(t *testing.T, ds datastore.Datastore) {
 	require.NoError(err)
 	require.Equal(int64(1), removed.Relationships)
 	require.Equal(int64(2), removed.Transactions) // relDeletedAt, injected
-	require.Zero(removed.Namespaces)
 
 	// Ensure the relationship is still not present.
 	tRequire.NoTupleExists(ctx, tpl, relDeletedAt)
@@ -453,7 +597,6 @@ func ChunkedGarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	require.NoError(err)
 	require.Zero(removed.Relationships)
 	require.True(removed.Transactions > 0)
-	require.Zero(removed.Namespaces)
 
 	// Sleep to ensure the relationships will GC.
 	time.Sleep(1 * time.Millisecond)
@@ -487,7 +630,12 @@ func ChunkedGarbageCollectionTest(t *testing.T, ds datastore.Datastore) {
 	require.NoError(err)
 	require.Equal(int64(chunkRelationshipCount), removed.Relationships)
 	require.Equal(int64(2), removed.Transactions)
-	require.Zero(removed.Namespaces)
+	require.Zero(testutil.ToFloat64(gcBacklogRowsGauge.WithLabelValues(tableTuple)))
+	require.InDelta(float64(time.Now().Unix()), testutil.ToFloat64(gcLastSuccessGauge), 60)
+
+	// The transaction retained by garbage collection is the oldest.
+	require.Equal(1, testutil.CollectAndCount(newTransactionAgeCollector(pds.readPool)))
+	require.Greater(testutil.ToFloat64(newTransactionAgeCollector(pds.readPool)), float64(0))
 }
 
 func QuantizedRevisionTest(t *testing.T, b testdatastore.RunningEngineForTest) {
@@ -706,6 +854,229 @@ func WatchNotEnabledTest(t *testing.T, b testdatastore.RunningEngineForTest) {
 	require.Contains(err.Error(), "track_commit_timestamp=on")
 }
 
+func TransactionNotificationTest(t *testing.T, ds datastore.Datastore) {
+	require := require.New(t)
+
+	pds := ds.(*pgDatastore)
+	require.Eventually(func() bool {
+		_, pollInterval := pds.notifier.wait()
+		return pollInterval == notifiedWatchPoll
+	}, 5*time.Second, 10*time.Millisecond)
+
+	notified, _ := pds.notifier.wait()
+	_, err := common.WriteTuples(context.Background(), ds, core.RelationTupleUpdate_CREATE, tuple.Parse("resource:someresource#reader@user:someuser#..."))
+	require.NoError(err)
+
+	select {
+	case <-notified:
+	case <-time.After(5 * time.Second):
+		require.Fail("transaction commit was not notified")
+	}
+}
+
+func OperationTimeoutsTest(t *testing.T, ds datastore.Datastore) {
+	require := require.New(t)
+	ctx := context.Background()
+	pds := ds.(*pgDatastore)
+
+	requireTimeouts := func(tx pgx.Tx, statementTimeout, lockTimeout string) {
+		var setting string
+		require.NoError(tx.QueryRow(ctx, "SHOW statement_timeout").Scan(&setting))
+		require.Equal(statementTimeout, setting)
+		require.NoError(tx.QueryRow(ctx, "SHOW lock_timeout").Scan(&setting))
+		require.Equal(lockTimeout, setting)
+	}
+
+	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		requireTimeouts(rwt.(*pgReadWriteTXN).tx, "0", "500ms")
+		return nil
+	})
+	require.NoError(err)
+
+	tx, cleanup, err := pds.snapshotTxSource(revision.(postgresRevision))(ctx)
+	require.NoError(err)
+	requireTimeouts(tx, "5s", "0")
+	cleanup(ctx)
+
+	// The timeouts are local to each transaction, so they are not left on pooled connections.
+	require.NoError(pds.writePool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		requireTimeouts(tx, "0", "0")
+		require.NoError(pds.gcTimeouts.setLocal(ctx, tx))
+		requireTimeouts(tx, "1min", "0")
+		return nil
+	}))
+}
+
+func SlowQueryExplainTest(t *testing.T, ds datastore.Datastore) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tuple.Parse("resource:someresource#reader@user:someuser#..."))
+	require.NoError(err)
+
+	// Every query exceeds the threshold, so each is explained without affecting its results.
+	before := testutil.ToFloat64(slowQueriesCounter)
+	iter, err := ds.SnapshotReader(revision).QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType: "resource",
+	})
+	require.NoError(err)
+	defer iter.Close()
+
+	found := iter.Next()
+	require.NotNil(found)
+	require.Equal("someresource", found.ResourceAndRelation.ObjectId)
+	require.Nil(iter.Next())
+	require.NoError(iter.Err())
+	require.Greater(testutil.ToFloat64(slowQueriesCounter), before)
+
+	pds := ds.(*pgDatastore)
+	plan, err := pds.explain(ctx, pds.snapshotTxSource(revision.(postgresRevision)), "SELECT $1::text", []any{"explained"})
+	require.NoError(err)
+	require.Contains(plan, "actual time")
+}
+
+func AtomicSchemaWriteTest(t *testing.T, ds datastore.Datastore) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	empty := ""
+	compiled, err := compiler.Compile(compiler.InputSchema{
+		Source: input.Source("schema"),
+		SchemaString: `caveat only_on_tuesday(day string) {
+			day == 'tuesday'
+		}
+
+		definition user {}
+
+		definition document {
+			relation viewer: user | user with only_on_tuesday
+			permission view = viewer
+		}`,
+	}, &empty)
+	require.NoError(err)
+
+	before, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+
+	// The caveats and namespaces of a schema are written by a single transaction, as by
+	// WriteSchema, and so become visible at a single revision.
+	written, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		if err := rwt.WriteCaveats(ctx, compiled.CaveatDefinitions); err != nil {
+			return err
+		}
+		return rwt.WriteNamespaces(ctx, compiled.ObjectDefinitions...)
+	})
+	require.NoError(err)
+
+	namespaces, err := ds.SnapshotReader(before).ListNamespaces(ctx)
+	require.NoError(err)
+	require.Empty(namespaces)
+
+	caveats, err := ds.SnapshotReader(before).ListCaveats(ctx)
+	require.NoError(err)
+	require.Empty(caveats)
+
+	reader := ds.SnapshotReader(written)
+	for _, definition := range compiled.ObjectDefinitions {
+		_, lastWritten, err := reader.ReadNamespace(ctx, definition.Name)
+		require.NoError(err)
+		require.True(written.Equal(lastWritten))
+	}
+
+	_, lastWritten, err := reader.ReadCaveatByName(ctx, "only_on_tuesday")
+	require.NoError(err)
+	require.True(written.Equal(lastWritten))
+}
+
+func NamespaceDiffHistoryTest(t *testing.T, ds datastore.Datastore) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	var written []datastore.Revision
+	for _, definition := range []*core.NamespaceDefinition{
+		namespace.Namespace("resource", namespace.Relation("reader", nil, namespace.AllowedRelation("user", "..."))),
+		namespace.Namespace("resource", namespace.Relation("reader", nil, namespace.AllowedRelation("user", "..."))),
+		namespace.Namespace("resource",
+			namespace.Relation("reader", nil, namespace.AllowedRelation("user", "..."), namespace.AllowedPublicNamespace("user")),
+			namespace.Relation("writer", nil, namespace.AllowedRelation("user", "...")),
+		),
+	} {
+		revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.WriteNamespaces(ctx, definition)
+		})
+		require.NoError(err)
+		written = append(written, revision)
+	}
+
+	// Neither the first definition nor its unchanged replacement record a diff.
+	diffs, err := ds.(*pgDatastore).NamespaceDiffHistory(ctx, "resource")
+	require.NoError(err)
+	require.Len(diffs, 1)
+	require.True(written[2].Equal(diffs[0].Revision))
+	require.False(diffs[0].WrittenAt.IsZero())
+
+	deltas := make(map[string]datastore.NamespaceDelta, len(diffs[0].Deltas))
+	for _, delta := range diffs[0].Deltas {
+		deltas[delta.Type] = delta
+	}
+	require.Len(deltas, 2)
+	require.Equal("writer", deltas["added-relation"].RelationName)
+	require.Nil(deltas["added-relation"].AllowedType)
+	require.Equal("reader", deltas["relation-allowed-type-added"].RelationName)
+	require.True(proto.Equal(namespace.AllowedPublicNamespace("user"), deltas["relation-allowed-type-added"].AllowedType))
+
+	diffs, err = ds.(*pgDatastore).NamespaceDiffHistory(ctx, "unknown")
+	require.NoError(err)
+	require.Empty(diffs)
+}
+
+func ReadReplicaTest(t *testing.T, b testdatastore.RunningEngineForTest) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// A separate database stands in for a replica which has replayed none of the transactions of
+	// the primary, while the primary itself stands in for a replica which is caught up.
+	var laggingURI string
+	lagging := b.NewDatastore(t, func(engine, uri string) datastore.Datastore {
+		laggingURI = uri
+		ds, err := newPostgresDatastore(uri)
+		require.NoError(err)
+		return ds
+	})
+	defer lagging.Close()
+
+	ds := b.NewDatastore(t, func(engine, uri string) datastore.Datastore {
+		ds, err := newPostgresDatastore(uri,
+			RevisionQuantization(0),
+			GCWindow(time.Millisecond*1),
+			ReadReplicaURLs(uri, laggingURI),
+		)
+		require.NoError(err)
+		return ds
+	})
+	defer ds.Close()
+
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tuple.Parse("resource:someresource#reader@user:someuser#..."))
+	require.NoError(err)
+
+	pds := ds.(*pgDatastore)
+	for _, replica := range pds.replicas {
+		tx, err := replica.beginReplicaTx(ctx, pds.readTxOptions, revision.(postgresRevision))
+		require.NoError(err)
+		require.Equal(replica.index == 0, tx != nil)
+		if tx != nil {
+			require.NoError(tx.Rollback(ctx))
+		}
+	}
+
+	// Reads routed to the lagging replica fall back to the primary.
+	for i := 0; i < 4; i++ {
+		count, err := ds.SnapshotReader(revision).CountRelationships(ctx, datastore.RelationshipsFilter{ResourceType: "resource"})
+		require.NoError(err)
+		require.Equal(uint64(1), count)
+	}
+}
+
 func BenchmarkPostgresQuery(b *testing.B) {
 	req := require.New(b)
 
@@ -19,6 +19,7 @@ type pgReader struct {
 	txSource      pgxcommon.TxFactory
 	querySplitter common.TupleQuerySplitter
 	filterer      queryFilterer
+	schema        common.SchemaInformation
 }
 
 type queryFilterer func(original sq.SelectBuilder) sq.SelectBuilder
@@ -33,6 +34,8 @@ var (
 		colUsersetRelation,
 		colCaveatContextName,
 		colCaveatContext,
+		colExpiration,
+		colMetadata,
 	).From(tableTuple)
 
 	schema = common.SchemaInformation{
@@ -43,14 +46,27 @@ var (
 		ColUsersetObjectID:  colUsersetObjectID,
 		ColUsersetRelation:  colUsersetRelation,
 		ColCaveatName:       colCaveatContextName,
+		ColExpiration:       colExpiration,
+		MetadataValue:       metadataValue,
 	}
 
 	readNamespace = psql.Select(colConfig, colCreatedXid).From(tableNamespace)
+
+	countTuples = psql.Select("COUNT(*)").From(tableTuple)
+
+	listResourceTypes = psql.Select(colNamespace).Distinct().From(tableTuple).OrderBy(colNamespace)
 )
 
+// metadataValue selects the value for a key from the JSONB metadata column.
+func metadataValue(key string) (string, []any) {
+	return colMetadata + "->>(?::text)", []any{key}
+}
+
 const (
-	errUnableToReadConfig     = "unable to read namespace config: %w"
-	errUnableToListNamespaces = "unable to list namespaces: %w"
+	errUnableToReadConfig         = "unable to read namespace config: %w"
+	errUnableToListNamespaces     = "unable to list namespaces: %w"
+	errUnableToCountRelationships = "unable to count relationships: %w"
+	errUnableToListResourceTypes  = "unable to list resource types: %w"
 )
 
 func (r *pgReader) QueryRelationships(
@@ -58,7 +74,7 @@ func (r *pgReader) QueryRelationships(
 	filter datastore.RelationshipsFilter,
 	opts ...options.QueryOptionsOption,
 ) (iter datastore.RelationshipIterator, err error) {
-	qBuilder := common.NewSchemaQueryFilterer(schema, r.filterer(queryTuples)).FilterWithRelationshipsFilter(filter)
+	qBuilder := common.NewSchemaQueryFilterer(r.schema, r.filterer(queryTuples)).FilterWithRelationshipsFilter(filter)
 	return r.querySplitter.SplitAndExecuteQuery(ctx, qBuilder, opts...)
 }
 
@@ -67,7 +83,7 @@ func (r *pgReader) ReverseQueryRelationships(
 	subjectsFilter datastore.SubjectsFilter,
 	opts ...options.ReverseQueryOptionsOption,
 ) (iter datastore.RelationshipIterator, err error) {
-	qBuilder := common.NewSchemaQueryFilterer(schema, r.filterer(queryTuples)).
+	qBuilder := common.NewSchemaQueryFilterer(r.schema, r.filterer(queryTuples)).
 		FilterWithSubjectsFilter(subjectsFilter)
 
 	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
@@ -78,12 +94,75 @@ func (r *pgReader) ReverseQueryRelationships(
 			FilterToRelation(queryOpts.ResRelation.Relation)
 	}
 
+	if queryOpts.ReverseSort == options.BySubject {
+		qBuilder = qBuilder.SortBySubject()
+	}
+
+	if queryOpts.ReverseAfter != nil {
+		qBuilder = qBuilder.FilterAfterSubject(queryOpts.ReverseAfter)
+	}
+
 	return r.querySplitter.SplitAndExecuteQuery(ctx,
 		qBuilder,
 		options.WithLimit(queryOpts.ReverseLimit),
 	)
 }
 
+func (r *pgReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	sql, args, err := common.NewSchemaQueryFilterer(r.schema, r.filterer(countTuples)).
+		FilterWithRelationshipsFilter(filter).
+		ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+
+	tx, txCleanup, err := r.txSource(ctx)
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+	defer txCleanup(ctx)
+
+	var count int64
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+
+	return uint64(count), nil
+}
+
+func (r *pgReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	sql, args, err := common.NewSchemaQueryFilterer(r.schema, r.filterer(listResourceTypes)).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+
+	tx, txCleanup, err := r.txSource(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+	defer txCleanup(ctx)
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+	defer rows.Close()
+
+	var resourceTypes []string
+	for rows.Next() {
+		var resourceType string
+		if err := rows.Scan(&resourceType); err != nil {
+			return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+		}
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, rows.Err())
+	}
+
+	return resourceTypes, nil
+}
+
 func (r *pgReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
 	tx, txCleanup, err := r.txSource(ctx)
 	if err != nil {
@@ -120,14 +199,16 @@ func (r *pgReader) loadNamespace(ctx context.Context, namespace string, tx pgx.T
 	return defs[0].nsDef, defs[0].revision, nil
 }
 
-func (r *pgReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+func (r *pgReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
 	tx, txCleanup, err := r.txSource(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer txCleanup(ctx)
 
-	nsDefsWithRevisions, err := loadAllNamespaces(ctx, tx, r.filterer)
+	nsDefsWithRevisions, err := loadAllNamespaces(ctx, tx, func(original sq.SelectBuilder) sq.SelectBuilder {
+		return common.ListDefinitionsPage(r.filterer(original), colNamespace, opts...)
+	})
 	if err != nil {
 		return nil, fmt.Errorf(errUnableToListNamespaces, err)
 	}
@@ -11,6 +11,7 @@ import (
 	"github.com/jzelinskie/stringz"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/authzed/spicedb/internal/datastore/common"
 	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -27,12 +28,16 @@ var (
 	writeNamespace = psql.Insert(tableNamespace).Columns(
 		colNamespace,
 		colConfig,
+		colTenant,
 	)
 
 	deleteNamespace = psql.Update(tableNamespace).Where(sq.Eq{colDeletedXid: liveDeletedTxnID})
 
 	deleteNamespaceTuples = psql.Update(tableTuple).Where(sq.Eq{colDeletedXid: liveDeletedTxnID})
 
+	// The created_xid is written rather than left to the column default, so that it is the ID
+	// recorded in the transactions table even where the row is inserted by another server, as on
+	// the workers of a distributed table.
 	writeTuple = psql.Insert(tableTuple).Columns(
 		colNamespace,
 		colObjectID,
@@ -42,6 +47,10 @@ var (
 		colUsersetRelation,
 		colCaveatContextName,
 		colCaveatContext,
+		colExpiration,
+		colMetadata,
+		colTenant,
+		colCreatedXid,
 	)
 
 	deleteTuple = psql.Update(tableTuple).Where(sq.Eq{colDeletedXid: liveDeletedTxnID})
@@ -51,6 +60,7 @@ type pgReadWriteTXN struct {
 	*pgReader
 	tx     pgx.Tx
 	newXID xid8
+	tenant string
 }
 
 func (rwt *pgReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
@@ -66,6 +76,15 @@ func (rwt *pgReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*
 			deleteClauses = append(deleteClauses, exactRelationshipClause(tpl))
 		}
 
+		// Expired relationships no longer exist, so they are removed before a CREATE of the same
+		// relationship.
+		if mut.Operation == core.RelationTupleUpdate_CREATE {
+			deleteClauses = append(deleteClauses, sq.And{
+				exactRelationshipClause(tpl),
+				sq.Expr(colExpiration + " <= NOW()"),
+			})
+		}
+
 		if mut.Operation == core.RelationTupleUpdate_TOUCH || mut.Operation == core.RelationTupleUpdate_CREATE {
 			var caveatName string
 			var caveatContext map[string]any
@@ -82,6 +101,10 @@ func (rwt *pgReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*
 				tpl.Subject.Relation,
 				caveatName,
 				caveatContext, // PGX driver serializes map[string]any to JSONB type columns
+				common.ExpirationTimeOf(tpl),
+				tpl.OptionalMetadata,
+				rwt.tenant,
+				rwt.newXID,
 			}
 
 			bulkWrite = bulkWrite.Values(valuesToWrite...)
@@ -92,6 +115,7 @@ func (rwt *pgReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*
 	if len(deleteClauses) > 0 {
 		sql, args, err := deleteTuple.
 			Where(deleteClauses).
+			Where(sq.Eq{colTenant: rwt.tenant}).
 			Set(colDeletedXid, rwt.newXID).
 			ToSql()
 		if err != nil {
@@ -125,7 +149,7 @@ func (rwt *pgReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*
 
 func (rwt *pgReadWriteTXN) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
 	// Add clauses for the ResourceFilter
-	query := deleteTuple.Where(sq.Eq{colNamespace: filter.ResourceType})
+	query := deleteTuple.Where(sq.Eq{colTenant: rwt.tenant, colNamespace: filter.ResourceType})
 	if filter.OptionalResourceId != "" {
 		query = query.Where(sq.Eq{colObjectID: filter.OptionalResourceId})
 	}
@@ -168,14 +192,19 @@ func (rwt *pgReadWriteTXN) WriteNamespaces(ctx context.Context, newConfigs ...*c
 
 		deletedNamespaceClause = append(deletedNamespaceClause, sq.Eq{colNamespace: newNamespace.Name})
 
-		valuesToWrite := []interface{}{newNamespace.Name, serialized}
+		valuesToWrite := []interface{}{newNamespace.Name, serialized, rwt.tenant}
 
 		writeQuery = writeQuery.Values(valuesToWrite...)
 	}
 
+	// The diffs are computed against the living definitions before they are replaced.
+	if err := rwt.writeNamespaceDiffs(ctx, newConfigs); err != nil {
+		return fmt.Errorf(errUnableToWriteConfig, err)
+	}
+
 	delSQL, delArgs, err := deleteNamespace.
 		Set(colDeletedXid, rwt.newXID).
-		Where(sq.And{sq.Eq{colDeletedXid: liveDeletedTxnID}, deletedNamespaceClause}).
+		Where(sq.And{sq.Eq{colDeletedXid: liveDeletedTxnID, colTenant: rwt.tenant}, deletedNamespaceClause}).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf(errUnableToWriteConfig, err)
@@ -199,9 +228,7 @@ func (rwt *pgReadWriteTXN) WriteNamespaces(ctx context.Context, newConfigs ...*c
 }
 
 func (rwt *pgReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
-	filterer := func(original sq.SelectBuilder) sq.SelectBuilder {
-		return original.Where(sq.Eq{colDeletedXid: liveDeletedTxnID})
-	}
+	filterer := filterToTenant(rwt.tenant, currentlyLivingObjects)
 
 	nsClauses := make([]sq.Sqlizer, 0, len(nsNames))
 	tplClauses := make([]sq.Sqlizer, 0, len(nsNames))
@@ -222,6 +249,7 @@ func (rwt *pgReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...stri
 
 	delSQL, delArgs, err := deleteNamespace.
 		Set(colDeletedXid, rwt.newXID).
+		Where(sq.Eq{colTenant: rwt.tenant}).
 		Where(sq.Or(nsClauses)).
 		ToSql()
 	if err != nil {
@@ -235,6 +263,7 @@ func (rwt *pgReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...stri
 
 	deleteTupleSQL, deleteTupleArgs, err := deleteNamespaceTuples.
 		Set(colDeletedXid, rwt.newXID).
+		Where(sq.Eq{colTenant: rwt.tenant}).
 		Where(sq.Or(tplClauses)).
 		ToSql()
 	if err != nil {
@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/pgxpoolprometheus"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
+	log "github.com/authzed/spicedb/internal/logging"
+)
+
+var replicaReadsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "spicedb",
+	Subsystem: "datastore",
+	Name:      "postgres_replica_reads_total",
+	Help:      "number of snapshot read transactions routed to a read replica, by whether the replica had replayed the requested revision.",
+}, []string{"replayed"})
+
+func init() {
+	prometheus.MustRegister(replicaReadsCounter)
+}
+
+// readReplica is a connection pool to a streaming replica of the primary database.
+type readReplica struct {
+	index  int
+	dbpool *pgxpool.Pool
+}
+
+// connectReplicas connects to each of the read replicas, using the same pool configuration as
+// the primary database.
+func connectReplicas(ctx context.Context, config postgresOptions, password pgxcommon.PasswordFunc) ([]*readReplica, error) {
+	replicas := make([]*readReplica, 0, len(config.readReplicaURLs))
+	for i, url := range config.readReplicaURLs {
+		pgxConfig, err := pgxpool.ParseConfig(url)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse read replica %d URL: %w", i, err)
+		}
+
+		if err := configurePool(config, config.readPoolOpts, pgxConfig, password); err != nil {
+			return nil, fmt.Errorf("unable to configure read replica %d: %w", i, err)
+		}
+
+		dbpool, err := pgxpool.ConnectConfig(ctx, pgxConfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to read replica %d: %w", i, err)
+		}
+
+		if config.enablePrometheusStats {
+			collector := pgxpoolprometheus.NewCollector(dbpool, map[string]string{"db_name": "spicedb", "pool_usage": fmt.Sprintf("replica_%d", i)})
+			if err := prometheus.Register(collector); err != nil {
+				return nil, err
+			}
+		}
+
+		replicas = append(replicas, &readReplica{i, dbpool})
+	}
+
+	return replicas, nil
+}
+
+// nextReplica returns the replica to which the next snapshot read is routed, or nil if there
+// are no replicas.
+func (pgd *pgDatastore) nextReplica() *readReplica {
+	if len(pgd.replicas) == 0 {
+		return nil
+	}
+
+	next := pgd.replicaCounter.Add(1)
+	return pgd.replicas[next%uint32(len(pgd.replicas))]
+}
+
+// beginReplicaTx begins a read transaction against the replica, returning nil if the replica
+// has not yet replayed the transaction of the revision.
+//
+// The transaction of the revision is looked up within the read transaction itself, so that the
+// check applies to the same snapshot as the reads. As a replica replays transactions in commit
+// order, every transaction visible to the revision has then been replayed as well.
+func (r *readReplica) beginReplicaTx(ctx context.Context, txOptions pgx.TxOptions, rev postgresRevision) (pgx.Tx, error) {
+	tx, err := r.dbpool.BeginTx(ctx, txOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	sql, args, err := psql.Select("1").
+		From(tableTransaction).
+		Where(sq.Eq{colXID: rev.tx}).
+		Prefix("SELECT EXISTS (").
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		rollbackReplicaTx(ctx, tx)
+		return nil, err
+	}
+
+	var replayed bool
+	if err := tx.QueryRow(ctx, sql, args...).Scan(&replayed); err != nil {
+		rollbackReplicaTx(ctx, tx)
+		return nil, err
+	}
+
+	replicaReadsCounter.WithLabelValues(fmt.Sprintf("%t", replayed)).Inc()
+	if !replayed {
+		rollbackReplicaTx(ctx, tx)
+		return nil, nil
+	}
+
+	return tx, nil
+}
+
+// snapshotTxSource begins the read transactions of a snapshot reader against the next replica
+// which has replayed the revision, falling back to the primary database.
+func (pgd *pgDatastore) snapshotTxSource(rev postgresRevision) pgxcommon.TxFactory {
+	return func(ctx context.Context) (pgx.Tx, common.TxCleanupFunc, error) {
+		var tx pgx.Tx
+		if replica := pgd.nextReplica(); replica != nil {
+			var err error
+			tx, err = replica.beginReplicaTx(ctx, pgd.readTxOptions, rev)
+			if err != nil {
+				log.Ctx(ctx).Warn().Err(err).Int("replica", replica.index).Msg("unable to read from replica, falling back to primary")
+			}
+		}
+
+		if tx == nil {
+			var err error
+			tx, err = pgd.readPool.BeginTx(ctx, pgd.readTxOptions)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		cleanup := func(ctx context.Context) {
+			if err := tx.Rollback(ctx); err != nil {
+				log.Ctx(ctx).Err(err).Msg("error running transaction cleanup function")
+			}
+		}
+
+		if err := pgd.readTimeouts.setLocal(ctx, tx); err != nil {
+			cleanup(ctx)
+			return nil, nil, err
+		}
+
+		return tx, cleanup, nil
+	}
+}
+
+func rollbackReplicaTx(ctx context.Context, tx pgx.Tx) {
+	if err := tx.Rollback(ctx); err != nil {
+		log.Ctx(ctx).Err(err).Msg("error rolling back read replica transaction")
+	}
+}
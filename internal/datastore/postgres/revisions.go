@@ -63,7 +63,7 @@ const (
 func (pgd *pgDatastore) optimizedRevisionFunc(ctx context.Context) (datastore.Revision, time.Duration, error) {
 	var revision, xmin xid8
 	var validForNanos time.Duration
-	if err := pgd.dbpool.QueryRow(ctx, pgd.optimizedRevisionQuery).
+	if err := pgd.readPool.QueryRow(ctx, pgd.optimizedRevisionQuery).
 		Scan(&revision, &xmin, &validForNanos); err != nil {
 		return datastore.NoRevision, 0, fmt.Errorf(errRevision, err)
 	}
@@ -87,7 +87,7 @@ func (pgd *pgDatastore) CheckRevision(ctx context.Context, revisionRaw datastore
 	}
 
 	var freshEnough, unknown bool
-	if err := pgd.dbpool.QueryRow(ctx, pgd.validTransactionQuery, revision.tx).
+	if err := pgd.readPool.QueryRow(ctx, pgd.validTransactionQuery, revision.tx).
 		Scan(&freshEnough, &unknown); err != nil {
 		return fmt.Errorf(errCheckRevision, err)
 	}
@@ -159,7 +159,7 @@ func (pgd *pgDatastore) loadRevision(ctx context.Context) (xid8, xid8, error) {
 	}
 
 	var revision, xmin xid8
-	err = pgd.dbpool.QueryRow(ctx, sql, args...).Scan(&revision, &xmin)
+	err = pgd.readPool.QueryRow(ctx, sql, args...).Scan(&revision, &xmin)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return xid8{}, xid8{}, nil
@@ -170,11 +170,16 @@ func (pgd *pgDatastore) loadRevision(ctx context.Context) (xid8, xid8, error) {
 	return revision, xmin, nil
 }
 
-func createNewTransaction(ctx context.Context, tx pgx.Tx) (newXID, newXmin xid8, err error) {
+func createNewTransaction(ctx context.Context, tx pgx.Tx, tenant string, metadata map[string]string) (newXID, newXmin xid8, err error) {
 	ctx, span := tracer.Start(ctx, "createNewTransaction")
 	defer span.End()
 
-	err = tx.QueryRow(ctx, createTxn).Scan(&newXID, &newXmin)
+	if len(metadata) == 0 {
+		err = tx.QueryRow(ctx, createTxn, tenant).Scan(&newXID, &newXmin)
+		return
+	}
+
+	err = tx.QueryRow(ctx, createTxnWithMetadata, tenant, metadata).Scan(&newXID, &newXmin)
 	return
 }
 
@@ -18,6 +18,12 @@ const (
 	tablePGClass = "pg_class"
 	colReltuples = "reltuples"
 	colRelname   = "relname"
+
+	tablePGStats       = "pg_stats"
+	colTablename       = "tablename"
+	colAttname         = "attname"
+	colNDistinct       = "n_distinct"
+	colMostCommonFreqs = "most_common_freqs"
 )
 
 var (
@@ -26,8 +32,31 @@ var (
 				Select(colReltuples).
 				From(tablePGClass).
 				Where(sq.Eq{colRelname: tableTuple})
+
+	// Column statistics gathered by ANALYZE, used to estimate the number of distinct resources
+	// and subjects, and the relationships of the most common object types.
+	queryColumnStatistics = psql.
+				Select(colAttname, colNDistinct, "most_common_vals::text::text[]", colMostCommonFreqs).
+				From(tablePGStats).
+				Where(sq.Eq{colTablename: tableTuple}).
+				Where(sq.Eq{colAttname: []string{colNamespace, colObjectID, colUsersetObjectID}})
+
+	queryStorageSize = fmt.Sprintf("SELECT pg_total_relation_size('%s')", tableTuple)
 )
 
+// estimateDistinct converts a pg_stats n_distinct value, which is the negative of a fraction of
+// the number of rows when the number of distinct values is expected to grow with the table, into
+// an estimated count.
+func estimateDistinct(nDistinct float32, rowCount int64) uint64 {
+	if nDistinct < 0 {
+		nDistinct = -nDistinct * float32(rowCount)
+	}
+	if nDistinct <= 0 {
+		return 0
+	}
+	return uint64(nDistinct)
+}
+
 func (pgd *pgDatastore) Statistics(ctx context.Context) (datastore.Stats, error) {
 	idSQL, idArgs, err := queryUniqueID.ToSql()
 	if err != nil {
@@ -39,14 +68,25 @@ func (pgd *pgDatastore) Statistics(ctx context.Context) (datastore.Stats, error)
 		return datastore.Stats{}, fmt.Errorf("unable to prepare row count sql: %w", err)
 	}
 
-	filterer := func(original sq.SelectBuilder) sq.SelectBuilder {
-		return original.Where(sq.Eq{colDeletedXid: liveDeletedTxnID})
+	// The estimates of relationships are for the table as a whole, shared by all tenants.
+	filterer := filterToTenant(pgd.tenant, currentlyLivingObjects)
+
+	columnStatsSQL, columnStatsArgs, err := queryColumnStatistics.ToSql()
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to prepare column statistics sql: %w", err)
 	}
 
 	var uniqueID string
 	var nsDefs []*corev1.NamespaceDefinition
 	var relCount int64
-	if err := pgd.dbpool.BeginTxFunc(ctx, pgd.readTxOptions, func(tx pgx.Tx) error {
+	var storageBytes int64
+	var distinctResources, distinctSubjects uint64
+	relationshipEstimates := make(map[string]uint64)
+	if err := pgd.readPool.BeginTxFunc(ctx, pgd.readTxOptions, func(tx pgx.Tx) error {
+		if err := pgd.readTimeouts.setLocal(ctx, tx); err != nil {
+			return err
+		}
+
 		if pgd.analyzeBeforeStatistics {
 			if _, err := tx.Exec(ctx, fmt.Sprintf("ANALYZE %s", tableTuple)); err != nil {
 				return fmt.Errorf("unable to analyze tuple table: %w", err)
@@ -68,6 +108,42 @@ func (pgd *pgDatastore) Statistics(ctx context.Context) (datastore.Stats, error)
 			return fmt.Errorf("unable to read relationship count: %w", err)
 		}
 
+		if err := tx.QueryRow(ctx, queryStorageSize).Scan(&storageBytes); err != nil {
+			return fmt.Errorf("unable to read relationship storage size: %w", err)
+		}
+
+		rows, err := tx.Query(ctx, columnStatsSQL, columnStatsArgs...)
+		if err != nil {
+			return fmt.Errorf("unable to read column statistics: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var column string
+			var nDistinct float32
+			var mostCommonValues []string
+			var mostCommonFreqs []float32
+			if err := rows.Scan(&column, &nDistinct, &mostCommonValues, &mostCommonFreqs); err != nil {
+				return fmt.Errorf("unable to decode column statistics: %w", err)
+			}
+
+			switch column {
+			case colNamespace:
+				for i, value := range mostCommonValues {
+					if i < len(mostCommonFreqs) && relCount > 0 {
+						relationshipEstimates[value] = uint64(mostCommonFreqs[i] * float32(relCount))
+					}
+				}
+			case colObjectID:
+				distinctResources = estimateDistinct(nDistinct, relCount)
+			case colUsersetObjectID:
+				distinctSubjects = estimateDistinct(nDistinct, relCount)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("unable to read column statistics: %w", err)
+		}
+
 		return nil
 	}); err != nil {
 		return datastore.Stats{}, err
@@ -79,9 +155,17 @@ func (pgd *pgDatastore) Statistics(ctx context.Context) (datastore.Stats, error)
 		relCountUint = uint64(relCount)
 	}
 
+	var storageBytesUint uint64
+	if storageBytes > 0 {
+		storageBytesUint = uint64(storageBytes)
+	}
+
 	return datastore.Stats{
 		UniqueID:                   uniqueID,
-		ObjectTypeStatistics:       datastore.ComputeObjectTypeStats(nsDefs),
+		ObjectTypeStatistics:       datastore.ComputeObjectTypeStatsWithEstimates(nsDefs, relationshipEstimates),
 		EstimatedRelationshipCount: relCountUint,
+		EstimatedDistinctResources: distinctResources,
+		EstimatedDistinctSubjects:  distinctSubjects,
+		EstimatedStorageBytes:      storageBytesUint,
 	}, nil
 }
@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+var _ datastore.TenantPartitionedDatastore = (*pgDatastore)(nil)
+
+// ForTenant returns a datastore for the data of the tenant, which shares the connection pool and
+// garbage collection of this datastore.
+func (pgd *pgDatastore) ForTenant(tenantID string) datastore.Datastore {
+	forTenant := *pgd
+	forTenant.tenant = tenantID
+	return &pgTenantDatastore{&forTenant}
+}
+
+// pgTenantDatastore is a datastore for the data of a single tenant.
+type pgTenantDatastore struct {
+	*pgDatastore
+}
+
+// Close does nothing, as the connection pool is owned by the datastore from which the tenant's
+// datastore was created.
+func (td *pgTenantDatastore) Close() error {
+	return nil
+}
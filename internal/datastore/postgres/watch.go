@@ -2,11 +2,13 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
 	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
@@ -16,6 +18,8 @@ import (
 
 const (
 	watchSleep = 100 * time.Millisecond
+
+	watchDisabledReason = "postgres must be run with track_commit_timestamp=on for watch to be enabled. See https://spicedb.dev/d/enable-watch-api-postgres"
 )
 
 var (
@@ -23,12 +27,12 @@ var (
 	// xid8 is one of the last ~2 billion transaction IDs generated. We should be garbage
 	// collecting these transactions long before we get to that point.
 	newRevisionsQuery = fmt.Sprintf(`
-	SELECT %[1]s from %[2]s
+	SELECT %[1]s, %[3]s, %[4]s from %[2]s
 	WHERE pg_xact_commit_timestamp(%[1]s::xid) > (
 		SELECT pg_xact_commit_timestamp(%[1]s::xid) FROM relation_tuple_transaction where %[1]s = $1
 	) AND %[1]s < pg_snapshot_xmin(pg_current_snapshot())
 	ORDER BY pg_xact_commit_timestamp(%[1]s::xid);
-`, colXID, tableTransaction)
+`, colXID, tableTransaction, colMetadata, colTenant)
 
 	queryChanged = psql.Select(
 		colNamespace,
@@ -39,6 +43,8 @@ var (
 		colUsersetRelation,
 		colCaveatContextName,
 		colCaveatContext,
+		colExpiration,
+		colMetadata,
 		colCreatedXid,
 		colDeletedXid,
 	).From(tableTuple)
@@ -52,19 +58,34 @@ func (pgd *pgDatastore) Watch(
 	errs := make(chan error, 1)
 
 	if !pgd.watchEnabled {
-		errs <- datastore.NewWatchDisabledErr("postgres must be run with track_commit_timestamp=on for watch to be enabled. See https://spicedb.dev/d/enable-watch-api-postgres")
+		errs <- datastore.NewWatchDisabledErr(watchDisabledReason)
 		return updates, errs
 	}
 
 	afterRevision := afterRevisionRaw.(postgresRevision)
 
+	if pgd.watchMode == watchLogicalReplication {
+		go func() {
+			defer close(updates)
+			defer close(errs)
+
+			pgd.watchLogicalReplication(ctx, afterRevision, updates, errs)
+		}()
+		return updates, errs
+	}
+
 	go func() {
 		defer close(updates)
 		defer close(errs)
 
 		currentTxn := afterRevision.tx
+		lastSent := time.Now()
 
 		for {
+			// The notification is awaited from before the query, so that a transaction which
+			// commits while the query runs still wakes the watch.
+			notified, pollInterval := pgd.notifier.wait()
+
 			newTxns, err := pgd.getNewRevisions(ctx, currentTxn)
 			if err != nil {
 				if errors.Is(ctx.Err(), context.Canceled) {
@@ -75,8 +96,15 @@ func (pgd *pgDatastore) Watch(
 				return
 			}
 
-			for _, revision := range newTxns {
-				changeToWrite, err := pgd.loadChanges(ctx, revision)
+			for _, newTxn := range newTxns {
+				// Transactions of other tenants are skipped, but still advance the watch so
+				// that they are covered by the next checkpoint.
+				if newTxn.tenant != pgd.tenant {
+					currentTxn = newTxn.xid
+					continue
+				}
+
+				changeToWrite, err := pgd.loadChanges(ctx, newTxn.xid, newTxn.metadata)
 				if err != nil {
 					if errors.Is(ctx.Err(), context.Canceled) {
 						errs <- datastore.NewWatchCanceledErr()
@@ -93,13 +121,30 @@ func (pgd *pgDatastore) Watch(
 					return
 				}
 
-				currentTxn = revision
+				currentTxn = newTxn.xid
+				lastSent = time.Now()
 			}
 
 			if len(newTxns) == 0 {
-				sleep := time.NewTimer(watchSleep)
+				// Send a checkpoint if no changes have been sent within the interval
+				if time.Since(lastSent) >= common.WatchCheckpointInterval {
+					select {
+					case updates <- &datastore.RevisionChanges{
+						Revision:     postgresRevision{currentTxn, noXmin},
+						IsCheckpoint: true,
+					}:
+					default:
+						errs <- datastore.NewWatchDisconnectedErr()
+						return
+					}
+					lastSent = time.Now()
+				}
+
+				sleep := time.NewTimer(pollInterval)
 
 				select {
+				case <-notified:
+					sleep.Stop()
 				case <-sleep.C:
 					break
 				case <-ctx.Done():
@@ -113,24 +158,32 @@ func (pgd *pgDatastore) Watch(
 	return updates, errs
 }
 
+// transactionInfo is a committed transaction, the metadata stored with it and the tenant which
+// wrote it.
+type transactionInfo struct {
+	xid      xid8
+	metadata map[string]string
+	tenant   string
+}
+
 func (pgd *pgDatastore) getNewRevisions(
 	ctx context.Context,
 	afterTX xid8,
-) ([]xid8, error) {
-	rows, err := pgd.dbpool.Query(context.Background(), newRevisionsQuery, afterTX)
+) ([]transactionInfo, error) {
+	rows, err := pgd.readPool.Query(context.Background(), newRevisionsQuery, afterTX)
 	if err != nil {
 		return nil, fmt.Errorf("unable to load new revisions: %w", err)
 	}
 	defer rows.Close()
 
-	var ids []xid8
+	var ids []transactionInfo
 	for rows.Next() {
-		var nextXID xid8
-		if err := rows.Scan(&nextXID); err != nil {
+		var nextTxn transactionInfo
+		if err := rows.Scan(&nextTxn.xid, &nextTxn.metadata, &nextTxn.tenant); err != nil {
 			return nil, fmt.Errorf("unable to decode new revision: %w", err)
 		}
 
-		ids = append(ids, nextXID)
+		ids = append(ids, nextTxn)
 	}
 	if rows.Err() != nil {
 		return nil, fmt.Errorf("unable to load new revisions: %w", err)
@@ -139,54 +192,25 @@ func (pgd *pgDatastore) getNewRevisions(
 	return ids, nil
 }
 
-func (pgd *pgDatastore) loadChanges(ctx context.Context, revision xid8) (*datastore.RevisionChanges, error) {
-	sql, args, err := queryChanged.Where(sq.Or{
+func (pgd *pgDatastore) loadChanges(ctx context.Context, revision xid8, metadata map[string]string) (*datastore.RevisionChanges, error) {
+	query, args, err := queryChanged.Where(sq.Or{
 		sq.Eq{colCreatedXid: revision},
 		sq.Eq{colDeletedXid: revision},
-	}).ToSql()
+	}).Where(sq.Eq{colTenant: pgd.tenant}).ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("unable to prepare changes SQL: %w", err)
 	}
 
-	changes, err := pgd.dbpool.Query(ctx, sql, args...)
+	changes, err := pgd.readPool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to load changes for XID: %w", err)
 	}
 
 	tracked := common.NewChanges()
 	for changes.Next() {
-		nextTuple := &core.RelationTuple{
-			ResourceAndRelation: &core.ObjectAndRelation{},
-			Subject:             &core.ObjectAndRelation{},
-		}
-
-		var createdXID, deletedXID xid8
-		var caveatName string
-		var caveatContext map[string]any
-		if err := changes.Scan(
-			&nextTuple.ResourceAndRelation.Namespace,
-			&nextTuple.ResourceAndRelation.ObjectId,
-			&nextTuple.ResourceAndRelation.Relation,
-			&nextTuple.Subject.Namespace,
-			&nextTuple.Subject.ObjectId,
-			&nextTuple.Subject.Relation,
-			&caveatName,
-			&caveatContext,
-			&createdXID,
-			&deletedXID,
-		); err != nil {
-			return nil, fmt.Errorf("unable to parse changed tuple: %w", err)
-		}
-
-		if caveatName != "" {
-			contextStruct, err := structpb.NewStruct(caveatContext)
-			if err != nil {
-				return nil, fmt.Errorf("failed to read caveat context from update: %w", err)
-			}
-			nextTuple.Caveat = &core.ContextualizedCaveat{
-				CaveatName: caveatName,
-				Context:    contextStruct,
-			}
+		nextTuple, createdXID, deletedXID, err := scanChangedTuple(changes)
+		if err != nil {
+			return nil, err
 		}
 
 		if createdXID.Uint == revision.Uint {
@@ -199,11 +223,63 @@ func (pgd *pgDatastore) loadChanges(ctx context.Context, revision xid8) (*datast
 		return nil, fmt.Errorf("unable to load changes for XID: %w", err)
 	}
 
+	return pgd.revisionChanges(tracked, revision, metadata), nil
+}
+
+// revisionChanges returns the changes tracked for the transaction, which are empty if it changed
+// no relationships.
+func (pgd *pgDatastore) revisionChanges(tracked common.Changes, revision xid8, metadata map[string]string) *datastore.RevisionChanges {
 	reconciledChanges := tracked.AsRevisionChanges(pgd)
 	if len(reconciledChanges) == 0 {
 		return &datastore.RevisionChanges{
 			Revision: postgresRevision{revision, noXmin},
-		}, nil
+			Metadata: metadata,
+		}
 	}
-	return reconciledChanges[0], nil
+
+	reconciledChanges[0].Metadata = metadata
+	return reconciledChanges[0]
+}
+
+// scanChangedTuple scans a row selected by queryChanged.
+func scanChangedTuple(row pgx.Row) (*core.RelationTuple, xid8, xid8, error) {
+	nextTuple := &core.RelationTuple{
+		ResourceAndRelation: &core.ObjectAndRelation{},
+		Subject:             &core.ObjectAndRelation{},
+	}
+
+	var createdXID, deletedXID xid8
+	var caveatName string
+	var caveatContext map[string]any
+	var expiration sql.NullTime
+	if err := row.Scan(
+		&nextTuple.ResourceAndRelation.Namespace,
+		&nextTuple.ResourceAndRelation.ObjectId,
+		&nextTuple.ResourceAndRelation.Relation,
+		&nextTuple.Subject.Namespace,
+		&nextTuple.Subject.ObjectId,
+		&nextTuple.Subject.Relation,
+		&caveatName,
+		&caveatContext,
+		&expiration,
+		&nextTuple.OptionalMetadata,
+		&createdXID,
+		&deletedXID,
+	); err != nil {
+		return nil, createdXID, deletedXID, fmt.Errorf("unable to parse changed tuple: %w", err)
+	}
+
+	if caveatName != "" {
+		contextStruct, err := structpb.NewStruct(caveatContext)
+		if err != nil {
+			return nil, createdXID, deletedXID, fmt.Errorf("failed to read caveat context from update: %w", err)
+		}
+		nextTuple.Caveat = &core.ContextualizedCaveat{
+			CaveatName: caveatName,
+			Context:    contextStruct,
+		}
+	}
+
+	nextTuple.OptionalExpirationTime = common.ExpirationFrom(expiration)
+	return nextTuple, createdXID, deletedXID, nil
 }
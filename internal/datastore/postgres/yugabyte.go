@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgconn"
+)
+
+// yugabyteRetryableMessages are the messages of the internal errors raised by YugabyteDB for
+// conflicts between distributed transactions, which more recent versions raise as serialization
+// failures. The transaction is aborted by each of them, and is safe to retry.
+var yugabyteRetryableMessages = []string{
+	"Restart read required",
+	"Transaction aborted",
+	"Transaction expired",
+	"conflicts with higher priority transaction",
+	"Try again",
+}
+
+// yugabyteErrorRetryable returns whether the error of a transaction run against YugabyteDB is
+// safe to retry.
+func yugabyteErrorRetryable(err error) bool {
+	if errorRetryable(err) {
+		return true
+	}
+
+	var pgerr *pgconn.PgError
+	if !errors.As(err, &pgerr) || pgerr.SQLState() != pgInternalError {
+		return false
+	}
+
+	for _, message := range yugabyteRetryableMessages {
+		if strings.Contains(pgerr.Message, message) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYugabyteErrorRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: pgSerializationFailure}, true},
+		{"read restart", &pgconn.PgError{Code: pgInternalError, Message: "Restart read required at: { read: ... }"}, true},
+		{"wrapped conflict", fmt.Errorf("unable to write: %w", &pgconn.PgError{
+			Code:    pgInternalError,
+			Message: "Operation failed. Try again: Transaction 1234 conflicts with higher priority transaction: 5678",
+		}), true},
+		{"other internal error", &pgconn.PgError{Code: pgInternalError, Message: "unexpected tablet state"}, false},
+		{"restart message of another code", &pgconn.PgError{Code: "42P01", Message: "Restart read required"}, false},
+		{"not a postgres error", errors.New("Try again"), false},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.retryable, yugabyteErrorRetryable(tc.err))
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/singleflight"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/cache"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -44,19 +45,29 @@ type nsCachingProxy struct {
 	readNsGroup singleflight.Group
 }
 
-func (p *nsCachingProxy) SnapshotReader(rev datastore.Revision) datastore.Reader {
-	delegateReader := p.Datastore.SnapshotReader(rev)
+func (p *nsCachingProxy) Unwrap() datastore.Datastore { return p.Datastore }
+
+func (p *nsCachingProxy) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	delegateReader := p.Datastore.SnapshotReader(rev, opts...)
+
+	// Namespaces are cached by revision, so reads which may not be made at exactly the revision
+	// bypass the cache.
+	if options.NewSnapshotReaderOptionsWithOptions(opts...).Consistency != options.ExactSnapshot {
+		return delegateReader
+	}
+
 	return &nsCachingReader{delegateReader, sync.Mutex{}, rev, p}
 }
 
 func (p *nsCachingProxy) ReadWriteTx(
 	ctx context.Context,
 	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
 	return p.Datastore.ReadWriteTx(ctx, func(delegateRWT datastore.ReadWriteTransaction) error {
 		rwt := &nsCachingRWT{delegateRWT, &sync.Map{}}
 		return f(rwt)
-	})
+	}, opts...)
 }
 
 type nsCachingReader struct {
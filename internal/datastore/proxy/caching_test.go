@@ -9,10 +9,12 @@ import (
 	"time"
 
 	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/internal/datastore/proxy/proxy_test"
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/datastore/revision"
@@ -102,6 +104,28 @@ func TestSnapshotNamespaceCaching(t *testing.T) {
 	twoReader.AssertExpectations(t)
 }
 
+func TestSnapshotNamespaceCachingBypassedForStaleReads(t *testing.T) {
+	dsMock := &proxy_test.MockDatastore{}
+
+	oneReader := &proxy_test.MockReader{}
+	dsMock.On("SnapshotReader", one, mock.Anything).Return(oneReader)
+	oneReader.On("ReadNamespace", nsA).Return(nil, old, nil).Twice()
+
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds := NewCachingDatastoreProxy(dsMock, DatastoreProxyTestCache(t))
+
+	for i := 0; i < 2; i++ {
+		_, updatedOneA, err := ds.SnapshotReader(one, options.WithConsistency(options.MinimizeLatency)).ReadNamespace(ctx, nsA)
+		require.NoError(err)
+		require.True(old.Equal(updatedOneA))
+	}
+
+	dsMock.AssertExpectations(t)
+	oneReader.AssertExpectations(t)
+}
+
 func TestRWTNamespaceCaching(t *testing.T) {
 	dsMock := &proxy_test.MockDatastore{}
 	rwtMock := &proxy_test.MockReadWriteTransaction{}
@@ -0,0 +1,267 @@
+package proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// EncryptedCaveatContextKey is the only key of the caveat context stored for a relationship whose
+// context has been encrypted, and holds the base64 encoded ciphertext of the context.
+const EncryptedCaveatContextKey = "spicedb_encrypted_context"
+
+// CaveatContextEncrypter encrypts the caveat context of relationships before it is stored by the
+// datastore, and decrypts it when it is read back.
+type CaveatContextEncrypter interface {
+	// Encrypt returns the ciphertext of the serialized caveat context.
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+
+	// Decrypt returns the serialized caveat context of a ciphertext returned by Encrypt.
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// NewCaveatContextEncryptionProxy creates a proxy which encrypts the caveat context of every
+// relationship written to the delegate datastore, and decrypts it for every relationship read
+// or watched. Relationships whose context was stored before encryption was enabled are returned
+// as stored.
+//
+// Only the caveat context is encrypted: resource and subject IDs are indexed and matched by the
+// datastore, and so must be stored as written.
+func NewCaveatContextEncryptionProxy(delegate datastore.Datastore, encrypter CaveatContextEncrypter) datastore.Datastore {
+	return &caveatEncryptionProxy{Datastore: delegate, encrypter: encrypter}
+}
+
+type caveatEncryptionProxy struct {
+	datastore.Datastore
+
+	encrypter CaveatContextEncrypter
+}
+
+func (p *caveatEncryptionProxy) Unwrap() datastore.Datastore { return p.Datastore }
+
+func (p *caveatEncryptionProxy) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return &caveatDecryptingReader{p.Datastore.SnapshotReader(rev, opts...), p.encrypter}
+}
+
+func (p *caveatEncryptionProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	return p.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return f(&caveatEncryptingRWT{rwt, &caveatDecryptingReader{rwt, p.encrypter}})
+	}, opts...)
+}
+
+func (p *caveatEncryptionProxy) BulkLoad(ctx context.Context, source datastore.BulkWriteRelationshipSource) (datastore.Revision, error) {
+	return p.Datastore.BulkLoad(ctx, &caveatEncryptingSource{source, p.encrypter})
+}
+
+func (p *caveatEncryptionProxy) Watch(ctx context.Context, afterRevision datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
+	delegateChanges, delegateErrs := p.Datastore.Watch(ctx, afterRevision)
+
+	changes := make(chan *datastore.RevisionChanges, cap(delegateChanges))
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(changes)
+		defer close(errs)
+
+		for {
+			select {
+			case change, ok := <-delegateChanges:
+				if !ok {
+					return
+				}
+
+				decrypted := &datastore.RevisionChanges{
+					Revision:     change.Revision,
+					Changes:      make([]*core.RelationTupleUpdate, 0, len(change.Changes)),
+					IsCheckpoint: change.IsCheckpoint,
+					Metadata:     change.Metadata,
+				}
+				for _, update := range change.Changes {
+					tpl, err := decryptCaveatContext(ctx, p.encrypter, update.Tuple)
+					if err != nil {
+						errs <- err
+						return
+					}
+					decrypted.Changes = append(decrypted.Changes, &core.RelationTupleUpdate{
+						Operation: update.Operation,
+						Tuple:     tpl,
+					})
+				}
+
+				select {
+				case changes <- decrypted:
+				case <-ctx.Done():
+					errs <- datastore.NewWatchCanceledErr()
+					return
+				}
+			case err, ok := <-delegateErrs:
+				if ok {
+					errs <- err
+				}
+				return
+			}
+		}
+	}()
+
+	return changes, errs
+}
+
+type caveatDecryptingReader struct {
+	datastore.Reader
+
+	encrypter CaveatContextEncrypter
+}
+
+func (r *caveatDecryptingReader) QueryRelationships(ctx context.Context, filter datastore.RelationshipsFilter, opts ...options.QueryOptionsOption) (datastore.RelationshipIterator, error) {
+	iter, err := r.Reader.QueryRelationships(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &caveatDecryptingIterator{delegate: iter, ctx: ctx, encrypter: r.encrypter}, nil
+}
+
+func (r *caveatDecryptingReader) ReverseQueryRelationships(ctx context.Context, subjectsFilter datastore.SubjectsFilter, opts ...options.ReverseQueryOptionsOption) (datastore.RelationshipIterator, error) {
+	iter, err := r.Reader.ReverseQueryRelationships(ctx, subjectsFilter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &caveatDecryptingIterator{delegate: iter, ctx: ctx, encrypter: r.encrypter}, nil
+}
+
+type caveatEncryptingRWT struct {
+	datastore.ReadWriteTransaction
+	reader *caveatDecryptingReader
+}
+
+func (rwt *caveatEncryptingRWT) QueryRelationships(ctx context.Context, filter datastore.RelationshipsFilter, opts ...options.QueryOptionsOption) (datastore.RelationshipIterator, error) {
+	return rwt.reader.QueryRelationships(ctx, filter, opts...)
+}
+
+func (rwt *caveatEncryptingRWT) ReverseQueryRelationships(ctx context.Context, subjectsFilter datastore.SubjectsFilter, opts ...options.ReverseQueryOptionsOption) (datastore.RelationshipIterator, error) {
+	return rwt.reader.ReverseQueryRelationships(ctx, subjectsFilter, opts...)
+}
+
+func (rwt *caveatEncryptingRWT) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	encrypted := make([]*core.RelationTupleUpdate, 0, len(mutations))
+	for _, mut := range mutations {
+		tpl, err := encryptCaveatContext(ctx, rwt.reader.encrypter, mut.Tuple)
+		if err != nil {
+			return err
+		}
+		encrypted = append(encrypted, &core.RelationTupleUpdate{Operation: mut.Operation, Tuple: tpl})
+	}
+	return rwt.ReadWriteTransaction.WriteRelationships(ctx, encrypted)
+}
+
+type caveatEncryptingSource struct {
+	datastore.BulkWriteRelationshipSource
+
+	encrypter CaveatContextEncrypter
+}
+
+func (s *caveatEncryptingSource) Next(ctx context.Context) (*core.RelationTuple, error) {
+	tpl, err := s.BulkWriteRelationshipSource.Next(ctx)
+	if err != nil || tpl == nil {
+		return tpl, err
+	}
+	return encryptCaveatContext(ctx, s.encrypter, tpl)
+}
+
+type caveatDecryptingIterator struct {
+	delegate  datastore.RelationshipIterator
+	ctx       context.Context
+	encrypter CaveatContextEncrypter
+	err       error
+}
+
+func (it *caveatDecryptingIterator) Next() *core.RelationTuple {
+	if it.err != nil {
+		return nil
+	}
+
+	tpl := it.delegate.Next()
+	if tpl == nil {
+		return nil
+	}
+
+	tpl, it.err = decryptCaveatContext(it.ctx, it.encrypter, tpl)
+	if it.err != nil {
+		return nil
+	}
+	return tpl
+}
+
+func (it *caveatDecryptingIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.delegate.Err()
+}
+
+func (it *caveatDecryptingIterator) Close() {
+	it.delegate.Close()
+}
+
+// encryptCaveatContext returns a copy of the relationship with its caveat context replaced by
+// the encrypted context, or the relationship itself if it has no caveat context.
+func encryptCaveatContext(ctx context.Context, encrypter CaveatContextEncrypter, tpl *core.RelationTuple) (*core.RelationTuple, error) {
+	if tpl.Caveat == nil || len(tpl.Caveat.Context.GetFields()) == 0 {
+		return tpl, nil
+	}
+
+	plaintext, err := proto.Marshal(tpl.Caveat.Context)
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize caveat context: %w", err)
+	}
+
+	ciphertext, err := encrypter.Encrypt(ctx, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encrypt caveat context: %w", err)
+	}
+
+	encrypted := tpl.CloneVT()
+	encrypted.Caveat.Context = &structpb.Struct{Fields: map[string]*structpb.Value{
+		EncryptedCaveatContextKey: structpb.NewStringValue(base64.StdEncoding.EncodeToString(ciphertext)),
+	}}
+	return encrypted, nil
+}
+
+// decryptCaveatContext returns a copy of the relationship with its encrypted caveat context
+// replaced by the decrypted context, or the relationship itself if its context is not encrypted.
+func decryptCaveatContext(ctx context.Context, encrypter CaveatContextEncrypter, tpl *core.RelationTuple) (*core.RelationTuple, error) {
+	fields := tpl.Caveat.GetContext().GetFields()
+	if len(fields) != 1 {
+		return tpl, nil
+	}
+
+	encoded, ok := fields[EncryptedCaveatContextKey].GetKind().(*structpb.Value_StringValue)
+	if !ok {
+		return tpl, nil
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded.StringValue)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode encrypted caveat context: %w", err)
+	}
+
+	plaintext, err := encrypter.Decrypt(ctx, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt caveat context: %w", err)
+	}
+
+	caveatContext := &structpb.Struct{}
+	if err := proto.Unmarshal(plaintext, caveatContext); err != nil {
+		return nil, fmt.Errorf("unable to deserialize caveat context: %w", err)
+	}
+
+	decrypted := tpl.CloneVT()
+	decrypted.Caveat.Context = caveatContext
+	return decrypted, nil
+}
@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+var wrappedKeyPrefix = []byte("wrapped:")
+
+// fakeKMS generates a new data key for each call, and "encrypts" data keys by prefixing them.
+type fakeKMS struct {
+	kmsiface.KMSAPI
+
+	generated byte
+	decrypts  int
+}
+
+func (f *fakeKMS) GenerateDataKeyWithContext(_ context.Context, _ *kms.GenerateDataKeyInput, _ ...request.Option) (*kms.GenerateDataKeyOutput, error) {
+	f.generated++
+	key := bytes.Repeat([]byte{f.generated}, 32)
+	return &kms.GenerateDataKeyOutput{
+		Plaintext:      key,
+		CiphertextBlob: append(append([]byte{}, wrappedKeyPrefix...), key...),
+	}, nil
+}
+
+func (f *fakeKMS) DecryptWithContext(_ context.Context, input *kms.DecryptInput, _ ...request.Option) (*kms.DecryptOutput, error) {
+	f.decrypts++
+	return &kms.DecryptOutput{Plaintext: bytes.TrimPrefix(input.CiphertextBlob, wrappedKeyPrefix)}, nil
+}
+
+func caveatedTuple(t *testing.T, rel string, caveatContext map[string]any) *core.RelationTuple {
+	tpl := tuple.MustParse(rel)
+	cctx, err := structpb.NewStruct(caveatContext)
+	require.NoError(t, err)
+	tpl.Caveat = &core.ContextualizedCaveat{CaveatName: "test", Context: cctx}
+	return tpl
+}
+
+func readCaveatContexts(t *testing.T, ds datastore.Datastore, revision datastore.Revision) map[string]map[string]any {
+	iter, err := ds.SnapshotReader(revision).QueryRelationships(context.Background(), datastore.RelationshipsFilter{
+		ResourceType: "document",
+	})
+	require.NoError(t, err)
+	defer iter.Close()
+
+	found := map[string]map[string]any{}
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		found[tpl.ResourceAndRelation.ObjectId] = tpl.Caveat.GetContext().AsMap()
+	}
+	require.NoError(t, iter.Err())
+	return found
+}
+
+func TestCaveatContextEncryption(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	ds, revision := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+
+	encrypter, err := NewKMSCaveatContextEncrypter(ctx, &fakeKMS{}, "key")
+	require.NoError(err)
+	encrypted := NewCaveatContextEncryptionProxy(ds, encrypter)
+
+	changes, errs := encrypted.Watch(ctx, revision)
+
+	// Context written before encryption was enabled is returned as stored.
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE,
+		caveatedTuple(t, "document:plain#caveated_viewer@user:tom", map[string]any{"secret": "plain"}))
+	require.NoError(err)
+
+	revision, err = common.WriteTuples(ctx, encrypted, core.RelationTupleUpdate_CREATE,
+		caveatedTuple(t, "document:written#caveated_viewer@user:tom", map[string]any{"secret": "written"}),
+		tuple.MustParse("document:uncaveated#viewer@user:tom"))
+	require.NoError(err)
+
+	revision, err = encrypted.BulkLoad(ctx, common.SliceBulkLoadSource([]*core.RelationTuple{
+		caveatedTuple(t, "document:loaded#caveated_viewer@user:tom", map[string]any{"secret": "loaded"}),
+	}))
+	require.NoError(err)
+
+	stored := readCaveatContexts(t, ds, revision)
+	require.Equal(map[string]any{"secret": "plain"}, stored["plain"])
+	for _, id := range []string{"written", "loaded"} {
+		require.Contains(stored[id], EncryptedCaveatContextKey)
+		require.Len(stored[id], 1)
+	}
+	require.Empty(stored["uncaveated"])
+
+	read := readCaveatContexts(t, encrypted, revision)
+	require.Equal(map[string]any{"secret": "plain"}, read["plain"])
+	require.Equal(map[string]any{"secret": "written"}, read["written"])
+	require.Equal(map[string]any{"secret": "loaded"}, read["loaded"])
+	require.Empty(read["uncaveated"])
+
+	// Watched changes are decrypted.
+	var watched []*core.RelationTupleUpdate
+	for len(watched) < 4 {
+		select {
+		case change := <-changes:
+			watched = append(watched, change.Changes...)
+		case err := <-errs:
+			require.NoError(err)
+		}
+	}
+	for _, update := range watched {
+		if update.Tuple.ResourceAndRelation.ObjectId == "written" {
+			require.Equal(map[string]any{"secret": "written"}, update.Tuple.Caveat.Context.AsMap())
+		}
+	}
+}
+
+func TestKMSCaveatContextEncrypter(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	client := &fakeKMS{}
+	first, err := NewKMSCaveatContextEncrypter(ctx, client, "key")
+	require.NoError(err)
+	second, err := NewKMSCaveatContextEncrypter(ctx, client, "key")
+	require.NoError(err)
+
+	ciphertext, err := first.Encrypt(ctx, []byte("context"))
+	require.NoError(err)
+	require.NotContains(string(ciphertext), "context")
+
+	plaintext, err := first.Decrypt(ctx, ciphertext)
+	require.NoError(err)
+	require.Equal([]byte("context"), plaintext)
+	require.Equal(0, client.decrypts)
+
+	// The data key of another encrypter is decrypted by KMS once.
+	for i := 0; i < 2; i++ {
+		plaintext, err = second.Decrypt(ctx, ciphertext)
+		require.NoError(err)
+		require.Equal([]byte("context"), plaintext)
+	}
+	require.Equal(1, client.decrypts)
+
+	for _, malformed := range [][]byte{nil, {0, 100}, ciphertext[:len(ciphertext)-1]} {
+		_, err = first.Decrypt(ctx, malformed)
+		require.Error(err)
+	}
+}
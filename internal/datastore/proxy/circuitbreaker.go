@@ -0,0 +1,445 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+var (
+	circuitOpenGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "spicedb",
+		Subsystem: "datastore",
+		Name:      "circuit_open",
+		Help:      "whether the circuit for a datastore operation is open (1) or closed (0)",
+	}, []string{"operation"})
+
+	circuitRejectedCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "spicedb",
+		Subsystem: "datastore",
+		Name:      "circuit_rejected_total",
+		Help:      "total number of datastore operations rejected by an open circuit",
+	}, []string{"operation"})
+)
+
+const (
+	opOptimizedRevision         = "OptimizedRevision"
+	opHeadRevision              = "HeadRevision"
+	opCheckRevision             = "CheckRevision"
+	opReadWriteTx               = "ReadWriteTx"
+	opBulkLoad                  = "BulkLoad"
+	opStatistics                = "Statistics"
+	opReadNamespace             = "ReadNamespace"
+	opListNamespaces            = "ListNamespaces"
+	opLookupNamespaces          = "LookupNamespaces"
+	opQueryRelationships        = "QueryRelationships"
+	opReverseQueryRelationships = "ReverseQueryRelationships"
+	opCountRelationships        = "CountRelationships"
+	opListResourceTypes         = "ListResourceTypes"
+	opReadCaveatByName          = "ReadCaveatByName"
+	opListCaveats               = "ListCaveats"
+	opLookupCaveats             = "LookupCaveats"
+)
+
+var circuitOperations = []string{
+	opOptimizedRevision,
+	opHeadRevision,
+	opCheckRevision,
+	opReadWriteTx,
+	opBulkLoad,
+	opStatistics,
+	opReadNamespace,
+	opListNamespaces,
+	opLookupNamespaces,
+	opQueryRelationships,
+	opReverseQueryRelationships,
+	opCountRelationships,
+	opListResourceTypes,
+	opReadCaveatByName,
+	opListCaveats,
+	opLookupCaveats,
+}
+
+// CircuitBreakerConfig configures the circuit breaker proxy. Each datastore operation has its own
+// circuit, which opens when the ratio of failed calls within a window exceeds the threshold.
+type CircuitBreakerConfig struct {
+	// FailureRatio is the ratio of failed calls to all calls within a window at or above which
+	// the circuit opens.
+	FailureRatio float64
+
+	// MinimumRequests is the number of calls which must be made within a window before the
+	// circuit can open.
+	MinimumRequests uint32
+
+	// Window is the interval over which calls are counted.
+	Window time.Duration
+
+	// SlowCallThreshold, if non-zero, is the latency above which a call is counted as failed.
+	SlowCallThreshold time.Duration
+
+	// OpenDuration is how long the circuit stays open before a single probe call is let through
+	// to determine whether the datastore has recovered.
+	OpenDuration time.Duration
+}
+
+// NewCircuitBreakerProxy creates a proxy which fails datastore operations fast with a retryable
+// ErrUnavailable while the delegate is unhealthy.
+func NewCircuitBreakerProxy(delegate datastore.Datastore, config CircuitBreakerConfig) (datastore.Datastore, error) {
+	return newCircuitBreakerProxyWithTimeSource(delegate, config, clock.New())
+}
+
+func newCircuitBreakerProxyWithTimeSource(
+	delegate datastore.Datastore,
+	config CircuitBreakerConfig,
+	timeSource clock.Clock,
+) (datastore.Datastore, error) {
+	if config.FailureRatio <= 0 || config.FailureRatio > 1 {
+		return nil, errors.New("circuit breaker failure ratio must be in the range (0.0-1.0]")
+	}
+	if config.Window <= 0 {
+		return nil, errors.New("circuit breaker requires a positive window")
+	}
+	if config.OpenDuration <= 0 {
+		return nil, errors.New("circuit breaker requires a positive open duration")
+	}
+
+	circuits := make(map[string]*circuit, len(circuitOperations))
+	for _, operation := range circuitOperations {
+		circuits[operation] = &circuit{
+			operation:   operation,
+			config:      config,
+			timeSource:  timeSource,
+			windowStart: timeSource.Now(),
+		}
+	}
+
+	return &circuitBreakerProxy{delegate, circuits}, nil
+}
+
+type circuitBreakerProxy struct {
+	datastore.Datastore
+	circuits map[string]*circuit
+}
+
+func (p *circuitBreakerProxy) Unwrap() datastore.Datastore { return p.Datastore }
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type callOutcome int
+
+const (
+	outcomeSuccess callOutcome = iota
+	outcomeFailure
+	outcomeIgnored
+)
+
+type circuit struct {
+	operation  string
+	config     CircuitBreakerConfig
+	timeSource clock.Clock
+
+	sync.Mutex
+	state       circuitState
+	windowStart time.Time
+	requests    uint32
+	failures    uint32
+	openedAt    time.Time
+	probing     bool
+}
+
+// call runs the function if the circuit allows it, recording its outcome.
+func (c *circuit) call(ctx context.Context, f func() error) error {
+	probe, err := c.allow()
+	if err != nil {
+		log.Ctx(ctx).Debug().Str("operation", c.operation).Msg("datastore circuit open, rejecting call")
+		return err
+	}
+
+	start := c.timeSource.Now()
+	err = f()
+	c.record(probe, c.outcome(err, c.timeSource.Since(start)))
+	return err
+}
+
+func (c *circuit) allow() (bool, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	switch c.state {
+	case circuitOpen:
+		sinceOpened := c.timeSource.Since(c.openedAt)
+		if sinceOpened < c.config.OpenDuration {
+			return false, c.rejectLocked(c.config.OpenDuration - sinceOpened)
+		}
+		c.state = circuitHalfOpen
+		c.probing = true
+		return true, nil
+
+	case circuitHalfOpen:
+		if c.probing {
+			return false, c.rejectLocked(c.config.OpenDuration)
+		}
+		c.probing = true
+		return true, nil
+
+	default:
+		return false, nil
+	}
+}
+
+func (c *circuit) rejectLocked(retryAfter time.Duration) error {
+	circuitRejectedCount.WithLabelValues(c.operation).Inc()
+	return datastore.NewUnavailableErr(fmt.Sprintf("circuit open for %s", c.operation), retryAfter)
+}
+
+func (c *circuit) outcome(err error, duration time.Duration) callOutcome {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return outcomeIgnored
+	case err != nil && !isExpectedDatastoreError(err):
+		return outcomeFailure
+	case c.config.SlowCallThreshold > 0 && duration > c.config.SlowCallThreshold:
+		return outcomeFailure
+	default:
+		return outcomeSuccess
+	}
+}
+
+func (c *circuit) record(probe bool, outcome callOutcome) {
+	c.Lock()
+	defer c.Unlock()
+
+	now := c.timeSource.Now()
+
+	if probe {
+		c.probing = false
+		switch outcome {
+		case outcomeSuccess:
+			log.Info().Str("operation", c.operation).Msg("datastore circuit closed")
+			c.state = circuitClosed
+			c.resetWindowLocked(now)
+			circuitOpenGauge.WithLabelValues(c.operation).Set(0)
+		case outcomeFailure:
+			c.openLocked(now)
+		}
+		return
+	}
+
+	// Calls admitted before the circuit opened do not affect it.
+	if c.state != circuitClosed || outcome == outcomeIgnored {
+		return
+	}
+
+	if now.Sub(c.windowStart) >= c.config.Window {
+		c.resetWindowLocked(now)
+	}
+
+	c.requests++
+	if outcome == outcomeFailure {
+		c.failures++
+	}
+
+	if c.requests >= c.config.MinimumRequests && float64(c.failures)/float64(c.requests) >= c.config.FailureRatio {
+		log.Warn().Str("operation", c.operation).Uint32("failures", c.failures).Uint32("requests", c.requests).Msg("datastore circuit opened")
+		c.openLocked(now)
+	}
+}
+
+func (c *circuit) openLocked(now time.Time) {
+	c.state = circuitOpen
+	c.openedAt = now
+	circuitOpenGauge.WithLabelValues(c.operation).Set(1)
+}
+
+func (c *circuit) resetWindowLocked(now time.Time) {
+	c.windowStart = now
+	c.requests = 0
+	c.failures = 0
+}
+
+// isExpectedDatastoreError returns whether the error is part of the normal operation of the
+// datastore, rather than a sign that it is unhealthy.
+func isExpectedDatastoreError(err error) bool {
+	return errors.As(err, &datastore.ErrNamespaceNotFound{}) ||
+		errors.As(err, &datastore.ErrCaveatNameNotFound{}) ||
+		errors.As(err, &datastore.ErrInvalidRevision{}) ||
+		errors.As(err, &datastore.ErrReadOnly{}) ||
+		errors.As(err, &datastore.ErrRelationshipLimitExceeded{}) ||
+		errors.As(err, &datastore.ErrWatchDisabled{})
+}
+
+func (p *circuitBreakerProxy) OptimizedRevision(ctx context.Context) (rev datastore.Revision, err error) {
+	err = p.circuits[opOptimizedRevision].call(ctx, func() error {
+		rev, err = p.Datastore.OptimizedRevision(ctx)
+		return err
+	})
+	return
+}
+
+func (p *circuitBreakerProxy) HeadRevision(ctx context.Context) (rev datastore.Revision, err error) {
+	err = p.circuits[opHeadRevision].call(ctx, func() error {
+		rev, err = p.Datastore.HeadRevision(ctx)
+		return err
+	})
+	return
+}
+
+func (p *circuitBreakerProxy) CheckRevision(ctx context.Context, revision datastore.Revision) error {
+	return p.circuits[opCheckRevision].call(ctx, func() error {
+		return p.Datastore.CheckRevision(ctx, revision)
+	})
+}
+
+// ReadWriteTx does not count errors returned by the user function as failures of the datastore.
+func (p *circuitBreakerProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (rev datastore.Revision, err error) {
+	var txErr, userErr error
+	err = p.circuits[opReadWriteTx].call(ctx, func() error {
+		rev, txErr = p.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			userErr = f(rwt)
+			return userErr
+		}, opts...)
+		if userErr != nil {
+			return nil
+		}
+		return txErr
+	})
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+	return rev, txErr
+}
+
+func (p *circuitBreakerProxy) BulkLoad(ctx context.Context, source datastore.BulkWriteRelationshipSource) (rev datastore.Revision, err error) {
+	err = p.circuits[opBulkLoad].call(ctx, func() error {
+		rev, err = p.Datastore.BulkLoad(ctx, source)
+		return err
+	})
+	return
+}
+
+func (p *circuitBreakerProxy) Statistics(ctx context.Context) (stats datastore.Stats, err error) {
+	err = p.circuits[opStatistics].call(ctx, func() error {
+		stats, err = p.Datastore.Statistics(ctx)
+		return err
+	})
+	return
+}
+
+func (p *circuitBreakerProxy) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return &circuitBreakerReader{p.Datastore.SnapshotReader(rev, opts...), p}
+}
+
+type circuitBreakerReader struct {
+	delegate datastore.Reader
+	p        *circuitBreakerProxy
+}
+
+func (r *circuitBreakerReader) ReadCaveatByName(ctx context.Context, name string) (caveat *core.CaveatDefinition, rev datastore.Revision, err error) {
+	err = r.p.circuits[opReadCaveatByName].call(ctx, func() error {
+		caveat, rev, err = r.delegate.ReadCaveatByName(ctx, name)
+		return err
+	})
+	return
+}
+
+func (r *circuitBreakerReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) (caveats []*core.CaveatDefinition, err error) {
+	err = r.p.circuits[opListCaveats].call(ctx, func() error {
+		caveats, err = r.delegate.ListCaveats(ctx, opts...)
+		return err
+	})
+	return
+}
+
+func (r *circuitBreakerReader) LookupCaveats(ctx context.Context, caveatNames []string) (caveats []*core.CaveatDefinition, err error) {
+	err = r.p.circuits[opLookupCaveats].call(ctx, func() error {
+		caveats, err = r.delegate.LookupCaveats(ctx, caveatNames)
+		return err
+	})
+	return
+}
+
+func (r *circuitBreakerReader) QueryRelationships(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	err = r.p.circuits[opQueryRelationships].call(ctx, func() error {
+		iter, err = r.delegate.QueryRelationships(ctx, filter, opts...)
+		return err
+	})
+	return
+}
+
+func (r *circuitBreakerReader) ReverseQueryRelationships(
+	ctx context.Context,
+	subjectsFilter datastore.SubjectsFilter,
+	opts ...options.ReverseQueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	err = r.p.circuits[opReverseQueryRelationships].call(ctx, func() error {
+		iter, err = r.delegate.ReverseQueryRelationships(ctx, subjectsFilter, opts...)
+		return err
+	})
+	return
+}
+
+func (r *circuitBreakerReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (count uint64, err error) {
+	err = r.p.circuits[opCountRelationships].call(ctx, func() error {
+		count, err = r.delegate.CountRelationships(ctx, filter)
+		return err
+	})
+	return
+}
+
+func (r *circuitBreakerReader) ListResourceTypes(ctx context.Context) (resourceTypes []string, err error) {
+	err = r.p.circuits[opListResourceTypes].call(ctx, func() error {
+		resourceTypes, err = r.delegate.ListResourceTypes(ctx)
+		return err
+	})
+	return
+}
+
+func (r *circuitBreakerReader) ReadNamespace(ctx context.Context, nsName string) (ns *core.NamespaceDefinition, rev datastore.Revision, err error) {
+	err = r.p.circuits[opReadNamespace].call(ctx, func() error {
+		ns, rev, err = r.delegate.ReadNamespace(ctx, nsName)
+		return err
+	})
+	return
+}
+
+func (r *circuitBreakerReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) (nsDefs []*core.NamespaceDefinition, err error) {
+	err = r.p.circuits[opListNamespaces].call(ctx, func() error {
+		nsDefs, err = r.delegate.ListNamespaces(ctx, opts...)
+		return err
+	})
+	return
+}
+
+func (r *circuitBreakerReader) LookupNamespaces(ctx context.Context, nsNames []string) (nsDefs []*core.NamespaceDefinition, err error) {
+	err = r.p.circuits[opLookupNamespaces].call(ctx, func() error {
+		nsDefs, err = r.delegate.LookupNamespaces(ctx, nsNames)
+		return err
+	})
+	return
+}
+
+var (
+	_ datastore.Datastore = (*circuitBreakerProxy)(nil)
+	_ datastore.Reader    = (*circuitBreakerReader)(nil)
+)
@@ -0,0 +1,148 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/proxy/proxy_test"
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+var errBackendDown = errors.New("connection refused")
+
+var testCircuitConfig = CircuitBreakerConfig{
+	FailureRatio:    0.5,
+	MinimumRequests: 4,
+	Window:          10 * time.Second,
+	OpenDuration:    5 * time.Second,
+}
+
+func newTestCircuitBreaker(t *testing.T, delegate datastore.Datastore) (datastore.Datastore, *clock.Mock) {
+	mockTime := clock.NewMock()
+	ds, err := newCircuitBreakerProxyWithTimeSource(delegate, testCircuitConfig, mockTime)
+	require.NoError(t, err)
+	return ds, mockTime
+}
+
+func TestCircuitOpensAndRecovers(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	delegate := &proxy_test.MockDatastore{}
+	ds, mockTime := newTestCircuitBreaker(t, delegate)
+
+	delegate.On("HeadRevision").Return(datastore.NoRevision, errBackendDown).Times(4)
+	for i := 0; i < 4; i++ {
+		_, err := ds.HeadRevision(ctx)
+		require.ErrorIs(err, errBackendDown)
+	}
+
+	// The circuit is now open and calls fail fast without reaching the delegate.
+	_, err := ds.HeadRevision(ctx)
+	var unavailable datastore.ErrUnavailable
+	require.ErrorAs(err, &unavailable)
+	require.Equal(testCircuitConfig.OpenDuration, unavailable.RetryAfter())
+	delegate.AssertExpectations(t)
+
+	// Other operations have their own circuits.
+	delegate.On("OptimizedRevision").Return(one, nil).Once()
+	_, err = ds.OptimizedRevision(ctx)
+	require.NoError(err)
+
+	// A failed probe reopens the circuit.
+	mockTime.Add(testCircuitConfig.OpenDuration)
+	delegate.On("HeadRevision").Return(datastore.NoRevision, errBackendDown).Once()
+	_, err = ds.HeadRevision(ctx)
+	require.ErrorIs(err, errBackendDown)
+
+	_, err = ds.HeadRevision(ctx)
+	require.ErrorAs(err, &unavailable)
+
+	// A successful probe closes the circuit.
+	mockTime.Add(testCircuitConfig.OpenDuration)
+	delegate.On("HeadRevision").Return(two, nil).Twice()
+	for i := 0; i < 2; i++ {
+		rev, err := ds.HeadRevision(ctx)
+		require.NoError(err)
+		require.True(two.Equal(rev))
+	}
+	delegate.AssertExpectations(t)
+}
+
+func TestCircuitIgnoresExpectedErrors(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	delegate := &proxy_test.MockDatastore{}
+	reader := &proxy_test.MockReader{}
+	ds, _ := newTestCircuitBreaker(t, delegate)
+
+	delegate.On("SnapshotReader", one).Return(reader)
+	reader.On("ReadNamespace", nsA).Return(nil, datastore.NoRevision, datastore.NewNamespaceNotFoundErr(nsA)).Times(5)
+
+	for i := 0; i < 5; i++ {
+		_, _, err := ds.SnapshotReader(one).ReadNamespace(ctx, nsA)
+		require.ErrorAs(err, &datastore.ErrNamespaceNotFound{})
+	}
+	reader.AssertExpectations(t)
+}
+
+func TestCircuitIgnoresUserFunctionErrors(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	delegate := &proxy_test.MockDatastore{}
+	rwt := &proxy_test.MockReadWriteTransaction{}
+	ds, _ := newTestCircuitBreaker(t, delegate)
+
+	errUser := errors.New("precondition failed")
+	delegate.On("ReadWriteTx").Return(rwt, one, nil).Times(5)
+
+	for i := 0; i < 5; i++ {
+		_, err := ds.ReadWriteTx(ctx, func(datastore.ReadWriteTransaction) error {
+			return errUser
+		})
+		require.ErrorIs(err, errUser)
+	}
+	delegate.AssertExpectations(t)
+}
+
+func TestCircuitWindowResets(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	delegate := &proxy_test.MockDatastore{}
+	ds, mockTime := newTestCircuitBreaker(t, delegate)
+
+	delegate.On("HeadRevision").Return(datastore.NoRevision, errBackendDown).Times(6)
+	for i := 0; i < 3; i++ {
+		_, err := ds.HeadRevision(ctx)
+		require.ErrorIs(err, errBackendDown)
+	}
+
+	mockTime.Add(testCircuitConfig.Window)
+
+	// Failures from the previous window are forgotten, so the circuit stays closed.
+	for i := 0; i < 3; i++ {
+		_, err := ds.HeadRevision(ctx)
+		require.ErrorIs(err, errBackendDown)
+	}
+	delegate.AssertExpectations(t)
+}
+
+func TestCircuitBreakerConfigValidation(t *testing.T) {
+	for _, config := range []CircuitBreakerConfig{
+		{FailureRatio: 0, Window: time.Second, OpenDuration: time.Second},
+		{FailureRatio: 1.5, Window: time.Second, OpenDuration: time.Second},
+		{FailureRatio: 0.5, OpenDuration: time.Second},
+		{FailureRatio: 0.5, Window: time.Second},
+	} {
+		_, err := NewCircuitBreakerProxy(&proxy_test.MockDatastore{}, config)
+		require.Error(t, err)
+	}
+}
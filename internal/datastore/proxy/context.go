@@ -38,8 +38,14 @@ func NewSeparatingContextDatastoreProxy(d datastore.Datastore) datastore.Datasto
 
 type ctxProxy struct{ delegate datastore.Datastore }
 
-func (p *ctxProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc) (datastore.Revision, error) {
-	return p.delegate.ReadWriteTx(ctx, f)
+func (p *ctxProxy) Unwrap() datastore.Datastore { return p.delegate }
+
+func (p *ctxProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	return p.delegate.ReadWriteTx(ctx, f, opts...)
+}
+
+func (p *ctxProxy) BulkLoad(ctx context.Context, source datastore.BulkWriteRelationshipSource) (datastore.Revision, error) {
+	return p.delegate.BulkLoad(ctx, source)
 }
 
 func (p *ctxProxy) OptimizedRevision(ctx context.Context) (datastore.Revision, error) {
@@ -76,8 +82,8 @@ func (p *ctxProxy) IsReady(ctx context.Context) (bool, error) {
 
 func (p *ctxProxy) Close() error { return p.delegate.Close() }
 
-func (p *ctxProxy) SnapshotReader(rev datastore.Revision) datastore.Reader {
-	delegateReader := p.delegate.SnapshotReader(rev)
+func (p *ctxProxy) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	delegateReader := p.delegate.SnapshotReader(rev, opts...)
 	return &ctxReader{delegateReader}
 }
 
@@ -87,12 +93,16 @@ func (r *ctxReader) ReadCaveatByName(ctx context.Context, name string) (*core.Ca
 	return r.delegate.ReadCaveatByName(SeparateContextWithTracing(ctx), name)
 }
 
-func (r *ctxReader) ListCaveats(ctx context.Context, caveatNamesForFiltering ...string) ([]*core.CaveatDefinition, error) {
-	return r.delegate.ListCaveats(SeparateContextWithTracing(ctx), caveatNamesForFiltering...)
+func (r *ctxReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	return r.delegate.ListCaveats(SeparateContextWithTracing(ctx), opts...)
+}
+
+func (r *ctxReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	return r.delegate.LookupCaveats(SeparateContextWithTracing(ctx), caveatNames)
 }
 
-func (r *ctxReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
-	return r.delegate.ListNamespaces(SeparateContextWithTracing(ctx))
+func (r *ctxReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	return r.delegate.ListNamespaces(SeparateContextWithTracing(ctx), opts...)
 }
 
 func (r *ctxReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
@@ -111,6 +121,14 @@ func (r *ctxReader) ReverseQueryRelationships(ctx context.Context, subjectFilter
 	return r.delegate.ReverseQueryRelationships(SeparateContextWithTracing(ctx), subjectFilter, options...)
 }
 
+func (r *ctxReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	return r.delegate.CountRelationships(SeparateContextWithTracing(ctx), filter)
+}
+
+func (r *ctxReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	return r.delegate.ListResourceTypes(SeparateContextWithTracing(ctx))
+}
+
 var (
 	_ datastore.Datastore = (*ctxProxy)(nil)
 	_ datastore.Reader    = (*ctxReader)(nil)
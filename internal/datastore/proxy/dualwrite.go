@@ -0,0 +1,294 @@
+package proxy
+
+import (
+	"context"
+	"sync/atomic"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+var dualWriteDivergenceCount = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "spicedb",
+	Subsystem: "datastore",
+	Name:      "dual_write_divergences_total",
+	Help:      "total number of writes committed to the authoritative datastore of the dual-write proxy which could not be applied to the secondary datastore, by kind of write",
+}, []string{"kind"})
+
+// DualWriteDatastore is a datastore which writes to both an old and a new datastore, reading only
+// from the authoritative one of the two.
+type DualWriteDatastore interface {
+	datastore.Datastore
+
+	// Cutover makes the new datastore authoritative, so that reads, revisions and watches are
+	// served by it, while writes continue to be applied to the old datastore as well.
+	Cutover()
+
+	// CutoverComplete returns whether the new datastore has been made authoritative.
+	CutoverComplete() bool
+
+	// Divergences returns the number of writes which were committed to the authoritative datastore
+	// but could not be applied to the other.
+	Divergences() uint64
+}
+
+// NewDualWriteProxy creates a proxy which allows for migrating from the old datastore to the new
+// one without downtime. Every write is committed to the authoritative datastore, which is the old
+// one until Cutover is called, and is then replayed into a transaction of the other. A write which
+// fails in the authoritative datastore fails; one which fails only in the other is logged and
+// counted as a divergence.
+//
+// Revisions are those of the authoritative datastore, so revisions issued before the cutover
+// cannot be used after it. Concurrent transactions which write the same relationships may be
+// replayed in a different order than they were committed, so the datastores should be compared
+// before the cutover.
+func NewDualWriteProxy(oldDS, newDS datastore.Datastore) DualWriteDatastore {
+	return &dualWriteProxy{oldDS: oldDS, newDS: newDS}
+}
+
+type dualWriteProxy struct {
+	oldDS datastore.Datastore
+	newDS datastore.Datastore
+
+	cutover     atomic.Bool
+	divergences atomic.Uint64
+}
+
+func (p *dualWriteProxy) Unwrap() datastore.Datastore { return p.authoritative() }
+
+func (p *dualWriteProxy) Cutover() {
+	if !p.cutover.Swap(true) {
+		log.Info().Msg("dual-write proxy cut over to the new datastore")
+	}
+}
+
+func (p *dualWriteProxy) CutoverComplete() bool { return p.cutover.Load() }
+
+func (p *dualWriteProxy) Divergences() uint64 { return p.divergences.Load() }
+
+func (p *dualWriteProxy) authoritative() datastore.Datastore {
+	if p.cutover.Load() {
+		return p.newDS
+	}
+	return p.oldDS
+}
+
+func (p *dualWriteProxy) secondary() datastore.Datastore {
+	if p.cutover.Load() {
+		return p.oldDS
+	}
+	return p.newDS
+}
+
+func (p *dualWriteProxy) diverged(ctx context.Context, kind string, err error) {
+	p.divergences.Add(1)
+	dualWriteDivergenceCount.WithLabelValues(kind).Inc()
+	log.Ctx(ctx).Warn().Err(err).Str("kind", kind).Msg("write committed to the authoritative datastore could not be applied to the secondary datastore")
+}
+
+func (p *dualWriteProxy) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return p.authoritative().SnapshotReader(rev, opts...)
+}
+
+func (p *dualWriteProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	// The cutover may happen during the transaction, so both datastores are chosen up front.
+	authoritative, secondary := p.authoritative(), p.secondary()
+
+	// NOTE: the transaction function may be retried by the underlying datastore, so the
+	// recorded writes are reset on each invocation.
+	var recording *replayableRWT
+	rev, err := authoritative.ReadWriteTx(ctx, func(delegateRWT datastore.ReadWriteTransaction) error {
+		recording = &replayableRWT{ReadWriteTransaction: delegateRWT}
+		return f(recording)
+	}, opts...)
+	if err != nil {
+		return rev, err
+	}
+
+	if recording == nil || len(recording.writes) == 0 {
+		return rev, nil
+	}
+
+	if _, err := secondary.ReadWriteTx(ctx, func(target datastore.ReadWriteTransaction) error {
+		return recording.replay(ctx, target)
+	}, opts...); err != nil {
+		p.diverged(ctx, "transaction", err)
+	}
+	return rev, nil
+}
+
+func (p *dualWriteProxy) BulkLoad(ctx context.Context, source datastore.BulkWriteRelationshipSource) (datastore.Revision, error) {
+	authoritative, secondary := p.authoritative(), p.secondary()
+
+	recording := &recordingBulkLoadSource{BulkWriteRelationshipSource: source}
+	rev, err := authoritative.BulkLoad(ctx, recording)
+	if err != nil {
+		// Some of the relationships may have been committed to the authoritative datastore, but
+		// which of them is unknown, so none are loaded into the secondary.
+		if len(recording.changes) > 0 {
+			p.diverged(ctx, "bulk_load", err)
+		}
+		return rev, err
+	}
+
+	if _, err := secondary.BulkLoad(ctx, &replayedBulkLoadSource{changes: recording.changes}); err != nil {
+		p.diverged(ctx, "bulk_load", err)
+	}
+	return rev, nil
+}
+
+func (p *dualWriteProxy) OptimizedRevision(ctx context.Context) (datastore.Revision, error) {
+	return p.authoritative().OptimizedRevision(ctx)
+}
+
+func (p *dualWriteProxy) HeadRevision(ctx context.Context) (datastore.Revision, error) {
+	return p.authoritative().HeadRevision(ctx)
+}
+
+func (p *dualWriteProxy) CheckRevision(ctx context.Context, revision datastore.Revision) error {
+	return p.authoritative().CheckRevision(ctx, revision)
+}
+
+func (p *dualWriteProxy) RevisionFromString(serialized string) (datastore.Revision, error) {
+	return p.authoritative().RevisionFromString(serialized)
+}
+
+func (p *dualWriteProxy) Watch(ctx context.Context, afterRevision datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
+	return p.authoritative().Watch(ctx, afterRevision)
+}
+
+// IsReady returns whether both datastores are ready, as writes are applied to both.
+func (p *dualWriteProxy) IsReady(ctx context.Context) (bool, error) {
+	for _, ds := range []datastore.Datastore{p.oldDS, p.newDS} {
+		ready, err := ds.IsReady(ctx)
+		if err != nil || !ready {
+			return ready, err
+		}
+	}
+	return true, nil
+}
+
+func (p *dualWriteProxy) Features(ctx context.Context) (*datastore.Features, error) {
+	return p.authoritative().Features(ctx)
+}
+
+func (p *dualWriteProxy) Statistics(ctx context.Context) (datastore.Stats, error) {
+	return p.authoritative().Statistics(ctx)
+}
+
+func (p *dualWriteProxy) Close() error {
+	closeErr := p.oldDS.Close()
+	if err := p.newDS.Close(); err != nil && closeErr == nil {
+		closeErr = err
+	}
+	return closeErr
+}
+
+// replayableRWT records each write which succeeds in the delegate transaction, so that the
+// writes can be applied, in the same order, to a transaction of another datastore.
+type replayableRWT struct {
+	datastore.ReadWriteTransaction
+
+	writes []func(ctx context.Context, rwt datastore.ReadWriteTransaction) error
+}
+
+func (rwt *replayableRWT) replay(ctx context.Context, target datastore.ReadWriteTransaction) error {
+	for _, write := range rwt.writes {
+		if err := write(ctx, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rwt *replayableRWT) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	if err := rwt.ReadWriteTransaction.WriteRelationships(ctx, mutations); err != nil {
+		return err
+	}
+
+	rwt.writes = append(rwt.writes, func(ctx context.Context, target datastore.ReadWriteTransaction) error {
+		return target.WriteRelationships(ctx, mutations)
+	})
+	return nil
+}
+
+func (rwt *replayableRWT) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
+	if err := rwt.ReadWriteTransaction.DeleteRelationships(ctx, filter); err != nil {
+		return err
+	}
+
+	rwt.writes = append(rwt.writes, func(ctx context.Context, target datastore.ReadWriteTransaction) error {
+		return target.DeleteRelationships(ctx, filter)
+	})
+	return nil
+}
+
+func (rwt *replayableRWT) WriteNamespaces(ctx context.Context, newConfigs ...*core.NamespaceDefinition) error {
+	if err := rwt.ReadWriteTransaction.WriteNamespaces(ctx, newConfigs...); err != nil {
+		return err
+	}
+
+	rwt.writes = append(rwt.writes, func(ctx context.Context, target datastore.ReadWriteTransaction) error {
+		return target.WriteNamespaces(ctx, newConfigs...)
+	})
+	return nil
+}
+
+func (rwt *replayableRWT) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+	if err := rwt.ReadWriteTransaction.DeleteNamespaces(ctx, nsNames...); err != nil {
+		return err
+	}
+
+	rwt.writes = append(rwt.writes, func(ctx context.Context, target datastore.ReadWriteTransaction) error {
+		return target.DeleteNamespaces(ctx, nsNames...)
+	})
+	return nil
+}
+
+func (rwt *replayableRWT) WriteCaveats(ctx context.Context, caveats []*core.CaveatDefinition) error {
+	if err := rwt.ReadWriteTransaction.WriteCaveats(ctx, caveats); err != nil {
+		return err
+	}
+
+	rwt.writes = append(rwt.writes, func(ctx context.Context, target datastore.ReadWriteTransaction) error {
+		return target.WriteCaveats(ctx, caveats)
+	})
+	return nil
+}
+
+func (rwt *replayableRWT) DeleteCaveats(ctx context.Context, names []string) error {
+	if err := rwt.ReadWriteTransaction.DeleteCaveats(ctx, names); err != nil {
+		return err
+	}
+
+	rwt.writes = append(rwt.writes, func(ctx context.Context, target datastore.ReadWriteTransaction) error {
+		return target.DeleteCaveats(ctx, names)
+	})
+	return nil
+}
+
+// replayedBulkLoadSource produces the relationships recorded from another bulk load.
+type replayedBulkLoadSource struct {
+	changes []*core.RelationTupleUpdate
+}
+
+func (s *replayedBulkLoadSource) Next(_ context.Context) (*core.RelationTuple, error) {
+	if len(s.changes) == 0 {
+		return nil, nil
+	}
+
+	tpl := s.changes[0].Tuple
+	s.changes = s.changes[1:]
+	return tpl, nil
+}
+
+var (
+	_ DualWriteDatastore             = (*dualWriteProxy)(nil)
+	_ datastore.ReadWriteTransaction = (*replayableRWT)(nil)
+)
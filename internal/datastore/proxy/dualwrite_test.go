@@ -0,0 +1,129 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func newDualWriteTestDatastore(t *testing.T) datastore.Datastore {
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	ds, _ := testfixtures.StandardDatastoreWithSchema(rawDS, require.New(t))
+	return ds
+}
+
+// documentViewers returns the relationships of the viewers of documents at the head revision.
+func documentViewers(t *testing.T, ds datastore.Datastore) []string {
+	ctx := context.Background()
+	rev, err := ds.HeadRevision(ctx)
+	require.NoError(t, err)
+
+	iter, err := ds.SnapshotReader(rev).QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:             "document",
+		OptionalResourceRelation: "viewer",
+	})
+	require.NoError(t, err)
+	defer iter.Close()
+
+	var found []string
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		found = append(found, tuple.String(tpl))
+	}
+	require.NoError(t, iter.Err())
+	return found
+}
+
+func TestDualWriteProxy(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	oldDS, newDS := newDualWriteTestDatastore(t), newDualWriteTestDatastore(t)
+	ds := NewDualWriteProxy(oldDS, newDS)
+	require.False(ds.CutoverComplete())
+
+	first := tuple.MustParse("document:firstdoc#viewer@user:tom")
+	second := tuple.MustParse("document:seconddoc#viewer@user:tom")
+
+	// Writes are applied to both datastores.
+	_, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, first, second)
+	require.NoError(err)
+	require.ElementsMatch(documentViewers(t, oldDS), documentViewers(t, newDS))
+	require.Len(documentViewers(t, newDS), 2)
+
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.DeleteRelationships(ctx, &v1.RelationshipFilter{
+			ResourceType:       "document",
+			OptionalResourceId: "firstdoc",
+		})
+	})
+	require.NoError(err)
+	require.Equal([]string{tuple.String(second)}, documentViewers(t, oldDS))
+	require.Equal([]string{tuple.String(second)}, documentViewers(t, newDS))
+	require.Zero(ds.Divergences())
+
+	// A write which fails only in the secondary datastore succeeds, and is counted as a divergence.
+	_, err = common.WriteTuples(ctx, newDS, core.RelationTupleUpdate_CREATE, first)
+	require.NoError(err)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, first)
+	require.NoError(err)
+	require.Equal(uint64(1), ds.Divergences())
+
+	// A write which fails in the authoritative datastore fails, and is not applied to the other.
+	third := tuple.MustParse("document:thirddoc#viewer@user:tom")
+	_, err = common.WriteTuples(ctx, oldDS, core.RelationTupleUpdate_CREATE, third)
+	require.NoError(err)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, third)
+	require.Error(err)
+	require.Len(documentViewers(t, newDS), 2)
+	require.Equal(uint64(1), ds.Divergences())
+
+	// After the cutover, the new datastore is read, and writes are still applied to the old one.
+	ds.Cutover()
+	require.True(ds.CutoverComplete())
+
+	rev, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+	newRev, err := newDS.HeadRevision(ctx)
+	require.NoError(err)
+	require.True(rev.Equal(newRev))
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH, third)
+	require.NoError(err)
+	require.Len(documentViewers(t, newDS), 3)
+	require.Len(documentViewers(t, oldDS), 3)
+	require.Equal(uint64(1), ds.Divergences())
+
+	require.NoError(ds.Close())
+}
+
+func TestDualWriteProxyBulkLoad(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	oldDS, newDS := newDualWriteTestDatastore(t), newDualWriteTestDatastore(t)
+	ds := NewDualWriteProxy(oldDS, newDS)
+
+	source := &replayedBulkLoadSource{changes: []*core.RelationTupleUpdate{
+		tuple.Create(tuple.MustParse("document:firstdoc#viewer@user:tom")),
+		tuple.Create(tuple.MustParse("document:seconddoc#viewer@user:tom")),
+	}}
+	_, err := ds.BulkLoad(ctx, source)
+	require.NoError(err)
+
+	require.Len(documentViewers(t, oldDS), 2)
+	require.ElementsMatch(documentViewers(t, oldDS), documentViewers(t, newDS))
+	require.Zero(ds.Divergences())
+}
@@ -0,0 +1,364 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+var injectedFaultCount = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "spicedb",
+	Subsystem: "datastore",
+	Name:      "injected_faults_total",
+	Help:      "total number of faults injected into datastore operations",
+}, []string{"operation", "fault"})
+
+const (
+	faultLatency              = "latency"
+	faultTransientError       = "transient_error"
+	faultSerializationFailure = "serialization_failure"
+	faultRevisionSkew         = "revision_skew"
+
+	// skewedRevisionHistory is the number of previously observed revisions from which a skewed
+	// revision is chosen.
+	skewedRevisionHistory = 10
+)
+
+// FaultInjectionConfig configures the faults injected by the fault injection proxy. Each ratio is
+// the fraction of calls, in the range [0.0-1.0], into which the fault is injected.
+type FaultInjectionConfig struct {
+	// LatencyRatio is the ratio of calls which are delayed by Latency before reaching the
+	// delegate.
+	LatencyRatio float64
+
+	// Latency is the delay added to calls selected by LatencyRatio.
+	Latency time.Duration
+
+	// TransientErrorRatio is the ratio of calls which fail with a retryable ErrUnavailable
+	// without reaching the delegate.
+	TransientErrorRatio float64
+
+	// SerializationFailureRatio is the ratio of read-write transactions which are rolled back
+	// with a retryable error after the transaction function has run.
+	SerializationFailureRatio float64
+
+	// RevisionSkewRatio is the ratio of OptimizedRevision calls which return an older,
+	// previously observed revision in place of the current one.
+	RevisionSkewRatio float64
+
+	// Seed seeds the choice of calls into which faults are injected. If zero, a seed is
+	// chosen from the current time.
+	Seed int64
+}
+
+// Enabled returns whether any faults are configured to be injected.
+func (c FaultInjectionConfig) Enabled() bool {
+	return c.LatencyRatio > 0 ||
+		c.TransientErrorRatio > 0 ||
+		c.SerializationFailureRatio > 0 ||
+		c.RevisionSkewRatio > 0
+}
+
+// Validate returns an error if the configuration is invalid.
+func (c FaultInjectionConfig) Validate() error {
+	for name, ratio := range map[string]float64{
+		"latency":               c.LatencyRatio,
+		"transient error":       c.TransientErrorRatio,
+		"serialization failure": c.SerializationFailureRatio,
+		"revision skew":         c.RevisionSkewRatio,
+	} {
+		if ratio < 0 || ratio > 1 {
+			return fmt.Errorf("fault injection %s ratio must be in the range [0.0-1.0]", name)
+		}
+	}
+
+	if c.LatencyRatio > 0 && c.Latency <= 0 {
+		return errors.New("fault injection requires a positive latency when latency ratio is set")
+	}
+
+	return nil
+}
+
+// NewFaultInjectionProxy creates a proxy which injects latency, transient errors, serialization
+// failures and revision skew into a fraction of datastore operations. It is intended to validate
+// the retry and consistency behavior of callers and must not be used in production.
+func NewFaultInjectionProxy(delegate datastore.Datastore, config FaultInjectionConfig) (datastore.Datastore, error) {
+	return newFaultInjectionProxyWithTimeSource(delegate, config, clock.New())
+}
+
+func newFaultInjectionProxyWithTimeSource(
+	delegate datastore.Datastore,
+	config FaultInjectionConfig,
+	timeSource clock.Clock,
+) (datastore.Datastore, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	return &faultInjectionProxy{
+		Datastore:  delegate,
+		config:     config,
+		timeSource: timeSource,
+		rand:       rand.New(rand.NewSource(seed)),
+	}, nil
+}
+
+type faultInjectionProxy struct {
+	datastore.Datastore
+	config     FaultInjectionConfig
+	timeSource clock.Clock
+
+	sync.Mutex
+	rand      *rand.Rand
+	revisions []datastore.Revision
+}
+
+func (p *faultInjectionProxy) Unwrap() datastore.Datastore { return p.Datastore }
+
+func (p *faultInjectionProxy) roll(ratio float64) bool {
+	if ratio <= 0 {
+		return false
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	return p.rand.Float64() < ratio
+}
+
+// inject applies the latency and transient error faults to a call of the operation.
+func (p *faultInjectionProxy) inject(ctx context.Context, operation string) error {
+	if p.roll(p.config.LatencyRatio) {
+		injectedFaultCount.WithLabelValues(operation, faultLatency).Inc()
+
+		select {
+		case <-p.timeSource.After(p.config.Latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if p.roll(p.config.TransientErrorRatio) {
+		injectedFaultCount.WithLabelValues(operation, faultTransientError).Inc()
+		return datastore.NewUnavailableErr(fmt.Sprintf("injected transient error in %s", operation), 0)
+	}
+
+	return nil
+}
+
+// observe records a revision returned by the delegate, so that it can later be returned as a
+// skewed revision.
+func (p *faultInjectionProxy) observe(rev datastore.Revision) {
+	p.Lock()
+	defer p.Unlock()
+
+	if len(p.revisions) > 0 && p.revisions[len(p.revisions)-1].Equal(rev) {
+		return
+	}
+
+	p.revisions = append(p.revisions, rev)
+	if len(p.revisions) > skewedRevisionHistory {
+		p.revisions = p.revisions[1:]
+	}
+}
+
+func (p *faultInjectionProxy) skewed(rev datastore.Revision) datastore.Revision {
+	p.Lock()
+	defer p.Unlock()
+
+	var older []datastore.Revision
+	for _, observed := range p.revisions {
+		if observed.LessThan(rev) {
+			older = append(older, observed)
+		}
+	}
+	if len(older) == 0 {
+		return rev
+	}
+
+	injectedFaultCount.WithLabelValues(opOptimizedRevision, faultRevisionSkew).Inc()
+	return older[p.rand.Intn(len(older))]
+}
+
+func (p *faultInjectionProxy) OptimizedRevision(ctx context.Context) (datastore.Revision, error) {
+	if err := p.inject(ctx, opOptimizedRevision); err != nil {
+		return datastore.NoRevision, err
+	}
+
+	rev, err := p.Datastore.OptimizedRevision(ctx)
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+
+	skew := p.roll(p.config.RevisionSkewRatio)
+	p.observe(rev)
+	if skew {
+		return p.skewed(rev), nil
+	}
+	return rev, nil
+}
+
+func (p *faultInjectionProxy) HeadRevision(ctx context.Context) (datastore.Revision, error) {
+	if err := p.inject(ctx, opHeadRevision); err != nil {
+		return datastore.NoRevision, err
+	}
+
+	rev, err := p.Datastore.HeadRevision(ctx)
+	if err == nil {
+		p.observe(rev)
+	}
+	return rev, err
+}
+
+func (p *faultInjectionProxy) CheckRevision(ctx context.Context, revision datastore.Revision) error {
+	if err := p.inject(ctx, opCheckRevision); err != nil {
+		return err
+	}
+	return p.Datastore.CheckRevision(ctx, revision)
+}
+
+// ReadWriteTx fails transactions selected for a serialization failure after the transaction
+// function has run, causing the delegate to roll the transaction back.
+func (p *faultInjectionProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	if err := p.inject(ctx, opReadWriteTx); err != nil {
+		return datastore.NoRevision, err
+	}
+
+	serializationFailure := p.roll(p.config.SerializationFailureRatio)
+	rev, err := p.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		if err := f(rwt); err != nil {
+			return err
+		}
+
+		if serializationFailure {
+			injectedFaultCount.WithLabelValues(opReadWriteTx, faultSerializationFailure).Inc()
+			return datastore.NewUnavailableErr("injected serialization failure", 0)
+		}
+		return nil
+	}, opts...)
+	if err == nil {
+		p.observe(rev)
+	}
+	return rev, err
+}
+
+func (p *faultInjectionProxy) BulkLoad(ctx context.Context, source datastore.BulkWriteRelationshipSource) (datastore.Revision, error) {
+	if err := p.inject(ctx, opBulkLoad); err != nil {
+		return datastore.NoRevision, err
+	}
+	return p.Datastore.BulkLoad(ctx, source)
+}
+
+func (p *faultInjectionProxy) Statistics(ctx context.Context) (datastore.Stats, error) {
+	if err := p.inject(ctx, opStatistics); err != nil {
+		return datastore.Stats{}, err
+	}
+	return p.Datastore.Statistics(ctx)
+}
+
+func (p *faultInjectionProxy) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return &faultInjectionReader{p.Datastore.SnapshotReader(rev, opts...), p}
+}
+
+type faultInjectionReader struct {
+	delegate datastore.Reader
+	p        *faultInjectionProxy
+}
+
+func (r *faultInjectionReader) ReadCaveatByName(ctx context.Context, name string) (*core.CaveatDefinition, datastore.Revision, error) {
+	if err := r.p.inject(ctx, opReadCaveatByName); err != nil {
+		return nil, datastore.NoRevision, err
+	}
+	return r.delegate.ReadCaveatByName(ctx, name)
+}
+
+func (r *faultInjectionReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	if err := r.p.inject(ctx, opListCaveats); err != nil {
+		return nil, err
+	}
+	return r.delegate.ListCaveats(ctx, opts...)
+}
+
+func (r *faultInjectionReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	if err := r.p.inject(ctx, opLookupCaveats); err != nil {
+		return nil, err
+	}
+	return r.delegate.LookupCaveats(ctx, caveatNames)
+}
+
+func (r *faultInjectionReader) QueryRelationships(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	if err := r.p.inject(ctx, opQueryRelationships); err != nil {
+		return nil, err
+	}
+	return r.delegate.QueryRelationships(ctx, filter, opts...)
+}
+
+func (r *faultInjectionReader) ReverseQueryRelationships(
+	ctx context.Context,
+	subjectsFilter datastore.SubjectsFilter,
+	opts ...options.ReverseQueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	if err := r.p.inject(ctx, opReverseQueryRelationships); err != nil {
+		return nil, err
+	}
+	return r.delegate.ReverseQueryRelationships(ctx, subjectsFilter, opts...)
+}
+
+func (r *faultInjectionReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	if err := r.p.inject(ctx, opCountRelationships); err != nil {
+		return 0, err
+	}
+	return r.delegate.CountRelationships(ctx, filter)
+}
+
+func (r *faultInjectionReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	if err := r.p.inject(ctx, opListResourceTypes); err != nil {
+		return nil, err
+	}
+	return r.delegate.ListResourceTypes(ctx)
+}
+
+func (r *faultInjectionReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
+	if err := r.p.inject(ctx, opReadNamespace); err != nil {
+		return nil, datastore.NoRevision, err
+	}
+	return r.delegate.ReadNamespace(ctx, nsName)
+}
+
+func (r *faultInjectionReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	if err := r.p.inject(ctx, opListNamespaces); err != nil {
+		return nil, err
+	}
+	return r.delegate.ListNamespaces(ctx, opts...)
+}
+
+func (r *faultInjectionReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
+	if err := r.p.inject(ctx, opLookupNamespaces); err != nil {
+		return nil, err
+	}
+	return r.delegate.LookupNamespaces(ctx, nsNames)
+}
+
+var (
+	_ datastore.Datastore = (*faultInjectionProxy)(nil)
+	_ datastore.Reader    = (*faultInjectionReader)(nil)
+)
@@ -0,0 +1,122 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/proxy/proxy_test"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestFaultInjectionTransientErrors(t *testing.T) {
+	require := require.New(t)
+
+	delegate := &proxy_test.MockDatastore{}
+	delegate.On("SnapshotReader", one).Return(&proxy_test.MockReader{})
+
+	ds, err := NewFaultInjectionProxy(delegate, FaultInjectionConfig{TransientErrorRatio: 1})
+	require.NoError(err)
+
+	_, err = ds.HeadRevision(context.Background())
+	require.ErrorAs(err, &datastore.ErrUnavailable{})
+
+	_, _, err = ds.SnapshotReader(one).ReadNamespace(context.Background(), nsA)
+	require.ErrorAs(err, &datastore.ErrUnavailable{})
+
+	delegate.AssertNotCalled(t, "HeadRevision")
+}
+
+func TestFaultInjectionLatency(t *testing.T) {
+	require := require.New(t)
+
+	delegate := &proxy_test.MockDatastore{}
+	ds, err := newFaultInjectionProxyWithTimeSource(delegate, FaultInjectionConfig{
+		LatencyRatio: 1,
+		Latency:      time.Hour,
+	}, clock.NewMock())
+	require.NoError(err)
+
+	// The mock clock never advances, so the call is delayed until the context is canceled.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = ds.HeadRevision(ctx)
+	require.ErrorIs(err, context.Canceled)
+	delegate.AssertNotCalled(t, "HeadRevision")
+}
+
+func TestFaultInjectionSerializationFailures(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	rawDS, _ = testfixtures.StandardDatastoreWithSchema(rawDS, require)
+
+	ds, err := NewFaultInjectionProxy(rawDS, FaultInjectionConfig{SerializationFailureRatio: 1})
+	require.NoError(err)
+
+	called := false
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		called = true
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+			tuple.Create(tuple.MustParse("document:foo#viewer@user:tom")),
+		})
+	})
+	require.ErrorAs(err, &datastore.ErrUnavailable{})
+	require.True(called)
+
+	// The transaction was rolled back.
+	head, err := rawDS.HeadRevision(ctx)
+	require.NoError(err)
+
+	count, err := rawDS.SnapshotReader(head).CountRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType: testfixtures.DocumentNS.Name,
+	})
+	require.NoError(err)
+	require.Zero(count)
+}
+
+func TestFaultInjectionRevisionSkew(t *testing.T) {
+	require := require.New(t)
+
+	delegate := &proxy_test.MockDatastore{}
+	ds, err := NewFaultInjectionProxy(delegate, FaultInjectionConfig{RevisionSkewRatio: 1})
+	require.NoError(err)
+
+	delegate.On("OptimizedRevision").Return(one, nil).Once()
+	delegate.On("OptimizedRevision").Return(two, nil).Once()
+
+	// With no older revision observed, the current revision is returned.
+	rev, err := ds.OptimizedRevision(context.Background())
+	require.NoError(err)
+	require.True(one.Equal(rev))
+
+	rev, err = ds.OptimizedRevision(context.Background())
+	require.NoError(err)
+	require.True(one.Equal(rev))
+
+	delegate.AssertExpectations(t)
+}
+
+func TestFaultInjectionConfigValidation(t *testing.T) {
+	require.False(t, FaultInjectionConfig{}.Enabled())
+	require.True(t, FaultInjectionConfig{RevisionSkewRatio: 0.1}.Enabled())
+
+	for _, config := range []FaultInjectionConfig{
+		{TransientErrorRatio: -0.1},
+		{SerializationFailureRatio: 1.5},
+		{LatencyRatio: 0.5},
+	} {
+		_, err := NewFaultInjectionProxy(&proxy_test.MockDatastore{}, config)
+		require.Error(t, err)
+	}
+}
@@ -126,6 +126,8 @@ type hedgingProxy struct {
 	queryTuplesHedger   hedger
 }
 
+func (hp hedgingProxy) Unwrap() datastore.Datastore { return hp.Datastore }
+
 // NewHedgingProxy creates a proxy which performs request hedging on read operations
 // according to the specified config.
 func NewHedgingProxy(
@@ -203,8 +205,8 @@ func (hp hedgingProxy) HeadRevision(ctx context.Context) (rev datastore.Revision
 	return
 }
 
-func (hp hedgingProxy) SnapshotReader(rev datastore.Revision) datastore.Reader {
-	delegate := hp.Datastore.SnapshotReader(rev)
+func (hp hedgingProxy) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	delegate := hp.Datastore.SnapshotReader(rev, opts...)
 	return &hedgingReader{delegate, hp}
 }
 
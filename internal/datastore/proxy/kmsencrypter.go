@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+var errMalformedCiphertext = errors.New("malformed caveat context ciphertext")
+
+// NewKMSCaveatContextEncrypter creates an encrypter which uses envelope encryption with an AWS KMS
+// key: a data key generated by KMS when the encrypter is created encrypts each caveat context
+// with AES-256-GCM, and the data key, encrypted by KMS, is stored with every ciphertext.
+//
+// KMS is only called again to decrypt the data keys of contexts written by other encrypters,
+// which are cached for the lifetime of the encrypter.
+func NewKMSCaveatContextEncrypter(ctx context.Context, client kmsiface.KMSAPI, keyID string) (CaveatContextEncrypter, error) {
+	dataKey, err := client.GenerateDataKeyWithContext(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(keyID),
+		KeySpec: aws.String(kms.DataKeySpecAes256),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate data key: %w", err)
+	}
+
+	if len(dataKey.CiphertextBlob) > 0xFFFF {
+		return nil, fmt.Errorf("encrypted data key of %d bytes is too large", len(dataKey.CiphertextBlob))
+	}
+
+	aead, err := newDataKeyAEAD(dataKey.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kmsEncrypter{
+		client:           client,
+		keyID:            keyID,
+		encryptedDataKey: dataKey.CiphertextBlob,
+		aead:             aead,
+		decryptedKeys:    map[string]cipher.AEAD{},
+	}, nil
+}
+
+type kmsEncrypter struct {
+	client           kmsiface.KMSAPI
+	keyID            string
+	encryptedDataKey []byte
+	aead             cipher.AEAD
+
+	sync.Mutex
+	decryptedKeys map[string]cipher.AEAD
+}
+
+// Encrypt returns the length of the encrypted data key as two big endian bytes, followed by the
+// encrypted data key, the nonce and the sealed plaintext.
+func (e *kmsEncrypter) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("unable to generate nonce: %w", err)
+	}
+
+	ciphertext := make([]byte, 2, 2+len(e.encryptedDataKey)+len(nonce)+len(plaintext)+e.aead.Overhead())
+	binary.BigEndian.PutUint16(ciphertext, uint16(len(e.encryptedDataKey)))
+	ciphertext = append(ciphertext, e.encryptedDataKey...)
+	ciphertext = append(ciphertext, nonce...)
+	return e.aead.Seal(ciphertext, nonce, plaintext, nil), nil
+}
+
+func (e *kmsEncrypter) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 2 {
+		return nil, errMalformedCiphertext
+	}
+	keyLength := int(binary.BigEndian.Uint16(ciphertext))
+	ciphertext = ciphertext[2:]
+	if len(ciphertext) < keyLength {
+		return nil, errMalformedCiphertext
+	}
+	encryptedDataKey, ciphertext := ciphertext[:keyLength], ciphertext[keyLength:]
+
+	aead, err := e.aeadForKey(ctx, encryptedDataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errMalformedCiphertext
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, nil)
+}
+
+func (e *kmsEncrypter) aeadForKey(ctx context.Context, encryptedDataKey []byte) (cipher.AEAD, error) {
+	if bytes.Equal(encryptedDataKey, e.encryptedDataKey) {
+		return e.aead, nil
+	}
+
+	e.Lock()
+	aead, ok := e.decryptedKeys[string(encryptedDataKey)]
+	e.Unlock()
+	if ok {
+		return aead, nil
+	}
+
+	decrypted, err := e.client.DecryptWithContext(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(e.keyID),
+		CiphertextBlob: encryptedDataKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt data key: %w", err)
+	}
+
+	aead, err = newDataKeyAEAD(decrypted.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Lock()
+	defer e.Unlock()
+	e.decryptedKeys[string(encryptedDataKey)] = aead
+	return aead, nil
+}
+
+func newDataKeyAEAD(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -45,15 +45,25 @@ func NewObservableDatastoreProxy(d datastore.Datastore) datastore.Datastore {
 
 type observableProxy struct{ delegate datastore.Datastore }
 
-func (p *observableProxy) SnapshotReader(rev datastore.Revision) datastore.Reader {
-	delegateReader := p.delegate.SnapshotReader(rev)
+func (p *observableProxy) Unwrap() datastore.Datastore { return p.delegate }
+
+func (p *observableProxy) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	delegateReader := p.delegate.SnapshotReader(rev, opts...)
 	return &observableReader{delegateReader}
 }
 
-func (p *observableProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc) (datastore.Revision, error) {
+func (p *observableProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
 	return p.delegate.ReadWriteTx(ctx, func(delegateRWT datastore.ReadWriteTransaction) error {
 		return f(&observableRWT{&observableReader{delegateRWT}, delegateRWT})
-	})
+	}, opts...)
+}
+
+func (p *observableProxy) BulkLoad(ctx context.Context, source datastore.BulkWriteRelationshipSource) (datastore.Revision, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "BulkLoad")
+	defer span.End()
+
+	return p.delegate.BulkLoad(ctx, source)
 }
 
 func (p *observableProxy) OptimizedRevision(ctx context.Context) (datastore.Revision, error) {
@@ -128,22 +138,30 @@ func (r *observableReader) ReadCaveatByName(ctx context.Context, name string) (*
 	return r.delegate.ReadCaveatByName(ctx, name)
 }
 
-func (r *observableReader) ListCaveats(ctx context.Context, caveatNamesForFiltering ...string) ([]*core.CaveatDefinition, error) {
+func (r *observableReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	var span trace.Span
+	ctx, span = tracer.Start(ctx, "ListCaveats")
+	defer span.End()
+
+	return r.delegate.ListCaveats(ctx, opts...)
+}
+
+func (r *observableReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
 	var span trace.Span
-	ctx, span = tracer.Start(ctx, "ListCaveats", trace.WithAttributes(
-		attribute.StringSlice("names", caveatNamesForFiltering),
+	ctx, span = tracer.Start(ctx, "LookupCaveats", trace.WithAttributes(
+		attribute.StringSlice("names", caveatNames),
 	))
 	defer span.End()
 
-	return r.delegate.ListCaveats(ctx, caveatNamesForFiltering...)
+	return r.delegate.LookupCaveats(ctx, caveatNames)
 }
 
-func (r *observableReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+func (r *observableReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
 	var span trace.Span
 	ctx, span = tracer.Start(ctx, "ListNamespaces")
 	defer span.End()
 
-	return r.delegate.ListNamespaces(ctx)
+	return r.delegate.ListNamespaces(ctx, opts...)
 }
 
 func (r *observableReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
@@ -196,6 +214,22 @@ func (r *observableReader) ReverseQueryRelationships(ctx context.Context, subjec
 	return observableRelationshipIterator{span, iterator}, nil
 }
 
+func (r *observableReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	ctx, span := tracer.Start(ctx, "CountRelationships", trace.WithAttributes(
+		common.ObjNamespaceNameKey.String(filter.ResourceType),
+	))
+	defer span.End()
+
+	return r.delegate.CountRelationships(ctx, filter)
+}
+
+func (r *observableReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "ListResourceTypes")
+	defer span.End()
+
+	return r.delegate.ListResourceTypes(ctx)
+}
+
 type observableRWT struct {
 	*observableReader
 	delegate datastore.ReadWriteTransaction
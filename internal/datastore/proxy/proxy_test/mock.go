@@ -15,14 +15,21 @@ type MockDatastore struct {
 	mock.Mock
 }
 
-func (dm *MockDatastore) SnapshotReader(rev datastore.Revision) datastore.Reader {
-	args := dm.Called(rev)
+func (dm *MockDatastore) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	callArgs := make([]interface{}, 0, len(opts)+1)
+	callArgs = append(callArgs, rev)
+	for _, option := range opts {
+		callArgs = append(callArgs, option)
+	}
+
+	args := dm.Called(callArgs...)
 	return args.Get(0).(datastore.Reader)
 }
 
 func (dm *MockDatastore) ReadWriteTx(
 	ctx context.Context,
 	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
 	args := dm.Called()
 	mockRWT := args.Get(0).(datastore.ReadWriteTransaction)
@@ -34,6 +41,11 @@ func (dm *MockDatastore) ReadWriteTx(
 	return args.Get(1).(datastore.Revision), args.Error(2)
 }
 
+func (dm *MockDatastore) BulkLoad(ctx context.Context, source datastore.BulkWriteRelationshipSource) (datastore.Revision, error) {
+	args := dm.Called(source)
+	return args.Get(0).(datastore.Revision), args.Error(1)
+}
+
 func (dm *MockDatastore) OptimizedRevision(ctx context.Context) (datastore.Revision, error) {
 	args := dm.Called()
 	return args.Get(0).(datastore.Revision), args.Error(1)
@@ -117,6 +129,20 @@ func (dm *MockReader) QueryRelationships(
 	return results, args.Error(1)
 }
 
+func (dm *MockReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	args := dm.Called(filter)
+	return args.Get(0).(uint64), args.Error(1)
+}
+
+func (dm *MockReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	args := dm.Called()
+	var resourceTypes []string
+	if args.Get(0) != nil {
+		resourceTypes = args.Get(0).([]string)
+	}
+	return resourceTypes, args.Error(1)
+}
+
 func (dm *MockReader) ReverseQueryRelationships(
 	ctx context.Context,
 	subjectsFilter datastore.SubjectsFilter,
@@ -137,7 +163,7 @@ func (dm *MockReader) ReverseQueryRelationships(
 	return results, args.Error(1)
 }
 
-func (dm *MockReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+func (dm *MockReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
 	args := dm.Called()
 	return args.Get(0).([]*core.NamespaceDefinition), args.Error(1)
 }
@@ -152,7 +178,12 @@ func (dm *MockReader) ReadCaveatByName(ctx context.Context, name string) (*core.
 	panic("implement me")
 }
 
-func (dm *MockReader) ListCaveats(ctx context.Context, caveatNames ...string) ([]*core.CaveatDefinition, error) {
+func (dm *MockReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	// TODO implement me
+	panic("implement me")
+}
+
+func (dm *MockReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
 	// TODO implement me
 	panic("implement me")
 }
@@ -195,6 +226,20 @@ func (dm *MockReadWriteTransaction) QueryRelationships(
 	return results, args.Error(1)
 }
 
+func (dm *MockReadWriteTransaction) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	args := dm.Called(filter)
+	return args.Get(0).(uint64), args.Error(1)
+}
+
+func (dm *MockReadWriteTransaction) ListResourceTypes(ctx context.Context) ([]string, error) {
+	args := dm.Called()
+	var resourceTypes []string
+	if args.Get(0) != nil {
+		resourceTypes = args.Get(0).([]string)
+	}
+	return resourceTypes, args.Error(1)
+}
+
 func (dm *MockReadWriteTransaction) ReverseQueryRelationships(
 	ctx context.Context,
 	subjectsFilter datastore.SubjectsFilter,
@@ -215,7 +260,7 @@ func (dm *MockReadWriteTransaction) ReverseQueryRelationships(
 	return results, args.Error(1)
 }
 
-func (dm *MockReadWriteTransaction) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
+func (dm *MockReadWriteTransaction) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
 	args := dm.Called()
 	return args.Get(0).([]*core.NamespaceDefinition), args.Error(1)
 }
@@ -255,7 +300,12 @@ func (dm *MockReadWriteTransaction) ReadCaveatByName(ctx context.Context, name s
 	panic("implement me")
 }
 
-func (dm *MockReadWriteTransaction) ListCaveats(ctx context.Context, caveatNames ...string) ([]*core.CaveatDefinition, error) {
+func (dm *MockReadWriteTransaction) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	// TODO implement me
+	panic("implement me")
+}
+
+func (dm *MockReadWriteTransaction) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
 	// TODO implement me
 	panic("implement me")
 }
@@ -0,0 +1,162 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+type queryLimitsCtxKeyType struct{}
+
+var skipQueryLimitsKey queryLimitsCtxKeyType = struct{}{}
+
+// ContextWithoutQueryLimits returns a context whose relationship queries are not subject to the
+// limits of a query limits proxy, for internal scans which are expected to read every
+// relationship.
+func ContextWithoutQueryLimits(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipQueryLimitsKey, true)
+}
+
+// NewQueryLimitsProxy creates a proxy which fails each relationship query which reads more than
+// maxRows relationships or takes longer than maxDuration with an ErrQueryLimitExceeded, so that a
+// single pathological request cannot saturate the datastore. A limit of zero is not enforced.
+//
+// The duration of a query includes the time taken by the caller to consume the relationships,
+// as the delegate may still be streaming them from the database.
+func NewQueryLimitsProxy(delegate datastore.Datastore, maxRows uint64, maxDuration time.Duration) datastore.Datastore {
+	return &queryLimitsProxy{Datastore: delegate, maxRows: maxRows, maxDuration: maxDuration}
+}
+
+type queryLimitsProxy struct {
+	datastore.Datastore
+
+	maxRows     uint64
+	maxDuration time.Duration
+}
+
+func (p *queryLimitsProxy) Unwrap() datastore.Datastore { return p.Datastore }
+
+func (p *queryLimitsProxy) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return &queryLimitsReader{p.Datastore.SnapshotReader(rev, opts...), p}
+}
+
+func (p *queryLimitsProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	return p.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return f(&queryLimitsRWT{rwt, &queryLimitsReader{rwt, p}})
+	}, opts...)
+}
+
+type queryLimitsReader struct {
+	datastore.Reader
+	p *queryLimitsProxy
+}
+
+func (r *queryLimitsReader) QueryRelationships(ctx context.Context, filter datastore.RelationshipsFilter, opts ...options.QueryOptionsOption) (datastore.RelationshipIterator, error) {
+	return r.p.limitQuery(ctx, func(ctx context.Context) (datastore.RelationshipIterator, error) {
+		return r.Reader.QueryRelationships(ctx, filter, opts...)
+	})
+}
+
+func (r *queryLimitsReader) ReverseQueryRelationships(ctx context.Context, subjectsFilter datastore.SubjectsFilter, opts ...options.ReverseQueryOptionsOption) (datastore.RelationshipIterator, error) {
+	return r.p.limitQuery(ctx, func(ctx context.Context) (datastore.RelationshipIterator, error) {
+		return r.Reader.ReverseQueryRelationships(ctx, subjectsFilter, opts...)
+	})
+}
+
+type queryLimitsRWT struct {
+	datastore.ReadWriteTransaction
+	reader *queryLimitsReader
+}
+
+func (rwt *queryLimitsRWT) QueryRelationships(ctx context.Context, filter datastore.RelationshipsFilter, opts ...options.QueryOptionsOption) (datastore.RelationshipIterator, error) {
+	return rwt.reader.QueryRelationships(ctx, filter, opts...)
+}
+
+func (rwt *queryLimitsRWT) ReverseQueryRelationships(ctx context.Context, subjectsFilter datastore.SubjectsFilter, opts ...options.ReverseQueryOptionsOption) (datastore.RelationshipIterator, error) {
+	return rwt.reader.ReverseQueryRelationships(ctx, subjectsFilter, opts...)
+}
+
+func (p *queryLimitsProxy) limitQuery(ctx context.Context, query func(context.Context) (datastore.RelationshipIterator, error)) (datastore.RelationshipIterator, error) {
+	if skip, _ := ctx.Value(skipQueryLimitsKey).(bool); skip {
+		return query(ctx)
+	}
+
+	queryCtx, cancel := ctx, context.CancelFunc(func() {})
+	if p.maxDuration > 0 {
+		queryCtx, cancel = context.WithTimeout(ctx, p.maxDuration)
+	}
+
+	iter, err := query(queryCtx)
+	if err != nil {
+		err = p.rewriteErr(ctx, queryCtx, err)
+		cancel()
+		return nil, err
+	}
+
+	return &queryLimitsIterator{delegate: iter, p: p, ctx: ctx, queryCtx: queryCtx, cancel: cancel}, nil
+}
+
+// rewriteErr returns a limit exceeded error in place of the error of a query whose context
+// expired because of the duration limit, rather than the deadline of the caller.
+func (p *queryLimitsProxy) rewriteErr(ctx, queryCtx context.Context, err error) error {
+	if ctx.Err() == nil && errors.Is(queryCtx.Err(), context.DeadlineExceeded) {
+		return datastore.NewDurationLimitExceededErr(p.maxDuration)
+	}
+	return err
+}
+
+type queryLimitsIterator struct {
+	delegate datastore.RelationshipIterator
+	p        *queryLimitsProxy
+	ctx      context.Context
+	queryCtx context.Context
+	cancel   context.CancelFunc
+
+	read uint64
+	err  error
+}
+
+func (it *queryLimitsIterator) Next() *core.RelationTuple {
+	if it.err != nil {
+		return nil
+	}
+
+	// Not every delegate stops iterating once its context has expired.
+	if err := it.queryCtx.Err(); err != nil {
+		it.err = it.p.rewriteErr(it.ctx, it.queryCtx, err)
+		return nil
+	}
+
+	tpl := it.delegate.Next()
+	if tpl == nil {
+		return nil
+	}
+
+	it.read++
+	if it.p.maxRows > 0 && it.read > it.p.maxRows {
+		it.err = datastore.NewRowLimitExceededErr(it.p.maxRows)
+		return nil
+	}
+
+	return tpl
+}
+
+func (it *queryLimitsIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+
+	if err := it.delegate.Err(); err != nil {
+		return it.p.rewriteErr(it.ctx, it.queryCtx, err)
+	}
+	return nil
+}
+
+func (it *queryLimitsIterator) Close() {
+	it.delegate.Close()
+	it.cancel()
+}
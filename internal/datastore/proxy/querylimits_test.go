@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func newQueryLimitsTestReader(t *testing.T, maxRows uint64, maxDuration time.Duration) datastore.Reader {
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	ds, _ := testfixtures.StandardDatastoreWithSchema(rawDS, require.New(t))
+	rev, err := common.WriteTuples(context.Background(), ds, core.RelationTupleUpdate_CREATE,
+		tuple.MustParse("document:first#viewer@user:tom"),
+		tuple.MustParse("document:second#viewer@user:tom"),
+		tuple.MustParse("document:third#viewer@user:tom"),
+	)
+	require.NoError(t, err)
+
+	return NewQueryLimitsProxy(ds, maxRows, maxDuration).SnapshotReader(rev)
+}
+
+// readAll reads every relationship of the document type and returns the number read.
+func readAll(ctx context.Context, reader datastore.Reader) (int, error) {
+	iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: "document"})
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+
+	read := 0
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		read++
+	}
+	return read, iter.Err()
+}
+
+func TestQueryLimitsRows(t *testing.T) {
+	read, err := readAll(context.Background(), newQueryLimitsTestReader(t, 3, 0))
+	require.NoError(t, err)
+	require.Equal(t, 3, read)
+
+	read, err = readAll(context.Background(), newQueryLimitsTestReader(t, 2, 0))
+	require.Equal(t, 2, read)
+
+	var limitErr datastore.ErrQueryLimitExceeded
+	require.True(t, errors.As(err, &limitErr))
+	require.Equal(t, datastore.QueryLimitRows, limitErr.Limit())
+}
+
+func TestQueryLimitsDuration(t *testing.T) {
+	reader := newQueryLimitsTestReader(t, 0, 10*time.Millisecond)
+
+	iter, err := reader.QueryRelationships(context.Background(), datastore.RelationshipsFilter{ResourceType: "document"})
+	require.NoError(t, err)
+	defer iter.Close()
+
+	require.NotNil(t, iter.Next())
+	time.Sleep(20 * time.Millisecond)
+	require.Nil(t, iter.Next())
+
+	var limitErr datastore.ErrQueryLimitExceeded
+	require.True(t, errors.As(iter.Err(), &limitErr))
+	require.Equal(t, datastore.QueryLimitDuration, limitErr.Limit())
+}
+
+func TestQueryLimitsCallerDeadline(t *testing.T) {
+	reader := newQueryLimitsTestReader(t, 0, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: "document"})
+	require.NoError(t, err)
+	defer iter.Close()
+
+	<-ctx.Done()
+	require.Nil(t, iter.Next())
+	require.ErrorIs(t, iter.Err(), context.DeadlineExceeded)
+	require.False(t, errors.As(iter.Err(), &datastore.ErrQueryLimitExceeded{}))
+}
+
+func TestQueryLimitsSkipped(t *testing.T) {
+	read, err := readAll(ContextWithoutQueryLimits(context.Background()), newQueryLimitsTestReader(t, 1, 0))
+	require.NoError(t, err)
+	require.Equal(t, 3, read)
+}
@@ -2,8 +2,14 @@ package proxy
 
 import (
 	"context"
+	"fmt"
+	"sync/atomic"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
+	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 var errReadOnly = datastore.NewReadonlyErr()
@@ -12,12 +18,315 @@ type roDatastore struct {
 	datastore.Datastore
 }
 
+func (rd roDatastore) Unwrap() datastore.Datastore { return rd.Datastore }
+
 // NewReadonlyDatastore creates a proxy which disables write operations to a downstream delegate
 // datastore.
 func NewReadonlyDatastore(delegate datastore.Datastore) datastore.Datastore {
 	return roDatastore{Datastore: delegate}
 }
 
-func (rd roDatastore) ReadWriteTx(context.Context, datastore.TxUserFunc) (datastore.Revision, error) {
+func (rd roDatastore) ReadWriteTx(context.Context, datastore.TxUserFunc, ...options.RWTOptionsOption) (datastore.Revision, error) {
 	return datastore.NoRevision, errReadOnly
 }
+
+func (rd roDatastore) BulkLoad(context.Context, datastore.BulkWriteRelationshipSource) (datastore.Revision, error) {
+	return datastore.NoRevision, errReadOnly
+}
+
+// Features reports the features of the delegate, except that relationships cannot be bulk loaded.
+func (rd roDatastore) Features(ctx context.Context) (*datastore.Features, error) {
+	features, err := rd.Datastore.Features(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	readOnly := *features
+	readOnly.BulkLoad = datastore.Feature{Enabled: false, Reason: errReadOnly.Error()}
+	return &readOnly, nil
+}
+
+// ReadonlyToggleDatastore is a datastore which can be switched between serving writes and
+// rejecting them while it is running. The mode is stored in the datastore, so it is shared by
+// every node using the datastore.
+type ReadonlyToggleDatastore interface {
+	datastore.Datastore
+
+	// SetReadOnly sets whether writes are rejected with a datastore.ErrReadOnly.
+	SetReadOnly(ctx context.Context, readOnly bool) error
+
+	// IsReadOnly returns whether writes are currently rejected.
+	IsReadOnly(ctx context.Context) (bool, error)
+}
+
+// readOnlyRelationship is stored while the datastore is read-only. Its resource type is
+// reserved, and relationships of it are hidden from those read and watched through the proxy.
+var readOnlyRelationship = tuple.MustParse(readOnlyResourceType + ":datastore#readonly@" + readOnlyResourceType + ":datastore")
+
+const readOnlyResourceType = "spicedb/datastore_mode"
+
+// NewReadonlyToggleDatastore creates a proxy which, while set to read-only, rejects write
+// operations to a downstream delegate datastore as NewReadonlyDatastore does. Reads, including
+// checks, continue to be served.
+//
+// The mode is stored as a relationship of a reserved resource type, which each read-write
+// transaction reads before it is applied, at the cost of an additional query per transaction, so
+// writes are rejected by every node using the datastore as soon as it is set to read-only.
+// Transactions started before then are allowed to complete, as are bulk loads, which only read the
+// mode before they are started. The relationship is hidden from the resource types listed, the
+// relationships counted and watched, and the statistics of the datastore.
+//
+// If readOnly is set, the proxy starts out rejecting writes without storing the mode, so that
+// starting it does not write to the datastore, which may itself be read-only. Only this proxy
+// then rejects writes, until it is set out of read-only mode.
+func NewReadonlyToggleDatastore(delegate datastore.Datastore, readOnly bool) ReadonlyToggleDatastore {
+	p := &readonlyToggleProxy{Datastore: delegate}
+	p.localReadOnly.Store(readOnly)
+	return p
+}
+
+type readonlyToggleProxy struct {
+	datastore.Datastore
+
+	// localReadOnly is set while this proxy rejects writes regardless of the mode stored.
+	localReadOnly atomic.Bool
+}
+
+func (p *readonlyToggleProxy) Unwrap() datastore.Datastore { return p.Datastore }
+
+func (p *readonlyToggleProxy) SetReadOnly(ctx context.Context, readOnly bool) error {
+	if !readOnly {
+		p.localReadOnly.Store(false)
+	}
+
+	// The mode is only written if it is changed, so that setting the current mode does not
+	// write to the datastore.
+	stored, err := p.storedReadOnly(ctx)
+	if err != nil {
+		return err
+	}
+	if stored == readOnly {
+		return nil
+	}
+
+	var changed bool
+	if _, err := p.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		current, err := readOnlyIn(ctx, rwt)
+		if err != nil {
+			return err
+		}
+
+		changed = current != readOnly
+		if !changed {
+			return nil
+		}
+
+		update := tuple.Delete(readOnlyRelationship)
+		if readOnly {
+			update = tuple.Touch(readOnlyRelationship)
+		}
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{update})
+	}); err != nil {
+		return fmt.Errorf("unable to set read-only mode: %w", err)
+	}
+
+	if changed {
+		log.Ctx(ctx).Warn().Bool("readOnly", readOnly).Msg("datastore read-only mode changed")
+	}
+	return nil
+}
+
+func (p *readonlyToggleProxy) IsReadOnly(ctx context.Context) (bool, error) {
+	if p.localReadOnly.Load() {
+		return true, nil
+	}
+	return p.storedReadOnly(ctx)
+}
+
+// storedReadOnly returns whether the read-only relationship is stored at the head revision.
+func (p *readonlyToggleProxy) storedReadOnly(ctx context.Context) (bool, error) {
+	revision, err := p.Datastore.HeadRevision(ctx)
+	if err != nil {
+		return false, fmt.Errorf("unable to read read-only mode: %w", err)
+	}
+	return readOnlyIn(ctx, p.Datastore.SnapshotReader(revision))
+}
+
+// readOnlyIn returns whether the read-only relationship is stored as seen by the reader.
+func readOnlyIn(ctx context.Context, reader datastore.Reader) (bool, error) {
+	iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:             readOnlyResourceType,
+		OptionalResourceIds:      []string{readOnlyRelationship.ResourceAndRelation.ObjectId},
+		OptionalResourceRelation: readOnlyRelationship.ResourceAndRelation.Relation,
+	})
+	if err != nil {
+		return false, fmt.Errorf("unable to read read-only mode: %w", err)
+	}
+	defer iter.Close()
+
+	found := iter.Next() != nil
+	if iter.Err() != nil {
+		return false, fmt.Errorf("unable to read read-only mode: %w", iter.Err())
+	}
+	return found, nil
+}
+
+func (p *readonlyToggleProxy) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return modeHidingReader{p.Datastore.SnapshotReader(rev, opts...)}
+}
+
+func (p *readonlyToggleProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	if p.localReadOnly.Load() {
+		return datastore.NoRevision, errReadOnly
+	}
+
+	return p.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		readOnly, err := readOnlyIn(ctx, rwt)
+		if err != nil {
+			return err
+		}
+		if readOnly {
+			return errReadOnly
+		}
+		return f(modeHidingRWT{rwt})
+	}, opts...)
+}
+
+func (p *readonlyToggleProxy) BulkLoad(ctx context.Context, source datastore.BulkWriteRelationshipSource) (datastore.Revision, error) {
+	readOnly, err := p.IsReadOnly(ctx)
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+	if readOnly {
+		return datastore.NoRevision, errReadOnly
+	}
+	return p.Datastore.BulkLoad(ctx, source)
+}
+
+func (p *readonlyToggleProxy) Features(ctx context.Context) (*datastore.Features, error) {
+	readOnly, err := p.IsReadOnly(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if readOnly {
+		return roDatastore{Datastore: p.Datastore}.Features(ctx)
+	}
+	return p.Datastore.Features(ctx)
+}
+
+// Statistics returns the statistics of the delegate, without the relationship of the read-only
+// mode.
+func (p *readonlyToggleProxy) Statistics(ctx context.Context) (datastore.Stats, error) {
+	stats, err := p.Datastore.Statistics(ctx)
+	if err != nil {
+		return stats, err
+	}
+
+	stored, err := p.storedReadOnly(ctx)
+	if err != nil {
+		return datastore.Stats{}, err
+	}
+	if stored && stats.EstimatedRelationshipCount > 0 {
+		stats.EstimatedRelationshipCount--
+	}
+	return stats, nil
+}
+
+// Watch watches the changes of the delegate, without the changes of the read-only mode. Changes
+// which only changed the mode are not watched at all.
+func (p *readonlyToggleProxy) Watch(ctx context.Context, afterRevision datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
+	delegateChanges, delegateErrs := p.Datastore.Watch(ctx, afterRevision)
+
+	changes := make(chan *datastore.RevisionChanges, cap(delegateChanges))
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(changes)
+		defer close(errs)
+
+		for {
+			select {
+			case change, ok := <-delegateChanges:
+				if !ok {
+					return
+				}
+
+				visible := &datastore.RevisionChanges{
+					Revision:     change.Revision,
+					Changes:      make([]*core.RelationTupleUpdate, 0, len(change.Changes)),
+					IsCheckpoint: change.IsCheckpoint,
+					Metadata:     change.Metadata,
+				}
+				for _, update := range change.Changes {
+					if update.Tuple.ResourceAndRelation.Namespace != readOnlyResourceType {
+						visible.Changes = append(visible.Changes, update)
+					}
+				}
+				if len(visible.Changes) == 0 && len(change.Changes) > 0 && !change.IsCheckpoint {
+					continue
+				}
+
+				select {
+				case changes <- visible:
+				case <-ctx.Done():
+					errs <- datastore.NewWatchCanceledErr()
+					return
+				}
+			case err, ok := <-delegateErrs:
+				if ok {
+					errs <- err
+				}
+				return
+			}
+		}
+	}()
+
+	return changes, errs
+}
+
+// modeHidingReader hides the relationships of the read-only mode from the resource types
+// listed and the relationships counted, which are otherwise only read by the queries of their
+// reserved resource type.
+type modeHidingReader struct {
+	datastore.Reader
+}
+
+func (r modeHidingReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	return hideModeResourceType(r.Reader.ListResourceTypes(ctx))
+}
+
+func (r modeHidingReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	if filter.ResourceType == readOnlyResourceType {
+		return 0, nil
+	}
+	return r.Reader.CountRelationships(ctx, filter)
+}
+
+type modeHidingRWT struct {
+	datastore.ReadWriteTransaction
+}
+
+func (rwt modeHidingRWT) ListResourceTypes(ctx context.Context) ([]string, error) {
+	return hideModeResourceType(rwt.ReadWriteTransaction.ListResourceTypes(ctx))
+}
+
+func (rwt modeHidingRWT) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	if filter.ResourceType == readOnlyResourceType {
+		return 0, nil
+	}
+	return rwt.ReadWriteTransaction.CountRelationships(ctx, filter)
+}
+
+func hideModeResourceType(resourceTypes []string, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	visible := resourceTypes[:0]
+	for _, resourceType := range resourceTypes {
+		if resourceType != readOnlyResourceType {
+			visible = append(visible, resourceType)
+		}
+	}
+	return visible, nil
+}
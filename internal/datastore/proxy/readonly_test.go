@@ -3,6 +3,7 @@ package proxy
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/mock"
@@ -126,6 +127,27 @@ func TestWatchPassthrough(t *testing.T) {
 	delegate.AssertExpectations(t)
 }
 
+func TestFeaturesDisableBulkLoad(t *testing.T) {
+	require := require.New(t)
+
+	delegate, _ := newReadOnlyMock()
+	ds := NewReadonlyDatastore(delegate)
+	ctx := context.Background()
+
+	delegateFeatures := &datastore.Features{
+		Watch:    datastore.Feature{Enabled: true},
+		BulkLoad: datastore.Feature{Enabled: true},
+	}
+	delegate.On("Features").Return(delegateFeatures, nil).Times(1)
+
+	features, err := ds.Features(ctx)
+	require.NoError(err)
+	require.True(features.Watch.Enabled)
+	require.False(features.BulkLoad.Enabled)
+	require.True(delegateFeatures.BulkLoad.Enabled)
+	delegate.AssertExpectations(t)
+}
+
 func TestSnapshotReaderPassthrough(t *testing.T) {
 	require := require.New(t)
 
@@ -141,3 +163,126 @@ func TestSnapshotReaderPassthrough(t *testing.T) {
 	delegate.AssertExpectations(t)
 	reader.AssertExpectations(t)
 }
+
+func TestReadonlyToggle(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// Each proxy stands for a node using the shared datastore.
+	shared := newDualWriteTestDatastore(t)
+	ds := NewReadonlyToggleDatastore(shared, false)
+	other := NewReadonlyToggleDatastore(shared, false)
+	requireReadOnly(t, false, ds, other)
+
+	first := tuple.MustParse("document:firstdoc#viewer@user:tom")
+	second := tuple.MustParse("document:seconddoc#viewer@user:tom")
+
+	_, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, first)
+	require.NoError(err)
+	writableStats, err := ds.Statistics(ctx)
+	require.NoError(err)
+
+	// While read-only, writes are rejected by every node and reads continue to be served.
+	require.NoError(ds.SetReadOnly(ctx, true))
+	requireReadOnly(t, true, ds, other)
+
+	for _, node := range []ReadonlyToggleDatastore{ds, other} {
+		_, err = common.WriteTuples(ctx, node, core.RelationTupleUpdate_CREATE, second)
+		require.ErrorAs(err, &datastore.ErrReadOnly{})
+		require.Equal([]string{tuple.String(first)}, documentViewers(t, node))
+
+		features, err := node.Features(ctx)
+		require.NoError(err)
+		require.False(features.BulkLoad.Enabled)
+	}
+
+	// The mode is hidden from the resource types stored, the relationships counted and the
+	// statistics.
+	rev, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+	resourceTypes, err := ds.SnapshotReader(rev).ListResourceTypes(ctx)
+	require.NoError(err)
+	require.Equal([]string{"document"}, resourceTypes)
+
+	count, err := ds.SnapshotReader(rev).CountRelationships(ctx, datastore.RelationshipsFilter{ResourceType: readOnlyResourceType})
+	require.NoError(err)
+	require.Zero(count)
+
+	stats, err := ds.Statistics(ctx)
+	require.NoError(err)
+	require.Equal(writableStats.EstimatedRelationshipCount, stats.EstimatedRelationshipCount)
+
+	// Once made writable again by another node, writes are applied.
+	require.NoError(other.SetReadOnly(ctx, false))
+	requireReadOnly(t, false, ds, other)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, second)
+	require.NoError(err)
+	require.ElementsMatch([]string{tuple.String(first), tuple.String(second)}, documentViewers(t, other))
+
+	features, err := ds.Features(ctx)
+	require.NoError(err)
+	require.True(features.BulkLoad.Enabled)
+}
+
+func TestReadonlyToggleWatch(t *testing.T) {
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ds := NewReadonlyToggleDatastore(newDualWriteTestDatastore(t), false)
+	rev, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+
+	changes, errs := ds.Watch(ctx, rev)
+
+	require.NoError(ds.SetReadOnly(ctx, true))
+	require.NoError(ds.SetReadOnly(ctx, false))
+	written := tuple.MustParse("document:firstdoc#viewer@user:tom")
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, written)
+	require.NoError(err)
+
+	// The changes of the mode are not watched.
+	var watched []string
+	for len(watched) == 0 {
+		select {
+		case change := <-changes:
+			require.True(change.IsCheckpoint || len(change.Changes) > 0, "watched an empty change")
+			for _, update := range change.Changes {
+				watched = append(watched, tuple.String(update.Tuple))
+			}
+		case err := <-errs:
+			require.FailNow("unexpected watch error", err)
+		case <-time.After(5 * time.Second):
+			require.FailNow("timed out waiting for changes")
+		}
+	}
+	require.Equal([]string{tuple.String(written)}, watched)
+}
+
+func TestReadonlyToggleStartsReadOnly(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// Starting read-only does not write the mode, so the delegate may itself reject writes.
+	shared := newDualWriteTestDatastore(t)
+	ds := NewReadonlyToggleDatastore(NewReadonlyDatastore(shared), true)
+	other := NewReadonlyToggleDatastore(shared, false)
+	requireReadOnly(t, true, ds)
+	requireReadOnly(t, false, other)
+
+	_, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tuple.MustParse("document:firstdoc#viewer@user:tom"))
+	require.ErrorAs(err, &datastore.ErrReadOnly{})
+
+	// Setting it out of read-only mode does not write the mode either, as it is not stored.
+	require.NoError(ds.SetReadOnly(ctx, false))
+	requireReadOnly(t, false, ds)
+}
+
+func requireReadOnly(t *testing.T, expected bool, nodes ...ReadonlyToggleDatastore) {
+	for _, node := range nodes {
+		readOnly, err := node.IsReadOnly(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, expected, readOnly)
+	}
+}
@@ -0,0 +1,155 @@
+package proxy
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+var replicaReadCount = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "spicedb",
+	Subsystem: "datastore",
+	Name:      "replica_reads_total",
+	Help:      "total number of snapshot readers routed by the replica proxy, by whether they fell back to the primary",
+}, []string{"fallback"})
+
+// NewReadReplicaDatastoreProxy creates a proxy which sends snapshot reads to the replica
+// datastores in turn, and all other operations to the primary datastore. A snapshot read at a
+// revision which the chosen replica has not yet replicated is sent to the primary instead.
+func NewReadReplicaDatastoreProxy(primary datastore.Datastore, replicas ...datastore.Datastore) datastore.Datastore {
+	if len(replicas) == 0 {
+		return primary
+	}
+
+	return &replicatedProxy{Datastore: primary, replicas: replicas}
+}
+
+type replicatedProxy struct {
+	datastore.Datastore
+
+	replicas []datastore.Datastore
+	next     uint64
+}
+
+func (p *replicatedProxy) Unwrap() datastore.Datastore { return p.Datastore }
+
+func (p *replicatedProxy) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	replica := p.replicas[atomic.AddUint64(&p.next, 1)%uint64(len(p.replicas))]
+	return &replicatedReader{
+		rev:     rev,
+		opts:    opts,
+		primary: p.Datastore,
+		replica: replica,
+	}
+}
+
+func (p *replicatedProxy) Close() error {
+	closeErr := p.Datastore.Close()
+	for _, replica := range p.replicas {
+		if err := replica.Close(); err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	return closeErr
+}
+
+// replicatedReader chooses between the replica and the primary on first use, as the freshness of
+// the replica can only be checked once a context is available.
+type replicatedReader struct {
+	rev     datastore.Revision
+	opts    []options.SnapshotReaderOptionsOption
+	primary datastore.Datastore
+	replica datastore.Datastore
+
+	sync.Mutex
+	chosen datastore.Reader
+}
+
+func (rr *replicatedReader) reader(ctx context.Context) datastore.Reader {
+	rr.Lock()
+	defer rr.Unlock()
+
+	if rr.chosen != nil {
+		return rr.chosen
+	}
+
+	replicaRev, err := rr.replica.HeadRevision(ctx)
+	switch {
+	case err != nil:
+		log.Ctx(ctx).Warn().Err(err).Msg("unable to determine replica revision, reading from primary")
+		rr.chosen = rr.primary.SnapshotReader(rr.rev, rr.opts...)
+		replicaReadCount.WithLabelValues("true").Inc()
+
+	case rr.rev.GreaterThan(replicaRev):
+		log.Ctx(ctx).Trace().Stringer("revision", rr.rev).Stringer("replica", replicaRev).Msg("replica is behind requested revision, reading from primary")
+		rr.chosen = rr.primary.SnapshotReader(rr.rev, rr.opts...)
+		replicaReadCount.WithLabelValues("true").Inc()
+
+	default:
+		rr.chosen = rr.replica.SnapshotReader(rr.rev, rr.opts...)
+		replicaReadCount.WithLabelValues("false").Inc()
+	}
+
+	return rr.chosen
+}
+
+func (rr *replicatedReader) ReadCaveatByName(ctx context.Context, name string) (*core.CaveatDefinition, datastore.Revision, error) {
+	return rr.reader(ctx).ReadCaveatByName(ctx, name)
+}
+
+func (rr *replicatedReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	return rr.reader(ctx).ListCaveats(ctx, opts...)
+}
+
+func (rr *replicatedReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	return rr.reader(ctx).LookupCaveats(ctx, caveatNames)
+}
+
+func (rr *replicatedReader) QueryRelationships(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	return rr.reader(ctx).QueryRelationships(ctx, filter, opts...)
+}
+
+func (rr *replicatedReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	return rr.reader(ctx).CountRelationships(ctx, filter)
+}
+
+func (rr *replicatedReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	return rr.reader(ctx).ListResourceTypes(ctx)
+}
+
+func (rr *replicatedReader) ReverseQueryRelationships(
+	ctx context.Context,
+	subjectsFilter datastore.SubjectsFilter,
+	opts ...options.ReverseQueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	return rr.reader(ctx).ReverseQueryRelationships(ctx, subjectsFilter, opts...)
+}
+
+func (rr *replicatedReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
+	return rr.reader(ctx).ReadNamespace(ctx, nsName)
+}
+
+func (rr *replicatedReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	return rr.reader(ctx).ListNamespaces(ctx, opts...)
+}
+
+func (rr *replicatedReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
+	return rr.reader(ctx).LookupNamespaces(ctx, nsNames)
+}
+
+var (
+	_ datastore.Datastore = (*replicatedProxy)(nil)
+	_ datastore.Reader    = (*replicatedReader)(nil)
+)
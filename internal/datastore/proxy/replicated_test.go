@@ -0,0 +1,116 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/proxy/proxy_test"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+func TestReadReplicaRouting(t *testing.T) {
+	cases := []struct {
+		name           string
+		readRevision   datastore.Revision
+		replicaHead    datastore.Revision
+		replicaHeadErr error
+		expectReplica  bool
+	}{
+		{"replica caught up", one, one, nil, true},
+		{"replica ahead", one, two, nil, true},
+		{"replica behind", two, one, nil, false},
+		{"replica unavailable", one, datastore.NoRevision, errors.New("replica unavailable"), false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			primary := &proxy_test.MockDatastore{}
+			primaryReader := &proxy_test.MockReader{}
+			replica := &proxy_test.MockDatastore{}
+			replicaReader := &proxy_test.MockReader{}
+
+			replica.On("HeadRevision").Return(tc.replicaHead, tc.replicaHeadErr).Once()
+			if tc.expectReplica {
+				replica.On("SnapshotReader", tc.readRevision).Return(replicaReader).Once()
+				replicaReader.On("ReadNamespace", nsA).Return(nil, zero, nil).Twice()
+			} else {
+				primary.On("SnapshotReader", tc.readRevision).Return(primaryReader).Once()
+				primaryReader.On("ReadNamespace", nsA).Return(nil, zero, nil).Twice()
+			}
+
+			ds := NewReadReplicaDatastoreProxy(primary, replica)
+			reader := ds.SnapshotReader(tc.readRevision)
+
+			// The replica is only checked once per reader.
+			for i := 0; i < 2; i++ {
+				_, _, err := reader.ReadNamespace(context.Background(), nsA)
+				require.NoError(err)
+			}
+
+			primary.AssertExpectations(t)
+			primaryReader.AssertExpectations(t)
+			replica.AssertExpectations(t)
+			replicaReader.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReadReplicaWritesGoToPrimary(t *testing.T) {
+	require := require.New(t)
+
+	primary := &proxy_test.MockDatastore{}
+	primaryRWT := &proxy_test.MockReadWriteTransaction{}
+	replica := &proxy_test.MockDatastore{}
+
+	primary.On("ReadWriteTx").Return(primaryRWT, one, nil).Once()
+	primary.On("HeadRevision").Return(two, nil).Once()
+
+	ds := NewReadReplicaDatastoreProxy(primary, replica)
+
+	rev, err := ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
+		return nil
+	})
+	require.NoError(err)
+	require.True(one.Equal(rev))
+
+	head, err := ds.HeadRevision(context.Background())
+	require.NoError(err)
+	require.True(two.Equal(head))
+
+	primary.AssertExpectations(t)
+	replica.AssertNotCalled(t, "ReadWriteTx", mock.Anything)
+	replica.AssertNotCalled(t, "HeadRevision")
+}
+
+func TestReadReplicaRoundRobin(t *testing.T) {
+	require := require.New(t)
+
+	primary := &proxy_test.MockDatastore{}
+	replicas := []*proxy_test.MockDatastore{{}, {}}
+
+	ds := NewReadReplicaDatastoreProxy(primary, replicas[0], replicas[1])
+
+	for _, replica := range replicas {
+		reader := &proxy_test.MockReader{}
+		reader.On("ListNamespaces").Return([]*core.NamespaceDefinition{}, nil).Once()
+		replica.On("HeadRevision").Return(one, nil).Once()
+		replica.On("SnapshotReader", one).Return(reader).Once()
+	}
+
+	for range replicas {
+		_, err := ds.SnapshotReader(one).ListNamespaces(context.Background())
+		require.NoError(err)
+	}
+
+	for _, replica := range replicas {
+		replica.AssertExpectations(t)
+	}
+}
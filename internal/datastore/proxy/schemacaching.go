@@ -0,0 +1,363 @@
+package proxy
+
+import (
+	"context"
+	"sync"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// SchemaChange describes the definitions written or deleted by a committed transaction.
+type SchemaChange struct {
+	Revision   datastore.Revision
+	Namespaces []string
+	Caveats    []string
+}
+
+// SchemaChangeHook is a function invoked after a read-write transaction which changed the schema
+// has been successfully committed. Hooks are invoked synchronously, in registration order, and
+// can be used to publish the change to other processes sharing the datastore.
+type SchemaChangeHook func(ctx context.Context, change SchemaChange)
+
+// SchemaCachingDatastore is a datastore which caches namespace and caveat definitions until they
+// are changed.
+type SchemaCachingDatastore interface {
+	datastore.Datastore
+
+	// RegisterSchemaChangeHook registers a hook to be invoked after every successfully committed
+	// read-write transaction that changed at least one definition.
+	RegisterSchemaChangeHook(hook SchemaChangeHook)
+
+	// InvalidateSchema drops the cached definitions changed by another process sharing the
+	// datastore, as received from that process's schema change hook.
+	InvalidateSchema(change SchemaChange)
+}
+
+// NewSchemaCachingDatastoreProxy creates a proxy which caches namespace and caveat definitions
+// across revisions, rather than per revision. Cached definitions are invalidated when changed by
+// a transaction made through the proxy, or when InvalidateSchema is called; changes made by other
+// processes are only seen once they are passed to InvalidateSchema.
+func NewSchemaCachingDatastoreProxy(delegate datastore.Datastore, hooks ...SchemaChangeHook) SchemaCachingDatastore {
+	return &schemaCachingProxy{
+		Datastore:  delegate,
+		hooks:      hooks,
+		namespaces: make(map[string]schemaCacheEntry[*core.NamespaceDefinition]),
+		caveats:    make(map[string]schemaCacheEntry[*core.CaveatDefinition]),
+	}
+}
+
+// schemaCacheEntry is a cached definition, or list of definitions, which is valid for reads at
+// or after validFrom until invalidated.
+type schemaCacheEntry[T any] struct {
+	value     T
+	updated   datastore.Revision
+	validFrom datastore.Revision
+}
+
+func (e schemaCacheEntry[T]) validAt(rev datastore.Revision) bool {
+	return atOrAfter(rev, e.validFrom)
+}
+
+func atOrAfter(rev, other datastore.Revision) bool {
+	return rev.Equal(other) || rev.GreaterThan(other)
+}
+
+type schemaCachingProxy struct {
+	datastore.Datastore
+
+	hooksLock sync.RWMutex
+	hooks     []SchemaChangeHook
+
+	sync.RWMutex
+	generation    uint64
+	lastChange    datastore.Revision
+	namespaces    map[string]schemaCacheEntry[*core.NamespaceDefinition]
+	caveats       map[string]schemaCacheEntry[*core.CaveatDefinition]
+	allNamespaces *schemaCacheEntry[[]*core.NamespaceDefinition]
+	allCaveats    *schemaCacheEntry[[]*core.CaveatDefinition]
+}
+
+func (p *schemaCachingProxy) Unwrap() datastore.Datastore { return p.Datastore }
+
+func (p *schemaCachingProxy) RegisterSchemaChangeHook(hook SchemaChangeHook) {
+	p.hooksLock.Lock()
+	defer p.hooksLock.Unlock()
+	p.hooks = append(p.hooks, hook)
+}
+
+func (p *schemaCachingProxy) registeredHooks() []SchemaChangeHook {
+	p.hooksLock.RLock()
+	defer p.hooksLock.RUnlock()
+	return p.hooks
+}
+
+func (p *schemaCachingProxy) InvalidateSchema(change SchemaChange) {
+	p.Lock()
+	defer p.Unlock()
+
+	// Bumping the generation prevents reads which started before the invalidation from caching
+	// what they loaded.
+	p.generation++
+	if change.Revision != nil && change.Revision != datastore.NoRevision &&
+		(p.lastChange == nil || change.Revision.GreaterThan(p.lastChange)) {
+		p.lastChange = change.Revision
+	}
+
+	for _, nsName := range change.Namespaces {
+		delete(p.namespaces, nsName)
+	}
+	if len(change.Namespaces) > 0 {
+		p.allNamespaces = nil
+	}
+
+	for _, caveatName := range change.Caveats {
+		delete(p.caveats, caveatName)
+	}
+	if len(change.Caveats) > 0 {
+		p.allCaveats = nil
+	}
+}
+
+// store runs the function to update the cache with definitions read at the revision, if no
+// invalidation has occurred since the generation was read and the read observed the most recent
+// schema change. Reads at older revisions may have loaded definitions which have since changed.
+func (p *schemaCachingProxy) store(generation uint64, readAt datastore.Revision, f func()) {
+	p.Lock()
+	defer p.Unlock()
+	if p.generation == generation && (p.lastChange == nil || atOrAfter(readAt, p.lastChange)) {
+		f()
+	}
+}
+
+func (p *schemaCachingProxy) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	delegate := p.Datastore.SnapshotReader(rev, opts...)
+
+	// Cached definitions are only known to be current as of exactly the requested revision.
+	if options.NewSnapshotReaderOptionsWithOptions(opts...).Consistency != options.ExactSnapshot {
+		return delegate
+	}
+
+	return &schemaCachingReader{delegate, rev, p}
+}
+
+func (p *schemaCachingProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	// NOTE: the transaction function may be retried by the underlying datastore, so the
+	// recorded changes are reset on each invocation.
+	var recording *schemaRecordingRWT
+	rev, err := p.Datastore.ReadWriteTx(ctx, func(delegateRWT datastore.ReadWriteTransaction) error {
+		recording = &schemaRecordingRWT{ReadWriteTransaction: delegateRWT}
+		return f(recording)
+	}, opts...)
+	if err != nil {
+		return rev, err
+	}
+
+	if recording == nil || (len(recording.namespaces) == 0 && len(recording.caveats) == 0) {
+		return rev, nil
+	}
+
+	change := SchemaChange{
+		Revision:   rev,
+		Namespaces: recording.namespaces,
+		Caveats:    recording.caveats,
+	}
+	p.InvalidateSchema(change)
+
+	log.Ctx(ctx).Trace().Strs("namespaces", change.Namespaces).Strs("caveats", change.Caveats).Stringer("revision", rev).Msg("invoking schema change hooks")
+	for _, hook := range p.registeredHooks() {
+		hook(ctx, change)
+	}
+
+	return rev, nil
+}
+
+type schemaCachingReader struct {
+	datastore.Reader
+	rev datastore.Revision
+	p   *schemaCachingProxy
+}
+
+func (r *schemaCachingReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
+	r.p.RLock()
+	entry, ok := r.p.namespaces[nsName]
+	generation := r.p.generation
+	r.p.RUnlock()
+
+	if ok && entry.validAt(r.rev) {
+		return entry.value.CloneVT(), entry.updated, nil
+	}
+
+	loaded, updated, err := r.Reader.ReadNamespace(ctx, nsName)
+	if err != nil {
+		return nil, datastore.NoRevision, err
+	}
+
+	// The definition is unchanged from the revision at which it was last written.
+	r.p.store(generation, r.rev, func() {
+		r.p.namespaces[nsName] = schemaCacheEntry[*core.NamespaceDefinition]{loaded.CloneVT(), updated, updated}
+	})
+
+	return loaded, updated, nil
+}
+
+func (r *schemaCachingReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
+	r.p.RLock()
+	found := make([]*core.NamespaceDefinition, 0, len(nsNames))
+	for _, nsName := range nsNames {
+		entry, ok := r.p.namespaces[nsName]
+		if !ok || !entry.validAt(r.rev) {
+			break
+		}
+		found = append(found, entry.value.CloneVT())
+	}
+	r.p.RUnlock()
+
+	if len(found) == len(nsNames) {
+		return found, nil
+	}
+
+	return r.Reader.LookupNamespaces(ctx, nsNames)
+}
+
+func (r *schemaCachingReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	// Only complete listings are cached.
+	if options.NewListOptionsWithOptions(opts...).IsPaged() {
+		return r.Reader.ListNamespaces(ctx, opts...)
+	}
+
+	r.p.RLock()
+	entry := r.p.allNamespaces
+	generation := r.p.generation
+	r.p.RUnlock()
+
+	if entry != nil && entry.validAt(r.rev) {
+		return cloneDefinitions(entry.value), nil
+	}
+
+	loaded, err := r.Reader.ListNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without the revisions of the individual definitions, the list is only known to be valid
+	// from the revision at which it was read.
+	r.p.store(generation, r.rev, func() {
+		r.p.allNamespaces = &schemaCacheEntry[[]*core.NamespaceDefinition]{cloneDefinitions(loaded), r.rev, r.rev}
+	})
+
+	return loaded, nil
+}
+
+func (r *schemaCachingReader) ReadCaveatByName(ctx context.Context, name string) (*core.CaveatDefinition, datastore.Revision, error) {
+	r.p.RLock()
+	entry, ok := r.p.caveats[name]
+	generation := r.p.generation
+	r.p.RUnlock()
+
+	if ok && entry.validAt(r.rev) {
+		return entry.value.CloneVT(), entry.updated, nil
+	}
+
+	loaded, updated, err := r.Reader.ReadCaveatByName(ctx, name)
+	if err != nil {
+		return nil, datastore.NoRevision, err
+	}
+
+	r.p.store(generation, r.rev, func() {
+		r.p.caveats[name] = schemaCacheEntry[*core.CaveatDefinition]{loaded.CloneVT(), updated, updated}
+	})
+
+	return loaded, updated, nil
+}
+
+func (r *schemaCachingReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	// Only complete listings are cached.
+	if options.NewListOptionsWithOptions(opts...).IsPaged() {
+		return r.Reader.ListCaveats(ctx, opts...)
+	}
+
+	r.p.RLock()
+	entry := r.p.allCaveats
+	generation := r.p.generation
+	r.p.RUnlock()
+
+	if entry != nil && entry.validAt(r.rev) {
+		return cloneDefinitions(entry.value), nil
+	}
+
+	loaded, err := r.Reader.ListCaveats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.p.store(generation, r.rev, func() {
+		r.p.allCaveats = &schemaCacheEntry[[]*core.CaveatDefinition]{cloneDefinitions(loaded), r.rev, r.rev}
+	})
+
+	return loaded, nil
+}
+
+func cloneDefinitions[T interface{ CloneVT() T }](defs []T) []T {
+	cloned := make([]T, 0, len(defs))
+	for _, def := range defs {
+		cloned = append(cloned, def.CloneVT())
+	}
+	return cloned
+}
+
+// schemaRecordingRWT records the names of the definitions written or deleted by a transaction.
+type schemaRecordingRWT struct {
+	datastore.ReadWriteTransaction
+
+	namespaces []string
+	caveats    []string
+}
+
+func (rwt *schemaRecordingRWT) WriteNamespaces(ctx context.Context, newConfigs ...*core.NamespaceDefinition) error {
+	if err := rwt.ReadWriteTransaction.WriteNamespaces(ctx, newConfigs...); err != nil {
+		return err
+	}
+
+	for _, nsDef := range newConfigs {
+		rwt.namespaces = append(rwt.namespaces, nsDef.Name)
+	}
+	return nil
+}
+
+func (rwt *schemaRecordingRWT) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+	if err := rwt.ReadWriteTransaction.DeleteNamespaces(ctx, nsNames...); err != nil {
+		return err
+	}
+
+	rwt.namespaces = append(rwt.namespaces, nsNames...)
+	return nil
+}
+
+func (rwt *schemaRecordingRWT) WriteCaveats(ctx context.Context, caveats []*core.CaveatDefinition) error {
+	if err := rwt.ReadWriteTransaction.WriteCaveats(ctx, caveats); err != nil {
+		return err
+	}
+
+	for _, caveat := range caveats {
+		rwt.caveats = append(rwt.caveats, caveat.Name)
+	}
+	return nil
+}
+
+func (rwt *schemaRecordingRWT) DeleteCaveats(ctx context.Context, names []string) error {
+	if err := rwt.ReadWriteTransaction.DeleteCaveats(ctx, names); err != nil {
+		return err
+	}
+
+	rwt.caveats = append(rwt.caveats, names...)
+	return nil
+}
+
+var (
+	_ SchemaCachingDatastore         = (*schemaCachingProxy)(nil)
+	_ datastore.Reader               = (*schemaCachingReader)(nil)
+	_ datastore.ReadWriteTransaction = (*schemaRecordingRWT)(nil)
+)
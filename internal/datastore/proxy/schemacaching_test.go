@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	ns "github.com/authzed/spicedb/pkg/namespace"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+func writeNamespace(t *testing.T, ds datastore.Datastore, nsDef *core.NamespaceDefinition) datastore.Revision {
+	rev, err := ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(context.Background(), nsDef)
+	})
+	require.NoError(t, err)
+	return rev
+}
+
+func TestSchemaCachingAcrossRevisions(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	ds, before := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+
+	cached := NewSchemaCachingDatastoreProxy(ds)
+
+	loaded, _, err := cached.SnapshotReader(before).ReadNamespace(ctx, testfixtures.UserNS.Name)
+	require.NoError(err)
+	require.Empty(loaded.Relation)
+
+	// Change the namespace without going through the proxy.
+	updatedUserNS := ns.Namespace(testfixtures.UserNS.Name, ns.Relation("manager", nil, ns.AllowedRelation("user", "...")))
+	changed := writeNamespace(t, ds, updatedUserNS)
+
+	// The cached definition is still used at the new revision, as the proxy has not been told
+	// about the change.
+	loaded, _, err = cached.SnapshotReader(changed).ReadNamespace(ctx, testfixtures.UserNS.Name)
+	require.NoError(err)
+	require.Empty(loaded.Relation)
+
+	cached.InvalidateSchema(SchemaChange{Revision: changed, Namespaces: []string{testfixtures.UserNS.Name}})
+
+	loaded, _, err = cached.SnapshotReader(changed).ReadNamespace(ctx, testfixtures.UserNS.Name)
+	require.NoError(err)
+	require.Len(loaded.Relation, 1)
+
+	// Reads at revisions before the change are not served from the cache.
+	loaded, _, err = cached.SnapshotReader(before).ReadNamespace(ctx, testfixtures.UserNS.Name)
+	require.NoError(err)
+	require.Empty(loaded.Relation)
+
+	listed, err := cached.SnapshotReader(changed).LookupNamespaces(ctx, []string{testfixtures.UserNS.Name})
+	require.NoError(err)
+	require.Len(listed, 1)
+	require.Len(listed[0].Relation, 1)
+}
+
+func TestSchemaCachingInvalidatedByWrites(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	ds, before := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+
+	var received []SchemaChange
+	cached := NewSchemaCachingDatastoreProxy(ds, func(ctx context.Context, change SchemaChange) {
+		received = append(received, change)
+	})
+
+	all, err := cached.SnapshotReader(before).ListNamespaces(ctx)
+	require.NoError(err)
+
+	loaded, _, err := cached.SnapshotReader(before).ReadNamespace(ctx, testfixtures.UserNS.Name)
+	require.NoError(err)
+	require.Empty(loaded.Relation)
+
+	updatedUserNS := ns.Namespace(testfixtures.UserNS.Name, ns.Relation("manager", nil, ns.AllowedRelation("user", "...")))
+	changed := writeNamespace(t, cached, updatedUserNS)
+
+	require.Len(received, 1)
+	require.True(changed.Equal(received[0].Revision))
+	require.Equal([]string{testfixtures.UserNS.Name}, received[0].Namespaces)
+	require.Empty(received[0].Caveats)
+
+	loaded, _, err = cached.SnapshotReader(changed).ReadNamespace(ctx, testfixtures.UserNS.Name)
+	require.NoError(err)
+	require.Len(loaded.Relation, 1)
+
+	listed, err := cached.SnapshotReader(changed).ListNamespaces(ctx)
+	require.NoError(err)
+	require.Len(listed, len(all))
+	for _, nsDef := range listed {
+		if nsDef.Name == testfixtures.UserNS.Name {
+			require.Len(nsDef.Relation, 1)
+		}
+	}
+
+	// Transactions which do not change the schema do not invoke the hooks.
+	_, err = cached.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return nil
+	})
+	require.NoError(err)
+	require.Len(received, 1)
+}
+
+func TestSchemaCachingCaveats(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	ds, rev := testfixtures.StandardDatastoreWithCaveatedData(rawDS, require)
+
+	cached := NewSchemaCachingDatastoreProxy(ds)
+
+	caveat, _, err := cached.SnapshotReader(rev).ReadCaveatByName(ctx, testfixtures.CaveatDef.Name)
+	require.NoError(err)
+	require.Equal(testfixtures.CaveatDef.Name, caveat.Name)
+
+	deleted, err := cached.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.DeleteCaveats(ctx, []string{testfixtures.CaveatDef.Name})
+	})
+	require.NoError(err)
+
+	_, _, err = cached.SnapshotReader(deleted).ReadCaveatByName(ctx, testfixtures.CaveatDef.Name)
+	require.ErrorAs(err, &datastore.ErrCaveatNameNotFound{})
+
+	caveats, err := cached.SnapshotReader(deleted).ListCaveats(ctx)
+	require.NoError(err)
+	require.Empty(caveats)
+}
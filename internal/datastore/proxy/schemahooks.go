@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"context"
+	"sync"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+// SchemaHookedDatastore is a datastore which allows for registering hooks that are invoked
+// whenever the schema is changed via the datastore.
+type SchemaHookedDatastore interface {
+	datastore.Datastore
+
+	// RegisterSchemaChangeHook registers a hook to be invoked after every successfully committed
+	// read-write transaction that changed at least one definition.
+	RegisterSchemaChangeHook(hook SchemaChangeHook)
+}
+
+// NewSchemaChangeHooksProxy creates a proxy which invokes the registered hooks with the
+// definitions changed by each read-write transaction once it has been committed. Unlike
+// NewSchemaCachingDatastoreProxy, definitions are not cached.
+func NewSchemaChangeHooksProxy(delegate datastore.Datastore, hooks ...SchemaChangeHook) SchemaHookedDatastore {
+	return &schemaHooksProxy{
+		Datastore: delegate,
+		hooks:     hooks,
+	}
+}
+
+type schemaHooksProxy struct {
+	datastore.Datastore
+
+	sync.RWMutex
+	hooks []SchemaChangeHook
+}
+
+func (p *schemaHooksProxy) Unwrap() datastore.Datastore { return p.Datastore }
+
+func (p *schemaHooksProxy) RegisterSchemaChangeHook(hook SchemaChangeHook) {
+	p.Lock()
+	defer p.Unlock()
+	p.hooks = append(p.hooks, hook)
+}
+
+func (p *schemaHooksProxy) registeredHooks() []SchemaChangeHook {
+	p.RLock()
+	defer p.RUnlock()
+	return p.hooks
+}
+
+func (p *schemaHooksProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	hooks := p.registeredHooks()
+	if len(hooks) == 0 {
+		return p.Datastore.ReadWriteTx(ctx, f, opts...)
+	}
+
+	// NOTE: the transaction function may be retried by the underlying datastore, so the
+	// recorded changes are reset on each invocation.
+	var recording *schemaRecordingRWT
+	rev, err := p.Datastore.ReadWriteTx(ctx, func(delegateRWT datastore.ReadWriteTransaction) error {
+		recording = &schemaRecordingRWT{ReadWriteTransaction: delegateRWT}
+		return f(recording)
+	}, opts...)
+	if err != nil {
+		return rev, err
+	}
+
+	if recording == nil || (len(recording.namespaces) == 0 && len(recording.caveats) == 0) {
+		return rev, nil
+	}
+
+	change := SchemaChange{
+		Revision:   rev,
+		Namespaces: recording.namespaces,
+		Caveats:    recording.caveats,
+	}
+
+	log.Ctx(ctx).Trace().Strs("namespaces", change.Namespaces).Strs("caveats", change.Caveats).Stringer("revision", rev).Msg("invoking schema change hooks")
+	for _, hook := range hooks {
+		hook(ctx, change)
+	}
+
+	return rev, nil
+}
+
+var _ SchemaHookedDatastore = (*schemaHooksProxy)(nil)
@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	ns "github.com/authzed/spicedb/pkg/namespace"
+)
+
+func TestSchemaChangeHooks(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	ds, _ := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+
+	hooked := NewSchemaChangeHooksProxy(ds)
+
+	var received []SchemaChange
+	hooked.RegisterSchemaChangeHook(func(ctx context.Context, change SchemaChange) {
+		received = append(received, change)
+	})
+
+	updatedUserNS := ns.Namespace(testfixtures.UserNS.Name, ns.Relation("manager", nil, ns.AllowedRelation("user", "...")))
+	changed := writeNamespace(t, hooked, updatedUserNS)
+
+	require.Len(received, 1)
+	require.True(changed.Equal(received[0].Revision))
+	require.Equal([]string{testfixtures.UserNS.Name}, received[0].Namespaces)
+	require.Empty(received[0].Caveats)
+
+	_, err = hooked.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.DeleteNamespaces(ctx, testfixtures.FolderNS.Name)
+	})
+	require.NoError(err)
+	require.Len(received, 2)
+	require.Equal([]string{testfixtures.FolderNS.Name}, received[1].Namespaces)
+
+	// Transactions which do not change the schema do not invoke the hooks.
+	_, err = hooked.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return nil
+	})
+	require.NoError(err)
+	require.Len(received, 2)
+}
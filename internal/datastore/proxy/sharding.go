@@ -0,0 +1,972 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	shardRevisionSeparator = ";"
+	shardNameSeparator     = "="
+)
+
+// NewShardingDatastoreProxy creates a proxy which stores the relationships of each resource type
+// in one of several underlying datastores, chosen by name from the namespaceShards mapping.
+// Resource types without a mapping are stored in the default shard.
+//
+// Schema is written to every shard and read from the default shard. Revisions returned by the
+// proxy are composites of a revision from each shard.
+//
+// Transactions which span shards are not atomic: each shard commits in turn, and a failure to
+// commit one shard does not roll back the shards which have already committed. Every read-write
+// transaction opens a transaction on every shard, whichever shards it writes to.
+func NewShardingDatastoreProxy(
+	shards map[string]datastore.Datastore,
+	defaultShard string,
+	namespaceShards map[string]string,
+) (datastore.Datastore, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("sharding proxy requires at least one shard")
+	}
+
+	names := make([]string, 0, len(shards))
+	for name := range shards {
+		if name == "" || strings.ContainsAny(name, shardRevisionSeparator+shardNameSeparator) {
+			return nil, fmt.Errorf("invalid shard name `%s`", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	indexes := make(map[string]int, len(names))
+	delegates := make([]datastore.Datastore, 0, len(names))
+	for i, name := range names {
+		indexes[name] = i
+		delegates = append(delegates, shards[name])
+	}
+
+	defaultIndex, ok := indexes[defaultShard]
+	if !ok {
+		return nil, fmt.Errorf("unknown default shard `%s`", defaultShard)
+	}
+
+	namespaceIndexes := make(map[string]int, len(namespaceShards))
+	for namespace, shard := range namespaceShards {
+		index, ok := indexes[shard]
+		if !ok {
+			return nil, fmt.Errorf("unknown shard `%s` for namespace `%s`", shard, namespace)
+		}
+		namespaceIndexes[namespace] = index
+	}
+
+	return &shardingProxy{
+		names:           names,
+		indexes:         indexes,
+		shards:          delegates,
+		defaultShard:    defaultIndex,
+		namespaceShards: namespaceIndexes,
+	}, nil
+}
+
+type shardingProxy struct {
+	names           []string
+	indexes         map[string]int
+	shards          []datastore.Datastore
+	defaultShard    int
+	namespaceShards map[string]int
+}
+
+func (p *shardingProxy) shardFor(resourceType string) int {
+	if index, ok := p.namespaceShards[resourceType]; ok {
+		return index
+	}
+	return p.defaultShard
+}
+
+func (p *shardingProxy) revision(revisions []datastore.Revision) shardedRevision {
+	return shardedRevision{names: p.names, revisions: revisions}
+}
+
+// shardedRevision returns the revision as a composite of the revisions of the shards, or an
+// invalid revision error if it is not one.
+func (p *shardingProxy) shardedRevision(revision datastore.Revision) (shardedRevision, error) {
+	sr, ok := revision.(shardedRevision)
+	if !ok || len(sr.revisions) != len(p.shards) {
+		return shardedRevision{}, datastore.NewInvalidRevisionErr(revision, datastore.CouldNotDetermineRevision)
+	}
+	return sr, nil
+}
+
+// forEachShard runs the function against all shards concurrently, collecting a revision from each.
+func (p *shardingProxy) forEachShard(
+	ctx context.Context,
+	f func(ctx context.Context, shard datastore.Datastore) (datastore.Revision, error),
+) (datastore.Revision, error) {
+	revisions := make([]datastore.Revision, len(p.shards))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, shard := range p.shards {
+		i, shard := i, shard
+		g.Go(func() error {
+			rev, err := f(gctx, shard)
+			if err != nil {
+				return fmt.Errorf("shard `%s`: %w", p.names[i], err)
+			}
+			revisions[i] = rev
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return datastore.NoRevision, err
+	}
+
+	return p.revision(revisions), nil
+}
+
+func (p *shardingProxy) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	sr, err := p.shardedRevision(rev)
+	if err != nil {
+		return &failingReader{err}
+	}
+
+	readers := make([]datastore.Reader, 0, len(p.shards))
+	for i, shard := range p.shards {
+		readers = append(readers, shard.SnapshotReader(sr.revisions[i], opts...))
+	}
+
+	return &shardingReader{p, readers}
+}
+
+// ReadWriteTx nests a transaction on each shard within the transaction on the previous shard, so
+// that the user function runs with a transaction open on every shard and is retried whenever any
+// of the shards retries.
+//
+// NOTE: a transaction is opened and committed on every shard, even those the user function does
+// not touch, so each write costs a transaction per shard and is retried on conflicts in any of
+// them.
+func (p *shardingProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	revisions := make([]datastore.Revision, len(p.shards))
+	rwts := make([]datastore.ReadWriteTransaction, len(p.shards))
+
+	var nest func(i int) error
+	nest = func(i int) error {
+		if i == len(p.shards) {
+			readers := make([]datastore.Reader, 0, len(rwts))
+			for _, rwt := range rwts {
+				readers = append(readers, rwt)
+			}
+			return f(&shardingRWT{&shardingReader{p, readers}, rwts})
+		}
+
+		rev, err := p.shards[i].ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			rwts[i] = rwt
+			return nest(i + 1)
+		}, opts...)
+		if err != nil {
+			return err
+		}
+
+		revisions[i] = rev
+		return nil
+	}
+
+	if err := nest(0); err != nil {
+		return datastore.NoRevision, err
+	}
+
+	return p.revision(revisions), nil
+}
+
+// BulkLoad loads into all shards concurrently, distributing the relationships read from the
+// source to the shard responsible for each.
+func (p *shardingProxy) BulkLoad(ctx context.Context, source datastore.BulkWriteRelationshipSource) (datastore.Revision, error) {
+	sources := make([]*shardBulkLoadSource, 0, len(p.shards))
+	for range p.shards {
+		sources = append(sources, &shardBulkLoadSource{make(chan *core.RelationTuple)})
+	}
+
+	revisions := make([]datastore.Revision, len(p.shards))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, shard := range p.shards {
+		i, shard := i, shard
+		g.Go(func() error {
+			rev, err := shard.BulkLoad(gctx, sources[i])
+			if err != nil {
+				return fmt.Errorf("shard `%s`: %w", p.names[i], err)
+			}
+			revisions[i] = rev
+			return nil
+		})
+	}
+
+	g.Go(func() error {
+		for {
+			tpl, err := source.Next(gctx)
+			if err != nil {
+				return err
+			}
+
+			if tpl == nil {
+				// The shard sources are only closed once the source has been fully read, so
+				// that an error never results in a shard committing a partial load.
+				for _, shardSource := range sources {
+					close(shardSource.tuples)
+				}
+				return nil
+			}
+
+			select {
+			case sources[p.shardFor(tpl.ResourceAndRelation.Namespace)].tuples <- tpl:
+			case <-gctx.Done():
+				return gctx.Err()
+			}
+		}
+	})
+
+	if err := g.Wait(); err != nil {
+		return datastore.NoRevision, err
+	}
+
+	return p.revision(revisions), nil
+}
+
+type shardBulkLoadSource struct {
+	tuples chan *core.RelationTuple
+}
+
+func (s *shardBulkLoadSource) Next(ctx context.Context) (*core.RelationTuple, error) {
+	select {
+	case tpl := <-s.tuples:
+		return tpl, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *shardingProxy) OptimizedRevision(ctx context.Context) (datastore.Revision, error) {
+	return p.forEachShard(ctx, func(ctx context.Context, shard datastore.Datastore) (datastore.Revision, error) {
+		return shard.OptimizedRevision(ctx)
+	})
+}
+
+func (p *shardingProxy) HeadRevision(ctx context.Context) (datastore.Revision, error) {
+	return p.forEachShard(ctx, func(ctx context.Context, shard datastore.Datastore) (datastore.Revision, error) {
+		return shard.HeadRevision(ctx)
+	})
+}
+
+func (p *shardingProxy) CheckRevision(ctx context.Context, revision datastore.Revision) error {
+	sr, err := p.shardedRevision(revision)
+	if err != nil {
+		return err
+	}
+
+	for i, shard := range p.shards {
+		if err := shard.CheckRevision(ctx, sr.revisions[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *shardingProxy) RevisionFromString(serialized string) (datastore.Revision, error) {
+	revisions := make([]datastore.Revision, len(p.shards))
+	for _, part := range strings.Split(serialized, shardRevisionSeparator) {
+		name, shardRevision, ok := strings.Cut(part, shardNameSeparator)
+		if !ok {
+			return datastore.NoRevision, fmt.Errorf("malformed sharded revision `%s`", serialized)
+		}
+
+		index, ok := p.indexes[name]
+		if !ok {
+			return datastore.NoRevision, fmt.Errorf("unknown shard `%s` in revision `%s`", name, serialized)
+		}
+
+		if revisions[index] != nil {
+			return datastore.NoRevision, fmt.Errorf("duplicate shard `%s` in revision `%s`", name, serialized)
+		}
+
+		rev, err := p.shards[index].RevisionFromString(shardRevision)
+		if err != nil {
+			return datastore.NoRevision, err
+		}
+		revisions[index] = rev
+	}
+
+	for i, rev := range revisions {
+		if rev == nil {
+			return datastore.NoRevision, fmt.Errorf("missing shard `%s` in revision `%s`", p.names[i], serialized)
+		}
+	}
+
+	return p.revision(revisions), nil
+}
+
+// Watch merges the changes from all shards into a single stream. Each change is reported at the
+// composite of the change's revision in its own shard and the latest revision seen for every
+// other shard. Checkpoints from each shard are sent as checkpoints at the composite revision,
+// as all changes before the latest revision seen for each shard have already been sent.
+func (p *shardingProxy) Watch(ctx context.Context, afterRevision datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
+	after, err := p.shardedRevision(afterRevision)
+	if err != nil {
+		updates := make(chan *datastore.RevisionChanges)
+		errs := make(chan error, 1)
+		errs <- err
+		close(updates)
+		close(errs)
+		return updates, errs
+	}
+
+	type shardChanges struct {
+		shard   int
+		changes *datastore.RevisionChanges
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	merged := make(chan shardChanges)
+	shardErrs := make(chan error, len(p.shards))
+
+	for i, shard := range p.shards {
+		i := i
+		changes, errs := shard.Watch(ctx, after.revisions[i])
+		go func() {
+			for {
+				select {
+				case change, ok := <-changes:
+					if !ok {
+						// Wait for the error which explains why the watch ended.
+						changes = nil
+						continue
+					}
+
+					select {
+					case merged <- shardChanges{i, change}:
+					case <-ctx.Done():
+						return
+					}
+
+				case err, ok := <-errs:
+					if ok {
+						shardErrs <- err
+					}
+					return
+				}
+			}
+		}()
+	}
+
+	updates := make(chan *datastore.RevisionChanges)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(updates)
+		defer close(errs)
+		defer cancel()
+
+		current := make([]datastore.Revision, len(after.revisions))
+		copy(current, after.revisions)
+
+		for {
+			select {
+			case sc := <-merged:
+				current[sc.shard] = sc.changes.Revision
+
+				revisions := make([]datastore.Revision, len(current))
+				copy(revisions, current)
+
+				select {
+				case updates <- &datastore.RevisionChanges{
+					Revision:     p.revision(revisions),
+					Changes:      sc.changes.Changes,
+					IsCheckpoint: sc.changes.IsCheckpoint,
+				}:
+				case <-ctx.Done():
+					errs <- datastore.NewWatchCanceledErr()
+					return
+				}
+
+			case err := <-shardErrs:
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	return updates, errs
+}
+
+func (p *shardingProxy) IsReady(ctx context.Context) (bool, error) {
+	for _, shard := range p.shards {
+		ready, err := shard.IsReady(ctx)
+		if err != nil || !ready {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Features reports each feature as enabled only if it is enabled for every shard.
+func (p *shardingProxy) Features(ctx context.Context) (*datastore.Features, error) {
+	features := &datastore.Features{
+		Watch:                  datastore.Feature{Enabled: true},
+		WatchCheckpoints:       datastore.Feature{Enabled: true},
+		Caveats:                datastore.Feature{Enabled: true},
+		BulkLoad:               datastore.Feature{Enabled: true},
+		ReverseQuerySort:       datastore.Feature{Enabled: true},
+		RelationshipExpiration: datastore.Feature{Enabled: true},
+	}
+	for i, shard := range p.shards {
+		shardFeatures, err := shard.Features(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, feature := range []struct {
+			name    string
+			feature *datastore.Feature
+			shard   datastore.Feature
+		}{
+			{"watch", &features.Watch, shardFeatures.Watch},
+			{"watch checkpoints", &features.WatchCheckpoints, shardFeatures.WatchCheckpoints},
+			{"caveats", &features.Caveats, shardFeatures.Caveats},
+			{"bulk load", &features.BulkLoad, shardFeatures.BulkLoad},
+			{"reverse query sort", &features.ReverseQuerySort, shardFeatures.ReverseQuerySort},
+			{"relationship expiration", &features.RelationshipExpiration, shardFeatures.RelationshipExpiration},
+		} {
+			if feature.feature.Enabled && !feature.shard.Enabled {
+				*feature.feature = datastore.Feature{
+					Enabled: false,
+					Reason:  fmt.Sprintf("%s is not enabled for shard `%s`: %s", feature.name, p.names[i], feature.shard.Reason),
+				}
+			}
+		}
+	}
+	return features, nil
+}
+
+// Statistics reports the identity and schema statistics of the default shard, and the number of
+// relationships across all shards.
+func (p *shardingProxy) Statistics(ctx context.Context) (datastore.Stats, error) {
+	stats, err := p.shards[p.defaultShard].Statistics(ctx)
+	if err != nil {
+		return datastore.Stats{}, err
+	}
+
+	// Relationships are summed across shards, while the schema is read from the default shard.
+	// Subjects may be referenced from more than one shard, so the distinct subject estimate
+	// is an upper bound.
+	stats.EstimatedRelationshipCount = 0
+	stats.EstimatedDistinctResources = 0
+	stats.EstimatedDistinctSubjects = 0
+	stats.EstimatedStorageBytes = 0
+
+	relationshipEstimates := make(map[string]uint64, len(stats.ObjectTypeStatistics))
+	for _, shard := range p.shards {
+		shardStats, err := shard.Statistics(ctx)
+		if err != nil {
+			return datastore.Stats{}, err
+		}
+		stats.EstimatedRelationshipCount += shardStats.EstimatedRelationshipCount
+		stats.EstimatedDistinctResources += shardStats.EstimatedDistinctResources
+		stats.EstimatedDistinctSubjects += shardStats.EstimatedDistinctSubjects
+		stats.EstimatedStorageBytes += shardStats.EstimatedStorageBytes
+
+		for _, objTypeStats := range shardStats.ObjectTypeStatistics {
+			relationshipEstimates[objTypeStats.Name] += objTypeStats.EstimatedRelationshipCount
+		}
+	}
+
+	for i := range stats.ObjectTypeStatistics {
+		stats.ObjectTypeStatistics[i].EstimatedRelationshipCount = relationshipEstimates[stats.ObjectTypeStatistics[i].Name]
+	}
+
+	return stats, nil
+}
+
+func (p *shardingProxy) Close() error {
+	var closeErr error
+	for _, shard := range p.shards {
+		if err := shard.Close(); err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	return closeErr
+}
+
+// failingReader is the reader at a revision which could not be read, returning the error for
+// every read.
+type failingReader struct {
+	err error
+}
+
+func (r *failingReader) ReadCaveatByName(context.Context, string) (*core.CaveatDefinition, datastore.Revision, error) {
+	return nil, datastore.NoRevision, r.err
+}
+
+func (r *failingReader) ListCaveats(context.Context, ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	return nil, r.err
+}
+
+func (r *failingReader) LookupCaveats(context.Context, []string) ([]*core.CaveatDefinition, error) {
+	return nil, r.err
+}
+
+func (r *failingReader) ReadNamespace(context.Context, string) (*core.NamespaceDefinition, datastore.Revision, error) {
+	return nil, datastore.NoRevision, r.err
+}
+
+func (r *failingReader) ListNamespaces(context.Context, ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	return nil, r.err
+}
+
+func (r *failingReader) LookupNamespaces(context.Context, []string) ([]*core.NamespaceDefinition, error) {
+	return nil, r.err
+}
+
+func (r *failingReader) QueryRelationships(context.Context, datastore.RelationshipsFilter, ...options.QueryOptionsOption) (datastore.RelationshipIterator, error) {
+	return nil, r.err
+}
+
+func (r *failingReader) ReverseQueryRelationships(context.Context, datastore.SubjectsFilter, ...options.ReverseQueryOptionsOption) (datastore.RelationshipIterator, error) {
+	return nil, r.err
+}
+
+func (r *failingReader) CountRelationships(context.Context, datastore.RelationshipsFilter) (uint64, error) {
+	return 0, r.err
+}
+
+func (r *failingReader) ListResourceTypes(context.Context) ([]string, error) {
+	return nil, r.err
+}
+
+type shardingReader struct {
+	p       *shardingProxy
+	readers []datastore.Reader
+}
+
+func (r *shardingReader) schemaReader() datastore.Reader {
+	return r.readers[r.p.defaultShard]
+}
+
+func (r *shardingReader) ReadCaveatByName(ctx context.Context, name string) (*core.CaveatDefinition, datastore.Revision, error) {
+	return r.schemaReader().ReadCaveatByName(ctx, name)
+}
+
+func (r *shardingReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	return r.schemaReader().ListCaveats(ctx, opts...)
+}
+
+func (r *shardingReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	return r.schemaReader().LookupCaveats(ctx, caveatNames)
+}
+
+func (r *shardingReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
+	return r.schemaReader().ReadNamespace(ctx, nsName)
+}
+
+func (r *shardingReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	return r.schemaReader().ListNamespaces(ctx, opts...)
+}
+
+func (r *shardingReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
+	return r.schemaReader().LookupNamespaces(ctx, nsNames)
+}
+
+func (r *shardingReader) QueryRelationships(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	return r.readers[r.p.shardFor(filter.ResourceType)].QueryRelationships(ctx, filter, opts...)
+}
+
+func (r *shardingReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	return r.readers[r.p.shardFor(filter.ResourceType)].CountRelationships(ctx, filter)
+}
+
+// ListResourceTypes lists the resource types of every shard, as relationships may have been
+// written to a shard other than the one their resource type is now mapped to.
+func (r *shardingReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	found := map[string]struct{}{}
+	for _, reader := range r.readers {
+		resourceTypes, err := reader.ListResourceTypes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, resourceType := range resourceTypes {
+			found[resourceType] = struct{}{}
+		}
+	}
+
+	resourceTypes := make([]string, 0, len(found))
+	for resourceType := range found {
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	sort.Strings(resourceTypes)
+	return resourceTypes, nil
+}
+
+// ReverseQueryRelationships queries only the shard for the resource type, if one was given, and
+// otherwise queries each shard in turn. Sorted queries are made to every shard at once and their
+// results merged.
+func (r *shardingReader) ReverseQueryRelationships(
+	ctx context.Context,
+	subjectsFilter datastore.SubjectsFilter,
+	opts ...options.ReverseQueryOptionsOption,
+) (datastore.RelationshipIterator, error) {
+	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
+	if queryOpts.ResRelation != nil {
+		return r.readers[r.p.shardFor(queryOpts.ResRelation.Namespace)].ReverseQueryRelationships(ctx, subjectsFilter, opts...)
+	}
+
+	if queryOpts.ReverseSort == options.BySubject {
+		iters := make([]datastore.RelationshipIterator, 0, len(r.readers))
+		for _, reader := range r.readers {
+			iter, err := reader.ReverseQueryRelationships(ctx, subjectsFilter, opts...)
+			if err != nil {
+				for _, opened := range iters {
+					opened.Close()
+				}
+				return nil, err
+			}
+			iters = append(iters, iter)
+		}
+
+		return &mergedRelationshipIterator{iters: iters, remaining: queryOpts.ReverseLimit}, nil
+	}
+
+	return &shardedRelationshipIterator{
+		ctx:       ctx,
+		readers:   r.readers,
+		remaining: queryOpts.ReverseLimit,
+		query: func(ctx context.Context, reader datastore.Reader, limit *uint64) (datastore.RelationshipIterator, error) {
+			return reader.ReverseQueryRelationships(ctx, subjectsFilter, options.WithReverseLimit(limit))
+		},
+	}, nil
+}
+
+// shardedRelationshipIterator lazily queries each shard in turn, stopping once the limit, if
+// any, has been reached.
+type shardedRelationshipIterator struct {
+	ctx       context.Context
+	readers   []datastore.Reader
+	remaining *uint64
+	query     func(ctx context.Context, reader datastore.Reader, limit *uint64) (datastore.RelationshipIterator, error)
+
+	current datastore.RelationshipIterator
+	err     error
+	closed  bool
+}
+
+func (it *shardedRelationshipIterator) Next() *core.RelationTuple {
+	if it.closed {
+		it.err = errors.New("unable to iterate: iterator closed")
+		return nil
+	}
+
+	for it.err == nil {
+		if it.remaining != nil && *it.remaining == 0 {
+			return nil
+		}
+
+		if it.current == nil {
+			if len(it.readers) == 0 {
+				return nil
+			}
+
+			it.current, it.err = it.query(it.ctx, it.readers[0], it.remaining)
+			it.readers = it.readers[1:]
+			continue
+		}
+
+		if tpl := it.current.Next(); tpl != nil {
+			if it.remaining != nil {
+				remaining := *it.remaining - 1
+				it.remaining = &remaining
+			}
+			return tpl
+		}
+
+		it.err = it.current.Err()
+		it.current.Close()
+		it.current = nil
+	}
+
+	return nil
+}
+
+func (it *shardedRelationshipIterator) Err() error {
+	return it.err
+}
+
+func (it *shardedRelationshipIterator) Close() {
+	if it.closed {
+		panic("tuple iterator double closed")
+	}
+
+	if it.current != nil {
+		it.current.Close()
+		it.current = nil
+	}
+	it.closed = true
+}
+
+// mergedRelationshipIterator merges the results of queries sorted options.BySubject, stopping
+// once the limit, if any, has been reached. The shards are assumed to sort strings bytewise.
+type mergedRelationshipIterator struct {
+	iters     []datastore.RelationshipIterator
+	heads     []*core.RelationTuple
+	remaining *uint64
+
+	err    error
+	closed bool
+}
+
+func (it *mergedRelationshipIterator) Next() *core.RelationTuple {
+	if it.closed {
+		it.err = errors.New("unable to iterate: iterator closed")
+		return nil
+	}
+
+	if it.err != nil || (it.remaining != nil && *it.remaining == 0) {
+		return nil
+	}
+
+	if it.heads == nil {
+		it.heads = make([]*core.RelationTuple, len(it.iters))
+		for i := range it.iters {
+			if !it.advance(i) {
+				return nil
+			}
+		}
+	}
+
+	next := -1
+	for i, head := range it.heads {
+		if head != nil && (next < 0 || lessBySubject(head, it.heads[next])) {
+			next = i
+		}
+	}
+	if next < 0 {
+		return nil
+	}
+
+	tpl := it.heads[next]
+	if !it.advance(next) {
+		return nil
+	}
+
+	if it.remaining != nil {
+		remaining := *it.remaining - 1
+		it.remaining = &remaining
+	}
+	return tpl
+}
+
+func (it *mergedRelationshipIterator) advance(index int) bool {
+	it.heads[index] = it.iters[index].Next()
+	it.err = it.iters[index].Err()
+	return it.err == nil
+}
+
+func (it *mergedRelationshipIterator) Err() error {
+	return it.err
+}
+
+func (it *mergedRelationshipIterator) Close() {
+	if it.closed {
+		panic("tuple iterator double closed")
+	}
+
+	for _, iter := range it.iters {
+		iter.Close()
+	}
+	it.closed = true
+}
+
+func lessBySubject(first, second *core.RelationTuple) bool {
+	firstKey := [...]string{
+		first.Subject.Namespace, first.Subject.ObjectId, first.Subject.Relation,
+		first.ResourceAndRelation.Namespace, first.ResourceAndRelation.ObjectId, first.ResourceAndRelation.Relation,
+	}
+	secondKey := [...]string{
+		second.Subject.Namespace, second.Subject.ObjectId, second.Subject.Relation,
+		second.ResourceAndRelation.Namespace, second.ResourceAndRelation.ObjectId, second.ResourceAndRelation.Relation,
+	}
+
+	for i := range firstKey {
+		if firstKey[i] != secondKey[i] {
+			return firstKey[i] < secondKey[i]
+		}
+	}
+	return false
+}
+
+type shardingRWT struct {
+	*shardingReader
+	rwts []datastore.ReadWriteTransaction
+}
+
+func (rwt *shardingRWT) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	byShard := make([][]*core.RelationTupleUpdate, len(rwt.rwts))
+	for _, mutation := range mutations {
+		index := rwt.p.shardFor(mutation.Tuple.ResourceAndRelation.Namespace)
+		byShard[index] = append(byShard[index], mutation)
+	}
+
+	for index, shardMutations := range byShard {
+		if len(shardMutations) == 0 {
+			continue
+		}
+
+		if err := rwt.rwts[index].WriteRelationships(ctx, shardMutations); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (rwt *shardingRWT) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
+	return rwt.rwts[rwt.p.shardFor(filter.ResourceType)].DeleteRelationships(ctx, filter)
+}
+
+func (rwt *shardingRWT) WriteNamespaces(ctx context.Context, newConfigs ...*core.NamespaceDefinition) error {
+	for _, shardRWT := range rwt.rwts {
+		if err := shardRWT.WriteNamespaces(ctx, newConfigs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteNamespaces deletes the namespaces, along with their relationships, from every shard.
+func (rwt *shardingRWT) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+	for _, shardRWT := range rwt.rwts {
+		if err := shardRWT.DeleteNamespaces(ctx, nsNames...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rwt *shardingRWT) WriteCaveats(ctx context.Context, caveats []*core.CaveatDefinition) error {
+	for _, shardRWT := range rwt.rwts {
+		if err := shardRWT.WriteCaveats(ctx, caveats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rwt *shardingRWT) DeleteCaveats(ctx context.Context, names []string) error {
+	for _, shardRWT := range rwt.rwts {
+		if err := shardRWT.DeleteCaveats(ctx, names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shardedRevision is a composite of a revision from each shard, in shard name order.
+type shardedRevision struct {
+	names     []string
+	revisions []datastore.Revision
+}
+
+func (sr shardedRevision) String() string {
+	parts := make([]string, 0, len(sr.revisions))
+	for i, rev := range sr.revisions {
+		parts = append(parts, sr.names[i]+shardNameSeparator+rev.String())
+	}
+	return strings.Join(parts, shardRevisionSeparator)
+}
+
+func (sr shardedRevision) MarshalBinary() ([]byte, error) {
+	return []byte(sr.String()), nil
+}
+
+func (sr shardedRevision) Equal(rhsRaw datastore.Revision) bool {
+	rhs, ok := sr.comparable(rhsRaw)
+	if !ok {
+		return false
+	}
+
+	for i, rev := range sr.revisions {
+		if !rev.Equal(rhs.revisions[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// GreaterThan returns whether the revision is provably greater than the right hand side, which
+// requires it to be greater in at least one shard and not less in any.
+func (sr shardedRevision) GreaterThan(rhsRaw datastore.Revision) bool {
+	if rhsRaw == datastore.NoRevision {
+		return true
+	}
+
+	rhs, ok := sr.comparable(rhsRaw)
+	if !ok {
+		return false
+	}
+
+	greater := false
+	for i, rev := range sr.revisions {
+		switch {
+		case rev.GreaterThan(rhs.revisions[i]):
+			greater = true
+		case !rev.Equal(rhs.revisions[i]):
+			return false
+		}
+	}
+	return greater
+}
+
+// LessThan returns whether the revision is provably less than the right hand side, which
+// requires it to be less in at least one shard and not greater in any.
+func (sr shardedRevision) LessThan(rhsRaw datastore.Revision) bool {
+	rhs, ok := sr.comparable(rhsRaw)
+	if !ok {
+		return false
+	}
+
+	less := false
+	for i, rev := range sr.revisions {
+		switch {
+		case rev.LessThan(rhs.revisions[i]):
+			less = true
+		case !rev.Equal(rhs.revisions[i]):
+			return false
+		}
+	}
+	return less
+}
+
+func (sr shardedRevision) comparable(rhsRaw datastore.Revision) (shardedRevision, bool) {
+	rhs, ok := rhsRaw.(shardedRevision)
+	return rhs, ok && len(rhs.revisions) == len(sr.revisions)
+}
+
+var (
+	_ datastore.Datastore            = (*shardingProxy)(nil)
+	_ datastore.Reader               = (*shardingReader)(nil)
+	_ datastore.Reader               = (*failingReader)(nil)
+	_ datastore.ReadWriteTransaction = (*shardingRWT)(nil)
+	_ datastore.RelationshipIterator = (*shardedRelationshipIterator)(nil)
+	_ datastore.Revision             = shardedRevision{}
+)
@@ -0,0 +1,289 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func newShardedTestDatastore(t *testing.T) (datastore.Datastore, map[string]datastore.Datastore) {
+	require := require.New(t)
+
+	shards := make(map[string]datastore.Datastore, 2)
+	for _, name := range []string{"documents", "other"} {
+		shard, err := memdb.NewMemdbDatastore(16, 0, memdb.DisableGC)
+		require.NoError(err)
+		shards[name] = shard
+	}
+
+	sharded, err := NewShardingDatastoreProxy(shards, "other", map[string]string{"document": "documents"})
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithSchema(sharded, require)
+	return ds, shards
+}
+
+func readShardTuples(t *testing.T, shard datastore.Datastore, resourceType string) []string {
+	ctx := context.Background()
+
+	rev, err := shard.HeadRevision(ctx)
+	require.NoError(t, err)
+
+	iter, err := shard.SnapshotReader(rev).QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: resourceType})
+	require.NoError(t, err)
+	defer iter.Close()
+
+	var found []string
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		found = append(found, tuple.String(tpl))
+	}
+	require.NoError(t, iter.Err())
+	return found
+}
+
+func TestShardingProxyRoutesRelationships(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds, shards := newShardedTestDatastore(t)
+
+	doc := tuple.MustParse("document:firstdoc#viewer@user:tom")
+	folder := tuple.MustParse("folder:somefolder#viewer@user:tom")
+	rev, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, doc, folder)
+	require.NoError(err)
+
+	require.Equal([]string{tuple.String(doc)}, readShardTuples(t, shards["documents"], "document"))
+	require.Empty(readShardTuples(t, shards["documents"], "folder"))
+	require.Equal([]string{tuple.String(folder)}, readShardTuples(t, shards["other"], "folder"))
+	require.Empty(readShardTuples(t, shards["other"], "document"))
+
+	// Schema is written to every shard.
+	for _, shard := range shards {
+		headRev, err := shard.HeadRevision(ctx)
+		require.NoError(err)
+		_, _, err = shard.SnapshotReader(headRev).ReadNamespace(ctx, "document")
+		require.NoError(err)
+	}
+
+	reader := ds.SnapshotReader(rev)
+	count, err := reader.CountRelationships(ctx, datastore.RelationshipsFilter{ResourceType: "document"})
+	require.NoError(err)
+	require.Equal(uint64(1), count)
+
+	// Reverse queries without a resource type are made against every shard.
+	iter, err := reader.ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+		SubjectType:        "user",
+		OptionalSubjectIds: []string{"tom"},
+	})
+	require.NoError(err)
+
+	var found []string
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		found = append(found, tuple.String(tpl))
+	}
+	require.NoError(iter.Err())
+	iter.Close()
+	require.ElementsMatch([]string{tuple.String(doc), tuple.String(folder)}, found)
+
+	// The limit applies across all shards.
+	iter, err = reader.ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+		SubjectType:        "user",
+		OptionalSubjectIds: []string{"tom"},
+	}, options.WithReverseLimit(options.LimitOne))
+	require.NoError(err)
+	require.NotNil(iter.Next())
+	require.Nil(iter.Next())
+	require.NoError(iter.Err())
+	iter.Close()
+
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.DeleteRelationships(ctx, &v1.RelationshipFilter{ResourceType: "document"})
+	})
+	require.NoError(err)
+	require.Empty(readShardTuples(t, shards["documents"], "document"))
+	require.Equal([]string{tuple.String(folder)}, readShardTuples(t, shards["other"], "folder"))
+}
+
+func TestShardingProxySortedReverseQuery(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds, _ := newShardedTestDatastore(t)
+
+	expected := []*core.RelationTuple{
+		tuple.MustParse("folder:somefolder#viewer@user:alice"),
+		tuple.MustParse("document:seconddoc#viewer@user:bob"),
+		tuple.MustParse("document:firstdoc#viewer@user:tom"),
+		tuple.MustParse("folder:somefolder#viewer@user:tom"),
+	}
+	rev, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, expected...)
+	require.NoError(err)
+
+	// Sorted results are merged across the shards, and can be paged through with a cursor.
+	limit := uint64(3)
+	var found []string
+	var cursor options.Cursor
+	for page := 0; page < 3; page++ {
+		iter, err := ds.SnapshotReader(rev).ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+			SubjectType: "user",
+		}, options.WithReverseSort(options.BySubject), options.WithReverseAfter(cursor), options.WithReverseLimit(&limit))
+		require.NoError(err)
+
+		for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+			found = append(found, tuple.String(tpl))
+			cursor = tpl
+		}
+		require.NoError(iter.Err())
+		iter.Close()
+	}
+
+	expectedStrings := make([]string, 0, len(expected))
+	for _, tpl := range expected {
+		expectedStrings = append(expectedStrings, tuple.String(tpl))
+	}
+	require.Equal(expectedStrings, found)
+}
+
+func TestShardingProxyBulkLoad(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds, shards := newShardedTestDatastore(t)
+
+	doc := tuple.MustParse("document:firstdoc#viewer@user:tom")
+	folder := tuple.MustParse("folder:somefolder#viewer@user:tom")
+	_, err := ds.BulkLoad(ctx, common.SliceBulkLoadSource([]*core.RelationTuple{doc, folder}))
+	require.NoError(err)
+
+	require.Equal([]string{tuple.String(doc)}, readShardTuples(t, shards["documents"], "document"))
+	require.Equal([]string{tuple.String(folder)}, readShardTuples(t, shards["other"], "folder"))
+}
+
+func TestShardedRevision(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds, _ := newShardedTestDatastore(t)
+
+	before, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+
+	after, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tuple.MustParse("document:firstdoc#viewer@user:tom"))
+	require.NoError(err)
+
+	require.True(after.GreaterThan(before))
+	require.True(before.LessThan(after))
+	require.False(after.Equal(before))
+	require.True(after.GreaterThan(datastore.NoRevision))
+
+	parsed, err := ds.RevisionFromString(after.String())
+	require.NoError(err)
+	require.True(parsed.Equal(after))
+	require.NoError(ds.CheckRevision(ctx, parsed))
+
+	for _, invalid := range []string{"", "documents=1", "documents=1;other=1;other=2", "documents=1;unknown=1"} {
+		_, err := ds.RevisionFromString(invalid)
+		require.Error(err, invalid)
+	}
+}
+
+func TestShardingProxyInvalidRevisions(t *testing.T) {
+	ctx := context.Background()
+
+	ds, shards := newShardedTestDatastore(t)
+
+	shardRevision, err := shards["documents"].HeadRevision(ctx)
+	require.NoError(t, err)
+
+	for _, invalid := range []datastore.Revision{datastore.NoRevision, shardRevision} {
+		require.ErrorAs(t, ds.CheckRevision(ctx, invalid), &datastore.ErrInvalidRevision{})
+
+		_, _, err := ds.SnapshotReader(invalid).ReadNamespace(ctx, "document")
+		require.ErrorAs(t, err, &datastore.ErrInvalidRevision{})
+
+		_, err = ds.SnapshotReader(invalid).QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: "document"})
+		require.ErrorAs(t, err, &datastore.ErrInvalidRevision{})
+
+		changes, errs := ds.Watch(ctx, invalid)
+		require.ErrorAs(t, <-errs, &datastore.ErrInvalidRevision{})
+		_, ok := <-changes
+		require.False(t, ok)
+	}
+}
+
+func TestShardingProxyWatch(t *testing.T) {
+	require := require.New(t)
+
+	ds, _ := newShardedTestDatastore(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+
+	changes, errs := ds.Watch(ctx, start)
+
+	doc := tuple.MustParse("document:firstdoc#viewer@user:tom")
+	folder := tuple.MustParse("folder:somefolder#viewer@user:tom")
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, doc, folder)
+	require.NoError(err)
+
+	var received []string
+	var last datastore.Revision = start
+	for len(received) < 2 {
+		select {
+		case change := <-changes:
+			if change.IsCheckpoint {
+				continue
+			}
+
+			require.True(change.Revision.GreaterThan(last))
+			last = change.Revision
+			for _, update := range change.Changes {
+				received = append(received, tuple.String(update.Tuple))
+			}
+		case err := <-errs:
+			require.FailNow("unexpected watch error", err)
+		case <-time.After(5 * time.Second):
+			require.FailNow("timed out waiting for changes")
+		}
+	}
+	require.ElementsMatch([]string{tuple.String(doc), tuple.String(folder)}, received)
+
+	cancel()
+	select {
+	case err := <-errs:
+		require.Error(err)
+	case <-time.After(5 * time.Second):
+		require.FailNow("timed out waiting for watch to be canceled")
+	}
+}
+
+func TestNewShardingDatastoreProxyValidation(t *testing.T) {
+	shard, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	_, err = NewShardingDatastoreProxy(nil, "default", nil)
+	require.Error(t, err)
+
+	_, err = NewShardingDatastoreProxy(map[string]datastore.Datastore{"default": shard}, "missing", nil)
+	require.Error(t, err)
+
+	_, err = NewShardingDatastoreProxy(map[string]datastore.Datastore{"default": shard}, "default", map[string]string{"document": "missing"})
+	require.Error(t, err)
+
+	_, err = NewShardingDatastoreProxy(map[string]datastore.Datastore{"bad;name": shard}, "bad;name", nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,355 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+var writeBatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+	Namespace: "spicedb",
+	Subsystem: "datastore",
+	Name:      "write_batch_size",
+	Help:      "number of read-write transactions combined into each batched write",
+	Buckets:   []float64{1, 2, 5, 10, 25, 50, 100, 250},
+})
+
+var errNotBatchable = errors.New("transaction cannot be batched")
+
+// NewWriteBatchingProxy creates a proxy which combines concurrent read-write transactions that
+// only write relationships into a single transaction of the delegate datastore, reducing the
+// number of commits made under many small writes. A transaction waits at most maxDelay for
+// others to be combined with it, and a batch is written as soon as it holds maxBatchSize
+// mutations.
+//
+// The transaction function is first run against a transaction which records its writes; if it
+// does anything other than write relationships, it is run again, unbatched, against the
+// delegate datastore. As with any datastore, it must therefore be safe to retry. Transactions
+// given options, such as metadata, are never batched.
+//
+// If a batch fails to be written, its transactions are retried individually, so that each caller
+// receives the error of its own writes.
+//
+// A caller whose context is done before its transaction is admitted into the write of a batch
+// returns the error of the context, and its writes are not made. Once admitted, the transaction
+// may be committed whatever happens to the context, so the caller waits for the result of the
+// write rather than reporting a failure for writes which were made.
+func NewWriteBatchingProxy(delegate datastore.Datastore, maxDelay time.Duration, maxBatchSize int) datastore.Datastore {
+	p := &writeBatchingProxy{
+		Datastore:    delegate,
+		maxDelay:     maxDelay,
+		maxBatchSize: maxBatchSize,
+		requests:     make(chan *batchedWrite),
+		closed:       make(chan struct{}),
+	}
+
+	p.writers.Add(1)
+	go p.collectBatches()
+
+	return p
+}
+
+type writeBatchingProxy struct {
+	datastore.Datastore
+
+	maxDelay     time.Duration
+	maxBatchSize int
+
+	requests  chan *batchedWrite
+	closed    chan struct{}
+	closeOnce sync.Once
+	writers   sync.WaitGroup
+}
+
+// batchedWrite is the relationship mutations of a single transaction, waiting to be batched.
+type batchedWrite struct {
+	ctx       context.Context
+	mutations []*core.RelationTupleUpdate
+	result    chan batchedWriteResult
+
+	// state is whether the transaction was admitted into the write of its batch or abandoned by
+	// its caller, whichever happened first.
+	state atomic.Int32
+}
+
+const (
+	batchedWritePending int32 = iota
+	batchedWriteAdmitted
+	batchedWriteAbandoned
+)
+
+// admit admits the transaction into the write of its batch, unless its caller abandoned it.
+func (bw *batchedWrite) admit() bool {
+	return bw.state.CompareAndSwap(batchedWritePending, batchedWriteAdmitted)
+}
+
+// abandon abandons the transaction, unless it was admitted into the write of its batch.
+func (bw *batchedWrite) abandon() bool {
+	return bw.state.CompareAndSwap(batchedWritePending, batchedWriteAbandoned)
+}
+
+type batchedWriteResult struct {
+	revision datastore.Revision
+	err      error
+}
+
+func (p *writeBatchingProxy) Unwrap() datastore.Datastore { return p.Datastore }
+
+func (p *writeBatchingProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	if len(opts) > 0 {
+		return p.Datastore.ReadWriteTx(ctx, f, opts...)
+	}
+
+	recording := &batchRecordingRWT{}
+	err := f(recording)
+	if recording.unbatchable || (err == nil && len(recording.mutations) == 0) {
+		return p.Datastore.ReadWriteTx(ctx, f)
+	}
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+
+	req := &batchedWrite{ctx: ctx, mutations: recording.mutations, result: make(chan batchedWriteResult, 1)}
+	select {
+	case p.requests <- req:
+	case <-p.closed:
+		return p.Datastore.ReadWriteTx(ctx, f)
+	case <-ctx.Done():
+		return datastore.NoRevision, ctx.Err()
+	}
+
+	select {
+	case result := <-req.result:
+		return result.revision, result.err
+	case <-ctx.Done():
+		if req.abandon() {
+			return datastore.NoRevision, ctx.Err()
+		}
+
+		// The transaction is being written, and may be committed.
+		result := <-req.result
+		return result.revision, result.err
+	}
+}
+
+func (p *writeBatchingProxy) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+	})
+	p.writers.Wait()
+	return p.Datastore.Close()
+}
+
+// collectBatches collects the requested writes into batches until the proxy is closed. A request
+// which writes a relationship already written by the batch being collected starts the next
+// batch, so that the order of writes to the same relationship is preserved.
+func (p *writeBatchingProxy) collectBatches() {
+	defer p.writers.Done()
+
+	var next *batchedWrite
+	for {
+		if next == nil {
+			select {
+			case next = <-p.requests:
+			case <-p.closed:
+				return
+			}
+		}
+
+		batch := []*batchedWrite{next}
+		size := len(next.mutations)
+		written := map[string]struct{}{}
+		addWritten(written, next)
+		next = nil
+
+		closed := false
+		timer := time.NewTimer(p.maxDelay)
+
+	collect:
+		for size < p.maxBatchSize {
+			select {
+			case req := <-p.requests:
+				if overlapsWritten(written, req) {
+					next = req
+					break collect
+				}
+				batch = append(batch, req)
+				size += len(req.mutations)
+				addWritten(written, req)
+			case <-timer.C:
+				break collect
+			case <-p.closed:
+				closed = true
+				break collect
+			}
+		}
+		timer.Stop()
+
+		p.writers.Add(1)
+		go p.writeBatch(batch)
+
+		if closed {
+			if next != nil {
+				p.writers.Add(1)
+				go p.writeBatch([]*batchedWrite{next})
+			}
+			return
+		}
+	}
+}
+
+func (p *writeBatchingProxy) writeBatch(batch []*batchedWrite) {
+	defer p.writers.Done()
+
+	// Transactions whose callers have gone are left out of the write, and their callers return the
+	// error of their contexts.
+	live := make([]*batchedWrite, 0, len(batch))
+	for _, req := range batch {
+		if req.ctx.Err() != nil || !req.admit() {
+			continue
+		}
+		live = append(live, req)
+	}
+	if len(live) == 0 {
+		return
+	}
+
+	writeBatchSize.Observe(float64(len(live)))
+
+	// The batch is written on behalf of all of its callers, so it must not be canceled along
+	// with the context of any one of them.
+	ctx := SeparateContextWithTracing(live[0].ctx)
+	revision, err := p.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		for _, req := range live {
+			if err := rwt.WriteRelationships(ctx, req.mutations); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil || len(live) == 1 {
+		for _, req := range live {
+			req.result <- batchedWriteResult{revision, err}
+		}
+		return
+	}
+
+	log.Ctx(ctx).Debug().Err(err).Int("transactions", len(live)).Msg("batched write failed, retrying transactions individually")
+	for _, req := range live {
+		req := req
+		revision, err := p.Datastore.ReadWriteTx(req.ctx, func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.WriteRelationships(req.ctx, req.mutations)
+		})
+		req.result <- batchedWriteResult{revision, err}
+	}
+}
+
+func mutationKey(mutation *core.RelationTupleUpdate) string {
+	return tuple.StringONR(mutation.Tuple.ResourceAndRelation) + "@" + tuple.StringONR(mutation.Tuple.Subject)
+}
+
+func addWritten(written map[string]struct{}, req *batchedWrite) {
+	for _, mutation := range req.mutations {
+		written[mutationKey(mutation)] = struct{}{}
+	}
+}
+
+func overlapsWritten(written map[string]struct{}, req *batchedWrite) bool {
+	for _, mutation := range req.mutations {
+		if _, ok := written[mutationKey(mutation)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// batchRecordingRWT records the relationship mutations of a transaction function. Any other
+// operation marks the transaction as unbatchable.
+type batchRecordingRWT struct {
+	mutations   []*core.RelationTupleUpdate
+	unbatchable bool
+}
+
+func (rwt *batchRecordingRWT) notBatchable() error {
+	rwt.unbatchable = true
+	return errNotBatchable
+}
+
+func (rwt *batchRecordingRWT) WriteRelationships(_ context.Context, mutations []*core.RelationTupleUpdate) error {
+	rwt.mutations = append(rwt.mutations, mutations...)
+	return nil
+}
+
+func (rwt *batchRecordingRWT) DeleteRelationships(context.Context, *v1.RelationshipFilter) error {
+	return rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) WriteNamespaces(context.Context, ...*core.NamespaceDefinition) error {
+	return rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) DeleteNamespaces(context.Context, ...string) error {
+	return rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) WriteCaveats(context.Context, []*core.CaveatDefinition) error {
+	return rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) DeleteCaveats(context.Context, []string) error {
+	return rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) QueryRelationships(context.Context, datastore.RelationshipsFilter, ...options.QueryOptionsOption) (datastore.RelationshipIterator, error) {
+	return nil, rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) ReverseQueryRelationships(context.Context, datastore.SubjectsFilter, ...options.ReverseQueryOptionsOption) (datastore.RelationshipIterator, error) {
+	return nil, rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) CountRelationships(context.Context, datastore.RelationshipsFilter) (uint64, error) {
+	return 0, rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) ListResourceTypes(context.Context) ([]string, error) {
+	return nil, rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) ReadNamespace(context.Context, string) (*core.NamespaceDefinition, datastore.Revision, error) {
+	return nil, datastore.NoRevision, rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) ListNamespaces(context.Context, ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	return nil, rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) LookupNamespaces(context.Context, []string) ([]*core.NamespaceDefinition, error) {
+	return nil, rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) ReadCaveatByName(context.Context, string) (*core.CaveatDefinition, datastore.Revision, error) {
+	return nil, datastore.NoRevision, rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) ListCaveats(context.Context, ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	return nil, rwt.notBatchable()
+}
+
+func (rwt *batchRecordingRWT) LookupCaveats(context.Context, []string) ([]*core.CaveatDefinition, error) {
+	return nil, rwt.notBatchable()
+}
+
+var _ datastore.ReadWriteTransaction = (*batchRecordingRWT)(nil)
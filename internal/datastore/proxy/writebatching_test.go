@@ -0,0 +1,197 @@
+package proxy
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// countingDatastore counts the read-write transactions of the delegate datastore.
+type countingDatastore struct {
+	datastore.Datastore
+
+	transactions atomic.Int32
+}
+
+func (c *countingDatastore) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	c.transactions.Add(1)
+	return c.Datastore.ReadWriteTx(ctx, f, opts...)
+}
+
+func newBatchingTestDatastore(t *testing.T, maxDelay time.Duration, maxBatchSize int) (*countingDatastore, datastore.Datastore) {
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	ds, _ := testfixtures.StandardDatastoreWithSchema(rawDS, require.New(t))
+	counting := &countingDatastore{Datastore: ds}
+	batching := NewWriteBatchingProxy(counting, maxDelay, maxBatchSize)
+	t.Cleanup(func() { require.NoError(t, batching.Close()) })
+	return counting, batching
+}
+
+// writeConcurrently writes each mutation in its own transaction, concurrently, and returns the
+// error of each.
+func writeConcurrently(ds datastore.Datastore, mutations ...*core.RelationTupleUpdate) []error {
+	errs := make([]error, len(mutations))
+
+	var wg sync.WaitGroup
+	for i, mutation := range mutations {
+		i, mutation := i, mutation
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
+				return rwt.WriteRelationships(context.Background(), []*core.RelationTupleUpdate{mutation})
+			})
+		}()
+	}
+	wg.Wait()
+
+	return errs
+}
+
+func TestWriteBatchingCombinesTransactions(t *testing.T) {
+	counting, ds := newBatchingTestDatastore(t, time.Second, 4)
+
+	errs := writeConcurrently(ds,
+		tuple.Create(tuple.MustParse("document:first#viewer@user:tom")),
+		tuple.Create(tuple.MustParse("document:second#viewer@user:tom")),
+		tuple.Create(tuple.MustParse("document:third#viewer@user:tom")),
+		tuple.Create(tuple.MustParse("document:fourth#viewer@user:tom")),
+	)
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(1), counting.transactions.Load())
+
+	rev, err := ds.HeadRevision(context.Background())
+	require.NoError(t, err)
+	count, err := ds.SnapshotReader(rev).CountRelationships(context.Background(), datastore.RelationshipsFilter{
+		ResourceType:             "document",
+		OptionalResourceRelation: "viewer",
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), count)
+}
+
+func TestWriteBatchingReturnsErrorsPerTransaction(t *testing.T) {
+	counting, ds := newBatchingTestDatastore(t, time.Second, 2)
+
+	existing := tuple.MustParse("document:existing#viewer@user:tom")
+	_, err := common.WriteTuples(context.Background(), counting.Datastore, core.RelationTupleUpdate_CREATE, existing)
+	require.NoError(t, err)
+
+	errs := writeConcurrently(ds,
+		tuple.Create(existing),
+		tuple.Create(tuple.MustParse("document:new#viewer@user:tom")),
+	)
+	require.Error(t, errs[0])
+	require.NoError(t, errs[1])
+
+	// The failed batch is followed by a transaction for each of its writes.
+	require.Equal(t, int32(3), counting.transactions.Load())
+}
+
+func TestWriteBatchingSeparatesWritesOfSameRelationship(t *testing.T) {
+	counting, ds := newBatchingTestDatastore(t, 100*time.Millisecond, 2)
+
+	tpl := tuple.MustParse("document:first#viewer@user:tom")
+	errs := writeConcurrently(ds, tuple.Touch(tpl), tuple.Touch(tpl))
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(2), counting.transactions.Load())
+}
+
+func TestWriteBatchingRunsUnbatchableTransactions(t *testing.T) {
+	counting, ds := newBatchingTestDatastore(t, time.Second, 100)
+	ctx := context.Background()
+
+	tpl := tuple.MustParse("document:first#viewer@user:tom")
+	calls := 0
+	rev, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		calls++
+		if _, _, err := rwt.ReadNamespace(ctx, "document"); err != nil {
+			return err
+		}
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{tuple.Create(tpl)})
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+	require.Equal(t, int32(1), counting.transactions.Load())
+
+	count, err := ds.SnapshotReader(rev).CountRelationships(ctx, datastore.RelationshipsFilter{ResourceType: "document"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), count)
+}
+
+// blockingDatastore blocks read-write transactions of the delegate datastore until released.
+type blockingDatastore struct {
+	datastore.Datastore
+
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingDatastore) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	b.entered <- struct{}{}
+	<-b.release
+	return b.Datastore.ReadWriteTx(ctx, f, opts...)
+}
+
+func TestWriteBatchingCanceledWhileWriting(t *testing.T) {
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	ds, _ := testfixtures.StandardDatastoreWithSchema(rawDS, require.New(t))
+	blocking := &blockingDatastore{Datastore: ds, entered: make(chan struct{}, 1), release: make(chan struct{})}
+	batching := NewWriteBatchingProxy(blocking, time.Millisecond, 1)
+	t.Cleanup(func() { require.NoError(t, batching.Close()) })
+
+	var releaseOnce sync.Once
+	release := func() { releaseOnce.Do(func() { close(blocking.release) }) }
+	t.Cleanup(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tpl := tuple.MustParse("document:first#viewer@user:tom")
+	errs := make(chan error, 1)
+	go func() {
+		_, err := batching.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{tuple.Create(tpl)})
+		})
+		errs <- err
+	}()
+
+	// The caller is canceled once its batch is being written, so its write is still made.
+	<-blocking.entered
+	cancel()
+
+	select {
+	case err := <-errs:
+		require.Failf(t, "returned before the batch was written", "error: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	release()
+	require.NoError(t, <-errs)
+
+	rev, err := ds.HeadRevision(context.Background())
+	require.NoError(t, err)
+	count, err := ds.SnapshotReader(rev).CountRelationships(context.Background(), datastore.RelationshipsFilter{ResourceType: "document"})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), count)
+}
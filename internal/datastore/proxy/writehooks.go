@@ -0,0 +1,228 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// RelationshipWriteHook is a function invoked after a read-write transaction has been
+// successfully committed, with the revision of the commit and the set of relationships
+// written (CREATE or TOUCH) and deleted (DELETE) by the transaction.
+//
+// Hooks are invoked synchronously, in registration order, before ReadWriteTx returns to
+// its caller. Hooks must not modify the changes they are given.
+type RelationshipWriteHook func(ctx context.Context, changes *datastore.RevisionChanges)
+
+// HookedDatastore is a datastore which allows for registering hooks that are invoked
+// whenever relationships are changed via the datastore.
+type HookedDatastore interface {
+	datastore.Datastore
+
+	// RegisterRelationshipWriteHook registers a hook to be invoked after every successfully
+	// committed read-write transaction that changed at least one relationship.
+	RegisterRelationshipWriteHook(hook RelationshipWriteHook)
+}
+
+// NewRelationshipWriteHooksProxy creates a proxy which invokes the registered hooks with the
+// relationships changed by each read-write transaction once it has been committed. This allows
+// embedders to maintain derived data (e.g. search index ACL mirrors or counters) without
+// having to consume the Watch API.
+func NewRelationshipWriteHooksProxy(delegate datastore.Datastore, hooks ...RelationshipWriteHook) HookedDatastore {
+	return &writeHooksProxy{
+		Datastore: delegate,
+		hooks:     hooks,
+	}
+}
+
+type writeHooksProxy struct {
+	datastore.Datastore
+
+	sync.RWMutex
+	hooks []RelationshipWriteHook
+}
+
+func (p *writeHooksProxy) Unwrap() datastore.Datastore { return p.Datastore }
+
+func (p *writeHooksProxy) RegisterRelationshipWriteHook(hook RelationshipWriteHook) {
+	p.Lock()
+	defer p.Unlock()
+	p.hooks = append(p.hooks, hook)
+}
+
+func (p *writeHooksProxy) registeredHooks() []RelationshipWriteHook {
+	p.RLock()
+	defer p.RUnlock()
+	return p.hooks
+}
+
+func (p *writeHooksProxy) ReadWriteTx(ctx context.Context, f datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	hooks := p.registeredHooks()
+	if len(hooks) == 0 {
+		return p.Datastore.ReadWriteTx(ctx, f, opts...)
+	}
+
+	// NOTE: the transaction function may be retried by the underlying datastore, so the
+	// recorded changes are reset on each invocation.
+	var recording *recordingRWT
+	rev, err := p.Datastore.ReadWriteTx(ctx, func(delegateRWT datastore.ReadWriteTransaction) error {
+		recording = &recordingRWT{ReadWriteTransaction: delegateRWT}
+		return f(recording)
+	}, opts...)
+	if err != nil {
+		return rev, err
+	}
+
+	if recording == nil || len(recording.changes) == 0 {
+		return rev, nil
+	}
+
+	changes := &datastore.RevisionChanges{
+		Revision: rev,
+		Changes:  recording.changes,
+	}
+
+	log.Ctx(ctx).Trace().Int("changes", len(changes.Changes)).Stringer("revision", rev).Msg("invoking relationship write hooks")
+	for _, hook := range hooks {
+		hook(ctx, changes)
+	}
+
+	return rev, nil
+}
+
+func (p *writeHooksProxy) BulkLoad(ctx context.Context, source datastore.BulkWriteRelationshipSource) (datastore.Revision, error) {
+	hooks := p.registeredHooks()
+	if len(hooks) == 0 {
+		return p.Datastore.BulkLoad(ctx, source)
+	}
+
+	recording := &recordingBulkLoadSource{BulkWriteRelationshipSource: source}
+	rev, err := p.Datastore.BulkLoad(ctx, recording)
+	if err != nil {
+		return rev, err
+	}
+
+	if len(recording.changes) == 0 {
+		return rev, nil
+	}
+
+	changes := &datastore.RevisionChanges{
+		Revision: rev,
+		Changes:  recording.changes,
+	}
+
+	log.Ctx(ctx).Trace().Int("changes", len(changes.Changes)).Stringer("revision", rev).Msg("invoking relationship write hooks for bulk load")
+	for _, hook := range hooks {
+		hook(ctx, changes)
+	}
+
+	return rev, nil
+}
+
+// recordingBulkLoadSource records each relationship produced by the delegate source as a CREATE,
+// so that the bulk loaded relationships can be handed to the hooks.
+type recordingBulkLoadSource struct {
+	datastore.BulkWriteRelationshipSource
+
+	changes []*core.RelationTupleUpdate
+}
+
+func (rs *recordingBulkLoadSource) Next(ctx context.Context) (*core.RelationTuple, error) {
+	tpl, err := rs.BulkWriteRelationshipSource.Next(ctx)
+	if err != nil || tpl == nil {
+		return tpl, err
+	}
+
+	rs.changes = append(rs.changes, &core.RelationTupleUpdate{
+		Operation: core.RelationTupleUpdate_CREATE,
+		Tuple:     tpl,
+	})
+	return tpl, nil
+}
+
+type recordingRWT struct {
+	datastore.ReadWriteTransaction
+
+	changes []*core.RelationTupleUpdate
+}
+
+func (rwt *recordingRWT) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	if err := rwt.ReadWriteTransaction.WriteRelationships(ctx, mutations); err != nil {
+		return err
+	}
+
+	rwt.changes = append(rwt.changes, mutations...)
+	return nil
+}
+
+func (rwt *recordingRWT) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
+	// Load the relationships that are about to be deleted, so they can be handed to the hooks.
+	deleted, err := rwt.collectMatching(ctx, datastore.RelationshipsFilterFromPublicFilter(filter))
+	if err != nil {
+		return err
+	}
+
+	if err := rwt.ReadWriteTransaction.DeleteRelationships(ctx, filter); err != nil {
+		return err
+	}
+
+	rwt.recordDeleted(deleted)
+	return nil
+}
+
+func (rwt *recordingRWT) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+	var deleted []*core.RelationTuple
+	for _, nsName := range nsNames {
+		found, err := rwt.collectMatching(ctx, datastore.RelationshipsFilter{ResourceType: nsName})
+		if err != nil {
+			return err
+		}
+		deleted = append(deleted, found...)
+	}
+
+	if err := rwt.ReadWriteTransaction.DeleteNamespaces(ctx, nsNames...); err != nil {
+		return err
+	}
+
+	rwt.recordDeleted(deleted)
+	return nil
+}
+
+func (rwt *recordingRWT) collectMatching(ctx context.Context, filter datastore.RelationshipsFilter) ([]*core.RelationTuple, error) {
+	iter, err := rwt.ReadWriteTransaction.QueryRelationships(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load relationships for write hooks: %w", err)
+	}
+	defer iter.Close()
+
+	var found []*core.RelationTuple
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		found = append(found, tpl)
+	}
+	if iter.Err() != nil {
+		return nil, fmt.Errorf("unable to load relationships for write hooks: %w", iter.Err())
+	}
+
+	return found, nil
+}
+
+func (rwt *recordingRWT) recordDeleted(deleted []*core.RelationTuple) {
+	for _, tpl := range deleted {
+		rwt.changes = append(rwt.changes, &core.RelationTupleUpdate{
+			Operation: core.RelationTupleUpdate_DELETE,
+			Tuple:     tpl,
+		})
+	}
+}
+
+var (
+	_ HookedDatastore                = (*writeHooksProxy)(nil)
+	_ datastore.ReadWriteTransaction = (*recordingRWT)(nil)
+)
@@ -0,0 +1,117 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestRelationshipWriteHooks(t *testing.T) {
+	require := require.New(t)
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+	hooked := NewRelationshipWriteHooksProxy(ds)
+
+	var received []*datastore.RevisionChanges
+	hooked.RegisterRelationshipWriteHook(func(ctx context.Context, changes *datastore.RevisionChanges) {
+		received = append(received, changes)
+	})
+
+	ctx := context.Background()
+	first := tuple.MustParse("document:firstdoc#viewer@user:tom")
+	second := tuple.MustParse("document:seconddoc#viewer@user:tom")
+
+	rev, err := common.WriteTuples(ctx, hooked, core.RelationTupleUpdate_CREATE, first, second)
+	require.NoError(err)
+	require.Len(received, 1)
+	require.True(rev.Equal(received[0].Revision))
+	require.Len(received[0].Changes, 2)
+	require.Equal(core.RelationTupleUpdate_CREATE, received[0].Changes[0].Operation)
+
+	// Deleting by filter should report the relationships that matched the filter.
+	rev, err = hooked.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.DeleteRelationships(ctx, &v1.RelationshipFilter{
+			ResourceType:       "document",
+			OptionalResourceId: "firstdoc",
+		})
+	})
+	require.NoError(err)
+	require.Len(received, 2)
+	require.True(rev.Equal(received[1].Revision))
+	require.Len(received[1].Changes, 1)
+	require.Equal(core.RelationTupleUpdate_DELETE, received[1].Changes[0].Operation)
+	require.Equal(tuple.String(first), tuple.String(received[1].Changes[0].Tuple))
+
+	// Transactions which change no relationships should not invoke the hooks.
+	_, err = hooked.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return nil
+	})
+	require.NoError(err)
+	require.Len(received, 2)
+}
+
+func TestRelationshipWriteHooksNotCalledOnFailure(t *testing.T) {
+	require := require.New(t)
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+
+	called := false
+	hooked := NewRelationshipWriteHooksProxy(ds, func(ctx context.Context, changes *datastore.RevisionChanges) {
+		called = true
+	})
+
+	ctx := context.Background()
+	tpl := tuple.MustParse("document:firstdoc#viewer@user:tom")
+
+	_, err = common.WriteTuples(ctx, hooked, core.RelationTupleUpdate_CREATE, tpl)
+	require.NoError(err)
+	require.True(called)
+
+	called = false
+	_, err = common.WriteTuples(ctx, hooked, core.RelationTupleUpdate_CREATE, tpl)
+	require.Error(err)
+	require.False(called)
+}
+
+func TestRelationshipWriteHooksBulkLoad(t *testing.T) {
+	require := require.New(t)
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+
+	var received []*datastore.RevisionChanges
+	hooked := NewRelationshipWriteHooksProxy(ds, func(ctx context.Context, changes *datastore.RevisionChanges) {
+		received = append(received, changes)
+	})
+
+	ctx := context.Background()
+	tuples := []*core.RelationTuple{
+		tuple.MustParse("document:firstdoc#viewer@user:tom"),
+		tuple.MustParse("document:seconddoc#viewer@user:tom"),
+	}
+
+	rev, err := hooked.BulkLoad(ctx, common.SliceBulkLoadSource(tuples))
+	require.NoError(err)
+	require.Len(received, 1)
+	require.True(rev.Equal(received[0].Revision))
+	require.Len(received[0].Changes, 2)
+	require.Equal(core.RelationTupleUpdate_CREATE, received[0].Changes[1].Operation)
+	require.Equal(tuple.String(tuples[1]), tuple.String(received[0].Changes[1].Tuple))
+}
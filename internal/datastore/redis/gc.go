@@ -0,0 +1,205 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/redis/resp"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+// gcScanCount is the number of fields of the relationships hash requested by each HSCAN.
+const gcScanCount = 1000
+
+var (
+	_ common.GarbageCollector               = (*Datastore)(nil)
+	_ datastore.GarbageCollectableDatastore = (*Datastore)(nil)
+)
+
+// Now returns the time of the process, which also scores the transactions it commits.
+func (rds *Datastore) Now(_ context.Context) (time.Time, error) {
+	return time.Now().UTC(), nil
+}
+
+func (rds *Datastore) TxIDBefore(ctx context.Context, before time.Time) (datastore.Revision, error) {
+	// Find the highest transaction ID before the GC window.
+	reply, err := rds.client.Do(ctx, "ZREVRANGEBYSCORE", rds.keys.transactions(),
+		fmt.Sprintf("(%d", before.UnixMicro()), "-inf", "LIMIT", 0, 1)
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+
+	members := stringsOf(reply)
+	if len(members) == 0 {
+		log.Debug().Time("before", before).Msg("no stale transactions found in the datastore")
+		return datastore.NoRevision, nil
+	}
+
+	txn, err := strconv.ParseUint(members[0], 10, 64)
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+	return revisionFromTransaction(txn), nil
+}
+
+func (rds *Datastore) CountTransactionsBeforeTx(ctx context.Context, txID datastore.Revision) (int64, error) {
+	score, err := rds.transactionScore(ctx, transactionFromRevision(txID.(revision.Decimal)))
+	if err != nil || score == "" {
+		return 0, err
+	}
+
+	reply, err := rds.client.Do(ctx, "ZCOUNT", rds.keys.transactions(), "-inf", "("+score)
+	if err != nil {
+		return 0, err
+	}
+	count, _ := reply.(int64)
+	return count, nil
+}
+
+// CollectGarbage runs garbage collection immediately, regardless of whether the background
+// garbage collection is enabled.
+func (rds *Datastore) CollectGarbage(ctx context.Context) (datastore.GarbageCollected, error) {
+	return common.RunGarbageCollection(ctx, rds, rds.gcWindow, rds.gcTimeout)
+}
+
+func (rds *Datastore) DeleteBeforeTx(
+	ctx context.Context,
+	txID datastore.Revision,
+) (removed common.DeletionCounts, err error) {
+	tx := transactionFromRevision(txID.(revision.Decimal))
+
+	// Delete any relationship versions deleted at or before the transaction, or which have
+	// expired.
+	removed.Relationships, err = rds.deleteRelationshipVersions(ctx, tx)
+	if err != nil {
+		return
+	}
+
+	// Delete the changes of the transactions which are being deleted, which can no longer be
+	// watched.
+	if _, err = rds.client.Do(ctx, "ZREMRANGEBYSCORE", rds.keys.changes(), "-inf", fmt.Sprintf("(%d", tx)); err != nil {
+		return
+	}
+
+	// Delete all transactions committed before the transaction.
+	//
+	// We don't delete the transaction itself to ensure there is always at least
+	// one transaction present.
+	score, err := rds.transactionScore(ctx, tx)
+	if err != nil || score == "" {
+		return
+	}
+	reply, err := rds.client.Do(ctx, "ZREMRANGEBYSCORE", rds.keys.transactions(), "-inf", "("+score)
+	if err != nil {
+		return
+	}
+	removed.Transactions, _ = reply.(int64)
+
+	// Namespace and caveat definitions are not deleted, as their prior versions are retained.
+	return
+}
+
+// transactionScore returns the score of the transaction in the sorted set of transactions, or
+// an empty string if it is not found.
+func (rds *Datastore) transactionScore(ctx context.Context, txn uint64) (string, error) {
+	reply, err := rds.client.Do(ctx, "ZSCORE", rds.keys.transactions(), txn)
+	if err != nil {
+		return "", err
+	}
+	score, _ := reply.([]byte)
+	return string(score), nil
+}
+
+// deleteRelationshipVersions scans the versions of the relationships, and deletes those which
+// were deleted at or before the transaction or have expired, with their index entries. The
+// expired versions are deleted by transactions which are aborted by any concurrent write of
+// the living relationships, so that a relationship replaced concurrently is never deleted.
+func (rds *Datastore) deleteRelationshipVersions(ctx context.Context, tx uint64) (int64, error) {
+	var deleted int64
+	cursor := "0"
+	now := time.Now()
+	for {
+		reply, err := rds.client.Do(ctx, "HSCAN", rds.keys.relationships(), cursor, "COUNT", gcScanCount)
+		if err != nil {
+			return deleted, err
+		}
+
+		page, _ := reply.([]any)
+		if len(page) != 2 {
+			return deleted, fmt.Errorf("unexpected reply to HSCAN of length %d", len(page))
+		}
+		next, _ := page[0].([]byte)
+		fields := stringsOf(page[1])
+
+		var dead, expired []*relationshipVersion
+		for i := 0; i+1 < len(fields); i += 2 {
+			version := &relationshipVersion{}
+			if err := json.Unmarshal([]byte(fields[i+1]), version); err != nil {
+				return deleted, fmt.Errorf("unable to decode relationship: %w", err)
+			}
+
+			switch {
+			case version.Deleted != 0 && version.Deleted <= tx:
+				dead = append(dead, version)
+			case version.Deleted == 0 && version.expired(now):
+				expired = append(expired, version)
+			}
+		}
+
+		if len(dead) > 0 {
+			if _, err := rds.client.Pipeline(ctx, rds.deleteVersionCommands(dead)...); err != nil {
+				return deleted, err
+			}
+			deleted += int64(len(dead))
+		}
+
+		if len(expired) > 0 {
+			if err := rds.deleteExpiredVersions(ctx, expired); err != nil {
+				return deleted, err
+			}
+			deleted += int64(len(expired))
+		}
+
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return deleted, nil
+		}
+	}
+}
+
+// deleteExpiredVersions deletes living versions which have expired, and the living entries of
+// their relationships.
+func (rds *Datastore) deleteExpiredVersions(ctx context.Context, expired []*relationshipVersion) error {
+	return rds.client.WithConn(ctx, func(conn *resp.Conn) error {
+		if err := conn.Watch(ctx, rds.keys.head(), rds.keys.living()); err != nil {
+			return err
+		}
+
+		commands := rds.deleteVersionCommands(expired)
+		for _, version := range expired {
+			commands = append(commands, []any{"HDEL", rds.keys.living(), version.key()})
+		}
+
+		// An aborted transaction is retried by the next garbage collection.
+		_, err := conn.Exec(ctx, commands...)
+		return err
+	})
+}
+
+func (rds *Datastore) deleteVersionCommands(versions []*relationshipVersion) [][]any {
+	commands := make([][]any, 0, 5*len(versions))
+	for _, version := range versions {
+		id := version.id()
+		commands = append(commands, []any{"HDEL", rds.keys.relationships(), id})
+		for _, index := range rds.keys.indexesOf(version) {
+			commands = append(commands, []any{"SREM", index, id})
+		}
+	}
+	return commands
+}
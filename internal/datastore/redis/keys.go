@@ -0,0 +1,64 @@
+package redis
+
+// keys are the names of the keys of a datastore, each of which starts with the key prefix of the
+// datastore within braces, so that every key of the datastore is stored by the same node of a
+// cluster and they can all be written by one transaction.
+type keys struct {
+	prefix string
+}
+
+func newKeys(prefix string) keys {
+	return keys{prefix: "{" + prefix + "}:"}
+}
+
+// head is the ID of the latest transaction.
+func (k keys) head() string { return k.prefix + "head" }
+
+// uniqueID is the unique ID of the datastore.
+func (k keys) uniqueID() string { return k.prefix + "unique_id" }
+
+// transactions is a sorted set of the IDs of the transactions, scored by the time at which they
+// committed in microseconds.
+func (k keys) transactions() string { return k.prefix + "transactions" }
+
+// changes is a sorted set of the changes of relationships and the metadata of transactions,
+// scored by the ID of their transaction.
+func (k keys) changes() string { return k.prefix + "changes" }
+
+// relationships is a hash of the versions of the relationships by their version IDs.
+func (k keys) relationships() string { return k.prefix + "relationships" }
+
+// living is a hash of the version IDs of the living relationships, by their relationship keys.
+func (k keys) living() string { return k.prefix + "living" }
+
+// namespaces and caveats are hashes of the versions of each definition, by the definition name.
+func (k keys) namespaces() string { return k.prefix + "namespaces" }
+func (k keys) caveats() string    { return k.prefix + "caveats" }
+
+// The indexes of the versions of the relationships are sets of version IDs, by resource type,
+// by resource, by subject type, and by subject.
+func (k keys) byResourceType(namespace string) string {
+	return k.prefix + "resource_type:" + namespace
+}
+
+func (k keys) byResource(namespace, objectID string) string {
+	return k.prefix + "resource:" + namespace + ":" + objectID
+}
+
+func (k keys) bySubjectType(namespace string) string {
+	return k.prefix + "subject_type:" + namespace
+}
+
+func (k keys) bySubject(namespace, objectID string) string {
+	return k.prefix + "subject:" + namespace + ":" + objectID
+}
+
+// indexesOf returns the indexes which contain a version of the relationship.
+func (k keys) indexesOf(rel *relationshipVersion) []string {
+	return []string{
+		k.byResourceType(rel.Namespace),
+		k.byResource(rel.Namespace, rel.ObjectID),
+		k.bySubjectType(rel.SubjectNamespace),
+		k.bySubject(rel.SubjectNamespace, rel.SubjectObjectID),
+	}
+}
@@ -0,0 +1,206 @@
+package redis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/redis/resp"
+)
+
+const (
+	errQuantizationTooLarge = "revision quantization interval (%s) must be less than GC window (%s)"
+
+	defaultGarbageCollectionWindow           = 24 * time.Hour
+	defaultGarbageCollectionInterval         = time.Minute * 3
+	defaultGarbageCollectionMaxOperationTime = time.Minute
+	defaultWatchBufferLength                 = 128
+	defaultQuantization                      = 5 * time.Second
+	defaultMaxRevisionStalenessPercent       = 0.1
+	defaultEnablePrometheusStats             = false
+	defaultMaxRetries                        = 8
+	defaultGCEnabled                         = true
+	defaultKeyPrefix                         = "spicedb"
+)
+
+type redisOptions struct {
+	revisionQuantization        time.Duration
+	gcWindow                    time.Duration
+	gcInterval                  time.Duration
+	gcMaxOperationTime          time.Duration
+	maxRevisionStalenessPercent float64
+	watchBufferLength           uint16
+	enablePrometheusStats       bool
+	maxRetries                  uint8
+	retryInitialBackoff         time.Duration
+	retryMaxBackoff             time.Duration
+	gcEnabled                   bool
+	keyPrefix                   string
+	client                      *resp.Client
+}
+
+// Option provides the facility to configure how the Redis datastore accesses
+// its server.
+type Option func(*redisOptions)
+
+func generateConfig(options []Option) (redisOptions, error) {
+	computed := redisOptions{
+		gcWindow:                    defaultGarbageCollectionWindow,
+		gcInterval:                  defaultGarbageCollectionInterval,
+		gcMaxOperationTime:          defaultGarbageCollectionMaxOperationTime,
+		watchBufferLength:           defaultWatchBufferLength,
+		revisionQuantization:        defaultQuantization,
+		maxRevisionStalenessPercent: defaultMaxRevisionStalenessPercent,
+		enablePrometheusStats:       defaultEnablePrometheusStats,
+		maxRetries:                  defaultMaxRetries,
+		retryInitialBackoff:         common.DefaultRetryInitialBackoff,
+		retryMaxBackoff:             common.DefaultRetryMaxBackoff,
+		gcEnabled:                   defaultGCEnabled,
+	}
+
+	for _, option := range options {
+		option(&computed)
+	}
+
+	// Run any checks on the config that need to be done
+	if computed.revisionQuantization >= computed.gcWindow {
+		return computed, fmt.Errorf(
+			errQuantizationTooLarge,
+			computed.revisionQuantization,
+			computed.gcWindow,
+		)
+	}
+
+	return computed, nil
+}
+
+// WatchBufferLength is the number of entries that can be stored in the watch
+// buffer while awaiting read by the client.
+//
+// This value defaults to 128.
+func WatchBufferLength(watchBufferLength uint16) Option {
+	return func(ro *redisOptions) {
+		ro.watchBufferLength = watchBufferLength
+	}
+}
+
+// RevisionQuantization is the time bucket size to which advertised
+// revisions will be rounded.
+//
+// This value defaults to 5 seconds.
+func RevisionQuantization(quantization time.Duration) Option {
+	return func(ro *redisOptions) {
+		ro.revisionQuantization = quantization
+	}
+}
+
+// MaxRevisionStalenessPercent is the amount of time, expressed as a percentage of
+// the revision quantization window, that a previously computed rounded revision
+// can still be advertised after the next rounded revision would otherwise be ready.
+//
+// This value defaults to 0.1 (10%).
+func MaxRevisionStalenessPercent(stalenessPercent float64) Option {
+	return func(ro *redisOptions) {
+		ro.maxRevisionStalenessPercent = stalenessPercent
+	}
+}
+
+// GCWindow is the maximum age of a passed revision that will be considered
+// valid.
+//
+// This value defaults to 24 hours.
+func GCWindow(window time.Duration) Option {
+	return func(ro *redisOptions) {
+		ro.gcWindow = window
+	}
+}
+
+// GCInterval is the interval at which garbage collection will occur.
+//
+// This value defaults to 3 minutes.
+func GCInterval(interval time.Duration) Option {
+	return func(ro *redisOptions) {
+		ro.gcInterval = interval
+	}
+}
+
+// GCMaxOperationTime is the maximum operation time of a garbage collection
+// pass before it times out.
+//
+// This value defaults to 1 minute.
+func GCMaxOperationTime(time time.Duration) Option {
+	return func(ro *redisOptions) {
+		ro.gcMaxOperationTime = time
+	}
+}
+
+// GCEnabled indicates whether garbage collection is enabled.
+//
+// GC is enabled by default.
+func GCEnabled(isGCEnabled bool) Option {
+	return func(ro *redisOptions) {
+		ro.gcEnabled = isGCEnabled
+	}
+}
+
+// MaxRetries is the maximum number of times a transaction which conflicted
+// with another transaction will be client-side retried.
+//
+// Default: 8
+func MaxRetries(maxRetries uint8) Option {
+	return func(ro *redisOptions) {
+		ro.maxRetries = maxRetries
+	}
+}
+
+// RetryInitialBackoff is the wait before the first client-side retry of a
+// transaction, which doubles with each further retry up to RetryMaxBackoff.
+// Zero retries immediately.
+//
+// Default: 10ms
+func RetryInitialBackoff(backoff time.Duration) Option {
+	return func(ro *redisOptions) {
+		ro.retryInitialBackoff = backoff
+	}
+}
+
+// RetryMaxBackoff is the longest wait between client-side retries of a
+// transaction.
+//
+// Default: 1s
+func RetryMaxBackoff(backoff time.Duration) Option {
+	return func(ro *redisOptions) {
+		ro.retryMaxBackoff = backoff
+	}
+}
+
+// WithEnablePrometheusStats marks whether Prometheus metrics of garbage
+// collection are enabled.
+//
+// Prometheus metrics are disabled by default.
+func WithEnablePrometheusStats(enablePrometheusStats bool) Option {
+	return func(ro *redisOptions) {
+		ro.enablePrometheusStats = enablePrometheusStats
+	}
+}
+
+// KeyPrefix is the prefix of every key of the datastore, which isolates it
+// from the other datastores stored by the same server. It takes precedence
+// over the prefix parameter of the connection URI.
+//
+// This value defaults to "spicedb".
+func KeyPrefix(prefix string) Option {
+	return func(ro *redisOptions) {
+		ro.keyPrefix = prefix
+	}
+}
+
+// SharedClient is a client shared with other datastores, which is used in
+// place of connecting to the server of the connection URI, and is not closed
+// with the datastore. Sharing a client lets many datastores with distinct key
+// prefixes share a small pool of connections.
+func SharedClient(client *resp.Client) Option {
+	return func(ro *redisOptions) {
+		ro.client = client
+	}
+}
@@ -0,0 +1,421 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	errUnableToReadConfig         = "unable to read namespace config: %w"
+	errUnableToListNamespaces     = "unable to list namespaces: %w"
+	errUnableToQueryTuples        = "unable to query tuples: %w"
+	errUnableToCountRelationships = "unable to count relationships: %w"
+	errUnableToListResourceTypes  = "unable to list resource types: %w"
+
+	// loadBatchSize is the number of versions read by each HMGET.
+	loadBatchSize = 1000
+)
+
+// commander executes commands, on a connection of the pool of a client or on the connection of a
+// transaction.
+type commander interface {
+	Do(ctx context.Context, args ...any) (any, error)
+	Pipeline(ctx context.Context, commands ...[]any) ([]any, error)
+}
+
+type redisReader struct {
+	conn commander
+	keys keys
+	txn  uint64
+}
+
+// readTime returns the time at which the transaction committed, its score in the sorted set of
+// transactions, at which expiration is evaluated. Read/write transactions read at the current
+// time, as they have not committed yet.
+func (rr *redisReader) readTime(ctx context.Context) (time.Time, error) {
+	if rr.txn == liveTransaction {
+		return time.Now(), nil
+	}
+
+	reply, err := rr.conn.Do(ctx, "ZSCORE", rr.keys.transactions(), rr.txn)
+	if err != nil {
+		return time.Time{}, err
+	}
+	score, _ := reply.([]byte)
+	micros, err := strconv.ParseFloat(string(score), 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to read the time of transaction %d: %w", rr.txn, err)
+	}
+	return time.UnixMicro(int64(micros)), nil
+}
+
+func (rr *redisReader) QueryRelationships(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	ctx, span := tracer.Start(ctx, "QueryRelationships")
+	defer span.End()
+
+	queryOpts := options.NewQueryOptionsWithOptions(opts...)
+
+	relationships, err := rr.loadRelationships(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToQueryTuples, err)
+	}
+
+	matched := relationships[:0]
+	for _, tpl := range relationships {
+		if matchesFilter(tpl, filter, queryOpts.Usersets) {
+			matched = append(matched, tpl)
+		}
+	}
+	return datastore.NewSliceRelationshipIterator(limited(matched, queryOpts.Limit)), nil
+}
+
+func (rr *redisReader) ReverseQueryRelationships(
+	ctx context.Context,
+	subjectsFilter datastore.SubjectsFilter,
+	opts ...options.ReverseQueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	ctx, span := tracer.Start(ctx, "ReverseQueryRelationships")
+	defer span.End()
+
+	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
+
+	filter := datastore.RelationshipsFilter{OptionalSubjectsFilter: &subjectsFilter}
+	if queryOpts.ResRelation != nil {
+		filter.ResourceType = queryOpts.ResRelation.Namespace
+		filter.OptionalResourceRelation = queryOpts.ResRelation.Relation
+	}
+
+	relationships, err := rr.loadRelationships(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToQueryTuples, err)
+	}
+
+	matched := relationships[:0]
+	for _, tpl := range relationships {
+		if matchesFilter(tpl, filter, nil) {
+			matched = append(matched, tpl)
+		}
+	}
+
+	if queryOpts.ReverseSort == options.BySubject {
+		sortBySubject(matched)
+		if queryOpts.ReverseAfter != nil {
+			matched = afterCursor(matched, queryOpts.ReverseAfter)
+		}
+	}
+
+	return datastore.NewSliceRelationshipIterator(limited(matched, queryOpts.ReverseLimit)), nil
+}
+
+func (rr *redisReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	ctx, span := tracer.Start(ctx, "CountRelationships")
+	defer span.End()
+
+	relationships, err := rr.loadRelationships(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+
+	var count uint64
+	for _, tpl := range relationships {
+		if matchesFilter(tpl, filter, nil) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (rr *redisReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "ListResourceTypes")
+	defer span.End()
+
+	relationships, err := rr.loadRelationships(ctx, datastore.RelationshipsFilter{})
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+
+	seen := map[string]struct{}{}
+	var resourceTypes []string
+	for _, tpl := range relationships {
+		if _, ok := seen[tpl.ResourceAndRelation.Namespace]; ok {
+			continue
+		}
+		seen[tpl.ResourceAndRelation.Namespace] = struct{}{}
+		resourceTypes = append(resourceTypes, tpl.ResourceAndRelation.Namespace)
+	}
+	sort.Strings(resourceTypes)
+	return resourceTypes, nil
+}
+
+// loadRelationships returns the relationships at the transaction of the reader which may match
+// the filter, read through its most selective index, in the order of their keys.
+func (rr *redisReader) loadRelationships(ctx context.Context, filter datastore.RelationshipsFilter) ([]*core.RelationTuple, error) {
+	var command []any
+	switch {
+	case filter.ResourceType != "" && len(filter.OptionalResourceIds) > 0:
+		command = []any{"SUNION"}
+		for _, resourceID := range filter.OptionalResourceIds {
+			command = append(command, rr.keys.byResource(filter.ResourceType, resourceID))
+		}
+	case filter.OptionalSubjectsFilter != nil && len(filter.OptionalSubjectsFilter.OptionalSubjectIds) > 0:
+		command = []any{"SUNION"}
+		for _, subjectID := range filter.OptionalSubjectsFilter.OptionalSubjectIds {
+			command = append(command, rr.keys.bySubject(filter.OptionalSubjectsFilter.SubjectType, subjectID))
+		}
+	case filter.ResourceType != "":
+		command = []any{"SMEMBERS", rr.keys.byResourceType(filter.ResourceType)}
+	case filter.OptionalSubjectsFilter != nil:
+		command = []any{"SMEMBERS", rr.keys.bySubjectType(filter.OptionalSubjectsFilter.SubjectType)}
+	default:
+		command = []any{"HKEYS", rr.keys.relationships()}
+	}
+
+	reply, err := rr.conn.Do(ctx, command...)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := loadVersions(ctx, rr.conn, rr.keys, stringsOf(reply))
+	if err != nil {
+		return nil, err
+	}
+
+	readTime, err := rr.readTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := versions[:0]
+	for _, version := range versions {
+		if version.visibleAt(rr.txn, readTime) {
+			visible = append(visible, version)
+		}
+	}
+	sort.Slice(visible, func(i, j int) bool {
+		return visible[i].key() < visible[j].key()
+	})
+
+	relationships := make([]*core.RelationTuple, 0, len(visible))
+	for _, version := range visible {
+		tpl, err := version.tuple()
+		if err != nil {
+			return nil, err
+		}
+		relationships = append(relationships, tpl)
+	}
+	return relationships, nil
+}
+
+// loadVersions reads the versions of the IDs, skipping any which have been garbage collected
+// since the IDs were read.
+func loadVersions(ctx context.Context, conn commander, k keys, ids []string) ([]*relationshipVersion, error) {
+	versions := make([]*relationshipVersion, 0, len(ids))
+	for start := 0; start < len(ids); start += loadBatchSize {
+		end := start + loadBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		command := make([]any, 0, end-start+2)
+		command = append(command, "HMGET", k.relationships())
+		for _, id := range ids[start:end] {
+			command = append(command, id)
+		}
+
+		reply, err := conn.Do(ctx, command...)
+		if err != nil {
+			return nil, err
+		}
+
+		values, _ := reply.([]any)
+		for _, value := range values {
+			encoded, ok := value.([]byte)
+			if !ok {
+				continue
+			}
+
+			version := &relationshipVersion{}
+			if err := json.Unmarshal(encoded, version); err != nil {
+				return nil, fmt.Errorf("unable to decode relationship: %w", err)
+			}
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+// definitionVersion is a version of a namespace or caveat definition, which was written by one
+// transaction and may have been replaced or deleted by a later one.
+type definitionVersion struct {
+	Created    uint64 `json:"c"`
+	Deleted    uint64 `json:"d,omitempty"`
+	Definition []byte `json:"def"`
+}
+
+// definitionVersionAt returns the version of the definition at the transaction, if it existed.
+func definitionVersionAt(versions []definitionVersion, txn uint64) (definitionVersion, bool) {
+	for _, version := range versions {
+		if version.Created <= txn && (version.Deleted == 0 || version.Deleted > txn) {
+			return version, true
+		}
+	}
+	return definitionVersion{}, false
+}
+
+// readDefinition returns the version of a definition of the hash at the transaction of the
+// reader, or false if it did not exist.
+func (rr *redisReader) readDefinition(ctx context.Context, hash, name string) (definitionVersion, bool, error) {
+	reply, err := rr.conn.Do(ctx, "HGET", hash, name)
+	if err != nil {
+		return definitionVersion{}, false, err
+	}
+
+	versions, err := decodeDefinitionVersions(reply)
+	if err != nil {
+		return definitionVersion{}, false, err
+	}
+
+	version, found := definitionVersionAt(versions, rr.txn)
+	return version, found, nil
+}
+
+// listDefinitions returns the definitions of the hash which existed at the transaction of the
+// reader, in order of their names, and paged by the options.
+func (rr *redisReader) listDefinitions(ctx context.Context, hash string, opts ...options.ListOptionsOption) ([]definitionVersion, error) {
+	reply, err := rr.conn.Do(ctx, "HGETALL", hash)
+	if err != nil {
+		return nil, err
+	}
+
+	listOpts := options.NewListOptionsWithOptions(opts...)
+	fields := stringsOf(reply)
+	names := make([]string, 0, len(fields)/2)
+	found := make(map[string]definitionVersion, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		name := fields[i]
+		if name <= listOpts.ListAfter {
+			continue
+		}
+
+		versions, err := decodeDefinitionVersions([]byte(fields[i+1]))
+		if err != nil {
+			return nil, err
+		}
+		if version, ok := definitionVersionAt(versions, rr.txn); ok {
+			names = append(names, name)
+			found[name] = version
+		}
+	}
+	sort.Strings(names)
+
+	if listOpts.ListLimit != nil && uint64(len(names)) > *listOpts.ListLimit {
+		names = names[:*listOpts.ListLimit]
+	}
+
+	definitions := make([]definitionVersion, 0, len(names))
+	for _, name := range names {
+		definitions = append(definitions, found[name])
+	}
+	return definitions, nil
+}
+
+func decodeDefinitionVersions(reply any) ([]definitionVersion, error) {
+	encoded, ok := reply.([]byte)
+	if !ok {
+		return nil, nil
+	}
+
+	var versions []definitionVersion
+	if err := json.Unmarshal(encoded, &versions); err != nil {
+		return nil, fmt.Errorf("unable to decode definition: %w", err)
+	}
+	return versions, nil
+}
+
+func (rr *redisReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
+	ctx, span := tracer.Start(ctx, "ReadNamespace")
+	defer span.End()
+
+	version, found, err := rr.readDefinition(ctx, rr.keys.namespaces(), nsName)
+	if err != nil {
+		return nil, datastore.NoRevision, fmt.Errorf(errUnableToReadConfig, err)
+	}
+	if !found {
+		return nil, datastore.NoRevision, datastore.NewNamespaceNotFoundErr(nsName)
+	}
+
+	loaded := &core.NamespaceDefinition{}
+	if err := loaded.UnmarshalVT(version.Definition); err != nil {
+		return nil, datastore.NoRevision, fmt.Errorf(errUnableToReadConfig, err)
+	}
+	return loaded, revisionFromTransaction(version.Created), nil
+}
+
+func (rr *redisReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	ctx, span := tracer.Start(ctx, "ListNamespaces")
+	defer span.End()
+
+	versions, err := rr.listDefinitions(ctx, rr.keys.namespaces(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListNamespaces, err)
+	}
+
+	nsDefs := make([]*core.NamespaceDefinition, 0, len(versions))
+	for _, version := range versions {
+		loaded := &core.NamespaceDefinition{}
+		if err := loaded.UnmarshalVT(version.Definition); err != nil {
+			return nil, fmt.Errorf(errUnableToListNamespaces, err)
+		}
+		nsDefs = append(nsDefs, loaded)
+	}
+	return nsDefs, nil
+}
+
+func (rr *redisReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
+	ctx, span := tracer.Start(ctx, "LookupNamespaces")
+	defer span.End()
+
+	nsDefs := make([]*core.NamespaceDefinition, 0, len(nsNames))
+	for _, nsName := range nsNames {
+		version, found, err := rr.readDefinition(ctx, rr.keys.namespaces(), nsName)
+		if err != nil {
+			return nil, fmt.Errorf(errUnableToReadConfig, err)
+		}
+		if !found {
+			continue
+		}
+
+		loaded := &core.NamespaceDefinition{}
+		if err := loaded.UnmarshalVT(version.Definition); err != nil {
+			return nil, fmt.Errorf(errUnableToReadConfig, err)
+		}
+		nsDefs = append(nsDefs, loaded)
+	}
+	return nsDefs, nil
+}
+
+// stringsOf returns the bulk strings of an array reply.
+func stringsOf(reply any) []string {
+	values, _ := reply.([]any)
+	strings := make([]string, 0, len(values))
+	for _, value := range values {
+		if encoded, ok := value.([]byte); ok {
+			strings = append(strings, string(encoded))
+		}
+	}
+	return strings
+}
+
+var _ datastore.Reader = &redisReader{}
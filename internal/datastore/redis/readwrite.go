@@ -0,0 +1,360 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/redis/resp"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+const (
+	errUnableToWriteRelationships  = "unable to write relationships: %w"
+	errUnableToDeleteRelationships = "unable to delete relationships: %w"
+	errUnableToWriteConfig         = "unable to write namespace config: %w"
+	errUnableToDeleteConfig        = "unable to delete namespace config: %w"
+	errUnableToCommit              = "unable to commit transaction: %w"
+)
+
+// change is a member of the changes of the datastore: the change of a relationship, or the
+// metadata of a transaction. Each change has a sequence number within its transaction, which
+// makes every member of the sorted set unique.
+type change struct {
+	Transaction  uint64               `json:"t"`
+	Seq          int                  `json:"s"`
+	Operation    int                  `json:"op,omitempty"`
+	Relationship *relationshipVersion `json:"rel,omitempty"`
+	Metadata     map[string]string    `json:"md,omitempty"`
+}
+
+// redisReadWriteTXN buffers the writes of a transaction, which are applied as it commits. The
+// last write of a relationship or definition within the transaction replaces any earlier write.
+type redisReadWriteTXN struct {
+	*redisReader
+
+	conn     *resp.Conn
+	metadata map[string]string
+
+	relationshipOrder []string
+	relationships     map[string]*core.RelationTupleUpdate
+
+	// The definitions written by the transaction, which are nil if they are deleted.
+	namespaceOrder []string
+	namespaces     map[string]*core.NamespaceDefinition
+	caveatOrder    []string
+	caveats        map[string]*core.CaveatDefinition
+}
+
+func newReadWriteTXN(conn *resp.Conn, k keys, metadata map[string]string) *redisReadWriteTXN {
+	return &redisReadWriteTXN{
+		redisReader:   &redisReader{conn: conn, keys: k, txn: liveTransaction},
+		conn:          conn,
+		metadata:      metadata,
+		relationships: make(map[string]*core.RelationTupleUpdate),
+		namespaces:    make(map[string]*core.NamespaceDefinition),
+		caveats:       make(map[string]*core.CaveatDefinition),
+	}
+}
+
+func (rwt *redisReadWriteTXN) bufferRelationship(update *core.RelationTupleUpdate) {
+	key := keyOf(update.Tuple)
+	if _, ok := rwt.relationships[key]; !ok {
+		rwt.relationshipOrder = append(rwt.relationshipOrder, key)
+	}
+	rwt.relationships[key] = update
+}
+
+// WriteRelationships buffers the mutations, which are applied as the transaction commits.
+func (rwt *redisReadWriteTXN) WriteRelationships(_ context.Context, mutations []*core.RelationTupleUpdate) error {
+	for _, mut := range mutations {
+		rwt.bufferRelationship(mut)
+	}
+	return nil
+}
+
+// DeleteRelationships buffers the deletion of the relationships matching the filter, including
+// those written earlier in the transaction.
+func (rwt *redisReadWriteTXN) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
+	dsFilter := datastore.RelationshipsFilterFromPublicFilter(filter)
+
+	existing, err := rwt.loadRelationships(ctx, dsFilter)
+	if err != nil {
+		return fmt.Errorf(errUnableToDeleteRelationships, err)
+	}
+	for _, tpl := range existing {
+		if matchesFilter(tpl, dsFilter, nil) {
+			rwt.bufferRelationship(tuple.Delete(tpl))
+		}
+	}
+
+	for _, key := range rwt.relationshipOrder {
+		update := rwt.relationships[key]
+		if update.Operation != core.RelationTupleUpdate_DELETE && matchesFilter(update.Tuple, dsFilter, nil) {
+			rwt.relationships[key] = tuple.Delete(update.Tuple)
+		}
+	}
+	return nil
+}
+
+func (rwt *redisReadWriteTXN) WriteNamespaces(_ context.Context, newNamespaces ...*core.NamespaceDefinition) error {
+	for _, newNamespace := range newNamespaces {
+		if _, ok := rwt.namespaces[newNamespace.Name]; !ok {
+			rwt.namespaceOrder = append(rwt.namespaceOrder, newNamespace.Name)
+		}
+		rwt.namespaces[newNamespace.Name] = newNamespace
+	}
+	return nil
+}
+
+func (rwt *redisReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+	// Check that each namespace exists before deleting any of them.
+	for _, nsName := range nsNames {
+		_, _, err := rwt.ReadNamespace(ctx, nsName)
+		switch {
+		case errors.As(err, &datastore.ErrNamespaceNotFound{}):
+			return err
+		case err == nil:
+			break
+		default:
+			return fmt.Errorf(errUnableToDeleteConfig, err)
+		}
+	}
+
+	for _, nsName := range nsNames {
+		if _, ok := rwt.namespaces[nsName]; !ok {
+			rwt.namespaceOrder = append(rwt.namespaceOrder, nsName)
+		}
+		rwt.namespaces[nsName] = nil
+
+		if err := rwt.DeleteRelationships(ctx, &v1.RelationshipFilter{ResourceType: nsName}); err != nil {
+			return fmt.Errorf(errUnableToDeleteConfig, err)
+		}
+	}
+	return nil
+}
+
+// commit applies the buffered writes as the transaction, by a single MULTI/EXEC which is aborted
+// if another transaction committed first.
+func (rwt *redisReadWriteTXN) commit(ctx context.Context, txn uint64) error {
+	ctx, span := tracer.Start(ctx, "commit")
+	defer span.End()
+
+	committedAt := nowMicros()
+	commands := [][]any{
+		{"SET", rwt.keys.head(), txn},
+		{"ZADD", rwt.keys.transactions(), committedAt, txn},
+	}
+
+	seq := 0
+	addChange := func(c change) error {
+		c.Transaction = txn
+		c.Seq = seq
+		seq++
+
+		encoded, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		commands = append(commands, []any{"ZADD", rwt.keys.changes(), txn, encoded})
+		return nil
+	}
+
+	if len(rwt.metadata) > 0 {
+		if err := addChange(change{Metadata: rwt.metadata}); err != nil {
+			return fmt.Errorf(errUnableToCommit, err)
+		}
+	}
+
+	definitionCommands, err := rwt.definitionCommands(ctx, txn)
+	if err != nil {
+		return err
+	}
+	commands = append(commands, definitionCommands...)
+
+	existing, err := rwt.livingVersions(ctx)
+	if err != nil {
+		return fmt.Errorf(errUnableToWriteRelationships, err)
+	}
+
+	now := time.UnixMicro(committedAt)
+	for _, key := range rwt.relationshipOrder {
+		update := rwt.relationships[key]
+		previous := existing[key]
+		living := previous != nil && !previous.expired(now)
+
+		if update.Operation == core.RelationTupleUpdate_CREATE && living {
+			return common.NewCreateRelationshipExistsError(update.Tuple)
+		}
+
+		if previous != nil {
+			previous.Deleted = txn
+			encoded, err := json.Marshal(previous)
+			if err != nil {
+				return fmt.Errorf(errUnableToWriteRelationships, err)
+			}
+			commands = append(commands, []any{"HSET", rwt.keys.relationships(), previous.id(), encoded})
+		}
+
+		if update.Operation == core.RelationTupleUpdate_DELETE {
+			if previous == nil {
+				continue
+			}
+
+			commands = append(commands, []any{"HDEL", rwt.keys.living(), key})
+			if living {
+				if err := addChange(change{Operation: changeDeleted, Relationship: previous}); err != nil {
+					return fmt.Errorf(errUnableToDeleteRelationships, err)
+				}
+			}
+			continue
+		}
+
+		version := versionOf(update.Tuple, txn)
+		encoded, err := json.Marshal(version)
+		if err != nil {
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+
+		commands = append(commands,
+			[]any{"HSET", rwt.keys.relationships(), version.id(), encoded},
+			[]any{"HSET", rwt.keys.living(), key, version.id()},
+		)
+		for _, index := range rwt.keys.indexesOf(version) {
+			commands = append(commands, []any{"SADD", index, version.id()})
+		}
+
+		if err := addChange(change{Operation: changeTouched, Relationship: version}); err != nil {
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+	}
+
+	if _, err := rwt.conn.Exec(ctx, commands...); err != nil {
+		if errors.Is(err, resp.ErrAborted) {
+			return err
+		}
+		return fmt.Errorf(errUnableToCommit, err)
+	}
+	return nil
+}
+
+// livingVersions returns the living versions of the relationships written by the transaction,
+// including those which have expired but not yet been garbage collected, by their keys.
+func (rwt *redisReadWriteTXN) livingVersions(ctx context.Context) (map[string]*relationshipVersion, error) {
+	versions := make(map[string]*relationshipVersion, len(rwt.relationshipOrder))
+	if len(rwt.relationshipOrder) == 0 {
+		return versions, nil
+	}
+
+	for start := 0; start < len(rwt.relationshipOrder); start += loadBatchSize {
+		end := start + loadBatchSize
+		if end > len(rwt.relationshipOrder) {
+			end = len(rwt.relationshipOrder)
+		}
+
+		command := make([]any, 0, end-start+2)
+		command = append(command, "HMGET", rwt.keys.living())
+		for _, key := range rwt.relationshipOrder[start:end] {
+			command = append(command, key)
+		}
+
+		reply, err := rwt.conn.Do(ctx, command...)
+		if err != nil {
+			return nil, err
+		}
+
+		loaded, err := loadVersions(ctx, rwt.conn, rwt.keys, stringsOf(reply))
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range loaded {
+			versions[version.key()] = version
+		}
+	}
+	return versions, nil
+}
+
+// definitionCommands returns the commands writing the versions of the definitions written by the
+// transaction, which end the version of each definition it replaces or deletes.
+func (rwt *redisReadWriteTXN) definitionCommands(ctx context.Context, txn uint64) ([][]any, error) {
+	var commands [][]any
+	for _, nsName := range rwt.namespaceOrder {
+		var serialized []byte
+		if nsDef := rwt.namespaces[nsName]; nsDef != nil {
+			var err error
+			if serialized, err = nsDef.MarshalVT(); err != nil {
+				return nil, fmt.Errorf(errUnableToWriteConfig, err)
+			}
+		}
+
+		command, err := rwt.definitionCommand(ctx, rwt.keys.namespaces(), nsName, serialized, txn)
+		if err != nil {
+			return nil, fmt.Errorf(errUnableToWriteConfig, err)
+		}
+		if command != nil {
+			commands = append(commands, command)
+		}
+	}
+
+	for _, name := range rwt.caveatOrder {
+		var serialized []byte
+		if caveat := rwt.caveats[name]; caveat != nil {
+			var err error
+			if serialized, err = caveat.MarshalVT(); err != nil {
+				return nil, fmt.Errorf(errWriteCaveats, err)
+			}
+		}
+
+		command, err := rwt.definitionCommand(ctx, rwt.keys.caveats(), name, serialized, txn)
+		if err != nil {
+			return nil, fmt.Errorf(errWriteCaveats, err)
+		}
+		if command != nil {
+			commands = append(commands, command)
+		}
+	}
+	return commands, nil
+}
+
+// definitionCommand returns the command writing a definition, or deleting it if it is nil, or
+// nil if a deleted definition did not exist.
+func (rwt *redisReadWriteTXN) definitionCommand(ctx context.Context, hash, name string, serialized []byte, txn uint64) ([]any, error) {
+	reply, err := rwt.conn.Do(ctx, "HGET", hash, name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := decodeDefinitionVersions(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	ended := false
+	for i := range versions {
+		if versions[i].Deleted == 0 {
+			versions[i].Deleted = txn
+			ended = true
+		}
+	}
+	if serialized == nil && !ended {
+		return nil, nil
+	}
+	if serialized != nil {
+		versions = append(versions, definitionVersion{Created: txn, Definition: serialized})
+	}
+
+	encoded, err := json.Marshal(versions)
+	if err != nil {
+		return nil, err
+	}
+	return []any{"HSET", hash, name, encoded}, nil
+}
+
+var _ datastore.ReadWriteTransaction = &redisReadWriteTXN{}
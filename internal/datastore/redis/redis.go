@@ -0,0 +1,302 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/common/revisions"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/internal/datastore/proxy"
+	"github.com/authzed/spicedb/internal/datastore/redis/resp"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+const (
+	Engine = "redis"
+
+	// The operations recorded in the changes of relationships.
+	changeTouched = 1
+	changeDeleted = 2
+
+	// initialTransaction is the transaction which created the datastore, at which it is empty.
+	initialTransaction = uint64(1)
+
+	errUnableToInstantiate = "unable to instantiate datastore: %w"
+)
+
+var tracer = otel.Tracer("spicedb/internal/datastore/redis")
+
+func init() {
+	datastore.Engines = append(datastore.Engines, Engine)
+}
+
+// NewRedisDatastore creates a new datastore.Datastore stored by the Redis or KeyDB server of the
+// connection URI, under the key prefix of the `prefix` parameter of the URI or the KeyPrefix
+// option. Supports customization via the various options available in this package.
+//
+// The datastore needs no migrations: its keys are initialized as it is created. It offers no
+// durability beyond that of the server, and is intended for testing.
+func NewRedisDatastore(uri string, options ...Option) (datastore.Datastore, error) {
+	ds, err := newRedisDatastore(uri, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return proxy.NewSeparatingContextDatastoreProxy(ds), nil
+}
+
+func newRedisDatastore(uri string, options ...Option) (*Datastore, error) {
+	config, err := generateConfig(options)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+
+	client := config.client
+	if client == nil {
+		clientConfig, err := resp.ParseURI(uri)
+		if err != nil {
+			return nil, fmt.Errorf(errUnableToInstantiate, err)
+		}
+		client = resp.NewClient(clientConfig)
+	}
+
+	prefix := config.keyPrefix
+	if prefix == "" && uri != "" {
+		parsed, err := url.Parse(uri)
+		if err != nil {
+			return nil, fmt.Errorf(errUnableToInstantiate, err)
+		}
+		prefix = parsed.Query().Get("prefix")
+	}
+	if prefix == "" {
+		prefix = defaultKeyPrefix
+	}
+
+	if config.enablePrometheusStats {
+		if err := common.RegisterGCMetrics(); err != nil {
+			return nil, fmt.Errorf(errUnableToInstantiate, err)
+		}
+	}
+
+	gcCtx, cancelGc := context.WithCancel(context.Background())
+
+	maxRevisionStaleness := time.Duration(float64(config.revisionQuantization.Nanoseconds())*
+		config.maxRevisionStalenessPercent) * time.Nanosecond
+
+	store := &Datastore{
+		client:               client,
+		ownsClient:           config.client == nil,
+		keys:                 newKeys(prefix),
+		revisionQuantization: config.revisionQuantization,
+		gcWindow:             config.gcWindow,
+		gcInterval:           config.gcInterval,
+		gcTimeout:            config.gcMaxOperationTime,
+		gcCtx:                gcCtx,
+		cancelGc:             cancelGc,
+		watchBufferLength:    config.watchBufferLength,
+		retryPolicy: common.RetryPolicy{
+			MaxRetries:     config.maxRetries,
+			InitialBackoff: config.retryInitialBackoff,
+			MaxBackoff:     config.retryMaxBackoff,
+		},
+		CachedOptimizedRevisions: revisions.NewCachedOptimizedRevisions(
+			maxRevisionStaleness,
+		),
+	}
+
+	store.SetOptimizedRevisionFunc(store.optimizedRevisionFunc)
+
+	if err := store.initialize(context.Background()); err != nil {
+		cancelGc()
+		if store.ownsClient {
+			_ = client.Close()
+		}
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+
+	// Start a goroutine for garbage collection.
+	if store.gcInterval > 0*time.Minute && config.gcEnabled {
+		store.gcGroup, store.gcCtx = errgroup.WithContext(store.gcCtx)
+		store.gcGroup.Go(func() error {
+			return common.StartGarbageCollector(
+				store.gcCtx,
+				store,
+				store.gcInterval,
+				store.gcWindow,
+				store.gcTimeout,
+			)
+		})
+	} else {
+		log.Warn().Msg("datastore garbage collection disabled")
+	}
+
+	return store, nil
+}
+
+// initialize writes the unique ID and the first transaction of the datastore, unless another
+// process already has.
+func (rds *Datastore) initialize(ctx context.Context) error {
+	if _, err := rds.client.Do(ctx, "SETNX", rds.keys.uniqueID(), uuid.NewString()); err != nil {
+		return err
+	}
+
+	created, err := rds.client.Do(ctx, "SETNX", rds.keys.head(), initialTransaction)
+	if err != nil {
+		return err
+	}
+	if created != int64(1) {
+		return nil
+	}
+
+	_, err = rds.client.Do(ctx, "ZADD", rds.keys.transactions(), nowMicros(), initialTransaction)
+	return err
+}
+
+// Datastore is a Redis-based implementation of the datastore.Datastore interface
+type Datastore struct {
+	client     *resp.Client
+	ownsClient bool
+	keys       keys
+
+	revisionQuantization time.Duration
+	gcWindow             time.Duration
+	gcInterval           time.Duration
+	gcTimeout            time.Duration
+	watchBufferLength    uint16
+	retryPolicy          common.RetryPolicy
+
+	gcGroup  *errgroup.Group
+	gcCtx    context.Context
+	cancelGc context.CancelFunc
+
+	*revisions.CachedOptimizedRevisions
+	revision.DecimalDecoder
+}
+
+// SnapshotReader ignores consistency hints, as all reads are served by the same server.
+func (rds *Datastore) SnapshotReader(revisionRaw datastore.Revision, _ ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return &redisReader{
+		conn: rds.client,
+		keys: rds.keys,
+		txn:  transactionFromRevision(revisionRaw.(revision.Decimal)),
+	}
+}
+
+// ReadWriteTx starts a read/write transaction, whose writes are applied if no error is returned
+// and discarded if an error is returned.
+//
+// Transactions are optimistic: the writes of a transaction are buffered and applied by a single
+// MULTI/EXEC, which is aborted and retried if another transaction committed after the
+// transaction began. The reads of the transaction do not see its own buffered writes.
+func (rds *Datastore) ReadWriteTx(
+	ctx context.Context,
+	fn datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
+) (datastore.Revision, error) {
+	config := options.NewRWTOptionsWithOptions(opts...)
+
+	var newTxnID uint64
+	_, err := common.RetryTx(ctx, rds.retryPolicy, isErrorRetryable, func(ctx context.Context) error {
+		return rds.client.WithConn(ctx, func(conn *resp.Conn) error {
+			// Every transaction writes the head, and garbage collection the living relationships
+			// which expired, so watching both aborts any transaction which raced with either.
+			if err := conn.Watch(ctx, rds.keys.head(), rds.keys.living()); err != nil {
+				return err
+			}
+
+			head, err := readHead(ctx, conn, rds.keys)
+			if err != nil {
+				return err
+			}
+
+			rwt := newReadWriteTXN(conn, rds.keys, config.Metadata)
+			if err := fn(rwt); err != nil {
+				return err
+			}
+
+			newTxnID = head + 1
+			return rwt.commit(ctx, newTxnID)
+		})
+	})
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+
+	return revisionFromTransaction(newTxnID), nil
+}
+
+func isErrorRetryable(err error) bool {
+	return errors.Is(err, resp.ErrAborted)
+}
+
+// BulkLoad writes all relationships from the source in a single transaction, whose batches of
+// common.DefaultBulkLoadBatchSize relationships are buffered before it commits.
+func (rds *Datastore) BulkLoad(
+	ctx context.Context,
+	source datastore.BulkWriteRelationshipSource,
+) (datastore.Revision, error) {
+	return rds.ReadWriteTx(ctx, common.SingleAttemptTxFunc(func(rwt datastore.ReadWriteTransaction) error {
+		_, err := common.BulkLoadInBatches(ctx, source, common.DefaultBulkLoadBatchSize, rwt.WriteRelationships)
+		return err
+	}))
+}
+
+// Close closes the data store. The keys of the datastore are left on the server, and the client
+// is left open if it was shared.
+func (rds *Datastore) Close() error {
+	rds.cancelGc()
+	if rds.gcGroup != nil {
+		if err := rds.gcGroup.Wait(); err != nil {
+			log.Error().Err(err).Msg("error waiting for garbage collector to shutdown")
+		}
+	}
+	if rds.ownsClient {
+		return rds.client.Close()
+	}
+	return nil
+}
+
+// IsReady returns whether the datastore is ready to accept data, which is once the server
+// responds.
+func (rds *Datastore) IsReady(ctx context.Context) (bool, error) {
+	if _, err := rds.client.Do(ctx, "PING"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (rds *Datastore) Features(ctx context.Context) (*datastore.Features, error) {
+	return &datastore.Features{
+		Watch:                  datastore.Feature{Enabled: true},
+		WatchCheckpoints:       datastore.Feature{Enabled: true},
+		Caveats:                datastore.Feature{Enabled: true},
+		BulkLoad:               datastore.Feature{Enabled: true},
+		ReverseQuerySort:       datastore.Feature{Enabled: true},
+		RelationshipExpiration: datastore.Feature{Enabled: true},
+	}, nil
+}
+
+func revisionFromTransaction(txID uint64) revision.Decimal {
+	return revision.NewFromDecimal(decimal.NewFromBigInt(new(big.Int).SetUint64(txID), 0))
+}
+
+func transactionFromRevision(revision revision.Decimal) uint64 {
+	return uint64(revision.IntPart())
+}
+
+// nowMicros returns the current time in microseconds, which scores the transactions.
+func nowMicros() int64 {
+	return time.Now().UnixMicro()
+}
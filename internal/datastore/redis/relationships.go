@@ -0,0 +1,222 @@
+package redis
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jzelinskie/stringz"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// liveTransaction is the revision at which read/write transactions read, at which every living
+// relationship and definition is read.
+const liveTransaction = uint64(math.MaxUint64)
+
+// relationshipVersion is a version of a relationship, which was created by one transaction and
+// may have been deleted by a later one. Versions are stored as JSON with short field names, as
+// every version is kept in memory by the server.
+type relationshipVersion struct {
+	Namespace        string `json:"ns"`
+	ObjectID         string `json:"id"`
+	Relation         string `json:"rel"`
+	SubjectNamespace string `json:"sns"`
+	SubjectObjectID  string `json:"sid"`
+	SubjectRelation  string `json:"srel"`
+
+	CaveatName    string            `json:"cav,omitempty"`
+	CaveatContext map[string]any    `json:"ctx,omitempty"`
+	Metadata      map[string]string `json:"md,omitempty"`
+
+	// Expiration is the expiration of the relationship in nanoseconds, or zero if it never
+	// expires.
+	Expiration int64 `json:"exp,omitempty"`
+
+	// Created is the transaction which created the version, and Deleted the transaction which
+	// deleted it, or zero if it is living.
+	Created uint64 `json:"c"`
+	Deleted uint64 `json:"d,omitempty"`
+}
+
+func versionOf(tpl *core.RelationTuple, created uint64) *relationshipVersion {
+	version := &relationshipVersion{
+		Namespace:        tpl.ResourceAndRelation.Namespace,
+		ObjectID:         tpl.ResourceAndRelation.ObjectId,
+		Relation:         tpl.ResourceAndRelation.Relation,
+		SubjectNamespace: tpl.Subject.Namespace,
+		SubjectObjectID:  tpl.Subject.ObjectId,
+		SubjectRelation:  tpl.Subject.Relation,
+		Metadata:         tpl.OptionalMetadata,
+		Created:          created,
+	}
+	if tpl.Caveat != nil {
+		version.CaveatName = tpl.Caveat.CaveatName
+		version.CaveatContext = tpl.Caveat.Context.AsMap()
+	}
+	if tpl.OptionalExpirationTime != nil {
+		version.Expiration = tpl.OptionalExpirationTime.AsTime().UnixNano()
+	}
+	return version
+}
+
+func (rv *relationshipVersion) tuple() (*core.RelationTuple, error) {
+	tpl := &core.RelationTuple{
+		ResourceAndRelation: &core.ObjectAndRelation{
+			Namespace: rv.Namespace,
+			ObjectId:  rv.ObjectID,
+			Relation:  rv.Relation,
+		},
+		Subject: &core.ObjectAndRelation{
+			Namespace: rv.SubjectNamespace,
+			ObjectId:  rv.SubjectObjectID,
+			Relation:  rv.SubjectRelation,
+		},
+		OptionalMetadata: rv.Metadata,
+	}
+
+	var err error
+	tpl.Caveat, err = common.ContextualizedCaveatFrom(rv.CaveatName, rv.CaveatContext)
+	if err != nil {
+		return nil, err
+	}
+
+	if rv.Expiration != 0 {
+		tpl.OptionalExpirationTime = common.ExpirationFrom(sql.NullTime{
+			Time:  time.Unix(0, rv.Expiration).UTC(),
+			Valid: true,
+		})
+	}
+	return tpl, nil
+}
+
+// key identifies the relationship across its versions.
+func (rv *relationshipVersion) key() string {
+	return relationshipKey(
+		rv.Namespace, rv.ObjectID, rv.Relation,
+		rv.SubjectNamespace, rv.SubjectObjectID, rv.SubjectRelation,
+	)
+}
+
+// id identifies the version, as a relationship is created at most once by a transaction.
+func (rv *relationshipVersion) id() string {
+	return strconv.FormatUint(rv.Created, 10) + "|" + rv.key()
+}
+
+func (rv *relationshipVersion) expired(now time.Time) bool {
+	return rv.Expiration != 0 && rv.Expiration <= now.UnixNano()
+}
+
+// visibleAt returns whether the version is the version of its relationship at the transaction,
+// and the relationship has not expired.
+func (rv *relationshipVersion) visibleAt(txn uint64, now time.Time) bool {
+	return rv.Created <= txn && (rv.Deleted == 0 || rv.Deleted > txn) && !rv.expired(now)
+}
+
+func relationshipKey(namespace, objectID, relation, subjectNamespace, subjectObjectID, subjectRelation string) string {
+	return fmt.Sprintf("%s:%s#%s@%s:%s#%s", namespace, objectID, relation, subjectNamespace, subjectObjectID, subjectRelation)
+}
+
+func keyOf(tpl *core.RelationTuple) string {
+	return relationshipKey(
+		tpl.ResourceAndRelation.Namespace, tpl.ResourceAndRelation.ObjectId, tpl.ResourceAndRelation.Relation,
+		tpl.Subject.Namespace, tpl.Subject.ObjectId, tpl.Subject.Relation,
+	)
+}
+
+// matchesFilter returns whether the relationship matches every part of the filter, and is one
+// of the usersets, if any.
+func matchesFilter(tpl *core.RelationTuple, filter datastore.RelationshipsFilter, usersets []*core.ObjectAndRelation) bool {
+	switch {
+	case filter.ResourceType != "" && filter.ResourceType != tpl.ResourceAndRelation.Namespace:
+		return false
+	case len(filter.OptionalResourceIds) > 0 && !stringz.SliceContains(filter.OptionalResourceIds, tpl.ResourceAndRelation.ObjectId):
+		return false
+	case filter.OptionalResourceRelation != "" && filter.OptionalResourceRelation != tpl.ResourceAndRelation.Relation:
+		return false
+	case filter.OptionalCaveatName != "" && (tpl.Caveat == nil || tpl.Caveat.CaveatName != filter.OptionalCaveatName):
+		return false
+	case filter.OptionalSubjectsFilter != nil && !matchesSubjectsFilter(tpl, *filter.OptionalSubjectsFilter):
+		return false
+	}
+
+	for key, value := range filter.OptionalMetadata {
+		found, ok := tpl.OptionalMetadata[key]
+		if !ok || (value != "" && value != found) {
+			return false
+		}
+	}
+
+	if len(usersets) == 0 {
+		return true
+	}
+	for _, userset := range usersets {
+		if userset.Namespace == tpl.Subject.Namespace &&
+			userset.ObjectId == tpl.Subject.ObjectId &&
+			userset.Relation == tpl.Subject.Relation {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesSubjectsFilter(tpl *core.RelationTuple, filter datastore.SubjectsFilter) bool {
+	if filter.SubjectType != tpl.Subject.Namespace {
+		return false
+	}
+	if len(filter.OptionalSubjectIds) > 0 && !stringz.SliceContains(filter.OptionalSubjectIds, tpl.Subject.ObjectId) {
+		return false
+	}
+	if filter.RelationFilter.IsEmpty() {
+		return true
+	}
+	return (filter.RelationFilter.IncludeEllipsisRelation && tpl.Subject.Relation == datastore.Ellipsis) ||
+		(filter.RelationFilter.NonEllipsisRelation != "" && tpl.Subject.Relation == filter.RelationFilter.NonEllipsisRelation)
+}
+
+// sortBySubject sorts the relationships by subject and then by resource.
+func sortBySubject(relationships []*core.RelationTuple) {
+	sort.Slice(relationships, func(i, j int) bool {
+		return compareBySubject(relationships[i], relationships[j]) < 0
+	})
+}
+
+func compareBySubject(a, b *core.RelationTuple) int {
+	for _, pair := range [][2]string{
+		{a.Subject.Namespace, b.Subject.Namespace},
+		{a.Subject.ObjectId, b.Subject.ObjectId},
+		{a.Subject.Relation, b.Subject.Relation},
+		{a.ResourceAndRelation.Namespace, b.ResourceAndRelation.Namespace},
+		{a.ResourceAndRelation.ObjectId, b.ResourceAndRelation.ObjectId},
+		{a.ResourceAndRelation.Relation, b.ResourceAndRelation.Relation},
+	} {
+		switch {
+		case pair[0] < pair[1]:
+			return -1
+		case pair[0] > pair[1]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// afterCursor returns the sorted relationships which follow the cursor.
+func afterCursor(sorted []*core.RelationTuple, cursor options.Cursor) []*core.RelationTuple {
+	index := sort.Search(len(sorted), func(i int) bool {
+		return compareBySubject(sorted[i], cursor) > 0
+	})
+	return sorted[index:]
+}
+
+func limited(relationships []*core.RelationTuple, limit *uint64) []*core.RelationTuple {
+	if limit != nil && uint64(len(relationships)) > *limit {
+		return relationships[:*limit]
+	}
+	return relationships
+}
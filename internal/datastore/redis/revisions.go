@@ -0,0 +1,121 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+const (
+	errRevision      = "unable to find revision: %w"
+	errCheckRevision = "unable to check revision: %w"
+)
+
+func (rds *Datastore) optimizedRevisionFunc(ctx context.Context) (datastore.Revision, time.Duration, error) {
+	quantization := rds.revisionQuantization.Microseconds()
+	if quantization < 1 {
+		quantization = 1
+	}
+
+	now := nowMicros()
+	periodStart := now - now%quantization
+	validFor := time.Duration(quantization-now%quantization) * time.Microsecond
+
+	// Picks the first transaction at or after the start of the current quantization period, or
+	// the latest transaction if there are none.
+	txn, found, err := rds.firstTransactionSince(ctx, periodStart)
+	if err != nil {
+		return revision.NoRevision, 0, fmt.Errorf(errRevision, err)
+	}
+	if !found {
+		if txn, err = readHead(ctx, rds.client, rds.keys); err != nil {
+			return revision.NoRevision, 0, fmt.Errorf(errRevision, err)
+		}
+	}
+	return revisionFromTransaction(txn), validFor, nil
+}
+
+func (rds *Datastore) HeadRevision(ctx context.Context) (datastore.Revision, error) {
+	ctx, span := tracer.Start(ctx, "HeadRevision")
+	defer span.End()
+
+	txn, err := readHead(ctx, rds.client, rds.keys)
+	if err != nil {
+		return datastore.NoRevision, fmt.Errorf(errRevision, err)
+	}
+	return revisionFromTransaction(txn), nil
+}
+
+func (rds *Datastore) CheckRevision(ctx context.Context, revisionRaw datastore.Revision) error {
+	if revisionRaw == datastore.NoRevision {
+		return datastore.NewInvalidRevisionErr(revisionRaw, datastore.CouldNotDetermineRevision)
+	}
+
+	rev := revisionRaw.(revision.Decimal)
+	revisionTx := transactionFromRevision(rev)
+
+	ctx, span := tracer.Start(ctx, "checkValidTransaction")
+	defer span.End()
+
+	head, err := readHead(ctx, rds.client, rds.keys)
+	if err != nil {
+		return fmt.Errorf(errCheckRevision, err)
+	}
+
+	// The latest transaction is always fresh enough, even if it is older than the garbage
+	// collection window.
+	oldest, found, err := rds.firstTransactionSince(ctx, time.Now().Add(-rds.gcWindow).UnixMicro())
+	if err != nil {
+		return fmt.Errorf(errCheckRevision, err)
+	}
+	if !found {
+		oldest = head
+	}
+
+	if revisionTx < oldest {
+		return datastore.NewInvalidRevisionErr(rev, datastore.RevisionStale)
+	}
+	if revisionTx > head {
+		return datastore.NewInvalidRevisionErr(rev, datastore.CouldNotDetermineRevision)
+	}
+
+	return nil
+}
+
+// firstTransactionSince returns the first transaction which committed at or after the time in
+// microseconds, if any.
+func (rds *Datastore) firstTransactionSince(ctx context.Context, micros int64) (uint64, bool, error) {
+	reply, err := rds.client.Do(ctx, "ZRANGEBYSCORE", rds.keys.transactions(), micros, "+inf", "LIMIT", 0, 1)
+	if err != nil {
+		return 0, false, err
+	}
+
+	members := stringsOf(reply)
+	if len(members) == 0 {
+		return 0, false, nil
+	}
+
+	txn, err := strconv.ParseUint(members[0], 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return txn, true, nil
+}
+
+// readHead returns the latest transaction.
+func readHead(ctx context.Context, conn commander, k keys) (uint64, error) {
+	reply, err := conn.Do(ctx, "GET", k.head())
+	if err != nil {
+		return 0, err
+	}
+
+	encoded, ok := reply.([]byte)
+	if !ok {
+		return 0, nil
+	}
+	return strconv.ParseUint(string(encoded), 10, 64)
+}
@@ -0,0 +1,63 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+// Statistics counts the living relationships from the keys of the living hash, which includes
+// any relationships which have expired but not yet been garbage collected.
+func (rds *Datastore) Statistics(ctx context.Context) (datastore.Stats, error) {
+	reply, err := rds.client.Do(ctx, "GET", rds.keys.uniqueID())
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to query unique ID: %w", err)
+	}
+	uniqueID, ok := reply.([]byte)
+	if !ok {
+		return datastore.Stats{}, fmt.Errorf("unable to query unique ID: no unique ID found")
+	}
+
+	reply, err = rds.client.Do(ctx, "HKEYS", rds.keys.living())
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to count relationships: %w", err)
+	}
+
+	var count uint64
+	relationshipEstimates := make(map[string]uint64)
+	resources := make(map[string]struct{})
+	subjects := make(map[string]struct{})
+	for _, key := range stringsOf(reply) {
+		resource, subject, found := strings.Cut(key, "@")
+		if !found {
+			continue
+		}
+
+		namespace, _, _ := strings.Cut(resource, ":")
+		relationshipEstimates[namespace]++
+		resources[objectOf(resource)] = struct{}{}
+		subjects[objectOf(subject)] = struct{}{}
+		count++
+	}
+
+	nsDefs, err := rds.SnapshotReader(revisionFromTransaction(liveTransaction)).ListNamespaces(ctx)
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to load namespaces: %w", err)
+	}
+
+	return datastore.Stats{
+		UniqueID:                   string(uniqueID),
+		ObjectTypeStatistics:       datastore.ComputeObjectTypeStatsWithEstimates(nsDefs, relationshipEstimates),
+		EstimatedRelationshipCount: count,
+		EstimatedDistinctResources: uint64(len(resources)),
+		EstimatedDistinctSubjects:  uint64(len(subjects)),
+	}, nil
+}
+
+// objectOf returns the object of an object and relation of a relationship key.
+func objectOf(objectAndRelation string) string {
+	object, _, _ := strings.Cut(objectAndRelation, "#")
+	return object
+}
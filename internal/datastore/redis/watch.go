@@ -0,0 +1,143 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	watchSleep = 100 * time.Millisecond
+)
+
+// Watch notifies the caller about all changes to tuples.
+//
+// All events following afterRevision will be sent to the caller. Changes are read from the
+// sorted set of changes, which is written by each transaction as it commits.
+func (rds *Datastore) Watch(ctx context.Context, afterRevisionRaw datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
+	afterRevision := afterRevisionRaw.(revision.Decimal)
+
+	updates := make(chan *datastore.RevisionChanges, rds.watchBufferLength)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(updates)
+		defer close(errs)
+
+		currentTxn := transactionFromRevision(afterRevision)
+
+		lastSent := time.Now()
+
+		for {
+			var stagedUpdates []*datastore.RevisionChanges
+			var err error
+			stagedUpdates, currentTxn, err = rds.loadChanges(ctx, currentTxn)
+			if err != nil {
+				if errors.Is(ctx.Err(), context.Canceled) {
+					errs <- datastore.NewWatchCanceledErr()
+				} else {
+					errs <- err
+				}
+				return
+			}
+
+			// Write the staged updates to the channel
+			for _, changeToWrite := range stagedUpdates {
+				select {
+				case updates <- changeToWrite:
+				default:
+					errs <- datastore.NewWatchDisconnectedErr()
+					return
+				}
+				lastSent = time.Now()
+			}
+
+			// If there were no changes, send a checkpoint if none has been sent within the
+			// interval, and sleep a bit
+			if len(stagedUpdates) == 0 {
+				if time.Since(lastSent) >= common.WatchCheckpointInterval {
+					select {
+					case updates <- &datastore.RevisionChanges{
+						Revision:     revisionFromTransaction(currentTxn),
+						IsCheckpoint: true,
+					}:
+					default:
+						errs <- datastore.NewWatchDisconnectedErr()
+						return
+					}
+					lastSent = time.Now()
+				}
+
+				sleep := time.NewTimer(watchSleep)
+
+				select {
+				case <-sleep.C:
+					break
+				case <-ctx.Done():
+					errs <- datastore.NewWatchCanceledErr()
+					return
+				}
+			}
+		}
+	}()
+
+	return updates, errs
+}
+
+func (rds *Datastore) loadChanges(
+	ctx context.Context,
+	afterRevision uint64,
+) (changes []*datastore.RevisionChanges, newRevision uint64, err error) {
+	newRevision, err = readHead(ctx, rds.client, rds.keys)
+	if err != nil {
+		return
+	}
+	if newRevision <= afterRevision {
+		newRevision = afterRevision
+		return
+	}
+
+	// Each transaction writes its changes together with the head, so that every change of a
+	// transaction at or before the head has been written.
+	reply, err := rds.client.Do(ctx, "ZRANGEBYSCORE", rds.keys.changes(),
+		fmt.Sprintf("(%d", afterRevision), newRevision)
+	if err != nil {
+		return
+	}
+
+	stagedChanges := common.NewChanges()
+	for _, encoded := range stringsOf(reply) {
+		var c change
+		if err = json.Unmarshal([]byte(encoded), &c); err != nil {
+			return
+		}
+
+		if c.Relationship == nil {
+			stagedChanges.SetRevisionMetadata(revisionFromTransaction(c.Transaction), c.Metadata)
+			continue
+		}
+
+		var tpl *core.RelationTuple
+		if tpl, err = c.Relationship.tuple(); err != nil {
+			return
+		}
+
+		switch c.Operation {
+		case changeTouched:
+			stagedChanges.AddChange(ctx, revisionFromTransaction(c.Transaction), tpl, core.RelationTupleUpdate_TOUCH)
+		case changeDeleted:
+			stagedChanges.AddChange(ctx, revisionFromTransaction(c.Transaction), tpl, core.RelationTupleUpdate_DELETE)
+		}
+	}
+
+	changes = stagedChanges.AsRevisionChanges(rds)
+
+	return
+}
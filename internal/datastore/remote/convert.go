@@ -0,0 +1,338 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dsv1 "github.com/authzed/spicedb/pkg/proto/datastore/v1"
+)
+
+// The messages of reconstructed errors are prefixed by their constructors.
+const (
+	unavailablePrefix   = "datastore is temporarily unavailable: "
+	watchDisabledPrefix = "watch is currently disabled: "
+)
+
+var decoder revision.DecimalDecoder
+
+func revisionToString(rev datastore.Revision) string {
+	if rev == datastore.NoRevision {
+		return ""
+	}
+	return rev.String()
+}
+
+// revisionFromString parses a revision received from a remote datastore, which must be a
+// decimal.
+func revisionFromString(serialized string) (datastore.Revision, error) {
+	if serialized == "" {
+		return datastore.NoRevision, nil
+	}
+	return decoder.RevisionFromString(serialized)
+}
+
+func filterToMessage(filter datastore.RelationshipsFilter) *dsv1.RelationshipsFilter {
+	return &dsv1.RelationshipsFilter{
+		ResourceType:             filter.ResourceType,
+		OptionalResourceIds:      filter.OptionalResourceIds,
+		OptionalResourceRelation: filter.OptionalResourceRelation,
+		OptionalSubjectsFilter:   subjectsFilterToMessage(filter.OptionalSubjectsFilter),
+		OptionalCaveatName:       filter.OptionalCaveatName,
+		OptionalMetadata:         filter.OptionalMetadata,
+	}
+}
+
+func filterFromMessage(filter *dsv1.RelationshipsFilter) datastore.RelationshipsFilter {
+	return datastore.RelationshipsFilter{
+		ResourceType:             filter.GetResourceType(),
+		OptionalResourceIds:      filter.GetOptionalResourceIds(),
+		OptionalResourceRelation: filter.GetOptionalResourceRelation(),
+		OptionalSubjectsFilter:   subjectsFilterFromMessage(filter.GetOptionalSubjectsFilter()),
+		OptionalCaveatName:       filter.GetOptionalCaveatName(),
+		OptionalMetadata:         filter.GetOptionalMetadata(),
+	}
+}
+
+func subjectsFilterToMessage(filter *datastore.SubjectsFilter) *dsv1.SubjectsFilter {
+	if filter == nil {
+		return nil
+	}
+
+	return &dsv1.SubjectsFilter{
+		SubjectType:             filter.SubjectType,
+		OptionalSubjectIds:      filter.OptionalSubjectIds,
+		NonEllipsisRelation:     filter.RelationFilter.NonEllipsisRelation,
+		IncludeEllipsisRelation: filter.RelationFilter.IncludeEllipsisRelation,
+	}
+}
+
+func subjectsFilterFromMessage(filter *dsv1.SubjectsFilter) *datastore.SubjectsFilter {
+	if filter == nil {
+		return nil
+	}
+
+	return &datastore.SubjectsFilter{
+		SubjectType:        filter.SubjectType,
+		OptionalSubjectIds: filter.OptionalSubjectIds,
+		RelationFilter: datastore.SubjectRelationFilter{
+			NonEllipsisRelation:     filter.NonEllipsisRelation,
+			IncludeEllipsisRelation: filter.IncludeEllipsisRelation,
+		},
+	}
+}
+
+func deleteFilterToMessage(filter *v1.RelationshipFilter) *dsv1.DeleteRelationshipsFilter {
+	converted := &dsv1.DeleteRelationshipsFilter{
+		ResourceType:       filter.ResourceType,
+		OptionalResourceId: filter.OptionalResourceId,
+		OptionalRelation:   filter.OptionalRelation,
+	}
+
+	if subjectFilter := filter.OptionalSubjectFilter; subjectFilter != nil {
+		converted.OptionalSubjectFilter = &dsv1.DeleteRelationshipsFilter_SubjectFilter{
+			SubjectType:       subjectFilter.SubjectType,
+			OptionalSubjectId: subjectFilter.OptionalSubjectId,
+		}
+		if subjectFilter.OptionalRelation != nil {
+			converted.OptionalSubjectFilter.OptionalRelation = &dsv1.DeleteRelationshipsFilter_SubjectFilter_RelationFilter{
+				Relation: subjectFilter.OptionalRelation.Relation,
+			}
+		}
+	}
+
+	return converted
+}
+
+func deleteFilterFromMessage(filter *dsv1.DeleteRelationshipsFilter) *v1.RelationshipFilter {
+	converted := &v1.RelationshipFilter{
+		ResourceType:       filter.GetResourceType(),
+		OptionalResourceId: filter.GetOptionalResourceId(),
+		OptionalRelation:   filter.GetOptionalRelation(),
+	}
+
+	if subjectFilter := filter.GetOptionalSubjectFilter(); subjectFilter != nil {
+		converted.OptionalSubjectFilter = &v1.SubjectFilter{
+			SubjectType:       subjectFilter.SubjectType,
+			OptionalSubjectId: subjectFilter.OptionalSubjectId,
+		}
+		if subjectFilter.OptionalRelation != nil {
+			converted.OptionalSubjectFilter.OptionalRelation = &v1.SubjectFilter_RelationFilter{
+				Relation: subjectFilter.OptionalRelation.Relation,
+			}
+		}
+	}
+
+	return converted
+}
+
+func resourceRelationToMessage(resRelation *options.ResourceRelation) *core.RelationReference {
+	if resRelation == nil {
+		return nil
+	}
+	return &core.RelationReference{Namespace: resRelation.Namespace, Relation: resRelation.Relation}
+}
+
+func resourceRelationFromMessage(resRelation *core.RelationReference) *options.ResourceRelation {
+	if resRelation == nil {
+		return nil
+	}
+	return &options.ResourceRelation{Namespace: resRelation.Namespace, Relation: resRelation.Relation}
+}
+
+func limitToMessage(limit *uint64) uint64 {
+	if limit == nil {
+		return 0
+	}
+	return *limit
+}
+
+func limitFromMessage(limit uint64) *uint64 {
+	if limit == 0 {
+		return nil
+	}
+	return &limit
+}
+
+func sortOrderFromMessage(sortBySubject bool) options.SortOrder {
+	if sortBySubject {
+		return options.BySubject
+	}
+	return options.Unsorted
+}
+
+var consistencyToMessage = map[options.ReadConsistency]dsv1.SnapshotReadRequest_ReadConsistency{
+	options.ExactSnapshot:    dsv1.SnapshotReadRequest_EXACT_SNAPSHOT,
+	options.MinimizeLatency:  dsv1.SnapshotReadRequest_MINIMIZE_LATENCY,
+	options.BoundedStaleness: dsv1.SnapshotReadRequest_BOUNDED_STALENESS,
+}
+
+var consistencyFromMessage = map[dsv1.SnapshotReadRequest_ReadConsistency]options.ReadConsistency{
+	dsv1.SnapshotReadRequest_EXACT_SNAPSHOT:    options.ExactSnapshot,
+	dsv1.SnapshotReadRequest_MINIMIZE_LATENCY:  options.MinimizeLatency,
+	dsv1.SnapshotReadRequest_BOUNDED_STALENESS: options.BoundedStaleness,
+}
+
+// errorToMessage describes an error returned by a datastore, so that it can be reconstructed
+// by the client with errorFromMessage.
+func errorToMessage(err error) *dsv1.Error {
+	converted := &dsv1.Error{Message: err.Error()}
+
+	var nsNotFound datastore.ErrNamespaceNotFound
+	var caveatNotFound datastore.ErrCaveatNameNotFound
+	var invalidRevision datastore.ErrInvalidRevision
+	var unavailable datastore.ErrUnavailable
+	var relExists common.CreateRelationshipExistsError
+
+	switch {
+	case errors.As(err, &nsNotFound):
+		converted.Kind = dsv1.Error_NAMESPACE_NOT_FOUND
+		converted.Name = nsNotFound.NotFoundNamespaceName()
+	case errors.As(err, &caveatNotFound):
+		converted.Kind = dsv1.Error_CAVEAT_NOT_FOUND
+		converted.Name = caveatNotFound.CaveatName()
+	case errors.As(err, &invalidRevision):
+		converted.Kind = dsv1.Error_INVALID_REVISION
+		converted.Revision = revisionToString(invalidRevision.InvalidRevision())
+		if invalidRevision.Reason() == datastore.CouldNotDetermineRevision {
+			converted.InvalidRevisionReason = dsv1.Error_COULD_NOT_DETERMINE_REVISION
+		}
+	case errors.As(err, &datastore.ErrReadOnly{}):
+		converted.Kind = dsv1.Error_READ_ONLY
+	case errors.As(err, &unavailable):
+		converted.Kind = dsv1.Error_UNAVAILABLE
+		converted.RetryAfter = durationpb.New(unavailable.RetryAfter())
+	case errors.As(err, &datastore.ErrWatchDisconnected{}):
+		converted.Kind = dsv1.Error_WATCH_DISCONNECTED
+	case errors.As(err, &datastore.ErrWatchCanceled{}):
+		converted.Kind = dsv1.Error_WATCH_CANCELED
+	case errors.As(err, &datastore.ErrWatchDisabled{}):
+		converted.Kind = dsv1.Error_WATCH_DISABLED
+	case errors.As(err, &relExists):
+		converted.Kind = dsv1.Error_CREATE_RELATIONSHIP_EXISTS
+		converted.Relationship = relExists.Relationship
+	}
+
+	return converted
+}
+
+func errorFromMessage(err *dsv1.Error) error {
+	switch err.Kind {
+	case dsv1.Error_NAMESPACE_NOT_FOUND:
+		return datastore.NewNamespaceNotFoundErr(err.Name)
+	case dsv1.Error_CAVEAT_NOT_FOUND:
+		return datastore.NewCaveatNameNotFoundErr(err.Name)
+	case dsv1.Error_INVALID_REVISION:
+		rev, parseErr := revisionFromString(err.Revision)
+		if parseErr != nil {
+			rev = datastore.NoRevision
+		}
+
+		reason := datastore.RevisionStale
+		if err.InvalidRevisionReason == dsv1.Error_COULD_NOT_DETERMINE_REVISION {
+			reason = datastore.CouldNotDetermineRevision
+		}
+		return datastore.NewInvalidRevisionErr(rev, reason)
+	case dsv1.Error_READ_ONLY:
+		return datastore.NewReadonlyErr()
+	case dsv1.Error_UNAVAILABLE:
+		return datastore.NewUnavailableErr(strings.TrimPrefix(err.Message, unavailablePrefix), err.RetryAfter.AsDuration())
+	case dsv1.Error_WATCH_DISCONNECTED:
+		return datastore.NewWatchDisconnectedErr()
+	case dsv1.Error_WATCH_CANCELED:
+		return datastore.NewWatchCanceledErr()
+	case dsv1.Error_WATCH_DISABLED:
+		return datastore.NewWatchDisabledErr(strings.TrimPrefix(err.Message, watchDisabledPrefix))
+	case dsv1.Error_CREATE_RELATIONSHIP_EXISTS:
+		return common.NewCreateRelationshipExistsError(err.Relationship)
+	default:
+		return errors.New(err.Message)
+	}
+}
+
+var codeForErrorKind = map[dsv1.Error_Kind]codes.Code{
+	dsv1.Error_UNKNOWN:                    codes.Unknown,
+	dsv1.Error_NAMESPACE_NOT_FOUND:        codes.NotFound,
+	dsv1.Error_CAVEAT_NOT_FOUND:           codes.NotFound,
+	dsv1.Error_INVALID_REVISION:           codes.OutOfRange,
+	dsv1.Error_READ_ONLY:                  codes.Unavailable,
+	dsv1.Error_UNAVAILABLE:                codes.Unavailable,
+	dsv1.Error_WATCH_DISCONNECTED:         codes.Aborted,
+	dsv1.Error_WATCH_CANCELED:             codes.Canceled,
+	dsv1.Error_WATCH_DISABLED:             codes.FailedPrecondition,
+	dsv1.Error_CREATE_RELATIONSHIP_EXISTS: codes.AlreadyExists,
+}
+
+// errorToStatus converts an error returned by a datastore into a gRPC status error carrying its
+// description.
+func errorToStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	converted := errorToMessage(err)
+	st, detailsErr := status.New(codeForErrorKind[converted.Kind], err.Error()).WithDetails(converted)
+	if detailsErr != nil {
+		return status.Error(codes.Internal, detailsErr.Error())
+	}
+	return st.Err()
+}
+
+// errorFromStatus reconstructs a datastore error from an error returned by a call to a remote
+// datastore.
+func errorFromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	for _, detail := range st.Details() {
+		if converted, ok := detail.(*dsv1.Error); ok {
+			return errorFromMessage(converted)
+		}
+	}
+
+	switch st.Code() {
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	default:
+		return err
+	}
+}
+
+func featureToMessage(feature datastore.Feature) *dsv1.FeaturesResponse_Feature {
+	return &dsv1.FeaturesResponse_Feature{Enabled: feature.Enabled, Reason: feature.Reason}
+}
+
+// featureFromMessage converts a feature reported by the service. A feature which is not
+// reported, such as by an older service, is disabled.
+func featureFromMessage(feature *dsv1.FeaturesResponse_Feature) datastore.Feature {
+	if feature == nil {
+		return datastore.Feature{Enabled: false, Reason: "not reported by the datastore service"}
+	}
+	return datastore.Feature{Enabled: feature.Enabled, Reason: feature.Reason}
+}
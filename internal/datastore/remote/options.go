@@ -0,0 +1,65 @@
+package remote
+
+import "google.golang.org/grpc"
+
+type remoteOptions struct {
+	watchBufferLength uint16
+	caCertPath        string
+	presharedKey      string
+	dialOptions       []grpc.DialOption
+}
+
+const (
+	defaultWatchBufferLength = 128
+	defaultBulkLoadBatchSize = 1000
+)
+
+// Option provides the facility to configure how the remote datastore connects to and interacts
+// with the datastore service.
+type Option func(*remoteOptions)
+
+func generateConfig(options []Option) remoteOptions {
+	computed := remoteOptions{
+		watchBufferLength: defaultWatchBufferLength,
+	}
+
+	for _, option := range options {
+		option(&computed)
+	}
+
+	return computed
+}
+
+// WatchBufferLength is the number of entries that can be stored in the watch
+// buffer while awaiting read by the client.
+//
+// This value defaults to 128.
+func WatchBufferLength(watchBufferLength uint16) Option {
+	return func(ro *remoteOptions) {
+		if watchBufferLength > 0 {
+			ro.watchBufferLength = watchBufferLength
+		}
+	}
+}
+
+// CACertPath is the path to the certificate authority used to verify the TLS certificate of the
+// datastore service. If empty, the connection is made without TLS.
+func CACertPath(path string) Option {
+	return func(ro *remoteOptions) {
+		ro.caCertPath = path
+	}
+}
+
+// PresharedKey is the bearer token sent with each call to the datastore service.
+func PresharedKey(key string) Option {
+	return func(ro *remoteOptions) {
+		ro.presharedKey = key
+	}
+}
+
+// DialOptions are additional options used when dialing the datastore service.
+func DialOptions(opts ...grpc.DialOption) Option {
+	return func(ro *remoteOptions) {
+		ro.dialOptions = append(ro.dialOptions, opts...)
+	}
+}
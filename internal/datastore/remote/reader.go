@@ -0,0 +1,366 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dsv1 "github.com/authzed/spicedb/pkg/proto/datastore/v1"
+)
+
+var errClosedIterator = errors.New("unable to iterate: iterator closed")
+
+// readStream is a stream of responses to a read.
+type readStream interface {
+	Recv() (*dsv1.ReadResponse, error)
+}
+
+// readFunc performs a read against the remote datastore, returning the stream of its responses
+// and a function to cancel the stream once it is no longer needed.
+type readFunc func(ctx context.Context, req *dsv1.ReadRequest) (readStream, context.CancelFunc, error)
+
+type remoteReader struct {
+	read readFunc
+}
+
+func (rr *remoteReader) readSingle(ctx context.Context, req *dsv1.ReadRequest) (*dsv1.ReadResponse, error) {
+	stream, cancel, err := rr.read(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, errorFromStatus(err)
+	}
+	return resp, nil
+}
+
+func (rr *remoteReader) QueryRelationships(ctx context.Context, filter datastore.RelationshipsFilter, opts ...options.QueryOptionsOption) (datastore.RelationshipIterator, error) {
+	queryOpts := options.NewQueryOptionsWithOptions(opts...)
+
+	stream, cancel, err := rr.read(ctx, &dsv1.ReadRequest{
+		Operation: &dsv1.ReadRequest_QueryRelationships_{QueryRelationships: &dsv1.ReadRequest_QueryRelationships{
+			Filter:   filterToMessage(filter),
+			Limit:    limitToMessage(queryOpts.Limit),
+			Usersets: queryOpts.Usersets,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &remoteIterator{stream: stream, cancel: cancel}, nil
+}
+
+func (rr *remoteReader) ReverseQueryRelationships(ctx context.Context, subjectsFilter datastore.SubjectsFilter, opts ...options.ReverseQueryOptionsOption) (datastore.RelationshipIterator, error) {
+	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
+
+	stream, cancel, err := rr.read(ctx, &dsv1.ReadRequest{
+		Operation: &dsv1.ReadRequest_ReverseQueryRelationships_{ReverseQueryRelationships: &dsv1.ReadRequest_ReverseQueryRelationships{
+			SubjectsFilter:   subjectsFilterToMessage(&subjectsFilter),
+			Limit:            limitToMessage(queryOpts.ReverseLimit),
+			ResourceRelation: resourceRelationToMessage(queryOpts.ResRelation),
+			SortBySubject:    queryOpts.ReverseSort == options.BySubject,
+			After:            queryOpts.ReverseAfter,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &remoteIterator{stream: stream, cancel: cancel}, nil
+}
+
+func (rr *remoteReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	resp, err := rr.readSingle(ctx, &dsv1.ReadRequest{
+		Operation: &dsv1.ReadRequest_CountRelationships_{CountRelationships: &dsv1.ReadRequest_CountRelationships{
+			Filter: filterToMessage(filter),
+		}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp.GetCount().GetCount(), nil
+}
+
+func (rr *remoteReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	resp, err := rr.readSingle(ctx, &dsv1.ReadRequest{
+		Operation: &dsv1.ReadRequest_ListResourceTypes_{ListResourceTypes: &dsv1.ReadRequest_ListResourceTypes{}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetResourceTypes().GetResourceTypes(), nil
+}
+
+func (rr *remoteReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
+	resp, err := rr.readSingle(ctx, &dsv1.ReadRequest{
+		Operation: &dsv1.ReadRequest_ReadNamespace_{ReadNamespace: &dsv1.ReadRequest_ReadNamespace{Name: nsName}},
+	})
+	if err != nil {
+		return nil, datastore.NoRevision, err
+	}
+
+	lastWritten, err := revisionFromString(resp.GetNamespace().GetLastWrittenRevision())
+	if err != nil {
+		return nil, datastore.NoRevision, err
+	}
+	return resp.GetNamespace().GetDefinition(), lastWritten, nil
+}
+
+func (rr *remoteReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	listOpts := options.NewListOptionsWithOptions(opts...)
+	resp, err := rr.readSingle(ctx, &dsv1.ReadRequest{
+		Operation: &dsv1.ReadRequest_ListNamespaces_{ListNamespaces: &dsv1.ReadRequest_ListNamespaces{
+			Limit: listOpts.ListLimit,
+			After: listOpts.ListAfter,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetNamespaces().GetDefinitions(), nil
+}
+
+func (rr *remoteReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
+	if len(nsNames) == 0 {
+		return nil, nil
+	}
+
+	resp, err := rr.readSingle(ctx, &dsv1.ReadRequest{
+		Operation: &dsv1.ReadRequest_LookupNamespaces_{LookupNamespaces: &dsv1.ReadRequest_LookupNamespaces{Names: nsNames}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetNamespaces().GetDefinitions(), nil
+}
+
+func (rr *remoteReader) ReadCaveatByName(ctx context.Context, name string) (*core.CaveatDefinition, datastore.Revision, error) {
+	resp, err := rr.readSingle(ctx, &dsv1.ReadRequest{
+		Operation: &dsv1.ReadRequest_ReadCaveat_{ReadCaveat: &dsv1.ReadRequest_ReadCaveat{Name: name}},
+	})
+	if err != nil {
+		return nil, datastore.NoRevision, err
+	}
+
+	lastWritten, err := revisionFromString(resp.GetCaveat().GetLastWrittenRevision())
+	if err != nil {
+		return nil, datastore.NoRevision, err
+	}
+	return resp.GetCaveat().GetDefinition(), lastWritten, nil
+}
+
+func (rr *remoteReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	listOpts := options.NewListOptionsWithOptions(opts...)
+	resp, err := rr.readSingle(ctx, &dsv1.ReadRequest{
+		Operation: &dsv1.ReadRequest_ListCaveats_{ListCaveats: &dsv1.ReadRequest_ListCaveats{
+			Limit: listOpts.ListLimit,
+			After: listOpts.ListAfter,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetCaveats().GetDefinitions(), nil
+}
+
+func (rr *remoteReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	if len(caveatNames) == 0 {
+		return nil, nil
+	}
+
+	resp, err := rr.readSingle(ctx, &dsv1.ReadRequest{
+		Operation: &dsv1.ReadRequest_LookupCaveats_{LookupCaveats: &dsv1.ReadRequest_LookupCaveats{Names: caveatNames}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetCaveats().GetDefinitions(), nil
+}
+
+// remoteIterator iterates over the relationships returned in a stream of responses.
+type remoteIterator struct {
+	stream   readStream
+	cancel   context.CancelFunc
+	buffered []*core.RelationTuple
+	done     bool
+	closed   bool
+	err      error
+}
+
+func (ri *remoteIterator) Next() *core.RelationTuple {
+	if ri.closed {
+		ri.err = errClosedIterator
+		return nil
+	}
+
+	for len(ri.buffered) == 0 {
+		if ri.done || ri.err != nil {
+			return nil
+		}
+
+		resp, err := ri.stream.Recv()
+		switch {
+		case errors.Is(err, io.EOF):
+			ri.done = true
+			return nil
+		case err != nil:
+			ri.err = errorFromStatus(err)
+			return nil
+		}
+
+		ri.buffered = resp.GetRelationships().GetRelationships()
+	}
+
+	next := ri.buffered[0]
+	ri.buffered = ri.buffered[1:]
+	return next
+}
+
+func (ri *remoteIterator) Err() error {
+	return ri.err
+}
+
+func (ri *remoteIterator) Close() {
+	if ri.closed {
+		panic("tuple iterator double closed")
+	}
+
+	ri.cancel()
+	ri.buffered = nil
+	ri.closed = true
+}
+
+// singleReadStream returns a single response to a read made in a transaction.
+type singleReadStream struct {
+	resp *dsv1.ReadResponse
+}
+
+func (srs *singleReadStream) Recv() (*dsv1.ReadResponse, error) {
+	if srs.resp == nil {
+		return nil, io.EOF
+	}
+
+	resp := srs.resp
+	srs.resp = nil
+	return resp, nil
+}
+
+type txStream interface {
+	Send(*dsv1.ReadWriteTxRequest) error
+	Recv() (*dsv1.ReadWriteTxResponse, error)
+}
+
+// sendTxRequest sends a request on a transaction stream, returning the status of the stream if
+// it has been terminated by the server.
+func sendTxRequest(stream txStream, req *dsv1.ReadWriteTxRequest) error {
+	err := stream.Send(req)
+	if errors.Is(err, io.EOF) {
+		_, err = stream.Recv()
+	}
+	return errorFromStatus(err)
+}
+
+type remoteReadWriteTx struct {
+	*remoteReader
+	stream txStream
+}
+
+func newRemoteReadWriteTx(stream txStream) *remoteReadWriteTx {
+	rwt := &remoteReadWriteTx{stream: stream}
+	rwt.remoteReader = &remoteReader{func(ctx context.Context, req *dsv1.ReadRequest) (readStream, context.CancelFunc, error) {
+		resp, err := rwt.apply(&dsv1.ReadWriteTxRequest{Operation: &dsv1.ReadWriteTxRequest_Read{Read: req}})
+		if err != nil {
+			return nil, nil, err
+		}
+		return &singleReadStream{resp.GetRead()}, func() {}, nil
+	}}
+	return rwt
+}
+
+// apply performs an operation in the transaction and waits for its result.
+func (rwt *remoteReadWriteTx) apply(req *dsv1.ReadWriteTxRequest) (*dsv1.ReadWriteTxResponse, error) {
+	if err := sendTxRequest(rwt.stream, req); err != nil {
+		return nil, err
+	}
+
+	resp, err := rwt.stream.Recv()
+	if err != nil {
+		return nil, errorFromStatus(err)
+	}
+
+	switch result := resp.Result.(type) {
+	case *dsv1.ReadWriteTxResponse_Error:
+		return nil, errorFromMessage(result.Error)
+	case *dsv1.ReadWriteTxResponse_Read, *dsv1.ReadWriteTxResponse_Written_:
+		return resp, nil
+	default:
+		return nil, fmt.Errorf("unexpected response to remote transaction operation: %T", resp.Result)
+	}
+}
+
+func (rwt *remoteReadWriteTx) WriteRelationships(_ context.Context, mutations []*core.RelationTupleUpdate) error {
+	_, err := rwt.apply(&dsv1.ReadWriteTxRequest{
+		Operation: &dsv1.ReadWriteTxRequest_WriteRelationships_{WriteRelationships: &dsv1.ReadWriteTxRequest_WriteRelationships{
+			Updates: mutations,
+		}},
+	})
+	return err
+}
+
+func (rwt *remoteReadWriteTx) DeleteRelationships(_ context.Context, filter *v1.RelationshipFilter) error {
+	_, err := rwt.apply(&dsv1.ReadWriteTxRequest{
+		Operation: &dsv1.ReadWriteTxRequest_DeleteRelationships_{DeleteRelationships: &dsv1.ReadWriteTxRequest_DeleteRelationships{
+			Filter: deleteFilterToMessage(filter),
+		}},
+	})
+	return err
+}
+
+func (rwt *remoteReadWriteTx) WriteNamespaces(_ context.Context, newConfigs ...*core.NamespaceDefinition) error {
+	_, err := rwt.apply(&dsv1.ReadWriteTxRequest{
+		Operation: &dsv1.ReadWriteTxRequest_WriteNamespaces_{WriteNamespaces: &dsv1.ReadWriteTxRequest_WriteNamespaces{
+			Definitions: newConfigs,
+		}},
+	})
+	return err
+}
+
+func (rwt *remoteReadWriteTx) DeleteNamespaces(_ context.Context, nsNames ...string) error {
+	_, err := rwt.apply(&dsv1.ReadWriteTxRequest{
+		Operation: &dsv1.ReadWriteTxRequest_DeleteNamespaces_{DeleteNamespaces: &dsv1.ReadWriteTxRequest_DeleteNamespaces{
+			Names: nsNames,
+		}},
+	})
+	return err
+}
+
+func (rwt *remoteReadWriteTx) WriteCaveats(_ context.Context, caveats []*core.CaveatDefinition) error {
+	_, err := rwt.apply(&dsv1.ReadWriteTxRequest{
+		Operation: &dsv1.ReadWriteTxRequest_WriteCaveats_{WriteCaveats: &dsv1.ReadWriteTxRequest_WriteCaveats{
+			Definitions: caveats,
+		}},
+	})
+	return err
+}
+
+func (rwt *remoteReadWriteTx) DeleteCaveats(_ context.Context, names []string) error {
+	_, err := rwt.apply(&dsv1.ReadWriteTxRequest{
+		Operation: &dsv1.ReadWriteTxRequest_DeleteCaveats_{DeleteCaveats: &dsv1.ReadWriteTxRequest_DeleteCaveats{
+			Names: names,
+		}},
+	})
+	return err
+}
+
+var (
+	_ datastore.Reader               = &remoteReader{}
+	_ datastore.ReadWriteTransaction = &remoteReadWriteTx{}
+)
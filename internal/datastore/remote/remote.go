@@ -0,0 +1,324 @@
+// Package remote implements a datastore which proxies all operations to an out-of-process
+// implementation of the RemoteDatastoreService, allowing custom storage backends to be used
+// without modifying SpiceDB.
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/authzed/grpcutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dsv1 "github.com/authzed/spicedb/pkg/proto/datastore/v1"
+)
+
+// Engine is the name of the remote datastore engine.
+const Engine = "remote"
+
+// NewRemoteDatastore creates a datastore which proxies to the RemoteDatastoreService served at
+// the given gRPC target.
+func NewRemoteDatastore(target string, options ...Option) (datastore.Datastore, error) {
+	config := generateConfig(options)
+
+	dialOpts := config.dialOptions
+	if config.caCertPath != "" {
+		if _, err := os.Stat(config.caCertPath); err != nil {
+			return nil, fmt.Errorf("unable to read remote datastore CA: %w", err)
+		}
+		dialOpts = append(dialOpts, grpcutil.WithCustomCerts(config.caCertPath, grpcutil.VerifyCA))
+		if config.presharedKey != "" {
+			dialOpts = append(dialOpts, grpcutil.WithBearerToken(config.presharedKey))
+		}
+	} else {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if config.presharedKey != "" {
+			dialOpts = append(dialOpts, grpcutil.WithInsecureBearerToken(config.presharedKey))
+		}
+	}
+
+	conn, err := grpc.Dial(target, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to remote datastore: %w", err)
+	}
+
+	return &remoteDatastore{
+		conn:              conn,
+		client:            dsv1.NewRemoteDatastoreServiceClient(conn),
+		watchBufferLength: config.watchBufferLength,
+	}, nil
+}
+
+type remoteDatastore struct {
+	revision.DecimalDecoder
+
+	conn              *grpc.ClientConn
+	client            dsv1.RemoteDatastoreServiceClient
+	watchBufferLength uint16
+}
+
+func (rd *remoteDatastore) SnapshotReader(rev datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	config := options.NewSnapshotReaderOptionsWithOptions(opts...)
+
+	return &remoteReader{func(ctx context.Context, req *dsv1.ReadRequest) (readStream, context.CancelFunc, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		stream, err := rd.client.SnapshotRead(ctx, &dsv1.SnapshotReadRequest{
+			Revision:     revisionToString(rev),
+			Consistency:  consistencyToMessage[config.Consistency],
+			MaxStaleness: durationpb.New(config.MaxStaleness),
+			Request:      req,
+		})
+		if err != nil {
+			cancel()
+			return nil, nil, errorFromStatus(err)
+		}
+		return stream, cancel, nil
+	}}
+}
+
+func (rd *remoteDatastore) ReadWriteTx(ctx context.Context, fn datastore.TxUserFunc, opts ...options.RWTOptionsOption) (datastore.Revision, error) {
+	config := options.NewRWTOptionsWithOptions(opts...)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := rd.client.ReadWriteTx(ctx)
+	if err != nil {
+		return datastore.NoRevision, errorFromStatus(err)
+	}
+
+	if err := sendTxRequest(stream, &dsv1.ReadWriteTxRequest{
+		Operation: &dsv1.ReadWriteTxRequest_Begin_{Begin: &dsv1.ReadWriteTxRequest_Begin{Metadata: config.Metadata}},
+	}); err != nil {
+		return datastore.NoRevision, err
+	}
+
+	for {
+		fnErr := fn(newRemoteReadWriteTx(stream))
+
+		final := &dsv1.ReadWriteTxRequest{Operation: &dsv1.ReadWriteTxRequest_Commit_{Commit: &dsv1.ReadWriteTxRequest_Commit{}}}
+		if fnErr != nil {
+			final.Operation = &dsv1.ReadWriteTxRequest_Rollback_{
+				Rollback: &dsv1.ReadWriteTxRequest_Rollback{Reason: fnErr.Error()},
+			}
+		}
+
+		sendErr := sendTxRequest(stream, final)
+		if fnErr != nil {
+			// The server asks for the transaction to be restarted if the datastore retries it.
+			if sendErr == nil {
+				if resp, err := stream.Recv(); err == nil && resp.GetRestart() != nil {
+					continue
+				}
+			}
+			return datastore.NoRevision, fnErr
+		}
+		if sendErr != nil {
+			return datastore.NoRevision, sendErr
+		}
+
+		resp, err := stream.Recv()
+		if err != nil {
+			return datastore.NoRevision, errorFromStatus(err)
+		}
+
+		switch result := resp.Result.(type) {
+		case *dsv1.ReadWriteTxResponse_Restart_:
+			continue
+		case *dsv1.ReadWriteTxResponse_Committed_:
+			return revisionFromString(result.Committed.Revision)
+		default:
+			return datastore.NoRevision, fmt.Errorf("unexpected response to commit of remote transaction: %T", resp.Result)
+		}
+	}
+}
+
+func (rd *remoteDatastore) BulkLoad(ctx context.Context, source datastore.BulkWriteRelationshipSource) (datastore.Revision, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := rd.client.BulkLoad(ctx)
+	if err != nil {
+		return datastore.NoRevision, errorFromStatus(err)
+	}
+
+	batch := make([]*core.RelationTuple, 0, defaultBulkLoadBatchSize)
+	sendBatch := func() error {
+		if err := stream.Send(&dsv1.BulkLoadRequest{Relationships: batch}); err != nil {
+			if errors.Is(err, io.EOF) {
+				_, err = stream.CloseAndRecv()
+			}
+			return errorFromStatus(err)
+		}
+		batch = make([]*core.RelationTuple, 0, defaultBulkLoadBatchSize)
+		return nil
+	}
+
+	for {
+		rel, err := source.Next(ctx)
+		if err != nil {
+			return datastore.NoRevision, err
+		}
+		if rel == nil {
+			break
+		}
+
+		batch = append(batch, rel)
+		if len(batch) == defaultBulkLoadBatchSize {
+			if err := sendBatch(); err != nil {
+				return datastore.NoRevision, err
+			}
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := sendBatch(); err != nil {
+			return datastore.NoRevision, err
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return datastore.NoRevision, errorFromStatus(err)
+	}
+	return revisionFromString(resp.Revision)
+}
+
+func (rd *remoteDatastore) OptimizedRevision(ctx context.Context) (datastore.Revision, error) {
+	resp, err := rd.client.OptimizedRevision(ctx, &dsv1.OptimizedRevisionRequest{})
+	if err != nil {
+		return datastore.NoRevision, errorFromStatus(err)
+	}
+	return revisionFromString(resp.Revision)
+}
+
+func (rd *remoteDatastore) HeadRevision(ctx context.Context) (datastore.Revision, error) {
+	resp, err := rd.client.HeadRevision(ctx, &dsv1.HeadRevisionRequest{})
+	if err != nil {
+		return datastore.NoRevision, errorFromStatus(err)
+	}
+	return revisionFromString(resp.Revision)
+}
+
+func (rd *remoteDatastore) CheckRevision(ctx context.Context, rev datastore.Revision) error {
+	_, err := rd.client.CheckRevision(ctx, &dsv1.CheckRevisionRequest{Revision: revisionToString(rev)})
+	return errorFromStatus(err)
+}
+
+func (rd *remoteDatastore) Watch(ctx context.Context, afterRevision datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
+	updates := make(chan *datastore.RevisionChanges, rd.watchBufferLength)
+	errs := make(chan error, 1)
+
+	stream, err := rd.client.Watch(ctx, &dsv1.WatchRequest{AfterRevision: revisionToString(afterRevision)})
+	if err != nil {
+		errs <- errorFromStatus(err)
+		close(updates)
+		close(errs)
+		return updates, errs
+	}
+
+	go func() {
+		defer close(updates)
+		defer close(errs)
+
+		for {
+			resp, err := stream.Recv()
+			switch {
+			case errors.Is(ctx.Err(), context.Canceled):
+				errs <- datastore.NewWatchCanceledErr()
+				return
+			case errors.Is(err, io.EOF):
+				errs <- datastore.NewWatchDisconnectedErr()
+				return
+			case err != nil:
+				errs <- errorFromStatus(err)
+				return
+			}
+
+			rev, err := revisionFromString(resp.Revision)
+			if err != nil {
+				errs <- fmt.Errorf("invalid revision from remote watch: %w", err)
+				return
+			}
+
+			select {
+			case updates <- &datastore.RevisionChanges{
+				Revision:     rev,
+				Changes:      resp.Changes,
+				Metadata:     resp.Metadata,
+				IsCheckpoint: resp.IsCheckpoint,
+			}:
+			default:
+				errs <- datastore.NewWatchDisconnectedErr()
+				return
+			}
+		}
+	}()
+
+	return updates, errs
+}
+
+func (rd *remoteDatastore) IsReady(ctx context.Context) (bool, error) {
+	resp, err := rd.client.IsReady(ctx, &dsv1.IsReadyRequest{})
+	if err != nil {
+		return false, errorFromStatus(err)
+	}
+	return resp.IsReady, nil
+}
+
+func (rd *remoteDatastore) Features(ctx context.Context) (*datastore.Features, error) {
+	resp, err := rd.client.Features(ctx, &dsv1.FeaturesRequest{})
+	if err != nil {
+		return nil, errorFromStatus(err)
+	}
+
+	return &datastore.Features{
+		Watch:                  featureFromMessage(resp.GetWatch()),
+		WatchCheckpoints:       featureFromMessage(resp.GetWatchCheckpoints()),
+		Caveats:                featureFromMessage(resp.GetCaveats()),
+		BulkLoad:               featureFromMessage(resp.GetBulkLoad()),
+		ReverseQuerySort:       featureFromMessage(resp.GetReverseQuerySort()),
+		RelationshipExpiration: featureFromMessage(resp.GetRelationshipExpiration()),
+	}, nil
+}
+
+func (rd *remoteDatastore) Statistics(ctx context.Context) (datastore.Stats, error) {
+	resp, err := rd.client.Statistics(ctx, &dsv1.StatisticsRequest{})
+	if err != nil {
+		return datastore.Stats{}, errorFromStatus(err)
+	}
+
+	objTypeStats := make([]datastore.ObjectTypeStat, 0, len(resp.ObjectTypeStatistics))
+	for _, stat := range resp.ObjectTypeStatistics {
+		objTypeStats = append(objTypeStats, datastore.ObjectTypeStat{
+			Name:                       stat.Name,
+			EstimatedRelationshipCount: stat.EstimatedRelationshipCount,
+			NumRelations:               stat.NumRelations,
+			NumPermissions:             stat.NumPermissions,
+		})
+	}
+
+	return datastore.Stats{
+		UniqueID:                   resp.UniqueId,
+		EstimatedRelationshipCount: resp.EstimatedRelationshipCount,
+		EstimatedDistinctResources: resp.EstimatedDistinctResources,
+		EstimatedDistinctSubjects:  resp.EstimatedDistinctSubjects,
+		EstimatedStorageBytes:      resp.EstimatedStorageBytes,
+		ObjectTypeStatistics:       objTypeStats,
+	}, nil
+}
+
+func (rd *remoteDatastore) Close() error {
+	return rd.conn.Close()
+}
+
+var _ datastore.Datastore = &remoteDatastore{}
@@ -0,0 +1,126 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	test "github.com/authzed/spicedb/pkg/datastore/test"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dsv1 "github.com/authzed/spicedb/pkg/proto/datastore/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+var errTestRollback = errors.New("rolled back by test")
+
+type remoteTest struct{}
+
+func (rt remoteTest) New(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+	backend, err := memdb.NewMemdbDatastore(watchBufferLength, revisionQuantization, gcWindow)
+	if err != nil {
+		return nil, err
+	}
+	return serveRemote(backend, WatchBufferLength(watchBufferLength))
+}
+
+// serveRemote serves the backend over an in-memory connection and returns a remote datastore
+// connected to it.
+func serveRemote(backend datastore.Datastore, options ...Option) (datastore.Datastore, error) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	dsv1.RegisterRemoteDatastoreServiceServer(server, NewRemoteDatastoreServer(backend))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	options = append(options, DialOptions(
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	))
+	return NewRemoteDatastore("bufnet", options...)
+}
+
+func TestRemoteDatastore(t *testing.T) {
+	test.All(t, remoteTest{})
+}
+
+func TestRemoteTransactionErrors(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	backend, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	backend, _ = testfixtures.StandardDatastoreWithSchema(backend, require)
+
+	ds, err := serveRemote(backend)
+	require.NoError(err)
+	t.Cleanup(func() { require.NoError(ds.Close()) })
+
+	// Errors are reconstructed as the datastore errors returned by the backend.
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		_, _, err := rwt.ReadNamespace(ctx, "unknown")
+		return err
+	})
+	require.ErrorAs(err, &datastore.ErrNamespaceNotFound{})
+
+	head, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+
+	_, _, err = ds.SnapshotReader(head).ReadCaveatByName(ctx, "unknown")
+	require.ErrorAs(err, &datastore.ErrCaveatNameNotFound{})
+
+	// Writes made in a transaction which is rolled back are discarded.
+	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		if err := rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+			tuple.Create(tuple.MustParse("document:foo#viewer@user:tom")),
+		}); err != nil {
+			return err
+		}
+		return errTestRollback
+	})
+	require.ErrorIs(err, errTestRollback)
+
+	head, err = ds.HeadRevision(ctx)
+	require.NoError(err)
+
+	count, err := ds.SnapshotReader(head).CountRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType: testfixtures.DocumentNS.Name,
+	})
+	require.NoError(err)
+	require.Zero(count)
+}
+
+func TestRemoteFeatures(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	backend, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	ds, err := serveRemote(backend)
+	require.NoError(err)
+	t.Cleanup(func() { require.NoError(ds.Close()) })
+
+	// The features of the backend are reported by the remote datastore.
+	expected, err := backend.Features(ctx)
+	require.NoError(err)
+
+	features, err := ds.Features(ctx)
+	require.NoError(err)
+	require.Equal(expected, features)
+
+	// A feature which the service does not report is disabled.
+	require.False(featureFromMessage(nil).Enabled)
+}
@@ -0,0 +1,460 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dsv1 "github.com/authzed/spicedb/pkg/proto/datastore/v1"
+)
+
+// relationshipsPerResponse is the maximum number of relationships sent in a single response to
+// a snapshot read.
+const relationshipsPerResponse = 1000
+
+var errTransactionRolledBack = errors.New("transaction rolled back by the client")
+
+type remoteDatastoreServer struct {
+	dsv1.UnimplementedRemoteDatastoreServiceServer
+
+	ds datastore.Datastore
+}
+
+// NewRemoteDatastoreServer creates a server which implements the RemoteDatastoreService over
+// the given datastore. It is the reference implementation of the protocol expected by the
+// remote datastore.
+func NewRemoteDatastoreServer(ds datastore.Datastore) dsv1.RemoteDatastoreServiceServer {
+	return &remoteDatastoreServer{ds: ds}
+}
+
+func (rds *remoteDatastoreServer) revisionFromString(serialized string) (datastore.Revision, error) {
+	if serialized == "" {
+		return datastore.NoRevision, nil
+	}
+
+	rev, err := rds.ds.RevisionFromString(serialized)
+	if err != nil {
+		return datastore.NoRevision, status.Errorf(codes.InvalidArgument, "invalid revision `%s`: %s", serialized, err)
+	}
+	return rev, nil
+}
+
+func (rds *remoteDatastoreServer) HeadRevision(ctx context.Context, _ *dsv1.HeadRevisionRequest) (*dsv1.HeadRevisionResponse, error) {
+	rev, err := rds.ds.HeadRevision(ctx)
+	if err != nil {
+		return nil, errorToStatus(err)
+	}
+	return &dsv1.HeadRevisionResponse{Revision: revisionToString(rev)}, nil
+}
+
+func (rds *remoteDatastoreServer) OptimizedRevision(ctx context.Context, _ *dsv1.OptimizedRevisionRequest) (*dsv1.OptimizedRevisionResponse, error) {
+	rev, err := rds.ds.OptimizedRevision(ctx)
+	if err != nil {
+		return nil, errorToStatus(err)
+	}
+	return &dsv1.OptimizedRevisionResponse{Revision: revisionToString(rev)}, nil
+}
+
+func (rds *remoteDatastoreServer) CheckRevision(ctx context.Context, req *dsv1.CheckRevisionRequest) (*dsv1.CheckRevisionResponse, error) {
+	rev, err := rds.revisionFromString(req.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rds.ds.CheckRevision(ctx, rev); err != nil {
+		return nil, errorToStatus(err)
+	}
+	return &dsv1.CheckRevisionResponse{}, nil
+}
+
+func (rds *remoteDatastoreServer) IsReady(ctx context.Context, _ *dsv1.IsReadyRequest) (*dsv1.IsReadyResponse, error) {
+	ready, err := rds.ds.IsReady(ctx)
+	if err != nil {
+		return nil, errorToStatus(err)
+	}
+	return &dsv1.IsReadyResponse{IsReady: ready}, nil
+}
+
+func (rds *remoteDatastoreServer) Features(ctx context.Context, _ *dsv1.FeaturesRequest) (*dsv1.FeaturesResponse, error) {
+	features, err := rds.ds.Features(ctx)
+	if err != nil {
+		return nil, errorToStatus(err)
+	}
+
+	return &dsv1.FeaturesResponse{
+		Watch:                  featureToMessage(features.Watch),
+		WatchCheckpoints:       featureToMessage(features.WatchCheckpoints),
+		Caveats:                featureToMessage(features.Caveats),
+		BulkLoad:               featureToMessage(features.BulkLoad),
+		ReverseQuerySort:       featureToMessage(features.ReverseQuerySort),
+		RelationshipExpiration: featureToMessage(features.RelationshipExpiration),
+	}, nil
+}
+
+func (rds *remoteDatastoreServer) Statistics(ctx context.Context, _ *dsv1.StatisticsRequest) (*dsv1.StatisticsResponse, error) {
+	stats, err := rds.ds.Statistics(ctx)
+	if err != nil {
+		return nil, errorToStatus(err)
+	}
+
+	objTypeStats := make([]*dsv1.StatisticsResponse_ObjectTypeStatistics, 0, len(stats.ObjectTypeStatistics))
+	for _, stat := range stats.ObjectTypeStatistics {
+		objTypeStats = append(objTypeStats, &dsv1.StatisticsResponse_ObjectTypeStatistics{
+			Name:                       stat.Name,
+			EstimatedRelationshipCount: stat.EstimatedRelationshipCount,
+			NumRelations:               stat.NumRelations,
+			NumPermissions:             stat.NumPermissions,
+		})
+	}
+
+	return &dsv1.StatisticsResponse{
+		UniqueId:                   stats.UniqueID,
+		EstimatedRelationshipCount: stats.EstimatedRelationshipCount,
+		EstimatedDistinctResources: stats.EstimatedDistinctResources,
+		EstimatedDistinctSubjects:  stats.EstimatedDistinctSubjects,
+		EstimatedStorageBytes:      stats.EstimatedStorageBytes,
+		ObjectTypeStatistics:       objTypeStats,
+	}, nil
+}
+
+func (rds *remoteDatastoreServer) SnapshotRead(req *dsv1.SnapshotReadRequest, stream dsv1.RemoteDatastoreService_SnapshotReadServer) error {
+	rev, err := rds.revisionFromString(req.Revision)
+	if err != nil {
+		return err
+	}
+
+	reader := rds.ds.SnapshotReader(
+		rev,
+		options.WithConsistency(consistencyFromMessage[req.Consistency]),
+		options.WithMaxStaleness(req.MaxStaleness.AsDuration()),
+	)
+
+	err = executeRead(stream.Context(), reader, req.Request, relationshipsPerResponse, stream.Send)
+	return errorToStatus(err)
+}
+
+func (rds *remoteDatastoreServer) ReadWriteTx(stream dsv1.RemoteDatastoreService_ReadWriteTxServer) error {
+	ctx := stream.Context()
+
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	begin := req.GetBegin()
+	if begin == nil {
+		return status.Error(codes.InvalidArgument, "the first request of a transaction must begin it")
+	}
+
+	attempt := 0
+	rolledBack := false
+	rev, err := rds.ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		attempt++
+		rolledBack = false
+		if attempt > 1 {
+			if err := stream.Send(&dsv1.ReadWriteTxResponse{
+				Result: &dsv1.ReadWriteTxResponse_Restart_{Restart: &dsv1.ReadWriteTxResponse_Restart{}},
+			}); err != nil {
+				return err
+			}
+		}
+
+		// The error of the last failed operation is returned when rolling back, so that the
+		// datastore can decide whether to retry the transaction.
+		var lastErr error
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				return err
+			}
+
+			switch req.Operation.(type) {
+			case *dsv1.ReadWriteTxRequest_Commit_:
+				return nil
+			case *dsv1.ReadWriteTxRequest_Rollback_:
+				rolledBack = true
+				if lastErr != nil {
+					return lastErr
+				}
+				return errTransactionRolledBack
+			}
+
+			resp, err := applyTxOperation(ctx, rwt, req)
+			if err != nil {
+				lastErr = err
+				resp = &dsv1.ReadWriteTxResponse{Result: &dsv1.ReadWriteTxResponse_Error{Error: errorToMessage(err)}}
+			}
+
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+	}, options.SetMetadata(begin.Metadata))
+	if rolledBack {
+		return nil
+	}
+	if err != nil {
+		return errorToStatus(err)
+	}
+
+	return stream.Send(&dsv1.ReadWriteTxResponse{
+		Result: &dsv1.ReadWriteTxResponse_Committed_{Committed: &dsv1.ReadWriteTxResponse_Committed{
+			Revision: revisionToString(rev),
+		}},
+	})
+}
+
+func applyTxOperation(ctx context.Context, rwt datastore.ReadWriteTransaction, req *dsv1.ReadWriteTxRequest) (*dsv1.ReadWriteTxResponse, error) {
+	var err error
+	switch op := req.Operation.(type) {
+	case *dsv1.ReadWriteTxRequest_Read:
+		var result *dsv1.ReadResponse
+		err = executeRead(ctx, rwt, op.Read, 0, func(resp *dsv1.ReadResponse) error {
+			result = resp
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &dsv1.ReadWriteTxResponse{Result: &dsv1.ReadWriteTxResponse_Read{Read: result}}, nil
+
+	case *dsv1.ReadWriteTxRequest_WriteRelationships_:
+		err = rwt.WriteRelationships(ctx, op.WriteRelationships.Updates)
+	case *dsv1.ReadWriteTxRequest_DeleteRelationships_:
+		err = rwt.DeleteRelationships(ctx, deleteFilterFromMessage(op.DeleteRelationships.Filter))
+	case *dsv1.ReadWriteTxRequest_WriteNamespaces_:
+		err = rwt.WriteNamespaces(ctx, op.WriteNamespaces.Definitions...)
+	case *dsv1.ReadWriteTxRequest_DeleteNamespaces_:
+		err = rwt.DeleteNamespaces(ctx, op.DeleteNamespaces.Names...)
+	case *dsv1.ReadWriteTxRequest_WriteCaveats_:
+		err = rwt.WriteCaveats(ctx, op.WriteCaveats.Definitions)
+	case *dsv1.ReadWriteTxRequest_DeleteCaveats_:
+		err = rwt.DeleteCaveats(ctx, op.DeleteCaveats.Names)
+	default:
+		return nil, fmt.Errorf("unsupported transaction operation: %T", req.Operation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &dsv1.ReadWriteTxResponse{Result: &dsv1.ReadWriteTxResponse_Written_{Written: &dsv1.ReadWriteTxResponse_Written{}}}, nil
+}
+
+// executeRead performs a read against the reader, sending the relationships found by queries in
+// responses of at most relationshipsPerResponse relationships, or in a single response if zero.
+func executeRead(
+	ctx context.Context,
+	reader datastore.Reader,
+	req *dsv1.ReadRequest,
+	relationshipsPerResponse int,
+	send func(*dsv1.ReadResponse) error,
+) error {
+	switch op := req.GetOperation().(type) {
+	case *dsv1.ReadRequest_QueryRelationships_:
+		iter, err := reader.QueryRelationships(
+			ctx,
+			filterFromMessage(op.QueryRelationships.Filter),
+			options.WithLimit(limitFromMessage(op.QueryRelationships.Limit)),
+			options.SetUsersets(op.QueryRelationships.Usersets),
+		)
+		if err != nil {
+			return err
+		}
+		return sendRelationships(iter, relationshipsPerResponse, send)
+
+	case *dsv1.ReadRequest_ReverseQueryRelationships_:
+		subjectsFilter := subjectsFilterFromMessage(op.ReverseQueryRelationships.SubjectsFilter)
+		if subjectsFilter == nil {
+			return status.Error(codes.InvalidArgument, "a subjects filter is required for a reverse query")
+		}
+
+		iter, err := reader.ReverseQueryRelationships(
+			ctx,
+			*subjectsFilter,
+			options.WithReverseLimit(limitFromMessage(op.ReverseQueryRelationships.Limit)),
+			options.WithResRelation(resourceRelationFromMessage(op.ReverseQueryRelationships.ResourceRelation)),
+			options.WithReverseSort(sortOrderFromMessage(op.ReverseQueryRelationships.SortBySubject)),
+			options.WithReverseAfter(op.ReverseQueryRelationships.After),
+		)
+		if err != nil {
+			return err
+		}
+		return sendRelationships(iter, relationshipsPerResponse, send)
+
+	case *dsv1.ReadRequest_CountRelationships_:
+		count, err := reader.CountRelationships(ctx, filterFromMessage(op.CountRelationships.Filter))
+		if err != nil {
+			return err
+		}
+		return send(&dsv1.ReadResponse{Result: &dsv1.ReadResponse_Count_{Count: &dsv1.ReadResponse_Count{Count: count}}})
+
+	case *dsv1.ReadRequest_ListResourceTypes_:
+		resourceTypes, err := reader.ListResourceTypes(ctx)
+		if err != nil {
+			return err
+		}
+		return send(&dsv1.ReadResponse{Result: &dsv1.ReadResponse_ResourceTypes_{ResourceTypes: &dsv1.ReadResponse_ResourceTypes{
+			ResourceTypes: resourceTypes,
+		}}})
+
+	case *dsv1.ReadRequest_ReadNamespace_:
+		def, lastWritten, err := reader.ReadNamespace(ctx, op.ReadNamespace.Name)
+		if err != nil {
+			return err
+		}
+		return send(&dsv1.ReadResponse{Result: &dsv1.ReadResponse_Namespace_{Namespace: &dsv1.ReadResponse_Namespace{
+			Definition:          def,
+			LastWrittenRevision: revisionToString(lastWritten),
+		}}})
+
+	case *dsv1.ReadRequest_ListNamespaces_:
+		defs, err := reader.ListNamespaces(ctx,
+			options.WithListLimit(op.ListNamespaces.Limit),
+			options.WithListAfter(op.ListNamespaces.After),
+		)
+		if err != nil {
+			return err
+		}
+		return send(&dsv1.ReadResponse{Result: &dsv1.ReadResponse_Namespaces_{Namespaces: &dsv1.ReadResponse_Namespaces{Definitions: defs}}})
+
+	case *dsv1.ReadRequest_LookupNamespaces_:
+		defs, err := reader.LookupNamespaces(ctx, op.LookupNamespaces.Names)
+		if err != nil {
+			return err
+		}
+		return send(&dsv1.ReadResponse{Result: &dsv1.ReadResponse_Namespaces_{Namespaces: &dsv1.ReadResponse_Namespaces{Definitions: defs}}})
+
+	case *dsv1.ReadRequest_ReadCaveat_:
+		def, lastWritten, err := reader.ReadCaveatByName(ctx, op.ReadCaveat.Name)
+		if err != nil {
+			return err
+		}
+		return send(&dsv1.ReadResponse{Result: &dsv1.ReadResponse_Caveat_{Caveat: &dsv1.ReadResponse_Caveat{
+			Definition:          def,
+			LastWrittenRevision: revisionToString(lastWritten),
+		}}})
+
+	case *dsv1.ReadRequest_ListCaveats_:
+		defs, err := reader.ListCaveats(ctx,
+			options.WithListLimit(op.ListCaveats.Limit),
+			options.WithListAfter(op.ListCaveats.After),
+		)
+		if err != nil {
+			return err
+		}
+		return send(&dsv1.ReadResponse{Result: &dsv1.ReadResponse_Caveats_{Caveats: &dsv1.ReadResponse_Caveats{Definitions: defs}}})
+
+	case *dsv1.ReadRequest_LookupCaveats_:
+		defs, err := reader.LookupCaveats(ctx, op.LookupCaveats.Names)
+		if err != nil {
+			return err
+		}
+		return send(&dsv1.ReadResponse{Result: &dsv1.ReadResponse_Caveats_{Caveats: &dsv1.ReadResponse_Caveats{Definitions: defs}}})
+
+	default:
+		return status.Errorf(codes.InvalidArgument, "unsupported read operation: %T", req.GetOperation())
+	}
+}
+
+func sendRelationships(iter datastore.RelationshipIterator, relationshipsPerResponse int, send func(*dsv1.ReadResponse) error) error {
+	defer iter.Close()
+
+	sendChunk := func(rels []*core.RelationTuple) error {
+		return send(&dsv1.ReadResponse{Result: &dsv1.ReadResponse_Relationships_{
+			Relationships: &dsv1.ReadResponse_Relationships{Relationships: rels},
+		}})
+	}
+
+	var chunk []*core.RelationTuple
+	for rel := iter.Next(); rel != nil; rel = iter.Next() {
+		chunk = append(chunk, rel)
+		if len(chunk) == relationshipsPerResponse {
+			if err := sendChunk(chunk); err != nil {
+				return err
+			}
+			chunk = nil
+		}
+	}
+	if iter.Err() != nil {
+		return iter.Err()
+	}
+
+	if len(chunk) > 0 || relationshipsPerResponse == 0 {
+		return sendChunk(chunk)
+	}
+	return nil
+}
+
+func (rds *remoteDatastoreServer) BulkLoad(stream dsv1.RemoteDatastoreService_BulkLoadServer) error {
+	rev, err := rds.ds.BulkLoad(stream.Context(), &bulkLoadStreamSource{stream: stream})
+	if err != nil {
+		return errorToStatus(err)
+	}
+	return stream.SendAndClose(&dsv1.BulkLoadResponse{Revision: revisionToString(rev)})
+}
+
+// bulkLoadStreamSource provides the relationships received in a bulk load stream to a datastore.
+type bulkLoadStreamSource struct {
+	stream   dsv1.RemoteDatastoreService_BulkLoadServer
+	buffered []*core.RelationTuple
+}
+
+func (bls *bulkLoadStreamSource) Next(_ context.Context) (*core.RelationTuple, error) {
+	for len(bls.buffered) == 0 {
+		req, err := bls.stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		bls.buffered = req.Relationships
+	}
+
+	next := bls.buffered[0]
+	bls.buffered = bls.buffered[1:]
+	return next, nil
+}
+
+func (rds *remoteDatastoreServer) Watch(req *dsv1.WatchRequest, stream dsv1.RemoteDatastoreService_WatchServer) error {
+	afterRevision, err := rds.revisionFromString(req.AfterRevision)
+	if err != nil {
+		return err
+	}
+
+	updates, errs := rds.ds.Watch(stream.Context(), afterRevision)
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				if errs == nil {
+					return nil
+				}
+				return errorToStatus(<-errs)
+			}
+
+			if err := stream.Send(&dsv1.WatchResponse{
+				Revision:     revisionToString(update.Revision),
+				Changes:      update.Changes,
+				Metadata:     update.Metadata,
+				IsCheckpoint: update.IsCheckpoint,
+			}); err != nil {
+				return err
+			}
+		case err, ok := <-errs:
+			if !ok {
+				// Continue sending any updates buffered before the datastore closed the watch.
+				errs = nil
+				continue
+			}
+			return errorToStatus(err)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"google.golang.org/grpc/codes"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
@@ -35,22 +36,29 @@ func (sr spannerReader) ReadCaveatByName(ctx context.Context, name string) (*cor
 	return loaded, revisionFromTimestamp(updated), nil
 }
 
-func (sr spannerReader) ListCaveats(ctx context.Context, caveatNames ...string) ([]*core.CaveatDefinition, error) {
-	keyset := spanner.AllKeys()
-	if len(caveatNames) > 0 {
-		keys := make([]spanner.Key, 0, len(caveatNames))
-		for _, n := range caveatNames {
-			keys = append(keys, spanner.Key{n})
-		}
-		keyset = spanner.KeySetFromKeys(keys...)
+func (sr spannerReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	return readAllCaveats(readDefinitionsPage(ctx, sr.txSource(), tableCaveat, []string{colCaveatDefinition}, opts...))
+}
+
+func (sr spannerReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	if len(caveatNames) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]spanner.Key, 0, len(caveatNames))
+	for _, n := range caveatNames {
+		keys = append(keys, spanner.Key{n})
 	}
-	iter := sr.txSource().Read(
+
+	return readAllCaveats(sr.txSource().Read(
 		ctx,
 		tableCaveat,
-		keyset,
+		spanner.KeySetFromKeys(keys...),
 		[]string{colCaveatDefinition},
-	)
+	))
+}
 
+func readAllCaveats(iter *spanner.RowIterator) ([]*core.CaveatDefinition, error) {
 	var caveats []*core.CaveatDefinition
 	if err := iter.Do(func(row *spanner.Row) error {
 		var serialized []byte
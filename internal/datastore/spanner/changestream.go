@@ -0,0 +1,276 @@
+package spanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/spanner"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/util"
+)
+
+// changeStreamHeartbeat is the interval at which a partition of the change stream reports how far
+// it has been read while there are no changes to report, at which a watch checks whether a
+// checkpoint is due.
+const changeStreamHeartbeat = time.Second
+
+var queryReadChangeStream = fmt.Sprintf(`SELECT ChangeRecord FROM READ_%s (
+	start_timestamp => @start_timestamp,
+	end_timestamp => NULL,
+	partition_token => @partition_token,
+	heartbeat_milliseconds => @heartbeat_milliseconds
+)`, changeStreamChangelog)
+
+// changeStreamRow is a row returned by a query of a change stream partition. Only the fields
+// read by a watch are decoded.
+type changeStreamRow struct {
+	ChangeRecord []*changeRecord `spanner:"ChangeRecord"`
+}
+
+type changeRecord struct {
+	DataChangeRecord      []*dataChangeRecord      `spanner:"data_change_record"`
+	HeartbeatRecord       []*heartbeatRecord       `spanner:"heartbeat_record"`
+	ChildPartitionsRecord []*childPartitionsRecord `spanner:"child_partitions_record"`
+}
+
+type dataChangeRecord struct {
+	CommitTimestamp time.Time `spanner:"commit_timestamp"`
+}
+
+type heartbeatRecord struct {
+	Timestamp time.Time `spanner:"timestamp"`
+}
+
+type childPartitionsRecord struct {
+	StartTimestamp  time.Time         `spanner:"start_timestamp"`
+	ChildPartitions []*childPartition `spanner:"child_partitions"`
+}
+
+type childPartition struct {
+	Token string `spanner:"token"`
+}
+
+// partitionStart is a partition of the change stream, and the timestamp from which it is read.
+type partitionStart struct {
+	token string
+	start time.Time
+}
+
+// partitionEvent is what the reader of a partition reports to the watch for each row it reads,
+// and once the partition ends.
+type partitionEvent struct {
+	token string
+
+	// readUpTo is the timestamp up to which every change of the partition has been read.
+	readUpTo time.Time
+
+	// commits are the commit timestamps of the changes read.
+	commits []time.Time
+
+	// children are the partitions into which the partition is split or merged.
+	children []partitionStart
+
+	done bool
+	err  error
+}
+
+// watchChangeStream sends the changes committed after the timestamp, as they are reported by the
+// change stream of the changelog table.
+//
+// The changes committed before the stream started are first loaded from the changelog. Each
+// partition of the change stream is then read concurrently, and reports the commit timestamps of
+// its changes in order, along with how far it has been read. Changes are loaded from the changelog
+// once every partition has been read past them, so that they are sent in the order in which they
+// were committed, and a checkpoint is sent at that timestamp when there are none.
+func (sd spannerDatastore) watchChangeStream(
+	ctx context.Context,
+	afterTimestamp time.Time,
+	updates chan<- *datastore.RevisionChanges,
+	errs chan<- error,
+) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sendError := func(err error) {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			errs <- datastore.NewWatchCanceledErr()
+		} else {
+			errs <- err
+		}
+	}
+
+	lastSent := time.Now()
+	send := func(changes *datastore.RevisionChanges) bool {
+		select {
+		case updates <- changes:
+			lastSent = time.Now()
+			return true
+		default:
+			errs <- datastore.NewWatchDisconnectedErr()
+			return false
+		}
+	}
+
+	loaded, currentTimestamp, err := sd.loadChanges(ctx, afterTimestamp)
+	if err != nil {
+		sendError(err)
+		return
+	}
+	for _, changes := range loaded {
+		if !send(changes) {
+			return
+		}
+	}
+
+	events := make(chan partitionEvent)
+	startPartition := func(partition partitionStart) {
+		go sd.readChangeStreamPartition(ctx, partition, events)
+	}
+
+	// The watermark of each partition being read, up to which every change of the partition has
+	// been read. The stream is first queried without a partition token, which returns the
+	// partitions of the stream as its children.
+	watermarks := map[string]time.Time{"": currentTimestamp}
+	started := util.NewSet[string]("")
+	startPartition(partitionStart{start: currentTimestamp})
+
+	var pending []time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			sendError(ctx.Err())
+			return
+
+		case event := <-events:
+			if event.err != nil {
+				sendError(fmt.Errorf("unable to read change stream: %w", event.err))
+				return
+			}
+
+			// The children of a partition are registered before it is removed, so that the
+			// watermark never passes changes which are yet to be read from them.
+			for _, child := range event.children {
+				if started.Has(child.token) {
+					// A merged partition is reported by each of its parents.
+					continue
+				}
+				started.Add(child.token)
+				watermarks[child.token] = child.start.Add(-time.Nanosecond)
+				startPartition(child)
+			}
+
+			if event.done {
+				delete(watermarks, event.token)
+			} else {
+				watermarks[event.token] = maxTime(watermarks[event.token], event.readUpTo)
+			}
+			pending = append(pending, event.commits...)
+		}
+
+		watermark, ok := minWatermark(watermarks)
+		if !ok || !watermark.After(currentTimestamp) {
+			continue
+		}
+
+		var remaining []time.Time
+		for _, commit := range pending {
+			if commit.After(watermark) {
+				remaining = append(remaining, commit)
+			}
+		}
+
+		if len(remaining) < len(pending) {
+			changes, err := sd.loadChangesBetween(ctx, currentTimestamp, watermark)
+			if err != nil {
+				sendError(err)
+				return
+			}
+			for _, change := range changes {
+				if !send(change) {
+					return
+				}
+			}
+		}
+		pending = remaining
+		currentTimestamp = watermark
+
+		// Send a checkpoint if no changes have been sent within the interval
+		if time.Since(lastSent) >= common.WatchCheckpointInterval {
+			if !send(&datastore.RevisionChanges{
+				Revision:     revisionFromTimestamp(currentTimestamp),
+				IsCheckpoint: true,
+			}) {
+				return
+			}
+		}
+	}
+}
+
+// readChangeStreamPartition queries a partition of the change stream until it ends, and reports
+// what it reads to the watch.
+func (sd spannerDatastore) readChangeStreamPartition(ctx context.Context, partition partitionStart, events chan<- partitionEvent) {
+	report := func(event partitionEvent) error {
+		select {
+		case events <- event:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	token := spanner.NullString{StringVal: partition.token, Valid: partition.token != ""}
+	stmt := spanner.Statement{
+		SQL: queryReadChangeStream,
+		Params: map[string]interface{}{
+			"start_timestamp":        partition.start,
+			"partition_token":        token,
+			"heartbeat_milliseconds": changeStreamHeartbeat.Milliseconds(),
+		},
+	}
+
+	err := sd.client.Single().Query(ctx, stmt).Do(func(r *spanner.Row) error {
+		var row changeStreamRow
+		if err := r.ToStructLenient(&row); err != nil {
+			return err
+		}
+
+		event := partitionEvent{token: partition.token}
+		for _, record := range row.ChangeRecord {
+			// The changes of a transaction can be split across records with its commit
+			// timestamp, so only the changes committed before it are known to have been read.
+			for _, data := range record.DataChangeRecord {
+				event.commits = append(event.commits, data.CommitTimestamp)
+				event.readUpTo = maxTime(event.readUpTo, data.CommitTimestamp.Add(-time.Nanosecond))
+			}
+			for _, heartbeat := range record.HeartbeatRecord {
+				event.readUpTo = maxTime(event.readUpTo, heartbeat.Timestamp)
+			}
+			for _, children := range record.ChildPartitionsRecord {
+				event.readUpTo = maxTime(event.readUpTo, children.StartTimestamp.Add(-time.Nanosecond))
+				for _, child := range children.ChildPartitions {
+					event.children = append(event.children, partitionStart{token: child.Token, start: children.StartTimestamp})
+				}
+			}
+		}
+		return report(event)
+	})
+
+	_ = report(partitionEvent{token: partition.token, done: true, err: err})
+}
+
+// minWatermark returns the earliest of the watermarks, up to which every partition has been read.
+func minWatermark(watermarks map[string]time.Time) (time.Time, bool) {
+	var watermark time.Time
+	found := false
+	for _, partitionWatermark := range watermarks {
+		if !found || partitionWatermark.Before(watermark) {
+			watermark = partitionWatermark
+			found = true
+		}
+	}
+	return watermark, found
+}
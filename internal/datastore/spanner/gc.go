@@ -44,16 +44,40 @@ func (sd spannerDatastore) runGC(ctx context.Context) error {
 			log.Error().Err(err).Msg("garbage collection: error creating delete statement")
 		}
 
-		_, err = sd.client.ReadWriteTransaction(ctx, func(ctx context.Context, rwt *spanner.ReadWriteTransaction) error {
-			numRemoved, err = rwt.Update(ctx, statementFromSQL(stmt, args))
-			return err
-		})
+		// Garbage is deleted with partitioned DML, which deletes the rows of each partition of the
+		// table in its own transaction, so that the number of rows deleted is not bound by the
+		// mutation limit of a single transaction.
+		numRemoved, err = sd.client.PartitionedUpdate(ctx, statementFromSQL(stmt, args))
 		if err != nil {
 			log.Error().Err(err).Msg("garbage collection: error deleting entries")
 		}
 
 		log.Info().Int64("removed", numRemoved).Stringer("before", oldestRevision).
 			Msg("garbage collection: removed changelog entries")
+
+		stmt, args, err = sql.Delete(tableRelationship).Where(sq.Lt{colExpiration: spannerNow}).ToSql()
+		if err != nil {
+			log.Error().Err(err).Msg("garbage collection: error creating delete statement")
+		}
+
+		numRemoved, err = sd.client.PartitionedUpdate(ctx, statementFromSQL(stmt, args))
+		if err != nil {
+			log.Error().Err(err).Msg("garbage collection: error deleting expired relationships")
+		}
+
+		// The count of a partitioned update is a lower bound of the rows deleted, which is
+		// precise enough for the estimated relationship count.
+		if numRemoved > 0 {
+			_, err = sd.client.ReadWriteTransaction(ctx, func(ctx context.Context, rwt *spanner.ReadWriteTransaction) error {
+				return updateCounter(ctx, rwt, -1*numRemoved)
+			})
+			if err != nil {
+				log.Error().Err(err).Msg("garbage collection: error updating relationship count")
+			}
+		}
+
+		log.Info().Int64("removed", numRemoved).Stringer("before", spannerNow).
+			Msg("garbage collection: removed expired relationships")
 	})
 	if err != nil {
 		return fmt.Errorf("unable to start garbage collection: %w", err)
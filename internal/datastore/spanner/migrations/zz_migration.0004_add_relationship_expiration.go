@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+)
+
+const (
+	addRelationshipExpiration = `ALTER TABLE relation_tuple
+		ADD COLUMN expiration TIMESTAMP`
+
+	addChangelogExpiration = `ALTER TABLE changelog
+		ADD COLUMN expiration TIMESTAMP`
+)
+
+func init() {
+	if err := SpannerMigrations.Register("add-relationship-expiration", "add-caveats", func(ctx context.Context, w Wrapper) error {
+		updateOp, err := w.adminClient.UpdateDatabaseDdl(ctx, &database.UpdateDatabaseDdlRequest{
+			Database: w.client.DatabaseName(),
+			Statements: []string{
+				addRelationshipExpiration,
+				addChangelogExpiration,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return updateOp.Wait(ctx)
+	}, nil); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+)
+
+const (
+	addRelationshipMetadata = `ALTER TABLE relation_tuple
+		ADD COLUMN metadata JSON`
+
+	addChangelogMetadata = `ALTER TABLE changelog
+		ADD COLUMN metadata JSON`
+)
+
+func init() {
+	if err := SpannerMigrations.Register("add-relationship-metadata", "add-relationship-expiration", func(ctx context.Context, w Wrapper) error {
+		updateOp, err := w.adminClient.UpdateDatabaseDdl(ctx, &database.UpdateDatabaseDdlRequest{
+			Database: w.client.DatabaseName(),
+			Statements: []string{
+				addRelationshipMetadata,
+				addChangelogMetadata,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return updateOp.Wait(ctx)
+	}, nil); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+)
+
+const addChangelogTransactionMetadata = `ALTER TABLE changelog
+	ADD COLUMN transaction_metadata JSON`
+
+func init() {
+	if err := SpannerMigrations.Register("add-transaction-metadata", "add-relationship-metadata", func(ctx context.Context, w Wrapper) error {
+		updateOp, err := w.adminClient.UpdateDatabaseDdl(ctx, &database.UpdateDatabaseDdlRequest{
+			Database: w.client.DatabaseName(),
+			Statements: []string{
+				addChangelogTransactionMetadata,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return updateOp.Wait(ctx)
+	}, nil); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
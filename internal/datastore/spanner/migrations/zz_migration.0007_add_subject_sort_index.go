@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+)
+
+const createSubjectSortIndex = `CREATE INDEX ix_relation_tuple_by_subject_and_resource
+	ON relation_tuple (userset_namespace, userset_object_id, userset_relation, namespace, object_id, relation)`
+
+func init() {
+	if err := SpannerMigrations.Register("add-subject-sort-index", "add-transaction-metadata", func(ctx context.Context, w Wrapper) error {
+		updateOp, err := w.adminClient.UpdateDatabaseDdl(ctx, &database.UpdateDatabaseDdlRequest{
+			Database: w.client.DatabaseName(),
+			Statements: []string{
+				createSubjectSortIndex,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return updateOp.Wait(ctx)
+	}, nil); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
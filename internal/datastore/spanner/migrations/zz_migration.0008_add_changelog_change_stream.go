@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"context"
+
+	"google.golang.org/genproto/googleapis/spanner/admin/database/v1"
+)
+
+const createChangelogChangeStream = `CREATE CHANGE STREAM changelog_stream
+	FOR changelog`
+
+func init() {
+	if err := SpannerMigrations.Register("add-changelog-change-stream", "add-subject-sort-index", func(ctx context.Context, w Wrapper) error {
+		updateOp, err := w.adminClient.UpdateDatabaseDdl(ctx, &database.UpdateDatabaseDdlRequest{
+			Database: w.client.DatabaseName(),
+			Statements: []string{
+				createChangelogChangeStream,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return updateOp.Wait(ctx)
+	}, nil); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
@@ -9,12 +9,27 @@ type spannerOptions struct {
 	watchBufferLength           uint16
 	revisionQuantization        time.Duration
 	followerReadDelay           time.Duration
+	maxStaleness                time.Duration
 	maxRevisionStalenessPercent float64
 	gcWindow                    time.Duration
 	gcInterval                  time.Duration
 	gcEnabled                   bool
 	credentialsFilePath         string
 	emulatorHost                string
+	watchMode                   string
+}
+
+type watchMode uint8
+
+const (
+	watchPolling watchMode = iota
+	watchChangeStream
+)
+
+var watchModes = map[string]watchMode{
+	"":              watchPolling,
+	"polling":       watchPolling,
+	"change-stream": watchChangeStream,
 }
 
 const (
@@ -57,6 +72,10 @@ func generateConfig(options []Option) (spannerOptions, error) {
 		)
 	}
 
+	if _, ok := watchModes[computed.watchMode]; !ok {
+		return computed, fmt.Errorf("unknown watch mode: %s", computed.watchMode)
+	}
+
 	return computed, nil
 }
 
@@ -70,6 +89,19 @@ func WatchBufferLength(watchBufferLength uint16) Option {
 	}
 }
 
+// WatchMode is how watches find the changes committed after their revision:
+//   - "polling" queries the changelog table for new changes at an interval
+//   - "change-stream" reads the change stream of the changelog table, whose
+//     partitions report each commit as it happens and how far they have been
+//     read, so that changes are loaded as soon as they can be sent in order
+//
+// Watches poll by default.
+func WatchMode(mode string) Option {
+	return func(so *spannerOptions) {
+		so.watchMode = mode
+	}
+}
+
 // RevisionQuantization is the time bucket size to which advertised revisions
 // will be rounded.
 //
@@ -89,6 +121,19 @@ func FollowerReadDelay(delay time.Duration) Option {
 	}
 }
 
+// MaxStaleness is the staleness of the bounded-staleness read from which the
+// revisions of minimize_latency requests are taken, in place of a strong read of
+// the current timestamp. The read is served by the nearest replica at the newest
+// timestamp it has caught up to, so that the reads at the revision need not
+// wait for the leader.
+//
+// This value defaults to 0, which takes revisions from strong reads.
+func MaxStaleness(staleness time.Duration) Option {
+	return func(so *spannerOptions) {
+		so.maxStaleness = staleness
+	}
+}
+
 // MaxRevisionStalenessPercent is the amount of time, expressed as a percentage of
 // the revision quantization window, that a previously computed rounded revision
 // can still be advertised after the next rounded revision would otherwise be ready.
@@ -22,6 +22,8 @@ type readTX interface {
 
 	Read(ctx context.Context, table string, keys spanner.KeySet, columns []string) *spanner.RowIterator
 
+	ReadWithOptions(ctx context.Context, table string, keys spanner.KeySet, columns []string, opts *spanner.ReadOptions) *spanner.RowIterator
+
 	Query(ctx context.Context, statement spanner.Statement) *spanner.RowIterator
 }
 
@@ -30,6 +32,7 @@ type txFactory func() readTX
 type spannerReader struct {
 	querySplitter common.TupleQuerySplitter
 	txSource      txFactory
+	schema        common.SchemaInformation
 }
 
 func (sr spannerReader) QueryRelationships(
@@ -37,7 +40,7 @@ func (sr spannerReader) QueryRelationships(
 	filter datastore.RelationshipsFilter,
 	opts ...options.QueryOptionsOption,
 ) (iter datastore.RelationshipIterator, err error) {
-	qBuilder := common.NewSchemaQueryFilterer(schema, queryTuples).FilterWithRelationshipsFilter(filter)
+	qBuilder := common.NewSchemaQueryFilterer(sr.schema, queryTuples).FilterWithRelationshipsFilter(filter)
 	return sr.querySplitter.SplitAndExecuteQuery(ctx, qBuilder, opts...)
 }
 
@@ -46,7 +49,7 @@ func (sr spannerReader) ReverseQueryRelationships(
 	subjectsFilter datastore.SubjectsFilter,
 	opts ...options.ReverseQueryOptionsOption,
 ) (iter datastore.RelationshipIterator, err error) {
-	qBuilder := common.NewSchemaQueryFilterer(schema, queryTuples).
+	qBuilder := common.NewSchemaQueryFilterer(sr.schema, queryTuples).
 		FilterWithSubjectsFilter(subjectsFilter)
 
 	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
@@ -57,12 +60,59 @@ func (sr spannerReader) ReverseQueryRelationships(
 			FilterToRelation(queryOpts.ResRelation.Relation)
 	}
 
+	if queryOpts.ReverseSort == options.BySubject {
+		qBuilder = qBuilder.SortBySubject()
+	}
+
+	if queryOpts.ReverseAfter != nil {
+		qBuilder = qBuilder.FilterAfterSubject(queryOpts.ReverseAfter)
+	}
+
 	return sr.querySplitter.SplitAndExecuteQuery(ctx,
 		qBuilder,
 		options.WithLimit(queryOpts.ReverseLimit),
 	)
 }
 
+func (sr spannerReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	sql, args, err := common.NewSchemaQueryFilterer(sr.schema, countTuples).
+		FilterWithRelationshipsFilter(filter).
+		ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+
+	var count int64
+	if err := sr.txSource().Query(ctx, statementFromSQL(sql, args)).Do(func(row *spanner.Row) error {
+		return row.Columns(&count)
+	}); err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+
+	return uint64(count), nil
+}
+
+func (sr spannerReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	sql, args, err := common.NewSchemaQueryFilterer(sr.schema, listResourceTypes).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+
+	var resourceTypes []string
+	if err := sr.txSource().Query(ctx, statementFromSQL(sql, args)).Do(func(row *spanner.Row) error {
+		var resourceType string
+		if err := row.Columns(&resourceType); err != nil {
+			return err
+		}
+		resourceTypes = append(resourceTypes, resourceType)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+
+	return resourceTypes, nil
+}
+
 func queryExecutor(txSource txFactory) common.ExecuteQueryFunc {
 	return func(
 		ctx context.Context,
@@ -83,6 +133,8 @@ func queryExecutor(txSource txFactory) common.ExecuteQueryFunc {
 			}
 			var caveatName spanner.NullString
 			var caveatCtx spanner.NullJSON
+			var expiration spanner.NullTime
+			var metadata spanner.NullJSON
 			err := row.Columns(
 				&nextTuple.ResourceAndRelation.Namespace,
 				&nextTuple.ResourceAndRelation.ObjectId,
@@ -92,6 +144,8 @@ func queryExecutor(txSource txFactory) common.ExecuteQueryFunc {
 				&nextTuple.Subject.Relation,
 				&caveatName,
 				&caveatCtx,
+				&expiration,
+				&metadata,
 			)
 			if err != nil {
 				return err
@@ -101,6 +155,8 @@ func queryExecutor(txSource txFactory) common.ExecuteQueryFunc {
 			if err != nil {
 				return err
 			}
+			nextTuple.OptionalExpirationTime = expirationFrom(expiration)
+			nextTuple.OptionalMetadata = metadataFrom(metadata)
 
 			tuples = append(tuples, nextTuple)
 
@@ -142,13 +198,8 @@ func (sr spannerReader) ReadNamespace(ctx context.Context, nsName string) (*core
 	return ns, revisionFromTimestamp(updated), nil
 }
 
-func (sr spannerReader) ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error) {
-	iter := sr.txSource().Read(
-		ctx,
-		tableNamespace,
-		spanner.AllKeys(),
-		[]string{colNamespaceConfig},
-	)
+func (sr spannerReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	iter := readDefinitionsPage(ctx, sr.txSource(), tableNamespace, []string{colNamespaceConfig}, opts...)
 
 	allNamespaces, err := readAllNamespaces(iter)
 	if err != nil {
@@ -183,6 +234,28 @@ func (sr spannerReader) LookupNamespaces(ctx context.Context, nsNames []string)
 	return foundNamespaces, nil
 }
 
+// readDefinitionsPage reads the page of namespace or caveat definitions selected by the list
+// options. The definitions are returned in order of their names, which are the primary key.
+func readDefinitionsPage(ctx context.Context, tx readTX, table string, columns []string, opts ...options.ListOptionsOption) *spanner.RowIterator {
+	listOpts := options.NewListOptionsWithOptions(opts...)
+
+	var keys spanner.KeySet = spanner.AllKeys()
+	if listOpts.ListAfter != "" {
+		keys = spanner.KeyRange{Start: spanner.Key{listOpts.ListAfter}, End: spanner.Key{}, Kind: spanner.OpenClosed}
+	}
+
+	readOpts := &spanner.ReadOptions{}
+	if listOpts.ListLimit != nil {
+		if *listOpts.ListLimit == 0 {
+			// Spanner treats a limit of zero as no limit.
+			keys = spanner.KeySets()
+		}
+		readOpts.Limit = int(*listOpts.ListLimit)
+	}
+
+	return tx.ReadWithOptions(ctx, table, keys, columns, readOpts)
+}
+
 func readAllNamespaces(iter *spanner.RowIterator) ([]*core.NamespaceDefinition, error) {
 	var allNamespaces []*core.NamespaceDefinition
 	if err := iter.Do(func(row *spanner.Row) error {
@@ -215,8 +288,14 @@ var queryTuples = sql.Select(
 	colUsersetRelation,
 	colCaveatName,
 	colCaveatContext,
+	colExpiration,
+	colMetadata,
 ).From(tableRelationship)
 
+var countTuples = sql.Select("COUNT(*)").From(tableRelationship)
+
+var listResourceTypes = sql.Select(colNamespace).Distinct().From(tableRelationship).OrderBy(colNamespace)
+
 var schema = common.SchemaInformation{
 	ColNamespace:        colNamespace,
 	ColObjectID:         colObjectID,
@@ -225,6 +304,14 @@ var schema = common.SchemaInformation{
 	ColUsersetObjectID:  colUsersetObjectID,
 	ColUsersetRelation:  colUsersetRelation,
 	ColCaveatName:       colCaveatName,
+	ColExpiration:       colExpiration,
+	MetadataValue:       metadataValue,
+}
+
+// metadataValue selects the value for a key from the JSON metadata column. The JSON path must be
+// a literal in Spanner; metadata keys are restricted to characters which need no escaping in it.
+func metadataValue(key string) (string, []any) {
+	return fmt.Sprintf(`JSON_VALUE(%s, '$."%s"')`, colMetadata, key), nil
 }
 
 var _ datastore.Reader = spannerReader{}
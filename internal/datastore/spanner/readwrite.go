@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jzelinskie/stringz"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -19,6 +20,7 @@ import (
 type spannerReadWriteTXN struct {
 	spannerReader
 	spannerRWT *spanner.ReadWriteTransaction
+	metadata   map[string]string
 }
 
 func (rwt spannerReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
@@ -26,6 +28,30 @@ func (rwt spannerReadWriteTXN) WriteRelationships(ctx context.Context, mutations
 
 	var rowCountChange int64
 
+	// Expired relationships no longer exist, so they are removed before a CREATE of the same
+	// relationship.
+	expiredCreates := sq.Or{}
+	for _, mutation := range mutations {
+		if mutation.Operation == core.RelationTupleUpdate_CREATE {
+			expiredCreates = append(expiredCreates, exactRelationshipClause(mutation.Tuple))
+		}
+	}
+	if len(expiredCreates) > 0 {
+		stmt, args, err := sql.Delete(tableRelationship).Where(sq.And{
+			expiredCreates,
+			sq.Expr(colExpiration + " <= CURRENT_TIMESTAMP()"),
+		}).ToSql()
+		if err != nil {
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+
+		numDeleted, err := rwt.spannerRWT.Update(ctx, statementFromSQL(stmt, args))
+		if err != nil {
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+		rowCountChange -= numDeleted
+	}
+
 	for _, mutation := range mutations {
 		var txnMut *spanner.Mutation
 		var op int
@@ -50,7 +76,7 @@ func (rwt spannerReadWriteTXN) WriteRelationships(ctx context.Context, mutations
 			)
 		}
 
-		changelogMut := spanner.Insert(tableChangelog, allChangelogCols, changeVals(changeUUID, op, mutation.Tuple))
+		changelogMut := spanner.Insert(tableChangelog, allChangelogCols, changeVals(changeUUID, op, mutation.Tuple, rwt.metadata))
 		if err := rwt.spannerRWT.BufferWrite([]*spanner.Mutation{txnMut, changelogMut}); err != nil {
 			return fmt.Errorf(errUnableToWriteRelationships, err)
 		}
@@ -64,7 +90,7 @@ func (rwt spannerReadWriteTXN) WriteRelationships(ctx context.Context, mutations
 }
 
 func (rwt spannerReadWriteTXN) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
-	err := deleteWithFilter(ctx, rwt.spannerRWT, filter)
+	err := deleteWithFilter(ctx, rwt.spannerRWT, filter, rwt.metadata)
 	if err != nil {
 		return fmt.Errorf(errUnableToDeleteRelationships, err)
 	}
@@ -82,7 +108,11 @@ func (snd selectAndDelete) Where(pred interface{}, args ...interface{}) selectAn
 	return snd
 }
 
-func deleteWithFilter(ctx context.Context, rwt *spanner.ReadWriteTransaction, filter *v1.RelationshipFilter) error {
+// deleteWithFilter deletes the relationships matching the filter within the transaction, writing
+// each to the change log so that it is watched. Unlike garbage collection, the deletes cannot use
+// partitioned DML, which runs outside of any transaction, so they remain bound by the mutation
+// limit of the transaction.
+func deleteWithFilter(ctx context.Context, rwt *spanner.ReadWriteTransaction, filter *v1.RelationshipFilter, txMetadata map[string]string) error {
 	queries := selectAndDelete{queryTuples, sql.Delete(tableRelationship)}
 
 	// Add clauses for the ResourceFilter
@@ -121,6 +151,8 @@ func deleteWithFilter(ctx context.Context, rwt *spanner.ReadWriteTransaction, fi
 	}
 	var caveatName spanner.NullString
 	var caveatCtx spanner.NullJSON
+	var expiration spanner.NullTime
+	var metadata spanner.NullJSON
 
 	var changelogMutations []*spanner.Mutation
 	if err := toDelete.Do(func(row *spanner.Row) error {
@@ -133,6 +165,8 @@ func deleteWithFilter(ctx context.Context, rwt *spanner.ReadWriteTransaction, fi
 			&rel.Subject.Relation,
 			&caveatName,
 			&caveatCtx,
+			&expiration,
+			&metadata,
 		)
 		if err != nil {
 			return err
@@ -141,11 +175,13 @@ func deleteWithFilter(ctx context.Context, rwt *spanner.ReadWriteTransaction, fi
 		if err != nil {
 			return err
 		}
+		rel.OptionalExpirationTime = expirationFrom(expiration)
+		rel.OptionalMetadata = metadataFrom(metadata)
 
 		changelogMutations = append(changelogMutations, spanner.Insert(
 			tableChangelog,
 			allChangelogCols,
-			changeVals(changeUUID, colChangeOpDelete, &rel),
+			changeVals(changeUUID, colChangeOpDelete, &rel, txMetadata),
 		))
 		return nil
 	}); err != nil {
@@ -177,6 +213,7 @@ func upsertVals(r *core.RelationTuple) []any {
 	key := keyFromRelationship(r)
 	key = append(key, spanner.CommitTimestamp)
 	key = append(key, caveatVals(r)...)
+	key = append(key, expirationVal(r), metadataVal(r))
 	return key
 }
 
@@ -191,7 +228,18 @@ func keyFromRelationship(r *core.RelationTuple) spanner.Key {
 	}
 }
 
-func changeVals(changeUUID string, op int, r *core.RelationTuple) []any {
+func exactRelationshipClause(r *core.RelationTuple) sq.Eq {
+	return sq.Eq{
+		colNamespace:        r.ResourceAndRelation.Namespace,
+		colObjectID:         r.ResourceAndRelation.ObjectId,
+		colRelation:         r.ResourceAndRelation.Relation,
+		colUsersetNamespace: r.Subject.Namespace,
+		colUsersetObjectID:  r.Subject.ObjectId,
+		colUsersetRelation:  r.Subject.Relation,
+	}
+}
+
+func changeVals(changeUUID string, op int, r *core.RelationTuple, txMetadata map[string]string) []any {
 	vals := []any{
 		spanner.CommitTimestamp,
 		changeUUID,
@@ -204,6 +252,7 @@ func changeVals(changeUUID string, op int, r *core.RelationTuple) []any {
 		r.Subject.Relation,
 	}
 	vals = append(vals, caveatVals(r)...)
+	vals = append(vals, expirationVal(r), metadataVal(r), jsonMetadataVal(txMetadata))
 	return vals
 }
 
@@ -220,6 +269,48 @@ func caveatVals(r *core.RelationTuple) []any {
 	return vals
 }
 
+func expirationVal(r *core.RelationTuple) spanner.NullTime {
+	if r.OptionalExpirationTime == nil {
+		return spanner.NullTime{}
+	}
+	return spanner.NullTime{Time: r.OptionalExpirationTime.AsTime(), Valid: true}
+}
+
+func expirationFrom(expiration spanner.NullTime) *timestamppb.Timestamp {
+	if !expiration.Valid {
+		return nil
+	}
+	return timestamppb.New(expiration.Time)
+}
+
+func metadataVal(r *core.RelationTuple) spanner.NullJSON {
+	return jsonMetadataVal(r.OptionalMetadata)
+}
+
+func jsonMetadataVal(metadata map[string]string) spanner.NullJSON {
+	if len(metadata) == 0 {
+		return spanner.NullJSON{}
+	}
+	return spanner.NullJSON{Value: metadata, Valid: true}
+}
+
+func metadataFrom(metadata spanner.NullJSON) map[string]string {
+	if !metadata.Valid {
+		return nil
+	}
+
+	values, ok := metadata.Value.(map[string]any)
+	if !ok || len(values) == 0 {
+		return nil
+	}
+
+	converted := make(map[string]string, len(values))
+	for key, value := range values {
+		converted[key] = fmt.Sprint(value)
+	}
+	return converted
+}
+
 func (rwt spannerReadWriteTXN) WriteNamespaces(ctx context.Context, newConfigs ...*core.NamespaceDefinition) error {
 	mutations := make([]*spanner.Mutation, 0, len(newConfigs))
 	for _, newConfig := range newConfigs {
@@ -242,7 +333,7 @@ func (rwt spannerReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...
 	for _, nsName := range nsNames {
 		if err := deleteWithFilter(ctx, rwt.spannerRWT, &v1.RelationshipFilter{
 			ResourceType: nsName,
-		}); err != nil {
+		}, rwt.metadata); err != nil {
 			return fmt.Errorf(errUnableToDeleteConfig, err)
 		}
 
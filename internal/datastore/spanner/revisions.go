@@ -2,16 +2,22 @@ package spanner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"cloud.google.com/go/spanner"
 	"github.com/shopspring/decimal"
+	"google.golang.org/api/iterator"
 
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/datastore/revision"
 )
 
+// queryBoundedStalenessRead reads a single row of the relationships table, so that the timestamp
+// of the read is one at which the replica serving it has caught up with the relationships.
+var queryBoundedStalenessRead = fmt.Sprintf("SELECT 1 FROM %s LIMIT 1", tableRelationship)
+
 func (sd spannerDatastore) headRevisionInternal(ctx context.Context) (revision.Decimal, error) {
 	now, err := sd.now(ctx)
 	if err != nil {
@@ -45,6 +51,29 @@ func (sd spannerDatastore) now(ctx context.Context) (time.Time, error) {
 	return timestamp, nil
 }
 
+// boundedStalenessRevision returns the revision of the timestamp chosen for a bounded-staleness
+// read of the relationships table, which is the newest timestamp up to which the nearest replica
+// has caught up, and so can serve reads without waiting for the leader.
+func (sd spannerDatastore) boundedStalenessRevision(ctx context.Context) (revision.Decimal, error) {
+	ctx, span := tracer.Start(ctx, "boundedStalenessRevision")
+	defer span.End()
+
+	tx := sd.client.Single().WithTimestampBound(spanner.MaxStaleness(sd.config.maxStaleness))
+	iter := tx.Query(ctx, spanner.NewStatement(queryBoundedStalenessRead))
+	defer iter.Stop()
+
+	if _, err := iter.Next(); err != nil && !errors.Is(err, iterator.Done) {
+		return revision.NoRevision, fmt.Errorf(errRevision, err)
+	}
+
+	timestamp, err := tx.Timestamp()
+	if err != nil {
+		return revision.NoRevision, fmt.Errorf(errRevision, err)
+	}
+
+	return revisionFromTimestamp(timestamp), nil
+}
+
 func revisionFromTimestamp(t time.Time) revision.Decimal {
 	return revision.NewFromDecimal(decimal.NewFromInt(t.UnixNano()))
 }
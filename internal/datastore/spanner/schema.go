@@ -20,6 +20,8 @@ const (
 	colTimestamp        = "timestamp"
 	colCaveatName       = "caveat_name"
 	colCaveatContext    = "caveat_context"
+	colExpiration       = "expiration"
+	colMetadata         = "metadata"
 
 	tableChangelog            = "changelog"
 	colChangeUUID             = "uuid"
@@ -33,6 +35,11 @@ const (
 	colChangeUsersetRelation  = "userset_relation"
 	colChangeCaveatName       = "caveat_name"
 	colChangeCaveatContext    = "caveat_context"
+	colChangeExpiration       = "expiration"
+	colChangeMetadata         = "metadata"
+	colChangeTxnMetadata      = "transaction_metadata"
+
+	changeStreamChangelog = "changelog_stream"
 
 	tableCaveat         = "caveat"
 	colName             = "name"
@@ -61,6 +68,8 @@ var allRelationshipCols = []string{
 	colTimestamp,
 	colCaveatName,
 	colCaveatContext,
+	colExpiration,
+	colMetadata,
 }
 
 var allChangelogCols = []string{
@@ -75,6 +84,9 @@ var allChangelogCols = []string{
 	colChangeUsersetRelation,
 	colChangeCaveatName,
 	colChangeCaveatContext,
+	colChangeExpiration,
+	colChangeMetadata,
+	colChangeTxnMetadata,
 }
 
 // Both creates and touches are emitted as touched to match other datastores.
@@ -15,6 +15,7 @@ import (
 
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/datastore/common/revisions"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/internal/datastore/spanner/migrations"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -35,6 +36,8 @@ const (
 
 	errUnableToWriteRelationships  = "unable to write relationships: %w"
 	errUnableToDeleteRelationships = "unable to delete relationships: %w"
+	errUnableToCountRelationships  = "unable to count relationships: %w"
+	errUnableToListResourceTypes   = "unable to list resource types: %w"
 
 	errUnableToWriteConfig    = "unable to write namespace config: %w"
 	errUnableToReadConfig     = "unable to read namespace config: %w"
@@ -51,6 +54,12 @@ const (
 	// https://cloud.google.com/spanner/quotas
 	// We can't share a default or config option with other datastore implementations.
 	usersetBatchsize = 100
+
+	// Spanner limits the number of mutations in a single commit, where each inserted cell counts
+	// as a mutation. Every bulk loaded relationship writes both a relationship and a changelog row,
+	// so batches are kept well below the limit.
+	// https://cloud.google.com/spanner/quotas#limits_for_creating_reading_updating_and_deleting_data
+	bulkLoadBatchSize = 500
 )
 
 var (
@@ -64,9 +73,10 @@ type spannerDatastore struct {
 	*revisions.RemoteClockRevisions
 	revision.DecimalDecoder
 
-	client *spanner.Client
-	config spannerOptions
-	stopGC context.CancelFunc
+	client    *spanner.Client
+	config    spannerOptions
+	watchMode watchMode
+	stopGC    context.CancelFunc
 }
 
 // NewSpannerDatastore returns a datastore backed by cloud spanner
@@ -101,10 +111,14 @@ func NewSpannerDatastore(database string, opts ...Option) (datastore.Datastore,
 			config.followerReadDelay,
 			config.revisionQuantization,
 		),
-		client: client,
-		config: config,
+		client:    client,
+		config:    config,
+		watchMode: watchModes[config.watchMode],
 	}
 	ds.RemoteClockRevisions.SetNowFunc(ds.headRevisionInternal)
+	if config.maxStaleness > 0 {
+		ds.RemoteClockRevisions.SetOptimizedNowFunc(ds.boundedStalenessRevision)
+	}
 
 	if config.gcInterval > 0*time.Minute && config.gcEnabled {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -120,24 +134,43 @@ func NewSpannerDatastore(database string, opts ...Option) (datastore.Datastore,
 	return ds, nil
 }
 
-func (sd spannerDatastore) SnapshotReader(revisionRaw datastore.Revision) datastore.Reader {
+func (sd spannerDatastore) SnapshotReader(revisionRaw datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
 	revision := revisionRaw.(revision.Decimal)
+	readTimestamp := timestampFromRevision(revision)
+	bound := timestampBound(readTimestamp, options.NewSnapshotReaderOptionsWithOptions(opts...))
 
 	txSource := func() readTX {
-		return sd.client.Single().WithTimestampBound(spanner.ReadTimestamp(timestampFromRevision(revision)))
+		return sd.client.Single().WithTimestampBound(bound)
 	}
 	querySplitter := common.TupleQuerySplitter{
 		Executor:         queryExecutor(txSource),
 		UsersetBatchSize: usersetBatchsize,
 	}
 
-	return spannerReader{querySplitter, txSource}
+	// Relationships which have expired by the revision are filtered out of reads of it, whichever
+	// timestamp they are served at.
+	return spannerReader{querySplitter, txSource, schema.WithExpirationCutoff(sq.Expr("?", readTimestamp))}
+}
+
+// timestampBound maps a consistency hint onto the timestamp bound used for single-use reads.
+func timestampBound(readTimestamp time.Time, opts *options.SnapshotReaderOptions) spanner.TimestampBound {
+	switch opts.Consistency {
+	case options.MinimizeLatency:
+		return spanner.MinReadTimestamp(readTimestamp)
+	case options.BoundedStaleness:
+		return spanner.MaxStaleness(opts.MaxStaleness)
+	default:
+		return spanner.ReadTimestamp(readTimestamp)
+	}
 }
 
 func (sd spannerDatastore) ReadWriteTx(
 	ctx context.Context,
 	fn datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
+	config := options.NewRWTOptionsWithOptions(opts...)
+
 	ts, err := sd.client.ReadWriteTransaction(ctx, func(ctx context.Context, spannerRWT *spanner.ReadWriteTransaction) error {
 		txSource := func() readTX {
 			return spannerRWT
@@ -147,7 +180,11 @@ func (sd spannerDatastore) ReadWriteTx(
 			Executor:         queryExecutor(txSource),
 			UsersetBatchSize: usersetBatchsize,
 		}
-		rwt := spannerReadWriteTXN{spannerReader{querySplitter, txSource}, spannerRWT}
+		rwt := spannerReadWriteTXN{
+			spannerReader{querySplitter, txSource, schema.WithExpirationCutoff(sq.Expr("CURRENT_TIMESTAMP()"))},
+			spannerRWT,
+			config.Metadata,
+		}
 		return fn(rwt)
 	})
 	if err != nil {
@@ -160,6 +197,34 @@ func (sd spannerDatastore) ReadWriteTx(
 	return revisionFromTimestamp(ts), nil
 }
 
+// BulkLoad writes the relationships from the source using buffered insert mutations. As Spanner
+// limits the number of mutations in a single commit, each batch of relationships is committed in
+// its own transaction; since the batch is held in memory, these transactions can be retried.
+func (sd spannerDatastore) BulkLoad(
+	ctx context.Context,
+	source datastore.BulkWriteRelationshipSource,
+) (datastore.Revision, error) {
+	lastRevision := datastore.NoRevision
+	if _, err := common.BulkLoadInBatches(ctx, source, bulkLoadBatchSize, func(ctx context.Context, batch []*core.RelationTupleUpdate) error {
+		rev, err := sd.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			return rwt.WriteRelationships(ctx, batch)
+		})
+		if err != nil {
+			return err
+		}
+
+		lastRevision = rev
+		return nil
+	}); err != nil {
+		return datastore.NoRevision, err
+	}
+
+	if lastRevision == datastore.NoRevision {
+		return sd.HeadRevision(ctx)
+	}
+	return lastRevision, nil
+}
+
 func (sd spannerDatastore) IsReady(ctx context.Context) (bool, error) {
 	headMigration, err := migrations.SpannerMigrations.HeadRevision()
 	if err != nil {
@@ -181,7 +246,14 @@ func (sd spannerDatastore) IsReady(ctx context.Context) (bool, error) {
 }
 
 func (sd spannerDatastore) Features(ctx context.Context) (*datastore.Features, error) {
-	return &datastore.Features{Watch: datastore.Feature{Enabled: true}}, nil
+	return &datastore.Features{
+		Watch:                  datastore.Feature{Enabled: true},
+		WatchCheckpoints:       datastore.Feature{Enabled: true},
+		Caveats:                datastore.Feature{Enabled: true},
+		BulkLoad:               datastore.Feature{Enabled: true},
+		ReverseQuerySort:       datastore.Feature{Enabled: true},
+		RelationshipExpiration: datastore.Feature{Enabled: true},
+	}, nil
 }
 
 func (sd spannerDatastore) Close() error {
@@ -4,6 +4,7 @@
 package spanner
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -25,3 +26,46 @@ func TestSpannerDatastore(t *testing.T) {
 		return ds, nil
 	}))
 }
+
+func TestSpannerDatastoreWithChangeStreamWatch(t *testing.T) {
+	b := testdatastore.RunSpannerForTesting(t, "")
+	tester := test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+		ds := b.NewDatastore(t, func(engine, uri string) datastore.Datastore {
+			ds, err := NewSpannerDatastore(uri,
+				RevisionQuantization(revisionQuantization),
+				GCWindow(gcWindow),
+				WatchBufferLength(watchBufferLength),
+				WatchMode("change-stream"),
+			)
+			require.NoError(t, err)
+			return ds
+		})
+		return ds, nil
+	})
+
+	t.Run("TestWatch", func(t *testing.T) { test.WatchTest(t, tester) })
+	t.Run("TestWatchCancel", func(t *testing.T) { test.WatchCancelTest(t, tester) })
+	t.Run("TestWatchWithMetadata", func(t *testing.T) { test.WatchWithMetadataTest(t, tester) })
+	t.Run("TestWatchCheckpoint", func(t *testing.T) { test.WatchCheckpointTest(t, tester) })
+	t.Run("TestCaveatedRelationshipWatch", func(t *testing.T) { test.CaveatedRelationshipWatchTest(t, tester) })
+}
+
+func TestSpannerDatastoreWithMaxStaleness(t *testing.T) {
+	require := require.New(t)
+
+	ds := testdatastore.RunSpannerForTesting(t, "").NewDatastore(t, func(engine, uri string) datastore.Datastore {
+		ds, err := NewSpannerDatastore(uri, RevisionQuantization(0), MaxStaleness(10*time.Second))
+		require.NoError(err)
+		return ds
+	})
+	defer ds.Close()
+
+	ctx := context.Background()
+	optimized, err := ds.OptimizedRevision(ctx)
+	require.NoError(err)
+
+	head, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+	require.True(optimized.LessThan(head) || optimized.Equal(head))
+	require.NoError(ds.CheckRevision(ctx, optimized))
+}
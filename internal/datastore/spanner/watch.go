@@ -26,12 +26,24 @@ func (sd spannerDatastore) Watch(ctx context.Context, afterRevisionRaw datastore
 	updates := make(chan *datastore.RevisionChanges, sd.config.watchBufferLength)
 	errs := make(chan error, 1)
 
+	if sd.watchMode == watchChangeStream {
+		go func() {
+			defer close(updates)
+			defer close(errs)
+
+			sd.watchChangeStream(ctx, timestampFromRevision(afterRevision), updates, errs)
+		}()
+		return updates, errs
+	}
+
 	go func() {
 		defer close(updates)
 		defer close(errs)
 
 		currentTxn := timestampFromRevision(afterRevision)
 
+		lastSent := time.Now()
+
 		for {
 			var stagedUpdates []*datastore.RevisionChanges
 			var err error
@@ -53,10 +65,25 @@ func (sd spannerDatastore) Watch(ctx context.Context, afterRevisionRaw datastore
 					errs <- datastore.NewWatchDisconnectedErr()
 					return
 				}
+				lastSent = time.Now()
 			}
 
-			// If there were no changes, sleep a bit
+			// If there were no changes, send a checkpoint if none has been sent within the
+			// interval, and sleep a bit
 			if len(stagedUpdates) == 0 {
+				if time.Since(lastSent) >= common.WatchCheckpointInterval {
+					select {
+					case updates <- &datastore.RevisionChanges{
+						Revision:     revisionFromTimestamp(currentTxn),
+						IsCheckpoint: true,
+					}:
+					default:
+						errs <- datastore.NewWatchDisconnectedErr()
+						return
+					}
+					lastSent = time.Now()
+				}
+
 				sleep := time.NewTimer(watchSleep)
 
 				select {
@@ -77,12 +104,47 @@ func (sd spannerDatastore) loadChanges(
 	ctx context.Context,
 	afterTimestamp time.Time,
 ) ([]*datastore.RevisionChanges, time.Time, error) {
-	sql, args, err := queryChanged.Where(sq.Gt{colChangeTS: afterTimestamp}).ToSql()
+	tx := sd.client.Single()
+	changes, newTimestamp, err := sd.readChanges(ctx, tx, queryChanged.Where(sq.Gt{colChangeTS: afterTimestamp}), afterTimestamp)
+	if err != nil {
+		return nil, afterTimestamp, err
+	}
+
+	// All changes committed at or before the read timestamp have been read, so the watch can
+	// continue from it even if it is later than the last change.
+	readTimestamp, err := tx.Timestamp()
+	if err != nil {
+		return nil, afterTimestamp, err
+	}
+	newTimestamp = maxTime(newTimestamp, readTimestamp)
+
+	return changes, newTimestamp, nil
+}
+
+// loadChangesBetween loads the changes committed after the first timestamp, up to and including
+// the second.
+func (sd spannerDatastore) loadChangesBetween(
+	ctx context.Context,
+	afterTimestamp time.Time,
+	upToTimestamp time.Time,
+) ([]*datastore.RevisionChanges, error) {
+	query := queryChanged.Where(sq.Gt{colChangeTS: afterTimestamp}).Where(sq.LtOrEq{colChangeTS: upToTimestamp})
+	changes, _, err := sd.readChanges(ctx, sd.client.Single(), query, afterTimestamp)
+	return changes, err
+}
+
+func (sd spannerDatastore) readChanges(
+	ctx context.Context,
+	tx *spanner.ReadOnlyTransaction,
+	query sq.SelectBuilder,
+	afterTimestamp time.Time,
+) ([]*datastore.RevisionChanges, time.Time, error) {
+	sql, args, err := query.ToSql()
 	if err != nil {
 		return nil, afterTimestamp, err
 	}
 
-	rows := sd.client.Single().Query(ctx, statementFromSQL(sql, args))
+	rows := tx.Query(ctx, statementFromSQL(sql, args))
 	stagedChanges := common.NewChanges()
 
 	newTimestamp := afterTimestamp
@@ -97,6 +159,9 @@ func (sd spannerDatastore) loadChanges(
 		var colChangeUUID string
 		var caveatName spanner.NullString
 		var caveatCtx spanner.NullJSON
+		var expiration spanner.NullTime
+		var metadata spanner.NullJSON
+		var txMetadata spanner.NullJSON
 		err := r.Columns(
 			&timestamp,
 			&colChangeUUID,
@@ -109,6 +174,9 @@ func (sd spannerDatastore) loadChanges(
 			&tpl.Subject.Relation,
 			&caveatName,
 			&caveatCtx,
+			&expiration,
+			&metadata,
+			&txMetadata,
 		)
 		if err != nil {
 			return err
@@ -117,10 +185,15 @@ func (sd spannerDatastore) loadChanges(
 		if err != nil {
 			return err
 		}
+		tpl.OptionalExpirationTime = expirationFrom(expiration)
+		tpl.OptionalMetadata = metadataFrom(metadata)
 
 		newTimestamp = maxTime(newTimestamp, timestamp)
 
 		stagedChanges.AddChange(ctx, revisionFromTimestamp(timestamp), tpl, opMap[op])
+		if txMetadata.Valid {
+			stagedChanges.SetRevisionMetadata(revisionFromTimestamp(timestamp), metadataFrom(txMetadata))
+		}
 
 		return nil
 	})
@@ -128,9 +201,7 @@ func (sd spannerDatastore) loadChanges(
 		return nil, afterTimestamp, err
 	}
 
-	changes := stagedChanges.AsRevisionChanges(sd)
-
-	return changes, newTimestamp, nil
+	return stagedChanges.AsRevisionChanges(sd), newTimestamp, nil
 }
 
 func maxTime(t1 time.Time, t2 time.Time) time.Time {
@@ -0,0 +1,169 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	errDeleteCaveat = "unable to delete caveats: %w"
+	errReadCaveat   = "unable to read caveat: %w"
+	errListCaveats  = "unable to list caveats: %w"
+	errWriteCaveats = "unable to write caveats: %w"
+)
+
+var (
+	readCaveat   = sb.Select(colCaveatDefinition, colCreatedTxn).From(tableCaveat)
+	listCaveats  = sb.Select(colCaveatDefinition).From(tableCaveat)
+	writeCaveat  = sb.Insert(tableCaveat).Columns(colName, colCaveatDefinition, colCreatedTxn)
+	deleteCaveat = sb.Update(tableCaveat).Where(sq.Eq{colDeletedTxn: liveDeletedTxnID})
+)
+
+func (sr *sqliteReader) ReadCaveatByName(ctx context.Context, name string) (*core.CaveatDefinition, datastore.Revision, error) {
+	sqlStatement, args, err := sr.filterer(readCaveat).Where(sq.Eq{colName: name}).ToSql()
+	if err != nil {
+		return nil, datastore.NoRevision, err
+	}
+
+	tx, txCleanup, err := sr.txSource(ctx)
+	if err != nil {
+		return nil, datastore.NoRevision, fmt.Errorf(errReadCaveat, err)
+	}
+	defer common.LogOnError(ctx, txCleanup)
+
+	var serializedDef []byte
+	var txnID uint64
+	err = tx.QueryRowContext(ctx, sqlStatement, args...).Scan(&serializedDef, &txnID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, datastore.NoRevision, datastore.NewCaveatNameNotFoundErr(name)
+		}
+		return nil, datastore.NoRevision, fmt.Errorf(errReadCaveat, err)
+	}
+	def := core.CaveatDefinition{}
+	err = def.UnmarshalVT(serializedDef)
+	if err != nil {
+		return nil, datastore.NoRevision, fmt.Errorf(errReadCaveat, err)
+	}
+	return &def, revisionFromTransaction(txnID), nil
+}
+
+func (sr *sqliteReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	return sr.loadCaveats(ctx, common.ListDefinitionsPage(sr.filterer(listCaveats), colName, opts...))
+}
+
+func (sr *sqliteReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	if len(caveatNames) == 0 {
+		return nil, nil
+	}
+
+	return sr.loadCaveats(ctx, sr.filterer(listCaveats).Where(sq.Eq{colName: caveatNames}))
+}
+
+func (sr *sqliteReader) loadCaveats(ctx context.Context, query sq.SelectBuilder) ([]*core.CaveatDefinition, error) {
+	listSQL, listArgs, err := query.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, txCleanup, err := sr.txSource(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errListCaveats, err)
+	}
+	defer common.LogOnError(ctx, txCleanup)
+
+	rows, err := tx.QueryContext(ctx, listSQL, listArgs...)
+	if err != nil {
+		return nil, fmt.Errorf(errListCaveats, err)
+	}
+	defer common.LogOnError(ctx, rows.Close)
+
+	var caveats []*core.CaveatDefinition
+	for rows.Next() {
+		var defBytes []byte
+		if err := rows.Scan(&defBytes); err != nil {
+			return nil, fmt.Errorf(errListCaveats, err)
+		}
+		c := core.CaveatDefinition{}
+		if err := c.UnmarshalVT(defBytes); err != nil {
+			return nil, fmt.Errorf(errListCaveats, err)
+		}
+		caveats = append(caveats, &c)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf(errListCaveats, rows.Err())
+	}
+
+	return caveats, nil
+}
+
+func (rwt *sqliteReadWriteTXN) WriteCaveats(ctx context.Context, caveats []*core.CaveatDefinition) error {
+	if len(caveats) == 0 {
+		return nil
+	}
+
+	txnID, err := rwt.transactionID(ctx)
+	if err != nil {
+		return fmt.Errorf(errWriteCaveats, err)
+	}
+
+	writeQuery := writeCaveat
+	caveatNamesToWrite := make([]string, 0, len(caveats))
+	for _, newCaveat := range caveats {
+		serialized, err := newCaveat.MarshalVT()
+		if err != nil {
+			return fmt.Errorf("unable to write caveat: %w", err)
+		}
+
+		writeQuery = writeQuery.Values(newCaveat.Name, serialized, txnID)
+		caveatNamesToWrite = append(caveatNamesToWrite, newCaveat.Name)
+	}
+
+	if err := rwt.deleteCaveatsFromNames(ctx, caveatNamesToWrite); err != nil {
+		return fmt.Errorf(errWriteCaveats, err)
+	}
+
+	querySQL, writeArgs, err := writeQuery.ToSql()
+	if err != nil {
+		return fmt.Errorf(errWriteCaveats, err)
+	}
+
+	if _, err := rwt.tx.ExecContext(ctx, querySQL, writeArgs...); err != nil {
+		return fmt.Errorf(errWriteCaveats, err)
+	}
+
+	return nil
+}
+
+func (rwt *sqliteReadWriteTXN) DeleteCaveats(ctx context.Context, names []string) error {
+	return rwt.deleteCaveatsFromNames(ctx, names)
+}
+
+func (rwt *sqliteReadWriteTXN) deleteCaveatsFromNames(ctx context.Context, names []string) error {
+	txnID, err := rwt.transactionID(ctx)
+	if err != nil {
+		return fmt.Errorf(errDeleteCaveat, err)
+	}
+
+	delSQL, delArgs, err := deleteCaveat.
+		Set(colDeletedTxn, txnID).
+		Where(sq.Eq{colName: names}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf(errDeleteCaveat, err)
+	}
+
+	if _, err := rwt.tx.ExecContext(ctx, delSQL, delArgs...); err != nil {
+		return fmt.Errorf(errDeleteCaveat, err)
+	}
+	return nil
+}
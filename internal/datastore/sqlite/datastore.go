@@ -0,0 +1,357 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/dlmiddlecote/sqlstats"
+	"github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/common/revisions"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/internal/datastore/proxy"
+	"github.com/authzed/spicedb/internal/datastore/sqlite/migrations"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+const (
+	Engine = "sqlite"
+
+	// driverName is the database/sql driver of go-sqlite3 with the functions used by the
+	// datastore registered on each connection.
+	driverName = "sqlite3_spicedb"
+
+	tableNamespace   = "namespace_config"
+	tableTransaction = "relation_tuple_transaction"
+	tableTuple       = "relation_tuple"
+	tableCaveat      = "caveat"
+	tableChangelog   = "relation_tuple_changelog"
+	tableMetadata    = "metadata"
+
+	colID               = "id"
+	colTimestamp        = "timestamp"
+	colNamespace        = "namespace"
+	colConfig           = "serialized_config"
+	colCreatedTxn       = "created_transaction"
+	colDeletedTxn       = "deleted_transaction"
+	colObjectID         = "object_id"
+	colRelation         = "relation"
+	colUsersetNamespace = "userset_namespace"
+	colUsersetObjectID  = "userset_object_id"
+	colUsersetRelation  = "userset_relation"
+	colName             = "name"
+	colCaveatDefinition = "definition"
+	colCaveatName       = "caveat_name"
+	colCaveatContext    = "caveat_context"
+	colExpiration       = "expiration"
+	colMetadata         = "metadata"
+	colTransactionID    = "transaction_id"
+	colOperation        = "operation"
+	colUniqueID         = "unique_id"
+
+	// The operations recorded in the changelog by the triggers of the initial migration.
+	changelogCreated = 1
+	changelogDeleted = 2
+
+	// nowFunction is the SQL function returning the current time in nanoseconds since the epoch.
+	nowFunction = "spicedb_now()"
+
+	errUnableToInstantiate = "unable to instantiate datastore: %w"
+	liveDeletedTxnID       = uint64(math.MaxInt64)
+	batchDeleteSize        = 1000
+)
+
+var (
+	tracer = otel.Tracer("spicedb/internal/datastore/sqlite")
+
+	sb = sq.StatementBuilder.PlaceholderFormat(sq.Question)
+)
+
+func init() {
+	datastore.Engines = append(datastore.Engines, Engine)
+
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("spicedb_now", nowNanos, false); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("spicedb_metadata_has", metadataHas, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("spicedb_metadata_value", metadataValueOf, true)
+		},
+	})
+}
+
+// NewSQLiteDatastore creates a new datastore.Datastore backed by the SQLite database file at the
+// path, which must already have been migrated. Supports customization via the various options
+// available in this package.
+//
+// The path may also be a "file:" URI, whose query parameters are passed to go-sqlite3.
+func NewSQLiteDatastore(path string, options ...Option) (datastore.Datastore, error) {
+	ds, err := newSQLiteDatastore(path, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return proxy.NewSeparatingContextDatastoreProxy(ds), nil
+}
+
+func newSQLiteDatastore(path string, options ...Option) (*Datastore, error) {
+	config, err := generateConfig(options)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+
+	db, err := sql.Open(driverName, migrations.ConnectionString(path, config.busyTimeout))
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+
+	// SQLite allows a single writer at a time, while readers of the write-ahead log are never
+	// blocked, so connections beyond the first are only used by reads.
+	db.SetMaxOpenConns(config.maxOpenConns)
+	db.SetMaxIdleConns(config.maxOpenConns)
+	db.SetConnMaxIdleTime(config.connMaxIdleTime)
+
+	if config.enablePrometheusStats {
+		collector := sqlstats.NewStatsCollector("spicedb", db)
+		if err := prometheus.Register(collector); err != nil {
+			return nil, fmt.Errorf(errUnableToInstantiate, err)
+		}
+
+		if err := common.RegisterGCMetrics(); err != nil {
+			return nil, fmt.Errorf(errUnableToInstantiate, err)
+		}
+	}
+
+	gcCtx, cancelGc := context.WithCancel(context.Background())
+
+	maxRevisionStaleness := time.Duration(float64(config.revisionQuantization.Nanoseconds())*
+		config.maxRevisionStalenessPercent) * time.Nanosecond
+
+	store := &Datastore{
+		db:                   db,
+		driver:               migrations.NewSQLiteDriverFromDB(db),
+		revisionQuantization: config.revisionQuantization,
+		gcWindow:             config.gcWindow,
+		gcInterval:           config.gcInterval,
+		gcTimeout:            config.gcMaxOperationTime,
+		gcCtx:                gcCtx,
+		cancelGc:             cancelGc,
+		watchBufferLength:    config.watchBufferLength,
+		usersetBatchSize:     config.splitAtUsersetCount,
+		retryPolicy: common.RetryPolicy{
+			MaxRetries:     config.maxRetries,
+			InitialBackoff: config.retryInitialBackoff,
+			MaxBackoff:     config.retryMaxBackoff,
+		},
+		CachedOptimizedRevisions: revisions.NewCachedOptimizedRevisions(
+			maxRevisionStaleness,
+		),
+	}
+
+	store.SetOptimizedRevisionFunc(store.optimizedRevisionFunc)
+
+	// Start a goroutine for garbage collection.
+	if store.gcInterval > 0*time.Minute && config.gcEnabled {
+		store.gcGroup, store.gcCtx = errgroup.WithContext(store.gcCtx)
+		store.gcGroup.Go(func() error {
+			return common.StartGarbageCollector(
+				store.gcCtx,
+				store,
+				store.gcInterval,
+				store.gcWindow,
+				store.gcTimeout,
+			)
+		})
+	} else {
+		log.Warn().Msg("datastore garbage collection disabled")
+	}
+
+	return store, nil
+}
+
+// Datastore is a SQLite-based implementation of the datastore.Datastore interface
+type Datastore struct {
+	db     *sql.DB
+	driver *migrations.SQLiteDriver
+
+	revisionQuantization time.Duration
+	gcWindow             time.Duration
+	gcInterval           time.Duration
+	gcTimeout            time.Duration
+	watchBufferLength    uint16
+	usersetBatchSize     uint16
+	retryPolicy          common.RetryPolicy
+
+	gcGroup  *errgroup.Group
+	gcCtx    context.Context
+	cancelGc context.CancelFunc
+
+	*revisions.CachedOptimizedRevisions
+	revision.DecimalDecoder
+}
+
+// SnapshotReader ignores consistency hints, as all reads are served by the same database file.
+func (sds *Datastore) SnapshotReader(revisionRaw datastore.Revision, _ ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	rev := revisionRaw.(revision.Decimal)
+
+	createTxFunc := func(ctx context.Context) (*sql.Tx, txCleanupFunc, error) {
+		tx, err := sds.db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return tx, tx.Rollback, nil
+	}
+
+	querySplitter := common.TupleQuerySplitter{
+		Executor:         newSQLiteExecutor(sds.db),
+		UsersetBatchSize: sds.usersetBatchSize,
+	}
+
+	return &sqliteReader{
+		createTxFunc,
+		querySplitter,
+		buildLivingObjectFilterForRevision(rev),
+		schema.WithExpirationCutoff(sq.Expr(
+			"(SELECT "+colTimestamp+" FROM "+tableTransaction+" WHERE "+colID+" = ?)",
+			transactionFromRevision(rev),
+		)),
+	}
+}
+
+func noCleanup() error { return nil }
+
+// ReadWriteTx starts a read/write transaction, which will be committed if no error is
+// returned and rolled back if an error is returned.
+//
+// Transactions are begun as deferred, so that they only take the write lock of the database
+// once they first write, and the transaction row which allocates their revision is only
+// written then. A transaction which finds the lock held by another writer for longer than the
+// busy timeout fails with SQLITE_BUSY and is retried.
+func (sds *Datastore) ReadWriteTx(
+	ctx context.Context,
+	fn datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
+) (datastore.Revision, error) {
+	config := options.NewRWTOptionsWithOptions(opts...)
+
+	var newTxnID uint64
+	_, err := common.RetryTx(ctx, sds.retryPolicy, isErrorRetryable, func(ctx context.Context) error {
+		return migrations.BeginTxFunc(ctx, sds.db, nil, func(tx *sql.Tx) error {
+			longLivedTx := func(context.Context) (*sql.Tx, txCleanupFunc, error) {
+				return tx, noCleanup, nil
+			}
+
+			querySplitter := common.TupleQuerySplitter{
+				Executor:         newSQLiteExecutor(tx),
+				UsersetBatchSize: sds.usersetBatchSize,
+			}
+
+			rwt := &sqliteReadWriteTXN{
+				sqliteReader: &sqliteReader{
+					longLivedTx,
+					querySplitter,
+					currentlyLivingObjects,
+					schema.WithExpirationCutoff(sq.Expr(nowFunction)),
+				},
+				tx:       tx,
+				metadata: config.Metadata,
+			}
+
+			if err := fn(rwt); err != nil {
+				return err
+			}
+
+			// A transaction which wrote nothing is still assigned a revision.
+			var err error
+			newTxnID, err = rwt.transactionID(ctx)
+			return err
+		})
+	})
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+
+	return revisionFromTransaction(newTxnID), nil
+}
+
+// BulkLoad writes all relationships from the source in a single transaction, using multi-row
+// inserts of common.DefaultBulkLoadBatchSize relationships each.
+func (sds *Datastore) BulkLoad(
+	ctx context.Context,
+	source datastore.BulkWriteRelationshipSource,
+) (datastore.Revision, error) {
+	return sds.ReadWriteTx(ctx, common.SingleAttemptTxFunc(func(rwt datastore.ReadWriteTransaction) error {
+		_, err := common.BulkLoadInBatches(ctx, source, common.DefaultBulkLoadBatchSize, rwt.WriteRelationships)
+		return err
+	}))
+}
+
+// Close closes the data store.
+func (sds *Datastore) Close() error {
+	sds.cancelGc()
+	if sds.gcGroup != nil {
+		if err := sds.gcGroup.Wait(); err != nil {
+			log.Error().Err(err).Msg("error waiting for garbage collector to shutdown")
+		}
+	}
+	return sds.db.Close()
+}
+
+// IsReady returns whether the datastore is ready to accept data, which is once the database
+// file has been migrated to the head migration.
+func (sds *Datastore) IsReady(ctx context.Context) (bool, error) {
+	if err := sds.db.PingContext(ctx); err != nil {
+		return false, err
+	}
+
+	headMigration, err := migrations.Manager.HeadRevision()
+	if err != nil {
+		return false, fmt.Errorf("invalid head migration found for sqlite: %w", err)
+	}
+
+	version, err := sds.driver.Version(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return version == headMigration, nil
+}
+
+func (sds *Datastore) Features(ctx context.Context) (*datastore.Features, error) {
+	return &datastore.Features{
+		Watch:                  datastore.Feature{Enabled: true},
+		WatchCheckpoints:       datastore.Feature{Enabled: true},
+		Caveats:                datastore.Feature{Enabled: true},
+		BulkLoad:               datastore.Feature{Enabled: true},
+		ReverseQuerySort:       datastore.Feature{Enabled: true},
+		RelationshipExpiration: datastore.Feature{Enabled: true},
+	}, nil
+}
+
+func buildLivingObjectFilterForRevision(revision revision.Decimal) queryFilterer {
+	return func(original sq.SelectBuilder) sq.SelectBuilder {
+		return original.Where(sq.LtOrEq{colCreatedTxn: transactionFromRevision(revision)}).
+			Where(sq.Or{
+				sq.Eq{colDeletedTxn: liveDeletedTxnID},
+				sq.Gt{colDeletedTxn: transactionFromRevision(revision)},
+			})
+	}
+}
+
+func currentlyLivingObjects(original sq.SelectBuilder) sq.SelectBuilder {
+	return original.Where(sq.Eq{colDeletedTxn: liveDeletedTxnID})
+}
@@ -0,0 +1,89 @@
+//go:build ci
+// +build ci
+
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/sqlite/migrations"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/test"
+	"github.com/authzed/spicedb/pkg/migrate"
+)
+
+type datastoreTester struct {
+	t *testing.T
+}
+
+func (dst *datastoreTester) createDatastore(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+	path := migratedDatabase(dst.t)
+
+	ds, err := newSQLiteDatastore(path,
+		RevisionQuantization(revisionQuantization),
+		GCWindow(gcWindow),
+		GCInterval(0*time.Second),
+		WatchBufferLength(watchBufferLength),
+		BusyTimeout(time.Second),
+		// The default of --datastore-query-userset-batch-size, which must be
+		// lowered to stay within the expression depth limit of SQLite.
+		SplitAtUsersetCount(1024),
+	)
+	require.NoError(dst.t, err)
+	dst.t.Cleanup(func() {
+		require.NoError(dst.t, ds.Close())
+	})
+
+	_, err = ds.IsReady(context.Background())
+	require.NoError(dst.t, err)
+	return ds, nil
+}
+
+// migratedDatabase returns the path of a new database file, migrated to the head migration.
+func migratedDatabase(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "spicedb.db")
+
+	driver, err := migrations.NewSQLiteDriver(path)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, driver.Close(context.Background()))
+	}()
+
+	err = migrations.Manager.Run(context.Background(), driver, migrate.Head, migrate.LiveRun)
+	require.NoError(t, err)
+	return path
+}
+
+func TestSQLiteDatastore(t *testing.T) {
+	dst := datastoreTester{t: t}
+	test.All(t, test.DatastoreTesterFunc(dst.createDatastore))
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	req := require.New(t)
+
+	driver, err := migrations.NewSQLiteDriver(filepath.Join(t.TempDir(), "spicedb.db"))
+	req.NoError(err)
+	defer func() {
+		req.NoError(driver.Close(context.Background()))
+	}()
+
+	version, err := driver.Version(context.Background())
+	req.NoError(err)
+	req.Equal("", version)
+
+	err = migrations.Manager.Run(context.Background(), driver, migrate.Head, migrate.LiveRun)
+	req.NoError(err)
+
+	version, err = driver.Version(context.Background())
+	req.NoError(err)
+
+	headVersion, err := migrations.Manager.HeadRevision()
+	req.NoError(err)
+	req.Equal(headVersion, version)
+}
@@ -0,0 +1,28 @@
+//go:build cgo
+// +build cgo
+
+package sqlite
+
+import (
+	"errors"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// isErrorRetryable returns whether the error was caused by the lock of another connection, which
+// is the only way in which transactions conflict, as SQLite serializes all writes.
+func isErrorRetryable(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+}
+
+// isUniqueConstraintError returns whether the error was caused by a row violating a unique
+// constraint.
+func isUniqueConstraintError(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
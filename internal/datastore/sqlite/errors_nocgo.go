@@ -0,0 +1,15 @@
+//go:build !cgo
+// +build !cgo
+
+package sqlite
+
+// Without cgo, go-sqlite3 is built as a stub which fails to open any database, so there are no
+// errors of SQLite to classify.
+
+func isErrorRetryable(_ error) bool {
+	return false
+}
+
+func isUniqueConstraintError(_ error) bool {
+	return false
+}
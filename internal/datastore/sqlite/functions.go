@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// nowNanos is registered as spicedb_now(). As the datastore is embedded in a single process,
+// the clock of the process is the clock of the database.
+func nowNanos() int64 {
+	return time.Now().UnixNano()
+}
+
+// metadataHas is registered as spicedb_metadata_has(metadata, key), returning whether the JSON
+// metadata of a relationship holds the key.
+func metadataHas(metadata, key string) (bool, error) {
+	decoded, err := decodeMetadata(metadata)
+	if err != nil {
+		return false, err
+	}
+	_, ok := decoded[key]
+	return ok, nil
+}
+
+// metadataValueOf is registered as spicedb_metadata_value(metadata, key), returning the value for
+// the key from the JSON metadata of a relationship.
+func metadataValueOf(metadata, key string) (string, error) {
+	decoded, err := decodeMetadata(metadata)
+	if err != nil {
+		return "", err
+	}
+	return decoded[key], nil
+}
+
+func decodeMetadata(metadata string) (map[string]string, error) {
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(metadata), &decoded); err != nil {
+		return nil, fmt.Errorf("malformed relationship metadata: %w", err)
+	}
+	return decoded, nil
+}
+
+// expirationNanosOf returns the expiration time of the tuple in nanoseconds since the epoch, or
+// nil if the tuple does not expire.
+func expirationNanosOf(tpl *core.RelationTuple) any {
+	if tpl.OptionalExpirationTime == nil {
+		return nil
+	}
+	return tpl.OptionalExpirationTime.AsTime().UnixNano()
+}
+
+// expirationFrom converts an expiration loaded from the nullable integer column into a time.
+func expirationFrom(nanos sql.NullInt64) sql.NullTime {
+	if !nanos.Valid {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: time.Unix(0, nanos.Int64).UTC(), Valid: true}
+}
@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+var (
+	_ common.GarbageCollector               = (*Datastore)(nil)
+	_ datastore.GarbageCollectableDatastore = (*Datastore)(nil)
+)
+
+// Now returns the time of the process, which is the clock of the embedded database.
+func (sds *Datastore) Now(_ context.Context) (time.Time, error) {
+	return time.Now().UTC(), nil
+}
+
+func (sds *Datastore) TxIDBefore(ctx context.Context, before time.Time) (datastore.Revision, error) {
+	// Find the highest transaction ID before the GC window.
+	query, args, err := sb.Select("MAX(id)").From(tableTransaction).Where(sq.Lt{colTimestamp: before.UnixNano()}).ToSql()
+	if err != nil {
+		return datastore.NoRevision, err
+	}
+
+	var value sql.NullInt64
+	if err := sds.db.QueryRowContext(ctx, query, args...).Scan(&value); err != nil {
+		return datastore.NoRevision, err
+	}
+
+	if !value.Valid {
+		log.Debug().Time("before", before).Msg("no stale transactions found in the datastore")
+		return datastore.NoRevision, nil
+	}
+	return revisionFromTransaction(uint64(value.Int64)), nil
+}
+
+func (sds *Datastore) CountTransactionsBeforeTx(ctx context.Context, txID datastore.Revision) (int64, error) {
+	query, args, err := sb.Select("COUNT(*)").From(tableTransaction).
+		Where(sq.Lt{colID: transactionFromRevision(txID.(revision.Decimal))}).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = sds.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count, err
+}
+
+// CollectGarbage runs garbage collection immediately, regardless of whether the background
+// garbage collection is enabled.
+func (sds *Datastore) CollectGarbage(ctx context.Context) (datastore.GarbageCollected, error) {
+	return common.RunGarbageCollection(ctx, sds, sds.gcWindow, sds.gcTimeout)
+}
+
+func (sds *Datastore) DeleteBeforeTx(
+	ctx context.Context,
+	txID datastore.Revision,
+) (removed common.DeletionCounts, err error) {
+	tx := transactionFromRevision(txID.(revision.Decimal))
+
+	// Delete any relationship rows with deleted_transaction <= the transaction ID, or which have
+	// expired.
+	removed.Relationships, err = sds.batchDelete(ctx, tableTuple, sq.Or{
+		sq.LtOrEq{colDeletedTxn: tx},
+		sq.Expr(colExpiration + " < " + nowFunction),
+	})
+	if err != nil {
+		return
+	}
+
+	// Delete the changes of the transactions which are being deleted, which can no longer be
+	// watched.
+	if _, err = sds.batchDelete(ctx, tableChangelog, sq.Lt{colTransactionID: tx}); err != nil {
+		return
+	}
+
+	// Delete all transaction rows with ID < the transaction ID.
+	//
+	// We don't delete the transaction itself to ensure there is always at least
+	// one transaction present.
+	removed.Transactions, err = sds.batchDelete(ctx, tableTransaction, sq.Lt{colID: tx})
+
+	// Namespace and caveat definitions are not deleted, as their prior versions are retained.
+	return
+}
+
+// batchDelete deletes the rows of the table matching the filter in batches, each in its own
+// transaction, so that the write lock is never held for long. SQLite is not built with support
+// for DELETE ... LIMIT by default, so each batch is selected by a subquery.
+func (sds *Datastore) batchDelete(ctx context.Context, tableName string, filter sq.Sqlizer) (int64, error) {
+	batch := sb.Select(colID).From(tableName).Where(filter).Limit(batchDeleteSize)
+	query, args, err := sb.Delete(tableName).Where(sq.Expr(colID+" IN (?)", batch)).ToSql()
+	if err != nil {
+		return -1, err
+	}
+
+	var deletedCount int64
+	for {
+		cr, err := sds.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return deletedCount, err
+		}
+
+		rowsDeleted, err := cr.RowsAffected()
+		if err != nil {
+			return deletedCount, err
+		}
+		deletedCount += rowsDeleted
+		if rowsDeleted < batchDeleteSize {
+			break
+		}
+	}
+
+	return deletedCount, nil
+}
@@ -0,0 +1,133 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	// Registers the sqlite3 database/sql driver.
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/migrate"
+)
+
+const (
+	errUnableToInstantiate = "unable to instantiate SQLiteDriver: %w"
+
+	// DefaultBusyTimeout is the time for which a connection waits for the lock held by another
+	// writer before failing with SQLITE_BUSY.
+	DefaultBusyTimeout = 5 * time.Second
+)
+
+// SQLiteDriver is an implementation of migrate.Driver for SQLite
+type SQLiteDriver struct {
+	db *sql.DB
+}
+
+// NewSQLiteDriver creates a new migration driver for the SQLite database file at the path.
+func NewSQLiteDriver(path string) (*SQLiteDriver, error) {
+	db, err := sql.Open("sqlite3", ConnectionString(path, DefaultBusyTimeout))
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToInstantiate, err)
+	}
+	return NewSQLiteDriverFromDB(db), nil
+}
+
+// NewSQLiteDriverFromDB creates a new migration driver with a connection pool specified upfront.
+func NewSQLiteDriverFromDB(db *sql.DB) *SQLiteDriver {
+	return &SQLiteDriver{db}
+}
+
+// ConnectionString returns the go-sqlite3 connection string for the database file at the path,
+// which opens it in WAL mode so that readers are not blocked by the single writer, and waits
+// up to the busy timeout for the lock of another writer.
+func ConnectionString(path string, busyTimeout time.Duration) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%s_journal_mode=WAL&_busy_timeout=%d", path, separator, busyTimeout.Milliseconds())
+}
+
+// Version returns the version of the schema to which the connected database
+// has been migrated.
+func (driver *SQLiteDriver) Version(ctx context.Context) (string, error) {
+	var tables int
+	if err := driver.db.QueryRowContext(
+		ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?",
+		tableMigrationVersion,
+	).Scan(&tables); err != nil {
+		return "", fmt.Errorf("unable to load driver migration revision: %w", err)
+	}
+	if tables == 0 {
+		return "", nil
+	}
+
+	var loaded string
+	if err := driver.db.QueryRowContext(ctx, "SELECT version_num FROM "+tableMigrationVersion).Scan(&loaded); err != nil {
+		return "", fmt.Errorf("unable to load driver migration revision: %w", err)
+	}
+
+	return loaded, nil
+}
+
+// Conn returns the underlying connection pool of the driver.
+func (driver *SQLiteDriver) Conn() *sql.DB {
+	return driver.db
+}
+
+func (driver *SQLiteDriver) RunTx(ctx context.Context, f migrate.TxMigrationFunc[*sql.Tx]) error {
+	return BeginTxFunc(ctx, driver.db, nil, func(tx *sql.Tx) error {
+		return f(ctx, tx)
+	})
+}
+
+// BeginTxFunc is a polyfill for database/sql which implements a closure style transaction lifecycle.
+// The underlying transaction is aborted if the supplied function returns an error.
+// The underlying transaction is committed if the supplied function returns nil.
+func BeginTxFunc(ctx context.Context, db *sql.DB, txOptions *sql.TxOptions, f func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, txOptions)
+	if err != nil {
+		return err
+	}
+	defer common.LogOnError(ctx, tx.Rollback)
+
+	if err := f(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// WriteVersion replaces the version of the database schema.
+func (driver *SQLiteDriver) WriteVersion(ctx context.Context, tx *sql.Tx, version, replaced string) error {
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE "+tableMigrationVersion+" SET version_num = ? WHERE version_num = ?",
+		version,
+		replaced,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to update version row: %w", err)
+	}
+
+	updatedCount, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unable to update version row: %w", err)
+	}
+	if updatedCount != 1 {
+		return fmt.Errorf("writing version update affected %d rows, should be 1", updatedCount)
+	}
+
+	return nil
+}
+
+func (driver *SQLiteDriver) Close(_ context.Context) error {
+	return driver.db.Close()
+}
+
+var _ migrate.Driver[*sql.DB, *sql.Tx] = &SQLiteDriver{}
@@ -0,0 +1,14 @@
+package migrations
+
+import (
+	"database/sql"
+
+	"github.com/authzed/spicedb/pkg/migrate"
+)
+
+const tableMigrationVersion = "migration_version"
+
+var noNonatomicMigration migrate.MigrationFunc[*sql.DB]
+
+// Manager is the singleton migration manager instance for SQLite
+var Manager = migrate.NewManager[*SQLiteDriver, *sql.DB, *sql.Tx]()
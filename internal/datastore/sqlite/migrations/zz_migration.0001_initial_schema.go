@@ -0,0 +1,164 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// Timestamps and expirations are stored as integer nanoseconds since the Unix epoch, as SQLite
+// has no native timestamp type.
+const createRelationTupleTransaction = `CREATE TABLE relation_tuple_transaction (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	timestamp INTEGER NOT NULL,
+	metadata TEXT
+);`
+
+const createTransactionTimestampIndex = `CREATE INDEX ix_relation_tuple_transaction_by_timestamp
+	ON relation_tuple_transaction (timestamp);`
+
+const createNamespaceConfig = `CREATE TABLE namespace_config (
+	namespace TEXT NOT NULL,
+	serialized_config BLOB NOT NULL,
+	created_transaction INTEGER NOT NULL,
+	deleted_transaction INTEGER NOT NULL DEFAULT 9223372036854775807,
+	CONSTRAINT uq_namespace_config UNIQUE (namespace, created_transaction, deleted_transaction)
+);`
+
+const createNamespaceLivingIndex = `CREATE UNIQUE INDEX uq_namespace_living
+	ON namespace_config (namespace)
+	WHERE deleted_transaction = 9223372036854775807;`
+
+const createRelationTuple = `CREATE TABLE relation_tuple (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	namespace TEXT NOT NULL,
+	object_id TEXT NOT NULL,
+	relation TEXT NOT NULL,
+	userset_namespace TEXT NOT NULL,
+	userset_object_id TEXT NOT NULL,
+	userset_relation TEXT NOT NULL,
+	caveat_name TEXT NOT NULL DEFAULT '',
+	caveat_context TEXT,
+	expiration INTEGER,
+	metadata TEXT,
+	created_transaction INTEGER NOT NULL,
+	deleted_transaction INTEGER NOT NULL DEFAULT 9223372036854775807,
+	CONSTRAINT uq_relation_tuple_living UNIQUE (namespace, object_id, relation, userset_namespace, userset_object_id, userset_relation, deleted_transaction)
+);`
+
+const createRelationTupleSubjectIndex = `CREATE INDEX ix_relation_tuple_by_subject
+	ON relation_tuple (userset_namespace, userset_object_id, userset_relation, namespace, object_id, relation);`
+
+const createRelationTupleGCIndex = `CREATE INDEX ix_relation_tuple_by_deleted_transaction
+	ON relation_tuple (deleted_transaction);`
+
+const createRelationTupleExpirationIndex = `CREATE INDEX ix_relation_tuple_by_expiration
+	ON relation_tuple (expiration)
+	WHERE expiration IS NOT NULL;`
+
+const createCaveat = `CREATE TABLE caveat (
+	name TEXT NOT NULL,
+	definition BLOB NOT NULL,
+	created_transaction INTEGER NOT NULL,
+	deleted_transaction INTEGER NOT NULL DEFAULT 9223372036854775807,
+	CONSTRAINT uq_caveat UNIQUE (name, created_transaction, deleted_transaction)
+);`
+
+const createCaveatLivingIndex = `CREATE UNIQUE INDEX uq_caveat_living
+	ON caveat (name)
+	WHERE deleted_transaction = 9223372036854775807;`
+
+// The changelog holds a copy of each relationship as it was created or deleted, keyed by the
+// transaction which did so, so that watches need not scan the relationships table and see
+// changes to relationships which have since been garbage collected.
+const createRelationTupleChangelog = `CREATE TABLE relation_tuple_changelog (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	transaction_id INTEGER NOT NULL,
+	operation INTEGER NOT NULL,
+	namespace TEXT NOT NULL,
+	object_id TEXT NOT NULL,
+	relation TEXT NOT NULL,
+	userset_namespace TEXT NOT NULL,
+	userset_object_id TEXT NOT NULL,
+	userset_relation TEXT NOT NULL,
+	caveat_name TEXT NOT NULL,
+	caveat_context TEXT,
+	expiration INTEGER,
+	metadata TEXT
+);`
+
+const createChangelogTransactionIndex = `CREATE INDEX ix_relation_tuple_changelog_by_transaction
+	ON relation_tuple_changelog (transaction_id);`
+
+// Operation 1 is a created relationship and operation 2 a deleted relationship.
+const createChangelogInsertTrigger = `CREATE TRIGGER tr_relation_tuple_changelog_created
+	AFTER INSERT ON relation_tuple
+BEGIN
+	INSERT INTO relation_tuple_changelog (transaction_id, operation, namespace, object_id, relation,
+		userset_namespace, userset_object_id, userset_relation, caveat_name, caveat_context, expiration, metadata)
+	VALUES (NEW.created_transaction, 1, NEW.namespace, NEW.object_id, NEW.relation,
+		NEW.userset_namespace, NEW.userset_object_id, NEW.userset_relation, NEW.caveat_name, NEW.caveat_context,
+		NEW.expiration, NEW.metadata);
+END;`
+
+const createChangelogDeleteTrigger = `CREATE TRIGGER tr_relation_tuple_changelog_deleted
+	AFTER UPDATE OF deleted_transaction ON relation_tuple
+	WHEN OLD.deleted_transaction = 9223372036854775807 AND NEW.deleted_transaction != 9223372036854775807
+BEGIN
+	INSERT INTO relation_tuple_changelog (transaction_id, operation, namespace, object_id, relation,
+		userset_namespace, userset_object_id, userset_relation, caveat_name, caveat_context, expiration, metadata)
+	VALUES (NEW.deleted_transaction, 2, NEW.namespace, NEW.object_id, NEW.relation,
+		NEW.userset_namespace, NEW.userset_object_id, NEW.userset_relation, NEW.caveat_name, NEW.caveat_context,
+		NEW.expiration, NEW.metadata);
+END;`
+
+const createMetadata = `CREATE TABLE metadata (
+	id INTEGER PRIMARY KEY CHECK (id = 0),
+	unique_id TEXT NOT NULL
+);`
+
+const insertUniqueID = `INSERT INTO metadata (id, unique_id) VALUES (0, ?);`
+
+const insertFirstTransaction = `INSERT INTO relation_tuple_transaction (timestamp) VALUES (0);`
+
+const createMigrationVersion = `CREATE TABLE migration_version (
+	version_num TEXT NOT NULL
+);`
+
+const insertEmptyVersion = `INSERT INTO migration_version (version_num) VALUES ('');`
+
+func init() {
+	if err := Manager.Register("initial", "", noNonatomicMigration, func(ctx context.Context, tx *sql.Tx) error {
+		statements := []string{
+			createRelationTupleTransaction,
+			createTransactionTimestampIndex,
+			createNamespaceConfig,
+			createNamespaceLivingIndex,
+			createRelationTuple,
+			createRelationTupleSubjectIndex,
+			createRelationTupleGCIndex,
+			createRelationTupleExpirationIndex,
+			createCaveat,
+			createCaveatLivingIndex,
+			createRelationTupleChangelog,
+			createChangelogTransactionIndex,
+			createChangelogInsertTrigger,
+			createChangelogDeleteTrigger,
+			createMetadata,
+			insertFirstTransaction,
+			createMigrationVersion,
+			insertEmptyVersion,
+		}
+		for _, stmt := range statements {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.ExecContext(ctx, insertUniqueID, uuid.NewString())
+		return err
+	}); err != nil {
+		panic("failed to register migration: " + err.Error())
+	}
+}
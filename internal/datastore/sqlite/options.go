@@ -0,0 +1,244 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/sqlite/migrations"
+)
+
+const (
+	errQuantizationTooLarge = "revision quantization interval (%s) must be less than GC window (%s)"
+
+	defaultGarbageCollectionWindow           = 24 * time.Hour
+	defaultGarbageCollectionInterval         = time.Minute * 3
+	defaultGarbageCollectionMaxOperationTime = time.Minute
+	defaultMaxOpenConns                      = 10
+	defaultConnMaxIdleTime                   = 30 * time.Minute
+	defaultWatchBufferLength                 = 128
+	defaultUsersetBatchSize                  = 100
+	maxUsersetBatchSize                      = 500
+	defaultQuantization                      = 5 * time.Second
+	defaultMaxRevisionStalenessPercent       = 0.1
+	defaultEnablePrometheusStats             = false
+	defaultMaxRetries                        = 8
+	defaultGCEnabled                         = true
+)
+
+type sqliteOptions struct {
+	revisionQuantization        time.Duration
+	gcWindow                    time.Duration
+	gcInterval                  time.Duration
+	gcMaxOperationTime          time.Duration
+	maxRevisionStalenessPercent float64
+	watchBufferLength           uint16
+	enablePrometheusStats       bool
+	maxOpenConns                int
+	connMaxIdleTime             time.Duration
+	splitAtUsersetCount         uint16
+	maxRetries                  uint8
+	retryInitialBackoff         time.Duration
+	retryMaxBackoff             time.Duration
+	busyTimeout                 time.Duration
+	gcEnabled                   bool
+}
+
+// Option provides the facility to configure how the SQLite datastore accesses
+// its database file.
+type Option func(*sqliteOptions)
+
+func generateConfig(options []Option) (sqliteOptions, error) {
+	computed := sqliteOptions{
+		gcWindow:                    defaultGarbageCollectionWindow,
+		gcInterval:                  defaultGarbageCollectionInterval,
+		gcMaxOperationTime:          defaultGarbageCollectionMaxOperationTime,
+		watchBufferLength:           defaultWatchBufferLength,
+		maxOpenConns:                defaultMaxOpenConns,
+		connMaxIdleTime:             defaultConnMaxIdleTime,
+		splitAtUsersetCount:         defaultUsersetBatchSize,
+		revisionQuantization:        defaultQuantization,
+		maxRevisionStalenessPercent: defaultMaxRevisionStalenessPercent,
+		enablePrometheusStats:       defaultEnablePrometheusStats,
+		maxRetries:                  defaultMaxRetries,
+		retryInitialBackoff:         common.DefaultRetryInitialBackoff,
+		retryMaxBackoff:             common.DefaultRetryMaxBackoff,
+		busyTimeout:                 migrations.DefaultBusyTimeout,
+		gcEnabled:                   defaultGCEnabled,
+	}
+
+	for _, option := range options {
+		option(&computed)
+	}
+
+	// Each batch of usersets is queried as a chain of ORs, whose expression tree
+	// must stay below the maximum depth of 1000 allowed by SQLite.
+	if computed.splitAtUsersetCount > maxUsersetBatchSize {
+		computed.splitAtUsersetCount = maxUsersetBatchSize
+	}
+
+	// Run any checks on the config that need to be done
+	if computed.revisionQuantization >= computed.gcWindow {
+		return computed, fmt.Errorf(
+			errQuantizationTooLarge,
+			computed.revisionQuantization,
+			computed.gcWindow,
+		)
+	}
+
+	return computed, nil
+}
+
+// WatchBufferLength is the number of entries that can be stored in the watch
+// buffer while awaiting read by the client.
+//
+// This value defaults to 128.
+func WatchBufferLength(watchBufferLength uint16) Option {
+	return func(so *sqliteOptions) {
+		so.watchBufferLength = watchBufferLength
+	}
+}
+
+// RevisionQuantization is the time bucket size to which advertised
+// revisions will be rounded.
+//
+// This value defaults to 5 seconds.
+func RevisionQuantization(quantization time.Duration) Option {
+	return func(so *sqliteOptions) {
+		so.revisionQuantization = quantization
+	}
+}
+
+// MaxRevisionStalenessPercent is the amount of time, expressed as a percentage of
+// the revision quantization window, that a previously computed rounded revision
+// can still be advertised after the next rounded revision would otherwise be ready.
+//
+// This value defaults to 0.1 (10%).
+func MaxRevisionStalenessPercent(stalenessPercent float64) Option {
+	return func(so *sqliteOptions) {
+		so.maxRevisionStalenessPercent = stalenessPercent
+	}
+}
+
+// GCWindow is the maximum age of a passed revision that will be considered
+// valid.
+//
+// This value defaults to 24 hours.
+func GCWindow(window time.Duration) Option {
+	return func(so *sqliteOptions) {
+		so.gcWindow = window
+	}
+}
+
+// GCInterval is the interval at which garbage collection will occur.
+//
+// This value defaults to 3 minutes.
+func GCInterval(interval time.Duration) Option {
+	return func(so *sqliteOptions) {
+		so.gcInterval = interval
+	}
+}
+
+// GCMaxOperationTime is the maximum operation time of a garbage collection
+// pass before it times out.
+//
+// This value defaults to 1 minute.
+func GCMaxOperationTime(time time.Duration) Option {
+	return func(so *sqliteOptions) {
+		so.gcMaxOperationTime = time
+	}
+}
+
+// GCEnabled indicates whether garbage collection is enabled.
+//
+// GC is enabled by default.
+func GCEnabled(isGCEnabled bool) Option {
+	return func(so *sqliteOptions) {
+		so.gcEnabled = isGCEnabled
+	}
+}
+
+// MaxRetries is the maximum number of times a transaction which found the
+// database locked by another writer will be client-side retried.
+//
+// Default: 8
+func MaxRetries(maxRetries uint8) Option {
+	return func(so *sqliteOptions) {
+		so.maxRetries = maxRetries
+	}
+}
+
+// RetryInitialBackoff is the wait before the first client-side retry of a
+// transaction, which doubles with each further retry up to RetryMaxBackoff.
+// Zero retries immediately.
+//
+// Default: 10ms
+func RetryInitialBackoff(backoff time.Duration) Option {
+	return func(so *sqliteOptions) {
+		so.retryInitialBackoff = backoff
+	}
+}
+
+// RetryMaxBackoff is the longest wait between client-side retries of a
+// transaction.
+//
+// Default: 1s
+func RetryMaxBackoff(backoff time.Duration) Option {
+	return func(so *sqliteOptions) {
+		so.retryMaxBackoff = backoff
+	}
+}
+
+// BusyTimeout is the time for which a transaction waits for the write lock
+// held by another transaction before failing with SQLITE_BUSY, after which it
+// is retried.
+//
+// Default: 5s
+func BusyTimeout(timeout time.Duration) Option {
+	return func(so *sqliteOptions) {
+		so.busyTimeout = timeout
+	}
+}
+
+// SplitAtUsersetCount is the batch size for which userset queries will be
+// split into smaller queries. Larger batch sizes than 500 are lowered to 500,
+// as SQLite limits the depth of the expression tree of each query.
+//
+// This defaults to 100.
+func SplitAtUsersetCount(splitAtUsersetCount uint16) Option {
+	return func(so *sqliteOptions) {
+		so.splitAtUsersetCount = splitAtUsersetCount
+	}
+}
+
+// WithEnablePrometheusStats marks whether Prometheus metrics provided by Go's database/sql package
+// are enabled.
+//
+// Prometheus metrics are disabled by default.
+func WithEnablePrometheusStats(enablePrometheusStats bool) Option {
+	return func(so *sqliteOptions) {
+		so.enablePrometheusStats = enablePrometheusStats
+	}
+}
+
+// ConnMaxIdleTime is the duration after which an idle connection will be
+// automatically closed.
+// See https://pkg.go.dev/database/sql#DB.SetConnMaxIdleTime/
+//
+// This value defaults to 30 minutes.
+func ConnMaxIdleTime(idle time.Duration) Option {
+	return func(so *sqliteOptions) {
+		so.connMaxIdleTime = idle
+	}
+}
+
+// MaxOpenConns is the maximum number of connections open to the database
+// file. Only one of them can write at a time, while the others read.
+// See https://pkg.go.dev/database/sql#DB.SetMaxOpenConns
+//
+// This value defaults to 10.
+func MaxOpenConns(conns int) Option {
+	return func(so *sqliteOptions) {
+		so.maxOpenConns = conns
+	}
+}
@@ -0,0 +1,352 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+type txCleanupFunc func() error
+
+type txFactory func(context.Context) (*sql.Tx, txCleanupFunc, error)
+
+type sqliteReader struct {
+	txSource      txFactory
+	querySplitter common.TupleQuerySplitter
+	filterer      queryFilterer
+	schema        common.SchemaInformation
+}
+
+type queryFilterer func(original sq.SelectBuilder) sq.SelectBuilder
+
+const (
+	errUnableToReadConfig         = "unable to read namespace config: %w"
+	errUnableToListNamespaces     = "unable to list namespaces: %w"
+	errUnableToQueryTuples        = "unable to query tuples: %w"
+	errUnableToCountRelationships = "unable to count relationships: %w"
+	errUnableToListResourceTypes  = "unable to list resource types: %w"
+)
+
+var (
+	queryTuples = sb.Select(
+		colNamespace,
+		colObjectID,
+		colRelation,
+		colUsersetNamespace,
+		colUsersetObjectID,
+		colUsersetRelation,
+		colCaveatName,
+		colCaveatContext,
+		colExpiration,
+		colMetadata,
+	).From(tableTuple)
+
+	countTuples = sb.Select("COUNT(*)").From(tableTuple)
+
+	listResourceTypes = sb.Select(colNamespace).Distinct().From(tableTuple).OrderBy(colNamespace)
+
+	readNamespace = sb.Select(colConfig, colCreatedTxn).From(tableNamespace)
+)
+
+var schema = common.SchemaInformation{
+	ColNamespace:        colNamespace,
+	ColObjectID:         colObjectID,
+	ColRelation:         colRelation,
+	ColUsersetNamespace: colUsersetNamespace,
+	ColUsersetObjectID:  colUsersetObjectID,
+	ColUsersetRelation:  colUsersetRelation,
+	ColCaveatName:       colCaveatName,
+	ColExpiration:       colExpiration,
+	MetadataValue:       metadataValue,
+}
+
+// metadataValue selects the value for a key from the JSON metadata column, with the functions
+// registered on each connection, as go-sqlite3 is not built with the JSON extension by default.
+func metadataValue(key string) (string, []any) {
+	return "CASE WHEN " + colMetadata + " IS NULL THEN NULL" +
+		" WHEN spicedb_metadata_has(" + colMetadata + ", ?) THEN spicedb_metadata_value(" + colMetadata + ", ?) END", []any{key, key}
+}
+
+func (sr *sqliteReader) QueryRelationships(
+	ctx context.Context,
+	filter datastore.RelationshipsFilter,
+	opts ...options.QueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	qBuilder := common.NewSchemaQueryFilterer(sr.schema, sr.filterer(queryTuples)).FilterWithRelationshipsFilter(filter)
+	return sr.querySplitter.SplitAndExecuteQuery(ctx, qBuilder, opts...)
+}
+
+func (sr *sqliteReader) ReverseQueryRelationships(
+	ctx context.Context,
+	subjectsFilter datastore.SubjectsFilter,
+	opts ...options.ReverseQueryOptionsOption,
+) (iter datastore.RelationshipIterator, err error) {
+	qBuilder := common.NewSchemaQueryFilterer(sr.schema, sr.filterer(queryTuples)).
+		FilterWithSubjectsFilter(subjectsFilter)
+
+	queryOpts := options.NewReverseQueryOptionsWithOptions(opts...)
+
+	if queryOpts.ResRelation != nil {
+		qBuilder = qBuilder.
+			FilterToResourceType(queryOpts.ResRelation.Namespace).
+			FilterToRelation(queryOpts.ResRelation.Relation)
+	}
+
+	if queryOpts.ReverseSort == options.BySubject {
+		qBuilder = qBuilder.SortBySubject()
+	}
+
+	if queryOpts.ReverseAfter != nil {
+		qBuilder = qBuilder.FilterAfterSubject(queryOpts.ReverseAfter)
+	}
+
+	return sr.querySplitter.SplitAndExecuteQuery(
+		ctx,
+		qBuilder,
+		options.WithLimit(queryOpts.ReverseLimit),
+	)
+}
+
+func (sr *sqliteReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	query, args, err := common.NewSchemaQueryFilterer(sr.schema, sr.filterer(countTuples)).
+		FilterWithRelationshipsFilter(filter).
+		ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+
+	tx, txCleanup, err := sr.txSource(ctx)
+	if err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+	defer common.LogOnError(ctx, txCleanup)
+
+	var count uint64
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf(errUnableToCountRelationships, err)
+	}
+
+	return count, nil
+}
+
+func (sr *sqliteReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	query, args, err := common.NewSchemaQueryFilterer(sr.schema, sr.filterer(listResourceTypes)).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+
+	tx, txCleanup, err := sr.txSource(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+	defer common.LogOnError(ctx, txCleanup)
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+	}
+	defer common.LogOnError(ctx, rows.Close)
+
+	var resourceTypes []string
+	for rows.Next() {
+		var resourceType string
+		if err := rows.Scan(&resourceType); err != nil {
+			return nil, fmt.Errorf(errUnableToListResourceTypes, err)
+		}
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf(errUnableToListResourceTypes, rows.Err())
+	}
+
+	return resourceTypes, nil
+}
+
+func (sr *sqliteReader) ReadNamespace(ctx context.Context, nsName string) (*core.NamespaceDefinition, datastore.Revision, error) {
+	tx, txCleanup, err := sr.txSource(ctx)
+	if err != nil {
+		return nil, datastore.NoRevision, fmt.Errorf(errUnableToReadConfig, err)
+	}
+	defer common.LogOnError(ctx, txCleanup)
+
+	loaded, version, err := loadNamespace(ctx, nsName, tx, sr.filterer(readNamespace))
+	switch {
+	case errors.As(err, &datastore.ErrNamespaceNotFound{}):
+		return nil, datastore.NoRevision, err
+	case err == nil:
+		return loaded, version, nil
+	default:
+		return nil, datastore.NoRevision, fmt.Errorf(errUnableToReadConfig, err)
+	}
+}
+
+func loadNamespace(ctx context.Context, namespace string, tx *sql.Tx, baseQuery sq.SelectBuilder) (*core.NamespaceDefinition, datastore.Revision, error) {
+	ctx, span := tracer.Start(ctx, "loadNamespace")
+	defer span.End()
+
+	query, args, err := baseQuery.Where(sq.Eq{colNamespace: namespace}).ToSql()
+	if err != nil {
+		return nil, datastore.NoRevision, err
+	}
+
+	var config []byte
+	var version uint64
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&config, &version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = datastore.NewNamespaceNotFoundErr(namespace)
+		}
+		return nil, datastore.NoRevision, err
+	}
+
+	loaded := &core.NamespaceDefinition{}
+	if err := loaded.UnmarshalVT(config); err != nil {
+		return nil, datastore.NoRevision, err
+	}
+
+	return loaded, revisionFromTransaction(version), nil
+}
+
+func (sr *sqliteReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	tx, txCleanup, err := sr.txSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer common.LogOnError(ctx, txCleanup)
+
+	query := common.ListDefinitionsPage(sr.filterer(readNamespace), colNamespace, opts...)
+
+	nsDefs, err := loadAllNamespaces(ctx, tx, query)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListNamespaces, err)
+	}
+
+	return nsDefs, err
+}
+
+func (sr *sqliteReader) LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error) {
+	if len(nsNames) == 0 {
+		return nil, nil
+	}
+
+	tx, txCleanup, err := sr.txSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer common.LogOnError(ctx, txCleanup)
+
+	query := sr.filterer(readNamespace.Where(sq.Eq{colNamespace: nsNames}))
+
+	nsDefs, err := loadAllNamespaces(ctx, tx, query)
+	if err != nil {
+		return nil, fmt.Errorf(errUnableToListNamespaces, err)
+	}
+
+	return nsDefs, err
+}
+
+type querier interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}
+
+func loadAllNamespaces(ctx context.Context, tx querier, queryBuilder sq.SelectBuilder) ([]*core.NamespaceDefinition, error) {
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer common.LogOnError(ctx, rows.Close)
+
+	var nsDefs []*core.NamespaceDefinition
+	for rows.Next() {
+		var config []byte
+		var version uint64
+		if err := rows.Scan(&config, &version); err != nil {
+			return nil, err
+		}
+
+		loaded := &core.NamespaceDefinition{}
+		if err := loaded.UnmarshalVT(config); err != nil {
+			return nil, fmt.Errorf(errUnableToReadConfig, err)
+		}
+
+		nsDefs = append(nsDefs, loaded)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return nsDefs, nil
+}
+
+func newSQLiteExecutor(tx querier) common.ExecuteQueryFunc {
+	return func(ctx context.Context, sqlQuery string, args []interface{}) ([]*core.RelationTuple, error) {
+		span := trace.SpanFromContext(ctx)
+
+		rows, err := tx.QueryContext(ctx, sqlQuery, args...)
+		if err != nil {
+			return nil, fmt.Errorf(errUnableToQueryTuples, err)
+		}
+		defer common.LogOnError(ctx, rows.Close)
+
+		span.AddEvent("Query issued to database")
+
+		var tuples []*core.RelationTuple
+		for rows.Next() {
+			nextTuple := &core.RelationTuple{
+				ResourceAndRelation: &core.ObjectAndRelation{},
+				Subject:             &core.ObjectAndRelation{},
+			}
+
+			var caveatName string
+			var caveatContext caveatContextWrapper
+			var expiration sql.NullInt64
+			var metadata metadataWrapper
+			err := rows.Scan(
+				&nextTuple.ResourceAndRelation.Namespace,
+				&nextTuple.ResourceAndRelation.ObjectId,
+				&nextTuple.ResourceAndRelation.Relation,
+				&nextTuple.Subject.Namespace,
+				&nextTuple.Subject.ObjectId,
+				&nextTuple.Subject.Relation,
+				&caveatName,
+				&caveatContext,
+				&expiration,
+				&metadata,
+			)
+			if err != nil {
+				return nil, fmt.Errorf(errUnableToQueryTuples, err)
+			}
+
+			nextTuple.Caveat, err = common.ContextualizedCaveatFrom(caveatName, caveatContext)
+			if err != nil {
+				return nil, fmt.Errorf(errUnableToQueryTuples, err)
+			}
+			nextTuple.OptionalExpirationTime = common.ExpirationFrom(expirationFrom(expiration))
+			nextTuple.OptionalMetadata = metadata
+
+			tuples = append(tuples, nextTuple)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf(errUnableToQueryTuples, err)
+		}
+		span.AddEvent("Tuples loaded", trace.WithAttributes(attribute.Int("tupleCount", len(tuples))))
+		return tuples, nil
+	}
+}
+
+var _ datastore.Reader = &sqliteReader{}
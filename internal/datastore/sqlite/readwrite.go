@@ -0,0 +1,377 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"github.com/jzelinskie/stringz"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	errUnableToWriteRelationships  = "unable to write relationships: %w"
+	errUnableToDeleteRelationships = "unable to delete relationships: %w"
+	errUnableToWriteConfig         = "unable to write namespace config: %w"
+	errUnableToDeleteConfig        = "unable to delete namespace config: %w"
+
+	// deleteClauseBatchSize is the number of relationships matched by each statement deleting
+	// relationships, as SQLite limits the depth of the expression tree of a statement.
+	deleteClauseBatchSize = 100
+)
+
+var (
+	createTxn = sb.Insert(tableTransaction).Columns(colTimestamp, colMetadata)
+
+	writeTuple = sb.Insert(tableTuple).Columns(
+		colNamespace,
+		colObjectID,
+		colRelation,
+		colUsersetNamespace,
+		colUsersetObjectID,
+		colUsersetRelation,
+		colCaveatName,
+		colCaveatContext,
+		colExpiration,
+		colMetadata,
+		colCreatedTxn,
+	)
+
+	deleteTuple = sb.Update(tableTuple).Where(sq.Eq{colDeletedTxn: liveDeletedTxnID})
+
+	writeNamespace = sb.Insert(tableNamespace).Columns(colNamespace, colConfig, colCreatedTxn)
+
+	deleteNamespace = sb.Update(tableNamespace).Where(sq.Eq{colDeletedTxn: liveDeletedTxnID})
+)
+
+type sqliteReadWriteTXN struct {
+	*sqliteReader
+
+	tx       *sql.Tx
+	metadata map[string]string
+
+	// newTxnID is the ID of the transaction row, which is zero until the first write.
+	newTxnID uint64
+}
+
+// caveatContextWrapper is used to marshall caveat contexts into a JSON text column
+type caveatContextWrapper map[string]any
+
+func (cc *caveatContextWrapper) Scan(val any) error {
+	switch v := val.(type) {
+	case nil:
+		*cc = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), cc)
+	case []byte:
+		return json.Unmarshal(v, cc)
+	default:
+		return fmt.Errorf("unsupported type: %T", val)
+	}
+}
+
+func (cc caveatContextWrapper) Value() (driver.Value, error) {
+	if cc == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(map[string]any(cc))
+	return string(encoded), err
+}
+
+// metadataWrapper is used to marshall relationship and transaction metadata into a JSON text
+// column, storing empty metadata as NULL
+type metadataWrapper map[string]string
+
+func (mw *metadataWrapper) Scan(val any) error {
+	switch v := val.(type) {
+	case nil:
+		*mw = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), mw)
+	case []byte:
+		return json.Unmarshal(v, mw)
+	default:
+		return fmt.Errorf("unsupported type: %T", val)
+	}
+}
+
+func (mw metadataWrapper) Value() (driver.Value, error) {
+	if len(mw) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(map[string]string(mw))
+	return string(encoded), err
+}
+
+// transactionID returns the ID of the transaction, writing its row on first use. The row is
+// written lazily so that the write lock of the database is not taken until it is needed.
+func (rwt *sqliteReadWriteTXN) transactionID(ctx context.Context) (uint64, error) {
+	if rwt.newTxnID != 0 {
+		return rwt.newTxnID, nil
+	}
+
+	ctx, span := tracer.Start(ctx, "createNewTransaction")
+	defer span.End()
+
+	query, args, err := createTxn.Values(sq.Expr(nowFunction), metadataWrapper(rwt.metadata)).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("createNewTransaction: %w", err)
+	}
+
+	result, err := rwt.tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("createNewTransaction: %w", err)
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("createNewTransaction: failed to get last inserted id: %w", err)
+	}
+
+	rwt.newTxnID = uint64(lastInsertID)
+	return rwt.newTxnID, nil
+}
+
+// WriteRelationships takes a list of existing relationships that must exist, and a list of
+// tuple mutations and applies it to the datastore for the specified namespace.
+func (rwt *sqliteReadWriteTXN) WriteRelationships(ctx context.Context, mutations []*core.RelationTupleUpdate) error {
+	if len(mutations) == 0 {
+		return nil
+	}
+
+	txnID, err := rwt.transactionID(ctx)
+	if err != nil {
+		return fmt.Errorf(errUnableToWriteRelationships, err)
+	}
+
+	bulkWrite := writeTuple
+	bulkWriteHasValues := false
+
+	var clauses []sq.Sqlizer
+	for _, mut := range mutations {
+		tpl := mut.Tuple
+
+		switch mut.Operation {
+		case core.RelationTupleUpdate_TOUCH, core.RelationTupleUpdate_DELETE:
+			clauses = append(clauses, exactRelationshipClause(tpl))
+		case core.RelationTupleUpdate_CREATE:
+			// Expired relationships no longer exist, so they are removed before a CREATE of the
+			// same relationship.
+			clauses = append(clauses, sq.And{
+				exactRelationshipClause(tpl),
+				sq.Expr(colExpiration + " <= " + nowFunction),
+			})
+		}
+
+		if mut.Operation == core.RelationTupleUpdate_TOUCH || mut.Operation == core.RelationTupleUpdate_CREATE {
+			var caveatName string
+			var caveatContext caveatContextWrapper
+			if tpl.Caveat != nil {
+				caveatName = tpl.Caveat.CaveatName
+				caveatContext = tpl.Caveat.Context.AsMap()
+			}
+
+			bulkWrite = bulkWrite.Values(
+				tpl.ResourceAndRelation.Namespace,
+				tpl.ResourceAndRelation.ObjectId,
+				tpl.ResourceAndRelation.Relation,
+				tpl.Subject.Namespace,
+				tpl.Subject.ObjectId,
+				tpl.Subject.Relation,
+				caveatName,
+				caveatContext,
+				expirationNanosOf(tpl),
+				metadataWrapper(tpl.OptionalMetadata),
+				txnID,
+			)
+			bulkWriteHasValues = true
+		}
+	}
+
+	for len(clauses) > 0 {
+		batch := clauses
+		if len(batch) > deleteClauseBatchSize {
+			batch = batch[:deleteClauseBatchSize]
+		}
+		clauses = clauses[len(batch):]
+
+		query, args, err := deleteTuple.Where(sq.Or(batch)).Set(colDeletedTxn, txnID).ToSql()
+		if err != nil {
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+		if _, err := rwt.tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+	}
+
+	if bulkWriteHasValues {
+		query, args, err := bulkWrite.ToSql()
+		if err != nil {
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+
+		if _, err := rwt.tx.ExecContext(ctx, query, args...); err != nil {
+			if isUniqueConstraintError(err) {
+				// SQLite does not report the values which violated the constraint.
+				return common.NewCreateRelationshipExistsError(nil)
+			}
+
+			return fmt.Errorf(errUnableToWriteRelationships, err)
+		}
+	}
+
+	return nil
+}
+
+func (rwt *sqliteReadWriteTXN) DeleteRelationships(ctx context.Context, filter *v1.RelationshipFilter) error {
+	txnID, err := rwt.transactionID(ctx)
+	if err != nil {
+		return fmt.Errorf(errUnableToDeleteRelationships, err)
+	}
+
+	// Add clauses for the ResourceFilter
+	query := deleteTuple.Where(sq.Eq{colNamespace: filter.ResourceType})
+	if filter.OptionalResourceId != "" {
+		query = query.Where(sq.Eq{colObjectID: filter.OptionalResourceId})
+	}
+	if filter.OptionalRelation != "" {
+		query = query.Where(sq.Eq{colRelation: filter.OptionalRelation})
+	}
+
+	// Add clauses for the SubjectFilter
+	if subjectFilter := filter.OptionalSubjectFilter; subjectFilter != nil {
+		query = query.Where(sq.Eq{colUsersetNamespace: subjectFilter.SubjectType})
+		if subjectFilter.OptionalSubjectId != "" {
+			query = query.Where(sq.Eq{colUsersetObjectID: subjectFilter.OptionalSubjectId})
+		}
+		if relationFilter := subjectFilter.OptionalRelation; relationFilter != nil {
+			query = query.Where(sq.Eq{colUsersetRelation: stringz.DefaultEmpty(relationFilter.Relation, datastore.Ellipsis)})
+		}
+	}
+
+	querySQL, args, err := query.Set(colDeletedTxn, txnID).ToSql()
+	if err != nil {
+		return fmt.Errorf(errUnableToDeleteRelationships, err)
+	}
+
+	if _, err := rwt.tx.ExecContext(ctx, querySQL, args...); err != nil {
+		return fmt.Errorf(errUnableToDeleteRelationships, err)
+	}
+
+	return nil
+}
+
+func (rwt *sqliteReadWriteTXN) WriteNamespaces(ctx context.Context, newNamespaces ...*core.NamespaceDefinition) error {
+	if len(newNamespaces) == 0 {
+		return nil
+	}
+
+	txnID, err := rwt.transactionID(ctx)
+	if err != nil {
+		return fmt.Errorf(errUnableToWriteConfig, err)
+	}
+
+	nsNames := make([]string, 0, len(newNamespaces))
+	writeQuery := writeNamespace
+	for _, newNamespace := range newNamespaces {
+		serialized, err := newNamespace.MarshalVT()
+		if err != nil {
+			return fmt.Errorf(errUnableToWriteConfig, err)
+		}
+
+		nsNames = append(nsNames, newNamespace.Name)
+		writeQuery = writeQuery.Values(newNamespace.Name, serialized, txnID)
+	}
+
+	delSQL, delArgs, err := deleteNamespace.
+		Set(colDeletedTxn, txnID).
+		Where(sq.Eq{colNamespace: nsNames}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf(errUnableToWriteConfig, err)
+	}
+
+	if _, err := rwt.tx.ExecContext(ctx, delSQL, delArgs...); err != nil {
+		return fmt.Errorf(errUnableToWriteConfig, err)
+	}
+
+	query, args, err := writeQuery.ToSql()
+	if err != nil {
+		return fmt.Errorf(errUnableToWriteConfig, err)
+	}
+
+	if _, err := rwt.tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf(errUnableToWriteConfig, err)
+	}
+
+	return nil
+}
+
+func (rwt *sqliteReadWriteTXN) DeleteNamespaces(ctx context.Context, nsNames ...string) error {
+	// Check that each namespace exists before deleting any of them.
+	for _, nsName := range nsNames {
+		_, _, err := loadNamespace(ctx, nsName, rwt.tx, rwt.filterer(readNamespace))
+		switch {
+		case errors.As(err, &datastore.ErrNamespaceNotFound{}):
+			return err
+		case err == nil:
+			break
+		default:
+			return fmt.Errorf(errUnableToDeleteConfig, err)
+		}
+	}
+
+	txnID, err := rwt.transactionID(ctx)
+	if err != nil {
+		return fmt.Errorf(errUnableToDeleteConfig, err)
+	}
+
+	delSQL, delArgs, err := deleteNamespace.
+		Set(colDeletedTxn, txnID).
+		Where(sq.Eq{colNamespace: nsNames}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf(errUnableToDeleteConfig, err)
+	}
+
+	if _, err := rwt.tx.ExecContext(ctx, delSQL, delArgs...); err != nil {
+		return fmt.Errorf(errUnableToDeleteConfig, err)
+	}
+
+	deleteTupleSQL, deleteTupleArgs, err := deleteTuple.
+		Set(colDeletedTxn, txnID).
+		Where(sq.Eq{colNamespace: nsNames}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf(errUnableToDeleteConfig, err)
+	}
+
+	if _, err := rwt.tx.ExecContext(ctx, deleteTupleSQL, deleteTupleArgs...); err != nil {
+		return fmt.Errorf(errUnableToDeleteConfig, err)
+	}
+
+	return nil
+}
+
+func exactRelationshipClause(r *core.RelationTuple) sq.Eq {
+	return sq.Eq{
+		colNamespace:        r.ResourceAndRelation.Namespace,
+		colObjectID:         r.ResourceAndRelation.ObjectId,
+		colRelation:         r.ResourceAndRelation.Relation,
+		colUsersetNamespace: r.Subject.Namespace,
+		colUsersetObjectID:  r.Subject.ObjectId,
+		colUsersetRelation:  r.Subject.Relation,
+	}
+}
+
+var _ datastore.ReadWriteTransaction = &sqliteReadWriteTXN{}
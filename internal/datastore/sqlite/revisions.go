@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+)
+
+const (
+	errRevision      = "unable to find revision: %w"
+	errCheckRevision = "unable to check revision: %w"
+
+	// querySelectRevision finds the first transaction at or after the start of the current
+	// quantization period, which is computed by the caller. If there are no transactions newer
+	// than the start of the period, it just picks the latest transaction.
+	querySelectRevision = `SELECT COALESCE((
+			SELECT MIN(id) FROM relation_tuple_transaction WHERE timestamp >= ?
+		), (
+			SELECT MAX(id) FROM relation_tuple_transaction
+		))`
+
+	// queryValidTransaction returns whether the transaction is at least as new as the first
+	// transaction within the garbage collection window, which is always true of the latest
+	// transaction, and whether the transaction is newer than the latest transaction.
+	queryValidTransaction = `SELECT ? >= COALESCE((
+			SELECT MIN(id) FROM relation_tuple_transaction WHERE timestamp >= ?
+		), (
+			SELECT MAX(id) FROM relation_tuple_transaction
+		)) AS fresh, ? > (
+			SELECT MAX(id) FROM relation_tuple_transaction
+		) AS unknown`
+
+	queryHeadRevision = "SELECT MAX(id) FROM relation_tuple_transaction"
+)
+
+func (sds *Datastore) optimizedRevisionFunc(ctx context.Context) (datastore.Revision, time.Duration, error) {
+	quantization := sds.revisionQuantization.Nanoseconds()
+	if quantization < 1 {
+		quantization = 1
+	}
+
+	now := time.Now().UnixNano()
+	periodStart := now - now%quantization
+	validFor := time.Duration(quantization - now%quantization)
+
+	var rev uint64
+	if err := sds.db.QueryRowContext(ctx, querySelectRevision, periodStart).Scan(&rev); err != nil {
+		return revision.NoRevision, 0, fmt.Errorf(errRevision, err)
+	}
+	return revisionFromTransaction(rev), validFor, nil
+}
+
+func (sds *Datastore) HeadRevision(ctx context.Context) (datastore.Revision, error) {
+	ctx, span := tracer.Start(ctx, "HeadRevision")
+	defer span.End()
+
+	var rev sql.NullInt64
+	if err := sds.db.QueryRowContext(ctx, queryHeadRevision).Scan(&rev); err != nil {
+		return datastore.NoRevision, fmt.Errorf(errRevision, err)
+	}
+	if !rev.Valid {
+		return datastore.NoRevision, nil
+	}
+
+	return revisionFromTransaction(uint64(rev.Int64)), nil
+}
+
+func (sds *Datastore) CheckRevision(ctx context.Context, revisionRaw datastore.Revision) error {
+	if revisionRaw == datastore.NoRevision {
+		return datastore.NewInvalidRevisionErr(revisionRaw, datastore.CouldNotDetermineRevision)
+	}
+
+	rev := revisionRaw.(revision.Decimal)
+	revisionTx := transactionFromRevision(rev)
+
+	ctx, span := tracer.Start(ctx, "checkValidTransaction")
+	defer span.End()
+
+	gcWindowStart := time.Now().Add(-sds.gcWindow).UnixNano()
+
+	var freshEnough, unknown sql.NullBool
+	if err := sds.db.QueryRowContext(ctx, queryValidTransaction, revisionTx, gcWindowStart, revisionTx).
+		Scan(&freshEnough, &unknown); err != nil {
+		return fmt.Errorf(errCheckRevision, err)
+	}
+
+	if !freshEnough.Bool {
+		return datastore.NewInvalidRevisionErr(rev, datastore.RevisionStale)
+	}
+	if unknown.Bool {
+		return datastore.NewInvalidRevisionErr(rev, datastore.CouldNotDetermineRevision)
+	}
+
+	return nil
+}
+
+func revisionFromTransaction(txID uint64) revision.Decimal {
+	return revision.NewFromDecimal(decimal.NewFromBigInt(new(big.Int).SetUint64(txID), 0))
+}
+
+func transactionFromRevision(revision revision.Decimal) uint64 {
+	return uint64(revision.IntPart())
+}
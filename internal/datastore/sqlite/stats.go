@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+var (
+	queryUniqueID = sb.Select(colUniqueID).From(tableMetadata)
+
+	// SQLite keeps no table statistics to estimate from, so the relationships are counted, which
+	// is cheap at the sizes for which an embedded datastore is suited.
+	queryRelationshipCounts = currentlyLivingObjects(
+		sb.Select(colNamespace, "COUNT(*)").From(tableTuple).GroupBy(colNamespace),
+	)
+
+	queryDistinctResources = sb.Select("COUNT(*)").FromSelect(
+		currentlyLivingObjects(sb.Select(colNamespace, colObjectID).Distinct().From(tableTuple)),
+		"resources",
+	)
+
+	queryDistinctSubjects = sb.Select("COUNT(*)").FromSelect(
+		currentlyLivingObjects(sb.Select(colUsersetNamespace, colUsersetObjectID).Distinct().From(tableTuple)),
+		"subjects",
+	)
+
+	// The size of the database file, of which relationships and their indexes are the bulk.
+	queryStorageSize = "SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()"
+)
+
+func (sds *Datastore) Statistics(ctx context.Context) (datastore.Stats, error) {
+	tx, err := sds.db.BeginTx(ctx, nil)
+	if err != nil {
+		return datastore.Stats{}, err
+	}
+	defer common.LogOnError(ctx, tx.Rollback)
+
+	uniqueIDSQL, uniqueIDArgs, err := queryUniqueID.ToSql()
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to generate query sql: %w", err)
+	}
+
+	var uniqueID string
+	if err := tx.QueryRowContext(ctx, uniqueIDSQL, uniqueIDArgs...).Scan(&uniqueID); err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to query unique ID: %w", err)
+	}
+
+	countsSQL, countsArgs, err := queryRelationshipCounts.ToSql()
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to prepare row count sql: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, countsSQL, countsArgs...)
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to count relationships: %w", err)
+	}
+	defer common.LogOnError(ctx, rows.Close)
+
+	var count uint64
+	relationshipEstimates := make(map[string]uint64)
+	for rows.Next() {
+		var namespace string
+		var namespaceCount uint64
+		if err := rows.Scan(&namespace, &namespaceCount); err != nil {
+			return datastore.Stats{}, fmt.Errorf("unable to count relationships: %w", err)
+		}
+		relationshipEstimates[namespace] = namespaceCount
+		count += namespaceCount
+	}
+	if err := rows.Err(); err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to count relationships: %w", err)
+	}
+
+	distinctResources, err := queryCount(ctx, tx, queryDistinctResources)
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to count distinct resources: %w", err)
+	}
+
+	distinctSubjects, err := queryCount(ctx, tx, queryDistinctSubjects)
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to count distinct subjects: %w", err)
+	}
+
+	var storageBytes uint64
+	if err := tx.QueryRowContext(ctx, queryStorageSize).Scan(&storageBytes); err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to query storage size: %w", err)
+	}
+
+	nsDefs, err := loadAllNamespaces(ctx, tx, currentlyLivingObjects(readNamespace))
+	if err != nil {
+		return datastore.Stats{}, fmt.Errorf("unable to load namespaces: %w", err)
+	}
+
+	return datastore.Stats{
+		UniqueID:                   uniqueID,
+		ObjectTypeStatistics:       datastore.ComputeObjectTypeStatsWithEstimates(nsDefs, relationshipEstimates),
+		EstimatedRelationshipCount: count,
+		EstimatedDistinctResources: distinctResources,
+		EstimatedDistinctSubjects:  distinctSubjects,
+		EstimatedStorageBytes:      storageBytes,
+	}, nil
+}
+
+func queryCount(ctx context.Context, tx *sql.Tx, query sq.SelectBuilder) (uint64, error) {
+	querySQL, args, err := query.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	err = tx.QueryRowContext(ctx, querySQL, args...).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,243 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+const (
+	watchSleep = 100 * time.Millisecond
+)
+
+var (
+	queryChangelog = sb.Select(
+		colNamespace,
+		colObjectID,
+		colRelation,
+		colUsersetNamespace,
+		colUsersetObjectID,
+		colUsersetRelation,
+		colCaveatName,
+		colCaveatContext,
+		colExpiration,
+		colMetadata,
+		colTransactionID,
+		colOperation,
+	).From(tableChangelog).OrderBy(colID)
+
+	queryTransactionMetadata = sb.Select(colID, colMetadata).From(tableTransaction).Where(sq.NotEq{colMetadata: nil})
+)
+
+// Watch notifies the caller about all changes to tuples.
+//
+// All events following afterRevision will be sent to the caller. Changes are read from the
+// changelog table, which is written by triggers on the relationships table as each relationship
+// is created or deleted.
+func (sds *Datastore) Watch(ctx context.Context, afterRevisionRaw datastore.Revision) (<-chan *datastore.RevisionChanges, <-chan error) {
+	afterRevision := afterRevisionRaw.(revision.Decimal)
+
+	updates := make(chan *datastore.RevisionChanges, sds.watchBufferLength)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(updates)
+		defer close(errs)
+
+		currentTxn := transactionFromRevision(afterRevision)
+
+		lastSent := time.Now()
+
+		for {
+			var stagedUpdates []*datastore.RevisionChanges
+			var err error
+			stagedUpdates, currentTxn, err = sds.loadChanges(ctx, currentTxn)
+			if err != nil {
+				if errors.Is(ctx.Err(), context.Canceled) {
+					errs <- datastore.NewWatchCanceledErr()
+				} else {
+					errs <- err
+				}
+				return
+			}
+
+			// Write the staged updates to the channel
+			for _, changeToWrite := range stagedUpdates {
+				select {
+				case updates <- changeToWrite:
+				default:
+					errs <- datastore.NewWatchDisconnectedErr()
+					return
+				}
+				lastSent = time.Now()
+			}
+
+			// If there were no changes, send a checkpoint if none has been sent within the
+			// interval, and sleep a bit
+			if len(stagedUpdates) == 0 {
+				if time.Since(lastSent) >= common.WatchCheckpointInterval {
+					select {
+					case updates <- &datastore.RevisionChanges{
+						Revision:     revisionFromTransaction(currentTxn),
+						IsCheckpoint: true,
+					}:
+					default:
+						errs <- datastore.NewWatchDisconnectedErr()
+						return
+					}
+					lastSent = time.Now()
+				}
+
+				sleep := time.NewTimer(watchSleep)
+
+				select {
+				case <-sleep.C:
+					break
+				case <-ctx.Done():
+					errs <- datastore.NewWatchCanceledErr()
+					return
+				}
+			}
+		}
+	}()
+
+	return updates, errs
+}
+
+func (sds *Datastore) loadChanges(
+	ctx context.Context,
+	afterRevision uint64,
+) (changes []*datastore.RevisionChanges, newRevision uint64, err error) {
+	head, err := sds.HeadRevision(ctx)
+	if err != nil {
+		return
+	}
+	newRevision = afterRevision
+	if head != datastore.NoRevision {
+		newRevision = transactionFromRevision(head.(revision.Decimal))
+	}
+
+	if newRevision == afterRevision {
+		return
+	}
+
+	// As SQLite serializes all writes, transactions commit in the order of their IDs, and no
+	// transaction at or before the head revision can commit later.
+	query, args, err := queryChangelog.Where(sq.And{
+		sq.Gt{colTransactionID: afterRevision},
+		sq.LtOrEq{colTransactionID: newRevision},
+	}).ToSql()
+	if err != nil {
+		return
+	}
+
+	rows, err := sds.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			err = datastore.NewWatchCanceledErr()
+		}
+		return
+	}
+	defer common.LogOnError(ctx, rows.Close)
+
+	stagedChanges := common.NewChanges()
+
+	for rows.Next() {
+		nextTuple := &core.RelationTuple{
+			ResourceAndRelation: &core.ObjectAndRelation{},
+			Subject:             &core.ObjectAndRelation{},
+		}
+
+		var txnID uint64
+		var operation int
+		var caveatName string
+		var caveatContext caveatContextWrapper
+		var expiration sql.NullInt64
+		var metadata metadataWrapper
+		err = rows.Scan(
+			&nextTuple.ResourceAndRelation.Namespace,
+			&nextTuple.ResourceAndRelation.ObjectId,
+			&nextTuple.ResourceAndRelation.Relation,
+			&nextTuple.Subject.Namespace,
+			&nextTuple.Subject.ObjectId,
+			&nextTuple.Subject.Relation,
+			&caveatName,
+			&caveatContext,
+			&expiration,
+			&metadata,
+			&txnID,
+			&operation,
+		)
+		if err != nil {
+			return
+		}
+		nextTuple.Caveat, err = common.ContextualizedCaveatFrom(caveatName, caveatContext)
+		if err != nil {
+			return
+		}
+		nextTuple.OptionalExpirationTime = common.ExpirationFrom(expirationFrom(expiration))
+		nextTuple.OptionalMetadata = metadata
+
+		switch operation {
+		case changelogCreated:
+			stagedChanges.AddChange(ctx, revisionFromTransaction(txnID), nextTuple, core.RelationTupleUpdate_TOUCH)
+		case changelogDeleted:
+			stagedChanges.AddChange(ctx, revisionFromTransaction(txnID), nextTuple, core.RelationTupleUpdate_DELETE)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	if err = sds.loadTransactionMetadata(ctx, afterRevision, newRevision, stagedChanges); err != nil {
+		return
+	}
+
+	changes = stagedChanges.AsRevisionChanges(sds)
+
+	return
+}
+
+// loadTransactionMetadata adds the metadata stored with the transactions in the revision range
+// to the staged changes.
+func (sds *Datastore) loadTransactionMetadata(
+	ctx context.Context,
+	afterRevision uint64,
+	newRevision uint64,
+	stagedChanges common.Changes,
+) error {
+	query, args, err := queryTransactionMetadata.Where(sq.And{
+		sq.Gt{colID: afterRevision},
+		sq.LtOrEq{colID: newRevision},
+	}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := sds.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			err = datastore.NewWatchCanceledErr()
+		}
+		return err
+	}
+	defer common.LogOnError(ctx, rows.Close)
+
+	for rows.Next() {
+		var txnID uint64
+		var metadata metadataWrapper
+		if err := rows.Scan(&txnID, &metadata); err != nil {
+			return err
+		}
+		stagedChanges.SetRevisionMetadata(revisionFromTransaction(txnID), metadata)
+	}
+	return rows.Err()
+}
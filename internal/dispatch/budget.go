@@ -0,0 +1,136 @@
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+// ErrBudgetExhausted is returned when resolving a request requires dispatching more subproblems,
+// weighted by their depth, than its dispatch budget allows.
+type ErrBudgetExhausted struct {
+	error
+	budget uint32
+}
+
+// Budget returns the dispatch budget which was exhausted.
+func (err ErrBudgetExhausted) Budget() uint32 {
+	return err.budget
+}
+
+func (err ErrBudgetExhausted) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Uint32("budget", err.budget)
+}
+
+// DetailsMetadata returns the metadata for details for this error.
+func (err ErrBudgetExhausted) DetailsMetadata() map[string]string {
+	return map[string]string{
+		"dispatch_budget": strconv.FormatUint(uint64(err.budget), 10),
+	}
+}
+
+// NewBudgetExhaustedErr constructs a new dispatch budget exhausted error.
+func NewBudgetExhaustedErr(budget uint32) error {
+	return ErrBudgetExhausted{
+		error:  fmt.Errorf("dispatch budget exhausted: resolving the request required dispatching more subproblems than its budget of %d allows, each costing one more than its depth; this usually indicates a schema with very wide and deep relationships", budget),
+		budget: budget,
+	}
+}
+
+type budgetKey struct{}
+
+// budget is the budget which remains to dispatch subproblems on a node to resolve a request,
+// shared by all of the subproblems of the request resolved on the node.
+//
+// Each subproblem costs one more than its depth below the API request, so that the budget adapts
+// to the shape of the dispatch tree: it may be spent on many shallow subproblems or on a long
+// chain of them, but a tree both wide and deep exhausts it quickly.
+type budget struct {
+	limit     uint32
+	rootDepth uint32
+	remaining atomic.Int64
+}
+
+// depth returns the depth below the API request of a subproblem with the given depth remaining.
+func (b *budget) depth(depthRemaining uint32) uint32 {
+	if depthRemaining >= b.rootDepth {
+		return 0
+	}
+	return b.rootDepth - depthRemaining
+}
+
+// SpendBudget charges the request as a subproblem to the dispatch budget of the request which
+// dispatched it, returning ErrBudgetExhausted if not enough remains. If the context does not
+// carry a budget, the budget is taken from the metadata of the request, and the returned
+// context carries it to the subproblems the request dispatches.
+func SpendBudget(ctx context.Context, req HasMetadata) (context.Context, error) {
+	metadata := req.GetMetadata()
+	b, ok := ctx.Value(budgetKey{}).(*budget)
+	if !ok {
+		limit := metadata.GetDispatchBudget()
+		if limit == 0 {
+			return ctx, nil
+		}
+
+		rootDepth := metadata.GetBudgetRootDepth()
+		if rootDepth == 0 {
+			rootDepth = metadata.GetDepthRemaining()
+		}
+
+		b = &budget{limit: limit, rootDepth: rootDepth}
+		b.remaining.Store(int64(limit))
+		ctx = context.WithValue(ctx, budgetKey{}, b)
+	}
+
+	cost := 1 + int64(b.depth(metadata.GetDepthRemaining()))
+	if b.remaining.Add(-cost) < 0 {
+		return ctx, NewBudgetExhaustedErr(b.limit)
+	}
+	return ctx, nil
+}
+
+// SetBudget gives a request about to be dispatched to another node the dispatch budget which
+// remains in the context, if any. The subproblems it resolves must then be charged to the
+// context with ChargeBudget.
+func SetBudget(ctx context.Context, req HasMetadata) error {
+	b, ok := ctx.Value(budgetKey{}).(*budget)
+	if !ok {
+		return nil
+	}
+
+	remaining := b.remaining.Load()
+	if remaining <= 0 {
+		return NewBudgetExhaustedErr(b.limit)
+	}
+	if remaining > math.MaxUint32 {
+		remaining = math.MaxUint32
+	}
+
+	if metadata := req.GetMetadata(); metadata != nil {
+		metadata.DispatchBudget = uint32(remaining)
+		metadata.BudgetRootDepth = b.rootDepth
+	}
+	return nil
+}
+
+// ChargeBudget charges the subproblems resolved by another node for a request dispatched to it
+// to the dispatch budget in the context, if any. As only their number is known, each is charged
+// as if it had been dispatched at the deepest depth the request required.
+func ChargeBudget(ctx context.Context, req HasMetadata, metadata *v1.ResponseMeta) {
+	b, ok := ctx.Value(budgetKey{}).(*budget)
+	if !ok {
+		return
+	}
+
+	deepest := int64(b.depth(req.GetMetadata().GetDepthRemaining()))
+	if required := metadata.GetDepthRequired(); required > 1 {
+		deepest += int64(required) - 1
+	}
+	b.remaining.Add(-int64(metadata.GetDispatchCount()) * (1 + deepest))
+}
@@ -0,0 +1,92 @@
+package dispatch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+func checkAtDepth(depthRemaining, budget, rootDepth uint32) *v1.DispatchCheckRequest {
+	return &v1.DispatchCheckRequest{Metadata: &v1.ResolverMeta{
+		DepthRemaining:  depthRemaining,
+		DispatchBudget:  budget,
+		BudgetRootDepth: rootDepth,
+	}}
+}
+
+func TestSpendBudgetByDepth(t *testing.T) {
+	testCases := []struct {
+		name            string
+		depthsRemaining []uint32
+		budget          uint32
+		expectedError   bool
+	}{
+		{"no budget", []uint32{50, 40, 30, 20, 10}, 0, false},
+		{"wide and shallow", []uint32{50, 49, 49, 49, 49, 49}, 11, false},
+		{"wide and shallow exhausted", []uint32{50, 49, 49, 49, 49, 49, 49}, 11, true},
+		{"narrow and deep", []uint32{50, 49, 48, 47}, 10, false},
+		{"narrow and deep exhausted", []uint32{50, 49, 48, 47, 46}, 10, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, err := SpendBudget(context.Background(), checkAtDepth(tc.depthsRemaining[0], tc.budget, 0))
+			require.NoError(t, err)
+
+			for _, depthRemaining := range tc.depthsRemaining[1:] {
+				ctx, err = SpendBudget(ctx, checkAtDepth(depthRemaining, 0, 0))
+				if err != nil {
+					break
+				}
+			}
+
+			if tc.expectedError {
+				var budgetErr ErrBudgetExhausted
+				require.ErrorAs(t, err, &budgetErr)
+				require.Equal(t, tc.budget, budgetErr.Budget())
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestBudgetAcrossNodes(t *testing.T) {
+	require := require.New(t)
+
+	// The API request and one subproblem cost 1 + 2 of the budget of 20.
+	ctx, err := SpendBudget(context.Background(), checkAtDepth(50, 20, 0))
+	require.NoError(err)
+	ctx, err = SpendBudget(ctx, checkAtDepth(49, 0, 0))
+	require.NoError(err)
+
+	// The request dispatched to another node carries the remaining budget and the depth of the API
+	// request, so that the other node costs its subproblems by their depth in the whole tree.
+	remote := checkAtDepth(48, 0, 0)
+	require.NoError(SetBudget(ctx, remote))
+	require.Equal(uint32(17), remote.Metadata.DispatchBudget)
+	require.Equal(uint32(50), remote.Metadata.BudgetRootDepth)
+
+	// There, the request costs 3 and its subproblems 4 each, exhausting the budget on the fourth.
+	remoteCtx, err := SpendBudget(context.Background(), remote)
+	require.NoError(err)
+	for i := 0; i < 3; i++ {
+		_, err = SpendBudget(remoteCtx, checkAtDepth(47, 0, 0))
+		require.NoError(err)
+	}
+	_, err = SpendBudget(remoteCtx, checkAtDepth(47, 0, 0))
+	require.ErrorAs(err, &ErrBudgetExhausted{})
+
+	// The subproblems resolved by the other node are charged as if they were all at the deepest
+	// depth it reached: 3 subproblems at depth 3, costing 4 each, leaving 5 of the budget.
+	ChargeBudget(ctx, remote, &v1.ResponseMeta{DispatchCount: 3, DepthRequired: 2})
+	_, err = SpendBudget(ctx, checkAtDepth(49, 0, 0))
+	require.NoError(err)
+	_, err = SpendBudget(ctx, checkAtDepth(48, 0, 0))
+	require.NoError(err)
+	require.ErrorAs(SetBudget(ctx, checkAtDepth(48, 0, 0)), &ErrBudgetExhausted{})
+}
@@ -2,19 +2,29 @@ package caching
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 	"unsafe"
 
 	"golang.org/x/exp/maps"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/dustin/go-humanize"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/dispatch/hotkeys"
 	"github.com/authzed/spicedb/internal/dispatch/keys"
+	"github.com/authzed/spicedb/internal/dispatch/prefetch"
+	"github.com/authzed/spicedb/internal/graph"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/cache"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
@@ -31,9 +41,22 @@ type Dispatcher struct {
 	d          dispatch.Dispatcher
 	c          cache.Cache
 	keyHandler keys.Handler
+	options    optionState
+
+	// checkGroup coalesces concurrent checks of the same subproblem into a single computation,
+	// which runs under the context of its flight in checkFlights.
+	checkGroup       singleflight.Group
+	checkFlightsLock sync.Mutex
+	checkFlights     map[string]*checkFlight
+
+	// schemaGeneration is incremented when the schema is changed, and is mixed into the cache keys
+	// of all operations, so that results computed with the previous definitions are no longer
+	// found, whichever definitions they depend on.
+	schemaGeneration atomic.Uint64
 
 	checkTotalCounter                  prometheus.Counter
 	checkFromCacheCounter              prometheus.Counter
+	checkDeduplicatedCounter           prometheus.Counter
 	lookupTotalCounter                 prometheus.Counter
 	lookupFromCacheCounter             prometheus.Counter
 	reachableResourcesTotalCounter     prometheus.Counter
@@ -45,6 +68,8 @@ type Dispatcher struct {
 	cacheMisses      prometheus.CounterFunc
 	costAddedBytes   prometheus.CounterFunc
 	costEvictedBytes prometheus.CounterFunc
+
+	namespaceMetrics *namespaceMetrics
 }
 
 func DispatchTestCache(t testing.TB) cache.Cache {
@@ -57,9 +82,49 @@ func DispatchTestCache(t testing.TB) cache.Cache {
 	return cache
 }
 
+// Option is a function-style option for configuring a caching Dispatcher.
+type Option func(*optionState)
+
+type optionState struct {
+	negativeCheckResultsDisabled bool
+	negativeCheckResultTTL       time.Duration
+	hotKeys                      *hotkeys.Tracker
+	prefetcher                   *prefetch.Prefetcher
+}
+
+// NegativeCheckResultsDisabled disables caching of negative check results, in which none of the
+// checked resources has the permission, even conditionally on a caveat.
+func NegativeCheckResultsDisabled() Option {
+	return func(state *optionState) {
+		state.negativeCheckResultsDisabled = true
+	}
+}
+
+// NegativeCheckResultTTL sets the time after which cached negative check results expire, so that
+// the more numerous negative results can be kept for less time than positive ones. If zero,
+// negative results are kept as long as positive ones.
+func NegativeCheckResultTTL(ttl time.Duration) Option {
+	return func(state *optionState) {
+		state.negativeCheckResultTTL = ttl
+	}
+}
+
+// HotKeys sets the tracker with which the resources and permissions of dispatched requests are
+// sampled, to find those which are dispatched most often.
+func HotKeys(tracker *hotkeys.Tracker) Option {
+	return func(state *optionState) {
+		state.hotKeys = tracker
+	}
+}
+
 // NewCachingDispatcher creates a new dispatch.Dispatcher which delegates
 // dispatch requests and caches the responses when possible and desirable.
-func NewCachingDispatcher(cacheInst cache.Cache, prometheusSubsystem string, keyHandler keys.Handler) (*Dispatcher, error) {
+func NewCachingDispatcher(cacheInst cache.Cache, prometheusSubsystem string, keyHandler keys.Handler, options ...Option) (*Dispatcher, error) {
+	var opts optionState
+	for _, fn := range options {
+		fn(&opts)
+	}
+
 	if cacheInst == nil {
 		cacheInst = cache.NoopCache()
 	}
@@ -74,6 +139,11 @@ func NewCachingDispatcher(cacheInst cache.Cache, prometheusSubsystem string, key
 		Subsystem: prometheusSubsystem,
 		Name:      "check_from_cache_total",
 	})
+	checkDeduplicatedCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: prometheusNamespace,
+		Subsystem: prometheusSubsystem,
+		Name:      "check_deduplicated_total",
+	})
 
 	lookupTotalCounter := prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: prometheusNamespace,
@@ -139,6 +209,8 @@ func NewCachingDispatcher(cacheInst cache.Cache, prometheusSubsystem string, key
 		return float64(cacheInst.GetMetrics().CostEvicted())
 	})
 
+	namespaceMetrics := newNamespaceMetrics(cacheInst, prometheusSubsystem)
+
 	if prometheusSubsystem != "" {
 		err := prometheus.Register(checkTotalCounter)
 		if err != nil {
@@ -148,6 +220,10 @@ func NewCachingDispatcher(cacheInst cache.Cache, prometheusSubsystem string, key
 		if err != nil {
 			return nil, fmt.Errorf(errCachingInitialization, err)
 		}
+		err = prometheus.Register(checkDeduplicatedCounter)
+		if err != nil {
+			return nil, fmt.Errorf(errCachingInitialization, err)
+		}
 		err = prometheus.Register(lookupTotalCounter)
 		if err != nil {
 			return nil, fmt.Errorf(errCachingInitialization, err)
@@ -190,6 +266,10 @@ func NewCachingDispatcher(cacheInst cache.Cache, prometheusSubsystem string, key
 		if err != nil {
 			return nil, fmt.Errorf(errCachingInitialization, err)
 		}
+		err = prometheus.Register(namespaceMetrics)
+		if err != nil {
+			return nil, fmt.Errorf(errCachingInitialization, err)
+		}
 	}
 
 	if keyHandler == nil {
@@ -200,8 +280,11 @@ func NewCachingDispatcher(cacheInst cache.Cache, prometheusSubsystem string, key
 		d:                                  fakeDelegate{},
 		c:                                  cacheInst,
 		keyHandler:                         keyHandler,
+		options:                            opts,
+		checkFlights:                       map[string]*checkFlight{},
 		checkTotalCounter:                  checkTotalCounter,
 		checkFromCacheCounter:              checkFromCacheCounter,
+		checkDeduplicatedCounter:           checkDeduplicatedCounter,
 		lookupTotalCounter:                 lookupTotalCounter,
 		lookupFromCacheCounter:             lookupFromCacheCounter,
 		reachableResourcesTotalCounter:     reachableResourcesTotalCounter,
@@ -212,6 +295,7 @@ func NewCachingDispatcher(cacheInst cache.Cache, prometheusSubsystem string, key
 		cacheMisses:                        cacheMissesTotal,
 		costAddedBytes:                     costAddedBytes,
 		costEvictedBytes:                   costEvictedBytes,
+		namespaceMetrics:                   namespaceMetrics,
 	}, nil
 }
 
@@ -220,24 +304,55 @@ func (cd *Dispatcher) SetDelegate(delegate dispatch.Dispatcher) {
 	cd.d = delegate
 }
 
+// InvalidateSchema invalidates the cached results of all operations, which must be called when
+// the definitions of namespaces or caveats are changed. Otherwise, results computed with the
+// previous definitions may be returned until the revisions they were computed at are no longer
+// used. Every result is invalidated, as an operation on one namespace may depend on the
+// definitions of any other namespace or caveat it reaches.
+//
+// Only the results cached by this dispatcher are invalidated: the dispatchers of other nodes keep
+// theirs until the revisions they were computed at are no longer used.
+func (cd *Dispatcher) InvalidateSchema() {
+	generation := cd.schemaGeneration.Add(1)
+	log.Debug().Uint64("generation", generation).Msg("invalidated cached dispatch results for schema change")
+}
+
+// keyForSchema returns the cache key of an operation for the current generation of the schema.
+func (cd *Dispatcher) keyForSchema(requestKey keys.DispatchCacheKey) keys.DispatchCacheKey {
+	return requestKey.WithGeneration(cd.schemaGeneration.Load())
+}
+
+// Prefetcher sets the prefetcher which observes dispatched checks, to recompute those of the
+// most dispatched subproblems before the revision quantization window rolls over.
+func Prefetcher(prefetcher *prefetch.Prefetcher) Option {
+	return func(state *optionState) {
+		state.prefetcher = prefetcher
+	}
+}
+
 // DispatchCheck implements dispatch.Check interface
 func (cd *Dispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
 	cd.checkTotalCounter.Inc()
+	cd.namespaceMetrics.request(apiCheck, req.ResourceRelation.Namespace)
+	cd.options.hotKeys.Record(hotkeys.APICheck, req.ResourceRelation.Namespace, req.ResourceRelation.Relation, req.ResourceIds...)
+	cd.options.prefetcher.Observe(req)
 
 	requestKey, err := cd.keyHandler.CheckCacheKey(ctx, req)
 	if err != nil {
 		return &v1.DispatchCheckResponse{Metadata: &v1.ResponseMeta{}}, err
 	}
+	requestKey = cd.keyForSchema(requestKey)
 
 	// Disable caching when debugging is enabled.
-	if cachedResultRaw, found := cd.c.Get(requestKey); found {
+	if cachedResultRaw, found := cd.c.Get(requestKey); found && cachedResultRaw.(cachedResult[[]byte]).usableFor(req.Metadata) {
 		var response v1.DispatchCheckResponse
-		if err := response.UnmarshalVT(cachedResultRaw.([]byte)); err != nil {
+		if err := response.UnmarshalVT(cachedResultRaw.(cachedResult[[]byte]).value); err != nil {
 			return &v1.DispatchCheckResponse{Metadata: &v1.ResponseMeta{}}, err
 		}
 
 		if req.Metadata.DepthRemaining >= response.Metadata.DepthRequired {
 			cd.checkFromCacheCounter.Inc()
+			cd.namespaceMetrics.hit(apiCheck, req.ResourceRelation.Namespace)
 			// If debugging is requested, add the req and the response to the trace.
 			if req.Debug == v1.DispatchCheckRequest_ENABLE_DEBUGGING {
 				response.Metadata.DebugInfo = &v1.DebugInformation{
@@ -245,13 +360,81 @@ func (cd *Dispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRe
 						Request:        req,
 						Results:        maps.Clone(response.ResultsByResourceId),
 						IsCachedResult: true,
+						HandledBy:      dispatch.NodeName,
 					},
 				}
+				trace.SpanFromContext(ctx).AddEvent("cached check result", trace.WithAttributes(
+					attribute.String("resource-type", req.ResourceRelation.Namespace+"::"+req.ResourceRelation.Relation),
+					attribute.StringSlice("resource-ids", req.ResourceIds),
+					attribute.String("debug.handled-by", dispatch.NodeName),
+				))
 			}
 
 			return &response, nil
 		}
 	}
+
+	// Debug traces are specific to each request, so the computation is not shared.
+	if req.Debug == v1.DispatchCheckRequest_ENABLE_DEBUGGING {
+		computed, _, err := cd.computeCheck(ctx, req, requestKey)
+		return computed, err
+	}
+
+	// Concurrent requests for the same subproblem are coalesced into a single computation, the
+	// result of which is shared with the requests waiting on it. Each request stops waiting as soon
+	// as its own context is done.
+	flightKey := checkFlightKey(requestKey)
+	flight, results := cd.joinCheckFlight(ctx, flightKey, func(flightCtx context.Context) (any, error) {
+		computed, adjusted, err := cd.computeCheck(flightCtx, req, requestKey)
+		return checkFlightResult{leader: req, computed: computed, adjusted: adjusted}, err
+	})
+	defer cd.leaveCheckFlight(flightKey, flight)
+
+	var result singleflight.Result
+	select {
+	case <-ctx.Done():
+		return &v1.DispatchCheckResponse{Metadata: &v1.ResponseMeta{}}, ctx.Err()
+	case result = <-results:
+	}
+
+	shared := result.Val.(checkFlightResult)
+	if shared.leader == req {
+		return shared.computed, result.Err
+	}
+
+	switch {
+	case result.Err == nil:
+		if req.Metadata.DepthRemaining >= shared.adjusted.Metadata.DepthRequired &&
+			recursionDepthsWithin(req.Metadata.RecursionDepths, shared.leader.Metadata.RecursionDepths) {
+			cd.checkDeduplicatedCounter.Inc()
+			return shared.adjusted.CloneVT(), nil
+		}
+
+	case errors.Is(result.Err, context.Canceled):
+		// The shared computation was canceled as every request waiting on it had gone before this
+		// one joined.
+
+	case errors.Is(result.Err, dispatch.ErrMaxDepth) && req.Metadata.DepthRemaining > shared.leader.Metadata.DepthRemaining:
+		// The request which started the shared computation had less depth remaining than this one.
+
+	case errors.As(result.Err, &graph.ErrRecursionDepthExceeded{}) && !recursionDepthsWithin(shared.leader.Metadata.RecursionDepths, req.Metadata.RecursionDepths):
+		// The request which started the shared computation had traversed further along some
+		// self-referential relation than this one.
+
+	default:
+		// The error is shared rather than having every waiting request compute the check again.
+		return &v1.DispatchCheckResponse{Metadata: &v1.ResponseMeta{}}, result.Err
+	}
+
+	// The shared result cannot be used for this request, which is computed on its own.
+	computed, _, err := cd.computeCheck(ctx, req, requestKey)
+	return computed, err
+}
+
+// computeCheck dispatches the check to the delegate and caches the result if there was no error.
+// Along with the computed response, it returns the response as it was cached, which must not be
+// modified.
+func (cd *Dispatcher) computeCheck(ctx context.Context, req *v1.DispatchCheckRequest, requestKey keys.DispatchCacheKey) (*v1.DispatchCheckResponse, *v1.DispatchCheckResponse, error) {
 	computed, err := cd.d.DispatchCheck(ctx, req)
 
 	// We only want to cache the result if there was no error
@@ -261,82 +444,161 @@ func (cd *Dispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRe
 		adjustedComputed.Metadata.DispatchCount = 0
 		adjustedComputed.Metadata.DebugInfo = nil
 
+		var ttl time.Duration
+		if isNegativeCheckResult(computed) {
+			if cd.options.negativeCheckResultsDisabled {
+				return computed, adjustedComputed, nil
+			}
+			ttl = cd.options.negativeCheckResultTTL
+		}
+
 		adjustedBytes, err := adjustedComputed.MarshalVT()
 		if err != nil {
-			return &v1.DispatchCheckResponse{Metadata: &v1.ResponseMeta{}}, err
+			return &v1.DispatchCheckResponse{Metadata: &v1.ResponseMeta{}}, nil, err
 		}
 
-		cd.c.Set(requestKey, adjustedBytes, sliceSize(adjustedBytes))
+		cached := newCachedResult(req.Metadata, adjustedBytes, sliceSize(adjustedBytes))
+		if cd.c.SetWithTTL(requestKey, cached, cached.size, ttl) {
+			cd.namespaceMetrics.added(apiCheck, req.ResourceRelation.Namespace, cached.size)
+		}
+		return computed, adjustedComputed, nil
 	}
 
 	// Return both the computed and err in ALL cases: computed contains resolved
 	// metadata even if there was an error.
-	return computed, err
+	return computed, nil, err
+}
+
+// isNegativeCheckResult returns whether none of the checked resources has the permission, even
+// conditionally on a caveat.
+func isNegativeCheckResult(resp *v1.DispatchCheckResponse) bool {
+	for _, result := range resp.ResultsByResourceId {
+		if result.Membership != v1.ResourceCheckResult_NOT_MEMBER {
+			return false
+		}
+	}
+	return true
+}
+
+// checkFlightKey returns the key under which concurrent computations of a check are coalesced.
+func checkFlightKey(requestKey keys.DispatchCacheKey) string {
+	processSpecific, stable := requestKey.AsUInt64s()
+
+	var key [16]byte
+	binary.LittleEndian.PutUint64(key[:8], processSpecific)
+	binary.LittleEndian.PutUint64(key[8:], stable)
+	return string(key[:])
 }
 
 // DispatchExpand implements dispatch.Expand interface and does not do any caching yet.
 func (cd *Dispatcher) DispatchExpand(ctx context.Context, req *v1.DispatchExpandRequest) (*v1.DispatchExpandResponse, error) {
+	cd.namespaceMetrics.request(apiExpand, req.ResourceAndRelation.Namespace)
+	cd.options.hotKeys.Record(hotkeys.APIExpand, req.ResourceAndRelation.Namespace, req.ResourceAndRelation.Relation, req.ResourceAndRelation.ObjectId)
 	resp, err := cd.d.DispatchExpand(ctx, req)
 	return resp, err
 }
 
-// DispatchLookup implements dispatch.Lookup interface and does not do any caching yet.
-func (cd *Dispatcher) DispatchLookup(ctx context.Context, req *v1.DispatchLookupRequest) (*v1.DispatchLookupResponse, error) {
+// DispatchLookup implements dispatch.Lookup interface.
+func (cd *Dispatcher) DispatchLookup(req *v1.DispatchLookupRequest, stream dispatch.LookupStream) error {
 	cd.lookupTotalCounter.Inc()
+	cd.namespaceMetrics.request(apiLookup, req.ObjectRelation.Namespace)
 
-	requestKey, err := cd.keyHandler.LookupResourcesCacheKey(ctx, req)
+	requestKey, err := cd.keyHandler.LookupResourcesCacheKey(stream.Context(), req)
 	if err != nil {
-		return &v1.DispatchLookupResponse{Metadata: &v1.ResponseMeta{}}, err
+		return err
 	}
+	requestKey = cd.keyForSchema(requestKey)
+
+	if cachedResultRaw, found := cd.c.Get(requestKey); found && cachedResultRaw.(cachedResult[[][]byte]).usableFor(req.Metadata) {
+		cachedChunks := cachedResultRaw.(cachedResult[[][]byte]).value
+		responses := make([]*v1.DispatchLookupResponse, 0, len(cachedChunks))
+		usable := true
+		for _, slice := range cachedChunks {
+			var response v1.DispatchLookupResponse
+			if err := response.UnmarshalVT(slice); err != nil {
+				return fmt.Errorf("could not publish cached lookup result: %w", err)
+			}
 
-	if cachedResultRaw, found := cd.c.Get(requestKey); found {
-		var response v1.DispatchLookupResponse
-		if err := response.UnmarshalVT(cachedResultRaw.([]byte)); err != nil {
-			return &v1.DispatchLookupResponse{Metadata: &v1.ResponseMeta{}}, err
+			if req.Metadata.DepthRemaining < response.Metadata.DepthRequired {
+				usable = false
+				break
+			}
+			responses = append(responses, &response)
 		}
 
-		if req.Metadata.DepthRemaining >= response.Metadata.DepthRequired {
-			log.Trace().Object("cachedLookup", req).Int("resultCount", len(response.ResolvedResources)).Send()
+		if usable {
+			log.Trace().Object("cachedLookup", req).Int("chunkCount", len(responses)).Send()
 			cd.lookupFromCacheCounter.Inc()
-			return &response, nil
+			cd.namespaceMetrics.hit(apiLookup, req.ObjectRelation.Namespace)
+			for _, response := range responses {
+				if err := stream.Publish(response); err != nil {
+					return fmt.Errorf("could not publish cached lookup result: %w", err)
+				}
+			}
+			return nil
 		}
 	}
-	computed, err := cd.d.DispatchLookup(ctx, req)
 
-	// We only want to cache the result if there was no error.
-	if err == nil {
-		log.Trace().Object("cachingLookup", req).Int("resultCount", len(computed.ResolvedResources)).Send()
+	var (
+		mu             sync.Mutex
+		toCacheResults [][]byte
+	)
+	wrapped := &dispatch.WrappedDispatchStream[*v1.DispatchLookupResponse]{
+		Stream: stream,
+		Ctx:    stream.Context(),
+		Processor: func(result *v1.DispatchLookupResponse) (*v1.DispatchLookupResponse, bool, error) {
+			adjustedResult := result.CloneVT()
+			adjustedResult.Metadata.CachedDispatchCount = adjustedResult.Metadata.DispatchCount
+			adjustedResult.Metadata.DispatchCount = 0
+			adjustedResult.Metadata.DebugInfo = nil
 
-		adjustedComputed := computed.CloneVT()
-		adjustedComputed.Metadata.CachedDispatchCount = adjustedComputed.Metadata.DispatchCount
-		adjustedComputed.Metadata.DispatchCount = 0
-		adjustedComputed.Metadata.DebugInfo = nil
+			adjustedBytes, err := adjustedResult.MarshalVT()
+			if err != nil {
+				return nil, false, err
+			}
 
-		adjustedBytes, err := adjustedComputed.MarshalVT()
-		if err != nil {
-			return &v1.DispatchLookupResponse{Metadata: &v1.ResponseMeta{}}, err
-		}
+			mu.Lock()
+			toCacheResults = append(toCacheResults, adjustedBytes)
+			mu.Unlock()
 
-		cd.c.Set(requestKey, adjustedBytes, sliceSize(adjustedBytes))
+			return result, true, nil
+		},
 	}
 
-	// Return both the computed and err in ALL cases: computed contains resolved
-	// metadata even if there was an error.
-	return computed, err
+	// We only want to cache the result if there was no error.
+	if err := cd.d.DispatchLookup(req, wrapped); err != nil {
+		return err
+	}
+
+	log.Trace().Object("cachingLookup", req).Int("chunkCount", len(toCacheResults)).Send()
+
+	var size int64
+	for _, slice := range toCacheResults {
+		size += sliceSize(slice)
+	}
+
+	cached := newCachedResult(req.Metadata, toCacheResults, size)
+	if cd.c.Set(requestKey, cached, cached.size) {
+		cd.namespaceMetrics.added(apiLookup, req.ObjectRelation.Namespace, cached.size)
+	}
+	return nil
 }
 
 // DispatchReachableResources implements dispatch.ReachableResources interface.
 func (cd *Dispatcher) DispatchReachableResources(req *v1.DispatchReachableResourcesRequest, stream dispatch.ReachableResourcesStream) error {
 	cd.reachableResourcesTotalCounter.Inc()
+	cd.namespaceMetrics.request(apiReachableResources, req.ResourceRelation.Namespace)
 
 	requestKey, err := cd.keyHandler.ReachableResourcesCacheKey(stream.Context(), req)
 	if err != nil {
 		return err
 	}
+	requestKey = cd.keyForSchema(requestKey)
 
-	if cachedResultRaw, found := cd.c.Get(requestKey); found {
+	if cachedResultRaw, found := cd.c.Get(requestKey); found && cachedResultRaw.(cachedResult[[][]byte]).usableFor(req.Metadata) {
 		cd.reachableResourcesFromCacheCounter.Inc()
-		for _, slice := range cachedResultRaw.([][]byte) {
+		cd.namespaceMetrics.hit(apiReachableResources, req.ResourceRelation.Namespace)
+		for _, slice := range cachedResultRaw.(cachedResult[[][]byte]).value {
 			var response v1.DispatchReachableResourcesResponse
 			if err := response.UnmarshalVT(slice); err != nil {
 				return fmt.Errorf("could not publish cached reachable resources result: %w", err)
@@ -384,10 +646,47 @@ func (cd *Dispatcher) DispatchReachableResources(req *v1.DispatchReachableResour
 		size += sliceSize(slice)
 	}
 
-	cd.c.Set(requestKey, toCacheResults, size)
+	cached := newCachedResult(req.Metadata, toCacheResults, size)
+	if cd.c.Set(requestKey, cached, cached.size) {
+		cd.namespaceMetrics.added(apiReachableResources, req.ResourceRelation.Namespace, cached.size)
+	}
 	return nil
 }
 
+// cachedResult is a cached result along with the recursion depths already traversed by the
+// request it was computed for. Results are only cached if no maximum recursion depth was exceeded
+// in computing them, so they hold for any request which has traversed no further along any
+// self-referential relation, and the depths are not part of the cache key.
+type cachedResult[T any] struct {
+	recursionDepths map[string]uint32
+	value           T
+	size            int64
+}
+
+func newCachedResult[T any](md *v1.ResolverMeta, value T, valueSize int64) cachedResult[T] {
+	size := valueSize
+	for key := range md.RecursionDepths {
+		size += int64(len(key)) + int64(unsafe.Sizeof(uint32(0)))
+	}
+
+	return cachedResult[T]{recursionDepths: md.RecursionDepths, value: value, size: size}
+}
+
+// usableFor returns whether the cached result holds for a request with the metadata.
+func (cr cachedResult[T]) usableFor(md *v1.ResolverMeta) bool {
+	return recursionDepthsWithin(md.RecursionDepths, cr.recursionDepths)
+}
+
+// recursionDepthsWithin returns whether the depths traverse no relation further than the limits.
+func recursionDepthsWithin(depths, limits map[string]uint32) bool {
+	for key, depth := range depths {
+		if depth > limits[key] {
+			return false
+		}
+	}
+	return true
+}
+
 func sliceSize(xs []byte) int64 {
 	// Slice Header + Slice Contents
 	return int64(int(unsafe.Sizeof(xs)) + len(xs))
@@ -396,15 +695,19 @@ func sliceSize(xs []byte) int64 {
 // DispatchLookupSubjects implements dispatch.LookupSubjects interface.
 func (cd *Dispatcher) DispatchLookupSubjects(req *v1.DispatchLookupSubjectsRequest, stream dispatch.LookupSubjectsStream) error {
 	cd.lookupSubjectsTotalCounter.Inc()
+	cd.namespaceMetrics.request(apiLookupSubjects, req.ResourceRelation.Namespace)
+	cd.options.hotKeys.Record(hotkeys.APILookupSubjects, req.ResourceRelation.Namespace, req.ResourceRelation.Relation, req.ResourceIds...)
 
 	requestKey, err := cd.keyHandler.LookupSubjectsCacheKey(stream.Context(), req)
 	if err != nil {
 		return err
 	}
+	requestKey = cd.keyForSchema(requestKey)
 
-	if cachedResultRaw, found := cd.c.Get(requestKey); found {
+	if cachedResultRaw, found := cd.c.Get(requestKey); found && cachedResultRaw.(cachedResult[[][]byte]).usableFor(req.Metadata) {
 		cd.lookupSubjectsFromCacheCounter.Inc()
-		for _, slice := range cachedResultRaw.([][]byte) {
+		cd.namespaceMetrics.hit(apiLookupSubjects, req.ResourceRelation.Namespace)
+		for _, slice := range cachedResultRaw.(cachedResult[[][]byte]).value {
 			var response v1.DispatchLookupSubjectsResponse
 			if err := response.UnmarshalVT(slice); err != nil {
 				return err
@@ -454,7 +757,10 @@ func (cd *Dispatcher) DispatchLookupSubjects(req *v1.DispatchLookupSubjectsReque
 		size += sliceSize(slice)
 	}
 
-	cd.c.Set(requestKey, toCacheResults, size)
+	cached := newCachedResult(req.Metadata, toCacheResults, size)
+	if cd.c.Set(requestKey, cached, cached.size) {
+		cd.namespaceMetrics.added(apiLookupSubjects, req.ResourceRelation.Namespace, cached.size)
+	}
 	return nil
 }
 
@@ -464,6 +770,7 @@ func (cd *Dispatcher) Close() error {
 	prometheus.Unregister(cd.reachableResourcesTotalCounter)
 	prometheus.Unregister(cd.lookupFromCacheCounter)
 	prometheus.Unregister(cd.checkFromCacheCounter)
+	prometheus.Unregister(cd.checkDeduplicatedCounter)
 	prometheus.Unregister(cd.reachableResourcesFromCacheCounter)
 	prometheus.Unregister(cd.lookupSubjectsFromCacheCounter)
 	prometheus.Unregister(cd.lookupSubjectsTotalCounter)
@@ -471,6 +778,7 @@ func (cd *Dispatcher) Close() error {
 	prometheus.Unregister(cd.cacheMisses)
 	prometheus.Unregister(cd.costAddedBytes)
 	prometheus.Unregister(cd.costEvictedBytes)
+	prometheus.Unregister(cd.namespaceMetrics)
 	if cache := cd.c; cache != nil {
 		cache.Close()
 	}
@@ -2,14 +2,22 @@ package caching
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
 
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/proxy"
 	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/graph"
+	"github.com/authzed/spicedb/pkg/datastore"
+	ns "github.com/authzed/spicedb/pkg/namespace"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 	"github.com/authzed/spicedb/pkg/tuple"
@@ -145,12 +153,527 @@ func TestMaxDepthCaching(t *testing.T) {
 	}
 }
 
+func TestRecursionDepthsCaching(t *testing.T) {
+	type step struct {
+		recursionDepths   map[string]uint32
+		expectPassthrough bool
+	}
+
+	testCases := []struct {
+		name   string
+		script []step
+	}{
+		{"same depths, hit", []step{
+			{map[string]uint32{"folder#parent": 2}, true},
+			{map[string]uint32{"folder#parent": 2}, false},
+		}},
+		{"traversed less, hit", []step{
+			{map[string]uint32{"folder#parent": 2, "group#member": 1}, true},
+			{map[string]uint32{"folder#parent": 1}, false},
+			{nil, false},
+		}},
+		{"traversed further, miss", []step{
+			{map[string]uint32{"folder#parent": 1}, true},
+			{map[string]uint32{"folder#parent": 2}, true},
+			{map[string]uint32{"folder#parent": 2}, false},
+		}},
+		{"traversed another relation, miss", []step{
+			{map[string]uint32{"folder#parent": 1}, true},
+			{map[string]uint32{"folder#parent": 1, "group#member": 1}, true},
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			delegate := delegateDispatchMock{&mock.Mock{}}
+			requestFor := func(recursionDepths map[string]uint32) *v1.DispatchCheckRequest {
+				return &v1.DispatchCheckRequest{
+					ResourceRelation: RR("folder", "view"),
+					ResourceIds:      []string{"root"},
+					Subject:          tuple.ParseSubjectONR("user:user1#..."),
+					Metadata: &v1.ResolverMeta{
+						AtRevision:      decimal.Zero.String(),
+						DepthRemaining:  50,
+						RecursionDepths: recursionDepths,
+					},
+				}
+			}
+
+			for _, step := range tc.script {
+				if step.expectPassthrough {
+					delegate.On("DispatchCheck", requestFor(step.recursionDepths)).Return(&v1.DispatchCheckResponse{
+						ResultsByResourceId: map[string]*v1.ResourceCheckResult{
+							"root": {Membership: v1.ResourceCheckResult_MEMBER},
+						},
+						Metadata: &v1.ResponseMeta{DispatchCount: 1, DepthRequired: 1},
+					}, nil).Times(1)
+				}
+			}
+
+			dispatch, err := NewCachingDispatcher(DispatchTestCache(t), "", nil)
+			dispatch.SetDelegate(delegate)
+			require.NoError(err)
+			defer dispatch.Close()
+
+			for _, step := range tc.script {
+				resp, err := dispatch.DispatchCheck(context.Background(), requestFor(step.recursionDepths))
+				require.NoError(err)
+				require.Equal(v1.ResourceCheckResult_MEMBER, resp.ResultsByResourceId["root"].Membership)
+
+				// Let the cache converge.
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			delegate.AssertExpectations(t)
+		})
+	}
+}
+
+type checkResult struct {
+	resp *v1.DispatchCheckResponse
+	err  error
+}
+
+func TestConcurrentCheckDeduplication(t *testing.T) {
+	parsed := tuple.ParseONR("document:doc1#read")
+	req := &v1.DispatchCheckRequest{
+		ResourceRelation: RR(parsed.Namespace, parsed.Relation),
+		ResourceIds:      []string{parsed.ObjectId},
+		Subject:          tuple.ParseSubjectONR("user:user1#..."),
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     decimal.Zero.String(),
+			DepthRemaining: 50,
+		},
+	}
+	memberResponse := &v1.DispatchCheckResponse{
+		ResultsByResourceId: map[string]*v1.ResourceCheckResult{
+			parsed.ObjectId: {
+				Membership: v1.ResourceCheckResult_MEMBER,
+			},
+		},
+		Metadata: &v1.ResponseMeta{
+			DispatchCount: 1,
+			DepthRequired: 1,
+		},
+	}
+	errDelegate := errors.New("delegate failed")
+
+	const concurrentChecks = 10
+
+	testCases := []struct {
+		name           string
+		delegateResp   *v1.DispatchCheckResponse
+		delegateErr    error
+		cancelLeader   bool
+		expectedLeader error
+	}{
+		{"shared result", memberResponse, nil, false, nil},
+		{"shared error", &v1.DispatchCheckResponse{Metadata: &v1.ResponseMeta{}}, errDelegate, false, errDelegate},
+		{"leader canceled", memberResponse, nil, true, context.Canceled},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			// The delegate blocks until released, so that the concurrent checks wait on the first.
+			release := make(chan time.Time)
+			delegate := delegateDispatchMock{&mock.Mock{}}
+			delegate.On("DispatchCheck", req).WaitUntil(release).Return(tc.delegateResp, tc.delegateErr).Times(1)
+
+			dispatch, err := NewCachingDispatcher(DispatchTestCache(t), "", nil)
+			require.NoError(err)
+			dispatch.SetDelegate(delegate)
+			defer dispatch.Close()
+
+			check := func(ctx context.Context, results chan<- checkResult) {
+				resp, err := dispatch.DispatchCheck(ctx, req.CloneVT())
+				results <- checkResult{resp, err}
+			}
+
+			// The leader is started first, so that the computation runs on its behalf.
+			leaderCtx, cancelLeader := context.WithCancel(context.Background())
+			defer cancelLeader()
+			leaderResult := make(chan checkResult, 1)
+			go check(leaderCtx, leaderResult)
+			waitForCheckWaiters(t, dispatch, 1)
+
+			followerResults := make(chan checkResult, concurrentChecks-1)
+			for i := 0; i < concurrentChecks-1; i++ {
+				go check(context.Background(), followerResults)
+			}
+			waitForCheckWaiters(t, dispatch, concurrentChecks)
+
+			if tc.cancelLeader {
+				cancelLeader()
+				leader := <-leaderResult
+				require.ErrorIs(leader.err, tc.expectedLeader)
+				waitForCheckWaiters(t, dispatch, concurrentChecks-1)
+			}
+
+			close(release)
+
+			if !tc.cancelLeader {
+				leader := <-leaderResult
+				if tc.expectedLeader != nil {
+					require.ErrorIs(leader.err, tc.expectedLeader)
+				} else {
+					require.NoError(leader.err)
+					require.Equal(uint32(1), leader.resp.Metadata.DispatchCount)
+				}
+			}
+
+			// The other checks shared the computation, whatever happened to the leader.
+			for i := 0; i < concurrentChecks-1; i++ {
+				follower := <-followerResults
+				if tc.delegateErr != nil {
+					require.ErrorIs(follower.err, tc.delegateErr)
+					continue
+				}
+
+				require.NoError(follower.err)
+				require.Equal(v1.ResourceCheckResult_MEMBER, follower.resp.ResultsByResourceId[parsed.ObjectId].Membership)
+				require.Equal(uint32(0), follower.resp.Metadata.DispatchCount)
+				require.Equal(uint32(1), follower.resp.Metadata.CachedDispatchCount)
+			}
+
+			delegate.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConcurrentCheckRecursionDepths(t *testing.T) {
+	requestFor := func(recursionDepths map[string]uint32) *v1.DispatchCheckRequest {
+		return &v1.DispatchCheckRequest{
+			ResourceRelation: RR("folder", "view"),
+			ResourceIds:      []string{"root"},
+			Subject:          tuple.ParseSubjectONR("user:user1#..."),
+			Metadata: &v1.ResolverMeta{
+				AtRevision:      decimal.Zero.String(),
+				DepthRemaining:  50,
+				RecursionDepths: recursionDepths,
+			},
+		}
+	}
+	memberResponse := &v1.DispatchCheckResponse{
+		ResultsByResourceId: map[string]*v1.ResourceCheckResult{
+			"root": {Membership: v1.ResourceCheckResult_MEMBER},
+		},
+		Metadata: &v1.ResponseMeta{DispatchCount: 1, DepthRequired: 1},
+	}
+	errExceeded := graph.NewRecursionDepthExceededErr("folder", "parent", 2)
+
+	testCases := []struct {
+		name              string
+		leaderDepths      map[string]uint32
+		leaderErr         error
+		followerDepths    map[string]uint32
+		expectRecomputed  bool
+		expectFollowerErr error
+	}{
+		{"result shared with follower which traversed less", map[string]uint32{"folder#parent": 2}, nil, map[string]uint32{"folder#parent": 1}, false, nil},
+		{"result recomputed for follower which traversed further", map[string]uint32{"folder#parent": 1}, nil, map[string]uint32{"folder#parent": 2}, true, nil},
+		{"exceeded depth shared with follower which traversed further", map[string]uint32{"folder#parent": 1}, errExceeded, map[string]uint32{"folder#parent": 2}, false, errExceeded},
+		{"exceeded depth recomputed for follower which traversed less", map[string]uint32{"folder#parent": 2}, errExceeded, map[string]uint32{"folder#parent": 1}, true, nil},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			release := make(chan time.Time)
+			delegate := delegateDispatchMock{&mock.Mock{}}
+			leaderResp := memberResponse
+			if tc.leaderErr != nil {
+				leaderResp = &v1.DispatchCheckResponse{Metadata: &v1.ResponseMeta{}}
+			}
+			delegate.On("DispatchCheck", requestFor(tc.leaderDepths)).WaitUntil(release).Return(leaderResp, tc.leaderErr).Times(1)
+			if tc.expectRecomputed {
+				delegate.On("DispatchCheck", requestFor(tc.followerDepths)).Return(memberResponse, nil).Times(1)
+			}
+
+			dispatch, err := NewCachingDispatcher(DispatchTestCache(t), "", nil)
+			require.NoError(err)
+			dispatch.SetDelegate(delegate)
+			defer dispatch.Close()
+
+			leaderResult := make(chan checkResult, 1)
+			go func() {
+				resp, err := dispatch.DispatchCheck(context.Background(), requestFor(tc.leaderDepths))
+				leaderResult <- checkResult{resp, err}
+			}()
+			waitForCheckWaiters(t, dispatch, 1)
+
+			followerResult := make(chan checkResult, 1)
+			go func() {
+				resp, err := dispatch.DispatchCheck(context.Background(), requestFor(tc.followerDepths))
+				followerResult <- checkResult{resp, err}
+			}()
+			waitForCheckWaiters(t, dispatch, 2)
+
+			close(release)
+			leader := <-leaderResult
+			require.ErrorIs(leader.err, tc.leaderErr)
+
+			follower := <-followerResult
+			if tc.expectFollowerErr != nil {
+				require.ErrorIs(follower.err, tc.expectFollowerErr)
+			} else {
+				require.NoError(follower.err)
+				require.Equal(v1.ResourceCheckResult_MEMBER, follower.resp.ResultsByResourceId["root"].Membership)
+			}
+
+			delegate.AssertExpectations(t)
+		})
+	}
+}
+
+// waitForCheckWaiters waits until the given number of checks are waiting on shared computations.
+func waitForCheckWaiters(t *testing.T, dispatch *Dispatcher, count int) {
+	require.Eventually(t, func() bool {
+		dispatch.checkFlightsLock.Lock()
+		defer dispatch.checkFlightsLock.Unlock()
+
+		waiters := 0
+		for _, flight := range dispatch.checkFlights {
+			waiters += flight.waiters
+		}
+		return waiters == count
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestCheckFlightDeadline(t *testing.T) {
+	withDeadline := func(deadline time.Time) context.Context {
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		t.Cleanup(cancel)
+		return ctx
+	}
+
+	now := time.Now()
+	earlier := withDeadline(now.Add(time.Minute))
+	later := withDeadline(now.Add(time.Hour))
+
+	testCases := []struct {
+		name             string
+		waiters          []context.Context
+		expectedDeadline time.Time
+	}{
+		{"single waiter", []context.Context{earlier}, now.Add(time.Minute)},
+		{"later waiter extends", []context.Context{earlier, later}, now.Add(time.Hour)},
+		{"earlier waiter does not shorten", []context.Context{later, earlier}, now.Add(time.Hour)},
+		{"waiter without deadline", []context.Context{earlier, context.Background(), later}, time.Time{}},
+		{"leader without deadline", []context.Context{context.Background(), earlier}, time.Time{}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			flight := &checkFlight{}
+			for _, ctx := range tc.waiters {
+				flight.extendDeadline(ctx)
+				flight.waiters++
+			}
+
+			deadline, ok := flightContext{context.Background(), flight}.Deadline()
+			require.Equal(t, !tc.expectedDeadline.IsZero(), ok)
+			require.True(t, tc.expectedDeadline.Equal(deadline))
+		})
+	}
+}
+
+func TestNegativeCheckResultCaching(t *testing.T) {
+	parsed := tuple.ParseONR("document:doc1#read")
+	req := &v1.DispatchCheckRequest{
+		ResourceRelation: RR(parsed.Namespace, parsed.Relation),
+		ResourceIds:      []string{parsed.ObjectId},
+		Subject:          tuple.ParseSubjectONR("user:user1#..."),
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     decimal.Zero.String(),
+			DepthRemaining: 50,
+		},
+	}
+
+	testCases := []struct {
+		name               string
+		options            []Option
+		wait               time.Duration
+		expectedDispatches int
+	}{
+		{"cached by default", nil, 0, 1},
+		{"disabled", []Option{NegativeCheckResultsDisabled()}, 0, 2},
+		{"unexpired", []Option{NegativeCheckResultTTL(time.Hour)}, 0, 1},
+		{"expired", []Option{NegativeCheckResultTTL(10 * time.Millisecond)}, 50 * time.Millisecond, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			delegate := delegateDispatchMock{&mock.Mock{}}
+			delegate.On("DispatchCheck", req).Return(&v1.DispatchCheckResponse{
+				ResultsByResourceId: map[string]*v1.ResourceCheckResult{},
+				Metadata: &v1.ResponseMeta{
+					DispatchCount: 1,
+					DepthRequired: 1,
+				},
+			}, nil).Times(tc.expectedDispatches)
+
+			dispatch, err := NewCachingDispatcher(DispatchTestCache(t), "", nil, tc.options...)
+			require.NoError(err)
+			dispatch.SetDelegate(delegate)
+			defer dispatch.Close()
+
+			for i := 0; i < 2; i++ {
+				resp, err := dispatch.DispatchCheck(context.Background(), req)
+				require.NoError(err)
+				require.Empty(resp.ResultsByResourceId)
+
+				// Let the cache converge, and any entry expire.
+				time.Sleep(10*time.Millisecond + tc.wait)
+			}
+
+			delegate.AssertExpectations(t)
+		})
+	}
+}
+
+func TestInvalidateSchema(t *testing.T) {
+	require := require.New(t)
+
+	parsed := tuple.ParseONR("document:doc1#view")
+	req := &v1.DispatchCheckRequest{
+		ResourceRelation: RR(parsed.Namespace, parsed.Relation),
+		ResourceIds:      []string{parsed.ObjectId},
+		Subject:          tuple.ParseSubjectONR("user:user1#..."),
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     decimal.Zero.String(),
+			DepthRemaining: 50,
+		},
+	}
+
+	delegate := delegateDispatchMock{&mock.Mock{}}
+	delegate.On("DispatchCheck", req).Return(&v1.DispatchCheckResponse{
+		ResultsByResourceId: map[string]*v1.ResourceCheckResult{
+			parsed.ObjectId: {
+				Membership: v1.ResourceCheckResult_MEMBER,
+			},
+		},
+		Metadata: &v1.ResponseMeta{
+			DispatchCount: 1,
+			DepthRequired: 1,
+		},
+	}, nil).Times(2)
+
+	dispatch, err := NewCachingDispatcher(DispatchTestCache(t), "", nil)
+	require.NoError(err)
+	dispatch.SetDelegate(delegate)
+	defer dispatch.Close()
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	ds := proxy.NewSchemaChangeHooksProxy(rawDS, func(_ context.Context, _ proxy.SchemaChange) {
+		dispatch.InvalidateSchema()
+	})
+
+	check := func() {
+		_, err := dispatch.DispatchCheck(context.Background(), req)
+		require.NoError(err)
+
+		// Let the cache converge.
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	check()
+	check()
+
+	// The check of the document may reach the folder, e.g. via `parent->view`, so changing the
+	// definition of the folder must recompute it.
+	_, err = ds.ReadWriteTx(context.Background(), func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteNamespaces(context.Background(), ns.Namespace("folder"))
+	})
+	require.NoError(err)
+	check()
+	check()
+
+	delegate.AssertExpectations(t)
+}
+
+func TestNamespaceMetrics(t *testing.T) {
+	require := require.New(t)
+
+	parsed := tuple.ParseONR("document:doc1#read")
+	req := &v1.DispatchCheckRequest{
+		ResourceRelation: RR(parsed.Namespace, parsed.Relation),
+		ResourceIds:      []string{parsed.ObjectId},
+		Subject:          tuple.ParseSubjectONR("user:user1#..."),
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     decimal.Zero.String(),
+			DepthRemaining: 50,
+		},
+	}
+
+	delegate := delegateDispatchMock{&mock.Mock{}}
+	delegate.On("DispatchCheck", req).Return(&v1.DispatchCheckResponse{
+		ResultsByResourceId: map[string]*v1.ResourceCheckResult{
+			parsed.ObjectId: {
+				Membership: v1.ResourceCheckResult_MEMBER,
+			},
+		},
+		Metadata: &v1.ResponseMeta{
+			DispatchCount: 1,
+			DepthRequired: 1,
+		},
+	}, nil).Once()
+
+	dispatch, err := NewCachingDispatcher(DispatchTestCache(t), "", nil)
+	require.NoError(err)
+	dispatch.SetDelegate(delegate)
+	defer dispatch.Close()
+
+	for i := 0; i < 3; i++ {
+		_, err := dispatch.DispatchCheck(context.Background(), req)
+		require.NoError(err)
+
+		// Let the cache converge.
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_, err = dispatch.DispatchExpand(context.Background(), &v1.DispatchExpandRequest{
+		ResourceAndRelation: tuple.ParseONR("folder:folder1#view"),
+	})
+	require.NoError(err)
+
+	// Requests for both APIs, hits for check alone, and the added and estimated bytes for the
+	// cached namespace alone are exported.
+	metrics := dispatch.namespaceMetrics
+	require.Equal(5, testutil.CollectAndCount(metrics))
+
+	require.Equal(3.0, testutil.ToFloat64(metrics.requests.WithLabelValues(apiCheck, "document")))
+	require.Equal(2.0, testutil.ToFloat64(metrics.hits.WithLabelValues(apiCheck, "document")))
+	require.Equal(1.0, testutil.ToFloat64(metrics.requests.WithLabelValues(apiExpand, "folder")))
+	require.Equal(0.0, testutil.ToFloat64(metrics.hits.WithLabelValues(apiExpand, "folder")))
+
+	require.Positive(metrics.bytesAdded[namespaceAPI{"document", apiCheck}])
+	require.Len(metrics.bytesAdded, 1)
+
+	delegate.AssertExpectations(t)
+}
+
 type delegateDispatchMock struct {
 	*mock.Mock
 }
 
 func (ddm delegateDispatchMock) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
 	args := ddm.Called(req)
+
+	// As any dispatcher, the check fails if its context was canceled while it was computed.
+	if err := ctx.Err(); err != nil {
+		return &v1.DispatchCheckResponse{Metadata: &v1.ResponseMeta{}}, err
+	}
 	return args.Get(0).(*v1.DispatchCheckResponse), args.Error(1)
 }
 
@@ -158,8 +681,8 @@ func (ddm delegateDispatchMock) DispatchExpand(ctx context.Context, req *v1.Disp
 	return &v1.DispatchExpandResponse{}, nil
 }
 
-func (ddm delegateDispatchMock) DispatchLookup(ctx context.Context, req *v1.DispatchLookupRequest) (*v1.DispatchLookupResponse, error) {
-	return &v1.DispatchLookupResponse{}, nil
+func (ddm delegateDispatchMock) DispatchLookup(req *v1.DispatchLookupRequest, stream dispatch.LookupStream) error {
+	return nil
 }
 
 func (ddm delegateDispatchMock) DispatchReachableResources(req *v1.DispatchReachableResourcesRequest, stream dispatch.ReachableResourcesStream) error {
@@ -179,3 +702,56 @@ func (ddm delegateDispatchMock) IsReady() bool {
 }
 
 var _ dispatch.Dispatcher = &delegateDispatchMock{}
+
+func TestCheckWithCaveatContextCaching(t *testing.T) {
+	parsed := tuple.ParseONR("document:doc1#read")
+	req := &v1.DispatchCheckRequest{
+		ResourceRelation: RR(parsed.Namespace, parsed.Relation),
+		ResourceIds:      []string{parsed.ObjectId},
+		Subject:          tuple.ParseSubjectONR("user:user1#..."),
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     decimal.Zero.String(),
+			DepthRemaining: 50,
+		},
+	}
+	resp := &v1.DispatchCheckResponse{
+		ResultsByResourceId: map[string]*v1.ResourceCheckResult{
+			parsed.ObjectId: {Membership: v1.ResourceCheckResult_MEMBER},
+		},
+		Metadata: &v1.ResponseMeta{
+			DispatchCount: 3,
+			DepthRequired: 2,
+		},
+	}
+
+	caveatContext, err := structpb.NewStruct(map[string]any{"somecondition": 42})
+	require.NoError(t, err)
+	otherContext, err := structpb.NewStruct(map[string]any{"somecondition": 41})
+	require.NoError(t, err)
+
+	require := require.New(t)
+
+	dispatch, err := NewCachingDispatcher(DispatchTestCache(t), "", nil)
+	require.NoError(err)
+	defer dispatch.Close()
+
+	_, found := dispatch.GetCheckWithCaveatContext(context.Background(), req, caveatContext)
+	require.False(found)
+
+	dispatch.SetCheckWithCaveatContext(context.Background(), req, caveatContext, resp)
+	dispatch.c.Wait()
+
+	cached, found := dispatch.GetCheckWithCaveatContext(context.Background(), req, caveatContext)
+	require.True(found)
+	require.Equal(v1.ResourceCheckResult_MEMBER, cached.ResultsByResourceId[parsed.ObjectId].Membership)
+	require.Equal(uint32(0), cached.Metadata.DispatchCount)
+	require.Equal(uint32(3), cached.Metadata.CachedDispatchCount)
+
+	_, found = dispatch.GetCheckWithCaveatContext(context.Background(), req, otherContext)
+	require.False(found)
+
+	shallow := req.CloneVT()
+	shallow.Metadata.DepthRemaining = 1
+	_, found = dispatch.GetCheckWithCaveatContext(context.Background(), shallow, caveatContext)
+	require.False(found)
+}
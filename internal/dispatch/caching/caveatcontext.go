@@ -0,0 +1,72 @@
+package caching
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/authzed/spicedb/internal/dispatch/keys"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+// checkWithCaveatContextKey returns the cache key of the results of the check computed with the
+// caveat context.
+func (cd *Dispatcher) checkWithCaveatContextKey(ctx context.Context, req *v1.DispatchCheckRequest, caveatContext *structpb.Struct) (keys.DispatchCacheKey, error) {
+	checkKey, err := cd.keyHandler.CheckCacheKey(ctx, req)
+	if err != nil {
+		return checkKey, err
+	}
+	return keys.CheckWithCaveatContextKey(cd.keyForSchema(checkKey), caveatContext), nil
+}
+
+// GetCheckWithCaveatContext returns the cached results of the check computed with the caveat
+// context, if they were cached by SetCheckWithCaveatContext. It implements
+// computed.CaveatContextCache.
+func (cd *Dispatcher) GetCheckWithCaveatContext(ctx context.Context, req *v1.DispatchCheckRequest, caveatContext *structpb.Struct) (*v1.DispatchCheckResponse, bool) {
+	requestKey, err := cd.checkWithCaveatContextKey(ctx, req, caveatContext)
+	if err != nil {
+		return nil, false
+	}
+
+	cachedResultRaw, found := cd.c.Get(requestKey)
+	if !found || !cachedResultRaw.(cachedResult[[]byte]).usableFor(req.Metadata) {
+		return nil, false
+	}
+
+	var response v1.DispatchCheckResponse
+	if err := response.UnmarshalVT(cachedResultRaw.(cachedResult[[]byte]).value); err != nil {
+		return nil, false
+	}
+	if req.Metadata.DepthRemaining < response.Metadata.DepthRequired {
+		return nil, false
+	}
+
+	cd.checkFromCacheCounter.Inc()
+	cd.namespaceMetrics.hit(apiCheck, req.ResourceRelation.Namespace)
+	return &response, true
+}
+
+// SetCheckWithCaveatContext caches the results of the check computed with the caveat context. The
+// results must have been fully computed, with no caveat left unevaluated for lack of context. It
+// implements computed.CaveatContextCache.
+func (cd *Dispatcher) SetCheckWithCaveatContext(ctx context.Context, req *v1.DispatchCheckRequest, caveatContext *structpb.Struct, resp *v1.DispatchCheckResponse) {
+	requestKey, err := cd.checkWithCaveatContextKey(ctx, req, caveatContext)
+	if err != nil {
+		return
+	}
+
+	adjusted := resp.CloneVT()
+	adjusted.Metadata.CachedDispatchCount = adjusted.Metadata.DispatchCount
+	adjusted.Metadata.DispatchCount = 0
+	adjusted.Metadata.DebugInfo = nil
+
+	adjustedBytes, err := adjusted.MarshalVT()
+	if err != nil {
+		return
+	}
+
+	cached := newCachedResult(req.Metadata, adjustedBytes, sliceSize(adjustedBytes))
+	if cd.c.Set(requestKey, cached, cached.size) {
+		cd.namespaceMetrics.added(apiCheck, req.ResourceRelation.Namespace, cached.size)
+	}
+}
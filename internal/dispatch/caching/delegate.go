@@ -27,7 +27,7 @@ func (fd fakeDelegate) DispatchExpand(ctx context.Context, req *v1.DispatchExpan
 	panic(errMessage)
 }
 
-func (fd fakeDelegate) DispatchLookup(ctx context.Context, req *v1.DispatchLookupRequest) (*v1.DispatchLookupResponse, error) {
+func (fd fakeDelegate) DispatchLookup(req *v1.DispatchLookupRequest, stream dispatch.LookupStream) error {
 	panic(errMessage)
 }
 
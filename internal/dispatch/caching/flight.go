@@ -0,0 +1,118 @@
+package caching
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+// checkFlight is a computation of a check shared by the concurrent requests for it. It runs under
+// its own context, which is only canceled once every request waiting on it has gone, so that the
+// cancellation of the request which started it does not fail the others. The deadline of its
+// context is the latest of those of the requests waiting on it, so that the time budget of the
+// requests it dispatches is that of the last request to give up on it.
+type checkFlight struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+
+	deadlineLock sync.Mutex
+
+	// deadline is the latest deadline of the requests waiting on the flight, or zero if any of them
+	// has none.
+	deadline time.Time
+}
+
+// extendDeadline extends the deadline of the flight to that of the context of a request joining
+// it, if later. It must be called before the request is counted as waiting on the flight.
+func (cf *checkFlight) extendDeadline(ctx context.Context) {
+	cf.deadlineLock.Lock()
+	defer cf.deadlineLock.Unlock()
+
+	deadline, ok := ctx.Deadline()
+	switch {
+	case cf.waiters == 0:
+		cf.deadline = deadline
+	case !ok:
+		cf.deadline = time.Time{}
+	case !cf.deadline.IsZero() && deadline.After(cf.deadline):
+		cf.deadline = deadline
+	}
+}
+
+// checkFlightResult is the result of a shared computation of a check.
+type checkFlightResult struct {
+	// leader is the request which computed the check.
+	leader *v1.DispatchCheckRequest
+
+	// computed is the response of the computation, returned to the leader.
+	computed *v1.DispatchCheckResponse
+
+	// adjusted is the response as it was cached, a clone of which is returned to the other
+	// requests. It must not be modified.
+	adjusted *v1.DispatchCheckResponse
+}
+
+// joinCheckFlight registers the request as waiting on the shared computation of the check with the
+// given key, starting it with compute if there is none, and returns the flight along with the channel
+// on which its result is sent. The flight must be left with leaveCheckFlight once the request stops
+// waiting.
+func (cd *Dispatcher) joinCheckFlight(ctx context.Context, key string, compute func(context.Context) (any, error)) (*checkFlight, <-chan singleflight.Result) {
+	cd.checkFlightsLock.Lock()
+	defer cd.checkFlightsLock.Unlock()
+
+	flight, ok := cd.checkFlights[key]
+	if !ok {
+		flight = &checkFlight{}
+		flight.ctx, flight.cancel = context.WithCancel(flightContext{ctx, flight})
+		cd.checkFlights[key] = flight
+	}
+	flight.extendDeadline(ctx)
+	flight.waiters++
+
+	// The request joins the computation while holding the lock, so that every request counted as
+	// waiting on the flight shares the same computation.
+	return flight, cd.checkGroup.DoChan(key, func() (any, error) {
+		return compute(flight.ctx)
+	})
+}
+
+// leaveCheckFlight unregisters a request from the shared computation of the check, canceling it
+// if no other request is waiting on it.
+func (cd *Dispatcher) leaveCheckFlight(key string, flight *checkFlight) {
+	cd.checkFlightsLock.Lock()
+	defer cd.checkFlightsLock.Unlock()
+
+	flight.waiters--
+	if flight.waiters > 0 {
+		return
+	}
+
+	flight.cancel()
+	if cd.checkFlights[key] == flight {
+		delete(cd.checkFlights, key)
+	}
+}
+
+// flightContext carries the values of the context of the request which started a flight, such as
+// its logger and datastore, without being canceled with it, and the deadline of the flight.
+type flightContext struct {
+	parent context.Context
+	flight *checkFlight
+}
+
+func (fc flightContext) Deadline() (time.Time, bool) {
+	fc.flight.deadlineLock.Lock()
+	defer fc.flight.deadlineLock.Unlock()
+	return fc.flight.deadline, !fc.flight.deadline.IsZero()
+}
+
+func (fc flightContext) Done() <-chan struct{} { return nil }
+
+func (fc flightContext) Err() error { return nil }
+
+func (fc flightContext) Value(key any) any { return fc.parent.Value(key) }
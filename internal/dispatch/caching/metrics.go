@@ -0,0 +1,114 @@
+package caching
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/authzed/spicedb/pkg/cache"
+)
+
+// The APIs by which the namespace metrics are broken down.
+const (
+	apiCheck              = "check"
+	apiExpand             = "expand"
+	apiLookup             = "lookup"
+	apiReachableResources = "reachable_resources"
+	apiLookupSubjects     = "lookup_subjects"
+)
+
+type namespaceAPI struct {
+	namespace string
+	api       string
+}
+
+// namespaceMetrics exports the use of the cache broken down by the API and the namespace of the
+// resources of each request, so that operators can see which namespaces benefit from the cache
+// and how much of it they occupy.
+type namespaceMetrics struct {
+	cache cache.Cache
+
+	requests *prometheus.CounterVec
+	hits     *prometheus.CounterVec
+
+	bytesAddedDesc     *prometheus.Desc
+	estimatedBytesDesc *prometheus.Desc
+
+	lock       sync.Mutex
+	bytesAdded map[namespaceAPI]uint64
+}
+
+func newNamespaceMetrics(cacheInst cache.Cache, prometheusSubsystem string) *namespaceMetrics {
+	labels := []string{"api", "namespace"}
+	return &namespaceMetrics{
+		cache: cacheInst,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "namespace_requests_total",
+			Help:      "number of dispatched requests which may be cached, by API and resource namespace",
+		}, labels),
+		hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prometheusNamespace,
+			Subsystem: prometheusSubsystem,
+			Name:      "namespace_cache_hits_total",
+			Help:      "number of dispatched requests answered from the cache, by API and resource namespace",
+		}, labels),
+		bytesAddedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(prometheusNamespace, prometheusSubsystem, "namespace_cached_bytes_added_total"),
+			"total cost in bytes of the results added to the cache, by API and resource namespace",
+			labels, nil,
+		),
+		estimatedBytesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(prometheusNamespace, prometheusSubsystem, "namespace_estimated_cached_bytes"),
+			"estimated cost in bytes of the results held in the cache, by API and resource namespace, assuming results are evicted evenly across namespaces",
+			labels, nil,
+		),
+		bytesAdded: map[namespaceAPI]uint64{},
+	}
+}
+
+func (nm *namespaceMetrics) request(api, namespace string) {
+	nm.requests.WithLabelValues(api, namespace).Inc()
+}
+
+func (nm *namespaceMetrics) hit(api, namespace string) {
+	nm.hits.WithLabelValues(api, namespace).Inc()
+}
+
+func (nm *namespaceMetrics) added(api, namespace string, size int64) {
+	nm.lock.Lock()
+	defer nm.lock.Unlock()
+	nm.bytesAdded[namespaceAPI{namespace, api}] += uint64(size)
+}
+
+// Describe implements prometheus.Collector.
+func (nm *namespaceMetrics) Describe(ch chan<- *prometheus.Desc) {
+	nm.requests.Describe(ch)
+	nm.hits.Describe(ch)
+	ch <- nm.bytesAddedDesc
+	ch <- nm.estimatedBytesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (nm *namespaceMetrics) Collect(ch chan<- prometheus.Metric) {
+	nm.requests.Collect(ch)
+	nm.hits.Collect(ch)
+
+	// Evictions cannot be attributed to namespaces, so the bytes held for each namespace are
+	// estimated from the proportion of all the bytes added which have not been evicted.
+	retained := 1.0
+	metrics := nm.cache.GetMetrics()
+	if added, evicted := metrics.CostAdded(), metrics.CostEvicted(); added > 0 && evicted <= added {
+		retained = float64(added-evicted) / float64(added)
+	}
+
+	nm.lock.Lock()
+	defer nm.lock.Unlock()
+	for key, bytes := range nm.bytesAdded {
+		ch <- prometheus.MustNewConstMetric(nm.bytesAddedDesc, prometheus.CounterValue, float64(bytes), key.api, key.namespace)
+		ch <- prometheus.MustNewConstMetric(nm.estimatedBytesDesc, prometheus.GaugeValue, float64(bytes)*retained, key.api, key.namespace)
+	}
+}
+
+var _ prometheus.Collector = (*namespaceMetrics)(nil)
@@ -5,6 +5,7 @@ import (
 	"github.com/authzed/spicedb/internal/dispatch/caching"
 	"github.com/authzed/spicedb/internal/dispatch/graph"
 	"github.com/authzed/spicedb/internal/dispatch/keys"
+	maingraph "github.com/authzed/spicedb/internal/graph"
 	"github.com/authzed/spicedb/pkg/cache"
 )
 
@@ -14,9 +15,11 @@ const defaultConcurrencyLimit = 50
 type Option func(*optionState)
 
 type optionState struct {
-	prometheusSubsystem string
-	cache               cache.Cache
-	concurrencyLimit    uint16
+	prometheusSubsystem       string
+	cache                     cache.Cache
+	cachingOptions            []caching.Option
+	concurrencyLimit          uint16
+	concurrencyLimitOverrides map[string]uint16
 }
 
 // PrometheusSubsystem sets the subsystem name for the prometheus metrics
@@ -33,6 +36,14 @@ func Cache(c cache.Cache) Option {
 	}
 }
 
+// CachingOptions sets the options of the caching dispatcher, such as how negative check results
+// are cached.
+func CachingOptions(options ...caching.Option) Option {
+	return func(state *optionState) {
+		state.cachingOptions = options
+	}
+}
+
 // ConcurrencyLimit sets the max number of goroutines per operation
 func ConcurrencyLimit(limit uint16) Option {
 	return func(state *optionState) {
@@ -40,6 +51,15 @@ func ConcurrencyLimit(limit uint16) Option {
 	}
 }
 
+// ConcurrencyLimitOverrides sets the max number of goroutines per operation on the resources of
+// a namespace (e.g. "document") or of a relation (e.g. "document#view"), in place of the
+// ConcurrencyLimit.
+func ConcurrencyLimitOverrides(overrides map[string]uint16) Option {
+	return func(state *optionState) {
+		state.concurrencyLimitOverrides = overrides
+	}
+}
+
 // NewClusterDispatcher takes a dispatcher (such as one created by
 // combined.NewDispatcher) and returns a cluster dispatcher suitable for use as
 // the dispatcher for the dispatch grpc server.
@@ -54,13 +74,18 @@ func NewClusterDispatcher(dispatch dispatch.Dispatcher, options ...Option) (disp
 		concurrencyLimit = opts.concurrencyLimit
 	}
 
-	clusterDispatch := graph.NewDispatcher(dispatch, concurrencyLimit)
+	concurrencyLimits, err := maingraph.SharedConcurrencyLimits(concurrencyLimit).WithOverrides(opts.concurrencyLimitOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterDispatch := graph.NewDispatcherWithLimits(dispatch, concurrencyLimits)
 
 	if opts.prometheusSubsystem == "" {
 		opts.prometheusSubsystem = "dispatch"
 	}
 
-	cachingClusterDispatch, err := caching.NewCachingDispatcher(opts.cache, opts.prometheusSubsystem, &keys.CanonicalKeyHandler{})
+	cachingClusterDispatch, err := caching.NewCachingDispatcher(opts.cache, opts.prometheusSubsystem, &keys.CanonicalKeyHandler{}, opts.cachingOptions...)
 	if err != nil {
 		return nil, err
 	}
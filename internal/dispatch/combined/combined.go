@@ -3,10 +3,16 @@
 package combined
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/authzed/grpcutil"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/authzed/spicedb/internal/dispatch"
@@ -14,9 +20,11 @@ import (
 	"github.com/authzed/spicedb/internal/dispatch/graph"
 	"github.com/authzed/spicedb/internal/dispatch/keys"
 	"github.com/authzed/spicedb/internal/dispatch/remote"
+	maingraph "github.com/authzed/spicedb/internal/graph"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/cache"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/x509util"
 )
 
 const defaultConcurrencyLimit = 50
@@ -25,13 +33,47 @@ const defaultConcurrencyLimit = 50
 type Option func(*optionState)
 
 type optionState struct {
-	prometheusSubsystem string
-	upstreamAddr        string
-	upstreamCAPath      string
-	grpcPresharedKey    string
-	grpcDialOpts        []grpc.DialOption
-	cache               cache.Cache
-	concurrencyLimit    uint16
+	prometheusSubsystem       string
+	upstreamAddr              string
+	upstreamCAPath            string
+	upstreamCertPath          string
+	upstreamKeyPath           string
+	upstreamPeerIDs           []string
+	grpcPresharedKey          string
+	grpcDialOpts              []grpc.DialOption
+	cache                     cache.Cache
+	cachingOptions            []caching.Option
+	concurrencyLimit          uint16
+	concurrencyLimitOverrides map[string]uint16
+	hedging                   *remote.HedgingConfig
+	checkBatchWindow          time.Duration
+	fallbackPolicy            FallbackPolicy
+	hopOverhead               time.Duration
+}
+
+// FallbackPolicy is what is done with the requests which cannot be dispatched because no node of
+// the optional upstream can be reached.
+type FallbackPolicy int
+
+const (
+	// FallbackLocal resolves the requests locally.
+	FallbackLocal FallbackPolicy = iota
+
+	// FallbackFail fails the requests, for deployments in which nodes must not resolve requests
+	// dispatched to others.
+	FallbackFail
+)
+
+// ParseFallbackPolicy parses a fallback policy of "local" or "fail".
+func ParseFallbackPolicy(policy string) (FallbackPolicy, error) {
+	switch policy {
+	case "", "local":
+		return FallbackLocal, nil
+	case "fail":
+		return FallbackFail, nil
+	default:
+		return FallbackLocal, fmt.Errorf("unknown dispatch fallback policy %q", policy)
+	}
 }
 
 // PrometheusSubsystem sets the subsystem name for the prometheus metrics
@@ -56,6 +98,25 @@ func UpstreamCAPath(path string) Option {
 	}
 }
 
+// UpstreamCertPaths sets the certificate and key with which to authenticate to
+// the optional cluster dispatching upstream, which requires an UpstreamCAPath.
+// They are read again on every connection, so they can be rotated.
+func UpstreamCertPaths(certPath, keyPath string) Option {
+	return func(state *optionState) {
+		state.upstreamCertPath = certPath
+		state.upstreamKeyPath = keyPath
+	}
+}
+
+// UpstreamPeerIDs sets the SPIFFE IDs or trust domains, one of which must be
+// carried by the certificate of the optional cluster dispatching upstream, in
+// place of verifying its host name. Requires an UpstreamCAPath.
+func UpstreamPeerIDs(ids []string) Option {
+	return func(state *optionState) {
+		state.upstreamPeerIDs = ids
+	}
+}
+
 // GrpcPresharedKey sets the preshared key used to authenticate for optional
 // cluster dispatching.
 func GrpcPresharedKey(key string) Option {
@@ -79,6 +140,46 @@ func Cache(c cache.Cache) Option {
 	}
 }
 
+// CachingOptions sets the options of the caching dispatcher, such as how negative check results
+// are cached.
+func CachingOptions(options ...caching.Option) Option {
+	return func(state *optionState) {
+		state.cachingOptions = options
+	}
+}
+
+// Hedging enables hedging of the requests dispatched to the optional upstream.
+func Hedging(config remote.HedgingConfig) Option {
+	return func(state *optionState) {
+		state.hedging = &config
+	}
+}
+
+// CheckBatchWindow enables batching of the checks dispatched to the same node of the optional
+// upstream within the given window into a single call.
+func CheckBatchWindow(window time.Duration) Option {
+	return func(state *optionState) {
+		state.checkBatchWindow = window
+	}
+}
+
+// UpstreamFallbackPolicy sets what is done with the requests which cannot be dispatched because no
+// node of the optional upstream can be reached. By default, they are resolved locally.
+func UpstreamFallbackPolicy(policy FallbackPolicy) Option {
+	return func(state *optionState) {
+		state.fallbackPolicy = policy
+	}
+}
+
+// UpstreamHopOverhead sets the time which dispatching a request to a node of the optional upstream
+// is expected to take on top of resolving it, which is deducted from the time budget given to the
+// node at each hop.
+func UpstreamHopOverhead(overhead time.Duration) Option {
+	return func(state *optionState) {
+		state.hopOverhead = overhead
+	}
+}
+
 // ConcurrencyLimit sets the max number of goroutines per operation
 func ConcurrencyLimit(limit uint16) Option {
 	return func(state *optionState) {
@@ -86,6 +187,15 @@ func ConcurrencyLimit(limit uint16) Option {
 	}
 }
 
+// ConcurrencyLimitOverrides sets the max number of goroutines per operation on the resources of
+// a namespace (e.g. "document") or of a relation (e.g. "document#view"), in place of the
+// ConcurrencyLimit.
+func ConcurrencyLimitOverrides(overrides map[string]uint16) Option {
+	return func(state *optionState) {
+		state.concurrencyLimitOverrides = overrides
+	}
+}
+
 // NewDispatcher initializes a Dispatcher that caches and redispatches
 // optionally to the provided upstream.
 func NewDispatcher(options ...Option) (dispatch.Dispatcher, error) {
@@ -99,7 +209,7 @@ func NewDispatcher(options ...Option) (dispatch.Dispatcher, error) {
 		opts.prometheusSubsystem = "dispatch_client"
 	}
 
-	cachingRedispatch, err := caching.NewCachingDispatcher(opts.cache, opts.prometheusSubsystem, &keys.CanonicalKeyHandler{})
+	cachingRedispatch, err := caching.NewCachingDispatcher(opts.cache, opts.prometheusSubsystem, &keys.CanonicalKeyHandler{}, opts.cachingOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +219,12 @@ func NewDispatcher(options ...Option) (dispatch.Dispatcher, error) {
 		concurrencyLimit = opts.concurrencyLimit
 	}
 
-	redispatch := graph.NewDispatcher(cachingRedispatch, concurrencyLimit)
+	concurrencyLimits, err := maingraph.SharedConcurrencyLimits(concurrencyLimit).WithOverrides(opts.concurrencyLimitOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	redispatch := graph.NewDispatcherWithLimits(cachingRedispatch, concurrencyLimits)
 
 	// If an upstream is specified, create a cluster dispatcher.
 	if opts.upstreamAddr != "" {
@@ -118,9 +233,20 @@ func NewDispatcher(options ...Option) (dispatch.Dispatcher, error) {
 			if _, err := os.Stat(opts.upstreamCAPath); err != nil {
 				return nil, err
 			}
-			opts.grpcDialOpts = append(opts.grpcDialOpts, grpcutil.WithCustomCerts(opts.upstreamCAPath, grpcutil.VerifyCA))
+			if opts.upstreamCertPath != "" || len(opts.upstreamPeerIDs) > 0 {
+				creds, err := upstreamMTLSCredentials(opts)
+				if err != nil {
+					return nil, err
+				}
+				opts.grpcDialOpts = append(opts.grpcDialOpts, grpc.WithTransportCredentials(creds))
+			} else {
+				opts.grpcDialOpts = append(opts.grpcDialOpts, grpcutil.WithCustomCerts(opts.upstreamCAPath, grpcutil.VerifyCA))
+			}
 			opts.grpcDialOpts = append(opts.grpcDialOpts, grpcutil.WithBearerToken(opts.grpcPresharedKey))
 		} else {
+			if opts.upstreamCertPath != "" || len(opts.upstreamPeerIDs) > 0 {
+				return nil, errors.New("dispatch upstream certificate and peer IDs require an upstream CA")
+			}
 			opts.grpcDialOpts = append(opts.grpcDialOpts, grpcutil.WithInsecureBearerToken(opts.grpcPresharedKey))
 			opts.grpcDialOpts = append(opts.grpcDialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 		}
@@ -131,10 +257,55 @@ func NewDispatcher(options ...Option) (dispatch.Dispatcher, error) {
 		if err != nil {
 			return nil, err
 		}
-		redispatch = remote.NewClusterDispatcher(v1.NewDispatchServiceClient(conn), conn, &keys.CanonicalKeyHandler{})
+		remoteOptions := []remote.Option{remote.HopOverhead(opts.hopOverhead)}
+		if opts.hedging != nil {
+			remoteOptions = append(remoteOptions, remote.Hedging(*opts.hedging))
+		}
+		if opts.checkBatchWindow > 0 {
+			remoteOptions = append(remoteOptions, remote.CheckBatching(opts.checkBatchWindow))
+		}
+		if opts.fallbackPolicy == FallbackLocal {
+			remoteOptions = append(remoteOptions, remote.LocalFallback(redispatch))
+		}
+		redispatch = remote.NewClusterDispatcher(v1.NewDispatchServiceClient(conn), conn, &keys.CanonicalKeyHandler{}, remoteOptions...)
 	}
 
 	cachingRedispatch.SetDelegate(redispatch)
 
 	return cachingRedispatch, nil
 }
+
+// upstreamMTLSCredentials returns the credentials with which to authenticate to
+// the upstream with a client certificate and verify its SPIFFE ID.
+func upstreamMTLSCredentials(opts optionState) (credentials.TransportCredentials, error) {
+	pool, err := x509util.CustomCertPool(opts.upstreamCAPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	if opts.upstreamCertPath != "" {
+		if opts.upstreamKeyPath == "" {
+			return nil, errors.New("dispatch upstream certificate requires a key")
+		}
+		certPath, keyPath := opts.upstreamCertPath, opts.upstreamKeyPath
+		if _, err := tls.LoadX509KeyPair(certPath, keyPath); err != nil {
+			return nil, fmt.Errorf("failed to load dispatch upstream certificate: %w", err)
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+			return &cert, err
+		}
+	}
+
+	if len(opts.upstreamPeerIDs) > 0 {
+		matcher, err := x509util.NewSPIFFEMatcher(opts.upstreamPeerIDs)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = matcher.VerifyPeer(pool, x509.ExtKeyUsageServerAuth)
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
@@ -0,0 +1,78 @@
+package combined
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	_ "github.com/mostynb/go-grpc-compression/experimental/s2"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+type recordingDispatchServer struct {
+	v1.UnimplementedDispatchServiceServer
+	requests chan *v1.DispatchCheckRequest
+}
+
+func (rs *recordingDispatchServer) DispatchCheck(_ context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
+	rs.requests <- req
+	return &v1.DispatchCheckResponse{
+		Metadata: &v1.ResponseMeta{DispatchCount: 1},
+		ResultsByResourceId: map[string]*v1.ResourceCheckResult{
+			req.ResourceIds[0]: {Membership: v1.ResourceCheckResult_MEMBER},
+		},
+	}, nil
+}
+
+func TestCheckTimeBudgetReachesUpstream(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	upstream := &recordingDispatchServer{requests: make(chan *v1.DispatchCheckRequest, 1)}
+	server := grpc.NewServer()
+	v1.RegisterDispatchServiceServer(server, upstream)
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	dispatcher, err := NewDispatcher(
+		PrometheusSubsystem(""),
+		UpstreamAddr(lis.Addr().String()),
+		UpstreamFallbackPolicy(FallbackFail),
+	)
+	require.NoError(t, err)
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+	ds, revision := testfixtures.StandardDatastoreWithData(rawDS, require.New(t))
+
+	ctx := datastoremw.ContextWithHandle(context.Background())
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	resp, err := dispatcher.DispatchCheck(ctx, &v1.DispatchCheckRequest{
+		ResourceRelation: &core.RelationReference{Namespace: "document", Relation: "view"},
+		ResourceIds:      []string{"masterplan"},
+		Subject:          &core.ObjectAndRelation{Namespace: "user", ObjectId: "eng_lead", Relation: "..."},
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     revision.String(),
+			DepthRemaining: 50,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, v1.ResourceCheckResult_MEMBER, resp.ResultsByResourceId["masterplan"].Membership)
+
+	// The check is computed under the context shared by the requests for it, which must carry the
+	// deadline of the request so that the upstream is sent the time remaining before it.
+	req := <-upstream.requests
+	require.NotNil(t, req.Metadata.TimeBudget)
+	require.Greater(t, req.Metadata.TimeBudget.AsDuration(), 50*time.Second)
+	require.LessOrEqual(t, req.Metadata.TimeBudget.AsDuration(), time.Minute)
+}
@@ -0,0 +1,83 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+// ErrTimeBudgetExhausted is returned when too little of the deadline of a request remains to
+// dispatch it to another node, once the overhead of the hop is accounted for. It wraps
+// context.DeadlineExceeded.
+type ErrTimeBudgetExhausted struct {
+	error
+	remaining time.Duration
+}
+
+// Remaining returns the time which remained before the deadline when the request was dispatched.
+func (err ErrTimeBudgetExhausted) Remaining() time.Duration {
+	return err.remaining
+}
+
+func (err ErrTimeBudgetExhausted) Unwrap() error {
+	return errors.Unwrap(err.error)
+}
+
+func (err ErrTimeBudgetExhausted) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Dur("remaining", err.remaining)
+}
+
+// DetailsMetadata returns the metadata for details for this error.
+func (err ErrTimeBudgetExhausted) DetailsMetadata() map[string]string {
+	return map[string]string{
+		"remaining_time_budget": err.remaining.String(),
+	}
+}
+
+// NewTimeBudgetExhaustedErr constructs a new time budget exhausted error.
+func NewTimeBudgetExhaustedErr(remaining time.Duration) error {
+	return ErrTimeBudgetExhausted{
+		error:     fmt.Errorf("time budget exhausted: %s remained to dispatch the request, which is less than the overhead of dispatching it: %w", remaining, context.DeadlineExceeded),
+		remaining: remaining,
+	}
+}
+
+// SetTimeBudget gives a request about to be dispatched to another node the time which remains
+// before the deadline of the context, less the given overhead of the hop to the node. If no time
+// would remain, ErrTimeBudgetExhausted is returned rather than dispatching a request which cannot
+// complete.
+func SetTimeBudget(ctx context.Context, req HasMetadata, overhead time.Duration) error {
+	metadata := req.GetMetadata()
+	if metadata == nil {
+		return nil
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		metadata.TimeBudget = nil
+		return nil
+	}
+
+	remaining := time.Until(deadline)
+	if remaining <= overhead {
+		return NewTimeBudgetExhaustedErr(remaining)
+	}
+
+	metadata.TimeBudget = durationpb.New(remaining - overhead)
+	return nil
+}
+
+// ContextWithTimeBudget returns a context which is canceled once the time budget of a request
+// dispatched by another node is spent, if it carries one.
+func ContextWithTimeBudget(ctx context.Context, metadata *v1.ResolverMeta) (context.Context, context.CancelFunc) {
+	if metadata.GetTimeBudget() == nil {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, metadata.GetTimeBudget().AsDuration())
+}
@@ -0,0 +1,73 @@
+package dispatch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+func TestSetTimeBudget(t *testing.T) {
+	testCases := []struct {
+		name          string
+		timeout       time.Duration
+		overhead      time.Duration
+		expectBudget  bool
+		expectedError bool
+	}{
+		{"no deadline", 0, 5 * time.Millisecond, false, false},
+		{"budget remains", time.Minute, 5 * time.Millisecond, true, false},
+		{"budget less than overhead", time.Millisecond, 5 * time.Millisecond, false, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+
+			req := &v1.DispatchCheckRequest{Metadata: &v1.ResolverMeta{}}
+			err := SetTimeBudget(ctx, req, tc.overhead)
+			if tc.expectedError {
+				require.ErrorAs(t, err, &ErrTimeBudgetExhausted{})
+				require.True(t, errors.Is(err, context.DeadlineExceeded))
+				return
+			}
+			require.NoError(t, err)
+
+			if !tc.expectBudget {
+				require.Nil(t, req.Metadata.TimeBudget)
+				return
+			}
+			budget := req.Metadata.TimeBudget.AsDuration()
+			require.Greater(t, budget, time.Duration(0))
+			require.LessOrEqual(t, budget, tc.timeout-tc.overhead)
+		})
+	}
+}
+
+func TestContextWithTimeBudget(t *testing.T) {
+	ctx, cancel := ContextWithTimeBudget(context.Background(), &v1.ResolverMeta{})
+	defer cancel()
+	_, ok := ctx.Deadline()
+	require.False(t, ok)
+
+	req := &v1.DispatchCheckRequest{Metadata: &v1.ResolverMeta{}}
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Minute)
+	defer parentCancel()
+	require.NoError(t, SetTimeBudget(parent, req, 10*time.Second))
+
+	ctx, cancel = ContextWithTimeBudget(context.Background(), req.Metadata)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	require.LessOrEqual(t, time.Until(deadline), 50*time.Second)
+}
@@ -2,17 +2,51 @@ package dispatch
 
 import (
 	"context"
+	"os"
 	"strings"
 
 	"github.com/authzed/spicedb/pkg/tuple"
 
+	"github.com/authzed/authzed-go/pkg/responsemeta"
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/authzed/spicedb/pkg/datastore"
 	dispatch "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 	"github.com/authzed/spicedb/pkg/schemadsl/generator"
 )
 
+// DispatchTraceTrailerKey is the response trailer in which the complete dispatch tree of a
+// request for debug information is returned, as the JSON encoding of a dispatch.v1.DebugInformation.
+// Unlike the API debug information, it includes the node which handled each subproblem and how
+// long it took.
+const DispatchTraceTrailerKey responsemeta.ResponseMetadataTrailerKey = "io.spicedb.respmeta.dispatchtrace"
+
+// NodeName is the name of this node as recorded in debug traces.
+var NodeName = func() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return hostname
+}()
+
+// MarshalDispatchTrace encodes the dispatch debug information found in the response metadata for
+// the DispatchTraceTrailerKey trailer, and records it on the span of the given context.
+func MarshalDispatchTrace(ctx context.Context, metadata *dispatch.ResponseMeta) (string, error) {
+	marshaled, err := protojson.Marshal(metadata.DebugInfo)
+	if err != nil {
+		return "", err
+	}
+
+	trace.SpanFromContext(ctx).AddEvent("dispatch trace", trace.WithAttributes(
+		attribute.String("trace", string(marshaled)),
+	))
+	return string(marshaled), nil
+}
+
 // ConvertDispatchDebugInformation converts dispatch debug information found in the response metadata
 // into DebugInformation returnable to the API.
 func ConvertDispatchDebugInformation(ctx context.Context, metadata *dispatch.ResponseMeta, reader datastore.Reader) (*v1.DebugInformation, error) {
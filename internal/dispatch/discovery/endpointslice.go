@@ -0,0 +1,340 @@
+// Package discovery implements the discovery of the nodes of a dispatch cluster by watching
+// the EndpointSlices of its headless Kubernetes service, so that the consistent hashring of
+// the nodes to dispatch to is updated as soon as pods are added, removed or become unready.
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sercand/kuberesolver/v3"
+	"google.golang.org/grpc/resolver"
+
+	log "github.com/authzed/spicedb/internal/logging"
+)
+
+// Scheme is the scheme of the dial targets resolved by watching EndpointSlices, which are of
+// the form kubernetes-endpointslices:///service.namespace:port. The port may be a number, the
+// name of a port of the service or omitted to use its first port, and the namespace defaults to
+// that of the running pod.
+const Scheme = "kubernetes-endpointslices"
+
+const (
+	namespaceFile    = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	defaultNamespace = "default"
+	serviceNameLabel = "kubernetes.io/service-name"
+	rewatchDelay     = time.Second
+)
+
+// RegisterInCluster registers the resolver of EndpointSlices with gRPC, using the service
+// account of the running pod to access the Kubernetes API.
+func RegisterInCluster() {
+	resolver.Register(NewBuilder(nil))
+}
+
+// NewBuilder creates a resolver builder watching EndpointSlices with the given client. If it
+// is nil, an in-cluster client is created when the first resolver is built.
+func NewBuilder(client kuberesolver.K8sClient) resolver.Builder {
+	return &builder{client: client}
+}
+
+type builder struct {
+	lock   sync.Mutex
+	client kuberesolver.K8sClient
+}
+
+func (b *builder) Scheme() string {
+	return Scheme
+}
+
+func (b *builder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	b.lock.Lock()
+	if b.client == nil {
+		client, err := kuberesolver.NewInClusterK8sClient()
+		if err != nil {
+			b.lock.Unlock()
+			return nil, fmt.Errorf("unable to create a Kubernetes client to watch endpoint slices: %w", err)
+		}
+		b.client = client
+	}
+	client := b.client
+	b.lock.Unlock()
+
+	service, err := parseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &endpointSliceResolver{
+		client:  client,
+		service: service,
+		cc:      cc,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go r.run(ctx)
+	return r, nil
+}
+
+// service is the service of which the EndpointSlices are watched.
+type service struct {
+	name      string
+	namespace string
+	port      string
+}
+
+func (s service) String() string {
+	return s.name + "." + s.namespace
+}
+
+func parseTarget(target resolver.Target) (service, error) {
+	endpoint := target.Endpoint
+	if endpoint == "" {
+		endpoint = target.Authority
+	}
+	if endpoint == "" {
+		return service{}, fmt.Errorf("target %q names no service", target.URL.String())
+	}
+
+	name, port := endpoint, ""
+	if strings.Contains(endpoint, ":") {
+		var err error
+		name, port, err = net.SplitHostPort(endpoint)
+		if err != nil {
+			return service{}, fmt.Errorf("target %q is invalid: %w", target.URL.String(), err)
+		}
+	}
+
+	s := service{name: name, port: port}
+	if serviceName, namespace, ok := strings.Cut(name, "."); ok {
+		s.name, s.namespace = serviceName, namespace
+	}
+	if s.namespace == "" {
+		s.namespace = currentNamespace()
+	}
+	return s, nil
+}
+
+// currentNamespace returns the namespace of the running pod, or the default namespace if it
+// cannot be read.
+func currentNamespace() string {
+	namespace, err := os.ReadFile(namespaceFile)
+	if err != nil || len(namespace) == 0 {
+		return defaultNamespace
+	}
+	return strings.TrimSpace(string(namespace))
+}
+
+// endpointSliceResolver updates the addresses of a connection from the ready endpoints of the
+// EndpointSlices of a service, listing them then watching them for changes, and listing them
+// again whenever the watch ends.
+type endpointSliceResolver struct {
+	client  kuberesolver.K8sClient
+	service service
+	cc      resolver.ClientConn
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// ResolveNow does nothing, as the addresses are updated as soon as the EndpointSlices change.
+func (r *endpointSliceResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (r *endpointSliceResolver) Close() {
+	r.cancel()
+	<-r.done
+}
+
+func (r *endpointSliceResolver) run(ctx context.Context) {
+	defer close(r.done)
+
+	for {
+		err := r.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Stringer("service", r.service).Msg("error watching endpoint slices of dispatch service")
+			r.cc.ReportError(err)
+		}
+
+		timer := time.NewTimer(rewatchDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// watch lists the EndpointSlices of the service then watches them, updating the addresses of
+// the connection after each change, until the watch ends.
+func (r *endpointSliceResolver) watch(ctx context.Context) error {
+	var list endpointSliceList
+	if err := r.get(ctx, false, "", func(resp *http.Response) error {
+		return json.NewDecoder(resp.Body).Decode(&list)
+	}); err != nil {
+		return err
+	}
+
+	slices := make(map[string]endpointSlice, len(list.Items))
+	for _, slice := range list.Items {
+		slices[slice.Metadata.Name] = slice
+	}
+	r.update(ctx, slices)
+
+	return r.get(ctx, true, list.Metadata.ResourceVersion, func(resp *http.Response) error {
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var event watchEvent
+			if err := decoder.Decode(&event); err != nil {
+				// The API server ends watches after a timeout, after which they are started again.
+				if ctx.Err() != nil || errors.Is(err, io.EOF) {
+					return nil
+				}
+				return fmt.Errorf("error reading endpoint slice watch: %w", err)
+			}
+
+			switch event.Type {
+			case "ADDED", "MODIFIED":
+				slices[event.Object.Metadata.Name] = event.Object
+			case "DELETED":
+				delete(slices, event.Object.Metadata.Name)
+			case "ERROR":
+				// The resource version is too old to watch from, so the slices are listed again.
+				return errors.New("endpoint slice watch failed, listing them again")
+			default:
+				continue
+			}
+
+			r.update(ctx, slices)
+		}
+	})
+}
+
+// get requests the EndpointSlices of the service, or a watch of them from the given resource
+// version, handling the response if it is successful.
+func (r *endpointSliceResolver) get(ctx context.Context, watch bool, resourceVersion string, handle func(*http.Response) error) error {
+	query := url.Values{"labelSelector": []string{serviceNameLabel + "=" + r.service.name}}
+	if watch {
+		query.Set("watch", "true")
+		query.Set("resourceVersion", resourceVersion)
+	}
+	u := fmt.Sprintf("%s/apis/discovery.k8s.io/v1/namespaces/%s/endpointslices?%s",
+		r.client.Host(), url.PathEscape(r.service.namespace), query.Encode())
+
+	req, err := r.client.GetRequest(u)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response code %d for endpoint slices of service %s", resp.StatusCode, r.service)
+	}
+	return handle(resp)
+}
+
+// update sets the addresses of the connection to the ready endpoints of the slices. An error
+// is only logged, as it is the balancer rejecting the addresses, which the next change of the
+// slices will update again.
+func (r *endpointSliceResolver) update(ctx context.Context, slices map[string]endpointSlice) {
+	addrs := addresses(slices, r.service)
+	if err := r.cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		log.Ctx(ctx).Debug().Err(err).Stringer("service", r.service).Int("addresses", len(addrs)).Msg("dispatch service addresses rejected")
+	}
+}
+
+// addresses returns the addresses of the ready endpoints of the slices at the port of the
+// service, sorted and without duplicates.
+func addresses(slices map[string]endpointSlice, s service) []resolver.Address {
+	seen := map[string]struct{}{}
+	addrs := []resolver.Address{}
+	for _, slice := range slices {
+		port, ok := slice.port(s.port)
+		if !ok {
+			continue
+		}
+
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+
+			for _, ip := range endpoint.Addresses {
+				addr := net.JoinHostPort(ip, strconv.Itoa(int(port)))
+				if _, ok := seen[addr]; ok {
+					continue
+				}
+				seen[addr] = struct{}{}
+				addrs = append(addrs, resolver.Address{Addr: addr, ServerName: s.String()})
+			}
+		}
+	}
+
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Addr < addrs[j].Addr })
+	return addrs
+}
+
+type endpointSliceList struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Items []endpointSlice `json:"items"`
+}
+
+type watchEvent struct {
+	Type   string        `json:"type"`
+	Object endpointSlice `json:"object"`
+}
+
+type endpointSlice struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Endpoints []struct {
+		Addresses  []string `json:"addresses"`
+		Conditions struct {
+			Ready *bool `json:"ready"`
+		} `json:"conditions"`
+	} `json:"endpoints"`
+	Ports []struct {
+		Name *string `json:"name"`
+		Port *int32  `json:"port"`
+	} `json:"ports"`
+}
+
+// port returns the given port if it is a number, otherwise the port of the slice with the given
+// name, or its first port if none is given.
+func (slice endpointSlice) port(port string) (int32, bool) {
+	if number, err := strconv.ParseInt(port, 10, 32); err == nil {
+		return int32(number), true
+	}
+
+	for _, p := range slice.Ports {
+		if p.Port == nil {
+			continue
+		}
+		if port == "" || (p.Name != nil && *p.Name == port) {
+			return *p.Port, true
+		}
+	}
+	return 0, false
+}
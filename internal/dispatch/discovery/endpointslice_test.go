@@ -0,0 +1,142 @@
+package discovery
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sercand/kuberesolver/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/resolver"
+)
+
+type fakeClientConn struct {
+	resolver.ClientConn
+	states chan resolver.State
+}
+
+func (cc *fakeClientConn) UpdateState(state resolver.State) error {
+	cc.states <- state
+	return nil
+}
+
+func (cc *fakeClientConn) ReportError(error) {}
+
+func (cc *fakeClientConn) nextAddresses(t *testing.T) []string {
+	select {
+	case state := <-cc.states:
+		addrs := make([]string, 0, len(state.Addresses))
+		for _, addr := range state.Addresses {
+			addrs = append(addrs, addr.Addr)
+		}
+		return addrs
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for addresses")
+		return nil
+	}
+}
+
+const sliceFormat = `{"metadata":{"name":%q},"ports":[{"name":"dispatch","port":50053}],"endpoints":[%s]}`
+
+func endpoint(ip string, ready bool) string {
+	return fmt.Sprintf(`{"addresses":[%q],"conditions":{"ready":%t}}`, ip, ready)
+}
+
+func TestEndpointSliceResolver(t *testing.T) {
+	require := require.New(t)
+
+	events := make(chan string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal("/apis/discovery.k8s.io/v1/namespaces/spicedb/endpointslices", r.URL.Path)
+		require.Equal("kubernetes.io/service-name=dispatch", r.URL.Query().Get("labelSelector"))
+
+		if r.URL.Query().Get("watch") != "true" {
+			fmt.Fprintf(w, `{"metadata":{"resourceVersion":"1"},"items":[`+sliceFormat+`]}`,
+				"dispatch-a", endpoint("10.0.0.1", true)+","+endpoint("10.0.0.2", false))
+			return
+		}
+
+		require.Equal("1", r.URL.Query().Get("resourceVersion"))
+		for {
+			select {
+			case event := <-events:
+				fmt.Fprintln(w, event)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	cc := &fakeClientConn{states: make(chan resolver.State, 10)}
+	target := resolver.Target{URL: url.URL{Scheme: Scheme, Path: "/dispatch.spicedb:dispatch"}, Endpoint: "dispatch.spicedb:dispatch"}
+	r, err := NewBuilder(kuberesolver.NewInsecureK8sClient(server.URL)).Build(target, cc, resolver.BuildOptions{})
+	require.NoError(err)
+	defer r.Close()
+
+	// Only ready endpoints are resolved.
+	require.Equal([]string{"10.0.0.1:50053"}, cc.nextAddresses(t))
+
+	events <- fmt.Sprintf(`{"type":"MODIFIED","object":`+sliceFormat+`}`,
+		"dispatch-a", endpoint("10.0.0.1", true)+","+endpoint("10.0.0.2", true))
+	require.Equal([]string{"10.0.0.1:50053", "10.0.0.2:50053"}, cc.nextAddresses(t))
+
+	events <- fmt.Sprintf(`{"type":"ADDED","object":`+sliceFormat+`}`, "dispatch-b", endpoint("10.0.0.3", true))
+	require.Equal([]string{"10.0.0.1:50053", "10.0.0.2:50053", "10.0.0.3:50053"}, cc.nextAddresses(t))
+
+	events <- fmt.Sprintf(`{"type":"DELETED","object":`+sliceFormat+`}`, "dispatch-a", "")
+	require.Equal([]string{"10.0.0.3:50053"}, cc.nextAddresses(t))
+}
+
+func TestParseTarget(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint string
+		expected service
+	}{
+		{"dispatch.spicedb:50053", service{name: "dispatch", namespace: "spicedb", port: "50053"}},
+		{"dispatch.spicedb:grpc", service{name: "dispatch", namespace: "spicedb", port: "grpc"}},
+		{"dispatch.spicedb", service{name: "dispatch", namespace: "spicedb"}},
+		{"dispatch", service{name: "dispatch", namespace: currentNamespace()}},
+	} {
+		tc := tc
+		t.Run(tc.endpoint, func(t *testing.T) {
+			parsed, err := parseTarget(resolver.Target{Endpoint: tc.endpoint})
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, parsed)
+		})
+	}
+
+	_, err := parseTarget(resolver.Target{})
+	require.Error(t, err)
+}
+
+func TestSlicePort(t *testing.T) {
+	var slice endpointSlice
+	slice.Ports = append(slice.Ports, struct {
+		Name *string `json:"name"`
+		Port *int32  `json:"port"`
+	}{Name: stringPtr("dispatch"), Port: int32Ptr(50053)})
+
+	for _, tc := range []struct {
+		port     string
+		expected int32
+		ok       bool
+	}{
+		{"", 50053, true},
+		{"dispatch", 50053, true},
+		{"8443", 8443, true},
+		{"grpc", 0, false},
+	} {
+		port, ok := slice.port(tc.port)
+		require.Equal(t, tc.ok, ok, tc.port)
+		require.Equal(t, tc.expected, port, tc.port)
+	}
+}
+
+func stringPtr(s string) *string { return &s }
+
+func int32Ptr(i int32) *int32 { return &i }
@@ -182,6 +182,191 @@ func TestMaxDepth(t *testing.T) {
 	require.Error(err)
 }
 
+func TestDispatchBudget(t *testing.T) {
+	testCases := []struct {
+		budget        uint32
+		expectedError bool
+	}{
+		{0, false},
+		{100, false},
+		{5, true},
+		{1, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("budget %d", tc.budget), func(t *testing.T) {
+			require := require.New(t)
+
+			ctx, dispatcher, revision := newLocalDispatcher(t)
+
+			resp, err := dispatcher.DispatchCheck(ctx, &v1.DispatchCheckRequest{
+				ResourceRelation: RR("document", "view"),
+				ResourceIds:      []string{"masterplan"},
+				ResultsSetting:   v1.DispatchCheckRequest_REQUIRE_ALL_RESULTS,
+				Subject:          ONR("user", "owner", graph.Ellipsis),
+				Metadata: &v1.ResolverMeta{
+					AtRevision:     revision.String(),
+					DepthRemaining: 50,
+					DispatchBudget: tc.budget,
+				},
+			})
+			if !tc.expectedError {
+				require.NoError(err)
+				require.Equal(v1.ResourceCheckResult_MEMBER, resp.ResultsByResourceId["masterplan"].Membership)
+				return
+			}
+
+			var budgetErr dispatch.ErrBudgetExhausted
+			require.ErrorAs(err, &budgetErr)
+			require.Equal(tc.budget, budgetErr.Budget())
+		})
+	}
+}
+
+func TestRecursionDepthLimit(t *testing.T) {
+	schema := `
+		definition user {}
+
+		definition folder {
+			relation parent: folder maxdepth 2
+			relation viewer: user
+			permission view = viewer + parent->view
+		}
+
+		definition group {
+			relation member: user | group#member maxdepth 1
+		}
+	`
+
+	testCases := []struct {
+		name          string
+		relationships []string
+		resource      *core.ObjectAndRelation
+		expectedError string
+	}{
+		{
+			"arrow within limit",
+			[]string{
+				"folder:child#parent@folder:middle",
+				"folder:middle#parent@folder:root",
+				"folder:root#viewer@user:tom",
+			},
+			ONR("folder", "child", "view"),
+			"",
+		},
+		{
+			"arrow exceeding limit",
+			[]string{
+				"folder:child#parent@folder:middle",
+				"folder:middle#parent@folder:upper",
+				"folder:upper#parent@folder:root",
+				"folder:root#viewer@user:tom",
+			},
+			ONR("folder", "child", "view"),
+			"relation `parent` under definition `folder` was traversed more than its maximum depth of 2",
+		},
+		{
+			"userset within limit",
+			[]string{
+				"group:outer#member@group:inner#member",
+				"group:inner#member@user:tom",
+			},
+			ONR("group", "outer", "member"),
+			"",
+		},
+		{
+			"userset exceeding limit",
+			[]string{
+				"group:outer#member@group:middle#member",
+				"group:middle#member@group:inner#member",
+				"group:inner#member@user:tom",
+			},
+			ONR("group", "outer", "member"),
+			"relation `member` under definition `group` was traversed more than its maximum depth of 1",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			require.NoError(t, err)
+
+			relationships := make([]*core.RelationTuple, 0, len(tc.relationships))
+			for _, rel := range tc.relationships {
+				relationships = append(relationships, tuple.MustParse(rel))
+			}
+
+			ds, revision := testfixtures.DatastoreFromSchemaAndTestRelationships(rawDS, schema, relationships, require.New(t))
+
+			ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+			require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+			requireResult := func(t *testing.T, err error) {
+				if tc.expectedError != "" {
+					require.ErrorContains(t, err, tc.expectedError)
+					require.ErrorAs(t, err, &graph.ErrRecursionDepthExceeded{})
+					return
+				}
+
+				require.NoError(t, err)
+			}
+
+			metadata := &v1.ResolverMeta{
+				AtRevision:     revision.String(),
+				DepthRemaining: 50,
+			}
+			dispatcher := NewLocalOnlyDispatcher(10)
+
+			t.Run("check", func(t *testing.T) {
+				resp, err := dispatcher.DispatchCheck(ctx, &v1.DispatchCheckRequest{
+					ResourceRelation: RR(tc.resource.Namespace, tc.resource.Relation),
+					ResourceIds:      []string{tc.resource.ObjectId},
+					ResultsSetting:   v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT,
+					Subject:          ONR("user", "tom", graph.Ellipsis),
+					Metadata:         metadata,
+				})
+				requireResult(t, err)
+				if err == nil {
+					require.Equal(t, v1.ResourceCheckResult_MEMBER, resp.ResultsByResourceId[tc.resource.ObjectId].Membership)
+				}
+			})
+
+			t.Run("expand", func(t *testing.T) {
+				_, err := dispatcher.DispatchExpand(ctx, &v1.DispatchExpandRequest{
+					ResourceAndRelation: tc.resource,
+					Metadata:            metadata,
+					ExpansionMode:       v1.DispatchExpandRequest_RECURSIVE,
+				})
+				requireResult(t, err)
+			})
+
+			t.Run("lookup subjects", func(t *testing.T) {
+				stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupSubjectsResponse](ctx)
+				err := dispatcher.DispatchLookupSubjects(&v1.DispatchLookupSubjectsRequest{
+					ResourceRelation: RR(tc.resource.Namespace, tc.resource.Relation),
+					ResourceIds:      []string{tc.resource.ObjectId},
+					SubjectRelation:  RR("user", graph.Ellipsis),
+					Metadata:         metadata,
+				}, stream)
+				requireResult(t, err)
+			})
+
+			t.Run("reachable resources", func(t *testing.T) {
+				stream := dispatch.NewCollectingDispatchStream[*v1.DispatchReachableResourcesResponse](ctx)
+				err := dispatcher.DispatchReachableResources(&v1.DispatchReachableResourcesRequest{
+					ResourceRelation: RR(tc.resource.Namespace, tc.resource.Relation),
+					SubjectRelation:  RR("user", graph.Ellipsis),
+					SubjectIds:       []string{"tom"},
+					Metadata:         metadata,
+				}, stream)
+				requireResult(t, err)
+			})
+		})
+	}
+}
+
 func TestCheckMetadata(t *testing.T) {
 	type expected struct {
 		relation              string
@@ -24,13 +24,19 @@ var tracer = otel.Tracer("spicedb/internal/dispatch/local")
 
 // NewLocalOnlyDispatcher creates a dispatcher that consults with the graph to formulate a response.
 func NewLocalOnlyDispatcher(concurrencyLimit uint16) dispatch.Dispatcher {
+	return NewLocalOnlyDispatcherWithLimits(graph.SharedConcurrencyLimits(concurrencyLimit))
+}
+
+// NewLocalOnlyDispatcherWithLimits creates a dispatcher that consults with the graph to formulate
+// a response, with the concurrency limits of each operation.
+func NewLocalOnlyDispatcherWithLimits(concurrencyLimits graph.ConcurrencyLimits) dispatch.Dispatcher {
 	d := &localDispatcher{}
 
-	d.checker = graph.NewConcurrentChecker(d, concurrencyLimit)
+	d.checker = graph.NewConcurrentChecker(d, concurrencyLimits)
 	d.expander = graph.NewConcurrentExpander(d)
-	d.lookupHandler = graph.NewConcurrentLookup(d, d, concurrencyLimit)
-	d.reachableResourcesHandler = graph.NewConcurrentReachableResources(d, concurrencyLimit)
-	d.lookupSubjectsHandler = graph.NewConcurrentLookupSubjects(d, concurrencyLimit)
+	d.lookupHandler = graph.NewConcurrentLookup(d, d, concurrencyLimits)
+	d.reachableResourcesHandler = graph.NewConcurrentReachableResources(d, concurrencyLimits)
+	d.lookupSubjectsHandler = graph.NewConcurrentLookupSubjects(d, concurrencyLimits)
 
 	return d
 }
@@ -38,11 +44,17 @@ func NewLocalOnlyDispatcher(concurrencyLimit uint16) dispatch.Dispatcher {
 // NewDispatcher creates a dispatcher that consults with the graph and redispatches subproblems to
 // the provided redispatcher.
 func NewDispatcher(redispatcher dispatch.Dispatcher, concurrencyLimit uint16) dispatch.Dispatcher {
-	checker := graph.NewConcurrentChecker(redispatcher, concurrencyLimit)
+	return NewDispatcherWithLimits(redispatcher, graph.SharedConcurrencyLimits(concurrencyLimit))
+}
+
+// NewDispatcherWithLimits creates a dispatcher that consults with the graph and redispatches
+// subproblems to the provided redispatcher, with the concurrency limits of each operation.
+func NewDispatcherWithLimits(redispatcher dispatch.Dispatcher, concurrencyLimits graph.ConcurrencyLimits) dispatch.Dispatcher {
+	checker := graph.NewConcurrentChecker(redispatcher, concurrencyLimits)
 	expander := graph.NewConcurrentExpander(redispatcher)
-	lookupHandler := graph.NewConcurrentLookup(redispatcher, redispatcher, concurrencyLimit)
-	reachableResourcesHandler := graph.NewConcurrentReachableResources(redispatcher, concurrencyLimit)
-	lookupSubjectsHandler := graph.NewConcurrentLookupSubjects(redispatcher, concurrencyLimit)
+	lookupHandler := graph.NewConcurrentLookup(redispatcher, redispatcher, concurrencyLimits)
+	reachableResourcesHandler := graph.NewConcurrentReachableResources(redispatcher, concurrencyLimits)
+	lookupSubjectsHandler := graph.NewConcurrentLookupSubjects(redispatcher, concurrencyLimits)
 
 	return &localDispatcher{
 		checker:                   checker,
@@ -141,6 +153,11 @@ func (ld *localDispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCh
 		}, err
 	}
 
+	ctx, err := dispatch.SpendBudget(ctx, req)
+	if err != nil {
+		return &v1.DispatchCheckResponse{Metadata: emptyMetadata}, err
+	}
+
 	revision, err := ld.parseRevision(ctx, req.Metadata.AtRevision)
 	if err != nil {
 		return &v1.DispatchCheckResponse{Metadata: emptyMetadata}, err
@@ -181,13 +198,13 @@ func (ld *localDispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCh
 			Revision: revision,
 		}
 
-		return ld.checker.Check(ctx, validatedReq, relation)
+		return ld.checker.Check(ctx, validatedReq, ns, relation)
 	}
 
 	return ld.checker.Check(ctx, graph.ValidatedCheckRequest{
 		DispatchCheckRequest: req,
 		Revision:             revision,
-	}, relation)
+	}, ns, relation)
 }
 
 // DispatchExpand implements dispatch.Expand interface
@@ -201,6 +218,11 @@ func (ld *localDispatcher) DispatchExpand(ctx context.Context, req *v1.DispatchE
 		return &v1.DispatchExpandResponse{Metadata: emptyMetadata}, err
 	}
 
+	ctx, err := dispatch.SpendBudget(ctx, req)
+	if err != nil {
+		return &v1.DispatchExpandResponse{Metadata: emptyMetadata}, err
+	}
+
 	revision, err := ld.parseRevision(ctx, req.Metadata.AtRevision)
 	if err != nil {
 		return &v1.DispatchExpandResponse{Metadata: emptyMetadata}, err
@@ -219,14 +241,17 @@ func (ld *localDispatcher) DispatchExpand(ctx context.Context, req *v1.DispatchE
 	return ld.expander.Expand(ctx, graph.ValidatedExpandRequest{
 		DispatchExpandRequest: req,
 		Revision:              revision,
-	}, relation)
+	}, ns, relation)
 }
 
 // DispatchLookup implements dispatch.Lookup interface
-func (ld *localDispatcher) DispatchLookup(ctx context.Context, req *v1.DispatchLookupRequest) (*v1.DispatchLookupResponse, error) {
+func (ld *localDispatcher) DispatchLookup(
+	req *v1.DispatchLookupRequest,
+	stream dispatch.LookupStream,
+) error {
 	// TODO(jschorr): Since lookup is now calling reachable resources exclusively, we should
 	// probably move it out of the dispatcher and into computed
-	ctx, span := tracer.Start(ctx, "DispatchLookup", trace.WithAttributes(
+	ctx, span := tracer.Start(stream.Context(), "DispatchLookup", trace.WithAttributes(
 		attribute.Stringer("start", stringableRelRef{req.ObjectRelation}),
 		attribute.Stringer("subject", stringableOnr{req.Subject}),
 		attribute.Int64("limit", int64(req.Limit)),
@@ -234,22 +259,30 @@ func (ld *localDispatcher) DispatchLookup(ctx context.Context, req *v1.DispatchL
 	defer span.End()
 
 	if err := dispatch.CheckDepth(ctx, req); err != nil {
-		return &v1.DispatchLookupResponse{Metadata: emptyMetadata}, err
+		return err
+	}
+
+	ctx, err := dispatch.SpendBudget(ctx, req)
+	if err != nil {
+		return err
 	}
 
 	revision, err := ld.parseRevision(ctx, req.Metadata.AtRevision)
 	if err != nil {
-		return &v1.DispatchLookupResponse{Metadata: emptyMetadata}, err
+		return err
 	}
 
 	if req.Limit <= 0 {
-		return &v1.DispatchLookupResponse{Metadata: emptyMetadata, ResolvedResources: []*v1.ResolvedResource{}}, nil
+		return stream.Publish(&v1.DispatchLookupResponse{Metadata: emptyMetadata, ResolvedResources: []*v1.ResolvedResource{}})
 	}
 
-	return ld.lookupHandler.LookupViaReachability(ctx, graph.ValidatedLookupRequest{
-		DispatchLookupRequest: req,
-		Revision:              revision,
-	})
+	return ld.lookupHandler.LookupViaReachability(
+		graph.ValidatedLookupRequest{
+			DispatchLookupRequest: req,
+			Revision:              revision,
+		},
+		dispatch.StreamWithContext(ctx, stream),
+	)
 }
 
 // DispatchReachableResources implements dispatch.ReachableResources interface
@@ -268,6 +301,11 @@ func (ld *localDispatcher) DispatchReachableResources(
 		return err
 	}
 
+	ctx, err := dispatch.SpendBudget(ctx, req)
+	if err != nil {
+		return err
+	}
+
 	revision, err := ld.parseRevision(ctx, req.Metadata.AtRevision)
 	if err != nil {
 		return err
@@ -298,6 +336,11 @@ func (ld *localDispatcher) DispatchLookupSubjects(
 		return err
 	}
 
+	ctx, err := dispatch.SpendBudget(ctx, req)
+	if err != nil {
+		return err
+	}
+
 	revision, err := ld.parseRevision(ctx, req.Metadata.AtRevision)
 	if err != nil {
 		return err
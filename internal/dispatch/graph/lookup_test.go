@@ -11,6 +11,7 @@ import (
 	"go.uber.org/goleak"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	"github.com/authzed/spicedb/internal/testfixtures"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -110,7 +111,7 @@ func TestSimpleLookup(t *testing.T) {
 			require := require.New(t)
 			ctx, dispatch, revision := newLocalDispatcher(t)
 
-			lookupResult, err := dispatch.DispatchLookup(ctx, &v1.DispatchLookupRequest{
+			lookupResult, err := collectLookup(ctx, dispatch, &v1.DispatchLookupRequest{
 				ObjectRelation: tc.start,
 				Subject:        tc.target,
 				Metadata: &v1.ResolverMeta{
@@ -132,7 +133,7 @@ func TestSimpleLookup(t *testing.T) {
 			time.Sleep(10 * time.Millisecond)
 
 			// Run again with the cache available.
-			lookupResult, err = dispatch.DispatchLookup(context.Background(), &v1.DispatchLookupRequest{
+			lookupResult, err = collectLookup(context.Background(), dispatch, &v1.DispatchLookupRequest{
 				ObjectRelation: tc.start,
 				Subject:        tc.target,
 				Metadata: &v1.ResolverMeta{
@@ -164,7 +165,7 @@ func TestMaxDepthLookup(t *testing.T) {
 	ctx := datastoremw.ContextWithHandle(context.Background())
 	require.NoError(datastoremw.SetInContext(ctx, ds))
 
-	_, err = dispatch.DispatchLookup(ctx, &v1.DispatchLookupRequest{
+	_, err = collectLookup(ctx, dispatch, &v1.DispatchLookupRequest{
 		ObjectRelation: RR("document", "view"),
 		Subject:        ONR("user", "legal", "..."),
 		Metadata: &v1.ResolverMeta{
@@ -177,6 +178,89 @@ func TestMaxDepthLookup(t *testing.T) {
 	require.Error(err)
 }
 
+func TestLookupResumesFromCursor(t *testing.T) {
+	defer goleak.VerifyNone(t, goleakIgnores...)
+
+	testCases := []struct {
+		start  *core.RelationReference
+		target *core.ObjectAndRelation
+	}{
+		{RR("document", "view"), ONR("user", "legal", "...")},
+		{RR("folder", "view"), ONR("user", "owner", "...")},
+		{RR("document", "view"), ONR("user", "unknown", "...")},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		name := fmt.Sprintf(
+			"%s#%s->%s",
+			tc.start.Namespace,
+			tc.start.Relation,
+			tuple.StringONR(tc.target),
+		)
+
+		t.Run(name, func(t *testing.T) {
+			ctx, dispatcher, revision := newLocalDispatcher(t)
+
+			lookupFrom := func(cursor *v1.Cursor) []*v1.DispatchLookupResponse {
+				stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupResponse](ctx)
+				require.NoError(t, dispatcher.DispatchLookup(&v1.DispatchLookupRequest{
+					ObjectRelation: tc.start,
+					Subject:        tc.target,
+					Metadata: &v1.ResolverMeta{
+						AtRevision:     revision.String(),
+						DepthRemaining: 50,
+					},
+					Limit:          10,
+					OptionalCursor: cursor,
+				}, stream))
+				return stream.Results()
+			}
+
+			var expected []string
+			responses := lookupFrom(nil)
+			for _, response := range responses {
+				for _, resource := range response.ResolvedResources {
+					expected = append(expected, resource.ResourceId)
+				}
+			}
+
+			// Resume the lookup after each of the responses, ensuring the resources received before
+			// and after resuming are those of the full lookup, each received once.
+			for index := range responses {
+				var found []string
+				cursor := &v1.Cursor{}
+				for _, response := range responses[:index+1] {
+					for _, resource := range response.ResolvedResources {
+						found = append(found, resource.ResourceId)
+					}
+					cursor.ResourceIds = append(cursor.ResourceIds, response.AfterResponseCursor.ResourceIds...)
+				}
+
+				for _, response := range lookupFrom(cursor) {
+					for _, resource := range response.ResolvedResources {
+						found = append(found, resource.ResourceId)
+					}
+				}
+				require.ElementsMatch(t, expected, found)
+			}
+		})
+	}
+}
+
+// collectLookup performs a lookup, collecting all of the chunks streamed into a single response.
+func collectLookup(ctx context.Context, d dispatch.Lookup, req *v1.DispatchLookupRequest) (*v1.DispatchLookupResponse, error) {
+	stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupResponse](ctx)
+	err := d.DispatchLookup(req, stream)
+
+	resp := &v1.DispatchLookupResponse{Metadata: &v1.ResponseMeta{}}
+	for _, result := range stream.Results() {
+		dispatch.AddResponseMetadata(resp.Metadata, result.Metadata)
+		resp.ResolvedResources = append(resp.ResolvedResources, result.ResolvedResources...)
+	}
+	return resp, err
+}
+
 type OrderedResolved []*v1.ResolvedResource
 
 func (a OrderedResolved) Len() int { return len(a) }
@@ -0,0 +1,190 @@
+// Package hotkeys implements the sampling of dispatched subproblems to find the resources and
+// permissions which are dispatched most often, which are the hotspots of a schema and its data
+// worth denormalizing.
+package hotkeys
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// trackedKeysPerWindow is the number of keys of which counts are kept in each window. Once
+	// full, the least dispatched key is replaced by each new key, which overestimates the counts
+	// of keys seen late in the window by at most the count of the key they replaced.
+	trackedKeysPerWindow = 1000
+
+	// reportedKeys is the number of the most dispatched keys reported for each window.
+	reportedKeys = 100
+
+	// exportedKeys is the number of the most dispatched keys exported as metrics, which is kept
+	// low to bound the number of series.
+	exportedKeys = 10
+)
+
+// The APIs by which dispatched keys are broken down.
+const (
+	APICheck          = "check"
+	APIExpand         = "expand"
+	APILookupSubjects = "lookup_subjects"
+)
+
+// Key is a resource and permission which is dispatched.
+type Key struct {
+	API          string `json:"api"`
+	ResourceType string `json:"resourceType"`
+	ResourceID   string `json:"resourceId"`
+	Relation     string `json:"relation"`
+}
+
+// HotKey is a key and the estimated number of times it was dispatched in a window.
+type HotKey struct {
+	Key
+	EstimatedDispatches uint64 `json:"estimatedDispatches"`
+}
+
+// Report holds the most dispatched keys of a window, in descending order of dispatches.
+type Report struct {
+	WindowStart time.Time `json:"windowStart"`
+	WindowEnd   time.Time `json:"windowEnd"`
+	SampleRate  float64   `json:"sampleRate"`
+	Keys        []HotKey  `json:"keys"`
+}
+
+// Tracker samples dispatched keys and reports those which were dispatched most often in each
+// window. A nil Tracker records nothing.
+type Tracker struct {
+	sampleRate float64
+	window     time.Duration
+
+	lock        sync.Mutex
+	windowStart time.Time
+	counts      map[Key]uint64
+	last        *Report
+
+	dispatchesDesc *prometheus.Desc
+}
+
+// NewTracker creates a Tracker which samples the given fraction of dispatched requests and
+// reports the most dispatched keys over each window.
+func NewTracker(sampleRate float64, window time.Duration) *Tracker {
+	return &Tracker{
+		sampleRate:  sampleRate,
+		window:      window,
+		windowStart: time.Now(),
+		counts:      make(map[Key]uint64, trackedKeysPerWindow),
+		dispatchesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName("spicedb", "dispatch", "hot_key_estimated_dispatches"),
+			"estimated number of times each of the most dispatched resources and permissions was dispatched in the last completed window",
+			[]string{"api", "resource_type", "resource_id", "relation"}, nil,
+		),
+	}
+}
+
+// Record samples a dispatched request for the relation of the given resources.
+func (t *Tracker) Record(api, resourceType, relation string, resourceIDs ...string) {
+	if t == nil || rand.Float64() >= t.sampleRate {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.rotate(time.Now())
+	for _, resourceID := range resourceIDs {
+		t.increment(Key{API: api, ResourceType: resourceType, ResourceID: resourceID, Relation: relation})
+	}
+}
+
+// increment counts a dispatch of the key, replacing the least dispatched key if the tracked keys
+// are full. Must be called with the lock held.
+func (t *Tracker) increment(key Key) {
+	if _, ok := t.counts[key]; ok || len(t.counts) < trackedKeysPerWindow {
+		t.counts[key]++
+		return
+	}
+
+	var (
+		minKey   Key
+		minCount uint64
+		found    bool
+	)
+	for tracked, count := range t.counts {
+		if !found || count < minCount {
+			minKey, minCount, found = tracked, count, true
+		}
+	}
+	delete(t.counts, minKey)
+	t.counts[key] = minCount + 1
+}
+
+// rotate completes the current window if it has passed, making its report the last one. If
+// further windows have passed since, in which nothing was sampled, the last of those is reported
+// instead. Must be called with the lock held.
+func (t *Tracker) rotate(now time.Time) {
+	elapsed := now.Sub(t.windowStart) / t.window
+	if elapsed < 1 {
+		return
+	}
+
+	report := &Report{
+		WindowStart: t.windowStart,
+		WindowEnd:   t.windowStart.Add(t.window),
+		SampleRate:  t.sampleRate,
+		Keys:        make([]HotKey, 0, len(t.counts)),
+	}
+	if elapsed == 1 {
+		for key, count := range t.counts {
+			report.Keys = append(report.Keys, HotKey{Key: key, EstimatedDispatches: uint64(float64(count) / t.sampleRate)})
+		}
+		sort.Slice(report.Keys, func(i, j int) bool {
+			return report.Keys[i].EstimatedDispatches > report.Keys[j].EstimatedDispatches
+		})
+		if len(report.Keys) > reportedKeys {
+			report.Keys = report.Keys[:reportedKeys]
+		}
+	} else {
+		report.WindowStart = t.windowStart.Add((elapsed - 1) * t.window)
+		report.WindowEnd = report.WindowStart.Add(t.window)
+	}
+
+	t.last = report
+	t.windowStart = report.WindowEnd
+	t.counts = make(map[Key]uint64, trackedKeysPerWindow)
+}
+
+// LastReport returns the report of the last completed window, or nil if no window has completed.
+func (t *Tracker) LastReport() *Report {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.rotate(time.Now())
+	return t.last
+}
+
+// Describe implements prometheus.Collector.
+func (t *Tracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- t.dispatchesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (t *Tracker) Collect(ch chan<- prometheus.Metric) {
+	report := t.LastReport()
+	if report == nil {
+		return
+	}
+
+	for i, hotKey := range report.Keys {
+		if i == exportedKeys {
+			break
+		}
+		ch <- prometheus.MustNewConstMetric(t.dispatchesDesc, prometheus.GaugeValue, float64(hotKey.EstimatedDispatches),
+			hotKey.API, hotKey.ResourceType, hotKey.ResourceID, hotKey.Relation)
+	}
+}
+
+var _ prometheus.Collector = (*Tracker)(nil)
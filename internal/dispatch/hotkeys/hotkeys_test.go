@@ -0,0 +1,76 @@
+package hotkeys
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackerReportsMostDispatchedKeys(t *testing.T) {
+	tracker := NewTracker(1, time.Minute)
+	require.Nil(t, tracker.LastReport())
+
+	for i := 0; i < 5; i++ {
+		tracker.Record(APICheck, "document", "view", "hot")
+	}
+	tracker.Record(APICheck, "document", "view", "warm", "hot")
+	tracker.Record(APICheck, "document", "view", "warm")
+	tracker.Record(APIExpand, "document", "view", "cold")
+
+	tracker.lock.Lock()
+	start := tracker.windowStart
+	tracker.rotate(start.Add(time.Minute))
+	tracker.lock.Unlock()
+
+	report := tracker.LastReport()
+	require.NotNil(t, report)
+	require.Equal(t, start, report.WindowStart)
+	require.Equal(t, start.Add(time.Minute), report.WindowEnd)
+	require.Equal(t, []HotKey{
+		{Key{APICheck, "document", "hot", "view"}, 6},
+		{Key{APICheck, "document", "warm", "view"}, 2},
+		{Key{APIExpand, "document", "cold", "view"}, 1},
+	}, report.Keys)
+}
+
+func TestTrackerReportsEmptyWindows(t *testing.T) {
+	tracker := NewTracker(1, time.Minute)
+	tracker.Record(APICheck, "document", "view", "hot")
+
+	tracker.lock.Lock()
+	start := tracker.windowStart
+	tracker.rotate(start.Add(3*time.Minute + time.Second))
+	tracker.lock.Unlock()
+
+	report := tracker.LastReport()
+	require.Equal(t, start.Add(2*time.Minute), report.WindowStart)
+	require.Empty(t, report.Keys)
+}
+
+func TestTrackerReplacesLeastDispatchedKey(t *testing.T) {
+	tracker := NewTracker(1, time.Minute)
+	tracker.Record(APICheck, "document", "view", "hot")
+	tracker.Record(APICheck, "document", "view", "hot")
+	for i := 0; i < trackedKeysPerWindow; i++ {
+		tracker.Record(APICheck, "document", "view", fmt.Sprintf("cold-%d", i))
+	}
+
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	require.Len(t, tracker.counts, trackedKeysPerWindow)
+	require.Equal(t, uint64(2), tracker.counts[Key{APICheck, "document", "hot", "view"}])
+}
+
+func TestTrackerSampling(t *testing.T) {
+	tracker := NewTracker(0, time.Minute)
+	tracker.Record(APICheck, "document", "view", "hot")
+
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	require.Empty(t, tracker.counts)
+
+	var nilTracker *Tracker
+	nilTracker.Record(APICheck, "document", "view", "hot")
+}
@@ -3,6 +3,8 @@ package keys
 import (
 	"fmt"
 
+	"google.golang.org/protobuf/types/known/structpb"
+
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 	"github.com/authzed/spicedb/pkg/tuple"
 )
@@ -19,6 +21,8 @@ const (
 	expandPrefix             cachePrefix = "e"
 	reachableResourcesPrefix cachePrefix = "rr"
 	lookupSubjectsPrefix     cachePrefix = "ls"
+
+	checkWithCaveatContextPrefix cachePrefix = "cx"
 )
 
 var cachePrefixes = []cachePrefix{
@@ -28,6 +32,7 @@ var cachePrefixes = []cachePrefix{
 	expandPrefix,
 	reachableResourcesPrefix,
 	lookupSubjectsPrefix,
+	checkWithCaveatContextPrefix,
 }
 
 // checkRequestToKey converts a check request into a cache key based on the relation
@@ -57,13 +62,29 @@ func checkRequestToKeyWithCanonical(req *v1.DispatchCheckRequest, canonicalKey s
 	)
 }
 
+// CheckWithCaveatContextKey returns the cache key of the results of a check computed with the
+// given caveat context, from the cache key of the check. The context is hashed as the context of
+// lookups is, so contexts with the same fields and values have the same key, whatever the order
+// of their fields and the Go types of their numbers.
+func CheckWithCaveatContextKey(checkKey DispatchCacheKey, caveatContext *structpb.Struct) DispatchCacheKey {
+	return dispatchCacheKeyHash(checkWithCaveatContextPrefix, "", computeBothHashes,
+		hashableDispatchCacheKey(checkKey),
+		hashableContext{caveatContext},
+	)
+}
+
 // lookupRequestToKey converts a lookup request into a cache key
 func lookupRequestToKey(req *v1.DispatchLookupRequest, option dispatchCacheKeyHashComputeOption) DispatchCacheKey {
-	return dispatchCacheKeyHash(lookupPrefix, req.Metadata.AtRevision, option,
+	values := []hashableValue{
 		hashableRelationReference{req.ObjectRelation},
 		hashableOnr{req.Subject},
 		hashableContext{req.Context}, // NOTE: context is included here because lookup does a single dispatch
-	)
+	}
+	if len(req.OptionalCursor.GetResourceIds()) > 0 {
+		values = append(values, hashableCursor{req.OptionalCursor})
+	}
+
+	return dispatchCacheKeyHash(lookupPrefix, req.Metadata.AtRevision, option, values...)
 }
 
 // expandRequestToKey converts an expand request into a cache key
@@ -171,7 +171,7 @@ func TestStableCacheKeys(t *testing.T) {
 					}(),
 				}, computeBothHashes)
 			},
-			"8a9bd5bba3ba9cde9301",
+			"d1dbd9f5ffc2adb28e01",
 		},
 		{
 			"lookup resources with different context",
@@ -192,7 +192,7 @@ func TestStableCacheKeys(t *testing.T) {
 					}(),
 				}, computeBothHashes)
 			},
-			"f6db868dc194c19ade01",
+			"b0adf1fdf0adf9819a01",
 		},
 		{
 			"lookup resources with escaped string",
@@ -236,7 +236,7 @@ func TestStableCacheKeys(t *testing.T) {
 					}(),
 				}, computeBothHashes)
 			},
-			"e0d8d0e099d68b96fa01",
+			"89888cbb81af80ea0c",
 		},
 		{
 			"reachable resources",
@@ -329,6 +329,31 @@ var generatorFuncs = map[string]generatorFunc{
 			}, resourceIds...)
 	},
 
+	// Check with caveat context.
+	string(checkWithCaveatContextPrefix): func(
+		resourceIds []string,
+		subjectIds []string,
+		resourceRelation *core.RelationReference,
+		subjectRelation *core.RelationReference,
+		metadata *v1.ResolverMeta,
+	) (DispatchCacheKey, []string) {
+		checkKey := checkRequestToKey(&v1.DispatchCheckRequest{
+			ResourceRelation: resourceRelation,
+			ResourceIds:      resourceIds,
+			Subject:          ONR(subjectRelation.Namespace, subjectIds[0], subjectRelation.Relation),
+			Metadata:         metadata,
+		}, computeBothHashes)
+		caveatContext, _ := structpb.NewStruct(map[string]any{"subjects": len(subjectIds)})
+		return CheckWithCaveatContextKey(checkKey, caveatContext), append([]string{
+			resourceRelation.Namespace,
+			resourceRelation.Relation,
+			subjectRelation.Namespace,
+			subjectIds[0],
+			subjectRelation.Relation,
+			fmt.Sprint(len(subjectIds)),
+		}, resourceIds...)
+	},
+
 	// Lookup Resources.
 	string(lookupPrefix): func(
 		resourceIds []string,
@@ -521,5 +546,67 @@ func TestComputeContextHash(t *testing.T) {
 		}(),
 	}, computeBothHashes)
 
-	require.Equal(t, "82b4a3a3c5e3ecf1df01", hex.EncodeToString(result.StableSumAsBytes()))
+	require.Equal(t, "88dfaeb095a4fda09601", hex.EncodeToString(result.StableSumAsBytes()))
+}
+
+func TestCheckWithCaveatContextKey(t *testing.T) {
+	checkKey := checkRequestToKey(&v1.DispatchCheckRequest{
+		ResourceRelation: RR("document", "view"),
+		ResourceIds:      []string{"foo"},
+		Subject:          ONR("user", "tom", "..."),
+		Metadata: &v1.ResolverMeta{
+			AtRevision: "1234",
+		},
+	}, computeBothHashes)
+
+	keyFor := func(caveatContext map[string]any) DispatchCacheKey {
+		s, err := structpb.NewStruct(caveatContext)
+		require.NoError(t, err)
+		return CheckWithCaveatContextKey(checkKey, s)
+	}
+
+	key := keyFor(map[string]any{"ip": "10.0.0.1", "count": 1, "nested": map[string]any{"a": true, "b": "hi"}})
+	require.Equal(t, key, keyFor(map[string]any{"nested": map[string]any{"b": "hi", "a": true}, "count": 1.0, "ip": "10.0.0.1"}))
+	require.NotEqual(t, key, keyFor(map[string]any{"ip": "10.0.0.1", "count": 1.0000001, "nested": map[string]any{"a": true, "b": "hi"}}))
+	require.NotEqual(t, key, keyFor(map[string]any{"ip": "10.0.0.2", "count": 1, "nested": map[string]any{"a": true, "b": "hi"}}))
+	require.NotEqual(t, checkKey, key)
+}
+
+func TestRecursionDepthsKey(t *testing.T) {
+	keyFor := func(recursionDepths map[string]uint32) DispatchCacheKey {
+		return expandRequestToKey(&v1.DispatchExpandRequest{
+			ResourceAndRelation: ONR("folder", "root", "view"),
+			Metadata: &v1.ResolverMeta{
+				AtRevision:      "1234",
+				RecursionDepths: recursionDepths,
+			},
+		}, computeBothHashes)
+	}
+
+	// Recursion depths are checked against those of the cached result when it is found, rather
+	// than being part of the key.
+	key := keyFor(map[string]uint32{"folder#parent": 2, "group#member": 5})
+	require.Equal(t, key, keyFor(map[string]uint32{"folder#parent": 1, "group#member": 5}))
+	require.Equal(t, key, keyFor(map[string]uint32{"folder#parent": 2}))
+	require.Equal(t, key, keyFor(nil))
+}
+
+func TestLookupCursorKey(t *testing.T) {
+	keyFor := func(cursor *v1.Cursor) DispatchCacheKey {
+		return lookupRequestToKey(&v1.DispatchLookupRequest{
+			ObjectRelation: RR("document", "view"),
+			Subject:        ONR("user", "mariah", "..."),
+			Limit:          10,
+			Metadata: &v1.ResolverMeta{
+				AtRevision: "1234",
+			},
+			OptionalCursor: cursor,
+		}, computeBothHashes)
+	}
+
+	key := keyFor(&v1.Cursor{ResourceIds: []string{"doc1", "doc2"}})
+	require.Equal(t, key, keyFor(&v1.Cursor{ResourceIds: []string{"doc2", "doc1"}}))
+	require.NotEqual(t, key, keyFor(&v1.Cursor{ResourceIds: []string{"doc1"}}))
+	require.NotEqual(t, key, keyFor(nil))
+	require.Equal(t, keyFor(nil), keyFor(&v1.Cursor{}))
 }
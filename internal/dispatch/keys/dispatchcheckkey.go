@@ -26,3 +26,24 @@ func (dck DispatchCacheKey) AsUInt64s() (uint64, uint64) {
 }
 
 var emptyDispatchCacheKey = DispatchCacheKey{0, 0}
+
+// WithGeneration returns the key for the given generation of the data on which the operation
+// depends, such that the keys for different generations of the same operation differ. The zero
+// generation returns the key unchanged.
+func (dck DispatchCacheKey) WithGeneration(generation uint64) DispatchCacheKey {
+	if generation == 0 {
+		return dck
+	}
+
+	// Mix the generation with the SplitMix64 finalizer, so that consecutive generations change
+	// many bits of the key.
+	mixed := generation + 0x9e3779b97f4a7c15
+	mixed = (mixed ^ (mixed >> 30)) * 0xbf58476d1ce4e5b9
+	mixed = (mixed ^ (mixed >> 27)) * 0x94d049bb133111eb
+	mixed ^= mixed >> 31
+
+	return DispatchCacheKey{
+		stableSum:          dck.stableSum ^ mixed,
+		processSpecificSum: dck.processSpecificSum ^ mixed,
+	}
+}
@@ -71,6 +71,14 @@ func (hs hashableString) AppendToHash(hasher hasherInterface) {
 	hasher.WriteString(string(hs))
 }
 
+type hashableDispatchCacheKey DispatchCacheKey
+
+func (hdk hashableDispatchCacheKey) AppendToHash(hasher hasherInterface) {
+	hasher.WriteString(strconv.FormatUint(hdk.stableSum, 16))
+	hasher.WriteString(":")
+	hasher.WriteString(strconv.FormatUint(hdk.processSpecificSum, 16))
+}
+
 type hashableContext struct{ *structpb.Struct }
 
 func (hc hashableContext) AppendToHash(hasher hasherInterface) {
@@ -102,16 +110,20 @@ func (hsv hashableStructValue) AppendToHash(hasher hasherInterface) {
 		hasher.WriteString(strconv.FormatBool(t.BoolValue))
 
 	case *structpb.Value_ListValue:
+		hasher.WriteString("[")
 		for _, value := range t.ListValue.Values {
 			hashableStructValue{value}.AppendToHash(hasher)
 			hasher.WriteString(",")
 		}
+		hasher.WriteString("]")
 
 	case *structpb.Value_NullValue:
 		hasher.WriteString("null")
 
 	case *structpb.Value_NumberValue:
-		hasher.WriteString(fmt.Sprintf("%f", t.NumberValue))
+		// NOTE: the shortest exact representation is used, so that numbers which differ only
+		// beyond a fixed precision do not overlap.
+		hasher.WriteString(strconv.FormatFloat(t.NumberValue, 'g', -1, 64))
 
 	case *structpb.Value_StringValue:
 		// NOTE: we escape the string value here to prevent accidental overlap in keys for string
@@ -129,3 +141,12 @@ func (hsv hashableStructValue) AppendToHash(hasher hasherInterface) {
 		panic(fmt.Sprintf("unknown struct value type: %T", t))
 	}
 }
+
+// hashableCursor hashes the cursor of a lookup, as a lookup resumed from a cursor skips the
+// resources in it.
+type hashableCursor struct{ *v1.Cursor }
+
+func (hc hashableCursor) AppendToHash(hasher hasherInterface) {
+	hasher.WriteString("cursor:")
+	hashableIds(hc.ResourceIds).AppendToHash(hasher)
+}
@@ -0,0 +1,15 @@
+package dispatch
+
+// Middleware wraps a Dispatcher to add behavior around the requests it dispatches, such as
+// logging, caching or enforcing tenancy. A middleware which only handles some kinds of requests
+// can embed the Dispatcher it wraps, to pass the others through along with Close and IsReady.
+type Middleware func(Dispatcher) Dispatcher
+
+// Chain wraps the dispatcher with the middlewares. The first middleware is the outermost, so it
+// is the first to see each request.
+func Chain(dispatcher Dispatcher, middlewares ...Middleware) Dispatcher {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		dispatcher = middlewares[i](dispatcher)
+	}
+	return dispatcher
+}
@@ -0,0 +1,42 @@
+package dispatch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+type recordingDispatcher struct {
+	Dispatcher
+	name  string
+	calls *[]string
+}
+
+func (rd recordingDispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
+	*rd.calls = append(*rd.calls, rd.name)
+	if rd.Dispatcher == nil {
+		return &v1.DispatchCheckResponse{}, nil
+	}
+	return rd.Dispatcher.DispatchCheck(ctx, req)
+}
+
+func recording(name string, calls *[]string) Middleware {
+	return func(next Dispatcher) Dispatcher {
+		return recordingDispatcher{Dispatcher: next, name: name, calls: calls}
+	}
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	base := recordingDispatcher{name: "base", calls: &calls}
+
+	require.Equal(t, Dispatcher(base), Chain(base))
+
+	chained := Chain(base, recording("first", &calls), recording("second", &calls))
+	_, err := chained.DispatchCheck(context.Background(), &v1.DispatchCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"first", "second", "base"}, calls)
+}
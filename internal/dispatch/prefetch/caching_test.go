@@ -0,0 +1,108 @@
+package prefetch_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/dispatch/caching"
+	"github.com/authzed/spicedb/internal/dispatch/hotkeys"
+	"github.com/authzed/spicedb/internal/dispatch/prefetch"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// countingDelegate answers every check with membership, recording the revisions it was
+// computed at.
+type countingDelegate struct {
+	dispatch.Dispatcher
+
+	lock      sync.Mutex
+	revisions []string
+}
+
+func (cd *countingDelegate) DispatchCheck(_ context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
+	cd.lock.Lock()
+	defer cd.lock.Unlock()
+	cd.revisions = append(cd.revisions, req.Metadata.AtRevision)
+
+	return &v1.DispatchCheckResponse{
+		ResultsByResourceId: map[string]*v1.ResourceCheckResult{
+			req.ResourceIds[0]: {Membership: v1.ResourceCheckResult_MEMBER},
+		},
+		Metadata: &v1.ResponseMeta{DispatchCount: 1, DepthRequired: 1},
+	}, nil
+}
+
+func (cd *countingDelegate) computed() []string {
+	cd.lock.Lock()
+	defer cd.lock.Unlock()
+	return append([]string(nil), cd.revisions...)
+}
+
+func (cd *countingDelegate) Close() error { return nil }
+
+func checkAt(rev string) *v1.DispatchCheckRequest {
+	return &v1.DispatchCheckRequest{
+		ResourceRelation: tuple.RelationReference("document", "view"),
+		ResourceIds:      []string{"hot"},
+		Subject:          tuple.ParseSubjectONR("user:tom#..."),
+		Metadata:         &v1.ResolverMeta{AtRevision: rev, DepthRemaining: 50},
+	}
+}
+
+func TestPrefetchedCheckIsCachedAfterRollover(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds, err := memdb.NewMemdbDatastore(0, 500*time.Millisecond, memdb.DisableGC)
+	require.NoError(err)
+
+	start := time.Now()
+	tracker := hotkeys.NewTracker(1, 50*time.Millisecond)
+	p := prefetch.NewPrefetcher(tracker, ds, 500*time.Millisecond, 100*time.Millisecond)
+
+	delegate := &countingDelegate{}
+	cache := caching.DispatchTestCache(t)
+	dispatcher, err := caching.NewCachingDispatcher(cache, "", nil, caching.Prefetcher(p))
+	require.NoError(err)
+	dispatcher.SetDelegate(delegate)
+	defer dispatcher.Close()
+	p.SetDispatcher(dispatcher)
+
+	// Report the key hot once the window in which it was recorded completes.
+	tracker.Record(hotkeys.APICheck, "document", "view", "hot")
+	time.Sleep(time.Until(start.Add(75 * time.Millisecond)))
+	p.Prefetch(ctx)
+
+	current, err := ds.OptimizedRevision(ctx)
+	require.NoError(err)
+	_, err = dispatcher.DispatchCheck(ctx, checkAt(current.String()))
+	require.NoError(err)
+
+	p.Prefetch(ctx)
+	computed := delegate.computed()
+	require.Len(computed, 2)
+	prefetchedAt := computed[1]
+	cache.Wait()
+
+	// Wait for the window of the prefetched revision to start.
+	prefetched, err := revision.DecimalDecoder{}.RevisionFromString(prefetchedAt)
+	require.NoError(err)
+	time.Sleep(time.Until(time.Unix(0, prefetched.(revision.Decimal).IntPart())))
+
+	next, err := ds.OptimizedRevision(ctx)
+	require.NoError(err)
+	require.Equal(prefetchedAt, next.String(), "the check was not prefetched at the revision selected after the rollover")
+
+	resp, err := dispatcher.DispatchCheck(ctx, checkAt(next.String()))
+	require.NoError(err)
+	require.Equal(v1.ResourceCheckResult_MEMBER, resp.ResultsByResourceId["hot"].Membership)
+	require.Len(delegate.computed(), 2, "the check after the rollover was not served from the cache")
+}
@@ -0,0 +1,8 @@
+package prefetch
+
+import "context"
+
+// Prefetch recomputes the kept checks immediately, rather than before the next rollover.
+func (p *Prefetcher) Prefetch(ctx context.Context) {
+	p.prefetch(ctx)
+}
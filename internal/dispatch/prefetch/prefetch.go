@@ -0,0 +1,201 @@
+// Package prefetch implements the recomputation of the checks of the most dispatched subproblems
+// just before the revision quantization window rolls over, so that their results are already
+// cached at the revision of the next window rather than all being computed once it starts.
+package prefetch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/dispatch/hotkeys"
+	log "github.com/authzed/spicedb/internal/logging"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/pkg/datastore"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+// prefetchConcurrency is the number of checks recomputed concurrently.
+const prefetchConcurrency = 10
+
+var prefetchedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "spicedb",
+	Subsystem: "dispatch",
+	Name:      "prefetched_checks_total",
+	Help:      "number of checks of the most dispatched subproblems recomputed before the revision quantization window rolled over",
+}, []string{"result"})
+
+// Prefetcher recomputes the checks of the subproblems reported as the most dispatched by a
+// hot key tracker shortly before each revision quantization window rolls over, at the revision
+// which the next window will select. A nil Prefetcher observes nothing.
+type Prefetcher struct {
+	tracker      *hotkeys.Tracker
+	ds           datastore.Datastore
+	quantization time.Duration
+	lead         time.Duration
+	dispatcher   dispatch.Check
+
+	lock sync.RWMutex
+	// hot holds the checked keys reported as the most dispatched in the last window.
+	hot map[hotkeys.Key]struct{}
+	// requests holds a check of each hot key observed since the last prefetch.
+	requests map[hotkeys.Key]*v1.DispatchCheckRequest
+}
+
+// NewPrefetcher creates a Prefetcher of the keys reported by the tracker, which recomputes them
+// the given lead time before each window of the given revision quantization rolls over.
+func NewPrefetcher(tracker *hotkeys.Tracker, ds datastore.Datastore, quantization, lead time.Duration) *Prefetcher {
+	return &Prefetcher{
+		tracker:      tracker,
+		ds:           ds,
+		quantization: quantization,
+		lead:         lead,
+		hot:          map[hotkeys.Key]struct{}{},
+		requests:     map[hotkeys.Key]*v1.DispatchCheckRequest{},
+	}
+}
+
+// SetDispatcher sets the dispatcher with which checks are recomputed, which must cache their
+// results. It must be called before Run.
+func (p *Prefetcher) SetDispatcher(dispatcher dispatch.Check) {
+	p.dispatcher = dispatcher
+}
+
+// Observe keeps the check to be recomputed at the next prefetch if it is of a hot key and no
+// other check of the key was kept since the last prefetch.
+func (p *Prefetcher) Observe(req *v1.DispatchCheckRequest) {
+	if p == nil || req.Debug == v1.DispatchCheckRequest_ENABLE_DEBUGGING {
+		return
+	}
+
+	for _, resourceID := range req.ResourceIds {
+		key := hotkeys.Key{
+			API:          hotkeys.APICheck,
+			ResourceType: req.ResourceRelation.Namespace,
+			ResourceID:   resourceID,
+			Relation:     req.ResourceRelation.Relation,
+		}
+
+		p.lock.RLock()
+		_, hot := p.hot[key]
+		_, kept := p.requests[key]
+		p.lock.RUnlock()
+		if !hot || kept {
+			continue
+		}
+
+		p.lock.Lock()
+		if _, kept := p.requests[key]; !kept {
+			p.requests[key] = req.CloneVT()
+		}
+		p.lock.Unlock()
+		return
+	}
+}
+
+// Run recomputes the kept checks before each revision quantization window rolls over, until
+// the context is canceled.
+func (p *Prefetcher) Run(ctx context.Context) error {
+	for {
+		timer := time.NewTimer(p.untilNextPrefetch(time.Now()))
+		select {
+		case <-timer.C:
+			p.prefetch(ctx)
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		}
+	}
+}
+
+// untilNextPrefetch returns the time until the lead time before the next rollover of the
+// revision quantization window.
+func (p *Prefetcher) untilNextPrefetch(now time.Time) time.Duration {
+	next := now.Truncate(p.quantization).Add(p.quantization - p.lead)
+	for !next.After(now) {
+		next = next.Add(p.quantization)
+	}
+	return next.Sub(now)
+}
+
+// prefetch recomputes the checks kept since the last prefetch at the revision which the next
+// window will select, then refreshes the hot keys from the last report of the tracker.
+func (p *Prefetcher) prefetch(ctx context.Context) {
+	p.lock.Lock()
+	requests := p.requests
+	p.requests = make(map[hotkeys.Key]*v1.DispatchCheckRequest, len(requests))
+	p.hot = hotChecks(p.tracker.LastReport())
+	p.lock.Unlock()
+
+	if len(requests) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.quantization)
+	defer cancel()
+
+	revision, err := p.nextRevision(ctx)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("error finding the revision at which to prefetch checks")
+		return
+	}
+	ctx = datastoremw.ContextWithDatastore(ctx, p.ds)
+
+	// A check of several hot keys is kept for each of them, but only recomputed once.
+	unique := make(map[*v1.DispatchCheckRequest]struct{}, len(requests))
+	for _, req := range requests {
+		unique[req] = struct{}{}
+	}
+
+	g := errgroup.Group{}
+	g.SetLimit(prefetchConcurrency)
+	for req := range unique {
+		req := req
+		req.Metadata.AtRevision = revision.String()
+		req.Metadata.DispatchBudget = 0
+		req.Metadata.BudgetRootDepth = 0
+		req.Metadata.TimeBudget = nil
+
+		g.Go(func() error {
+			if _, err := p.dispatcher.DispatchCheck(ctx, req); err != nil {
+				prefetchedCounter.WithLabelValues("error").Inc()
+				log.Ctx(ctx).Debug().Err(err).Object("request", req).Msg("error prefetching check")
+				return nil
+			}
+			prefetchedCounter.WithLabelValues("success").Inc()
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+// nextRevision returns the revision which the next revision quantization window will select.
+// That is the start of the next window for datastores whose revisions are times, and otherwise
+// the head revision, which datastores whose revisions are transactions select for the next
+// window unless they are written to before it starts.
+func (p *Prefetcher) nextRevision(ctx context.Context) (datastore.Revision, error) {
+	if quantized, ok := datastore.UnwrapAs[datastore.QuantizedClockDatastore](p.ds); ok {
+		return quantized.NextOptimizedRevision(ctx)
+	}
+	return p.ds.HeadRevision(ctx)
+}
+
+// hotChecks returns the checked keys of the report.
+func hotChecks(report *hotkeys.Report) map[hotkeys.Key]struct{} {
+	hot := map[hotkeys.Key]struct{}{}
+	if report == nil {
+		return hot
+	}
+
+	for _, hotKey := range report.Keys {
+		if hotKey.API == hotkeys.APICheck {
+			hot[hotKey.Key] = struct{}{}
+		}
+	}
+	return hot
+}
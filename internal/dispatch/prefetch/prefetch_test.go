@@ -0,0 +1,103 @@
+package prefetch
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch/hotkeys"
+	"github.com/authzed/spicedb/pkg/datastore/revision"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+type recordingChecker struct {
+	lock     sync.Mutex
+	requests []*v1.DispatchCheckRequest
+}
+
+func (rc *recordingChecker) DispatchCheck(_ context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	rc.requests = append(rc.requests, req)
+	return &v1.DispatchCheckResponse{Metadata: &v1.ResponseMeta{}}, nil
+}
+
+func checkRequest(resourceID string) *v1.DispatchCheckRequest {
+	return &v1.DispatchCheckRequest{
+		ResourceRelation: tuple.RelationReference("document", "view"),
+		ResourceIds:      []string{resourceID},
+		Subject:          tuple.ParseSubjectONR("user:tom#..."),
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     "1",
+			DepthRemaining: 50,
+			DispatchBudget: 10,
+		},
+	}
+}
+
+func TestUntilNextPrefetch(t *testing.T) {
+	p := NewPrefetcher(nil, nil, 5*time.Second, time.Second)
+	base := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.Equal(t, 4*time.Second, p.untilNextPrefetch(base))
+	require.Equal(t, 2*time.Second, p.untilNextPrefetch(base.Add(2*time.Second)))
+	require.Equal(t, 5*time.Second, p.untilNextPrefetch(base.Add(4*time.Second)))
+	require.Equal(t, 4500*time.Millisecond, p.untilNextPrefetch(base.Add(4500*time.Millisecond)))
+}
+
+func TestPrefetch(t *testing.T) {
+	require := require.New(t)
+
+	ds, err := memdb.NewMemdbDatastore(0, time.Second, memdb.DisableGC)
+	require.NoError(err)
+	current, err := ds.OptimizedRevision(context.Background())
+	require.NoError(err)
+
+	start := time.Now()
+	tracker := hotkeys.NewTracker(1, 50*time.Millisecond)
+	checker := &recordingChecker{}
+	p := NewPrefetcher(tracker, ds, time.Second, 100*time.Millisecond)
+	p.SetDispatcher(checker)
+
+	// Nothing is kept before any key is reported hot.
+	p.Observe(checkRequest("hot"))
+	p.prefetch(context.Background())
+	require.Empty(checker.requests)
+
+	// Report the key hot once the window in which it was recorded completes.
+	tracker.Record(hotkeys.APICheck, "document", "view", "hot")
+	time.Sleep(time.Until(start.Add(75 * time.Millisecond)))
+	p.prefetch(context.Background())
+
+	p.Observe(checkRequest("hot"))
+	p.Observe(checkRequest("hot"))
+	p.Observe(checkRequest("cold"))
+	p.prefetch(context.Background())
+
+	require.Len(checker.requests, 1)
+	prefetched := checker.requests[0]
+	require.Equal([]string{"hot"}, prefetched.ResourceIds)
+	// The check is prefetched at the revision of the next window, which starts a second after the
+	// current one, or two if the current window rolled over during the test.
+	require.Contains([]string{
+		current.(revision.Decimal).Add(decimal.NewFromInt(time.Second.Nanoseconds())).String(),
+		current.(revision.Decimal).Add(decimal.NewFromInt(2 * time.Second.Nanoseconds())).String(),
+	}, prefetched.Metadata.AtRevision)
+	require.Equal(uint32(0), prefetched.Metadata.DispatchBudget)
+	require.Equal(uint32(50), prefetched.Metadata.DepthRemaining)
+
+	// Kept checks are only prefetched once.
+	p.prefetch(context.Background())
+	require.Len(checker.requests, 1)
+}
+
+func TestNilPrefetcher(t *testing.T) {
+	var p *Prefetcher
+	p.Observe(checkRequest("hot"))
+}
@@ -0,0 +1,32 @@
+package dispatch
+
+import (
+	"context"
+
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+type priorityKey struct{}
+
+// ContextWithPriority returns a context carrying the priority of the request being served, which
+// is given to the requests dispatched to other nodes on its behalf.
+func ContextWithPriority(ctx context.Context, priority v1.ResolverMeta_Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext returns the priority carried by the context, or UNSPECIFIED_PRIORITY if
+// there is none.
+func PriorityFromContext(ctx context.Context) v1.ResolverMeta_Priority {
+	priority, _ := ctx.Value(priorityKey{}).(v1.ResolverMeta_Priority)
+	return priority
+}
+
+// SetPriority sets the priority of a request about to be dispatched to another node to the
+// priority carried by the context, unless the request already has a priority.
+func SetPriority(ctx context.Context, req HasMetadata) {
+	metadata := req.GetMetadata()
+	if metadata == nil || metadata.Priority != v1.ResolverMeta_UNSPECIFIED_PRIORITY {
+		return
+	}
+	metadata.Priority = PriorityFromContext(ctx)
+}
@@ -0,0 +1,235 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/pkg/balancer"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+var checkBatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+	Namespace: "spicedb",
+	Subsystem: "dispatch",
+	Name:      "check_batch_size",
+	Help:      "number of checks sent to a peer in each batched dispatch",
+	Buckets:   []float64{1, 2, 5, 10, 25, 50, 100},
+})
+
+// maxCheckBatchSize is the maximum number of checks sent to a peer in a single batch.
+const maxCheckBatchSize = 100
+
+// errCheckBatchingUnsupported is returned for checks which could not be batched because the peer
+// does not support batches, and must be dispatched on their own.
+var errCheckBatchingUnsupported = errors.New("peer does not support batched checks")
+
+type batchClient interface {
+	DispatchCheckBatch(ctx context.Context, in *v1.DispatchCheckBatchRequest, opts ...grpc.CallOption) (v1.DispatchService_DispatchCheckBatchClient, error)
+}
+
+// checkBatcher coalesces the checks dispatched to the same peer within a window into a single
+// call, so that wide fan outs, such as the checks of the members of a large group, do not pay the
+// overhead of a call per subproblem.
+type checkBatcher struct {
+	client batchClient
+	window time.Duration
+
+	// unsupported is set once a peer has been found not to support batches, after which checks
+	// are no longer batched.
+	unsupported atomic.Bool
+
+	lock    sync.Mutex
+	pending map[string]*checkBatch
+}
+
+type checkBatch struct {
+	member string
+	checks []*batchedCheck
+}
+
+type batchedCheck struct {
+	ctx        context.Context
+	requestKey []byte
+	req        *v1.DispatchCheckRequest
+	done       chan batchedCheckResult
+}
+
+type batchedCheckResult struct {
+	resp *v1.DispatchCheckResponse
+	err  error
+}
+
+func newCheckBatcher(client batchClient, window time.Duration) *checkBatcher {
+	return &checkBatcher{
+		client:  client,
+		window:  window,
+		pending: map[string]*checkBatch{},
+	}
+}
+
+// dispatch adds the check to the pending batch of the given member of the hashring, starting a
+// new batch if there is none, and waits for its result. It returns errCheckBatchingUnsupported
+// if the check must instead be dispatched on its own.
+func (b *checkBatcher) dispatch(ctx context.Context, member string, requestKey []byte, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
+	if b.unsupported.Load() {
+		return nil, errCheckBatchingUnsupported
+	}
+
+	check := &batchedCheck{
+		ctx:        ctx,
+		requestKey: requestKey,
+		req:        req,
+		done:       make(chan batchedCheckResult, 1),
+	}
+
+	b.lock.Lock()
+	batch, ok := b.pending[member]
+	if !ok {
+		batch = &checkBatch{member: member}
+		b.pending[member] = batch
+		time.AfterFunc(b.window, func() { b.flush(batch) })
+	}
+	batch.checks = append(batch.checks, check)
+	if len(batch.checks) >= maxCheckBatchSize {
+		delete(b.pending, member)
+		go b.send(batch)
+	}
+	b.lock.Unlock()
+
+	select {
+	case result := <-check.done:
+		return result.resp, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// flush sends the batch once its window has passed, unless it was already sent because it was
+// full.
+func (b *checkBatcher) flush(batch *checkBatch) {
+	b.lock.Lock()
+	if b.pending[batch.member] != batch {
+		b.lock.Unlock()
+		return
+	}
+	delete(b.pending, batch.member)
+	b.lock.Unlock()
+
+	b.send(batch)
+}
+
+func (b *checkBatcher) send(batch *checkBatch) {
+	checkBatchSize.Observe(float64(len(batch.checks)))
+
+	ctx, cancel := batchContext(batch)
+	defer cancel()
+
+	req := &v1.DispatchCheckBatchRequest{Requests: make([]*v1.DispatchCheckRequest, 0, len(batch.checks))}
+	for _, check := range batch.checks {
+		req.Requests = append(req.Requests, check.req)
+	}
+
+	// The results are streamed as the checks are resolved, so that a check is never held up by
+	// the slowest of those batched with it.
+	delivered := make([]bool, len(batch.checks))
+	err := b.receive(ctx, req, func(result *v1.DispatchCheckBatchResponse) error {
+		index := int(result.Index)
+		if index >= len(batch.checks) || delivered[index] {
+			return fmt.Errorf("received an unexpected result for check %d of a batch of %d", index, len(batch.checks))
+		}
+		delivered[index] = true
+
+		if result.ErrorCode != uint32(codes.OK) {
+			batch.checks[index].done <- batchedCheckResult{err: status.Error(codes.Code(result.ErrorCode), result.ErrorMessage)}
+			return nil
+		}
+		batch.checks[index].done <- batchedCheckResult{resp: result.Response}
+		return nil
+	})
+
+	for index, check := range batch.checks {
+		if delivered[index] {
+			continue
+		}
+		if err == nil {
+			err = fmt.Errorf("received no result for check %d of a batch of %d", index, len(batch.checks))
+		}
+		check.done <- batchedCheckResult{err: err}
+	}
+}
+
+// receive sends the batch and calls handle with each result received.
+func (b *checkBatcher) receive(ctx context.Context, req *v1.DispatchCheckBatchRequest, handle func(*v1.DispatchCheckBatchResponse) error) error {
+	client, err := b.client.DispatchCheckBatch(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	received := false
+	for {
+		result, err := client.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		// Peers running a version without batches return Unimplemented before sending any
+		// results, in which case the checks are sent on their own.
+		if !received && status.Code(err) == codes.Unimplemented {
+			b.unsupported.Store(true)
+			return errCheckBatchingUnsupported
+		}
+
+		if err != nil {
+			return err
+		}
+
+		received = true
+		if err := handle(result); err != nil {
+			return err
+		}
+	}
+}
+
+// batchContext returns the context in which to send the batch, which carries the values of the
+// context of its first check and is routed to the member of the batch. It is not canceled with
+// any single check, but expires with the latest deadline of the checks, if they all have one.
+func batchContext(batch *checkBatch) (context.Context, context.CancelFunc) {
+	first := batch.checks[0]
+
+	var ctx context.Context = detachedContext{first.ctx}
+	ctx = context.WithValue(ctx, balancer.CtxKey, first.requestKey)
+	ctx = context.WithValue(ctx, balancer.MemberCtxKey, batch.member)
+
+	var latest time.Time
+	for _, check := range batch.checks {
+		deadline, ok := check.ctx.Deadline()
+		if !ok {
+			return context.WithCancel(ctx)
+		}
+		if deadline.After(latest) {
+			latest = deadline
+		}
+	}
+	return context.WithDeadline(ctx, latest)
+}
+
+// detachedContext carries the values of its parent, but not its deadline or cancellation.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
@@ -0,0 +1,189 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/pkg/balancer"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+type fakeBatchClient struct {
+	sync.Mutex
+	batches []*v1.DispatchCheckBatchRequest
+	members []string
+	err     error
+}
+
+func (c *fakeBatchClient) DispatchCheckBatch(ctx context.Context, in *v1.DispatchCheckBatchRequest, _ ...grpc.CallOption) (v1.DispatchService_DispatchCheckBatchClient, error) {
+	c.Lock()
+	c.batches = append(c.batches, in)
+	c.members = append(c.members, ctx.Value(balancer.MemberCtxKey).(string))
+	c.Unlock()
+
+	if c.err != nil {
+		return &fakeBatchStream{err: c.err}, nil
+	}
+
+	// Results are streamed in the reverse order of the requests.
+	stream := &fakeBatchStream{err: io.EOF}
+	for index := len(in.Requests) - 1; index >= 0; index-- {
+		resourceID := in.Requests[index].ResourceIds[0]
+		if resourceID == "fails" {
+			stream.results = append(stream.results, &v1.DispatchCheckBatchResponse{
+				Index:        uint32(index),
+				ErrorCode:    uint32(codes.ResourceExhausted),
+				ErrorMessage: "budget exhausted",
+			})
+			continue
+		}
+		stream.results = append(stream.results, &v1.DispatchCheckBatchResponse{
+			Index: uint32(index),
+			Response: &v1.DispatchCheckResponse{
+				Metadata: &v1.ResponseMeta{DispatchCount: 1},
+				ResultsByResourceId: map[string]*v1.ResourceCheckResult{
+					resourceID: {Membership: v1.ResourceCheckResult_MEMBER},
+				},
+			},
+		})
+	}
+	return stream, nil
+}
+
+type fakeBatchStream struct {
+	grpc.ClientStream
+	results []*v1.DispatchCheckBatchResponse
+	err     error
+}
+
+func (s *fakeBatchStream) Recv() (*v1.DispatchCheckBatchResponse, error) {
+	if len(s.results) == 0 {
+		return nil, s.err
+	}
+	result := s.results[0]
+	s.results = s.results[1:]
+	return result, nil
+}
+
+func checkRequest(resourceID string) *v1.DispatchCheckRequest {
+	return &v1.DispatchCheckRequest{ResourceIds: []string{resourceID}}
+}
+
+func TestCheckBatching(t *testing.T) {
+	client := &fakeBatchClient{}
+	batcher := newCheckBatcher(client, 50*time.Millisecond)
+
+	resourceIDs := []string{"first", "second", "fails", "third"}
+	results := make([]*v1.DispatchCheckResponse, len(resourceIDs))
+	errs := make([]error, len(resourceIDs))
+
+	var wg sync.WaitGroup
+	for i, resourceID := range resourceIDs {
+		i, resourceID := i, resourceID
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = batcher.dispatch(context.Background(), "member", []byte(resourceID), checkRequest(resourceID))
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, client.batches, 1)
+	require.Len(t, client.batches[0].Requests, len(resourceIDs))
+	require.Equal(t, []string{"member"}, client.members)
+
+	for i, resourceID := range resourceIDs {
+		if resourceID == "fails" {
+			require.Equal(t, codes.ResourceExhausted, status.Code(errs[i]))
+			continue
+		}
+		require.NoError(t, errs[i])
+		require.Contains(t, results[i].ResultsByResourceId, resourceID)
+	}
+}
+
+func TestCheckBatchingByMember(t *testing.T) {
+	client := &fakeBatchClient{}
+	batcher := newCheckBatcher(client, 50*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for _, member := range []string{"first", "second", "first"} {
+		member := member
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := batcher.dispatch(context.Background(), member, []byte(member), checkRequest(member))
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, client.batches, 2)
+	require.ElementsMatch(t, []string{"first", "second"}, client.members)
+}
+
+func TestCheckBatchingFullBatch(t *testing.T) {
+	client := &fakeBatchClient{}
+	batcher := newCheckBatcher(client, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < maxCheckBatchSize; i++ {
+		resourceID := fmt.Sprintf("resource-%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := batcher.dispatch(context.Background(), "member", []byte(resourceID), checkRequest(resourceID))
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, client.batches, 1)
+	require.Len(t, client.batches[0].Requests, maxCheckBatchSize)
+}
+
+func TestCheckBatchingUnsupported(t *testing.T) {
+	client := &fakeBatchClient{err: status.Error(codes.Unimplemented, "unknown method")}
+	batcher := newCheckBatcher(client, time.Millisecond)
+
+	_, err := batcher.dispatch(context.Background(), "member", []byte("key"), checkRequest("first"))
+	require.ErrorIs(t, err, errCheckBatchingUnsupported)
+
+	_, err = batcher.dispatch(context.Background(), "member", []byte("key"), checkRequest("second"))
+	require.ErrorIs(t, err, errCheckBatchingUnsupported)
+	require.Len(t, client.batches, 1)
+}
+
+func TestCheckBatchingCanceled(t *testing.T) {
+	client := &fakeBatchClient{}
+	batcher := newCheckBatcher(client, 50*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := batcher.dispatch(ctx, "member", []byte("key"), checkRequest("canceled"))
+	require.ErrorIs(t, err, context.Canceled)
+
+	// The batch is still sent for the checks which were not canceled.
+	resp, err := batcher.dispatch(context.Background(), "member", []byte("key"), checkRequest("remaining"))
+	require.NoError(t, err)
+	require.Contains(t, resp.ResultsByResourceId, "remaining")
+	require.Len(t, client.batches, 1)
+	require.Len(t, client.batches[0].Requests, 2)
+}
+
+func TestCheckBatchingMissingResult(t *testing.T) {
+	client := &fakeBatchClient{err: io.EOF}
+	batcher := newCheckBatcher(client, time.Millisecond)
+
+	_, err := batcher.dispatch(context.Background(), "member", []byte("key"), checkRequest("first"))
+	require.ErrorContains(t, err, "received no result")
+}
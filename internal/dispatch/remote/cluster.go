@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"io"
+	"time"
 
+	"github.com/benbjohnson/clock"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
 
 	"github.com/authzed/spicedb/internal/dispatch"
 	"github.com/authzed/spicedb/internal/dispatch/keys"
@@ -17,29 +21,90 @@ import (
 
 type clusterClient interface {
 	DispatchCheck(ctx context.Context, req *v1.DispatchCheckRequest, opts ...grpc.CallOption) (*v1.DispatchCheckResponse, error)
+	DispatchCheckBatch(ctx context.Context, in *v1.DispatchCheckBatchRequest, opts ...grpc.CallOption) (v1.DispatchService_DispatchCheckBatchClient, error)
 	DispatchExpand(ctx context.Context, req *v1.DispatchExpandRequest, opts ...grpc.CallOption) (*v1.DispatchExpandResponse, error)
 	DispatchLookup(ctx context.Context, req *v1.DispatchLookupRequest, opts ...grpc.CallOption) (*v1.DispatchLookupResponse, error)
 	DispatchReachableResources(ctx context.Context, in *v1.DispatchReachableResourcesRequest, opts ...grpc.CallOption) (v1.DispatchService_DispatchReachableResourcesClient, error)
 	DispatchLookupSubjects(ctx context.Context, in *v1.DispatchLookupSubjectsRequest, opts ...grpc.CallOption) (v1.DispatchService_DispatchLookupSubjectsClient, error)
+	DispatchLookupResources(ctx context.Context, in *v1.DispatchLookupRequest, opts ...grpc.CallOption) (v1.DispatchService_DispatchLookupResourcesClient, error)
+}
+
+// Option is a function-style option for configuring a cluster dispatcher.
+type Option func(*clusterDispatcher)
+
+// Hedging enables hedging of the check and expand requests dispatched to peer nodes. Lookups
+// are streamed and so are only hedged when sent to peers which do not support streamed lookups.
+func Hedging(config HedgingConfig) Option {
+	return func(cr *clusterDispatcher) {
+		timeSource := clock.New()
+		cr.checkHedger = newHedger("check", timeSource, config)
+		cr.expandHedger = newHedger("expand", timeSource, config)
+		cr.lookupHedger = newHedger("lookup", timeSource, config)
+	}
+}
+
+// CheckBatching enables batching of the checks dispatched to the same peer node within the given
+// window into a single call. Batches are not hedged, and checks are no longer batched once a peer
+// which does not support batches is found.
+func CheckBatching(window time.Duration) Option {
+	return func(cr *clusterDispatcher) {
+		cr.checkBatcher = newCheckBatcher(cr.clusterClient, window)
+	}
+}
+
+// HopOverhead sets the time which dispatching a request to a peer node is expected to take on
+// top of resolving it, which is deducted from the time budget given to the peer so that requests
+// which cannot complete before their deadline fail before being dispatched.
+func HopOverhead(overhead time.Duration) Option {
+	return func(cr *clusterDispatcher) {
+		cr.hopOverhead = overhead
+	}
 }
 
 // NewClusterDispatcher creates a dispatcher implementation that uses the provided client
 // to dispatch requests to peer nodes in the cluster.
-func NewClusterDispatcher(client clusterClient, conn *grpc.ClientConn, keyHandler keys.Handler) dispatch.Dispatcher {
+func NewClusterDispatcher(client clusterClient, conn *grpc.ClientConn, keyHandler keys.Handler, options ...Option) dispatch.Dispatcher {
 	if keyHandler == nil {
 		keyHandler = &keys.DirectKeyHandler{}
 	}
 
-	return &clusterDispatcher{clusterClient: client, conn: conn, keyHandler: keyHandler}
+	cr := &clusterDispatcher{clusterClient: client, conn: conn, keyHandler: keyHandler}
+	for _, fn := range options {
+		fn(cr)
+	}
+	return cr
 }
 
 type clusterDispatcher struct {
 	clusterClient clusterClient
 	conn          *grpc.ClientConn
 	keyHandler    keys.Handler
+
+	// The hedgers of each operation, which are nil if hedging is disabled.
+	checkHedger  *hedger
+	expandHedger *hedger
+	lookupHedger *hedger
+
+	// checkBatcher is nil if batching is disabled.
+	checkBatcher *checkBatcher
+
+	// hopOverhead is deducted from the time budget of each dispatched request.
+	hopOverhead time.Duration
+
+	// localFallback is nil if requests are never resolved locally.
+	localFallback dispatch.Dispatcher
+	fallback      fallbackState
 }
 
 func (cr *clusterDispatcher) DispatchCheck(ctx context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
+	return withLocalFallback(cr, "check", func() (*v1.DispatchCheckResponse, error) {
+		return cr.dispatchCheck(ctx, req)
+	}, func() (*v1.DispatchCheckResponse, error) {
+		return cr.localFallback.DispatchCheck(ctx, req)
+	})
+}
+
+func (cr *clusterDispatcher) dispatchCheck(ctx context.Context, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
 	if err := dispatch.CheckDepth(ctx, req); err != nil {
 		return &v1.DispatchCheckResponse{Metadata: emptyMetadata}, err
 	}
@@ -49,16 +114,53 @@ func (cr *clusterDispatcher) DispatchCheck(ctx context.Context, req *v1.Dispatch
 		return &v1.DispatchCheckResponse{Metadata: emptyMetadata}, err
 	}
 
-	ctx = context.WithValue(ctx, balancer.CtxKey, requestKey)
-	resp, err := cr.clusterClient.DispatchCheck(ctx, req)
+	dispatch.SetPriority(ctx, req)
+	if err := dispatch.SetBudget(ctx, req); err != nil {
+		return &v1.DispatchCheckResponse{Metadata: emptyMetadata}, err
+	}
+	if err := dispatch.SetTimeBudget(ctx, req, cr.hopOverhead); err != nil {
+		return &v1.DispatchCheckResponse{Metadata: emptyMetadata}, err
+	}
+
+	resp, err := cr.dispatchBatchedCheck(ctx, requestKey, req)
+	if errors.Is(err, errCheckBatchingUnsupported) {
+		ctx = context.WithValue(ctx, balancer.CtxKey, requestKey)
+		resp, err = hedge(ctx, cr.checkHedger, func(ctx context.Context) (*v1.DispatchCheckResponse, error) {
+			return cr.clusterClient.DispatchCheck(ctx, req)
+		})
+	}
 	if err != nil {
 		return &v1.DispatchCheckResponse{Metadata: requestFailureMetadata}, err
 	}
 
+	dispatch.ChargeBudget(ctx, req, resp.Metadata)
 	return resp, nil
 }
 
+// dispatchBatchedCheck dispatches the check in a batch with the others sent to the same peer, if
+// batching is enabled, and returns errCheckBatchingUnsupported otherwise.
+func (cr *clusterDispatcher) dispatchBatchedCheck(ctx context.Context, requestKey []byte, req *v1.DispatchCheckRequest) (*v1.DispatchCheckResponse, error) {
+	if cr.checkBatcher == nil {
+		return nil, errCheckBatchingUnsupported
+	}
+
+	member, ok := balancer.PickMember(cr.conn.Target(), requestKey)
+	if !ok {
+		return nil, errCheckBatchingUnsupported
+	}
+
+	return cr.checkBatcher.dispatch(ctx, member, requestKey, req)
+}
+
 func (cr *clusterDispatcher) DispatchExpand(ctx context.Context, req *v1.DispatchExpandRequest) (*v1.DispatchExpandResponse, error) {
+	return withLocalFallback(cr, "expand", func() (*v1.DispatchExpandResponse, error) {
+		return cr.dispatchExpand(ctx, req)
+	}, func() (*v1.DispatchExpandResponse, error) {
+		return cr.localFallback.DispatchExpand(ctx, req)
+	})
+}
+
+func (cr *clusterDispatcher) dispatchExpand(ctx context.Context, req *v1.DispatchExpandRequest) (*v1.DispatchExpandResponse, error) {
 	if err := dispatch.CheckDepth(ctx, req); err != nil {
 		return &v1.DispatchExpandResponse{Metadata: emptyMetadata}, err
 	}
@@ -68,37 +170,115 @@ func (cr *clusterDispatcher) DispatchExpand(ctx context.Context, req *v1.Dispatc
 		return &v1.DispatchExpandResponse{Metadata: emptyMetadata}, err
 	}
 
+	dispatch.SetPriority(ctx, req)
+	if err := dispatch.SetBudget(ctx, req); err != nil {
+		return &v1.DispatchExpandResponse{Metadata: emptyMetadata}, err
+	}
+	if err := dispatch.SetTimeBudget(ctx, req, cr.hopOverhead); err != nil {
+		return &v1.DispatchExpandResponse{Metadata: emptyMetadata}, err
+	}
+
 	ctx = context.WithValue(ctx, balancer.CtxKey, requestKey)
-	resp, err := cr.clusterClient.DispatchExpand(ctx, req)
+	resp, err := hedge(ctx, cr.expandHedger, func(ctx context.Context) (*v1.DispatchExpandResponse, error) {
+		return cr.clusterClient.DispatchExpand(ctx, req)
+	})
 	if err != nil {
 		return &v1.DispatchExpandResponse{Metadata: requestFailureMetadata}, err
 	}
 
+	dispatch.ChargeBudget(ctx, req, resp.Metadata)
 	return resp, nil
 }
 
-func (cr *clusterDispatcher) DispatchLookup(ctx context.Context, req *v1.DispatchLookupRequest) (*v1.DispatchLookupResponse, error) {
+func (cr *clusterDispatcher) DispatchLookup(
+	req *v1.DispatchLookupRequest,
+	stream dispatch.LookupStream,
+) error {
+	return withLocalLookupFallback(cr, req, stream, cr.dispatchLookup, func(req *v1.DispatchLookupRequest, stream dispatch.LookupStream) error {
+		return cr.localFallback.DispatchLookup(req, stream)
+	})
+}
+
+func (cr *clusterDispatcher) dispatchLookup(
+	req *v1.DispatchLookupRequest,
+	stream dispatch.LookupStream,
+) error {
+	requestKey, err := cr.keyHandler.LookupResourcesDispatchKey(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.WithValue(stream.Context(), balancer.CtxKey, requestKey)
+	stream = dispatch.StreamWithContext(ctx, stream)
+
 	if err := dispatch.CheckDepth(ctx, req); err != nil {
-		return &v1.DispatchLookupResponse{Metadata: emptyMetadata}, err
+		return err
+	}
+	dispatch.SetPriority(ctx, req)
+	if err := dispatch.SetBudget(ctx, req); err != nil {
+		return err
+	}
+	if err := dispatch.SetTimeBudget(ctx, req, cr.hopOverhead); err != nil {
+		return err
 	}
 
-	requestKey, err := cr.keyHandler.LookupResourcesDispatchKey(ctx, req)
+	client, err := cr.clusterClient.DispatchLookupResources(ctx, req)
 	if err != nil {
-		return &v1.DispatchLookupResponse{Metadata: emptyMetadata}, err
+		return err
 	}
 
-	ctx = context.WithValue(ctx, balancer.CtxKey, requestKey)
-	resp, err := cr.clusterClient.DispatchLookup(ctx, req)
+	for {
+		result, err := client.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		// Peers running a version without streamed lookups return Unimplemented before
+		// sending any results, in which case the lookup is retried as a single request.
+		if status.Code(err) == codes.Unimplemented {
+			return cr.dispatchUnaryLookup(ctx, req, stream)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		dispatch.ChargeBudget(ctx, req, result.Metadata)
+		serr := stream.Publish(result)
+		if serr != nil {
+			return serr
+		}
+	}
+
+	return nil
+}
+
+func (cr *clusterDispatcher) dispatchUnaryLookup(ctx context.Context, req *v1.DispatchLookupRequest, stream dispatch.LookupStream) error {
+	resp, err := hedge(ctx, cr.lookupHedger, func(ctx context.Context) (*v1.DispatchLookupResponse, error) {
+		return cr.clusterClient.DispatchLookup(ctx, req)
+	})
 	if err != nil {
-		return &v1.DispatchLookupResponse{Metadata: requestFailureMetadata}, err
+		return err
 	}
 
-	return resp, nil
+	dispatch.ChargeBudget(ctx, req, resp.Metadata)
+	return stream.Publish(resp)
 }
 
 func (cr *clusterDispatcher) DispatchReachableResources(
 	req *v1.DispatchReachableResourcesRequest,
 	stream dispatch.ReachableResourcesStream,
+) error {
+	return withLocalStreamFallback(cr, "reachable_resources", stream, func(stream dispatch.ReachableResourcesStream) error {
+		return cr.dispatchReachableResources(req, stream)
+	}, func(stream dispatch.ReachableResourcesStream) error {
+		return cr.localFallback.DispatchReachableResources(req, stream)
+	})
+}
+
+func (cr *clusterDispatcher) dispatchReachableResources(
+	req *v1.DispatchReachableResourcesRequest,
+	stream dispatch.ReachableResourcesStream,
 ) error {
 	requestKey, err := cr.keyHandler.ReachableResourcesDispatchKey(stream.Context(), req)
 	if err != nil {
@@ -111,6 +291,13 @@ func (cr *clusterDispatcher) DispatchReachableResources(
 	if err := dispatch.CheckDepth(ctx, req); err != nil {
 		return err
 	}
+	dispatch.SetPriority(ctx, req)
+	if err := dispatch.SetBudget(ctx, req); err != nil {
+		return err
+	}
+	if err := dispatch.SetTimeBudget(ctx, req, cr.hopOverhead); err != nil {
+		return err
+	}
 
 	client, err := cr.clusterClient.DispatchReachableResources(ctx, req)
 	if err != nil {
@@ -127,6 +314,7 @@ func (cr *clusterDispatcher) DispatchReachableResources(
 			return err
 		}
 
+		dispatch.ChargeBudget(ctx, req, result.Metadata)
 		serr := stream.Publish(result)
 		if serr != nil {
 			return serr
@@ -139,6 +327,17 @@ func (cr *clusterDispatcher) DispatchReachableResources(
 func (cr *clusterDispatcher) DispatchLookupSubjects(
 	req *v1.DispatchLookupSubjectsRequest,
 	stream dispatch.LookupSubjectsStream,
+) error {
+	return withLocalStreamFallback(cr, "lookup_subjects", stream, func(stream dispatch.LookupSubjectsStream) error {
+		return cr.dispatchLookupSubjects(req, stream)
+	}, func(stream dispatch.LookupSubjectsStream) error {
+		return cr.localFallback.DispatchLookupSubjects(req, stream)
+	})
+}
+
+func (cr *clusterDispatcher) dispatchLookupSubjects(
+	req *v1.DispatchLookupSubjectsRequest,
+	stream dispatch.LookupSubjectsStream,
 ) error {
 	requestKey, err := cr.keyHandler.LookupSubjectsDispatchKey(stream.Context(), req)
 	if err != nil {
@@ -151,6 +350,13 @@ func (cr *clusterDispatcher) DispatchLookupSubjects(
 	if err := dispatch.CheckDepth(ctx, req); err != nil {
 		return err
 	}
+	dispatch.SetPriority(ctx, req)
+	if err := dispatch.SetBudget(ctx, req); err != nil {
+		return err
+	}
+	if err := dispatch.SetTimeBudget(ctx, req, cr.hopOverhead); err != nil {
+		return err
+	}
 
 	client, err := cr.clusterClient.DispatchLookupSubjects(ctx, req)
 	if err != nil {
@@ -167,6 +373,7 @@ func (cr *clusterDispatcher) DispatchLookupSubjects(
 			return err
 		}
 
+		dispatch.ChargeBudget(ctx, req, result.Metadata)
 		serr := stream.Publish(result)
 		if serr != nil {
 			return serr
@@ -180,10 +387,14 @@ func (cr *clusterDispatcher) Close() error {
 	return nil
 }
 
-// IsReady returns whether the underlying dispatch connection is available
+// IsReady returns whether the underlying dispatch connection is available, or requests can
+// otherwise be resolved locally.
 func (cr *clusterDispatcher) IsReady() bool {
 	state := cr.conn.GetState()
 	log.Trace().Interface("connection-state", state).Msg("checked if cluster dispatcher is ready")
+	if cr.localFallback != nil && cr.localFallback.IsReady() {
+		return true
+	}
 	return state == connectivity.Ready || state == connectivity.Idle
 }
 
@@ -0,0 +1,165 @@
+package remote
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	log "github.com/authzed/spicedb/internal/logging"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+var localFallbackCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "spicedb",
+	Subsystem: "dispatch",
+	Name:      "local_fallback_total",
+	Help:      "number of requests resolved locally because no peer node could be reached to dispatch them to",
+}, []string{"operation"})
+
+var errNoReachablePeers = errors.New("no peer node can be reached")
+
+// LocalFallback resolves requests with the given local dispatcher when no peer node can be
+// reached to dispatch them to, such as when the hashring is empty or every connection has
+// failed, rather than failing them. Streamed requests are only resolved locally if no result was
+// received from a peer.
+func LocalFallback(local dispatch.Dispatcher) Option {
+	return func(cr *clusterDispatcher) {
+		cr.localFallback = local
+	}
+}
+
+// fallbackState tracks whether requests are being resolved locally, so that falling back and
+// recovering are logged once rather than for each request.
+type fallbackState struct {
+	fallingBack atomic.Bool
+}
+
+func (fs *fallbackState) fellBack(operation string, err error) {
+	localFallbackCounter.WithLabelValues(operation).Inc()
+	if fs.fallingBack.CompareAndSwap(false, true) {
+		log.Warn().Err(err).Str("operation", operation).Msg("peer nodes cannot be reached; resolving dispatched requests locally until they can be")
+	}
+}
+
+func (fs *fallbackState) reached() {
+	if fs.fallingBack.Load() && fs.fallingBack.CompareAndSwap(true, false) {
+		log.Info().Msg("peer nodes can be reached again; resuming dispatch to them")
+	}
+}
+
+// peersUnreachable returns whether requests must be resolved locally without being sent, because
+// the connection to the peer nodes has failed or there are none to connect to.
+func (cr *clusterDispatcher) peersUnreachable() bool {
+	return cr.localFallback != nil && cr.conn.GetState() == connectivity.TransientFailure
+}
+
+// shouldFallBack returns whether a request which failed with the given error must be resolved
+// locally, because no peer node could be reached.
+func (cr *clusterDispatcher) shouldFallBack(operation string, err error) bool {
+	if cr.localFallback == nil || status.Code(err) != codes.Unavailable {
+		return false
+	}
+
+	cr.fallback.fellBack(operation, err)
+	return true
+}
+
+func withLocalFallback[T any](cr *clusterDispatcher, operation string, remote func() (T, error), local func() (T, error)) (T, error) {
+	if cr.peersUnreachable() {
+		cr.fallback.fellBack(operation, errNoReachablePeers)
+		return local()
+	}
+
+	resp, err := remote()
+	if err == nil {
+		cr.fallback.reached()
+		return resp, nil
+	}
+	if cr.shouldFallBack(operation, err) {
+		return local()
+	}
+	return resp, err
+}
+
+func withLocalStreamFallback[T any](cr *clusterDispatcher, operation string, stream dispatch.Stream[T], remote func(dispatch.Stream[T]) error, local func(dispatch.Stream[T]) error) error {
+	if cr.peersUnreachable() {
+		cr.fallback.fellBack(operation, errNoReachablePeers)
+		return local(stream)
+	}
+
+	// Results already published cannot be taken back, so requests are only resolved locally if
+	// none were received from the peer.
+	var published atomic.Bool
+	tracked := &dispatch.WrappedDispatchStream[T]{
+		Stream: stream,
+		Ctx:    stream.Context(),
+		Processor: func(result T) (T, bool, error) {
+			published.Store(true)
+			return result, true, nil
+		},
+	}
+
+	err := remote(tracked)
+	if err == nil {
+		cr.fallback.reached()
+		return nil
+	}
+	if !published.Load() && cr.shouldFallBack(operation, err) {
+		return local(stream)
+	}
+	return err
+}
+
+// withLocalLookupFallback resolves a lookup as withLocalStreamFallback does, but also resolves it
+// locally if the peer becomes unreachable once results were received from it, by resuming it from
+// the cursors of the results received.
+func withLocalLookupFallback(cr *clusterDispatcher, req *v1.DispatchLookupRequest, stream dispatch.LookupStream, remote func(*v1.DispatchLookupRequest, dispatch.LookupStream) error, local func(*v1.DispatchLookupRequest, dispatch.LookupStream) error) error {
+	var (
+		mu                sync.Mutex
+		cursorResourceIDs []string
+		receivedCount     uint32
+	)
+
+	err := withLocalStreamFallback(cr, "lookup", stream, func(stream dispatch.LookupStream) error {
+		return remote(req, &dispatch.WrappedDispatchStream[*v1.DispatchLookupResponse]{
+			Stream: stream,
+			Ctx:    stream.Context(),
+			Processor: func(result *v1.DispatchLookupResponse) (*v1.DispatchLookupResponse, bool, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				cursorResourceIDs = append(cursorResourceIDs, result.AfterResponseCursor.GetResourceIds()...)
+				receivedCount += uint32(len(result.ResolvedResources))
+				return result, true, nil
+			},
+		})
+	}, func(stream dispatch.LookupStream) error {
+		return local(req, stream)
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Peers running a version without lookup cursors send results without them, in which case the
+	// lookup cannot be resumed.
+	if err == nil || len(cursorResourceIDs) == 0 || !cr.shouldFallBack("lookup", err) {
+		return err
+	}
+
+	if receivedCount >= req.Limit {
+		return nil
+	}
+
+	resumed := req.CloneVT()
+	resumed.OptionalCursor = &v1.Cursor{
+		ResourceIds: append(resumed.OptionalCursor.GetResourceIds(), cursorResourceIDs...),
+	}
+	resumed.Limit -= receivedCount
+	return local(resumed, stream)
+}
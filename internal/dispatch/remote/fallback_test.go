@@ -0,0 +1,197 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+func fallbackTestDispatcher(t *testing.T, fallback bool) *clusterDispatcher {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	cr := &clusterDispatcher{conn: conn}
+	if fallback {
+		cr.localFallback = struct{ dispatch.Dispatcher }{}
+	}
+	return cr
+}
+
+func TestLocalFallback(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	failed := status.Error(codes.Internal, "failed")
+
+	testCases := []struct {
+		name          string
+		fallback      bool
+		remoteErr     error
+		expected      string
+		expectedError error
+	}{
+		{"remote success", true, nil, "remote", nil},
+		{"unavailable falls back", true, unavailable, "local", nil},
+		{"other errors do not fall back", true, failed, "", failed},
+		{"unavailable without fallback", false, unavailable, "", unavailable},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cr := fallbackTestDispatcher(t, tc.fallback)
+			result, err := withLocalFallback(cr, "check", func() (string, error) {
+				if tc.remoteErr != nil {
+					return "", tc.remoteErr
+				}
+				return "remote", nil
+			}, func() (string, error) {
+				return "local", nil
+			})
+			require.ErrorIs(t, err, tc.expectedError)
+			require.Equal(t, tc.expected, result)
+			require.Equal(t, tc.fallback && errors.Is(tc.remoteErr, unavailable), cr.fallback.fallingBack.Load())
+		})
+	}
+}
+
+func TestLocalStreamFallback(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	for _, publishedRemotely := range []bool{false, true} {
+		publishedRemotely := publishedRemotely
+		t.Run("", func(t *testing.T) {
+			cr := fallbackTestDispatcher(t, true)
+			stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupSubjectsResponse](context.Background())
+
+			err := withLocalStreamFallback(cr, "lookup_subjects", dispatch.LookupSubjectsStream(stream), func(stream dispatch.LookupSubjectsStream) error {
+				if publishedRemotely {
+					if err := stream.Publish(&v1.DispatchLookupSubjectsResponse{}); err != nil {
+						return err
+					}
+				}
+				return unavailable
+			}, func(stream dispatch.LookupSubjectsStream) error {
+				return stream.Publish(&v1.DispatchLookupSubjectsResponse{})
+			})
+
+			// Results received from a peer cannot be taken back, so the request fails instead.
+			if publishedRemotely {
+				require.ErrorIs(t, err, unavailable)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Len(t, stream.Results(), 1)
+		})
+	}
+}
+
+func TestLocalLookupFallbackResumes(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	testCases := []struct {
+		name            string
+		remoteResponses []*v1.DispatchLookupResponse
+		expectedLimit   uint32
+		expectedCursor  []string
+		expectedError   error
+	}{
+		{
+			"resumed from the cursors received",
+			[]*v1.DispatchLookupResponse{
+				{ResolvedResources: []*v1.ResolvedResource{{ResourceId: "foo"}}, AfterResponseCursor: &v1.Cursor{ResourceIds: []string{"foo", "bar"}}},
+				{AfterResponseCursor: &v1.Cursor{ResourceIds: []string{"baz"}}},
+			},
+			9,
+			[]string{"initial", "foo", "bar", "baz"},
+			nil,
+		},
+		{
+			"not resumed without cursors",
+			[]*v1.DispatchLookupResponse{
+				{ResolvedResources: []*v1.ResolvedResource{{ResourceId: "foo"}}},
+			},
+			0,
+			nil,
+			unavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cr := fallbackTestDispatcher(t, true)
+			stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupResponse](context.Background())
+
+			var resumed *v1.DispatchLookupRequest
+			err := withLocalLookupFallback(cr, &v1.DispatchLookupRequest{
+				Limit:          10,
+				OptionalCursor: &v1.Cursor{ResourceIds: []string{"initial"}},
+			}, stream, func(req *v1.DispatchLookupRequest, stream dispatch.LookupStream) error {
+				for _, response := range tc.remoteResponses {
+					if err := stream.Publish(response); err != nil {
+						return err
+					}
+				}
+				return unavailable
+			}, func(req *v1.DispatchLookupRequest, stream dispatch.LookupStream) error {
+				resumed = req
+				return stream.Publish(&v1.DispatchLookupResponse{})
+			})
+
+			require.ErrorIs(t, err, tc.expectedError)
+			if tc.expectedError != nil {
+				require.Nil(t, resumed)
+				require.Len(t, stream.Results(), len(tc.remoteResponses))
+				return
+			}
+
+			require.Equal(t, tc.expectedLimit, resumed.Limit)
+			require.Equal(t, tc.expectedCursor, resumed.OptionalCursor.ResourceIds)
+			require.Len(t, stream.Results(), len(tc.remoteResponses)+1)
+		})
+	}
+}
+
+func TestLocalFallbackWithoutPeers(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.NoError(t, lis.Close())
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.Connect()
+	require.Eventually(t, func() bool {
+		return conn.GetState() == connectivity.TransientFailure
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Requests are resolved locally without being sent while the connection has failed.
+	cr := &clusterDispatcher{conn: conn, localFallback: struct{ dispatch.Dispatcher }{}}
+	result, err := withLocalFallback(cr, "check", func() (string, error) {
+		require.Fail(t, "request sent to unreachable peers")
+		return "", nil
+	}, func() (string, error) {
+		return "local", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "local", result)
+}
@@ -0,0 +1,160 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/influxdata/tdigest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/balancer"
+)
+
+var hedgedDispatchCount = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "spicedb",
+	Subsystem: "dispatch",
+	Name:      "hedged_requests_total",
+	Help:      "total number of dispatched requests which were hedged by a duplicate request to another node, by operation",
+}, []string{"operation"})
+
+const (
+	minHedgingMaxRequests     = 1000
+	defaultTDigestCompression = float64(1000)
+)
+
+// HedgingConfig configures the hedging of dispatched requests: a request which has not completed
+// after the given quantile of the durations of recent requests is duplicated to the next node on
+// the hashring, and the first response of either is used.
+type HedgingConfig struct {
+	// InitialSlowValue is the duration after which requests are hedged before statistics have
+	// been collected.
+	InitialSlowValue time.Duration
+
+	// MaxRequests is the number of recent requests from which the quantile is computed.
+	MaxRequests uint64
+
+	// Quantile is the quantile of recent request durations after which a request is hedged, in
+	// the range (0.0-1.0) exclusive.
+	Quantile float64
+}
+
+// Validate returns an error if the configuration is invalid.
+func (c HedgingConfig) Validate() error {
+	if c.InitialSlowValue < 0 {
+		return fmt.Errorf("initial slow value must not be negative")
+	}
+
+	if c.MaxRequests < minHedgingMaxRequests {
+		return fmt.Errorf("max requests must be >=%d", minHedgingMaxRequests)
+	}
+
+	if c.Quantile <= 0.0 || c.Quantile >= 1.0 {
+		return fmt.Errorf("quantile must be in the range (0.0-1.0) exclusive")
+	}
+	return nil
+}
+
+// hedger hedges the requests of a single operation, from the statistics of their durations.
+type hedger struct {
+	operation  string
+	timeSource clock.Clock
+	config     HedgingConfig
+
+	digestLock sync.Mutex
+	digests    []*tdigest.TDigest
+}
+
+func newHedger(operation string, timeSource clock.Clock, config HedgingConfig) *hedger {
+	digests := []*tdigest.TDigest{
+		tdigest.NewWithCompression(defaultTDigestCompression),
+		tdigest.NewWithCompression(defaultTDigestCompression),
+	}
+
+	// The first digest is pre-loaded with the initial slow value, so that there is reasonable
+	// data for the first requests and the other digest is out of phase with it.
+	digests[0].Add(config.InitialSlowValue.Seconds(), float64(config.MaxRequests)/2)
+
+	return &hedger{operation: operation, timeSource: timeSource, config: config, digests: digests}
+}
+
+func (h *hedger) slowThreshold() time.Duration {
+	h.digestLock.Lock()
+	defer h.digestLock.Unlock()
+	return time.Duration(h.digests[0].Quantile(h.config.Quantile) * float64(time.Second))
+}
+
+func (h *hedger) record(duration time.Duration) {
+	h.digestLock.Lock()
+	defer h.digestLock.Unlock()
+
+	// Swap the active digest once it has too many samples.
+	if h.digests[0].Count() >= float64(h.config.MaxRequests) {
+		exhausted := h.digests[0]
+		h.digests = append(h.digests[1:], exhausted)
+		exhausted.Reset()
+	}
+
+	for _, digest := range h.digests {
+		digest.Add(duration.Seconds(), 1)
+	}
+}
+
+type hedgedResult[T any] struct {
+	resp     T
+	err      error
+	duration time.Duration
+}
+
+// hedge calls the function, and calls it again as a hedged request if the first call is slow. It
+// returns the first successful response, or the last error if both calls fail. A nil hedger
+// disables hedging.
+func hedge[T any](ctx context.Context, h *hedger, call func(ctx context.Context) (T, error)) (T, error) {
+	if h == nil {
+		return call(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgedResult[T], 2)
+	send := func(ctx context.Context) {
+		start := h.timeSource.Now()
+		resp, err := call(ctx)
+		results <- hedgedResult[T]{resp, err, h.timeSource.Since(start)}
+	}
+
+	threshold := h.slowThreshold()
+	timer := h.timeSource.Timer(threshold)
+	defer timer.Stop()
+
+	go send(ctx)
+	outstanding := 1
+
+	select {
+	case result := <-results:
+		h.record(result.duration)
+		return result.resp, result.err
+	case <-timer.C:
+	}
+
+	log.Ctx(ctx).Debug().Dur("after", threshold).Str("operation", h.operation).Msg("sending hedged dispatch request")
+	hedgedDispatchCount.WithLabelValues(h.operation).Inc()
+	go send(context.WithValue(ctx, balancer.HedgeCtxKey, true))
+	outstanding++
+
+	var result hedgedResult[T]
+	for ; outstanding > 0; outstanding-- {
+		result = <-results
+		if result.err == nil {
+			break
+		}
+	}
+
+	h.record(result.duration)
+	return result.resp, result.err
+}
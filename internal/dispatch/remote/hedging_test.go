@@ -0,0 +1,135 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/balancer"
+)
+
+var testHedgingConfig = HedgingConfig{
+	InitialSlowValue: 1 * time.Millisecond,
+	MaxRequests:      minHedgingMaxRequests,
+	Quantile:         0.9,
+}
+
+func isHedged(ctx context.Context) bool {
+	hedged, _ := ctx.Value(balancer.HedgeCtxKey).(bool)
+	return hedged
+}
+
+func TestHedgingConfigValidate(t *testing.T) {
+	require.NoError(t, testHedgingConfig.Validate())
+
+	invalid := testHedgingConfig
+	invalid.MaxRequests = minHedgingMaxRequests - 1
+	require.ErrorContains(t, invalid.Validate(), "max requests")
+
+	invalid = testHedgingConfig
+	invalid.Quantile = 1.0
+	require.ErrorContains(t, invalid.Validate(), "quantile")
+}
+
+func TestHedge(t *testing.T) {
+	testCases := []struct {
+		name          string
+		call          func(ctx context.Context) (string, error)
+		expected      string
+		expectedError string
+		expectedCalls uint32
+	}{
+		{
+			name: "fast request is not hedged",
+			call: func(ctx context.Context) (string, error) {
+				return "primary", nil
+			},
+			expected:      "primary",
+			expectedCalls: 1,
+		},
+		{
+			name: "failed fast request is not hedged",
+			call: func(ctx context.Context) (string, error) {
+				return "", errors.New("primary failed")
+			},
+			expectedError: "primary failed",
+			expectedCalls: 1,
+		},
+		{
+			name: "slow request is hedged",
+			call: func(ctx context.Context) (string, error) {
+				if isHedged(ctx) {
+					return "hedged", nil
+				}
+				<-ctx.Done()
+				return "", ctx.Err()
+			},
+			expected:      "hedged",
+			expectedCalls: 2,
+		},
+		{
+			name: "failed hedge waits for slow request",
+			call: func(ctx context.Context) (string, error) {
+				if isHedged(ctx) {
+					return "", errors.New("hedge failed")
+				}
+				time.Sleep(10 * time.Millisecond)
+				return "primary", nil
+			},
+			expected:      "primary",
+			expectedCalls: 2,
+		},
+		{
+			name: "both requests fail",
+			call: func(ctx context.Context) (string, error) {
+				if isHedged(ctx) {
+					return "", errors.New("hedge failed")
+				}
+				time.Sleep(10 * time.Millisecond)
+				return "", errors.New("primary failed")
+			},
+			expectedError: "primary failed",
+			expectedCalls: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			// Fast requests complete immediately, so they are only hedged if the threshold has
+			// already passed.
+			config := testHedgingConfig
+			if tc.expectedCalls == 1 {
+				config.InitialSlowValue = 1 * time.Minute
+			}
+			h := newHedger("test", clock.New(), config)
+
+			var calls atomic.Uint32
+			resp, err := hedge(context.Background(), h, func(ctx context.Context) (string, error) {
+				calls.Add(1)
+				return tc.call(ctx)
+			})
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, resp)
+			}
+			require.Equal(t, tc.expectedCalls, calls.Load())
+		})
+	}
+}
+
+func TestHedgeDisabled(t *testing.T) {
+	resp, err := hedge(context.Background(), nil, func(ctx context.Context) (string, error) {
+		require.False(t, isHedged(ctx))
+		return "primary", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "primary", resp)
+}
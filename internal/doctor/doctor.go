@@ -0,0 +1,206 @@
+// Package doctor implements the diagnostics run by the `spicedb doctor` command.
+package doctor
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+// Status is the outcome of a single diagnostic check.
+type Status int
+
+const (
+	// StatusOK indicates the check passed.
+	StatusOK Status = iota
+
+	// StatusWarning indicates the check found something worth investigating, but
+	// which will not prevent SpiceDB from running.
+	StatusWarning
+
+	// StatusFailure indicates the check found a problem that must be fixed.
+	StatusFailure
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "OK"
+	case StatusWarning:
+		return "WARN"
+	case StatusFailure:
+		return "FAIL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Result is the result of a single diagnostic check.
+type Result struct {
+	// Name is the name of the check.
+	Name string
+
+	// Status is the outcome of the check.
+	Status Status
+
+	// Message is a human-readable description of the outcome.
+	Message string
+}
+
+// OK returns a passing result.
+func OK(name string, format string, args ...any) Result {
+	return Result{name, StatusOK, fmt.Sprintf(format, args...)}
+}
+
+// Warning returns a warning result.
+func Warning(name string, format string, args ...any) Result {
+	return Result{name, StatusWarning, fmt.Sprintf(format, args...)}
+}
+
+// Failure returns a failing result.
+func Failure(name string, format string, args ...any) Result {
+	return Result{name, StatusFailure, fmt.Sprintf(format, args...)}
+}
+
+// CheckDatastore verifies connectivity to the datastore, reports the latency of
+// computing the head revision and verifies that the datastore's migrations are at head.
+func CheckDatastore(ctx context.Context, ds datastore.Datastore) []Result {
+	start := time.Now()
+	_, err := ds.HeadRevision(ctx)
+	latency := time.Since(start)
+	if err != nil {
+		return []Result{Failure("datastore connectivity", "unable to read head revision: %s", err)}
+	}
+
+	results := []Result{OK("datastore connectivity", "head revision read in %s", latency)}
+
+	ready, err := ds.IsReady(ctx)
+	switch {
+	case err != nil:
+		results = append(results, Failure("datastore migrations", "unable to determine migration state: %s", err))
+	case !ready:
+		results = append(results, Failure("datastore migrations", "datastore is not migrated to head; run `spicedb migrate head`"))
+	default:
+		results = append(results, OK("datastore migrations", "datastore is migrated to head"))
+	}
+
+	return results
+}
+
+// CheckCertificateExpiry verifies that every certificate found in the PEM file at the given
+// path is currently valid, warning if any expires within the warning window.
+func CheckCertificateExpiry(name, path string, now time.Time, warnWithin time.Duration) Result {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return Failure(name, "unable to read certificate file %s: %s", path, err)
+	}
+
+	var earliest *x509.Certificate
+	for block, rest := pem.Decode(contents); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return Failure(name, "unable to parse certificate in %s: %s", path, err)
+		}
+
+		if earliest == nil || cert.NotAfter.Before(earliest.NotAfter) {
+			earliest = cert
+		}
+	}
+
+	if earliest == nil {
+		return Failure(name, "no certificates found in %s", path)
+	}
+
+	switch {
+	case now.Before(earliest.NotBefore):
+		return Failure(name, "certificate %q is not valid until %s", earliest.Subject.CommonName, earliest.NotBefore)
+	case now.After(earliest.NotAfter):
+		return Failure(name, "certificate %q expired at %s", earliest.Subject.CommonName, earliest.NotAfter)
+	case earliest.NotAfter.Sub(now) < warnWithin:
+		return Warning(name, "certificate %q expires soon, at %s", earliest.Subject.CommonName, earliest.NotAfter)
+	default:
+		return OK(name, "certificate %q valid until %s", earliest.Subject.CommonName, earliest.NotAfter)
+	}
+}
+
+// CheckPeerClockSkew estimates the clock skew between this machine and an HTTP endpoint
+// exposed by a dispatch peer (such as its metrics endpoint), using the Date header of the
+// response. As the Date header has a resolution of one second, skew below one second
+// cannot be detected.
+func CheckPeerClockSkew(ctx context.Context, client *http.Client, url string, maxSkew time.Duration) Result {
+	name := fmt.Sprintf("clock skew (%s)", url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Failure(name, "invalid peer address: %s", err)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	rtt := time.Since(start)
+	if err != nil {
+		return Failure(name, "unable to reach peer: %s", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	dateHeader := resp.Header.Get("Date")
+	if dateHeader == "" {
+		return Warning(name, "peer did not return a Date header; unable to determine clock skew")
+	}
+
+	peerTime, err := http.ParseTime(dateHeader)
+	if err != nil {
+		return Warning(name, "peer returned an invalid Date header: %s", err)
+	}
+
+	// Assume the peer produced its response halfway through the round trip.
+	skew := peerTime.Sub(start.Add(rtt / 2))
+	if skew < 0 {
+		skew = -skew
+	}
+
+	// Account for the truncation of the Date header to the second.
+	skew = skew.Truncate(time.Second)
+	if skew > maxSkew {
+		return Failure(name, "clock skew of at least %s exceeds the maximum of %s", skew, maxSkew)
+	}
+
+	return OK(name, "clock skew below %s (round trip %s)", maxSkew+time.Second, rtt)
+}
+
+// WriteReport writes the results to the writer, returning the number of failures found.
+func WriteReport(w io.Writer, results []Result) (int, error) {
+	failures := 0
+	for _, result := range results {
+		var status string
+		switch result.Status {
+		case StatusOK:
+			status = color.GreenString("%-4s", result.Status)
+		case StatusWarning:
+			status = color.YellowString("%-4s", result.Status)
+		default:
+			failures++
+			status = color.RedString("%-4s", result.Status)
+		}
+
+		if _, err := fmt.Fprintf(w, "[%s] %s: %s\n", status, result.Name, result.Message); err != nil {
+			return failures, err
+		}
+	}
+
+	return failures, nil
+}
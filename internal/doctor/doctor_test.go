@@ -0,0 +1,111 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+)
+
+func TestCheckDatastore(t *testing.T) {
+	require := require.New(t)
+
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	defer ds.Close()
+
+	results := CheckDatastore(context.Background(), ds)
+	require.Len(results, 2)
+	for _, result := range results {
+		require.Equal(StatusOK, result.Status, result.Message)
+	}
+}
+
+func writeCertificate(t *testing.T, notBefore, notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "spicedb"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	return path
+}
+
+func TestCheckCertificateExpiry(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name           string
+		notBefore      time.Time
+		notAfter       time.Time
+		expectedStatus Status
+	}{
+		{"valid", now.Add(-time.Hour), now.Add(365 * 24 * time.Hour), StatusOK},
+		{"expiring soon", now.Add(-time.Hour), now.Add(24 * time.Hour), StatusWarning},
+		{"expired", now.Add(-48 * time.Hour), now.Add(-24 * time.Hour), StatusFailure},
+		{"not yet valid", now.Add(24 * time.Hour), now.Add(48 * time.Hour), StatusFailure},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeCertificate(t, tc.notBefore, tc.notAfter)
+			result := CheckCertificateExpiry("cert", path, now, 7*24*time.Hour)
+			require.Equal(t, tc.expectedStatus, result.Status, result.Message)
+		})
+	}
+
+	result := CheckCertificateExpiry("cert", filepath.Join(t.TempDir(), "missing.pem"), now, time.Hour)
+	require.Equal(t, StatusFailure, result.Status)
+}
+
+func TestCheckPeerClockSkew(t *testing.T) {
+	offset := 0 * time.Second
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(offset).UTC().Format(http.TimeFormat))
+	}))
+	defer server.Close()
+
+	result := CheckPeerClockSkew(context.Background(), server.Client(), server.URL, 2*time.Second)
+	require.Equal(t, StatusOK, result.Status, result.Message)
+
+	offset = 1 * time.Minute
+	result = CheckPeerClockSkew(context.Background(), server.Client(), server.URL, 2*time.Second)
+	require.Equal(t, StatusFailure, result.Status, result.Message)
+}
+
+func TestWriteReport(t *testing.T) {
+	var buf bytes.Buffer
+	failures, err := WriteReport(&buf, []Result{
+		OK("first", "all good"),
+		Warning("second", "hmm"),
+		Failure("third", "broken: %d", 42),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, failures)
+	require.Contains(t, buf.String(), "third: broken: 42")
+}
@@ -5,6 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	"github.com/authzed/spicedb/internal/dispatch"
 	log "github.com/authzed/spicedb/internal/logging"
@@ -20,15 +25,15 @@ import (
 )
 
 // NewConcurrentChecker creates an instance of ConcurrentChecker.
-func NewConcurrentChecker(d dispatch.Check, concurrencyLimit uint16) *ConcurrentChecker {
-	return &ConcurrentChecker{d, concurrencyLimit}
+func NewConcurrentChecker(d dispatch.Check, concurrencyLimits ConcurrencyLimits) *ConcurrentChecker {
+	return &ConcurrentChecker{d, concurrencyLimits}
 }
 
 // ConcurrentChecker exposes a method to perform Check requests, and delegates subproblems to the
 // provided dispatch.Check instance.
 type ConcurrentChecker struct {
-	d                dispatch.Check
-	concurrencyLimit uint16
+	d                 dispatch.Check
+	concurrencyLimits ConcurrencyLimits
 }
 
 // ValidatedCheckRequest represents a request after it has been validated and parsed for internal
@@ -44,6 +49,9 @@ type currentRequestContext struct {
 	// parentReq is the parent request being processed.
 	parentReq ValidatedCheckRequest
 
+	// resourceNamespace is the definition of the namespace of the resources being checked.
+	resourceNamespace *core.NamespaceDefinition
+
 	// filteredResourceIDs are those resource IDs to be checked after filtering for
 	// any resource IDs found directly matching the incoming subject.
 	//
@@ -61,9 +69,11 @@ type currentRequestContext struct {
 	resultsSetting v1.DispatchCheckRequest_ResultsSetting
 }
 
-// Check performs a check request with the provided request and context
-func (cc *ConcurrentChecker) Check(ctx context.Context, req ValidatedCheckRequest, relation *core.Relation) (*v1.DispatchCheckResponse, error) {
-	resolved := cc.checkInternal(ctx, req, relation)
+// Check performs a check request with the provided request and context, over the relation of the
+// resource namespace.
+func (cc *ConcurrentChecker) Check(ctx context.Context, req ValidatedCheckRequest, nsDef *core.NamespaceDefinition, relation *core.Relation) (*v1.DispatchCheckResponse, error) {
+	start := time.Now()
+	resolved := cc.checkInternal(ctx, req, nsDef, relation)
 	resolved.Resp.Metadata = addCallToResponseMetadata(resolved.Resp.Metadata)
 	if req.Debug != v1.DispatchCheckRequest_ENABLE_DEBUGGING {
 		return resolved.Resp, resolved.Err
@@ -94,11 +104,31 @@ func (cc *ConcurrentChecker) Check(ctx context.Context, req ValidatedCheckReques
 	}
 
 	debugInfo.Check.Results = results
+	debugInfo.Check.HandledBy = dispatch.NodeName
+	debugInfo.Check.Duration = durationpb.New(time.Since(start))
 	resolved.Resp.Metadata.DebugInfo = debugInfo
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String("debug.handled-by", dispatch.NodeName),
+		attribute.StringSlice("debug.found-resource-ids", foundResourceIDs(results)),
+		attribute.Int("debug.subproblems", len(debugInfo.Check.SubProblems)),
+	)
 	return resolved.Resp, resolved.Err
 }
 
-func (cc *ConcurrentChecker) checkInternal(ctx context.Context, req ValidatedCheckRequest, relation *core.Relation) CheckResult {
+// foundResourceIDs returns the IDs of the resources which are members, possibly conditionally,
+// in the given results.
+func foundResourceIDs(results map[string]*v1.ResourceCheckResult) []string {
+	found := make([]string, 0, len(results))
+	for resourceID, result := range results {
+		if result.Membership == v1.ResourceCheckResult_MEMBER || result.Membership == v1.ResourceCheckResult_CAVEATED_MEMBER {
+			found = append(found, resourceID)
+		}
+	}
+	return found
+}
+
+func (cc *ConcurrentChecker) checkInternal(ctx context.Context, req ValidatedCheckRequest, nsDef *core.NamespaceDefinition, relation *core.Relation) CheckResult {
 	// Ensure that we have proper type information for running the check. This is now required as of the deprecation and removal
 	// of the v0 API.
 	if relation.GetTypeInformation() == nil && relation.GetUsersetRewrite() == nil {
@@ -145,12 +175,13 @@ func (cc *ConcurrentChecker) checkInternal(ctx context.Context, req ValidatedChe
 
 	crc := currentRequestContext{
 		parentReq:           req,
+		resourceNamespace:   nsDef,
 		filteredResourceIDs: filteredResourcesIds,
 		resultsSetting:      resultsSetting,
 	}
 
 	if relation.UsersetRewrite == nil {
-		return combineResultWithFoundResources(cc.checkDirect(ctx, crc), membershipSet)
+		return combineResultWithFoundResources(cc.checkDirect(ctx, crc, relation), membershipSet)
 	}
 
 	return combineResultWithFoundResources(cc.checkUsersetRewrite(ctx, crc, relation.UsersetRewrite), membershipSet)
@@ -170,7 +201,7 @@ type directDispatch struct {
 	resourceIds  []string
 }
 
-func (cc *ConcurrentChecker) checkDirect(ctx context.Context, crc currentRequestContext) CheckResult {
+func (cc *ConcurrentChecker) checkDirect(ctx context.Context, crc currentRequestContext, relation *core.Relation) CheckResult {
 	log.Ctx(ctx).Trace().Object("direct", crc.parentReq).Send()
 	ds := datastoremw.MustFromContext(ctx).SnapshotReader(crc.parentReq.Revision)
 
@@ -225,6 +256,11 @@ func (cc *ConcurrentChecker) checkDirect(ctx context.Context, crc currentRequest
 
 	// Dispatch and map to the associated resource ID(s).
 	result := union(ctx, crc, toDispatch, func(ctx context.Context, crc currentRequestContext, dd directDispatch) CheckResult {
+		metadata, err := dispatchMetadata(crc.parentReq.Metadata, relation, crc.parentReq.ResourceRelation.Namespace, dd.resourceType.Namespace)
+		if err != nil {
+			return checkResultError(err, emptyMetadata)
+		}
+
 		childResult := cc.dispatch(ctx, crc, ValidatedCheckRequest{
 			&v1.DispatchCheckRequest{
 				ResourceRelation: dd.resourceType,
@@ -232,7 +268,7 @@ func (cc *ConcurrentChecker) checkDirect(ctx context.Context, crc currentRequest
 				Subject:          crc.parentReq.Subject,
 				ResultsSetting:   crc.resultsSetting,
 
-				Metadata: decrementDepth(crc.parentReq.Metadata),
+				Metadata: metadata,
 				Debug:    crc.parentReq.Debug,
 			},
 			crc.parentReq.Revision,
@@ -242,7 +278,7 @@ func (cc *ConcurrentChecker) checkDirect(ctx context.Context, crc currentRequest
 		}
 
 		return mapFoundResources(childResult, dd.resourceType, relationshipsBySubjectONR)
-	}, cc.concurrencyLimit)
+	}, cc.concurrencyLimits.limitFor(crc.parentReq.ResourceRelation))
 
 	return combineResultWithFoundResources(result, foundResources)
 }
@@ -273,11 +309,11 @@ func mapFoundResources(result CheckResult, resourceType *core.RelationReference,
 func (cc *ConcurrentChecker) checkUsersetRewrite(ctx context.Context, crc currentRequestContext, rewrite *core.UsersetRewrite) CheckResult {
 	switch rw := rewrite.RewriteOperation.(type) {
 	case *core.UsersetRewrite_Union:
-		return union(ctx, crc, rw.Union.Child, cc.runSetOperation, cc.concurrencyLimit)
+		return union(ctx, crc, rw.Union.Child, cc.runSetOperation, cc.concurrencyLimits.limitFor(crc.parentReq.ResourceRelation))
 	case *core.UsersetRewrite_Intersection:
-		return all(ctx, crc, rw.Intersection.Child, cc.runSetOperation, cc.concurrencyLimit)
+		return all(ctx, crc, rw.Intersection.Child, cc.runSetOperation, cc.concurrencyLimits.limitFor(crc.parentReq.ResourceRelation))
 	case *core.UsersetRewrite_Exclusion:
-		return difference(ctx, crc, rw.Exclusion.Child, cc.runSetOperation, cc.concurrencyLimit)
+		return difference(ctx, crc, rw.Exclusion.Child, cc.runSetOperation, cc.concurrencyLimits.limitFor(crc.parentReq.ResourceRelation))
 	default:
 		return checkResultError(fmt.Errorf("unknown userset rewrite operator"), emptyMetadata)
 	}
@@ -294,7 +330,7 @@ func (cc *ConcurrentChecker) runSetOperation(ctx context.Context, crc currentReq
 	case *core.SetOperation_Child_XThis:
 		return checkResultError(errors.New("use of _this is unsupported; please rewrite your schema"), emptyMetadata)
 	case *core.SetOperation_Child_ComputedUserset:
-		return cc.checkComputedUserset(ctx, crc, child.ComputedUserset, nil, nil)
+		return cc.checkComputedUserset(ctx, crc, child.ComputedUserset, nil, nil, decrementDepth(crc.parentReq.Metadata))
 	case *core.SetOperation_Child_UsersetRewrite:
 		return cc.checkUsersetRewrite(ctx, crc, child.UsersetRewrite)
 	case *core.SetOperation_Child_TupleToUserset:
@@ -306,7 +342,7 @@ func (cc *ConcurrentChecker) runSetOperation(ctx context.Context, crc currentReq
 	}
 }
 
-func (cc *ConcurrentChecker) checkComputedUserset(ctx context.Context, crc currentRequestContext, cu *core.ComputedUserset, rr *core.RelationReference, resourceIds []string) CheckResult {
+func (cc *ConcurrentChecker) checkComputedUserset(ctx context.Context, crc currentRequestContext, cu *core.ComputedUserset, rr *core.RelationReference, resourceIds []string, metadata *v1.ResolverMeta) CheckResult {
 	var startNamespace string
 	var targetResourceIds []string
 	if cu.Object == core.ComputedUserset_TUPLE_USERSET_OBJECT {
@@ -353,7 +389,7 @@ func (cc *ConcurrentChecker) checkComputedUserset(ctx context.Context, crc curre
 			ResourceIds:      updatedTargetResourceIds,
 			Subject:          crc.parentReq.Subject,
 			ResultsSetting:   crc.resultsSetting,
-			Metadata:         decrementDepth(crc.parentReq.Metadata),
+			Metadata:         metadata,
 			Debug:            crc.parentReq.Debug,
 		},
 		crc.parentReq.Revision,
@@ -386,6 +422,13 @@ func removeIndexFromSlice[T any](s []T, index int) []T {
 func (cc *ConcurrentChecker) checkTupleToUserset(ctx context.Context, crc currentRequestContext, ttu *core.TupleToUserset) CheckResult {
 	log.Ctx(ctx).Trace().Object("ttu", crc.parentReq).Send()
 	ds := datastoremw.MustFromContext(ctx).SnapshotReader(crc.parentReq.Revision)
+
+	// The tupleset relation may define a maximum recursion depth.
+	tuplesetRelation := findRelation(crc.resourceNamespace, ttu.Tupleset.Relation)
+	if tuplesetRelation == nil {
+		return noMembers()
+	}
+
 	it, err := ds.QueryRelationships(ctx, datastore.RelationshipsFilter{
 		ResourceType:             crc.parentReq.ResourceRelation.Namespace,
 		OptionalResourceIds:      crc.filteredResourceIDs,
@@ -423,7 +466,12 @@ func (cc *ConcurrentChecker) checkTupleToUserset(ctx context.Context, crc curren
 		crc,
 		toDispatch,
 		func(ctx context.Context, crc currentRequestContext, dd directDispatch) CheckResult {
-			childResult := cc.checkComputedUserset(ctx, crc, ttu.ComputedUserset, dd.resourceType, dd.resourceIds)
+			metadata, err := dispatchMetadata(crc.parentReq.Metadata, tuplesetRelation, crc.parentReq.ResourceRelation.Namespace, dd.resourceType.Namespace)
+			if err != nil {
+				return checkResultError(err, emptyMetadata)
+			}
+
+			childResult := cc.checkComputedUserset(ctx, crc, ttu.ComputedUserset, dd.resourceType, dd.resourceIds, metadata)
 			if childResult.Err != nil {
 				return childResult
 			}
@@ -434,7 +482,7 @@ func (cc *ConcurrentChecker) checkTupleToUserset(ctx context.Context, crc curren
 
 			return mapFoundResources(childResult, dd.resourceType, relationshipsBySubjectONR)
 		},
-		cc.concurrencyLimit,
+		cc.concurrencyLimits.limitFor(crc.parentReq.ResourceRelation),
 	)
 }
 
@@ -505,6 +553,7 @@ func all[T any](
 
 	cleanupFunc := dispatchAllAsync(childCtx, currentRequestContext{
 		parentReq:           crc.parentReq,
+		resourceNamespace:   crc.resourceNamespace,
 		filteredResourceIDs: crc.filteredResourceIDs,
 		resultsSetting:      v1.DispatchCheckRequest_REQUIRE_ALL_RESULTS,
 	}, children, handler, resultChan, concurrencyLimit)
@@ -573,6 +622,7 @@ func difference[T any](
 
 	cleanupFunc := dispatchAllAsync(childCtx, currentRequestContext{
 		parentReq:           crc.parentReq,
+		resourceNamespace:   crc.resourceNamespace,
 		filteredResourceIDs: crc.filteredResourceIDs,
 		resultsSetting:      v1.DispatchCheckRequest_REQUIRE_ALL_RESULTS,
 	}, children[1:], handler, othersChan, concurrencyLimit-1)
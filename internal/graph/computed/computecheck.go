@@ -3,6 +3,8 @@ package computed
 import (
 	"context"
 
+	"google.golang.org/protobuf/types/known/structpb"
+
 	cexpr "github.com/authzed/spicedb/internal/caveats"
 	"github.com/authzed/spicedb/internal/dispatch"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
@@ -11,14 +13,27 @@ import (
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 )
 
-// CheckParameters are the parameters for the ComputeCheck call. *All* are required.
+// CheckParameters are the parameters for the ComputeCheck call. *All* are required, except for
+// the CaveatContextCache.
 type CheckParameters struct {
 	ResourceType       *core.RelationReference
 	Subject            *core.ObjectAndRelation
 	CaveatContext      map[string]any
 	AtRevision         datastore.Revision
 	MaximumDepth       uint32
+	DispatchBudget     uint32
 	IsDebuggingEnabled bool
+
+	// CaveatContextCache, if set, caches the results of checks with a caveat context, so that
+	// their caveats are not evaluated again.
+	CaveatContextCache CaveatContextCache
+}
+
+// CaveatContextCache caches the results of checks computed with fully-specified caveat contexts,
+// as the caching dispatcher does.
+type CaveatContextCache interface {
+	GetCheckWithCaveatContext(ctx context.Context, req *v1.DispatchCheckRequest, caveatContext *structpb.Struct) (*v1.DispatchCheckResponse, bool)
+	SetCheckWithCaveatContext(ctx context.Context, req *v1.DispatchCheckRequest, caveatContext *structpb.Struct, resp *v1.DispatchCheckResponse)
 }
 
 // ComputeCheck computes a check result for the given resource and subject, computing any
@@ -62,7 +77,7 @@ func computeCheck(ctx context.Context,
 		setting = v1.DispatchCheckRequest_ALLOW_SINGLE_RESULT
 	}
 
-	checkResult, err := d.DispatchCheck(ctx, &v1.DispatchCheckRequest{
+	req := &v1.DispatchCheckRequest{
 		ResourceRelation: params.ResourceType,
 		ResourceIds:      resourceIDs,
 		ResultsSetting:   setting,
@@ -70,9 +85,25 @@ func computeCheck(ctx context.Context,
 		Metadata: &v1.ResolverMeta{
 			AtRevision:     params.AtRevision.String(),
 			DepthRemaining: params.MaximumDepth,
+			DispatchBudget: params.DispatchBudget,
 		},
 		Debug: debugging,
-	})
+	}
+
+	// The results of checks with a caveat context are cached along with the context, if a cache
+	// is given, so that their caveats are not evaluated again.
+	var contextCache CaveatContextCache
+	var caveatContext *structpb.Struct
+	if params.CaveatContextCache != nil && !params.IsDebuggingEnabled && len(params.CaveatContext) > 0 {
+		if converted, err := structpb.NewStruct(params.CaveatContext); err == nil {
+			contextCache, caveatContext = params.CaveatContextCache, converted
+			if cached, ok := contextCache.GetCheckWithCaveatContext(ctx, req, caveatContext); ok {
+				return cached.ResultsByResourceId, cached.Metadata, nil
+			}
+		}
+	}
+
+	checkResult, err := d.DispatchCheck(ctx, req)
 	if err != nil {
 		return nil, checkResult.Metadata, err
 	}
@@ -85,9 +116,38 @@ func computeCheck(ctx context.Context,
 		}
 		results[resourceID] = computed
 	}
+
+	if contextCache != nil && caveatsFullyEvaluated(checkResult, results) {
+		contextCache.SetCheckWithCaveatContext(ctx, req, caveatContext, &v1.DispatchCheckResponse{
+			Metadata:            checkResult.Metadata,
+			ResultsByResourceId: results,
+		})
+	}
 	return results, checkResult.Metadata, nil
 }
 
+// caveatsFullyEvaluated returns whether the check result had caveats, all of which were evaluated
+// with the caveat context rather than being left partially evaluated for lack of context.
+func caveatsFullyEvaluated(checkResult *v1.DispatchCheckResponse, results map[string]*v1.ResourceCheckResult) bool {
+	caveated := false
+	for _, result := range checkResult.ResultsByResourceId {
+		if result.Membership == v1.ResourceCheckResult_CAVEATED_MEMBER {
+			caveated = true
+			break
+		}
+	}
+	if !caveated {
+		return false
+	}
+
+	for _, result := range results {
+		if result.Membership == v1.ResourceCheckResult_CAVEATED_MEMBER {
+			return false
+		}
+	}
+	return true
+}
+
 func computeCaveatedCheckResult(ctx context.Context, params CheckParameters, resourceID string, checkResult *v1.DispatchCheckResponse) (*v1.ResourceCheckResult, error) {
 	result, ok := checkResult.ResultsByResourceId[resourceID]
 	if !ok {
@@ -3,10 +3,12 @@ package computed_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch/caching"
 	"github.com/authzed/spicedb/internal/dispatch/graph"
 	"github.com/authzed/spicedb/internal/graph/computed"
 	log "github.com/authzed/spicedb/internal/logging"
@@ -930,6 +932,68 @@ func TestComputeBulkCheck(t *testing.T) {
 	require.Equal(t, resp["third"].Membership, v1.ResourceCheckResult_NOT_MEMBER)
 }
 
+func TestComputeCheckWithCaveatContextCache(t *testing.T) {
+	ds, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+
+	cachingDispatch, err := caching.NewCachingDispatcher(caching.DispatchTestCache(t), "", nil)
+	require.NoError(t, err)
+	cachingDispatch.SetDelegate(graph.NewDispatcher(cachingDispatch, 10))
+	defer cachingDispatch.Close()
+
+	ctx := log.Logger.WithContext(datastoremw.ContextWithHandle(context.Background()))
+	require.NoError(t, datastoremw.SetInContext(ctx, ds))
+
+	revision, err := writeCaveatedTuples(ctx, t, ds, `
+	definition user {}
+
+	caveat somecaveat(somecondition int) {
+		somecondition == 42
+	}
+
+	definition document {
+		relation viewer: user | user with somecaveat
+		permission view = viewer
+	}
+	`, []caveatedUpdate{
+		{core.RelationTupleUpdate_CREATE, "document:first#viewer@user:tom", "somecaveat", map[string]any{}},
+	})
+	require.NoError(t, err)
+
+	check := func(caveatContext map[string]any) v1.ResourceCheckResult_Membership {
+		result, _, err := computed.ComputeCheck(ctx, cachingDispatch,
+			computed.CheckParameters{
+				ResourceType: &core.RelationReference{
+					Namespace: "document",
+					Relation:  "view",
+				},
+				Subject: &core.ObjectAndRelation{
+					Namespace: "user",
+					ObjectId:  "tom",
+					Relation:  "...",
+				},
+				CaveatContext:      caveatContext,
+				AtRevision:         revision,
+				MaximumDepth:       50,
+				CaveatContextCache: cachingDispatch,
+			},
+			"first",
+		)
+		require.NoError(t, err)
+
+		// Let the cache converge.
+		time.Sleep(10 * time.Millisecond)
+		return result.Membership
+	}
+
+	for i := 0; i < 2; i++ {
+		require.Equal(t, v1.ResourceCheckResult_MEMBER, check(map[string]any{"somecondition": 42.0}))
+		require.Equal(t, v1.ResourceCheckResult_NOT_MEMBER, check(map[string]any{"somecondition": 32.0}))
+		require.Equal(t, v1.ResourceCheckResult_CAVEATED_MEMBER, check(map[string]any{"othercondition": 42.0}))
+		require.Equal(t, v1.ResourceCheckResult_CAVEATED_MEMBER, check(nil))
+	}
+}
+
 func writeCaveatedTuples(ctx context.Context, t *testing.T, ds datastore.Datastore, schema string, updates []caveatedUpdate) (datastore.Revision, error) {
 	empty := ""
 	compiled, err := compiler.Compile(compiler.InputSchema{
@@ -0,0 +1,51 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// ConcurrencyLimits are the maximum numbers of goroutines to create for each dispatched operation.
+// The limit of an operation is the override for the namespace and relation of its resources if
+// there is one, otherwise the override for their namespace, otherwise the default.
+type ConcurrencyLimits struct {
+	defaultLimit uint16
+	overrides    map[string]uint16
+}
+
+// SharedConcurrencyLimits returns ConcurrencyLimits with the same limit for every operation.
+func SharedConcurrencyLimits(limit uint16) ConcurrencyLimits {
+	return ConcurrencyLimits{defaultLimit: limit}
+}
+
+// WithOverrides returns a copy of the limits with the given overrides, keyed by either a namespace
+// (e.g. "document") or a namespace and relation (e.g. "document#view").
+func (cl ConcurrencyLimits) WithOverrides(overrides map[string]uint16) (ConcurrencyLimits, error) {
+	for key, limit := range overrides {
+		namespace, relation, hasRelation := strings.Cut(key, "#")
+		if namespace == "" || (hasRelation && relation == "") {
+			return cl, fmt.Errorf("invalid concurrency limit override key %q", key)
+		}
+		if limit == 0 {
+			return cl, fmt.Errorf("concurrency limit override for %q must be greater than zero", key)
+		}
+	}
+
+	return ConcurrencyLimits{defaultLimit: cl.defaultLimit, overrides: overrides}, nil
+}
+
+func (cl ConcurrencyLimits) limitFor(rr *core.RelationReference) uint16 {
+	if len(cl.overrides) == 0 || rr == nil {
+		return cl.defaultLimit
+	}
+
+	if limit, ok := cl.overrides[rr.Namespace+"#"+rr.Relation]; ok {
+		return limit
+	}
+	if limit, ok := cl.overrides[rr.Namespace]; ok {
+		return limit
+	}
+	return cl.defaultLimit
+}
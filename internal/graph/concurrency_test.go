@@ -0,0 +1,33 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestConcurrencyLimits(t *testing.T) {
+	require := require.New(t)
+
+	limits, err := SharedConcurrencyLimits(50).WithOverrides(map[string]uint16{
+		"document":      10,
+		"document#view": 5,
+	})
+	require.NoError(err)
+
+	require.Equal(uint16(5), limits.limitFor(tuple.RelationReference("document", "view")))
+	require.Equal(uint16(10), limits.limitFor(tuple.RelationReference("document", "edit")))
+	require.Equal(uint16(50), limits.limitFor(tuple.RelationReference("folder", "view")))
+	require.Equal(uint16(50), limits.limitFor(nil))
+	require.Equal(uint16(50), SharedConcurrencyLimits(50).limitFor(tuple.RelationReference("document", "view")))
+
+	for _, key := range []string{"", "#view", "document#"} {
+		_, err := SharedConcurrencyLimits(50).WithOverrides(map[string]uint16{key: 1})
+		require.ErrorContains(err, "invalid concurrency limit override key")
+	}
+
+	_, err = SharedConcurrencyLimits(50).WithOverrides(map[string]uint16{"document": 0})
+	require.ErrorContains(err, "must be greater than zero")
+}
@@ -3,6 +3,7 @@ package graph
 import (
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/rs/zerolog"
 
@@ -139,6 +140,53 @@ func NewRelationMissingTypeInfoErr(nsName string, relationName string) error {
 	}
 }
 
+// ErrRecursionDepthExceeded occurs when a self-referential relation is traversed more times than
+// the maximum depth defined for it in the schema.
+type ErrRecursionDepthExceeded struct {
+	error
+	namespaceName string
+	relationName  string
+	maxDepth      uint32
+}
+
+// NamespaceName returns the name of the namespace containing the relation.
+func (err ErrRecursionDepthExceeded) NamespaceName() string {
+	return err.namespaceName
+}
+
+// RelationName returns the name of the relation whose maximum depth was exceeded.
+func (err ErrRecursionDepthExceeded) RelationName() string {
+	return err.relationName
+}
+
+// MaxDepth returns the maximum depth defined for the relation.
+func (err ErrRecursionDepthExceeded) MaxDepth() uint32 {
+	return err.maxDepth
+}
+
+func (err ErrRecursionDepthExceeded) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Str("namespace", err.namespaceName).Str("relation", err.relationName).Uint32("max-depth", err.maxDepth)
+}
+
+// DetailsMetadata returns the metadata for details for this error.
+func (err ErrRecursionDepthExceeded) DetailsMetadata() map[string]string {
+	return map[string]string{
+		"definition_name": err.namespaceName,
+		"relation_name":   err.relationName,
+		"max_depth":       strconv.FormatUint(uint64(err.maxDepth), 10),
+	}
+}
+
+// NewRecursionDepthExceededErr constructs a new recursion depth exceeded error.
+func NewRecursionDepthExceededErr(nsName string, relationName string, maxDepth uint32) error {
+	return ErrRecursionDepthExceeded{
+		error:         fmt.Errorf("relation `%s` under definition `%s` was traversed more than its maximum depth of %d", relationName, nsName, maxDepth),
+		namespaceName: nsName,
+		relationName:  relationName,
+		maxDepth:      maxDepth,
+	}
+}
+
 // ErrInvalidArgument occurs when a request sent has an invalid argument.
 type ErrInvalidArgument struct {
 	error
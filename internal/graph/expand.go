@@ -32,15 +32,16 @@ type ValidatedExpandRequest struct {
 	Revision datastore.Revision
 }
 
-// Expand performs an expand request with the provided request and context.
-func (ce *ConcurrentExpander) Expand(ctx context.Context, req ValidatedExpandRequest, relation *core.Relation) (*v1.DispatchExpandResponse, error) {
+// Expand performs an expand request with the provided request and context, over the relation of the
+// resource namespace.
+func (ce *ConcurrentExpander) Expand(ctx context.Context, req ValidatedExpandRequest, nsDef *core.NamespaceDefinition, relation *core.Relation) (*v1.DispatchExpandResponse, error) {
 	log.Ctx(ctx).Trace().Object("expand", req).Send()
 
 	var directFunc ReduceableExpandFunc
 	if relation.UsersetRewrite == nil {
-		directFunc = ce.expandDirect(ctx, req)
+		directFunc = ce.expandDirect(ctx, req, relation)
 	} else {
-		directFunc = ce.expandUsersetRewrite(ctx, req, relation.UsersetRewrite)
+		directFunc = ce.expandUsersetRewrite(ctx, req, nsDef, relation.UsersetRewrite)
 	}
 
 	resolved := expandOne(ctx, directFunc)
@@ -51,6 +52,7 @@ func (ce *ConcurrentExpander) Expand(ctx context.Context, req ValidatedExpandReq
 func (ce *ConcurrentExpander) expandDirect(
 	ctx context.Context,
 	req ValidatedExpandRequest,
+	relation *core.Relation,
 ) ReduceableExpandFunc {
 	log.Ctx(ctx).Trace().Object("direct", req).Send()
 	return func(ctx context.Context, resultChan chan<- ExpandResult) {
@@ -101,10 +103,16 @@ func (ce *ConcurrentExpander) expandDirect(
 		// found terminals together.
 		var requestsToDispatch []ReduceableExpandFunc
 		for _, nonTerminalUser := range foundNonTerminalUsersets {
+			metadata, err := dispatchMetadata(req.Metadata, relation, req.ResourceAndRelation.Namespace, nonTerminalUser.Namespace)
+			if err != nil {
+				resultChan <- expandResultError(err, emptyMetadata)
+				return
+			}
+
 			requestsToDispatch = append(requestsToDispatch, ce.dispatch(ValidatedExpandRequest{
 				&v1.DispatchExpandRequest{
 					ResourceAndRelation: nonTerminalUser,
-					Metadata:            decrementDepth(req.Metadata),
+					Metadata:            metadata,
 					ExpansionMode:       req.ExpansionMode,
 				},
 				req.Revision,
@@ -130,34 +138,34 @@ func (ce *ConcurrentExpander) expandDirect(
 	}
 }
 
-func (ce *ConcurrentExpander) expandUsersetRewrite(ctx context.Context, req ValidatedExpandRequest, usr *core.UsersetRewrite) ReduceableExpandFunc {
+func (ce *ConcurrentExpander) expandUsersetRewrite(ctx context.Context, req ValidatedExpandRequest, nsDef *core.NamespaceDefinition, usr *core.UsersetRewrite) ReduceableExpandFunc {
 	switch rw := usr.RewriteOperation.(type) {
 	case *core.UsersetRewrite_Union:
 		log.Ctx(ctx).Trace().Msg("union")
-		return ce.expandSetOperation(ctx, req, rw.Union, expandAny)
+		return ce.expandSetOperation(ctx, req, nsDef, rw.Union, expandAny)
 	case *core.UsersetRewrite_Intersection:
 		log.Ctx(ctx).Trace().Msg("intersection")
-		return ce.expandSetOperation(ctx, req, rw.Intersection, expandAll)
+		return ce.expandSetOperation(ctx, req, nsDef, rw.Intersection, expandAll)
 	case *core.UsersetRewrite_Exclusion:
 		log.Ctx(ctx).Trace().Msg("exclusion")
-		return ce.expandSetOperation(ctx, req, rw.Exclusion, expandDifference)
+		return ce.expandSetOperation(ctx, req, nsDef, rw.Exclusion, expandDifference)
 	default:
 		return alwaysFailExpand
 	}
 }
 
-func (ce *ConcurrentExpander) expandSetOperation(ctx context.Context, req ValidatedExpandRequest, so *core.SetOperation, reducer ExpandReducer) ReduceableExpandFunc {
+func (ce *ConcurrentExpander) expandSetOperation(ctx context.Context, req ValidatedExpandRequest, nsDef *core.NamespaceDefinition, so *core.SetOperation, reducer ExpandReducer) ReduceableExpandFunc {
 	var requests []ReduceableExpandFunc
 	for _, childOneof := range so.Child {
 		switch child := childOneof.ChildType.(type) {
 		case *core.SetOperation_Child_XThis:
 			return expandError(errors.New("use of _this is unsupported; please rewrite your schema"))
 		case *core.SetOperation_Child_ComputedUserset:
-			requests = append(requests, ce.expandComputedUserset(ctx, req, child.ComputedUserset, nil))
+			requests = append(requests, ce.expandComputedUserset(ctx, req, child.ComputedUserset, nil, decrementDepth(req.Metadata)))
 		case *core.SetOperation_Child_UsersetRewrite:
-			requests = append(requests, ce.expandUsersetRewrite(ctx, req, child.UsersetRewrite))
+			requests = append(requests, ce.expandUsersetRewrite(ctx, req, nsDef, child.UsersetRewrite))
 		case *core.SetOperation_Child_TupleToUserset:
-			requests = append(requests, ce.expandTupleToUserset(ctx, req, child.TupleToUserset))
+			requests = append(requests, ce.expandTupleToUserset(ctx, req, nsDef, child.TupleToUserset))
 		case *core.SetOperation_Child_XNil:
 			requests = append(requests, emptyExpansion(req.ResourceAndRelation))
 		default:
@@ -177,7 +185,7 @@ func (ce *ConcurrentExpander) dispatch(req ValidatedExpandRequest) ReduceableExp
 	}
 }
 
-func (ce *ConcurrentExpander) expandComputedUserset(ctx context.Context, req ValidatedExpandRequest, cu *core.ComputedUserset, tpl *core.RelationTuple) ReduceableExpandFunc {
+func (ce *ConcurrentExpander) expandComputedUserset(ctx context.Context, req ValidatedExpandRequest, cu *core.ComputedUserset, tpl *core.RelationTuple, metadata *v1.ResolverMeta) ReduceableExpandFunc {
 	log.Ctx(ctx).Trace().Str("relation", cu.Relation).Msg("computed userset")
 	var start *core.ObjectAndRelation
 	if cu.Object == core.ComputedUserset_TUPLE_USERSET_OBJECT {
@@ -213,16 +221,24 @@ func (ce *ConcurrentExpander) expandComputedUserset(ctx context.Context, req Val
 				ObjectId:  start.ObjectId,
 				Relation:  cu.Relation,
 			},
-			Metadata:      decrementDepth(req.Metadata),
+			Metadata:      metadata,
 			ExpansionMode: req.ExpansionMode,
 		},
 		req.Revision,
 	})
 }
 
-func (ce *ConcurrentExpander) expandTupleToUserset(ctx context.Context, req ValidatedExpandRequest, ttu *core.TupleToUserset) ReduceableExpandFunc {
+func (ce *ConcurrentExpander) expandTupleToUserset(ctx context.Context, req ValidatedExpandRequest, nsDef *core.NamespaceDefinition, ttu *core.TupleToUserset) ReduceableExpandFunc {
 	return func(ctx context.Context, resultChan chan<- ExpandResult) {
 		ds := datastoremw.MustFromContext(ctx).SnapshotReader(req.Revision)
+
+		// The tupleset relation may define a maximum recursion depth.
+		tuplesetRelation := findRelation(nsDef, ttu.Tupleset.Relation)
+		if tuplesetRelation == nil {
+			resultChan <- expandAny(ctx, req.ResourceAndRelation, nil)
+			return
+		}
+
 		it, err := ds.QueryRelationships(ctx, datastore.RelationshipsFilter{
 			ResourceType:             req.ResourceAndRelation.Namespace,
 			OptionalResourceIds:      []string{req.ResourceAndRelation.ObjectId},
@@ -236,7 +252,13 @@ func (ce *ConcurrentExpander) expandTupleToUserset(ctx context.Context, req Vali
 
 		var requestsToDispatch []ReduceableExpandFunc
 		for tpl := it.Next(); tpl != nil; tpl = it.Next() {
-			requestsToDispatch = append(requestsToDispatch, ce.expandComputedUserset(ctx, req, ttu.ComputedUserset, tpl))
+			metadata, err := dispatchMetadata(req.Metadata, tuplesetRelation, req.ResourceAndRelation.Namespace, tpl.Subject.Namespace)
+			if err != nil {
+				resultChan <- expandResultError(err, emptyMetadata)
+				return
+			}
+
+			requestsToDispatch = append(requestsToDispatch, ce.expandComputedUserset(ctx, req, ttu.ComputedUserset, tpl, metadata))
 		}
 		if it.Err() != nil {
 			resultChan <- expandResultError(NewExpansionFailureErr(it.Err()), emptyMetadata)
@@ -6,7 +6,9 @@ import (
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 
 	"github.com/authzed/spicedb/pkg/datastore"
+	nspkg "github.com/authzed/spicedb/pkg/namespace"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
 )
 
 // Ellipsis relation is used to signify a semantic-free relationship.
@@ -32,12 +34,6 @@ type ExpandResult struct {
 	Err  error
 }
 
-// LookupResult is the data that is returned by a single lookup or sub-lookup.
-type LookupResult struct {
-	Resp *v1.DispatchLookupResponse
-	Err  error
-}
-
 // ReduceableExpandFunc is a function that can be bound to a execution context.
 type ReduceableExpandFunc func(ctx context.Context, resultChan chan<- ExpandResult)
 
@@ -55,9 +51,60 @@ type ExpandReducer func(
 
 func decrementDepth(md *v1.ResolverMeta) *v1.ResolverMeta {
 	return &v1.ResolverMeta{
-		AtRevision:     md.AtRevision,
-		DepthRemaining: md.DepthRemaining - 1,
+		AtRevision:      md.AtRevision,
+		DepthRemaining:  md.DepthRemaining - 1,
+		RecursionDepths: md.RecursionDepths,
+	}
+}
+
+// dispatchMetadata returns the metadata for dispatching across the relation between resources of
+// the resource namespace and subjects of the subject namespace, in either direction, counting the
+// traversal towards the relation's maximum recursion depth if both are of the same definition.
+// A nil relation, such as when rewriting to a computed userset, is never counted.
+func dispatchMetadata(md *v1.ResolverMeta, relation *core.Relation, resourceNamespace, subjectNamespace string) (*v1.ResolverMeta, error) {
+	if relation == nil || resourceNamespace != subjectNamespace {
+		return decrementDepth(md), nil
+	}
+
+	return traverseRecursiveRelation(md, resourceNamespace, relation)
+}
+
+// traverseRecursiveRelation returns the metadata for dispatching through the self-referential
+// relation, recording one more traversal of it, or an error if that would exceed its maximum
+// depth. If the relation has no maximum depth, the metadata is simply decremented.
+func traverseRecursiveRelation(md *v1.ResolverMeta, nsName string, relation *core.Relation) (*v1.ResolverMeta, error) {
+	decremented := decrementDepth(md)
+
+	maxDepth := nspkg.GetRelationMaxRecursionDepth(relation)
+	if maxDepth == 0 {
+		return decremented, nil
+	}
+
+	key := tuple.StringRR(&core.RelationReference{Namespace: nsName, Relation: relation.Name})
+	depth := md.RecursionDepths[key] + 1
+	if depth > maxDepth {
+		return nil, NewRecursionDepthExceededErr(nsName, relation.Name, maxDepth)
+	}
+
+	// The map is shared with sibling dispatches, so it is copied rather than modified.
+	recursionDepths := make(map[string]uint32, len(md.RecursionDepths)+1)
+	for existingKey, existingDepth := range md.RecursionDepths {
+		recursionDepths[existingKey] = existingDepth
+	}
+	recursionDepths[key] = depth
+
+	decremented.RecursionDepths = recursionDepths
+	return decremented, nil
+}
+
+// findRelation returns the relation of the namespace with the given name, or nil if there is none.
+func findRelation(nsDef *core.NamespaceDefinition, relationName string) *core.Relation {
+	for _, relation := range nsDef.Relation {
+		if relation.Name == relationName {
+			return relation
+		}
 	}
+	return nil
 }
 
 func max(x, y uint32) uint32 {
@@ -3,7 +3,6 @@ package graph
 import (
 	"context"
 	"errors"
-	"sync"
 
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
@@ -14,16 +13,16 @@ import (
 )
 
 // NewConcurrentLookup creates and instance of ConcurrentLookup.
-func NewConcurrentLookup(c dispatch.Check, r dispatch.ReachableResources, concurrencyLimit uint16) *ConcurrentLookup {
-	return &ConcurrentLookup{c, r, concurrencyLimit}
+func NewConcurrentLookup(c dispatch.Check, r dispatch.ReachableResources, concurrencyLimits ConcurrencyLimits) *ConcurrentLookup {
+	return &ConcurrentLookup{c, r, concurrencyLimits}
 }
 
 // ConcurrentLookup exposes a method to perform Lookup requests, and delegates subproblems to the
 // provided dispatch.Lookup instance.
 type ConcurrentLookup struct {
-	c                dispatch.Check
-	r                dispatch.ReachableResources
-	concurrencyLimit uint16
+	c                 dispatch.Check
+	r                 dispatch.ReachableResources
+	concurrencyLimits ConcurrencyLimits
 }
 
 // ValidatedLookupRequest represents a request after it has been validated and parsed for internal
@@ -33,61 +32,50 @@ type ValidatedLookupRequest struct {
 	Revision datastore.Revision
 }
 
-type collectingStream struct {
+// checkingStream receives the reachable resources for a lookup, publishing those which
+// definitely have permission and queueing the remainder to be checked.
+type checkingStream struct {
 	checker *parallelChecker
-	req     ValidatedLookupRequest
 	context context.Context
-
-	dispatchCount       uint32
-	cachedDispatchCount uint32
-	depthRequired       uint32
-
-	mu sync.Mutex
 }
 
-func (ls *collectingStream) Context() context.Context {
+func (ls *checkingStream) Context() context.Context {
 	return ls.context
 }
 
-func (ls *collectingStream) Publish(result *v1.DispatchReachableResourcesResponse) error {
+func (ls *checkingStream) Publish(result *v1.DispatchReachableResourcesResponse) error {
 	if result == nil {
 		panic("Got nil result")
 	}
 
-	func() {
-		ls.mu.Lock()
-		defer ls.mu.Unlock()
-
-		ls.dispatchCount += result.Metadata.DispatchCount
-		ls.cachedDispatchCount += result.Metadata.CachedDispatchCount
-		ls.depthRequired = max(result.Metadata.DepthRequired, ls.depthRequired)
-	}()
-
-	for _, found := range result.Resources {
-		if found.ResultStatus == v1.ReachableResource_HAS_PERMISSION {
-			ls.checker.AddResolvedResource(&v1.ResolvedResource{
-				ResourceId:     found.ResourceId,
+	found := make([]*v1.ResolvedResource, 0, len(result.Resources))
+	for _, reachable := range result.Resources {
+		if reachable.ResultStatus == v1.ReachableResource_HAS_PERMISSION {
+			found = append(found, &v1.ResolvedResource{
+				ResourceId:     reachable.ResourceId,
 				Permissionship: v1.ResolvedResource_HAS_PERMISSION,
 			})
 			continue
 		}
 
-		ls.checker.QueueToCheck(found.ResourceId)
+		ls.checker.QueueToCheck(reachable.ResourceId)
 	}
-	return nil
+
+	return ls.checker.AddResolvedResources(found, nil, result.Metadata)
 }
 
-func (cl *ConcurrentLookup) LookupViaReachability(ctx context.Context, req ValidatedLookupRequest) (*v1.DispatchLookupResponse, error) {
+// LookupViaReachability performs a lookup by finding all reachable resources and checking those
+// which are not already known to have permission. Resources are published to the stream in
+// chunks as they are found, rather than being collected into a single response.
+func (cl *ConcurrentLookup) LookupViaReachability(req ValidatedLookupRequest, stream dispatch.LookupStream) error {
 	if req.Subject.ObjectId == tuple.PublicWildcard {
-		resp := lookupResultError(NewErrInvalidArgument(errors.New("cannot perform lookup on wildcard")), emptyMetadata)
-		return resp.Resp, resp.Err
+		return NewErrInvalidArgument(errors.New("cannot perform lookup on wildcard"))
 	}
 
-	cancelCtx, cancel := context.WithCancel(ctx)
+	cancelCtx, cancel := context.WithCancel(stream.Context())
 	defer cancel()
 
-	checker := newParallelChecker(cancelCtx, cancel, cl.c, req, cl.concurrencyLimit)
-	stream := &collectingStream{checker, req, cancelCtx, 0, 0, 0, sync.Mutex{}}
+	checker := newParallelChecker(cancelCtx, cancel, cl.c, req, cl.concurrencyLimits.limitFor(req.ObjectRelation), stream)
 
 	// Start the checker.
 	checker.Start()
@@ -104,52 +92,17 @@ func (cl *ConcurrentLookup) LookupViaReachability(ctx context.Context, req Valid
 		},
 		SubjectIds: []string{req.Subject.ObjectId},
 		Metadata:   req.Metadata,
-	}, stream)
-	if err != nil {
-		resp := lookupResultError(err, emptyMetadata)
-		return resp.Resp, resp.Err
+	}, &checkingStream{checker, cancelCtx})
+	if err != nil && !checker.LimitReached() {
+		cancel()
+		_ = checker.Wait()
+		return err
 	}
 
 	// Wait for the checker to finish.
-	allowed, err := checker.Wait()
-	if err != nil {
-		resp := lookupResultError(err, emptyMetadata)
-		return resp.Resp, resp.Err
-	}
-
-	res := lookupResult(allowed, req, &v1.ResponseMeta{
-		DispatchCount:       stream.dispatchCount + checker.DispatchCount() + 1, // +1 for the lookup
-		CachedDispatchCount: stream.cachedDispatchCount + checker.CachedDispatchCount(),
-		DepthRequired:       max(stream.depthRequired, checker.DepthRequired()) + 1, // +1 for the lookup
-	})
-	return res.Resp, res.Err
-}
-
-func lookupResult(foundResources []*v1.ResolvedResource, req ValidatedLookupRequest, subProblemMetadata *v1.ResponseMeta) LookupResult {
-	limitedResources := limitedSlice(foundResources, req.Limit)
-
-	return LookupResult{
-		&v1.DispatchLookupResponse{
-			Metadata:          ensureMetadata(subProblemMetadata),
-			ResolvedResources: limitedResources,
-		},
-		nil,
-	}
-}
-
-func limitedSlice[T any](slice []T, limit uint32) []T {
-	if len(slice) > int(limit) {
-		return slice[0:limit]
+	if err := checker.Wait(); err != nil {
+		return err
 	}
 
-	return slice
-}
-
-func lookupResultError(err error, subProblemMetadata *v1.ResponseMeta) LookupResult {
-	return LookupResult{
-		&v1.DispatchLookupResponse{
-			Metadata: ensureMetadata(subProblemMetadata),
-		},
-		err,
-	}
+	return checker.PublishRemaining()
 }
@@ -27,13 +27,13 @@ type ValidatedLookupSubjectsRequest struct {
 }
 
 // NewConcurrentLookupSubjects creates an instance of ConcurrentLookupSubjects.
-func NewConcurrentLookupSubjects(d dispatch.LookupSubjects, concurrencyLimit uint16) *ConcurrentLookupSubjects {
-	return &ConcurrentLookupSubjects{d, concurrencyLimit}
+func NewConcurrentLookupSubjects(d dispatch.LookupSubjects, concurrencyLimits ConcurrencyLimits) *ConcurrentLookupSubjects {
+	return &ConcurrentLookupSubjects{d, concurrencyLimits}
 }
 
 type ConcurrentLookupSubjects struct {
-	d                dispatch.LookupSubjects
-	concurrencyLimit uint16
+	d                 dispatch.LookupSubjects
+	concurrencyLimits ConcurrencyLimits
 }
 
 func (cl *ConcurrentLookupSubjects) LookupSubjects(
@@ -59,7 +59,7 @@ func (cl *ConcurrentLookupSubjects) LookupSubjects(
 
 	ds := datastoremw.MustFromContext(ctx)
 	reader := ds.SnapshotReader(req.Revision)
-	_, relation, err := namespace.ReadNamespaceAndRelation(
+	nsDef, relation, err := namespace.ReadNamespaceAndRelation(
 		ctx,
 		req.ResourceRelation.Namespace,
 		req.ResourceRelation.Relation,
@@ -73,7 +73,7 @@ func (cl *ConcurrentLookupSubjects) LookupSubjects(
 		return cl.lookupDirectSubjects(ctx, req, stream, relation, reader)
 	}
 
-	return cl.lookupViaRewrite(ctx, req, stream, relation.UsersetRewrite)
+	return cl.lookupViaRewrite(ctx, req, stream, nsDef, relation.UsersetRewrite)
 }
 
 func subjectsForConcreteIds(subjectIds []string) map[string]*v1.FoundSubjects {
@@ -139,7 +139,7 @@ func (cl *ConcurrentLookupSubjects) lookupDirectSubjects(
 		}
 	}
 
-	return cl.dispatchTo(ctx, req, toDispatchByType, relationshipsBySubjectONR, stream)
+	return cl.dispatchTo(ctx, req, relation, toDispatchByType, relationshipsBySubjectONR, stream)
 }
 
 func (cl *ConcurrentLookupSubjects) lookupViaComputed(
@@ -175,10 +175,7 @@ func (cl *ConcurrentLookupSubjects) lookupViaComputed(
 		},
 		ResourceIds:     parentRequest.ResourceIds,
 		SubjectRelation: parentRequest.SubjectRelation,
-		Metadata: &v1.ResolverMeta{
-			AtRevision:     parentRequest.Revision.String(),
-			DepthRemaining: parentRequest.Metadata.DepthRemaining - 1,
-		},
+		Metadata:        decrementDepth(parentRequest.Metadata),
 	}, stream)
 }
 
@@ -186,9 +183,17 @@ func (cl *ConcurrentLookupSubjects) lookupViaTupleToUserset(
 	ctx context.Context,
 	parentRequest ValidatedLookupSubjectsRequest,
 	parentStream dispatch.LookupSubjectsStream,
+	nsDef *core.NamespaceDefinition,
 	ttu *core.TupleToUserset,
 ) error {
 	ds := datastoremw.MustFromContext(ctx).SnapshotReader(parentRequest.Revision)
+
+	// The tupleset relation may define a maximum recursion depth.
+	tuplesetRelation := findRelation(nsDef, ttu.Tupleset.Relation)
+	if tuplesetRelation == nil {
+		return nil
+	}
+
 	it, err := ds.QueryRelationships(ctx, datastore.RelationshipsFilter{
 		ResourceType:             parentRequest.ResourceRelation.Namespace,
 		OptionalResourceRelation: ttu.Tupleset.Relation,
@@ -237,25 +242,26 @@ func (cl *ConcurrentLookupSubjects) lookupViaTupleToUserset(
 		return err
 	}
 
-	return cl.dispatchTo(ctx, parentRequest, toDispatchByComputedRelationType, relationshipsBySubjectONR, parentStream)
+	return cl.dispatchTo(ctx, parentRequest, tuplesetRelation, toDispatchByComputedRelationType, relationshipsBySubjectONR, parentStream)
 }
 
 func (cl *ConcurrentLookupSubjects) lookupViaRewrite(
 	ctx context.Context,
 	req ValidatedLookupSubjectsRequest,
 	stream dispatch.LookupSubjectsStream,
+	nsDef *core.NamespaceDefinition,
 	usr *core.UsersetRewrite,
 ) error {
 	switch rw := usr.RewriteOperation.(type) {
 	case *core.UsersetRewrite_Union:
 		log.Ctx(ctx).Trace().Msg("union")
-		return cl.lookupSetOperation(ctx, req, rw.Union, newLookupSubjectsUnion(stream))
+		return cl.lookupSetOperation(ctx, req, nsDef, rw.Union, newLookupSubjectsUnion(stream))
 	case *core.UsersetRewrite_Intersection:
 		log.Ctx(ctx).Trace().Msg("intersection")
-		return cl.lookupSetOperation(ctx, req, rw.Intersection, newLookupSubjectsIntersection(stream))
+		return cl.lookupSetOperation(ctx, req, nsDef, rw.Intersection, newLookupSubjectsIntersection(stream))
 	case *core.UsersetRewrite_Exclusion:
 		log.Ctx(ctx).Trace().Msg("exclusion")
-		return cl.lookupSetOperation(ctx, req, rw.Exclusion, newLookupSubjectsExclusion(stream))
+		return cl.lookupSetOperation(ctx, req, nsDef, rw.Exclusion, newLookupSubjectsExclusion(stream))
 	default:
 		return fmt.Errorf("unknown kind of rewrite in lookup subjects")
 	}
@@ -264,6 +270,7 @@ func (cl *ConcurrentLookupSubjects) lookupViaRewrite(
 func (cl *ConcurrentLookupSubjects) lookupSetOperation(
 	ctx context.Context,
 	req ValidatedLookupSubjectsRequest,
+	nsDef *core.NamespaceDefinition,
 	so *core.SetOperation,
 	reducer lookupSubjectsReducer,
 ) error {
@@ -271,7 +278,7 @@ func (cl *ConcurrentLookupSubjects) lookupSetOperation(
 	defer checkCancel()
 
 	g, subCtx := errgroup.WithContext(cancelCtx)
-	g.SetLimit(int(cl.concurrencyLimit))
+	g.SetLimit(int(cl.concurrencyLimits.limitFor(req.ResourceRelation)))
 
 	for index, childOneof := range so.Child {
 		stream := reducer.ForIndex(subCtx, index)
@@ -287,12 +294,12 @@ func (cl *ConcurrentLookupSubjects) lookupSetOperation(
 
 		case *core.SetOperation_Child_UsersetRewrite:
 			g.Go(func() error {
-				return cl.lookupViaRewrite(subCtx, req, stream, child.UsersetRewrite)
+				return cl.lookupViaRewrite(subCtx, req, stream, nsDef, child.UsersetRewrite)
 			})
 
 		case *core.SetOperation_Child_TupleToUserset:
 			g.Go(func() error {
-				return cl.lookupViaTupleToUserset(subCtx, req, stream, child.TupleToUserset)
+				return cl.lookupViaTupleToUserset(subCtx, req, stream, nsDef, child.TupleToUserset)
 			})
 
 		case *core.SetOperation_Child_XNil:
@@ -312,9 +319,12 @@ func (cl *ConcurrentLookupSubjects) lookupSetOperation(
 	return reducer.CompletedChildOperations()
 }
 
+// dispatchTo dispatches the subjects found via the relation as the resources of the next step,
+// counting the traversal towards the relation's maximum recursion depth.
 func (cl *ConcurrentLookupSubjects) dispatchTo(
 	ctx context.Context,
 	parentRequest ValidatedLookupSubjectsRequest,
+	relation *core.Relation,
 	toDispatchByType *datasets.SubjectByTypeSet,
 	relationshipsBySubjectONR *util.MultiMap[string, *core.RelationTuple],
 	parentStream dispatch.LookupSubjectsStream,
@@ -327,9 +337,15 @@ func (cl *ConcurrentLookupSubjects) dispatchTo(
 	defer checkCancel()
 
 	g, subCtx := errgroup.WithContext(cancelCtx)
-	g.SetLimit(int(cl.concurrencyLimit))
+	g.SetLimit(int(cl.concurrencyLimits.limitFor(parentRequest.ResourceRelation)))
 
 	toDispatchByType.ForEachType(func(resourceType *core.RelationReference, foundSubjects datasets.SubjectSet) {
+		metadata, err := dispatchMetadata(parentRequest.Metadata, relation, parentRequest.ResourceRelation.Namespace, resourceType.Namespace)
+		if err != nil {
+			g.Go(func() error { return err })
+			return
+		}
+
 		slice := foundSubjects.AsSlice()
 		resourceIds := make([]string, 0, len(slice))
 		for _, foundSubject := range slice {
@@ -410,10 +426,7 @@ func (cl *ConcurrentLookupSubjects) dispatchTo(
 					ResourceRelation: resourceType,
 					ResourceIds:      resourceIdChunk,
 					SubjectRelation:  parentRequest.SubjectRelation,
-					Metadata: &v1.ResolverMeta{
-						AtRevision:     parentRequest.Revision.String(),
-						DepthRemaining: parentRequest.Metadata.DepthRemaining - 1,
-					},
+					Metadata:         metadata,
 				}, stream)
 			})
 		})
@@ -15,7 +15,8 @@ import (
 )
 
 // parallelChecker is a helper for initiating checks over a large set of resources of a specific
-// type, for a specific subject, and putting the results concurrently into a set.
+// type, for a specific subject, and publishing the resources found to have permission to a lookup
+// stream in chunks as they are found.
 type parallelChecker struct {
 	c        dispatch.Check
 	g        *errgroup.Group
@@ -28,17 +29,36 @@ type parallelChecker struct {
 	lookupRequest ValidatedLookupRequest
 	maxConcurrent uint16
 
-	foundResourceIDs map[string]*v1.ResolvedResource
+	stream dispatch.LookupStream
 
+	// publishedResourceIDs are the IDs of resources already published as having permission.
+	publishedResourceIDs *util.Set[string]
+
+	// cursorResourceIDs are the IDs of the resources in the cursor of the lookup, whose results were
+	// sent before the lookup was resumed, and which are therefore skipped.
+	cursorResourceIDs *util.Set[string]
+
+	// settledResourceIDs are the IDs of the resources whose results were settled since the last chunk
+	// was published, which are sent as the cursor of the next chunk.
+	settledResourceIDs []string
+
+	// conditionalResources are the resources found to conditionally have permission. They are
+	// held back until all checks have completed, as the same resource may yet be found to
+	// have permission unconditionally.
+	conditionalResources map[string]*v1.ResolvedResource
+
+	// The metadata for the work done since the last chunk was published.
 	dispatchCount       uint32
 	cachedDispatchCount uint32
 	depthRequired       uint32
 
+	limitReached bool
+
 	mu sync.Mutex
 }
 
 // newParallelChecker creates a new parallel checker, for a given subject.
-func newParallelChecker(ctx context.Context, cancel func(), c dispatch.Check, req ValidatedLookupRequest, maxConcurrent uint16) *parallelChecker {
+func newParallelChecker(ctx context.Context, cancel func(), c dispatch.Check, req ValidatedLookupRequest, maxConcurrent uint16, stream dispatch.LookupStream) *parallelChecker {
 	g, checkCtx := errgroup.WithContext(ctx)
 	toCheck := make(chan string, maxConcurrent)
 	return &parallelChecker{
@@ -54,51 +74,78 @@ func newParallelChecker(ctx context.Context, cancel func(), c dispatch.Check, re
 		lookupRequest: req,
 		maxConcurrent: maxConcurrent,
 
-		foundResourceIDs:    map[string]*v1.ResolvedResource{},
-		dispatchCount:       0,
-		cachedDispatchCount: 0,
-		depthRequired:       0,
+		stream: stream,
+
+		publishedResourceIDs: util.NewSet[string](),
+		cursorResourceIDs:    util.NewSet[string](req.OptionalCursor.GetResourceIds()...),
+		conditionalResources: map[string]*v1.ResolvedResource{},
+		dispatchCount:        0,
+		cachedDispatchCount:  0,
+		depthRequired:        0,
 
 		mu: sync.Mutex{},
 	}
 }
 
-// AddResolvedResource adds a resource that has been already checked to the set.
-func (pc *parallelChecker) AddResolvedResource(resolvedResource *v1.ResolvedResource) {
+// AddResolvedResources adds resources that have been already checked, publishing those with
+// permission as a chunk along with the metadata for the work done since the last chunk. The
+// resources found to not have permission are published only in the cursor of the chunk.
+func (pc *parallelChecker) AddResolvedResources(resolvedResources []*v1.ResolvedResource, withoutPermission []string, metadata *v1.ResponseMeta) error {
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
-	pc.addResultsUnsafe(resolvedResource)
-}
 
-// DispatchCount returns the number of dispatches used for checks.
-func (pc *parallelChecker) DispatchCount() uint32 {
-	return pc.dispatchCount
-}
+	pc.updateStatsUnsafe(metadata)
+
+	toPublish := make([]*v1.ResolvedResource, 0, len(resolvedResources))
+	for _, resolvedResource := range resolvedResources {
+		if pc.addResultUnsafe(resolvedResource) {
+			toPublish = append(toPublish, resolvedResource)
+			pc.settledResourceIDs = append(pc.settledResourceIDs, resolvedResource.ResourceId)
+		}
+	}
+
+	if !pc.limitReached {
+		pc.settledResourceIDs = append(pc.settledResourceIDs, withoutPermission...)
+	}
+
+	if len(pc.settledResourceIDs) == 0 {
+		return nil
+	}
 
-// CachedDispatchCount returns the number of cached dispatches used for checks.
-func (pc *parallelChecker) CachedDispatchCount() uint32 {
-	return pc.cachedDispatchCount
+	return pc.publishUnsafe(toPublish, 0)
 }
 
-// DepthRequired returns the maximum depth required for the checks.
-func (pc *parallelChecker) DepthRequired() uint32 {
-	return pc.depthRequired
+// LimitReached returns whether the limit of the lookup has been reached, in which case any
+// further work has been canceled.
+func (pc *parallelChecker) LimitReached() bool {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.limitReached
 }
 
-func (pc *parallelChecker) addResultsUnsafe(resolvedResource *v1.ResolvedResource) {
-	// If the result being added is conditional and we've already found a valid permission, skip.
+// addResultUnsafe adds a resolved resource, returning whether it should be published now.
+func (pc *parallelChecker) addResultUnsafe(resolvedResource *v1.ResolvedResource) bool {
+	if pc.limitReached || pc.publishedResourceIDs.Has(resolvedResource.ResourceId) || pc.cursorResourceIDs.Has(resolvedResource.ResourceId) {
+		return false
+	}
+
 	if resolvedResource.Permissionship == v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION {
-		existing, ok := pc.foundResourceIDs[resolvedResource.ResourceId]
-		if ok && existing.Permissionship == v1.ResolvedResource_HAS_PERMISSION {
-			return
-		}
+		pc.conditionalResources[resolvedResource.ResourceId] = resolvedResource
+		pc.checkLimitUnsafe()
+		return false
 	}
 
-	pc.foundResourceIDs[resolvedResource.ResourceId] = resolvedResource
-	if len(pc.foundResourceIDs) >= int(pc.lookupRequest.Limit) {
+	delete(pc.conditionalResources, resolvedResource.ResourceId)
+	pc.publishedResourceIDs.Add(resolvedResource.ResourceId)
+	pc.checkLimitUnsafe()
+	return true
+}
+
+func (pc *parallelChecker) checkLimitUnsafe() {
+	if pc.publishedResourceIDs.Len()+len(pc.conditionalResources) >= int(pc.lookupRequest.Limit) {
 		// Cancel any further work
+		pc.limitReached = true
 		pc.cancel()
-		return
 	}
 }
 
@@ -108,13 +155,32 @@ func (pc *parallelChecker) updateStatsUnsafe(metadata *v1.ResponseMeta) {
 	pc.depthRequired = max(pc.depthRequired, metadata.DepthRequired)
 }
 
+// publishUnsafe publishes a chunk of resources, along with the metadata for the work done since
+// the previous chunk and the resources settled since then. The depth required is that of the
+// lookup thus far.
+func (pc *parallelChecker) publishUnsafe(resolvedResources []*v1.ResolvedResource, additionalDispatchCount uint32) error {
+	err := pc.stream.Publish(&v1.DispatchLookupResponse{
+		Metadata: &v1.ResponseMeta{
+			DispatchCount:       pc.dispatchCount + additionalDispatchCount,
+			CachedDispatchCount: pc.cachedDispatchCount,
+			DepthRequired:       pc.depthRequired + 1, // +1 for the lookup
+		},
+		ResolvedResources:   resolvedResources,
+		AfterResponseCursor: &v1.Cursor{ResourceIds: pc.settledResourceIDs},
+	})
+
+	pc.dispatchCount = 0
+	pc.cachedDispatchCount = 0
+	pc.settledResourceIDs = nil
+	return err
+}
+
 // QueueToCheck queues a resource ID to be checked.
 func (pc *parallelChecker) QueueToCheck(resourceID string) bool {
 	queue := func() bool {
 		pc.mu.Lock()
 		defer pc.mu.Unlock()
-		if len(pc.foundResourceIDs) >= int(pc.lookupRequest.Limit) {
-			close(pc.toCheck)
+		if pc.limitReached || pc.cursorResourceIDs.Has(resourceID) {
 			return false
 		}
 
@@ -124,15 +190,20 @@ func (pc *parallelChecker) QueueToCheck(resourceID string) bool {
 		return false
 	}
 
-	pc.toCheck <- resourceID
-	return true
+	select {
+	case pc.toCheck <- resourceID:
+		return true
+	case <-pc.checkCtx.Done():
+		return false
+	}
 }
 
 // Start starts the parallel checks over those items added via QueueToCheck.
 func (pc *parallelChecker) Start() {
 	meta := &v1.ResolverMeta{
-		AtRevision:     pc.lookupRequest.Revision.String(),
-		DepthRemaining: pc.lookupRequest.Metadata.DepthRemaining,
+		AtRevision:      pc.lookupRequest.Revision.String(),
+		DepthRemaining:  pc.lookupRequest.Metadata.DepthRemaining,
+		RecursionDepths: pc.lookupRequest.Metadata.RecursionDepths,
 	}
 
 	pc.g.Go(func() error {
@@ -179,25 +250,29 @@ func (pc *parallelChecker) Start() {
 					return err
 				}
 
-				pc.mu.Lock()
-				pc.updateStatsUnsafe(resultsMeta)
-
+				found := make([]*v1.ResolvedResource, 0, len(results))
+				withoutPermission := make([]string, 0, len(collected))
+				for _, resourceID := range collected {
+					result, ok := results[resourceID]
+					if !ok || result.Membership == v1.ResourceCheckResult_NOT_MEMBER {
+						withoutPermission = append(withoutPermission, resourceID)
+					}
+				}
 				for resourceID, result := range results {
 					if result.Membership == v1.ResourceCheckResult_MEMBER {
-						pc.addResultsUnsafe(&v1.ResolvedResource{
+						found = append(found, &v1.ResolvedResource{
 							ResourceId:     resourceID,
 							Permissionship: v1.ResolvedResource_HAS_PERMISSION,
 						})
 					} else if result.Membership == v1.ResourceCheckResult_CAVEATED_MEMBER {
-						pc.addResultsUnsafe(&v1.ResolvedResource{
+						found = append(found, &v1.ResolvedResource{
 							ResourceId:             resourceID,
 							Permissionship:         v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION,
 							MissingRequiredContext: result.MissingExprFields,
 						})
 					}
 				}
-				pc.mu.Unlock()
-				return nil
+				return pc.AddResolvedResources(found, withoutPermission, resultsMeta)
 			})
 		}
 		if err := sem.Acquire(pc.checkCtx, int64(pc.maxConcurrent)); err != nil {
@@ -207,14 +282,23 @@ func (pc *parallelChecker) Start() {
 	})
 }
 
-// Wait waits for the parallel checker to finish performing all of its
-// checks and returns the set of resources that checked, along with whether an
-// error occurred. Once called, no new items can be added via QueueToCheck.
-func (pc *parallelChecker) Wait() ([]*v1.ResolvedResource, error) {
+// Wait waits for the parallel checker to finish performing all of its checks, returning
+// whether an error occurred. Once called, no new items can be added via QueueToCheck.
+func (pc *parallelChecker) Wait() error {
 	close(pc.toCheck)
-	if err := pc.g.Wait(); err != nil {
-		return nil, err
+	if err := pc.g.Wait(); err != nil && !pc.LimitReached() {
+		return err
 	}
+	return nil
+}
+
+// PublishRemaining publishes the resources found to conditionally have permission, along with the
+// metadata for the work done since the last chunk was published. It must be called after Wait.
+func (pc *parallelChecker) PublishRemaining() error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
 
-	return maps.Values(pc.foundResourceIDs), nil
+	remaining := maps.Values(pc.conditionalResources)
+	pc.settledResourceIDs = append(pc.settledResourceIDs, maps.Keys(pc.conditionalResources)...)
+	return pc.publishUnsafe(remaining, 1) // +1 for the lookup
 }
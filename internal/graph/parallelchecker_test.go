@@ -6,52 +6,121 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/authzed/spicedb/internal/dispatch"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 )
 
 func TestParallelCheckerDirectOverload(t *testing.T) {
+	stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupResponse](context.Background())
 	pc := newParallelChecker(context.Background(), func() {}, nil, ValidatedLookupRequest{
 		DispatchLookupRequest: &v1.DispatchLookupRequest{
 			Limit: 50,
 		},
-	}, 10)
+	}, 10, stream)
 
-	// Add a conditional item and ensure it is added.
-	pc.addResultsUnsafe(&v1.ResolvedResource{
+	// Add a conditional item and ensure it is held back.
+	require.NoError(t, pc.AddResolvedResources([]*v1.ResolvedResource{{
 		ResourceId:     "foo",
 		Permissionship: v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION,
-	})
+	}}, nil, emptyMetadata))
 
-	require.Equal(t, v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION, pc.foundResourceIDs["foo"].Permissionship)
+	require.Empty(t, stream.Results())
+	require.Equal(t, v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION, pc.conditionalResources["foo"].Permissionship)
 
-	// Add a concrete item and ensure it overloads.
-	pc.addResultsUnsafe(&v1.ResolvedResource{
+	// Add a concrete item and ensure it overloads and is published.
+	require.NoError(t, pc.AddResolvedResources([]*v1.ResolvedResource{{
 		ResourceId:     "foo",
 		Permissionship: v1.ResolvedResource_HAS_PERMISSION,
-	})
+	}}, nil, emptyMetadata))
 
-	require.Equal(t, v1.ResolvedResource_HAS_PERMISSION, pc.foundResourceIDs["foo"].Permissionship)
+	require.Len(t, stream.Results(), 1)
+	require.Equal(t, "foo", stream.Results()[0].ResolvedResources[0].ResourceId)
+	require.Empty(t, pc.conditionalResources)
 
 	// Add a conditional item and ensure it is ignored.
-	pc.addResultsUnsafe(&v1.ResolvedResource{
+	require.NoError(t, pc.AddResolvedResources([]*v1.ResolvedResource{{
 		ResourceId:     "foo",
 		Permissionship: v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION,
-	})
+	}}, nil, emptyMetadata))
 
-	require.Equal(t, v1.ResolvedResource_HAS_PERMISSION, pc.foundResourceIDs["foo"].Permissionship)
+	require.Len(t, stream.Results(), 1)
+	require.Empty(t, pc.conditionalResources)
+
+	// Ensure the remaining metadata is published without the resource again.
+	require.NoError(t, pc.PublishRemaining())
+	require.Len(t, stream.Results(), 2)
+	require.Empty(t, stream.Results()[1].ResolvedResources)
+}
+
+func TestParallelCheckerPublishesChunks(t *testing.T) {
+	stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupResponse](context.Background())
+	pc := newParallelChecker(context.Background(), func() {}, nil, ValidatedLookupRequest{
+		DispatchLookupRequest: &v1.DispatchLookupRequest{
+			Limit: 50,
+		},
+	}, 10, stream)
+
+	require.NoError(t, pc.AddResolvedResources([]*v1.ResolvedResource{
+		{ResourceId: "foo", Permissionship: v1.ResolvedResource_HAS_PERMISSION},
+		{ResourceId: "bar", Permissionship: v1.ResolvedResource_CONDITIONALLY_HAS_PERMISSION},
+	}, nil, &v1.ResponseMeta{DispatchCount: 2, DepthRequired: 1}))
+	require.NoError(t, pc.AddResolvedResources([]*v1.ResolvedResource{
+		{ResourceId: "baz", Permissionship: v1.ResolvedResource_HAS_PERMISSION},
+	}, []string{"qux"}, &v1.ResponseMeta{DispatchCount: 3, CachedDispatchCount: 1, DepthRequired: 3}))
+	require.NoError(t, pc.PublishRemaining())
+
+	results := stream.Results()
+	require.Len(t, results, 3)
+
+	require.Equal(t, "foo", results[0].ResolvedResources[0].ResourceId)
+	require.Equal(t, &v1.ResponseMeta{DispatchCount: 2, DepthRequired: 2}, results[0].Metadata)
+	require.Equal(t, []string{"foo"}, results[0].AfterResponseCursor.ResourceIds)
+
+	require.Equal(t, "baz", results[1].ResolvedResources[0].ResourceId)
+	require.Equal(t, &v1.ResponseMeta{DispatchCount: 3, CachedDispatchCount: 1, DepthRequired: 4}, results[1].Metadata)
+	require.Equal(t, []string{"baz", "qux"}, results[1].AfterResponseCursor.ResourceIds)
+
+	require.Equal(t, "bar", results[2].ResolvedResources[0].ResourceId)
+	require.Equal(t, &v1.ResponseMeta{DispatchCount: 1, DepthRequired: 4}, results[2].Metadata)
+	require.Equal(t, []string{"bar"}, results[2].AfterResponseCursor.ResourceIds)
+}
+
+func TestParallelCheckerSkipsCursor(t *testing.T) {
+	stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupResponse](context.Background())
+	pc := newParallelChecker(context.Background(), func() {}, nil, ValidatedLookupRequest{
+		DispatchLookupRequest: &v1.DispatchLookupRequest{
+			Limit:          50,
+			OptionalCursor: &v1.Cursor{ResourceIds: []string{"foo", "bar"}},
+		},
+	}, 10, stream)
+
+	// Resources in the cursor are neither checked nor published again.
+	require.False(t, pc.QueueToCheck("bar"))
+	require.NoError(t, pc.AddResolvedResources([]*v1.ResolvedResource{
+		{ResourceId: "foo", Permissionship: v1.ResolvedResource_HAS_PERMISSION},
+		{ResourceId: "baz", Permissionship: v1.ResolvedResource_HAS_PERMISSION},
+	}, nil, emptyMetadata))
+
+	results := stream.Results()
+	require.Len(t, results, 1)
+	require.Len(t, results[0].ResolvedResources, 1)
+	require.Equal(t, "baz", results[0].ResolvedResources[0].ResourceId)
+	require.Equal(t, []string{"baz"}, results[0].AfterResponseCursor.ResourceIds)
 }
 
 func TestQueueToCheckLimit(t *testing.T) {
+	stream := dispatch.NewCollectingDispatchStream[*v1.DispatchLookupResponse](context.Background())
 	pc := newParallelChecker(context.Background(), func() {}, nil, ValidatedLookupRequest{
 		DispatchLookupRequest: &v1.DispatchLookupRequest{
 			Limit: 1,
 		},
-	}, 10)
+	}, 10, stream)
 
-	pc.addResultsUnsafe(&v1.ResolvedResource{
+	require.NoError(t, pc.AddResolvedResources([]*v1.ResolvedResource{{
 		ResourceId:     "foo",
 		Permissionship: v1.ResolvedResource_HAS_PERMISSION,
-	})
+	}}, nil, emptyMetadata))
+	require.True(t, pc.LimitReached())
 
 	// Queue a second and ensure it is ignored.
 	require.False(t, pc.QueueToCheck("bar"))
@@ -19,15 +19,15 @@ import (
 )
 
 // NewConcurrentReachableResources creates an instance of ConcurrentReachableResources.
-func NewConcurrentReachableResources(d dispatch.ReachableResources, concurrencyLimit uint16) *ConcurrentReachableResources {
-	return &ConcurrentReachableResources{d, concurrencyLimit}
+func NewConcurrentReachableResources(d dispatch.ReachableResources, concurrencyLimits ConcurrencyLimits) *ConcurrentReachableResources {
+	return &ConcurrentReachableResources{d, concurrencyLimits}
 }
 
 // ConcurrentReachableResources exposes a method to perform ReachableResources requests, and
 // delegates subproblems to the provided dispatch.ReachableResources instance.
 type ConcurrentReachableResources struct {
-	d                dispatch.ReachableResources
-	concurrencyLimit uint16
+	d                 dispatch.ReachableResources
+	concurrencyLimits ConcurrencyLimits
 }
 
 // ValidatedReachableResourcesRequest represents a request after it has been validated and parsed for internal
@@ -101,7 +101,7 @@ func (crr *ConcurrentReachableResources) ReachableResources(
 	defer checkCancel()
 
 	g, subCtx := errgroup.WithContext(cancelCtx)
-	g.SetLimit(int(crr.concurrencyLimit))
+	g.SetLimit(int(crr.concurrencyLimits.limitFor(req.ResourceRelation)))
 
 	// For each entrypoint, load the necessary data and re-dispatch if a subproblem was found.
 	for _, entrypoint := range entrypoints {
@@ -122,6 +122,7 @@ func (crr *ConcurrentReachableResources) ReachableResources(
 			err := crr.redispatchOrReport(
 				subCtx,
 				rewrittenSubjectRelation,
+				nil,
 				subjectIDsToResourcesMap(rewrittenSubjectRelation, req.SubjectIds),
 				rg,
 				g,
@@ -163,6 +164,12 @@ func (crr *ConcurrentReachableResources) lookupRelationEntrypoint(ctx context.Co
 		return err
 	}
 
+	// The relation may define a maximum recursion depth.
+	relation, ok := relTypeSystem.GetRelation(relationReference.Relation)
+	if !ok {
+		return namespace.NewRelationNotFoundErr(relationReference.Namespace, relationReference.Relation)
+	}
+
 	// Build the list of subjects to lookup based on the type information available.
 	isDirectAllowed, err := relTypeSystem.IsAllowedDirectRelation(
 		relationReference.Relation,
@@ -214,7 +221,7 @@ func (crr *ConcurrentReachableResources) lookupRelationEntrypoint(ctx context.Co
 		defer it.Close()
 
 		return crr.chunkedRedispatch(relationReference, it, func(rsm resourcesSubjectMap) error {
-			return crr.redispatchOrReport(ctx, relationReference, rsm, rg, g, entrypoint, stream, req, dispatched)
+			return crr.redispatchOrReport(ctx, relationReference, relation, rsm, rg, g, entrypoint, stream, req, dispatched)
 		})
 	})
 
@@ -279,6 +286,12 @@ func (crr *ConcurrentReachableResources) lookupTTUEntrypoint(ctx context.Context
 
 	tuplesetRelation := entrypoint.TuplesetRelation()
 
+	// The tupleset relation may define a maximum recursion depth.
+	traversedRelation, ok := ttuTypeSystem.GetRelation(tuplesetRelation)
+	if !ok {
+		return namespace.NewRelationNotFoundErr(containingRelation.Namespace, tuplesetRelation)
+	}
+
 	// Determine the subject relation(s) for which to search. Note that we need to do so
 	// for both `...` as well as the subject's defined relation, as either is applicable in
 	// the tupleset (the relation is ignored when following the arrow).
@@ -332,7 +345,7 @@ func (crr *ConcurrentReachableResources) lookupTTUEntrypoint(ctx context.Context
 		}
 
 		return crr.chunkedRedispatch(tuplesetRelationReference, it, func(rsm resourcesSubjectMap) error {
-			return crr.redispatchOrReport(ctx, containingRelation, rsm, rg, g, entrypoint, stream, req, dispatched)
+			return crr.redispatchOrReport(ctx, containingRelation, traversedRelation, rsm, rg, g, entrypoint, stream, req, dispatched)
 		})
 	})
 
@@ -341,10 +354,13 @@ func (crr *ConcurrentReachableResources) lookupTTUEntrypoint(ctx context.Context
 
 // redispatchOrReport checks if further redispatching is necessary for the found resource
 // type. If not, and the found resource type+relation matches the target resource type+relation,
-// the resource is reported to the parent stream.
+// the resource is reported to the parent stream. The traversed relation is that by which the
+// resources were found from the subjects, if any, which counts towards its maximum recursion
+// depth when redispatching.
 func (crr *ConcurrentReachableResources) redispatchOrReport(
 	ctx context.Context,
 	foundResourceType *core.RelationReference,
+	traversedRelation *core.Relation,
 	foundResources resourcesSubjectMap,
 	rg *namespace.ReachabilityGraph,
 	g *errgroup.Group,
@@ -383,6 +399,11 @@ func (crr *ConcurrentReachableResources) redispatchOrReport(
 	}
 
 	// Otherwise, redispatch.
+	metadata, err := dispatchMetadata(parentRequest.Metadata, traversedRelation, foundResourceType.Namespace, parentRequest.SubjectRelation.Namespace)
+	if err != nil {
+		return err
+	}
+
 	g.Go(func() error {
 		stream := &dispatch.WrappedDispatchStream[*v1.DispatchReachableResourcesResponse]{
 			Stream: parentStream,
@@ -403,10 +424,7 @@ func (crr *ConcurrentReachableResources) redispatchOrReport(
 			ResourceRelation: parentRequest.ResourceRelation,
 			SubjectRelation:  foundResourceType,
 			SubjectIds:       foundResources.resourceIDs(),
-			Metadata: &v1.ResolverMeta{
-				AtRevision:     parentRequest.Revision.String(),
-				DepthRemaining: parentRequest.Metadata.DepthRemaining - 1,
-			},
+			Metadata:         metadata,
 		}, stream)
 	})
 	return nil
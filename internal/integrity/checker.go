@@ -0,0 +1,452 @@
+// Package integrity checks that the relationships stored in a datastore only reference the
+// relations, object types and caveats defined by its schema.
+package integrity
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/exp/maps"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/authzed/spicedb/internal/datastore/proxy"
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+var orphanedRelationshipsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "spicedb",
+	Subsystem: "datastore",
+	Name:      "integrity_orphaned_relationships",
+	Help:      "number of relationships found by the last integrity check to reference schema which is not defined",
+}, []string{"kind"})
+
+var repairedRelationshipsCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: "spicedb",
+	Subsystem: "datastore",
+	Name:      "integrity_repaired_relationships_total",
+	Help:      "total number of orphaned relationships deleted by integrity checks",
+})
+
+const (
+	// maxReportedOrphans is the maximum number of orphaned relationships listed in a report.
+	maxReportedOrphans = 100
+
+	// repairBatchSize is the maximum number of orphaned relationships deleted per transaction.
+	repairBatchSize = 1000
+)
+
+// OrphanKind is the reason a relationship is considered orphaned.
+type OrphanKind string
+
+const (
+	// OrphanUnknownResourceType relationships have a resource type which is not defined.
+	OrphanUnknownResourceType OrphanKind = "unknown_resource_type"
+
+	// OrphanUnknownRelation relationships have a relation not defined on their resource type.
+	OrphanUnknownRelation OrphanKind = "unknown_relation"
+
+	// OrphanUnknownSubjectType relationships have a subject whose object type is not defined.
+	OrphanUnknownSubjectType OrphanKind = "unknown_subject_type"
+
+	// OrphanUnknownSubjectRelation relationships have a subject relation not defined on the
+	// subject's object type.
+	OrphanUnknownSubjectRelation OrphanKind = "unknown_subject_relation"
+
+	// OrphanUnknownCaveat relationships reference a caveat which is not defined.
+	OrphanUnknownCaveat OrphanKind = "unknown_caveat"
+)
+
+var allOrphanKinds = []OrphanKind{
+	OrphanUnknownResourceType,
+	OrphanUnknownRelation,
+	OrphanUnknownSubjectType,
+	OrphanUnknownSubjectRelation,
+	OrphanUnknownCaveat,
+}
+
+// RepairMode is what a check does with the orphaned relationships it finds.
+type RepairMode int
+
+const (
+	// RepairNone only reports orphaned relationships.
+	RepairNone RepairMode = iota
+
+	// RepairDelete deletes orphaned relationships.
+	RepairDelete
+
+	// RepairQuarantine appends orphaned relationships to the quarantine file before deleting
+	// them, so that they can be restored. Each relationship is written as it was stored, as a
+	// line of JSON encoded core.v1.RelationTuple. A relationship may be written more than once
+	// if the transaction deleting it is retried.
+	RepairQuarantine
+)
+
+// ParseRepairMode parses a repair mode of "none", "delete" or "quarantine".
+func ParseRepairMode(mode string) (RepairMode, error) {
+	switch mode {
+	case "", "none":
+		return RepairNone, nil
+	case "delete":
+		return RepairDelete, nil
+	case "quarantine":
+		return RepairQuarantine, nil
+	default:
+		return RepairNone, fmt.Errorf("unknown integrity repair mode %q", mode)
+	}
+}
+
+// Config configures a Checker.
+type Config struct {
+	// RepairMode is what is done with orphaned relationships.
+	RepairMode RepairMode
+
+	// QuarantinePath is the file to which orphaned relationships are appended when RepairMode
+	// is RepairQuarantine.
+	QuarantinePath string
+}
+
+// Orphan is an orphaned relationship found by a check.
+type Orphan struct {
+	Relationship string     `json:"relationship"`
+	Kind         OrphanKind `json:"kind"`
+}
+
+// Report is the result of a check.
+type Report struct {
+	Revision    string                `json:"revision"`
+	StartedAt   time.Time             `json:"startedAt"`
+	CompletedAt time.Time             `json:"completedAt"`
+	Scanned     uint64                `json:"scanned"`
+	Orphans     map[OrphanKind]uint64 `json:"orphans"`
+	Examples    []Orphan              `json:"examples"`
+	Repaired    uint64                `json:"repaired"`
+}
+
+// Checker scans the relationships of a datastore for orphaned relationships, which reference
+// relations, object types or caveats that are not defined by the schema.
+//
+// Relationships are scanned by each resource type stored, including those which are no longer
+// defined. Orphaned relationships are only repaired if they are still orphaned by the schema
+// read in the transaction deleting them, so that relationships made valid by a concurrent
+// schema write are kept.
+type Checker struct {
+	ds     datastore.Datastore
+	config Config
+
+	checkLock sync.Mutex
+
+	reportLock sync.RWMutex
+	lastReport *Report
+}
+
+// NewChecker creates a checker for the datastore.
+func NewChecker(ds datastore.Datastore, config Config) (*Checker, error) {
+	if config.RepairMode == RepairQuarantine && config.QuarantinePath == "" {
+		return nil, fmt.Errorf("a quarantine path is required to quarantine orphaned relationships")
+	}
+
+	return &Checker{ds: ds, config: config}, nil
+}
+
+// LastReport returns the report of the last completed check, or nil if no check has completed.
+func (c *Checker) LastReport() *Report {
+	c.reportLock.RLock()
+	defer c.reportLock.RUnlock()
+	return c.lastReport
+}
+
+// Run checks the datastore every interval until the context is canceled.
+func (c *Checker) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			report, err := c.Check(ctx)
+			if err != nil {
+				log.Ctx(ctx).Warn().Err(err).Msg("error checking datastore integrity")
+				continue
+			}
+
+			log.Ctx(ctx).Debug().
+				Uint64("scanned", report.Scanned).
+				Interface("orphans", report.Orphans).
+				Uint64("repaired", report.Repaired).
+				Msg("checked datastore integrity")
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Check scans the datastore at its head revision, repairing any orphaned relationships found
+// according to the repair mode. Only one check runs at a time.
+func (c *Checker) Check(ctx context.Context) (*Report, error) {
+	c.checkLock.Lock()
+	defer c.checkLock.Unlock()
+
+	report := &Report{
+		StartedAt: time.Now(),
+		Orphans:   map[OrphanKind]uint64{},
+	}
+
+	revision, err := c.ds.HeadRevision(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine head revision: %w", err)
+	}
+	report.Revision = revision.String()
+
+	reader := c.ds.SnapshotReader(revision)
+	schema, err := loadSchema(ctx, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceTypes, err := reader.ListResourceTypes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list resource types: %w", err)
+	}
+
+	for _, resourceType := range resourceTypes {
+		orphans, err := c.scanResourceType(ctx, reader, schema, resourceType, report)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.repair(ctx, resourceType, orphans, report); err != nil {
+			return nil, err
+		}
+	}
+
+	report.CompletedAt = time.Now()
+	for _, kind := range allOrphanKinds {
+		orphanedRelationshipsGauge.WithLabelValues(string(kind)).Set(float64(report.Orphans[kind]))
+	}
+
+	c.reportLock.Lock()
+	defer c.reportLock.Unlock()
+	c.lastReport = report
+
+	return report, nil
+}
+
+func (c *Checker) scanResourceType(ctx context.Context, reader datastore.Reader, schema *schemaIndex, resourceType string, report *Report) ([]*core.RelationTuple, error) {
+	// Every relationship of the resource type is read, so the scan is exempt from query limits.
+	iter, err := reader.QueryRelationships(proxy.ContextWithoutQueryLimits(ctx), datastore.RelationshipsFilter{ResourceType: resourceType})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query relationships of %s: %w", resourceType, err)
+	}
+	defer iter.Close()
+
+	var orphans []*core.RelationTuple
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		report.Scanned++
+
+		kind, orphaned := schema.orphanKind(tpl)
+		if !orphaned {
+			continue
+		}
+
+		report.Orphans[kind]++
+		if len(report.Examples) < maxReportedOrphans {
+			report.Examples = append(report.Examples, Orphan{Relationship: tuple.String(tpl), Kind: kind})
+		}
+		if c.config.RepairMode != RepairNone {
+			orphans = append(orphans, tpl)
+		}
+	}
+	if iter.Err() != nil {
+		return nil, fmt.Errorf("unable to query relationships of %s: %w", resourceType, iter.Err())
+	}
+
+	return orphans, nil
+}
+
+// repair deletes the orphaned relationships of the resource type found by a scan, in batches.
+// Each batch is read again along with the schema in the transaction deleting it, and only the
+// relationships which are still orphaned are deleted.
+func (c *Checker) repair(ctx context.Context, resourceType string, orphans []*core.RelationTuple, report *Report) error {
+	if len(orphans) == 0 {
+		return nil
+	}
+
+	var repaired int
+	for start := 0; start < len(orphans); start += repairBatchSize {
+		end := start + repairBatchSize
+		if end > len(orphans) {
+			end = len(orphans)
+		}
+
+		var deletes []*core.RelationTupleUpdate
+		if _, err := c.ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+			stillOrphaned, err := c.revalidate(ctx, rwt, resourceType, orphans[start:end])
+			if err != nil {
+				return err
+			}
+			if len(stillOrphaned) == 0 {
+				deletes = nil
+				return nil
+			}
+
+			if c.config.RepairMode == RepairQuarantine {
+				if err := c.quarantine(stillOrphaned); err != nil {
+					return err
+				}
+			}
+
+			deletes = make([]*core.RelationTupleUpdate, 0, len(stillOrphaned))
+			for _, tpl := range stillOrphaned {
+				deletes = append(deletes, tuple.Delete(tpl))
+			}
+			return rwt.WriteRelationships(ctx, deletes)
+		}); err != nil {
+			return fmt.Errorf("unable to delete orphaned relationships: %w", err)
+		}
+
+		repaired += len(deletes)
+		report.Repaired += uint64(len(deletes))
+		repairedRelationshipsCounter.Add(float64(len(deletes)))
+	}
+
+	if repaired > 0 {
+		log.Ctx(ctx).Info().Str("resourceType", resourceType).Int("count", repaired).Msg("deleted orphaned relationships")
+	}
+	return nil
+}
+
+// revalidate returns the relationships of the orphans as read in the transaction which are
+// still orphaned by the schema read in the transaction.
+func (c *Checker) revalidate(ctx context.Context, rwt datastore.ReadWriteTransaction, resourceType string, orphans []*core.RelationTuple) ([]*core.RelationTuple, error) {
+	schema, err := loadSchema(ctx, rwt)
+	if err != nil {
+		return nil, err
+	}
+
+	orphanKeys := make(map[string]struct{}, len(orphans))
+	resourceIDs := make(map[string]struct{}, len(orphans))
+	for _, tpl := range orphans {
+		orphanKeys[tuple.String(tpl)] = struct{}{}
+		resourceIDs[tpl.ResourceAndRelation.ObjectId] = struct{}{}
+	}
+
+	iter, err := rwt.QueryRelationships(proxy.ContextWithoutQueryLimits(ctx), datastore.RelationshipsFilter{
+		ResourceType:        resourceType,
+		OptionalResourceIds: maps.Keys(resourceIDs),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query orphaned relationships of %s: %w", resourceType, err)
+	}
+	defer iter.Close()
+
+	var stillOrphaned []*core.RelationTuple
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		if _, ok := orphanKeys[tuple.String(tpl)]; !ok {
+			continue
+		}
+		if _, orphaned := schema.orphanKind(tpl); orphaned {
+			stillOrphaned = append(stillOrphaned, tpl)
+		}
+	}
+	if iter.Err() != nil {
+		return nil, fmt.Errorf("unable to query orphaned relationships of %s: %w", resourceType, iter.Err())
+	}
+
+	return stillOrphaned, nil
+}
+
+func (c *Checker) quarantine(orphans []*core.RelationTuple) error {
+	f, err := os.OpenFile(c.config.QuarantinePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("unable to open quarantine file: %w", err)
+	}
+
+	for _, tpl := range orphans {
+		line, err := protojson.Marshal(tpl)
+		if err == nil {
+			_, err = fmt.Fprintln(f, string(line))
+		}
+		if err != nil {
+			_ = f.Close()
+			return fmt.Errorf("unable to quarantine orphaned relationships: %w", err)
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to quarantine orphaned relationships: %w", err)
+	}
+	return nil
+}
+
+// schemaIndex indexes the definitions of a schema by name.
+type schemaIndex struct {
+	relations map[string]map[string]struct{}
+	caveats   map[string]struct{}
+}
+
+func loadSchema(ctx context.Context, reader datastore.Reader) (*schemaIndex, error) {
+	nsDefs, err := reader.ListNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list namespaces: %w", err)
+	}
+
+	caveatDefs, err := reader.ListCaveats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list caveats: %w", err)
+	}
+
+	schema := &schemaIndex{
+		relations: make(map[string]map[string]struct{}, len(nsDefs)),
+		caveats:   make(map[string]struct{}, len(caveatDefs)),
+	}
+	for _, nsDef := range nsDefs {
+		relations := make(map[string]struct{}, len(nsDef.Relation))
+		for _, relation := range nsDef.Relation {
+			relations[relation.Name] = struct{}{}
+		}
+		schema.relations[nsDef.Name] = relations
+	}
+	for _, caveatDef := range caveatDefs {
+		schema.caveats[caveatDef.Name] = struct{}{}
+	}
+
+	return schema, nil
+}
+
+func (s *schemaIndex) orphanKind(tpl *core.RelationTuple) (OrphanKind, bool) {
+	resourceRelations, ok := s.relations[tpl.ResourceAndRelation.Namespace]
+	if !ok {
+		return OrphanUnknownResourceType, true
+	}
+
+	if _, ok := resourceRelations[tpl.ResourceAndRelation.Relation]; !ok {
+		return OrphanUnknownRelation, true
+	}
+
+	subjectRelations, ok := s.relations[tpl.Subject.Namespace]
+	if !ok {
+		return OrphanUnknownSubjectType, true
+	}
+
+	if tpl.Subject.Relation != tuple.Ellipsis {
+		if _, ok := subjectRelations[tpl.Subject.Relation]; !ok {
+			return OrphanUnknownSubjectRelation, true
+		}
+	}
+
+	if tpl.Caveat != nil && tpl.Caveat.CaveatName != "" {
+		if _, ok := s.caveats[tpl.Caveat.CaveatName]; !ok {
+			return OrphanUnknownCaveat, true
+		}
+	}
+
+	return "", false
+}
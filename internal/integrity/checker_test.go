@@ -0,0 +1,232 @@
+package integrity
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+var (
+	validRelationship = tuple.MustParse("document:valid#viewer@user:tom")
+
+	orphanedRelationships = map[string]OrphanKind{
+		"unknown:resourcetype#viewer@user:tom":                 OrphanUnknownResourceType,
+		"document:relation#unknown@user:tom":                   OrphanUnknownRelation,
+		"document:subjecttype#viewer@unknown:tom":              OrphanUnknownSubjectType,
+		"document:subjectrelation#parent@folder:plans#unknown": OrphanUnknownSubjectRelation,
+		"document:caveat#caveated_viewer@user:tom":             OrphanUnknownCaveat,
+	}
+)
+
+func orphanedTuple(rel string) *core.RelationTuple {
+	tpl := tuple.MustParse(rel)
+	if orphanedRelationships[rel] == OrphanUnknownCaveat {
+		tpl = tuple.WithCaveat(tpl, "unknown")
+	}
+	return tpl
+}
+
+// newCheckedDatastore returns a datastore with the standard schema, a valid relationship and
+// one orphaned relationship of each kind, written without validation.
+func newCheckedDatastore(t *testing.T) datastore.Datastore {
+	require := require.New(t)
+
+	rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	testfixtures.StandardDatastoreWithSchema(rawDS, require)
+
+	tpls := []*core.RelationTuple{validRelationship}
+	for rel := range orphanedRelationships {
+		tpls = append(tpls, orphanedTuple(rel))
+	}
+	_, err = common.WriteTuples(context.Background(), rawDS, core.RelationTupleUpdate_CREATE, tpls...)
+	require.NoError(err)
+
+	return rawDS
+}
+
+func countRelationships(t *testing.T, ds datastore.Datastore) uint64 {
+	ctx := context.Background()
+	revision, err := ds.HeadRevision(ctx)
+	require.NoError(t, err)
+
+	var count uint64
+	for _, resourceType := range []string{"document", "unknown"} {
+		typeCount, err := ds.SnapshotReader(revision).CountRelationships(ctx, datastore.RelationshipsFilter{
+			ResourceType: resourceType,
+		})
+		require.NoError(t, err)
+		count += typeCount
+	}
+	return count
+}
+
+func TestCheckReportsOrphans(t *testing.T) {
+	require := require.New(t)
+	ds := newCheckedDatastore(t)
+
+	checker, err := NewChecker(ds, Config{})
+	require.NoError(err)
+	require.Nil(checker.LastReport())
+
+	report, err := checker.Check(context.Background())
+	require.NoError(err)
+	require.Equal(report, checker.LastReport())
+
+	require.Equal(uint64(6), report.Scanned)
+	require.Equal(map[OrphanKind]uint64{
+		OrphanUnknownResourceType:    1,
+		OrphanUnknownRelation:        1,
+		OrphanUnknownSubjectType:     1,
+		OrphanUnknownSubjectRelation: 1,
+		OrphanUnknownCaveat:          1,
+	}, report.Orphans)
+	require.Len(report.Examples, 5)
+	for _, orphan := range report.Examples {
+		require.Equal(orphanedRelationships[orphan.Relationship], orphan.Kind)
+	}
+
+	require.Zero(report.Repaired)
+	require.Equal(uint64(6), countRelationships(t, ds))
+}
+
+func TestCheckDeletesOrphans(t *testing.T) {
+	require := require.New(t)
+	ds := newCheckedDatastore(t)
+
+	checker, err := NewChecker(ds, Config{RepairMode: RepairDelete})
+	require.NoError(err)
+
+	report, err := checker.Check(context.Background())
+	require.NoError(err)
+	require.Equal(uint64(5), report.Repaired)
+	require.Equal(uint64(1), countRelationships(t, ds))
+
+	report, err = checker.Check(context.Background())
+	require.NoError(err)
+	require.Equal(uint64(1), report.Scanned)
+	require.Empty(report.Examples)
+}
+
+func TestCheckQuarantinesOrphans(t *testing.T) {
+	require := require.New(t)
+	ds := newCheckedDatastore(t)
+
+	_, err := NewChecker(ds, Config{RepairMode: RepairQuarantine})
+	require.Error(err)
+
+	quarantinePath := filepath.Join(t.TempDir(), "quarantine")
+	checker, err := NewChecker(ds, Config{RepairMode: RepairQuarantine, QuarantinePath: quarantinePath})
+	require.NoError(err)
+
+	report, err := checker.Check(context.Background())
+	require.NoError(err)
+	require.Equal(uint64(5), report.Repaired)
+	require.Equal(uint64(1), countRelationships(t, ds))
+
+	contents, err := os.ReadFile(quarantinePath)
+	require.NoError(err)
+
+	// Each quarantined relationship is restored along with its caveat.
+	var expected, quarantined []string
+	for rel := range orphanedRelationships {
+		tpl := orphanedTuple(rel)
+		expected = append(expected, tuple.String(tpl)+tpl.Caveat.GetCaveatName())
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(contents)), "\n") {
+		tpl := &core.RelationTuple{}
+		require.NoError(protojson.Unmarshal([]byte(line), tpl))
+		quarantined = append(quarantined, tuple.String(tpl)+tpl.Caveat.GetCaveatName())
+	}
+	require.ElementsMatch(expected, quarantined)
+}
+
+// staleSchemaDatastore hides a namespace from the schema read by snapshot readers, as if it was
+// written after they were read.
+type staleSchemaDatastore struct {
+	datastore.Datastore
+	hidden string
+}
+
+func (ds staleSchemaDatastore) SnapshotReader(revision datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return staleSchemaReader{ds.Datastore.SnapshotReader(revision, opts...), ds.hidden}
+}
+
+type staleSchemaReader struct {
+	datastore.Reader
+	hidden string
+}
+
+func (r staleSchemaReader) ListNamespaces(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error) {
+	nsDefs, err := r.Reader.ListNamespaces(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := nsDefs[:0]
+	for _, nsDef := range nsDefs {
+		if nsDef.Name != r.hidden {
+			visible = append(visible, nsDef)
+		}
+	}
+	return visible, nil
+}
+
+func TestCheckRevalidatesOrphansBeforeDeleting(t *testing.T) {
+	require := require.New(t)
+	ds := newCheckedDatastore(t)
+
+	// The relationship is valid, but is found to be orphaned by the scan as its subject type is
+	// hidden from it.
+	valid := tuple.MustParse("document:revalidated#parent@folder:plans")
+	_, err := common.WriteTuples(context.Background(), ds, core.RelationTupleUpdate_CREATE, valid)
+	require.NoError(err)
+
+	checker, err := NewChecker(staleSchemaDatastore{ds, "folder"}, Config{RepairMode: RepairDelete})
+	require.NoError(err)
+
+	report, err := checker.Check(context.Background())
+	require.NoError(err)
+	require.Equal(uint64(3), report.Orphans[OrphanUnknownSubjectType])
+	require.Equal(uint64(5), report.Repaired)
+	require.Equal(uint64(2), countRelationships(t, ds))
+
+	revision, err := ds.HeadRevision(context.Background())
+	require.NoError(err)
+	iter, err := ds.SnapshotReader(revision).QueryRelationships(context.Background(), datastore.RelationshipsFilter{
+		ResourceType:        "document",
+		OptionalResourceIds: []string{"revalidated"},
+	})
+	require.NoError(err)
+	defer iter.Close()
+	require.NotNil(iter.Next())
+}
+
+func TestParseRepairMode(t *testing.T) {
+	for mode, expected := range map[string]RepairMode{
+		"":           RepairNone,
+		"none":       RepairNone,
+		"delete":     RepairDelete,
+		"quarantine": RepairQuarantine,
+	} {
+		parsed, err := ParseRepairMode(mode)
+		require.NoError(t, err)
+		require.Equal(t, expected, parsed)
+	}
+
+	_, err := ParseRepairMode("unknown")
+	require.Error(t, err)
+}
@@ -63,9 +63,13 @@ func MustRevisionFromContext(ctx context.Context) (datastore.Revision, *v1.ZedTo
 // AddRevisionToContext adds a revision to the given context, based on the consistency block found
 // in the given request (if applicable).
 func AddRevisionToContext(ctx context.Context, req interface{}, ds datastore.Datastore) error {
+	return addRevisionToContext(ctx, req, ds, nil)
+}
+
+func addRevisionToContext(ctx context.Context, req interface{}, ds datastore.Datastore, quantizer *namespaceQuantizer) error {
 	switch req := req.(type) {
 	case hasConsistency:
-		return addRevisionToContextFromConsistency(ctx, req, ds)
+		return addRevisionToContextFromConsistency(ctx, req, ds, quantizer)
 	default:
 		return addHeadRevision(ctx, ds)
 	}
@@ -88,7 +92,7 @@ func addHeadRevision(ctx context.Context, ds datastore.Datastore) error {
 
 // addRevisionToContextFromConsistency adds a revision to the given context, based on the consistency block found
 // in the given request (if applicable).
-func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency, ds datastore.Datastore) error {
+func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency, ds datastore.Datastore, quantizer *namespaceQuantizer) error {
 	handle := ctx.Value(revisionKey)
 	if handle == nil {
 		return nil
@@ -100,7 +104,7 @@ func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency
 	switch {
 	case consistency == nil || consistency.GetMinimizeLatency():
 		// Minimize Latency: Use the datastore's current revision, whatever it may be.
-		databaseRev, err := ds.OptimizedRevision(ctx)
+		databaseRev, err := quantizer.OptimizedRevision(ctx, req, ds)
 		if err != nil {
 			return rewriteDatastoreError(ctx, err)
 		}
@@ -117,7 +121,7 @@ func addRevisionToContextFromConsistency(ctx context.Context, req hasConsistency
 	case consistency.GetAtLeastAsFresh() != nil:
 		// At least as fresh as: Pick one of the datastore's revision and that specified, which
 		// ever is later.
-		picked, err := pickBestRevision(ctx, consistency.GetAtLeastAsFresh(), ds)
+		picked, err := pickBestRevision(ctx, req, consistency.GetAtLeastAsFresh(), ds, quantizer)
 		if err != nil {
 			return rewriteDatastoreError(ctx, err)
 		}
@@ -150,9 +154,33 @@ var bypassServiceWhitelist = map[string]struct{}{
 	"/grpc.health.v1.Health/":                    {},
 }
 
+// Option is an option for the consistency middleware.
+type Option func(*optionState)
+
+type optionState struct {
+	namespaceQuantization []NamespaceQuantization
+}
+
+// WithNamespaceQuantization overrides the revision quantization of the datastore for requests
+// whose resources are in namespaces matching the prefixes of the given overrides.
+func WithNamespaceQuantization(overrides ...NamespaceQuantization) Option {
+	return func(state *optionState) {
+		state.namespaceQuantization = append(state.namespaceQuantization, overrides...)
+	}
+}
+
+func quantizerFromOptions(opts []Option) *namespaceQuantizer {
+	state := &optionState{}
+	for _, opt := range opts {
+		opt(state)
+	}
+	return newNamespaceQuantizer(state.namespaceQuantization)
+}
+
 // UnaryServerInterceptor returns a new unary server interceptor that performs per-request exchange of
 // the specified consistency configuration for the revision at which to perform the request.
-func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	quantizer := quantizerFromOptions(opts)
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		for bypass := range bypassServiceWhitelist {
 			if strings.HasPrefix(info.FullMethod, bypass) {
@@ -161,7 +189,7 @@ func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 		}
 		ds := datastoremw.MustFromContext(ctx)
 		newCtx := ContextWithHandle(ctx)
-		if err := AddRevisionToContext(newCtx, req, ds); err != nil {
+		if err := addRevisionToContext(newCtx, req, ds, quantizer); err != nil {
 			return nil, err
 		}
 
@@ -171,21 +199,23 @@ func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 
 // StreamServerInterceptor returns a new stream server interceptor that performs per-request exchange of
 // the specified consistency configuration for the revision at which to perform the request.
-func StreamServerInterceptor() grpc.StreamServerInterceptor {
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	quantizer := quantizerFromOptions(opts)
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		for bypass := range bypassServiceWhitelist {
 			if strings.HasPrefix(info.FullMethod, bypass) {
 				return handler(srv, stream)
 			}
 		}
-		wrapper := &recvWrapper{stream, ContextWithHandle(stream.Context())}
+		wrapper := &recvWrapper{stream, ContextWithHandle(stream.Context()), quantizer}
 		return handler(srv, wrapper)
 	}
 }
 
 type recvWrapper struct {
 	grpc.ServerStream
-	ctx context.Context
+	ctx       context.Context
+	quantizer *namespaceQuantizer
 }
 
 func (s *recvWrapper) Context() context.Context {
@@ -198,16 +228,16 @@ func (s *recvWrapper) RecvMsg(m interface{}) error {
 	}
 	ds := datastoremw.MustFromContext(s.ctx)
 
-	if err := AddRevisionToContext(s.ctx, m, ds); err != nil {
+	if err := addRevisionToContext(s.ctx, m, ds, s.quantizer); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func pickBestRevision(ctx context.Context, requested *v1.ZedToken, ds datastore.Datastore) (datastore.Revision, error) {
+func pickBestRevision(ctx context.Context, req interface{}, requested *v1.ZedToken, ds datastore.Datastore, quantizer *namespaceQuantizer) (datastore.Revision, error) {
 	// Calculate a revision as we see fit
-	databaseRev, err := ds.OptimizedRevision(ctx)
+	databaseRev, err := quantizer.OptimizedRevision(ctx, req, ds)
 	if err != nil {
 		return datastore.NoRevision, err
 	}
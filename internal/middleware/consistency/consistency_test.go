@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"testing"
+	"time"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/testing/testpb"
@@ -150,6 +151,65 @@ func TestAddRevisionToContextAPIAlwaysFullyConsistent(t *testing.T) {
 	ds.AssertExpectations(t)
 }
 
+func TestAddRevisionToContextNamespaceQuantization(t *testing.T) {
+	require := require.New(t)
+
+	ds := &proxy_test.MockDatastore{}
+	ds.On("OptimizedRevision").Return(optimized, nil).Once()
+	ds.On("HeadRevision").Return(head, nil).Once()
+
+	quantizer := newNamespaceQuantizer([]NamespaceQuantization{
+		{Prefix: "billing/", Window: time.Hour, MaxStaleness: time.Hour},
+		{Prefix: "billing/invoice", Window: 0},
+	})
+	ctx := datastoremw.ContextWithDatastore(context.Background(), ds)
+
+	// Namespaces without an override use the datastore's optimized revision.
+	updated := ContextWithHandle(ctx)
+	err := addRevisionToContext(updated, &v1.CheckPermissionRequest{
+		Resource: &v1.ObjectReference{ObjectType: "document", ObjectId: "foo"},
+	}, ds, quantizer)
+	require.NoError(err)
+	require.True(optimized.Equal(RevisionFromContext(updated)))
+
+	// The longest matching prefix is used, and a zero window always selects the head revision.
+	updated = ContextWithHandle(ctx)
+	err = addRevisionToContext(updated, &v1.LookupResourcesRequest{
+		ResourceObjectType: "billing/invoice",
+	}, ds, quantizer)
+	require.NoError(err)
+	require.True(head.Equal(RevisionFromContext(updated)))
+
+	// Revisions for other matching namespaces are cached for the window.
+	ds.On("HeadRevision").Return(exact, nil).Once()
+	for i := 0; i < 3; i++ {
+		updated = ContextWithHandle(ctx)
+		err = addRevisionToContext(updated, &v1.ReadRelationshipsRequest{
+			RelationshipFilter: &v1.RelationshipFilter{ResourceType: "billing/account"},
+		}, ds, quantizer)
+		require.NoError(err)
+		require.True(exact.Equal(RevisionFromContext(updated)))
+	}
+
+	ds.AssertExpectations(t)
+}
+
+func TestParseNamespaceQuantization(t *testing.T) {
+	parsed, err := ParseNamespaceQuantization("billing/", "2s")
+	require.NoError(t, err)
+	require.Equal(t, NamespaceQuantization{Prefix: "billing/", Window: 2 * time.Second, MaxStaleness: 200 * time.Millisecond}, parsed)
+
+	parsed, err = ParseNamespaceQuantization("billing/", "2s:0s")
+	require.NoError(t, err)
+	require.Equal(t, NamespaceQuantization{Prefix: "billing/", Window: 2 * time.Second}, parsed)
+
+	_, err = ParseNamespaceQuantization("billing/", "soon")
+	require.Error(t, err)
+
+	_, err = ParseNamespaceQuantization("billing/", "1s:-1s")
+	require.Error(t, err)
+}
+
 func TestMiddlewareConsistencyTestSuite(t *testing.T) {
 	ds := &proxy_test.MockDatastore{}
 	ds.On("HeadRevision").Return(head, nil)
@@ -0,0 +1,153 @@
+package consistency
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
+
+	"github.com/authzed/spicedb/internal/datastore/common/revisions"
+	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+// DefaultQuantizationStalenessPercent is the staleness bound, as a percentage of the window, used
+// for a NamespaceQuantization that does not specify one.
+const DefaultQuantizationStalenessPercent = 0.1
+
+// NamespaceQuantization overrides the revision quantization of the datastore for minimize_latency
+// and at_least_as_fresh requests whose resources are in namespaces with the given prefix.
+//
+// Overridden revisions are taken from the head revision of the datastore at most once per window
+// (plus the staleness bound) on each node, and are therefore part of the dispatch cache keys: a
+// shorter window trades cache hit rate for freshness for those namespaces alone.
+type NamespaceQuantization struct {
+	// Prefix is the namespace prefix to which the override applies. When several overrides match
+	// a namespace, the one with the longest prefix is used.
+	Prefix string
+
+	// Window is the interval to which revisions are quantized. A window of zero always selects
+	// the head revision.
+	Window time.Duration
+
+	// MaxStaleness is the additional amount of time for which a quantized revision may be used
+	// after its window has passed.
+	MaxStaleness time.Duration
+}
+
+// ParseNamespaceQuantization parses an override from a namespace prefix and a value of the form
+// `window` or `window:staleness`, e.g. `1s` or `1s:100ms`. If no staleness is given, it defaults
+// to DefaultQuantizationStalenessPercent of the window.
+func ParseNamespaceQuantization(prefix, value string) (NamespaceQuantization, error) {
+	windowStr, stalenessStr, hasStaleness := strings.Cut(value, ":")
+	window, err := time.ParseDuration(windowStr)
+	if err != nil {
+		return NamespaceQuantization{}, fmt.Errorf("invalid quantization window for namespace prefix %q: %w", prefix, err)
+	}
+	if window < 0 {
+		return NamespaceQuantization{}, fmt.Errorf("quantization window for namespace prefix %q must not be negative", prefix)
+	}
+
+	staleness := time.Duration(float64(window.Nanoseconds()) * DefaultQuantizationStalenessPercent)
+	if hasStaleness {
+		staleness, err = time.ParseDuration(stalenessStr)
+		if err != nil {
+			return NamespaceQuantization{}, fmt.Errorf("invalid max staleness for namespace prefix %q: %w", prefix, err)
+		}
+		if staleness < 0 {
+			return NamespaceQuantization{}, fmt.Errorf("max staleness for namespace prefix %q must not be negative", prefix)
+		}
+	}
+
+	return NamespaceQuantization{Prefix: prefix, Window: window, MaxStaleness: staleness}, nil
+}
+
+type quantizedNamespaces struct {
+	prefix    string
+	revisions *revisions.CachedOptimizedRevisions
+}
+
+// namespaceQuantizer selects the optimized revision for requests based on the namespace of their
+// resources.
+type namespaceQuantizer struct {
+	// overrides are sorted by descending prefix length.
+	overrides []quantizedNamespaces
+}
+
+func newNamespaceQuantizer(overrides []NamespaceQuantization) *namespaceQuantizer {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	quantized := make([]quantizedNamespaces, 0, len(overrides))
+	for _, override := range overrides {
+		cached := revisions.NewCachedOptimizedRevisions(override.MaxStaleness)
+		window := override.Window
+		cached.SetOptimizedRevisionFunc(func(ctx context.Context) (datastore.Revision, time.Duration, error) {
+			rev, err := datastoremw.MustFromContext(ctx).HeadRevision(ctx)
+			if err != nil {
+				return datastore.NoRevision, 0, err
+			}
+			if window <= 0 {
+				return rev, 0, nil
+			}
+
+			// The revision is valid until the end of the current window.
+			return rev, window - time.Duration(time.Now().UnixNano()%window.Nanoseconds()), nil
+		})
+		quantized = append(quantized, quantizedNamespaces{override.Prefix, cached})
+	}
+
+	sort.SliceStable(quantized, func(i, j int) bool {
+		return len(quantized[i].prefix) > len(quantized[j].prefix)
+	})
+	return &namespaceQuantizer{quantized}
+}
+
+// OptimizedRevision returns the optimized revision at which to perform the request, falling back
+// to that of the datastore if no override applies.
+func (nq *namespaceQuantizer) OptimizedRevision(ctx context.Context, req interface{}, ds datastore.Datastore) (datastore.Revision, error) {
+	if nq != nil {
+		if namespace, ok := resourceNamespace(req); ok {
+			for _, override := range nq.overrides {
+				if strings.HasPrefix(namespace, override.prefix) {
+					return override.revisions.OptimizedRevision(ctx)
+				}
+			}
+		}
+	}
+
+	return ds.OptimizedRevision(ctx)
+}
+
+type hasResource interface {
+	GetResource() *v1.ObjectReference
+}
+
+type hasResourceObjectType interface {
+	GetResourceObjectType() string
+}
+
+type hasRelationshipFilter interface {
+	GetRelationshipFilter() *v1.RelationshipFilter
+}
+
+// resourceNamespace returns the namespace of the resources of the request, if it has one.
+func resourceNamespace(req interface{}) (string, bool) {
+	switch req := req.(type) {
+	case hasResource:
+		if resource := req.GetResource(); resource != nil {
+			return resource.ObjectType, true
+		}
+	case hasResourceObjectType:
+		return req.GetResourceObjectType(), true
+	case hasRelationshipFilter:
+		if filter := req.GetRelationshipFilter(); filter != nil {
+			return filter.ResourceType, true
+		}
+	}
+	return "", false
+}
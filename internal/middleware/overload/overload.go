@@ -0,0 +1,352 @@
+package overload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/spiceerrors"
+)
+
+// Priority is the priority given to a request when the server is overloaded.
+type Priority int
+
+const (
+	// PriorityExempt requests are never queued or shed, and do not count towards the
+	// concurrency limit. Used for long-lived streams and health checks.
+	PriorityExempt Priority = iota
+
+	// PriorityLow requests are shed as soon as the server is overloaded.
+	PriorityLow
+
+	// PriorityNormal requests are queued behind high priority requests.
+	PriorityNormal
+
+	// PriorityHigh requests are admitted ahead of all other requests.
+	PriorityHigh
+)
+
+const numQueuedPriorities = int(PriorityHigh)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityExempt:
+		return "exempt"
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrorReasonOverloaded is the reason given in the ErrorInfo of errors returned for shed requests.
+const ErrorReasonOverloaded = "ERROR_REASON_SERVER_OVERLOADED"
+
+// queueDelaySmoothing is the weight given to each newly observed queue delay in the moving
+// average used to determine whether the server is overloaded.
+const queueDelaySmoothing = 0.2
+
+var (
+	requestsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "spicedb",
+		Subsystem: "overload",
+		Name:      "requests_total",
+		Help:      "total number of requests subject to overload control, by priority",
+	}, []string{"priority"})
+
+	shedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "spicedb",
+		Subsystem: "overload",
+		Name:      "shed_requests_total",
+		Help:      "total number of requests rejected by overload control, by priority",
+	}, []string{"priority"})
+
+	queueDelayHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "spicedb",
+		Subsystem: "overload",
+		Name:      "queue_delay_seconds",
+		Help:      "time requests spent waiting to be admitted, by priority",
+		Buckets:   []float64{.0005, .001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"priority"})
+)
+
+// ClassifierFunc determines the priority of a request. The request is nil for streaming calls,
+// unless they are admitted by StreamServerInterceptorByRequest.
+type ClassifierFunc func(fullMethod string, req interface{}) Priority
+
+// Config configures the overload Controller.
+type Config struct {
+	// MaxConcurrentRequests is the maximum number of requests processed concurrently; requests
+	// beyond this limit wait in a priority queue.
+	MaxConcurrentRequests uint32
+
+	// TargetQueueDelay is the average queue delay above which the server is considered
+	// overloaded. It is also the longest time a low priority request will be queued.
+	TargetQueueDelay time.Duration
+
+	// Classifier determines the priority of each request. Defaults to DefaultClassifier.
+	Classifier ClassifierFunc
+}
+
+// Controller limits the number of concurrently processed requests, admitting queued requests in
+// priority order and shedding low priority requests when the queue delay exceeds its target.
+type Controller struct {
+	maxInFlight      uint32
+	targetQueueDelay time.Duration
+	classifier       ClassifierFunc
+
+	sync.Mutex
+	inFlight   uint32
+	waiting    [numQueuedPriorities][]*waiter
+	queueDelay time.Duration
+}
+
+type waiter struct {
+	admitted chan struct{}
+}
+
+// NewController creates a new overload controller for the given config.
+func NewController(config Config) (*Controller, error) {
+	if config.MaxConcurrentRequests == 0 {
+		return nil, errors.New("overload controller requires a maximum number of concurrent requests")
+	}
+	if config.TargetQueueDelay <= 0 {
+		return nil, errors.New("overload controller requires a positive target queue delay")
+	}
+
+	classifier := config.Classifier
+	if classifier == nil {
+		classifier = DefaultClassifier
+	}
+
+	return &Controller{
+		maxInFlight:      config.MaxConcurrentRequests,
+		targetQueueDelay: config.TargetQueueDelay,
+		classifier:       classifier,
+	}, nil
+}
+
+// Admit blocks until a request of the given priority may proceed, returning a function which
+// must be called once the request has completed. If the request is shed, a RESOURCE_EXHAUSTED
+// error carrying a retry hint is returned instead.
+func (c *Controller) Admit(ctx context.Context, priority Priority) (func(), error) {
+	if priority == PriorityExempt {
+		return func() {}, nil
+	}
+
+	requestsCounter.WithLabelValues(priority.String()).Inc()
+
+	c.Lock()
+	if c.inFlight < c.maxInFlight {
+		c.inFlight++
+		c.observeLocked(priority, 0)
+		c.Unlock()
+		return c.release, nil
+	}
+
+	if priority == PriorityLow && c.overloadedLocked() {
+		err := c.shedLocked(priority)
+		c.Unlock()
+		return nil, err
+	}
+
+	w := &waiter{admitted: make(chan struct{})}
+	queue := &c.waiting[priority-1]
+	*queue = append(*queue, w)
+	c.Unlock()
+
+	// Low priority requests are never queued for longer than the target delay.
+	var timeout <-chan time.Time
+	if priority == PriorityLow {
+		timer := time.NewTimer(c.targetQueueDelay)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	start := time.Now()
+	select {
+	case <-w.admitted:
+		c.Lock()
+		c.observeLocked(priority, time.Since(start))
+		c.Unlock()
+		return c.release, nil
+
+	case <-timeout:
+		c.Lock()
+		defer c.Unlock()
+		if !c.dequeueLocked(priority, w) {
+			// Admitted concurrently with the timeout firing.
+			c.observeLocked(priority, time.Since(start))
+			return c.release, nil
+		}
+		c.observeLocked(priority, time.Since(start))
+		return nil, c.shedLocked(priority)
+
+	case <-ctx.Done():
+		c.Lock()
+		defer c.Unlock()
+		if !c.dequeueLocked(priority, w) {
+			c.releaseLocked()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Controller) release() {
+	c.Lock()
+	defer c.Unlock()
+	c.releaseLocked()
+}
+
+// releaseLocked hands the slot of a completed request to the highest priority waiter, if any.
+func (c *Controller) releaseLocked() {
+	for i := numQueuedPriorities - 1; i >= 0; i-- {
+		if len(c.waiting[i]) > 0 {
+			next := c.waiting[i][0]
+			c.waiting[i] = c.waiting[i][1:]
+			close(next.admitted)
+			return
+		}
+	}
+
+	c.inFlight--
+}
+
+// dequeueLocked removes the waiter from its queue, returning false if it was already admitted.
+func (c *Controller) dequeueLocked(priority Priority, w *waiter) bool {
+	queue := &c.waiting[priority-1]
+	for i, found := range *queue {
+		if found == w {
+			*queue = append((*queue)[:i], (*queue)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Controller) observeLocked(priority Priority, delay time.Duration) {
+	queueDelayHistogram.WithLabelValues(priority.String()).Observe(delay.Seconds())
+	c.queueDelay = time.Duration(queueDelaySmoothing*float64(delay) + (1-queueDelaySmoothing)*float64(c.queueDelay))
+}
+
+func (c *Controller) overloadedLocked() bool {
+	return c.queueDelay > c.targetQueueDelay
+}
+
+func (c *Controller) shedLocked(priority Priority) error {
+	shedCounter.WithLabelValues(priority.String()).Inc()
+
+	// Suggest retrying once the current queue has had a chance to drain.
+	retryDelay := c.queueDelay
+	if retryDelay < c.targetQueueDelay {
+		retryDelay = c.targetQueueDelay
+	}
+
+	return spiceerrors.WithCodeAndDetails(
+		fmt.Errorf("server is overloaded; retry the %s priority request after %s", priority, retryDelay),
+		codes.ResourceExhausted,
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(retryDelay)},
+		&errdetails.ErrorInfo{
+			Reason: ErrorReasonOverloaded,
+			Domain: spiceerrors.Domain,
+			Metadata: map[string]string{
+				"priority":       priority.String(),
+				"retry_after_ms": strconv.FormatInt(retryDelay.Milliseconds(), 10),
+			},
+		},
+	).Err()
+}
+
+// DefaultClassifier gives exports and lookups, whose responses are unbounded in size, low
+// priority and checks high priority. Watch and health checks are exempt from overload control.
+func DefaultClassifier(fullMethod string, _ interface{}) Priority {
+	switch fullMethod {
+	case "/authzed.api.v1.WatchService/Watch",
+		"/grpc.health.v1.Health/Check",
+		"/grpc.health.v1.Health/Watch":
+		return PriorityExempt
+
+	case "/authzed.api.v1.PermissionsService/ReadRelationships",
+		"/authzed.api.v1.PermissionsService/LookupResources",
+		"/authzed.api.v1.PermissionsService/LookupSubjects":
+		return PriorityLow
+
+	case "/authzed.api.v1.PermissionsService/CheckPermission":
+		return PriorityHigh
+
+	default:
+		return PriorityNormal
+	}
+}
+
+// UnaryServerInterceptor returns a new unary server interceptor that admits requests through
+// the overload controller.
+func UnaryServerInterceptor(c *Controller) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		priority := requestPriority(ctx, c.classifier, info.FullMethod, req)
+		done, err := c.Admit(ctx, priority)
+		if err != nil {
+			log.Ctx(ctx).Debug().Err(err).Str("method", info.FullMethod).Stringer("priority", priority).Msg("request not admitted")
+			return nil, err
+		}
+		defer done()
+
+		return handler(contextWithPriority(ctx, priority), req)
+	}
+}
+
+// StreamServerInterceptor returns a new stream server interceptor that admits requests through
+// the overload controller.
+func StreamServerInterceptor(c *Controller) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		priority := requestPriority(stream.Context(), c.classifier, info.FullMethod, nil)
+		done, err := c.Admit(stream.Context(), priority)
+		if err != nil {
+			log.Ctx(stream.Context()).Debug().Err(err).Str("method", info.FullMethod).Stringer("priority", priority).Msg("request not admitted")
+			return err
+		}
+		defer done()
+
+		wrapped := middleware.WrapServerStream(stream)
+		wrapped.WrappedContext = contextWithPriority(wrapped.WrappedContext, priority)
+		return handler(srv, wrapped)
+	}
+}
+
+// UnaryPriorityServerInterceptor returns a new unary server interceptor that records the
+// priority of requests, so that it is given to the requests they dispatch, without admitting
+// them through an overload controller.
+func UnaryPriorityServerInterceptor(classifier ClassifierFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		priority := requestPriority(ctx, classifier, info.FullMethod, req)
+		return handler(contextWithPriority(ctx, priority), req)
+	}
+}
+
+// StreamPriorityServerInterceptor returns a new stream server interceptor that records the
+// priority of requests, so that it is given to the requests they dispatch, without admitting
+// them through an overload controller.
+func StreamPriorityServerInterceptor(classifier ClassifierFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		priority := requestPriority(stream.Context(), classifier, info.FullMethod, nil)
+		wrapped := middleware.WrapServerStream(stream)
+		wrapped.WrappedContext = contextWithPriority(wrapped.WrappedContext, priority)
+		return handler(srv, wrapped)
+	}
+}
@@ -0,0 +1,139 @@
+package overload
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestController(t *testing.T, maxConcurrent uint32) *Controller {
+	c, err := NewController(Config{
+		MaxConcurrentRequests: maxConcurrent,
+		TargetQueueDelay:      50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func TestAdmitUnderLimit(t *testing.T) {
+	require := require.New(t)
+	c := newTestController(t, 2)
+
+	first, err := c.Admit(context.Background(), PriorityLow)
+	require.NoError(err)
+	second, err := c.Admit(context.Background(), PriorityNormal)
+	require.NoError(err)
+
+	first()
+	second()
+	require.Equal(uint32(0), c.inFlight)
+}
+
+func TestShedLowPriorityWhenOverloaded(t *testing.T) {
+	require := require.New(t)
+	c := newTestController(t, 1)
+
+	done, err := c.Admit(context.Background(), PriorityHigh)
+	require.NoError(err)
+	defer done()
+
+	c.Lock()
+	c.queueDelay = time.Second
+	c.Unlock()
+
+	_, err = c.Admit(context.Background(), PriorityLow)
+	require.Error(err)
+
+	st, ok := status.FromError(err)
+	require.True(ok)
+	require.Equal(codes.ResourceExhausted, st.Code())
+	require.Len(st.Details(), 2)
+
+	retryInfo := st.Details()[0].(*errdetails.RetryInfo)
+	require.Equal(time.Second, retryInfo.RetryDelay.AsDuration())
+
+	errInfo := st.Details()[1].(*errdetails.ErrorInfo)
+	require.Equal(ErrorReasonOverloaded, errInfo.Reason)
+	require.Equal("low", errInfo.Metadata["priority"])
+}
+
+func TestLowPriorityQueueTimeout(t *testing.T) {
+	require := require.New(t)
+	c := newTestController(t, 1)
+
+	done, err := c.Admit(context.Background(), PriorityNormal)
+	require.NoError(err)
+	defer done()
+
+	_, err = c.Admit(context.Background(), PriorityLow)
+	require.Equal(codes.ResourceExhausted, status.Code(err))
+
+	c.Lock()
+	defer c.Unlock()
+	require.Empty(c.waiting[PriorityLow-1])
+}
+
+func TestAdmitInPriorityOrder(t *testing.T) {
+	require := require.New(t)
+	c := newTestController(t, 1)
+
+	done, err := c.Admit(context.Background(), PriorityNormal)
+	require.NoError(err)
+
+	admitted := make(chan Priority, 2)
+	for _, priority := range []Priority{PriorityNormal, PriorityHigh} {
+		priority := priority
+		go func() {
+			release, err := c.Admit(context.Background(), priority)
+			require.NoError(err)
+			admitted <- priority
+			release()
+		}()
+
+		require.Eventually(func() bool {
+			c.Lock()
+			defer c.Unlock()
+			return len(c.waiting[priority-1]) == 1
+		}, time.Second, time.Millisecond)
+	}
+
+	done()
+	require.Equal(PriorityHigh, <-admitted)
+	require.Equal(PriorityNormal, <-admitted)
+}
+
+func TestAdmitContextCanceled(t *testing.T) {
+	require := require.New(t)
+	c := newTestController(t, 1)
+
+	done, err := c.Admit(context.Background(), PriorityHigh)
+	require.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = c.Admit(ctx, PriorityHigh)
+	require.ErrorIs(err, context.Canceled)
+
+	done()
+	require.Equal(uint32(0), c.inFlight)
+}
+
+func TestExemptNotCounted(t *testing.T) {
+	require := require.New(t)
+	c := newTestController(t, 1)
+
+	done, err := c.Admit(context.Background(), PriorityHigh)
+	require.NoError(err)
+	defer done()
+
+	exempt, err := c.Admit(context.Background(), PriorityExempt)
+	require.NoError(err)
+	exempt()
+	require.Equal(uint32(1), c.inFlight)
+}
@@ -0,0 +1,156 @@
+package overload
+
+import (
+	"context"
+	"fmt"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	log "github.com/authzed/spicedb/internal/logging"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+// PriorityMetadataKey is the key of the request header with which clients can override the
+// priority given to a request by the classifier. Its value is one of low, normal or high.
+const PriorityMetadataKey = "io.spicedb.requestpriority"
+
+// ParsePriority parses the name of a priority which can be requested by clients.
+func ParsePriority(name string) (Priority, error) {
+	switch name {
+	case "low":
+		return PriorityLow, nil
+	case "normal":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	default:
+		return PriorityExempt, fmt.Errorf("unknown request priority %q", name)
+	}
+}
+
+// requestPriority returns the priority requested in the headers of the request if there is
+// one, otherwise the priority given to it by the classifier. Exempt requests remain exempt.
+func requestPriority(ctx context.Context, classifier ClassifierFunc, fullMethod string, req interface{}) Priority {
+	priority := classifier(fullMethod, req)
+	if priority == PriorityExempt {
+		return priority
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(PriorityMetadataKey); len(values) > 0 {
+			requested, err := ParsePriority(values[0])
+			if err != nil {
+				log.Ctx(ctx).Debug().Err(err).Str("method", fullMethod).Msg("ignoring requested priority")
+				return priority
+			}
+			return requested
+		}
+	}
+
+	return priority
+}
+
+// contextWithPriority records the priority of the request in its context, from which it is
+// given to the requests dispatched on its behalf.
+func contextWithPriority(ctx context.Context, priority Priority) context.Context {
+	if priority == PriorityExempt {
+		return ctx
+	}
+	return dispatch.ContextWithPriority(ctx, toDispatchPriority(priority))
+}
+
+func toDispatchPriority(priority Priority) v1.ResolverMeta_Priority {
+	switch priority {
+	case PriorityLow:
+		return v1.ResolverMeta_LOW_PRIORITY
+	case PriorityHigh:
+		return v1.ResolverMeta_HIGH_PRIORITY
+	default:
+		return v1.ResolverMeta_NORMAL_PRIORITY
+	}
+}
+
+func fromDispatchPriority(priority v1.ResolverMeta_Priority) Priority {
+	switch priority {
+	case v1.ResolverMeta_LOW_PRIORITY:
+		return PriorityLow
+	case v1.ResolverMeta_HIGH_PRIORITY:
+		return PriorityHigh
+	default:
+		return PriorityNormal
+	}
+}
+
+// DispatchClassifier gives dispatched requests the priority of the API request on whose
+// behalf they were dispatched. Requests without a priority are given normal priority, and
+// batches the highest priority of their requests.
+func DispatchClassifier(_ string, req interface{}) Priority {
+	if batch, ok := req.(*v1.DispatchCheckBatchRequest); ok {
+		priority := PriorityExempt
+		for _, batched := range batch.Requests {
+			if batchedPriority := DispatchClassifier("", batched); batchedPriority > priority {
+				priority = batchedPriority
+			}
+		}
+		if priority == PriorityExempt {
+			return PriorityNormal
+		}
+		return priority
+	}
+
+	if withMetadata, ok := req.(interface{ GetMetadata() *v1.ResolverMeta }); ok {
+		return fromDispatchPriority(withMetadata.GetMetadata().GetPriority())
+	}
+	return PriorityNormal
+}
+
+// StreamServerInterceptorByRequest returns a new stream server interceptor that admits server
+// streaming requests through the overload controller once their request has been received, so
+// that the classifier is given the request.
+func StreamServerInterceptorByRequest(c *Controller) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		wrapped := &admittingServerStream{
+			WrappedServerStream: middleware.WrapServerStream(stream),
+			controller:          c,
+			fullMethod:          info.FullMethod,
+		}
+		defer func() {
+			if wrapped.done != nil {
+				wrapped.done()
+			}
+		}()
+
+		return handler(srv, wrapped)
+	}
+}
+
+type admittingServerStream struct {
+	*middleware.WrappedServerStream
+
+	controller *Controller
+	fullMethod string
+	done       func()
+}
+
+func (s *admittingServerStream) RecvMsg(m interface{}) error {
+	if err := s.WrappedServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.done != nil {
+		return nil
+	}
+
+	priority := requestPriority(s.Context(), s.controller.classifier, s.fullMethod, m)
+	done, err := s.controller.Admit(s.Context(), priority)
+	if err != nil {
+		log.Ctx(s.Context()).Debug().Err(err).Str("method", s.fullMethod).Stringer("priority", priority).Msg("request not admitted")
+		return err
+	}
+
+	s.done = done
+	s.WrappedContext = contextWithPriority(s.WrappedContext, priority)
+	return nil
+}
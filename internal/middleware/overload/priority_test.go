@@ -0,0 +1,86 @@
+package overload
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/authzed/spicedb/internal/dispatch"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+func TestRequestPriority(t *testing.T) {
+	testCases := []struct {
+		name      string
+		method    string
+		requested string
+		expected  Priority
+	}{
+		{"classified", "/authzed.api.v1.PermissionsService/LookupResources", "", PriorityLow},
+		{"requested low", "/authzed.api.v1.PermissionsService/CheckPermission", "low", PriorityLow},
+		{"requested high", "/authzed.api.v1.PermissionsService/LookupResources", "high", PriorityHigh},
+		{"invalid request ignored", "/authzed.api.v1.PermissionsService/CheckPermission", "urgent", PriorityHigh},
+		{"exempt remains exempt", "/authzed.api.v1.WatchService/Watch", "low", PriorityExempt},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.requested != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(PriorityMetadataKey, tc.requested))
+			}
+			require.Equal(t, tc.expected, requestPriority(ctx, DefaultClassifier, tc.method, nil))
+		})
+	}
+}
+
+func TestDispatchClassifier(t *testing.T) {
+	require := require.New(t)
+
+	request := func(priority v1.ResolverMeta_Priority) interface{} {
+		return &v1.DispatchCheckRequest{Metadata: &v1.ResolverMeta{Priority: priority}}
+	}
+
+	require.Equal(PriorityLow, DispatchClassifier("", request(v1.ResolverMeta_LOW_PRIORITY)))
+	require.Equal(PriorityNormal, DispatchClassifier("", request(v1.ResolverMeta_NORMAL_PRIORITY)))
+	require.Equal(PriorityHigh, DispatchClassifier("", request(v1.ResolverMeta_HIGH_PRIORITY)))
+	require.Equal(PriorityNormal, DispatchClassifier("", request(v1.ResolverMeta_UNSPECIFIED_PRIORITY)))
+	require.Equal(PriorityNormal, DispatchClassifier("", &v1.DispatchCheckRequest{}))
+	require.Equal(PriorityNormal, DispatchClassifier("", nil))
+
+	batch := func(priorities ...v1.ResolverMeta_Priority) interface{} {
+		req := &v1.DispatchCheckBatchRequest{}
+		for _, priority := range priorities {
+			req.Requests = append(req.Requests, request(priority).(*v1.DispatchCheckRequest))
+		}
+		return req
+	}
+	require.Equal(PriorityHigh, DispatchClassifier("", batch(v1.ResolverMeta_LOW_PRIORITY, v1.ResolverMeta_HIGH_PRIORITY)))
+	require.Equal(PriorityLow, DispatchClassifier("", batch(v1.ResolverMeta_LOW_PRIORITY, v1.ResolverMeta_LOW_PRIORITY)))
+	require.Equal(PriorityNormal, DispatchClassifier("", batch()))
+}
+
+func TestPriorityGivenToDispatches(t *testing.T) {
+	require := require.New(t)
+
+	interceptor := UnaryPriorityServerInterceptor(DefaultClassifier)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(PriorityMetadataKey, "low"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/authzed.api.v1.PermissionsService/CheckPermission"}
+
+	_, err := interceptor(ctx, nil, info, func(ctx context.Context, _ interface{}) (interface{}, error) {
+		req := &v1.DispatchCheckRequest{Metadata: &v1.ResolverMeta{DepthRemaining: 50}}
+		dispatch.SetPriority(ctx, req)
+		require.Equal(v1.ResolverMeta_LOW_PRIORITY, req.Metadata.Priority)
+
+		// Requests which already have a priority keep it.
+		req.Metadata.Priority = v1.ResolverMeta_HIGH_PRIORITY
+		dispatch.SetPriority(ctx, req)
+		require.Equal(v1.ResolverMeta_HIGH_PRIORITY, req.Metadata.Priority)
+		return nil, nil
+	})
+	require.NoError(err)
+}
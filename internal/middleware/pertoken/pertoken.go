@@ -2,7 +2,11 @@ package pertoken
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -11,6 +15,9 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/proxy"
+	"github.com/authzed/spicedb/internal/datastore/redis"
+	"github.com/authzed/spicedb/internal/datastore/redis/resp"
 	log "github.com/authzed/spicedb/internal/logging"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -27,17 +34,115 @@ const (
 type MiddlewareForTesting struct {
 	datastoreByToken *sync.Map
 	configFilePaths  []string
+	faultInjection   proxy.FaultInjectionConfig
+	snapshotDir      string
+	datastoreOpts    []memdb.Option
+	redisClient      *resp.Client
 }
 
 // NewMiddleware returns a new per-token datastore middleware that initializes each datastore with the data in the
-// config files.
-func NewMiddleware(configFilePaths []string) *MiddlewareForTesting {
+// config files. If any faults are configured, they are injected into each datastore once it has been initialized.
+//
+// If a snapshot directory is given, the datastore of each token is saved to it as the middleware is closed, and a
+// datastore which was saved is restored from it in place of loading the config files. Any datastore options, such as
+// limits, are applied to the datastore of each token.
+func NewMiddleware(configFilePaths []string, faultInjection proxy.FaultInjectionConfig, snapshotDir string, datastoreOpts ...memdb.Option) *MiddlewareForTesting {
 	return &MiddlewareForTesting{
 		datastoreByToken: &sync.Map{},
 		configFilePaths:  configFilePaths,
+		faultInjection:   faultInjection,
+		snapshotDir:      snapshotDir,
+		datastoreOpts:    datastoreOpts,
 	}
 }
 
+// NewRedisMiddleware returns a new per-token datastore middleware whose datastores are stored by the Redis server of
+// the URI, each under a key prefix named for the hash of its token, over a pool of connections shared by every
+// datastore. A datastore which already holds a schema is kept as it is in place of loading the config files, so that
+// many test servers can share a server and a datastore outlives the test server which created it.
+func NewRedisMiddleware(configFilePaths []string, faultInjection proxy.FaultInjectionConfig, redisURI string) (*MiddlewareForTesting, error) {
+	config, err := resp.ParseURI(redisURI)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MiddlewareForTesting{
+		datastoreByToken: &sync.Map{},
+		configFilePaths:  configFilePaths,
+		faultInjection:   faultInjection,
+		redisClient:      resp.NewClient(config),
+	}, nil
+}
+
+// Close closes the datastore of each token, which saves those with a snapshot file. It returns the first error
+// encountered, after closing every datastore.
+func (m *MiddlewareForTesting) Close() error {
+	var closeErr error
+	m.datastoreByToken.Range(func(_, ds any) bool {
+		if err := ds.(datastore.Datastore).Close(); err != nil && closeErr == nil {
+			closeErr = err
+		}
+		return true
+	})
+	if m.redisClient != nil {
+		if err := m.redisClient.Close(); err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	return closeErr
+}
+
+// tokenHash returns the hash of the token, which names its snapshot file or key prefix so that tokens are not
+// stored.
+func tokenHash(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// snapshotFile returns the path of the snapshot file of the token's datastore.
+func (m *MiddlewareForTesting) snapshotFile(token string) string {
+	return filepath.Join(m.snapshotDir, tokenHash(token)+".json")
+}
+
+// newDatastore returns a new datastore for the token, and whether it was restored with its data rather than created
+// empty.
+func (m *MiddlewareForTesting) newDatastore(ctx context.Context, token string) (datastore.Datastore, bool, error) {
+	if m.redisClient != nil {
+		ds, err := redis.NewRedisDatastore("",
+			redis.SharedClient(m.redisClient),
+			redis.KeyPrefix("spicedb-testserver-"+tokenHash(token)),
+			redis.GCWindow(gcWindow),
+			redis.RevisionQuantization(revisionQuantization),
+		)
+		if err != nil {
+			return nil, false, err
+		}
+
+		head, err := ds.HeadRevision(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		nsDefs, err := ds.SnapshotReader(head).ListNamespaces(ctx)
+		if err != nil {
+			return nil, false, err
+		}
+		return ds, len(nsDefs) > 0, nil
+	}
+
+	opts := append([]memdb.Option(nil), m.datastoreOpts...)
+	restored := false
+	if m.snapshotDir != "" {
+		path := m.snapshotFile(token)
+		if _, err := os.Stat(path); err == nil {
+			restored = true
+		}
+		opts = append(opts, memdb.SnapshotFile(path))
+	}
+
+	ds, err := memdb.NewMemdbDatastore(0, revisionQuantization, gcWindow, opts...)
+	return ds, restored, err
+}
+
 func (m *MiddlewareForTesting) getOrCreateDatastore(ctx context.Context) (datastore.Datastore, error) {
 	tokenStr, _ := grpcauth.AuthFromMD(ctx, "bearer")
 	tokenDatastore, ok := m.datastoreByToken.Load(tokenStr)
@@ -46,14 +151,23 @@ func (m *MiddlewareForTesting) getOrCreateDatastore(ctx context.Context) (datast
 	}
 
 	log.Debug().Str("token", tokenStr).Msg("initializing new upstream for token")
-	ds, err := memdb.NewMemdbDatastore(0, revisionQuantization, gcWindow)
+	ds, restored, err := m.newDatastore(ctx, tokenStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to init datastore: %w", err)
 	}
 
-	_, _, err = validationfile.PopulateFromFiles(ctx, ds, m.configFilePaths)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load config files: %w", err)
+	if !restored {
+		_, _, err = validationfile.PopulateFromFiles(ctx, ds, m.configFilePaths)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config files: %w", err)
+		}
+	}
+
+	if m.faultInjection.Enabled() {
+		ds, err = proxy.NewFaultInjectionProxy(ds, m.faultInjection)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init fault injection: %w", err)
+		}
 	}
 
 	m.datastoreByToken.Store(tokenStr, ds)
@@ -232,6 +232,27 @@ func (err ErrDuplicateAllowedRelation) DetailsMetadata() map[string]string {
 	}
 }
 
+// ErrNonRecursiveMaxDepth occurs when a maximum recursion depth is defined on a relation that
+// does not refer to its own definition.
+type ErrNonRecursiveMaxDepth struct {
+	error
+	namespaceName string
+	relationName  string
+}
+
+// MarshalZerologObject implements zerolog object marshalling.
+func (err ErrNonRecursiveMaxDepth) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Str("namespace", err.namespaceName).Str("relation", err.relationName)
+}
+
+// DetailsMetadata returns the metadata for details for this error.
+func (err ErrNonRecursiveMaxDepth) DetailsMetadata() map[string]string {
+	return map[string]string{
+		"definition_name": err.namespaceName,
+		"relation_name":   err.relationName,
+	}
+}
+
 // ErrUnusedCaveatParameter indicates that a caveat parameter is unused in the caveat expression.
 type ErrUnusedCaveatParameter struct {
 	error
@@ -334,6 +355,16 @@ func NewTransitiveWildcardErr(nsName string, relationName string, foundRelationN
 	}
 }
 
+// NewNonRecursiveMaxDepthErr constructs an error indicating that a maximum recursion depth was
+// defined on a relation that is not self-referential.
+func NewNonRecursiveMaxDepthErr(nsName string, relationName string) error {
+	return ErrNonRecursiveMaxDepth{
+		error:         fmt.Errorf("relation `%s` defines a maximum depth but does not allow subjects of type `%s`: maximum depths can only be defined on self-referential relations", relationName, nsName),
+		namespaceName: nsName,
+		relationName:  relationName,
+	}
+}
+
 // NewPermissionsCycleErr constructs an error indicating that a cycle exists amongst permissions.
 func NewPermissionsCycleErr(nsName string, permissionNames []string) error {
 	return ErrPermissionsCycle{
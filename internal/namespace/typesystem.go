@@ -109,6 +109,12 @@ func (nts *TypeSystem) HasTypeInformation(relationName string) bool {
 	return ok && rel.GetTypeInformation() != nil
 }
 
+// GetRelation returns the relation with the given name, if it is defined in the namespace.
+func (nts *TypeSystem) GetRelation(relationName string) (*core.Relation, bool) {
+	relation, ok := nts.relationMap[relationName]
+	return relation, ok
+}
+
 // HasRelation returns true if the namespace has the given relation defined.
 func (nts *TypeSystem) HasRelation(relationName string) bool {
 	_, ok := nts.relationMap[relationName]
@@ -424,6 +430,15 @@ func (nts *TypeSystem) Validate(ctx context.Context) (*ValidatedNamespaceTypeSys
 			}
 		}
 
+		// A maximum recursion depth is only meaningful if the relation can refer back to
+		// its own definition.
+		if nspkg.GetRelationMaxRecursionDepth(relation) > 0 && !isSelfReferential(nts.nsDef.Name, allowedDirectRelations) {
+			return nil, newTypeErrorWithSource(
+				NewNonRecursiveMaxDepthErr(nts.nsDef.Name, relation.Name),
+				relation, relation.Name,
+			)
+		}
+
 		// Allowed relations verification:
 		// 1) that all allowed relations are not this very relation
 		// 2) that they exist within the referenced namespace
@@ -515,6 +530,15 @@ func (nts *TypeSystem) Validate(ctx context.Context) (*ValidatedNamespaceTypeSys
 	return &ValidatedNamespaceTypeSystem{nts}, nil
 }
 
+func isSelfReferential(nsName string, allowedDirectRelations []*core.AllowedRelation) bool {
+	for _, allowedRelation := range allowedDirectRelations {
+		if allowedRelation.GetNamespace() == nsName && allowedRelation.GetPublicWildcard() == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // SourceForAllowedRelation returns the source code representation of an allowed relation.
 func SourceForAllowedRelation(allowedRelation *core.AllowedRelation) string {
 	caveatStr := ""
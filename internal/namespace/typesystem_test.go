@@ -201,6 +201,28 @@ func TestTypeSystem(t *testing.T) {
 			nil,
 			"for arrow under permission `viewer`: relation `folder#parent` includes wildcard type `folder` via relation `folder#parent`: wildcard relations cannot be used on the left side of arrows",
 		},
+		{
+			"max depth on self-referential relation",
+			ns.Namespace(
+				"folder",
+				withMaxRecursionDepth(ns.Relation("parent", nil, ns.AllowedRelation("folder", "...")), 5),
+			),
+			[]*core.NamespaceDefinition{},
+			nil,
+			"",
+		},
+		{
+			"max depth on non-recursive relation",
+			ns.Namespace(
+				"document",
+				withMaxRecursionDepth(ns.Relation("viewer", nil, ns.AllowedRelation("user", "...")), 5),
+			),
+			[]*core.NamespaceDefinition{
+				ns.Namespace("user"),
+			},
+			nil,
+			"relation `viewer` defines a maximum depth but does not allow subjects of type `document`: maximum depths can only be defined on self-referential relations",
+		},
 		{
 			"recursive transitive wildcard type check",
 			ns.Namespace(
@@ -382,3 +404,10 @@ func TestTypeSystem(t *testing.T) {
 		})
 	}
 }
+
+func withMaxRecursionDepth(relation *core.Relation, maxDepth uint32) *core.Relation {
+	if err := ns.SetRelationMaxRecursionDepth(relation, maxDepth); err != nil {
+		panic(err)
+	}
+	return relation
+}
@@ -116,3 +116,74 @@ func (err ErrCaveatNotFound) GRPCStatus() *status.Status {
 		),
 	)
 }
+
+// ErrRelationshipVersionConflict indicates that a relationship was not in the exact state
+// required by a version precondition.
+type ErrRelationshipVersionConflict struct {
+	error
+	relationship *core.RelationTuple
+	expected     *core.RelationTuple
+	found        *core.RelationTuple
+}
+
+// NewRelationshipVersionConflictErr constructs a new error for a relationship which was not in
+// the expected state. A nil expected or found relationship indicates that the relationship was
+// expected not to exist or was not found, respectively.
+func NewRelationshipVersionConflictErr(relationship, expected, found *core.RelationTuple) ErrRelationshipVersionConflict {
+	return ErrRelationshipVersionConflict{
+		error: fmt.Errorf(
+			"relationship `%s` was not in the expected state: expected `%s`, found `%s`",
+			tuple.String(relationship),
+			stateString(expected),
+			stateString(found),
+		),
+		relationship: relationship,
+		expected:     expected,
+		found:        found,
+	}
+}
+
+// Expected is the expected state of the relationship, or nil if it was expected not to exist.
+func (err ErrRelationshipVersionConflict) Expected() *core.RelationTuple {
+	return err.expected
+}
+
+// Found is the state of the relationship found, or nil if it does not exist.
+func (err ErrRelationshipVersionConflict) Found() *core.RelationTuple {
+	return err.found
+}
+
+// GRPCStatus implements retrieving the gRPC status for the error.
+func (err ErrRelationshipVersionConflict) GRPCStatus() *status.Status {
+	return spiceerrors.WithCodeAndDetails(
+		err,
+		codes.FailedPrecondition,
+		spiceerrors.ForReason(
+			v1.ErrorReason_ERROR_REASON_WRITE_OR_DELETE_PRECONDITION_FAILURE,
+			map[string]string{
+				"relationship": tuple.String(err.relationship),
+				"expected":     stateString(err.expected),
+				"found":        stateString(err.found),
+			},
+		),
+	)
+}
+
+func stateString(tpl *core.RelationTuple) string {
+	if tpl == nil {
+		return "(none)"
+	}
+
+	str := tuple.String(tpl)
+	if tpl.Caveat == nil || tpl.Caveat.CaveatName == "" {
+		return str
+	}
+
+	caveat := tpl.Caveat.CaveatName
+	if len(tpl.Caveat.Context.GetFields()) > 0 {
+		if context, err := tpl.Caveat.Context.MarshalJSON(); err == nil {
+			caveat += ":" + string(context)
+		}
+	}
+	return str + "[" + caveat + "]"
+}
@@ -0,0 +1,101 @@
+package relationships
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/authzed/spicedb/internal/datastore/options"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// VersionPrecondition requires a relationship to be in an exact state when a write is applied,
+// allowing callers to implement read-modify-write flows, such as updating the caveat context of
+// a relationship, without overwriting concurrent changes.
+type VersionPrecondition struct {
+	// Expected is the expected state of the relationship, including its caveat and caveat
+	// context. If nil, the relationship identified by Relationship must not exist.
+	Expected *core.RelationTuple
+
+	// Relationship identifies the relationship by its resource, relation and subject. If nil,
+	// the relationship is identified by Expected.
+	Relationship *core.RelationTuple
+}
+
+func (vp VersionPrecondition) relationship() *core.RelationTuple {
+	if vp.Relationship != nil {
+		return vp.Relationship
+	}
+	return vp.Expected
+}
+
+var limitOne uint64 = 1
+
+// CheckVersionPreconditions checks that each relationship is in the exact state required by its
+// precondition, returning an ErrRelationshipVersionConflict for the first which is not.
+func CheckVersionPreconditions(
+	ctx context.Context,
+	reader datastore.Reader,
+	preconditions []VersionPrecondition,
+) error {
+	for _, precond := range preconditions {
+		rel := precond.relationship()
+		if rel == nil {
+			return fmt.Errorf("version precondition is missing a relationship")
+		}
+
+		found, err := readRelationship(ctx, reader, rel)
+		if err != nil {
+			return err
+		}
+
+		if !matchesExpected(found, precond.Expected) {
+			return NewRelationshipVersionConflictErr(rel, precond.Expected, found)
+		}
+	}
+
+	return nil
+}
+
+func readRelationship(ctx context.Context, reader datastore.Reader, rel *core.RelationTuple) (*core.RelationTuple, error) {
+	filter := datastore.RelationshipsFilterFromPublicFilter(tuple.ToFilter(rel))
+	iter, err := reader.QueryRelationships(ctx, filter, options.WithLimit(&limitOne))
+	if err != nil {
+		return nil, fmt.Errorf("error reading relationships: %w", err)
+	}
+	defer iter.Close()
+
+	found := iter.Next()
+	if found == nil && iter.Err() != nil {
+		return nil, fmt.Errorf("error reading relationships from iterator: %w", iter.Err())
+	}
+	return found, nil
+}
+
+func matchesExpected(found, expected *core.RelationTuple) bool {
+	if found == nil || expected == nil {
+		return found == nil && expected == nil
+	}
+	return caveatsEqual(found.Caveat, expected.Caveat)
+}
+
+// caveatsEqual returns whether the caveats have the same name and context, treating a missing
+// context as empty.
+func caveatsEqual(first, second *core.ContextualizedCaveat) bool {
+	if first.GetCaveatName() != second.GetCaveatName() {
+		return false
+	}
+
+	firstContext, secondContext := first.GetContext(), second.GetContext()
+	if firstContext == nil {
+		firstContext = &structpb.Struct{}
+	}
+	if secondContext == nil {
+		secondContext = &structpb.Struct{}
+	}
+	return proto.Equal(firstContext, secondContext)
+}
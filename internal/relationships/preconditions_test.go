@@ -0,0 +1,87 @@
+package relationships
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func withCaveat(tpl string, context map[string]any) *core.RelationTuple {
+	parsed := tuple.MustParse(tpl)
+	caveatContext, err := structpb.NewStruct(context)
+	if err != nil {
+		panic(err)
+	}
+	parsed.Caveat = &core.ContextualizedCaveat{CaveatName: "test", Context: caveatContext}
+	return parsed
+}
+
+func TestCheckVersionPreconditions(t *testing.T) {
+	const existing = "document:companyplan#parent@folder:company#..."
+
+	testCases := []struct {
+		name          string
+		preconditions []VersionPrecondition
+		expectedError bool
+	}{
+		{
+			"exact state",
+			[]VersionPrecondition{{Expected: withCaveat(existing, map[string]any{"expectedSecret": "1234"})}},
+			false,
+		},
+		{
+			"different caveat context",
+			[]VersionPrecondition{{Expected: withCaveat(existing, map[string]any{"expectedSecret": "4321"})}},
+			true,
+		},
+		{
+			"missing caveat",
+			[]VersionPrecondition{{Expected: tuple.MustParse(existing)}},
+			true,
+		},
+		{
+			"expected to not exist",
+			[]VersionPrecondition{{Relationship: tuple.MustParse(existing)}},
+			true,
+		},
+		{
+			"does not exist",
+			[]VersionPrecondition{{Relationship: tuple.MustParse("document:companyplan#parent@folder:unknown#...")}},
+			false,
+		},
+		{
+			"expected to exist",
+			[]VersionPrecondition{{Expected: withCaveat("document:companyplan#parent@folder:unknown#...", nil)}},
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			rawDS, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			require.NoError(err)
+
+			ds, revision := testfixtures.StandardDatastoreWithCaveatedData(rawDS, require)
+
+			err = CheckVersionPreconditions(context.Background(), ds.SnapshotReader(revision), tc.preconditions)
+			if !tc.expectedError {
+				require.NoError(err)
+				return
+			}
+
+			var conflict ErrRelationshipVersionConflict
+			require.True(errors.As(err, &conflict))
+		})
+	}
+}
@@ -3,6 +3,7 @@ package dispatch
 import (
 	"context"
 	"errors"
+	"sync"
 
 	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
 	"google.golang.org/grpc/codes"
@@ -12,6 +13,7 @@ import (
 	"github.com/authzed/spicedb/internal/graph"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/internal/services/shared"
+	"github.com/authzed/spicedb/pkg/datastore"
 	dispatchv1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 )
 
@@ -34,17 +36,73 @@ func NewDispatchServer(localDispatch dispatch.Dispatcher) dispatchv1.DispatchSer
 }
 
 func (ds *dispatchServer) DispatchCheck(ctx context.Context, req *dispatchv1.DispatchCheckRequest) (*dispatchv1.DispatchCheckResponse, error) {
+	ctx, cancel := requestContext(ctx, req.GetMetadata())
+	defer cancel()
 	resp, err := ds.localDispatch.DispatchCheck(ctx, req)
 	return resp, rewriteGraphError(ctx, err)
 }
 
+// DispatchCheckBatch serves a batch of checks concurrently, each at its own priority, streaming
+// the result or error of each as soon as it is resolved.
+func (ds *dispatchServer) DispatchCheckBatch(req *dispatchv1.DispatchCheckBatchRequest, resp dispatchv1.DispatchService_DispatchCheckBatchServer) error {
+	ctx := resp.Context()
+
+	var sendLock sync.Mutex
+	var sendErr error
+	send := func(result *dispatchv1.DispatchCheckBatchResponse) {
+		sendLock.Lock()
+		defer sendLock.Unlock()
+		if sendErr == nil {
+			sendErr = resp.Send(result)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(req.Requests))
+	for index, checkReq := range req.Requests {
+		index, checkReq := index, checkReq
+		go func() {
+			defer wg.Done()
+
+			checkResp, err := ds.DispatchCheck(ctx, checkReq)
+			if err != nil {
+				s := status.Convert(err)
+				send(&dispatchv1.DispatchCheckBatchResponse{
+					Index:        uint32(index),
+					ErrorCode:    uint32(s.Code()),
+					ErrorMessage: s.Message(),
+				})
+				return
+			}
+			send(&dispatchv1.DispatchCheckBatchResponse{Index: uint32(index), Response: checkResp})
+		}()
+	}
+	wg.Wait()
+
+	return sendErr
+}
+
 func (ds *dispatchServer) DispatchExpand(ctx context.Context, req *dispatchv1.DispatchExpandRequest) (*dispatchv1.DispatchExpandResponse, error) {
+	ctx, cancel := requestContext(ctx, req.GetMetadata())
+	defer cancel()
 	resp, err := ds.localDispatch.DispatchExpand(ctx, req)
 	return resp, rewriteGraphError(ctx, err)
 }
 
+// DispatchLookup serves lookups from peers which do not support streamed lookups, by collecting
+// all of the chunks of the lookup into a single response.
 func (ds *dispatchServer) DispatchLookup(ctx context.Context, req *dispatchv1.DispatchLookupRequest) (*dispatchv1.DispatchLookupResponse, error) {
-	resp, err := ds.localDispatch.DispatchLookup(ctx, req)
+	ctx, cancel := requestContext(ctx, req.GetMetadata())
+	defer cancel()
+
+	resp := &dispatchv1.DispatchLookupResponse{Metadata: &dispatchv1.ResponseMeta{}}
+	stream := dispatch.NewHandlingDispatchStream(ctx, func(result *dispatchv1.DispatchLookupResponse) error {
+		dispatch.AddResponseMetadata(resp.Metadata, result.Metadata)
+		resp.ResolvedResources = append(resp.ResolvedResources, result.ResolvedResources...)
+		return nil
+	})
+
+	err := ds.localDispatch.DispatchLookup(req, stream)
 	return resp, rewriteGraphError(ctx, err)
 }
 
@@ -52,16 +110,37 @@ func (ds *dispatchServer) DispatchReachableResources(
 	req *dispatchv1.DispatchReachableResourcesRequest,
 	resp dispatchv1.DispatchService_DispatchReachableResourcesServer,
 ) error {
+	ctx, cancel := requestContext(resp.Context(), req.GetMetadata())
+	defer cancel()
 	return ds.localDispatch.DispatchReachableResources(req,
-		dispatch.WrapGRPCStream[*dispatchv1.DispatchReachableResourcesResponse](resp))
+		dispatch.StreamWithContext(ctx, dispatch.WrapGRPCStream[*dispatchv1.DispatchReachableResourcesResponse](resp)))
 }
 
 func (ds *dispatchServer) DispatchLookupSubjects(
 	req *dispatchv1.DispatchLookupSubjectsRequest,
 	resp dispatchv1.DispatchService_DispatchLookupSubjectsServer,
 ) error {
+	ctx, cancel := requestContext(resp.Context(), req.GetMetadata())
+	defer cancel()
 	return ds.localDispatch.DispatchLookupSubjects(req,
-		dispatch.WrapGRPCStream[*dispatchv1.DispatchLookupSubjectsResponse](resp))
+		dispatch.StreamWithContext(ctx, dispatch.WrapGRPCStream[*dispatchv1.DispatchLookupSubjectsResponse](resp)))
+}
+
+func (ds *dispatchServer) DispatchLookupResources(
+	req *dispatchv1.DispatchLookupRequest,
+	resp dispatchv1.DispatchService_DispatchLookupResourcesServer,
+) error {
+	ctx, cancel := requestContext(resp.Context(), req.GetMetadata())
+	defer cancel()
+	return ds.localDispatch.DispatchLookup(req,
+		dispatch.StreamWithContext(ctx, dispatch.WrapGRPCStream[*dispatchv1.DispatchLookupResponse](resp)))
+}
+
+// requestContext returns the context in which to resolve a request dispatched by another node, at
+// its priority and within its time budget.
+func requestContext(ctx context.Context, metadata *dispatchv1.ResolverMeta) (context.Context, context.CancelFunc) {
+	ctx = dispatch.ContextWithPriority(ctx, metadata.GetPriority())
+	return dispatch.ContextWithTimeBudget(ctx, metadata)
 }
 
 func (ds *dispatchServer) Close() error {
@@ -72,10 +151,16 @@ func rewriteGraphError(ctx context.Context, err error) error {
 	switch {
 	case errors.As(err, &graph.ErrRequestCanceled{}):
 		return status.Errorf(codes.Canceled, "request canceled: %s", err)
+	case errors.As(err, &dispatch.ErrTimeBudgetExhausted{}):
+		return status.Errorf(codes.DeadlineExceeded, "%s", err)
 	case errors.Is(err, context.DeadlineExceeded):
 		return status.Errorf(codes.DeadlineExceeded, "%s", err)
 	case errors.Is(err, context.Canceled):
 		return status.Errorf(codes.Canceled, "%s", err)
+	case errors.As(err, &datastore.ErrQueryLimitExceeded{}):
+		return status.Errorf(codes.ResourceExhausted, "%s", err)
+	case errors.As(err, &dispatch.ErrBudgetExhausted{}):
+		return status.Errorf(codes.ResourceExhausted, "%s", err)
 	case err == nil:
 		return nil
 
@@ -7,11 +7,14 @@ import (
 	"strconv"
 
 	"github.com/rs/zerolog"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 
+	"github.com/authzed/spicedb/internal/dispatch"
 	"github.com/authzed/spicedb/internal/graph"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/internal/namespace"
@@ -199,6 +202,7 @@ func rewriteError(ctx context.Context, err error) error {
 	var compilerError compiler.BaseCompilerError
 	var sourceError spiceerrors.ErrorWithSource
 	var typeError namespace.TypeError
+	var unavailableError datastore.ErrUnavailable
 
 	switch {
 	case errors.As(err, &typeError):
@@ -223,6 +227,16 @@ func rewriteError(ctx context.Context, err error) error {
 		return spiceerrors.WithCodeAndReason(err, codes.FailedPrecondition, v1.ErrorReason_ERROR_REASON_UNKNOWN_CAVEAT)
 	case errors.As(err, &datastore.ErrWatchDisabled{}):
 		return status.Errorf(codes.FailedPrecondition, "%s", err)
+	case errors.As(err, &unavailableError):
+		return spiceerrors.WithCodeAndDetails(err, codes.Unavailable, &errdetails.RetryInfo{
+			RetryDelay: durationpb.New(unavailableError.RetryAfter()),
+		}).Err()
+	case errors.As(err, &datastore.ErrMaxRetriesExceeded{}):
+		return status.Errorf(codes.Aborted, "%s", err)
+	case errors.As(err, &datastore.ErrQueryLimitExceeded{}):
+		return status.Errorf(codes.ResourceExhausted, "%s", err)
+	case errors.As(err, &datastore.ErrRelationshipLimitExceeded{}):
+		return status.Errorf(codes.ResourceExhausted, "%s", err)
 
 	case errors.As(err, &graph.ErrInvalidArgument{}):
 		return status.Errorf(codes.InvalidArgument, "%s", err)
@@ -230,6 +244,12 @@ func rewriteError(ctx context.Context, err error) error {
 		return status.Errorf(codes.Canceled, "request canceled: %s", err)
 	case errors.As(err, &graph.ErrRelationMissingTypeInfo{}):
 		return status.Errorf(codes.FailedPrecondition, "failed precondition: %s", err)
+	case errors.As(err, &graph.ErrRecursionDepthExceeded{}):
+		return status.Errorf(codes.FailedPrecondition, "failed precondition: %s", err)
+	case errors.As(err, &dispatch.ErrBudgetExhausted{}):
+		return status.Errorf(codes.ResourceExhausted, "%s", err)
+	case errors.As(err, &dispatch.ErrTimeBudgetExhausted{}):
+		return status.Errorf(codes.DeadlineExceeded, "%s", err)
 	case errors.As(err, &graph.ErrAlwaysFail{}):
 		log.Ctx(ctx).Err(err).Msg("received internal error")
 		return status.Errorf(codes.Internal, "internal error: %s", err)
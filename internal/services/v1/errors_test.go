@@ -2,11 +2,17 @@ package v1
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/authzed/grpcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/authzed/spicedb/pkg/datastore"
 )
 
 func TestRewriteCanceledError(t *testing.T) {
@@ -22,3 +28,13 @@ func TestRewriteDeadlineExceededError(t *testing.T) {
 	errorRewritten := rewriteError(ctx, ctx.Err())
 	grpcutil.RequireStatus(t, codes.DeadlineExceeded, errorRewritten)
 }
+
+func TestRewriteUnavailableError(t *testing.T) {
+	err := fmt.Errorf("failed to read: %w", datastore.NewUnavailableErr("circuit open", 2*time.Second))
+	errorRewritten := rewriteError(context.Background(), err)
+	grpcutil.RequireStatus(t, codes.Unavailable, errorRewritten)
+
+	details := status.Convert(errorRewritten).Details()
+	require.Len(t, details, 1)
+	require.Equal(t, 2*time.Second, details[0].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+}
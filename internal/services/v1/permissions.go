@@ -85,7 +85,9 @@ func (ps *permissionServer) CheckPermission(ctx context.Context, req *v1.CheckPe
 			CaveatContext:      caveatContext,
 			AtRevision:         atRevision,
 			MaximumDepth:       ps.config.MaximumAPIDepth,
+			DispatchBudget:     ps.config.DispatchBudgets["CheckPermission"],
 			IsDebuggingEnabled: isDebuggingEnabled,
+			CaveatContextCache: ps.config.CaveatContextCache,
 		},
 		req.Resource.ObjectId,
 	)
@@ -104,8 +106,16 @@ func (ps *permissionServer) CheckPermission(ctx context.Context, req *v1.CheckPe
 			return nil, rewriteError(ctx, merr)
 		}
 
+		// The complete dispatch tree, including where and how long each subproblem was
+		// resolved, is returned alongside the API debug information.
+		dispatchTrace, terr := dispatchpkg.MarshalDispatchTrace(ctx, metadata)
+		if terr != nil {
+			return nil, rewriteError(ctx, terr)
+		}
+
 		serr := responsemeta.SetResponseTrailerMetadata(ctx, map[responsemeta.ResponseMetadataTrailerKey]string{
-			responsemeta.DebugInformation: string(marshaled),
+			responsemeta.DebugInformation:       string(marshaled),
+			dispatchpkg.DispatchTraceTrailerKey: dispatchTrace,
 		})
 		if serr != nil {
 			return nil, rewriteError(ctx, serr)
@@ -147,6 +157,7 @@ func (ps *permissionServer) ExpandPermissionTree(ctx context.Context, req *v1.Ex
 		Metadata: &dispatch.ResolverMeta{
 			AtRevision:     atRevision.String(),
 			DepthRemaining: ps.config.MaximumAPIDepth,
+			DispatchBudget: ps.config.DispatchBudgets["ExpandPermissionTree"],
 		},
 		ResourceAndRelation: &core.ObjectAndRelation{
 			Namespace: req.Resource.ObjectType,
@@ -340,49 +351,64 @@ func (ps *permissionServer) LookupResources(req *v1.LookupResourcesRequest, resp
 		return rewriteError(ctx, err)
 	}
 
-	// TODO(jschorr): Change the internal dispatched lookup to also be streamed.
-	lookupResp, err := ps.dispatch.DispatchLookup(ctx, &dispatch.DispatchLookupRequest{
-		Metadata: &dispatch.ResolverMeta{
-			AtRevision:     atRevision.String(),
-			DepthRemaining: ps.config.MaximumAPIDepth,
-		},
-		ObjectRelation: &core.RelationReference{
-			Namespace: req.ResourceObjectType,
-			Relation:  req.Permission,
-		},
-		Subject: &core.ObjectAndRelation{
-			Namespace: req.Subject.Object.ObjectType,
-			ObjectId:  req.Subject.Object.ObjectId,
-			Relation:  normalizeSubjectRelation(req.Subject),
-		},
-		Context: req.Context,
-		Limit:   ^uint32(0), // Set no limit for now
-	})
-	usagemetrics.SetInContext(ctx, lookupResp.Metadata)
-	if err != nil {
-		return rewriteError(ctx, err)
+	respMetadata := &dispatch.ResponseMeta{
+		DispatchCount:       0,
+		CachedDispatchCount: 0,
+		DepthRequired:       0,
+		DebugInfo:           nil,
 	}
+	usagemetrics.SetInContext(ctx, respMetadata)
 
-	for _, found := range lookupResp.ResolvedResources {
-		var partial *v1.PartialCaveatInfo
-		permissionship := v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_HAS_PERMISSION
-		if found.Permissionship == dispatch.ResolvedResource_CONDITIONALLY_HAS_PERMISSION {
-			permissionship = v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_CONDITIONAL_PERMISSION
-			partial = &v1.PartialCaveatInfo{
-				MissingRequiredContext: found.MissingRequiredContext,
+	stream := dispatchpkg.NewHandlingDispatchStream(ctx, func(result *dispatch.DispatchLookupResponse) error {
+		for _, found := range result.ResolvedResources {
+			var partial *v1.PartialCaveatInfo
+			permissionship := v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_HAS_PERMISSION
+			if found.Permissionship == dispatch.ResolvedResource_CONDITIONALLY_HAS_PERMISSION {
+				permissionship = v1.LookupPermissionship_LOOKUP_PERMISSIONSHIP_CONDITIONAL_PERMISSION
+				partial = &v1.PartialCaveatInfo{
+					MissingRequiredContext: found.MissingRequiredContext,
+				}
 			}
-		}
 
-		err := resp.Send(&v1.LookupResourcesResponse{
-			LookedUpAt:        revisionReadAt,
-			ResourceObjectId:  found.ResourceId,
-			Permissionship:    permissionship,
-			PartialCaveatInfo: partial,
-		})
-		if err != nil {
-			return err
+			err := resp.Send(&v1.LookupResourcesResponse{
+				LookedUpAt:        revisionReadAt,
+				ResourceObjectId:  found.ResourceId,
+				Permissionship:    permissionship,
+				PartialCaveatInfo: partial,
+			})
+			if err != nil {
+				return err
+			}
 		}
+
+		dispatchpkg.AddResponseMetadata(respMetadata, result.Metadata)
+		return nil
+	})
+
+	err := ps.dispatch.DispatchLookup(
+		&dispatch.DispatchLookupRequest{
+			Metadata: &dispatch.ResolverMeta{
+				AtRevision:     atRevision.String(),
+				DepthRemaining: ps.config.MaximumAPIDepth,
+				DispatchBudget: ps.config.DispatchBudgets["LookupResources"],
+			},
+			ObjectRelation: &core.RelationReference{
+				Namespace: req.ResourceObjectType,
+				Relation:  req.Permission,
+			},
+			Subject: &core.ObjectAndRelation{
+				Namespace: req.Subject.Object.ObjectType,
+				ObjectId:  req.Subject.Object.ObjectId,
+				Relation:  normalizeSubjectRelation(req.Subject),
+			},
+			Context: req.Context,
+			Limit:   ^uint32(0), // Set no limit for now
+		},
+		stream)
+	if err != nil {
+		return rewriteError(ctx, err)
 	}
+
 	return nil
 }
 
@@ -486,6 +512,7 @@ func (ps *permissionServer) LookupSubjects(req *v1.LookupSubjectsRequest, resp v
 			Metadata: &dispatch.ResolverMeta{
 				AtRevision:     atRevision.String(),
 				DepthRemaining: ps.config.MaximumAPIDepth,
+				DispatchBudget: ps.config.DispatchBudgets["LookupSubjects"],
 			},
 			ResourceRelation: &core.RelationReference{
 				Namespace: req.Resource.ObjectType,
@@ -26,11 +26,13 @@ import (
 	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/dispatch"
 	v1svc "github.com/authzed/spicedb/internal/services/v1"
 	tf "github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/internal/testserver"
 	pgraph "github.com/authzed/spicedb/pkg/graph"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dispatchv1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
 	"github.com/authzed/spicedb/pkg/schemadsl/input"
 	"github.com/authzed/spicedb/pkg/tuple"
@@ -340,6 +342,23 @@ func TestCheckPermissionWithDebugInfo(t *testing.T) {
 	}, &emptyDefaultPrefix)
 	require.NoError(err, "Invalid schema: %s", debugInfo.SchemaUsed)
 	require.Equal(3, len(compiled.OrderedDefinitions))
+
+	// The complete dispatch tree records where and for how long each subproblem was resolved.
+	encodedDispatchTrace, err := responsemeta.GetResponseTrailerMetadataOrNil(trailer, dispatch.DispatchTraceTrailerKey)
+	require.NoError(err)
+	require.NotNil(encodedDispatchTrace)
+
+	dispatchTrace := &dispatchv1.DebugInformation{}
+	err = protojson.Unmarshal([]byte(*encodedDispatchTrace), dispatchTrace)
+	require.NoError(err)
+
+	require.Equal("masterplan", dispatchTrace.Check.Request.ResourceIds[0])
+	require.Equal(dispatch.NodeName, dispatchTrace.Check.HandledBy)
+	require.NotNil(dispatchTrace.Check.Duration)
+	require.GreaterOrEqual(len(dispatchTrace.Check.SubProblems), 1)
+	for _, subProblem := range dispatchTrace.Check.SubProblems {
+		require.Equal(dispatch.NodeName, subProblem.HandledBy)
+	}
 }
 
 func TestLookupResources(t *testing.T) {
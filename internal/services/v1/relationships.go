@@ -9,7 +9,9 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/graph/computed"
 	"github.com/authzed/spicedb/internal/middleware"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	"github.com/authzed/spicedb/internal/middleware/handwrittenvalidation"
@@ -38,8 +40,21 @@ type PermissionsServerConfig struct {
 	// MaximumAPIDepth is the default/starting depth remaining for API calls made
 	// to the permissions server.
 	MaximumAPIDepth uint32
+
+	// DispatchBudgets are the budgets for dispatching the subproblems of a call to each API
+	// method, keyed by the name of the method (e.g. "CheckPermission"), in which each subproblem
+	// costs one more than its depth. Calls to methods without a budget are limited only by their
+	// depth.
+	DispatchBudgets map[string]uint32
+
+	// CaveatContextCache, if set, caches the results of permission checks evaluated with a
+	// fully-specified caveat context.
+	CaveatContextCache computed.CaveatContextCache
 }
 
+// DispatchBudgetMethods are the names of the API methods which can be given a dispatch budget.
+var DispatchBudgetMethods = []string{"CheckPermission", "ExpandPermissionTree", "LookupResources", "LookupSubjects"}
+
 // NewPermissionsServer creates a PermissionsServiceServer instance.
 func NewPermissionsServer(
 	dispatch dispatch.Dispatcher,
@@ -50,6 +65,8 @@ func NewPermissionsServer(
 		MaxPreconditionsCount: defaultIfZero(config.MaxPreconditionsCount, 1000),
 		MaxUpdatesPerWrite:    defaultIfZero(config.MaxUpdatesPerWrite, 1000),
 		MaximumAPIDepth:       defaultIfZero(config.MaximumAPIDepth, 50),
+		DispatchBudgets:       config.DispatchBudgets,
+		CaveatContextCache:    config.CaveatContextCache,
 	}
 
 	return &permissionServer{
@@ -175,6 +192,11 @@ func (ps *permissionServer) WriteRelationships(ctx context.Context, req *v1.Writ
 		}
 	}
 
+	txMetadata, err := transactionMetadataFromContext(ctx)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
 	// Execute the write operation(s).
 	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
 		// Validate the preconditions.
@@ -201,11 +223,13 @@ func (ps *permissionServer) WriteRelationships(ctx context.Context, req *v1.Writ
 		}
 
 		return rwt.WriteRelationships(ctx, tupleUpdates)
-	})
+	}, options.SetMetadata(txMetadata))
 	if err != nil {
 		return nil, rewriteError(ctx, err)
 	}
 
+	logTransactionMetadata(ctx, "WriteRelationships", revision, txMetadata)
+
 	return &v1.WriteRelationshipsResponse{
 		WrittenAt: zedtoken.NewFromRevision(revision),
 	}, nil
@@ -221,6 +245,11 @@ func (ps *permissionServer) DeleteRelationships(ctx context.Context, req *v1.Del
 
 	ds := datastoremw.MustFromContext(ctx)
 
+	txMetadata, err := transactionMetadataFromContext(ctx)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
 	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
 		if err := ps.checkFilterNamespaces(ctx, req.RelationshipFilter, rwt); err != nil {
 			return err
@@ -236,11 +265,13 @@ func (ps *permissionServer) DeleteRelationships(ctx context.Context, req *v1.Del
 		}
 
 		return rwt.DeleteRelationships(ctx, req.RelationshipFilter)
-	})
+	}, options.SetMetadata(txMetadata))
 	if err != nil {
 		return nil, rewriteError(ctx, err)
 	}
 
+	logTransactionMetadata(ctx, "DeleteRelationships", revision, txMetadata)
+
 	return &v1.DeleteRelationshipsResponse{
 		DeletedAt: zedtoken.NewFromRevision(revision),
 	}, nil
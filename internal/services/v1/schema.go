@@ -2,13 +2,14 @@ package v1
 
 import (
 	"context"
-	"fmt"
+	"strings"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	grpcvalidate "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/validator"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/internal/middleware/consistency"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
@@ -45,40 +46,75 @@ func (ss *schemaServer) ReadSchema(ctx context.Context, in *v1.ReadSchemaRequest
 	readRevision, _ := consistency.MustRevisionFromContext(ctx)
 	ds := datastoremw.MustFromContext(ctx).SnapshotReader(readRevision)
 
-	nsDefs, err := ds.ListNamespaces(ctx)
+	// Definitions are read and generated a page at a time, so that only the generated schema
+	// text, and not every definition, is held in memory.
+	var schemaText strings.Builder
+	caveatCount, err := generateDefinitionsInPages(ctx, &schemaText, ds.ListCaveats)
 	if err != nil {
 		return nil, rewriteError(ctx, err)
 	}
 
-	caveatDefs, err := ds.ListCaveats(ctx)
+	nsCount, err := generateDefinitionsInPages(ctx, &schemaText, ds.ListNamespaces)
 	if err != nil {
 		return nil, rewriteError(ctx, err)
 	}
 
-	if len(nsDefs) == 0 {
+	if nsCount == 0 {
 		return nil, status.Errorf(codes.NotFound, "No schema has been defined; please call WriteSchema to start")
 	}
 
-	schemaDefinitions := make([]compiler.SchemaDefinition, 0, len(nsDefs)+len(caveatDefs))
-	for _, caveatDef := range caveatDefs {
-		schemaDefinitions = append(schemaDefinitions, caveatDef)
-	}
-
-	for _, nsDef := range nsDefs {
-		schemaDefinitions = append(schemaDefinitions, nsDef)
-	}
-
-	schemaText, _ := generator.GenerateSchema(schemaDefinitions)
-
 	usagemetrics.SetInContext(ctx, &dispatchv1.ResponseMeta{
-		DispatchCount: uint32(len(nsDefs) + len(caveatDefs)),
+		DispatchCount: uint32(nsCount + caveatCount),
 	})
 
 	return &v1.ReadSchemaResponse{
-		SchemaText: schemaText,
+		SchemaText: schemaText.String(),
 	}, nil
 }
 
+// schemaDefinitionsPageSize is the number of definitions read from the datastore at a time when
+// generating the schema.
+const schemaDefinitionsPageSize = 1000
+
+// generateDefinitionsInPages lists the definitions in pages of schemaDefinitionsPageSize,
+// appending the generated source of each page to the schema text, and returns the number of
+// definitions listed.
+func generateDefinitionsInPages[T compiler.SchemaDefinition](
+	ctx context.Context,
+	schemaText *strings.Builder,
+	list func(context.Context, ...options.ListOptionsOption) ([]T, error),
+) (int, error) {
+	limit := uint64(schemaDefinitionsPageSize)
+	after := ""
+	count := 0
+	for {
+		defs, err := list(ctx, options.WithListLimit(&limit), options.WithListAfter(after))
+		if err != nil {
+			return 0, err
+		}
+		if len(defs) == 0 {
+			return count, nil
+		}
+
+		schemaDefinitions := make([]compiler.SchemaDefinition, 0, len(defs))
+		for _, def := range defs {
+			schemaDefinitions = append(schemaDefinitions, def)
+		}
+
+		generated, _ := generator.GenerateSchema(schemaDefinitions)
+		if schemaText.Len() > 0 {
+			schemaText.WriteString("\n\n")
+		}
+		schemaText.WriteString(generated)
+
+		count += len(defs)
+		if len(defs) < schemaDefinitionsPageSize {
+			return count, nil
+		}
+		after = defs[len(defs)-1].GetName()
+	}
+}
+
 func (ss *schemaServer) WriteSchema(ctx context.Context, in *v1.WriteSchemaRequest) (*v1.WriteSchemaResponse, error) {
 	log.Ctx(ctx).Trace().Str("schema", in.GetSchema()).Msg("requested Schema to be written")
 
@@ -96,7 +132,7 @@ func (ss *schemaServer) WriteSchema(ctx context.Context, in *v1.WriteSchemaReque
 	log.Ctx(ctx).Trace().Int("objectDefinitions", len(compiled.ObjectDefinitions)).Int("caveatDefinitions", len(compiled.CaveatDefinitions)).Msg("compiled namespace definitions")
 
 	if !ss.caveatsEnabled && len(compiled.CaveatDefinitions) > 0 {
-		return nil, fmt.Errorf("caveats are currently not supported")
+		return nil, status.Errorf(codes.FailedPrecondition, "caveats are currently not supported")
 	}
 
 	// Do as much validation as we can before talking to the datastore.
@@ -105,8 +141,13 @@ func (ss *schemaServer) WriteSchema(ctx context.Context, in *v1.WriteSchemaReque
 		return nil, rewriteError(ctx, err)
 	}
 
+	txMetadata, err := transactionMetadataFromContext(ctx)
+	if err != nil {
+		return nil, rewriteError(ctx, err)
+	}
+
 	// Update the schema.
-	_, err = ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
 		applied, err := shared.ApplySchemaChanges(ctx, rwt, validated)
 		if err != nil {
 			return err
@@ -115,10 +156,12 @@ func (ss *schemaServer) WriteSchema(ctx context.Context, in *v1.WriteSchemaReque
 			DispatchCount: applied.TotalOperationCount,
 		})
 		return nil
-	})
+	}, options.SetMetadata(txMetadata))
 	if err != nil {
 		return nil, rewriteError(ctx, err)
 	}
 
+	logTransactionMetadata(ctx, "WriteSchema", revision, txMetadata)
+
 	return &v1.WriteSchemaResponse{}, nil
 }
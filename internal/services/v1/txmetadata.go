@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	"github.com/authzed/spicedb/pkg/datastore"
+)
+
+const (
+	// TransactionMetadataHeaderPrefix is the prefix of request headers whose values are stored
+	// as metadata with the transaction of a write, keyed by the remainder of the header name.
+	// For example, `x-spicedb-tx-actor: user:tom` stores the metadata `actor=user:tom`.
+	TransactionMetadataHeaderPrefix = "x-spicedb-tx-"
+
+	maxTransactionMetadataEntries     = 16
+	maxTransactionMetadataValueLength = 256
+)
+
+// transactionMetadataFromContext returns the transaction metadata supplied in the headers of
+// the incoming request, if any.
+func transactionMetadataFromContext(ctx context.Context) (map[string]string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+
+	var txMetadata map[string]string
+	for header, values := range md {
+		key := strings.TrimPrefix(header, TransactionMetadataHeaderPrefix)
+		if key == header || len(values) == 0 {
+			continue
+		}
+
+		if key == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "transaction metadata header `%s` is missing a key", header)
+		}
+
+		value := values[len(values)-1]
+		if len(value) > maxTransactionMetadataValueLength {
+			return nil, status.Errorf(codes.InvalidArgument, "transaction metadata `%s` exceeds the maximum length of %d", key, maxTransactionMetadataValueLength)
+		}
+
+		if txMetadata == nil {
+			txMetadata = make(map[string]string)
+		}
+		txMetadata[key] = value
+	}
+
+	if len(txMetadata) > maxTransactionMetadataEntries {
+		return nil, status.Errorf(codes.InvalidArgument, "transaction metadata exceeds the maximum of %d entries", maxTransactionMetadataEntries)
+	}
+
+	return txMetadata, nil
+}
+
+// logTransactionMetadata records a write made with transaction metadata, so that the mutation
+// can be attributed from the audit log.
+func logTransactionMetadata(ctx context.Context, operation string, revision datastore.Revision, txMetadata map[string]string) {
+	if len(txMetadata) == 0 {
+		return
+	}
+
+	log.Ctx(ctx).Info().
+		Str("operation", operation).
+		Stringer("revision", revision).
+		Interface("metadata", txMetadata).
+		Msg("applied write with transaction metadata")
+}
@@ -0,0 +1,54 @@
+package v1
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTransactionMetadataFromContext(t *testing.T) {
+	testCases := []struct {
+		name          string
+		headers       []string
+		expected      map[string]string
+		expectedError bool
+	}{
+		{"no headers", nil, nil, false},
+		{"unrelated headers", []string{"authorization", "bearer foo"}, nil, false},
+		{
+			"metadata headers",
+			[]string{"x-spicedb-tx-actor", "user:tom", "x-spicedb-tx-reason", "onboarding", "authorization", "bearer foo"},
+			map[string]string{"actor": "user:tom", "reason": "onboarding"},
+			false,
+		},
+		{
+			"repeated header uses last value",
+			[]string{"x-spicedb-tx-actor", "user:tom", "x-spicedb-tx-actor", "user:fred"},
+			map[string]string{"actor": "user:fred"},
+			false,
+		},
+		{"missing key", []string{"x-spicedb-tx-", "user:tom"}, nil, true},
+		{"value too long", []string{"x-spicedb-tx-actor", strings.Repeat("a", maxTransactionMetadataValueLength+1)}, nil, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tc.headers...))
+
+			txMetadata, err := transactionMetadataFromContext(ctx)
+			if tc.expectedError {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, txMetadata)
+		})
+	}
+}
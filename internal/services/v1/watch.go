@@ -83,6 +83,8 @@ func (ws *watchServer) Watch(req *v1.WatchRequest, stream v1.WatchService_WatchS
 				return status.Errorf(codes.Canceled, "watch canceled by user: %s", err)
 			case errors.As(err, &datastore.ErrWatchDisconnected{}):
 				return status.Errorf(codes.ResourceExhausted, "watch disconnected: %s", err)
+			case errors.As(err, &datastore.ErrWatchDisabled{}):
+				return status.Errorf(codes.FailedPrecondition, "%s", err)
 			default:
 				return status.Errorf(codes.Internal, "watch error: %s", err)
 			}
@@ -24,13 +24,14 @@ func NewValidatingDatastore(delegate datastore.Datastore) datastore.Datastore {
 	return validatingDatastore{Datastore: delegate}
 }
 
-func (vd validatingDatastore) SnapshotReader(revision datastore.Revision) datastore.Reader {
-	return validatingSnapshotReader{vd.Datastore.SnapshotReader(revision)}
+func (vd validatingDatastore) SnapshotReader(revision datastore.Revision, opts ...options.SnapshotReaderOptionsOption) datastore.Reader {
+	return validatingSnapshotReader{vd.Datastore.SnapshotReader(revision, opts...)}
 }
 
 func (vd validatingDatastore) ReadWriteTx(
 	ctx context.Context,
 	f datastore.TxUserFunc,
+	opts ...options.RWTOptionsOption,
 ) (datastore.Revision, error) {
 	if f == nil {
 		return datastore.NoRevision, fmt.Errorf("nil delegate function")
@@ -39,7 +40,43 @@ func (vd validatingDatastore) ReadWriteTx(
 	return vd.Datastore.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
 		txDelegate := validatingReadWriteTransaction{validatingSnapshotReader{rwt}, rwt}
 		return f(txDelegate)
-	})
+	}, opts...)
+}
+
+func (vd validatingDatastore) BulkLoad(
+	ctx context.Context,
+	source datastore.BulkWriteRelationshipSource,
+) (datastore.Revision, error) {
+	return vd.Datastore.BulkLoad(ctx, &validatingBulkLoadSource{source, util.NewSet[string]()})
+}
+
+// validatingBulkLoadSource validates each relationship produced by the delegate source and
+// ensures that no relationship is produced more than once.
+type validatingBulkLoadSource struct {
+	delegate datastore.BulkWriteRelationshipSource
+	seen     *util.Set[string]
+}
+
+func (vbs *validatingBulkLoadSource) Next(ctx context.Context) (*core.RelationTuple, error) {
+	tpl, err := vbs.delegate.Next(ctx)
+	if err != nil || tpl == nil {
+		return tpl, err
+	}
+
+	update := &core.RelationTupleUpdate{Operation: core.RelationTupleUpdate_CREATE, Tuple: tpl}
+	if err := validateUpdatesToWrite(update); err != nil {
+		return nil, err
+	}
+
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	if !vbs.seen.Add(tuple.String(tpl)) {
+		return nil, fmt.Errorf("found duplicate update for relationship %s", tuple.String(tpl))
+	}
+
+	return tpl, nil
 }
 
 type validatingSnapshotReader struct {
@@ -48,8 +85,9 @@ type validatingSnapshotReader struct {
 
 func (vsr validatingSnapshotReader) ListNamespaces(
 	ctx context.Context,
+	opts ...options.ListOptionsOption,
 ) ([]*core.NamespaceDefinition, error) {
-	read, err := vsr.delegate.ListNamespaces(ctx)
+	read, err := vsr.delegate.ListNamespaces(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +135,18 @@ func (vsr validatingSnapshotReader) QueryRelationships(ctx context.Context,
 	return vsr.delegate.QueryRelationships(ctx, filter, opts...)
 }
 
+func (vsr validatingSnapshotReader) CountRelationships(ctx context.Context, filter datastore.RelationshipsFilter) (uint64, error) {
+	if filter.ResourceType == "" {
+		return 0, errors.New("relationships filter missing resource type")
+	}
+
+	return vsr.delegate.CountRelationships(ctx, filter)
+}
+
+func (vsr validatingSnapshotReader) ListResourceTypes(ctx context.Context) ([]string, error) {
+	return vsr.delegate.ListResourceTypes(ctx)
+}
+
 func (vsr validatingSnapshotReader) ReadNamespace(
 	ctx context.Context,
 	nsName string,
@@ -137,8 +187,24 @@ func (vsr validatingSnapshotReader) ReadCaveatByName(ctx context.Context, name s
 	return read, createdAt, err
 }
 
-func (vsr validatingSnapshotReader) ListCaveats(ctx context.Context, caveatNames ...string) ([]*core.CaveatDefinition, error) {
-	read, err := vsr.delegate.ListCaveats(ctx, caveatNames...)
+func (vsr validatingSnapshotReader) ListCaveats(ctx context.Context, opts ...options.ListOptionsOption) ([]*core.CaveatDefinition, error) {
+	read, err := vsr.delegate.ListCaveats(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, caveatDef := range read {
+		err := caveatDef.Validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return read, err
+}
+
+func (vsr validatingSnapshotReader) LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error) {
+	read, err := vsr.delegate.LookupCaveats(ctx, caveatNames)
 	if err != nil {
 		return nil, err
 	}
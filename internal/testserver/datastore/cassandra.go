@@ -0,0 +1,104 @@
+//go:build docker
+// +build docker
+
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/cassandra/cql"
+	"github.com/authzed/spicedb/internal/datastore/cassandra/migrations"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/migrate"
+	"github.com/authzed/spicedb/pkg/secrets"
+)
+
+const cassandraPort = 9042
+
+type cassandraTester struct {
+	session  *cql.Session
+	hostname string
+	port     string
+}
+
+// RunCassandraForTesting returns a RunningEngineForTest for the cassandra driver, backed by a
+// single ScyllaDB node, on which each database is a keyspace.
+func RunCassandraForTesting(t testing.TB, bridgeNetworkName string) RunningEngineForTest {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	name := fmt.Sprintf("cassandra-%s", uuid.New().String())
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Name:       name,
+		Repository: "scylladb/scylla",
+		Tag:        "5.4",
+		Cmd:        []string{"--smp", "1", "--developer-mode", "1"},
+		NetworkID:  bridgeNetworkName,
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, pool.Purge(resource))
+	})
+
+	port := resource.GetPort(fmt.Sprintf("%d/tcp", cassandraPort))
+	builder := &cassandraTester{hostname: "localhost", port: port}
+	if bridgeNetworkName != "" {
+		builder.hostname = name
+		builder.port = fmt.Sprintf("%d", cassandraPort)
+	}
+
+	builder.session, err = cql.NewSession(cql.Config{
+		Hosts:       []string{fmt.Sprintf("localhost:%s", port)},
+		Consistency: cql.One,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, builder.session.Close())
+	})
+
+	// ScyllaDB takes a while to start, during which its connections are refused.
+	pool.MaxWait = 3 * pool.MaxWait
+	require.NoError(t, pool.Retry(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), dockerBootTimeout)
+		defer cancel()
+		_, err := builder.session.Query(ctx, "SELECT release_version FROM system.local")
+		return err
+	}))
+
+	return builder
+}
+
+func (b *cassandraTester) NewDatabase(t testing.TB) string {
+	uniquePortion, err := secrets.TokenHex(4)
+	require.NoError(t, err)
+
+	keyspace := fmt.Sprintf("db%s", uniquePortion)
+	ctx, cancel := context.WithTimeout(context.Background(), dockerBootTimeout)
+	defer cancel()
+	require.NoError(t, b.session.Exec(ctx, fmt.Sprintf(
+		"CREATE KEYSPACE %s WITH replication = {'class': 'SimpleStrategy', 'replication_factor': 1}",
+		keyspace,
+	)))
+
+	return fmt.Sprintf("cassandra://%s:%s/%s?consistency=ONE", b.hostname, b.port, keyspace)
+}
+
+func (b *cassandraTester) NewDatastore(t testing.TB, initFunc InitFunc) datastore.Datastore {
+	uri := b.NewDatabase(t)
+
+	migrationDriver, err := migrations.NewCassandraDriver(uri)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, migrations.Manager.Run(ctx, migrationDriver, migrate.Head, migrate.LiveRun))
+	require.NoError(t, migrationDriver.Close(ctx))
+
+	return initFunc("cassandra", uri)
+}
@@ -67,6 +67,10 @@ func RunDatastoreEngineWithBridge(t testing.TB, engine string, bridgeNetworkName
 		return RunMySQLForTesting(t, bridgeNetworkName)
 	case "spanner":
 		return RunSpannerForTesting(t, bridgeNetworkName)
+	case "cassandra":
+		return RunCassandraForTesting(t, bridgeNetworkName)
+	case "redis":
+		return RunRedisForTesting(t, bridgeNetworkName)
 	default:
 		t.Fatalf("found missing engine for RunDatastoreEngine: %s", engine)
 		return nil
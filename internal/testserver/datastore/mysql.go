@@ -31,6 +31,9 @@ type mysqlTester struct {
 	creds    string
 	port     string
 	options  MySQLTesterOptions
+
+	// newMigrationDriver creates the driver which migrates each new database.
+	newMigrationDriver func(url string, tablePrefix string) (*migrations.MySQLDriver, error)
 }
 
 // MySQLTesterOptions allows tweaking the behaviour of the builder for the MySQL datastore
@@ -65,8 +68,9 @@ func RunMySQLForTestingWithOptions(t testing.TB, options MySQLTesterOptions, bri
 	require.NoError(t, err)
 
 	builder := &mysqlTester{
-		creds:   defaultCreds,
-		options: options,
+		creds:              defaultCreds,
+		options:            options,
+		newMigrationDriver: migrations.NewMySQLDriverFromDSN,
 	}
 	t.Cleanup(func() {
 		require.NoError(t, pool.Purge(resource))
@@ -113,7 +117,7 @@ func (mb *mysqlTester) NewDatabase(t testing.TB) string {
 }
 
 func (mb *mysqlTester) runMigrate(t testing.TB, dsn string) {
-	driver, err := migrations.NewMySQLDriverFromDSN(dsn, mb.options.Prefix)
+	driver, err := mb.newMigrationDriver(dsn, mb.options.Prefix)
 	require.NoError(t, err, "failed to create migration driver: %s", err)
 	err = migrations.Manager.Run(context.Background(), driver, migrate.Head, migrate.LiveRun)
 	require.NoError(t, err, "failed to run migration: %s", err)
@@ -37,7 +37,7 @@ func RunPostgresForTestingWithCommitTimestamps(t testing.TB, bridgeNetworkName s
 	require.NoError(t, err)
 
 	name := fmt.Sprintf("postgres-%s", uuid.New().String())
-	cmd := []string{"-c", "track_commit_timestamp=1"}
+	cmd := []string{"-c", "track_commit_timestamp=1", "-c", "wal_level=logical"}
 	if !withCommitTimestamps {
 		cmd = []string{}
 	}
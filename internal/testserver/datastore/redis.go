@@ -0,0 +1,77 @@
+//go:build docker
+// +build docker
+
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/redis/resp"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/secrets"
+)
+
+const redisPort = 6379
+
+type redisTester struct {
+	hostname string
+	port     string
+}
+
+// RunRedisForTesting returns a RunningEngineForTest for the redis driver, backed by a single
+// Redis server, on which each database is a key prefix.
+func RunRedisForTesting(t testing.TB, bridgeNetworkName string) RunningEngineForTest {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	name := fmt.Sprintf("redis-%s", uuid.New().String())
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Name:       name,
+		Repository: "redis",
+		Tag:        "7",
+		NetworkID:  bridgeNetworkName,
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, pool.Purge(resource))
+	})
+
+	port := resource.GetPort(fmt.Sprintf("%d/tcp", redisPort))
+	builder := &redisTester{hostname: "localhost", port: port}
+	if bridgeNetworkName != "" {
+		builder.hostname = name
+		builder.port = fmt.Sprintf("%d", redisPort)
+	}
+
+	client := resp.NewClient(resp.Config{Address: fmt.Sprintf("localhost:%s", port), PoolSize: 1})
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+
+	require.NoError(t, pool.Retry(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), dockerBootTimeout)
+		defer cancel()
+		_, err := client.Do(ctx, "PING")
+		return err
+	}))
+
+	return builder
+}
+
+func (b *redisTester) NewDatabase(t testing.TB) string {
+	uniquePortion, err := secrets.TokenHex(4)
+	require.NoError(t, err)
+
+	return fmt.Sprintf("redis://%s:%s/0?prefix=db%s", b.hostname, b.port, uniquePortion)
+}
+
+func (b *redisTester) NewDatastore(t testing.TB, initFunc InitFunc) datastore.Datastore {
+	return initFunc("redis", b.NewDatabase(t))
+}
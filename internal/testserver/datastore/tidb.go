@@ -0,0 +1,66 @@
+//go:build docker
+// +build docker
+
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/mysql/migrations"
+)
+
+const tidbPort = 4000
+
+// RunTiDBForTesting returns a RunningEngineForTest for the mysql driver backed by a standalone
+// TiDB instance, whose databases are migrated for TiDB.
+func RunTiDBForTesting(t testing.TB, bridgeNetworkName string) RunningEngineForTest {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	name := fmt.Sprintf("tidb-%s", uuid.New().String())
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Name:       name,
+		Repository: "pingcap/tidb",
+		Tag:        "v7.5.1",
+		NetworkID:  bridgeNetworkName,
+	})
+	require.NoError(t, err)
+
+	builder := &mysqlTester{
+		creds:              "root",
+		options:            MySQLTesterOptions{MigrateForNewDatastore: true},
+		newMigrationDriver: migrations.NewTiDBDriverFromDSN,
+	}
+	t.Cleanup(func() {
+		require.NoError(t, pool.Purge(resource))
+	})
+
+	port := resource.GetPort(fmt.Sprintf("%d/tcp", tidbPort))
+	if bridgeNetworkName != "" {
+		builder.hostname = name
+		builder.port = fmt.Sprintf("%d", tidbPort)
+	} else {
+		builder.port = port
+	}
+
+	dsn := fmt.Sprintf("%s@(localhost:%s)/mysql?parseTime=true", builder.creds, port)
+	require.NoError(t, pool.Retry(func() error {
+		var err error
+		builder.db, err = sql.Open("mysql", dsn)
+		if err != nil {
+			return err
+		}
+		ctx, cancelPing := context.WithTimeout(context.Background(), dockerBootTimeout)
+		defer cancelPing()
+		return builder.db.PingContext(ctx)
+	}))
+
+	return builder
+}
@@ -0,0 +1,108 @@
+//go:build docker
+// +build docker
+
+package datastore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/mysql/migrations"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/migrate"
+	"github.com/authzed/spicedb/pkg/secrets"
+)
+
+const (
+	// vttestserver listens with the MySQL protocol of vtgate three ports above its base port.
+	vitessBasePort  = 33574
+	vitessMySQLPort = vitessBasePort + 3
+	vitessKeyspace  = "spicedb"
+)
+
+// VitessInitFunc initializes a datastore instance from the uri of a Vitess keyspace and the
+// table prefix of the datastore within it.
+type VitessInitFunc func(uri, tablePrefix string) datastore.Datastore
+
+// VitessEngineForTest is a Vitess instance running expressly for testing. vtgate does not create
+// keyspaces, so the datastores created with it share a single keyspace, each with its own tables.
+type VitessEngineForTest interface {
+	// NewDatastore migrates the tables of a new table prefix within the keyspace, and returns
+	// the datastore initialized with them by initFunc.
+	NewDatastore(t testing.TB, initFunc VitessInitFunc) datastore.Datastore
+}
+
+type vitessTester struct {
+	uri string
+}
+
+// RunVitessForTesting returns a VitessEngineForTest backed by a single-shard keyspace of a
+// vttestserver instance.
+func RunVitessForTesting(t testing.TB, bridgeNetworkName string) VitessEngineForTest {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	name := fmt.Sprintf("vitess-%s", uuid.New().String())
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Name:       name,
+		Repository: "vitess/vttestserver",
+		Tag:        "mysql80",
+		Env: []string{
+			fmt.Sprintf("PORT=%d", vitessBasePort),
+			"KEYSPACES=" + vitessKeyspace,
+			"NUM_SHARDS=1",
+			"MYSQL_BIND_HOST=0.0.0.0",
+			"MYSQL_MAX_CONNECTIONS=500",
+		},
+		ExposedPorts: []string{fmt.Sprintf("%d/tcp", vitessMySQLPort)},
+		NetworkID:    bridgeNetworkName,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, pool.Purge(resource))
+	})
+
+	hostname := "localhost"
+	port := resource.GetPort(fmt.Sprintf("%d/tcp", vitessMySQLPort))
+	if bridgeNetworkName != "" {
+		hostname = name
+		port = fmt.Sprintf("%d", vitessMySQLPort)
+	}
+
+	dsn := fmt.Sprintf("root@(localhost:%s)/%s?parseTime=true", resource.GetPort(fmt.Sprintf("%d/tcp", vitessMySQLPort)), vitessKeyspace)
+	require.NoError(t, pool.Retry(func() error {
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		ctx, cancelPing := context.WithTimeout(context.Background(), dockerBootTimeout)
+		defer cancelPing()
+		return db.PingContext(ctx)
+	}))
+
+	return &vitessTester{
+		uri: fmt.Sprintf("root@(%s:%s)/%s?parseTime=true", hostname, port, vitessKeyspace),
+	}
+}
+
+func (vt *vitessTester) NewDatastore(t testing.TB, initFunc VitessInitFunc) datastore.Datastore {
+	uniquePortion, err := secrets.TokenHex(4)
+	require.NoError(t, err, "Could not generate unique portion of table prefix: %s", err)
+	tablePrefix := fmt.Sprintf("test_%s_", uniquePortion)
+
+	driver, err := migrations.NewVitessDriverFromDSN(vt.uri, tablePrefix)
+	require.NoError(t, err, "failed to create migration driver: %s", err)
+	err = migrations.Manager.Run(context.Background(), driver, migrate.Head, migrate.LiveRun)
+	require.NoError(t, err, "failed to run migration: %s", err)
+	require.NoError(t, driver.Close(context.Background()))
+
+	return initFunc(vt.uri, tablePrefix)
+}
@@ -1,6 +1,7 @@
 package balancer
 
 import (
+	"math"
 	"math/rand"
 	"sync"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"google.golang.org/grpc/balancer"
 	"google.golang.org/grpc/balancer/base"
 	"google.golang.org/grpc/grpclog"
+	"google.golang.org/grpc/resolver"
 
 	"github.com/authzed/spicedb/pkg/consistent"
 )
@@ -25,6 +27,18 @@ const (
 	// CtxKey is the key for the grpc request's context.Context which points to
 	// the key to hash for the request. The value it points to must be []byte
 	CtxKey ctxKey = "requestKey"
+
+	// HedgeCtxKey is the key for the grpc request's context.Context which marks
+	// the request as a hedge of another. A hedged request is sent to the next
+	// member of the hashring after those to which its key is spread, if there is
+	// one. The value it points to must be bool
+	HedgeCtxKey ctxKey = "hedgedRequest"
+
+	// MemberCtxKey is the key for the grpc request's context.Context which
+	// points to the key of the member of the hashring, as returned by
+	// PickMember, to which the request must be sent if it is still a member.
+	// It is ignored for hedged requests. The value it points to must be string
+	MemberCtxKey ctxKey = "requestMember"
 )
 
 var logger = grpclog.Component("consistenthashring")
@@ -34,16 +48,17 @@ var logger = grpclog.Component("consistenthashring")
 // Before making a connection, register it with grpc with:
 // `balancer.Register(consistent.NewConsistentHashringBuilder(hasher, factor, spread))`
 func NewConsistentHashringBuilder(hasher consistent.HasherFunc, replicationFactor uint16, spread uint8) balancer.Builder {
-	return base.NewBalancerBuilder(
+	return pickerTrackingBuilder{base.NewBalancerBuilder(
 		BalancerName,
-		&consistentHashringPickerBuilder{hasher: hasher, replicationFactor: replicationFactor, spread: spread},
+		&consistentHashringPickerBuilder{hasher: hasher, replicationFactor: replicationFactor, spread: spread, weights: map[string]uint16{}},
 		base.Config{HealthCheck: true},
-	)
+	)}
 }
 
 type subConnMember struct {
 	balancer.SubConn
-	key string
+	key    string
+	weight uint16
 }
 
 // Key implements consistent.Member
@@ -52,12 +67,41 @@ func (s subConnMember) Key() string {
 	return s.key
 }
 
-var _ consistent.Member = &subConnMember{}
+// Weight implements consistent.WeightedMember
+func (s subConnMember) Weight() uint16 {
+	return s.weight
+}
+
+var _ consistent.WeightedMember = &subConnMember{}
 
 type consistentHashringPickerBuilder struct {
 	hasher            consistent.HasherFunc
 	replicationFactor uint16
 	spread            uint8
+
+	// weights holds the last weight advertised by each member, so that it is
+	// kept when the picker is rebuilt.
+	weightsLock sync.Mutex
+	weights     map[string]uint16
+}
+
+func (b *consistentHashringPickerBuilder) weight(key string, addr resolver.Address) uint16 {
+	b.weightsLock.Lock()
+	defer b.weightsLock.Unlock()
+
+	if weight, ok := b.weights[key]; ok {
+		return weight
+	}
+	if weight, ok := WeightFromAddress(addr); ok {
+		return weight
+	}
+	return consistent.DefaultWeight
+}
+
+func (b *consistentHashringPickerBuilder) setWeight(key string, weight uint16) {
+	b.weightsLock.Lock()
+	defer b.weightsLock.Unlock()
+	b.weights[key] = weight
 }
 
 func (b *consistentHashringPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
@@ -66,42 +110,116 @@ func (b *consistentHashringPickerBuilder) Build(info base.PickerBuildInfo) balan
 		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
 	}
 	hashring := consistent.NewHashring(b.hasher, b.replicationFactor)
+	weights := make(map[string]uint16, len(info.ReadySCs))
+	members := make(map[string]balancer.SubConn, len(info.ReadySCs))
 	for sc, scInfo := range info.ReadySCs {
+		key := scInfo.Address.Addr + scInfo.Address.ServerName
+		weights[key] = b.weight(key, scInfo.Address)
+		members[key] = sc
 		if err := hashring.Add(subConnMember{
 			SubConn: sc,
-			key:     scInfo.Address.Addr + scInfo.Address.ServerName,
+			key:     key,
+			weight:  weights[key],
 		}); err != nil {
 			return base.NewErrPicker(err)
 		}
 	}
 	return &consistentHashringPicker{
+		builder:  b,
 		hashring: hashring,
 		spread:   b.spread,
 		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		weights:  weights,
+		members:  members,
 	}
 }
 
 type consistentHashringPicker struct {
 	sync.Mutex
+	builder  *consistentHashringPickerBuilder
 	hashring *consistent.Hashring
 	spread   uint8
 	rand     *rand.Rand
+	weights  map[string]uint16
+	members  map[string]balancer.SubConn
 }
 
 func (p *consistentHashringPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
 	key := info.Ctx.Value(CtxKey).([]byte)
-	members, err := p.hashring.FindN(key, p.spread)
+
+	if hedged, _ := info.Ctx.Value(HedgeCtxKey).(bool); hedged && p.spread < math.MaxUint8 {
+		members, err := p.hashring.FindN(key, p.spread+1)
+		if err == nil {
+			chosen := members[p.spread].(subConnMember)
+			return balancer.PickResult{
+				SubConn: chosen.SubConn,
+				Done:    p.weightUpdater(chosen),
+			}, nil
+		}
+	}
+
+	if memberKey, ok := info.Ctx.Value(MemberCtxKey).(string); ok {
+		p.Lock()
+		sc, ok := p.members[memberKey]
+		weight := p.weights[memberKey]
+		p.Unlock()
+		if ok {
+			chosen := subConnMember{SubConn: sc, key: memberKey, weight: weight}
+			return balancer.PickResult{
+				SubConn: chosen.SubConn,
+				Done:    p.weightUpdater(chosen),
+			}, nil
+		}
+	}
+
+	chosen, err := p.pickMember(key)
 	if err != nil {
 		return balancer.PickResult{}, err
 	}
+	return balancer.PickResult{
+		SubConn: chosen.SubConn,
+		Done:    p.weightUpdater(chosen),
+	}, nil
+}
+
+// pickMember chooses one of the members of the hashring to which the key is
+// spread.
+func (p *consistentHashringPicker) pickMember(key []byte) (subConnMember, error) {
+	members, err := p.hashring.FindN(key, p.spread)
+	if err != nil {
+		return subConnMember{}, err
+	}
 
 	// rand is not safe for concurrent use
 	p.Lock()
 	index := p.rand.Intn(int(p.spread))
 	p.Unlock()
 
-	chosen := members[index].(subConnMember)
-	return balancer.PickResult{
-		SubConn: chosen.SubConn,
-	}, nil
+	return members[index].(subConnMember), nil
+}
+
+// weightUpdater returns a callback which moves the member on the hashring
+// when the weight it advertises in the trailer of a response has changed.
+func (p *consistentHashringPicker) weightUpdater(member subConnMember) func(balancer.DoneInfo) {
+	return func(info balancer.DoneInfo) {
+		weight, ok := weightFromMetadata(info.Trailer)
+		if !ok {
+			return
+		}
+
+		p.Lock()
+		defer p.Unlock()
+		if p.weights[member.key] == weight {
+			return
+		}
+
+		logger.Infof("consistentHashringPicker: member %s advertised weight %d", member.key, weight)
+		member.weight = weight
+		if err := p.hashring.Update(member); err != nil {
+			logger.Warningf("consistentHashringPicker: failed to update weight of member %s: %v", member.key, err)
+			return
+		}
+		p.weights[member.key] = weight
+		p.builder.setWeight(member.key, weight)
+	}
 }
@@ -0,0 +1,127 @@
+package balancer
+
+import (
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/resolver"
+)
+
+// pickers holds the latest consistent hashring picker of each dial target, so
+// that the member to which a key will be sent can be found ahead of a request.
+var (
+	pickersLock sync.Mutex
+	pickers     = map[string]*trackedPicker{}
+)
+
+type trackedPicker struct {
+	owner  *pickerTrackingConn
+	picker *consistentHashringPicker
+}
+
+// PickMember returns the key of the member of the hashring of the connection
+// to the given dial target to which a request with the given key would be
+// sent, if the connection uses the consistent hashring balancer and has ready
+// members. Requests carrying the member in their context under MemberCtxKey are
+// sent to it for as long as it remains a member.
+func PickMember(target string, key []byte) (string, bool) {
+	pickersLock.Lock()
+	tracked, ok := pickers[target]
+	if !ok {
+		// Targets without a registered scheme are parsed with the default one.
+		tracked, ok = pickers[resolver.GetDefaultScheme()+":///"+target]
+	}
+	pickersLock.Unlock()
+	if !ok {
+		return "", false
+	}
+
+	member, err := tracked.picker.pickMember(key)
+	if err != nil {
+		return "", false
+	}
+	return member.key, true
+}
+
+// RingMember is a member of the consistent hashring of a connection.
+type RingMember struct {
+	Key    string `json:"key"`
+	Weight uint16 `json:"weight"`
+}
+
+// Rings returns the members of the consistent hashring of each connection
+// using the balancer which has ready members, by dial target.
+func Rings() map[string][]RingMember {
+	pickersLock.Lock()
+	tracked := make(map[string]*consistentHashringPicker, len(pickers))
+	for target, picker := range pickers {
+		tracked[target] = picker.picker
+	}
+	pickersLock.Unlock()
+
+	rings := make(map[string][]RingMember, len(tracked))
+	for target, picker := range tracked {
+		picker.Lock()
+		members := make([]RingMember, 0, len(picker.weights))
+		for key, weight := range picker.weights {
+			members = append(members, RingMember{Key: key, Weight: weight})
+		}
+		picker.Unlock()
+
+		sort.Slice(members, func(i, j int) bool { return members[i].Key < members[j].Key })
+		rings[target] = members
+	}
+	return rings
+}
+
+// pickerTrackingBuilder wraps the balancer builder to record the pickers of
+// each connection for PickMember.
+type pickerTrackingBuilder struct {
+	balancer.Builder
+}
+
+func (b pickerTrackingBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	conn := &pickerTrackingConn{ClientConn: cc, target: opts.Target.URL.String()}
+	return &pickerTrackingBalancer{Balancer: b.Builder.Build(conn, opts), conn: conn}
+}
+
+type pickerTrackingConn struct {
+	balancer.ClientConn
+	target string
+}
+
+func (cc *pickerTrackingConn) UpdateState(state balancer.State) {
+	pickersLock.Lock()
+	if picker, ok := state.Picker.(*consistentHashringPicker); ok {
+		pickers[cc.target] = &trackedPicker{owner: cc, picker: picker}
+	} else if tracked, ok := pickers[cc.target]; ok && tracked.owner == cc {
+		delete(pickers, cc.target)
+	}
+	pickersLock.Unlock()
+
+	cc.ClientConn.UpdateState(state)
+}
+
+type pickerTrackingBalancer struct {
+	balancer.Balancer
+	conn *pickerTrackingConn
+}
+
+func (b *pickerTrackingBalancer) Close() {
+	pickersLock.Lock()
+	if tracked, ok := pickers[b.conn.target]; ok && tracked.owner == b.conn {
+		delete(pickers, b.conn.target)
+	}
+	pickersLock.Unlock()
+
+	b.Balancer.Close()
+}
+
+func (b *pickerTrackingBalancer) ExitIdle() {
+	if exitIdler, ok := b.Balancer.(balancer.ExitIdler); ok {
+		exitIdler.ExitIdle()
+	}
+}
+
+var _ balancer.ExitIdler = &pickerTrackingBalancer{}
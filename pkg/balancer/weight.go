@@ -0,0 +1,75 @@
+package balancer
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/resolver"
+)
+
+// WeightMetadataKey is the key of the trailer in which servers advertise their
+// weight on the hashring to the consistent hashring balancer of their clients.
+const WeightMetadataKey = "io.spicedb.hashring-weight"
+
+type weightAttributeKey struct{}
+
+// SetWeight returns a copy of the address with the given weight on the
+// hashring, for resolvers which know the capacity of the members they resolve.
+// A weight advertised by the member itself takes precedence.
+func SetWeight(addr resolver.Address, weight uint16) resolver.Address {
+	addr.BalancerAttributes = addr.BalancerAttributes.WithValue(weightAttributeKey{}, weight)
+	return addr
+}
+
+// WeightFromAddress returns the weight set on the address with SetWeight, if any.
+func WeightFromAddress(addr resolver.Address) (uint16, bool) {
+	weight, ok := addr.BalancerAttributes.Value(weightAttributeKey{}).(uint16)
+	return weight, ok
+}
+
+// CPUWeight returns the weight of a member with the given weight per CPU
+// available to the process.
+func CPUWeight(weightPerCPU uint16) uint16 {
+	weight := uint64(weightPerCPU) * uint64(runtime.GOMAXPROCS(0))
+	if weight > math.MaxUint16 {
+		return math.MaxUint16
+	}
+	return uint16(weight)
+}
+
+func weightFromMetadata(md metadata.MD) (uint16, bool) {
+	values := md.Get(WeightMetadataKey)
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	weight, err := strconv.ParseUint(values[0], 10, 16)
+	if err != nil {
+		return 0, false
+	}
+	return uint16(weight), true
+}
+
+// UnaryServerInterceptor returns a new unary server interceptor which advertises
+// the given weight in the trailer of every response.
+func UnaryServerInterceptor(weight uint16) grpc.UnaryServerInterceptor {
+	trailer := metadata.Pairs(WeightMetadataKey, strconv.FormatUint(uint64(weight), 10))
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		_ = grpc.SetTrailer(ctx, trailer)
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a new stream server interceptor which
+// advertises the given weight in the trailer of every stream.
+func StreamServerInterceptor(weight uint16) grpc.StreamServerInterceptor {
+	trailer := metadata.Pairs(WeightMetadataKey, strconv.FormatUint(uint64(weight), 10))
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		stream.SetTrailer(trailer)
+		return handler(srv, stream)
+	}
+}
@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"time"
+
 	"github.com/dustin/go-humanize"
 	"github.com/rs/zerolog"
 )
@@ -36,13 +38,18 @@ type Config struct {
 	// only set this flag to true when testing or throughput performance isn't a
 	// major factor.
 	Metrics bool
+
+	// MemoryAware shrinks the cache below MaxCost while the memory used by the
+	// process nears its limit, and grows it back once the pressure has passed.
+	MemoryAware bool
 }
 
 func (c *Config) MarshalZerologObject(e *zerolog.Event) {
 	e.
 		Str("maxCost", humanize.IBytes(uint64(c.MaxCost))).
 		Int64("numCounters", c.NumCounters).
-		Bool("metrics", c.Metrics)
+		Bool("metrics", c.Metrics).
+		Bool("memoryAware", c.MemoryAware)
 }
 
 // Cache defines an interface for a generic cache.
@@ -53,6 +60,10 @@ type Cache interface {
 	// Set sets a value for the key in the cache, with the given cost.
 	Set(key interface{}, entry interface{}, cost int64) bool
 
+	// SetWithTTL sets a value for the key in the cache, with the given cost, which expires after
+	// the given TTL. A zero TTL never expires.
+	SetWithTTL(key interface{}, entry interface{}, cost int64, ttl time.Duration) bool
+
 	// Wait waits for the cache to process and apply updates.
 	Wait()
 
@@ -91,9 +102,12 @@ var _ Cache = (*noopCache)(nil)
 
 func (no *noopCache) Get(key interface{}) (interface{}, bool)                 { return nil, false }
 func (no *noopCache) Set(key interface{}, entry interface{}, cost int64) bool { return false }
-func (no *noopCache) Wait()                                                   {}
-func (no *noopCache) Close()                                                  {}
-func (no *noopCache) GetMetrics() Metrics                                     { return &noopMetrics{} }
+func (no *noopCache) SetWithTTL(key interface{}, entry interface{}, cost int64, ttl time.Duration) bool {
+	return false
+}
+func (no *noopCache) Wait()               {}
+func (no *noopCache) Close()              {}
+func (no *noopCache) GetMetrics() Metrics { return &noopMetrics{} }
 func (no *noopCache) MarshalZerologObject(e *zerolog.Event) {
 	e.Bool("enabled", false)
 }
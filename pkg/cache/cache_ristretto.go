@@ -29,15 +29,29 @@ func NewCache(config *Config) (Cache, error) {
 	if err != nil {
 		return nil, err
 	}
-	return wrapped{config, cache}, nil
+
+	w := wrapped{config: config, Cache: cache}
+	if config.MemoryAware {
+		w.monitor = newMemoryMonitor(cache, config.MaxCost)
+		w.monitor.start()
+	}
+	return w, nil
 }
 
 type wrapped struct {
-	config *Config
+	config  *Config
+	monitor *memoryMonitor
 	*ristretto.Cache
 }
 
 var _ Cache = (*wrapped)(nil)
 
+func (w wrapped) Close() {
+	if w.monitor != nil {
+		w.monitor.close()
+	}
+	w.Cache.Close()
+}
+
 func (w wrapped) GetMetrics() Metrics                   { return w.Cache.Metrics }
 func (w wrapped) MarshalZerologObject(e *zerolog.Event) { e.EmbedObject(w.config) }
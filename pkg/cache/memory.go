@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"math"
+	"os"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pbnjay/memory"
+
+	log "github.com/authzed/spicedb/internal/logging"
+)
+
+const (
+	// memoryPressureThreshold is the fraction of the memory limit of the process above which
+	// memory-aware caches are shrunk.
+	memoryPressureThreshold = 0.85
+
+	// memoryPressureRecoveryThreshold is the fraction of the memory limit of the process below
+	// which memory-aware caches which were shrunk grow back towards their max cost. The gap with
+	// memoryPressureThreshold keeps caches from oscillating around the threshold.
+	memoryPressureRecoveryThreshold = 0.75
+
+	// memoryPressureInterval is the interval at which the memory used by the process is checked.
+	memoryPressureInterval = time.Second
+
+	// minMemoryAwareCostDivisor bounds how far a memory-aware cache is shrunk, as a fraction of its
+	// max cost, so that it keeps serving the hottest entries under sustained pressure.
+	minMemoryAwareCostDivisor = 10
+)
+
+// resizable is implemented by caches whose max cost can be changed while in use.
+type resizable interface {
+	MaxCost() int64
+	UpdateMaxCost(maxCost int64)
+}
+
+// memoryMonitor adjusts the max cost of a cache to the memory used by the process: the cache is
+// shrunk while the process nears its memory limit, so that the node sheds cached entries rather
+// than being killed for running out of memory, and grows back once the pressure has passed.
+//
+// Lowering the max cost does not evict anything on its own: entries are evicted down to the new
+// max cost by the next entry added to the cache.
+type memoryMonitor struct {
+	cache   resizable
+	maxCost int64
+	minCost int64
+	limit   uint64
+	usage   func() uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func newMemoryMonitor(cache resizable, maxCost int64) *memoryMonitor {
+	return &memoryMonitor{
+		cache:   cache,
+		maxCost: maxCost,
+		minCost: maxCost / minMemoryAwareCostDivisor,
+		limit:   memoryLimit(),
+		usage:   memoryUsage,
+		stop:    make(chan struct{}),
+	}
+}
+
+func (m *memoryMonitor) start() {
+	go func() {
+		ticker := time.NewTicker(memoryPressureInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.adjust()
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (m *memoryMonitor) close() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+// adjust shrinks the cache by a quarter of its current max cost while the memory used is above
+// the pressure threshold, and grows it back by a tenth of its configured max cost while the
+// memory used is below the recovery threshold.
+func (m *memoryMonitor) adjust() {
+	if m.limit == 0 {
+		return
+	}
+
+	usage := m.usage()
+	current := m.cache.MaxCost()
+	updated := current
+
+	switch {
+	case float64(usage) > float64(m.limit)*memoryPressureThreshold:
+		updated = current - current/4
+		if updated < m.minCost {
+			updated = m.minCost
+		}
+	case float64(usage) < float64(m.limit)*memoryPressureRecoveryThreshold:
+		updated = current + m.maxCost/10
+		if updated > m.maxCost {
+			updated = m.maxCost
+		}
+	}
+
+	if updated == current {
+		return
+	}
+
+	log.Debug().
+		Str("usage", humanize.IBytes(usage)).
+		Str("limit", humanize.IBytes(m.limit)).
+		Str("previousMaxCost", humanize.IBytes(uint64(current))).
+		Str("maxCost", humanize.IBytes(uint64(updated))).
+		Msg("resizing cache for memory pressure")
+	m.cache.UpdateMaxCost(updated)
+}
+
+// memoryLimit returns the memory limit of the process, which is the soft limit of the Go
+// runtime if one is set (e.g. via GOMEMLIMIT), the limit of its cgroup if it has one, and the
+// total memory of the system otherwise.
+func memoryLimit() uint64 {
+	if limit := debug.SetMemoryLimit(-1); limit > 0 && limit < math.MaxInt64 {
+		return uint64(limit)
+	}
+
+	for _, path := range []string{
+		"/sys/fs/cgroup/memory.max",                   // cgroup v2
+		"/sys/fs/cgroup/memory/memory.limit_in_bytes", // cgroup v1
+	} {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		// Unlimited cgroups report "max" (v2) or a value beyond the memory of the system (v1).
+		limit, err := strconv.ParseUint(strings.TrimSpace(string(contents)), 10, 64)
+		if err == nil && limit > 0 && limit < memory.TotalMemory() {
+			return limit
+		}
+	}
+
+	return memory.TotalMemory()
+}
+
+var memoryUsageMetrics = []string{
+	"/memory/classes/total:bytes",
+	"/memory/classes/heap/released:bytes",
+}
+
+// memoryUsage returns the memory mapped by the Go runtime which has not been released to the
+// operating system, which approximates the resident memory of the process.
+func memoryUsage() uint64 {
+	samples := make([]metrics.Sample, len(memoryUsageMetrics))
+	for i, name := range memoryUsageMetrics {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+
+	for _, sample := range samples {
+		if sample.Value.Kind() != metrics.KindUint64 {
+			return 0
+		}
+	}
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64()
+}
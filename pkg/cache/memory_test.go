@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResizable struct {
+	maxCost int64
+}
+
+func (f *fakeResizable) MaxCost() int64              { return f.maxCost }
+func (f *fakeResizable) UpdateMaxCost(maxCost int64) { f.maxCost = maxCost }
+
+func TestMemoryMonitorAdjust(t *testing.T) {
+	cache := &fakeResizable{maxCost: 1000}
+	usage := uint64(0)
+	monitor := &memoryMonitor{
+		cache:   cache,
+		maxCost: 1000,
+		minCost: 100,
+		limit:   100,
+		usage:   func() uint64 { return usage },
+	}
+
+	// Below the threshold, the cache keeps its max cost.
+	usage = 80
+	monitor.adjust()
+	require.Equal(t, int64(1000), cache.maxCost)
+
+	// Above the threshold, the cache is shrunk each time, down to its min cost.
+	usage = 90
+	monitor.adjust()
+	require.Equal(t, int64(750), cache.maxCost)
+	monitor.adjust()
+	require.Equal(t, int64(563), cache.maxCost)
+	for i := 0; i < 10; i++ {
+		monitor.adjust()
+	}
+	require.Equal(t, int64(100), cache.maxCost)
+
+	// Between the thresholds, the cache is left as is.
+	usage = 80
+	monitor.adjust()
+	require.Equal(t, int64(100), cache.maxCost)
+
+	// Below the recovery threshold, the cache grows back to its max cost.
+	usage = 50
+	monitor.adjust()
+	require.Equal(t, int64(200), cache.maxCost)
+	for i := 0; i < 10; i++ {
+		monitor.adjust()
+	}
+	require.Equal(t, int64(1000), cache.maxCost)
+}
+
+func TestMemoryAwareCache(t *testing.T) {
+	c, err := NewCache(&Config{NumCounters: 100, MaxCost: 1000, MemoryAware: true})
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.True(t, c.Set("key", "value", 1))
+	c.Wait()
+
+	value, ok := c.Get("key")
+	require.True(t, ok)
+	require.Equal(t, "value", value)
+}
@@ -0,0 +1,267 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	log "github.com/authzed/spicedb/internal/logging"
+)
+
+var sharedCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "spicedb",
+	Subsystem: "dispatch",
+	Name:      "shared_cache_requests_total",
+	Help:      "total number of requests to the shared dispatch cache, by operation and result",
+}, []string{"operation", "result"})
+
+const (
+	// sharedCacheKeyPrefix prefixes the keys of entries in the shared cache, and must be changed
+	// whenever the format of the keys or of the cached entries changes.
+	sharedCacheKeyPrefix = "spicedb:dispatch:v1:"
+
+	// sharedCacheWriteQueueLength is the number of entries which may be waiting to be written to
+	// the shared cache, beyond which further entries are not written.
+	sharedCacheWriteQueueLength = 1024
+)
+
+// Serialization is the serialization of the entries written to a shared cache.
+type Serialization string
+
+const (
+	// SerializationProto writes entries as the serialized dispatch responses themselves.
+	SerializationProto Serialization = "proto"
+
+	// SerializationProtoSnappy compresses the serialized dispatch responses with snappy.
+	SerializationProtoSnappy Serialization = "proto-snappy"
+)
+
+// The first byte of each shared cache entry identifies its serialization, so that nodes which
+// write with different serializations can read the entries of one another.
+const (
+	serializationProtoByte       byte = 1
+	serializationProtoSnappyByte byte = 2
+)
+
+// SharedCache is a cache of dispatch results which is shared by the nodes of a cluster, such as
+// one stored in Redis.
+type SharedCache interface {
+	// Get returns the entry for the key, if it exists.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set sets the entry for the key, which expires after the TTL. A zero TTL never expires.
+	Set(ctx context.Context, key string, entry []byte, ttl time.Duration) error
+
+	// Close closes the connections of the cache.
+	Close() error
+}
+
+// SharedCacheConfig configures a TieredCache.
+type SharedCacheConfig struct {
+	// TTL is the time after which entries written to the shared cache expire. If zero, they are
+	// kept until evicted by the shared cache.
+	TTL time.Duration
+
+	// Timeout is the longest that a read of the shared cache may take before it is treated as a
+	// miss.
+	Timeout time.Duration
+
+	// Serialization is the serialization of the entries written to the shared cache.
+	Serialization Serialization
+}
+
+// TieredCache is a dispatch cache which reads entries missing from a local cache from a cache
+// shared with the other nodes of the cluster, and writes the entries set locally to both.
+//
+// Entries in the shared cache are keyed by the stable sum of the dispatch cache key, which
+// includes the revision of the request. Writes to the shared cache are made in the background, and
+// dropped if the shared cache falls behind.
+type TieredCache struct {
+	Cache
+
+	shared SharedCache
+	config SharedCacheConfig
+
+	writes    chan sharedCacheWrite
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type sharedCacheWrite struct {
+	key   string
+	entry []byte
+	ttl   time.Duration
+}
+
+// NewTieredCache returns a cache which reads through the local cache to the shared one.
+func NewTieredCache(local Cache, shared SharedCache, config SharedCacheConfig) (*TieredCache, error) {
+	switch config.Serialization {
+	case "":
+		config.Serialization = SerializationProto
+	case SerializationProto, SerializationProtoSnappy:
+	default:
+		return nil, fmt.Errorf("unknown shared cache serialization: %s", config.Serialization)
+	}
+
+	tc := &TieredCache{
+		Cache:  local,
+		shared: shared,
+		config: config,
+		writes: make(chan sharedCacheWrite, sharedCacheWriteQueueLength),
+		done:   make(chan struct{}),
+	}
+
+	tc.wg.Add(1)
+	go tc.writeShared()
+	return tc, nil
+}
+
+func (tc *TieredCache) Get(key interface{}) (interface{}, bool) {
+	if found, ok := tc.Cache.Get(key); ok {
+		return found, true
+	}
+
+	sharedKey, ok := sharedCacheKey(key)
+	if !ok {
+		return nil, false
+	}
+
+	ctx := context.Background()
+	if tc.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, tc.config.Timeout)
+		defer cancel()
+	}
+
+	serialized, found, err := tc.shared.Get(ctx, sharedKey)
+	if err != nil {
+		sharedCacheRequests.WithLabelValues("get", "error").Inc()
+		log.Debug().Err(err).Msg("error reading from the shared dispatch cache")
+		return nil, false
+	}
+	if !found {
+		sharedCacheRequests.WithLabelValues("get", "miss").Inc()
+		return nil, false
+	}
+
+	entry, err := deserializeSharedEntry(serialized)
+	if err != nil {
+		sharedCacheRequests.WithLabelValues("get", "error").Inc()
+		log.Debug().Err(err).Msg("error reading from the shared dispatch cache")
+		return nil, false
+	}
+
+	sharedCacheRequests.WithLabelValues("get", "hit").Inc()
+	tc.Cache.Set(key, entry, int64(len(entry)))
+	return entry, true
+}
+
+func (tc *TieredCache) Set(key interface{}, entry interface{}, cost int64) bool {
+	tc.queueShared(key, entry, 0)
+	return tc.Cache.Set(key, entry, cost)
+}
+
+func (tc *TieredCache) SetWithTTL(key interface{}, entry interface{}, cost int64, ttl time.Duration) bool {
+	tc.queueShared(key, entry, ttl)
+	return tc.Cache.SetWithTTL(key, entry, cost, ttl)
+}
+
+// Close stops writing to the shared cache and closes both caches.
+func (tc *TieredCache) Close() {
+	tc.closeOnce.Do(func() {
+		close(tc.done)
+		tc.wg.Wait()
+
+		if err := tc.shared.Close(); err != nil {
+			log.Warn().Err(err).Msg("error closing the shared dispatch cache")
+		}
+		tc.Cache.Close()
+	})
+}
+
+func (tc *TieredCache) queueShared(key interface{}, entry interface{}, ttl time.Duration) {
+	sharedKey, ok := sharedCacheKey(key)
+	if !ok {
+		return
+	}
+
+	value, ok := entry.([]byte)
+	if !ok {
+		return
+	}
+
+	if tc.config.TTL > 0 && (ttl == 0 || tc.config.TTL < ttl) {
+		ttl = tc.config.TTL
+	}
+
+	select {
+	case <-tc.done:
+	case tc.writes <- sharedCacheWrite{sharedKey, value, ttl}:
+	default:
+		sharedCacheRequests.WithLabelValues("set", "dropped").Inc()
+	}
+}
+
+func (tc *TieredCache) writeShared() {
+	defer tc.wg.Done()
+
+	for {
+		select {
+		case <-tc.done:
+			return
+		case write := <-tc.writes:
+			ctx := context.Background()
+			var cancel context.CancelFunc = func() {}
+			if tc.config.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, tc.config.Timeout)
+			}
+
+			err := tc.shared.Set(ctx, write.key, serializeSharedEntry(tc.config.Serialization, write.entry), write.ttl)
+			cancel()
+			if err != nil {
+				sharedCacheRequests.WithLabelValues("set", "error").Inc()
+				log.Debug().Err(err).Msg("error writing to the shared dispatch cache")
+				continue
+			}
+			sharedCacheRequests.WithLabelValues("set", "success").Inc()
+		}
+	}
+}
+
+func sharedCacheKey(key interface{}) (string, bool) {
+	stable, ok := stableSum(key)
+	if !ok {
+		return "", false
+	}
+	return sharedCacheKeyPrefix + strconv.FormatUint(stable, 16), true
+}
+
+func serializeSharedEntry(serialization Serialization, entry []byte) []byte {
+	if serialization == SerializationProtoSnappy {
+		return append([]byte{serializationProtoSnappyByte}, snappy.Encode(nil, entry)...)
+	}
+	return append([]byte{serializationProtoByte}, entry...)
+}
+
+func deserializeSharedEntry(serialized []byte) ([]byte, error) {
+	if len(serialized) == 0 {
+		return nil, errors.New("empty shared cache entry")
+	}
+
+	switch serialized[0] {
+	case serializationProtoByte:
+		return serialized[1:], nil
+	case serializationProtoSnappyByte:
+		return snappy.Decode(nil, serialized[1:])
+	default:
+		return nil, fmt.Errorf("unknown shared cache entry serialization: %d", serialized[0])
+	}
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/authzed/spicedb/internal/datastore/redis/resp"
+)
+
+// NewRedisSharedCache returns a shared cache stored in the Redis or KeyDB server of the connection
+// URI, of the form accepted by the redis datastore.
+func NewRedisSharedCache(uri string) (SharedCache, error) {
+	config, err := resp.ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	return &redisSharedCache{client: resp.NewClient(config)}, nil
+}
+
+type redisSharedCache struct {
+	client *resp.Client
+}
+
+func (rc *redisSharedCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := rc.client.Do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch entry := reply.(type) {
+	case nil:
+		return nil, false, nil
+	case []byte:
+		return entry, true, nil
+	default:
+		return nil, false, fmt.Errorf("unexpected reply to GET: %T", reply)
+	}
+}
+
+func (rc *redisSharedCache) Set(ctx context.Context, key string, entry []byte, ttl time.Duration) error {
+	if ttl > 0 {
+		_, err := rc.client.Do(ctx, "SET", key, entry, "PX", ttl.Milliseconds())
+		return err
+	}
+
+	_, err := rc.client.Do(ctx, "SET", key, entry)
+	return err
+}
+
+func (rc *redisSharedCache) Close() error {
+	return rc.client.Close()
+}
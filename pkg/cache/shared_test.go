@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSharedCache struct {
+	lock    sync.Mutex
+	entries map[string][]byte
+	ttls    map[string]time.Duration
+}
+
+func newFakeSharedCache() *fakeSharedCache {
+	return &fakeSharedCache{entries: map[string][]byte{}, ttls: map[string]time.Duration{}}
+}
+
+func (fc *fakeSharedCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+	entry, ok := fc.entries[key]
+	return entry, ok, nil
+}
+
+func (fc *fakeSharedCache) Set(_ context.Context, key string, entry []byte, ttl time.Duration) error {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+	fc.entries[key] = entry
+	fc.ttls[key] = ttl
+	return nil
+}
+
+func (fc *fakeSharedCache) Close() error { return nil }
+
+func (fc *fakeSharedCache) len() int {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+	return len(fc.entries)
+}
+
+func TestTieredCache(t *testing.T) {
+	for _, serialization := range []Serialization{SerializationProto, SerializationProtoSnappy} {
+		serialization := serialization
+		t.Run(string(serialization), func(t *testing.T) {
+			require := require.New(t)
+			shared := newFakeSharedCache()
+			config := SharedCacheConfig{TTL: time.Hour, Serialization: serialization}
+
+			first, err := NewTieredCache(newTestCache(t), shared, config)
+			require.NoError(err)
+			t.Cleanup(first.Close)
+
+			second, err := NewTieredCache(newTestCache(t), shared, config)
+			require.NoError(err)
+			t.Cleanup(second.Close)
+
+			key := testCheckKey(t, "doc1")
+			_, found := second.Get(key)
+			require.False(found)
+
+			// An entry set on one node is written to the shared cache in the background.
+			first.Set(key, []byte("result1"), 7)
+			first.SetWithTTL(testCheckKey(t, "doc2"), []byte("result2"), 7, time.Minute)
+			require.Eventually(func() bool { return shared.len() == 2 }, time.Second, 5*time.Millisecond)
+
+			// Entries are kept for at most the configured TTL.
+			doc1Key, _ := sharedCacheKey(key)
+			doc2Key, _ := sharedCacheKey(testCheckKey(t, "doc2"))
+			require.Equal(time.Hour, shared.ttls[doc1Key])
+			require.Equal(time.Minute, shared.ttls[doc2Key])
+
+			// Another node reads the entry from the shared cache into its local one.
+			entry, ok := second.Get(key)
+			require.True(ok)
+			require.Equal([]byte("result1"), entry)
+
+			second.Wait()
+			cached, ok := second.Cache.Get(key)
+			require.True(ok)
+			require.Equal([]byte("result1"), cached)
+		})
+	}
+}
+
+func TestTieredCacheReadsOtherSerializations(t *testing.T) {
+	require := require.New(t)
+	shared := newFakeSharedCache()
+
+	writer, err := NewTieredCache(NoopCache(), shared, SharedCacheConfig{Serialization: SerializationProtoSnappy})
+	require.NoError(err)
+	t.Cleanup(writer.Close)
+
+	reader, err := NewTieredCache(NoopCache(), shared, SharedCacheConfig{Serialization: SerializationProto})
+	require.NoError(err)
+	t.Cleanup(reader.Close)
+
+	key := testCheckKey(t, "doc1")
+	writer.Set(key, []byte("result1"), 7)
+	require.Eventually(func() bool { return shared.len() == 1 }, time.Second, 5*time.Millisecond)
+
+	found, ok := reader.Get(key)
+	require.True(ok)
+	require.Equal([]byte("result1"), found)
+}
+
+func TestTieredCacheUnknownSerialization(t *testing.T) {
+	_, err := NewTieredCache(NoopCache(), newFakeSharedCache(), SharedCacheConfig{Serialization: "json"})
+	require.ErrorContains(t, err, "unknown shared cache serialization")
+}
@@ -0,0 +1,240 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/authzed/spicedb/internal/dispatch/keys"
+	log "github.com/authzed/spicedb/internal/logging"
+)
+
+// snapshotVersion is written at the start of each snapshot file, and must be changed whenever
+// the format of the file or of the cached entries changes.
+const snapshotVersion = 1
+
+// SnapshottingCache is a dispatch cache which records the entries set in it, so that they can be
+// saved to a snapshot file and used to warm the cache of a later process.
+//
+// Dispatch cache keys are only comparable across processes by their stable sum, so entries loaded
+// from a snapshot are kept apart from the cache and are moved into it when first read.
+type SnapshottingCache struct {
+	Cache
+
+	path       string
+	maxEntries int
+
+	lock     sync.Mutex
+	recorded map[uint64][]byte
+	order    []uint64
+	warm     map[uint64][]byte
+}
+
+// NewSnapshottingCache wraps a cache to record up to maxEntries of the entries most recently set in
+// it, and warms it with the entries of the snapshot file at path, if one exists.
+func NewSnapshottingCache(c Cache, path string, maxEntries int) (*SnapshottingCache, error) {
+	warm, err := readSnapshot(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading dispatch cache snapshot: %w", err)
+	}
+
+	log.Info().Str("path", path).Int("entries", len(warm)).Msg("loaded dispatch cache snapshot")
+	return &SnapshottingCache{
+		Cache:      c,
+		path:       path,
+		maxEntries: maxEntries,
+		recorded:   make(map[uint64][]byte, maxEntries),
+		warm:       warm,
+	}, nil
+}
+
+func (sc *SnapshottingCache) Get(key interface{}) (interface{}, bool) {
+	if found, ok := sc.Cache.Get(key); ok {
+		return found, true
+	}
+
+	stable, ok := stableSum(key)
+	if !ok {
+		return nil, false
+	}
+
+	sc.lock.Lock()
+	entry, ok := sc.warm[stable]
+	delete(sc.warm, stable)
+	sc.lock.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	sc.Set(key, entry, int64(len(entry)))
+	return entry, true
+}
+
+func (sc *SnapshottingCache) Set(key interface{}, entry interface{}, cost int64) bool {
+	sc.record(key, entry)
+	return sc.Cache.Set(key, entry, cost)
+}
+
+// SetWithTTL does not record entries which expire, as they may have expired by the time the
+// snapshot is loaded.
+func (sc *SnapshottingCache) SetWithTTL(key interface{}, entry interface{}, cost int64, ttl time.Duration) bool {
+	if ttl == 0 {
+		sc.record(key, entry)
+	}
+	return sc.Cache.SetWithTTL(key, entry, cost, ttl)
+}
+
+func (sc *SnapshottingCache) record(key interface{}, entry interface{}) {
+	stable, ok := stableSum(key)
+	if !ok {
+		return
+	}
+
+	value, ok := entry.([]byte)
+	if !ok || sc.maxEntries <= 0 {
+		return
+	}
+
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	if _, ok := sc.recorded[stable]; !ok {
+		sc.order = append(sc.order, stable)
+	}
+	sc.recorded[stable] = value
+
+	for len(sc.recorded) > sc.maxEntries {
+		delete(sc.recorded, sc.order[0])
+		sc.order = sc.order[1:]
+	}
+}
+
+// Snapshot writes the recorded entries to the snapshot file, replacing it.
+func (sc *SnapshottingCache) Snapshot() error {
+	sc.lock.Lock()
+	entries := make(map[uint64][]byte, len(sc.recorded))
+	for stable, value := range sc.recorded {
+		entries[stable] = value
+	}
+	sc.lock.Unlock()
+
+	// The snapshot is written beside the file and then moved over it, so that a process which
+	// stops while writing does not leave a partial snapshot.
+	tmpPath := sc.path + ".tmp"
+	if err := writeSnapshot(tmpPath, entries); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, sc.path)
+}
+
+// Run writes a snapshot on every interval, and once more when the context is canceled.
+func (sc *SnapshottingCache) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := sc.Snapshot(); err != nil {
+				log.Ctx(ctx).Warn().Err(err).Msg("error writing dispatch cache snapshot")
+			}
+		case <-ctx.Done():
+			if err := sc.Snapshot(); err != nil {
+				log.Ctx(ctx).Warn().Err(err).Msg("error writing dispatch cache snapshot")
+			}
+			return nil
+		}
+	}
+}
+
+func stableSum(key interface{}) (uint64, bool) {
+	dispatchCacheKey, ok := key.(keys.DispatchCacheKey)
+	if !ok {
+		return 0, false
+	}
+	_, stable := dispatchCacheKey.AsUInt64s()
+	return stable, true
+}
+
+func writeSnapshot(path string, entries map[uint64][]byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	buf := binary.AppendUvarint(nil, snapshotVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(entries)))
+	if _, err := w.Write(buf); err != nil {
+		return err
+	}
+
+	for stable, value := range entries {
+		buf = binary.LittleEndian.AppendUint64(buf[:0], stable)
+		buf = binary.AppendUvarint(buf, uint64(len(value)))
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+		if _, err := w.Write(value); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func readSnapshot(path string) (map[uint64][]byte, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[uint64][]byte{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	version, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if version != snapshotVersion {
+		log.Warn().Uint64("version", version).Msg("ignoring dispatch cache snapshot of unsupported version")
+		return map[uint64][]byte{}, nil
+	}
+
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// The count is not trusted to size the map, in case the file is corrupt.
+	entries := make(map[uint64][]byte)
+	for i := uint64(0); i < count; i++ {
+		var stable [8]byte
+		if _, err := io.ReadFull(r, stable[:]); err != nil {
+			return nil, err
+		}
+
+		size, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+
+		value := make([]byte, size)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, err
+		}
+		entries[binary.LittleEndian.Uint64(stable[:])] = value
+	}
+	return entries, nil
+}
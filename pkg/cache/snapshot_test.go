@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/dispatch/keys"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+)
+
+func testCheckKey(t *testing.T, resourceID string) keys.DispatchCacheKey {
+	key, err := (&keys.DirectKeyHandler{}).CheckCacheKey(context.Background(), &v1.DispatchCheckRequest{
+		ResourceRelation: &core.RelationReference{Namespace: "document", Relation: "view"},
+		ResourceIds:      []string{resourceID},
+		Subject:          &core.ObjectAndRelation{Namespace: "user", ObjectId: "tom", Relation: "..."},
+		Metadata:         &v1.ResolverMeta{AtRevision: "1", DepthRemaining: 50},
+	})
+	require.NoError(t, err)
+	return key
+}
+
+func newTestCache(t *testing.T) Cache {
+	c, err := NewCache(&Config{NumCounters: 1000, MaxCost: 1 << 20})
+	require.NoError(t, err)
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestSnapshottingCache(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), "dispatch-cache")
+
+	first, err := NewSnapshottingCache(newTestCache(t), path, 2)
+	require.NoError(err)
+
+	for i := 0; i < 3; i++ {
+		key := testCheckKey(t, fmt.Sprintf("doc%d", i))
+		first.Set(key, []byte(fmt.Sprintf("result%d", i)), 7)
+	}
+	first.SetWithTTL(testCheckKey(t, "expiring"), []byte("expiring"), 8, time.Hour)
+	require.NoError(first.Snapshot())
+
+	// Only the most recently set entries which do not expire are loaded into a new cache.
+	second, err := NewSnapshottingCache(newTestCache(t), path, 2)
+	require.NoError(err)
+
+	_, found := second.Get(testCheckKey(t, "doc0"))
+	require.False(found)
+
+	_, found = second.Get(testCheckKey(t, "expiring"))
+	require.False(found)
+
+	for i := 1; i < 3; i++ {
+		found, ok := second.Get(testCheckKey(t, fmt.Sprintf("doc%d", i)))
+		require.True(ok)
+		require.Equal([]byte(fmt.Sprintf("result%d", i)), found)
+	}
+
+	// Entries read from the snapshot are moved into the cache, and recorded for the next one.
+	second.Wait()
+	cached, ok := second.Cache.Get(testCheckKey(t, "doc1"))
+	require.True(ok)
+	require.Equal([]byte("result1"), cached)
+
+	require.NoError(second.Snapshot())
+	third, err := NewSnapshottingCache(newTestCache(t), path, 2)
+	require.NoError(err)
+	_, found = third.Get(testCheckKey(t, "doc2"))
+	require.True(found)
+}
+
+func TestSnapshottingCacheWithoutSnapshot(t *testing.T) {
+	require := require.New(t)
+
+	c, err := NewSnapshottingCache(NoopCache(), filepath.Join(t.TempDir(), "missing"), 10)
+	require.NoError(err)
+
+	_, found := c.Get(testCheckKey(t, "doc1"))
+	require.False(found)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jzelinskie/cobrautil/v2"
+	"github.com/spf13/cobra"
+
+	log "github.com/authzed/spicedb/internal/logging"
+	dsconfig "github.com/authzed/spicedb/pkg/cmd/datastore"
+	"github.com/authzed/spicedb/pkg/cmd/server"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/datastore/snapshot"
+)
+
+func NewDatastoreCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "datastore",
+		Short: "operate on a datastore",
+	}
+}
+
+func RegisterDatastoreCopyFlags(cmd *cobra.Command) {
+	cmd.Flags().String("from-engine", "", fmt.Sprintf(`type of the datastore copied from (%s)`, datastore.EngineOptions()))
+	cmd.Flags().String("from", "", "connection string of the datastore copied from")
+	cmd.Flags().String("to-engine", "", fmt.Sprintf(`type of the datastore copied to (%s)`, datastore.EngineOptions()))
+	cmd.Flags().String("to", "", "connection string of the datastore copied to, which must be empty and migrated to the latest revision")
+	cmd.Flags().String("revision", "", "revision of the datastore copied from at which it is copied (omit to copy at its head revision)")
+	cmd.Flags().Bool("follow", false, "after the copy, apply the changes to relationships made in the datastore copied from to the datastore copied to, until interrupted")
+}
+
+func NewDatastoreCopyCommand(programName string) *cobra.Command {
+	return &cobra.Command{
+		Use:     "copy",
+		Short:   "copy the data of one datastore into another",
+		Long:    "Copies the schema and relationships of one datastore, as of a single revision, into another empty datastore, such as from postgres to cockroachdb.\nWith --follow, the changes to relationships made after that revision are then applied to the copy until interrupted, so that clients can be moved to it once it has caught up.",
+		PreRunE: server.DefaultPreRunE(programName),
+		RunE:    datastoreCopyRun,
+		Args:    cobra.ExactArgs(0),
+	}
+}
+
+func datastoreCopyRun(cmd *cobra.Command, args []string) error {
+	ctx := SignalContextWithGracePeriod(cmd.Context(), 0)
+
+	source, err := newCopyDatastore(ctx, cobrautil.MustGetStringExpanded(cmd, "from-engine"), cobrautil.MustGetStringExpanded(cmd, "from"))
+	if err != nil {
+		return fmt.Errorf("unable to open the datastore copied from: %w", err)
+	}
+	defer source.Close()
+
+	target, err := newCopyDatastore(ctx, cobrautil.MustGetStringExpanded(cmd, "to-engine"), cobrautil.MustGetStringExpanded(cmd, "to"))
+	if err != nil {
+		return fmt.Errorf("unable to open the datastore copied to: %w", err)
+	}
+	defer target.Close()
+
+	var revision datastore.Revision
+	if serialized := cobrautil.MustGetString(cmd, "revision"); serialized != "" {
+		revision, err = source.RevisionFromString(serialized)
+		if err == nil {
+			err = source.CheckRevision(ctx, revision)
+		}
+	} else {
+		revision, err = source.HeadRevision(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to determine the revision to copy: %w", err)
+	}
+
+	log.Info().Stringer("revision", revision).Msg("copying datastore")
+
+	targetRevision, summary, err := snapshot.Copy(ctx, source, revision, target, func(progress snapshot.Summary) {
+		log.Info().Uint64("relationships", progress.Relationships).Msg("copying relationships")
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Uint64("caveats", summary.Caveats).
+		Uint64("namespaces", summary.Namespaces).
+		Uint64("relationships", summary.Relationships).
+		Stringer("revision", targetRevision).
+		Msg("copied datastore")
+
+	if !cobrautil.MustGetBool(cmd, "follow") {
+		return nil
+	}
+
+	log.Info().Stringer("revision", revision).Msg("following changes to the datastore copied from")
+
+	var appliedRevisions, appliedChanges uint64
+	err = snapshot.Follow(ctx, source, revision, target, func(applied datastore.Revision, changes int) {
+		appliedRevisions++
+		appliedChanges += uint64(changes)
+		log.Debug().Stringer("revision", applied).Int("changes", changes).Msg("applied changes")
+	})
+
+	log.Info().
+		Uint64("transactions", appliedRevisions).
+		Uint64("changes", appliedChanges).
+		Msg("stopped following changes")
+	return err
+}
+
+func newCopyDatastore(ctx context.Context, engine, uri string) (datastore.Datastore, error) {
+	return dsconfig.NewDatastore(ctx,
+		dsconfig.WithEngine(engine),
+		dsconfig.WithURI(uri),
+		dsconfig.WithRequestHedgingEnabled(false),
+		dsconfig.WithEnableDatastoreMetrics(false),
+	)
+}
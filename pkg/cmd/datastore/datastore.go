@@ -6,14 +6,22 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
 	"github.com/spf13/cobra"
 
+	"github.com/authzed/spicedb/internal/datastore/badger"
+	"github.com/authzed/spicedb/internal/datastore/cassandra"
 	"github.com/authzed/spicedb/internal/datastore/crdb"
 	"github.com/authzed/spicedb/internal/datastore/memdb"
 	"github.com/authzed/spicedb/internal/datastore/mysql"
+	"github.com/authzed/spicedb/internal/datastore/objectstore"
 	"github.com/authzed/spicedb/internal/datastore/postgres"
 	"github.com/authzed/spicedb/internal/datastore/proxy"
+	"github.com/authzed/spicedb/internal/datastore/redis"
+	"github.com/authzed/spicedb/internal/datastore/remote"
 	"github.com/authzed/spicedb/internal/datastore/spanner"
+	"github.com/authzed/spicedb/internal/datastore/sqlite"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/datastore"
 	"github.com/authzed/spicedb/pkg/validationfile"
@@ -22,19 +30,31 @@ import (
 type engineBuilderFunc func(options Config) (datastore.Datastore, error)
 
 const (
-	MemoryEngine    = "memory"
-	PostgresEngine  = "postgres"
-	CockroachEngine = "cockroachdb"
-	SpannerEngine   = "spanner"
-	MySQLEngine     = "mysql"
+	MemoryEngine      = "memory"
+	PostgresEngine    = "postgres"
+	CockroachEngine   = "cockroachdb"
+	SpannerEngine     = "spanner"
+	MySQLEngine       = "mysql"
+	SQLiteEngine      = "sqlite"
+	CassandraEngine   = "cassandra"
+	RedisEngine       = "redis"
+	ObjectStoreEngine = "objectstore"
+	RemoteEngine      = "remote"
+	BadgerEngine      = "badger"
 )
 
 var BuilderForEngine = map[string]engineBuilderFunc{
-	CockroachEngine: newCRDBDatastore,
-	PostgresEngine:  newPostgresDatastore,
-	MemoryEngine:    newMemoryDatstore,
-	SpannerEngine:   newSpannerDatastore,
-	MySQLEngine:     newMySQLDatastore,
+	CockroachEngine:   newCRDBDatastore,
+	PostgresEngine:    newPostgresDatastore,
+	MemoryEngine:      newMemoryDatstore,
+	SpannerEngine:     newSpannerDatastore,
+	MySQLEngine:       newMySQLDatastore,
+	SQLiteEngine:      newSQLiteDatastore,
+	CassandraEngine:   newCassandraDatastore,
+	RedisEngine:       newRedisDatastore,
+	ObjectStoreEngine: newObjectStoreDatastore,
+	RemoteEngine:      newRemoteDatastore,
+	BadgerEngine:      newBadgerDatastore,
 }
 
 //go:generate go run github.com/ecordell/optgen -output zz_generated.options.go . Config
@@ -52,6 +72,7 @@ type Config struct {
 	MinOpenConns           int
 	SplitQueryCount        uint16
 	ReadOnly               bool
+	ReadOnlyToggleEnabled  bool
 	EnableDatastoreMetrics bool
 	DisableStats           bool
 
@@ -66,23 +87,105 @@ type Config struct {
 	RequestHedgingMaxRequests      uint64
 	RequestHedgingQuantile         float64
 
+	// Write batching
+	WriteBatchMaxDelay time.Duration
+	WriteBatchMaxSize  int
+
+	// Query limits
+	QueryMaxRows     uint64
+	QueryMaxDuration time.Duration
+
+	// Transaction retries
+	RetryInitialBackoff time.Duration
+	RetryMaxBackoff     time.Duration
+
 	// CRDB
-	FollowerReadDelay time.Duration
-	MaxRetries        int
-	OverlapKey        string
-	OverlapStrategy   string
+	FollowerReadDelay     time.Duration
+	FollowerReads         bool
+	MaxRetries            int
+	OverlapKey            string
+	OverlapStrategy       string
+	WatchResolvedInterval time.Duration
+	NamespaceRegions      map[string]string
 
 	// Postgres
 	HealthCheckPeriod  time.Duration
 	GCInterval         time.Duration
 	GCMaxOperationTime time.Duration
+	GCBatchSize        uint64
+	GCBatchDelay       time.Duration
+	ReadReplicaURIs    []string
+
+	StatementCacheMode     string
+	StatementCacheCapacity int
+
+	SlowQueryThreshold      time.Duration
+	SlowQueryExplainAnalyze bool
+	TransactionPooling      bool
+	PostgresWatchMode       string
+	PostgresYugabyte        bool
+
+	PostgresAuthMethod     string
+	PostgresAWSRegion      string
+	PostgresClientCertPath string
+	PostgresClientKeyPath  string
+	PostgresRootCertPath   string
+
+	ReadStatementTimeout  time.Duration
+	ReadLockTimeout       time.Duration
+	WriteStatementTimeout time.Duration
+	WriteLockTimeout      time.Duration
+	GCStatementTimeout    time.Duration
+	GCLockTimeout         time.Duration
+
+	// Postgres connection pools, each overriding the shared pool options when set
+	ReadMaxOpenConns        int
+	ReadMinOpenConns        int
+	ReadMaxLifetime         time.Duration
+	ReadMaxIdleTime         time.Duration
+	ReadTargetSessionAttrs  string
+	WriteMaxOpenConns       int
+	WriteMinOpenConns       int
+	WriteMaxLifetime        time.Duration
+	WriteMaxIdleTime        time.Duration
+	WriteTargetSessionAttrs string
 
 	// Spanner
 	SpannerCredentialsFile string
 	SpannerEmulatorHost    string
+	SpannerMaxStaleness    time.Duration
+	SpannerWatchMode       string
 
 	// MySQL
-	TablePrefix string
+	TablePrefix         string
+	VitessCompatibility bool
+	TiDBCompatibility   bool
+	MySQLWatchMode      string
+
+	// SQLite
+	SQLiteBusyTimeout time.Duration
+
+	// Cassandra
+	CassandraWatchLag         time.Duration
+	CassandraWriteConcurrency int
+
+	// Memory
+	MemorySnapshotFile     string
+	MemorySnapshotInterval time.Duration
+	MemoryMaxRelationships uint64
+	MemoryMaxRevisions     int
+
+	// Remote
+	RemoteCAPath       string
+	RemotePresharedKey string
+
+	// Encryption
+	CaveatContextKMSKeyID string
+
+	// Dual-write migration
+	DualWriteEngine  string
+	DualWriteURI     string
+	DualWriteCutover bool
 
 	// Internal
 	WatchBufferLength uint16
@@ -103,8 +206,40 @@ func RegisterDatastoreFlags(cmd *cobra.Command, opts *Config) {
 	cmd.Flags().DurationVar(&opts.GCWindow, "datastore-gc-window", 24*time.Hour, "amount of time before revisions are garbage collected")
 	cmd.Flags().DurationVar(&opts.GCInterval, "datastore-gc-interval", 3*time.Minute, "amount of time between passes of garbage collection (postgres driver only)")
 	cmd.Flags().DurationVar(&opts.GCMaxOperationTime, "datastore-gc-max-operation-time", 1*time.Minute, "maximum amount of time a garbage collection pass can operate before timing out (postgres driver only)")
+	cmd.Flags().Uint64Var(&opts.GCBatchSize, "datastore-postgres-gc-batch-size", 1000, "maximum number of rows deleted by each garbage collection transaction (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.GCBatchDelay, "datastore-postgres-gc-batch-delay", 0, "time to wait after each full batch of garbage collection deletes, limiting their rate (postgres driver only)")
 	cmd.Flags().DurationVar(&opts.RevisionQuantization, "datastore-revision-quantization-interval", 5*time.Second, "boundary interval to which to round the quantized revision")
 	cmd.Flags().BoolVar(&opts.ReadOnly, "datastore-readonly", false, "set the service to read-only mode")
+	cmd.Flags().BoolVar(&opts.ReadOnlyToggleEnabled, "datastore-readonly-toggle-enabled", false, "allow the service to be switched in and out of read-only mode while running, a mode stored in the datastore, read by each write transaction and shared by every node using it; with --datastore-readonly, the node starts out rejecting writes without storing the mode")
+	cmd.Flags().IntVar(&opts.ReadMaxOpenConns, "datastore-conn-pool-read-max-open", 0, "number of concurrent connections open in the pool used for snapshot reads, revisions and watches, overriding --datastore-conn-max-open when set (postgres driver only)")
+	cmd.Flags().IntVar(&opts.ReadMinOpenConns, "datastore-conn-pool-read-min-open", 0, "number of minimum concurrent connections open in the pool used for snapshot reads, revisions and watches, overriding --datastore-conn-min-open when set (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.ReadMaxLifetime, "datastore-conn-pool-read-max-lifetime", 0, "maximum amount of time a connection can live in the pool used for snapshot reads, revisions and watches, overriding --datastore-conn-max-lifetime when set (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.ReadMaxIdleTime, "datastore-conn-pool-read-max-idletime", 0, "maximum amount of time a connection can idle in the pool used for snapshot reads, revisions and watches, overriding --datastore-conn-max-idletime when set (postgres driver only)")
+	cmd.Flags().StringVar(&opts.ReadTargetSessionAttrs, "datastore-conn-pool-read-target-session-attrs", "", `libpq target_session_attrs (e.g. "prefer-standby") which the connections of the pool used for snapshot reads, revisions and watches must satisfy when the connection string lists multiple hosts (postgres driver only)`)
+	cmd.Flags().IntVar(&opts.WriteMaxOpenConns, "datastore-conn-pool-write-max-open", 0, "number of concurrent connections open in the pool used for read-write transactions and garbage collection, overriding --datastore-conn-max-open when set (postgres driver only)")
+	cmd.Flags().IntVar(&opts.WriteMinOpenConns, "datastore-conn-pool-write-min-open", 0, "number of minimum concurrent connections open in the pool used for read-write transactions and garbage collection, overriding --datastore-conn-min-open when set (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.WriteMaxLifetime, "datastore-conn-pool-write-max-lifetime", 0, "maximum amount of time a connection can live in the pool used for read-write transactions and garbage collection, overriding --datastore-conn-max-lifetime when set (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.WriteMaxIdleTime, "datastore-conn-pool-write-max-idletime", 0, "maximum amount of time a connection can idle in the pool used for read-write transactions and garbage collection, overriding --datastore-conn-max-idletime when set (postgres driver only)")
+	cmd.Flags().StringVar(&opts.WriteTargetSessionAttrs, "datastore-conn-pool-write-target-session-attrs", "", `libpq target_session_attrs (e.g. "read-write") which the connections of the pool used for read-write transactions and garbage collection must satisfy when the connection string lists multiple hosts (postgres driver only)`)
+	cmd.Flags().StringVar(&opts.StatementCacheMode, "datastore-statement-cache-mode", "", `how queries are prepared on each connection ("prepare", "describe", "disabled" or "simple-protocol"); "describe" or "simple-protocol" are required behind poolers in transaction mode, defaults to the statement_cache_mode of the connection string (postgres driver only)`)
+	cmd.Flags().IntVar(&opts.StatementCacheCapacity, "datastore-statement-cache-capacity", 0, "number of queries whose statements are cached by each connection, defaults to the statement_cache_capacity of the connection string (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.SlowQueryThreshold, "datastore-slow-query-threshold", 0, "duration after which a relationship query is logged along with its EXPLAIN plan (0 disables logging slow queries) (postgres driver only)")
+	cmd.Flags().BoolVar(&opts.SlowQueryExplainAnalyze, "datastore-slow-query-explain-analyze", false, "re-run slow queries with EXPLAIN (ANALYZE, BUFFERS) so that their logged plans include actual timings and buffer usage (postgres driver only)")
+	cmd.Flags().BoolVar(&opts.TransactionPooling, "datastore-postgres-transaction-pooling", false, "hold no session state across transactions, as required behind a pooler in transaction pooling mode such as PgBouncer; statements are executed unnamed rather than prepared, and watches poll rather than listen for new transactions (postgres driver only)")
+	cmd.Flags().BoolVar(&opts.PostgresYugabyte, "datastore-postgres-yugabyte", false, "adapt the datastore to the YSQL API of a YugabyteDB cluster, retrying transactions aborted by its conflicts and polling rather than listening for new transactions (postgres driver only)")
+	cmd.Flags().StringVar(&opts.PostgresWatchMode, "datastore-postgres-watch-mode", "polling", `how watches find new transactions ("polling" or "logical-replication"); "logical-replication" streams changes from a temporary replication slot and requires wal_level=logical (postgres driver only)`)
+	cmd.Flags().StringVar(&opts.PostgresAuthMethod, "datastore-postgres-auth-method", "password", `how connections authenticate ("password", "aws-iam" or "gcp-iam"); "aws-iam" signs RDS IAM authentication tokens and "gcp-iam" uses Cloud SQL IAM access tokens of the default credentials, refreshed as connections are opened (postgres driver only)`)
+	cmd.Flags().StringVar(&opts.PostgresAWSRegion, "datastore-postgres-aws-region", "", "region of the RDS instance for aws-iam authentication, defaulting to that of the AWS configuration (postgres driver only)")
+	cmd.Flags().StringVar(&opts.PostgresClientCertPath, "datastore-postgres-client-cert", "", "path to the PEM encoded client certificate presented for mutual TLS authentication (postgres driver only)")
+	cmd.Flags().StringVar(&opts.PostgresClientKeyPath, "datastore-postgres-client-key", "", "path to the PEM encoded key of the client certificate (postgres driver only)")
+	cmd.Flags().StringVar(&opts.PostgresRootCertPath, "datastore-postgres-root-cert", "", "path to the PEM encoded certificate authority which must have signed the certificate of the server (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.ReadStatementTimeout, "datastore-postgres-read-statement-timeout", 0, "statement_timeout set on each snapshot read transaction (0 keeps that of the connection) (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.ReadLockTimeout, "datastore-postgres-read-lock-timeout", 0, "lock_timeout set on each snapshot read transaction (0 keeps that of the connection) (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.WriteStatementTimeout, "datastore-postgres-write-statement-timeout", 0, "statement_timeout set on each read-write transaction (0 keeps that of the connection) (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.WriteLockTimeout, "datastore-postgres-write-lock-timeout", 0, "lock_timeout set on each read-write transaction (0 keeps that of the connection) (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.GCStatementTimeout, "datastore-postgres-gc-statement-timeout", 0, "statement_timeout set on each garbage collection transaction (0 keeps that of the connection) (postgres driver only)")
+	cmd.Flags().DurationVar(&opts.GCLockTimeout, "datastore-postgres-gc-lock-timeout", 0, "lock_timeout set on each garbage collection transaction (0 keeps that of the connection) (postgres driver only)")
+	cmd.Flags().StringSliceVar(&opts.ReadReplicaURIs, "datastore-read-replica-conn-uri", []string{}, "connection strings of read replicas to which snapshot reads are routed once they have replayed the requested revision (postgres driver only)")
 	cmd.Flags().StringSliceVar(&opts.BootstrapFiles, "datastore-bootstrap-files", []string{}, "bootstrap data yaml files to load")
 	cmd.Flags().BoolVar(&opts.BootstrapOverwrite, "datastore-bootstrap-overwrite", false, "overwrite any existing data with bootstrap data")
 	cmd.Flags().DurationVar(&opts.BootstrapTimeout, "datastore-bootstrap-timeout", 10*time.Second, "maximum duration before timeout for the bootstrap data to be written")
@@ -112,16 +247,43 @@ func RegisterDatastoreFlags(cmd *cobra.Command, opts *Config) {
 	cmd.Flags().DurationVar(&opts.RequestHedgingInitialSlowValue, "datastore-request-hedging-initial-slow-value", 10*time.Millisecond, "initial value to use for slow datastore requests, before statistics have been collected")
 	cmd.Flags().Uint64Var(&opts.RequestHedgingMaxRequests, "datastore-request-hedging-max-requests", 1_000_000, "maximum number of historical requests to consider")
 	cmd.Flags().Float64Var(&opts.RequestHedgingQuantile, "datastore-request-hedging-quantile", 0.95, "quantile of historical datastore request time over which a request will be considered slow")
+	cmd.Flags().DurationVar(&opts.WriteBatchMaxDelay, "datastore-write-batch-max-delay", 0, "maximum amount of time a transaction which only writes relationships waits to be combined with concurrent transactions into a single datastore transaction (0 disables write batching)")
+	cmd.Flags().IntVar(&opts.WriteBatchMaxSize, "datastore-write-batch-max-size", 1000, "number of relationship updates after which a batch of combined transactions is written without waiting")
+	cmd.Flags().Uint64Var(&opts.QueryMaxRows, "datastore-query-max-rows", 0, "maximum number of relationships a single relationship query may read before it fails (0 for no limit)")
+	cmd.Flags().DurationVar(&opts.QueryMaxDuration, "datastore-query-max-duration", 0, "maximum amount of time a single relationship query may take before it fails (0 for no limit)")
 	cmd.Flags().BoolVar(&opts.EnableDatastoreMetrics, "datastore-prometheus-metrics", true, "set to false to disabled prometheus metrics from the datastore")
 	// See crdb doc for info about follower reads and how it is configured: https://www.cockroachlabs.com/docs/stable/follower-reads.html
 	cmd.Flags().DurationVar(&opts.FollowerReadDelay, "datastore-follower-read-delay-duration", 4_800*time.Millisecond, "amount of time to subtract from non-sync revision timestamps to ensure they are sufficiently in the past to enable follower reads (cockroach driver only)")
+	cmd.Flags().DurationVar(&opts.WatchResolvedInterval, "datastore-watch-resolved-interval", time.Second, "interval at which watch changefeeds emit resolved timestamps, after which changes are sent (cockroach driver only)")
+	cmd.Flags().BoolVar(&opts.FollowerReads, "datastore-follower-reads", false, "read at the follower read timestamp of the cluster for requests which minimize latency, so that they are served by the nearest replica, in place of --datastore-follower-read-delay-duration (cockroach driver only)")
 	cmd.Flags().Uint16Var(&opts.SplitQueryCount, "datastore-query-userset-batch-size", 1024, "number of usersets after which a relationship query will be split into multiple queries")
 	cmd.Flags().IntVar(&opts.MaxRetries, "datastore-max-tx-retries", 10, "number of times a retriable transaction should be retried")
+	cmd.Flags().DurationVar(&opts.RetryInitialBackoff, "datastore-tx-retry-initial-backoff", 10*time.Millisecond, "wait before the first retry of a transaction, doubled before each further retry (0 to retry immediately; cockroach, postgres and mysql drivers only)")
+	cmd.Flags().DurationVar(&opts.RetryMaxBackoff, "datastore-tx-retry-max-backoff", time.Second, "longest wait between retries of a transaction (cockroach, postgres and mysql drivers only)")
 	cmd.Flags().StringVar(&opts.OverlapStrategy, "datastore-tx-overlap-strategy", "static", `strategy to generate transaction overlap keys ("prefix", "static", "insecure") (cockroach driver only)`)
+	cmd.Flags().StringToStringVar(&opts.NamespaceRegions, "datastore-crdb-namespace-regions", map[string]string{}, "regions of the database in which the relationships of each resource type are homed, once the relationship table is REGIONAL BY ROW (e.g. tenant/document=us-east1) (cockroach driver only)")
 	cmd.Flags().StringVar(&opts.OverlapKey, "datastore-tx-overlap-key", "key", "static key to touch when writing to ensure transactions overlap (only used if --datastore-tx-overlap-strategy=static is set; cockroach driver only)")
 	cmd.Flags().StringVar(&opts.SpannerCredentialsFile, "datastore-spanner-credentials", "", "path to service account key credentials file with access to the cloud spanner instance (omit to use application default credentials)")
 	cmd.Flags().StringVar(&opts.SpannerEmulatorHost, "datastore-spanner-emulator-host", "", "URI of spanner emulator instance used for development and testing (e.g. localhost:9010)")
+	cmd.Flags().DurationVar(&opts.SpannerMaxStaleness, "datastore-spanner-max-staleness", 0, "maximum staleness of the bounded-staleness read from which minimize_latency revisions are taken, so that they can be read from the nearest replica (0 to take them from strong reads; spanner driver only)")
+	cmd.Flags().StringVar(&opts.SpannerWatchMode, "datastore-spanner-watch-mode", "polling", `how watches find new changes ("polling" or "change-stream"); "change-stream" reads the change stream of the changelog table, and sends changes as they are committed (spanner driver only)`)
 	cmd.Flags().StringVar(&opts.TablePrefix, "datastore-mysql-table-prefix", "", "prefix to add to the name of all SpiceDB database tables")
+	cmd.Flags().BoolVar(&opts.VitessCompatibility, "datastore-mysql-vitess", false, "adapt the datastore to a Vitess keyspace reached through vtgate (mysql driver only)")
+	cmd.Flags().BoolVar(&opts.TiDBCompatibility, "datastore-mysql-tidb", false, "adapt the datastore to a TiDB database (mysql driver only)")
+	cmd.Flags().StringVar(&opts.MySQLWatchMode, "datastore-mysql-watch-mode", "polling", `how watches find new transactions ("polling" or "binlog"); "binlog" streams the binary log as a replica would, and falls back to polling where it cannot be streamed (mysql driver only)`)
+	cmd.Flags().DurationVar(&opts.SQLiteBusyTimeout, "datastore-sqlite-busy-timeout", 5*time.Second, "amount of time a transaction waits for the write lock held by another transaction before it is retried (sqlite driver only)")
+	cmd.Flags().DurationVar(&opts.CassandraWatchLag, "datastore-cassandra-watch-lag", time.Second, "how far behind the current time watches read changes, which must exceed the time taken to commit a transaction plus the clock skew between SpiceDB instances (cassandra driver only)")
+	cmd.Flags().IntVar(&opts.CassandraWriteConcurrency, "datastore-cassandra-write-concurrency", 16, "number of lightweight transactions run concurrently as a transaction which creates relationships commits (cassandra driver only)")
+	cmd.Flags().StringVar(&opts.MemorySnapshotFile, "datastore-memory-snapshot-file", "", "path of the file to which the datastore is saved as SpiceDB stops, and from which it is restored as SpiceDB starts (omit to keep the datastore only in memory; memory driver only)")
+	cmd.Flags().DurationVar(&opts.MemorySnapshotInterval, "datastore-memory-snapshot-interval", 0, "interval at which the datastore is saved to its snapshot file if it has changed (0 to save it only as SpiceDB stops; memory driver only)")
+	cmd.Flags().Uint64Var(&opts.MemoryMaxRelationships, "datastore-memory-max-relationships", 0, "maximum number of relationships stored, beyond which writes fail (0 for no limit; memory driver only)")
+	cmd.Flags().IntVar(&opts.MemoryMaxRevisions, "datastore-memory-max-revisions", 0, "maximum number of revisions retained, beyond which the oldest are evicted and can no longer be read or watched from (0 for no limit; memory driver only)")
+	cmd.Flags().StringVar(&opts.RemoteCAPath, "datastore-remote-ca-path", "", "path to the certificate authority used to verify the TLS connection to the datastore service (omit to connect without TLS; remote driver only)")
+	cmd.Flags().StringVar(&opts.RemotePresharedKey, "datastore-remote-preshared-key", "", "preshared key sent as a bearer token with each call to the datastore service (remote driver only)")
+	cmd.Flags().StringVar(&opts.CaveatContextKMSKeyID, "datastore-caveat-context-kms-key-id", "", "ID or ARN of the AWS KMS key used to encrypt the caveat context of relationships before it is stored (omit to store caveat context unencrypted)")
+	cmd.Flags().StringVar(&opts.DualWriteEngine, "datastore-dual-write-engine", "", fmt.Sprintf(`type of a new datastore to which every write is also applied, for migrating to it without downtime (%s)`, datastore.EngineOptions()))
+	cmd.Flags().StringVar(&opts.DualWriteURI, "datastore-dual-write-conn-uri", "", "connection string of the new datastore to which every write is also applied")
+	cmd.Flags().BoolVar(&opts.DualWriteCutover, "datastore-dual-write-cutover", false, "serve reads from the new datastore to which every write is also applied, while still applying writes to the old one")
 	cmd.Flags().StringVar(&opts.MigrationPhase, "datastore-migration-phase", "", "datastore-specific flag that should be used to signal to a datastore which phase of a multi-step migration it is in")
 
 	// disabling stats is only for tests
@@ -138,22 +300,31 @@ func RegisterDatastoreFlags(cmd *cobra.Command, opts *Config) {
 
 func DefaultDatastoreConfig() *Config {
 	return &Config{
-		GCWindow:               24 * time.Hour,
-		RevisionQuantization:   5 * time.Second,
-		MaxLifetime:            30 * time.Minute,
-		MaxIdleTime:            30 * time.Minute,
-		MaxOpenConns:           20,
-		MinOpenConns:           10,
-		SplitQueryCount:        1024,
-		MaxRetries:             50,
-		OverlapStrategy:        "prefix",
-		HealthCheckPeriod:      30 * time.Second,
-		GCInterval:             3 * time.Minute,
-		GCMaxOperationTime:     1 * time.Minute,
-		WatchBufferLength:      128,
-		EnableDatastoreMetrics: true,
-		DisableStats:           false,
-		BootstrapTimeout:       10 * time.Second,
+		GCWindow:                  24 * time.Hour,
+		RevisionQuantization:      5 * time.Second,
+		MaxLifetime:               30 * time.Minute,
+		MaxIdleTime:               30 * time.Minute,
+		MaxOpenConns:              20,
+		MinOpenConns:              10,
+		SplitQueryCount:           1024,
+		MaxRetries:                50,
+		RetryInitialBackoff:       10 * time.Millisecond,
+		RetryMaxBackoff:           time.Second,
+		OverlapStrategy:           "prefix",
+		NamespaceRegions:          map[string]string{},
+		HealthCheckPeriod:         30 * time.Second,
+		GCInterval:                3 * time.Minute,
+		GCMaxOperationTime:        1 * time.Minute,
+		GCBatchSize:               1000,
+		WriteBatchMaxSize:         1000,
+		WatchBufferLength:         128,
+		WatchResolvedInterval:     time.Second,
+		EnableDatastoreMetrics:    true,
+		DisableStats:              false,
+		BootstrapTimeout:          10 * time.Second,
+		SQLiteBusyTimeout:         5 * time.Second,
+		CassandraWatchLag:         time.Second,
+		CassandraWriteConcurrency: 16,
 	}
 }
 
@@ -180,6 +351,48 @@ func NewDatastore(ctx context.Context, options ...ConfigOption) (datastore.Datas
 		return nil, err
 	}
 
+	if opts.DualWriteEngine != "" {
+		newBuilder, ok := BuilderForEngine[opts.DualWriteEngine]
+		if !ok {
+			return nil, fmt.Errorf("unknown dual-write datastore engine type: %s", opts.DualWriteEngine)
+		}
+
+		newOpts := *opts
+		newOpts.Engine = opts.DualWriteEngine
+		newOpts.URI = opts.DualWriteURI
+		newDS, err := newBuilder(newOpts)
+		if err != nil {
+			_ = ds.Close()
+			return nil, fmt.Errorf("unable to initialize dual-write datastore: %w", err)
+		}
+
+		log.Info().
+			Str("engine", opts.DualWriteEngine).
+			Bool("cutover", opts.DualWriteCutover).
+			Msg("dual-write migration enabled")
+
+		dualWrite := proxy.NewDualWriteProxy(ds, newDS)
+		if opts.DualWriteCutover {
+			dualWrite.Cutover()
+		}
+		ds = dualWrite
+	}
+
+	if opts.CaveatContextKMSKeyID != "" {
+		sess, err := session.NewSession()
+		if err != nil {
+			return nil, fmt.Errorf("unable to create AWS session for caveat context encryption: %w", err)
+		}
+
+		encrypter, err := proxy.NewKMSCaveatContextEncrypter(ctx, kms.New(sess), opts.CaveatContextKMSKeyID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to initialize caveat context encryption: %w", err)
+		}
+
+		log.Info().Str("keyID", opts.CaveatContextKMSKeyID).Msg("encrypting caveat context with AWS KMS")
+		ds = proxy.NewCaveatContextEncryptionProxy(ds, encrypter)
+	}
+
 	if len(opts.BootstrapFiles) > 0 {
 		ctx, cancel := context.WithTimeout(ctx, opts.BootstrapTimeout)
 		defer cancel()
@@ -204,6 +417,34 @@ func NewDatastore(ctx context.Context, options ...ConfigOption) (datastore.Datas
 		}
 	}
 
+	// The read-only mode is read by each transaction applied by the datastore, so it is read once
+	// for each batch of writes. When starting read-only, only this node rejects writes, so that
+	// starting does not write the mode to a datastore which may itself be read-only.
+	if opts.ReadOnlyToggleEnabled {
+		if opts.ReadOnly {
+			log.Warn().Msg("setting the datastore to read-only")
+		}
+		ds = proxy.NewReadonlyToggleDatastore(ds, opts.ReadOnly)
+	}
+
+	if opts.WriteBatchMaxDelay > 0 {
+		log.Info().
+			Stringer("maxDelay", opts.WriteBatchMaxDelay).
+			Int("maxSize", opts.WriteBatchMaxSize).
+			Msg("write batching enabled")
+
+		ds = proxy.NewWriteBatchingProxy(ds, opts.WriteBatchMaxDelay, opts.WriteBatchMaxSize)
+	}
+
+	if opts.QueryMaxRows > 0 || opts.QueryMaxDuration > 0 {
+		log.Info().
+			Uint64("maxRows", opts.QueryMaxRows).
+			Stringer("maxDuration", opts.QueryMaxDuration).
+			Msg("relationship query limits enabled")
+
+		ds = proxy.NewQueryLimitsProxy(ds, opts.QueryMaxRows, opts.QueryMaxDuration)
+	}
+
 	if opts.RequestHedgingEnabled {
 		log.Info().
 			Stringer("initialSlowRequest", opts.RequestHedgingInitialSlowValue).
@@ -219,7 +460,7 @@ func NewDatastore(ctx context.Context, options ...ConfigOption) (datastore.Datas
 		)
 	}
 
-	if opts.ReadOnly {
+	if opts.ReadOnly && !opts.ReadOnlyToggleEnabled {
 		log.Warn().Msg("setting the datastore to read-only")
 		ds = proxy.NewReadonlyDatastore(ds)
 	}
@@ -227,6 +468,12 @@ func NewDatastore(ctx context.Context, options ...ConfigOption) (datastore.Datas
 }
 
 func newCRDBDatastore(opts Config) (datastore.Datastore, error) {
+	// The follower read timestamp already lags by as much as is needed for follower reads.
+	followerReadDelay := opts.FollowerReadDelay
+	if opts.FollowerReads {
+		followerReadDelay = 0
+	}
+
 	return crdb.NewCRDBDatastore(
 		opts.URI,
 		crdb.GCWindow(opts.GCWindow),
@@ -237,11 +484,16 @@ func newCRDBDatastore(opts Config) (datastore.Datastore, error) {
 		crdb.MaxOpenConns(opts.MaxOpenConns),
 		crdb.MinOpenConns(opts.MinOpenConns),
 		crdb.SplitAtUsersetCount(opts.SplitQueryCount),
-		crdb.FollowerReadDelay(opts.FollowerReadDelay),
+		crdb.FollowerReadDelay(followerReadDelay),
+		crdb.FollowerReads(opts.FollowerReads),
 		crdb.MaxRetries(uint8(opts.MaxRetries)),
+		crdb.RetryInitialBackoff(opts.RetryInitialBackoff),
+		crdb.RetryMaxBackoff(opts.RetryMaxBackoff),
 		crdb.OverlapKey(opts.OverlapKey),
 		crdb.OverlapStrategy(opts.OverlapStrategy),
+		crdb.NamespaceRegions(opts.NamespaceRegions),
 		crdb.WatchBufferLength(opts.WatchBufferLength),
+		crdb.WatchResolvedInterval(opts.WatchResolvedInterval),
 		crdb.DisableStats(opts.DisableStats),
 		crdb.WithEnablePrometheusStats(opts.EnableDatastoreMetrics),
 	)
@@ -260,25 +512,86 @@ func newPostgresDatastore(opts Config) (datastore.Datastore, error) {
 		postgres.HealthCheckPeriod(opts.HealthCheckPeriod),
 		postgres.GCInterval(opts.GCInterval),
 		postgres.GCMaxOperationTime(opts.GCMaxOperationTime),
+		postgres.GCBatchSize(opts.GCBatchSize),
+		postgres.GCBatchDelay(opts.GCBatchDelay),
 		postgres.EnableTracing(),
 		postgres.WatchBufferLength(opts.WatchBufferLength),
 		postgres.WithEnablePrometheusStats(opts.EnableDatastoreMetrics),
 		postgres.MaxRetries(uint8(opts.MaxRetries)),
+		postgres.RetryInitialBackoff(opts.RetryInitialBackoff),
+		postgres.RetryMaxBackoff(opts.RetryMaxBackoff),
 		postgres.MigrationPhase(opts.MigrationPhase),
+		postgres.ReadReplicaURLs(opts.ReadReplicaURIs...),
+		postgres.ReadTargetSessionAttrs(opts.ReadTargetSessionAttrs),
+		postgres.WriteTargetSessionAttrs(opts.WriteTargetSessionAttrs),
+		postgres.StatementCacheMode(opts.StatementCacheMode),
+		postgres.SlowQueryThreshold(opts.SlowQueryThreshold),
+		postgres.SlowQueryExplainAnalyze(opts.SlowQueryExplainAnalyze),
+		postgres.TransactionPooling(opts.TransactionPooling),
+		postgres.YugabyteDB(opts.PostgresYugabyte),
+		postgres.WatchMode(opts.PostgresWatchMode),
+		postgres.AuthMethod(opts.PostgresAuthMethod),
+		postgres.AWSRegion(opts.PostgresAWSRegion),
+		postgres.ClientCertificate(opts.PostgresClientCertPath, opts.PostgresClientKeyPath),
+		postgres.RootCertificate(opts.PostgresRootCertPath),
+		postgres.ReadStatementTimeout(opts.ReadStatementTimeout),
+		postgres.ReadLockTimeout(opts.ReadLockTimeout),
+		postgres.WriteStatementTimeout(opts.WriteStatementTimeout),
+		postgres.WriteLockTimeout(opts.WriteLockTimeout),
+		postgres.GCStatementTimeout(opts.GCStatementTimeout),
+		postgres.GCLockTimeout(opts.GCLockTimeout),
+	}
+
+	if opts.StatementCacheCapacity > 0 {
+		pgOpts = append(pgOpts, postgres.StatementCacheCapacity(opts.StatementCacheCapacity))
+	}
+
+	// The pool specific options are only applied when set, so that the shared options apply otherwise.
+	if opts.ReadMaxOpenConns > 0 {
+		pgOpts = append(pgOpts, postgres.ReadMaxOpenConns(opts.ReadMaxOpenConns))
+	}
+	if opts.ReadMinOpenConns > 0 {
+		pgOpts = append(pgOpts, postgres.ReadMinOpenConns(opts.ReadMinOpenConns))
+	}
+	if opts.ReadMaxLifetime > 0 {
+		pgOpts = append(pgOpts, postgres.ReadConnMaxLifetime(opts.ReadMaxLifetime))
+	}
+	if opts.ReadMaxIdleTime > 0 {
+		pgOpts = append(pgOpts, postgres.ReadConnMaxIdleTime(opts.ReadMaxIdleTime))
+	}
+	if opts.WriteMaxOpenConns > 0 {
+		pgOpts = append(pgOpts, postgres.WriteMaxOpenConns(opts.WriteMaxOpenConns))
+	}
+	if opts.WriteMinOpenConns > 0 {
+		pgOpts = append(pgOpts, postgres.WriteMinOpenConns(opts.WriteMinOpenConns))
+	}
+	if opts.WriteMaxLifetime > 0 {
+		pgOpts = append(pgOpts, postgres.WriteConnMaxLifetime(opts.WriteMaxLifetime))
+	}
+	if opts.WriteMaxIdleTime > 0 {
+		pgOpts = append(pgOpts, postgres.WriteConnMaxIdleTime(opts.WriteMaxIdleTime))
 	}
 	return postgres.NewPostgresDatastore(opts.URI, pgOpts...)
 }
 
 func newSpannerDatastore(opts Config) (datastore.Datastore, error) {
+	// Bounded-staleness reads already lag by as much as is needed to be served by a replica.
+	followerReadDelay := opts.FollowerReadDelay
+	if opts.SpannerMaxStaleness > 0 {
+		followerReadDelay = 0
+	}
+
 	return spanner.NewSpannerDatastore(
 		opts.URI,
-		spanner.FollowerReadDelay(opts.FollowerReadDelay),
+		spanner.FollowerReadDelay(followerReadDelay),
+		spanner.MaxStaleness(opts.SpannerMaxStaleness),
 		spanner.GCInterval(opts.GCInterval),
 		spanner.GCWindow(opts.GCWindow),
 		spanner.GCEnabled(!opts.ReadOnly),
 		spanner.CredentialsFile(opts.SpannerCredentialsFile),
 		spanner.WatchBufferLength(opts.WatchBufferLength),
 		spanner.EmulatorHost(opts.SpannerEmulatorHost),
+		spanner.WatchMode(opts.SpannerWatchMode),
 	)
 }
 
@@ -297,13 +610,112 @@ func newMySQLDatastore(opts Config) (datastore.Datastore, error) {
 		mysql.WatchBufferLength(opts.WatchBufferLength),
 		mysql.WithEnablePrometheusStats(opts.EnableDatastoreMetrics),
 		mysql.MaxRetries(uint8(opts.MaxRetries)),
+		mysql.RetryInitialBackoff(opts.RetryInitialBackoff),
+		mysql.RetryMaxBackoff(opts.RetryMaxBackoff),
 		mysql.OverrideLockWaitTimeout(1),
 		mysql.SplitAtUsersetCount(opts.SplitQueryCount),
+		mysql.VitessCompatibility(opts.VitessCompatibility),
+		mysql.TiDBCompatibility(opts.TiDBCompatibility),
+		mysql.WatchMode(opts.MySQLWatchMode),
 	}
 	return mysql.NewMySQLDatastore(opts.URI, mysqlOpts...)
 }
 
+func newSQLiteDatastore(opts Config) (datastore.Datastore, error) {
+	sqliteOpts := []sqlite.Option{
+		sqlite.GCInterval(opts.GCInterval),
+		sqlite.GCWindow(opts.GCWindow),
+		sqlite.GCEnabled(!opts.ReadOnly),
+		sqlite.GCMaxOperationTime(opts.GCMaxOperationTime),
+		sqlite.ConnMaxIdleTime(opts.MaxIdleTime),
+		sqlite.MaxOpenConns(opts.MaxOpenConns),
+		sqlite.RevisionQuantization(opts.RevisionQuantization),
+		sqlite.WatchBufferLength(opts.WatchBufferLength),
+		sqlite.WithEnablePrometheusStats(opts.EnableDatastoreMetrics),
+		sqlite.MaxRetries(uint8(opts.MaxRetries)),
+		sqlite.RetryInitialBackoff(opts.RetryInitialBackoff),
+		sqlite.RetryMaxBackoff(opts.RetryMaxBackoff),
+		sqlite.BusyTimeout(opts.SQLiteBusyTimeout),
+		sqlite.SplitAtUsersetCount(opts.SplitQueryCount),
+	}
+	return sqlite.NewSQLiteDatastore(opts.URI, sqliteOpts...)
+}
+
+func newCassandraDatastore(opts Config) (datastore.Datastore, error) {
+	cassandraOpts := []cassandra.Option{
+		cassandra.GCInterval(opts.GCInterval),
+		cassandra.GCWindow(opts.GCWindow),
+		cassandra.GCEnabled(!opts.ReadOnly),
+		cassandra.GCMaxOperationTime(opts.GCMaxOperationTime),
+		cassandra.RevisionQuantization(opts.RevisionQuantization),
+		cassandra.WatchBufferLength(opts.WatchBufferLength),
+		cassandra.WatchLag(opts.CassandraWatchLag),
+		cassandra.WriteConcurrency(opts.CassandraWriteConcurrency),
+		cassandra.WithEnablePrometheusStats(opts.EnableDatastoreMetrics),
+		cassandra.MaxRetries(uint8(opts.MaxRetries)),
+		cassandra.RetryInitialBackoff(opts.RetryInitialBackoff),
+		cassandra.RetryMaxBackoff(opts.RetryMaxBackoff),
+	}
+	return cassandra.NewCassandraDatastore(opts.URI, cassandraOpts...)
+}
+
+func newRedisDatastore(opts Config) (datastore.Datastore, error) {
+	log.Warn().Msg("redis datastore is only as durable as its server and is intended for testing")
+	redisOpts := []redis.Option{
+		redis.GCInterval(opts.GCInterval),
+		redis.GCWindow(opts.GCWindow),
+		redis.GCEnabled(!opts.ReadOnly),
+		redis.GCMaxOperationTime(opts.GCMaxOperationTime),
+		redis.RevisionQuantization(opts.RevisionQuantization),
+		redis.WatchBufferLength(opts.WatchBufferLength),
+		redis.WithEnablePrometheusStats(opts.EnableDatastoreMetrics),
+		redis.MaxRetries(uint8(opts.MaxRetries)),
+		redis.RetryInitialBackoff(opts.RetryInitialBackoff),
+		redis.RetryMaxBackoff(opts.RetryMaxBackoff),
+	}
+	return redis.NewRedisDatastore(opts.URI, redisOpts...)
+}
+
+func newBadgerDatastore(opts Config) (datastore.Datastore, error) {
+	badgerOpts := []badger.Option{
+		badger.GCInterval(opts.GCInterval),
+		badger.GCWindow(opts.GCWindow),
+		badger.GCEnabled(!opts.ReadOnly),
+		badger.GCMaxOperationTime(opts.GCMaxOperationTime),
+		badger.RevisionQuantization(opts.RevisionQuantization),
+		badger.WatchBufferLength(opts.WatchBufferLength),
+		badger.WithEnablePrometheusStats(opts.EnableDatastoreMetrics),
+		badger.MaxRetries(uint8(opts.MaxRetries)),
+		badger.RetryInitialBackoff(opts.RetryInitialBackoff),
+		badger.RetryMaxBackoff(opts.RetryMaxBackoff),
+	}
+	return badger.NewBadgerDatastore(opts.URI, badgerOpts...)
+}
+
+func newObjectStoreDatastore(opts Config) (datastore.Datastore, error) {
+	log.Warn().Msg("objectstore datastore is read-only and serves its snapshot bundle at a fixed revision")
+	return objectstore.NewObjectStoreDatastore(opts.URI)
+}
+
 func newMemoryDatstore(opts Config) (datastore.Datastore, error) {
-	log.Warn().Msg("in-memory datastore is not persistent and not feasible to run in a high availability fashion")
-	return memdb.NewMemdbDatastore(opts.WatchBufferLength, opts.RevisionQuantization, opts.GCWindow)
+	if opts.MemorySnapshotFile == "" {
+		log.Warn().Msg("in-memory datastore is not persistent and not feasible to run in a high availability fashion")
+	} else {
+		log.Warn().Str("path", opts.MemorySnapshotFile).Msg("in-memory datastore is persisted only to a snapshot file and not feasible to run in a high availability fashion")
+	}
+	return memdb.NewMemdbDatastore(opts.WatchBufferLength, opts.RevisionQuantization, opts.GCWindow,
+		memdb.SnapshotFile(opts.MemorySnapshotFile),
+		memdb.SnapshotInterval(opts.MemorySnapshotInterval),
+		memdb.MaxRelationships(opts.MemoryMaxRelationships),
+		memdb.MaxRevisions(opts.MemoryMaxRevisions),
+	)
+}
+
+func newRemoteDatastore(opts Config) (datastore.Datastore, error) {
+	return remote.NewRemoteDatastore(
+		opts.URI,
+		remote.WatchBufferLength(opts.WatchBufferLength),
+		remote.CACertPath(opts.RemoteCAPath),
+		remote.PresharedKey(opts.RemotePresharedKey),
+	)
 }
@@ -28,6 +28,7 @@ func (c *Config) ToOption() ConfigOption {
 		to.MinOpenConns = c.MinOpenConns
 		to.SplitQueryCount = c.SplitQueryCount
 		to.ReadOnly = c.ReadOnly
+		to.ReadOnlyToggleEnabled = c.ReadOnlyToggleEnabled
 		to.EnableDatastoreMetrics = c.EnableDatastoreMetrics
 		to.DisableStats = c.DisableStats
 		to.BootstrapFiles = c.BootstrapFiles
@@ -37,16 +38,74 @@ func (c *Config) ToOption() ConfigOption {
 		to.RequestHedgingInitialSlowValue = c.RequestHedgingInitialSlowValue
 		to.RequestHedgingMaxRequests = c.RequestHedgingMaxRequests
 		to.RequestHedgingQuantile = c.RequestHedgingQuantile
+		to.WriteBatchMaxDelay = c.WriteBatchMaxDelay
+		to.WriteBatchMaxSize = c.WriteBatchMaxSize
+		to.QueryMaxRows = c.QueryMaxRows
+		to.QueryMaxDuration = c.QueryMaxDuration
+		to.RetryInitialBackoff = c.RetryInitialBackoff
+		to.RetryMaxBackoff = c.RetryMaxBackoff
 		to.FollowerReadDelay = c.FollowerReadDelay
+		to.FollowerReads = c.FollowerReads
 		to.MaxRetries = c.MaxRetries
 		to.OverlapKey = c.OverlapKey
 		to.OverlapStrategy = c.OverlapStrategy
+		to.WatchResolvedInterval = c.WatchResolvedInterval
+		to.NamespaceRegions = c.NamespaceRegions
 		to.HealthCheckPeriod = c.HealthCheckPeriod
 		to.GCInterval = c.GCInterval
 		to.GCMaxOperationTime = c.GCMaxOperationTime
+		to.GCBatchSize = c.GCBatchSize
+		to.GCBatchDelay = c.GCBatchDelay
+		to.ReadReplicaURIs = c.ReadReplicaURIs
+		to.StatementCacheMode = c.StatementCacheMode
+		to.StatementCacheCapacity = c.StatementCacheCapacity
+		to.SlowQueryThreshold = c.SlowQueryThreshold
+		to.SlowQueryExplainAnalyze = c.SlowQueryExplainAnalyze
+		to.TransactionPooling = c.TransactionPooling
+		to.PostgresWatchMode = c.PostgresWatchMode
+		to.PostgresYugabyte = c.PostgresYugabyte
+		to.PostgresAuthMethod = c.PostgresAuthMethod
+		to.PostgresAWSRegion = c.PostgresAWSRegion
+		to.PostgresClientCertPath = c.PostgresClientCertPath
+		to.PostgresClientKeyPath = c.PostgresClientKeyPath
+		to.PostgresRootCertPath = c.PostgresRootCertPath
+		to.ReadStatementTimeout = c.ReadStatementTimeout
+		to.ReadLockTimeout = c.ReadLockTimeout
+		to.WriteStatementTimeout = c.WriteStatementTimeout
+		to.WriteLockTimeout = c.WriteLockTimeout
+		to.GCStatementTimeout = c.GCStatementTimeout
+		to.GCLockTimeout = c.GCLockTimeout
+		to.ReadMaxOpenConns = c.ReadMaxOpenConns
+		to.ReadMinOpenConns = c.ReadMinOpenConns
+		to.ReadMaxLifetime = c.ReadMaxLifetime
+		to.ReadMaxIdleTime = c.ReadMaxIdleTime
+		to.ReadTargetSessionAttrs = c.ReadTargetSessionAttrs
+		to.WriteMaxOpenConns = c.WriteMaxOpenConns
+		to.WriteMinOpenConns = c.WriteMinOpenConns
+		to.WriteMaxLifetime = c.WriteMaxLifetime
+		to.WriteMaxIdleTime = c.WriteMaxIdleTime
+		to.WriteTargetSessionAttrs = c.WriteTargetSessionAttrs
 		to.SpannerCredentialsFile = c.SpannerCredentialsFile
 		to.SpannerEmulatorHost = c.SpannerEmulatorHost
+		to.SpannerMaxStaleness = c.SpannerMaxStaleness
+		to.SpannerWatchMode = c.SpannerWatchMode
 		to.TablePrefix = c.TablePrefix
+		to.VitessCompatibility = c.VitessCompatibility
+		to.TiDBCompatibility = c.TiDBCompatibility
+		to.MySQLWatchMode = c.MySQLWatchMode
+		to.SQLiteBusyTimeout = c.SQLiteBusyTimeout
+		to.CassandraWatchLag = c.CassandraWatchLag
+		to.CassandraWriteConcurrency = c.CassandraWriteConcurrency
+		to.MemorySnapshotFile = c.MemorySnapshotFile
+		to.MemorySnapshotInterval = c.MemorySnapshotInterval
+		to.MemoryMaxRelationships = c.MemoryMaxRelationships
+		to.MemoryMaxRevisions = c.MemoryMaxRevisions
+		to.RemoteCAPath = c.RemoteCAPath
+		to.RemotePresharedKey = c.RemotePresharedKey
+		to.CaveatContextKMSKeyID = c.CaveatContextKMSKeyID
+		to.DualWriteEngine = c.DualWriteEngine
+		to.DualWriteURI = c.DualWriteURI
+		to.DualWriteCutover = c.DualWriteCutover
 		to.WatchBufferLength = c.WatchBufferLength
 		to.MigrationPhase = c.MigrationPhase
 	}
@@ -137,6 +196,13 @@ func WithReadOnly(readOnly bool) ConfigOption {
 	}
 }
 
+// WithReadOnlyToggleEnabled returns an option that can set ReadOnlyToggleEnabled on a Config
+func WithReadOnlyToggleEnabled(readOnlyToggleEnabled bool) ConfigOption {
+	return func(c *Config) {
+		c.ReadOnlyToggleEnabled = readOnlyToggleEnabled
+	}
+}
+
 // WithEnableDatastoreMetrics returns an option that can set EnableDatastoreMetrics on a Config
 func WithEnableDatastoreMetrics(enableDatastoreMetrics bool) ConfigOption {
 	return func(c *Config) {
@@ -207,6 +273,48 @@ func WithRequestHedgingQuantile(requestHedgingQuantile float64) ConfigOption {
 	}
 }
 
+// WithWriteBatchMaxDelay returns an option that can set WriteBatchMaxDelay on a Config
+func WithWriteBatchMaxDelay(writeBatchMaxDelay time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.WriteBatchMaxDelay = writeBatchMaxDelay
+	}
+}
+
+// WithWriteBatchMaxSize returns an option that can set WriteBatchMaxSize on a Config
+func WithWriteBatchMaxSize(writeBatchMaxSize int) ConfigOption {
+	return func(c *Config) {
+		c.WriteBatchMaxSize = writeBatchMaxSize
+	}
+}
+
+// WithQueryMaxRows returns an option that can set QueryMaxRows on a Config
+func WithQueryMaxRows(queryMaxRows uint64) ConfigOption {
+	return func(c *Config) {
+		c.QueryMaxRows = queryMaxRows
+	}
+}
+
+// WithQueryMaxDuration returns an option that can set QueryMaxDuration on a Config
+func WithQueryMaxDuration(queryMaxDuration time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.QueryMaxDuration = queryMaxDuration
+	}
+}
+
+// WithRetryInitialBackoff returns an option that can set RetryInitialBackoff on a Config
+func WithRetryInitialBackoff(retryInitialBackoff time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.RetryInitialBackoff = retryInitialBackoff
+	}
+}
+
+// WithRetryMaxBackoff returns an option that can set RetryMaxBackoff on a Config
+func WithRetryMaxBackoff(retryMaxBackoff time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.RetryMaxBackoff = retryMaxBackoff
+	}
+}
+
 // WithFollowerReadDelay returns an option that can set FollowerReadDelay on a Config
 func WithFollowerReadDelay(followerReadDelay time.Duration) ConfigOption {
 	return func(c *Config) {
@@ -214,6 +322,13 @@ func WithFollowerReadDelay(followerReadDelay time.Duration) ConfigOption {
 	}
 }
 
+// WithFollowerReads returns an option that can set FollowerReads on a Config
+func WithFollowerReads(followerReads bool) ConfigOption {
+	return func(c *Config) {
+		c.FollowerReads = followerReads
+	}
+}
+
 // WithMaxRetries returns an option that can set MaxRetries on a Config
 func WithMaxRetries(maxRetries int) ConfigOption {
 	return func(c *Config) {
@@ -235,6 +350,27 @@ func WithOverlapStrategy(overlapStrategy string) ConfigOption {
 	}
 }
 
+// WithWatchResolvedInterval returns an option that can set WatchResolvedInterval on a Config
+func WithWatchResolvedInterval(watchResolvedInterval time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.WatchResolvedInterval = watchResolvedInterval
+	}
+}
+
+// WithNamespaceRegions returns an option that can append NamespaceRegionss to Config.NamespaceRegions
+func WithNamespaceRegions(key string, value string) ConfigOption {
+	return func(c *Config) {
+		c.NamespaceRegions[key] = value
+	}
+}
+
+// SetNamespaceRegions returns an option that can set NamespaceRegions on a Config
+func SetNamespaceRegions(namespaceRegions map[string]string) ConfigOption {
+	return func(c *Config) {
+		c.NamespaceRegions = namespaceRegions
+	}
+}
+
 // WithHealthCheckPeriod returns an option that can set HealthCheckPeriod on a Config
 func WithHealthCheckPeriod(healthCheckPeriod time.Duration) ConfigOption {
 	return func(c *Config) {
@@ -256,6 +392,230 @@ func WithGCMaxOperationTime(gCMaxOperationTime time.Duration) ConfigOption {
 	}
 }
 
+// WithGCBatchSize returns an option that can set GCBatchSize on a Config
+func WithGCBatchSize(gCBatchSize uint64) ConfigOption {
+	return func(c *Config) {
+		c.GCBatchSize = gCBatchSize
+	}
+}
+
+// WithGCBatchDelay returns an option that can set GCBatchDelay on a Config
+func WithGCBatchDelay(gCBatchDelay time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.GCBatchDelay = gCBatchDelay
+	}
+}
+
+// WithReadReplicaURIs returns an option that can append ReadReplicaURIss to Config.ReadReplicaURIs
+func WithReadReplicaURIs(readReplicaURIs string) ConfigOption {
+	return func(c *Config) {
+		c.ReadReplicaURIs = append(c.ReadReplicaURIs, readReplicaURIs)
+	}
+}
+
+// SetReadReplicaURIs returns an option that can set ReadReplicaURIs on a Config
+func SetReadReplicaURIs(readReplicaURIs []string) ConfigOption {
+	return func(c *Config) {
+		c.ReadReplicaURIs = readReplicaURIs
+	}
+}
+
+// WithStatementCacheMode returns an option that can set StatementCacheMode on a Config
+func WithStatementCacheMode(statementCacheMode string) ConfigOption {
+	return func(c *Config) {
+		c.StatementCacheMode = statementCacheMode
+	}
+}
+
+// WithStatementCacheCapacity returns an option that can set StatementCacheCapacity on a Config
+func WithStatementCacheCapacity(statementCacheCapacity int) ConfigOption {
+	return func(c *Config) {
+		c.StatementCacheCapacity = statementCacheCapacity
+	}
+}
+
+// WithSlowQueryThreshold returns an option that can set SlowQueryThreshold on a Config
+func WithSlowQueryThreshold(slowQueryThreshold time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.SlowQueryThreshold = slowQueryThreshold
+	}
+}
+
+// WithSlowQueryExplainAnalyze returns an option that can set SlowQueryExplainAnalyze on a Config
+func WithSlowQueryExplainAnalyze(slowQueryExplainAnalyze bool) ConfigOption {
+	return func(c *Config) {
+		c.SlowQueryExplainAnalyze = slowQueryExplainAnalyze
+	}
+}
+
+// WithTransactionPooling returns an option that can set TransactionPooling on a Config
+func WithTransactionPooling(transactionPooling bool) ConfigOption {
+	return func(c *Config) {
+		c.TransactionPooling = transactionPooling
+	}
+}
+
+// WithPostgresWatchMode returns an option that can set PostgresWatchMode on a Config
+func WithPostgresWatchMode(postgresWatchMode string) ConfigOption {
+	return func(c *Config) {
+		c.PostgresWatchMode = postgresWatchMode
+	}
+}
+
+// WithPostgresYugabyte returns an option that can set PostgresYugabyte on a Config
+func WithPostgresYugabyte(postgresYugabyte bool) ConfigOption {
+	return func(c *Config) {
+		c.PostgresYugabyte = postgresYugabyte
+	}
+}
+
+// WithPostgresAuthMethod returns an option that can set PostgresAuthMethod on a Config
+func WithPostgresAuthMethod(postgresAuthMethod string) ConfigOption {
+	return func(c *Config) {
+		c.PostgresAuthMethod = postgresAuthMethod
+	}
+}
+
+// WithPostgresAWSRegion returns an option that can set PostgresAWSRegion on a Config
+func WithPostgresAWSRegion(postgresAWSRegion string) ConfigOption {
+	return func(c *Config) {
+		c.PostgresAWSRegion = postgresAWSRegion
+	}
+}
+
+// WithPostgresClientCertPath returns an option that can set PostgresClientCertPath on a Config
+func WithPostgresClientCertPath(postgresClientCertPath string) ConfigOption {
+	return func(c *Config) {
+		c.PostgresClientCertPath = postgresClientCertPath
+	}
+}
+
+// WithPostgresClientKeyPath returns an option that can set PostgresClientKeyPath on a Config
+func WithPostgresClientKeyPath(postgresClientKeyPath string) ConfigOption {
+	return func(c *Config) {
+		c.PostgresClientKeyPath = postgresClientKeyPath
+	}
+}
+
+// WithPostgresRootCertPath returns an option that can set PostgresRootCertPath on a Config
+func WithPostgresRootCertPath(postgresRootCertPath string) ConfigOption {
+	return func(c *Config) {
+		c.PostgresRootCertPath = postgresRootCertPath
+	}
+}
+
+// WithReadStatementTimeout returns an option that can set ReadStatementTimeout on a Config
+func WithReadStatementTimeout(readStatementTimeout time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.ReadStatementTimeout = readStatementTimeout
+	}
+}
+
+// WithReadLockTimeout returns an option that can set ReadLockTimeout on a Config
+func WithReadLockTimeout(readLockTimeout time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.ReadLockTimeout = readLockTimeout
+	}
+}
+
+// WithWriteStatementTimeout returns an option that can set WriteStatementTimeout on a Config
+func WithWriteStatementTimeout(writeStatementTimeout time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.WriteStatementTimeout = writeStatementTimeout
+	}
+}
+
+// WithWriteLockTimeout returns an option that can set WriteLockTimeout on a Config
+func WithWriteLockTimeout(writeLockTimeout time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.WriteLockTimeout = writeLockTimeout
+	}
+}
+
+// WithGCStatementTimeout returns an option that can set GCStatementTimeout on a Config
+func WithGCStatementTimeout(gCStatementTimeout time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.GCStatementTimeout = gCStatementTimeout
+	}
+}
+
+// WithGCLockTimeout returns an option that can set GCLockTimeout on a Config
+func WithGCLockTimeout(gCLockTimeout time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.GCLockTimeout = gCLockTimeout
+	}
+}
+
+// WithReadMaxOpenConns returns an option that can set ReadMaxOpenConns on a Config
+func WithReadMaxOpenConns(readMaxOpenConns int) ConfigOption {
+	return func(c *Config) {
+		c.ReadMaxOpenConns = readMaxOpenConns
+	}
+}
+
+// WithReadMinOpenConns returns an option that can set ReadMinOpenConns on a Config
+func WithReadMinOpenConns(readMinOpenConns int) ConfigOption {
+	return func(c *Config) {
+		c.ReadMinOpenConns = readMinOpenConns
+	}
+}
+
+// WithReadMaxLifetime returns an option that can set ReadMaxLifetime on a Config
+func WithReadMaxLifetime(readMaxLifetime time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.ReadMaxLifetime = readMaxLifetime
+	}
+}
+
+// WithReadMaxIdleTime returns an option that can set ReadMaxIdleTime on a Config
+func WithReadMaxIdleTime(readMaxIdleTime time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.ReadMaxIdleTime = readMaxIdleTime
+	}
+}
+
+// WithReadTargetSessionAttrs returns an option that can set ReadTargetSessionAttrs on a Config
+func WithReadTargetSessionAttrs(readTargetSessionAttrs string) ConfigOption {
+	return func(c *Config) {
+		c.ReadTargetSessionAttrs = readTargetSessionAttrs
+	}
+}
+
+// WithWriteMaxOpenConns returns an option that can set WriteMaxOpenConns on a Config
+func WithWriteMaxOpenConns(writeMaxOpenConns int) ConfigOption {
+	return func(c *Config) {
+		c.WriteMaxOpenConns = writeMaxOpenConns
+	}
+}
+
+// WithWriteMinOpenConns returns an option that can set WriteMinOpenConns on a Config
+func WithWriteMinOpenConns(writeMinOpenConns int) ConfigOption {
+	return func(c *Config) {
+		c.WriteMinOpenConns = writeMinOpenConns
+	}
+}
+
+// WithWriteMaxLifetime returns an option that can set WriteMaxLifetime on a Config
+func WithWriteMaxLifetime(writeMaxLifetime time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.WriteMaxLifetime = writeMaxLifetime
+	}
+}
+
+// WithWriteMaxIdleTime returns an option that can set WriteMaxIdleTime on a Config
+func WithWriteMaxIdleTime(writeMaxIdleTime time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.WriteMaxIdleTime = writeMaxIdleTime
+	}
+}
+
+// WithWriteTargetSessionAttrs returns an option that can set WriteTargetSessionAttrs on a Config
+func WithWriteTargetSessionAttrs(writeTargetSessionAttrs string) ConfigOption {
+	return func(c *Config) {
+		c.WriteTargetSessionAttrs = writeTargetSessionAttrs
+	}
+}
+
 // WithSpannerCredentialsFile returns an option that can set SpannerCredentialsFile on a Config
 func WithSpannerCredentialsFile(spannerCredentialsFile string) ConfigOption {
 	return func(c *Config) {
@@ -270,6 +630,20 @@ func WithSpannerEmulatorHost(spannerEmulatorHost string) ConfigOption {
 	}
 }
 
+// WithSpannerMaxStaleness returns an option that can set SpannerMaxStaleness on a Config
+func WithSpannerMaxStaleness(spannerMaxStaleness time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.SpannerMaxStaleness = spannerMaxStaleness
+	}
+}
+
+// WithSpannerWatchMode returns an option that can set SpannerWatchMode on a Config
+func WithSpannerWatchMode(spannerWatchMode string) ConfigOption {
+	return func(c *Config) {
+		c.SpannerWatchMode = spannerWatchMode
+	}
+}
+
 // WithTablePrefix returns an option that can set TablePrefix on a Config
 func WithTablePrefix(tablePrefix string) ConfigOption {
 	return func(c *Config) {
@@ -277,6 +651,118 @@ func WithTablePrefix(tablePrefix string) ConfigOption {
 	}
 }
 
+// WithVitessCompatibility returns an option that can set VitessCompatibility on a Config
+func WithVitessCompatibility(vitessCompatibility bool) ConfigOption {
+	return func(c *Config) {
+		c.VitessCompatibility = vitessCompatibility
+	}
+}
+
+// WithTiDBCompatibility returns an option that can set TiDBCompatibility on a Config
+func WithTiDBCompatibility(tiDBCompatibility bool) ConfigOption {
+	return func(c *Config) {
+		c.TiDBCompatibility = tiDBCompatibility
+	}
+}
+
+// WithMySQLWatchMode returns an option that can set MySQLWatchMode on a Config
+func WithMySQLWatchMode(mySQLWatchMode string) ConfigOption {
+	return func(c *Config) {
+		c.MySQLWatchMode = mySQLWatchMode
+	}
+}
+
+// WithSQLiteBusyTimeout returns an option that can set SQLiteBusyTimeout on a Config
+func WithSQLiteBusyTimeout(sQLiteBusyTimeout time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.SQLiteBusyTimeout = sQLiteBusyTimeout
+	}
+}
+
+// WithCassandraWatchLag returns an option that can set CassandraWatchLag on a Config
+func WithCassandraWatchLag(cassandraWatchLag time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.CassandraWatchLag = cassandraWatchLag
+	}
+}
+
+// WithCassandraWriteConcurrency returns an option that can set CassandraWriteConcurrency on a Config
+func WithCassandraWriteConcurrency(cassandraWriteConcurrency int) ConfigOption {
+	return func(c *Config) {
+		c.CassandraWriteConcurrency = cassandraWriteConcurrency
+	}
+}
+
+// WithMemorySnapshotFile returns an option that can set MemorySnapshotFile on a Config
+func WithMemorySnapshotFile(memorySnapshotFile string) ConfigOption {
+	return func(c *Config) {
+		c.MemorySnapshotFile = memorySnapshotFile
+	}
+}
+
+// WithMemorySnapshotInterval returns an option that can set MemorySnapshotInterval on a Config
+func WithMemorySnapshotInterval(memorySnapshotInterval time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.MemorySnapshotInterval = memorySnapshotInterval
+	}
+}
+
+// WithMemoryMaxRelationships returns an option that can set MemoryMaxRelationships on a Config
+func WithMemoryMaxRelationships(memoryMaxRelationships uint64) ConfigOption {
+	return func(c *Config) {
+		c.MemoryMaxRelationships = memoryMaxRelationships
+	}
+}
+
+// WithMemoryMaxRevisions returns an option that can set MemoryMaxRevisions on a Config
+func WithMemoryMaxRevisions(memoryMaxRevisions int) ConfigOption {
+	return func(c *Config) {
+		c.MemoryMaxRevisions = memoryMaxRevisions
+	}
+}
+
+// WithRemoteCAPath returns an option that can set RemoteCAPath on a Config
+func WithRemoteCAPath(remoteCAPath string) ConfigOption {
+	return func(c *Config) {
+		c.RemoteCAPath = remoteCAPath
+	}
+}
+
+// WithRemotePresharedKey returns an option that can set RemotePresharedKey on a Config
+func WithRemotePresharedKey(remotePresharedKey string) ConfigOption {
+	return func(c *Config) {
+		c.RemotePresharedKey = remotePresharedKey
+	}
+}
+
+// WithCaveatContextKMSKeyID returns an option that can set CaveatContextKMSKeyID on a Config
+func WithCaveatContextKMSKeyID(caveatContextKMSKeyID string) ConfigOption {
+	return func(c *Config) {
+		c.CaveatContextKMSKeyID = caveatContextKMSKeyID
+	}
+}
+
+// WithDualWriteEngine returns an option that can set DualWriteEngine on a Config
+func WithDualWriteEngine(dualWriteEngine string) ConfigOption {
+	return func(c *Config) {
+		c.DualWriteEngine = dualWriteEngine
+	}
+}
+
+// WithDualWriteURI returns an option that can set DualWriteURI on a Config
+func WithDualWriteURI(dualWriteURI string) ConfigOption {
+	return func(c *Config) {
+		c.DualWriteURI = dualWriteURI
+	}
+}
+
+// WithDualWriteCutover returns an option that can set DualWriteCutover on a Config
+func WithDualWriteCutover(dualWriteCutover bool) ConfigOption {
+	return func(c *Config) {
+		c.DualWriteCutover = dualWriteCutover
+	}
+}
+
 // WithWatchBufferLength returns an option that can set WatchBufferLength on a Config
 func WithWatchBufferLength(watchBufferLength uint16) ConfigOption {
 	return func(c *Config) {
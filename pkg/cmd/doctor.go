@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jzelinskie/cobrautil/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/authzed/spicedb/internal/doctor"
+	"github.com/authzed/spicedb/pkg/cmd/datastore"
+	"github.com/authzed/spicedb/pkg/cmd/server"
+)
+
+func RegisterDoctorFlags(cmd *cobra.Command, config *server.Config) {
+	// The doctor accepts the same flags as serve, so that it diagnoses the exact configuration
+	// that would be used to run the server.
+	RegisterServeFlags(cmd, config)
+
+	// A missing preshared key is reported as a diagnostic rather than failing flag parsing.
+	delete(cmd.Flags().Lookup(PresharedKeyFlag).Annotations, cobra.BashCompOneRequiredFlag)
+
+	cmd.Flags().StringSlice("doctor-peer-addrs", []string{}, `HTTP(S) addresses of endpoints (such as the metrics endpoint) on dispatch peers used to check clock skew (e.g. "http://spicedb-1:9090/metrics")`)
+	cmd.Flags().Duration("doctor-max-clock-skew", 2*time.Second, "maximum clock skew allowed between this machine and dispatch peers")
+	cmd.Flags().Duration("doctor-cert-expiry-warning", 30*24*time.Hour, "warn about certificates expiring within this duration")
+	cmd.Flags().Duration("doctor-timeout", 30*time.Second, "maximum amount of time for all diagnostics to complete")
+}
+
+func NewDoctorCommand(programName string, config *server.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "doctor",
+		Short:   "diagnose the environment and configuration of SpiceDB",
+		Long:    "Checks datastore connectivity and migrations, clock skew across dispatch peers, TLS certificate expiry and misconfigured flags, reporting all findings in a single pass.",
+		PreRunE: server.DefaultPreRunE(programName),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(cmd.Context(), cobrautil.MustGetDuration(cmd, "doctor-timeout"))
+			defer cancel()
+
+			results := configResults(config)
+			results = append(results, datastoreResults(ctx, config.DatastoreConfig)...)
+			results = append(results, certificateResults(config, cobrautil.MustGetDuration(cmd, "doctor-cert-expiry-warning"))...)
+
+			maxSkew := cobrautil.MustGetDuration(cmd, "doctor-max-clock-skew")
+			client := &http.Client{Timeout: 5 * time.Second}
+			for _, addr := range cobrautil.MustGetStringSlice(cmd, "doctor-peer-addrs") {
+				results = append(results, doctor.CheckPeerClockSkew(ctx, client, addr, maxSkew))
+			}
+
+			failures, err := doctor.WriteReport(cmd.OutOrStdout(), results)
+			if err != nil {
+				return err
+			}
+			if failures > 0 {
+				return fmt.Errorf("found %d problem(s) with the environment or configuration", failures)
+			}
+			return nil
+		},
+		Args: cobra.ExactArgs(0),
+	}
+}
+
+func datastoreResults(ctx context.Context, dsConfig datastore.Config) []doctor.Result {
+	// Never write bootstrap data or run garbage collection as part of diagnostics.
+	dsConfig.BootstrapFiles = nil
+	dsConfig.ReadOnly = true
+	dsConfig.RequestHedgingEnabled = false
+
+	ds, err := datastore.NewDatastore(ctx, dsConfig.ToOption())
+	if err != nil {
+		return []doctor.Result{doctor.Failure("datastore connectivity", "unable to create datastore: %s", err)}
+	}
+	defer ds.Close()
+
+	return doctor.CheckDatastore(ctx, ds)
+}
+
+func certificateResults(config *server.Config, warnWithin time.Duration) []doctor.Result {
+	now := time.Now()
+	certs := []struct {
+		name string
+		path string
+	}{
+		{"grpc tls certificate", config.GRPCServer.TLSCertPath},
+		{"grpc client ca", config.GRPCServer.ClientCAPath},
+		{"http tls certificate", config.HTTPGateway.TLSCertPath},
+		{"dispatch tls certificate", config.DispatchServer.TLSCertPath},
+		{"dispatch client ca", config.DispatchServer.ClientCAPath},
+		{"dispatch upstream ca", config.DispatchUpstreamCAPath},
+		{"dispatch upstream tls certificate", config.DispatchUpstreamTLSCertPath},
+		{"dispatch cluster tls client ca", config.DispatchClusterTLSClientCAPath},
+		{"dashboard tls certificate", config.DashboardAPI.TLSCertPath},
+		{"metrics tls certificate", config.MetricsAPI.TLSCertPath},
+	}
+
+	var results []doctor.Result
+	for _, cert := range certs {
+		if cert.path == "" {
+			continue
+		}
+		results = append(results, doctor.CheckCertificateExpiry(cert.name, cert.path, now, warnWithin))
+	}
+	return results
+}
+
+func configResults(config *server.Config) []doctor.Result {
+	var results []doctor.Result
+
+	if len(config.PresharedKey) == 0 {
+		results = append(results, doctor.Failure("configuration", "--%s must be specified", PresharedKeyFlag))
+	}
+	for index, key := range config.PresharedKey {
+		if key == "" {
+			results = append(results, doctor.Failure("configuration", "preshared key #%d is empty", index+1))
+		}
+	}
+
+	servers := []struct {
+		prefix   string
+		enabled  bool
+		certPath string
+		keyPath  string
+	}{
+		{"grpc", config.GRPCServer.Enabled, config.GRPCServer.TLSCertPath, config.GRPCServer.TLSKeyPath},
+		{"dispatch-cluster", config.DispatchServer.Enabled, config.DispatchServer.TLSCertPath, config.DispatchServer.TLSKeyPath},
+		{"http", config.HTTPGateway.Enabled, config.HTTPGateway.TLSCertPath, config.HTTPGateway.TLSKeyPath},
+		{"dashboard", config.DashboardAPI.Enabled, config.DashboardAPI.TLSCertPath, config.DashboardAPI.TLSKeyPath},
+		{"metrics", config.MetricsAPI.Enabled, config.MetricsAPI.TLSCertPath, config.MetricsAPI.TLSKeyPath},
+	}
+	for _, srv := range servers {
+		if srv.enabled && (srv.certPath == "") != (srv.keyPath == "") {
+			results = append(results, doctor.Failure("configuration", "--%s-tls-cert-path and --%s-tls-key-path must be specified together", srv.prefix, srv.prefix))
+		}
+	}
+
+	if config.DispatchServer.Enabled && config.DispatchServer.TLSCertPath == "" {
+		results = append(results, doctor.Warning("configuration", "the dispatch cluster server is enabled without TLS"))
+	}
+
+	if (config.DispatchUpstreamTLSCertPath == "") != (config.DispatchUpstreamTLSKeyPath == "") {
+		results = append(results, doctor.Failure("configuration", "--dispatch-upstream-tls-cert-path and --dispatch-upstream-tls-key-path must be specified together"))
+	}
+
+	if config.DispatchServer.Enabled && config.DispatchClusterTLSClientCAPath == "" && config.DispatchServer.TLSCertPath != "" {
+		results = append(results, doctor.Warning("configuration", "the dispatch cluster server does not authenticate clients with mutual TLS; see --dispatch-cluster-tls-client-ca-path"))
+	}
+
+	if config.DispatchUpstreamAddr != "" && config.DispatchUpstreamCAPath == "" {
+		results = append(results, doctor.Warning("configuration", "--dispatch-upstream-addr is set without --dispatch-upstream-ca-path; TLS verification will use the system certificate pool"))
+	}
+
+	dsConfig := config.DatastoreConfig
+	if dsConfig.Engine == datastore.MemoryEngine {
+		results = append(results, doctor.Warning("configuration", "the in-memory datastore is not persistent and cannot be run in a high availability fashion"))
+	}
+
+	if dsConfig.GCWindow < dsConfig.RevisionQuantization {
+		results = append(results, doctor.Failure("configuration", "--datastore-gc-window (%s) must be greater than --datastore-revision-quantization-interval (%s)", dsConfig.GCWindow, dsConfig.RevisionQuantization))
+	}
+
+	if dsConfig.MinOpenConns > dsConfig.MaxOpenConns {
+		results = append(results, doctor.Failure("configuration", "--datastore-conn-min-open (%d) must not exceed --datastore-conn-max-open (%d)", dsConfig.MinOpenConns, dsConfig.MaxOpenConns))
+	}
+
+	if dsConfig.ReadOnly && len(dsConfig.BootstrapFiles) > 0 {
+		results = append(results, doctor.Failure("configuration", "bootstrap files cannot be applied to a read-only datastore"))
+	}
+
+	if len(results) == 0 {
+		results = append(results, doctor.OK("configuration", "no misconfigured flags found"))
+	}
+
+	return results
+}
@@ -9,10 +9,13 @@ import (
 	"github.com/jzelinskie/cobrautil/v2"
 	"github.com/spf13/cobra"
 
+	cassandramigrations "github.com/authzed/spicedb/internal/datastore/cassandra/migrations"
 	crdbmigrations "github.com/authzed/spicedb/internal/datastore/crdb/migrations"
 	mysqlmigrations "github.com/authzed/spicedb/internal/datastore/mysql/migrations"
+	pgxcommon "github.com/authzed/spicedb/internal/datastore/postgres/common"
 	"github.com/authzed/spicedb/internal/datastore/postgres/migrations"
 	spannermigrations "github.com/authzed/spicedb/internal/datastore/spanner/migrations"
+	sqlitemigrations "github.com/authzed/spicedb/internal/datastore/sqlite/migrations"
 	log "github.com/authzed/spicedb/internal/logging"
 	"github.com/authzed/spicedb/pkg/cmd/server"
 	"github.com/authzed/spicedb/pkg/datastore"
@@ -25,6 +28,18 @@ func RegisterMigrateFlags(cmd *cobra.Command) {
 	cmd.Flags().String("datastore-spanner-credentials", "", "path to service account key credentials file with access to the cloud spanner instance (omit to use application default credentials)")
 	cmd.Flags().String("datastore-spanner-emulator-host", "", "URI of spanner emulator instance used for development and testing (e.g. localhost:9010)")
 	cmd.Flags().String("datastore-mysql-table-prefix", "", "prefix to add to the name of all mysql database tables")
+	cmd.Flags().Bool("datastore-mysql-vitess", false, "migrate a Vitess keyspace reached through vtgate (mysql driver only)")
+	cmd.Flags().Bool("datastore-mysql-tidb", false, "migrate a TiDB database (mysql driver only)")
+	cmd.Flags().String("datastore-postgres-relationship-partitioning", "", `partitioning of the relationships table applied by the migration which adds it, as "namespace-hash:<partitions>" or "created-xid-range:<transactions per partition>" (postgres driver only)`)
+	cmd.Flags().String("datastore-postgres-relationship-distribution", "", `column by which the relationships table is distributed across the workers of a Citus cluster by the migration which adds it, as "namespace" or "object-id" (postgres driver only)`)
+	cmd.Flags().Bool("datastore-postgres-transaction-pooling", false, "hold no session state across transactions, as required behind a pooler in transaction pooling mode such as PgBouncer (postgres driver only)")
+	cmd.Flags().String("datastore-postgres-auth-method", "password", `how the connection authenticates ("password", "aws-iam" or "gcp-iam") (postgres driver only)`)
+	cmd.Flags().String("datastore-postgres-aws-region", "", "region of the RDS instance for aws-iam authentication, defaulting to that of the AWS configuration (postgres driver only)")
+	cmd.Flags().String("datastore-postgres-client-cert", "", "path to the PEM encoded client certificate presented for mutual TLS authentication (postgres driver only)")
+	cmd.Flags().String("datastore-postgres-client-key", "", "path to the PEM encoded key of the client certificate (postgres driver only)")
+	cmd.Flags().String("datastore-postgres-root-cert", "", "path to the PEM encoded certificate authority which must have signed the certificate of the server (postgres driver only)")
+	cmd.Flags().Duration("datastore-postgres-migration-statement-timeout", 0, "statement_timeout set on each migration transaction (0 keeps that of the connection) (postgres driver only)")
+	cmd.Flags().Duration("datastore-postgres-migration-lock-timeout", 0, "lock_timeout set on each migration transaction (0 keeps that of the connection) (postgres driver only)")
 	cmd.Flags().Uint64("migration-backfill-batch-size", 1000, "number of items to migrate per iteration of a datastore backfill")
 	cmd.Flags().Duration("migration-timeout", 1*time.Hour, "defines a timeout for the execution of the migration, set to 1 hour by default")
 }
@@ -59,11 +74,39 @@ func migrateRun(cmd *cobra.Command, args []string) error {
 		log.Info().Msg("migrating postgres datastore")
 
 		var err error
-		migrationDriver, err := migrations.NewAlembicPostgresDriver(dbURL)
+		partitioning := cobrautil.MustGetStringExpanded(cmd, "datastore-postgres-relationship-partitioning")
+		if _, err := migrations.ParsePartitioning(partitioning); err != nil {
+			return err
+		}
+		distribution := cobrautil.MustGetStringExpanded(cmd, "datastore-postgres-relationship-distribution")
+		if _, err := migrations.ParseDistribution(distribution); err != nil {
+			return err
+		}
+		ctx := context.WithValue(cmd.Context(), migrate.RelationshipPartitioning, partitioning)
+		ctx = context.WithValue(ctx, migrate.RelationshipDistribution, distribution)
+
+		driverOpts := []migrations.DriverOption{
+			migrations.WithTimeouts(
+				cobrautil.MustGetDuration(cmd, "datastore-postgres-migration-statement-timeout"),
+				cobrautil.MustGetDuration(cmd, "datastore-postgres-migration-lock-timeout"),
+			),
+			migrations.WithCredentials(pgxcommon.Credentials{
+				AuthMethod:     cobrautil.MustGetStringExpanded(cmd, "datastore-postgres-auth-method"),
+				AWSRegion:      cobrautil.MustGetStringExpanded(cmd, "datastore-postgres-aws-region"),
+				ClientCertPath: cobrautil.MustGetStringExpanded(cmd, "datastore-postgres-client-cert"),
+				ClientKeyPath:  cobrautil.MustGetStringExpanded(cmd, "datastore-postgres-client-key"),
+				RootCertPath:   cobrautil.MustGetStringExpanded(cmd, "datastore-postgres-root-cert"),
+			}),
+		}
+		if cobrautil.MustGetBool(cmd, "datastore-postgres-transaction-pooling") {
+			driverOpts = append(driverOpts, migrations.WithTransactionPooling())
+		}
+
+		migrationDriver, err := migrations.NewAlembicPostgresDriver(dbURL, driverOpts...)
 		if err != nil {
 			return fmt.Errorf("unable to create migration driver for %s: %w", datastoreEngine, err)
 		}
-		return runMigration(cmd.Context(), migrationDriver, migrations.DatabaseMigrations, args[0], timeout, migrationBatachSize)
+		return runMigration(ctx, migrationDriver, migrations.DatabaseMigrations, args[0], timeout, migrationBatachSize)
 	} else if datastoreEngine == "spanner" {
 		log.Info().Msg("migrating spanner datastore")
 
@@ -87,11 +130,47 @@ func migrateRun(cmd *cobra.Command, args []string) error {
 			log.Fatal().Msg(fmt.Sprintf("unable to get table prefix: %s", err))
 		}
 
-		migrationDriver, err := mysqlmigrations.NewMySQLDriverFromDSN(dbURL, tablePrefix)
+		vitess, err := cmd.Flags().GetBool("datastore-mysql-vitess")
+		if err != nil {
+			return fmt.Errorf("unable to get vitess compatibility: %w", err)
+		}
+
+		tidb, err := cmd.Flags().GetBool("datastore-mysql-tidb")
+		if err != nil {
+			return fmt.Errorf("unable to get tidb compatibility: %w", err)
+		}
+
+		newDriver := mysqlmigrations.NewMySQLDriverFromDSN
+		switch {
+		case vitess && tidb:
+			return fmt.Errorf("vitess and tidb compatibility cannot be used together")
+		case vitess:
+			newDriver = mysqlmigrations.NewVitessDriverFromDSN
+		case tidb:
+			newDriver = mysqlmigrations.NewTiDBDriverFromDSN
+		}
+
+		migrationDriver, err := newDriver(dbURL, tablePrefix)
 		if err != nil {
 			return fmt.Errorf("unable to create migration driver for %s: %w", datastoreEngine, err)
 		}
 		return runMigration(cmd.Context(), migrationDriver, mysqlmigrations.Manager, args[0], timeout, migrationBatachSize)
+	} else if datastoreEngine == "sqlite" {
+		log.Info().Msg("migrating sqlite datastore")
+
+		migrationDriver, err := sqlitemigrations.NewSQLiteDriver(dbURL)
+		if err != nil {
+			return fmt.Errorf("unable to create migration driver for %s: %w", datastoreEngine, err)
+		}
+		return runMigration(cmd.Context(), migrationDriver, sqlitemigrations.Manager, args[0], timeout, migrationBatachSize)
+	} else if datastoreEngine == "cassandra" {
+		log.Info().Msg("migrating cassandra datastore")
+
+		migrationDriver, err := cassandramigrations.NewCassandraDriver(dbURL)
+		if err != nil {
+			return fmt.Errorf("unable to create migration driver for %s: %w", datastoreEngine, err)
+		}
+		return runMigration(cmd.Context(), migrationDriver, cassandramigrations.Manager, args[0], timeout, migrationBatachSize)
 	}
 
 	return fmt.Errorf("cannot migrate datastore engine type: %s", datastoreEngine)
@@ -151,6 +230,10 @@ func HeadRevision(engine string) (string, error) {
 		return mysqlmigrations.Manager.HeadRevision()
 	case "spanner":
 		return spannermigrations.SpannerMigrations.HeadRevision()
+	case "sqlite":
+		return sqlitemigrations.Manager.HeadRevision()
+	case "cassandra":
+		return cassandramigrations.Manager.HeadRevision()
 	default:
 		return "", fmt.Errorf("cannot migrate datastore engine type: %s", engine)
 	}
@@ -2,14 +2,17 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/authzed/spicedb/internal/telemetry"
+	"github.com/authzed/spicedb/pkg/cache"
 	"github.com/authzed/spicedb/pkg/cmd/datastore"
 	"github.com/authzed/spicedb/pkg/cmd/server"
 	"github.com/authzed/spicedb/pkg/cmd/util"
+	"github.com/authzed/spicedb/pkg/consistent"
 )
 
 const PresharedKeyFlag = "grpc-preshared-key"
@@ -48,6 +51,7 @@ func RegisterServeFlags(cmd *cobra.Command, config *server.Config) {
 
 	// Flags for the datastore
 	datastore.RegisterDatastoreFlags(cmd, &config.DatastoreConfig)
+	cmd.Flags().StringToStringVar(&config.DatastoreRevisionQuantizationOverrides, "datastore-revision-quantization-overrides", nil, `revision quantization interval, and optionally the maximum staleness, for requests on the resources of definitions with the given prefixes, in place of --datastore-revision-quantization-interval (e.g. "billing/=1s,audit/=30s:10s"; the staleness defaults to 10% of the interval)`)
 
 	// Flags for the namespace cache
 	cmd.Flags().Duration("ns-cache-expiration", 1*time.Minute, "amount of time a namespace entry should remain cached")
@@ -82,12 +86,45 @@ func RegisterServeFlags(cmd *cobra.Command, config *server.Config) {
 	util.RegisterGRPCServerFlags(cmd.Flags(), &config.DispatchServer, "dispatch-cluster", "dispatch", ":50053", false)
 	server.RegisterCacheFlags(cmd.Flags(), "dispatch-cache", &config.DispatchCacheConfig, dispatchCacheDefaults)
 	server.RegisterCacheFlags(cmd.Flags(), "dispatch-cluster-cache", &config.ClusterDispatchCacheConfig, dispatchClusterCacheDefaults)
+	cmd.Flags().BoolVar(&config.DispatchCacheNegativeResultsDisabled, "dispatch-cache-negative-results-disabled", false, "disables caching of check results in which no resource has the permission")
+	cmd.Flags().DurationVar(&config.DispatchCacheNegativeResultTTL, "dispatch-cache-negative-result-ttl", 0, "time after which cached check results in which no resource has the permission expire (0 keeps them as long as other results)")
+	cmd.Flags().BoolVar(&config.DispatchCacheCaveatContextEnabled, "dispatch-cache-caveat-context-enabled", false, "enables caching the results of checks with caveats evaluated with a fully-specified caveat context, keyed by a hash of the context")
+	cmd.Flags().StringVar(&config.DispatchCacheSnapshotPath, "dispatch-cache-snapshot-path", "", "local path of a file to which the dispatch cache is periodically saved, and from which it is warmed on startup")
+	cmd.Flags().StringVar(&config.DispatchSharedCacheURI, "dispatch-shared-cache-uri", "", `connection string of a Redis server in which dispatch results are shared by the nodes of the cluster, behind the dispatch cache of each (e.g. "redis://localhost:6379/0")`)
+	cmd.Flags().DurationVar(&config.DispatchSharedCacheTTL, "dispatch-shared-cache-ttl", 1*time.Hour, "time after which dispatch results written to the shared dispatch cache expire (0 keeps them until evicted by the server)")
+	cmd.Flags().DurationVar(&config.DispatchSharedCacheTimeout, "dispatch-shared-cache-timeout", 20*time.Millisecond, "maximum amount of time a read of the shared dispatch cache can take before it is treated as a miss")
+	cmd.Flags().StringVar(&config.DispatchSharedCacheSerialization, "dispatch-shared-cache-serialization", string(cache.SerializationProto), fmt.Sprintf("serialization of the dispatch results written to the shared dispatch cache (%s, %s)", cache.SerializationProto, cache.SerializationProtoSnappy))
+	cmd.Flags().DurationVar(&config.DispatchCacheSnapshotInterval, "dispatch-cache-snapshot-interval", 1*time.Minute, "amount of time between saves of the dispatch cache to --dispatch-cache-snapshot-path, in addition to the save on shutdown")
 
 	// Flags for configuring dispatch requests
 	cmd.Flags().Uint32Var(&config.DispatchMaxDepth, "dispatch-max-depth", 50, "maximum recursion depth for nested calls")
-	cmd.Flags().StringVar(&config.DispatchUpstreamAddr, "dispatch-upstream-addr", "", "upstream grpc address to dispatch to")
+	cmd.Flags().StringVar(&config.DispatchUpstreamAddr, "dispatch-upstream-addr", "", `upstream grpc address to dispatch to ("kubernetes-endpointslices:///service.namespace:port" watches the EndpointSlices of a headless service to update the nodes dispatched to as soon as pods change)`)
 	cmd.Flags().StringVar(&config.DispatchUpstreamCAPath, "dispatch-upstream-ca-path", "", "local path to the TLS CA used when connecting to the dispatch cluster")
+	cmd.Flags().StringVar(&config.DispatchUpstreamTLSCertPath, "dispatch-upstream-tls-cert-path", "", "local path to the TLS certificate presented when connecting to the dispatch cluster, for mutual TLS (requires --dispatch-upstream-ca-path)")
+	cmd.Flags().StringVar(&config.DispatchUpstreamTLSKeyPath, "dispatch-upstream-tls-key-path", "", "local path to the TLS key of --dispatch-upstream-tls-cert-path")
+	cmd.Flags().StringVar(&config.DispatchClusterTLSClientCAPath, "dispatch-cluster-tls-client-ca-path", "", "local path to the TLS CA by which the certificates of clients of the dispatch server must be issued, enabling mutual TLS (requires --dispatch-cluster-tls-cert-path)")
+	cmd.Flags().StringSliceVar(&config.DispatchPeerSPIFFEIDs, "dispatch-peer-spiffe-ids", nil, `SPIFFE IDs or trust domains, one of which must be carried by the certificates of the nodes dispatched to and from, in place of verifying their host names (e.g. "spiffe://example.org/ns/spicedb/sa/spicedb" or "spiffe://example.org")`)
 	cmd.Flags().Uint16Var(&config.DispatchConcurrencyLimit, "dispatch-concurrency-limit", 50, "maximum number of parallel goroutines to create for each request or subrequest")
+	cmd.Flags().StringToIntVar(&config.DispatchConcurrencyLimitOverrides, "dispatch-concurrency-limit-overrides", nil, `maximum number of parallel goroutines to create for each request or subrequest on the resources of a definition or relation, in place of --dispatch-concurrency-limit (e.g. "document=10,document#view=5")`)
+	cmd.Flags().StringToIntVar(&config.DispatchBudgets, "dispatch-budgets", nil, `budget for dispatching the subproblems of a call to each API method, across all nodes, in which each subproblem costs one more than its depth, so that calls may be wide or deep but not both (e.g. "CheckPermission=10000,LookupResources=1000000"; methods without a budget are limited only by --dispatch-max-depth)`)
+	cmd.Flags().Uint16Var(&config.DispatchHashringWeight, "dispatch-hashring-weight", consistent.DefaultWeight, fmt.Sprintf("weight of this node on the dispatch hashring of the other nodes, relative to the default of %d, by which its share of dispatched requests is scaled", consistent.DefaultWeight))
+	cmd.Flags().Uint16Var(&config.DispatchHashringWeightPerCPU, "dispatch-hashring-weight-per-cpu", 0, "if non-zero, the weight of this node on the dispatch hashring is this value multiplied by the number of CPUs available to it, instead of --dispatch-hashring-weight")
+	cmd.Flags().StringVar(&config.DispatchCompression, "dispatch-compression", "", `compression of requests and responses dispatched between nodes ("gzip", "zstd", or "" for none)`)
+	cmd.Flags().DurationVar(&config.DispatchKeepaliveTime, "dispatch-keepalive-time", 0, "amount of time without activity after which dispatch connections between nodes are pinged to check they are alive (0 keeps the gRPC default)")
+	cmd.Flags().DurationVar(&config.DispatchKeepaliveTimeout, "dispatch-keepalive-timeout", 20*time.Second, "amount of time to wait for a response to a ping of a dispatch connection before it is closed")
+	cmd.Flags().Int32Var(&config.DispatchInitialWindowSize, "dispatch-initial-window-size", 0, "initial HTTP/2 flow control window size, in bytes, of each dispatch stream between nodes (0 keeps the gRPC default)")
+	cmd.Flags().Int32Var(&config.DispatchInitialConnWindowSize, "dispatch-initial-conn-window-size", 0, "initial HTTP/2 flow control window size, in bytes, of each dispatch connection between nodes (0 keeps the gRPC default)")
+	cmd.Flags().BoolVar(&config.DispatchHedgingEnabled, "dispatch-hedging", false, "enable hedging of slow requests dispatched to other nodes, by sending a duplicate request to the next node on the hashring")
+	cmd.Flags().DurationVar(&config.DispatchHedgingInitialSlowValue, "dispatch-hedging-initial-slow-value", 10*time.Millisecond, "initial value to use for slow dispatch requests, before statistics have been collected")
+	cmd.Flags().Uint64Var(&config.DispatchHedgingMaxRequests, "dispatch-hedging-max-requests", 1_000_000, "maximum number of historical dispatch requests to consider")
+	cmd.Flags().Float64Var(&config.DispatchHedgingQuantile, "dispatch-hedging-quantile", 0.95, "quantile of historical dispatch requests at which a request will be considered slow")
+	cmd.Flags().DurationVar(&config.DispatchCheckBatchWindow, "dispatch-check-batch-window", 0, "amount of time for which checks dispatched to the same node are collected into a single batched request (0 disables batching)")
+	cmd.Flags().DurationVar(&config.DispatchHopOverhead, "dispatch-hop-overhead", 5*time.Millisecond, "amount of time deducted from the remaining deadline of a request each time it is dispatched to another node, so that requests which cannot complete fail before being dispatched")
+	cmd.Flags().StringVar(&config.DispatchFallbackPolicy, "dispatch-fallback-policy", "local", `what is done with requests which cannot be dispatched because no node of --dispatch-upstream-addr can be reached ("local" resolves them on this node, "fail" fails them)`)
+	cmd.Flags().Float64Var(&config.DispatchHotKeySampleRate, "dispatch-hot-key-sample-rate", 0, "fraction of dispatched requests sampled to find the most dispatched resources and permissions, which are exported as metrics and served at /debug/dispatch/hotkeys on the metrics server (0 disables sampling)")
+	cmd.Flags().DurationVar(&config.DispatchHotKeyWindow, "dispatch-hot-key-window", 1*time.Minute, "amount of time over which the most dispatched resources and permissions are reported")
+	cmd.Flags().BoolVar(&config.DispatchPrefetchEnabled, "dispatch-prefetch-enabled", false, "enables recomputing the checks of the most dispatched resources and permissions before each datastore revision quantization window rolls over, so that their results are already cached when it does (requires --dispatch-hot-key-sample-rate)")
+	cmd.Flags().DurationVar(&config.DispatchPrefetchLead, "dispatch-prefetch-lead", 500*time.Millisecond, "amount of time before the datastore revision quantization window rolls over at which checks are prefetched")
 
 	// Flags for configuring API behavior
 	cmd.Flags().BoolVar(&config.DisableV1SchemaAPI, "disable-v1-schema-api", false, "disables the V1 schema API")
@@ -95,6 +132,17 @@ func RegisterServeFlags(cmd *cobra.Command, config *server.Config) {
 	cmd.Flags().Uint16Var(&config.MaximumUpdatesPerWrite, "write-relationships-max-updates-per-call", 1000, "maximum number of updates allowed for WriteRelationships calls")
 	cmd.Flags().Uint16Var(&config.MaximumPreconditionCount, "update-relationships-max-preconditions-per-call", 1000, "maximum number of preconditions allowed for WriteRelationships and DeleteRelationships calls")
 
+	// Flags for overload control
+	cmd.Flags().Uint32Var(&config.OverloadMaxConcurrentRequests, "overload-max-concurrent-requests", 0, "maximum number of API requests processed concurrently before requests are queued by priority and low priority requests are shed (0 disables overload control)")
+	cmd.Flags().DurationVar(&config.OverloadTargetQueueDelay, "overload-target-queue-delay", 100*time.Millisecond, "average queue delay above which the server is considered overloaded and low priority requests are shed")
+	cmd.Flags().Uint32Var(&config.DispatchOverloadMaxConcurrentRequests, "dispatch-overload-max-concurrent-requests", 0, "maximum number of dispatched requests processed concurrently before they are queued by the priority of their API request and low priority requests are shed (0 disables dispatch overload control)")
+	cmd.Flags().DurationVar(&config.DispatchOverloadTargetQueueDelay, "dispatch-overload-target-queue-delay", 50*time.Millisecond, "average queue delay above which the dispatch server is considered overloaded and low priority dispatched requests are shed")
+
+	// Flags for integrity checking
+	cmd.Flags().DurationVar(&config.IntegrityCheckInterval, "integrity-check-interval", 0, "amount of time between background scans for relationships referencing undefined relations, object types or caveats (0 disables background scans)")
+	cmd.Flags().StringVar(&config.IntegrityRepairMode, "integrity-repair-mode", "none", `what integrity checks do with orphaned relationships ("none", "delete", "quarantine")`)
+	cmd.Flags().StringVar(&config.IntegrityQuarantinePath, "integrity-quarantine-path", "", "file to which orphaned relationships are appended before being deleted (required with --integrity-repair-mode=quarantine)")
+
 	cmd.Flags().BoolVar(&config.V1SchemaAdditiveOnly, "testing-only-schema-additive-writes", false, "append new definitions to the existing schema, rather than overwriting it")
 	if err := cmd.Flags().MarkHidden("testing-only-schema-additive-writes"); err != nil {
 		panic("failed to mark flag hidden: " + err.Error())
@@ -103,6 +151,7 @@ func RegisterServeFlags(cmd *cobra.Command, config *server.Config) {
 	// Flags for misc services
 	util.RegisterHTTPServerFlags(cmd.Flags(), &config.DashboardAPI, "dashboard", "dashboard", ":8080", true)
 	util.RegisterHTTPServerFlags(cmd.Flags(), &config.MetricsAPI, "metrics", "metrics", ":9090", true)
+	cmd.Flags().BoolVar(&config.MetricsAdminEndpointsEnabled, "metrics-admin-endpoints-enabled", false, "DANGEROUS: enables the endpoints of the metrics server which change or scan the datastore, such as /debug/datastore/gc, /debug/datastore/integrity and /debug/datastore/readonly, which are unauthenticated and must only be enabled if the metrics server is not exposed")
 
 	// Flags for telemetry
 	cmd.Flags().StringVar(&config.TelemetryEndpoint, "telemetry-endpoint", telemetry.DefaultEndpoint, "endpoint to which telemetry is reported, empty string to disable")
@@ -31,6 +31,7 @@ type CacheConfig struct {
 	NumCounters int64
 	Metrics     bool
 	Enabled     bool
+	MemoryAware bool
 }
 
 // Complete translates the CLI cache config into a cache config.
@@ -57,6 +58,7 @@ func (cc *CacheConfig) Complete() (cache.Cache, error) {
 		MaxCost:     int64(maxCost),
 		NumCounters: cc.NumCounters,
 		Metrics:     cc.Metrics,
+		MemoryAware: cc.MemoryAware,
 	})
 }
 
@@ -82,4 +84,5 @@ func RegisterCacheFlags(flags *pflag.FlagSet, flagPrefix string, config, default
 	flags.Int64Var(&config.NumCounters, flagPrefix+"-num-counters", defaults.NumCounters, "number of TinyLFU samples to track")
 	flags.BoolVar(&config.Metrics, flagPrefix+"-metrics", defaults.Metrics, "enable cache metrics")
 	flags.BoolVar(&config.Enabled, flagPrefix+"-enabled", defaults.Enabled, "enable caching")
+	flags.BoolVar(&config.MemoryAware, flagPrefix+"-memory-aware", defaults.MemoryAware, "shrink the cache below its max cost while the memory used by the process nears its limit (GOMEMLIMIT, the cgroup limit or the system memory)")
 }
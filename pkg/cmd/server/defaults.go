@@ -1,9 +1,11 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/pprof"
+	"strconv"
 
 	"github.com/fatih/color"
 	"github.com/go-logr/zerologr"
@@ -21,13 +23,18 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 
+	"github.com/authzed/spicedb/internal/datastore/proxy"
 	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/dispatch/hotkeys"
+	"github.com/authzed/spicedb/internal/integrity"
 	"github.com/authzed/spicedb/internal/logging"
 	consistencymw "github.com/authzed/spicedb/internal/middleware/consistency"
 	datastoremw "github.com/authzed/spicedb/internal/middleware/datastore"
 	dispatchmw "github.com/authzed/spicedb/internal/middleware/dispatcher"
+	"github.com/authzed/spicedb/internal/middleware/overload"
 	"github.com/authzed/spicedb/internal/middleware/serverversion"
 	"github.com/authzed/spicedb/internal/middleware/servicespecific"
+	"github.com/authzed/spicedb/pkg/balancer"
 	"github.com/authzed/spicedb/pkg/datastore"
 	logmw "github.com/authzed/spicedb/pkg/middleware/logging"
 	"github.com/authzed/spicedb/pkg/middleware/requestid"
@@ -70,8 +77,16 @@ func DefaultPreRunE(programName string) cobrautil.CobraRunFunc {
 }
 
 // MetricsHandler sets up an HTTP server that handles serving Prometheus
-// metrics and pprof endpoints.
-func MetricsHandler(telemetryRegistry *prometheus.Registry) http.Handler {
+// metrics and pprof endpoints. If a datastore is provided, it also serves an
+// endpoint to trigger datastore garbage collection and, if the datastore can be
+// switched to read-only mode while running, an endpoint to do so. If an
+// integrity checker is provided, an endpoint to run and report integrity checks.
+// If a hot key tracker is provided, an endpoint to report the most dispatched
+// keys. It also serves an endpoint to report the members of the consistent
+// hashrings of the nodes dispatched to. The endpoints are unauthenticated, so
+// those which change or scan the datastore reject requests to do so unless
+// adminEnabled is set.
+func MetricsHandler(telemetryRegistry *prometheus.Registry, ds datastore.Datastore, checker *integrity.Checker, hotKeys *hotkeys.Tracker, adminEnabled bool) http.Handler {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
 	mux.HandleFunc("/debug/pprof/", pprof.Index)
@@ -82,9 +97,187 @@ func MetricsHandler(telemetryRegistry *prometheus.Registry) http.Handler {
 	if telemetryRegistry != nil {
 		mux.Handle("/telemetry", promhttp.HandlerFor(telemetryRegistry, promhttp.HandlerOpts{}))
 	}
+	if ds != nil {
+		mux.Handle("/debug/datastore/gc", adminOnly(adminEnabled, datastoreGCHandler(ds)))
+		if rods, ok := datastore.UnwrapAs[proxy.ReadonlyToggleDatastore](ds); ok {
+			mux.Handle("/debug/datastore/readonly", adminOnly(adminEnabled, datastoreReadonlyHandler(rods)))
+		}
+	}
+	if checker != nil {
+		mux.Handle("/debug/datastore/integrity", adminOnly(adminEnabled, integrityCheckHandler(checker)))
+	}
+	if hotKeys != nil {
+		mux.Handle("/debug/dispatch/hotkeys", hotKeysHandler(hotKeys))
+	}
+	mux.Handle("/debug/dispatch/ring", ringHandler())
 	return mux
 }
 
+// integrityCheckHandler responds with the report of the last integrity check
+// for each GET request, and runs an integrity check for each POST request,
+// responding with its report.
+func integrityCheckHandler(checker *integrity.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var report *integrity.Report
+		switch r.Method {
+		case http.MethodGet:
+			report = checker.LastReport()
+			if report == nil {
+				http.Error(w, "no integrity check has completed", http.StatusNotFound)
+				return
+			}
+
+		case http.MethodPost:
+			var err error
+			report, err = checker.Check(r.Context())
+			if err != nil {
+				logging.Ctx(r.Context()).Warn().Err(err).Msg("error performing requested integrity check")
+				http.Error(w, fmt.Sprintf("integrity check failed: %s", err), http.StatusInternalServerError)
+				return
+			}
+
+		default:
+			w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			logging.Ctx(r.Context()).Warn().Err(err).Msg("error writing integrity check response")
+		}
+	}
+}
+
+// hotKeysHandler responds with the report of the most dispatched keys in the
+// last completed window for each GET request.
+func hotKeysHandler(hotKeys *hotkeys.Tracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		report := hotKeys.LastReport()
+		if report == nil {
+			http.Error(w, "no hot key window has completed", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			logging.Ctx(r.Context()).Warn().Err(err).Msg("error writing hot keys response")
+		}
+	}
+}
+
+// ringHandler responds with the members of the consistent hashring of each
+// connection dispatched over, by dial target, for each GET request.
+func ringHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(balancer.Rings()); err != nil {
+			logging.Ctx(r.Context()).Warn().Err(err).Msg("error writing hashring response")
+		}
+	}
+}
+
+// adminOnly wraps a handler of the metrics server to reject the requests which
+// change or scan the datastore, which are all but GET requests, unless the
+// admin endpoints are enabled.
+func adminOnly(enabled bool, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !enabled && r.Method != http.MethodGet {
+			http.Error(w, "changing or scanning the datastore from the metrics server requires --metrics-admin-endpoints-enabled", http.StatusForbidden)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// datastoreGCHandler runs garbage collection on the datastore for each POST
+// request, responding with the amount of data collected.
+func datastoreGCHandler(ds datastore.Datastore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		gcds, ok := datastore.UnwrapAs[datastore.GarbageCollectableDatastore](ds)
+		if !ok {
+			http.Error(w, "datastore does not support garbage collection", http.StatusNotImplemented)
+			return
+		}
+
+		collected, err := gcds.CollectGarbage(r.Context())
+		if err != nil {
+			logging.Ctx(r.Context()).Warn().Err(err).Msg("error performing requested garbage collection")
+			http.Error(w, fmt.Sprintf("garbage collection failed: %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(struct {
+			Relationships int64 `json:"relationships"`
+			Transactions  int64 `json:"transactions"`
+			Backlog       int64 `json:"backlog"`
+		}(collected)); err != nil {
+			logging.Ctx(r.Context()).Warn().Err(err).Msg("error writing garbage collection response")
+		}
+	}
+}
+
+// datastoreReadonlyHandler responds with whether the datastore is in read-only
+// mode for each GET request, and sets the mode of every node using the
+// datastore from the `enabled` query parameter for each POST request,
+// responding with the new mode.
+func datastoreReadonlyHandler(ds proxy.ReadonlyToggleDatastore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+
+		case http.MethodPost:
+			enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+			if err != nil {
+				http.Error(w, "the `enabled` query parameter must be true or false", http.StatusBadRequest)
+				return
+			}
+			if err := ds.SetReadOnly(r.Context(), enabled); err != nil {
+				logging.Ctx(r.Context()).Warn().Err(err).Msg("error setting read-only mode")
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+		default:
+			w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		readOnly, err := ds.IsReadOnly(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(struct {
+			ReadOnly bool `json:"readOnly"`
+		}{readOnly}); err != nil {
+			logging.Ctx(r.Context()).Warn().Err(err).Msg("error writing read-only mode response")
+		}
+	}
+}
+
 var defaultGRPCLogOptions = []grpclog.Option{
 	// the server has a deadline set, so we consider it a normal condition
 	// this makes sure we don't log them as errors
@@ -100,52 +293,83 @@ var defaultGRPCLogOptions = []grpclog.Option{
 	}),
 }
 
-func DefaultMiddleware(logger zerolog.Logger, authFunc grpcauth.AuthFunc, enableVersionResponse bool, dispatcher dispatch.Dispatcher, ds datastore.Datastore) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
-	return []grpc.UnaryServerInterceptor{
-			requestid.UnaryServerInterceptor(requestid.GenerateIfMissing(true)),
-			logmw.UnaryServerInterceptor(logmw.ExtractMetadataField("x-request-id", "requestID")),
-			grpclog.UnaryServerInterceptor(grpczerolog.InterceptorLogger(logger), defaultGRPCLogOptions...),
-			otelgrpc.UnaryServerInterceptor(),
-			grpcauth.UnaryServerInterceptor(authFunc),
-			grpcprom.UnaryServerInterceptor,
+// DefaultMiddleware returns the default middleware for the API server. If an overload controller
+// is given, requests are admitted through it once they have been authenticated. The priority of
+// each request is given to the requests dispatched on its behalf either way. Revisions for requests
+// on namespaces matching the prefixes of the namespaceQuantization overrides are quantized by them
+// in place of the datastore.
+func DefaultMiddleware(logger zerolog.Logger, authFunc grpcauth.AuthFunc, enableVersionResponse bool, dispatcher dispatch.Dispatcher, ds datastore.Datastore, overloadController *overload.Controller, namespaceQuantization ...consistencymw.NamespaceQuantization) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
+	unary := []grpc.UnaryServerInterceptor{
+		requestid.UnaryServerInterceptor(requestid.GenerateIfMissing(true)),
+		logmw.UnaryServerInterceptor(logmw.ExtractMetadataField("x-request-id", "requestID")),
+		grpclog.UnaryServerInterceptor(grpczerolog.InterceptorLogger(logger), defaultGRPCLogOptions...),
+		otelgrpc.UnaryServerInterceptor(),
+		grpcauth.UnaryServerInterceptor(authFunc),
+		grpcprom.UnaryServerInterceptor,
+	}
+	streaming := []grpc.StreamServerInterceptor{
+		requestid.StreamServerInterceptor(requestid.GenerateIfMissing(true)),
+		logmw.StreamServerInterceptor(logmw.ExtractMetadataField("x-request-id", "requestID")),
+		grpclog.StreamServerInterceptor(grpczerolog.InterceptorLogger(logger), defaultGRPCLogOptions...),
+		otelgrpc.StreamServerInterceptor(),
+		grpcauth.StreamServerInterceptor(authFunc),
+		grpcprom.StreamServerInterceptor,
+	}
+
+	if overloadController != nil {
+		unary = append(unary, overload.UnaryServerInterceptor(overloadController))
+		streaming = append(streaming, overload.StreamServerInterceptor(overloadController))
+	} else {
+		unary = append(unary, overload.UnaryPriorityServerInterceptor(overload.DefaultClassifier))
+		streaming = append(streaming, overload.StreamPriorityServerInterceptor(overload.DefaultClassifier))
+	}
+
+	return append(unary,
 			dispatchmw.UnaryServerInterceptor(dispatcher),
 			datastoremw.UnaryServerInterceptor(ds),
-			consistencymw.UnaryServerInterceptor(),
+			consistencymw.UnaryServerInterceptor(consistencymw.WithNamespaceQuantization(namespaceQuantization...)),
 			servicespecific.UnaryServerInterceptor,
 			serverversion.UnaryServerInterceptor(enableVersionResponse),
-		}, []grpc.StreamServerInterceptor{
-			requestid.StreamServerInterceptor(requestid.GenerateIfMissing(true)),
-			logmw.StreamServerInterceptor(logmw.ExtractMetadataField("x-request-id", "requestID")),
-			grpclog.StreamServerInterceptor(grpczerolog.InterceptorLogger(logger), defaultGRPCLogOptions...),
-			otelgrpc.StreamServerInterceptor(),
-			grpcauth.StreamServerInterceptor(authFunc),
-			grpcprom.StreamServerInterceptor,
+		), append(streaming,
 			dispatchmw.StreamServerInterceptor(dispatcher),
 			datastoremw.StreamServerInterceptor(ds),
-			consistencymw.StreamServerInterceptor(),
+			consistencymw.StreamServerInterceptor(consistencymw.WithNamespaceQuantization(namespaceQuantization...)),
 			servicespecific.StreamServerInterceptor,
 			serverversion.StreamServerInterceptor(enableVersionResponse),
-		}
+		)
 }
 
-func DefaultDispatchMiddleware(logger zerolog.Logger, authFunc grpcauth.AuthFunc, ds datastore.Datastore) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
-	return []grpc.UnaryServerInterceptor{
-			requestid.UnaryServerInterceptor(requestid.GenerateIfMissing(true)),
-			logmw.UnaryServerInterceptor(logmw.ExtractMetadataField("x-request-id", "requestID")),
-			grpclog.UnaryServerInterceptor(grpczerolog.InterceptorLogger(logger), defaultGRPCLogOptions...),
-			otelgrpc.UnaryServerInterceptor(),
-			grpcauth.UnaryServerInterceptor(authFunc),
-			grpcprom.UnaryServerInterceptor,
+// DefaultDispatchMiddleware returns the default middleware for the dispatch server. If an overload
+// controller is given, dispatched requests are admitted through it by the priority of the API
+// request on whose behalf they were dispatched.
+func DefaultDispatchMiddleware(logger zerolog.Logger, authFunc grpcauth.AuthFunc, ds datastore.Datastore, overloadController *overload.Controller) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
+	unary := []grpc.UnaryServerInterceptor{
+		requestid.UnaryServerInterceptor(requestid.GenerateIfMissing(true)),
+		logmw.UnaryServerInterceptor(logmw.ExtractMetadataField("x-request-id", "requestID")),
+		grpclog.UnaryServerInterceptor(grpczerolog.InterceptorLogger(logger), defaultGRPCLogOptions...),
+		otelgrpc.UnaryServerInterceptor(),
+		grpcauth.UnaryServerInterceptor(authFunc),
+		grpcprom.UnaryServerInterceptor,
+	}
+	streaming := []grpc.StreamServerInterceptor{
+		requestid.StreamServerInterceptor(requestid.GenerateIfMissing(true)),
+		logmw.StreamServerInterceptor(logmw.ExtractMetadataField("x-request-id", "requestID")),
+		grpclog.StreamServerInterceptor(grpczerolog.InterceptorLogger(logger), defaultGRPCLogOptions...),
+		otelgrpc.StreamServerInterceptor(),
+		grpcauth.StreamServerInterceptor(authFunc),
+		grpcprom.StreamServerInterceptor,
+	}
+
+	if overloadController != nil {
+		unary = append(unary, overload.UnaryServerInterceptor(overloadController))
+		streaming = append(streaming, overload.StreamServerInterceptorByRequest(overloadController))
+	}
+
+	return append(unary,
 			datastoremw.UnaryServerInterceptor(ds),
 			servicespecific.UnaryServerInterceptor,
-		}, []grpc.StreamServerInterceptor{
-			requestid.StreamServerInterceptor(requestid.GenerateIfMissing(true)),
-			logmw.StreamServerInterceptor(logmw.ExtractMetadataField("x-request-id", "requestID")),
-			grpclog.StreamServerInterceptor(grpczerolog.InterceptorLogger(logger), defaultGRPCLogOptions...),
-			otelgrpc.StreamServerInterceptor(),
-			grpcauth.StreamServerInterceptor(authFunc),
-			grpcprom.StreamServerInterceptor,
+		), append(streaming,
 			datastoremw.StreamServerInterceptor(ds),
 			servicespecific.StreamServerInterceptor,
-		}
+		)
 }
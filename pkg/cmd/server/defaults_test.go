@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/proxy"
+	"github.com/authzed/spicedb/internal/integrity"
+)
+
+func TestMetricsHandlerAdminEndpoints(t *testing.T) {
+	rawDS, err := memdb.NewMemdbDatastore(0, 1*time.Second, 10*time.Second)
+	require.NoError(t, err)
+	ds := proxy.NewReadonlyToggleDatastore(rawDS, false)
+
+	for _, tc := range []struct {
+		name         string
+		adminEnabled bool
+		method       string
+		path         string
+		expected     int
+	}{
+		{"gc disabled", false, http.MethodPost, "/debug/datastore/gc", http.StatusForbidden},
+		// memdb does not collect garbage, which is only reported once the request is allowed.
+		{"gc enabled", true, http.MethodPost, "/debug/datastore/gc", http.StatusNotImplemented},
+		{"gc wrong method", true, http.MethodGet, "/debug/datastore/gc", http.StatusMethodNotAllowed},
+		{"integrity check disabled", false, http.MethodPost, "/debug/datastore/integrity", http.StatusForbidden},
+		{"integrity check enabled", true, http.MethodPost, "/debug/datastore/integrity", http.StatusOK},
+		{"integrity report", false, http.MethodGet, "/debug/datastore/integrity", http.StatusNotFound},
+		{"set read-only disabled", false, http.MethodPost, "/debug/datastore/readonly?enabled=false", http.StatusForbidden},
+		{"set read-only enabled", true, http.MethodPost, "/debug/datastore/readonly?enabled=false", http.StatusOK},
+		{"read-only mode", false, http.MethodGet, "/debug/datastore/readonly", http.StatusOK},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			checker, err := integrity.NewChecker(ds, integrity.Config{})
+			require.NoError(t, err)
+
+			handler := MetricsHandler(DisableTelemetryHandler, ds, checker, nil, tc.adminEnabled)
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(tc.method, tc.path, nil))
+			require.Equal(t, tc.expected, recorder.Code)
+		})
+	}
+}
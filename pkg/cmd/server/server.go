@@ -2,8 +2,10 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"sync"
@@ -12,31 +14,48 @@ import (
 	"github.com/authzed/grpcutil"
 	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
 	grpcprom "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/cors"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"golang.org/x/exp/slices"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/keepalive"
 
 	"github.com/authzed/spicedb/internal/auth"
 	"github.com/authzed/spicedb/internal/dashboard"
 	"github.com/authzed/spicedb/internal/datastore/proxy"
 	"github.com/authzed/spicedb/internal/dispatch"
+	"github.com/authzed/spicedb/internal/dispatch/caching"
 	clusterdispatch "github.com/authzed/spicedb/internal/dispatch/cluster"
 	combineddispatch "github.com/authzed/spicedb/internal/dispatch/combined"
+	"github.com/authzed/spicedb/internal/dispatch/hotkeys"
+	"github.com/authzed/spicedb/internal/dispatch/prefetch"
+	"github.com/authzed/spicedb/internal/dispatch/remote"
 	"github.com/authzed/spicedb/internal/gateway"
+	"github.com/authzed/spicedb/internal/graph/computed"
+	"github.com/authzed/spicedb/internal/integrity"
 	log "github.com/authzed/spicedb/internal/logging"
+	consistencymw "github.com/authzed/spicedb/internal/middleware/consistency"
+	"github.com/authzed/spicedb/internal/middleware/overload"
 	"github.com/authzed/spicedb/internal/services"
 	dispatchSvc "github.com/authzed/spicedb/internal/services/dispatch"
 	"github.com/authzed/spicedb/internal/services/health"
 	v1svc "github.com/authzed/spicedb/internal/services/v1"
 	"github.com/authzed/spicedb/internal/telemetry"
 	"github.com/authzed/spicedb/pkg/balancer"
+	"github.com/authzed/spicedb/pkg/cache"
 	datastorecfg "github.com/authzed/spicedb/pkg/cmd/datastore"
 	"github.com/authzed/spicedb/pkg/cmd/util"
 	"github.com/authzed/spicedb/pkg/datastore"
 )
 
+// dispatchCacheSnapshotMaxEntries is the number of the most recently cached dispatch results which
+// are saved to the dispatch cache snapshot.
+const dispatchCacheSnapshotMaxEntries = 100_000
+
 //go:generate go run github.com/ecordell/optgen -output zz_generated.options.go . Config
 type Config struct {
 	// API config
@@ -54,8 +73,9 @@ type Config struct {
 	HTTPGatewayCorsAllowedOrigins  []string
 
 	// Datastore
-	DatastoreConfig datastorecfg.Config
-	Datastore       datastore.Datastore
+	DatastoreConfig                        datastorecfg.Config
+	Datastore                              datastore.Datastore
+	DatastoreRevisionQuantizationOverrides map[string]string
 
 	// Namespace cache
 	NamespaceCacheConfig CacheConfig
@@ -64,18 +84,57 @@ type Config struct {
 	SchemaPrefixesRequired bool
 
 	// Dispatch options
-	DispatchServer               util.GRPCServerConfig
-	DispatchMaxDepth             uint32
-	DispatchConcurrencyLimit     uint16
-	DispatchUpstreamAddr         string
-	DispatchUpstreamCAPath       string
-	DispatchClientMetricsPrefix  string
-	DispatchClusterMetricsPrefix string
-	Dispatcher                   dispatch.Dispatcher
+	DispatchServer                    util.GRPCServerConfig
+	DispatchMaxDepth                  uint32
+	DispatchConcurrencyLimit          uint16
+	DispatchConcurrencyLimitOverrides map[string]int
+	DispatchBudgets                   map[string]int
+	DispatchUpstreamAddr              string
+	DispatchUpstreamCAPath            string
+	DispatchUpstreamTLSCertPath       string
+	DispatchUpstreamTLSKeyPath        string
+	DispatchClusterTLSClientCAPath    string
+	DispatchPeerSPIFFEIDs             []string
+	DispatchClientMetricsPrefix       string
+	DispatchClusterMetricsPrefix      string
+	Dispatcher                        dispatch.Dispatcher
 
 	DispatchCacheConfig        CacheConfig
 	ClusterDispatchCacheConfig CacheConfig
 
+	DispatchCacheNegativeResultsDisabled bool
+	DispatchCacheNegativeResultTTL       time.Duration
+	DispatchCacheCaveatContextEnabled    bool
+	DispatchCacheSnapshotPath            string
+	DispatchCacheSnapshotInterval        time.Duration
+	DispatchSharedCacheURI               string
+	DispatchSharedCacheTTL               time.Duration
+	DispatchSharedCacheTimeout           time.Duration
+	DispatchSharedCacheSerialization     string
+
+	DispatchHedgingEnabled          bool
+	DispatchHedgingInitialSlowValue time.Duration
+	DispatchHedgingMaxRequests      uint64
+	DispatchHedgingQuantile         float64
+
+	DispatchCheckBatchWindow time.Duration
+	DispatchFallbackPolicy   string
+	DispatchHopOverhead      time.Duration
+
+	DispatchHotKeySampleRate float64
+	DispatchHotKeyWindow     time.Duration
+	DispatchPrefetchEnabled  bool
+	DispatchPrefetchLead     time.Duration
+
+	DispatchHashringWeight       uint16
+	DispatchHashringWeightPerCPU uint16
+
+	DispatchCompression           string
+	DispatchKeepaliveTime         time.Duration
+	DispatchKeepaliveTimeout      time.Duration
+	DispatchInitialWindowSize     int32
+	DispatchInitialConnWindowSize int32
+
 	// API Behavior
 	DisableV1SchemaAPI         bool
 	V1SchemaAdditiveOnly       bool
@@ -83,10 +142,28 @@ type Config struct {
 	MaximumPreconditionCount   uint16
 	ExperimentalCaveatsEnabled bool
 
+	// Overload control
+	OverloadMaxConcurrentRequests uint32
+	OverloadTargetQueueDelay      time.Duration
+
+	// Overload control of dispatched requests
+	DispatchOverloadMaxConcurrentRequests uint32
+	DispatchOverloadTargetQueueDelay      time.Duration
+
+	// Integrity checking
+	IntegrityCheckInterval  time.Duration
+	IntegrityRepairMode     string
+	IntegrityQuarantinePath string
+
 	// Additional Services
 	DashboardAPI util.HTTPServerConfig
 	MetricsAPI   util.HTTPServerConfig
 
+	// MetricsAdminEndpointsEnabled enables the unauthenticated endpoints of the
+	// metrics server which change the datastore, such as triggering garbage
+	// collection.
+	MetricsAdminEndpointsEnabled bool
+
 	// Middleware for grpc
 	UnaryMiddleware     []grpc.UnaryServerInterceptor
 	StreamingMiddleware []grpc.StreamServerInterceptor
@@ -95,6 +172,10 @@ type Config struct {
 	DispatchUnaryMiddleware     []grpc.UnaryServerInterceptor
 	DispatchStreamingMiddleware []grpc.StreamServerInterceptor
 
+	// DispatcherMiddleware wraps the dispatchers which resolve the requests of the API and the
+	// requests dispatched by peer nodes, the first middleware being the outermost.
+	DispatcherMiddleware []dispatch.Middleware
+
 	// Telemetry
 	SilentlyDisableTelemetry bool
 	TelemetryCAOverridePath  string
@@ -143,8 +224,111 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 	ds = proxy.NewCachingDatastoreProxy(ds, nscc)
 	ds = proxy.NewObservableDatastoreProxy(ds)
 
+	schemaHookedDS := proxy.NewSchemaChangeHooksProxy(ds)
+	ds = schemaHookedDS
+
 	enableGRPCHistogram()
 
+	cachingOptions := []caching.Option{caching.NegativeCheckResultTTL(c.DispatchCacheNegativeResultTTL)}
+	if c.DispatchCacheNegativeResultsDisabled {
+		cachingOptions = append(cachingOptions, caching.NegativeCheckResultsDisabled())
+	}
+
+	var hotKeys *hotkeys.Tracker
+	if c.DispatchHotKeySampleRate > 0 {
+		if c.DispatchHotKeySampleRate > 1 || c.DispatchHotKeyWindow <= 0 {
+			return nil, fmt.Errorf("dispatch hot key sample rate must be at most 1 and its window positive")
+		}
+		hotKeys = hotkeys.NewTracker(c.DispatchHotKeySampleRate, c.DispatchHotKeyWindow)
+		if err := prometheus.Register(hotKeys); err != nil {
+			return nil, fmt.Errorf("failed to register dispatch hot key metrics: %w", err)
+		}
+		cachingOptions = append(cachingOptions, caching.HotKeys(hotKeys))
+	}
+
+	var dispatchPrefetcher *prefetch.Prefetcher
+	if c.DispatchPrefetchEnabled {
+		if hotKeys == nil {
+			return nil, fmt.Errorf("dispatch prefetching requires a dispatch hot key sample rate")
+		}
+		quantization := c.DatastoreConfig.RevisionQuantization
+		if c.DispatchPrefetchLead <= 0 || c.DispatchPrefetchLead >= quantization {
+			return nil, fmt.Errorf("dispatch prefetch lead must be positive and less than the datastore revision quantization interval")
+		}
+		dispatchPrefetcher = prefetch.NewPrefetcher(hotKeys, ds, quantization, c.DispatchPrefetchLead)
+		cachingOptions = append(cachingOptions, caching.Prefetcher(dispatchPrefetcher))
+	}
+
+	var dispatchHedging *remote.HedgingConfig
+	if c.DispatchHedgingEnabled {
+		dispatchHedging = &remote.HedgingConfig{
+			InitialSlowValue: c.DispatchHedgingInitialSlowValue,
+			MaxRequests:      c.DispatchHedgingMaxRequests,
+			Quantile:         c.DispatchHedgingQuantile,
+		}
+		if err := dispatchHedging.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid dispatch hedging configuration: %w", err)
+		}
+	}
+
+	dispatchFallbackPolicy, err := combineddispatch.ParseFallbackPolicy(c.DispatchFallbackPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	dispatchDialOpts := []grpc.DialOption{
+		grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		grpc.WithDefaultServiceConfig(balancer.BalancerServiceConfig),
+	}
+	if c.DispatchCompression != "" {
+		if encoding.GetCompressor(c.DispatchCompression) == nil {
+			return nil, fmt.Errorf("unknown dispatch compression %q", c.DispatchCompression)
+		}
+		dispatchDialOpts = append(dispatchDialOpts, grpc.WithDefaultCallOptions(grpc.UseCompressor(c.DispatchCompression)))
+	}
+	if c.DispatchKeepaliveTime > 0 {
+		dispatchDialOpts = append(dispatchDialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                c.DispatchKeepaliveTime,
+			Timeout:             c.DispatchKeepaliveTimeout,
+			PermitWithoutStream: true,
+		}))
+	}
+	if c.DispatchInitialWindowSize > 0 {
+		dispatchDialOpts = append(dispatchDialOpts, grpc.WithInitialWindowSize(c.DispatchInitialWindowSize))
+	}
+	if c.DispatchInitialConnWindowSize > 0 {
+		dispatchDialOpts = append(dispatchDialOpts, grpc.WithInitialConnWindowSize(c.DispatchInitialConnWindowSize))
+	}
+
+	concurrencyLimitOverrides := make(map[string]uint16, len(c.DispatchConcurrencyLimitOverrides))
+	for key, limit := range c.DispatchConcurrencyLimitOverrides {
+		if limit < 1 || limit > math.MaxUint16 {
+			return nil, fmt.Errorf("dispatch concurrency limit override for %q must be between 1 and %d", key, math.MaxUint16)
+		}
+		concurrencyLimitOverrides[key] = uint16(limit)
+	}
+
+	dispatchBudgets := make(map[string]uint32, len(c.DispatchBudgets))
+	for method, budget := range c.DispatchBudgets {
+		if !slices.Contains(v1svc.DispatchBudgetMethods, method) {
+			return nil, fmt.Errorf("unknown API method %q for dispatch budget; must be one of %v", method, v1svc.DispatchBudgetMethods)
+		}
+		if budget < 1 || budget > math.MaxUint32 {
+			return nil, fmt.Errorf("dispatch budget for %q must be between 1 and %d", method, uint32(math.MaxUint32))
+		}
+		dispatchBudgets[method] = uint32(budget)
+	}
+
+	namespaceQuantization := make([]consistencymw.NamespaceQuantization, 0, len(c.DatastoreRevisionQuantizationOverrides))
+	for prefix, value := range c.DatastoreRevisionQuantizationOverrides {
+		override, err := consistencymw.ParseNamespaceQuantization(prefix, value)
+		if err != nil {
+			return nil, err
+		}
+		namespaceQuantization = append(namespaceQuantization, override)
+	}
+
+	var dispatchCacheSnapshotter *cache.SnapshottingCache
 	dispatcher := c.Dispatcher
 	if dispatcher == nil {
 		var err error
@@ -154,33 +338,80 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 		}
 		log.Info().EmbedObject(cc).Msg("configured dispatch cache")
 
+		if c.DispatchSharedCacheURI != "" {
+			shared, err := cache.NewRedisSharedCache(c.DispatchSharedCacheURI)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create dispatcher: %w", err)
+			}
+
+			cc, err = cache.NewTieredCache(cc, shared, cache.SharedCacheConfig{
+				TTL:           c.DispatchSharedCacheTTL,
+				Timeout:       c.DispatchSharedCacheTimeout,
+				Serialization: cache.Serialization(c.DispatchSharedCacheSerialization),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create dispatcher: %w", err)
+			}
+			log.Info().Stringer("ttl", c.DispatchSharedCacheTTL).Str("serialization", c.DispatchSharedCacheSerialization).Msg("configured shared dispatch cache")
+		}
+
+		if c.DispatchCacheSnapshotPath != "" {
+			dispatchCacheSnapshotter, err = cache.NewSnapshottingCache(cc, c.DispatchCacheSnapshotPath, dispatchCacheSnapshotMaxEntries)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create dispatcher: %w", err)
+			}
+			cc = dispatchCacheSnapshotter
+		}
+
 		dispatchPresharedKey := ""
 		if len(c.PresharedKey) > 0 {
 			dispatchPresharedKey = c.PresharedKey[0]
 		}
 
-		dispatcher, err = combineddispatch.NewDispatcher(
+		combinedOptions := []combineddispatch.Option{
 			combineddispatch.UpstreamAddr(c.DispatchUpstreamAddr),
 			combineddispatch.UpstreamCAPath(c.DispatchUpstreamCAPath),
+			combineddispatch.UpstreamCertPaths(c.DispatchUpstreamTLSCertPath, c.DispatchUpstreamTLSKeyPath),
+			combineddispatch.UpstreamPeerIDs(c.DispatchPeerSPIFFEIDs),
 			combineddispatch.GrpcPresharedKey(dispatchPresharedKey),
-			combineddispatch.GrpcDialOpts(
-				grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
-				grpc.WithDefaultServiceConfig(balancer.BalancerServiceConfig),
-			),
+			combineddispatch.GrpcDialOpts(dispatchDialOpts...),
 			combineddispatch.PrometheusSubsystem(c.DispatchClientMetricsPrefix),
 			combineddispatch.Cache(cc),
+			combineddispatch.CachingOptions(cachingOptions...),
 			combineddispatch.ConcurrencyLimit(c.DispatchConcurrencyLimit),
-		)
+			combineddispatch.ConcurrencyLimitOverrides(concurrencyLimitOverrides),
+			combineddispatch.CheckBatchWindow(c.DispatchCheckBatchWindow),
+			combineddispatch.UpstreamFallbackPolicy(dispatchFallbackPolicy),
+			combineddispatch.UpstreamHopOverhead(c.DispatchHopOverhead),
+		}
+		if dispatchHedging != nil {
+			combinedOptions = append(combinedOptions, combineddispatch.Hedging(*dispatchHedging))
+		}
+
+		dispatcher, err = combineddispatch.NewDispatcher(combinedOptions...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create dispatcher: %w", err)
 		}
 	}
 
+	var dispatchOverloadController *overload.Controller
+	if c.DispatchOverloadMaxConcurrentRequests > 0 {
+		var err error
+		dispatchOverloadController, err = overload.NewController(overload.Config{
+			MaxConcurrentRequests: c.DispatchOverloadMaxConcurrentRequests,
+			TargetQueueDelay:      c.DispatchOverloadTargetQueueDelay,
+			Classifier:            overload.DispatchClassifier,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create dispatch overload controller: %w", err)
+		}
+	}
+
 	if len(c.DispatchUnaryMiddleware) == 0 && len(c.DispatchStreamingMiddleware) == 0 {
 		if c.GRPCAuthFunc == nil {
-			c.DispatchUnaryMiddleware, c.DispatchStreamingMiddleware = DefaultDispatchMiddleware(log.Logger, auth.RequirePresharedKey(c.PresharedKey), ds)
+			c.DispatchUnaryMiddleware, c.DispatchStreamingMiddleware = DefaultDispatchMiddleware(log.Logger, auth.RequirePresharedKey(c.PresharedKey), ds, dispatchOverloadController)
 		} else {
-			c.DispatchUnaryMiddleware, c.DispatchStreamingMiddleware = DefaultDispatchMiddleware(log.Logger, c.GRPCAuthFunc, ds)
+			c.DispatchUnaryMiddleware, c.DispatchStreamingMiddleware = DefaultDispatchMiddleware(log.Logger, c.GRPCAuthFunc, ds, dispatchOverloadController)
 		}
 	}
 
@@ -197,18 +428,70 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 			dispatcher,
 			clusterdispatch.PrometheusSubsystem(c.DispatchClusterMetricsPrefix),
 			clusterdispatch.Cache(cdcc),
+			clusterdispatch.CachingOptions(cachingOptions...),
+			clusterdispatch.ConcurrencyLimit(c.DispatchConcurrencyLimit),
+			clusterdispatch.ConcurrencyLimitOverrides(concurrencyLimitOverrides),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to configure cluster dispatch: %w", err)
 		}
 	}
 
+	// Invalidate the cached results as soon as the definitions of namespaces or caveats are changed
+	// via this node, rather than returning results computed with the previous definitions until the
+	// revisions they were computed at are no longer used. The results cached by other nodes are not
+	// invalidated, so they may return such results until then.
+	for _, d := range []dispatch.Dispatcher{dispatcher, cachingClusterDispatch} {
+		if cachingDispatch, ok := d.(*caching.Dispatcher); ok {
+			schemaHookedDS.RegisterSchemaChangeHook(func(_ context.Context, _ proxy.SchemaChange) {
+				cachingDispatch.InvalidateSchema()
+			})
+		}
+	}
+
+	// Check results evaluated with a caveat context are cached by the local caching dispatcher,
+	// which is given to the permissions service as it is no longer reachable once wrapped.
+	var caveatContextCache computed.CaveatContextCache
+	if c.DispatchCacheCaveatContextEnabled {
+		cachingDispatch, ok := dispatcher.(*caching.Dispatcher)
+		if !ok {
+			return nil, errors.New("caching check results by caveat context requires the default dispatcher")
+		}
+		caveatContextCache = cachingDispatch
+	}
+
+	if len(c.DispatcherMiddleware) > 0 {
+		dispatcher = dispatch.Chain(dispatcher, c.DispatcherMiddleware...)
+		if cachingClusterDispatch != nil {
+			cachingClusterDispatch = dispatch.Chain(cachingClusterDispatch, c.DispatcherMiddleware...)
+		}
+	}
+
+	if dispatchPrefetcher != nil {
+		dispatchPrefetcher.SetDispatcher(dispatcher)
+	}
+
+	hashringWeight := c.DispatchHashringWeight
+	if c.DispatchHashringWeightPerCPU > 0 {
+		hashringWeight = balancer.CPUWeight(c.DispatchHashringWeightPerCPU)
+	}
+	log.Info().Uint16("weight", hashringWeight).Msg("configured dispatch hashring weight")
+
+	c.DispatchServer.KeepaliveTime = c.DispatchKeepaliveTime
+	c.DispatchServer.KeepaliveTimeout = c.DispatchKeepaliveTimeout
+	c.DispatchServer.InitialWindowSize = c.DispatchInitialWindowSize
+	c.DispatchServer.InitialConnWindowSize = c.DispatchInitialConnWindowSize
+	c.DispatchServer.ClientAuthCAPath = c.DispatchClusterTLSClientCAPath
+	c.DispatchServer.AllowedPeerIDs = c.DispatchPeerSPIFFEIDs
+
 	dispatchGrpcServer, err := c.DispatchServer.Complete(zerolog.InfoLevel,
 		func(server *grpc.Server) {
 			dispatchSvc.RegisterGrpcServices(server, cachingClusterDispatch)
 		},
 		grpc.ChainUnaryInterceptor(c.DispatchUnaryMiddleware...),
 		grpc.ChainStreamInterceptor(c.DispatchStreamingMiddleware...),
+		grpc.ChainUnaryInterceptor(balancer.UnaryServerInterceptor(hashringWeight)),
+		grpc.ChainStreamInterceptor(balancer.StreamServerInterceptor(hashringWeight)),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create dispatch gRPC server: %w", err)
@@ -232,20 +515,37 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 		watchServiceOption = services.WatchServiceDisabled
 	}
 
+	var overloadController *overload.Controller
+	if c.OverloadMaxConcurrentRequests > 0 {
+		overloadController, err = overload.NewController(overload.Config{
+			MaxConcurrentRequests: c.OverloadMaxConcurrentRequests,
+			TargetQueueDelay:      c.OverloadTargetQueueDelay,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create overload controller: %w", err)
+		}
+	}
+
 	if len(c.UnaryMiddleware) == 0 && len(c.StreamingMiddleware) == 0 {
-		c.UnaryMiddleware, c.StreamingMiddleware = DefaultMiddleware(log.Logger, c.GRPCAuthFunc, !c.DisableVersionResponse, dispatcher, ds)
+		c.UnaryMiddleware, c.StreamingMiddleware = DefaultMiddleware(log.Logger, c.GRPCAuthFunc, !c.DisableVersionResponse, dispatcher, ds, overloadController, namespaceQuantization...)
 	}
 
 	permSysConfig := v1svc.PermissionsServerConfig{
 		MaxPreconditionsCount: c.MaximumPreconditionCount,
 		MaxUpdatesPerWrite:    c.MaximumUpdatesPerWrite,
 		MaximumAPIDepth:       c.DispatchMaxDepth,
+		DispatchBudgets:       dispatchBudgets,
+		CaveatContextCache:    caveatContextCache,
 	}
 
 	caveatsOption := services.CaveatsDisabled
 	if c.ExperimentalCaveatsEnabled {
-		log.Warn().Msg("experimental caveats support enabled")
-		caveatsOption = services.CaveatsEnabled
+		if datastoreFeatures.Caveats.Enabled {
+			log.Warn().Msg("experimental caveats support enabled")
+			caveatsOption = services.CaveatsEnabled
+		} else {
+			log.Warn().Str("reason", datastoreFeatures.Caveats.Reason).Msg("caveats disabled; underlying datastore does not support them")
+		}
 	}
 
 	healthManager := health.NewHealthManager(dispatcher, ds)
@@ -301,7 +601,20 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 		}
 	}
 
-	metricsServer, err := c.MetricsAPI.Complete(zerolog.InfoLevel, MetricsHandler(registry))
+	repairMode, err := integrity.ParseRepairMode(c.IntegrityRepairMode)
+	if err != nil {
+		return nil, err
+	}
+
+	integrityChecker, err := integrity.NewChecker(ds, integrity.Config{
+		RepairMode:     repairMode,
+		QuarantinePath: c.IntegrityQuarantinePath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create integrity checker: %w", err)
+	}
+
+	metricsServer, err := c.MetricsAPI.Complete(zerolog.InfoLevel, MetricsHandler(registry, ds, integrityChecker, hotKeys, c.MetricsAdminEndpointsEnabled))
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize metrics server: %w", err)
 	}
@@ -317,6 +630,13 @@ func (c *Config) Complete(ctx context.Context) (RunnableServer, error) {
 		presharedKeys:       c.PresharedKey,
 		telemetryReporter:   reporter,
 		healthManager:       healthManager,
+		integrityChecker:    integrityChecker,
+		integrityInterval:   c.IntegrityCheckInterval,
+
+		dispatchCacheSnapshotter:      dispatchCacheSnapshotter,
+		dispatchCacheSnapshotInterval: c.DispatchCacheSnapshotInterval,
+		dispatchPrefetcher:            dispatchPrefetcher,
+
 		closeFunc: func() error {
 			if err := ds.Close(); err != nil {
 				return err
@@ -401,6 +721,12 @@ type completedServerConfig struct {
 	dashboardServer    util.RunnableHTTPServer
 	telemetryReporter  telemetry.Reporter
 	healthManager      health.Manager
+	integrityChecker   *integrity.Checker
+	integrityInterval  time.Duration
+
+	dispatchCacheSnapshotter      *cache.SnapshottingCache
+	dispatchCacheSnapshotInterval time.Duration
+	dispatchPrefetcher            *prefetch.Prefetcher
 
 	unaryMiddleware     []grpc.UnaryServerInterceptor
 	streamingMiddleware []grpc.StreamServerInterceptor
@@ -471,6 +797,18 @@ func (c *completedServerConfig) Run(ctx context.Context) error {
 
 	g.Go(func() error { return c.telemetryReporter(ctx) })
 
+	if c.integrityInterval > 0 {
+		g.Go(func() error { return c.integrityChecker.Run(ctx, c.integrityInterval) })
+	}
+
+	if c.dispatchCacheSnapshotter != nil && c.dispatchCacheSnapshotInterval > 0 {
+		g.Go(func() error { return c.dispatchCacheSnapshotter.Run(ctx, c.dispatchCacheSnapshotInterval) })
+	}
+
+	if c.dispatchPrefetcher != nil {
+		g.Go(func() error { return c.dispatchPrefetcher.Run(ctx) })
+	}
+
 	g.Go(stopOnCancelWithErr(c.closeFunc))
 
 	if err := g.Wait(); err != nil {
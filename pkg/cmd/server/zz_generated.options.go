@@ -37,29 +37,72 @@ func (c *Config) ToOption() ConfigOption {
 		to.HTTPGatewayCorsAllowedOrigins = c.HTTPGatewayCorsAllowedOrigins
 		to.DatastoreConfig = c.DatastoreConfig
 		to.Datastore = c.Datastore
+		to.DatastoreRevisionQuantizationOverrides = c.DatastoreRevisionQuantizationOverrides
 		to.NamespaceCacheConfig = c.NamespaceCacheConfig
 		to.SchemaPrefixesRequired = c.SchemaPrefixesRequired
 		to.DispatchServer = c.DispatchServer
 		to.DispatchMaxDepth = c.DispatchMaxDepth
 		to.DispatchConcurrencyLimit = c.DispatchConcurrencyLimit
+		to.DispatchConcurrencyLimitOverrides = c.DispatchConcurrencyLimitOverrides
+		to.DispatchBudgets = c.DispatchBudgets
 		to.DispatchUpstreamAddr = c.DispatchUpstreamAddr
 		to.DispatchUpstreamCAPath = c.DispatchUpstreamCAPath
+		to.DispatchUpstreamTLSCertPath = c.DispatchUpstreamTLSCertPath
+		to.DispatchUpstreamTLSKeyPath = c.DispatchUpstreamTLSKeyPath
+		to.DispatchClusterTLSClientCAPath = c.DispatchClusterTLSClientCAPath
+		to.DispatchPeerSPIFFEIDs = c.DispatchPeerSPIFFEIDs
 		to.DispatchClientMetricsPrefix = c.DispatchClientMetricsPrefix
 		to.DispatchClusterMetricsPrefix = c.DispatchClusterMetricsPrefix
 		to.Dispatcher = c.Dispatcher
 		to.DispatchCacheConfig = c.DispatchCacheConfig
 		to.ClusterDispatchCacheConfig = c.ClusterDispatchCacheConfig
+		to.DispatchCacheNegativeResultsDisabled = c.DispatchCacheNegativeResultsDisabled
+		to.DispatchCacheNegativeResultTTL = c.DispatchCacheNegativeResultTTL
+		to.DispatchCacheCaveatContextEnabled = c.DispatchCacheCaveatContextEnabled
+		to.DispatchCacheSnapshotPath = c.DispatchCacheSnapshotPath
+		to.DispatchCacheSnapshotInterval = c.DispatchCacheSnapshotInterval
+		to.DispatchSharedCacheURI = c.DispatchSharedCacheURI
+		to.DispatchSharedCacheTTL = c.DispatchSharedCacheTTL
+		to.DispatchSharedCacheTimeout = c.DispatchSharedCacheTimeout
+		to.DispatchSharedCacheSerialization = c.DispatchSharedCacheSerialization
+		to.DispatchHedgingEnabled = c.DispatchHedgingEnabled
+		to.DispatchHedgingInitialSlowValue = c.DispatchHedgingInitialSlowValue
+		to.DispatchHedgingMaxRequests = c.DispatchHedgingMaxRequests
+		to.DispatchHedgingQuantile = c.DispatchHedgingQuantile
+		to.DispatchCheckBatchWindow = c.DispatchCheckBatchWindow
+		to.DispatchFallbackPolicy = c.DispatchFallbackPolicy
+		to.DispatchHopOverhead = c.DispatchHopOverhead
+		to.DispatchHotKeySampleRate = c.DispatchHotKeySampleRate
+		to.DispatchHotKeyWindow = c.DispatchHotKeyWindow
+		to.DispatchPrefetchEnabled = c.DispatchPrefetchEnabled
+		to.DispatchPrefetchLead = c.DispatchPrefetchLead
+		to.DispatchHashringWeight = c.DispatchHashringWeight
+		to.DispatchHashringWeightPerCPU = c.DispatchHashringWeightPerCPU
+		to.DispatchCompression = c.DispatchCompression
+		to.DispatchKeepaliveTime = c.DispatchKeepaliveTime
+		to.DispatchKeepaliveTimeout = c.DispatchKeepaliveTimeout
+		to.DispatchInitialWindowSize = c.DispatchInitialWindowSize
+		to.DispatchInitialConnWindowSize = c.DispatchInitialConnWindowSize
 		to.DisableV1SchemaAPI = c.DisableV1SchemaAPI
 		to.V1SchemaAdditiveOnly = c.V1SchemaAdditiveOnly
 		to.MaximumUpdatesPerWrite = c.MaximumUpdatesPerWrite
 		to.MaximumPreconditionCount = c.MaximumPreconditionCount
 		to.ExperimentalCaveatsEnabled = c.ExperimentalCaveatsEnabled
+		to.OverloadMaxConcurrentRequests = c.OverloadMaxConcurrentRequests
+		to.OverloadTargetQueueDelay = c.OverloadTargetQueueDelay
+		to.DispatchOverloadMaxConcurrentRequests = c.DispatchOverloadMaxConcurrentRequests
+		to.DispatchOverloadTargetQueueDelay = c.DispatchOverloadTargetQueueDelay
+		to.IntegrityCheckInterval = c.IntegrityCheckInterval
+		to.IntegrityRepairMode = c.IntegrityRepairMode
+		to.IntegrityQuarantinePath = c.IntegrityQuarantinePath
 		to.DashboardAPI = c.DashboardAPI
 		to.MetricsAPI = c.MetricsAPI
+		to.MetricsAdminEndpointsEnabled = c.MetricsAdminEndpointsEnabled
 		to.UnaryMiddleware = c.UnaryMiddleware
 		to.StreamingMiddleware = c.StreamingMiddleware
 		to.DispatchUnaryMiddleware = c.DispatchUnaryMiddleware
 		to.DispatchStreamingMiddleware = c.DispatchStreamingMiddleware
+		to.DispatcherMiddleware = c.DispatcherMiddleware
 		to.SilentlyDisableTelemetry = c.SilentlyDisableTelemetry
 		to.TelemetryCAOverridePath = c.TelemetryCAOverridePath
 		to.TelemetryEndpoint = c.TelemetryEndpoint
@@ -173,6 +216,20 @@ func WithDatastore(datastore datastore1.Datastore) ConfigOption {
 	}
 }
 
+// WithDatastoreRevisionQuantizationOverrides returns an option that can append DatastoreRevisionQuantizationOverridess to Config.DatastoreRevisionQuantizationOverrides
+func WithDatastoreRevisionQuantizationOverrides(key string, value string) ConfigOption {
+	return func(c *Config) {
+		c.DatastoreRevisionQuantizationOverrides[key] = value
+	}
+}
+
+// SetDatastoreRevisionQuantizationOverrides returns an option that can set DatastoreRevisionQuantizationOverrides on a Config
+func SetDatastoreRevisionQuantizationOverrides(datastoreRevisionQuantizationOverrides map[string]string) ConfigOption {
+	return func(c *Config) {
+		c.DatastoreRevisionQuantizationOverrides = datastoreRevisionQuantizationOverrides
+	}
+}
+
 // WithNamespaceCacheConfig returns an option that can set NamespaceCacheConfig on a Config
 func WithNamespaceCacheConfig(namespaceCacheConfig CacheConfig) ConfigOption {
 	return func(c *Config) {
@@ -208,6 +265,34 @@ func WithDispatchConcurrencyLimit(dispatchConcurrencyLimit uint16) ConfigOption
 	}
 }
 
+// WithDispatchConcurrencyLimitOverrides returns an option that can append DispatchConcurrencyLimitOverridess to Config.DispatchConcurrencyLimitOverrides
+func WithDispatchConcurrencyLimitOverrides(key string, value int) ConfigOption {
+	return func(c *Config) {
+		c.DispatchConcurrencyLimitOverrides[key] = value
+	}
+}
+
+// SetDispatchConcurrencyLimitOverrides returns an option that can set DispatchConcurrencyLimitOverrides on a Config
+func SetDispatchConcurrencyLimitOverrides(dispatchConcurrencyLimitOverrides map[string]int) ConfigOption {
+	return func(c *Config) {
+		c.DispatchConcurrencyLimitOverrides = dispatchConcurrencyLimitOverrides
+	}
+}
+
+// WithDispatchBudgets returns an option that can append DispatchBudgetss to Config.DispatchBudgets
+func WithDispatchBudgets(key string, value int) ConfigOption {
+	return func(c *Config) {
+		c.DispatchBudgets[key] = value
+	}
+}
+
+// SetDispatchBudgets returns an option that can set DispatchBudgets on a Config
+func SetDispatchBudgets(dispatchBudgets map[string]int) ConfigOption {
+	return func(c *Config) {
+		c.DispatchBudgets = dispatchBudgets
+	}
+}
+
 // WithDispatchUpstreamAddr returns an option that can set DispatchUpstreamAddr on a Config
 func WithDispatchUpstreamAddr(dispatchUpstreamAddr string) ConfigOption {
 	return func(c *Config) {
@@ -222,6 +307,41 @@ func WithDispatchUpstreamCAPath(dispatchUpstreamCAPath string) ConfigOption {
 	}
 }
 
+// WithDispatchUpstreamTLSCertPath returns an option that can set DispatchUpstreamTLSCertPath on a Config
+func WithDispatchUpstreamTLSCertPath(dispatchUpstreamTLSCertPath string) ConfigOption {
+	return func(c *Config) {
+		c.DispatchUpstreamTLSCertPath = dispatchUpstreamTLSCertPath
+	}
+}
+
+// WithDispatchUpstreamTLSKeyPath returns an option that can set DispatchUpstreamTLSKeyPath on a Config
+func WithDispatchUpstreamTLSKeyPath(dispatchUpstreamTLSKeyPath string) ConfigOption {
+	return func(c *Config) {
+		c.DispatchUpstreamTLSKeyPath = dispatchUpstreamTLSKeyPath
+	}
+}
+
+// WithDispatchClusterTLSClientCAPath returns an option that can set DispatchClusterTLSClientCAPath on a Config
+func WithDispatchClusterTLSClientCAPath(dispatchClusterTLSClientCAPath string) ConfigOption {
+	return func(c *Config) {
+		c.DispatchClusterTLSClientCAPath = dispatchClusterTLSClientCAPath
+	}
+}
+
+// WithDispatchPeerSPIFFEIDs returns an option that can append DispatchPeerSPIFFEIDss to Config.DispatchPeerSPIFFEIDs
+func WithDispatchPeerSPIFFEIDs(dispatchPeerSPIFFEIDs string) ConfigOption {
+	return func(c *Config) {
+		c.DispatchPeerSPIFFEIDs = append(c.DispatchPeerSPIFFEIDs, dispatchPeerSPIFFEIDs)
+	}
+}
+
+// SetDispatchPeerSPIFFEIDs returns an option that can set DispatchPeerSPIFFEIDs on a Config
+func SetDispatchPeerSPIFFEIDs(dispatchPeerSPIFFEIDs []string) ConfigOption {
+	return func(c *Config) {
+		c.DispatchPeerSPIFFEIDs = dispatchPeerSPIFFEIDs
+	}
+}
+
 // WithDispatchClientMetricsPrefix returns an option that can set DispatchClientMetricsPrefix on a Config
 func WithDispatchClientMetricsPrefix(dispatchClientMetricsPrefix string) ConfigOption {
 	return func(c *Config) {
@@ -257,6 +377,195 @@ func WithClusterDispatchCacheConfig(clusterDispatchCacheConfig CacheConfig) Conf
 	}
 }
 
+// WithDispatchCacheNegativeResultsDisabled returns an option that can set DispatchCacheNegativeResultsDisabled on a Config
+func WithDispatchCacheNegativeResultsDisabled(dispatchCacheNegativeResultsDisabled bool) ConfigOption {
+	return func(c *Config) {
+		c.DispatchCacheNegativeResultsDisabled = dispatchCacheNegativeResultsDisabled
+	}
+}
+
+// WithDispatchCacheNegativeResultTTL returns an option that can set DispatchCacheNegativeResultTTL on a Config
+func WithDispatchCacheNegativeResultTTL(dispatchCacheNegativeResultTTL time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchCacheNegativeResultTTL = dispatchCacheNegativeResultTTL
+	}
+}
+
+// WithDispatchCacheCaveatContextEnabled returns an option that can set DispatchCacheCaveatContextEnabled on a Config
+func WithDispatchCacheCaveatContextEnabled(dispatchCacheCaveatContextEnabled bool) ConfigOption {
+	return func(c *Config) {
+		c.DispatchCacheCaveatContextEnabled = dispatchCacheCaveatContextEnabled
+	}
+}
+
+// WithDispatchCacheSnapshotPath returns an option that can set DispatchCacheSnapshotPath on a Config
+func WithDispatchCacheSnapshotPath(dispatchCacheSnapshotPath string) ConfigOption {
+	return func(c *Config) {
+		c.DispatchCacheSnapshotPath = dispatchCacheSnapshotPath
+	}
+}
+
+// WithDispatchCacheSnapshotInterval returns an option that can set DispatchCacheSnapshotInterval on a Config
+func WithDispatchCacheSnapshotInterval(dispatchCacheSnapshotInterval time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchCacheSnapshotInterval = dispatchCacheSnapshotInterval
+	}
+}
+
+// WithDispatchSharedCacheURI returns an option that can set DispatchSharedCacheURI on a Config
+func WithDispatchSharedCacheURI(dispatchSharedCacheURI string) ConfigOption {
+	return func(c *Config) {
+		c.DispatchSharedCacheURI = dispatchSharedCacheURI
+	}
+}
+
+// WithDispatchSharedCacheTTL returns an option that can set DispatchSharedCacheTTL on a Config
+func WithDispatchSharedCacheTTL(dispatchSharedCacheTTL time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchSharedCacheTTL = dispatchSharedCacheTTL
+	}
+}
+
+// WithDispatchSharedCacheTimeout returns an option that can set DispatchSharedCacheTimeout on a Config
+func WithDispatchSharedCacheTimeout(dispatchSharedCacheTimeout time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchSharedCacheTimeout = dispatchSharedCacheTimeout
+	}
+}
+
+// WithDispatchSharedCacheSerialization returns an option that can set DispatchSharedCacheSerialization on a Config
+func WithDispatchSharedCacheSerialization(dispatchSharedCacheSerialization string) ConfigOption {
+	return func(c *Config) {
+		c.DispatchSharedCacheSerialization = dispatchSharedCacheSerialization
+	}
+}
+
+// WithDispatchHedgingEnabled returns an option that can set DispatchHedgingEnabled on a Config
+func WithDispatchHedgingEnabled(dispatchHedgingEnabled bool) ConfigOption {
+	return func(c *Config) {
+		c.DispatchHedgingEnabled = dispatchHedgingEnabled
+	}
+}
+
+// WithDispatchHedgingInitialSlowValue returns an option that can set DispatchHedgingInitialSlowValue on a Config
+func WithDispatchHedgingInitialSlowValue(dispatchHedgingInitialSlowValue time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchHedgingInitialSlowValue = dispatchHedgingInitialSlowValue
+	}
+}
+
+// WithDispatchHedgingMaxRequests returns an option that can set DispatchHedgingMaxRequests on a Config
+func WithDispatchHedgingMaxRequests(dispatchHedgingMaxRequests uint64) ConfigOption {
+	return func(c *Config) {
+		c.DispatchHedgingMaxRequests = dispatchHedgingMaxRequests
+	}
+}
+
+// WithDispatchHedgingQuantile returns an option that can set DispatchHedgingQuantile on a Config
+func WithDispatchHedgingQuantile(dispatchHedgingQuantile float64) ConfigOption {
+	return func(c *Config) {
+		c.DispatchHedgingQuantile = dispatchHedgingQuantile
+	}
+}
+
+// WithDispatchCheckBatchWindow returns an option that can set DispatchCheckBatchWindow on a Config
+func WithDispatchCheckBatchWindow(dispatchCheckBatchWindow time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchCheckBatchWindow = dispatchCheckBatchWindow
+	}
+}
+
+// WithDispatchHopOverhead returns an option that can set DispatchHopOverhead on a Config
+func WithDispatchHopOverhead(dispatchHopOverhead time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchHopOverhead = dispatchHopOverhead
+	}
+}
+
+// WithDispatchFallbackPolicy returns an option that can set DispatchFallbackPolicy on a Config
+func WithDispatchFallbackPolicy(dispatchFallbackPolicy string) ConfigOption {
+	return func(c *Config) {
+		c.DispatchFallbackPolicy = dispatchFallbackPolicy
+	}
+}
+
+// WithDispatchHotKeySampleRate returns an option that can set DispatchHotKeySampleRate on a Config
+func WithDispatchHotKeySampleRate(dispatchHotKeySampleRate float64) ConfigOption {
+	return func(c *Config) {
+		c.DispatchHotKeySampleRate = dispatchHotKeySampleRate
+	}
+}
+
+// WithDispatchHotKeyWindow returns an option that can set DispatchHotKeyWindow on a Config
+func WithDispatchHotKeyWindow(dispatchHotKeyWindow time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchHotKeyWindow = dispatchHotKeyWindow
+	}
+}
+
+// WithDispatchPrefetchEnabled returns an option that can set DispatchPrefetchEnabled on a Config
+func WithDispatchPrefetchEnabled(dispatchPrefetchEnabled bool) ConfigOption {
+	return func(c *Config) {
+		c.DispatchPrefetchEnabled = dispatchPrefetchEnabled
+	}
+}
+
+// WithDispatchPrefetchLead returns an option that can set DispatchPrefetchLead on a Config
+func WithDispatchPrefetchLead(dispatchPrefetchLead time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchPrefetchLead = dispatchPrefetchLead
+	}
+}
+
+// WithDispatchHashringWeight returns an option that can set DispatchHashringWeight on a Config
+func WithDispatchHashringWeight(dispatchHashringWeight uint16) ConfigOption {
+	return func(c *Config) {
+		c.DispatchHashringWeight = dispatchHashringWeight
+	}
+}
+
+// WithDispatchHashringWeightPerCPU returns an option that can set DispatchHashringWeightPerCPU on a Config
+func WithDispatchHashringWeightPerCPU(dispatchHashringWeightPerCPU uint16) ConfigOption {
+	return func(c *Config) {
+		c.DispatchHashringWeightPerCPU = dispatchHashringWeightPerCPU
+	}
+}
+
+// WithDispatchCompression returns an option that can set DispatchCompression on a Config
+func WithDispatchCompression(dispatchCompression string) ConfigOption {
+	return func(c *Config) {
+		c.DispatchCompression = dispatchCompression
+	}
+}
+
+// WithDispatchKeepaliveTime returns an option that can set DispatchKeepaliveTime on a Config
+func WithDispatchKeepaliveTime(dispatchKeepaliveTime time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchKeepaliveTime = dispatchKeepaliveTime
+	}
+}
+
+// WithDispatchKeepaliveTimeout returns an option that can set DispatchKeepaliveTimeout on a Config
+func WithDispatchKeepaliveTimeout(dispatchKeepaliveTimeout time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchKeepaliveTimeout = dispatchKeepaliveTimeout
+	}
+}
+
+// WithDispatchInitialWindowSize returns an option that can set DispatchInitialWindowSize on a Config
+func WithDispatchInitialWindowSize(dispatchInitialWindowSize int32) ConfigOption {
+	return func(c *Config) {
+		c.DispatchInitialWindowSize = dispatchInitialWindowSize
+	}
+}
+
+// WithDispatchInitialConnWindowSize returns an option that can set DispatchInitialConnWindowSize on a Config
+func WithDispatchInitialConnWindowSize(dispatchInitialConnWindowSize int32) ConfigOption {
+	return func(c *Config) {
+		c.DispatchInitialConnWindowSize = dispatchInitialConnWindowSize
+	}
+}
+
 // WithDisableV1SchemaAPI returns an option that can set DisableV1SchemaAPI on a Config
 func WithDisableV1SchemaAPI(disableV1SchemaAPI bool) ConfigOption {
 	return func(c *Config) {
@@ -292,6 +601,55 @@ func WithExperimentalCaveatsEnabled(experimentalCaveatsEnabled bool) ConfigOptio
 	}
 }
 
+// WithOverloadMaxConcurrentRequests returns an option that can set OverloadMaxConcurrentRequests on a Config
+func WithOverloadMaxConcurrentRequests(overloadMaxConcurrentRequests uint32) ConfigOption {
+	return func(c *Config) {
+		c.OverloadMaxConcurrentRequests = overloadMaxConcurrentRequests
+	}
+}
+
+// WithOverloadTargetQueueDelay returns an option that can set OverloadTargetQueueDelay on a Config
+func WithOverloadTargetQueueDelay(overloadTargetQueueDelay time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.OverloadTargetQueueDelay = overloadTargetQueueDelay
+	}
+}
+
+// WithDispatchOverloadMaxConcurrentRequests returns an option that can set DispatchOverloadMaxConcurrentRequests on a Config
+func WithDispatchOverloadMaxConcurrentRequests(dispatchOverloadMaxConcurrentRequests uint32) ConfigOption {
+	return func(c *Config) {
+		c.DispatchOverloadMaxConcurrentRequests = dispatchOverloadMaxConcurrentRequests
+	}
+}
+
+// WithDispatchOverloadTargetQueueDelay returns an option that can set DispatchOverloadTargetQueueDelay on a Config
+func WithDispatchOverloadTargetQueueDelay(dispatchOverloadTargetQueueDelay time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.DispatchOverloadTargetQueueDelay = dispatchOverloadTargetQueueDelay
+	}
+}
+
+// WithIntegrityCheckInterval returns an option that can set IntegrityCheckInterval on a Config
+func WithIntegrityCheckInterval(integrityCheckInterval time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.IntegrityCheckInterval = integrityCheckInterval
+	}
+}
+
+// WithIntegrityRepairMode returns an option that can set IntegrityRepairMode on a Config
+func WithIntegrityRepairMode(integrityRepairMode string) ConfigOption {
+	return func(c *Config) {
+		c.IntegrityRepairMode = integrityRepairMode
+	}
+}
+
+// WithIntegrityQuarantinePath returns an option that can set IntegrityQuarantinePath on a Config
+func WithIntegrityQuarantinePath(integrityQuarantinePath string) ConfigOption {
+	return func(c *Config) {
+		c.IntegrityQuarantinePath = integrityQuarantinePath
+	}
+}
+
 // WithDashboardAPI returns an option that can set DashboardAPI on a Config
 func WithDashboardAPI(dashboardAPI util.HTTPServerConfig) ConfigOption {
 	return func(c *Config) {
@@ -306,6 +664,13 @@ func WithMetricsAPI(metricsAPI util.HTTPServerConfig) ConfigOption {
 	}
 }
 
+// WithMetricsAdminEndpointsEnabled returns an option that can set MetricsAdminEndpointsEnabled on a Config
+func WithMetricsAdminEndpointsEnabled(metricsAdminEndpointsEnabled bool) ConfigOption {
+	return func(c *Config) {
+		c.MetricsAdminEndpointsEnabled = metricsAdminEndpointsEnabled
+	}
+}
+
 // WithUnaryMiddleware returns an option that can append UnaryMiddlewares to Config.UnaryMiddleware
 func WithUnaryMiddleware(unaryMiddleware grpc.UnaryServerInterceptor) ConfigOption {
 	return func(c *Config) {
@@ -362,6 +727,20 @@ func SetDispatchStreamingMiddleware(dispatchStreamingMiddleware []grpc.StreamSer
 	}
 }
 
+// WithDispatcherMiddleware returns an option that can append DispatcherMiddlewares to Config.DispatcherMiddleware
+func WithDispatcherMiddleware(dispatcherMiddleware dispatch.Middleware) ConfigOption {
+	return func(c *Config) {
+		c.DispatcherMiddleware = append(c.DispatcherMiddleware, dispatcherMiddleware)
+	}
+}
+
+// SetDispatcherMiddleware returns an option that can set DispatcherMiddleware on a Config
+func SetDispatcherMiddleware(dispatcherMiddleware []dispatch.Middleware) ConfigOption {
+	return func(c *Config) {
+		c.DispatcherMiddleware = dispatcherMiddleware
+	}
+}
+
 // WithSilentlyDisableTelemetry returns an option that can set SilentlyDisableTelemetry on a Config
 func WithSilentlyDisableTelemetry(silentlyDisableTelemetry bool) ConfigOption {
 	return func(c *Config) {
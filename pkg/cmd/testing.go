@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -18,10 +19,22 @@ func RegisterTestingFlags(cmd *cobra.Command, config *testserver.Config) {
 	util.RegisterHTTPServerFlags(cmd.Flags(), &config.ReadOnlyHTTPGateway, "readonly-http", "read-only HTTP", ":8082", false)
 
 	cmd.Flags().StringSliceVar(&config.LoadConfigs, "load-configs", []string{}, "configuration yaml files to load")
+	cmd.Flags().StringVar(&config.SnapshotDir, "snapshot-dir", "", "directory to which the datastore of each token is saved as the server stops, and from which it is restored in place of loading the configuration files (omit to keep datastores only in memory)")
+	cmd.Flags().Uint64Var(&config.MaxRelationships, "max-relationships", 0, "maximum number of relationships stored in the datastore of each token, beyond which writes fail (0 for no limit)")
+	cmd.Flags().IntVar(&config.MaxRevisions, "max-revisions", 0, "maximum number of revisions retained by the datastore of each token, beyond which the oldest are evicted (0 for no limit)")
+	cmd.Flags().StringVar(&config.RedisURI, "redis-uri", "", `URI of a Redis server storing the datastore of each token in place of memory, which is kept across restarts and may be shared by many test servers (e.g. "redis://localhost:6379/0"); --snapshot-dir, --max-relationships and --max-revisions are ignored`)
 
 	// Flags for API behavior
 	cmd.Flags().Uint16Var(&config.MaximumUpdatesPerWrite, "write-relationships-max-updates-per-call", 1000, "maximum number of updates allowed for WriteRelationships calls")
 	cmd.Flags().Uint16Var(&config.MaximumPreconditionCount, "update-relationships-max-preconditions-per-call", 1000, "maximum number of preconditions allowed for WriteRelationships and DeleteRelationships calls")
+
+	// Flags for injecting datastore faults
+	cmd.Flags().Float64Var(&config.FaultInjection.LatencyRatio, "fault-injection-latency-ratio", 0, "ratio of datastore operations (0.0-1.0) delayed by --fault-injection-latency")
+	cmd.Flags().DurationVar(&config.FaultInjection.Latency, "fault-injection-latency", 100*time.Millisecond, "latency added to datastore operations selected by --fault-injection-latency-ratio")
+	cmd.Flags().Float64Var(&config.FaultInjection.TransientErrorRatio, "fault-injection-transient-error-ratio", 0, "ratio of datastore operations (0.0-1.0) which fail with a retryable unavailable error")
+	cmd.Flags().Float64Var(&config.FaultInjection.SerializationFailureRatio, "fault-injection-serialization-failure-ratio", 0, "ratio of datastore write transactions (0.0-1.0) which are rolled back with a retryable error")
+	cmd.Flags().Float64Var(&config.FaultInjection.RevisionSkewRatio, "fault-injection-revision-skew-ratio", 0, "ratio of optimized revisions (0.0-1.0) replaced with an older revision")
+	cmd.Flags().Int64Var(&config.FaultInjection.Seed, "fault-injection-seed", 0, "seed for choosing the datastore operations into which faults are injected (0 for a random seed)")
 }
 
 func NewTestingCommand(programName string, config *testserver.Config) *cobra.Command {
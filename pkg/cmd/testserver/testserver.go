@@ -8,6 +8,8 @@ import (
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/datastore/proxy"
 	"github.com/authzed/spicedb/internal/dispatch/graph"
 	"github.com/authzed/spicedb/internal/gateway"
 	log "github.com/authzed/spicedb/internal/logging"
@@ -33,6 +35,11 @@ type Config struct {
 	LoadConfigs              []string
 	MaximumUpdatesPerWrite   uint16
 	MaximumPreconditionCount uint16
+	FaultInjection           proxy.FaultInjectionConfig
+	SnapshotDir              string
+	MaxRelationships         uint64
+	MaxRevisions             int
+	RedisURI                 string
 }
 
 type RunnableTestServer interface {
@@ -48,9 +55,23 @@ func (dr datastoreReady) IsReady(ctx context.Context) (bool, error) {
 }
 
 func (c *Config) Complete() (RunnableTestServer, error) {
+	if err := c.FaultInjection.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid fault injection config: %w", err)
+	}
+
 	dispatcher := graph.NewLocalOnlyDispatcher(10)
 
-	datastoreMiddleware := pertoken.NewMiddleware(c.LoadConfigs)
+	datastoreMiddleware := pertoken.NewMiddleware(c.LoadConfigs, c.FaultInjection, c.SnapshotDir,
+		memdb.MaxRelationships(c.MaxRelationships),
+		memdb.MaxRevisions(c.MaxRevisions),
+	)
+	if c.RedisURI != "" {
+		var err error
+		datastoreMiddleware, err = pertoken.NewRedisMiddleware(c.LoadConfigs, c.FaultInjection, c.RedisURI)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis URI: %w", err)
+		}
+	}
 
 	healthManager := health.NewHealthManager(dispatcher, &datastoreReady{})
 
@@ -141,6 +162,7 @@ func (c *Config) Complete() (RunnableTestServer, error) {
 		gatewayServer:         gatewayServer,
 		readOnlyGatewayServer: readOnlyGatewayServer,
 		healthManager:         healthManager,
+		datastoreMiddleware:   datastoreMiddleware,
 	}, nil
 }
 
@@ -152,6 +174,8 @@ type completedTestServer struct {
 	readOnlyGatewayServer util.RunnableHTTPServer
 
 	healthManager health.Manager
+
+	datastoreMiddleware *pertoken.MiddlewareForTesting
 }
 
 func (c *completedTestServer) Run(ctx context.Context) error {
@@ -183,6 +207,10 @@ func (c *completedTestServer) Run(ctx context.Context) error {
 		log.Warn().Err(err).Msg("error shutting down servers")
 	}
 
+	if err := c.datastoreMiddleware.Close(); err != nil {
+		log.Warn().Err(err).Msg("error closing datastores")
+	}
+
 	return nil
 }
 
@@ -1,7 +1,10 @@
 // Code generated by github.com/ecordell/optgen. DO NOT EDIT.
 package testserver
 
-import util "github.com/authzed/spicedb/pkg/cmd/util"
+import (
+	proxy "github.com/authzed/spicedb/internal/datastore/proxy"
+	util "github.com/authzed/spicedb/pkg/cmd/util"
+)
 
 type ConfigOption func(c *Config)
 
@@ -24,6 +27,11 @@ func (c *Config) ToOption() ConfigOption {
 		to.LoadConfigs = c.LoadConfigs
 		to.MaximumUpdatesPerWrite = c.MaximumUpdatesPerWrite
 		to.MaximumPreconditionCount = c.MaximumPreconditionCount
+		to.FaultInjection = c.FaultInjection
+		to.SnapshotDir = c.SnapshotDir
+		to.MaxRelationships = c.MaxRelationships
+		to.MaxRevisions = c.MaxRevisions
+		to.RedisURI = c.RedisURI
 	}
 }
 
@@ -90,3 +98,38 @@ func WithMaximumPreconditionCount(maximumPreconditionCount uint16) ConfigOption
 		c.MaximumPreconditionCount = maximumPreconditionCount
 	}
 }
+
+// WithFaultInjection returns an option that can set FaultInjection on a Config
+func WithFaultInjection(faultInjection proxy.FaultInjectionConfig) ConfigOption {
+	return func(c *Config) {
+		c.FaultInjection = faultInjection
+	}
+}
+
+// WithSnapshotDir returns an option that can set SnapshotDir on a Config
+func WithSnapshotDir(snapshotDir string) ConfigOption {
+	return func(c *Config) {
+		c.SnapshotDir = snapshotDir
+	}
+}
+
+// WithMaxRelationships returns an option that can set MaxRelationships on a Config
+func WithMaxRelationships(maxRelationships uint64) ConfigOption {
+	return func(c *Config) {
+		c.MaxRelationships = maxRelationships
+	}
+}
+
+// WithMaxRevisions returns an option that can set MaxRevisions on a Config
+func WithMaxRevisions(maxRevisions int) ConfigOption {
+	return func(c *Config) {
+		c.MaxRevisions = maxRevisions
+	}
+}
+
+// WithRedisURI returns an option that can set RedisURI on a Config
+func WithRedisURI(redisURI string) ConfigOption {
+	return func(c *Config) {
+		c.RedisURI = redisURI
+	}
+}
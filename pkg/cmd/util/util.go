@@ -21,6 +21,9 @@ import (
 
 	// Register Snappy S2 compression
 	_ "github.com/mostynb/go-grpc-compression/experimental/s2"
+	// Register gzip and zstd compression, for dispatch between nodes
+	_ "github.com/mostynb/go-grpc-compression/zstd"
+	_ "google.golang.org/grpc/encoding/gzip"
 
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	// Register cert watcher metrics
@@ -43,6 +46,20 @@ type GRPCServerConfig struct {
 	ClientCAPath string
 	MaxWorkers   uint32
 
+	// Transport tuning, which has no flags of its own. A zero value keeps the
+	// gRPC default.
+	KeepaliveTime         time.Duration
+	KeepaliveTimeout      time.Duration
+	InitialWindowSize     int32
+	InitialConnWindowSize int32
+
+	// Mutual TLS, which has no flags of its own. If ClientAuthCAPath is set,
+	// clients must present a certificate issued by the CA at that path, which
+	// must also carry one of the AllowedPeerIDs, if any are given. The
+	// AllowedPeerIDs are SPIFFE IDs or trust domains.
+	ClientAuthCAPath string
+	AllowedPeerIDs   []string
+
 	flagPrefix string
 }
 
@@ -82,7 +99,22 @@ func (c *GRPCServerConfig) Complete(level zerolog.Level, svcRegistrationFn func(
 	}
 	opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
 		MaxConnectionAge: c.MaxConnAge,
+		Time:             c.KeepaliveTime,
+		Timeout:          c.KeepaliveTimeout,
 	}), grpc.NumStreamWorkers(c.MaxWorkers))
+	if c.KeepaliveTime > 0 {
+		// Clients are expected to ping as often as the server does.
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             c.KeepaliveTime,
+			PermitWithoutStream: true,
+		}))
+	}
+	if c.InitialWindowSize > 0 {
+		opts = append(opts, grpc.InitialWindowSize(c.InitialWindowSize))
+	}
+	if c.InitialConnWindowSize > 0 {
+		opts = append(opts, grpc.InitialConnWindowSize(c.InitialConnWindowSize))
+	}
 
 	tlsOpts, certWatcher, err := c.tlsOpts()
 	if err != nil {
@@ -90,7 +122,7 @@ func (c *GRPCServerConfig) Complete(level zerolog.Level, svcRegistrationFn func(
 	}
 	opts = append(opts, tlsOpts...)
 
-	clientCreds, err := c.clientCreds()
+	clientCreds, err := c.clientCreds(certWatcher)
 	if err != nil {
 		return nil, err
 	}
@@ -105,6 +137,7 @@ func (c *GRPCServerConfig) Complete(level zerolog.Level, svcRegistrationFn func(
 		Str("service", c.flagPrefix).
 		Uint32("workers", c.MaxWorkers).
 		Bool("insecure", c.TLSCertPath == "" && c.TLSKeyPath == "").
+		Bool("mtls", c.ClientAuthCAPath != "").
 		Msg("grpc server started serving")
 
 	srv := grpc.NewServer(opts...)
@@ -156,23 +189,44 @@ func (c *GRPCServerConfig) listenerAndDialer() (net.Listener, DialFunc, NetDialF
 func (c *GRPCServerConfig) tlsOpts() ([]grpc.ServerOption, *certwatcher.CertWatcher, error) {
 	switch {
 	case c.TLSCertPath == "" && c.TLSKeyPath == "":
+		if c.ClientAuthCAPath != "" {
+			return nil, nil, fmt.Errorf("client authentication of %s requires a TLS certificate and key", c.flagPrefix)
+		}
 		return nil, nil, nil
 	case c.TLSCertPath != "" && c.TLSKeyPath != "":
 		watcher, err := certwatcher.New(c.TLSCertPath, c.TLSKeyPath)
 		if err != nil {
 			return nil, nil, err
 		}
-		creds := credentials.NewTLS(&tls.Config{
+		tlsConfig := &tls.Config{
 			GetCertificate: watcher.GetCertificate,
 			MinVersion:     tls.VersionTLS12,
-		})
-		return []grpc.ServerOption{grpc.Creds(creds)}, watcher, nil
+		}
+		if c.ClientAuthCAPath != "" {
+			pool, err := x509util.CustomCertPool(c.ClientAuthCAPath)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if len(c.AllowedPeerIDs) > 0 {
+				matcher, err := x509util.NewSPIFFEMatcher(c.AllowedPeerIDs)
+				if err != nil {
+					return nil, nil, err
+				}
+				tlsConfig.ClientAuth = tls.RequireAnyClientCert
+				tlsConfig.VerifyPeerCertificate = matcher.VerifyPeer(pool, x509.ExtKeyUsageClientAuth)
+			} else {
+				tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+				tlsConfig.ClientCAs = pool
+			}
+		}
+		return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(tlsConfig))}, watcher, nil
 	default:
 		return nil, nil, nil
 	}
 }
 
-func (c *GRPCServerConfig) clientCreds() (credentials.TransportCredentials, error) {
+func (c *GRPCServerConfig) clientCreds(watcher *certwatcher.CertWatcher) (credentials.TransportCredentials, error) {
 	switch {
 	case c.TLSCertPath == "" && c.TLSKeyPath == "":
 		return insecure.NewCredentials(), nil
@@ -188,7 +242,23 @@ func (c *GRPCServerConfig) clientCreds() (credentials.TransportCredentials, erro
 			return nil, err
 		}
 
-		return credentials.NewTLS(&tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}), nil
+		tlsConfig := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+		if c.ClientAuthCAPath != "" {
+			// Clients of the server itself authenticate with its certificate.
+			tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return watcher.GetCertificate(nil)
+			}
+
+			if len(c.AllowedPeerIDs) > 0 {
+				matcher, err := x509util.NewSPIFFEMatcher(c.AllowedPeerIDs)
+				if err != nil {
+					return nil, err
+				}
+				tlsConfig.InsecureSkipVerify = true
+				tlsConfig.VerifyPeerCertificate = matcher.VerifyPeer(pool, x509.ExtKeyUsageServerAuth)
+			}
+		}
+		return credentials.NewTLS(tlsConfig), nil
 	default:
 		return nil, nil
 	}
@@ -2,10 +2,27 @@ package util
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 )
 
 func TestDisabledGRPC(t *testing.T) {
@@ -22,3 +39,152 @@ func TestDisabledHTTP(t *testing.T) {
 	require.NoError(t, s.ListenAndServe())
 	s.Close()
 }
+
+func TestTunedGRPC(t *testing.T) {
+	require := require.New(t)
+
+	s, err := (&GRPCServerConfig{
+		Enabled:               true,
+		Network:               BufferedNetwork,
+		KeepaliveTime:         time.Second,
+		KeepaliveTimeout:      time.Second,
+		InitialWindowSize:     1 << 20,
+		InitialConnWindowSize: 1 << 21,
+	}).Complete(zerolog.InfoLevel, func(server *grpc.Server) {
+		healthpb.RegisterHealthServer(server, health.NewServer())
+	})
+	require.NoError(err)
+	go func() {
+		_ = s.Listen(context.Background())()
+	}()
+	defer s.GracefulStop()
+
+	for _, compressor := range []string{"gzip", "zstd"} {
+		conn, err := s.DialContext(context.Background(),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithDefaultCallOptions(grpc.UseCompressor(compressor)),
+			grpc.WithKeepaliveParams(keepalive.ClientParameters{Time: time.Second, PermitWithoutStream: true}),
+			grpc.WithInitialWindowSize(1<<20),
+		)
+		require.NoError(err)
+
+		resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(err)
+		require.Equal(healthpb.HealthCheckResponse_SERVING, resp.Status)
+		require.NoError(conn.Close())
+	}
+}
+
+func TestMutualTLSGRPC(t *testing.T) {
+	dir := t.TempDir()
+	caPath, certPath, keyPath := writeTestSVID(t, dir, "spiffe://example.org/ns/spicedb/sa/spicedb")
+
+	testCases := []struct {
+		name           string
+		allowedPeerIDs []string
+		expectedError  string
+	}{
+		{"any client certificate issued by the CA", nil, ""},
+		{"allowed SPIFFE ID", []string{"spiffe://example.org/ns/spicedb/sa/spicedb"}, ""},
+		{"allowed trust domain", []string{"spiffe://example.org"}, ""},
+		{"disallowed SPIFFE ID", []string{"spiffe://example.org/ns/spicedb/sa/other"}, "does not carry an allowed SPIFFE ID"},
+		{"disallowed trust domain", []string{"spiffe://other.org"}, "does not carry an allowed SPIFFE ID"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			s, err := (&GRPCServerConfig{
+				Enabled:          true,
+				Network:          BufferedNetwork,
+				TLSCertPath:      certPath,
+				TLSKeyPath:       keyPath,
+				ClientCAPath:     caPath,
+				ClientAuthCAPath: caPath,
+				AllowedPeerIDs:   tc.allowedPeerIDs,
+			}).Complete(zerolog.InfoLevel, func(server *grpc.Server) {
+				healthpb.RegisterHealthServer(server, health.NewServer())
+			})
+			require.NoError(err)
+			go func() {
+				_ = s.Listen(context.Background())()
+			}()
+			defer s.GracefulStop()
+
+			conn, err := s.DialContext(context.Background())
+			require.NoError(err)
+			defer conn.Close()
+
+			_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+			if tc.expectedError != "" {
+				require.ErrorContains(err, tc.expectedError)
+				return
+			}
+			require.NoError(err)
+
+			// Clients without a certificate are rejected.
+			anonymous, err := grpc.DialContext(context.Background(), BufferedNetwork,
+				grpc.WithContextDialer(s.NetDialContext),
+				grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})), //nolint:gosec
+			)
+			require.NoError(err)
+			defer anonymous.Close()
+
+			_, err = healthpb.NewHealthClient(anonymous).Check(context.Background(), &healthpb.HealthCheckRequest{})
+			require.Error(err)
+		})
+	}
+}
+
+func TestMutualTLSRequiresCertificate(t *testing.T) {
+	_, err := (&GRPCServerConfig{
+		Enabled:          true,
+		Network:          BufferedNetwork,
+		ClientAuthCAPath: "/ca.pem",
+	}).Complete(zerolog.InfoLevel, func(server *grpc.Server) {})
+	require.ErrorContains(t, err, "requires a TLS certificate")
+}
+
+// writeTestSVID writes a CA and a certificate issued by it carrying the SPIFFE ID, for both
+// server and client authentication, returning their paths. The certificate is also valid for
+// the host name of the buffered network, for tests which do not verify SPIFFE IDs.
+func writeTestSVID(t *testing.T, dir, spiffeID string) (caPath, certPath, keyPath string) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	id, err := url.Parse(spiffeID)
+	require.NoError(t, err)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	certDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		URIs:         []*url.URL{id},
+		DNSNames:     []string{BufferedNetwork},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}, caTemplate, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	write := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+		return path
+	}
+	return write("ca.pem", "CERTIFICATE", caDER), write("cert.pem", "CERTIFICATE", certDER), write("key.pem", "EC PRIVATE KEY", keyDER)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"sync"
 )
@@ -14,6 +15,10 @@ var (
 	ErrNotEnoughMembers    = errors.New("not enough member nodes to satisfy request")
 )
 
+// DefaultWeight is the weight of members which do not implement WeightedMember. A member with
+// the default weight is placed on the hashring with replicationFactor virtual nodes.
+const DefaultWeight = 100
+
 // HasherFunc is the interface for any function that can act as a hasher.
 type HasherFunc func([]byte) uint64
 
@@ -23,6 +28,13 @@ type Member interface {
 	Key() string
 }
 
+// WeightedMember is a Member whose number of virtual nodes, and hence its share of keys, is
+// scaled by its weight relative to DefaultWeight.
+type WeightedMember interface {
+	Member
+	Weight() uint16
+}
+
 // Hashring provides a ring consistent hash implementation using a configurable number of virtual
 // nodes. It is internally synchronized and thread-safe.
 type Hashring struct {
@@ -59,16 +71,52 @@ func NewHashring(hasher HasherFunc, replicationFactor uint16) *Hashring {
 // If a member with the same key is already in the hashring,
 // ErrMemberAlreadyExists is returned.
 func (h *Hashring) Add(member Member) error {
-	nodeKeyString := member.Key()
-
 	h.Lock()
 	defer h.Unlock()
 
-	if _, ok := h.nodes[nodeKeyString]; ok {
+	if _, ok := h.nodes[member.Key()]; ok {
 		// already have node, bail
 		return ErrMemberAlreadyExists
 	}
 
+	h.add(member)
+	return nil
+}
+
+// Update replaces the member with the same key as the specified member object, such as when the
+// weight of a WeightedMember has changed.
+//
+// If no member with the same key is in the hashring, ErrMemberNotFound is returned.
+func (h *Hashring) Update(member Member) error {
+	h.Lock()
+	defer h.Unlock()
+
+	if err := h.remove(member); err != nil {
+		return err
+	}
+
+	h.add(member)
+	return nil
+}
+
+func (h *Hashring) virtualNodeCount(member Member) uint16 {
+	weighted, ok := member.(WeightedMember)
+	if !ok {
+		return h.replicationFactor
+	}
+
+	count := uint64(h.replicationFactor) * uint64(weighted.Weight()) / DefaultWeight
+	if count < 1 {
+		return 1
+	}
+	if count > math.MaxUint16 {
+		return math.MaxUint16
+	}
+	return uint16(count)
+}
+
+func (h *Hashring) add(member Member) {
+	nodeKeyString := member.Key()
 	nodeHash := h.hasher([]byte(nodeKeyString))
 
 	newNodeRecord := nodeRecord{
@@ -84,7 +132,7 @@ func (h *Hashring) Add(member Member) error {
 	virtualNodeBuffer := make([]byte, 10)
 	binary.LittleEndian.PutUint64(virtualNodeBuffer, nodeHash)
 
-	for i := uint16(0); i < h.replicationFactor; i++ {
+	for i, count := uint16(0), h.virtualNodeCount(member); i < count; i++ {
 		binary.LittleEndian.PutUint16(virtualNodeBuffer[8:], i)
 		virtualNodeHash := h.hasher(virtualNodeBuffer)
 
@@ -101,26 +149,28 @@ func (h *Hashring) Add(member Member) error {
 
 	// Add the node to our map of nodes
 	h.nodes[nodeKeyString] = newNodeRecord
-
-	return nil
 }
 
 // Remove removes an object with the same key as the specified member object.
 //
 // If no member with the same key is in the hashring, ErrMemberNotFound is returned.
 func (h *Hashring) Remove(member Member) error {
-	nodeKeyString := member.Key()
-
 	h.Lock()
 	defer h.Unlock()
 
+	return h.remove(member)
+}
+
+func (h *Hashring) remove(member Member) error {
+	nodeKeyString := member.Key()
+
 	foundNode, ok := h.nodes[nodeKeyString]
 	if !ok {
 		// don't have the node, bail
 		return ErrMemberNotFound
 	}
 
-	indexesToRemove := make([]int, 0, h.replicationFactor)
+	indexesToRemove := make([]int, 0, len(foundNode.virtualNodes))
 	for _, vnode := range foundNode.virtualNodes {
 		vnodeIndex := sort.Search(len(h.virtualNodes), func(i int) bool {
 			return !h.virtualNodes[i].less(vnode)
@@ -137,8 +187,8 @@ func (h *Hashring) Remove(member Member) error {
 		return indexesToRemove[j] < indexesToRemove[i]
 	})
 
-	if len(indexesToRemove) != int(h.replicationFactor) {
-		panic(fmt.Sprintf("found wrong number of vnodes to remove: %d != %d", len(indexesToRemove), h.replicationFactor))
+	if len(indexesToRemove) != len(foundNode.virtualNodes) {
+		panic(fmt.Sprintf("found wrong number of vnodes to remove: %d != %d", len(indexesToRemove), len(foundNode.virtualNodes)))
 	}
 
 	for i, indexToRemove := range indexesToRemove {
@@ -178,6 +178,62 @@ func TestBackendBalance(t *testing.T) {
 	}
 }
 
+type weightedMember struct {
+	key    string
+	weight uint16
+}
+
+func (m weightedMember) Key() string {
+	return m.key
+}
+
+func (m weightedMember) Weight() uint16 {
+	return m.weight
+}
+
+func TestWeightedMembers(t *testing.T) {
+	require := require.New(t)
+
+	ring := NewHashring(xxhash.Sum64, 100)
+	require.NoError(ring.Add(weightedMember{"large", 2 * DefaultWeight}))
+	require.NoError(ring.Add(weightedMember{"standard", DefaultWeight}))
+	require.NoError(ring.Add(member(0)))
+	require.NoError(ring.Add(weightedMember{"canary", 0}))
+
+	require.Len(ring.nodes["large"].virtualNodes, 200)
+	require.Len(ring.nodes["standard"].virtualNodes, 100)
+	require.Len(ring.nodes[member(0).Key()].virtualNodes, 100)
+	require.Len(ring.nodes["canary"].virtualNodes, 1)
+	require.Len(ring.virtualNodes, 401)
+
+	countKeys := func() map[string]int {
+		counts := map[string]int{}
+		for i := 0; i < numTestKeys; i++ {
+			found, err := ring.FindN([]byte(strconv.Itoa(i)), 1)
+			require.NoError(err)
+			counts[found[0].Key()]++
+		}
+		return counts
+	}
+
+	counts := countKeys()
+	require.InDelta(2.0, float64(counts["large"])/float64(counts["standard"]), 0.5)
+	require.InDelta(1.0, float64(counts[member(0).Key()])/float64(counts["standard"]), 0.3)
+	require.Less(counts["canary"], numTestKeys/50)
+
+	// Updating the weight of a member moves keys to it, and the ring can still
+	// remove it.
+	require.NoError(ring.Update(weightedMember{"canary", 2 * DefaultWeight}))
+	require.Len(ring.nodes["canary"].virtualNodes, 200)
+	require.Len(ring.virtualNodes, 600)
+	require.Greater(countKeys()["canary"], counts["canary"])
+
+	require.Equal(ErrMemberNotFound, ring.Update(weightedMember{"unknown", DefaultWeight}))
+
+	require.NoError(ring.Remove(weightedMember{"canary", 0}))
+	require.Len(ring.virtualNodes, 400)
+}
+
 type perturbationKind int
 
 const (
@@ -3,6 +3,7 @@ package datastore
 import (
 	"context"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 )
 
@@ -11,9 +12,13 @@ type CaveatReader interface {
 	// ReadCaveatByName returns a caveat with the provided name
 	ReadCaveatByName(ctx context.Context, name string) (*core.CaveatDefinition, Revision, error)
 
-	// ListCaveats returns all caveats stored in the system. If caveatNames are provided
-	// the result will be filtered to the provided caveat names
-	ListCaveats(ctx context.Context, caveatNamesForFiltering ...string) ([]*core.CaveatDefinition, error)
+	// ListCaveats lists the caveats stored in the system, in order of their names. With
+	// options.WithListLimit and options.WithListAfter, the caveats can be read in pages rather
+	// than all at once.
+	ListCaveats(ctx context.Context, options ...options.ListOptionsOption) ([]*core.CaveatDefinition, error)
+
+	// LookupCaveats finds all caveats with the matching names.
+	LookupCaveats(ctx context.Context, caveatNames []string) ([]*core.CaveatDefinition, error)
 }
 
 // CaveatStorer offers both read and write operations for Caveats
@@ -3,9 +3,11 @@ package datastore
 import (
 	"context"
 	"encoding"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 
@@ -44,6 +46,15 @@ const FilterMaximumIDCount = 100
 type RevisionChanges struct {
 	Revision Revision
 	Changes  []*core.RelationTupleUpdate
+
+	// Metadata is the caller-supplied metadata stored with the transaction, if any.
+	Metadata map[string]string
+
+	// IsCheckpoint is true if this is a checkpoint rather than the changes of a transaction.
+	// A checkpoint has no changes, and indicates that all changes at or before its revision
+	// have already been sent. Checkpoints are sent periodically while no changes occur, and
+	// may repeat the revision of the previous checkpoint or changes.
+	IsCheckpoint bool
 }
 
 // RelationshipsFilter is a filter for relationships.
@@ -63,6 +74,11 @@ type RelationshipsFilter struct {
 	// OptionalCaveatName is the filter to use for caveated relationships, filtering by a specific caveat name.
 	// If nil, all caveated and non-caveated relationships are allowed
 	OptionalCaveatName string
+
+	// OptionalMetadata is the filter to use for relationship metadata. Only relationships with
+	// metadata containing every key in the map are allowed; if the value for a key is non-empty,
+	// the metadata must also hold that value for the key.
+	OptionalMetadata map[string]string
 }
 
 // RelationshipsFilterFromPublicFilter constructs a datastore RelationshipsFilter from an API-defined RelationshipFilter.
@@ -157,19 +173,32 @@ type Reader interface {
 		options ...options.QueryOptionsOption,
 	) (RelationshipIterator, error)
 
-	// ReverseQueryRelationships reads relationships, starting from the subject.
+	// ReverseQueryRelationships reads relationships, starting from the subject. With
+	// options.WithReverseSort(options.BySubject), the relationships are returned in a stable
+	// order, and the query can be paged through by passing the last relationship returned as
+	// the cursor to options.WithReverseAfter, along with a limit.
 	ReverseQueryRelationships(
 		ctx context.Context,
 		subjectFilter SubjectsFilter,
 		options ...options.ReverseQueryOptionsOption,
 	) (RelationshipIterator, error)
 
+	// CountRelationships returns the number of relationships matching the filter, without
+	// loading the relationships themselves.
+	CountRelationships(ctx context.Context, filter RelationshipsFilter) (uint64, error)
+
+	// ListResourceTypes lists the distinct resource types of the relationships stored, in order,
+	// including those of relationships whose namespace is no longer defined.
+	ListResourceTypes(ctx context.Context) ([]string, error)
+
 	// ReadNamespace reads a namespace definition and the revision at which it was created or
 	// last written. It returns an instance of ErrNamespaceNotFound if not found.
 	ReadNamespace(ctx context.Context, nsName string) (ns *core.NamespaceDefinition, lastWritten Revision, err error)
 
-	// ListNamespaces lists all namespaces defined.
-	ListNamespaces(ctx context.Context) ([]*core.NamespaceDefinition, error)
+	// ListNamespaces lists the namespaces defined, in order of their names. With
+	// options.WithListLimit and options.WithListAfter, the namespaces can be read in pages rather
+	// than all at once.
+	ListNamespaces(ctx context.Context, options ...options.ListOptionsOption) ([]*core.NamespaceDefinition, error)
 
 	// LookupNamespaces finds all namespaces with the matching names.
 	LookupNamespaces(ctx context.Context, nsNames []string) ([]*core.NamespaceDefinition, error)
@@ -195,15 +224,32 @@ type ReadWriteTransaction interface {
 // TxUserFunc is a type for the function that users supply when they invoke a read-write transaction.
 type TxUserFunc func(ReadWriteTransaction) error
 
+// BulkWriteRelationshipSource is a source of relationships to be loaded via BulkLoad, allowing
+// relationships to be streamed into the datastore without being held in memory all at once.
+type BulkWriteRelationshipSource interface {
+	// Next returns the next relationship to be loaded, or nil if there are no more relationships
+	// or an error occurred.
+	Next(ctx context.Context) (*core.RelationTuple, error)
+}
+
 // Datastore represents tuple access for a single namespace.
 type Datastore interface {
 	// SnapshotReader creates a read-only handle that reads the datastore at the specified revision.
-	// Any errors establishing the reader will be returned by subsequent calls.
-	SnapshotReader(Revision) Reader
+	// Any errors establishing the reader will be returned by subsequent calls. The options may
+	// carry a consistency hint, which datastores that cannot take advantage of it will ignore.
+	SnapshotReader(Revision, ...options.SnapshotReaderOptionsOption) Reader
 
 	// ReadWriteTx tarts a read/write transaction, which will be committed if no error is
-	// returned and rolled back if an error is returned.
-	ReadWriteTx(context.Context, TxUserFunc) (Revision, error)
+	// returned and rolled back if an error is returned. The options may carry metadata to be
+	// stored with the transaction.
+	ReadWriteTx(context.Context, TxUserFunc, ...options.RWTOptionsOption) (Revision, error)
+
+	// BulkLoad writes all relationships from the source, as if each were written with the CREATE
+	// operation, using the most efficient mechanism supported by the datastore. As the source
+	// cannot be replayed, the load is never retried. Depending on the datastore, the relationships
+	// may be committed in more than one transaction, in which case the revision returned is that
+	// of the final transaction and an error may leave some of the relationships written.
+	BulkLoad(ctx context.Context, source BulkWriteRelationshipSource) (Revision, error)
 
 	// OptimizedRevision gets a revision that will likely already be replicated
 	// and will likely be shared amongst many queries.
@@ -223,7 +269,8 @@ type Datastore interface {
 
 	// Watch notifies the caller about all changes to tuples.
 	//
-	// All events following afterRevision will be sent to the caller.
+	// All events following afterRevision will be sent to the caller, interleaved with
+	// checkpoints sent while no changes are occurring.
 	Watch(ctx context.Context, afterRevision Revision) (<-chan *RevisionChanges, <-chan error)
 
 	// IsReady returns whether the datastore is ready to accept data. Datastores that require
@@ -242,6 +289,159 @@ type Datastore interface {
 	Close() error
 }
 
+// UnwrappableDatastore represents a datastore proxy which wraps a single datastore.
+type UnwrappableDatastore interface {
+	// Unwrap returns the wrapped datastore.
+	Unwrap() Datastore
+}
+
+// UnwrapAs returns the first datastore of type T found by unwrapping the datastore, including
+// the datastore itself.
+func UnwrapAs[T any](ds Datastore) (T, bool) {
+	for {
+		if found, ok := ds.(T); ok {
+			return found, true
+		}
+
+		unwrappable, ok := ds.(UnwrappableDatastore)
+		if !ok {
+			var none T
+			return none, false
+		}
+		ds = unwrappable.Unwrap()
+	}
+}
+
+// DefaultTenant is the tenant which owns all data that was not written for a specific tenant,
+// including all data written before the datastore was partitioned by tenant.
+const DefaultTenant = ""
+
+// TenantPartitionedDatastore represents a datastore which partitions its data by tenant, allowing
+// a single datastore to serve the relationships and schema of isolated tenants.
+//
+// The datastore itself reads and writes the data of the DefaultTenant.
+type TenantPartitionedDatastore interface {
+	Datastore
+
+	// ForTenant returns a datastore which reads, writes and watches only the data of the tenant.
+	// The tenant's datastore shares its connections, revisions and garbage collection with this
+	// datastore, and closing it has no effect.
+	ForTenant(tenantID string) Datastore
+}
+
+// ForTenant returns a datastore for the data of the tenant, or an error if the datastore does
+// not support partitioning by tenant. Proxies wrapping the datastore are not applied to the
+// returned datastore.
+func ForTenant(ds Datastore, tenantID string) (Datastore, error) {
+	partitioned, ok := UnwrapAs[TenantPartitionedDatastore](ds)
+	if !ok {
+		return nil, ErrTenantsUnsupported
+	}
+	return partitioned.ForTenant(tenantID), nil
+}
+
+// ErrTenantsUnsupported is returned by ForTenant for datastores which are not partitioned by
+// tenant.
+var ErrTenantsUnsupported = errors.New("datastore does not support partitioning by tenant")
+
+// GarbageCollectableDatastore represents a datastore which garbage collects the data of
+// revisions that have fallen out of its garbage collection window.
+type GarbageCollectableDatastore interface {
+	Datastore
+
+	// CollectGarbage runs garbage collection immediately, rather than waiting for the next
+	// background run, and returns the amount of data which was collected.
+	CollectGarbage(ctx context.Context) (GarbageCollected, error)
+}
+
+// GarbageCollected is the amount of data deleted by a run of garbage collection.
+type GarbageCollected struct {
+	// Relationships is the number of deleted or expired relationships which were removed.
+	Relationships int64
+
+	// Transactions is the number of transactions which were pruned.
+	Transactions int64
+
+	// Backlog is the number of transactions outside of the garbage collection window which
+	// remain to be pruned, which is non-zero if the run did not complete.
+	Backlog int64
+}
+
+// QuantizedClockDatastore represents a datastore whose optimized revisions are the starts of
+// quantization windows of its clock, so that the revision which the next window will select is
+// known before it starts.
+type QuantizedClockDatastore interface {
+	Datastore
+
+	// NextOptimizedRevision returns the optimized revision which will be selected once the
+	// current quantization window rolls over.
+	NextOptimizedRevision(ctx context.Context) (Revision, error)
+}
+
+// SchemaHistoryDatastore represents a datastore which retains the prior versions of namespace
+// and caveat definitions, rather than garbage collecting them.
+type SchemaHistoryDatastore interface {
+	Datastore
+
+	// NamespaceHistory returns every version of the namespace definition which has been written,
+	// oldest first, or an empty list if it was never written.
+	NamespaceHistory(ctx context.Context, nsName string) ([]DefinitionVersion[*core.NamespaceDefinition], error)
+
+	// CaveatHistory returns every version of the caveat definition which has been written,
+	// oldest first, or an empty list if it was never written.
+	CaveatHistory(ctx context.Context, name string) ([]DefinitionVersion[*core.CaveatDefinition], error)
+}
+
+// DefinitionVersion is a version of a schema definition, along with when it was written.
+type DefinitionVersion[T any] struct {
+	Definition T
+
+	// Revision is the revision at which the version was written.
+	Revision Revision
+
+	// WrittenAt is the time at which the version was written. It is the zero time if the
+	// datastore no longer knows when the version was written.
+	WrittenAt time.Time
+
+	// Deleted is set if the definition was deleted, rather than replaced, after this version.
+	Deleted bool
+}
+
+// NamespaceDiffHistoryDatastore represents a datastore which records the semantic changes made
+// by each replacement of a namespace definition.
+type NamespaceDiffHistoryDatastore interface {
+	Datastore
+
+	// NamespaceDiffHistory returns the changes made by each replacement of the namespace
+	// definition, oldest first, or an empty list if it was never replaced.
+	NamespaceDiffHistory(ctx context.Context, nsName string) ([]NamespaceDiff, error)
+}
+
+// NamespaceDiff is the changes made by a replacement of a namespace definition.
+type NamespaceDiff struct {
+	// Revision is the revision at which the replacement was written.
+	Revision Revision
+
+	// WrittenAt is the time at which the replacement was written.
+	WrittenAt time.Time
+
+	// Deltas are the changes made to the namespace by the replacement.
+	Deltas []NamespaceDelta
+}
+
+// NamespaceDelta is a change made to a namespace definition.
+type NamespaceDelta struct {
+	// Type is the type of the change, such as `added-relation` or `changed-permission-implementation`.
+	Type string
+
+	// RelationName is the name of the relation or permission which was changed, if any.
+	RelationName string
+
+	// AllowedType is the allowed subject type which was added to or removed from the relation,
+	// if any.
+	AllowedType *core.AllowedRelation
+}
+
 // Feature represents a capability that a datastore can support, plus an
 // optional message explaining the feature is available (or not).
 type Feature struct {
@@ -253,10 +453,35 @@ type Feature struct {
 type Features struct {
 	// Watch is enabled if the underlying datastore can support the Watch api.
 	Watch Feature
+
+	// WatchCheckpoints is enabled if Watch sends periodic checkpoints while no changes occur.
+	WatchCheckpoints Feature
+
+	// Caveats is enabled if the datastore can store caveat definitions and caveated
+	// relationships.
+	Caveats Feature
+
+	// BulkLoad is enabled if relationships can be written with BulkLoad.
+	BulkLoad Feature
+
+	// ReverseQuerySort is enabled if ReverseQueryRelationships returns relationships in the order
+	// of options.BySubject when requested, and can be resumed from a cursor.
+	ReverseQuerySort Feature
+
+	// RelationshipExpiration is enabled if relationships can be written with an expiration time,
+	// after which they are no longer read.
+	RelationshipExpiration Feature
 }
 
 // ObjectTypeStat represents statistics for a single object type (namespace).
 type ObjectTypeStat struct {
+	// Name is the name of the object type.
+	Name string
+
+	// EstimatedRelationshipCount is a best-guess estimate of the number of relationships
+	// with a resource of the object type, or zero if the datastore cannot estimate it.
+	EstimatedRelationshipCount uint64
+
 	// NumRelations is the number of relations defined in a single object type.
 	NumRelations uint32
 
@@ -274,6 +499,18 @@ type Stats struct {
 	// table statistics.
 	EstimatedRelationshipCount uint64
 
+	// EstimatedDistinctResources is a best-guess estimate of the number of distinct resource
+	// objects referenced by relationships, or zero if the datastore cannot estimate it.
+	EstimatedDistinctResources uint64
+
+	// EstimatedDistinctSubjects is a best-guess estimate of the number of distinct subject
+	// objects referenced by relationships, or zero if the datastore cannot estimate it.
+	EstimatedDistinctSubjects uint64
+
+	// EstimatedStorageBytes is the approximate size of the stored relationships, including
+	// indexes, or zero if the datastore cannot report it.
+	EstimatedStorageBytes uint64
+
 	// ObjectTypeStatistics returns a slice element for each object type (namespace)
 	// stored in the datastore.
 	ObjectTypeStatistics []ObjectTypeStat
@@ -95,3 +95,29 @@ func TestRelationshipsFilterFromPublicFilter(t *testing.T) {
 		})
 	}
 }
+
+type fakeDatastore struct {
+	Datastore
+}
+
+type fakeProxy struct {
+	Datastore
+}
+
+func (p fakeProxy) Unwrap() Datastore { return p.Datastore }
+
+func TestUnwrapAs(t *testing.T) {
+	wrapped := fakeDatastore{}
+	proxied := fakeProxy{fakeProxy{wrapped}}
+
+	found, ok := UnwrapAs[fakeDatastore](proxied)
+	require.True(t, ok)
+	require.Equal(t, wrapped, found)
+
+	outer, ok := UnwrapAs[fakeProxy](proxied)
+	require.True(t, ok)
+	require.Equal(t, proxied, outer)
+
+	_, ok = UnwrapAs[GarbageCollectableDatastore](proxied)
+	require.False(t, ok)
+}
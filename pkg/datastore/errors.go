@@ -2,6 +2,7 @@ package datastore
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/rs/zerolog"
 )
@@ -43,6 +44,74 @@ type ErrWatchDisabled struct{ error }
 // read-only mode.
 type ErrReadOnly struct{ error }
 
+// ErrUnavailable is returned when the datastore is temporarily unable to serve an operation,
+// which may be retried after a delay.
+type ErrUnavailable struct {
+	error
+	retryAfter time.Duration
+}
+
+// RetryAfter is the suggested delay before retrying the operation.
+func (err ErrUnavailable) RetryAfter() time.Duration {
+	return err.retryAfter
+}
+
+// QueryLimit is a limit placed on each relationship query.
+type QueryLimit int
+
+const (
+	// QueryLimitRows limits the number of relationships read by a query.
+	QueryLimitRows QueryLimit = iota
+
+	// QueryLimitDuration limits the time taken to read the relationships of a query.
+	QueryLimitDuration
+)
+
+func (limit QueryLimit) String() string {
+	switch limit {
+	case QueryLimitRows:
+		return "rows"
+	case QueryLimitDuration:
+		return "duration"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrQueryLimitExceeded occurs when a relationship query has exceeded a configured limit on the
+// relationships it may read or the time it may take.
+type ErrQueryLimitExceeded struct {
+	error
+	limit QueryLimit
+}
+
+// Limit is the limit which was exceeded.
+func (err ErrQueryLimitExceeded) Limit() QueryLimit {
+	return err.limit
+}
+
+// MarshalZerologObject implements zerolog object marshalling.
+func (err ErrQueryLimitExceeded) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Stringer("limit", err.limit)
+}
+
+// ErrRelationshipLimitExceeded occurs when a write would store more relationships than the
+// datastore is configured to hold.
+type ErrRelationshipLimitExceeded struct {
+	error
+	maxRelationships uint64
+}
+
+// MaxRelationships is the maximum number of relationships which the datastore holds.
+func (err ErrRelationshipLimitExceeded) MaxRelationships() uint64 {
+	return err.maxRelationships
+}
+
+// MarshalZerologObject implements zerolog object marshalling.
+func (err ErrRelationshipLimitExceeded) MarshalZerologObject(e *zerolog.Event) {
+	e.Err(err.error).Uint64("maxRelationships", err.maxRelationships)
+}
+
 // InvalidRevisionReason is the reason the revision could not be used.
 type InvalidRevisionReason int
 
@@ -85,6 +154,24 @@ func (err ErrInvalidRevision) MarshalZerologObject(e *zerolog.Event) {
 	}
 }
 
+// ErrMaxRetriesExceeded occurs when a transaction has failed with a retryable error on every
+// attempt allowed by the retry budget of the datastore.
+type ErrMaxRetriesExceeded struct {
+	error
+	attempts int
+	lastErr  error
+}
+
+// Attempts is the number of times the transaction was attempted.
+func (err ErrMaxRetriesExceeded) Attempts() int {
+	return err.attempts
+}
+
+// Unwrap returns the error of the last attempt.
+func (err ErrMaxRetriesExceeded) Unwrap() error {
+	return err.lastErr
+}
+
 // NewNamespaceNotFoundErr constructs a new namespace not found error.
 func NewNamespaceNotFoundErr(nsName string) error {
 	return ErrNamespaceNotFound{
@@ -114,6 +201,16 @@ func NewWatchDisabledErr(reason string) error {
 	}
 }
 
+// NewMaxRetriesExceededErr constructs an error for when a transaction has failed with a
+// retryable error on each of its attempts.
+func NewMaxRetriesExceededErr(attempts int, lastErr error) error {
+	return ErrMaxRetriesExceeded{
+		error:    fmt.Errorf("transaction failed after %d attempts: %w", attempts, lastErr),
+		attempts: attempts,
+		lastErr:  lastErr,
+	}
+}
+
 // NewReadonlyErr constructs an error for when a request has failed because
 // the datastore has been configured to be read-only.
 func NewReadonlyErr() error {
@@ -122,6 +219,42 @@ func NewReadonlyErr() error {
 	}
 }
 
+// NewUnavailableErr constructs an error for when a request has failed because the datastore
+// is temporarily unavailable.
+func NewUnavailableErr(reason string, retryAfter time.Duration) error {
+	return ErrUnavailable{
+		error:      fmt.Errorf("datastore is temporarily unavailable: %s", reason),
+		retryAfter: retryAfter,
+	}
+}
+
+// NewRowLimitExceededErr constructs an error for when a relationship query has read more than
+// the maximum number of relationships.
+func NewRowLimitExceededErr(maxRows uint64) error {
+	return ErrQueryLimitExceeded{
+		error: fmt.Errorf("relationship query exceeded the limit of %d relationships read", maxRows),
+		limit: QueryLimitRows,
+	}
+}
+
+// NewDurationLimitExceededErr constructs an error for when a relationship query has taken longer
+// than the maximum duration.
+func NewDurationLimitExceededErr(maxDuration time.Duration) error {
+	return ErrQueryLimitExceeded{
+		error: fmt.Errorf("relationship query exceeded the time limit of %s", maxDuration),
+		limit: QueryLimitDuration,
+	}
+}
+
+// NewRelationshipLimitExceededErr constructs an error for when a write would store more than the
+// maximum number of relationships.
+func NewRelationshipLimitExceededErr(maxRelationships uint64) error {
+	return ErrRelationshipLimitExceeded{
+		error:            fmt.Errorf("write would exceed the limit of %d relationships stored", maxRelationships),
+		maxRelationships: maxRelationships,
+	}
+}
+
 // NewInvalidRevisionErr constructs a new invalid revision error.
 func NewInvalidRevisionErr(revision Revision, reason InvalidRevisionReason) error {
 	switch reason {
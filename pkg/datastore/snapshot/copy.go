@@ -0,0 +1,194 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+)
+
+// progressInterval is the number of relationships copied between calls to the progress function.
+const progressInterval = 10_000
+
+// ErrTargetNotEmpty is returned when copying into a datastore which already has a schema.
+var ErrTargetNotEmpty = errors.New("target datastore is not empty")
+
+// Copy copies all of the data in the source datastore at the given revision into the target
+// datastore, which must be empty, without writing it to an intermediate snapshot. The caveats and
+// namespaces are written in a single transaction, after which the relationships are streamed into
+// the target with BulkLoad. The progress function, if any, is called with the records copied so
+// far as the relationships are copied. Returns the revision of the target at which the
+// relationships were loaded.
+func Copy(ctx context.Context, source datastore.Datastore, revision datastore.Revision, target datastore.Datastore, progress func(Summary)) (datastore.Revision, Summary, error) {
+	var summary Summary
+	reader := source.SnapshotReader(revision)
+
+	targetRevision, err := target.HeadRevision(ctx)
+	if err != nil {
+		return datastore.NoRevision, summary, fmt.Errorf("unable to read target datastore: %w", err)
+	}
+	existing, err := target.SnapshotReader(targetRevision).ListNamespaces(ctx)
+	if err != nil {
+		return datastore.NoRevision, summary, fmt.Errorf("unable to read target datastore: %w", err)
+	}
+	if len(existing) > 0 {
+		return datastore.NoRevision, summary, ErrTargetNotEmpty
+	}
+
+	caveats, err := reader.ListCaveats(ctx)
+	if err != nil {
+		return datastore.NoRevision, summary, fmt.Errorf("unable to read caveats: %w", err)
+	}
+
+	namespaces, err := reader.ListNamespaces(ctx)
+	if err != nil {
+		return datastore.NoRevision, summary, fmt.Errorf("unable to read namespaces: %w", err)
+	}
+
+	targetRevision, err = target.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		if len(caveats) > 0 {
+			if err := rwt.WriteCaveats(ctx, caveats); err != nil {
+				return err
+			}
+		}
+		if len(namespaces) > 0 {
+			return rwt.WriteNamespaces(ctx, namespaces...)
+		}
+		return nil
+	})
+	if err != nil {
+		return datastore.NoRevision, summary, fmt.Errorf("unable to write schema: %w", err)
+	}
+	summary.Caveats = uint64(len(caveats))
+	summary.Namespaces = uint64(len(namespaces))
+
+	resourceTypes := make([]string, 0, len(namespaces))
+	for _, ns := range namespaces {
+		resourceTypes = append(resourceTypes, ns.Name)
+	}
+
+	src := &copySource{
+		reader:        reader,
+		resourceTypes: resourceTypes,
+		summary:       &summary,
+		progress:      progress,
+	}
+	defer src.close()
+
+	if len(resourceTypes) > 0 {
+		targetRevision, err = target.BulkLoad(ctx, src)
+		if err != nil {
+			return datastore.NoRevision, summary, fmt.Errorf("unable to load relationships: %w", err)
+		}
+	}
+
+	if progress != nil {
+		progress(summary)
+	}
+	return targetRevision, summary, nil
+}
+
+// copySource provides the relationships of each resource type of a snapshot reader to BulkLoad.
+type copySource struct {
+	reader        datastore.Reader
+	resourceTypes []string
+	iter          datastore.RelationshipIterator
+
+	summary  *Summary
+	progress func(Summary)
+}
+
+func (cs *copySource) Next(ctx context.Context) (*core.RelationTuple, error) {
+	for {
+		if cs.iter == nil {
+			if len(cs.resourceTypes) == 0 {
+				return nil, nil
+			}
+
+			resourceType := cs.resourceTypes[0]
+			cs.resourceTypes = cs.resourceTypes[1:]
+
+			iter, err := cs.reader.QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: resourceType})
+			if err != nil {
+				return nil, fmt.Errorf("unable to read relationships for `%s`: %w", resourceType, err)
+			}
+			cs.iter = iter
+		}
+
+		if tpl := cs.iter.Next(); tpl != nil {
+			cs.summary.Relationships++
+			if cs.progress != nil && cs.summary.Relationships%progressInterval == 0 {
+				cs.progress(*cs.summary)
+			}
+			return tpl, nil
+		}
+
+		err := cs.iter.Err()
+		cs.close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read relationships: %w", err)
+		}
+	}
+}
+
+func (cs *copySource) close() {
+	if cs.iter != nil {
+		cs.iter.Close()
+		cs.iter = nil
+	}
+}
+
+// Follow applies the changes to relationships made in the source datastore after the given
+// revision to the target datastore, one transaction of the target for each transaction of the
+// source, until the context is canceled. It is used after Copy to catch the target up with writes
+// made to the source while it was copied. Changes to the schema are not followed. The applied
+// function, if any, is called with the revision of the source of each transaction applied.
+func Follow(ctx context.Context, source datastore.Datastore, afterRevision datastore.Revision, target datastore.Datastore, applied func(datastore.Revision, int)) error {
+	changes, errs := source.Watch(ctx, afterRevision)
+	for {
+		select {
+		case revChanges, ok := <-changes:
+			if !ok {
+				// The watch ends with an error, unless the context was canceled.
+				if err := <-errs; err != nil && ctx.Err() == nil {
+					return fmt.Errorf("unable to watch source datastore: %w", err)
+				}
+				return nil
+			}
+			if revChanges.IsCheckpoint || len(revChanges.Changes) == 0 {
+				continue
+			}
+
+			// Relationships created in the source may have been copied already, so creations are
+			// applied as touches.
+			mutations := make([]*core.RelationTupleUpdate, 0, len(revChanges.Changes))
+			for _, change := range revChanges.Changes {
+				if change.Operation == core.RelationTupleUpdate_CREATE {
+					change = &core.RelationTupleUpdate{Operation: core.RelationTupleUpdate_TOUCH, Tuple: change.Tuple}
+				}
+				mutations = append(mutations, change)
+			}
+
+			if _, err := target.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+				return rwt.WriteRelationships(ctx, mutations)
+			}); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("unable to apply changes at revision %s: %w", revChanges.Revision, err)
+			}
+
+			if applied != nil {
+				applied(revChanges.Revision, len(mutations))
+			}
+
+		case err := <-errs:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("unable to watch source datastore: %w", err)
+		}
+	}
+}
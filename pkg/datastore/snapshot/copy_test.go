@@ -0,0 +1,105 @@
+package snapshot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestCopy(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawSource, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	source, revision := testfixtures.StandardDatastoreWithCaveatedData(rawSource, require)
+
+	// Writes after the revision are not copied.
+	_, err = common.WriteTuples(ctx, source, core.RelationTupleUpdate_CREATE, tuple.MustParse("document:later#viewer@user:tom"))
+	require.NoError(err)
+
+	target, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	var reported []Summary
+	copiedRevision, copied, err := Copy(ctx, source, revision, target, func(progress Summary) {
+		reported = append(reported, progress)
+	})
+	require.NoError(err)
+	require.Equal(Summary{Caveats: 1, Namespaces: 3, Relationships: uint64(len(testfixtures.StandardTuples))}, copied)
+	require.Equal([]Summary{copied}, reported)
+
+	require.Equal(readAllRelationships(t, source, revision), readAllRelationships(t, target, copiedRevision))
+
+	caveat, _, err := target.SnapshotReader(copiedRevision).ReadCaveatByName(ctx, "test")
+	require.NoError(err)
+	require.Equal("test", caveat.Name)
+
+	// A datastore which already has a schema cannot be copied into.
+	_, _, err = Copy(ctx, source, revision, target, nil)
+	require.ErrorIs(err, ErrTargetNotEmpty)
+}
+
+func TestFollow(t *testing.T) {
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rawSource, err := memdb.NewMemdbDatastore(16, 0, memdb.DisableGC)
+	require.NoError(err)
+	source, revision := testfixtures.StandardDatastoreWithData(rawSource, require)
+
+	target, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	_, _, err = Copy(ctx, source, revision, target, nil)
+	require.NoError(err)
+
+	// Changes made while the copy is followed, including the creation of a relationship which was
+	// already copied, are applied to the target.
+	first := tuple.MustParse("document:masterplan#viewer@user:eng_lead")
+	later := tuple.MustParse("document:later#viewer@user:tom")
+	_, err = common.WriteTuples(ctx, source, core.RelationTupleUpdate_DELETE, first)
+	require.NoError(err)
+	_, err = common.WriteTuples(ctx, target, core.RelationTupleUpdate_CREATE, later)
+	require.NoError(err)
+	_, err = common.WriteTuples(ctx, source, core.RelationTupleUpdate_CREATE, later)
+	require.NoError(err)
+
+	applied := make(chan int, 2)
+	followed := make(chan error, 1)
+	go func() {
+		followed <- Follow(ctx, source, revision, target, func(_ datastore.Revision, changes int) {
+			applied <- changes
+		})
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case changes := <-applied:
+			require.Equal(1, changes)
+		case err := <-followed:
+			require.Fail("follow stopped", "%v", err)
+		case <-time.After(5 * time.Second):
+			require.Fail("timed out waiting for changes to be applied")
+		}
+	}
+
+	head, err := source.HeadRevision(ctx)
+	require.NoError(err)
+	targetHead, err := target.HeadRevision(ctx)
+	require.NoError(err)
+	require.Equal(readAllRelationships(t, source, head), readAllRelationships(t, target, targetHead))
+
+	cancel()
+	require.NoError(<-followed)
+}
@@ -0,0 +1,292 @@
+// Package snapshot implements a portable format for snapshots of the data in a datastore, which
+// can be used for backups, to seed other environments or to migrate data between datastores.
+//
+// A snapshot is a stream of SnapshotRecord messages, each prefixed by its length as a varint.
+// It consists of a header, the caveats, namespaces and relationships in the datastore in that
+// order, and a footer recording the number of each which were written.
+package snapshot
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	dsv1 "github.com/authzed/spicedb/pkg/proto/datastore/v1"
+)
+
+// Version is the version of the snapshot format written by Export.
+const Version = 1
+
+// maxRecordSize is the maximum size of a single record, which guards against allocating large
+// buffers when reading a corrupt snapshot.
+const maxRecordSize = 64 * 1024 * 1024
+
+// ErrTruncated is returned when a snapshot ends before its footer, or its footer does not match
+// the records read.
+var ErrTruncated = errors.New("snapshot is truncated")
+
+// Summary is the number of each kind of record in a snapshot.
+type Summary struct {
+	Caveats       uint64
+	Namespaces    uint64
+	Relationships uint64
+}
+
+// Export writes a snapshot of all of the data in the datastore at the given revision.
+func Export(ctx context.Context, ds datastore.Datastore, revision datastore.Revision, w io.Writer) (Summary, error) {
+	var summary Summary
+	reader := ds.SnapshotReader(revision)
+	rw := &recordWriter{w: bufio.NewWriter(w)}
+
+	if err := rw.write(&dsv1.SnapshotRecord{Record: &dsv1.SnapshotRecord_Header_{Header: &dsv1.SnapshotRecord_Header{
+		Version:   Version,
+		Revision:  revision.String(),
+		CreatedAt: timestamppb.Now(),
+	}}}); err != nil {
+		return summary, err
+	}
+
+	caveats, err := reader.ListCaveats(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("unable to read caveats: %w", err)
+	}
+
+	for _, caveat := range caveats {
+		if err := rw.write(&dsv1.SnapshotRecord{Record: &dsv1.SnapshotRecord_Caveat{Caveat: caveat}}); err != nil {
+			return summary, err
+		}
+		summary.Caveats++
+	}
+
+	namespaces, err := reader.ListNamespaces(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("unable to read namespaces: %w", err)
+	}
+
+	for _, ns := range namespaces {
+		if err := rw.write(&dsv1.SnapshotRecord{Record: &dsv1.SnapshotRecord_Namespace{Namespace: ns}}); err != nil {
+			return summary, err
+		}
+		summary.Namespaces++
+	}
+
+	for _, ns := range namespaces {
+		written, err := exportRelationships(ctx, reader, ns.Name, rw)
+		summary.Relationships += written
+		if err != nil {
+			return summary, err
+		}
+	}
+
+	if err := rw.write(&dsv1.SnapshotRecord{Record: &dsv1.SnapshotRecord_Footer_{Footer: &dsv1.SnapshotRecord_Footer{
+		CaveatCount:       summary.Caveats,
+		NamespaceCount:    summary.Namespaces,
+		RelationshipCount: summary.Relationships,
+	}}}); err != nil {
+		return summary, err
+	}
+
+	return summary, rw.w.Flush()
+}
+
+func exportRelationships(ctx context.Context, reader datastore.Reader, resourceType string, rw *recordWriter) (uint64, error) {
+	iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: resourceType})
+	if err != nil {
+		return 0, fmt.Errorf("unable to read relationships for `%s`: %w", resourceType, err)
+	}
+	defer iter.Close()
+
+	var written uint64
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		if err := rw.write(&dsv1.SnapshotRecord{Record: &dsv1.SnapshotRecord_Relationship{Relationship: tpl}}); err != nil {
+			return written, err
+		}
+		written++
+	}
+	if iter.Err() != nil {
+		return written, fmt.Errorf("unable to read relationships for `%s`: %w", resourceType, iter.Err())
+	}
+
+	return written, nil
+}
+
+// Import loads a snapshot into the datastore. The caveats and namespaces are written in a single
+// transaction, after which the relationships are loaded with BulkLoad. Returns the revision at
+// which the relationships were loaded.
+func Import(ctx context.Context, ds datastore.Datastore, r io.Reader) (datastore.Revision, Summary, error) {
+	var summary Summary
+	rr := &recordReader{r: bufio.NewReader(r)}
+
+	first, err := rr.read()
+	if err != nil {
+		return datastore.NoRevision, summary, err
+	}
+
+	header := first.GetHeader()
+	if header == nil {
+		return datastore.NoRevision, summary, errors.New("snapshot is missing its header")
+	}
+	if header.Version != Version {
+		return datastore.NoRevision, summary, fmt.Errorf("unsupported snapshot version %d", header.Version)
+	}
+
+	var caveats []*core.CaveatDefinition
+	var namespaces []*core.NamespaceDefinition
+	var next *dsv1.SnapshotRecord
+	for next == nil {
+		record, err := rr.read()
+		if err != nil {
+			return datastore.NoRevision, summary, err
+		}
+
+		switch {
+		case record.GetCaveat() != nil:
+			if len(namespaces) > 0 {
+				return datastore.NoRevision, summary, errors.New("snapshot contains a caveat after namespaces")
+			}
+			caveats = append(caveats, record.GetCaveat())
+		case record.GetNamespace() != nil:
+			namespaces = append(namespaces, record.GetNamespace())
+		default:
+			next = record
+		}
+	}
+
+	revision, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		if len(caveats) > 0 {
+			if err := rwt.WriteCaveats(ctx, caveats); err != nil {
+				return err
+			}
+		}
+		if len(namespaces) > 0 {
+			return rwt.WriteNamespaces(ctx, namespaces...)
+		}
+		return nil
+	})
+	if err != nil {
+		return datastore.NoRevision, summary, fmt.Errorf("unable to write schema: %w", err)
+	}
+	summary.Caveats = uint64(len(caveats))
+	summary.Namespaces = uint64(len(namespaces))
+
+	source := &relationshipSource{rr: rr, next: next}
+	if next.GetRelationship() != nil {
+		revision, err = ds.BulkLoad(ctx, source)
+		if err != nil {
+			return datastore.NoRevision, summary, fmt.Errorf("unable to load relationships: %w", err)
+		}
+	} else if _, err := source.Next(ctx); err != nil {
+		return datastore.NoRevision, summary, err
+	}
+	summary.Relationships = source.count
+
+	footer := source.footer
+	if footer == nil {
+		return revision, summary, ErrTruncated
+	}
+	if footer.CaveatCount != summary.Caveats ||
+		footer.NamespaceCount != summary.Namespaces ||
+		footer.RelationshipCount != summary.Relationships {
+		return revision, summary, fmt.Errorf("%w: footer does not match the records read", ErrTruncated)
+	}
+
+	return revision, summary, nil
+}
+
+// relationshipSource provides the relationships read from a snapshot to BulkLoad, until the
+// footer is reached.
+type relationshipSource struct {
+	rr     *recordReader
+	next   *dsv1.SnapshotRecord
+	footer *dsv1.SnapshotRecord_Footer
+	count  uint64
+}
+
+func (rs *relationshipSource) Next(_ context.Context) (*core.RelationTuple, error) {
+	if rs.footer != nil {
+		return nil, nil
+	}
+
+	record := rs.next
+	rs.next = nil
+	if record == nil {
+		var err error
+		record, err = rs.rr.read()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case record.GetRelationship() != nil:
+		rs.count++
+		return record.GetRelationship(), nil
+	case record.GetFooter() != nil:
+		rs.footer = record.GetFooter()
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected snapshot record after relationships: %T", record.Record)
+	}
+}
+
+type recordWriter struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+func (rw *recordWriter) write(record *dsv1.SnapshotRecord) error {
+	// Records are marshaled with proto.Marshal, rather than MarshalVT, to match how definitions
+	// are serialized by the datastores.
+	serialized, err := proto.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("unable to serialize snapshot record: %w", err)
+	}
+
+	rw.buf = binary.AppendUvarint(rw.buf[:0], uint64(len(serialized)))
+	if _, err := rw.w.Write(rw.buf); err != nil {
+		return fmt.Errorf("unable to write snapshot: %w", err)
+	}
+	if _, err := rw.w.Write(serialized); err != nil {
+		return fmt.Errorf("unable to write snapshot: %w", err)
+	}
+	return nil
+}
+
+type recordReader struct {
+	r *bufio.Reader
+}
+
+func (rr *recordReader) read() (*dsv1.SnapshotRecord, error) {
+	size, err := binary.ReadUvarint(rr.r)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, ErrTruncated
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read snapshot: %w", err)
+	}
+	if size > maxRecordSize {
+		return nil, fmt.Errorf("snapshot record of %d bytes exceeds the maximum size", size)
+	}
+
+	serialized := make([]byte, size)
+	if _, err := io.ReadFull(rr.r, serialized); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrTruncated
+		}
+		return nil, fmt.Errorf("unable to read snapshot: %w", err)
+	}
+
+	record := &dsv1.SnapshotRecord{}
+	if err := proto.Unmarshal(serialized, record); err != nil {
+		return nil, fmt.Errorf("unable to deserialize snapshot record: %w", err)
+	}
+	return record, nil
+}
@@ -0,0 +1,98 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/memdb"
+	"github.com/authzed/spicedb/internal/testfixtures"
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func readAllRelationships(t *testing.T, ds datastore.Datastore, revision datastore.Revision) []string {
+	ctx := context.Background()
+	reader := ds.SnapshotReader(revision)
+
+	namespaces, err := reader.ListNamespaces(ctx)
+	require.NoError(t, err)
+
+	var found []string
+	for _, ns := range namespaces {
+		iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: ns.Name})
+		require.NoError(t, err)
+
+		for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+			found = append(found, tuple.String(tpl))
+		}
+		require.NoError(t, iter.Err())
+		iter.Close()
+	}
+
+	sort.Strings(found)
+	return found
+}
+
+func TestExportImport(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	rawSource, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+	source, revision := testfixtures.StandardDatastoreWithCaveatedData(rawSource, require)
+
+	var snapshot bytes.Buffer
+	exported, err := Export(ctx, source, revision, &snapshot)
+	require.NoError(err)
+	require.Equal(Summary{Caveats: 1, Namespaces: 3, Relationships: uint64(len(testfixtures.StandardTuples))}, exported)
+
+	target, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(err)
+
+	importedRevision, imported, err := Import(ctx, target, &snapshot)
+	require.NoError(err)
+	require.Equal(exported, imported)
+
+	require.Equal(readAllRelationships(t, source, revision), readAllRelationships(t, target, importedRevision))
+
+	caveat, _, err := target.SnapshotReader(importedRevision).ReadCaveatByName(ctx, "test")
+	require.NoError(err)
+	require.Equal("test", caveat.Name)
+}
+
+func TestImportErrors(t *testing.T) {
+	ctx := context.Background()
+
+	rawSource, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+	require.NoError(t, err)
+	source, revision := testfixtures.StandardDatastoreWithData(rawSource, require.New(t))
+
+	var snapshot bytes.Buffer
+	_, err = Export(ctx, source, revision, &snapshot)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		snapshot []byte
+	}{
+		{"empty", nil},
+		{"truncated in header", snapshot.Bytes()[:3]},
+		{"truncated in relationships", snapshot.Bytes()[:snapshot.Len()-20]},
+		{"missing footer", snapshot.Bytes()[:snapshot.Len()-8]},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			target, err := memdb.NewMemdbDatastore(0, 0, memdb.DisableGC)
+			require.NoError(t, err)
+
+			_, _, err = Import(ctx, target, bytes.NewReader(tc.snapshot))
+			require.ErrorIs(t, err, ErrTruncated)
+		})
+	}
+}
@@ -9,6 +9,13 @@ import (
 // ComputeObjectTypeStats creates a list of object type stats from an input list of
 // parsed object types.
 func ComputeObjectTypeStats(objTypes []*core.NamespaceDefinition) []ObjectTypeStat {
+	return ComputeObjectTypeStatsWithEstimates(objTypes, nil)
+}
+
+// ComputeObjectTypeStatsWithEstimates creates a list of object type stats from an input list of
+// parsed object types, along with the estimated number of relationships for each object type,
+// keyed by object type name.
+func ComputeObjectTypeStatsWithEstimates(objTypes []*core.NamespaceDefinition, relationshipEstimates map[string]uint64) []ObjectTypeStat {
 	stats := make([]ObjectTypeStat, 0, len(objTypes))
 
 	for _, objType := range objTypes {
@@ -23,8 +30,10 @@ func ComputeObjectTypeStats(objTypes []*core.NamespaceDefinition) []ObjectTypeSt
 		}
 
 		stats = append(stats, ObjectTypeStat{
-			NumRelations:   relations,
-			NumPermissions: permissions,
+			Name:                       objType.Name,
+			EstimatedRelationshipCount: relationshipEstimates[objType.Name],
+			NumRelations:               relations,
+			NumPermissions:             permissions,
 		})
 	}
 
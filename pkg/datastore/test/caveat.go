@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/pkg/caveats"
 	caveattypes "github.com/authzed/spicedb/pkg/caveats/types"
@@ -73,8 +74,8 @@ func WriteReadDeleteCaveatTest(t *testing.T, tester DatastoreTester) {
 	foundDiff = cmp.Diff(anotherCoreCaveat, cvs[1], protocmp.Transform())
 	req.Empty(foundDiff)
 
-	// All caveats can be filtered by names
-	cvs, err = cr.ListCaveats(ctx, coreCaveat.Name)
+	// Caveats can be looked up by names
+	cvs, err = cr.LookupCaveats(ctx, []string{coreCaveat.Name})
 	req.NoError(err)
 	req.Len(cvs, 1)
 
@@ -82,7 +83,7 @@ func WriteReadDeleteCaveatTest(t *testing.T, tester DatastoreTester) {
 	req.Empty(foundDiff)
 
 	// Non-existing names returns no caveat
-	cvs, err = cr.ListCaveats(ctx, "doesnotexist")
+	cvs, err = cr.LookupCaveats(ctx, []string{"doesnotexist"})
 	req.NoError(err)
 	req.Empty(cvs)
 
@@ -215,6 +216,49 @@ func CaveatedRelationshipFilterTest(t *testing.T, tester DatastoreTester) {
 	expectTuple(req, iter, anotherTpl)
 }
 
+// ListCaveatsPaginationTest tests listing the caveats of a datastore in pages.
+func ListCaveatsPaginationTest(t *testing.T, tester DatastoreTester) {
+	req := require.New(t)
+	ds, err := tester.New(0*time.Second, veryLargeGCWindow, 1)
+	req.NoError(err)
+
+	skipIfNotCaveatStorer(t, ds)
+
+	ctx := context.Background()
+	var written []*core.CaveatDefinition
+	for _, name := range []string{"echo", "alpha", "delta", "charlie", "bravo"} {
+		coreCaveat := createCoreCaveat(t)
+		coreCaveat.Name = name
+		written = append(written, coreCaveat)
+	}
+	rev, err := writeCaveats(ctx, ds, written...)
+	req.NoError(err)
+
+	cr := ds.SnapshotReader(rev)
+	var paged []string
+	limit := uint64(2)
+	for after := ""; ; {
+		page, err := cr.ListCaveats(ctx, options.WithListLimit(&limit), options.WithListAfter(after))
+		req.NoError(err)
+		req.LessOrEqual(len(page), int(limit))
+		if len(page) == 0 {
+			break
+		}
+
+		for _, cv := range page {
+			paged = append(paged, cv.Name)
+		}
+		after = page[len(page)-1].Name
+	}
+	req.Equal([]string{"alpha", "bravo", "charlie", "delta", "echo"}, paged)
+
+	cvs, err := cr.ListCaveats(ctx, options.WithListAfter("charlie"))
+	req.NoError(err)
+	req.Len(cvs, 2)
+	req.Equal("delta", cvs[0].Name)
+	req.Equal("echo", cvs[1].Name)
+}
+
 func CaveatSnapshotReadsTest(t *testing.T, tester DatastoreTester) {
 	req := require.New(t)
 	ds, err := tester.New(0*time.Second, veryLargeGCWindow, 1)
@@ -316,6 +360,7 @@ func expectTupleChange(t *testing.T, ds datastore.Datastore, revBeforeWrite data
 
 	chanRevisionChanges, chanErr := ds.Watch(ctx, revBeforeWrite)
 	require.Zero(t, len(chanErr))
+	chanRevisionChanges = skipCheckpoints(ctx, chanRevisionChanges)
 
 	changeWait := time.NewTimer(waitForChangesTimeout)
 	select {
@@ -350,6 +395,50 @@ func assertTupleCorrectlyStored(req *require.Assertions, ds datastore.Datastore,
 	req.Empty(foundDiff)
 }
 
+// CaveatHistoryTest tests that every version of a caveat definition is retained and returned in
+// the order it was written.
+func CaveatHistoryTest(t *testing.T, tester DatastoreTester) {
+	req := require.New(t)
+	ds, err := tester.New(0*time.Second, veryLargeGCWindow, 1)
+	req.NoError(err)
+
+	skipIfNotCaveatStorer(t, ds)
+
+	historyDS, ok := datastore.UnwrapAs[datastore.SchemaHistoryDatastore](ds)
+	if !ok {
+		t.Skip("datastore does not retain schema history")
+	}
+
+	ctx := context.Background()
+	coreCaveat := createCoreCaveat(t)
+	oldRev, err := writeCaveat(ctx, ds, coreCaveat)
+	req.NoError(err)
+
+	oldExpression := coreCaveat.SerializedExpression
+	coreCaveat.SerializedExpression = []byte{0x0a}
+	newRev, err := writeCaveat(ctx, ds, coreCaveat)
+	req.NoError(err)
+
+	deletedRev, err := ds.ReadWriteTx(ctx, func(tx datastore.ReadWriteTransaction) error {
+		return tx.DeleteCaveats(ctx, []string{coreCaveat.Name})
+	})
+	req.NoError(err)
+	req.True(deletedRev.GreaterThan(newRev))
+
+	history, err := historyDS.CaveatHistory(ctx, coreCaveat.Name)
+	req.NoError(err)
+	req.Len(history, 2)
+
+	req.Equal(oldExpression, history[0].Definition.SerializedExpression)
+	req.True(oldRev.Equal(history[0].Revision))
+	req.False(history[0].Deleted)
+
+	req.Equal(coreCaveat.SerializedExpression, history[1].Definition.SerializedExpression)
+	req.True(newRev.Equal(history[1].Revision))
+	req.True(history[1].Deleted)
+	req.False(history[1].WrittenAt.Before(history[0].WrittenAt))
+}
+
 func skipIfNotCaveatStorer(t *testing.T, ds datastore.Datastore) {
 	ctx := context.Background()
 	_, _ = ds.ReadWriteTx(ctx, func(transaction datastore.ReadWriteTransaction) error { // nolint: errcheck
@@ -35,8 +35,10 @@ func All(t *testing.T, tester DatastoreTester) {
 	t.Run("TestNamespaceWrite", func(t *testing.T) { NamespaceWriteTest(t, tester) })
 	t.Run("TestNamespaceDelete", func(t *testing.T) { NamespaceDeleteTest(t, tester) })
 	t.Run("TestNamespaceMultiDelete", func(t *testing.T) { NamespaceMultiDeleteTest(t, tester) })
+	t.Run("TestListNamespacesPagination", func(t *testing.T) { ListNamespacesPaginationTest(t, tester) })
 	t.Run("TestEmptyNamespaceDelete", func(t *testing.T) { EmptyNamespaceDeleteTest(t, tester) })
 	t.Run("TestStableNamespaceReadWrite", func(t *testing.T) { StableNamespaceReadWriteTest(t, tester) })
+	t.Run("TestNamespaceHistory", func(t *testing.T) { NamespaceHistoryTest(t, tester) })
 
 	t.Run("TestSimple", func(t *testing.T) { SimpleTest(t, tester) })
 	t.Run("TestDeleteRelationships", func(t *testing.T) { DeleteRelationshipsTest(t, tester) })
@@ -46,6 +48,13 @@ func All(t *testing.T, tester DatastoreTester) {
 	t.Run("TestWriteDeleteWrite", func(t *testing.T) { WriteDeleteWriteTest(t, tester) })
 	t.Run("TestCreateAlreadyExisting", func(t *testing.T) { CreateAlreadyExistingTest(t, tester) })
 	t.Run("TestTouchAlreadyExisting", func(t *testing.T) { TouchAlreadyExistingTest(t, tester) })
+	t.Run("TestBulkLoad", func(t *testing.T) { BulkLoadTest(t, tester) })
+	t.Run("TestCountRelationships", func(t *testing.T) { CountRelationshipsTest(t, tester) })
+	t.Run("TestListResourceTypes", func(t *testing.T) { ListResourceTypesTest(t, tester) })
+	t.Run("TestSortedReverseQuery", func(t *testing.T) { SortedReverseQueryTest(t, tester) })
+	t.Run("TestExpiringRelationships", func(t *testing.T) { ExpiringRelationshipsTest(t, tester) })
+	t.Run("TestExpirationAtRevision", func(t *testing.T) { ExpirationAtRevisionTest(t, tester) })
+	t.Run("TestRelationshipMetadata", func(t *testing.T) { RelationshipMetadataTest(t, tester) })
 	t.Run("TestUsersets", func(t *testing.T) { UsersetsTest(t, tester) })
 	t.Run("TestMultipleReadsInRWT", func(t *testing.T) { MultipleReadsInRWTTest(t, tester) })
 	t.Run("TestConcurrentWriteSerialization", func(t *testing.T) { ConcurrentWriteSerializationTest(t, tester) })
@@ -55,13 +64,22 @@ func All(t *testing.T, tester DatastoreTester) {
 
 	t.Run("TestWatch", func(t *testing.T) { WatchTest(t, tester) })
 	t.Run("TestWatchCancel", func(t *testing.T) { WatchCancelTest(t, tester) })
+	t.Run("TestWatchWithMetadata", func(t *testing.T) { WatchWithMetadataTest(t, tester) })
+	t.Run("TestWatchCheckpoint", func(t *testing.T) { WatchCheckpointTest(t, tester) })
+	t.Run("TestWatchExpiration", func(t *testing.T) { WatchExpirationTest(t, tester) })
 
 	t.Run("TestStats", func(t *testing.T) { StatsTest(t, tester) })
 
+	t.Run("TestGarbageCollection", func(t *testing.T) { GarbageCollectionTest(t, tester) })
+
+	t.Run("TestTenantIsolation", func(t *testing.T) { TenantIsolationTest(t, tester) })
+
 	t.Run("TestWriteReadDeleteCaveat", func(t *testing.T) { WriteReadDeleteCaveatTest(t, tester) })
 	t.Run("TestWriteCaveatedRelationship", func(t *testing.T) { WriteCaveatedRelationshipTest(t, tester) })
 	t.Run("TestCaveatedRelationshipFilter", func(t *testing.T) { CaveatedRelationshipFilterTest(t, tester) })
+	t.Run("TestListCaveatsPagination", func(t *testing.T) { ListCaveatsPaginationTest(t, tester) })
 	t.Run("TestCaveatSnapshotReads", func(t *testing.T) { CaveatSnapshotReadsTest(t, tester) })
+	t.Run("TestCaveatHistory", func(t *testing.T) { CaveatHistoryTest(t, tester) })
 	t.Run("TestCaveatedRelationshipWatch", func(t *testing.T) { CaveatedRelationshipWatchTest(t, tester) })
 }
 
@@ -0,0 +1,22 @@
+// Package test is the conformance suite for datastore implementations. Each datastore in SpiceDB
+// runs it, and the authors of other datastores can run it to validate their implementations
+// against the same consistency, revision, garbage collection and watch semantics.
+//
+// The suite is run by passing All a DatastoreTester, which creates a new, empty datastore for each
+// test:
+//
+//	func TestMyDatastore(t *testing.T) {
+//		test.All(t, test.DatastoreTesterFunc(func(revisionQuantization, gcWindow time.Duration, watchBufferLength uint16) (datastore.Datastore, error) {
+//			return mydatastore.New(revisionQuantization, gcWindow, watchBufferLength)
+//		}))
+//	}
+//
+// The revision quantization is the interval to which optimized revisions are rounded, the GC
+// window is the duration for which deleted data must remain readable at earlier revisions, and
+// the watch buffer length is the number of changes which may be buffered for a watch before it is
+// disconnected. A datastore which does not support a setting may ignore it, but the tests which
+// depend on it may then fail.
+//
+// The tests of optional capabilities, such as garbage collection and tenants, are skipped for a
+// datastore which does not implement the interface of the capability.
+package test
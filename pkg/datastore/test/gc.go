@@ -0,0 +1,85 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// GarbageCollectionTest tests that garbage collection removes the relationships deleted before
+// the garbage collection window, and leaves the data at later revisions intact.
+func GarbageCollectionTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	gcWindow := time.Second
+	rawDS, err := tester.New(0, gcWindow, 1)
+	require.NoError(err)
+
+	ds, ok := datastore.UnwrapAs[datastore.GarbageCollectableDatastore](rawDS)
+	if !ok {
+		t.Skip("datastore does not support garbage collection")
+	}
+
+	ctx := context.Background()
+	setupDatastore(ds, require)
+
+	deleted := makeTestTuple("deleted", "tom")
+	living := makeTestTuple("living", "tom")
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, deleted, living)
+	require.NoError(err)
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, deleted)
+	require.NoError(err)
+
+	// A transaction after the deletion is needed for the deletion to fall before the watermark.
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_TOUCH, living)
+	require.NoError(err)
+
+	time.Sleep(gcWindow + 100*time.Millisecond)
+
+	recent := makeTestTuple("recent", "tom")
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, recent)
+	require.NoError(err)
+
+	collected, err := ds.CollectGarbage(ctx)
+	require.NoError(err)
+	require.GreaterOrEqual(collected.Relationships, int64(1))
+
+	head, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+	require.ElementsMatch(
+		[]string{tuple.String(living), tuple.String(recent)},
+		readTestResources(ctx, t, ds, head),
+	)
+
+	// The collected relationship can be written again.
+	rewritten, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, deleted)
+	require.NoError(err)
+	require.ElementsMatch(
+		[]string{tuple.String(deleted), tuple.String(living), tuple.String(recent)},
+		readTestResources(ctx, t, ds, rewritten),
+	)
+}
+
+// readTestResources returns the relationships of the test resources at the revision.
+func readTestResources(ctx context.Context, t *testing.T, ds datastore.Datastore, revision datastore.Revision) []string {
+	iter, err := ds.SnapshotReader(revision).QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType: testResourceNamespace,
+	})
+	require.NoError(t, err)
+	defer iter.Close()
+
+	var found []string
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		found = append(found, tuple.String(tpl))
+	}
+	require.NoError(t, iter.Err())
+	return found
+}
@@ -3,15 +3,18 @@ package test
 import (
 	"context"
 	"errors"
+	"sort"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/testing/protocmp"
 
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/internal/testfixtures"
 	"github.com/authzed/spicedb/pkg/datastore"
 	ns "github.com/authzed/spicedb/pkg/namespace"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
 	"github.com/authzed/spicedb/pkg/schemadsl/generator"
 	"github.com/authzed/spicedb/pkg/schemadsl/input"
@@ -199,6 +202,50 @@ func NamespaceMultiDeleteTest(t *testing.T, tester DatastoreTester) {
 	require.Len(t, namespacesAfterDel, 0)
 }
 
+// ListNamespacesPaginationTest tests listing the namespaces of a datastore in pages.
+func ListNamespacesPaginationTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, revision := testfixtures.StandardDatastoreWithSchema(rawDS, require)
+	ctx := context.Background()
+	reader := ds.SnapshotReader(revision)
+
+	namespaces, err := reader.ListNamespaces(ctx)
+	require.NoError(err)
+	require.Greater(len(namespaces), 2)
+
+	var expected []string
+	for _, ns := range namespaces {
+		expected = append(expected, ns.Name)
+	}
+	require.True(sort.StringsAreSorted(expected))
+
+	var paged []string
+	limit := uint64(2)
+	for after := ""; ; {
+		page, err := reader.ListNamespaces(ctx, options.WithListLimit(&limit), options.WithListAfter(after))
+		require.NoError(err)
+		require.LessOrEqual(len(page), int(limit))
+		if len(page) == 0 {
+			break
+		}
+
+		for _, ns := range page {
+			paged = append(paged, ns.Name)
+		}
+		after = page[len(page)-1].Name
+	}
+	require.Equal(expected, paged)
+
+	noLimit := uint64(0)
+	empty, err := reader.ListNamespaces(ctx, options.WithListLimit(&noLimit))
+	require.NoError(err)
+	require.Empty(empty)
+}
+
 // EmptyNamespaceDeleteTest tests deleting an empty namespace in the datastore.
 func EmptyNamespaceDeleteTest(t *testing.T, tester DatastoreTester) {
 	require := require.New(t)
@@ -279,3 +326,57 @@ definition document {
 	generated, _ := generator.GenerateSchema([]compiler.SchemaDefinition{readCaveatDef, readNsDef})
 	require.Equal(schemaString, generated)
 }
+
+// NamespaceHistoryTest tests that every version of a namespace definition is retained and
+// returned in the order it was written.
+func NamespaceHistoryTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	historyDS, ok := datastore.UnwrapAs[datastore.SchemaHistoryDatastore](rawDS)
+	if !ok {
+		t.Skip("datastore does not retain schema history")
+	}
+
+	ctx := context.Background()
+	var written []datastore.Revision
+	for _, write := range []func(rwt datastore.ReadWriteTransaction) error{
+		func(rwt datastore.ReadWriteTransaction) error { return rwt.WriteNamespaces(ctx, testNamespace) },
+		func(rwt datastore.ReadWriteTransaction) error { return rwt.WriteNamespaces(ctx, updatedNamespace) },
+		func(rwt datastore.ReadWriteTransaction) error { return rwt.DeleteNamespaces(ctx, testNamespace.Name) },
+		func(rwt datastore.ReadWriteTransaction) error { return rwt.WriteNamespaces(ctx, testNamespace) },
+	} {
+		revision, err := rawDS.ReadWriteTx(ctx, write)
+		require.NoError(err)
+		written = append(written, revision)
+	}
+
+	history, err := historyDS.NamespaceHistory(ctx, testNamespace.Name)
+	require.NoError(err)
+	require.Len(history, 3)
+
+	for index, expected := range []struct {
+		definition *core.NamespaceDefinition
+		revision   datastore.Revision
+		deleted    bool
+	}{
+		{testNamespace, written[0], false},
+		{updatedNamespace, written[1], true},
+		{testNamespace, written[3], false},
+	} {
+		version := history[index]
+		testutil.RequireProtoEqual(t, expected.definition, version.Definition, "found changed namespace definition")
+		require.True(expected.revision.Equal(version.Revision))
+		require.Equal(expected.deleted, version.Deleted)
+		require.False(version.WrittenAt.IsZero())
+		if index > 0 {
+			require.False(version.WrittenAt.Before(history[index-1].WrittenAt))
+		}
+	}
+
+	history, err = historyDS.NamespaceHistory(ctx, "unknown/namespace")
+	require.NoError(err)
+	require.Empty(history)
+}
@@ -27,6 +27,9 @@ func StatsTest(t *testing.T, tester DatastoreTester) {
 
 		require.Len(stats.UniqueID, 36, "unique ID must be a valid UUID")
 		require.Len(stats.ObjectTypeStatistics, 3, "must report object stats")
+		for _, objTypeStats := range stats.ObjectTypeStatistics {
+			require.NotEmpty(objTypeStats.Name, "must report object type names")
+		}
 
 		if stats.EstimatedRelationshipCount == uint64(0) && retryCount > 0 {
 			// Sleep for a bit to get the stats table to update.
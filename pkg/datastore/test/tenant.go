@@ -0,0 +1,119 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// TenantIsolationTest tests that the data of each tenant of a datastore is read, written and
+// watched in isolation from the data of other tenants.
+func TenantIsolationTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 16)
+	require.NoError(err)
+
+	partitioned, ok := datastore.UnwrapAs[datastore.TenantPartitionedDatastore](rawDS)
+	if !ok {
+		t.Skip("datastore does not support partitioning by tenant")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := partitioned.ForTenant("first")
+	second := partitioned.ForTenant("second")
+
+	setupDatastore(first, require)
+	head, err := first.HeadRevision(ctx)
+	require.NoError(err)
+	changes, errchan := first.Watch(ctx, head)
+
+	tpl := makeTestTuple("foo", "tom")
+	firstRevision, err := first.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{tuple.Create(tpl)})
+	})
+	require.NoError(err)
+
+	// The same definitions and relationship can be written by another tenant.
+	setupDatastore(second, require)
+	secondRevision, err := second.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+			tuple.Create(tpl),
+			tuple.Create(makeTestTuple("bar", "fred")),
+		})
+	})
+	require.NoError(err)
+
+	for _, tc := range []struct {
+		name     string
+		ds       datastore.Datastore
+		revision datastore.Revision
+		expected []*core.RelationTuple
+	}{
+		{"first", first, firstRevision, []*core.RelationTuple{tpl}},
+		{"second", second, secondRevision, []*core.RelationTuple{tpl, makeTestTuple("bar", "fred")}},
+		{"default", rawDS, secondRevision, nil},
+	} {
+		reader := tc.ds.SnapshotReader(tc.revision)
+
+		iter, err := reader.QueryRelationships(ctx, datastore.RelationshipsFilter{ResourceType: testResourceNamespace})
+		require.NoError(err, tc.name)
+
+		var found []string
+		for rel := iter.Next(); rel != nil; rel = iter.Next() {
+			found = append(found, tuple.String(rel))
+		}
+		require.NoError(iter.Err(), tc.name)
+		iter.Close()
+
+		var expected []string
+		for _, tpl := range tc.expected {
+			expected = append(expected, tuple.String(tpl))
+		}
+		require.ElementsMatch(expected, found, tc.name)
+
+		namespaces, err := reader.ListNamespaces(ctx)
+		require.NoError(err, tc.name)
+		if tc.expected == nil {
+			require.Empty(namespaces, tc.name)
+		} else {
+			require.Len(namespaces, 2, tc.name)
+		}
+	}
+
+	// Deleting a tenant's namespace deletes only the tenant's relationships.
+	deletedRevision, err := second.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.DeleteNamespaces(ctx, testResourceNamespace)
+	})
+	require.NoError(err)
+
+	_, _, err = second.SnapshotReader(deletedRevision).ReadNamespace(ctx, testResourceNamespace)
+	require.ErrorAs(err, &datastore.ErrNamespaceNotFound{})
+
+	_, _, err = first.SnapshotReader(deletedRevision).ReadNamespace(ctx, testResourceNamespace)
+	require.NoError(err)
+
+	count, err := first.SnapshotReader(deletedRevision).CountRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType: testResourceNamespace,
+	})
+	require.NoError(err)
+	require.Equal(uint64(1), count)
+
+	// The watch of the first tenant sees only its own changes.
+	_, err = first.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{tuple.Delete(tpl)})
+	})
+	require.NoError(err)
+
+	verifyUpdates(require, [][]*core.RelationTupleUpdate{
+		{tuple.Touch(tpl)},
+		{tuple.Delete(tpl)},
+	}, skipCheckpoints(ctx, changes), errchan, false)
+}
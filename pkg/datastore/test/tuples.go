@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"sync"
 	"testing"
@@ -12,6 +13,7 @@ import (
 	v1 "github.com/authzed/authzed-go/proto/authzed/api/v1"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
 	"github.com/authzed/spicedb/internal/datastore/options"
@@ -591,6 +593,382 @@ func TouchAlreadyExistingTest(t *testing.T, tester DatastoreTester) {
 	require.NoError(err)
 }
 
+// BulkLoadTest tests loading relationships in bulk from a source.
+func BulkLoadTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+
+	// Load enough relationships to span multiple batches.
+	numTuples := common.DefaultBulkLoadBatchSize*2 + 1
+	tuples := make([]*core.RelationTuple, 0, numTuples)
+	for i := 0; i < numTuples; i++ {
+		tuples = append(tuples, makeTestTuple("bulk", fmt.Sprintf("user%d", i)))
+	}
+
+	revision, err := ds.BulkLoad(ctx, common.SliceBulkLoadSource(tuples))
+	require.NoError(err)
+
+	iter, err := ds.SnapshotReader(revision).QueryRelationships(ctx, datastore.RelationshipsFilter{
+		ResourceType:             testResourceNamespace,
+		OptionalResourceIds:      []string{"bulk"},
+		OptionalResourceRelation: testReaderRelation,
+	})
+	require.NoError(err)
+	tRequire := testfixtures.TupleChecker{Require: require, DS: ds}
+	tRequire.VerifyIteratorResults(iter, tuples...)
+
+	// Loading a relationship which already exists must fail.
+	_, err = ds.BulkLoad(ctx, common.SliceBulkLoadSource(tuples[:1]))
+	require.ErrorAs(err, &common.CreateRelationshipExistsError{})
+}
+
+// CountRelationshipsTest tests counting relationships matching filters.
+func CountRelationshipsTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+
+	tpl1 := makeTestTuple("foo", "tom")
+	tpl2 := makeTestTuple("foo", "sarah")
+	tpl3 := makeTestTuple("bar", "tom")
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, tpl1, tpl2, tpl3)
+	require.NoError(err)
+
+	testCases := []struct {
+		name     string
+		filter   datastore.RelationshipsFilter
+		expected uint64
+	}{
+		{
+			"resource type",
+			datastore.RelationshipsFilter{ResourceType: testResourceNamespace},
+			3,
+		},
+		{
+			"resource IDs",
+			datastore.RelationshipsFilter{
+				ResourceType:        testResourceNamespace,
+				OptionalResourceIds: []string{"foo"},
+			},
+			2,
+		},
+		{
+			"subject",
+			datastore.RelationshipsFilter{
+				ResourceType:             testResourceNamespace,
+				OptionalResourceRelation: testReaderRelation,
+				OptionalSubjectsFilter: &datastore.SubjectsFilter{
+					SubjectType:        testUserNamespace,
+					OptionalSubjectIds: []string{"tom"},
+				},
+			},
+			2,
+		},
+		{
+			"no matches",
+			datastore.RelationshipsFilter{
+				ResourceType:        testResourceNamespace,
+				OptionalResourceIds: []string{"unknown"},
+			},
+			0,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			count, err := ds.SnapshotReader(revision).CountRelationships(ctx, tc.filter)
+			require.NoError(err)
+			require.Equal(tc.expected, count)
+		})
+	}
+
+	// Deleted relationships must not be counted at later revisions.
+	deletedAt, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, tpl1)
+	require.NoError(err)
+
+	count, err := ds.SnapshotReader(deletedAt).CountRelationships(ctx, datastore.RelationshipsFilter{ResourceType: testResourceNamespace})
+	require.NoError(err)
+	require.Equal(uint64(2), count)
+
+	count, err = ds.SnapshotReader(revision).CountRelationships(ctx, datastore.RelationshipsFilter{ResourceType: testResourceNamespace})
+	require.NoError(err)
+	require.Equal(uint64(3), count)
+}
+
+// ListResourceTypesTest tests that the resource types of the relationships stored are listed,
+// whether or not their namespace is defined.
+func ListResourceTypesTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+	ctx := context.Background()
+
+	head, err := ds.HeadRevision(ctx)
+	require.NoError(err)
+
+	resourceTypes, err := ds.SnapshotReader(head).ListResourceTypes(ctx)
+	require.NoError(err)
+	require.Empty(resourceTypes)
+
+	undefined := tuple.MustParse("test/undefined:foo#viewer@test/user:tom")
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE,
+		makeTestTuple("foo", "tom"),
+		makeTestTuple("bar", "tom"),
+		undefined,
+	)
+	require.NoError(err)
+
+	resourceTypes, err = ds.SnapshotReader(revision).ListResourceTypes(ctx)
+	require.NoError(err)
+	require.Equal([]string{testResourceNamespace, "test/undefined"}, resourceTypes)
+
+	deletedAt, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_DELETE, undefined)
+	require.NoError(err)
+
+	resourceTypes, err = ds.SnapshotReader(deletedAt).ListResourceTypes(ctx)
+	require.NoError(err)
+	require.Equal([]string{testResourceNamespace}, resourceTypes)
+}
+
+// SortedReverseQueryTest tests that reverse queries sorted by subject return the relationships
+// in a stable order, and can be paged through with a cursor.
+func SortedReverseQueryTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+
+	var written []*core.RelationTuple
+	for _, resourceID := range []string{"foo", "bar", "baz"} {
+		for _, userID := range []string{"tom", "sarah", "alice"} {
+			if resourceID == "baz" && userID == "sarah" {
+				continue
+			}
+			written = append(written, makeTestTuple(resourceID, userID))
+		}
+	}
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, written...)
+	require.NoError(err)
+
+	expected := make([]string, 0, len(written))
+	for _, tpl := range written {
+		expected = append(expected, tuple.String(tpl))
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		first, second := tuple.MustParse(expected[i]), tuple.MustParse(expected[j])
+		if first.Subject.ObjectId != second.Subject.ObjectId {
+			return first.Subject.ObjectId < second.Subject.ObjectId
+		}
+		return first.ResourceAndRelation.ObjectId < second.ResourceAndRelation.ObjectId
+	})
+
+	reader := ds.SnapshotReader(revision)
+	for _, pageSize := range []uint64{1, 2, 3, uint64(len(written))} {
+		pageSize := pageSize
+		t.Run(fmt.Sprintf("page size %d", pageSize), func(t *testing.T) {
+			var found []string
+			var cursor options.Cursor
+			for {
+				iter, err := reader.ReverseQueryRelationships(ctx, datastore.SubjectsFilter{
+					SubjectType: testUserNamespace,
+				}, options.WithReverseSort(options.BySubject), options.WithReverseAfter(cursor), options.WithReverseLimit(&pageSize))
+				require.NoError(err)
+
+				var page uint64
+				for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+					found = append(found, tuple.String(tpl))
+					cursor = tpl
+					page++
+				}
+				require.NoError(iter.Err())
+				iter.Close()
+
+				if page < pageSize {
+					break
+				}
+			}
+
+			require.Equal(expected, found)
+		})
+	}
+}
+
+// ExpiringRelationshipsTest tests that expired relationships are no longer read and can be
+// created again.
+func ExpiringRelationshipsTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+
+	expiresLater := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	expiredEarlier := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	living := makeTestTuple("foo", "tom")
+	living.OptionalExpirationTime = timestamppb.New(expiresLater)
+
+	expired := makeTestTuple("foo", "sarah")
+	expired.OptionalExpirationTime = timestamppb.New(expiredEarlier)
+
+	permanent := makeTestTuple("foo", "fred")
+
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, living, expired, permanent)
+	require.NoError(err)
+
+	filter := datastore.RelationshipsFilter{ResourceType: testResourceNamespace, OptionalResourceIds: []string{"foo"}}
+	iter, err := ds.SnapshotReader(revision).QueryRelationships(ctx, filter)
+	require.NoError(err)
+	t.Cleanup(iter.Close)
+
+	found := make(map[string]*core.RelationTuple)
+	for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+		found[tpl.Subject.ObjectId] = tpl
+	}
+	require.NoError(iter.Err())
+
+	require.Len(found, 2)
+	require.NotNil(found["tom"].OptionalExpirationTime)
+	require.True(expiresLater.Equal(found["tom"].OptionalExpirationTime.AsTime()))
+	require.Nil(found["fred"].OptionalExpirationTime)
+
+	count, err := ds.SnapshotReader(revision).CountRelationships(ctx, filter)
+	require.NoError(err)
+	require.Equal(uint64(2), count)
+
+	// An expired relationship no longer exists, so it can be created again.
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, makeTestTuple("foo", "sarah"))
+	require.NoError(err)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, living)
+	require.ErrorAs(err, &common.CreateRelationshipExistsError{})
+}
+
+// ExpirationAtRevisionTest tests that expiration is evaluated at the time of the revision being
+// read, so that a relationship which has since expired is still read at earlier revisions.
+func ExpirationAtRevisionTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(time.Second).UTC()
+	expiring := makeTestTuple("foo", "tom")
+	expiring.OptionalExpirationTime = timestamppb.New(expiresAt)
+
+	beforeExpiration, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, expiring)
+	require.NoError(err)
+
+	time.Sleep(time.Until(expiresAt) + 100*time.Millisecond)
+
+	afterExpiration, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, makeTestTuple("foo", "fred"))
+	require.NoError(err)
+
+	filter := datastore.RelationshipsFilter{
+		ResourceType:           testResourceNamespace,
+		OptionalResourceIds:    []string{"foo"},
+		OptionalSubjectsFilter: &datastore.SubjectsFilter{SubjectType: testUserNamespace, OptionalSubjectIds: []string{"tom"}},
+	}
+
+	count, err := ds.SnapshotReader(beforeExpiration).CountRelationships(ctx, filter)
+	require.NoError(err)
+	require.Equal(uint64(1), count, "the relationship had not expired at the revision it was written")
+
+	count, err = ds.SnapshotReader(afterExpiration).CountRelationships(ctx, filter)
+	require.NoError(err)
+	require.Equal(uint64(0), count, "the relationship had expired at the later revision")
+}
+
+// RelationshipMetadataTest tests that relationship metadata is persisted and can be filtered on.
+func RelationshipMetadataTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	rawDS, err := tester.New(0, veryLargeGCWindow, 1)
+	require.NoError(err)
+
+	ds, _ := testfixtures.StandardDatastoreWithData(rawDS, require)
+	ctx := context.Background()
+
+	fromImport := makeTestTuple("foo", "tom")
+	fromImport.OptionalMetadata = map[string]string{"source": "import", "ticket": "ABC-123"}
+
+	fromConsole := makeTestTuple("foo", "sarah")
+	fromConsole.OptionalMetadata = map[string]string{"source": "console"}
+
+	unlabeled := makeTestTuple("foo", "fred")
+
+	revision, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, fromImport, fromConsole, unlabeled)
+	require.NoError(err)
+
+	testCases := []struct {
+		name     string
+		metadata map[string]string
+		expected []string
+	}{
+		{"no metadata filter", nil, []string{"fred", "sarah", "tom"}},
+		{"key present", map[string]string{"source": ""}, []string{"sarah", "tom"}},
+		{"key and value", map[string]string{"source": "console"}, []string{"sarah"}},
+		{"multiple keys", map[string]string{"source": "import", "ticket": ""}, []string{"tom"}},
+		{"no matches", map[string]string{"source": "unknown"}, nil},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			iter, err := ds.SnapshotReader(revision).QueryRelationships(ctx, datastore.RelationshipsFilter{
+				ResourceType:     testResourceNamespace,
+				OptionalMetadata: tc.metadata,
+			})
+			require.NoError(err)
+			defer iter.Close()
+
+			var found []string
+			for tpl := iter.Next(); tpl != nil; tpl = iter.Next() {
+				found = append(found, tpl.Subject.ObjectId)
+
+				switch tpl.Subject.ObjectId {
+				case "tom":
+					require.Equal(fromImport.OptionalMetadata, tpl.OptionalMetadata)
+				case "sarah":
+					require.Equal(fromConsole.OptionalMetadata, tpl.OptionalMetadata)
+				default:
+					require.Empty(tpl.OptionalMetadata)
+				}
+			}
+			require.NoError(iter.Err())
+
+			sort.Strings(found)
+			require.Equal(tc.expected, found)
+
+			count, err := ds.SnapshotReader(revision).CountRelationships(ctx, datastore.RelationshipsFilter{
+				ResourceType:     testResourceNamespace,
+				OptionalMetadata: tc.metadata,
+			})
+			require.NoError(err)
+			require.Equal(uint64(len(tc.expected)), count)
+		})
+	}
+}
+
 // UsersetsTest tests whether or not the requirements for reading usersets hold
 // for a particular datastore.
 func UsersetsTest(t *testing.T, tester DatastoreTester) {
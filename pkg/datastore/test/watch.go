@@ -13,8 +13,10 @@ import (
 	"github.com/scylladb/go-set/strset"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/internal/datastore/options"
 	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	"github.com/authzed/spicedb/pkg/tuple"
@@ -60,6 +62,7 @@ func WatchTest(t *testing.T, tester DatastoreTester) {
 
 			changes, errchan := ds.Watch(ctx, lowestRevision)
 			require.Zero(len(errchan))
+			changes = skipCheckpoints(ctx, changes)
 
 			var testUpdates [][]*core.RelationTupleUpdate
 			var bulkDeletes []*core.RelationTupleUpdate
@@ -111,6 +114,7 @@ func WatchTest(t *testing.T, tester DatastoreTester) {
 
 			// Test the catch-up case
 			changes, errchan = ds.Watch(ctx, lowestRevision)
+			changes = skipCheckpoints(ctx, changes)
 			verifyUpdates(require, testUpdates, changes, errchan, tc.expectFallBehind)
 		})
 	}
@@ -158,6 +162,26 @@ func verifyUpdates(
 	require.False(expectDisconnect, "all changes verified without expected disconnect")
 }
 
+// skipCheckpoints returns the changes sent by a watch, without its checkpoints.
+func skipCheckpoints(ctx context.Context, changes <-chan *datastore.RevisionChanges) <-chan *datastore.RevisionChanges {
+	withoutCheckpoints := make(chan *datastore.RevisionChanges)
+	go func() {
+		defer close(withoutCheckpoints)
+		for change := range changes {
+			if change.IsCheckpoint {
+				continue
+			}
+
+			select {
+			case withoutCheckpoints <- change:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return withoutCheckpoints
+}
+
 func setOfChanges(changes []*core.RelationTupleUpdate) *strset.Set {
 	changeSet := strset.NewWithSize(len(changes))
 	for _, change := range changes {
@@ -179,6 +203,7 @@ func WatchCancelTest(t *testing.T, tester DatastoreTester) {
 	ctx, cancel := context.WithCancel(context.Background())
 	changes, errchan := ds.Watch(ctx, startWatchRevision)
 	require.Zero(len(errchan))
+	changes = skipCheckpoints(ctx, changes)
 
 	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, makeTestTuple("test", "test"))
 	require.NoError(err)
@@ -214,3 +239,141 @@ func WatchCancelTest(t *testing.T, tester DatastoreTester) {
 		}
 	}
 }
+
+// WatchWithMetadataTest tests that the metadata supplied with a transaction is returned with
+// its changes.
+func WatchWithMetadataTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 16)
+	require.NoError(err)
+
+	startWatchRevision := setupDatastore(ds, require)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, errchan := ds.Watch(ctx, startWatchRevision)
+	require.Zero(len(errchan))
+	changes = skipCheckpoints(ctx, changes)
+
+	metadata := map[string]string{"actor": "user:tom", "reason": "onboarding"}
+	writeRev, err := ds.ReadWriteTx(ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(ctx, []*core.RelationTupleUpdate{
+			tuple.Create(makeTestTuple("withmetadata", "test")),
+		})
+	}, options.WithMetadata("actor", "user:tom"), options.WithMetadata("reason", "onboarding"))
+	require.NoError(err)
+
+	changeWait := time.NewTimer(waitForChangesTimeout)
+	select {
+	case change, ok := <-changes:
+		require.True(ok)
+		require.True(change.Revision.Equal(writeRev))
+		require.Equal(metadata, change.Metadata)
+	case <-changeWait.C:
+		require.Fail("timed out waiting for changes with metadata")
+	}
+}
+
+// WatchExpirationTest tests that a relationship expiring is not reported as a change, as no
+// transaction deletes it: the next changes sent are those of the following write.
+func WatchExpirationTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 16)
+	require.NoError(err)
+
+	startWatchRevision := setupDatastore(ds, require)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, errchan := ds.Watch(ctx, startWatchRevision)
+	require.Zero(len(errchan))
+	changes = skipCheckpoints(ctx, changes)
+
+	expiresAt := time.Now().Add(time.Second).UTC()
+	expiring := makeTestTuple("expiring", "test")
+	expiring.OptionalExpirationTime = timestamppb.New(expiresAt)
+
+	_, err = common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, expiring)
+	require.NoError(err)
+
+	time.Sleep(time.Until(expiresAt) + 100*time.Millisecond)
+
+	afterExpiration, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, makeTestTuple("afterexpiration", "test"))
+	require.NoError(err)
+
+	var received []*core.RelationTupleUpdate
+	changeWait := time.NewTimer(waitForChangesTimeout)
+	defer changeWait.Stop()
+	for {
+		select {
+		case change, ok := <-changes:
+			require.True(ok)
+			received = append(received, change.Changes...)
+			if !change.Revision.Equal(afterExpiration) {
+				continue
+			}
+
+			expected := setOfChanges([]*core.RelationTupleUpdate{
+				tuple.Touch(expiring),
+				tuple.Touch(makeTestTuple("afterexpiration", "test")),
+			})
+			require.ElementsMatch(expected.List(), setOfChanges(received).List())
+			return
+		case <-changeWait.C:
+			require.Fail("timed out waiting for the changes after expiration")
+		}
+	}
+}
+
+// WatchCheckpointTest tests that a watch sends checkpoints while no changes are occurring, and
+// that no checkpoint covers a change which has not yet been sent.
+func WatchCheckpointTest(t *testing.T, tester DatastoreTester) {
+	require := require.New(t)
+
+	ds, err := tester.New(0, veryLargeGCWindow, 16)
+	require.NoError(err)
+
+	startWatchRevision := setupDatastore(ds, require)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, errchan := ds.Watch(ctx, startWatchRevision)
+	require.Zero(len(errchan))
+
+	writeRev, err := common.WriteTuples(ctx, ds, core.RelationTupleUpdate_CREATE, makeTestTuple("checkpoint", "test"))
+	require.NoError(err)
+
+	sentChange := false
+	for {
+		changeWait := time.NewTimer(waitForChangesTimeout)
+		select {
+		case change, ok := <-changes:
+			require.True(ok, "unexpected disconnect")
+
+			if !change.IsCheckpoint {
+				require.True(change.Revision.Equal(writeRev))
+				sentChange = true
+				continue
+			}
+
+			require.Empty(change.Changes)
+			if !sentChange {
+				require.False(change.Revision.Equal(writeRev) || change.Revision.GreaterThan(writeRev),
+					"checkpoint at %s sent before the change at %s", change.Revision, writeRev)
+				continue
+			}
+
+			require.False(change.Revision.LessThan(writeRev))
+			return
+		case err := <-errchan:
+			require.FailNow("unexpected watch error", err)
+		case <-changeWait.C:
+			require.FailNow("timed out waiting for a checkpoint")
+		}
+	}
+}
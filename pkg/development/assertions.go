@@ -9,8 +9,6 @@ import (
 	"github.com/authzed/spicedb/pkg/validationfile/blocks"
 )
 
-const maxDispatchDepth = 25
-
 // RunAllAssertions runs all assertions found in the given assertions block against the
 // developer context, returning whether any errors occurred.
 func RunAllAssertions(devContext *DevContext, assertions *blocks.Assertions) ([]*devinterface.DeveloperError, error) {
@@ -52,12 +50,14 @@ func runAssertions(devContext *DevContext, assertions []blocks.Assertion, expect
 			}
 		} else if (cr == v1.ResourceCheckResult_MEMBER) != expected {
 			failures = append(failures, &devinterface.DeveloperError{
-				Message: fmt.Sprintf(fmtString, tuple.String(tpl)),
-				Source:  devinterface.DeveloperError_ASSERTION,
-				Kind:    devinterface.DeveloperError_ASSERTION_FAILED,
-				Context: tuple.String(tpl),
-				Line:    uint32(assertion.SourcePosition.LineNumber),
-				Column:  uint32(assertion.SourcePosition.ColumnPosition),
+				Message:  fmt.Sprintf(fmtString, tuple.String(tpl)),
+				Source:   devinterface.DeveloperError_ASSERTION,
+				Kind:     devinterface.DeveloperError_ASSERTION_FAILED,
+				Severity: devinterface.DeveloperError_ERROR,
+				Code:     ErrorCodeAssertionFailed,
+				Context:  tuple.String(tpl),
+				Line:     uint32(assertion.SourcePosition.LineNumber),
+				Column:   uint32(assertion.SourcePosition.ColumnPosition),
 			})
 		}
 	}
@@ -2,6 +2,7 @@ package development
 
 import (
 	"github.com/authzed/spicedb/internal/graph/computed"
+	"github.com/authzed/spicedb/pkg/datastore"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
 )
@@ -11,8 +12,32 @@ import (
 // Note that it is up to the caller to call DistinguishGraphError on the error
 // if they want to distinguish between user errors and internal errors.
 func RunCheck(devContext *DevContext, resource *core.ObjectAndRelation, subject *core.ObjectAndRelation) (v1.ResourceCheckResult_Membership, error) {
+	membership, _, err := runCheck(devContext, devContext.Revision, resource, subject, false)
+	return membership, err
+}
+
+// RunCheckAtRevision performs a check against the data in the development context as of the given
+// revision, which is typically one of those found in the context's Revisions.
+//
+// Note that it is up to the caller to call DistinguishGraphError on the error
+// if they want to distinguish between user errors and internal errors.
+func RunCheckAtRevision(devContext *DevContext, revision datastore.Revision, resource *core.ObjectAndRelation, subject *core.ObjectAndRelation) (v1.ResourceCheckResult_Membership, error) {
+	membership, _, err := runCheck(devContext, revision, resource, subject, false)
+	return membership, err
+}
+
+// RunCheckWithDebugTrace performs a check against the data in the development context, returning
+// the debug trace of the resolution of the check alongside its result.
+//
+// Note that it is up to the caller to call DistinguishGraphError on the error
+// if they want to distinguish between user errors and internal errors.
+func RunCheckWithDebugTrace(devContext *DevContext, resource *core.ObjectAndRelation, subject *core.ObjectAndRelation) (v1.ResourceCheckResult_Membership, *v1.CheckDebugTrace, error) {
+	return runCheck(devContext, devContext.Revision, resource, subject, true)
+}
+
+func runCheck(devContext *DevContext, revision datastore.Revision, resource *core.ObjectAndRelation, subject *core.ObjectAndRelation, isDebuggingEnabled bool) (v1.ResourceCheckResult_Membership, *v1.CheckDebugTrace, error) {
 	ctx := devContext.Ctx
-	cr, _, err := computed.ComputeCheck(ctx, devContext.Dispatcher,
+	cr, meta, err := computed.ComputeCheck(ctx, devContext.Dispatcher,
 		computed.CheckParameters{
 			ResourceType: &core.RelationReference{
 				Namespace: resource.Namespace,
@@ -20,15 +45,15 @@ func RunCheck(devContext *DevContext, resource *core.ObjectAndRelation, subject
 			},
 			Subject:            subject,
 			CaveatContext:      nil, // TODO(jschorr): get from the dev context?
-			AtRevision:         devContext.Revision,
-			MaximumDepth:       maxDispatchDepth,
-			IsDebuggingEnabled: false,
+			AtRevision:         revision,
+			MaximumDepth:       devContext.MaxDispatchDepth,
+			IsDebuggingEnabled: isDebuggingEnabled,
 		},
 		resource.ObjectId,
 	)
 	if err != nil {
-		return v1.ResourceCheckResult_NOT_MEMBER, err
+		return v1.ResourceCheckResult_NOT_MEMBER, nil, err
 	}
 
-	return cr.Membership, nil
+	return cr.Membership, meta.GetDebugInfo().GetCheck(), nil
 }
@@ -24,25 +24,75 @@ import (
 	"github.com/authzed/spicedb/pkg/tuple"
 )
 
+const (
+	defaultConcurrencyLimit = 10
+	defaultMaxDispatchDepth = 25
+)
+
 // DevContext holds the various helper types for running the developer calls.
 type DevContext struct {
-	Ctx            context.Context
-	Datastore      datastore.Datastore
-	Revision       datastore.Revision
-	CompiledSchema *compiler.CompiledSchema
-	Dispatcher     dispatch.Dispatcher
+	Ctx              context.Context
+	Datastore        datastore.Datastore
+	Revision         datastore.Revision
+	CompiledSchema   *compiler.CompiledSchema
+	Dispatcher       dispatch.Dispatcher
+	MaxDispatchDepth uint32
+
+	// Revisions holds every revision of the datastore produced by the DevContext, in order, starting
+	// with that at which the request context was loaded. The last entry is always Revision.
+	Revisions []datastore.Revision
+}
+
+// DevContextOption is a function-style option for configuring a DevContext.
+type DevContextOption func(*devContextOptions)
+
+type devContextOptions struct {
+	concurrencyLimit uint16
+	maxDispatchDepth uint32
+}
+
+// WithConcurrencyLimit sets the maximum number of goroutines per dispatch operation
+// used by the dispatcher of the DevContext.
+func WithConcurrencyLimit(limit uint16) DevContextOption {
+	return func(opts *devContextOptions) {
+		opts.concurrencyLimit = limit
+	}
+}
+
+// WithMaximumDispatchDepth sets the maximum recursion depth for checks, assertions and
+// validation run against the DevContext.
+func WithMaximumDispatchDepth(depth uint32) DevContextOption {
+	return func(opts *devContextOptions) {
+		opts.maxDispatchDepth = depth
+	}
 }
 
 // NewDevContext creates a new DevContext from the specified request context, parsing and populating
 // the datastore as needed.
-func NewDevContext(ctx context.Context, requestContext *devinterface.RequestContext) (*DevContext, *devinterface.DeveloperErrors, error) {
+func NewDevContext(ctx context.Context, requestContext *devinterface.RequestContext, options ...DevContextOption) (*DevContext, *devinterface.DeveloperErrors, error) {
+	opts := devContextOptions{
+		concurrencyLimit: defaultConcurrencyLimit,
+		maxDispatchDepth: defaultMaxDispatchDepth,
+	}
+	for _, fn := range options {
+		fn(&opts)
+	}
+
+	if opts.concurrencyLimit == 0 {
+		return nil, nil, errors.New("concurrency limit must be greater than zero")
+	}
+
+	if opts.maxDispatchDepth == 0 {
+		return nil, nil, errors.New("maximum dispatch depth must be greater than zero")
+	}
+
 	ds, err := memdb.NewMemdbDatastore(0, 0*time.Second, memdb.DisableGC)
 	if err != nil {
 		return nil, nil, err
 	}
 	ctx = datastoremw.ContextWithDatastore(ctx, ds)
 
-	dctx, devErrs, nerr := newDevContextWithDatastore(ctx, requestContext, ds)
+	dctx, devErrs, nerr := newDevContextWithDatastore(ctx, requestContext, ds, opts)
 	if nerr != nil || devErrs != nil {
 		// If any form of error occurred, immediately close the datastore
 		derr := ds.Close()
@@ -56,7 +106,7 @@ func NewDevContext(ctx context.Context, requestContext *devinterface.RequestCont
 	return dctx, nil, nil
 }
 
-func newDevContextWithDatastore(ctx context.Context, requestContext *devinterface.RequestContext, ds datastore.Datastore) (*DevContext, *devinterface.DeveloperErrors, error) {
+func newDevContextWithDatastore(ctx context.Context, requestContext *devinterface.RequestContext, ds datastore.Datastore, opts devContextOptions) (*DevContext, *devinterface.DeveloperErrors, error) {
 	// Compile the schema and load its caveats and namespaces into the datastore.
 	compiled, devError, err := CompileSchema(requestContext.Schema)
 	if err != nil {
@@ -94,11 +144,13 @@ func newDevContextWithDatastore(ctx context.Context, requestContext *devinterfac
 	}
 
 	return &DevContext{
-		Ctx:            ctx,
-		Datastore:      ds,
-		CompiledSchema: compiled,
-		Revision:       currentRevision,
-		Dispatcher:     graph.NewLocalOnlyDispatcher(10),
+		Ctx:              ctx,
+		Datastore:        ds,
+		CompiledSchema:   compiled,
+		Revision:         currentRevision,
+		Revisions:        []datastore.Revision{currentRevision},
+		Dispatcher:       graph.NewLocalOnlyDispatcher(opts.concurrencyLimit),
+		MaxDispatchDepth: opts.maxDispatchDepth,
 	}, nil, nil
 }
 
@@ -127,10 +179,12 @@ func loadTuples(ctx context.Context, tuples []*core.RelationTuple, rwt datastore
 		verr := tpl.Validate()
 		if verr != nil {
 			devErrors = append(devErrors, &devinterface.DeveloperError{
-				Message: verr.Error(),
-				Source:  devinterface.DeveloperError_RELATIONSHIP,
-				Kind:    devinterface.DeveloperError_PARSE_ERROR,
-				Context: tuple.String(tpl),
+				Message:  verr.Error(),
+				Source:   devinterface.DeveloperError_RELATIONSHIP,
+				Kind:     devinterface.DeveloperError_PARSE_ERROR,
+				Severity: devinterface.DeveloperError_ERROR,
+				Code:     ErrorCodeInvalidRelationship,
+				Context:  tuple.String(tpl),
 			})
 			continue
 		}
@@ -177,19 +231,23 @@ func loadCompiled(
 		errWithSource, ok := spiceerrors.AsErrorWithSource(cverr)
 		if ok {
 			errors = append(errors, &devinterface.DeveloperError{
-				Message: cverr.Error(),
-				Kind:    devinterface.DeveloperError_SCHEMA_ISSUE,
-				Source:  devinterface.DeveloperError_SCHEMA,
-				Context: errWithSource.SourceCodeString,
-				Line:    uint32(errWithSource.LineNumber),
-				Column:  uint32(errWithSource.ColumnPosition),
+				Message:  cverr.Error(),
+				Kind:     devinterface.DeveloperError_SCHEMA_ISSUE,
+				Severity: devinterface.DeveloperError_ERROR,
+				Code:     ErrorCodeInvalidCaveat,
+				Source:   devinterface.DeveloperError_SCHEMA,
+				Context:  errWithSource.SourceCodeString,
+				Line:     uint32(errWithSource.LineNumber),
+				Column:   uint32(errWithSource.ColumnPosition),
 			})
 		} else {
 			errors = append(errors, &devinterface.DeveloperError{
-				Message: cverr.Error(),
-				Kind:    devinterface.DeveloperError_SCHEMA_ISSUE,
-				Source:  devinterface.DeveloperError_SCHEMA,
-				Context: caveatDef.Name,
+				Message:  cverr.Error(),
+				Kind:     devinterface.DeveloperError_SCHEMA_ISSUE,
+				Severity: devinterface.DeveloperError_ERROR,
+				Code:     ErrorCodeInvalidCaveat,
+				Source:   devinterface.DeveloperError_SCHEMA,
+				Context:  caveatDef.Name,
 			})
 		}
 	}
@@ -200,21 +258,25 @@ func loadCompiled(
 			errWithSource, ok := spiceerrors.AsErrorWithSource(terr)
 			if ok {
 				errors = append(errors, &devinterface.DeveloperError{
-					Message: terr.Error(),
-					Kind:    devinterface.DeveloperError_SCHEMA_ISSUE,
-					Source:  devinterface.DeveloperError_SCHEMA,
-					Context: errWithSource.SourceCodeString,
-					Line:    uint32(errWithSource.LineNumber),
-					Column:  uint32(errWithSource.ColumnPosition),
+					Message:  terr.Error(),
+					Kind:     devinterface.DeveloperError_SCHEMA_ISSUE,
+					Severity: devinterface.DeveloperError_ERROR,
+					Code:     ErrorCodeInvalidDefinition,
+					Source:   devinterface.DeveloperError_SCHEMA,
+					Context:  errWithSource.SourceCodeString,
+					Line:     uint32(errWithSource.LineNumber),
+					Column:   uint32(errWithSource.ColumnPosition),
 				})
 				continue
 			}
 
 			errors = append(errors, &devinterface.DeveloperError{
-				Message: terr.Error(),
-				Kind:    devinterface.DeveloperError_SCHEMA_ISSUE,
-				Source:  devinterface.DeveloperError_SCHEMA,
-				Context: nsDef.Name,
+				Message:  terr.Error(),
+				Kind:     devinterface.DeveloperError_SCHEMA_ISSUE,
+				Severity: devinterface.DeveloperError_ERROR,
+				Code:     ErrorCodeInvalidDefinition,
+				Source:   devinterface.DeveloperError_SCHEMA,
+				Context:  nsDef.Name,
 			})
 			continue
 		}
@@ -230,19 +292,23 @@ func loadCompiled(
 		errWithSource, ok := spiceerrors.AsErrorWithSource(tverr)
 		if ok {
 			errors = append(errors, &devinterface.DeveloperError{
-				Message: tverr.Error(),
-				Kind:    devinterface.DeveloperError_SCHEMA_ISSUE,
-				Source:  devinterface.DeveloperError_SCHEMA,
-				Context: errWithSource.SourceCodeString,
-				Line:    uint32(errWithSource.LineNumber),
-				Column:  uint32(errWithSource.ColumnPosition),
+				Message:  tverr.Error(),
+				Kind:     devinterface.DeveloperError_SCHEMA_ISSUE,
+				Severity: devinterface.DeveloperError_ERROR,
+				Code:     ErrorCodeSchemaTypeError,
+				Source:   devinterface.DeveloperError_SCHEMA,
+				Context:  errWithSource.SourceCodeString,
+				Line:     uint32(errWithSource.LineNumber),
+				Column:   uint32(errWithSource.ColumnPosition),
 			})
 		} else {
 			errors = append(errors, &devinterface.DeveloperError{
-				Message: tverr.Error(),
-				Kind:    devinterface.DeveloperError_SCHEMA_ISSUE,
-				Source:  devinterface.DeveloperError_SCHEMA,
-				Context: nsDef.Name,
+				Message:  tverr.Error(),
+				Kind:     devinterface.DeveloperError_SCHEMA_ISSUE,
+				Severity: devinterface.DeveloperError_ERROR,
+				Code:     ErrorCodeSchemaTypeError,
+				Source:   devinterface.DeveloperError_SCHEMA,
+				Context:  nsDef.Name,
 			})
 		}
 	}
@@ -262,46 +328,67 @@ func distinguishGraphError(ctx context.Context, dispatchError error, source devi
 
 	if errors.Is(dispatchError, dispatch.ErrMaxDepth) {
 		return &devinterface.DeveloperError{
-			Message: dispatchError.Error(),
-			Source:  source,
-			Kind:    devinterface.DeveloperError_MAXIMUM_RECURSION,
-			Line:    line,
-			Column:  column,
-			Context: context,
+			Message:  dispatchError.Error(),
+			Source:   source,
+			Kind:     devinterface.DeveloperError_MAXIMUM_RECURSION,
+			Severity: devinterface.DeveloperError_ERROR,
+			Code:     ErrorCodeMaximumRecursion,
+			Line:     line,
+			Column:   column,
+			Context:  context,
+		}, nil
+	}
+
+	if errors.As(dispatchError, &maingraph.ErrRecursionDepthExceeded{}) {
+		return &devinterface.DeveloperError{
+			Message:  dispatchError.Error(),
+			Source:   source,
+			Kind:     devinterface.DeveloperError_MAXIMUM_RECURSION,
+			Severity: devinterface.DeveloperError_ERROR,
+			Code:     ErrorCodeRecursionDepthExceeded,
+			Line:     line,
+			Column:   column,
+			Context:  context,
 		}, nil
 	}
 
 	if errors.As(dispatchError, &nsNotFoundError) {
 		return &devinterface.DeveloperError{
-			Message: dispatchError.Error(),
-			Source:  source,
-			Kind:    devinterface.DeveloperError_UNKNOWN_OBJECT_TYPE,
-			Line:    line,
-			Column:  column,
-			Context: context,
+			Message:  dispatchError.Error(),
+			Source:   source,
+			Kind:     devinterface.DeveloperError_UNKNOWN_OBJECT_TYPE,
+			Severity: devinterface.DeveloperError_ERROR,
+			Code:     ErrorCodeUnknownObjectType,
+			Line:     line,
+			Column:   column,
+			Context:  context,
 		}, nil
 	}
 
 	if errors.As(dispatchError, &relNotFoundError) {
 		return &devinterface.DeveloperError{
-			Message: dispatchError.Error(),
-			Source:  source,
-			Kind:    devinterface.DeveloperError_UNKNOWN_RELATION,
-			Line:    line,
-			Column:  column,
-			Context: context,
+			Message:  dispatchError.Error(),
+			Source:   source,
+			Kind:     devinterface.DeveloperError_UNKNOWN_RELATION,
+			Severity: devinterface.DeveloperError_ERROR,
+			Code:     ErrorCodeUnknownRelation,
+			Line:     line,
+			Column:   column,
+			Context:  context,
 		}, nil
 	}
 
 	var ire invalidRelationError
 	if errors.As(dispatchError, &ire) {
 		return &devinterface.DeveloperError{
-			Message: dispatchError.Error(),
-			Source:  source,
-			Kind:    devinterface.DeveloperError_UNKNOWN_RELATION,
-			Line:    line,
-			Column:  column,
-			Context: context,
+			Message:  dispatchError.Error(),
+			Source:   source,
+			Kind:     devinterface.DeveloperError_UNKNOWN_RELATION,
+			Severity: devinterface.DeveloperError_ERROR,
+			Code:     ErrorCodeUnknownRelation,
+			Line:     line,
+			Column:   column,
+			Context:  context,
 		}, nil
 	}
 
@@ -74,3 +74,48 @@ definition document {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "invalid resource id")
 }
+
+func TestDevelopmentMaximumDispatchDepth(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreTopFunction("github.com/golang/glog.(*loggingT).flushDaemon"), goleak.IgnoreCurrent())
+
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition group {
+	relation member: user | group#member
+}
+`,
+		Relationships: []*core.RelationTuple{
+			tuple.MustParse("group:first#member@group:second#member"),
+			tuple.MustParse("group:second#member@group:third#member"),
+			tuple.MustParse("group:third#member@user:someuser"),
+		},
+	}, WithConcurrencyLimit(1), WithMaximumDispatchDepth(2))
+	require.Nil(t, err)
+	require.Nil(t, devErrs)
+	defer devCtx.Dispose()
+
+	require.Equal(t, uint32(2), devCtx.MaxDispatchDepth)
+
+	assertions := &blocks.Assertions{
+		AssertTrue: []blocks.Assertion{
+			{
+				RelationshipString: "group:first#member@user:someuser",
+				Relationship:       tuple.MustToRelationship(tuple.MustParse("group:first#member@user:someuser")),
+			},
+		},
+	}
+
+	adErrs, err := RunAllAssertions(devCtx, assertions)
+	require.NoError(t, err)
+	require.Len(t, adErrs, 1)
+	require.Equal(t, devinterface.DeveloperError_MAXIMUM_RECURSION, adErrs[0].Kind)
+}
+
+func TestDevelopmentInvalidOptions(t *testing.T) {
+	_, _, err := NewDevContext(context.Background(), &devinterface.RequestContext{}, WithConcurrencyLimit(0))
+	require.Error(t, err)
+
+	_, _, err = NewDevContext(context.Background(), &devinterface.RequestContext{}, WithMaximumDispatchDepth(0))
+	require.Error(t, err)
+}
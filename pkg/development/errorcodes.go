@@ -0,0 +1,84 @@
+package development
+
+import (
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+)
+
+// The stable, machine-readable codes set on DeveloperErrors. Codes are never changed once
+// published, so tooling can rely on them to filter or suppress categories of errors.
+const (
+	// ErrorCodeSchemaCompile indicates the schema could not be parsed or compiled.
+	ErrorCodeSchemaCompile = "schema.compile_error"
+
+	// ErrorCodeInvalidCaveat indicates a caveat definition in the schema is invalid.
+	ErrorCodeInvalidCaveat = "schema.invalid_caveat"
+
+	// ErrorCodeInvalidDefinition indicates an object definition in the schema is invalid.
+	ErrorCodeInvalidDefinition = "schema.invalid_definition"
+
+	// ErrorCodeSchemaTypeError indicates an object definition failed type checking, such as a
+	// reference to an unknown relation.
+	ErrorCodeSchemaTypeError = "schema.type_error"
+
+	// ErrorCodeInvalidRelationship indicates a relationship is malformed or is not allowed by
+	// the schema.
+	ErrorCodeInvalidRelationship = "relationship.invalid"
+
+	// ErrorCodeDuplicateRelationship indicates a relationship to be created already exists.
+	ErrorCodeDuplicateRelationship = "relationship.duplicate"
+
+	// ErrorCodeMaximumRecursion indicates the maximum dispatch depth was reached when resolving.
+	ErrorCodeMaximumRecursion = "graph.maximum_recursion"
+
+	// ErrorCodeRecursionDepthExceeded indicates a self-referential relation was nested more deeply
+	// than the maximum depth defined for it in the schema.
+	ErrorCodeRecursionDepthExceeded = "graph.recursion_depth_exceeded"
+
+	// ErrorCodeApproachingRecursionDepth indicates the relationships nest a self-referential
+	// relation close to the maximum depth defined for it in the schema.
+	ErrorCodeApproachingRecursionDepth = "relationship.approaching_recursion_depth"
+
+	// ErrorCodeUnknownObjectType indicates a reference to an object type not found in the schema.
+	ErrorCodeUnknownObjectType = "graph.unknown_object_type"
+
+	// ErrorCodeUnknownRelation indicates a reference to a relation or permission not found in
+	// the schema.
+	ErrorCodeUnknownRelation = "graph.unknown_relation"
+
+	// ErrorCodeYAMLParse indicates the assertions or expected relations YAML could not be parsed.
+	ErrorCodeYAMLParse = "yaml.parse_error"
+
+	// ErrorCodeAssertionFailed indicates an assertion did not hold.
+	ErrorCodeAssertionFailed = "assertion.failed"
+
+	// ErrorCodeMissingExpectedRelationship indicates an expected relationship was not found, or
+	// differed from the one computed.
+	ErrorCodeMissingExpectedRelationship = "validation.missing_expected_relationship"
+
+	// ErrorCodeExtraRelationshipFound indicates a relationship was computed but not expected.
+	ErrorCodeExtraRelationshipFound = "validation.extra_relationship_found"
+)
+
+// FilterDeveloperErrors returns the errors at least as severe as the given minimum severity,
+// excluding any with one of the suppressed codes.
+func FilterDeveloperErrors(devErrs []*devinterface.DeveloperError, minimum devinterface.DeveloperError_Severity, suppressedCodes ...string) []*devinterface.DeveloperError {
+	suppressed := make(map[string]struct{}, len(suppressedCodes))
+	for _, code := range suppressedCodes {
+		suppressed[code] = struct{}{}
+	}
+
+	filtered := make([]*devinterface.DeveloperError, 0, len(devErrs))
+	for _, devErr := range devErrs {
+		// Severities are ordered from most (ERROR) to least (INFO) severe.
+		if devErr.Severity > minimum {
+			continue
+		}
+
+		if _, ok := suppressed[devErr.Code]; ok {
+			continue
+		}
+
+		filtered = append(filtered, devErr)
+	}
+	return filtered
+}
@@ -0,0 +1,73 @@
+package development
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestDeveloperErrorCodes(t *testing.T) {
+	_, devErr, err := CompileSchema(`definition user {`)
+	require.NoError(t, err)
+	require.NotNil(t, devErr)
+	require.Equal(t, ErrorCodeSchemaCompile, devErr.Code)
+	require.Equal(t, devinterface.DeveloperError_ERROR, devErr.Severity)
+
+	_, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition document {
+	relation viewer: user
+	permission view = viewer + unknown
+}
+`,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, devErrs)
+	require.Len(t, devErrs.InputErrors, 1)
+	require.Equal(t, ErrorCodeSchemaTypeError, devErrs.InputErrors[0].Code)
+
+	_, devErrs, err = NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition document {
+	relation viewer: user
+}
+`,
+		Relationships: []*core.RelationTuple{
+			tuple.MustParse("document:somedoc#unknown@user:someuser"),
+		},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, devErrs)
+	require.Len(t, devErrs.InputErrors, 1)
+	require.Equal(t, ErrorCodeUnknownRelation, devErrs.InputErrors[0].Code)
+	require.Equal(t, devinterface.DeveloperError_RELATIONSHIP, devErrs.InputErrors[0].Source)
+}
+
+func TestFilterDeveloperErrors(t *testing.T) {
+	devErrs := []*devinterface.DeveloperError{
+		{Message: "first", Severity: devinterface.DeveloperError_ERROR, Code: ErrorCodeSchemaTypeError},
+		{Message: "second", Severity: devinterface.DeveloperError_WARNING, Code: ErrorCodeUnknownRelation},
+		{Message: "third", Severity: devinterface.DeveloperError_INFO, Code: ErrorCodeAssertionFailed},
+		{Message: "fourth", Severity: devinterface.DeveloperError_ERROR, Code: ErrorCodeAssertionFailed},
+	}
+
+	messages := func(filtered []*devinterface.DeveloperError) []string {
+		found := make([]string, 0, len(filtered))
+		for _, devErr := range filtered {
+			found = append(found, devErr.Message)
+		}
+		return found
+	}
+
+	require.Equal(t, []string{"first", "second", "third", "fourth"}, messages(FilterDeveloperErrors(devErrs, devinterface.DeveloperError_INFO)))
+	require.Equal(t, []string{"first", "second", "fourth"}, messages(FilterDeveloperErrors(devErrs, devinterface.DeveloperError_WARNING)))
+	require.Equal(t, []string{"first", "fourth"}, messages(FilterDeveloperErrors(devErrs, devinterface.DeveloperError_ERROR)))
+	require.Equal(t, []string{"first"}, messages(FilterDeveloperErrors(devErrs, devinterface.DeveloperError_ERROR, ErrorCodeAssertionFailed)))
+}
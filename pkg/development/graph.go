@@ -0,0 +1,272 @@
+package development
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// GraphNodeKind is the kind of a node in a ResolutionGraph.
+type GraphNodeKind string
+
+const (
+	// ObjectRelationNodeKind is a node representing a relation or permission on one or more
+	// objects, e.g. `document:firstdoc#view`.
+	ObjectRelationNodeKind GraphNodeKind = "object_relation"
+
+	// SubjectNodeKind is a node representing a subject found directly via relationships.
+	SubjectNodeKind GraphNodeKind = "subject"
+
+	// OperationNodeKind is a node representing a set operation (union, intersection or
+	// exclusion), or an operation over caveats (and, or, not).
+	OperationNodeKind GraphNodeKind = "operation"
+
+	// CaveatNodeKind is a node representing a caveat that must be satisfied.
+	CaveatNodeKind GraphNodeKind = "caveat"
+)
+
+// GraphNode is a single node in a ResolutionGraph.
+type GraphNode struct {
+	ID     string        `json:"id"`
+	Kind   GraphNodeKind `json:"kind"`
+	Label  string        `json:"label"`
+	Result string        `json:"result,omitempty"`
+	Cached bool          `json:"cached,omitempty"`
+}
+
+// GraphEdge is a directed edge between two nodes in a ResolutionGraph.
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label,omitempty"`
+}
+
+// ResolutionGraph is the graph walked to resolve a Check or Expand, suitable for rendering
+// by tooling such as the playground.
+type ResolutionGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+
+	subjectNodeIDs map[string]string
+}
+
+func newResolutionGraph() *ResolutionGraph {
+	return &ResolutionGraph{
+		Nodes:          []GraphNode{},
+		Edges:          []GraphEdge{},
+		subjectNodeIDs: map[string]string{},
+	}
+}
+
+func (g *ResolutionGraph) addNode(node GraphNode) string {
+	node.ID = fmt.Sprintf("n%d", len(g.Nodes))
+	g.Nodes = append(g.Nodes, node)
+	return node.ID
+}
+
+func (g *ResolutionGraph) addEdge(from, to, label string) {
+	g.Edges = append(g.Edges, GraphEdge{From: from, To: to, Label: label})
+}
+
+// subjectNode returns the ID of the node for the subject, adding it if necessary, so that
+// subjects reachable via multiple paths are only rendered once.
+func (g *ResolutionGraph) subjectNode(subject *core.ObjectAndRelation) string {
+	key := tuple.StringONR(subject)
+	if id, ok := g.subjectNodeIDs[key]; ok {
+		return id
+	}
+
+	id := g.addNode(GraphNode{Kind: SubjectNodeKind, Label: key})
+	g.subjectNodeIDs[key] = id
+	return id
+}
+
+// CheckGraph performs a check against the data in the development context and returns the
+// graph resolved to compute it.
+//
+// Note that it is up to the caller to call DistinguishGraphError on the error
+// if they want to distinguish between user errors and internal errors.
+func CheckGraph(devContext *DevContext, resource *core.ObjectAndRelation, subject *core.ObjectAndRelation) (*ResolutionGraph, error) {
+	_, trace, err := RunCheckWithDebugTrace(devContext, resource, subject)
+	if err != nil {
+		return nil, err
+	}
+	return CheckResolutionGraph(trace), nil
+}
+
+// ExpandGraph performs a recursive expand of the object and relation against the data in the
+// development context and returns the graph of the expansion.
+//
+// Note that it is up to the caller to call DistinguishGraphError on the error
+// if they want to distinguish between user errors and internal errors.
+func ExpandGraph(devContext *DevContext, onr *core.ObjectAndRelation) (*ResolutionGraph, error) {
+	er, err := devContext.Dispatcher.DispatchExpand(devContext.Ctx, &v1.DispatchExpandRequest{
+		ResourceAndRelation: onr,
+		Metadata: &v1.ResolverMeta{
+			AtRevision:     devContext.Revision.String(),
+			DepthRemaining: devContext.MaxDispatchDepth,
+		},
+		ExpansionMode: v1.DispatchExpandRequest_RECURSIVE,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ExpandResolutionGraph(er.TreeNode), nil
+}
+
+// CheckResolutionGraph builds a ResolutionGraph from the debug trace of a check, as returned by
+// RunCheckWithDebugTrace. Caveated results are rendered as caveat expression subgraphs.
+func CheckResolutionGraph(trace *v1.CheckDebugTrace) *ResolutionGraph {
+	g := newResolutionGraph()
+	if trace != nil {
+		g.addCheckTrace(trace)
+	}
+	return g
+}
+
+func (g *ResolutionGraph) addCheckTrace(trace *v1.CheckDebugTrace) string {
+	req := trace.Request
+	label := fmt.Sprintf("%s:%s#%s", req.ResourceRelation.Namespace, strings.Join(req.ResourceIds, ","), req.ResourceRelation.Relation)
+
+	resourceIDs := make([]string, 0, len(trace.Results))
+	for resourceID := range trace.Results {
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+	sort.Strings(resourceIDs)
+
+	results := make([]string, 0, len(resourceIDs))
+	for _, resourceID := range resourceIDs {
+		results = append(results, fmt.Sprintf("%s=%s", resourceID, trace.Results[resourceID].Membership))
+	}
+
+	id := g.addNode(GraphNode{
+		Kind:   ObjectRelationNodeKind,
+		Label:  label,
+		Result: strings.Join(results, ","),
+		Cached: trace.IsCachedResult,
+	})
+
+	for _, resourceID := range resourceIDs {
+		if expr := trace.Results[resourceID].Expression; expr != nil {
+			g.addEdge(id, g.addCaveatExpression(expr), resourceID)
+		}
+	}
+
+	for _, subProblem := range trace.SubProblems {
+		g.addEdge(id, g.addCheckTrace(subProblem), "")
+	}
+
+	return id
+}
+
+func (g *ResolutionGraph) addCaveatExpression(expr *v1.CaveatExpression) string {
+	if caveat := expr.GetCaveat(); caveat != nil {
+		return g.addNode(GraphNode{Kind: CaveatNodeKind, Label: caveat.CaveatName})
+	}
+
+	op := expr.GetOperation()
+	id := g.addNode(GraphNode{Kind: OperationNodeKind, Label: strings.ToLower(op.Op.String())})
+	for _, child := range op.Children {
+		g.addEdge(id, g.addCaveatExpression(child), "")
+	}
+	return id
+}
+
+// ExpandResolutionGraph builds a ResolutionGraph from the tree returned by a recursive expand.
+func ExpandResolutionGraph(tree *core.RelationTupleTreeNode) *ResolutionGraph {
+	g := newResolutionGraph()
+	if tree != nil {
+		g.addExpandNode(tree)
+	}
+	return g
+}
+
+func (g *ResolutionGraph) addExpandNode(node *core.RelationTupleTreeNode) string {
+	var id string
+	if node.Expanded != nil {
+		id = g.addNode(GraphNode{Kind: ObjectRelationNodeKind, Label: tuple.StringONR(node.Expanded)})
+	}
+
+	switch typed := node.NodeType.(type) {
+	case *core.RelationTupleTreeNode_IntermediateNode:
+		opID := g.addNode(GraphNode{
+			Kind:  OperationNodeKind,
+			Label: strings.ToLower(typed.IntermediateNode.Operation.String()),
+		})
+		if id == "" {
+			id = opID
+		} else {
+			g.addEdge(id, opID, "")
+		}
+
+		for index, child := range typed.IntermediateNode.ChildNodes {
+			label := ""
+			if typed.IntermediateNode.Operation == core.SetOperationUserset_EXCLUSION {
+				label = "base"
+				if index > 0 {
+					label = "excluded"
+				}
+			}
+			g.addEdge(opID, g.addExpandNode(child), label)
+		}
+
+	case *core.RelationTupleTreeNode_LeafNode:
+		if id == "" {
+			id = g.addNode(GraphNode{Kind: OperationNodeKind, Label: "direct"})
+		}
+
+		for _, subject := range typed.LeafNode.Subjects {
+			g.addEdge(id, g.subjectNode(subject), "")
+		}
+	}
+
+	return id
+}
+
+// JSON returns the graph serialized as a JSON object with `nodes` and `edges` keys.
+func (g *ResolutionGraph) JSON() ([]byte, error) {
+	return json.Marshal(g)
+}
+
+var dotShapes = map[GraphNodeKind]string{
+	ObjectRelationNodeKind: "box",
+	SubjectNodeKind:        "ellipse",
+	OperationNodeKind:      "diamond",
+	CaveatNodeKind:         "hexagon",
+}
+
+// DOT returns the graph rendered in the Graphviz DOT language.
+func (g *ResolutionGraph) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph resolution {\n")
+	for _, node := range g.Nodes {
+		label := node.Label
+		if node.Result != "" {
+			label += "\n" + node.Result
+		}
+		if node.Cached {
+			label += "\n(cached)"
+		}
+		fmt.Fprintf(&sb, "  %s [label=%s shape=%s];\n", node.ID, dotQuote(label), dotShapes[node.Kind])
+	}
+	for _, edge := range g.Edges {
+		if edge.Label == "" {
+			fmt.Fprintf(&sb, "  %s -> %s;\n", edge.From, edge.To)
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s -> %s [label=%s];\n", edge.From, edge.To, dotQuote(edge.Label))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
@@ -0,0 +1,101 @@
+package development
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+const graphTestSchema = `definition user {}
+
+caveat somecaveat(somecondition int) {
+	somecondition == 42
+}
+
+definition document {
+	relation viewer: user | user with somecaveat
+	relation banned: user
+	permission view = viewer - banned
+}
+`
+
+func newGraphTestContext(t *testing.T) *DevContext {
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: graphTestSchema,
+		Relationships: []*core.RelationTuple{
+			tuple.MustParse("document:somedoc#viewer@user:tom"),
+			tuple.WithCaveat(tuple.MustParse("document:somedoc#viewer@user:sarah"), "somecaveat"),
+			tuple.MustParse("document:somedoc#banned@user:fred"),
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, devErrs)
+	t.Cleanup(devCtx.Dispose)
+	return devCtx
+}
+
+func TestCheckGraph(t *testing.T) {
+	devCtx := newGraphTestContext(t)
+
+	graph, err := CheckGraph(devCtx, tuple.ParseONR("document:somedoc#view"), tuple.ParseSubjectONR("user:sarah"))
+	require.NoError(t, err)
+	require.NotEmpty(t, graph.Nodes)
+	require.Equal(t, ObjectRelationNodeKind, graph.Nodes[0].Kind)
+	require.Equal(t, "document:somedoc#view", graph.Nodes[0].Label)
+
+	foundCaveat := false
+	for _, node := range graph.Nodes {
+		if node.Kind == CaveatNodeKind {
+			require.Equal(t, "somecaveat", node.Label)
+			foundCaveat = true
+		}
+	}
+	require.True(t, foundCaveat, "expected a caveat node in the check graph")
+
+	dot := graph.DOT()
+	require.Contains(t, dot, "digraph resolution {")
+	require.Contains(t, dot, "shape=hexagon")
+}
+
+func TestExpandGraph(t *testing.T) {
+	devCtx := newGraphTestContext(t)
+
+	graph, err := ExpandGraph(devCtx, tuple.ParseONR("document:somedoc#view"))
+	require.NoError(t, err)
+
+	labels := map[string]GraphNodeKind{}
+	for _, node := range graph.Nodes {
+		labels[node.Label] = node.Kind
+	}
+
+	require.Equal(t, ObjectRelationNodeKind, labels["document:somedoc#view"])
+	require.Equal(t, OperationNodeKind, labels["exclusion"])
+	require.Equal(t, SubjectNodeKind, labels["user:tom"])
+	require.Equal(t, SubjectNodeKind, labels["user:fred"])
+
+	foundExcluded := false
+	for _, edge := range graph.Edges {
+		if edge.Label == "excluded" {
+			foundExcluded = true
+		}
+	}
+	require.True(t, foundExcluded)
+
+	serialized, err := graph.JSON()
+	require.NoError(t, err)
+
+	var decoded map[string][]map[string]any
+	require.NoError(t, json.Unmarshal(serialized, &decoded))
+	require.Len(t, decoded["nodes"], len(graph.Nodes))
+	require.Len(t, decoded["edges"], len(graph.Edges))
+}
+
+func TestDOTEscaping(t *testing.T) {
+	require.Equal(t, `"some\"label\nnext"`, dotQuote("some\"label\nnext"))
+}
@@ -38,20 +38,24 @@ func convertError(source devinterface.DeveloperError_Source, err error) *devinte
 	}
 
 	return &devinterface.DeveloperError{
-		Message: err.Error(),
-		Kind:    devinterface.DeveloperError_PARSE_ERROR,
-		Source:  source,
-		Line:    0,
+		Message:  err.Error(),
+		Kind:     devinterface.DeveloperError_PARSE_ERROR,
+		Severity: devinterface.DeveloperError_ERROR,
+		Code:     ErrorCodeYAMLParse,
+		Source:   source,
+		Line:     0,
 	}
 }
 
 func convertSourceError(source devinterface.DeveloperError_Source, err *spiceerrors.ErrorWithSource) *devinterface.DeveloperError {
 	return &devinterface.DeveloperError{
-		Message: err.Error(),
-		Kind:    devinterface.DeveloperError_PARSE_ERROR,
-		Source:  source,
-		Line:    uint32(err.LineNumber),
-		Column:  uint32(err.ColumnPosition),
-		Context: err.SourceCodeString,
+		Message:  err.Error(),
+		Kind:     devinterface.DeveloperError_PARSE_ERROR,
+		Severity: devinterface.DeveloperError_ERROR,
+		Code:     ErrorCodeYAMLParse,
+		Source:   source,
+		Line:     uint32(err.LineNumber),
+		Column:   uint32(err.ColumnPosition),
+		Context:  err.SourceCodeString,
 	}
 }
@@ -0,0 +1,148 @@
+package development
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	"github.com/authzed/spicedb/pkg/namespace"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// recursionWarningThreshold is the fraction of a relation's maximum depth at or above which
+// a warning is produced for the nesting found in the data.
+const recursionWarningThreshold = 0.8
+
+// CheckRecursionDepths checks the nesting found in the relationships of the development context
+// for every relation with a maximum recursion depth. A warning is returned for each relation
+// whose deepest nesting approaches its maximum depth, and an error for each whose nesting
+// exceeds it (or is cyclic), as checks traversing that nesting would fail.
+func CheckRecursionDepths(devContext *DevContext) ([]*devinterface.DeveloperError, error) {
+	reader := devContext.Datastore.SnapshotReader(devContext.Revision)
+
+	var devErrs []*devinterface.DeveloperError
+	for _, nsDef := range devContext.CompiledSchema.ObjectDefinitions {
+		for _, relation := range nsDef.Relation {
+			maxDepth := namespace.GetRelationMaxRecursionDepth(relation)
+			if maxDepth == 0 {
+				continue
+			}
+
+			depth, deepest, err := deepestNesting(devContext, reader, nsDef.Name, relation.Name)
+			if err != nil {
+				return nil, err
+			}
+
+			onr := tuple.StringONR(&core.ObjectAndRelation{
+				Namespace: nsDef.Name,
+				ObjectId:  deepest,
+				Relation:  relation.Name,
+			})
+
+			switch {
+			case depth < 0 || uint32(depth) > maxDepth:
+				devErrs = append(devErrs, &devinterface.DeveloperError{
+					Message:  fmt.Sprintf("nesting under `%s` exceeds the maximum depth of %d for relation `%s`; checks traversing it will fail", onr, maxDepth, relation.Name),
+					Source:   devinterface.DeveloperError_RELATIONSHIP,
+					Kind:     devinterface.DeveloperError_MAXIMUM_RECURSION,
+					Severity: devinterface.DeveloperError_ERROR,
+					Code:     ErrorCodeRecursionDepthExceeded,
+					Context:  onr,
+				})
+
+			case float64(depth) >= recursionWarningThreshold*float64(maxDepth):
+				devErrs = append(devErrs, &devinterface.DeveloperError{
+					Message:  fmt.Sprintf("nesting under `%s` has depth %d, approaching the maximum depth of %d for relation `%s`", onr, depth, maxDepth, relation.Name),
+					Source:   devinterface.DeveloperError_RELATIONSHIP,
+					Kind:     devinterface.DeveloperError_MAXIMUM_RECURSION,
+					Severity: devinterface.DeveloperError_WARNING,
+					Code:     ErrorCodeApproachingRecursionDepth,
+					Context:  onr,
+				})
+			}
+		}
+	}
+
+	return devErrs, nil
+}
+
+// deepestNesting returns the maximum number of times the relation is traversed from any object
+// to reach another object of the same definition, along with the ID of the object at the start
+// of that nesting. A depth of -1 is returned if the nesting is cyclic.
+func deepestNesting(devContext *DevContext, reader datastore.Reader, nsName string, relationName string) (int, string, error) {
+	it, err := reader.QueryRelationships(devContext.Ctx, datastore.RelationshipsFilter{
+		ResourceType:             nsName,
+		OptionalResourceRelation: relationName,
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	defer it.Close()
+
+	children := map[string][]string{}
+	for tpl := it.Next(); tpl != nil; tpl = it.Next() {
+		if it.Err() != nil {
+			return 0, "", it.Err()
+		}
+
+		if tpl.Subject.Namespace != nsName || tpl.Subject.ObjectId == tuple.PublicWildcard {
+			continue
+		}
+
+		resourceID := tpl.ResourceAndRelation.ObjectId
+		children[resourceID] = append(children[resourceID], tpl.Subject.ObjectId)
+	}
+
+	resourceIDs := make([]string, 0, len(children))
+	for resourceID := range children {
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+	sort.Strings(resourceIDs)
+
+	const visiting = -2
+	depths := make(map[string]int, len(children))
+
+	var depthOf func(objectID string) int
+	depthOf = func(objectID string) int {
+		if depth, ok := depths[objectID]; ok {
+			if depth == visiting {
+				return -1
+			}
+			return depth
+		}
+
+		depths[objectID] = visiting
+		depth := 0
+		for _, child := range children[objectID] {
+			childDepth := depthOf(child)
+			if childDepth < 0 {
+				depths[objectID] = -1
+				return -1
+			}
+			if childDepth+1 > depth {
+				depth = childDepth + 1
+			}
+		}
+
+		depths[objectID] = depth
+		return depth
+	}
+
+	maxDepth := 0
+	deepest := ""
+	for _, resourceID := range resourceIDs {
+		depth := depthOf(resourceID)
+		if depth < 0 {
+			return -1, resourceID, nil
+		}
+
+		if depth > maxDepth {
+			maxDepth = depth
+			deepest = resourceID
+		}
+	}
+
+	return maxDepth, deepest, nil
+}
@@ -0,0 +1,134 @@
+package development
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestCheckRecursionDepths(t *testing.T) {
+	schema := `definition user {}
+
+definition folder {
+	relation parent: folder maxdepth 4
+	relation viewer: user
+	permission view = viewer + parent->view
+}
+`
+
+	testCases := []struct {
+		name             string
+		relationships    []string
+		expectedSeverity []devinterface.DeveloperError_Severity
+		expectedContext  string
+	}{
+		{
+			"shallow nesting",
+			[]string{
+				"folder:child#parent@folder:root",
+			},
+			nil,
+			"",
+		},
+		{
+			"approaching limit",
+			[]string{
+				"folder:a#parent@folder:b",
+				"folder:b#parent@folder:c",
+				"folder:c#parent@folder:d",
+				"folder:d#parent@folder:root",
+				"folder:other#parent@folder:root",
+			},
+			[]devinterface.DeveloperError_Severity{devinterface.DeveloperError_WARNING},
+			"folder:a#parent",
+		},
+		{
+			"exceeding limit",
+			[]string{
+				"folder:a#parent@folder:b",
+				"folder:b#parent@folder:c",
+				"folder:c#parent@folder:d",
+				"folder:d#parent@folder:e",
+				"folder:e#parent@folder:root",
+			},
+			[]devinterface.DeveloperError_Severity{devinterface.DeveloperError_ERROR},
+			"folder:a#parent",
+		},
+		{
+			"cycle",
+			[]string{
+				"folder:a#parent@folder:b",
+				"folder:b#parent@folder:a",
+			},
+			[]devinterface.DeveloperError_Severity{devinterface.DeveloperError_ERROR},
+			"folder:a#parent",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require := require.New(t)
+
+			relationships := make([]*core.RelationTuple, 0, len(tc.relationships))
+			for _, rel := range tc.relationships {
+				relationships = append(relationships, tuple.MustParse(rel))
+			}
+
+			devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+				Schema:        schema,
+				Relationships: relationships,
+			})
+			require.NoError(err)
+			require.Nil(devErrs)
+			defer devCtx.Dispose()
+
+			recursionErrs, err := CheckRecursionDepths(devCtx)
+			require.NoError(err)
+			require.Len(recursionErrs, len(tc.expectedSeverity))
+
+			for index, devErr := range recursionErrs {
+				require.Equal(tc.expectedSeverity[index], devErr.Severity)
+				require.Equal(devinterface.DeveloperError_MAXIMUM_RECURSION, devErr.Kind)
+				require.Equal(tc.expectedContext, devErr.Context)
+			}
+		})
+	}
+}
+
+func TestRecursionDepthExceededDuringCheck(t *testing.T) {
+	require := require.New(t)
+
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition folder {
+	relation parent: folder maxdepth 1
+	relation viewer: user
+	permission view = viewer + parent->view
+}
+`,
+		Relationships: []*core.RelationTuple{
+			tuple.MustParse("folder:a#parent@folder:b"),
+			tuple.MustParse("folder:b#parent@folder:c"),
+			tuple.MustParse("folder:c#viewer@user:tom"),
+		},
+	})
+	require.NoError(err)
+	require.Nil(devErrs)
+	defer devCtx.Dispose()
+
+	_, err = RunCheck(devCtx, tuple.ParseONR("folder:a#view"), tuple.ParseSubjectONR("user:tom"))
+	require.Error(err)
+
+	devErr, wireErr := DistinguishGraphError(devCtx, err, devinterface.DeveloperError_ASSERTION, 0, 0, "folder:a#view@user:tom")
+	require.NoError(wireErr)
+	require.NotNil(devErr)
+	require.Equal(ErrorCodeRecursionDepthExceeded, devErr.Code)
+	require.Contains(devErr.Message, "relation `parent` under definition `folder`")
+}
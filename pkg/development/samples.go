@@ -0,0 +1,148 @@
+package development
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	"github.com/authzed/spicedb/pkg/schemadsl/compiler"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+const (
+	defaultSampleRelationshipCount = 100
+	defaultSampleObjectsPerType    = 10
+
+	// maxSampleAttemptsMultiplier bounds the number of attempts made to find unique relationships,
+	// relative to the requested count, so that small schemas don't loop forever.
+	maxSampleAttemptsMultiplier = 10
+)
+
+// SampleOption is a function-style option for configuring the generation of sample relationships.
+type SampleOption func(*sampleOptions)
+
+type sampleOptions struct {
+	count          int
+	objectsPerType int
+	seed           int64
+}
+
+// WithSampleCount sets the number of sample relationships to generate. Fewer relationships may be
+// returned if the schema does not allow for that many unique relationships.
+func WithSampleCount(count int) SampleOption {
+	return func(opts *sampleOptions) {
+		opts.count = count
+	}
+}
+
+// WithSampleObjectsPerType sets the number of distinct object IDs generated for each object
+// definition.
+func WithSampleObjectsPerType(objectsPerType int) SampleOption {
+	return func(opts *sampleOptions) {
+		opts.objectsPerType = objectsPerType
+	}
+}
+
+// WithSampleSeed sets the seed used for generation, so that the same schema and seed always
+// produce the same set of relationships.
+func WithSampleSeed(seed int64) SampleOption {
+	return func(opts *sampleOptions) {
+		opts.seed = seed
+	}
+}
+
+type sampleSlot struct {
+	resourceType string
+	relation     string
+	allowed      *core.AllowedRelation
+}
+
+// GenerateSampleRelationships generates a plausible set of relationships for the compiled schema.
+// Only subject types allowed by each relation are used, with any required caveat attached (without
+// context, which is expected to be supplied at check time), so the generated relationships can be
+// written to a datastore holding the schema.
+func GenerateSampleRelationships(compiled *compiler.CompiledSchema, options ...SampleOption) ([]*core.RelationTuple, error) {
+	opts := sampleOptions{
+		count:          defaultSampleRelationshipCount,
+		objectsPerType: defaultSampleObjectsPerType,
+	}
+	for _, fn := range options {
+		fn(&opts)
+	}
+
+	if opts.count < 0 {
+		return nil, errors.New("sample count must not be negative")
+	}
+
+	if opts.objectsPerType <= 0 {
+		return nil, errors.New("objects per type must be greater than zero")
+	}
+
+	var slots []sampleSlot
+	for _, nsDef := range compiled.ObjectDefinitions {
+		for _, rel := range nsDef.Relation {
+			for _, allowed := range rel.GetTypeInformation().GetAllowedDirectRelations() {
+				slots = append(slots, sampleSlot{nsDef.Name, rel.Name, allowed})
+			}
+		}
+	}
+
+	if len(slots) == 0 || opts.count == 0 {
+		return []*core.RelationTuple{}, nil
+	}
+
+	rnd := rand.New(rand.NewSource(opts.seed))
+	objectID := func(namespace string) string {
+		name := namespace[strings.LastIndex(namespace, "/")+1:]
+		return fmt.Sprintf("%s_%d", name, rnd.Intn(opts.objectsPerType))
+	}
+
+	seen := make(map[string]struct{}, opts.count)
+	relationships := make([]*core.RelationTuple, 0, opts.count)
+	for attempt := 0; attempt < opts.count*maxSampleAttemptsMultiplier && len(relationships) < opts.count; attempt++ {
+		slot := slots[attempt%len(slots)]
+
+		subject := &core.ObjectAndRelation{
+			Namespace: slot.allowed.Namespace,
+			Relation:  datastore.Ellipsis,
+		}
+
+		if slot.allowed.GetPublicWildcard() != nil {
+			subject.ObjectId = tuple.PublicWildcard
+		} else {
+			subject.ObjectId = objectID(slot.allowed.Namespace)
+			subject.Relation = slot.allowed.GetRelation()
+		}
+
+		tpl := &core.RelationTuple{
+			ResourceAndRelation: &core.ObjectAndRelation{
+				Namespace: slot.resourceType,
+				ObjectId:  objectID(slot.resourceType),
+				Relation:  slot.relation,
+			},
+			Subject: subject,
+		}
+
+		// Skip relationships pointing an object at itself, which are legal but never useful.
+		if tpl.ResourceAndRelation.Namespace == subject.Namespace && tpl.ResourceAndRelation.ObjectId == subject.ObjectId {
+			continue
+		}
+
+		if slot.allowed.RequiredCaveat != nil {
+			tpl.Caveat = &core.ContextualizedCaveat{CaveatName: slot.allowed.RequiredCaveat.CaveatName}
+		}
+
+		key := tuple.String(tpl)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		relationships = append(relationships, tpl)
+	}
+
+	return relationships, nil
+}
@@ -0,0 +1,95 @@
+package development
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+const sampleTestSchema = `definition user {}
+
+caveat somecaveat(somecondition int) {
+	somecondition == 42
+}
+
+definition group {
+	relation member: user | group#member
+}
+
+definition document {
+	relation viewer: user | user:* | group#member
+	relation editor: user with somecaveat
+	permission view = viewer + editor
+}
+`
+
+func TestGenerateSampleRelationships(t *testing.T) {
+	compiled, devErr, err := CompileSchema(sampleTestSchema)
+	require.NoError(t, err)
+	require.Nil(t, devErr)
+
+	rels, err := GenerateSampleRelationships(compiled, WithSampleCount(50), WithSampleObjectsPerType(20), WithSampleSeed(42))
+	require.NoError(t, err)
+	require.Len(t, rels, 50)
+
+	seen := map[string]struct{}{}
+	for _, rel := range rels {
+		key := tuple.String(rel)
+		require.NotContains(t, seen, key)
+		seen[key] = struct{}{}
+
+		if rel.ResourceAndRelation.Relation == "editor" {
+			require.NotNil(t, rel.Caveat)
+			require.Equal(t, "somecaveat", rel.Caveat.CaveatName)
+		} else {
+			require.Nil(t, rel.Caveat)
+		}
+	}
+
+	// The same seed must produce the same relationships.
+	again, err := GenerateSampleRelationships(compiled, WithSampleCount(50), WithSampleObjectsPerType(20), WithSampleSeed(42))
+	require.NoError(t, err)
+	require.Equal(t, len(rels), len(again))
+	for index := range rels {
+		require.Equal(t, tuple.String(rels[index]), tuple.String(again[index]))
+	}
+
+	// The generated relationships must be valid for the schema.
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema:        sampleTestSchema,
+		Relationships: rels,
+	})
+	require.NoError(t, err)
+	require.Nil(t, devErrs)
+	devCtx.Dispose()
+}
+
+func TestGenerateSampleRelationshipsLimitedByUniqueness(t *testing.T) {
+	compiled, devErr, err := CompileSchema(`definition user {}
+
+definition document {
+	relation viewer: user
+}`)
+	require.NoError(t, err)
+	require.Nil(t, devErr)
+
+	rels, err := GenerateSampleRelationships(compiled, WithSampleCount(100), WithSampleObjectsPerType(2))
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(rels), 4)
+	require.NotEmpty(t, rels)
+}
+
+func TestGenerateSampleRelationshipsInvalidOptions(t *testing.T) {
+	compiled, _, err := CompileSchema(`definition user {}`)
+	require.NoError(t, err)
+
+	_, err = GenerateSampleRelationships(compiled, WithSampleObjectsPerType(0))
+	require.Error(t, err)
+
+	_, err = GenerateSampleRelationships(compiled, WithSampleCount(-1))
+	require.Error(t, err)
+}
@@ -26,12 +26,14 @@ func CompileSchema(schema string) (*compiler.CompiledSchema, *devinterface.Devel
 		}
 
 		return nil, &devinterface.DeveloperError{
-			Message: contextError.BaseCompilerError.BaseMessage,
-			Kind:    devinterface.DeveloperError_SCHEMA_ISSUE,
-			Source:  devinterface.DeveloperError_SCHEMA,
-			Line:    uint32(line) + 1, // 0-indexed in parser.
-			Column:  uint32(col) + 1,  // 0-indexed in parser.
-			Context: contextError.ErrorSourceCode,
+			Message:  contextError.BaseCompilerError.BaseMessage,
+			Kind:     devinterface.DeveloperError_SCHEMA_ISSUE,
+			Severity: devinterface.DeveloperError_ERROR,
+			Code:     ErrorCodeSchemaCompile,
+			Source:   devinterface.DeveloperError_SCHEMA,
+			Line:     uint32(line) + 1, // 0-indexed in parser.
+			Column:   uint32(col) + 1,  // 0-indexed in parser.
+			Context:  contextError.ErrorSourceCode,
 		}, nil
 	}
 
@@ -31,7 +31,7 @@ func RunValidation(devContext *DevContext, validation *blocks.ParsedExpectedRela
 			ResourceAndRelation: onrKey.ObjectAndRelation,
 			Metadata: &v1.ResolverMeta{
 				AtRevision:     devContext.Revision.String(),
-				DepthRemaining: maxDispatchDepth,
+				DepthRemaining: devContext.MaxDispatchDepth,
 			},
 			ExpansionMode: v1.DispatchExpandRequest_RECURSIVE,
 		})
@@ -91,12 +91,14 @@ func validateSubjects(onrKey blocks.ObjectRelation, fs developmentmembership.Fou
 		subjectWithExceptions := expectedSubject.SubjectWithExceptions
 		if subjectWithExceptions == nil {
 			failures = append(failures, &devinterface.DeveloperError{
-				Message: fmt.Sprintf("For object and permission/relation `%s`, no expected subject specified in `%s`", tuple.StringONR(onr), expectedSubject.ValidationString),
-				Source:  devinterface.DeveloperError_VALIDATION_YAML,
-				Kind:    devinterface.DeveloperError_MISSING_EXPECTED_RELATIONSHIP,
-				Context: string(expectedSubject.ValidationString),
-				Line:    uint32(expectedSubject.SourcePosition.LineNumber),
-				Column:  uint32(expectedSubject.SourcePosition.ColumnPosition),
+				Message:  fmt.Sprintf("For object and permission/relation `%s`, no expected subject specified in `%s`", tuple.StringONR(onr), expectedSubject.ValidationString),
+				Source:   devinterface.DeveloperError_VALIDATION_YAML,
+				Kind:     devinterface.DeveloperError_MISSING_EXPECTED_RELATIONSHIP,
+				Severity: devinterface.DeveloperError_ERROR,
+				Code:     ErrorCodeMissingExpectedRelationship,
+				Context:  string(expectedSubject.ValidationString),
+				Line:     uint32(expectedSubject.SourcePosition.LineNumber),
+				Column:   uint32(expectedSubject.SourcePosition.ColumnPosition),
 			})
 			continue
 		}
@@ -106,12 +108,14 @@ func validateSubjects(onrKey blocks.ObjectRelation, fs developmentmembership.Fou
 		subject, ok := fs.LookupSubject(subjectWithExceptions.Subject)
 		if !ok {
 			failures = append(failures, &devinterface.DeveloperError{
-				Message: fmt.Sprintf("For object and permission/relation `%s`, missing expected subject `%s`", tuple.StringONR(onr), tuple.StringONR(subjectWithExceptions.Subject)),
-				Source:  devinterface.DeveloperError_VALIDATION_YAML,
-				Kind:    devinterface.DeveloperError_MISSING_EXPECTED_RELATIONSHIP,
-				Context: string(expectedSubject.ValidationString),
-				Line:    uint32(expectedSubject.SourcePosition.LineNumber),
-				Column:  uint32(expectedSubject.SourcePosition.ColumnPosition),
+				Message:  fmt.Sprintf("For object and permission/relation `%s`, missing expected subject `%s`", tuple.StringONR(onr), tuple.StringONR(subjectWithExceptions.Subject)),
+				Source:   devinterface.DeveloperError_VALIDATION_YAML,
+				Kind:     devinterface.DeveloperError_MISSING_EXPECTED_RELATIONSHIP,
+				Severity: devinterface.DeveloperError_ERROR,
+				Code:     ErrorCodeMissingExpectedRelationship,
+				Context:  string(expectedSubject.ValidationString),
+				Line:     uint32(expectedSubject.SourcePosition.LineNumber),
+				Column:   uint32(expectedSubject.SourcePosition.ColumnPosition),
 			})
 			continue
 		}
@@ -129,11 +133,13 @@ func validateSubjects(onrKey blocks.ObjectRelation, fs developmentmembership.Fou
 					strings.Join(wrapRelationships(expectedONRStrings), "/"),
 					strings.Join(wrapRelationships(foundONRStrings), "/"),
 				),
-				Source:  devinterface.DeveloperError_VALIDATION_YAML,
-				Kind:    devinterface.DeveloperError_MISSING_EXPECTED_RELATIONSHIP,
-				Context: string(expectedSubject.ValidationString),
-				Line:    uint32(expectedSubject.SourcePosition.LineNumber),
-				Column:  uint32(expectedSubject.SourcePosition.ColumnPosition),
+				Source:   devinterface.DeveloperError_VALIDATION_YAML,
+				Kind:     devinterface.DeveloperError_MISSING_EXPECTED_RELATIONSHIP,
+				Severity: devinterface.DeveloperError_ERROR,
+				Code:     ErrorCodeMissingExpectedRelationship,
+				Context:  string(expectedSubject.ValidationString),
+				Line:     uint32(expectedSubject.SourcePosition.LineNumber),
+				Column:   uint32(expectedSubject.SourcePosition.ColumnPosition),
 			})
 		}
 
@@ -151,11 +157,13 @@ func validateSubjects(onrKey blocks.ObjectRelation, fs developmentmembership.Fou
 						strings.Join(wrapRelationships(expectedExcludedONRStrings), ", "),
 						strings.Join(wrapRelationships(foundExcludedONRStrings), ", "),
 					),
-					Source:  devinterface.DeveloperError_VALIDATION_YAML,
-					Kind:    devinterface.DeveloperError_MISSING_EXPECTED_RELATIONSHIP,
-					Context: string(expectedSubject.ValidationString),
-					Line:    uint32(expectedSubject.SourcePosition.LineNumber),
-					Column:  uint32(expectedSubject.SourcePosition.ColumnPosition),
+					Source:   devinterface.DeveloperError_VALIDATION_YAML,
+					Kind:     devinterface.DeveloperError_MISSING_EXPECTED_RELATIONSHIP,
+					Severity: devinterface.DeveloperError_ERROR,
+					Code:     ErrorCodeMissingExpectedRelationship,
+					Context:  string(expectedSubject.ValidationString),
+					Line:     uint32(expectedSubject.SourcePosition.LineNumber),
+					Column:   uint32(expectedSubject.SourcePosition.ColumnPosition),
 				})
 			}
 		} else {
@@ -164,11 +172,13 @@ func validateSubjects(onrKey blocks.ObjectRelation, fs developmentmembership.Fou
 					Message: fmt.Sprintf("For object and permission/relation `%s`, found unexpected excluded subjects",
 						tuple.StringONR(onr),
 					),
-					Source:  devinterface.DeveloperError_VALIDATION_YAML,
-					Kind:    devinterface.DeveloperError_EXTRA_RELATIONSHIP_FOUND,
-					Context: string(expectedSubject.ValidationString),
-					Line:    uint32(expectedSubject.SourcePosition.LineNumber),
-					Column:  uint32(expectedSubject.SourcePosition.ColumnPosition),
+					Source:   devinterface.DeveloperError_VALIDATION_YAML,
+					Kind:     devinterface.DeveloperError_EXTRA_RELATIONSHIP_FOUND,
+					Severity: devinterface.DeveloperError_ERROR,
+					Code:     ErrorCodeExtraRelationshipFound,
+					Context:  string(expectedSubject.ValidationString),
+					Line:     uint32(expectedSubject.SourcePosition.LineNumber),
+					Column:   uint32(expectedSubject.SourcePosition.ColumnPosition),
 				})
 			}
 		}
@@ -183,11 +193,13 @@ func validateSubjects(onrKey blocks.ObjectRelation, fs developmentmembership.Fou
 					tuple.StringONR(onr),
 					tuple.StringONR(foundSubject.Subject()),
 				),
-				Source:  devinterface.DeveloperError_VALIDATION_YAML,
-				Kind:    devinterface.DeveloperError_EXTRA_RELATIONSHIP_FOUND,
-				Context: tuple.StringONR(onr),
-				Line:    uint32(onrKey.SourcePosition.LineNumber),
-				Column:  uint32(onrKey.SourcePosition.ColumnPosition),
+				Source:   devinterface.DeveloperError_VALIDATION_YAML,
+				Kind:     devinterface.DeveloperError_EXTRA_RELATIONSHIP_FOUND,
+				Severity: devinterface.DeveloperError_ERROR,
+				Code:     ErrorCodeExtraRelationshipFound,
+				Context:  tuple.StringONR(onr),
+				Line:     uint32(onrKey.SourcePosition.LineNumber),
+				Column:   uint32(onrKey.SourcePosition.ColumnPosition),
 			})
 		}
 	}
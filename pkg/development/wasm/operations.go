@@ -98,6 +98,12 @@ func runOperation(devContext *development.DevContext, operation *devinterface.Op
 			return nil, err
 		}
 
+		recursionErrors, err := development.CheckRecursionDepths(devContext)
+		if err != nil {
+			return nil, err
+		}
+		validationErrors = append(validationErrors, recursionErrors...)
+
 		updatedValidationYaml := ""
 		if membershipSet != nil {
 			generatedValidationYaml, gerr := development.GenerateValidation(membershipSet)
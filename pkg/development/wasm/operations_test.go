@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/authzed/spicedb/pkg/development"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
 	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
 	"github.com/authzed/spicedb/pkg/testutil"
@@ -41,6 +42,7 @@ func TestCheckOperation(t *testing.T) {
 			&devinterface.DeveloperError{
 				Message: "Expected identifier, found token TokenTypeRightBrace",
 				Kind:    devinterface.DeveloperError_SCHEMA_ISSUE,
+				Code:    development.ErrorCodeSchemaCompile,
 				Source:  devinterface.DeveloperError_SCHEMA,
 				Line:    3,
 				Column:  4,
@@ -56,6 +58,7 @@ func TestCheckOperation(t *testing.T) {
 			&devinterface.DeveloperError{
 				Message: "error in object definition fo: invalid NamespaceDefinition.Name: value does not match regex pattern \"^([a-z][a-z0-9_]{1,62}[a-z0-9]/)?[a-z][a-z0-9_]{1,62}[a-z0-9]$\"",
 				Kind:    devinterface.DeveloperError_SCHEMA_ISSUE,
+				Code:    development.ErrorCodeSchemaCompile,
 				Source:  devinterface.DeveloperError_SCHEMA,
 				Line:    1,
 				Column:  1,
@@ -75,6 +78,7 @@ func TestCheckOperation(t *testing.T) {
 			&devinterface.DeveloperError{
 				Message: "found duplicate relation/permission name `writer` under definition `resource`",
 				Kind:    devinterface.DeveloperError_SCHEMA_ISSUE,
+				Code:    development.ErrorCodeInvalidDefinition,
 				Source:  devinterface.DeveloperError_SCHEMA,
 				Line:    5,
 				Column:  6,
@@ -100,6 +104,7 @@ func TestCheckOperation(t *testing.T) {
 				Error: &devinterface.DeveloperError{
 					Message: "relation/permission `anotherrel` not found under definition `somenamespace`",
 					Kind:    devinterface.DeveloperError_UNKNOWN_RELATION,
+					Code:    development.ErrorCodeUnknownRelation,
 					Source:  devinterface.DeveloperError_CHECK_WATCH,
 					Context: "somenamespace:someobj#anotherrel@user:foo",
 				},
@@ -193,6 +198,7 @@ func TestCheckOperation(t *testing.T) {
 				Error: &devinterface.DeveloperError{
 					Message: "max depth exceeded: this usually indicates a recursive or too deep data dependency",
 					Kind:    devinterface.DeveloperError_MAXIMUM_RECURSION,
+					Code:    development.ErrorCodeMaximumRecursion,
 					Source:  devinterface.DeveloperError_CHECK_WATCH,
 					Context: "document:someobj#viewer@user:foo",
 				},
@@ -286,6 +292,7 @@ func TestRunAssertionsAndValidationOperations(t *testing.T) {
 			&devinterface.DeveloperError{
 				Message: "unexpected value `asdkjhg`",
 				Kind:    devinterface.DeveloperError_PARSE_ERROR,
+				Code:    development.ErrorCodeYAMLParse,
 				Source:  devinterface.DeveloperError_VALIDATION_YAML,
 				Context: "asdkjhg",
 				Line:    1,
@@ -301,6 +308,7 @@ func TestRunAssertionsAndValidationOperations(t *testing.T) {
 			&devinterface.DeveloperError{
 				Message: "unexpected value `asdhasj`",
 				Kind:    devinterface.DeveloperError_PARSE_ERROR,
+				Code:    development.ErrorCodeYAMLParse,
 				Source:  devinterface.DeveloperError_ASSERTION,
 				Context: "asdhasj",
 				Line:    1,
@@ -321,6 +329,7 @@ assertFalse: garbage
 			&devinterface.DeveloperError{
 				Message: "did not find expected key",
 				Kind:    devinterface.DeveloperError_PARSE_ERROR,
+				Code:    development.ErrorCodeYAMLParse,
 				Source:  devinterface.DeveloperError_ASSERTION,
 				Line:    5,
 			},
@@ -340,6 +349,7 @@ assertFalse: garbage
 			&devinterface.DeveloperError{
 				Message: "unexpected value `garbage`",
 				Kind:    devinterface.DeveloperError_PARSE_ERROR,
+				Code:    development.ErrorCodeYAMLParse,
 				Source:  devinterface.DeveloperError_ASSERTION,
 				Line:    5,
 				Column:  0,
@@ -357,6 +367,7 @@ assertFalse: garbage
 			&devinterface.DeveloperError{
 				Message: "error parsing relationship `something`",
 				Kind:    devinterface.DeveloperError_PARSE_ERROR,
+				Code:    development.ErrorCodeYAMLParse,
 				Source:  devinterface.DeveloperError_ASSERTION,
 				Line:    2,
 				Column:  3,
@@ -379,6 +390,7 @@ assertFalse: garbage
 			&devinterface.DeveloperError{
 				Message: "Expected relation or permission document:somedoc#viewer@user:jake to exist",
 				Kind:    devinterface.DeveloperError_ASSERTION_FAILED,
+				Code:    development.ErrorCodeAssertionFailed,
 				Source:  devinterface.DeveloperError_ASSERTION,
 				Context: "document:somedoc#viewer@user:jake",
 				Line:    2,
@@ -401,6 +413,7 @@ assertFalse: garbage
 			&devinterface.DeveloperError{
 				Message: "Expected relation or permission document:somedoc#viewer@user:jimmy to not exist",
 				Kind:    devinterface.DeveloperError_ASSERTION_FAILED,
+				Code:    development.ErrorCodeAssertionFailed,
 				Source:  devinterface.DeveloperError_ASSERTION,
 				Context: "document:somedoc#viewer@user:jimmy",
 				Line:    2,
@@ -421,6 +434,7 @@ assertFalse: garbage
 			&devinterface.DeveloperError{
 				Message: "relation/permission `viewer` not found under definition `document`",
 				Kind:    devinterface.DeveloperError_UNKNOWN_RELATION,
+				Code:    development.ErrorCodeUnknownRelation,
 				Source:  devinterface.DeveloperError_ASSERTION,
 				Context: "document:somedoc#viewer@user:jimmy",
 				Line:    2,
@@ -445,6 +459,7 @@ assertFalse: garbage
 			&devinterface.DeveloperError{
 				Message: "For object and permission/relation `document:somedoc#view`, subject `user:jimmy` found but missing from specified",
 				Kind:    devinterface.DeveloperError_EXTRA_RELATIONSHIP_FOUND,
+				Code:    development.ErrorCodeExtraRelationshipFound,
 				Source:  devinterface.DeveloperError_VALIDATION_YAML,
 				Context: "document:somedoc#view",
 				Line:    1,
@@ -473,6 +488,7 @@ assertFalse: garbage
 			&devinterface.DeveloperError{
 				Message: "For object and permission/relation `document:somedoc#view`, missing expected subject `user:jake`",
 				Kind:    devinterface.DeveloperError_MISSING_EXPECTED_RELATIONSHIP,
+				Code:    development.ErrorCodeMissingExpectedRelationship,
 				Source:  devinterface.DeveloperError_VALIDATION_YAML,
 				Context: "[user:jake] is <document:somedoc#viewer>",
 				Line:    3,
@@ -500,6 +516,7 @@ assertFalse: garbage
 			&devinterface.DeveloperError{
 				Message: "invalid subject: `user`",
 				Kind:    devinterface.DeveloperError_PARSE_ERROR,
+				Code:    development.ErrorCodeYAMLParse,
 				Source:  devinterface.DeveloperError_VALIDATION_YAML,
 				Context: "[user]",
 				Line:    2,
@@ -525,6 +542,7 @@ assertFalse: garbage
 			&devinterface.DeveloperError{
 				Message: "invalid resource and relation: `document:som`",
 				Kind:    devinterface.DeveloperError_PARSE_ERROR,
+				Code:    development.ErrorCodeYAMLParse,
 				Source:  devinterface.DeveloperError_VALIDATION_YAML,
 				Context: "document:som",
 				Line:    2,
@@ -550,6 +568,7 @@ assertFalse: garbage
 			&devinterface.DeveloperError{
 				Message: "For object and permission/relation `document:somedoc#view`, found different relationships for subject `user:jimmy`: Specified: `<document:somedoc#viewer>`, Computed: `<document:somedoc#writer>`",
 				Kind:    devinterface.DeveloperError_MISSING_EXPECTED_RELATIONSHIP,
+				Code:    development.ErrorCodeMissingExpectedRelationship,
 				Source:  devinterface.DeveloperError_VALIDATION_YAML,
 				Context: `[user:jimmy] is <document:somedoc#viewer>`,
 				Line:    2,
@@ -635,6 +654,7 @@ assertFalse:
 			&devinterface.DeveloperError{
 				Message: "For object and permission/relation `document:somedoc#view`, found different relationships for subject `user:jimmy`: Specified: `<document:somedoc#writer>`, Computed: `<document:somedoc#viewer>/<document:somedoc#writer>`",
 				Kind:    devinterface.DeveloperError_MISSING_EXPECTED_RELATIONSHIP,
+				Code:    development.ErrorCodeMissingExpectedRelationship,
 				Source:  devinterface.DeveloperError_VALIDATION_YAML,
 				Context: `[user:jimmy] is <document:somedoc#writer>`,
 				Line:    2,
@@ -655,6 +675,7 @@ assertFalse:
 			&devinterface.DeveloperError{
 				Message: "object definition `document` not found",
 				Kind:    devinterface.DeveloperError_UNKNOWN_OBJECT_TYPE,
+				Code:    development.ErrorCodeUnknownObjectType,
 				Source:  devinterface.DeveloperError_RELATIONSHIP,
 				Context: `document:somedoc#writer@user:jimmy`,
 			},
@@ -672,6 +693,7 @@ assertFalse:
 			&devinterface.DeveloperError{
 				Message: "relation/permission `writer` not found under definition `document`",
 				Kind:    devinterface.DeveloperError_UNKNOWN_RELATION,
+				Code:    development.ErrorCodeUnknownRelation,
 				Source:  devinterface.DeveloperError_RELATIONSHIP,
 				Context: `document:somedoc#writer@user:jimmy`,
 			},
@@ -808,6 +830,7 @@ assertFalse:
 			&devinterface.DeveloperError{
 				Message: "For object and permission/relation `document:somedoc#view`, no expected subject specified in `is <document:somedoc#viewer>`",
 				Kind:    devinterface.DeveloperError_MISSING_EXPECTED_RELATIONSHIP,
+				Code:    development.ErrorCodeMissingExpectedRelationship,
 				Source:  devinterface.DeveloperError_VALIDATION_YAML,
 				Context: `is <document:somedoc#viewer>`,
 				Line:    2,
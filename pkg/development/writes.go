@@ -0,0 +1,84 @@
+package development
+
+import (
+	"context"
+	"errors"
+
+	"github.com/authzed/spicedb/internal/datastore/common"
+	"github.com/authzed/spicedb/pkg/datastore"
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+// ApplyWrites applies the relationship updates to the data in the development context in a single
+// transaction, producing a new revision. The new revision becomes the context's current Revision
+// and is appended to its Revisions, while the data as of the prior revisions remains available
+// via RunCheckAtRevision, allowing consistency semantics to be tested.
+//
+// If any of the updates is invalid, no updates are applied and the errors are returned as
+// DeveloperErrors.
+func (dc *DevContext) ApplyWrites(updates []*core.RelationTupleUpdate) (datastore.Revision, []*devinterface.DeveloperError, error) {
+	devErrs, err := validateUpdates(dc.Ctx, updates, dc.Datastore.SnapshotReader(dc.Revision))
+	if err != nil || len(devErrs) > 0 {
+		return datastore.NoRevision, devErrs, err
+	}
+
+	revision, err := dc.Datastore.ReadWriteTx(dc.Ctx, func(rwt datastore.ReadWriteTransaction) error {
+		return rwt.WriteRelationships(dc.Ctx, updates)
+	})
+	if err != nil {
+		var existsErr common.CreateRelationshipExistsError
+		if errors.As(err, &existsErr) {
+			return datastore.NoRevision, []*devinterface.DeveloperError{{
+				Message:  err.Error(),
+				Source:   devinterface.DeveloperError_RELATIONSHIP,
+				Kind:     devinterface.DeveloperError_DUPLICATE_RELATIONSHIP,
+				Severity: devinterface.DeveloperError_ERROR,
+				Code:     ErrorCodeDuplicateRelationship,
+				Context:  tuple.String(existsErr.Relationship),
+			}}, nil
+		}
+
+		return datastore.NoRevision, nil, err
+	}
+
+	dc.Revision = revision
+	dc.Revisions = append(dc.Revisions, revision)
+	return revision, nil, nil
+}
+
+func validateUpdates(ctx context.Context, updates []*core.RelationTupleUpdate, reader datastore.Reader) ([]*devinterface.DeveloperError, error) {
+	var devErrs []*devinterface.DeveloperError
+	for _, update := range updates {
+		tpl := update.Tuple
+		if verr := update.Validate(); verr != nil {
+			devErrs = append(devErrs, &devinterface.DeveloperError{
+				Message:  verr.Error(),
+				Source:   devinterface.DeveloperError_RELATIONSHIP,
+				Kind:     devinterface.DeveloperError_PARSE_ERROR,
+				Severity: devinterface.DeveloperError_ERROR,
+				Code:     ErrorCodeInvalidRelationship,
+				Context:  tuple.String(tpl),
+			})
+			continue
+		}
+
+		// Deletes of relationships no longer allowed by the schema are always permitted.
+		if update.Operation == core.RelationTupleUpdate_DELETE {
+			continue
+		}
+
+		if err := validateTupleWrite(ctx, tpl, reader); err != nil {
+			devErr, wireErr := distinguishGraphError(ctx, err, devinterface.DeveloperError_RELATIONSHIP, 0, 0, tuple.String(tpl))
+			if devErr != nil {
+				devErrs = append(devErrs, devErr)
+				continue
+			}
+
+			return devErrs, wireErr
+		}
+	}
+
+	return devErrs, nil
+}
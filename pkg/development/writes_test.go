@@ -0,0 +1,116 @@
+package development
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	devinterface "github.com/authzed/spicedb/pkg/proto/developer/v1"
+	v1 "github.com/authzed/spicedb/pkg/proto/dispatch/v1"
+	"github.com/authzed/spicedb/pkg/tuple"
+)
+
+func TestApplyWrites(t *testing.T) {
+	require := require.New(t)
+
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition document {
+	relation viewer: user
+}
+`,
+		Relationships: []*core.RelationTuple{
+			tuple.MustParse("document:somedoc#viewer@user:tom"),
+		},
+	})
+	require.NoError(err)
+	require.Nil(devErrs)
+	defer devCtx.Dispose()
+
+	initial := devCtx.Revision
+	require.Len(devCtx.Revisions, 1)
+
+	added, writeErrs, err := devCtx.ApplyWrites([]*core.RelationTupleUpdate{
+		tuple.Create(tuple.MustParse("document:somedoc#viewer@user:jill")),
+		tuple.Delete(tuple.MustParse("document:somedoc#viewer@user:tom")),
+	})
+	require.NoError(err)
+	require.Nil(writeErrs)
+	require.Equal(added, devCtx.Revision)
+	require.Len(devCtx.Revisions, 2)
+	require.True(added.GreaterThan(initial))
+
+	resource := tuple.ParseONR("document:somedoc#viewer")
+	tom := tuple.ParseSubjectONR("user:tom")
+	jill := tuple.ParseSubjectONR("user:jill")
+
+	checks := []struct {
+		revisionIndex int
+		subject       *core.ObjectAndRelation
+		expected      v1.ResourceCheckResult_Membership
+	}{
+		{0, tom, v1.ResourceCheckResult_MEMBER},
+		{0, jill, v1.ResourceCheckResult_NOT_MEMBER},
+		{1, tom, v1.ResourceCheckResult_NOT_MEMBER},
+		{1, jill, v1.ResourceCheckResult_MEMBER},
+	}
+
+	for _, check := range checks {
+		membership, err := RunCheckAtRevision(devCtx, devCtx.Revisions[check.revisionIndex], resource, check.subject)
+		require.NoError(err)
+		require.Equal(check.expected, membership, "revision %d, subject %s", check.revisionIndex, tuple.StringONR(check.subject))
+	}
+
+	// The current revision is used by default.
+	membership, err := RunCheck(devCtx, resource, jill)
+	require.NoError(err)
+	require.Equal(v1.ResourceCheckResult_MEMBER, membership)
+}
+
+func TestApplyWritesInvalid(t *testing.T) {
+	require := require.New(t)
+
+	devCtx, devErrs, err := NewDevContext(context.Background(), &devinterface.RequestContext{
+		Schema: `definition user {}
+
+definition document {
+	relation viewer: user
+}
+`,
+		Relationships: []*core.RelationTuple{
+			tuple.MustParse("document:somedoc#viewer@user:tom"),
+		},
+	})
+	require.NoError(err)
+	require.Nil(devErrs)
+	defer devCtx.Dispose()
+
+	initial := devCtx.Revision
+
+	_, writeErrs, err := devCtx.ApplyWrites([]*core.RelationTupleUpdate{
+		tuple.Touch(tuple.MustParse("document:somedoc#viewer@user:jill")),
+		tuple.Touch(tuple.MustParse("document:somedoc#unknown@user:jill")),
+	})
+	require.NoError(err)
+	require.Len(writeErrs, 1)
+	require.Equal(ErrorCodeUnknownRelation, writeErrs[0].Code)
+
+	_, writeErrs, err = devCtx.ApplyWrites([]*core.RelationTupleUpdate{
+		tuple.Create(tuple.MustParse("document:somedoc#viewer@user:tom")),
+	})
+	require.NoError(err)
+	require.Len(writeErrs, 1)
+	require.Equal(ErrorCodeDuplicateRelationship, writeErrs[0].Code)
+	require.Equal(devinterface.DeveloperError_DUPLICATE_RELATIONSHIP, writeErrs[0].Kind)
+
+	// No writes were applied.
+	require.Equal(initial, devCtx.Revision)
+	require.Len(devCtx.Revisions, 1)
+
+	membership, err := RunCheck(devCtx, tuple.ParseONR("document:somedoc#viewer"), tuple.ParseSubjectONR("user:jill"))
+	require.NoError(err)
+	require.Equal(v1.ResourceCheckResult_NOT_MEMBER, membership)
+}
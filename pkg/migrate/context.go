@@ -8,4 +8,14 @@ const (
 	// BackfillBatchSize represents the number of items that should be backfilled in a
 	// single step of an incremental backfill, and should be of type uint64.
 	BackfillBatchSize MigrationVariable = iota
+
+	// RelationshipPartitioning represents the partitioning of the relationships table for
+	// datastores which support it, and should be of type string. An unset or empty value leaves
+	// the table unpartitioned.
+	RelationshipPartitioning
+
+	// RelationshipDistribution represents the column by which the relationships table is
+	// distributed across the workers of a sharded cluster, for datastores which support it, and
+	// should be of type string. An unset or empty value leaves the table undistributed.
+	RelationshipDistribution
 )
@@ -190,7 +190,7 @@ func setOperation(firstChild *core.SetOperation_Child, rest []*core.SetOperation
 // Nil creates a child for a set operation that references the empty set.
 func Nil() *core.SetOperation_Child {
 	return &core.SetOperation_Child{
-		ChildType: &core.SetOperation_Child_XNil{},
+		ChildType: &core.SetOperation_Child_XNil{XNil: &core.SetOperation_Child_Nil{}},
 	}
 }
 
@@ -108,3 +108,57 @@ func SetRelationKind(relation *core.Relation, kind iv1.RelationMetadata_Relation
 	metadata.MetadataMessage = append(metadata.MetadataMessage, encoded)
 	return nil
 }
+
+// GetRelationMaxRecursionDepth returns the maximum number of times the relation may be traversed
+// recursively when resolving, or zero if unlimited.
+func GetRelationMaxRecursionDepth(relation *core.Relation) uint32 {
+	metadata := relation.Metadata
+	if metadata == nil {
+		return 0
+	}
+
+	for _, msg := range metadata.MetadataMessage {
+		var rm iv1.RelationMetadata
+		if err := msg.UnmarshalTo(&rm); err == nil {
+			return rm.MaxRecursionDepth
+		}
+	}
+
+	return 0
+}
+
+// SetRelationMaxRecursionDepth sets the maximum number of times the relation may be traversed
+// recursively when resolving.
+func SetRelationMaxRecursionDepth(relation *core.Relation, maxDepth uint32) error {
+	metadata := relation.Metadata
+	if metadata == nil {
+		metadata = &core.Metadata{}
+		relation.Metadata = metadata
+	}
+
+	// Update the existing relation metadata, if any, so that the kind is preserved.
+	for index, msg := range metadata.MetadataMessage {
+		var rm iv1.RelationMetadata
+		if err := msg.UnmarshalTo(&rm); err == nil {
+			rm.MaxRecursionDepth = maxDepth
+			encoded, err := anypb.New(&rm)
+			if err != nil {
+				return err
+			}
+
+			metadata.MetadataMessage[index] = encoded
+			return nil
+		}
+	}
+
+	var rm iv1.RelationMetadata
+	rm.MaxRecursionDepth = maxDepth
+
+	encoded, err := anypb.New(&rm)
+	if err != nil {
+		return err
+	}
+
+	metadata.MetadataMessage = append(metadata.MetadataMessage, encoded)
+	return nil
+}
@@ -61,3 +61,19 @@ func TestMetadata(t *testing.T) {
 
 	require.Equal(iv1.RelationMetadata_PERMISSION, GetRelationKind(ns.Relation[0]))
 }
+
+func TestRelationMaxRecursionDepth(t *testing.T) {
+	require := require.New(t)
+
+	relation := Relation("parent", nil, AllowedRelation("folder", "..."))
+	require.Equal(uint32(0), GetRelationMaxRecursionDepth(relation))
+
+	require.NoError(SetRelationMaxRecursionDepth(relation, 5))
+	require.Equal(uint32(5), GetRelationMaxRecursionDepth(relation))
+	require.Equal(iv1.RelationMetadata_RELATION, GetRelationKind(relation))
+	require.Len(relation.Metadata.MetadataMessage, 1)
+
+	withoutMetadata := &core.Relation{Name: "parent"}
+	require.NoError(SetRelationMaxRecursionDepth(withoutMetadata, 3))
+	require.Equal(uint32(3), GetRelationMaxRecursionDepth(withoutMetadata))
+}
@@ -87,6 +87,9 @@ func (cs *DispatchLookupSubjectsResponse) MarshalZerologObject(e *zerolog.Event)
 func (cr *ResolverMeta) MarshalZerologObject(e *zerolog.Event) {
 	e.Str("revision", cr.AtRevision)
 	e.Uint32("depth", cr.DepthRemaining)
+	if cr.DispatchBudget > 0 {
+		e.Uint32("budget", cr.DispatchBudget)
+	}
 }
 
 // MarshalZerologObject implements zerolog object marshalling.
@@ -10,6 +10,7 @@ import (
 	caveattypes "github.com/authzed/spicedb/pkg/caveats/types"
 	"github.com/authzed/spicedb/pkg/namespace"
 	core "github.com/authzed/spicedb/pkg/proto/core/v1"
+	iv1 "github.com/authzed/spicedb/pkg/proto/impl/v1"
 	"github.com/authzed/spicedb/pkg/schemadsl/input"
 	"github.com/authzed/spicedb/pkg/testutil"
 )
@@ -716,6 +717,15 @@ func TestCompile(t *testing.T) {
 					`someMap.isSubtreeOf(anotherMap)`),
 			},
 		},
+		{
+			"zero max depth",
+			&someTenant,
+			`definition folder {
+				relation parent: folder maxdepth 0
+			}`,
+			"parse error in `zero max depth`, line 2, column 5: invalid maximum depth `0` for relation parent: must be a positive integer",
+			[]SchemaDefinition{},
+		},
 	}
 
 	for _, test := range tests {
@@ -769,6 +779,25 @@ func TestCompile(t *testing.T) {
 	}
 }
 
+func TestCompileMaxRecursionDepth(t *testing.T) {
+	require := require.New(t)
+
+	compiled, err := Compile(InputSchema{
+		input.Source("max depth"), `definition folder {
+			relation parent: folder maxdepth 5
+			relation owner: folder
+		}`,
+	}, &someTenant)
+	require.NoError(err)
+	require.Len(compiled.ObjectDefinitions, 1)
+
+	relations := compiled.ObjectDefinitions[0].Relation
+	require.Len(relations, 2)
+	require.Equal(uint32(5), namespace.GetRelationMaxRecursionDepth(relations[0]))
+	require.Equal(iv1.RelationMetadata_RELATION, namespace.GetRelationKind(relations[0]))
+	require.Equal(uint32(0), namespace.GetRelationMaxRecursionDepth(relations[1]))
+}
+
 func filterSourcePositions(m protoreflect.Message) {
 	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
 		if fd.Kind() == protoreflect.MessageKind {
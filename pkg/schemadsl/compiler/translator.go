@@ -3,6 +3,7 @@ package compiler
 import (
 	"bufio"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/authzed/spicedb/pkg/caveats"
@@ -333,6 +334,22 @@ func translateRelation(tctx translationContext, relationNode *dslNode) (*core.Re
 		return nil, relationNode.Errorf("error in relation %s: %w", relationName, err)
 	}
 
+	if relationNode.Has(dslshape.NodeRelationPredicateMaxRecursionDepth) {
+		maxDepthStr, err := relationNode.GetString(dslshape.NodeRelationPredicateMaxRecursionDepth)
+		if err != nil {
+			return nil, relationNode.Errorf("invalid maximum depth: %w", err)
+		}
+
+		maxDepth, err := strconv.ParseUint(maxDepthStr, 10, 32)
+		if err != nil || maxDepth == 0 {
+			return nil, relationNode.Errorf("invalid maximum depth `%s` for relation %s: must be a positive integer", maxDepthStr, relationName)
+		}
+
+		if err := namespace.SetRelationMaxRecursionDepth(relation, uint32(maxDepth)); err != nil {
+			return nil, relationNode.Errorf("error in relation %s: %w", relationName, err)
+		}
+	}
+
 	return relation, nil
 }
 
@@ -132,6 +132,9 @@ const (
 	// The allowed types for the relation.
 	NodeRelationPredicateAllowedTypes = "allowed-types"
 
+	// The maximum recursion depth for the relation, if any.
+	NodeRelationPredicateMaxRecursionDepth = "max-recursion-depth"
+
 	//
 	// NodeTypeTypeReference
 	//
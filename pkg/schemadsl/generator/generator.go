@@ -172,6 +172,10 @@ func (sg *sourceGenerator) emitRelation(relation *core.Relation) {
 				sg.emitAllowedRelation(allowedRelation)
 			}
 		}
+
+		if maxDepth := namespace.GetRelationMaxRecursionDepth(relation); maxDepth > 0 {
+			sg.append(fmt.Sprintf(" maxdepth %d", maxDepth))
+		}
 	}
 
 	if relation.UsersetRewrite != nil {
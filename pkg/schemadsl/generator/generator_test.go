@@ -347,6 +347,17 @@ definition foos/document {
 	permission read = reader + writer + another
 	permission write = writer
 	permission minus = (rela - relb) - relc
+}`,
+		},
+		{
+			"max depth",
+			`definition foos/folder {
+	relation parent: foos/folder    maxdepth   7
+	relation viewer: foos/user
+}`,
+			`definition foos/folder {
+	relation parent: foos/folder maxdepth 7
+	relation viewer: foos/user
 }`,
 		},
 	}
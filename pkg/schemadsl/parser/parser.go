@@ -3,6 +3,7 @@ package parser
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/authzed/spicedb/pkg/schemadsl/dslshape"
 	"github.com/authzed/spicedb/pkg/schemadsl/input"
@@ -276,6 +277,7 @@ func (p *sourceParser) consumeDefinition() AstNode {
 
 // consumeRelation consumes a relation.
 // ```relation foo: sometype```
+// ```relation parent: folder maxdepth 5```
 func (p *sourceParser) consumeRelation() AstNode {
 	relNode := p.startNode(dslshape.NodeTypeRelation)
 	defer p.finishNode()
@@ -298,6 +300,26 @@ func (p *sourceParser) consumeRelation() AstNode {
 	// Relation allowed type(s).
 	relNode.Connect(dslshape.NodeRelationPredicateAllowedTypes, p.consumeTypeReference())
 
+	// Optional maximum recursion depth. `maxdepth` is contextual, rather than a keyword, so that
+	// it remains usable as a relation or permission name.
+	// maxdepth 5
+	if p.isToken(lexer.TokenTypeIdentifier) && p.currentToken.Value == "maxdepth" {
+		p.consumeToken()
+
+		// The lexer does not distinguish numbers from identifiers, so the depth is validated here.
+		depth, ok := p.consumeIdentifier()
+		if !ok {
+			return relNode
+		}
+
+		if _, err := strconv.ParseUint(depth, 10, 32); err != nil {
+			p.emitErrorf("Expected a non-negative integer maximum depth, found: %s", depth)
+			return relNode
+		}
+
+		relNode.Decorate(dslshape.NodeRelationPredicateMaxRecursionDepth, depth)
+	}
+
 	return relNode
 }
 
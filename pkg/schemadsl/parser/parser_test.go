@@ -113,6 +113,8 @@ func TestParser(t *testing.T) {
 		{"complex caveat test", "complexcaveat"},
 		{"empty caveat test", "emptycaveat"},
 		{"unclosed caveat test", "unclosedcaveat"},
+		{"max depth test", "maxdepth"},
+		{"broken max depth test", "brokenmaxdepth"},
 	}
 
 	for _, test := range parserTests {
@@ -0,0 +1,133 @@
+package x509util
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const spiffeScheme = "spiffe"
+
+// SPIFFEMatcher matches the SPIFFE IDs carried in the URI SANs of X.509 SVIDs
+// against a set of allowed IDs. An allowed ID without a path, such as
+// "spiffe://example.org", matches every ID in its trust domain.
+type SPIFFEMatcher struct {
+	ids          map[string]struct{}
+	trustDomains map[string]struct{}
+}
+
+// NewSPIFFEMatcher creates a SPIFFEMatcher for the given SPIFFE IDs and trust
+// domains.
+func NewSPIFFEMatcher(allowed []string) (*SPIFFEMatcher, error) {
+	if len(allowed) == 0 {
+		return nil, errors.New("at least one SPIFFE ID or trust domain must be allowed")
+	}
+
+	m := &SPIFFEMatcher{
+		ids:          make(map[string]struct{}, len(allowed)),
+		trustDomains: make(map[string]struct{}),
+	}
+	for _, id := range allowed {
+		parsed, err := parseSPIFFEID(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if parsed.Path == "" {
+			m.trustDomains[parsed.Host] = struct{}{}
+		} else {
+			m.ids[parsed.String()] = struct{}{}
+		}
+	}
+	return m, nil
+}
+
+// Matches returns whether the certificate carries an allowed SPIFFE ID.
+func (m *SPIFFEMatcher) Matches(cert *x509.Certificate) bool {
+	for _, uri := range cert.URIs {
+		if uri.Scheme != spiffeScheme {
+			continue
+		}
+		if _, ok := m.ids[uri.String()]; ok {
+			return true
+		}
+		if _, ok := m.trustDomains[uri.Host]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyPeer returns a function for tls.Config.VerifyPeerCertificate which
+// verifies the chain of the peer certificate against the roots and requires the
+// peer certificate to carry an allowed SPIFFE ID. SPIFFE IDs take the place of
+// host names, so clients using it should set InsecureSkipVerify to skip the
+// default verification, which includes the host name.
+func (m *SPIFFEMatcher) VerifyPeer(roots *x509.CertPool, usage x509.ExtKeyUsage) func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("peer presented no certificate")
+		}
+
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("failed to parse peer certificate: %w", err)
+			}
+			certs = append(certs, cert)
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		if _, err := certs[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{usage},
+		}); err != nil {
+			return fmt.Errorf("failed to verify peer certificate: %w", err)
+		}
+
+		if !m.Matches(certs[0]) {
+			return fmt.Errorf("peer certificate does not carry an allowed SPIFFE ID: %s", uriSANs(certs[0]))
+		}
+		return nil
+	}
+}
+
+func parseSPIFFEID(id string) (*url.URL, error) {
+	parsed, err := url.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SPIFFE ID %q: %w", id, err)
+	}
+
+	switch {
+	case parsed.Scheme != spiffeScheme:
+		return nil, fmt.Errorf("invalid SPIFFE ID %q: scheme must be %s", id, spiffeScheme)
+	case parsed.Host == "":
+		return nil, fmt.Errorf("invalid SPIFFE ID %q: missing trust domain", id)
+	case parsed.Port() != "" || parsed.User != nil:
+		return nil, fmt.Errorf("invalid SPIFFE ID %q: trust domain cannot have a port or user info", id)
+	case parsed.RawQuery != "" || parsed.Fragment != "":
+		return nil, fmt.Errorf("invalid SPIFFE ID %q: cannot have a query or fragment", id)
+	case parsed.Path == "/":
+		return nil, fmt.Errorf("invalid SPIFFE ID %q: path cannot be empty", id)
+	}
+	return parsed, nil
+}
+
+func uriSANs(cert *x509.Certificate) string {
+	uris := make([]string, 0, len(cert.URIs))
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+	if len(uris) == 0 {
+		return "none"
+	}
+	return strings.Join(uris, ", ")
+}
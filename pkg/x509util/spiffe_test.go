@@ -0,0 +1,46 @@
+package x509util
+
+import (
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSPIFFEMatcher(t *testing.T) {
+	require := require.New(t)
+
+	matcher, err := NewSPIFFEMatcher([]string{"spiffe://example.org/ns/spicedb/sa/spicedb", "spiffe://trusted.org"})
+	require.NoError(err)
+
+	certWithURIs := func(uris ...string) *x509.Certificate {
+		cert := &x509.Certificate{}
+		for _, uri := range uris {
+			parsed, err := url.Parse(uri)
+			require.NoError(err)
+			cert.URIs = append(cert.URIs, parsed)
+		}
+		return cert
+	}
+
+	require.True(matcher.Matches(certWithURIs("spiffe://example.org/ns/spicedb/sa/spicedb")))
+	require.True(matcher.Matches(certWithURIs("https://example.org", "spiffe://trusted.org/any/workload")))
+	require.False(matcher.Matches(certWithURIs("spiffe://example.org/ns/spicedb/sa/other")))
+	require.False(matcher.Matches(certWithURIs("https://trusted.org/workload")))
+	require.False(matcher.Matches(certWithURIs()))
+}
+
+func TestNewSPIFFEMatcherErrors(t *testing.T) {
+	for _, allowed := range [][]string{
+		nil,
+		{"https://example.org/workload"},
+		{"spiffe:///workload"},
+		{"spiffe://example.org:8443/workload"},
+		{"spiffe://example.org/workload?query"},
+		{"spiffe://example.org/"},
+	} {
+		_, err := NewSPIFFEMatcher(allowed)
+		require.Error(t, err, "allowed: %v", allowed)
+	}
+}